@@ -0,0 +1,402 @@
+package adapters
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/secrets"
+)
+
+// Tipos de perfil de autenticación HTTP
+const (
+	AuthTypeOAuth2ClientCredentials = "oauth2_client_credentials"
+	AuthTypeAPIKey                  = "api_key"
+	AuthTypeBasic                   = "basic"
+)
+
+const (
+	defaultAPIKeyHeader = "X-API-Key"
+	// tokenExpiryMargin renueva el token antes de que caduque en pleno envío
+	tokenExpiryMargin = 30 * time.Second
+	// defaultTokenLifetime se usa si el servidor no devuelve expires_in
+	defaultTokenLifetime = time.Hour
+)
+
+// AuthProfile son las credenciales con las que se autentican las llamadas
+// HTTP que lo referencian por nombre. Los valores admiten ${secret:nombre}.
+type AuthProfile struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+
+	// oauth2_client_credentials
+	TokenURL     string   `json:"token_url,omitempty"`
+	ClientID     string   `json:"client_id,omitempty"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+	Audience     string   `json:"audience,omitempty"`
+	// ClientAuth es header (Basic, por defecto) o body (client_id y
+	// client_secret en el formulario)
+	ClientAuth string `json:"client_auth,omitempty"`
+
+	// api_key: en el header (X-API-Key por defecto, con Prefix delante) o,
+	// con QueryParam, en la query
+	Key        string `json:"key,omitempty"`
+	Header     string `json:"header,omitempty"`
+	Prefix     string `json:"prefix,omitempty"`
+	QueryParam string `json:"query_param,omitempty"`
+
+	// basic
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+func (p AuthProfile) validate() error {
+	if p.Name == "" {
+		return fmt.Errorf("auth profile name is required")
+	}
+	switch p.Type {
+	case AuthTypeOAuth2ClientCredentials:
+		if p.TokenURL == "" || p.ClientID == "" || p.ClientSecret == "" {
+			return fmt.Errorf("auth profile %s: token_url, client_id and client_secret are required", p.Name)
+		}
+		if p.ClientAuth != "" && p.ClientAuth != "header" && p.ClientAuth != "body" {
+			return fmt.Errorf("auth profile %s: client_auth must be header or body", p.Name)
+		}
+	case AuthTypeAPIKey:
+		if p.Key == "" {
+			return fmt.Errorf("auth profile %s: key is required", p.Name)
+		}
+	case AuthTypeBasic:
+		if p.Username == "" {
+			return fmt.Errorf("auth profile %s: username is required", p.Name)
+		}
+	default:
+		return fmt.Errorf("auth profile %s: unsupported type %q", p.Name, p.Type)
+	}
+	return nil
+}
+
+// Credentials son los headers y parámetros de query que autentican una petición
+type Credentials struct {
+	Headers map[string]string
+	Query   map[string]string
+}
+
+// Apply añade las credenciales a la petición
+func (c *Credentials) Apply(req *http.Request) {
+	for key, value := range c.Headers {
+		req.Header.Set(key, value)
+	}
+	if len(c.Query) > 0 {
+		query := req.URL.Query()
+		for key, value := range c.Query {
+			query.Set(key, value)
+		}
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+type oauthToken struct {
+	value     string
+	expiresAt time.Time
+}
+
+// AuthProfiles guarda los perfiles de autenticación y los tokens OAuth2
+// obtenidos, que se reutilizan hasta poco antes de caducar
+type AuthProfiles struct {
+	profiles map[string]AuthProfile
+	secrets  *secrets.Manager
+	client   *http.Client
+
+	mu     sync.Mutex
+	tokens map[string]oauthToken
+	// fetching serializa la petición de token de cada perfil
+	fetching map[string]*sync.Mutex
+}
+
+// NewAuthProfiles valida los perfiles. Con manager nil las referencias
+// ${secret:nombre} no se resuelven.
+func NewAuthProfiles(profiles []AuthProfile, manager *secrets.Manager) (*AuthProfiles, error) {
+	p := &AuthProfiles{
+		profiles: make(map[string]AuthProfile, len(profiles)),
+		secrets:  manager,
+		client:   &http.Client{Timeout: 30 * time.Second},
+		tokens:   make(map[string]oauthToken),
+		fetching: make(map[string]*sync.Mutex),
+	}
+	for _, profile := range profiles {
+		if err := profile.validate(); err != nil {
+			return nil, err
+		}
+		if _, exists := p.profiles[profile.Name]; exists {
+			return nil, fmt.Errorf("duplicate auth profile: %s", profile.Name)
+		}
+		p.profiles[profile.Name] = profile
+		p.fetching[profile.Name] = &sync.Mutex{}
+	}
+	return p, nil
+}
+
+// LoadAuthProfiles lee una lista JSON de perfiles del fichero path
+func LoadAuthProfiles(path string) ([]AuthProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth profiles: %w", err)
+	}
+	var profiles []AuthProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("failed to parse auth profiles: %w", err)
+	}
+	return profiles, nil
+}
+
+// Has indica si existe el perfil
+func (p *AuthProfiles) Has(name string) bool {
+	if p == nil {
+		return false
+	}
+	_, ok := p.profiles[name]
+	return ok
+}
+
+// Names devuelve los nombres de los perfiles, ordenados
+func (p *AuthProfiles) Names() []string {
+	if p == nil {
+		return nil
+	}
+	names := make([]string, 0, len(p.profiles))
+	for name := range p.profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Refreshable indica si una respuesta 401 se puede reintentar con un token nuevo
+func (p *AuthProfiles) Refreshable(name string) bool {
+	return p.Has(name) && p.profiles[name].Type == AuthTypeOAuth2ClientCredentials
+}
+
+// Credentials devuelve las credenciales del perfil, pidiendo un token OAuth2
+// si no hay uno vigente
+func (p *AuthProfiles) Credentials(ctx context.Context, name string) (*Credentials, error) {
+	if !p.Has(name) {
+		return nil, fmt.Errorf("unknown auth profile: %s", name)
+	}
+	profile := p.profiles[name]
+	credentials := &Credentials{Headers: map[string]string{}, Query: map[string]string{}}
+
+	switch profile.Type {
+	case AuthTypeOAuth2ClientCredentials:
+		token, err := p.token(ctx, profile)
+		if err != nil {
+			return nil, err
+		}
+		credentials.Headers["Authorization"] = "Bearer " + token
+	case AuthTypeAPIKey:
+		key, err := p.resolve(ctx, profile.Key)
+		if err != nil {
+			return nil, err
+		}
+		if profile.QueryParam != "" {
+			credentials.Query[profile.QueryParam] = key
+		} else {
+			header := profile.Header
+			if header == "" {
+				header = defaultAPIKeyHeader
+			}
+			credentials.Headers[header] = profile.Prefix + key
+		}
+	case AuthTypeBasic:
+		username, err := p.resolve(ctx, profile.Username)
+		if err != nil {
+			return nil, err
+		}
+		password, err := p.resolve(ctx, profile.Password)
+		if err != nil {
+			return nil, err
+		}
+		credentials.Headers["Authorization"] = "Basic " + basicAuth(username, password)
+	}
+	return credentials, nil
+}
+
+// Invalidate descarta el token OAuth2 guardado, por ejemplo tras un 401
+func (p *AuthProfiles) Invalidate(name string) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.tokens, name)
+}
+
+func (p *AuthProfiles) resolve(ctx context.Context, value string) (string, error) {
+	if p.secrets == nil {
+		return value, nil
+	}
+	return p.secrets.ResolveString(ctx, value)
+}
+
+func (p *AuthProfiles) cachedToken(name string) (string, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	token, ok := p.tokens[name]
+	if !ok || time.Now().After(token.expiresAt) {
+		return "", false
+	}
+	return token.value, true
+}
+
+// token devuelve el token vigente del perfil o pide uno nuevo. Las peticiones
+// concurrentes del mismo perfil esperan a la primera en lugar de repetirla.
+func (p *AuthProfiles) token(ctx context.Context, profile AuthProfile) (string, error) {
+	if token, ok := p.cachedToken(profile.Name); ok {
+		return token, nil
+	}
+
+	fetching := p.fetching[profile.Name]
+	fetching.Lock()
+	defer fetching.Unlock()
+	if token, ok := p.cachedToken(profile.Name); ok {
+		return token, nil
+	}
+
+	token, lifetime, err := p.requestToken(ctx, profile)
+	if err != nil {
+		return "", fmt.Errorf("auth profile %s: %w", profile.Name, err)
+	}
+	p.mu.Lock()
+	p.tokens[profile.Name] = oauthToken{value: token, expiresAt: time.Now().Add(lifetime - tokenExpiryMargin)}
+	p.mu.Unlock()
+	return token, nil
+}
+
+// requestToken pide un token con el grant client_credentials (RFC 6749 §4.4)
+func (p *AuthProfiles) requestToken(ctx context.Context, profile AuthProfile) (string, time.Duration, error) {
+	tokenURL, err := p.resolve(ctx, profile.TokenURL)
+	if err != nil {
+		return "", 0, err
+	}
+	clientID, err := p.resolve(ctx, profile.ClientID)
+	if err != nil {
+		return "", 0, err
+	}
+	clientSecret, err := p.resolve(ctx, profile.ClientSecret)
+	if err != nil {
+		return "", 0, err
+	}
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(profile.Scopes) > 0 {
+		form.Set("scope", strings.Join(profile.Scopes, " "))
+	}
+	if profile.Audience != "" {
+		form.Set("audience", profile.Audience)
+	}
+	if profile.ClientAuth == "body" {
+		form.Set("client_id", clientID)
+		form.Set("client_secret", clientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if profile.ClientAuth != "body" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", 0, fmt.Errorf("token endpoint returned %d: %s", resp.StatusCode, string(body))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil || token.AccessToken == "" {
+		return "", 0, fmt.Errorf("invalid token response")
+	}
+	lifetime := defaultTokenLifetime
+	if token.ExpiresIn > 0 {
+		lifetime = time.Duration(token.ExpiresIn) * time.Second
+	}
+	return token.AccessToken, max(lifetime, 2*tokenExpiryMargin), nil
+}
+
+func basicAuth(username, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+}
+
+// WithAuthProfiles autentica las peticiones que indican AuthProfile con las
+// credenciales del perfil. Si un token OAuth2 recibe 401 se pide otro y la
+// petición se repite una vez.
+func WithAuthProfiles(adapter HTTPAdapter, profiles *AuthProfiles) HTTPAdapter {
+	return &authProfileAdapter{HTTPAdapter: adapter, profiles: profiles}
+}
+
+type authProfileAdapter struct {
+	HTTPAdapter
+	profiles *AuthProfiles
+}
+
+func (a *authProfileAdapter) MakeRequest(ctx context.Context, request *HTTPRequest) (*HTTPResponse, error) {
+	if request.AuthProfile == "" {
+		return a.HTTPAdapter.MakeRequest(ctx, request)
+	}
+
+	response, err := a.authenticated(ctx, request)
+	if err == nil && response.StatusCode == http.StatusUnauthorized && a.profiles.Refreshable(request.AuthProfile) {
+		a.profiles.Invalidate(request.AuthProfile)
+		return a.authenticated(ctx, request)
+	}
+	return response, err
+}
+
+func (a *authProfileAdapter) authenticated(ctx context.Context, request *HTTPRequest) (*HTTPResponse, error) {
+	credentials, err := a.profiles.Credentials(ctx, request.AuthProfile)
+	if err != nil {
+		return nil, err
+	}
+
+	authenticated := *request
+	authenticated.Headers = make(map[string]string, len(request.Headers)+len(credentials.Headers))
+	for key, value := range request.Headers {
+		authenticated.Headers[key] = value
+	}
+	for key, value := range credentials.Headers {
+		authenticated.Headers[key] = value
+	}
+	if len(credentials.Query) > 0 {
+		authenticated.Params = make(map[string]interface{}, len(request.Params)+len(credentials.Query))
+		for key, value := range request.Params {
+			authenticated.Params[key] = value
+		}
+		for key, value := range credentials.Query {
+			authenticated.Params[key] = value
+		}
+	}
+	return a.HTTPAdapter.MakeRequest(ctx, &authenticated)
+}
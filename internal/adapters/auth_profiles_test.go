@@ -0,0 +1,99 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthProfiles_OAuth2ReusesTokenAndRefreshesOnUnauthorized(t *testing.T) {
+	ctx := context.Background()
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, _ := r.BasicAuth()
+		assert.Equal(t, "bot", clientID)
+		assert.Equal(t, "s3cret", clientSecret)
+		assert.Equal(t, "client_credentials", r.FormValue("grant_type"))
+		assert.Equal(t, "orders:read", r.FormValue("scope"))
+		n := atomic.AddInt32(&issued, 1)
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": []string{"", "old", "new"}[n], "expires_in": 3600})
+	}))
+	defer tokenServer.Close()
+
+	// La API rechaza el primer token como si lo hubieran revocado
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer new" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer api.Close()
+
+	profiles, err := NewAuthProfiles([]AuthProfile{{
+		Name: "crm", Type: AuthTypeOAuth2ClientCredentials,
+		TokenURL: tokenServer.URL, ClientID: "bot", ClientSecret: "s3cret", Scopes: []string{"orders:read"},
+	}}, nil)
+	require.NoError(t, err)
+
+	credentials, err := profiles.Credentials(ctx, "crm")
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer old", credentials.Headers["Authorization"])
+	_, err = profiles.Credentials(ctx, "crm")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&issued))
+
+	httpAdapter := NewHTTPAdapter("test", "1.0", logger.NewLogger("error"))
+	require.NoError(t, httpAdapter.Start(ctx))
+	response, err := WithAuthProfiles(httpAdapter, profiles).MakeRequest(ctx, &HTTPRequest{
+		Method: "GET", URL: api.URL, AuthProfile: "crm",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&issued))
+}
+
+func TestAuthProfiles_APIKeyAndBasic(t *testing.T) {
+	ctx := context.Background()
+	profiles, err := NewAuthProfiles([]AuthProfile{
+		{Name: "maps", Type: AuthTypeAPIKey, Key: "k1", QueryParam: "key"},
+		{Name: "erp", Type: AuthTypeAPIKey, Key: "k2", Header: "Authorization", Prefix: "Token "},
+		{Name: "legacy", Type: AuthTypeBasic, Username: "user", Password: "pass"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"erp", "legacy", "maps"}, profiles.Names())
+	assert.False(t, profiles.Refreshable("erp"))
+
+	credentials, err := profiles.Credentials(ctx, "maps")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"key": "k1"}, credentials.Query)
+	assert.Empty(t, credentials.Headers)
+
+	credentials, err = profiles.Credentials(ctx, "erp")
+	require.NoError(t, err)
+	assert.Equal(t, "Token k2", credentials.Headers["Authorization"])
+
+	req := httptest.NewRequest(http.MethodGet, "https://legacy.example.com/items?page=2", nil)
+	credentials, err = profiles.Credentials(ctx, "legacy")
+	require.NoError(t, err)
+	credentials.Apply(req)
+	username, password, ok := req.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+
+	_, err = profiles.Credentials(ctx, "missing")
+	assert.ErrorContains(t, err, "unknown auth profile")
+
+	_, err = NewAuthProfiles([]AuthProfile{{Name: "crm", Type: AuthTypeOAuth2ClientCredentials, TokenURL: "https://idp"}}, nil)
+	assert.ErrorContains(t, err, "client_id")
+	_, err = NewAuthProfiles([]AuthProfile{{Name: "a", Type: AuthTypeBasic, Username: "u"}, {Name: "a", Type: AuthTypeBasic, Username: "u"}}, nil)
+	assert.Error(t, err)
+}
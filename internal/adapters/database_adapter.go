@@ -0,0 +1,296 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Dialectos SQL soportados por el adaptador database
+const (
+	DialectPostgres = "postgres"
+	DialectMySQL    = "mysql"
+)
+
+const (
+	defaultDatabaseMaxRows     = 100
+	defaultDatabaseTimeout     = 10 * time.Second
+	defaultDatabaseMaxOpen     = 5
+	defaultDatabaseMaxIdle     = 2
+	defaultDatabaseMaxLifetime = 5 * time.Minute
+)
+
+// readOnlyStatements son las sentencias que acepta Query
+var readOnlyStatements = []string{"select", "with", "show", "explain", "values", "table", "describe", "desc"}
+
+// sqlDatabaseAdapter ejecuta consultas de solo lectura contra una base de
+// datos externa. Cada consulta va en una transacción READ ONLY que se
+// descarta al terminar, con timeout y un máximo de filas.
+type sqlDatabaseAdapter struct {
+	name        string
+	version     string
+	dialect     string
+	driver      string
+	dsn         string
+	maxRows     int
+	timeout     time.Duration
+	maxOpen     int
+	maxIdle     int
+	maxLifetime time.Duration
+	logger      logger.Logger
+
+	mu      sync.RWMutex
+	db      *sql.DB
+	healthy bool
+}
+
+// defaultDrivers son los drivers de database/sql de cada dialecto: pgx para
+// postgres y go-sql-driver para mysql
+var defaultDrivers = map[string]string{
+	DialectPostgres: "pgx",
+	DialectMySQL:    "mysql",
+}
+
+// defaultDriver devuelve el driver del dialecto, o el propio dialecto si no
+// es uno de los soportados
+func defaultDriver(dialect string) string {
+	if driver, ok := defaultDrivers[dialect]; ok {
+		return driver
+	}
+	return dialect
+}
+
+// NewDatabaseAdapter crea un adaptador para la base de datos del DSN. El
+// driver de database/sql es por defecto el del dialecto.
+func NewDatabaseAdapter(name, version, dialect, dsn string, logger logger.Logger) DatabaseAdapter {
+	return &sqlDatabaseAdapter{
+		name:        name,
+		version:     version,
+		dialect:     dialect,
+		driver:      defaultDriver(dialect),
+		dsn:         dsn,
+		maxRows:     defaultDatabaseMaxRows,
+		timeout:     defaultDatabaseTimeout,
+		maxOpen:     defaultDatabaseMaxOpen,
+		maxIdle:     defaultDatabaseMaxIdle,
+		maxLifetime: defaultDatabaseMaxLifetime,
+		logger:      logger,
+	}
+}
+
+func (a *sqlDatabaseAdapter) GetName() string    { return a.name }
+func (a *sqlDatabaseAdapter) GetType() string    { return "database" }
+func (a *sqlDatabaseAdapter) GetVersion() string { return a.version }
+
+func (a *sqlDatabaseAdapter) Initialize(ctx context.Context, config map[string]interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	values := configschema.Values(config)
+	a.dialect = values.String("dialect", a.dialect)
+	a.driver = values.String("driver", defaultDriver(a.dialect))
+	a.dsn = values.String("dsn", a.dsn)
+	a.maxRows = values.Int("max_rows", a.maxRows)
+	a.timeout = values.Duration("timeout", a.timeout)
+	a.maxOpen = values.Int("max_open_conns", a.maxOpen)
+	a.maxIdle = values.Int("max_idle_conns", a.maxIdle)
+	a.maxLifetime = values.Duration("conn_max_lifetime", a.maxLifetime)
+	return nil
+}
+
+// Start abre el pool y comprueba la conexión
+func (a *sqlDatabaseAdapter) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.dialect != DialectPostgres && a.dialect != DialectMySQL {
+		return fmt.Errorf("unsupported database dialect %q", a.dialect)
+	}
+	if a.dsn == "" {
+		return fmt.Errorf("database adapter dsn is required")
+	}
+	if !driverRegistered(a.driver) {
+		return fmt.Errorf("database driver %q is not registered in this build", a.driver)
+	}
+	db, err := sql.Open(a.driver, a.dsn)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(a.maxOpen)
+	db.SetMaxIdleConns(a.maxIdle)
+	db.SetConnMaxLifetime(a.maxLifetime)
+
+	pingCtx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+	if err := db.PingContext(pingCtx); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to connect to database: %w", err)
+	}
+	a.db = db
+	a.healthy = true
+	a.logger.Info("Database adapter started", "name", a.name, "dialect", a.dialect, "max_rows", a.maxRows)
+	return nil
+}
+
+func (a *sqlDatabaseAdapter) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.healthy = false
+	if a.db == nil {
+		return nil
+	}
+	err := a.db.Close()
+	a.db = nil
+	return err
+}
+
+func (a *sqlDatabaseAdapter) IsHealthy() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.healthy
+}
+
+func (a *sqlDatabaseAdapter) GetCapabilities() []string {
+	return []string{"database", "sql_query", "database_query"}
+}
+
+func (a *sqlDatabaseAdapter) CanHandle(operation string) bool {
+	for _, capability := range a.GetCapabilities() {
+		if capability == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Query ejecuta una consulta parametrizada con los placeholders del
+// dialecto ($1 en postgres, ? en mysql). Devuelve como mucho max_rows filas
+// y marca Truncated si había más.
+func (a *sqlDatabaseAdapter) Query(ctx context.Context, query string, params ...interface{}) (*QueryResult, error) {
+	a.mu.RLock()
+	db, healthy, maxRows, timeout := a.db, a.healthy, a.maxRows, a.timeout
+	a.mu.RUnlock()
+	if !healthy || db == nil {
+		return nil, fmt.Errorf("database adapter is not healthy")
+	}
+	if err := checkReadOnlyQuery(query); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin read-only transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return &QueryResult{Error: err.Error(), Duration: time.Since(start)}, fmt.Errorf("database query failed: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+	result := &QueryResult{Rows: []map[string]interface{}{}}
+	for rows.Next() {
+		if len(result.Rows) >= maxRows {
+			result.Truncated = true
+			break
+		}
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			// Los drivers devuelven texto como []byte, que en JSON saldría en base64
+			if raw, ok := values[i].([]byte); ok {
+				values[i] = string(raw)
+			}
+			row[column] = values[i]
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("database query failed: %w", err)
+	}
+
+	result.Count = len(result.Rows)
+	result.Duration = time.Since(start)
+	result.Success = true
+	return result, nil
+}
+
+// Execute no está permitido: el adaptador solo lee
+func (a *sqlDatabaseAdapter) Execute(ctx context.Context, command string, params ...interface{}) (*ExecuteResult, error) {
+	return nil, fmt.Errorf("database adapter %s is read-only", a.name)
+}
+
+// BeginTransaction no está permitido: cada Query usa su propia transacción
+func (a *sqlDatabaseAdapter) BeginTransaction(ctx context.Context) (Transaction, error) {
+	return nil, fmt.Errorf("database adapter %s is read-only", a.name)
+}
+
+// checkReadOnlyQuery rechaza lo que no sea una única consulta de lectura. La
+// transacción READ ONLY es la garantía; esto da un error claro antes.
+func checkReadOnlyQuery(query string) error {
+	statement := strings.TrimSpace(query)
+	for strings.HasPrefix(statement, "--") || strings.HasPrefix(statement, "/*") {
+		end, skip := strings.Index(statement, "\n"), 1
+		if strings.HasPrefix(statement, "/*") {
+			end, skip = strings.Index(statement, "*/"), 2
+		}
+		if end < 0 {
+			return fmt.Errorf("database query is empty")
+		}
+		statement = strings.TrimSpace(statement[end+skip:])
+	}
+	if statement == "" {
+		return fmt.Errorf("database query is empty")
+	}
+	if strings.Contains(strings.TrimRight(statement, "; \t\n"), ";") {
+		return fmt.Errorf("database query must be a single statement")
+	}
+
+	words := strings.FieldsFunc(statement, func(r rune) bool {
+		return r == ' ' || r == '\t' || r == '\n' || r == '\r' || r == '('
+	})
+	if len(words) == 0 {
+		return fmt.Errorf("database query is empty")
+	}
+	keyword := strings.ToLower(words[0])
+	for _, allowed := range readOnlyStatements {
+		if keyword == allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("database adapter only runs read-only queries, got %s", strings.ToUpper(keyword))
+}
+
+func driverRegistered(name string) bool {
+	for _, driver := range sql.Drivers() {
+		if driver == name {
+			return true
+		}
+	}
+	return false
+}
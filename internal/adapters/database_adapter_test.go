@@ -0,0 +1,114 @@
+package adapters
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSQL es un driver mínimo que registra las consultas y si la transacción
+// era de solo lectura
+type fakeSQL struct {
+	mu       sync.Mutex
+	readOnly []bool
+	args     [][]driver.NamedValue
+}
+
+func (d *fakeSQL) Open(string) (driver.Conn, error) { return &fakeSQLConn{driver: d}, nil }
+
+type fakeSQLConn struct{ driver *fakeSQL }
+
+func (c *fakeSQLConn) Prepare(string) (driver.Stmt, error) { return nil, driver.ErrSkip }
+func (c *fakeSQLConn) Close() error                        { return nil }
+func (c *fakeSQLConn) Begin() (driver.Tx, error)           { return c, nil }
+func (c *fakeSQLConn) Commit() error                       { return nil }
+func (c *fakeSQLConn) Rollback() error                     { return nil }
+
+func (c *fakeSQLConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.readOnly = append(c.driver.readOnly, opts.ReadOnly)
+	return c, nil
+}
+
+func (c *fakeSQLConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.driver.mu.Lock()
+	defer c.driver.mu.Unlock()
+	c.driver.args = append(c.driver.args, args)
+	return &fakeSQLRows{values: [][]driver.Value{
+		{int64(1), []byte("shipped")},
+		{int64(2), []byte("pending")},
+		{int64(3), nil},
+	}}, nil
+}
+
+type fakeSQLRows struct{ values [][]driver.Value }
+
+func (r *fakeSQLRows) Columns() []string { return []string{"id", "status"} }
+func (r *fakeSQLRows) Close() error      { return nil }
+
+func (r *fakeSQLRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var fakeSQLDrivers atomic.Int64
+
+// registerFakeSQL registra un driver nuevo en cada ejecución del test, porque
+// database/sql no permite registrar dos veces el mismo nombre
+func registerFakeSQL() (string, *fakeSQL) {
+	name := fmt.Sprintf("fakesql-%d", fakeSQLDrivers.Add(1))
+	driver := &fakeSQL{}
+	sql.Register(name, driver)
+	return name, driver
+}
+
+func TestDatabaseAdapter_RunsReadOnlyQueriesWithRowLimit(t *testing.T) {
+	ctx := context.Background()
+	driverName, fakeSQLDriver := registerFakeSQL()
+
+	config := map[string]interface{}{"name": "orders-db", "dialect": "postgres", "driver": driverName, "dsn": "orders", "max_rows": float64(2)}
+	adapter, err := NewAdapterFactory(logger.NewLogger("error")).CreateAdapter("database", config)
+	require.NoError(t, err)
+	require.NoError(t, adapter.Initialize(ctx, config))
+	require.NoError(t, adapter.Start(ctx))
+	defer adapter.Stop(ctx)
+	database := adapter.(DatabaseAdapter)
+
+	result, err := database.Query(ctx, "-- pedidos del cliente\nSELECT id, status FROM orders WHERE customer_id = $1", "c-1")
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.True(t, result.Truncated)
+	assert.Equal(t, []map[string]interface{}{{"id": int64(1), "status": "shipped"}, {"id": int64(2), "status": "pending"}}, result.Rows)
+	assert.Equal(t, []bool{true}, fakeSQLDriver.readOnly)
+	assert.Equal(t, "c-1", fakeSQLDriver.args[0][0].Value)
+
+	for _, query := range []string{"DELETE FROM orders", "SELECT 1; DROP TABLE orders", "  ", "/* nada */"} {
+		_, err := database.Query(ctx, query)
+		assert.Error(t, err, query)
+	}
+	_, err = database.Execute(ctx, "UPDATE orders SET status = 'x'")
+	assert.ErrorContains(t, err, "read-only")
+
+	// Los dialectos soportados usan los drivers que importa el paquete
+	assert.True(t, driverRegistered(defaultDriver(DialectPostgres)))
+	assert.True(t, driverRegistered(defaultDriver(DialectMySQL)))
+	missing := NewDatabaseAdapter("crm-db", "1.0", DialectMySQL, "crm", logger.NewLogger("error"))
+	require.NoError(t, missing.Initialize(ctx, map[string]interface{}{"driver": "odbc"}))
+	assert.ErrorContains(t, missing.Start(ctx), "not registered")
+	_, err = NewAdapterFactory(logger.NewLogger("error")).CreateAdapter("database", map[string]interface{}{"dialect": "oracle", "dsn": "x"})
+	assert.Error(t, err)
+}
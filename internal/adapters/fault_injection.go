@@ -0,0 +1,71 @@
+package adapters
+
+import (
+	"context"
+	"io"
+
+	"github.com/company/bot-service/internal/chaos"
+)
+
+// WithFaultInjection envuelve los adaptadores HTTP y de almacenamiento de
+// objetos para que sus llamadas pasen por faults; el resto se devuelve tal cual
+func WithFaultInjection(adapter Adapter, faults *chaos.Injector) Adapter {
+	switch a := adapter.(type) {
+	case HTTPAdapter:
+		return WithHTTPFaults(a, faults)
+	case ObjectStoreAdapter:
+		return &faultyObjectStore{ObjectStoreAdapter: a, faults: faults}
+	default:
+		return adapter
+	}
+}
+
+// WithHTTPFaults inyecta fallos en MakeRequest
+func WithHTTPFaults(adapter HTTPAdapter, faults *chaos.Injector) HTTPAdapter {
+	return &faultyHTTPAdapter{HTTPAdapter: adapter, faults: faults}
+}
+
+type faultyHTTPAdapter struct {
+	HTTPAdapter
+	faults *chaos.Injector
+}
+
+func (a *faultyHTTPAdapter) MakeRequest(ctx context.Context, request *HTTPRequest) (*HTTPResponse, error) {
+	if err := a.faults.Inject(ctx, "adapter:"+a.GetName()); err != nil {
+		return nil, err
+	}
+	return a.HTTPAdapter.MakeRequest(ctx, request)
+}
+
+type faultyObjectStore struct {
+	ObjectStoreAdapter
+	faults *chaos.Injector
+}
+
+func (s *faultyObjectStore) PutObject(ctx context.Context, key string, data []byte, contentType string) (*StoredObject, error) {
+	if err := s.faults.Inject(ctx, "adapter:"+s.GetName()); err != nil {
+		return nil, err
+	}
+	return s.ObjectStoreAdapter.PutObject(ctx, key, data, contentType)
+}
+
+func (s *faultyObjectStore) GetObject(ctx context.Context, key string) ([]byte, error) {
+	if err := s.faults.Inject(ctx, "adapter:"+s.GetName()); err != nil {
+		return nil, err
+	}
+	return s.ObjectStoreAdapter.GetObject(ctx, key)
+}
+
+func (s *faultyObjectStore) OpenObject(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	if err := s.faults.Inject(ctx, "adapter:"+s.GetName()); err != nil {
+		return nil, 0, err
+	}
+	return s.ObjectStoreAdapter.OpenObject(ctx, key)
+}
+
+func (s *faultyObjectStore) DeleteObject(ctx context.Context, key string) error {
+	if err := s.faults.Inject(ctx, "adapter:"+s.GetName()); err != nil {
+		return err
+	}
+	return s.ObjectStoreAdapter.DeleteObject(ctx, key)
+}
@@ -0,0 +1,184 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// GraphQLAdapter define operaciones para backends GraphQL
+type GraphQLAdapter interface {
+	Adapter
+	Execute(ctx context.Context, request *GraphQLRequest) (*GraphQLResponse, error)
+}
+
+// GraphQLRequest es una query o mutation con sus variables
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Headers       map[string]string      `json:"-"`
+}
+
+// GraphQLError es un error de la sección errors de la respuesta
+type GraphQLError struct {
+	Message    string                 `json:"message"`
+	Path       []interface{}          `json:"path,omitempty"`
+	Extensions map[string]interface{} `json:"extensions,omitempty"`
+}
+
+// GraphQLResponse representa la respuesta de un backend GraphQL. Una
+// respuesta con errors no es correcta aunque traiga datos parciales.
+type GraphQLResponse struct {
+	StatusCode int            `json:"status_code"`
+	Data       interface{}    `json:"data"`
+	Errors     []GraphQLError `json:"errors,omitempty"`
+	Duration   time.Duration  `json:"duration"`
+	Success    bool           `json:"success"`
+	Error      string         `json:"error,omitempty"`
+}
+
+// graphqlAdapter envía las operaciones por POST en JSON a un único endpoint
+type graphqlAdapter struct {
+	name           string
+	version        string
+	endpoint       string
+	client         *http.Client
+	defaultHeaders map[string]string
+	logger         logger.Logger
+	mu             sync.RWMutex
+	healthy        bool
+}
+
+// NewGraphQLAdapter crea un adaptador para el endpoint GraphQL indicado
+func NewGraphQLAdapter(name, version, endpoint string, logger logger.Logger) GraphQLAdapter {
+	return &graphqlAdapter{
+		name:           name,
+		version:        version,
+		endpoint:       endpoint,
+		client:         &http.Client{Timeout: 30 * time.Second},
+		defaultHeaders: make(map[string]string),
+		logger:         logger,
+	}
+}
+
+func (a *graphqlAdapter) GetName() string    { return a.name }
+func (a *graphqlAdapter) GetType() string    { return "graphql" }
+func (a *graphqlAdapter) GetVersion() string { return a.version }
+
+// Initialize permite cambiar el endpoint, el timeout y los headers por defecto
+func (a *graphqlAdapter) Initialize(ctx context.Context, config map[string]interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	values := configschema.Values(config)
+	a.endpoint = values.String("endpoint", a.endpoint)
+	if timeout := values.Duration("timeout", 0); timeout > 0 {
+		a.client.Timeout = timeout
+	}
+	for k, v := range values.StringMap("default_headers") {
+		a.defaultHeaders[k] = v
+	}
+	return nil
+}
+
+func (a *graphqlAdapter) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.endpoint == "" {
+		return fmt.Errorf("graphql adapter endpoint is required")
+	}
+	a.healthy = true
+	a.logger.Info("GraphQL adapter started", "name", a.name, "endpoint", a.endpoint)
+	return nil
+}
+
+func (a *graphqlAdapter) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.healthy = false
+	return nil
+}
+
+func (a *graphqlAdapter) IsHealthy() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.healthy
+}
+
+func (a *graphqlAdapter) GetCapabilities() []string {
+	return []string{"graphql", "graphql_query", "graphql_mutation"}
+}
+
+func (a *graphqlAdapter) CanHandle(operation string) bool {
+	for _, capability := range a.GetCapabilities() {
+		if capability == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute envía la operación. Los errores de transporte o un status distinto
+// de 2xx devuelven error; los errors de GraphQL sólo marcan la respuesta.
+func (a *graphqlAdapter) Execute(ctx context.Context, request *GraphQLRequest) (*GraphQLResponse, error) {
+	if !a.IsHealthy() {
+		return nil, fmt.Errorf("GraphQL adapter is not healthy")
+	}
+	if request.Query == "" {
+		return nil, fmt.Errorf("graphql query is required")
+	}
+	start := time.Now()
+
+	payload, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal graphql request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create graphql request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json")
+	a.mu.RLock()
+	for k, v := range a.defaultHeaders {
+		httpReq.Header.Set(k, v)
+	}
+	a.mu.RUnlock()
+	for k, v := range request.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	resp, err := a.client.Do(httpReq)
+	if err != nil {
+		return &GraphQLResponse{Error: err.Error(), Duration: time.Since(start)}, fmt.Errorf("graphql request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read graphql response: %w", err)
+	}
+
+	response := &GraphQLResponse{StatusCode: resp.StatusCode, Duration: time.Since(start)}
+	if err := json.Unmarshal(body, response); err != nil && resp.StatusCode < 300 {
+		return nil, fmt.Errorf("invalid graphql response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		response.Error = fmt.Sprintf("graphql endpoint returned %d", resp.StatusCode)
+		return response, fmt.Errorf("%s", response.Error)
+	}
+	response.Success = len(response.Errors) == 0
+	if !response.Success {
+		response.Error = response.Errors[0].Message
+	}
+	return response, nil
+}
@@ -0,0 +1,363 @@
+package adapters
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	reflectionpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// GRPCAdapter define operaciones para backends gRPC sin código generado
+type GRPCAdapter interface {
+	Adapter
+	Invoke(ctx context.Context, request *GRPCRequest) (*GRPCResponse, error)
+}
+
+// GRPCRequest es una llamada unaria con el mensaje en JSON
+type GRPCRequest struct {
+	// Method es "paquete.Servicio/Metodo"
+	Method   string                 `json:"method"`
+	Message  map[string]interface{} `json:"message"`
+	Metadata map[string]string      `json:"metadata"`
+	Timeout  time.Duration          `json:"timeout"`
+}
+
+// GRPCResponse representa la respuesta de una llamada gRPC
+type GRPCResponse struct {
+	Message  map[string]interface{} `json:"message"`
+	Code     string                 `json:"code"`
+	Duration time.Duration          `json:"duration"`
+	Success  bool                   `json:"success"`
+	Error    string                 `json:"error,omitempty"`
+}
+
+// grpcAdapter invoca métodos con mensajes dinámicos. Los descriptores salen
+// de un FileDescriptorSet compilado (protoc --include_imports
+// --descriptor_set_out) o, sin él, de la reflexión del servidor.
+type grpcAdapter struct {
+	name            string
+	version         string
+	target          string
+	useTLS          bool
+	descriptorSet   string
+	timeout         time.Duration
+	defaultMetadata map[string]string
+	logger          logger.Logger
+
+	mu       sync.RWMutex
+	conn     *grpc.ClientConn
+	files    *protoregistry.Files
+	services map[string]protoreflect.ServiceDescriptor
+	healthy  bool
+}
+
+// NewGRPCAdapter crea un adaptador para el servidor gRPC en target (host:puerto)
+func NewGRPCAdapter(name, version, target string, logger logger.Logger) GRPCAdapter {
+	return &grpcAdapter{
+		name:            name,
+		version:         version,
+		target:          target,
+		timeout:         30 * time.Second,
+		defaultMetadata: make(map[string]string),
+		logger:          logger,
+		services:        make(map[string]protoreflect.ServiceDescriptor),
+	}
+}
+
+func (a *grpcAdapter) GetName() string    { return a.name }
+func (a *grpcAdapter) GetType() string    { return "grpc" }
+func (a *grpcAdapter) GetVersion() string { return a.version }
+
+func (a *grpcAdapter) Initialize(ctx context.Context, config map[string]interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	values := configschema.Values(config)
+	a.target = values.String("target", a.target)
+	a.useTLS = values.Bool("tls", a.useTLS)
+	a.descriptorSet = values.String("descriptor_set", a.descriptorSet)
+	if timeout := values.Duration("timeout", 0); timeout > 0 {
+		a.timeout = timeout
+	}
+	for k, v := range values.StringMap("default_metadata") {
+		a.defaultMetadata[strings.ToLower(k)] = v
+	}
+	return nil
+}
+
+// Start abre la conexión y carga el descriptor set, si lo hay
+func (a *grpcAdapter) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.target == "" {
+		return fmt.Errorf("grpc adapter target is required")
+	}
+	if a.descriptorSet != "" {
+		files, err := loadDescriptorSet(a.descriptorSet)
+		if err != nil {
+			return err
+		}
+		a.files = files
+	}
+
+	transport := insecure.NewCredentials()
+	if a.useTLS {
+		transport = credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12})
+	}
+	conn, err := grpc.Dial(a.target, grpc.WithTransportCredentials(transport))
+	if err != nil {
+		return fmt.Errorf("failed to dial grpc target %s: %w", a.target, err)
+	}
+	a.conn = conn
+	a.healthy = true
+	a.logger.Info("gRPC adapter started", "name", a.name, "target", a.target, "reflection", a.files == nil)
+	return nil
+}
+
+func (a *grpcAdapter) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.healthy = false
+	if a.conn == nil {
+		return nil
+	}
+	err := a.conn.Close()
+	a.conn = nil
+	return err
+}
+
+func (a *grpcAdapter) IsHealthy() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.healthy
+}
+
+func (a *grpcAdapter) GetCapabilities() []string {
+	return []string{"grpc", "grpc_call"}
+}
+
+func (a *grpcAdapter) CanHandle(operation string) bool {
+	for _, capability := range a.GetCapabilities() {
+		if capability == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// Invoke llama a un método unario. Un status distinto de OK devuelve la
+// respuesta con Code y Error además del error.
+func (a *grpcAdapter) Invoke(ctx context.Context, request *GRPCRequest) (*GRPCResponse, error) {
+	a.mu.RLock()
+	conn, healthy := a.conn, a.healthy
+	a.mu.RUnlock()
+	if !healthy || conn == nil {
+		return nil, fmt.Errorf("gRPC adapter is not healthy")
+	}
+
+	serviceName, methodName, err := splitGRPCMethod(request.Method)
+	if err != nil {
+		return nil, err
+	}
+	timeout := a.timeout
+	if request.Timeout > 0 {
+		timeout = request.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	service, err := a.service(ctx, conn, serviceName)
+	if err != nil {
+		return nil, err
+	}
+	method := service.Methods().ByName(protoreflect.Name(methodName))
+	if method == nil {
+		return nil, fmt.Errorf("method %s not found in service %s", methodName, serviceName)
+	}
+	if method.IsStreamingClient() || method.IsStreamingServer() {
+		return nil, fmt.Errorf("method %s is streaming; only unary methods are supported", request.Method)
+	}
+
+	input := dynamicpb.NewMessage(method.Input())
+	if len(request.Message) > 0 {
+		data, err := json.Marshal(request.Message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode grpc message: %w", err)
+		}
+		if err := protojson.Unmarshal(data, input); err != nil {
+			return nil, fmt.Errorf("invalid message for %s: %w", method.Input().FullName(), err)
+		}
+	}
+
+	pairs := make([]string, 0, 2*(len(a.defaultMetadata)+len(request.Metadata)))
+	for k, v := range a.defaultMetadata {
+		pairs = append(pairs, k, v)
+	}
+	for k, v := range request.Metadata {
+		pairs = append(pairs, strings.ToLower(k), v)
+	}
+	ctx = metadata.AppendToOutgoingContext(ctx, pairs...)
+
+	start := time.Now()
+	output := dynamicpb.NewMessage(method.Output())
+	fullMethod := fmt.Sprintf("/%s/%s", serviceName, methodName)
+	if err := conn.Invoke(ctx, fullMethod, input, output); err != nil {
+		st := status.Convert(err)
+		return &GRPCResponse{
+			Code:     st.Code().String(),
+			Error:    st.Message(),
+			Duration: time.Since(start),
+		}, fmt.Errorf("grpc call %s failed: %w", fullMethod, err)
+	}
+
+	response := &GRPCResponse{Code: "OK", Duration: time.Since(start), Success: true}
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(output)
+	if err == nil {
+		err = json.Unmarshal(data, &response.Message)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode grpc response: %w", err)
+	}
+	return response, nil
+}
+
+// service busca el descriptor del servicio en el descriptor set o, sin él,
+// lo pide por reflexión la primera vez
+func (a *grpcAdapter) service(ctx context.Context, conn *grpc.ClientConn, name string) (protoreflect.ServiceDescriptor, error) {
+	a.mu.RLock()
+	files, cached := a.files, a.services[name]
+	a.mu.RUnlock()
+	if cached != nil {
+		return cached, nil
+	}
+
+	if files == nil {
+		var err error
+		if files, err = reflectFiles(ctx, conn, name); err != nil {
+			return nil, err
+		}
+	}
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(name))
+	if err != nil {
+		return nil, fmt.Errorf("grpc service %s not found: %w", name, err)
+	}
+	service, ok := descriptor.(protoreflect.ServiceDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a grpc service", name)
+	}
+
+	a.mu.Lock()
+	a.services[name] = service
+	a.mu.Unlock()
+	return service, nil
+}
+
+// reflectFiles descarga por reflexión el archivo que define el símbolo y
+// todas sus dependencias
+func reflectFiles(ctx context.Context, conn *grpc.ClientConn, symbol string) (*protoregistry.Files, error) {
+	stream, err := reflectionpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("grpc reflection unavailable: %w", err)
+	}
+	defer stream.CloseSend()
+
+	set := &descriptorpb.FileDescriptorSet{}
+	seen := make(map[string]bool)
+	pending := []*reflectionpb.ServerReflectionRequest{{
+		MessageRequest: &reflectionpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: symbol},
+	}}
+	for len(pending) > 0 {
+		if err := stream.Send(pending[0]); err != nil {
+			return nil, fmt.Errorf("grpc reflection request failed: %w", err)
+		}
+		pending = pending[1:]
+		reply, err := stream.Recv()
+		if err != nil {
+			return nil, fmt.Errorf("grpc reflection request failed: %w", err)
+		}
+		if errReply := reply.GetErrorResponse(); errReply != nil {
+			return nil, fmt.Errorf("grpc reflection: %s", errReply.GetErrorMessage())
+		}
+		for _, raw := range reply.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			file := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, file); err != nil {
+				return nil, fmt.Errorf("invalid descriptor from grpc reflection: %w", err)
+			}
+			if seen[file.GetName()] {
+				continue
+			}
+			seen[file.GetName()] = true
+			set.File = append(set.File, file)
+		}
+		// El servidor puede omitir dependencias; se piden por nombre
+		for _, file := range set.File {
+			for _, dependency := range file.GetDependency() {
+				if !seen[dependency] {
+					seen[dependency] = true
+					pending = append(pending, &reflectionpb.ServerReflectionRequest{
+						MessageRequest: &reflectionpb.ServerReflectionRequest_FileByFilename{FileByFilename: dependency},
+					})
+				}
+			}
+		}
+	}
+
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptors from grpc reflection: %w", err)
+	}
+	return files, nil
+}
+
+// loadDescriptorSet lee un FileDescriptorSet binario
+func loadDescriptorSet(path string) (*protoregistry.Files, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(data, set); err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+	files, err := protodesc.NewFiles(set)
+	if err != nil {
+		return nil, fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+	return files, nil
+}
+
+// splitGRPCMethod acepta "paquete.Servicio/Metodo", con o sin "/" inicial, y
+// "paquete.Servicio.Metodo"
+func splitGRPCMethod(method string) (string, string, error) {
+	method = strings.TrimPrefix(method, "/")
+	separator := strings.LastIndex(method, "/")
+	if separator < 0 {
+		separator = strings.LastIndex(method, ".")
+	}
+	if separator <= 0 || separator == len(method)-1 {
+		return "", "", fmt.Errorf("invalid grpc method %q: expected package.Service/Method", method)
+	}
+	return method[:separator], method[separator+1:], nil
+}
@@ -0,0 +1,111 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestGRPCAdapter_InvokesWithReflectionAndDescriptorSet(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("orders", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+	reflection.Register(server)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	descriptorSet, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{protodesc.ToFileDescriptorProto(healthpb.File_grpc_health_v1_health_proto)},
+	})
+	require.NoError(t, err)
+	descriptorPath := filepath.Join(t.TempDir(), "health.pb")
+	require.NoError(t, os.WriteFile(descriptorPath, descriptorSet, 0o600))
+
+	factory := NewAdapterFactory(log)
+	for name, config := range map[string]map[string]interface{}{
+		"reflection":     {"target": listener.Addr().String()},
+		"descriptor_set": {"target": listener.Addr().String(), "descriptor_set": descriptorPath},
+	} {
+		t.Run(name, func(t *testing.T) {
+			adapter, err := factory.CreateAdapter("grpc", config)
+			require.NoError(t, err)
+			require.NoError(t, adapter.Initialize(ctx, config))
+			require.NoError(t, adapter.Start(ctx))
+			defer adapter.Stop(ctx)
+			client := adapter.(GRPCAdapter)
+
+			response, err := client.Invoke(ctx, &GRPCRequest{Method: "grpc.health.v1.Health/Check", Message: map[string]interface{}{"service": "orders"}})
+			require.NoError(t, err)
+			assert.True(t, response.Success)
+			assert.Equal(t, "NOT_SERVING", response.Message["status"])
+
+			response, err = client.Invoke(ctx, &GRPCRequest{Method: "/grpc.health.v1.Health/Check", Message: map[string]interface{}{"service": "missing"}})
+			assert.Error(t, err)
+			require.NotNil(t, response)
+			assert.Equal(t, "NotFound", response.Code)
+
+			_, err = client.Invoke(ctx, &GRPCRequest{Method: "grpc.health.v1.Health/Watch"})
+			assert.ErrorContains(t, err, "streaming")
+			_, err = client.Invoke(ctx, &GRPCRequest{Method: "grpc.health.v1.Health/Check", Message: map[string]interface{}{"unknown": 1}})
+			assert.ErrorContains(t, err, "invalid message")
+		})
+	}
+
+	_, err = factory.CreateAdapter("grpc", map[string]interface{}{})
+	assert.Error(t, err)
+}
+
+func TestGraphQLAdapter_SendsVariablesAndReportsErrors(t *testing.T) {
+	ctx := context.Background()
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request GraphQLRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "Bearer t", r.Header.Get("Authorization"))
+		if request.Variables["id"] == "missing" {
+			w.Write([]byte(`{"data":{"order":null},"errors":[{"message":"order not found","path":["order"]}]}`))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{"order": map[string]interface{}{"id": request.Variables["id"], "status": "shipped"}}})
+	}))
+	defer api.Close()
+
+	config := map[string]interface{}{"endpoint": api.URL, "default_headers": map[string]interface{}{"Authorization": "Bearer t"}}
+	adapter, err := NewAdapterFactory(logger.NewLogger("error")).CreateAdapter("graphql", config)
+	require.NoError(t, err)
+	require.NoError(t, adapter.Initialize(ctx, config))
+	require.NoError(t, adapter.Start(ctx))
+	client := adapter.(GraphQLAdapter)
+
+	query := `query Order($id: ID!) { order(id: $id) { id status } }`
+	response, err := client.Execute(ctx, &GraphQLRequest{Query: query, Variables: map[string]interface{}{"id": "A-1"}})
+	require.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.Equal(t, map[string]interface{}{"order": map[string]interface{}{"id": "A-1", "status": "shipped"}}, response.Data)
+
+	response, err = client.Execute(ctx, &GraphQLRequest{Query: query, Variables: map[string]interface{}{"id": "missing"}})
+	require.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Equal(t, "order not found", response.Error)
+}
@@ -11,6 +11,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/company/bot-service/pkg/configschema"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -61,28 +62,17 @@ func (a *httpAdapter) Initialize(ctx context.Context, config map[string]interfac
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	values := configschema.Values(config)
+
 	// Configurar timeout si se proporciona
-	if timeoutVal, exists := config["timeout"]; exists {
-		if timeoutStr, ok := timeoutVal.(string); ok {
-			if timeout, err := time.ParseDuration(timeoutStr); err == nil {
-				a.timeout = timeout
-				a.client.Timeout = timeout
-			}
-		} else if timeoutFloat, ok := timeoutVal.(float64); ok {
-			a.timeout = time.Duration(timeoutFloat) * time.Second
-			a.client.Timeout = a.timeout
-		}
+	if timeout := values.Duration("timeout", 0); timeout > 0 {
+		a.timeout = timeout
+		a.client.Timeout = timeout
 	}
 
 	// Configurar headers por defecto
-	if headersVal, exists := config["default_headers"]; exists {
-		if headersMap, ok := headersVal.(map[string]interface{}); ok {
-			for k, v := range headersMap {
-				if strVal, ok := v.(string); ok {
-					a.defaultHeaders[k] = strVal
-				}
-			}
-		}
+	for k, v := range values.StringMap("default_headers") {
+		a.defaultHeaders[k] = v
 	}
 
 	// Configurar User-Agent por defecto
@@ -45,6 +45,8 @@ type MessageQueueAdapter interface {
 	Publish(ctx context.Context, topic string, message *Message) error
 	Subscribe(ctx context.Context, topic string, handler MessageHandler) error
 	Unsubscribe(ctx context.Context, topic string) error
+	// Receive consume hasta max mensajes esperando como mucho wait
+	Receive(ctx context.Context, topic string, max int, wait time.Duration) ([]*Message, error)
 }
 
 // WebhookAdapter define operaciones para adaptadores de webhooks
@@ -65,6 +67,9 @@ type HTTPRequest struct {
 	Body    interface{}            `json:"body"`
 	Timeout time.Duration          `json:"timeout"`
 	Params  map[string]interface{} `json:"params"`
+	// AuthProfile es el perfil de autenticación con que se firma la petición
+	// (ver WithAuthProfiles)
+	AuthProfile string `json:"auth_profile,omitempty"`
 }
 
 // HTTPResponse representa una respuesta HTTP
@@ -81,6 +86,8 @@ type HTTPResponse struct {
 type QueryResult struct {
 	Rows     []map[string]interface{} `json:"rows"`
 	Count    int                      `json:"count"`
+	// Truncated indica que la consulta devolvía más filas que el límite
+	Truncated bool                    `json:"truncated,omitempty"`
 	Duration time.Duration            `json:"duration"`
 	Success  bool                     `json:"success"`
 	Error    string                   `json:"error,omitempty"`
@@ -0,0 +1,210 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// StoredObject describe un objeto guardado en el almacenamiento
+type StoredObject struct {
+	Key         string `json:"key"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// ObjectStoreAdapter define operaciones para almacenamiento de objetos
+type ObjectStoreAdapter interface {
+	Adapter
+	PutObject(ctx context.Context, key string, data []byte, contentType string) (*StoredObject, error)
+	GetObject(ctx context.Context, key string) ([]byte, error)
+	// OpenObject abre el objeto para leerlo por partes; devuelve también su tamaño
+	OpenObject(ctx context.Context, key string) (io.ReadCloser, int64, error)
+	DeleteObject(ctx context.Context, key string) error
+	URLFor(key string) string
+}
+
+// localObjectStore guarda los objetos en disco y los expone bajo una URL
+// pública servida por el propio servicio
+type localObjectStore struct {
+	name          string
+	baseDir       string
+	publicBaseURL string
+	logger        logger.Logger
+	mu            sync.RWMutex
+	healthy       bool
+}
+
+// NewLocalObjectStore crea un almacenamiento de objetos sobre el sistema de archivos
+func NewLocalObjectStore(name, baseDir, publicBaseURL string, logger logger.Logger) ObjectStoreAdapter {
+	return &localObjectStore{
+		name:          name,
+		baseDir:       baseDir,
+		publicBaseURL: strings.TrimSuffix(publicBaseURL, "/"),
+		logger:        logger,
+	}
+}
+
+func (s *localObjectStore) GetName() string    { return s.name }
+func (s *localObjectStore) GetType() string    { return "object_store" }
+func (s *localObjectStore) GetVersion() string { return "1.0" }
+
+// Initialize permite sobrescribir el directorio y la URL pública
+func (s *localObjectStore) Initialize(ctx context.Context, config map[string]interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if baseDir, ok := config["base_dir"].(string); ok && baseDir != "" {
+		s.baseDir = baseDir
+	}
+	if publicURL, ok := config["public_base_url"].(string); ok && publicURL != "" {
+		s.publicBaseURL = strings.TrimSuffix(publicURL, "/")
+	}
+	return nil
+}
+
+func (s *localObjectStore) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.baseDir == "" {
+		return fmt.Errorf("object store base_dir is required")
+	}
+	if err := os.MkdirAll(s.baseDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create object store directory: %w", err)
+	}
+	s.healthy = true
+	s.logger.Info("Object store started", "name", s.name, "base_dir", s.baseDir)
+	return nil
+}
+
+func (s *localObjectStore) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.healthy = false
+	return nil
+}
+
+func (s *localObjectStore) IsHealthy() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.healthy
+}
+
+func (s *localObjectStore) GetCapabilities() []string {
+	return []string{"put_object", "get_object", "delete_object"}
+}
+
+func (s *localObjectStore) CanHandle(operation string) bool {
+	for _, capability := range s.GetCapabilities() {
+		if capability == operation {
+			return true
+		}
+	}
+	return false
+}
+
+// PutObject guarda el contenido bajo la clave indicada, reemplazando el anterior
+func (s *localObjectStore) PutObject(ctx context.Context, key string, data []byte, contentType string) (*StoredObject, error) {
+	filePath, err := s.pathFor(key)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create object directory: %w", err)
+	}
+
+	// Escribir a un temporal y renombrar para no exponer archivos a medio escribir
+	tmpPath := filePath + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write object: %w", err)
+	}
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to store object: %w", err)
+	}
+
+	return &StoredObject{
+		Key:         cleanObjectKey(key),
+		URL:         s.URLFor(key),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}, nil
+}
+
+func (s *localObjectStore) GetObject(ctx context.Context, key string) ([]byte, error) {
+	filePath, err := s.pathFor(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read object: %w", err)
+	}
+	return data, nil
+}
+
+func (s *localObjectStore) OpenObject(ctx context.Context, key string) (io.ReadCloser, int64, error) {
+	filePath, err := s.pathFor(key)
+	if err != nil {
+		return nil, 0, err
+	}
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open object: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to stat object: %w", err)
+	}
+	return file, info.Size(), nil
+}
+
+func (s *localObjectStore) DeleteObject(ctx context.Context, key string) error {
+	filePath, err := s.pathFor(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete object: %w", err)
+	}
+	return nil
+}
+
+// URLFor devuelve la URL pública de una clave
+func (s *localObjectStore) URLFor(key string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	segments := strings.Split(cleanObjectKey(key), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return s.publicBaseURL + "/" + strings.Join(segments, "/")
+}
+
+// pathFor resuelve la ruta en disco impidiendo salir del directorio base
+func (s *localObjectStore) pathFor(key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	cleaned := cleanObjectKey(key)
+	if cleaned == "" || cleaned == "." || strings.HasPrefix(cleaned, "..") {
+		return "", fmt.Errorf("invalid object key: %q", key)
+	}
+	return filepath.Join(s.baseDir, filepath.FromSlash(cleaned)), nil
+}
+
+func cleanObjectKey(key string) string {
+	return strings.TrimPrefix(path.Clean("/"+key), "/")
+}
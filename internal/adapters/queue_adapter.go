@@ -0,0 +1,509 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Proveedores de cola del adaptador message_queue
+const (
+	QueueProviderKafka    = "kafka"
+	QueueProviderRabbitMQ = "rabbitmq"
+	QueueProviderSQS      = "sqs"
+)
+
+const (
+	defaultQueueTimeout = 10 * time.Second
+	// subscribeWait es la espera de cada consulta del bucle de Subscribe
+	subscribeWait = 20 * time.Second
+)
+
+// queueProvider habla con un broker concreto. receive espera como mucho wait
+// y devuelve lo que haya, quizá nada.
+type queueProvider interface {
+	publish(ctx context.Context, topic string, message *Message) error
+	receive(ctx context.Context, topic string, max int, wait time.Duration) ([]*Message, error)
+}
+
+// queueAdapter publica y consume mensajes en Kafka (a través del REST Proxy),
+// RabbitMQ (a través de la API de management) o SQS, todo sobre HTTP
+type queueAdapter struct {
+	name     string
+	version  string
+	provider string
+	client   queueProvider
+	config   map[string]interface{}
+	logger   logger.Logger
+
+	mu            sync.RWMutex
+	healthy       bool
+	subscriptions map[string]context.CancelFunc
+}
+
+// NewQueueAdapter crea un adaptador de colas para el proveedor indicado; la
+// conexión se configura en Initialize
+func NewQueueAdapter(name, version, provider string, logger logger.Logger) MessageQueueAdapter {
+	return &queueAdapter{
+		name:          name,
+		version:       version,
+		provider:      provider,
+		logger:        logger,
+		subscriptions: make(map[string]context.CancelFunc),
+	}
+}
+
+func (a *queueAdapter) GetName() string    { return a.name }
+func (a *queueAdapter) GetType() string    { return "message_queue" }
+func (a *queueAdapter) GetVersion() string { return a.version }
+
+func (a *queueAdapter) Initialize(ctx context.Context, config map[string]interface{}) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.config = config
+	return nil
+}
+
+// Start crea el cliente del proveedor con la configuración recibida
+func (a *queueAdapter) Start(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	values := configschema.Values(a.config)
+	httpClient := &http.Client{Timeout: values.Duration("timeout", defaultQueueTimeout)}
+	baseURL := strings.TrimSuffix(values.String("url", ""), "/")
+	switch a.provider {
+	case QueueProviderKafka:
+		if baseURL == "" {
+			return fmt.Errorf("kafka queue adapter requires the REST proxy url")
+		}
+		a.client = &kafkaRESTQueue{baseURL: baseURL, group: values.String("consumer_group", a.name), client: httpClient}
+	case QueueProviderRabbitMQ:
+		if baseURL == "" {
+			return fmt.Errorf("rabbitmq queue adapter requires the management url")
+		}
+		a.client = &rabbitMQQueue{
+			baseURL:  baseURL,
+			vhost:    values.String("vhost", "/"),
+			exchange: values.String("exchange", "amq.default"),
+			username: values.String("username", "guest"),
+			password: values.String("password", "guest"),
+			client:   httpClient,
+		}
+	case QueueProviderSQS:
+		region := values.String("region", "")
+		if region == "" {
+			return fmt.Errorf("sqs queue adapter requires a region")
+		}
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("https://sqs.%s.amazonaws.com", region)
+		}
+		a.client = &sqsQueue{
+			endpoint:     baseURL,
+			queueBaseURL: strings.TrimSuffix(values.String("queue_base_url", ""), "/"),
+			region:       region,
+			accessKey:    values.String("access_key_id", ""),
+			secretKey:    values.String("secret_access_key", ""),
+			sessionToken: values.String("session_token", ""),
+			client:       httpClient,
+		}
+	default:
+		return fmt.Errorf("unsupported queue provider %q", a.provider)
+	}
+
+	a.healthy = true
+	a.logger.Info("Message queue adapter started", "name", a.name, "provider", a.provider)
+	return nil
+}
+
+// Stop cancela las suscripciones activas
+func (a *queueAdapter) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for topic, cancel := range a.subscriptions {
+		cancel()
+		delete(a.subscriptions, topic)
+	}
+	a.healthy = false
+	return nil
+}
+
+func (a *queueAdapter) IsHealthy() bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.healthy
+}
+
+func (a *queueAdapter) GetCapabilities() []string {
+	return []string{"message_queue", "queue_publish", "queue_consume", a.provider}
+}
+
+func (a *queueAdapter) CanHandle(operation string) bool {
+	for _, capability := range a.GetCapabilities() {
+		if capability == operation {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *queueAdapter) provided() (queueProvider, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if !a.healthy || a.client == nil {
+		return nil, fmt.Errorf("message queue adapter is not healthy")
+	}
+	return a.client, nil
+}
+
+// Publish envía el mensaje al topic (Kafka), routing key (RabbitMQ) o cola (SQS)
+func (a *queueAdapter) Publish(ctx context.Context, topic string, message *Message) error {
+	client, err := a.provided()
+	if err != nil {
+		return err
+	}
+	if topic == "" {
+		return fmt.Errorf("queue topic is required")
+	}
+	if message.ID == "" {
+		message.ID = id.New()
+	}
+	if err := client.publish(ctx, topic, message); err != nil {
+		return fmt.Errorf("%s publish to %s failed: %w", a.provider, topic, err)
+	}
+	return nil
+}
+
+// Receive consume hasta max mensajes, esperando como mucho wait a que llegue
+// alguno. Los mensajes recibidos se confirman y no se vuelven a entregar.
+func (a *queueAdapter) Receive(ctx context.Context, topic string, max int, wait time.Duration) ([]*Message, error) {
+	client, err := a.provided()
+	if err != nil {
+		return nil, err
+	}
+	if max <= 0 {
+		max = 1
+	}
+	messages, err := client.receive(ctx, topic, max, wait)
+	if err != nil {
+		return nil, fmt.Errorf("%s receive from %s failed: %w", a.provider, topic, err)
+	}
+	return messages, nil
+}
+
+// Subscribe consume el topic en segundo plano hasta Unsubscribe o Stop
+func (a *queueAdapter) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	if _, err := a.provided(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	if _, exists := a.subscriptions[topic]; exists {
+		a.mu.Unlock()
+		return fmt.Errorf("already subscribed to %s", topic)
+	}
+	subscriptionCtx, cancel := context.WithCancel(context.Background())
+	a.subscriptions[topic] = cancel
+	a.mu.Unlock()
+
+	go func() {
+		for subscriptionCtx.Err() == nil {
+			messages, err := a.Receive(subscriptionCtx, topic, 10, subscribeWait)
+			if err != nil {
+				if subscriptionCtx.Err() == nil {
+					a.logger.Warn("Queue subscription receive failed", "adapter", a.name, "topic", topic, "error", err)
+					time.Sleep(time.Second)
+				}
+				continue
+			}
+			for _, message := range messages {
+				if err := handler(subscriptionCtx, message); err != nil {
+					a.logger.Warn("Queue message handler failed", "adapter", a.name, "topic", topic, "message_id", message.ID, "error", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (a *queueAdapter) Unsubscribe(ctx context.Context, topic string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cancel, exists := a.subscriptions[topic]
+	if !exists {
+		return fmt.Errorf("not subscribed to %s", topic)
+	}
+	cancel()
+	delete(a.subscriptions, topic)
+	return nil
+}
+
+// messageBody serializa el payload: el texto va tal cual y el resto en JSON
+func messageBody(message *Message) (string, error) {
+	if text, ok := message.Payload.(string); ok {
+		return text, nil
+	}
+	data, err := json.Marshal(message.Payload)
+	return string(data), err
+}
+
+// decodePayload devuelve el cuerpo como JSON si lo es o como texto
+func decodePayload(body string) interface{} {
+	var payload interface{}
+	if err := json.Unmarshal([]byte(body), &payload); err != nil {
+		return body
+	}
+	return payload
+}
+
+// queueRequest envía una petición JSON y decodifica la respuesta en out
+func queueRequest(ctx context.Context, client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if out == nil || len(body) == 0 {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+func newJSONRequest(method, target, contentType string, payload interface{}) (*http.Request, error) {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequest(method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+// kafkaRESTQueue usa la API v2 del Kafka REST Proxy. Consumir crea una
+// instancia de consumidor del grupo, la suscribe y la borra al terminar.
+type kafkaRESTQueue struct {
+	baseURL string
+	group   string
+	client  *http.Client
+}
+
+const kafkaJSONContentType = "application/vnd.kafka.json.v2+json"
+
+func (q *kafkaRESTQueue) publish(ctx context.Context, topic string, message *Message) error {
+	record := map[string]interface{}{"value": message.Payload}
+	if key, ok := message.Metadata["key"].(string); ok && key != "" {
+		record["key"] = key
+	}
+	req, err := newJSONRequest(http.MethodPost, q.baseURL+"/topics/"+url.PathEscape(topic), kafkaJSONContentType,
+		map[string]interface{}{"records": []interface{}{record}})
+	if err != nil {
+		return err
+	}
+	var result struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := queueRequest(ctx, q.client, req, &result); err != nil {
+		return err
+	}
+	for _, offset := range result.Offsets {
+		if offset.ErrorCode != nil {
+			return fmt.Errorf("%s", offset.Error)
+		}
+	}
+	return nil
+}
+
+func (q *kafkaRESTQueue) receive(ctx context.Context, topic string, max int, wait time.Duration) ([]*Message, error) {
+	req, err := newJSONRequest(http.MethodPost, q.baseURL+"/consumers/"+url.PathEscape(q.group), kafkaJSONContentType, map[string]interface{}{
+		"name":              "bot-service-" + id.New(),
+		"format":            "json",
+		"auto.offset.reset": "earliest",
+	})
+	if err != nil {
+		return nil, err
+	}
+	var instance struct {
+		BaseURI string `json:"base_uri"`
+	}
+	if err := queueRequest(ctx, q.client, req, &instance); err != nil {
+		return nil, fmt.Errorf("failed to create consumer: %w", err)
+	}
+	defer func() {
+		// Al borrar la instancia se confirman los offsets leídos
+		if req, err := newJSONRequest(http.MethodDelete, instance.BaseURI, kafkaJSONContentType, nil); err == nil {
+			queueRequest(context.Background(), q.client, req, nil)
+		}
+	}()
+
+	req, err = newJSONRequest(http.MethodPost, instance.BaseURI+"/subscription", kafkaJSONContentType, map[string]interface{}{"topics": []string{topic}})
+	if err != nil {
+		return nil, err
+	}
+	if err := queueRequest(ctx, q.client, req, nil); err != nil {
+		return nil, fmt.Errorf("failed to subscribe: %w", err)
+	}
+
+	// La primera lectura tras suscribirse suele volver vacía mientras se
+	// asignan las particiones, así que se repite hasta agotar wait
+	deadline := time.Now().Add(wait)
+	var messages []*Message
+	for len(messages) == 0 {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/records?timeout=%d", instance.BaseURI, min(remaining, time.Second).Milliseconds()), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", kafkaJSONContentType)
+		var records []struct {
+			Topic     string      `json:"topic"`
+			Key       interface{} `json:"key"`
+			Value     interface{} `json:"value"`
+			Partition int         `json:"partition"`
+			Offset    int64       `json:"offset"`
+		}
+		if err := queueRequest(ctx, q.client, req, &records); err != nil {
+			return nil, fmt.Errorf("failed to fetch records: %w", err)
+		}
+		for _, record := range records {
+			if len(messages) == max {
+				break
+			}
+			messages = append(messages, &Message{
+				ID:        fmt.Sprintf("%s-%d-%d", record.Topic, record.Partition, record.Offset),
+				Topic:     record.Topic,
+				Payload:   record.Value,
+				Timestamp: time.Now(),
+				Metadata:  map[string]interface{}{"key": record.Key, "partition": record.Partition, "offset": record.Offset},
+			})
+		}
+	}
+	return messages, nil
+}
+
+// rabbitMQQueue usa la API HTTP del plugin de management: publica en el
+// exchange con el topic como routing key y consume con get en modo ack
+type rabbitMQQueue struct {
+	baseURL  string
+	vhost    string
+	exchange string
+	username string
+	password string
+	client   *http.Client
+}
+
+// rabbitMQPollInterval separa las consultas mientras la cola está vacía
+const rabbitMQPollInterval = 500 * time.Millisecond
+
+func (q *rabbitMQQueue) publish(ctx context.Context, topic string, message *Message) error {
+	body, err := messageBody(message)
+	if err != nil {
+		return err
+	}
+	properties := map[string]interface{}{"message_id": message.ID, "delivery_mode": 2}
+	if len(message.Headers) > 0 {
+		properties["headers"] = message.Headers
+	}
+	if _, isText := message.Payload.(string); !isText {
+		properties["content_type"] = "application/json"
+	}
+	target := fmt.Sprintf("%s/api/exchanges/%s/%s/publish", q.baseURL, url.PathEscape(q.vhost), url.PathEscape(q.exchange))
+	req, err := newJSONRequest(http.MethodPost, target, "application/json", map[string]interface{}{
+		"properties":       properties,
+		"routing_key":      topic,
+		"payload":          body,
+		"payload_encoding": "string",
+	})
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(q.username, q.password)
+	var result struct {
+		Routed bool `json:"routed"`
+	}
+	if err := queueRequest(ctx, q.client, req, &result); err != nil {
+		return err
+	}
+	if !result.Routed {
+		return fmt.Errorf("message was not routed to any queue")
+	}
+	return nil
+}
+
+func (q *rabbitMQQueue) receive(ctx context.Context, topic string, max int, wait time.Duration) ([]*Message, error) {
+	target := fmt.Sprintf("%s/api/queues/%s/%s/get", q.baseURL, url.PathEscape(q.vhost), url.PathEscape(topic))
+	deadline := time.Now().Add(wait)
+	for {
+		req, err := newJSONRequest(http.MethodPost, target, "application/json", map[string]interface{}{
+			"count":    max,
+			"ackmode":  "ack_requeue_false",
+			"encoding": "auto",
+		})
+		if err != nil {
+			return nil, err
+		}
+		req.SetBasicAuth(q.username, q.password)
+		var deliveries []struct {
+			Payload    string `json:"payload"`
+			RoutingKey string `json:"routing_key"`
+			Properties struct {
+				MessageID string            `json:"message_id"`
+				Headers   map[string]string `json:"headers"`
+			} `json:"properties"`
+		}
+		if err := queueRequest(ctx, q.client, req, &deliveries); err != nil {
+			return nil, err
+		}
+		if len(deliveries) > 0 || !time.Now().Add(rabbitMQPollInterval).Before(deadline) {
+			messages := make([]*Message, 0, len(deliveries))
+			for _, delivery := range deliveries {
+				messages = append(messages, &Message{
+					ID:        delivery.Properties.MessageID,
+					Topic:     topic,
+					Payload:   decodePayload(delivery.Payload),
+					Headers:   delivery.Properties.Headers,
+					Timestamp: time.Now(),
+					Metadata:  map[string]interface{}{"routing_key": delivery.RoutingKey},
+				})
+			}
+			return messages, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(rabbitMQPollInterval):
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBroker guarda lo publicado y lo devuelve en la siguiente lectura
+type fakeBroker struct {
+	mu       sync.Mutex
+	bodies   []map[string]interface{}
+	requests []*http.Request
+}
+
+func (b *fakeBroker) record(t *testing.T, r *http.Request) map[string]interface{} {
+	var body map[string]interface{}
+	if r.Body != nil && r.ContentLength != 0 {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.requests = append(b.requests, r)
+	b.bodies = append(b.bodies, body)
+	return body
+}
+
+func startQueueAdapter(t *testing.T, config map[string]interface{}) MessageQueueAdapter {
+	ctx := context.Background()
+	adapter, err := NewAdapterFactory(logger.NewLogger("error")).CreateAdapter("message_queue", config)
+	require.NoError(t, err)
+	require.NoError(t, adapter.Initialize(ctx, config))
+	require.NoError(t, adapter.Start(ctx))
+	t.Cleanup(func() { adapter.Stop(ctx) })
+	return adapter.(MessageQueueAdapter)
+}
+
+func TestQueueAdapter_PublishesAndConsumesAcrossProviders(t *testing.T) {
+	ctx := context.Background()
+	order := map[string]interface{}{"order_id": "A-1", "total": 42.5}
+
+	t.Run("kafka", func(t *testing.T) {
+		broker := &fakeBroker{}
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body := broker.record(t, r)
+			switch {
+			case r.Method == http.MethodPost && r.URL.Path == "/topics/orders":
+				assert.Equal(t, kafkaJSONContentType, r.Header.Get("Content-Type"))
+				w.Write([]byte(`{"offsets":[{"partition":0,"offset":7}]}`))
+			case r.Method == http.MethodPost && r.URL.Path == "/consumers/bots":
+				w.Write([]byte(`{"instance_id":"c1","base_uri":"` + server.URL + `/consumers/bots/instances/c1"}`))
+			case strings.HasSuffix(r.URL.Path, "/subscription"):
+				assert.Equal(t, []interface{}{"orders"}, body["topics"])
+				w.WriteHeader(http.StatusNoContent)
+			case strings.HasSuffix(r.URL.Path, "/records"):
+				w.Write([]byte(`[{"topic":"orders","key":"A-1","value":{"order_id":"A-1"},"partition":0,"offset":7}]`))
+			case r.Method == http.MethodDelete:
+				w.WriteHeader(http.StatusNoContent)
+			default:
+				t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			}
+		}))
+		defer server.Close()
+
+		queue := startQueueAdapter(t, map[string]interface{}{"provider": "kafka", "url": server.URL, "consumer_group": "bots"})
+		require.NoError(t, queue.Publish(ctx, "orders", &Message{Payload: order, Metadata: map[string]interface{}{"key": "A-1"}}))
+		records := broker.bodies[0]["records"].([]interface{})
+		assert.Equal(t, map[string]interface{}{"key": "A-1", "value": order}, records[0])
+
+		messages, err := queue.Receive(ctx, "orders", 5, time.Second)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, map[string]interface{}{"order_id": "A-1"}, messages[0].Payload)
+		assert.Equal(t, http.MethodDelete, broker.requests[len(broker.requests)-1].Method)
+	})
+
+	t.Run("rabbitmq", func(t *testing.T) {
+		broker := &fakeBroker{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, password, _ := r.BasicAuth()
+			assert.Equal(t, "bot:secret", user+":"+password)
+			body := broker.record(t, r)
+			switch r.URL.EscapedPath() {
+			case "/api/exchanges/%2F/events/publish":
+				routed := body["routing_key"] == "orders.created"
+				json.NewEncoder(w).Encode(map[string]bool{"routed": routed})
+			case "/api/queues/%2F/orders/get":
+				assert.Equal(t, "ack_requeue_false", body["ackmode"])
+				w.Write([]byte(`[{"payload":"{\"order_id\":\"A-1\"}","routing_key":"orders.created","properties":{"message_id":"m-1","headers":{"source":"bot"}}}]`))
+			default:
+				t.Errorf("unexpected request %s", r.URL.EscapedPath())
+			}
+		}))
+		defer server.Close()
+
+		queue := startQueueAdapter(t, map[string]interface{}{"provider": "rabbitmq", "url": server.URL, "exchange": "events", "username": "bot", "password": "secret"})
+		require.NoError(t, queue.Publish(ctx, "orders.created", &Message{Payload: order, Headers: map[string]string{"source": "bot"}}))
+		assert.JSONEq(t, `{"order_id":"A-1","total":42.5}`, broker.bodies[0]["payload"].(string))
+		assert.ErrorContains(t, queue.Publish(ctx, "nowhere", &Message{Payload: "x"}), "not routed")
+
+		messages, err := queue.Receive(ctx, "orders", 1, 0)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, "m-1", messages[0].ID)
+		assert.Equal(t, map[string]interface{}{"order_id": "A-1"}, messages[0].Payload)
+		assert.Equal(t, "bot", messages[0].Headers["source"])
+	})
+
+	t.Run("sqs", func(t *testing.T) {
+		broker := &fakeBroker{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"), r.Header.Get("Authorization"))
+			assert.Contains(t, r.Header.Get("Authorization"), "/us-east-1/sqs/aws4_request")
+			body := broker.record(t, r)
+			switch r.Header.Get("X-Amz-Target") {
+			case "AmazonSQS.SendMessage":
+				w.Write([]byte(`{"MessageId":"sqs-1"}`))
+			case "AmazonSQS.ReceiveMessage":
+				assert.Equal(t, float64(2), body["WaitTimeSeconds"])
+				w.Write([]byte(`{"Messages":[{"MessageId":"sqs-1","ReceiptHandle":"r-1","Body":"{\"order_id\":\"A-1\"}"}]}`))
+			case "AmazonSQS.DeleteMessage":
+				assert.Equal(t, "r-1", body["ReceiptHandle"])
+				w.Write([]byte(`{}`))
+			default:
+				t.Errorf("unexpected target %s", r.Header.Get("X-Amz-Target"))
+			}
+		}))
+		defer server.Close()
+
+		queue := startQueueAdapter(t, map[string]interface{}{
+			"provider": "sqs", "url": server.URL, "region": "us-east-1", "queue_base_url": server.URL + "/123456789012",
+			"access_key_id": "AKID", "secret_access_key": "secret",
+		})
+		require.NoError(t, queue.Publish(ctx, "orders.fifo", &Message{Payload: order, Metadata: map[string]interface{}{"key": "A-1"}}))
+		assert.Equal(t, server.URL+"/123456789012/orders.fifo", broker.bodies[0]["QueueUrl"])
+		assert.Equal(t, "A-1", broker.bodies[0]["MessageGroupId"])
+		assert.NotEmpty(t, broker.bodies[0]["MessageDeduplicationId"])
+
+		messages, err := queue.Receive(ctx, "orders.fifo", 1, 2*time.Second)
+		require.NoError(t, err)
+		require.Len(t, messages, 1)
+		assert.Equal(t, map[string]interface{}{"order_id": "A-1"}, messages[0].Payload)
+		assert.Equal(t, "AmazonSQS.DeleteMessage", broker.requests[len(broker.requests)-1].Header.Get("X-Amz-Target"))
+	})
+
+	factory := NewAdapterFactory(logger.NewLogger("error"))
+	_, err := factory.CreateAdapter("message_queue", map[string]interface{}{"provider": "nats"})
+	assert.Error(t, err)
+	missingURL, err := factory.CreateAdapter("message_queue", map[string]interface{}{"provider": "kafka"})
+	require.NoError(t, err)
+	assert.Error(t, missingURL.Start(ctx))
+}
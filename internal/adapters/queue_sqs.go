@@ -0,0 +1,196 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// sqsMaxWait es el máximo long polling que admite ReceiveMessage
+const sqsMaxWait = 20 * time.Second
+
+// sqsQueue usa el protocolo JSON de SQS firmado con SigV4. El topic es la URL
+// de la cola o su nombre, que se resuelve contra queue_base_url.
+type sqsQueue struct {
+	endpoint     string
+	queueBaseURL string
+	region       string
+	accessKey    string
+	secretKey    string
+	sessionToken string
+	client       *http.Client
+}
+
+func (q *sqsQueue) queueURL(topic string) (string, error) {
+	if strings.HasPrefix(topic, "https://") || strings.HasPrefix(topic, "http://") {
+		return topic, nil
+	}
+	if q.queueBaseURL == "" {
+		return "", fmt.Errorf("queue %q is not a URL and queue_base_url is not configured", topic)
+	}
+	return q.queueBaseURL + "/" + topic, nil
+}
+
+func (q *sqsQueue) publish(ctx context.Context, topic string, message *Message) error {
+	queueURL, err := q.queueURL(topic)
+	if err != nil {
+		return err
+	}
+	body, err := messageBody(message)
+	if err != nil {
+		return err
+	}
+	input := map[string]interface{}{"QueueUrl": queueURL, "MessageBody": body}
+	if len(message.Headers) > 0 {
+		attributes := make(map[string]interface{}, len(message.Headers))
+		for name, value := range message.Headers {
+			attributes[name] = map[string]string{"DataType": "String", "StringValue": value}
+		}
+		input["MessageAttributes"] = attributes
+	}
+	if strings.HasSuffix(queueURL, ".fifo") {
+		group, _ := message.Metadata["key"].(string)
+		if group == "" {
+			group = "default"
+		}
+		input["MessageGroupId"] = group
+		input["MessageDeduplicationId"] = message.ID
+	}
+	return q.call(ctx, "SendMessage", input, nil)
+}
+
+func (q *sqsQueue) receive(ctx context.Context, topic string, max int, wait time.Duration) ([]*Message, error) {
+	queueURL, err := q.queueURL(topic)
+	if err != nil {
+		return nil, err
+	}
+	if max > 10 {
+		max = 10
+	}
+	if wait > sqsMaxWait {
+		wait = sqsMaxWait
+	}
+	var output struct {
+		Messages []struct {
+			MessageID         string `json:"MessageId"`
+			ReceiptHandle     string `json:"ReceiptHandle"`
+			Body              string `json:"Body"`
+			MessageAttributes map[string]struct {
+				StringValue string `json:"StringValue"`
+			} `json:"MessageAttributes"`
+		} `json:"Messages"`
+	}
+	err = q.call(ctx, "ReceiveMessage", map[string]interface{}{
+		"QueueUrl":              queueURL,
+		"MaxNumberOfMessages":   max,
+		"WaitTimeSeconds":       int(wait.Seconds()),
+		"MessageAttributeNames": []string{"All"},
+	}, &output)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]*Message, 0, len(output.Messages))
+	for _, received := range output.Messages {
+		// Se borra al recibir para que no vuelva a entregarse al vencer la visibilidad
+		if err := q.call(ctx, "DeleteMessage", map[string]interface{}{"QueueUrl": queueURL, "ReceiptHandle": received.ReceiptHandle}, nil); err != nil {
+			return messages, fmt.Errorf("failed to delete message %s: %w", received.MessageID, err)
+		}
+		headers := make(map[string]string, len(received.MessageAttributes))
+		for name, attribute := range received.MessageAttributes {
+			headers[name] = attribute.StringValue
+		}
+		messages = append(messages, &Message{
+			ID:        received.MessageID,
+			Topic:     topic,
+			Payload:   decodePayload(received.Body),
+			Headers:   headers,
+			Timestamp: time.Now(),
+		})
+	}
+	return messages, nil
+}
+
+// call invoca una acción de la API de SQS
+func (q *sqsQueue) call(ctx context.Context, action string, input, output interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, q.endpoint+"/", bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS."+action)
+	q.sign(req, data, time.Now().UTC())
+	return queueRequest(ctx, q.client, req, output)
+}
+
+// sign añade la firma AWS Signature Version 4 del servicio sqs
+func (q *sqsQueue) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if q.sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", q.sessionToken)
+	}
+
+	signedHeaders := []string{"content-type", "host", "x-amz-date", "x-amz-target"}
+	if q.sessionToken != "" {
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+		sort.Strings(signedHeaders)
+	}
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		sha256Hex(body),
+	}, "\n")
+
+	scope := date + "/" + q.region + "/sqs/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+q.secretKey), date)
+	key = hmacSHA256(key, q.region)
+	key = hmacSHA256(key, "sqs")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		q.accessKey, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -1,9 +1,12 @@
 package adapters
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"sync"
 
+	"github.com/company/bot-service/pkg/configschema"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -114,6 +117,80 @@ func (r *adapterRegistry) GetByCapability(capability string) []Adapter {
 	return result
 }
 
+// adapterConfigSchemas describe la configuración de los adaptadores implementados
+var adapterConfigSchemas = map[string][]configschema.Field{
+	"http": {
+		{Name: "name", Type: configschema.TypeString, Description: "Adapter name", Default: "default-http-adapter"},
+		{Name: "version", Type: configschema.TypeString, Description: "Adapter version", Default: "1.0"},
+		{Name: "timeout", Type: configschema.TypeDuration, Description: "Request timeout, e.g. 30s or a number of seconds"},
+		{Name: "default_headers", Type: configschema.TypeObject, Description: "Headers sent with every request"},
+	},
+	"grpc": {
+		{Name: "name", Type: configschema.TypeString, Description: "Adapter name", Default: "default-grpc-adapter"},
+		{Name: "version", Type: configschema.TypeString, Description: "Adapter version", Default: "1.0"},
+		{Name: "target", Type: configschema.TypeString, Required: true, Description: "Server address as host:port"},
+		{Name: "tls", Type: configschema.TypeBoolean, Description: "Connect with TLS"},
+		{Name: "descriptor_set", Type: configschema.TypeString, Description: "Compiled FileDescriptorSet; server reflection is used when empty"},
+		{Name: "timeout", Type: configschema.TypeDuration, Description: "Call timeout, e.g. 30s or a number of seconds"},
+		{Name: "default_metadata", Type: configschema.TypeObject, Description: "Metadata sent with every call"},
+	},
+	"graphql": {
+		{Name: "name", Type: configschema.TypeString, Description: "Adapter name", Default: "default-graphql-adapter"},
+		{Name: "version", Type: configschema.TypeString, Description: "Adapter version", Default: "1.0"},
+		{Name: "endpoint", Type: configschema.TypeString, Required: true, Format: configschema.FormatURL, Description: "GraphQL endpoint URL"},
+		{Name: "timeout", Type: configschema.TypeDuration, Description: "Request timeout, e.g. 30s or a number of seconds"},
+		{Name: "default_headers", Type: configschema.TypeObject, Description: "Headers sent with every request"},
+	},
+	"database": {
+		{Name: "name", Type: configschema.TypeString, Description: "Adapter name", Default: "default-database-adapter"},
+		{Name: "version", Type: configschema.TypeString, Description: "Adapter version", Default: "1.0"},
+		{Name: "dialect", Type: configschema.TypeString, Required: true, Enum: []string{DialectPostgres, DialectMySQL}, Description: "SQL dialect"},
+		{Name: "driver", Type: configschema.TypeString, Description: "database/sql driver name, defaults to the dialect"},
+		{Name: "dsn", Type: configschema.TypeString, Required: true, Description: "Connection string with the adapter credentials"},
+		{Name: "max_rows", Type: configschema.TypeInteger, Description: "Maximum rows returned per query", Default: defaultDatabaseMaxRows, Min: configschema.Bound(1)},
+		{Name: "timeout", Type: configschema.TypeDuration, Description: "Query timeout, e.g. 10s or a number of seconds"},
+		{Name: "max_open_conns", Type: configschema.TypeInteger, Description: "Connection pool size", Default: defaultDatabaseMaxOpen, Min: configschema.Bound(1)},
+		{Name: "max_idle_conns", Type: configschema.TypeInteger, Description: "Idle connections kept in the pool", Default: defaultDatabaseMaxIdle, Min: configschema.Bound(0)},
+		{Name: "conn_max_lifetime", Type: configschema.TypeDuration, Description: "Maximum lifetime of a pooled connection"},
+	},
+	"message_queue": {
+		{Name: "name", Type: configschema.TypeString, Description: "Adapter name", Default: "default-message-queue"},
+		{Name: "version", Type: configschema.TypeString, Description: "Adapter version", Default: "1.0"},
+		{Name: "provider", Type: configschema.TypeString, Required: true, Enum: []string{QueueProviderKafka, QueueProviderRabbitMQ, QueueProviderSQS}, Description: "Queue broker"},
+		{Name: "url", Type: configschema.TypeString, Format: configschema.FormatURL, Description: "Kafka REST proxy, RabbitMQ management API or SQS endpoint URL"},
+		{Name: "timeout", Type: configschema.TypeDuration, Description: "Request timeout, e.g. 10s or a number of seconds"},
+		{Name: "consumer_group", Type: configschema.TypeString, Description: "Kafka consumer group, defaults to the adapter name"},
+		{Name: "username", Type: configschema.TypeString, Description: "RabbitMQ user", Default: "guest"},
+		{Name: "password", Type: configschema.TypeString, Description: "RabbitMQ password", Default: "guest"},
+		{Name: "vhost", Type: configschema.TypeString, Description: "RabbitMQ virtual host", Default: "/"},
+		{Name: "exchange", Type: configschema.TypeString, Description: "RabbitMQ exchange; topics are routing keys", Default: "amq.default"},
+		{Name: "region", Type: configschema.TypeString, Description: "SQS region"},
+		{Name: "queue_base_url", Type: configschema.TypeString, Format: configschema.FormatURL, Description: "SQS URL prefix for topics given as queue names"},
+		{Name: "access_key_id", Type: configschema.TypeString, Description: "SQS access key"},
+		{Name: "secret_access_key", Type: configschema.TypeString, Description: "SQS secret key"},
+		{Name: "session_token", Type: configschema.TypeString, Description: "SQS session token for temporary credentials"},
+	},
+	"object_store": {
+		{Name: "name", Type: configschema.TypeString, Description: "Adapter name", Default: "default-object-store"},
+		{Name: "base_dir", Type: configschema.TypeString, Required: true, Description: "Directory where objects are stored"},
+		{Name: "public_base_url", Type: configschema.TypeString, Format: configschema.FormatURL, Description: "Public URL prefix for stored objects"},
+	},
+}
+
+// LoadAdapterConfigs lee un JSON con la lista de configuraciones de los
+// adaptadores que se registran al arrancar
+func LoadAdapterConfigs(path string) ([]map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read adapters file: %w", err)
+	}
+	var configs []map[string]interface{}
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return nil, fmt.Errorf("invalid adapters file %s: %w", path, err)
+	}
+	return configs, nil
+}
+
 // adapterFactory implementa AdapterFactory
 type adapterFactory struct {
 	logger logger.Logger
@@ -133,6 +210,16 @@ func (f *adapterFactory) CreateAdapter(adapterType string, config map[string]int
 		return f.createHTTPAdapter(config)
 	case "webhook":
 		return f.createWebhookAdapter(config)
+	case "object_store":
+		return f.createObjectStoreAdapter(config)
+	case "database":
+		return f.createDatabaseAdapter(config)
+	case "grpc":
+		return f.createGRPCAdapter(config)
+	case "graphql":
+		return f.createGraphQLAdapter(config)
+	case "message_queue":
+		return f.createMessageQueueAdapter(config)
 	default:
 		return nil, fmt.Errorf("unsupported adapter type: %s", adapterType)
 	}
@@ -145,20 +232,22 @@ func (f *adapterFactory) GetSupportedTypes() []string {
 		"webhook",
 		"database",
 		"message_queue",
+		"object_store",
+		"grpc",
+		"graphql",
 	}
 }
 
-// ValidateConfig valida la configuración de un adaptador
+// ValidateConfig valida la configuración de un adaptador contra su esquema y
+// devuelve un *configschema.ValidationError con todos los campos inválidos
 func (f *adapterFactory) ValidateConfig(adapterType string, config map[string]interface{}) error {
+	if schema, ok := adapterConfigSchemas[adapterType]; ok {
+		return configschema.Validate(schema, config)
+	}
+
 	switch adapterType {
-	case "http":
-		return f.validateHTTPConfig(config)
 	case "webhook":
 		return f.validateWebhookConfig(config)
-	case "database":
-		return f.validateDatabaseConfig(config)
-	case "message_queue":
-		return f.validateMessageQueueConfig(config)
 	default:
 		return fmt.Errorf("unsupported adapter type: %s", adapterType)
 	}
@@ -166,45 +255,72 @@ func (f *adapterFactory) ValidateConfig(adapterType string, config map[string]in
 
 // createHTTPAdapter crea un adaptador HTTP
 func (f *adapterFactory) createHTTPAdapter(config map[string]interface{}) (Adapter, error) {
-	name, _ := config["name"].(string)
-	if name == "" {
-		name = "default-http-adapter"
+	if err := f.ValidateConfig("http", config); err != nil {
+		return nil, err
 	}
 
-	version, _ := config["version"].(string)
-	if version == "" {
-		version = "1.0"
+	values := configschema.Values(config)
+	adapter := NewHTTPAdapter(values.String("name", "default-http-adapter"), values.String("version", "1.0"), f.logger)
+	return adapter, nil
+}
+
+// createObjectStoreAdapter crea un almacenamiento de objetos local
+func (f *adapterFactory) createObjectStoreAdapter(config map[string]interface{}) (Adapter, error) {
+	if err := f.ValidateConfig("object_store", config); err != nil {
+		return nil, err
 	}
 
-	adapter := NewHTTPAdapter(name, version, f.logger)
-	return adapter, nil
+	values := configschema.Values(config)
+	name := values.String("name", "default-object-store")
+	return NewLocalObjectStore(name, values.String("base_dir", ""), values.String("public_base_url", ""), f.logger), nil
 }
 
-// createWebhookAdapter crea un adaptador de webhook (placeholder)
-func (f *adapterFactory) createWebhookAdapter(config map[string]interface{}) (Adapter, error) {
-	// TODO: Implementar adaptador de webhook
-	return nil, fmt.Errorf("webhook adapter not implemented yet")
+// createDatabaseAdapter crea un adaptador SQL de solo lectura; el pool se abre en Start
+func (f *adapterFactory) createDatabaseAdapter(config map[string]interface{}) (Adapter, error) {
+	if err := f.ValidateConfig("database", config); err != nil {
+		return nil, err
+	}
+
+	values := configschema.Values(config)
+	return NewDatabaseAdapter(values.String("name", "default-database-adapter"), values.String("version", "1.0"),
+		values.String("dialect", ""), values.String("dsn", ""), f.logger), nil
 }
 
-// validateHTTPConfig valida la configuración HTTP
-func (f *adapterFactory) validateHTTPConfig(config map[string]interface{}) error {
-	// La configuración HTTP es opcional, solo validar tipos si están presentes
-	if timeout, exists := config["timeout"]; exists {
-		switch timeout.(type) {
-		case string, float64, int:
-			// Tipos válidos
-		default:
-			return fmt.Errorf("timeout must be a string, number, or duration")
-		}
+// createGRPCAdapter crea un adaptador gRPC; la conexión se abre en Start
+func (f *adapterFactory) createGRPCAdapter(config map[string]interface{}) (Adapter, error) {
+	if err := f.ValidateConfig("grpc", config); err != nil {
+		return nil, err
 	}
 
-	if headers, exists := config["default_headers"]; exists {
-		if _, ok := headers.(map[string]interface{}); !ok {
-			return fmt.Errorf("default_headers must be an object")
-		}
+	values := configschema.Values(config)
+	return NewGRPCAdapter(values.String("name", "default-grpc-adapter"), values.String("version", "1.0"), values.String("target", ""), f.logger), nil
+}
+
+// createGraphQLAdapter crea un adaptador GraphQL
+func (f *adapterFactory) createGraphQLAdapter(config map[string]interface{}) (Adapter, error) {
+	if err := f.ValidateConfig("graphql", config); err != nil {
+		return nil, err
 	}
 
-	return nil
+	values := configschema.Values(config)
+	return NewGraphQLAdapter(values.String("name", "default-graphql-adapter"), values.String("version", "1.0"), values.String("endpoint", ""), f.logger), nil
+}
+
+// createMessageQueueAdapter crea un adaptador de colas; el cliente del
+// proveedor se configura en Start
+func (f *adapterFactory) createMessageQueueAdapter(config map[string]interface{}) (Adapter, error) {
+	if err := f.ValidateConfig("message_queue", config); err != nil {
+		return nil, err
+	}
+
+	values := configschema.Values(config)
+	return NewQueueAdapter(values.String("name", "default-message-queue"), values.String("version", "1.0"), values.String("provider", ""), f.logger), nil
+}
+
+// createWebhookAdapter crea un adaptador de webhook (placeholder)
+func (f *adapterFactory) createWebhookAdapter(config map[string]interface{}) (Adapter, error) {
+	// TODO: Implementar adaptador de webhook
+	return nil, fmt.Errorf("webhook adapter not implemented yet")
 }
 
 // validateWebhookConfig valida la configuración de webhook
@@ -212,15 +328,3 @@ func (f *adapterFactory) validateWebhookConfig(config map[string]interface{}) er
 	// TODO: Implementar validación de webhook
 	return fmt.Errorf("webhook validation not implemented yet")
 }
-
-// validateDatabaseConfig valida la configuración de base de datos
-func (f *adapterFactory) validateDatabaseConfig(config map[string]interface{}) error {
-	// TODO: Implementar validación de base de datos
-	return fmt.Errorf("database validation not implemented yet")
-}
-
-// validateMessageQueueConfig valida la configuración de cola de mensajes
-func (f *adapterFactory) validateMessageQueueConfig(config map[string]interface{}) error {
-	// TODO: Implementar validación de cola de mensajes
-	return fmt.Errorf("message queue validation not implemented yet")
-}
\ No newline at end of file
@@ -0,0 +1,85 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/secrets"
+)
+
+// WithSecretHeaders resuelve las referencias ${secret:nombre} de los headers
+// (por defecto y de cada petición) justo antes de enviarla, de modo que una
+// credencial rotada se usa en la siguiente llamada sin reiniciar el adaptador
+func WithSecretHeaders(adapter HTTPAdapter, manager *secrets.Manager) HTTPAdapter {
+	return &secretHeadersAdapter{HTTPAdapter: adapter, secrets: manager, defaults: make(map[string]string)}
+}
+
+type secretHeadersAdapter struct {
+	HTTPAdapter
+	secrets *secrets.Manager
+
+	mu       sync.RWMutex
+	defaults map[string]string
+}
+
+// Initialize separa los default_headers con referencias antes de inicializar el adaptador
+func (a *secretHeadersAdapter) Initialize(ctx context.Context, config map[string]interface{}) error {
+	headers := configschema.Values(config).StringMap("default_headers")
+	if len(headers) == 0 {
+		return a.HTTPAdapter.Initialize(ctx, config)
+	}
+
+	rest := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		if key != "default_headers" {
+			rest[key] = value
+		}
+	}
+	if err := a.HTTPAdapter.Initialize(ctx, rest); err != nil {
+		return err
+	}
+	a.SetDefaultHeaders(headers)
+	return nil
+}
+
+// SetDefaultHeaders guarda aparte los headers con referencias para no
+// entregarle valores al adaptador que luego queden obsoletos
+func (a *secretHeadersAdapter) SetDefaultHeaders(headers map[string]string) {
+	plain := make(map[string]string)
+	a.mu.Lock()
+	for key, value := range headers {
+		if len(secrets.References(value)) > 0 {
+			a.defaults[key] = value
+		} else {
+			delete(a.defaults, key)
+			plain[key] = value
+		}
+	}
+	a.mu.Unlock()
+	a.HTTPAdapter.SetDefaultHeaders(plain)
+}
+
+func (a *secretHeadersAdapter) MakeRequest(ctx context.Context, request *HTTPRequest) (*HTTPResponse, error) {
+	a.mu.RLock()
+	headers := make(map[string]string, len(a.defaults)+len(request.Headers))
+	for key, value := range a.defaults {
+		headers[key] = value
+	}
+	a.mu.RUnlock()
+	for key, value := range request.Headers {
+		headers[key] = value
+	}
+
+	for key, value := range headers {
+		resolved, err := a.secrets.ResolveString(ctx, value)
+		if err != nil {
+			return nil, err
+		}
+		headers[key] = resolved
+	}
+
+	resolved := *request
+	resolved.Headers = headers
+	return a.HTTPAdapter.MakeRequest(ctx, &resolved)
+}
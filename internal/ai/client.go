@@ -4,20 +4,34 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/company/bot-service/internal/metrics"
 	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/sse"
 )
 
 // AIClient interface genérico para clientes de IA
 type AIClient interface {
 	GenerateResponse(ctx context.Context, prompt string, options ...Option) (*Response, error)
 	GenerateChatResponse(ctx context.Context, messages []Message, options ...Option) (*Response, error)
+	StreamChatResponse(ctx context.Context, messages []Message, options ...Option) (<-chan StreamChunk, error)
 	Close() error
 }
 
+// StreamChunk representa un fragmento parcial de una respuesta en streaming.
+// El último fragmento del canal tiene Done en true.
+type StreamChunk struct {
+	Content      string `json:"content"`
+	Done         bool   `json:"done"`
+	FinishReason string `json:"finish_reason,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
 // Message representa un mensaje en una conversación
 type Message struct {
 	Role    string `json:"role"`    // system, user, assistant
@@ -125,7 +139,9 @@ func (c *OpenAIClient) GenerateChatResponse(ctx context.Context, messages []Mess
 			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
 		Usage struct {
-			TotalTokens int `json:"total_tokens"`
+			PromptTokens     int `json:"prompt_tokens"`
+			CompletionTokens int `json:"completion_tokens"`
+			TotalTokens      int `json:"total_tokens"`
 		} `json:"usage"`
 		Model string `json:"model"`
 	}
@@ -137,13 +153,17 @@ func (c *OpenAIClient) GenerateChatResponse(ctx context.Context, messages []Mess
 	if len(openAIResp.Choices) == 0 {
 		return nil, fmt.Errorf("no choices in response")
 	}
+	metrics.RecordAITokens("openai", openAIResp.Model, openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
 
 	response := &Response{
 		Content:      openAIResp.Choices[0].Message.Content,
 		TokensUsed:   openAIResp.Usage.TotalTokens,
 		Model:        openAIResp.Model,
 		FinishReason: openAIResp.Choices[0].FinishReason,
-		Metadata:     make(map[string]interface{}),
+		Metadata: map[string]interface{}{
+			"prompt_tokens":     openAIResp.Usage.PromptTokens,
+			"completion_tokens": openAIResp.Usage.CompletionTokens,
+		},
 	}
 
 	c.logger.Info("AI response generated", 
@@ -154,6 +174,79 @@ func (c *OpenAIClient) GenerateChatResponse(ctx context.Context, messages []Mess
 	return response, nil
 }
 
+// StreamChatResponse solicita la respuesta con "stream": true y reenvía cada delta por el canal
+func (c *OpenAIClient) StreamChatResponse(ctx context.Context, messages []Message, options ...Option) (<-chan StreamChunk, error) {
+	config := &RequestConfig{
+		Model:       "gpt-3.5-turbo",
+		MaxTokens:   1000,
+		Temperature: 0.7,
+		TopP:        1.0,
+	}
+
+	for _, option := range options {
+		option(config)
+	}
+
+	requestBody := map[string]interface{}{
+		"model":       config.Model,
+		"messages":    messages,
+		"max_tokens":  config.MaxTokens,
+		"temperature": config.Temperature,
+		"top_p":       config.TopP,
+		"stream":      true,
+	}
+
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	// El timeout del cliente cortaría streams largos, así que se usa un cliente sin timeout
+	// y la cancelación queda a cargo del contexto
+	streamClient := &http.Client{Transport: c.httpClient.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+	}
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		finishReason, err := sse.ReadChat(resp.Body, func(content string) bool {
+			return sendChunk(ctx, chunks, StreamChunk{Content: content})
+		}, func(err error) {
+			c.logger.Warn("Failed to decode stream event", "error", err)
+		})
+		if errors.Is(err, sse.ErrStopped) {
+			return
+		}
+
+		final := StreamChunk{Done: true, FinishReason: finishReason}
+		if err != nil {
+			final.Error = err.Error()
+		}
+		sendChunk(ctx, chunks, final)
+	}()
+
+	return chunks, nil
+}
+
 func (c *OpenAIClient) Close() error {
 	return nil
 }
@@ -204,10 +297,46 @@ func (c *MockAIClient) GenerateChatResponse(ctx context.Context, messages []Mess
 	return c.GenerateResponse(ctx, lastMessage, options...)
 }
 
+// StreamChatResponse emite la respuesta mock palabra por palabra
+func (c *MockAIClient) StreamChatResponse(ctx context.Context, messages []Message, options ...Option) (<-chan StreamChunk, error) {
+	response, err := c.GenerateChatResponse(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+
+		for i, word := range strings.Fields(response.Content) {
+			if i > 0 {
+				word = " " + word
+			}
+			if !sendChunk(ctx, chunks, StreamChunk{Content: word}) {
+				return
+			}
+		}
+
+		sendChunk(ctx, chunks, StreamChunk{Done: true, FinishReason: response.FinishReason})
+	}()
+
+	return chunks, nil
+}
+
 func (c *MockAIClient) Close() error {
 	return nil
 }
 
+// sendChunk envía un fragmento respetando la cancelación del contexto
+func sendChunk(ctx context.Context, chunks chan<- StreamChunk, chunk StreamChunk) bool {
+	select {
+	case chunks <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
 // Opciones de configuración
 func WithModel(model string) Option {
 	return func(config *RequestConfig) {
@@ -0,0 +1,93 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOpenAIClient(url string) *OpenAIClient {
+	client := NewOpenAIClient("sk-live", logger.NewLogger("error")).(*OpenAIClient)
+	client.baseURL = url
+	return client
+}
+
+func TestOpenAIClient_StreamChatResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-live", r.Header.Get("Authorization"))
+		assert.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		var request map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, true, request["stream"])
+		assert.Equal(t, "gpt-4o", request["model"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range []string{
+			`{"choices":[{"delta":{"content":"Hello"}}]}`,
+			`{"choices":[{"delta":{"content":" there"}}]}`,
+			`{"choices":[{"delta":{},"finish_reason":"stop"}]}`,
+			`[DONE]`,
+		} {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	chunks, err := newTestOpenAIClient(server.URL).StreamChatResponse(context.Background(),
+		[]Message{{Role: "user", Content: "Hi"}}, WithModel("gpt-4o"))
+	require.NoError(t, err)
+
+	var received []StreamChunk
+	for chunk := range chunks {
+		received = append(received, chunk)
+	}
+	assert.Equal(t, []StreamChunk{
+		{Content: "Hello"},
+		{Content: " there"},
+		{Done: true, FinishReason: "stop"},
+	}, received)
+}
+
+func TestOpenAIClient_StreamChatResponseErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rate limited", http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := newTestOpenAIClient(server.URL).StreamChatResponse(context.Background(), []Message{{Role: "user", Content: "Hi"}})
+	assert.ErrorContains(t, err, "status: 429")
+}
+
+func TestOpenAIClient_StreamChatResponseStopsOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"first\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	chunks, err := newTestOpenAIClient(server.URL).StreamChatResponse(ctx, []Message{{Role: "user", Content: "Hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, StreamChunk{Content: "first"}, <-chunks)
+
+	// Al cancelar se deja de leer y el canal se cierra
+	cancel()
+	for chunk := range chunks {
+		assert.NotEqual(t, "first", chunk.Content)
+	}
+}
@@ -0,0 +1,131 @@
+package ai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultEmbeddingModel es el modelo de embeddings de OpenAI por defecto
+	DefaultEmbeddingModel = "text-embedding-3-small"
+	// MockEmbeddingDimensions es la dimensión de los vectores de MockAIClient
+	MockEmbeddingDimensions = 256
+)
+
+// ErrEmbeddingsUnsupported indica que el cliente envuelto no genera embeddings
+var ErrEmbeddingsUnsupported = errors.New("ai client does not support embeddings")
+
+// Embedder genera un vector por cada texto, en el mismo orden
+type Embedder interface {
+	Embed(ctx context.Context, texts []string, options ...Option) ([][]float32, error)
+}
+
+// Embed llama a /embeddings; WithModel cambia el modelo
+func (c *OpenAIClient) Embed(ctx context.Context, texts []string, options ...Option) ([][]float32, error) {
+	config := &RequestConfig{Model: DefaultEmbeddingModel}
+	for _, option := range options {
+		option(config)
+	}
+
+	jsonData, err := json.Marshal(map[string]interface{}{
+		"model": config.Model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embeddings", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("API request failed with status: %d", resp.StatusCode)
+	}
+
+	var embeddingResp struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&embeddingResp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range embeddingResp.Data {
+		if item.Index < 0 || item.Index >= len(vectors) {
+			return nil, fmt.Errorf("embedding index %d out of range", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if len(vector) == 0 {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// Embed genera vectores deterministas repartiendo las palabras del texto en
+// MockEmbeddingDimensions posiciones: textos con palabras en común se parecen
+func (c *MockAIClient) Embed(ctx context.Context, texts []string, options ...Option) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, MockEmbeddingDimensions)
+		words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+		})
+		for _, word := range words {
+			hash := fnv.New32a()
+			hash.Write([]byte(word))
+			vector[hash.Sum32()%MockEmbeddingDimensions]++
+		}
+
+		norm := 0.0
+		for _, value := range vector {
+			norm += float64(value * value)
+		}
+		if norm > 0 {
+			norm = math.Sqrt(norm)
+			for j := range vector {
+				vector[j] = float32(float64(vector[j]) / norm)
+			}
+		}
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// Embed delega en el cliente envuelto si genera embeddings
+func (c *LoadTrackingClient) Embed(ctx context.Context, texts []string, options ...Option) ([][]float32, error) {
+	embedder, ok := c.AIClient.(Embedder)
+	if !ok {
+		return nil, ErrEmbeddingsUnsupported
+	}
+
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+
+	vectors, err := embedder.Embed(ctx, texts, options...)
+	c.observe(err)
+	return vectors, err
+}
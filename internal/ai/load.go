@@ -0,0 +1,81 @@
+package ai
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LoadTrackingClient envuelve un AIClient para saber cuántas llamadas hay en
+// curso y cuándo respondió el proveedor por última vez con un límite de tasa
+type LoadTrackingClient struct {
+	AIClient
+	inFlight    atomic.Int64
+	rateLimited atomic.Int64 // UnixNano del último 429
+}
+
+// NewLoadTrackingClient crea el cliente que mide la carga de inner
+func NewLoadTrackingClient(inner AIClient) *LoadTrackingClient {
+	return &LoadTrackingClient{AIClient: inner}
+}
+
+func (c *LoadTrackingClient) GenerateResponse(ctx context.Context, prompt string, options ...Option) (*Response, error) {
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+
+	response, err := c.AIClient.GenerateResponse(ctx, prompt, options...)
+	c.observe(err)
+	return response, err
+}
+
+func (c *LoadTrackingClient) GenerateChatResponse(ctx context.Context, messages []Message, options ...Option) (*Response, error) {
+	c.inFlight.Add(1)
+	defer c.inFlight.Add(-1)
+
+	response, err := c.AIClient.GenerateChatResponse(ctx, messages, options...)
+	c.observe(err)
+	return response, err
+}
+
+// StreamChatResponse cuenta la llamada en curso hasta que se cierra el canal
+func (c *LoadTrackingClient) StreamChatResponse(ctx context.Context, messages []Message, options ...Option) (<-chan StreamChunk, error) {
+	c.inFlight.Add(1)
+	chunks, err := c.AIClient.StreamChatResponse(ctx, messages, options...)
+	c.observe(err)
+	if err != nil {
+		c.inFlight.Add(-1)
+		return nil, err
+	}
+
+	out := make(chan StreamChunk)
+	go func() {
+		defer close(out)
+		defer c.inFlight.Add(-1)
+		for chunk := range chunks {
+			out <- chunk
+		}
+	}()
+	return out, nil
+}
+
+// InFlight devuelve las llamadas al proveedor que aún no han terminado
+func (c *LoadTrackingClient) InFlight() int {
+	return int(c.inFlight.Load())
+}
+
+// RateLimitedWithin indica si el proveedor devolvió un 429 en la última ventana
+func (c *LoadTrackingClient) RateLimitedWithin(window time.Duration) bool {
+	last := c.rateLimited.Load()
+	return last > 0 && time.Since(time.Unix(0, last)) < window
+}
+
+func (c *LoadTrackingClient) observe(err error) {
+	if err == nil {
+		return
+	}
+	msg := strings.ToLower(err.Error())
+	if strings.Contains(msg, "status: 429") || strings.Contains(msg, "rate limit") {
+		c.rateLimited.Store(time.Now().UnixNano())
+	}
+}
@@ -0,0 +1,158 @@
+package auth
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Roles incluidos en la política por defecto
+const (
+	RoleViewer   = "viewer"
+	RoleEditor   = "editor"
+	RoleOperator = "operator"
+	RoleAdmin    = "admin"
+)
+
+const (
+	// PermissionPublic marca rutas que no requieren token
+	PermissionPublic = "public"
+	// PermissionAll concede cualquier permiso al rol que lo tenga
+	PermissionAll = "*"
+	// PermissionDebugTrace permite pedir la traza de ejecución en las respuestas
+	PermissionDebugTrace = "debug:trace"
+)
+
+// routeScopedPermissions son los permisos que una API key cubre con el scope
+// de su ruta (bots:read, incoming:write); el resto exige el propio permiso
+// como scope de la clave
+var routeScopedPermissions = map[string]bool{
+	"read":               true,
+	"bots:write":         true,
+	"conversations:send": true,
+	"tests:run":          true,
+	"sandbox:use":        true,
+}
+
+// APIKeyScope devuelve el scope que una API key necesita, además del de su
+// ruta, para una ruta que exige permission; "" si basta el de la ruta
+func APIKeyScope(permission string) string {
+	if routeScopedPermissions[permission] {
+		return ""
+	}
+	return permission
+}
+
+//go:embed rbac_policy.json
+var defaultPolicy []byte
+
+// RolePolicy son los permisos de un rol; Inherits añade los de otros roles
+type RolePolicy struct {
+	Inherits    []string `json:"inherits,omitempty"`
+	Permissions []string `json:"permissions"`
+}
+
+// RouteRule exige Permission en las rutas que coinciden con Method y Path.
+// Method "*" vale para cualquier método y un Path terminado en "/*" cubre
+// todas las rutas bajo ese prefijo; Path usa la sintaxis de Gin (/bots/:id).
+type RouteRule struct {
+	Method     string `json:"method"`
+	Path       string `json:"path"`
+	Permission string `json:"permission"`
+}
+
+// Policy define roles y reglas por ruta. Las reglas se evalúan en orden y
+// gana la primera que coincide.
+type Policy struct {
+	Roles  map[string]RolePolicy `json:"roles"`
+	Routes []RouteRule           `json:"routes"`
+
+	grants map[string]map[string]bool
+}
+
+// LoadPolicy lee la política de path o, si path está vacío, usa la incluida
+// en el binario (rbac_policy.json)
+func LoadPolicy(path string) (*Policy, error) {
+	data := defaultPolicy
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read RBAC policy: %w", err)
+		}
+	}
+	return ParsePolicy(data)
+}
+
+// ParsePolicy valida la política y resuelve la herencia de roles
+func ParsePolicy(data []byte) (*Policy, error) {
+	var policy Policy
+	if err := json.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("invalid RBAC policy: %w", err)
+	}
+
+	for i, rule := range policy.Routes {
+		if rule.Method == "" || rule.Path == "" || rule.Permission == "" {
+			return nil, fmt.Errorf("invalid RBAC policy: route %d needs method, path and permission", i)
+		}
+		policy.Routes[i].Method = strings.ToUpper(rule.Method)
+	}
+
+	policy.grants = make(map[string]map[string]bool, len(policy.Roles))
+	for role := range policy.Roles {
+		grants := make(map[string]bool)
+		if err := policy.collect(role, grants, map[string]bool{}); err != nil {
+			return nil, err
+		}
+		policy.grants[role] = grants
+	}
+	return &policy, nil
+}
+
+func (p *Policy) collect(role string, grants, visiting map[string]bool) error {
+	if visiting[role] {
+		return fmt.Errorf("invalid RBAC policy: role %s inherits from itself", role)
+	}
+	def, ok := p.Roles[role]
+	if !ok {
+		return fmt.Errorf("invalid RBAC policy: unknown role %s", role)
+	}
+
+	visiting[role] = true
+	defer delete(visiting, role)
+	for _, permission := range def.Permissions {
+		grants[permission] = true
+	}
+	for _, parent := range def.Inherits {
+		if err := p.collect(parent, grants, visiting); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Permission devuelve el permiso que exige la ruta; false si ninguna regla la cubre
+func (p *Policy) Permission(method, path string) (string, bool) {
+	for _, rule := range p.Routes {
+		if rule.Method != "*" && rule.Method != method {
+			continue
+		}
+		if rule.Path == path || (strings.HasSuffix(rule.Path, "/*") && strings.HasPrefix(path, strings.TrimSuffix(rule.Path, "*"))) {
+			return rule.Permission, true
+		}
+	}
+	return "", false
+}
+
+// Allowed indica si alguno de los roles concede permission. Los roles que no
+// están en la política no conceden nada.
+func (p *Policy) Allowed(roles []string, permission string) bool {
+	for _, role := range roles {
+		grants := p.grants[role]
+		if grants[PermissionAll] || grants[permission] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// Package chaos inyecta latencia, errores y timeouts en las ejecuciones de
+// agentes y en las llamadas a adaptadores para validar reintentos, breakers y
+// fallbacks. Solo se activa fuera de producción (ver main.go).
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Tipos de fallo inyectado
+const (
+	FaultLatency = "latency"
+	FaultError   = "error"
+	FaultTimeout = "timeout"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// ErrInjected permite distinguir con errors.Is los fallos inyectados de los reales
+var ErrInjected = errors.New("chaos: injected fault")
+
+// Fault es el error de un fallo inyectado. Los timeouts también cumplen
+// errors.Is(err, context.DeadlineExceeded), como un timeout real.
+type Fault struct {
+	Kind   string
+	Target string
+}
+
+func (f *Fault) Error() string {
+	return fmt.Sprintf("chaos: injected %s in %s", f.Kind, f.Target)
+}
+
+func (f *Fault) Is(target error) bool {
+	return target == ErrInjected || (f.Kind == FaultTimeout && target == context.DeadlineExceeded)
+}
+
+// Config define la probabilidad (0-1) de cada fallo. Targets son prefijos de
+// destino como "agent:" o "adapter:trigger-actions"; vacío afecta a todos.
+type Config struct {
+	LatencyRate float64
+	MaxLatency  time.Duration
+	ErrorRate   float64
+	TimeoutRate float64
+	// Timeout es lo que espera un timeout inyectado si el contexto no vence antes
+	Timeout time.Duration
+	Targets []string
+	Seed    int64
+}
+
+// Injector decide al azar qué llamadas fallan según Config
+type Injector struct {
+	config Config
+	logger logger.Logger
+	mu     sync.Mutex
+	rand   *rand.Rand
+}
+
+// NewInjector crea un inyector; con Seed 0 la secuencia depende de la hora
+func NewInjector(config Config, logger logger.Logger) *Injector {
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &Injector{
+		config: config,
+		logger: logger,
+		rand:   rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Inject se llama antes de ejecutar la llamada real a target. Puede esperar
+// (latencia), devolver un *Fault de error o bloquear hasta el timeout.
+// Un nil deja continuar la llamada.
+func (i *Injector) Inject(ctx context.Context, target string) error {
+	if i == nil || !i.applies(target) {
+		return nil
+	}
+
+	switch {
+	case i.roll(i.config.TimeoutRate):
+		i.record(ctx, target, FaultTimeout)
+		timer := time.NewTimer(i.config.Timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+		}
+		return &Fault{Kind: FaultTimeout, Target: target}
+
+	case i.roll(i.config.ErrorRate):
+		i.record(ctx, target, FaultError)
+		return &Fault{Kind: FaultError, Target: target}
+
+	case i.config.MaxLatency > 0 && i.roll(i.config.LatencyRate):
+		i.record(ctx, target, FaultLatency)
+		i.mu.Lock()
+		delay := time.Duration(i.rand.Int63n(int64(i.config.MaxLatency)) + 1)
+		i.mu.Unlock()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func (i *Injector) applies(target string) bool {
+	if len(i.config.Targets) == 0 {
+		return true
+	}
+	for _, prefix := range i.config.Targets {
+		if strings.HasPrefix(target, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *Injector) roll(rate float64) bool {
+	if rate <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rand.Float64() < rate
+}
+
+func (i *Injector) record(ctx context.Context, target, fault string) {
+	metrics.ChaosFaults.WithLabelValues(target, fault).Inc()
+	i.logger.WithContext(ctx).Debug("Chaos fault injected", "target", target, "fault", fault)
+}
@@ -0,0 +1,62 @@
+package chaos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/chaos"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInjector_Faults(t *testing.T) {
+	log := logger.NewLogger("error")
+	ctx := context.Background()
+
+	failing := chaos.NewInjector(chaos.Config{ErrorRate: 1, Targets: []string{"agent:"}}, log)
+	err := failing.Inject(ctx, "agent:ai")
+	assert.True(t, errors.Is(err, chaos.ErrInjected))
+	assert.False(t, errors.Is(err, context.DeadlineExceeded))
+	// Fuera de los targets no se inyecta nada
+	assert.NoError(t, failing.Inject(ctx, "adapter:trigger-actions"))
+
+	timeouts := chaos.NewInjector(chaos.Config{TimeoutRate: 1, Timeout: time.Hour}, log)
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = timeouts.Inject(short, "adapter:http")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), time.Second)
+
+	slow := chaos.NewInjector(chaos.Config{LatencyRate: 1, MaxLatency: 10 * time.Millisecond, Seed: 1}, log)
+	assert.NoError(t, slow.Inject(ctx, "agent:http"))
+
+	var disabled *chaos.Injector
+	assert.NoError(t, disabled.Inject(ctx, "agent:ai"))
+}
+
+func TestWithFaultInjection_WrapsAgents(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	faults := chaos.NewInjector(chaos.Config{ErrorRate: 1, Targets: []string{"agent:http"}}, log)
+	orchestrator := mcp.NewOrchestrator(mcp.WithFaultInjection(mcp.NewAgentFactory(log), faults), log)
+
+	agent, err := orchestrator.InstantiateMCP(ctx, mcp.MCPConfig{Type: "http", Name: "api", Version: "1.0", Config: map[string]interface{}{"base_url": "http://localhost:1"}})
+	require.NoError(t, err)
+
+	result, err := agent.Execute(ctx, mcp.Task{ID: "t1", Type: "http_request"})
+	assert.True(t, errors.Is(err, chaos.ErrInjected))
+	assert.False(t, result.Success)
+
+	// El envoltorio no oculta las capacidades opcionales del agente
+	_, err = orchestrator.UpdateAgentConfig(ctx, agent.GetID(), map[string]interface{}{"timeout": "5s"})
+	assert.NoError(t, err)
+
+	_, err = orchestrator.ExecuteTask(ctx, mcp.Task{ID: "t2", Type: "http_request"})
+	assert.True(t, errors.Is(err, chaos.ErrInjected))
+	assert.NotEmpty(t, agent.GetHistory().Errors)
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -10,10 +11,32 @@ import (
 type Config struct {
 	Environment string
 	Port        string
-	LogLevel    string
-	VaultConfig VaultConfig
-	Database    DatabaseConfig
-	ExternalAPI ExternalAPIConfig
+	// GRPCPort vacío desactiva el servidor gRPC
+	GRPCPort     string
+	LogLevel     string
+	VaultConfig  VaultConfig
+	Secrets      SecretsConfig
+	Database     DatabaseConfig
+	ExternalAPI  ExternalAPIConfig
+	Tasks        TasksConfig
+	Triggers     TriggersConfig
+	Storage      StorageConfig
+	Channels     ChannelsConfig
+	Replication  ReplicationConfig
+	Chaos        ChaosConfig
+	Auth         AuthConfig
+	APIKeys      APIKeysConfig
+	Backpressure BackpressureConfig
+	Incoming     IncomingConfig
+	Webhooks     WebhooksConfig
+	Sandbox      SandboxConfig
+	Email        EmailConfig
+	EventBroker  EventBrokerConfig
+	Capture      ExecutionCaptureConfig
+	Memory       MemoryConfig
+	Orchestrator OrchestratorConfig
+	Usage        UsageConfig
+	Cache        CacheConfig
 }
 
 type VaultConfig struct {
@@ -22,6 +45,132 @@ type VaultConfig struct {
 	Path    string
 }
 
+// SecretsConfig elige de dónde salen las referencias ${secret:nombre} de la
+// configuración de agentes, headers HTTP y credenciales de base de datos
+type SecretsConfig struct {
+	// Provider es env (por defecto) o vault
+	Provider string
+	// RefreshIntervalSeconds es cada cuánto se releen para detectar rotaciones; 0 no los relee
+	RefreshIntervalSeconds int
+	// HTTPAuthProfilesFile es el JSON con los perfiles OAuth2, API key y basic
+	// que usan las llamadas HTTP por nombre (auth_profile); vacío no carga ninguno
+	HTTPAuthProfilesFile string
+}
+
+// AuthConfig activa JWT y la política RBAC en todas las rutas no públicas
+type AuthConfig struct {
+	Enabled   bool
+	JWTSecret string
+	JWTIssuer string
+	// RBACPolicyFile sustituye la política incluida en el binario (internal/auth/rbac_policy.json)
+	RBACPolicyFile string
+}
+
+// APIKeysConfig activa las API keys para llamadas entre servicios
+type APIKeysConfig struct {
+	Enabled bool
+	// RequiredRoutes son prefijos de ruta que exigen API key (o JWT con AUTH_ENABLED)
+	RequiredRoutes []string
+	// DefaultRateLimit son las peticiones por minuto de las claves emitidas sin límite propio
+	DefaultRateLimit int
+}
+
+// WebhooksConfig controla la entrega de eventos a las suscripciones de webhooks
+type WebhooksConfig struct {
+	Workers   int
+	QueueSize int
+	// MaxAttempts incluye el primer intento; el backoff se duplica desde
+	// InitialBackoffMs hasta MaxBackoffMs
+	MaxAttempts      int
+	InitialBackoffMs int
+	MaxBackoffMs     int
+	TimeoutSeconds   int
+}
+
+// IncomingConfig controla POST /incoming/batch: conversaciones procesadas en
+// paralelo y máximo de mensajes por batch
+type IncomingConfig struct {
+	BatchConcurrency int
+	BatchMaxSize     int
+}
+
+// SandboxConfig controla los bots efímeros de POST /sandbox/bots
+type SandboxConfig struct {
+	Enabled         bool
+	DefaultTTLHours int
+	MaxTTLHours     int
+	// MaxBots limita los bots de sandbox vivos en total y MaxBotsPerCaller por usuario o API key
+	MaxBots                int
+	MaxBotsPerCaller       int
+	CleanupIntervalMinutes int
+}
+
+// EmailConfig activa el canal de email: webhooks de entrada de SendGrid/SES,
+// polling IMAP opcional y respuestas por SMTP
+type EmailConfig struct {
+	Enabled      bool
+	FromAddress  string
+	FromName     string
+	DefaultBotID string
+	// InboundToken protege los webhooks de entrada (?token=); sin él no se
+	// registran
+	InboundToken string
+	// SESTopicARN restringe el webhook de SES a ese topic SNS
+	SESTopicARN string
+	// SMTPHost vacío sólo registra las respuestas en el log
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	// IMAPAddress (host:puerto) vacío desactiva el polling del buzón
+	IMAPAddress             string
+	IMAPUsername            string
+	IMAPPassword            string
+	IMAPMailbox             string
+	IMAPTLS                 bool
+	IMAPPollIntervalSeconds int
+}
+
+// ExecutionCaptureConfig controla la captura de tareas de agentes de los bots
+// que la activan en execution_capture
+type ExecutionCaptureConfig struct {
+	Enabled           bool
+	DefaultSampleRate float64
+	RetentionHours    int
+	// MaxRecords limita las ejecuciones guardadas; se descartan las más antiguas
+	MaxRecords     int
+	MaxFieldLength int
+}
+
+// EventBrokerConfig publica los eventos de conversación en Kafka o NATS
+type EventBrokerConfig struct {
+	// Driver es none (por defecto), nats o kafka (a través del Kafka REST Proxy)
+	Driver string
+	URL    string
+	// Topic es el topic de Kafka o el prefijo de los subjects de NATS
+	Topic string
+	// Events limita los tipos publicados; vacío usa la lista por defecto
+	Events    []string
+	QueueSize int
+}
+
+// BackpressureConfig rechaza mensajes entrantes con 503 cuando la cola de
+// tareas o el proveedor de IA están saturados
+type BackpressureConfig struct {
+	Enabled bool
+	// Routes son prefijos de ruta (POST) a los que se aplica
+	Routes []string
+	// RejectThreshold es la carga (0-1) a partir de la que se rechaza todo
+	RejectThreshold            float64
+	AIMaxInFlight              int
+	AIRateLimitCooldownSeconds int
+	// ShedLowPriority rechaza desde LowPriorityThreshold los bots fuera de PriorityBots
+	ShedLowPriority      bool
+	LowPriorityThreshold float64
+	PriorityBots         []string
+	RetryAfterSeconds    int
+}
+
 type DatabaseConfig struct {
 	Host     string
 	Port     string
@@ -31,12 +180,130 @@ type DatabaseConfig struct {
 	SSLMode  string
 }
 
+// OrchestratorConfig ajusta la cola de tareas MCP que esperan un agente libre
+// y los circuit breakers que dejan de enviar tareas a los agentes que fallan
+type OrchestratorConfig struct {
+	// QueueMaxDepth es el máximo de tareas en espera por tipo; negativo desactiva la cola
+	QueueMaxDepth      int
+	QueueWaitTimeoutMs int
+	// BreakerFailureRate es la fracción de fallos que abre el circuito; negativo lo desactiva
+	BreakerFailureRate   float64
+	BreakerMinRequests   int
+	BreakerWindowSize    int
+	BreakerOpenTimeoutMs int
+	// AgentSnapshotContext guarda también el contexto de los agentes persistentes
+	AgentSnapshotContext         bool
+	AgentSnapshotIntervalSeconds int
+	// MCPServerCommands son los comandos que pueden lanzar los agentes
+	// mcp_client con transporte stdio; vacío solo permite sse
+	MCPServerCommands []string
+}
+
+// MemoryConfig controla la búsqueda semántica de memorias de usuario
+type MemoryConfig struct {
+	// VectorStore es hnsw (en memoria, por defecto), pgvector o none para
+	// buscar solo por texto
+	VectorStore         string
+	EmbeddingModel      string
+	EmbeddingDimensions int
+	MinScore            float64
+	// PGVectorDriver es el driver de database/sql; el binario incluye pgx
+	PGVectorDriver string
+	PGVectorTable  string
+}
+
+// UsageConfig ajusta la estimación del coste de IA de los bots
+type UsageConfig struct {
+	// ModelPrices son entradas modelo=prompt:completion en USD por 1K tokens
+	// que sustituyen o amplían los precios incluidos
+	ModelPrices []string
+}
+
+// CacheConfig elige dónde se guardan las respuestas de los pasos con cache_ttl
+type CacheConfig struct {
+	// Driver es memory (por defecto), redis o none
+	Driver     string
+	RedisURL   string
+	MaxEntries int
+}
+
 type ExternalAPIConfig struct {
 	BaseURL string
 	APIKey  string
 	Timeout int
 }
 
+type TasksConfig struct {
+	Workers        int
+	QueueSize      int
+	RetentionHours int
+	// TypeConcurrency limita las tareas simultáneas por tipo (tipo=máximo)
+	TypeConcurrency []string
+	// MaxWorkers > 0 activa el autoescalado entre MinWorkers y MaxWorkers
+	MinWorkers             int
+	MaxWorkers             int
+	ScaleIntervalSeconds   int
+	ScaleTargetWaitSeconds int
+	// Store es dónde se persisten las tareas: embedded (por defecto, en
+	// StorePath o en el almacén de STORAGE_DRIVER=embedded), postgres (DB_*)
+	// o memory, que las pierde al reiniciar
+	Store      string
+	StorePath  string
+	StoreTable string
+}
+
+type TriggersConfig struct {
+	SchedulerIntervalSeconds int
+}
+
+type StorageConfig struct {
+	ObjectStoreDir string
+	PublicBaseURL  string
+	// Driver es memory (por defecto) o embedded para persistir en EmbeddedPath
+	Driver       string
+	EmbeddedPath string
+	// DatabaseAdaptersFile lista las bases de datos externas que consultan los
+	// flujos (adaptadores database); vacío no registra ninguna
+	DatabaseAdaptersFile string
+	// QueueAdaptersFile lista las colas (adaptadores message_queue) en las
+	// que publican los flujos
+	QueueAdaptersFile string
+}
+
+// ChannelsConfig contiene las credenciales de los canales que se validan en /health
+type ChannelsConfig struct {
+	WhatsAppToken         string
+	WhatsAppPhoneNumberID string
+	SlackBotToken         string
+	TelegramBotToken      string
+	// ProbesCritical hace que un canal con credenciales inválidas marque el servicio como no listo
+	ProbesCritical bool
+}
+
+// ReplicationConfig activa la réplica asíncrona de sesiones y memoria entre regiones
+type ReplicationConfig struct {
+	Enabled bool
+	Region  string
+	// Role es primary (acepta escrituras y las replica) o secondary (las aplica)
+	Role      string
+	QueueSize int
+	// BotIDs limita la réplica a esos bots; vacío replica todos
+	BotIDs []string
+}
+
+// ChaosConfig inyecta fallos en agentes y adaptadores; se ignora en producción
+type ChaosConfig struct {
+	Enabled bool
+	// Probabilidades entre 0 y 1 de cada tipo de fallo por llamada
+	LatencyRate  float64
+	MaxLatencyMs int
+	ErrorRate    float64
+	TimeoutRate  float64
+	TimeoutMs    int
+	// Targets son prefijos como "agent:ai" o "adapter:"; vacío afecta a todos
+	Targets []string
+}
+
 func Load() *Config {
 	// Cargar variables de entorno desde .env si existe
 	_ = godotenv.Load()
@@ -44,12 +311,92 @@ func Load() *Config {
 	return &Config{
 		Environment: getEnv("ENVIRONMENT", "development"),
 		Port:        getEnv("IT_BOT_SERVICE_PORT", "8084"),
+		GRPCPort:    getEnv("IT_BOT_SERVICE_GRPC_PORT", "9084"),
 		LogLevel:    getEnv("LOG_LEVEL", "info"),
 		VaultConfig: VaultConfig{
 			Address: getEnv("VAULT_ADDR", "http://localhost:8200"),
 			Token:   getEnv("VAULT_TOKEN", ""),
 			Path:    getEnv("VAULT_PATH", "secret/microservice"),
 		},
+		Secrets: SecretsConfig{
+			Provider:               getEnv("SECRETS_PROVIDER", "env"),
+			RefreshIntervalSeconds: getEnvAsInt("SECRETS_REFRESH_INTERVAL_SECONDS", 300),
+			HTTPAuthProfilesFile:   getEnv("HTTP_AUTH_PROFILES_FILE", ""),
+		},
+		Auth: AuthConfig{
+			Enabled:        getEnvAsBool("AUTH_ENABLED", false),
+			JWTSecret:      getEnv("JWT_SECRET", ""),
+			JWTIssuer:      getEnv("JWT_ISSUER", "bot-service"),
+			RBACPolicyFile: getEnv("RBAC_POLICY_FILE", ""),
+		},
+		APIKeys: APIKeysConfig{
+			Enabled:          getEnvAsBool("API_KEYS_ENABLED", false),
+			RequiredRoutes:   getEnvAsListOr("API_KEYS_REQUIRED_ROUTES", []string{"/api/v1/incoming"}),
+			DefaultRateLimit: getEnvAsInt("API_KEYS_DEFAULT_RATE_LIMIT", 600),
+		},
+		Backpressure: BackpressureConfig{
+			Enabled:                    getEnvAsBool("BACKPRESSURE_ENABLED", true),
+			Routes:                     getEnvAsListOr("BACKPRESSURE_ROUTES", []string{"/api/v1/incoming"}),
+			RejectThreshold:            getEnvAsFloat("BACKPRESSURE_REJECT_THRESHOLD", 0.9),
+			AIMaxInFlight:              getEnvAsInt("BACKPRESSURE_AI_MAX_IN_FLIGHT", 50),
+			AIRateLimitCooldownSeconds: getEnvAsInt("BACKPRESSURE_AI_RATE_LIMIT_COOLDOWN_SECONDS", 30),
+			ShedLowPriority:            getEnvAsBool("BACKPRESSURE_SHED_LOW_PRIORITY", false),
+			LowPriorityThreshold:       getEnvAsFloat("BACKPRESSURE_LOW_PRIORITY_THRESHOLD", 0.7),
+			PriorityBots:               getEnvAsList("BACKPRESSURE_PRIORITY_BOTS"),
+			RetryAfterSeconds:          getEnvAsInt("BACKPRESSURE_RETRY_AFTER_SECONDS", 5),
+		},
+		Sandbox: SandboxConfig{
+			Enabled:                getEnvAsBool("SANDBOX_ENABLED", true),
+			DefaultTTLHours:        getEnvAsInt("SANDBOX_DEFAULT_TTL_HOURS", 24),
+			MaxTTLHours:            getEnvAsInt("SANDBOX_MAX_TTL_HOURS", 72),
+			MaxBots:                getEnvAsInt("SANDBOX_MAX_BOTS", 200),
+			MaxBotsPerCaller:       getEnvAsInt("SANDBOX_MAX_BOTS_PER_CALLER", 5),
+			CleanupIntervalMinutes: getEnvAsInt("SANDBOX_CLEANUP_INTERVAL_MINUTES", 5),
+		},
+		Email: EmailConfig{
+			Enabled:                 getEnvAsBool("EMAIL_ENABLED", false),
+			FromAddress:             getEnv("EMAIL_FROM_ADDRESS", ""),
+			FromName:                getEnv("EMAIL_FROM_NAME", ""),
+			DefaultBotID:            getEnv("EMAIL_DEFAULT_BOT_ID", ""),
+			InboundToken:            getEnv("EMAIL_INBOUND_TOKEN", ""),
+			SESTopicARN:             getEnv("EMAIL_SES_TOPIC_ARN", ""),
+			SMTPHost:                getEnv("EMAIL_SMTP_HOST", ""),
+			SMTPPort:                getEnv("EMAIL_SMTP_PORT", "587"),
+			SMTPUsername:            getEnv("EMAIL_SMTP_USERNAME", ""),
+			SMTPPassword:            getEnv("EMAIL_SMTP_PASSWORD", ""),
+			IMAPAddress:             getEnv("EMAIL_IMAP_ADDRESS", ""),
+			IMAPUsername:            getEnv("EMAIL_IMAP_USERNAME", ""),
+			IMAPPassword:            getEnv("EMAIL_IMAP_PASSWORD", ""),
+			IMAPMailbox:             getEnv("EMAIL_IMAP_MAILBOX", "INBOX"),
+			IMAPTLS:                 getEnvAsBool("EMAIL_IMAP_TLS", true),
+			IMAPPollIntervalSeconds: getEnvAsInt("EMAIL_IMAP_POLL_INTERVAL_SECONDS", 60),
+		},
+		EventBroker: EventBrokerConfig{
+			Driver:    getEnv("EVENT_BROKER", "none"),
+			URL:       getEnv("EVENT_BROKER_URL", ""),
+			Topic:     getEnv("EVENT_BROKER_TOPIC", "bot-service.events"),
+			Events:    getEnvAsList("EVENT_BROKER_EVENTS"),
+			QueueSize: getEnvAsInt("EVENT_BROKER_QUEUE_SIZE", 10000),
+		},
+		Capture: ExecutionCaptureConfig{
+			Enabled:           getEnvAsBool("EXECUTION_CAPTURE_ENABLED", true),
+			DefaultSampleRate: getEnvAsFloat("EXECUTION_CAPTURE_DEFAULT_SAMPLE_RATE", 0.1),
+			RetentionHours:    getEnvAsInt("EXECUTION_CAPTURE_RETENTION_HOURS", 72),
+			MaxRecords:        getEnvAsInt("EXECUTION_CAPTURE_MAX_RECORDS", 50000),
+			MaxFieldLength:    getEnvAsInt("EXECUTION_CAPTURE_MAX_FIELD_LENGTH", 4000),
+		},
+		Incoming: IncomingConfig{
+			BatchConcurrency: getEnvAsInt("INCOMING_BATCH_CONCURRENCY", 8),
+			BatchMaxSize:     getEnvAsInt("INCOMING_BATCH_MAX_SIZE", 500),
+		},
+		Webhooks: WebhooksConfig{
+			Workers:          getEnvAsInt("WEBHOOK_WORKERS", 4),
+			QueueSize:        getEnvAsInt("WEBHOOK_QUEUE_SIZE", 1000),
+			MaxAttempts:      getEnvAsInt("WEBHOOK_MAX_ATTEMPTS", 6),
+			InitialBackoffMs: getEnvAsInt("WEBHOOK_INITIAL_BACKOFF_MS", 1000),
+			MaxBackoffMs:     getEnvAsInt("WEBHOOK_MAX_BACKOFF_MS", 300000),
+			TimeoutSeconds:   getEnvAsInt("WEBHOOK_TIMEOUT_SECONDS", 10),
+		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			Port:     getEnv("DB_PORT", "5432"),
@@ -58,11 +405,85 @@ func Load() *Config {
 			Name:     getEnv("DB_NAME", "it_bot_service"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 		},
+		Orchestrator: OrchestratorConfig{
+			QueueMaxDepth:                getEnvAsInt("MCP_QUEUE_MAX_DEPTH", 100),
+			QueueWaitTimeoutMs:           getEnvAsInt("MCP_QUEUE_WAIT_TIMEOUT_MS", 30000),
+			BreakerFailureRate:           getEnvAsFloat("MCP_BREAKER_FAILURE_RATE", 0.5),
+			BreakerMinRequests:           getEnvAsInt("MCP_BREAKER_MIN_REQUESTS", 5),
+			BreakerWindowSize:            getEnvAsInt("MCP_BREAKER_WINDOW_SIZE", 20),
+			BreakerOpenTimeoutMs:         getEnvAsInt("MCP_BREAKER_OPEN_TIMEOUT_MS", 30000),
+			AgentSnapshotContext:         getEnvAsBool("MCP_AGENT_SNAPSHOT_CONTEXT", false),
+			AgentSnapshotIntervalSeconds: getEnvAsInt("MCP_AGENT_SNAPSHOT_INTERVAL_SECONDS", 60),
+			MCPServerCommands:            getEnvAsList("MCP_SERVER_COMMANDS"),
+		},
+		Memory: MemoryConfig{
+			VectorStore:         getEnv("MEMORY_VECTOR_STORE", "hnsw"),
+			EmbeddingModel:      getEnv("MEMORY_EMBEDDING_MODEL", ""),
+			EmbeddingDimensions: getEnvAsInt("MEMORY_EMBEDDING_DIMENSIONS", 1536),
+			MinScore:            getEnvAsFloat("MEMORY_MIN_SCORE", 0.3),
+			PGVectorDriver:      getEnv("MEMORY_PGVECTOR_DRIVER", "pgx"),
+			PGVectorTable:       getEnv("MEMORY_PGVECTOR_TABLE", "memory_embeddings"),
+		},
+		Usage: UsageConfig{
+			ModelPrices: getEnvAsList("AI_MODEL_PRICES"),
+		},
+		Cache: CacheConfig{
+			Driver:     getEnv("RESPONSE_CACHE_DRIVER", "memory"),
+			RedisURL:   getEnv("RESPONSE_CACHE_REDIS_URL", "redis://localhost:6379/0"),
+			MaxEntries: getEnvAsInt("RESPONSE_CACHE_MAX_ENTRIES", 10000),
+		},
 		ExternalAPI: ExternalAPIConfig{
 			BaseURL: getEnv("IT_INTEGRATION_SERVICE_URL", "http://localhost:8080"),
 			APIKey:  getEnv("EXTERNAL_API_KEY", ""),
 			Timeout: getEnvAsInt("EXTERNAL_API_TIMEOUT", 30),
 		},
+		Tasks: TasksConfig{
+			Workers:                getEnvAsInt("TASK_WORKERS", 5),
+			QueueSize:              getEnvAsInt("TASK_QUEUE_SIZE", 1000),
+			RetentionHours:         getEnvAsInt("TASK_RETENTION_HOURS", 168),
+			TypeConcurrency:        getEnvAsList("TASK_TYPE_CONCURRENCY"),
+			MinWorkers:             getEnvAsInt("TASK_MIN_WORKERS", 1),
+			MaxWorkers:             getEnvAsInt("TASK_MAX_WORKERS", 0),
+			ScaleIntervalSeconds:   getEnvAsInt("TASK_SCALE_INTERVAL_SECONDS", 10),
+			ScaleTargetWaitSeconds: getEnvAsInt("TASK_SCALE_TARGET_WAIT_SECONDS", 5),
+			Store:                  getEnv("TASK_STORE", "embedded"),
+			StorePath:              getEnv("TASK_STORE_PATH", "./data/tasks.db"),
+			StoreTable:             getEnv("TASK_STORE_TABLE", "async_tasks"),
+		},
+		Triggers: TriggersConfig{
+			SchedulerIntervalSeconds: getEnvAsInt("TRIGGER_SCHEDULER_INTERVAL_SECONDS", 30),
+		},
+		Storage: StorageConfig{
+			ObjectStoreDir:       getEnv("OBJECT_STORE_DIR", "./data/objects"),
+			PublicBaseURL:        getEnv("OBJECT_STORE_PUBLIC_URL", ""),
+			Driver:               getEnv("STORAGE_DRIVER", "memory"),
+			EmbeddedPath:         getEnv("EMBEDDED_STORE_PATH", "./data/bot-service.db"),
+			DatabaseAdaptersFile: getEnv("DATABASE_ADAPTERS_FILE", ""),
+			QueueAdaptersFile:    getEnv("QUEUE_ADAPTERS_FILE", ""),
+		},
+		Channels: ChannelsConfig{
+			WhatsAppToken:         getEnv("WHATSAPP_ACCESS_TOKEN", ""),
+			WhatsAppPhoneNumberID: getEnv("WHATSAPP_PHONE_NUMBER_ID", ""),
+			SlackBotToken:         getEnv("SLACK_BOT_TOKEN", ""),
+			TelegramBotToken:      getEnv("TELEGRAM_BOT_TOKEN", ""),
+			ProbesCritical:        getEnvAsBool("HEALTH_CHANNELS_CRITICAL", false),
+		},
+		Replication: ReplicationConfig{
+			Enabled:   getEnvAsBool("IT_BOT_SERVICE_REPLICATION_ENABLED", false),
+			Region:    getEnv("IT_BOT_SERVICE_REPLICATION_REGION", ""),
+			Role:      getEnv("IT_BOT_SERVICE_REPLICATION_ROLE", "primary"),
+			QueueSize: getEnvAsInt("IT_BOT_SERVICE_REPLICATION_QUEUE_SIZE", 1000),
+			BotIDs:    getEnvAsList("IT_BOT_SERVICE_REPLICATION_BOT_IDS"),
+		},
+		Chaos: ChaosConfig{
+			Enabled:      getEnvAsBool("CHAOS_ENABLED", false),
+			LatencyRate:  getEnvAsFloat("CHAOS_LATENCY_RATE", 0),
+			MaxLatencyMs: getEnvAsInt("CHAOS_MAX_LATENCY_MS", 2000),
+			ErrorRate:    getEnvAsFloat("CHAOS_ERROR_RATE", 0),
+			TimeoutRate:  getEnvAsFloat("CHAOS_TIMEOUT_RATE", 0),
+			TimeoutMs:    getEnvAsInt("CHAOS_TIMEOUT_MS", 30000),
+			Targets:      getEnvAsList("CHAOS_TARGETS"),
+		},
 	}
 }
 
@@ -80,4 +501,40 @@ func getEnvAsInt(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
-}
\ No newline at end of file
+}
+
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsListOr usa defaultValue sólo si la variable no está definida; vacía da una lista vacía
+func getEnvAsListOr(key string, defaultValue []string) []string {
+	if _, ok := os.LookupEnv(key); !ok {
+		return defaultValue
+	}
+	return getEnvAsList(key)
+}
+
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
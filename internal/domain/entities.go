@@ -17,16 +17,165 @@ type User struct {
 
 // AuditLog representa un registro de auditoría
 type AuditLog struct {
-	ID        string                 `json:"id" db:"id"`
-	UserID    string                 `json:"user_id" db:"user_id"`
-	Action    string                 `json:"action" db:"action"`
-	Resource  string                 `json:"resource" db:"resource"`
-	Details   map[string]interface{} `json:"details" db:"details"`
-	IPAddress string                 `json:"ip_address" db:"ip_address"`
-	UserAgent string                 `json:"user_agent" db:"user_agent"`
-	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+	ID         string                 `json:"id" db:"id"`
+	UserID     string                 `json:"user_id" db:"user_id"`
+	Action     string                 `json:"action" db:"action"`
+	Resource   string                 `json:"resource" db:"resource"`
+	ResourceID string                 `json:"resource_id,omitempty" db:"resource_id"`
+	Details    map[string]interface{} `json:"details" db:"details"`
+	IPAddress  string                 `json:"ip_address" db:"ip_address"`
+	UserAgent  string                 `json:"user_agent" db:"user_agent"`
+	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
 }
 
+// APIKey es una credencial para llamadas entre servicios (p. ej. messaging-service
+// contra /incoming). Sólo se guarda el hash SHA-256 de la clave; el valor en
+// claro se devuelve una única vez al emitirla.
+type APIKey struct {
+	ID         string     `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Prefix     string     `json:"prefix" db:"prefix"`
+	KeyHash    string     `json:"key_hash,omitempty" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"scopes"`
+	RateLimit  int        `json:"rate_limit" db:"rate_limit"` // peticiones por minuto; 0 = sin límite
+	CreatedBy  string     `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+}
+
+// WebhookSubscription suscribe una URL externa a eventos del servicio. Cada
+// entrega se firma con HMAC-SHA256 usando Secret, que sólo se devuelve al crearla.
+type WebhookSubscription struct {
+	ID     string   `json:"id" db:"id"`
+	Name   string   `json:"name" db:"name"`
+	URL    string   `json:"url" db:"url"`
+	Events []string `json:"events" db:"events"`
+	// BotID limita la suscripción a los eventos de ese bot; vacío recibe todos
+	BotID     string    `json:"bot_id,omitempty" db:"bot_id"`
+	Secret    string    `json:"secret,omitempty" db:"secret"`
+	Active    bool      `json:"active" db:"active"`
+	CreatedBy string    `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// WebhookDeliveryStatus representa el estado de una entrega de webhook
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery registra el envío de un evento a una suscripción y el
+// resultado de su último intento
+type WebhookDelivery struct {
+	ID             string                `json:"id" db:"id"`
+	SubscriptionID string                `json:"subscription_id" db:"subscription_id"`
+	EventID        string                `json:"event_id" db:"event_id"`
+	EventType      string                `json:"event_type" db:"event_type"`
+	Payload        json.RawMessage       `json:"payload" db:"payload"`
+	Status         WebhookDeliveryStatus `json:"status" db:"status"`
+	Attempts       int                   `json:"attempts" db:"attempts"`
+	ResponseStatus int                   `json:"response_status,omitempty" db:"response_status"`
+	ResponseBody   string                `json:"response_body,omitempty" db:"response_body"` // truncado
+	Error          string                `json:"error,omitempty" db:"error"`
+	Duration       int64                 `json:"duration" db:"duration"` // en milliseconds, del último intento
+	NextAttemptAt  *time.Time            `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	CreatedAt      time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+// AgentExecution es una ejecución capturada de una tarea de agente, con la
+// entrada y la salida ya redactadas
+type AgentExecution struct {
+	ID         string                 `json:"id" db:"id"`
+	AgentID    string                 `json:"agent_id" db:"agent_id"`
+	AgentType  string                 `json:"agent_type" db:"agent_type"`
+	TaskID     string                 `json:"task_id" db:"task_id"`
+	TaskType   string                 `json:"task_type" db:"task_type"`
+	BotID      string                 `json:"bot_id" db:"bot_id"`
+	SessionID  string                 `json:"session_id,omitempty" db:"session_id"`
+	Input      map[string]interface{} `json:"input" db:"input"`
+	Output     map[string]interface{} `json:"output,omitempty" db:"output"`
+	Success    bool                   `json:"success" db:"success"`
+	Error      string                 `json:"error,omitempty" db:"error"`
+	Duration   int64                  `json:"duration" db:"duration"` // en milliseconds
+	CapturedAt time.Time              `json:"captured_at" db:"captured_at"`
+}
+
+// AgentExecutionFilter acota la búsqueda de ejecuciones capturadas; los
+// campos vacíos no filtran
+type AgentExecutionFilter struct {
+	BotID     string
+	TaskType  string
+	AgentType string
+	From      *time.Time
+	To        *time.Time
+	Success   *bool
+	Limit     int
+	Offset    int
+}
+
+// BotDailyStats son los contadores de actividad de un bot en un día (UTC),
+// agregados desde los eventos de conversación
+type BotDailyStats struct {
+	BotID                  string          `json:"bot_id"`
+	Date                   string          `json:"date"` // YYYY-MM-DD
+	MessagesReceived       int             `json:"messages_received"`
+	MessagesSent           int             `json:"messages_sent"`
+	UserIDs                map[string]bool `json:"user_ids,omitempty"`
+	SessionsStarted        int             `json:"sessions_started"`
+	ConversationsCompleted int             `json:"conversations_completed"`
+	Handoffs               int             `json:"handoffs"`
+	Intents                map[string]int  `json:"intents,omitempty"`
+	ConfidenceSum          float64         `json:"confidence_sum"`
+	ConfidenceCount        int             `json:"confidence_count"`
+	SentimentSum           float64         `json:"sentiment_sum"`
+	SentimentCount         int             `json:"sentiment_count"`
+	NegativeMessages       int             `json:"negative_messages"`
+}
+
+// BotDailyUsage es el consumo de IA de un bot en un día (UTC), agregado desde
+// los resultados de los agentes
+type BotDailyUsage struct {
+	BotID            string `json:"bot_id"`
+	OwnerID          string `json:"owner_id,omitempty"`
+	Date             string `json:"date"` // YYYY-MM-DD
+	Requests         int    `json:"requests"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	// Cost es el coste estimado en USD con los precios vigentes al registrar
+	Cost   float64        `json:"cost"`
+	Models map[string]int `json:"models,omitempty"` // tokens por modelo
+	// BudgetBlocked cuenta las respuestas sustituidas por el presupuesto agotado
+	BudgetBlocked int `json:"budget_blocked"`
+}
+
+// Acciones y recursos registrados por la auditoría de la API
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	// Acciones de la moderación de contenido sobre mensajes
+	AuditActionFlag  = "flag"
+	AuditActionMask  = "mask"
+	AuditActionBlock = "block"
+
+	AuditResourceBot            = "bot"
+	AuditResourceFlow           = "flow"
+	AuditResourceStep           = "step"
+	AuditResourceTrigger        = "trigger"
+	AuditResourceMCPAgent       = "mcp_agent"
+	AuditResourceWebhook        = "webhook"
+	AuditResourceMessage        = "message"
+	AuditResourceWorkflow       = "workflow"
+	AuditResourceScriptApproval = "script_approval"
+)
+
 // Bot representa un bot conversacional
 type Bot struct {
 	ID        string          `json:"id" db:"id"`
@@ -39,6 +188,221 @@ type Bot struct {
 	UpdatedAt time.Time       `json:"updated_at" db:"updated_at"`
 }
 
+// BotConfig representa la configuración de comportamiento almacenada en Bot.Config
+type BotConfig struct {
+	GlobalIntents []GlobalIntent     `json:"global_intents,omitempty"`
+	Entities      []EntityDefinition `json:"entities,omitempty"`
+	VoiceNotes    *VoiceNoteConfig   `json:"voice_notes,omitempty"`
+	Translation   *TranslationConfig `json:"translation,omitempty"`
+	Summary       *SummaryConfig     `json:"summary,omitempty"`
+	// FlowMigration se aplica a los flujos sin migration_policy y a las
+	// sesiones cuyo flujo fue eliminado
+	FlowMigration *FlowMigrationPolicy `json:"flow_migration,omitempty"`
+	// Variables son constantes del bot (URLs, listas de SKUs, emails de
+	// soporte) disponibles en plantillas y configs como {{vars.<clave>}}
+	Variables map[string]interface{} `json:"variables,omitempty"`
+	// Sandbox marca los bots efímeros creados con POST /sandbox/bots
+	Sandbox *SandboxConfig `json:"sandbox,omitempty"`
+	Email   *EmailConfig   `json:"email,omitempty"`
+	// ExecutionCapture guarda muestras de las tareas de agentes del bot para depurar regresiones
+	ExecutionCapture *ExecutionCaptureConfig `json:"execution_capture,omitempty"`
+	// NLU ajusta la clasificación de intents de los pasos de IA
+	NLU *NLUConfig `json:"nlu,omitempty"`
+	// Sentiment puntúa el sentimiento de cada mensaje entrante
+	Sentiment *SentimentConfig `json:"sentiment,omitempty"`
+	// Moderation filtra el contenido no permitido de mensajes y respuestas de IA
+	Moderation *ModerationConfig `json:"moderation,omitempty"`
+	// AITools son las herramientas que el modelo puede pedir en los pasos de IA
+	AITools *AIToolsConfig `json:"ai_tools,omitempty"`
+	// AIProvider es el tipo de agente (ai, azure_openai, gemini, ollama) que
+	// genera las respuestas de IA del bot; vacío usa cualquiera
+	AIProvider string `json:"ai_provider,omitempty"`
+	// AIBudget limita el gasto mensual estimado en IA del bot
+	AIBudget *AIBudgetConfig `json:"ai_budget,omitempty"`
+}
+
+// AIBudgetConfig es el presupuesto mensual (mes natural UTC) de IA de un bot.
+// Agotado, los pasos de IA responden FallbackMessage sin llamar al modelo.
+type AIBudgetConfig struct {
+	MonthlyLimit    float64 `json:"monthly_limit"` // USD; 0 sin límite
+	FallbackMessage string  `json:"fallback_message,omitempty"`
+}
+
+// AIToolsConfig lista las herramientas del orquestador (tipos de tarea como
+// http_request o workflow y herramientas registradas como memory_search) que
+// se ofrecen al modelo
+type AIToolsConfig struct {
+	Tools         []string `json:"tools"`
+	MaxIterations int      `json:"max_iterations,omitempty"` // por defecto 5
+}
+
+// Modos de la moderación de contenido
+const (
+	ModerationModeOff   = "off"
+	ModerationModeFlag  = "flag"
+	ModerationModeMask  = "mask"
+	ModerationModeBlock = "block"
+)
+
+// ModerationConfig es la política de moderación del bot. Config se pasa al
+// agente MCP de moderación (provider, words, openai_api_key...).
+type ModerationConfig struct {
+	Inbound         string                 `json:"inbound,omitempty"`          // flag (por defecto), mask u off
+	Outbound        string                 `json:"outbound,omitempty"`         // block (por defecto) u off
+	BlockedResponse string                 `json:"blocked_response,omitempty"` // texto que sustituye a la respuesta bloqueada
+	Config          map[string]interface{} `json:"config,omitempty"`
+}
+
+// SentimentConfig activa la puntuación de sentimiento de los mensajes. Config
+// se pasa al agente MCP de IA; sin openai_api_key se usa un léxico. Cuando
+// ConsecutiveMessages mensajes seguidos puntúan Threshold o menos se disparan
+// los triggers sentiment_threshold.
+type SentimentConfig struct {
+	Threshold           float64                `json:"threshold,omitempty"`            // -1 a 0; por defecto -0.3
+	ConsecutiveMessages int                    `json:"consecutive_messages,omitempty"` // por defecto 2
+	Config              map[string]interface{} `json:"config,omitempty"`
+}
+
+// MessageSentiment es la puntuación de sentimiento de un mensaje
+type MessageSentiment struct {
+	Score  float64 `json:"score"` // de -1 (muy negativo) a 1 (muy positivo)
+	Label  string  `json:"label"` // positive, neutral o negative
+	Source string  `json:"source"` // ai o lexicon
+	// NegativeStreak cuenta los mensajes seguidos por debajo del umbral, este incluido
+	NegativeStreak int `json:"negative_streak"`
+	// ThresholdReached indica que con este mensaje la racha llegó a ConsecutiveMessages
+	ThresholdReached bool `json:"threshold_reached,omitempty"`
+}
+
+// NLUConfig configura la clasificación de intents a partir de las SmartReply del
+// bot. Por debajo de Threshold el mensaje se considera no entendido y, si hay
+// FallbackFlowID, el bot pasa a ese flujo en lugar de responder con IA.
+//
+// ResponseThreshold hace lo mismo con la confianza de la respuesta generada: por
+// debajo el bot no la envía, pide que se aclare el mensaje y, tras
+// MaxClarifications aclaraciones seguidas, pasa a FallbackFlowID.
+type NLUConfig struct {
+	Threshold      float64 `json:"threshold,omitempty"` // 0-1; por defecto 0.4
+	FallbackFlowID string  `json:"fallback_flow_id,omitempty"`
+
+	ResponseThreshold    float64 `json:"response_threshold,omitempty"` // 0-1; 0 lo desactiva
+	ClarificationMessage string  `json:"clarification_message,omitempty"`
+	MaxClarifications    int     `json:"max_clarifications,omitempty"` // por defecto 1
+}
+
+// SandboxOwnerID es el propietario de los bots de sandbox, para que no se
+// mezclen con los bots de ningún usuario
+const SandboxOwnerID = "sandbox"
+
+// SandboxConfig describe un bot de sandbox: se elimina al llegar a ExpiresAt
+// y sus agentes MCP usan respuestas simuladas en lugar de servicios externos
+type SandboxConfig struct {
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedBy string    `json:"created_by,omitempty"`
+}
+
+// EmailConfig personaliza las respuestas del bot en el canal de email.
+// ReplyTemplate es un text/template con .Content, .Options, .BotName,
+// .Subject y .Signature; vacío usa la plantilla por defecto.
+type EmailConfig struct {
+	ReplyTemplate string `json:"reply_template,omitempty"`
+	Signature     string `json:"signature,omitempty"`
+}
+
+// ExecutionCaptureConfig activa la captura de entradas y salidas de las tareas
+// de agentes del bot. SampleRate (0-1) es la fracción de ejecuciones
+// correctas que se guardan; las fallidas se guardan siempre. RedactKeys se
+// suma a las claves que se ocultan por defecto (tokens, contraseñas, etc.).
+type ExecutionCaptureConfig struct {
+	Enabled    bool     `json:"enabled"`
+	SampleRate *float64 `json:"sample_rate,omitempty"`
+	RedactKeys []string `json:"redact_keys,omitempty"`
+}
+
+// SummaryConfig configura el agente MCP de IA que resume las conversaciones
+type SummaryConfig struct {
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// VoiceNoteConfig configura la transcripción de notas de voz. Config se pasa
+// al agente MCP de transcripción (provider, openai_api_key, base_url, model).
+type VoiceNoteConfig struct {
+	Disabled       bool                   `json:"disabled,omitempty"`
+	Language       string                 `json:"language,omitempty"`
+	FailureMessage string                 `json:"failure_message,omitempty"`
+	Config         map[string]interface{} `json:"config,omitempty"`
+}
+
+// TranslationConfig configura la traducción automática: los mensajes del
+// usuario se traducen al idioma del bot y las respuestas al idioma del usuario.
+// Config se pasa al agente MCP de traducción (openai_api_key, model, fallback_model).
+type TranslationConfig struct {
+	AutoTranslate bool                   `json:"auto_translate"`
+	BotLanguage   string                 `json:"bot_language"`
+	LanguagePairs []LanguagePair         `json:"language_pairs,omitempty"`
+	Glossary      []GlossaryEntry        `json:"glossary,omitempty"`
+	Config        map[string]interface{} `json:"config,omitempty"`
+}
+
+// LanguagePair es un par de idiomas permitido; "*" admite cualquiera
+type LanguagePair struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// GlossaryEntry es un término protegido. Sin traducción para el idioma
+// destino se conserva tal cual (marcas, productos).
+type GlossaryEntry struct {
+	Term          string            `json:"term"`
+	Translations  map[string]string `json:"translations,omitempty"` // idioma -> traducción fija
+	CaseSensitive bool              `json:"case_sensitive,omitempty"`
+}
+
+// Transcript representa la transcripción de una nota de voz, enlazada al audio original
+type Transcript struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language,omitempty"`
+	Duration float64 `json:"duration,omitempty"` // en segundos
+	AudioURL string  `json:"audio_url"`
+	MimeType string  `json:"mime_type,omitempty"`
+	Provider string  `json:"provider,omitempty"`
+}
+
+// EntityDefinition declara una entidad personalizada a extraer de los mensajes
+type EntityDefinition struct {
+	Name        string              `json:"name"`
+	Type        string              `json:"type"`                  // enum, pattern o ai
+	Values      map[string][]string `json:"values,omitempty"`      // enum: valor canónico -> sinónimos
+	Pattern     string              `json:"pattern,omitempty"`     // pattern: expresión regular
+	Description string              `json:"description,omitempty"` // ai: descripción para el modelo
+}
+
+// Entity representa una entidad extraída de un mensaje
+type Entity struct {
+	Type       string      `json:"type"`
+	Value      interface{} `json:"value"`
+	Raw        string      `json:"raw"`
+	Confidence float64     `json:"confidence"`
+	Source     string      `json:"source"` // rules o ai
+}
+
+// Tipos de entidades built-in
+const (
+	EntityTypeDate        = "date"
+	EntityTypeMoney       = "money"
+	EntityTypeEmail       = "email"
+	EntityTypePhone       = "phone"
+	EntityTypeOrderNumber = "order_number"
+)
+
+// GlobalIntent es una intención a nivel de bot que interrumpe cualquier flujo activo
+type GlobalIntent struct {
+	Name     string   `json:"name"`
+	Keywords []string `json:"keywords"`
+	FlowID   string   `json:"flow_id"`
+	Resume   bool     `json:"resume"` // volver al paso interrumpido al terminar el flujo
+}
+
 // BotFlow representa un flujo de conversación
 type BotFlow struct {
 	ID         string    `json:"id" db:"id"`
@@ -49,12 +413,68 @@ type BotFlow struct {
 	IsDefault  bool      `json:"is_default" db:"is_default"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+
+	TriggerConfig *FlowTrigger `json:"trigger_config,omitempty" db:"trigger_config"`
+
+	// PublishedVersion es la versión que reciben las conversaciones nuevas.
+	// Con 0 el flujo nunca se publicó y se ejecuta el borrador.
+	PublishedVersion int `json:"published_version" db:"published_version"`
+
+	MigrationPolicy *FlowMigrationPolicy `json:"migration_policy,omitempty" db:"migration_policy"`
+}
+
+// MigrationStrategy indica qué hacer con una sesión cuyo paso actual ya no existe
+type MigrationStrategy string
+
+const (
+	MigrationRestart  MigrationStrategy = "restart"  // volver al entry point (por defecto)
+	MigrationFallback MigrationStrategy = "fallback" // continuar en FallbackFlowID/FallbackStep
+	MigrationMap      MigrationStrategy = "map"      // traducir el paso con StepMap
+)
+
+// FlowMigrationPolicy resuelve las referencias obsoletas de las sesiones en curso:
+// pasos borrados o que no están en la versión fijada. Con map, los pasos sin
+// entrada en StepMap van al fallback si está configurado.
+type FlowMigrationPolicy struct {
+	Strategy       MigrationStrategy `json:"strategy"`
+	FallbackFlowID string            `json:"fallback_flow_id,omitempty"` // por defecto el mismo flujo
+	FallbackStep   string            `json:"fallback_step,omitempty"`    // ID o nombre; por defecto el entry point
+	StepMap        map[string]string `json:"step_map,omitempty"`         // ID viejo -> ID o nombre nuevo
+}
+
+// FlowTrigger configura la activación flexible de un flujo
+type FlowTrigger struct {
+	Keywords    []string            `json:"keywords,omitempty"`
+	Synonyms    map[string][]string `json:"synonyms,omitempty"`     // palabra clave -> sinónimos
+	MaxDistance int                 `json:"max_distance,omitempty"` // distancia de edición tolerada por palabra
+}
+
+// FlowVersionStatus representa el estado de una versión de flujo
+type FlowVersionStatus string
+
+const (
+	FlowVersionStatusPublished FlowVersionStatus = "published"
+	FlowVersionStatusArchived  FlowVersionStatus = "archived"
+)
+
+// FlowVersion es una copia inmutable del flujo y sus pasos tomada al publicar
+type FlowVersion struct {
+	ID          string            `json:"id" db:"id"`
+	FlowID      string            `json:"flow_id" db:"flow_id"`
+	Version     int               `json:"version" db:"version"`
+	Status      FlowVersionStatus `json:"status" db:"status"`
+	Notes       string            `json:"notes,omitempty" db:"notes"`
+	Flow        BotFlow           `json:"flow" db:"flow"`
+	Steps       []BotStep         `json:"steps" db:"steps"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	PublishedAt time.Time         `json:"published_at" db:"published_at"`
 }
 
 // BotStep representa un paso en un flujo de conversación
 type BotStep struct {
 	ID           string          `json:"id" db:"id"`
 	FlowID       string          `json:"flow_id" db:"flow_id"`
+	Name         string          `json:"name,omitempty" db:"name"`
 	Type         StepType        `json:"type" db:"type"`
 	Content      json.RawMessage `json:"content" db:"content"`
 	NextStepID   *string         `json:"next_step_id" db:"next_step_id"`
@@ -68,12 +488,124 @@ type SmartReply struct {
 	ID         string    `json:"id" db:"id"`
 	BotID      string    `json:"bot_id" db:"bot_id"`
 	Intent     string    `json:"intent" db:"intent"`
+	// Examples son frases de usuario con las que se entrena el clasificador de intents
+	Examples   []string  `json:"examples,omitempty" db:"examples"`
 	Response   string    `json:"response" db:"response"`
 	Confidence float64   `json:"confidence" db:"confidence"`
 	CreatedAt  time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// IntentExample es una frase de usuario con la que se entrena un intent. Se
+// guardan aparte de la SmartReply para que cada entrenamiento sume ejemplos.
+type IntentExample struct {
+	ID        string    `json:"id" db:"id"`
+	BotID     string    `json:"bot_id" db:"bot_id"`
+	Intent    string    `json:"intent" db:"intent"`
+	Text      string    `json:"text" db:"text"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// IntentTraining es un intent a entrenar con su respuesta y las frases que lo expresan
+type IntentTraining struct {
+	Intent     string   `json:"intent"`
+	Response   string   `json:"response,omitempty"`
+	Utterances []string `json:"utterances"`
+	// Examples es el nombre del formato anterior; se suma a Utterances
+	Examples []string `json:"examples,omitempty"`
+}
+
+// IntentTrainingResult resume lo que cambió un entrenamiento
+type IntentTrainingResult struct {
+	Trained           int `json:"trained_count"`
+	IntentsCreated    int `json:"intents_created"`
+	IntentsUpdated    int `json:"intents_updated"`
+	ExamplesAdded     int `json:"examples_added"`
+	DuplicatesSkipped int `json:"duplicates_skipped"`
+}
+
+// BotBundleVersion es la versión actual del formato de exportación de bots
+const BotBundleVersion = 1
+
+// BotBundle es un bot exportado con todos sus recursos, para importarlo en
+// otro entorno. Los IDs son los del entorno de origen.
+type BotBundle struct {
+	Version      int           `json:"version"`
+	ExportedAt   time.Time     `json:"exported_at"`
+	Bot          Bot           `json:"bot"`
+	Flows        []BotFlow     `json:"flows"`
+	Steps        []BotStep     `json:"steps"`
+	SmartReplies []SmartReply  `json:"smart_replies"`
+	Conditionals []Conditional `json:"conditionals"`
+	Triggers     []Trigger     `json:"triggers"`
+}
+
+// BotImportResult resume una importación; IDMap relaciona IDs de origen y nuevos
+type BotImportResult struct {
+	Bot          *Bot              `json:"bot"`
+	Flows        int               `json:"flows"`
+	Steps        int               `json:"steps"`
+	SmartReplies int               `json:"smart_replies"`
+	Conditionals int               `json:"conditionals"`
+	Triggers     int               `json:"triggers"`
+	IDMap        map[string]string `json:"id_map"`
+}
+
+// FAQEntry representa un par pregunta/respuesta de la FAQ de un bot
+type FAQEntry struct {
+	ID           string    `json:"id" db:"id"`
+	BotID        string    `json:"bot_id" db:"bot_id"`
+	Question     string    `json:"question" db:"question" binding:"required"`
+	Alternatives []string  `json:"alternatives,omitempty" db:"alternatives"` // otras formas de la pregunta
+	Answer       string    `json:"answer" db:"answer" binding:"required"`
+	Tags         []string  `json:"tags,omitempty" db:"tags"`
+	Hits         int       `json:"hits" db:"hits"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FAQMatch representa la respuesta de la FAQ a una pregunta
+type FAQMatch struct {
+	Entry   *FAQEntry `json:"entry"`
+	Score   float64   `json:"score"`
+	Matched string    `json:"matched"` // pregunta o alternativa que coincidió
+}
+
+// UnansweredQuestion representa una pregunta que ni la FAQ ni los flujos pudieron responder
+type UnansweredQuestion struct {
+	ID        string    `json:"id"`
+	BotID     string    `json:"bot_id"`
+	UserID    string    `json:"user_id"`
+	Question  string    `json:"question"`
+	BestScore float64   `json:"best_score"` // similitud con la entrada más cercana de la FAQ
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// FAQCluster agrupa preguntas sin respuesta similares entre sí
+type FAQCluster struct {
+	Representative string    `json:"representative"`
+	Questions      []string  `json:"questions"`
+	Count          int       `json:"count"`
+	FirstSeen      time.Time `json:"first_seen"`
+	LastSeen       time.Time `json:"last_seen"`
+}
+
+// FAQClusterReport es el informe de preguntas sin respuesta agrupadas
+type FAQClusterReport struct {
+	BotID           string       `json:"bot_id"`
+	TotalUnanswered int          `json:"total_unanswered"`
+	Threshold       float64      `json:"threshold"`
+	Clusters        []FAQCluster `json:"clusters"`
+}
+
+// SmartReplyChunk representa un fragmento de una respuesta inteligente en streaming
+type SmartReplyChunk struct {
+	Content string      `json:"content,omitempty"`
+	Done    bool        `json:"done"`
+	Reply   *SmartReply `json:"reply,omitempty"`
+	Error   string      `json:"error,omitempty"`
+}
+
 // IncomingMessage representa un mensaje entrante
 type IncomingMessage struct {
 	ID        string                 `json:"id"`
@@ -83,6 +615,10 @@ type IncomingMessage struct {
 	Channel   ChannelType            `json:"channel"`
 	Metadata  map[string]interface{} `json:"metadata"`
 	Timestamp time.Time              `json:"timestamp"`
+	// Entities son las entidades extraídas del mensaje durante su procesamiento
+	Entities []Entity `json:"-"`
+	// Sentiment es la puntuación del mensaje si el bot tiene sentiment configurado
+	Sentiment *MessageSentiment `json:"-"`
 }
 
 // BotResponse representa la respuesta del bot
@@ -108,10 +644,154 @@ type ConversationSession struct {
 	UserID        string                 `json:"user_id"`
 	CurrentFlowID string                 `json:"current_flow_id"`
 	CurrentStepID string                 `json:"current_step_id"`
+	FlowVersion   int                    `json:"flow_version,omitempty"` // versión fijada al entrar al flujo
 	Context       map[string]interface{} `json:"context"`
 	CreatedAt     time.Time              `json:"created_at"`
 	UpdatedAt     time.Time              `json:"updated_at"`
 	ExpiresAt     time.Time              `json:"expires_at"`
+	EndedAt       *time.Time             `json:"ended_at,omitempty"`
+	Messages      []ConversationMessage  `json:"messages,omitempty"`
+	Summary       *ConversationSummary   `json:"summary,omitempty"`
+	Handoff       *SessionHandoff        `json:"handoff,omitempty"` // no nil mientras la atiende un humano
+}
+
+// ConversationMessage es un mensaje de la transcripción de una sesión. En el
+// historial de conversaciones lleva además su ID y la sesión, el bot y el
+// usuario a los que pertenece.
+type ConversationMessage struct {
+	ID        string    `json:"id,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	BotID     string    `json:"bot_id,omitempty"`
+	UserID    string    `json:"user_id,omitempty"`
+	Role      string    `json:"role"` // user, bot o agent (agente humano tras un handoff)
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ConversationThread resume una conversación del historial
+type ConversationThread struct {
+	SessionID     string    `json:"session_id"`
+	BotID         string    `json:"bot_id"`
+	UserID        string    `json:"user_id"`
+	MessageCount  int       `json:"message_count"`
+	StartedAt     time.Time `json:"started_at"`
+	LastMessageAt time.Time `json:"last_message_at"`
+	LastMessage   string    `json:"last_message"`
+}
+
+// ConversationHistoryFilter acota el historial por usuario y por fecha del
+// mensaje (o de la actividad, para las conversaciones)
+type ConversationHistoryFilter struct {
+	UserID string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// ConversationSummary es el resumen estructurado de una conversación.
+// MessageCount indica cuántos mensajes cubre, para saber si sigue vigente.
+type ConversationSummary struct {
+	Issue        string    `json:"issue"`
+	Resolution   string    `json:"resolution"`
+	Sentiment    string    `json:"sentiment"` // positive, neutral o negative
+	ActionItems  []string  `json:"action_items"`
+	MessageCount int       `json:"message_count"`
+	Source       string    `json:"source"` // ai o fallback
+	GeneratedAt  time.Time `json:"generated_at"`
+}
+
+// HandoffStatus es el estado de una conversación derivada a un agente humano
+type HandoffStatus string
+
+const (
+	HandoffStatusPending HandoffStatus = "pending" // en cola, sin agente asignado
+	HandoffStatusActive  HandoffStatus = "active"  // un agente tomó la conversación
+)
+
+// SessionHandoff describe la derivación de una sesión a un agente humano.
+// Mientras existe, el bot no procesa los mensajes del usuario.
+type SessionHandoff struct {
+	Status      HandoffStatus `json:"status"`
+	Queue       string        `json:"queue,omitempty"`
+	Reason      string        `json:"reason,omitempty"`
+	AgentID     string        `json:"agent_id,omitempty"`
+	RequestedAt time.Time     `json:"requested_at"`
+	AcceptedAt  *time.Time    `json:"accepted_at,omitempty"`
+}
+
+// SessionStatus resume en qué situación está una conversación
+type SessionStatus string
+
+const (
+	SessionStatusIdle    SessionStatus = "idle"    // sin paso pendiente; el próximo mensaje elige flujo
+	SessionStatusActive  SessionStatus = "active"  // el próximo mensaje ejecuta el paso actual
+	SessionStatusDelayed SessionStatus = "delayed" // esperando a que venza un paso delay
+	SessionStatusHandoff SessionStatus = "handoff" // atendida por un humano
+	SessionStatusEnded   SessionStatus = "ended"
+)
+
+// SessionState es la foto de una conversación en curso: en qué flujo y paso
+// está, qué entrada espera el bot y hacia dónde puede avanzar
+type SessionState struct {
+	SessionID       string                 `json:"session_id"`
+	BotID           string                 `json:"bot_id"`
+	UserID          string                 `json:"user_id"`
+	Status          SessionStatus          `json:"status"`
+	Flow            *SessionFlowRef        `json:"flow,omitempty"`
+	Step            *SessionStepRef        `json:"step,omitempty"`
+	Expects         *ExpectedInput         `json:"expects,omitempty"`
+	Transitions     []StateTransition      `json:"transitions"`
+	PendingTimers   []PendingTimer         `json:"pending_timers"`
+	Variables       map[string]interface{} `json:"variables"`
+	SubflowStack    []SessionFlowRef       `json:"subflow_stack,omitempty"`    // flujos llamadores, del más externo al más interno
+	InterruptedFlow *SessionFlowRef        `json:"interrupted_flow,omitempty"` // se retoma al terminar un intent global
+	Handoff         *SessionHandoff        `json:"handoff,omitempty"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+	ExpiresAt       time.Time              `json:"expires_at"`
+}
+
+// SessionFlowRef identifica un flujo (y su versión fijada) dentro de una sesión
+type SessionFlowRef struct {
+	ID         string `json:"id"`
+	Name       string `json:"name,omitempty"`
+	Version    int    `json:"version,omitempty"`
+	ReturnStep string `json:"return_step,omitempty"` // paso donde continúa al volver de un sub-flujo
+}
+
+// SessionStepRef identifica el paso actual de una sesión
+type SessionStepRef struct {
+	ID   string   `json:"id"`
+	Name string   `json:"name,omitempty"`
+	Type StepType `json:"type"`
+}
+
+// ExpectedInput describe la entrada que necesita el paso actual. Kind es text
+// (se guarda en Variable), entity, slot o any (cualquier mensaje avanza).
+type ExpectedInput struct {
+	Kind     string `json:"kind"`
+	Variable string `json:"variable,omitempty"`
+	Entity   string `json:"entity,omitempty"`
+	Slot     string `json:"slot,omitempty"`
+	Prompt   string `json:"prompt,omitempty"`
+}
+
+// StateTransition es una salida posible del paso actual. Trigger es message,
+// condition, default, timer o global_intent.
+type StateTransition struct {
+	Trigger      string `json:"trigger"`
+	Condition    string `json:"condition,omitempty"`
+	TargetStepID string `json:"target_step_id,omitempty"`
+	TargetFlowID string `json:"target_flow_id,omitempty"`
+}
+
+// PendingTimer es una espera programada de la sesión (paso delay)
+type PendingTimer struct {
+	Type     string    `json:"type"`
+	StepID   string    `json:"step_id"`
+	TaskID   string    `json:"task_id"`
+	ResumeAt time.Time `json:"resume_at"`
+	Overdue  bool      `json:"overdue"`
 }
 
 // Enums
@@ -122,6 +802,7 @@ const (
 	ChannelWhatsApp ChannelType = "whatsapp"
 	ChannelTelegram ChannelType = "telegram"
 	ChannelSlack    ChannelType = "slack"
+	ChannelEmail    ChannelType = "email"
 )
 
 type BotStatus string
@@ -140,6 +821,14 @@ const (
 	StepTypeInput    StepType = "input"
 	StepTypeAPICall  StepType = "api_call"
 	StepTypeAI       StepType = "ai"
+	StepTypeRandom   StepType = "random"
+	StepTypeJump     StepType = "jump"
+	StepTypeSwitch   StepType = "switch_flow"
+	StepTypeSubflow  StepType = "subflow"
+	StepTypeEnd      StepType = "end"
+	StepTypeHandoff  StepType = "handoff"
+	StepTypeForm     StepType = "form"
+	StepTypeDelay    StepType = "delay"
 )
 
 type ResponseType string
@@ -156,6 +845,8 @@ type APIResponse struct {
 	Code    string      `json:"code"`
 	Message string      `json:"message"`
 	Data    interface{} `json:"data"`
+	// Detail conserva el texto específico del error cuando Message se traduce
+	Detail string `json:"detail,omitempty"`
 }
 
 // HealthStatus representa el estado de salud del servicio
@@ -180,10 +871,40 @@ type MCPAgent struct {
 	Capabilities []string               `json:"capabilities"`
 	Status       MCPAgentStatus         `json:"status"`
 	Timeout      int64                  `json:"timeout"` // en milliseconds
+	// Context y Metrics son la última instantánea del agente persistido;
+	// AgentMetrics son las métricas propias del agente (mcp.AgentMetrics)
+	Context      map[string]interface{} `json:"context,omitempty"`
+	Metrics      *MCPAgentMetrics       `json:"metrics,omitempty"`
+	AgentMetrics json.RawMessage        `json:"agent_metrics,omitempty"`
 	CreatedAt    time.Time              `json:"created_at"`
 	UpdatedAt    time.Time              `json:"updated_at"`
 }
 
+// WorkflowDefinition es un workflow guardado que los agentes workflow y las
+// tareas referencian por ID. Cada cambio crea una versión nueva y las
+// anteriores se conservan para poder fijarlas.
+type WorkflowDefinition struct {
+	ID          string                   `json:"id" db:"id"`
+	Name        string                   `json:"name" db:"name"`
+	Description string                   `json:"description,omitempty" db:"description"`
+	Version     int                      `json:"version" db:"version"`
+	Steps       []map[string]interface{} `json:"steps" db:"steps"`
+	Notes       string                   `json:"notes,omitempty" db:"notes"` // motivo del cambio de esta versión
+	CreatedBy   string                   `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time                `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time                `json:"updated_at" db:"updated_at"`
+}
+
+// ScriptApproval registra que un administrador aprobó el contenido exacto de un
+// script para los agentes sandbox. Cualquier cambio en el script cambia el hash
+// y necesita una aprobación nueva.
+type ScriptApproval struct {
+	Hash       string    `json:"script_sha256" db:"hash"`
+	ApprovedBy string    `json:"approved_by" db:"approved_by"`
+	Notes      string    `json:"notes,omitempty" db:"notes"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
 // MCPTask representa una tarea para ejecutar en un agente MCP
 type MCPTask struct {
 	ID          string                 `json:"id"`
@@ -220,6 +941,7 @@ type MCPAgentMetrics struct {
 	LastExecution       time.Time `json:"last_execution"`
 	LastError           time.Time `json:"last_error"`
 	SuccessRate         float64   `json:"success_rate"`
+	CircuitState        string    `json:"circuit_state"` // closed, open o half_open
 }
 
 // MCPSystemMetrics representa métricas del sistema MCP
@@ -290,12 +1012,69 @@ type AsyncTask struct {
 	Result        map[string]interface{} `json:"result,omitempty"`
 	Error         string                 `json:"error,omitempty"`
 	ExecutionTime int64                  `json:"execution_time,omitempty"` // en milliseconds
-	CreatedAt     time.Time              `json:"created_at"`
-	UpdatedAt     time.Time              `json:"updated_at"`
-	StartedAt     time.Time              `json:"started_at,omitempty"`
-	CompletedAt   time.Time              `json:"completed_at,omitempty"`
+	RetryPolicy   *RetryPolicy           `json:"retry_policy,omitempty"`
+	Attempts      int                    `json:"attempts"`
+	NextRetryAt   time.Time              `json:"next_retry_at,omitempty"`
+	ScheduledAt   time.Time              `json:"scheduled_at,omitempty"` // no se ejecuta antes de este momento
+	ErrorHistory  []TaskAttemptError     `json:"error_history,omitempty"`
+	// CallbackURL recibe un POST firmado con CallbackSecret cuando la tarea
+	// termina (completada, fallida o cancelada)
+	CallbackURL    string    `json:"callback_url,omitempty"`
+	CallbackSecret string    `json:"callback_secret,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	StartedAt      time.Time `json:"started_at,omitempty"`
+	CompletedAt    time.Time `json:"completed_at,omitempty"`
+}
+
+// TaskFilter acota el historial de tareas; los campos vacíos no filtran
+type TaskFilter struct {
+	Status TaskStatus
+	Type   string
+	UserID string
+	BotID  string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
+
+// TaskAttemptError registra el error de un intento de ejecución
+type TaskAttemptError struct {
+	Attempt    int       `json:"attempt"`
+	Error      string    `json:"error"`
+	Class      string    `json:"class"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// DeadLetterTask representa una tarea que falló definitivamente
+type DeadLetterTask struct {
+	ID             string     `json:"id"`
+	Task           *AsyncTask `json:"task"`
+	Reason         string     `json:"reason"`
+	RedriveCount   int        `json:"redrive_count"`
+	DeadLetteredAt time.Time  `json:"dead_lettered_at"`
+}
+
+// RetryPolicy define cómo se reintenta una tarea asíncrona fallida
+type RetryPolicy struct {
+	MaxAttempts     int      `json:"max_attempts"`                // incluye el primer intento
+	InitialBackoff  int64    `json:"initial_backoff"`             // en milliseconds
+	MaxBackoff      int64    `json:"max_backoff,omitempty"`       // en milliseconds
+	Multiplier      float64  `json:"multiplier,omitempty"`
+	RetryableErrors []string `json:"retryable_errors,omitempty"` // clases de error; vacío = errores transitorios
 }
 
+// Clases de error usadas por RetryPolicy.RetryableErrors
+const (
+	TaskErrorTimeout          = "timeout"
+	TaskErrorNetwork          = "network"
+	TaskErrorRateLimit        = "rate_limit"
+	TaskErrorServer           = "server_error"
+	TaskErrorAgentUnavailable = "agent_unavailable"
+	TaskErrorPermanent        = "permanent"
+)
+
 // TaskStatus representa los posibles estados de una tarea asíncrona
 type TaskStatus string
 
@@ -322,6 +1101,7 @@ type Memory struct {
 	CreatedAt  time.Time              `json:"created_at"`
 	UpdatedAt  time.Time              `json:"updated_at"`
 	ExpiresAt  time.Time              `json:"expires_at"`
+	Score      float64                `json:"score,omitempty"` // similitud con la consulta en búsquedas semánticas
 }
 
 // ContextSummary representa un resumen del contexto de conversación
@@ -347,6 +1127,12 @@ type MemoryStats struct {
 	LastUpdated          time.Time         `json:"last_updated"`
 }
 
+// SessionStoreStats resume el almacén de sesiones para las métricas de capacidad
+type SessionStoreStats struct {
+	SessionsByBot      map[string]int `json:"sessions_by_bot"`
+	AverageContextSize float64        `json:"average_context_size"` // bytes del contexto serializado
+}
+
 // MemoryType representa los tipos de memoria soportados
 type MemoryType string
 
@@ -374,6 +1160,41 @@ type Conditional struct {
 	UpdatedAt   time.Time              `json:"updated_at"`
 }
 
+// ConditionTrace representa un nodo del árbol de evaluación de una expresión
+type ConditionTrace struct {
+	Expression string            `json:"expression"`
+	Kind       string            `json:"kind,omitempty"`     // or, and, not, function, comparison, operator, literal
+	Operator   string            `json:"operator,omitempty"` // función u operador aplicado
+	Values     []interface{}     `json:"values,omitempty"`   // argumentos ya resueltos
+	Resolved   string            `json:"resolved,omitempty"` // expresión tras reemplazar variables
+	Result     bool              `json:"result"`
+	Error      string            `json:"error,omitempty"`
+	Children   []*ConditionTrace `json:"children,omitempty"`
+}
+
+// BatchEvaluationRequest solicita evaluar expresiones contra varios contextos
+type BatchEvaluationRequest struct {
+	Expression     string                   `json:"expression,omitempty"`
+	ConditionalIDs []string                 `json:"conditional_ids,omitempty"`
+	Contexts       []map[string]interface{} `json:"contexts" binding:"required,min=1"`
+}
+
+// ExpressionEvaluation es el resultado de una expresión sobre un contexto
+type ExpressionEvaluation struct {
+	ConditionalID string          `json:"conditional_id,omitempty"`
+	Name          string          `json:"name,omitempty"`
+	Expression    string          `json:"expression"`
+	Result        bool            `json:"result"`
+	Error         string          `json:"error,omitempty"`
+	Trace         *ConditionTrace `json:"trace,omitempty"`
+}
+
+// PayloadEvaluation agrupa las evaluaciones de un contexto del batch
+type PayloadEvaluation struct {
+	Index       int                    `json:"index"`
+	Evaluations []ExpressionEvaluation `json:"evaluations"`
+}
+
 // ConditionalType representa los tipos de condiciones
 type ConditionalType string
 
@@ -396,11 +1217,21 @@ type Trigger struct {
 	Action      TriggerAction          `json:"action"`
 	Priority    int                    `json:"priority"`
 	Enabled     bool                   `json:"enabled"`
+	Schedule    *TriggerSchedule       `json:"schedule,omitempty"`
+	LastFiredAt *time.Time             `json:"last_fired_at,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 }
 
+// TriggerSchedule define cuándo se dispara un trigger temporal
+type TriggerSchedule struct {
+	Cron              string     `json:"cron,omitempty"`               // expresión cron de 5 campos (evento schedule)
+	At                *time.Time `json:"at,omitempty"`                 // fecha absoluta (evento schedule)
+	InactivityMinutes int        `json:"inactivity_minutes,omitempty"` // minutos de silencio (evento timeout)
+	Timezone          string     `json:"timezone,omitempty"`
+}
+
 // TriggerEvent representa los tipos de eventos que pueden disparar triggers
 type TriggerEvent string
 
@@ -411,6 +1242,9 @@ const (
 	TriggerEventTimeout         TriggerEvent = "timeout"
 	TriggerEventError           TriggerEvent = "error"
 	TriggerEventCustom          TriggerEvent = "custom"
+	TriggerEventSchedule        TriggerEvent = "schedule"
+	// TriggerEventSentimentThreshold se dispara cuando el usuario encadena mensajes negativos
+	TriggerEventSentimentThreshold TriggerEvent = "sentiment_threshold"
 )
 
 // TriggerAction representa las acciones que puede ejecutar un trigger
@@ -447,12 +1281,15 @@ type TestInput struct {
 
 // TestExpected representa el resultado esperado de un caso de prueba
 type TestExpected struct {
-	Response    string                 `json:"response"`
-	NextStep    string                 `json:"next_step,omitempty"`
-	Conditions  []string               `json:"conditions,omitempty"`
-	Triggers    []string               `json:"triggers,omitempty"`
-	Context     map[string]interface{} `json:"context,omitempty"`
-	Timeout     int64                  `json:"timeout"` // en milliseconds
+	Response            string                 `json:"response"`
+	ResponseMatch       AssertionMode          `json:"response_match,omitempty"` // exact por defecto
+	SimilarityThreshold float64                `json:"similarity_threshold,omitempty"`
+	Assertions          []ResponseAssertion    `json:"assertions,omitempty"`
+	NextStep            string                 `json:"next_step,omitempty"`
+	Conditions          []string               `json:"conditions,omitempty"`
+	Triggers            []string               `json:"triggers,omitempty"`
+	Context             map[string]interface{} `json:"context,omitempty"`
+	Timeout             int64                  `json:"timeout"` // en milliseconds
 }
 
 // TestResult representa el resultado de ejecutar un caso de prueba
@@ -464,10 +1301,33 @@ type TestResult struct {
 	ExecutedTriggers   []string          `json:"executed_triggers,omitempty"`
 	ActualContext      map[string]interface{} `json:"actual_context,omitempty"`
 	ExecutionTime      int64             `json:"execution_time"` // en milliseconds
+	Failures           []string          `json:"failures,omitempty"`
 	Error             string             `json:"error,omitempty"`
 	ExecutedAt        time.Time          `json:"executed_at"`
 }
 
+// AssertionMode define cómo se compara un valor esperado con el obtenido
+type AssertionMode string
+
+const (
+	AssertionExact      AssertionMode = "exact"
+	AssertionContains   AssertionMode = "contains"
+	AssertionRegex      AssertionMode = "regex"
+	AssertionSimilarity AssertionMode = "similarity"
+	AssertionExists     AssertionMode = "exists"
+	AssertionNotExists  AssertionMode = "not_exists"
+)
+
+// ResponseAssertion verifica un valor de la respuesta mediante una ruta JSON
+// sobre {response, type, options, metadata, context}, por ejemplo
+// "$.metadata.intent" o "context.items[0].sku"
+type ResponseAssertion struct {
+	Path      string        `json:"path"`
+	Mode      AssertionMode `json:"mode,omitempty"`
+	Value     interface{}   `json:"value,omitempty"`
+	Threshold float64       `json:"threshold,omitempty"`
+}
+
 // TestStatus representa el estado de un caso de prueba
 type TestStatus string
 
@@ -479,6 +1339,63 @@ const (
 	TestStatusSkipped   TestStatus = "skipped"
 )
 
+// MultiTurnTestCase representa una prueba de conversación completa: una
+// secuencia ordenada de mensajes ejecutada sobre una sesión nueva
+type MultiTurnTestCase struct {
+	ID          string                 `json:"id"`
+	BotID       string                 `json:"bot_id"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Channel     ChannelType            `json:"channel,omitempty"`
+	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Turns       []TestTurn             `json:"turns"`
+	Status      TestStatus             `json:"status"`
+	Result      *MultiTurnTestResult   `json:"result,omitempty"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+// TestTurn representa un turno de la conversación y lo que se espera de él
+type TestTurn struct {
+	Message  string                 `json:"message"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Expected TestTurnExpected       `json:"expected"`
+}
+
+// TestTurnExpected define las verificaciones de un turno. Context compara
+// valores del contexto de la sesión tras el turno; un valor nil exige que
+// la clave no exista
+type TestTurnExpected struct {
+	Response            string                 `json:"response,omitempty"`
+	ResponseContains    string                 `json:"response_contains,omitempty"`
+	ResponseMatch       AssertionMode          `json:"response_match,omitempty"`
+	SimilarityThreshold float64                `json:"similarity_threshold,omitempty"`
+	NextStep            string                 `json:"next_step,omitempty"`
+	Context             map[string]interface{} `json:"context,omitempty"`
+	Assertions          []ResponseAssertion    `json:"assertions,omitempty"`
+}
+
+// MultiTurnTestResult representa el resultado de una prueba de conversación
+type MultiTurnTestResult struct {
+	Success       bool             `json:"success"`
+	Turns         []TestTurnResult `json:"turns"`
+	FailedTurn    int              `json:"failed_turn"` // -1 si todos los turnos pasaron
+	ExecutionTime int64            `json:"execution_time"` // en milliseconds
+	Error         string           `json:"error,omitempty"`
+	ExecutedAt    time.Time        `json:"executed_at"`
+}
+
+// TestTurnResult representa el resultado de un turno individual
+type TestTurnResult struct {
+	Index          int                    `json:"index"`
+	Message        string                 `json:"message"`
+	Success        bool                   `json:"success"`
+	ActualResponse string                 `json:"actual_response"`
+	ActualNextStep string                 `json:"actual_next_step,omitempty"`
+	ActualContext  map[string]interface{} `json:"actual_context,omitempty"`
+	Failures       []string               `json:"failures,omitempty"`
+}
+
 // TestSuite representa una suite de pruebas
 type TestSuite struct {
 	ID          string                 `json:"id"`
@@ -515,4 +1432,122 @@ type TestSuiteResult struct {
 	StartedAt      time.Time              `json:"started_at"`
 	CompletedAt    time.Time              `json:"completed_at"`
 	TestResults    map[string]*TestResult `json:"test_results,omitempty"`
-}
\ No newline at end of file
+}
+
+// TestRunKind indica qué se ejecutó en un TestRun
+type TestRunKind string
+
+const (
+	TestRunKindCase  TestRunKind = "case"
+	TestRunKindSuite TestRunKind = "suite"
+)
+
+// TestRun representa una ejecución persistida de un caso o de una suite.
+// A diferencia de Result, que se sobrescribe, cada ejecución queda en el historial.
+type TestRun struct {
+	ID          string              `json:"id"`
+	Kind        TestRunKind         `json:"kind"`
+	BotID       string              `json:"bot_id"`
+	SuiteID     string              `json:"suite_id,omitempty"`
+	TestCaseID  string              `json:"test_case_id,omitempty"`
+	Name        string              `json:"name"`
+	Status      TestSuiteStatus     `json:"status"`
+	Total       int                 `json:"total"`
+	Passed      int                 `json:"passed"`
+	Failed      int                 `json:"failed"`
+	Errored     int                 `json:"errored"`
+	Skipped     int                 `json:"skipped"`
+	Duration    int64               `json:"duration"` // en milliseconds
+	Cases       []TestRunCaseResult `json:"cases"`
+	Regressions []string            `json:"regressions,omitempty"` // casos que pasaban en la ejecución anterior
+	Fixed       []string            `json:"fixed,omitempty"`       // casos que fallaban en la ejecución anterior
+	StartedAt   time.Time           `json:"started_at"`
+	CompletedAt time.Time           `json:"completed_at"`
+}
+
+// TestRunCaseResult es el resultado de un caso dentro de un TestRun, con la
+// diferencia entre lo esperado y lo obtenido
+type TestRunCaseResult struct {
+	TestCaseID       string   `json:"test_case_id"`
+	Name             string   `json:"name"`
+	Success          bool     `json:"success"`
+	Skipped          bool     `json:"skipped,omitempty"`
+	ExpectedResponse string   `json:"expected_response,omitempty"`
+	ActualResponse   string   `json:"actual_response,omitempty"`
+	Failures         []string `json:"failures,omitempty"`
+	Error            string   `json:"error,omitempty"`
+	ExecutionTime    int64    `json:"execution_time"` // en milliseconds
+}
+// Knowledge Base Entities
+
+// KnowledgeSourceType representa los conectores de sincronización soportados
+type KnowledgeSourceType string
+
+const (
+	KnowledgeSourceWebsite    KnowledgeSourceType = "website"
+	KnowledgeSourceNotion     KnowledgeSourceType = "notion"
+	KnowledgeSourceConfluence KnowledgeSourceType = "confluence"
+)
+
+// SyncState representa el estado de sincronización de una fuente
+type SyncState string
+
+const (
+	SyncStateIdle    SyncState = "idle"
+	SyncStateRunning SyncState = "running"
+	SyncStateSuccess SyncState = "success"
+	SyncStateFailed  SyncState = "failed"
+)
+
+// KnowledgeSource es una fuente externa de documentos para la base de conocimiento de un bot
+type KnowledgeSource struct {
+	ID                  string                 `json:"id"`
+	BotID               string                 `json:"bot_id"`
+	Name                string                 `json:"name" binding:"required"`
+	Type                KnowledgeSourceType    `json:"type" binding:"required"`
+	Config              map[string]interface{} `json:"config"`
+	SyncIntervalMinutes int                    `json:"sync_interval_minutes"` // 0 = sólo sincronización manual
+	Enabled             bool                   `json:"enabled"`
+	Status              SyncStatus             `json:"status"`
+	CreatedAt           time.Time              `json:"created_at"`
+	UpdatedAt           time.Time              `json:"updated_at"`
+}
+
+// SyncStatus resume la última sincronización de una fuente
+type SyncStatus struct {
+	State      SyncState  `json:"state"`
+	StartedAt  *time.Time `json:"started_at,omitempty"`
+	LastSyncAt *time.Time `json:"last_sync_at,omitempty"`
+	Duration   int64      `json:"duration"` // en milliseconds
+	Added      int        `json:"added"`
+	Updated    int        `json:"updated"`
+	Removed    int        `json:"removed"`
+	Unchanged  int        `json:"unchanged"`
+	Failed     int        `json:"failed"`
+	LastError  string     `json:"last_error,omitempty"`
+}
+
+// KnowledgeDocument es un documento indexado en la base de conocimiento
+type KnowledgeDocument struct {
+	ID               string    `json:"id"`
+	BotID            string    `json:"bot_id"`
+	SourceID         string    `json:"source_id"`
+	ExternalID       string    `json:"external_id"`
+	Title            string    `json:"title"`
+	URL              string    `json:"url,omitempty"`
+	Content          string    `json:"-"`
+	ContentHash      string    `json:"content_hash"`
+	Chunks           []string  `json:"-"`
+	SourceModifiedAt time.Time `json:"source_modified_at"`
+	IndexedAt        time.Time `json:"indexed_at"`
+}
+
+// KnowledgeSearchResult es un fragmento de la base de conocimiento que responde una consulta
+type KnowledgeSearchResult struct {
+	DocumentID string  `json:"document_id"`
+	SourceID   string  `json:"source_id"`
+	Title      string  `json:"title"`
+	URL        string  `json:"url,omitempty"`
+	Chunk      string  `json:"chunk"`
+	Score      float64 `json:"score"`
+}
@@ -0,0 +1,116 @@
+package domain
+
+import "net/http"
+
+// Códigos de APIResponse.Code. Forman parte del contrato público de la API:
+// no se renombran ni se reutilizan con otro significado.
+const (
+	CodeSuccess = "SUCCESS"
+
+	// Errores genéricos
+	CodeInvalidRequest     = "INVALID_REQUEST"
+	CodeNotFound           = "NOT_FOUND"
+	CodeInternalError      = "INTERNAL_ERROR"
+	CodeServiceUnavailable = "SERVICE_UNAVAILABLE"
+
+	// Autenticación y permisos
+	CodeUnauthorized            = "UNAUTHORIZED"
+	CodeInvalidToken            = "INVALID_TOKEN"
+	CodeForbidden               = "FORBIDDEN"
+	CodeInsufficientPermissions = "INSUFFICIENT_PERMISSIONS"
+	CodeInvalidAPIKey           = "INVALID_API_KEY"
+	CodeRateLimited             = "RATE_LIMITED"
+
+	// Recursos no encontrados
+	CodeBotNotFound             = "BOT_NOT_FOUND"
+	CodeFlowNotFound            = "FLOW_NOT_FOUND"
+	CodeFlowVersionNotFound     = "FLOW_VERSION_NOT_FOUND"
+	CodeFAQNotFound             = "FAQ_NOT_FOUND"
+	CodeConversationNotFound    = "CONVERSATION_NOT_FOUND"
+	CodeKnowledgeSourceNotFound = "KNOWLEDGE_SOURCE_NOT_FOUND"
+	CodeAgentNotFound           = "AGENT_NOT_FOUND"
+	CodeTaskNotFound            = "TASK_NOT_FOUND"
+	CodeDeadLetterNotFound      = "DEAD_LETTER_NOT_FOUND"
+	CodeConditionalNotFound     = "CONDITIONAL_NOT_FOUND"
+	CodeTriggerNotFound         = "TRIGGER_NOT_FOUND"
+	CodeTestCaseNotFound        = "TEST_CASE_NOT_FOUND"
+	CodeTestSuiteNotFound       = "TEST_SUITE_NOT_FOUND"
+	CodeTestRunNotFound         = "TEST_RUN_NOT_FOUND"
+	CodeAPIKeyNotFound          = "API_KEY_NOT_FOUND"
+	CodeWebhookNotFound         = "WEBHOOK_NOT_FOUND"
+	CodeWorkflowNotFound        = "WORKFLOW_NOT_FOUND"
+	CodeScriptApprovalNotFound  = "SCRIPT_APPROVAL_NOT_FOUND"
+	CodeMemoryNotFound          = "MEMORY_NOT_FOUND"
+	CodeIntentExampleNotFound   = "INTENT_EXAMPLE_NOT_FOUND"
+
+	// Errores de dominio
+	CodeFlowInvalid      = "FLOW_INVALID"
+	CodeHandoffConflict  = "HANDOFF_CONFLICT"
+	CodeSyncFailed       = "SYNC_FAILED"
+	CodeQuotaExceeded    = "QUOTA_EXCEEDED"
+	CodeAgentUnavailable = "AGENT_UNAVAILABLE"
+	CodeOverloaded       = "OVERLOADED"
+)
+
+// ErrorCodeInfo describe un código del catálogo para los SDKs cliente
+type ErrorCodeInfo struct {
+	Code        string `json:"code"`
+	HTTPStatus  int    `json:"http_status"`
+	Description string `json:"description"`
+	// Retryable indica si repetir la misma petición más tarde puede funcionar
+	Retryable bool `json:"retryable"`
+}
+
+var errorCatalog = []ErrorCodeInfo{
+	{CodeInvalidRequest, http.StatusBadRequest, "The request body or parameters are invalid", false},
+	{CodeNotFound, http.StatusNotFound, "The requested resource does not exist", false},
+	{CodeInternalError, http.StatusInternalServerError, "Unexpected server error", true},
+	{CodeServiceUnavailable, http.StatusServiceUnavailable, "The service is not ready to accept traffic", true},
+	{CodeUnauthorized, http.StatusUnauthorized, "Missing or malformed Authorization header", false},
+	{CodeInvalidToken, http.StatusUnauthorized, "The access token is invalid or expired", false},
+	{CodeForbidden, http.StatusForbidden, "The caller has no roles attached", false},
+	{CodeInsufficientPermissions, http.StatusForbidden, "The caller lacks the role required by the endpoint", false},
+	{CodeInvalidAPIKey, http.StatusUnauthorized, "The API key is unknown, revoked or expired", false},
+	{CodeRateLimited, http.StatusTooManyRequests, "The API key exceeded its requests per minute; see Retry-After", true},
+	{CodeBotNotFound, http.StatusNotFound, "The bot does not exist", false},
+	{CodeFlowNotFound, http.StatusNotFound, "The flow does not exist", false},
+	{CodeFlowVersionNotFound, http.StatusNotFound, "The flow has no version with that number", false},
+	{CodeFAQNotFound, http.StatusNotFound, "The FAQ entry does not exist or no entry matches the question", false},
+	{CodeConversationNotFound, http.StatusNotFound, "The conversation session does not exist", false},
+	{CodeKnowledgeSourceNotFound, http.StatusNotFound, "The knowledge source does not exist", false},
+	{CodeAgentNotFound, http.StatusNotFound, "The MCP agent does not exist", false},
+	{CodeTaskNotFound, http.StatusNotFound, "The async task does not exist", false},
+	{CodeDeadLetterNotFound, http.StatusNotFound, "The dead-letter entry does not exist", false},
+	{CodeConditionalNotFound, http.StatusNotFound, "The conditional does not exist", false},
+	{CodeTriggerNotFound, http.StatusNotFound, "The trigger does not exist", false},
+	{CodeTestCaseNotFound, http.StatusNotFound, "The test case or conversation test does not exist", false},
+	{CodeTestSuiteNotFound, http.StatusNotFound, "The test suite does not exist", false},
+	{CodeTestRunNotFound, http.StatusNotFound, "The test execution does not exist", false},
+	{CodeAPIKeyNotFound, http.StatusNotFound, "The API key does not exist", false},
+	{CodeWebhookNotFound, http.StatusNotFound, "The webhook subscription or delivery does not exist", false},
+	{CodeWorkflowNotFound, http.StatusNotFound, "The workflow definition or version does not exist", false},
+	{CodeScriptApprovalNotFound, http.StatusNotFound, "The sandbox script approval does not exist", false},
+	{CodeMemoryNotFound, http.StatusNotFound, "The user memory does not exist or has expired", false},
+	{CodeIntentExampleNotFound, http.StatusNotFound, "The intent training example does not exist", false},
+	{CodeFlowInvalid, http.StatusBadRequest, "The flow draft cannot be published: missing entry point or broken step references", false},
+	{CodeHandoffConflict, http.StatusConflict, "The conversation is not in a state that allows the handoff operation", false},
+	{CodeSyncFailed, http.StatusBadGateway, "Synchronizing the knowledge source with its origin failed", true},
+	{CodeQuotaExceeded, http.StatusTooManyRequests, "A capacity limit was reached, such as a full task queue", true},
+	{CodeAgentUnavailable, http.StatusServiceUnavailable, "No healthy idle MCP agent can handle the task type", true},
+	{CodeOverloaded, http.StatusServiceUnavailable, "The task queue or AI provider is saturated; retry after Retry-After seconds", true},
+}
+
+// ErrorCatalog devuelve todos los códigos de error de la API
+func ErrorCatalog() []ErrorCodeInfo {
+	return append([]ErrorCodeInfo(nil), errorCatalog...)
+}
+
+// LookupErrorCode busca un código del catálogo
+func LookupErrorCode(code string) (ErrorCodeInfo, bool) {
+	for _, info := range errorCatalog {
+		if info.Code == code {
+			return info, true
+		}
+	}
+	return ErrorCodeInfo{}, false
+}
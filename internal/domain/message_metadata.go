@@ -0,0 +1,68 @@
+package domain
+
+// Espacios de nombres reservados en IncomingMessage.Metadata. Sus claves se
+// copian al contexto de evaluación de condiciones, pasos de decisión y
+// plantillas como "<namespace>.<clave>" (p. ej. {{user.name}}).
+const (
+	MetadataNamespaceChannel = "channel"
+	MetadataNamespaceUser    = "user"
+	MetadataNamespaceMessage = "message"
+)
+
+// MetadataField documenta una clave conocida de un espacio de nombres.
+// BuiltIn indica que la rellena el motor y no se puede sobrescribir.
+type MetadataField struct {
+	Key         string `json:"key"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	BuiltIn     bool   `json:"built_in"`
+}
+
+// MetadataNamespace describe un espacio de nombres reservado. Los canales
+// pueden enviar cualquier clave dentro de él, no solo las documentadas.
+type MetadataNamespace struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Fields      []MetadataField `json:"fields"`
+}
+
+var metadataNamespaces = []MetadataNamespace{
+	{
+		Name:        MetadataNamespaceChannel,
+		Description: "Channel the message arrived through",
+		Fields: []MetadataField{
+			{"channel.type", "string", "Channel type of the message (web, whatsapp, telegram...)", true},
+			{"channel.id", "string", "Channel account or phone number that received the message", false},
+			{"channel.conversation_id", "string", "Conversation or chat ID on the channel", false},
+			{"channel.thread_id", "string", "Thread the message belongs to, when the channel has threads", false},
+		},
+	},
+	{
+		Name:        MetadataNamespaceUser,
+		Description: "Profile of the user as known by the channel",
+		Fields: []MetadataField{
+			{"user.id", "string", "User ID of the message", true},
+			{"user.name", "string", "Display name", false},
+			{"user.locale", "string", "Preferred language, e.g. es-AR", false},
+			{"user.timezone", "string", "IANA time zone, e.g. America/Bogota", false},
+			{"user.phone", "string", "Phone number", false},
+			{"user.email", "string", "Email address", false},
+		},
+	},
+	{
+		Name:        MetadataNamespaceMessage,
+		Description: "The incoming message itself",
+		Fields: []MetadataField{
+			{"message.id", "string", "Message ID", true},
+			{"message.text", "string", "Message content", true},
+			{"message.timestamp", "time", "When the message was sent", true},
+			{"message.type", "string", "Content type sent by the channel (text, audio, image, location...)", false},
+			{"message.reply_to", "string", "ID of the message being replied to", false},
+		},
+	},
+}
+
+// MetadataNamespaces devuelve los espacios de nombres reservados y sus claves conocidas
+func MetadataNamespaces() []MetadataNamespace {
+	return append([]MetadataNamespace(nil), metadataNamespaces...)
+}
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // UserRepository define las operaciones de persistencia para usuarios
 type UserRepository interface {
@@ -17,6 +20,61 @@ type AuditRepository interface {
 	Create(ctx context.Context, log *AuditLog) error
 	GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*AuditLog, error)
 	GetByAction(ctx context.Context, action string, limit, offset int) ([]*AuditLog, error)
+	List(ctx context.Context, limit, offset int) ([]*AuditLog, error)
+}
+
+// APIKeyRepository define las operaciones de persistencia para API keys
+type APIKeyRepository interface {
+	GetByID(ctx context.Context, id string) (*APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*APIKey, error)
+	Create(ctx context.Context, key *APIKey) error
+	Update(ctx context.Context, key *APIKey) error
+	List(ctx context.Context) ([]*APIKey, error)
+}
+
+// WebhookSubscriptionRepository define las operaciones de persistencia para suscripciones de webhooks
+type WebhookSubscriptionRepository interface {
+	GetByID(ctx context.Context, id string) (*WebhookSubscription, error)
+	Create(ctx context.Context, subscription *WebhookSubscription) error
+	Update(ctx context.Context, subscription *WebhookSubscription) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context) ([]*WebhookSubscription, error)
+}
+
+// WebhookDeliveryRepository guarda el registro de entregas de webhooks
+type WebhookDeliveryRepository interface {
+	GetByID(ctx context.Context, id string) (*WebhookDelivery, error)
+	Create(ctx context.Context, delivery *WebhookDelivery) error
+	Update(ctx context.Context, delivery *WebhookDelivery) error
+	// GetBySubscriptionID devuelve las entregas más recientes primero y el total
+	GetBySubscriptionID(ctx context.Context, subscriptionID string, limit, offset int) ([]*WebhookDelivery, int, error)
+	DeleteBySubscriptionID(ctx context.Context, subscriptionID string) error
+}
+
+// AgentExecutionRepository guarda las ejecuciones de agentes capturadas
+type AgentExecutionRepository interface {
+	GetByID(ctx context.Context, id string) (*AgentExecution, error)
+	Create(ctx context.Context, execution *AgentExecution) error
+	// Search devuelve las ejecuciones más recientes primero y el total que cumple el filtro
+	Search(ctx context.Context, filter AgentExecutionFilter) ([]*AgentExecution, int, error)
+	// DeleteBefore elimina las capturadas antes de cutoff y devuelve cuántas eliminó
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int, error)
+}
+
+// AnalyticsRepository guarda los contadores diarios de actividad de los bots
+type AnalyticsRepository interface {
+	// Increment suma delta a los contadores del día delta.Date del bot delta.BotID
+	Increment(ctx context.Context, delta *BotDailyStats) error
+	// GetRange devuelve los días con actividad entre from y to (YYYY-MM-DD, ambos incluidos) en orden
+	GetRange(ctx context.Context, botID, from, to string) ([]*BotDailyStats, error)
+}
+
+// UsageRepository guarda el consumo diario de IA de los bots
+type UsageRepository interface {
+	// Increment suma delta al consumo del día delta.Date del bot delta.BotID
+	Increment(ctx context.Context, delta *BotDailyUsage) error
+	// GetRange devuelve los días con consumo entre from y to (YYYY-MM-DD, ambos incluidos) en orden
+	GetRange(ctx context.Context, botID, from, to string) ([]*BotDailyUsage, error)
 }
 
 // HealthRepository define las operaciones para health checks
@@ -54,6 +112,15 @@ type BotStepRepository interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// FlowVersionRepository define las operaciones de persistencia para versiones de flujo
+type FlowVersionRepository interface {
+	Create(ctx context.Context, version *FlowVersion) error
+	Update(ctx context.Context, version *FlowVersion) error
+	GetByVersion(ctx context.Context, flowID string, version int) (*FlowVersion, error)
+	GetByFlowID(ctx context.Context, flowID string) ([]*FlowVersion, error)
+	DeleteByFlowID(ctx context.Context, flowID string) error
+}
+
 // SmartReplyRepository define las operaciones de persistencia para respuestas inteligentes
 type SmartReplyRepository interface {
 	GetByID(ctx context.Context, id string) (*SmartReply, error)
@@ -64,6 +131,68 @@ type SmartReplyRepository interface {
 	Delete(ctx context.Context, id string) error
 }
 
+// IntentExampleRepository define las operaciones de persistencia para los
+// ejemplos de entrenamiento de intents
+type IntentExampleRepository interface {
+	GetByID(ctx context.Context, id string) (*IntentExample, error)
+	GetByBotID(ctx context.Context, botID string) ([]*IntentExample, error)
+	GetByIntent(ctx context.Context, botID, intent string) ([]*IntentExample, error)
+	Create(ctx context.Context, example *IntentExample) error
+	Delete(ctx context.Context, id string) error
+	DeleteByIntent(ctx context.Context, botID, intent string) error
+}
+
+// MCPAgentRepository guarda los agentes MCP persistentes para restaurarlos al arrancar
+type MCPAgentRepository interface {
+	GetByID(ctx context.Context, id string) (*MCPAgent, error)
+	Create(ctx context.Context, agent *MCPAgent) error
+	Update(ctx context.Context, agent *MCPAgent) error
+	Delete(ctx context.Context, id string) error
+	// List devuelve los agentes del más antiguo al más reciente
+	List(ctx context.Context) ([]*MCPAgent, error)
+}
+
+// ScriptApprovalRepository guarda los hashes de scripts aprobados para los
+// agentes sandbox
+type ScriptApprovalRepository interface {
+	Get(ctx context.Context, hash string) (*ScriptApproval, error)
+	List(ctx context.Context) ([]*ScriptApproval, error)
+	// Create reemplaza la aprobación si el hash ya estaba aprobado
+	Create(ctx context.Context, approval *ScriptApproval) error
+	Delete(ctx context.Context, hash string) error
+}
+
+// WorkflowDefinitionRepository guarda los workflows con una copia de cada versión
+type WorkflowDefinitionRepository interface {
+	// GetByID devuelve la última versión
+	GetByID(ctx context.Context, id string) (*WorkflowDefinition, error)
+	List(ctx context.Context) ([]*WorkflowDefinition, error)
+	Create(ctx context.Context, definition *WorkflowDefinition) error
+	// Update guarda la definición como una versión más
+	Update(ctx context.Context, definition *WorkflowDefinition) error
+	// Delete borra el workflow con todas sus versiones
+	Delete(ctx context.Context, id string) error
+	GetVersion(ctx context.Context, id string, version int) (*WorkflowDefinition, error)
+	// ListVersions devuelve las versiones de la más reciente a la más antigua
+	ListVersions(ctx context.Context, id string) ([]*WorkflowDefinition, error)
+}
+
+// FAQRepository define las operaciones de persistencia para la FAQ
+type FAQRepository interface {
+	GetByID(ctx context.Context, id string) (*FAQEntry, error)
+	GetByBotID(ctx context.Context, botID string) ([]*FAQEntry, error)
+	Create(ctx context.Context, entry *FAQEntry) error
+	Update(ctx context.Context, entry *FAQEntry) error
+	Delete(ctx context.Context, id string) error
+}
+
+// UnansweredQuestionRepository define las operaciones de persistencia para preguntas sin respuesta
+type UnansweredQuestionRepository interface {
+	Create(ctx context.Context, question *UnansweredQuestion) error
+	GetByBotID(ctx context.Context, botID string) ([]*UnansweredQuestion, error)
+	DeleteByBotID(ctx context.Context, botID string) (int, error)
+}
+
 // ConversationSessionRepository define las operaciones para sesiones de conversación
 type ConversationSessionRepository interface {
 	GetByID(ctx context.Context, id string) (*ConversationSession, error)
@@ -72,6 +201,21 @@ type ConversationSessionRepository interface {
 	Update(ctx context.Context, session *ConversationSession) error
 	Delete(ctx context.Context, id string) error
 	DeleteExpired(ctx context.Context) error
+	GetInactiveSince(ctx context.Context, botID string, before time.Time) ([]*ConversationSession, error)
+	GetHandoffs(ctx context.Context, botID string) ([]*ConversationSession, error)
+	Stats(ctx context.Context) (*SessionStoreStats, error)
+}
+
+// ConversationMessageRepository guarda el historial completo de mensajes de
+// las conversaciones, que se conserva aunque la sesión caduque
+type ConversationMessageRepository interface {
+	Create(ctx context.Context, message *ConversationMessage) error
+	// GetConversation devuelve el resumen de la conversación de una sesión
+	GetConversation(ctx context.Context, sessionID string) (*ConversationThread, error)
+	// ListConversations devuelve las conversaciones del bot con actividad más reciente primero y el total
+	ListConversations(ctx context.Context, botID string, filter ConversationHistoryFilter) ([]*ConversationThread, int, error)
+	// GetBySessionID devuelve los mensajes de la sesión en orden cronológico y el total
+	GetBySessionID(ctx context.Context, sessionID string, filter ConversationHistoryFilter) ([]*ConversationMessage, int, error)
 }
 
 // ConditionalRepository define las operaciones de persistencia para condiciones
@@ -91,6 +235,7 @@ type TriggerRepository interface {
 	GetByBotID(ctx context.Context, botID string) ([]*Trigger, error)
 	GetByEvent(ctx context.Context, botID string, event TriggerEvent) ([]*Trigger, error)
 	GetEnabledByBotID(ctx context.Context, botID string) ([]*Trigger, error)
+	GetEnabledByEvent(ctx context.Context, event TriggerEvent) ([]*Trigger, error)
 	Create(ctx context.Context, trigger *Trigger) error
 	Update(ctx context.Context, trigger *Trigger) error
 	Delete(ctx context.Context, id string) error
@@ -109,6 +254,24 @@ type TestCaseRepository interface {
 	BulkExecute(ctx context.Context, ids []string) (map[string]*TestResult, error)
 }
 
+// TestRunRepository define las operaciones de persistencia para el historial de ejecuciones
+type TestRunRepository interface {
+	Create(ctx context.Context, run *TestRun) error
+	GetByID(ctx context.Context, id string) (*TestRun, error)
+	// GetBySuiteID y GetByTestCaseID devuelven las ejecuciones más recientes primero y el total
+	GetBySuiteID(ctx context.Context, suiteID string, limit, offset int) ([]*TestRun, int, error)
+	GetByTestCaseID(ctx context.Context, testCaseID string, limit, offset int) ([]*TestRun, int, error)
+}
+
+// MultiTurnTestCaseRepository define las operaciones de persistencia para pruebas de conversación
+type MultiTurnTestCaseRepository interface {
+	GetByID(ctx context.Context, id string) (*MultiTurnTestCase, error)
+	GetByBotID(ctx context.Context, botID string) ([]*MultiTurnTestCase, error)
+	Create(ctx context.Context, testCase *MultiTurnTestCase) error
+	Update(ctx context.Context, testCase *MultiTurnTestCase) error
+	Delete(ctx context.Context, id string) error
+}
+
 // TestSuiteRepository define las operaciones de persistencia para suites de prueba
 type TestSuiteRepository interface {
 	GetByID(ctx context.Context, id string) (*TestSuite, error)
@@ -120,4 +283,43 @@ type TestSuiteRepository interface {
 	Execute(ctx context.Context, id string) (*TestSuiteResult, error)
 	AddTestCase(ctx context.Context, suiteID, testCaseID string) error
 	RemoveTestCase(ctx context.Context, suiteID, testCaseID string) error
-}
\ No newline at end of file
+}
+// TaskRepository define las operaciones de persistencia para tareas asíncronas
+type TaskRepository interface {
+	GetByID(ctx context.Context, id string) (*AsyncTask, error)
+	GetByStatus(ctx context.Context, statuses ...TaskStatus) ([]*AsyncTask, error)
+	Create(ctx context.Context, task *AsyncTask) error
+	Update(ctx context.Context, task *AsyncTask) error
+	// List devuelve las tareas que cumplen el filtro, de la más reciente a la más antigua
+	List(ctx context.Context, filter TaskFilter) ([]*AsyncTask, error)
+	DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error)
+}
+
+// DeadLetterRepository define las operaciones de persistencia para tareas en dead-letter
+type DeadLetterRepository interface {
+	GetByID(ctx context.Context, id string) (*DeadLetterTask, error)
+	Create(ctx context.Context, task *DeadLetterTask) error
+	List(ctx context.Context, limit, offset int) ([]*DeadLetterTask, error)
+	Delete(ctx context.Context, id string) error
+	DeleteAll(ctx context.Context) (int, error)
+	Count(ctx context.Context) (int, error)
+}
+
+// KnowledgeSourceRepository define las operaciones de persistencia para fuentes de conocimiento
+type KnowledgeSourceRepository interface {
+	GetByID(ctx context.Context, id string) (*KnowledgeSource, error)
+	GetByBotID(ctx context.Context, botID string) ([]*KnowledgeSource, error)
+	GetEnabled(ctx context.Context) ([]*KnowledgeSource, error)
+	Create(ctx context.Context, source *KnowledgeSource) error
+	Update(ctx context.Context, source *KnowledgeSource) error
+	Delete(ctx context.Context, id string) error
+}
+
+// KnowledgeDocumentRepository define las operaciones de persistencia para documentos indexados
+type KnowledgeDocumentRepository interface {
+	GetBySourceID(ctx context.Context, sourceID string) ([]*KnowledgeDocument, error)
+	GetByBotID(ctx context.Context, botID string) ([]*KnowledgeDocument, error)
+	Save(ctx context.Context, document *KnowledgeDocument) error
+	Delete(ctx context.Context, id string) error
+	DeleteBySourceID(ctx context.Context, sourceID string) (int, error)
+}
@@ -0,0 +1,159 @@
+package grpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/grpc/botpb"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func botToProto(bot *domain.Bot) (*botpb.Bot, error) {
+	config, err := rawToStruct(bot.Config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config of bot %s: %w", bot.ID, err)
+	}
+	return &botpb.Bot{
+		Id:        bot.ID,
+		Name:      bot.Name,
+		OwnerId:   bot.OwnerID,
+		Channel:   string(bot.Channel),
+		Status:    string(bot.Status),
+		Config:    config,
+		CreatedAt: timestampToProto(bot.CreatedAt),
+		UpdatedAt: timestampToProto(bot.UpdatedAt),
+	}, nil
+}
+
+func botFromProto(bot *botpb.Bot) (*domain.Bot, error) {
+	config, err := structToRaw(bot.GetConfig())
+	if err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &domain.Bot{
+		ID:      bot.GetId(),
+		Name:    bot.GetName(),
+		OwnerID: bot.GetOwnerId(),
+		Channel: domain.ChannelType(bot.GetChannel()),
+		Status:  domain.BotStatus(bot.GetStatus()),
+		Config:  config,
+	}, nil
+}
+
+func flowToProto(flow *domain.BotFlow) (*botpb.Flow, error) {
+	var triggerConfig *structpb.Struct
+	if flow.TriggerConfig != nil {
+		raw, err := json.Marshal(flow.TriggerConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode trigger config of flow %s: %w", flow.ID, err)
+		}
+		if triggerConfig, err = rawToStruct(raw); err != nil {
+			return nil, fmt.Errorf("failed to encode trigger config of flow %s: %w", flow.ID, err)
+		}
+	}
+	return &botpb.Flow{
+		Id:               flow.ID,
+		BotId:            flow.BotID,
+		Name:             flow.Name,
+		Trigger:          flow.Trigger,
+		EntryPoint:       flow.EntryPoint,
+		IsDefault:        flow.IsDefault,
+		TriggerConfig:    triggerConfig,
+		PublishedVersion: int32(flow.PublishedVersion),
+		CreatedAt:        timestampToProto(flow.CreatedAt),
+		UpdatedAt:        timestampToProto(flow.UpdatedAt),
+	}, nil
+}
+
+// flowFromProto no copia published_version: solo cambia al publicar o hacer rollback
+func flowFromProto(flow *botpb.Flow) (*domain.BotFlow, error) {
+	result := &domain.BotFlow{
+		ID:         flow.GetId(),
+		BotID:      flow.GetBotId(),
+		Name:       flow.GetName(),
+		Trigger:    flow.GetTrigger(),
+		EntryPoint: flow.GetEntryPoint(),
+		IsDefault:  flow.GetIsDefault(),
+	}
+	if flow.GetTriggerConfig() != nil {
+		raw, err := structToRaw(flow.GetTriggerConfig())
+		if err != nil {
+			return nil, fmt.Errorf("invalid trigger config: %w", err)
+		}
+		result.TriggerConfig = &domain.FlowTrigger{}
+		if err := json.Unmarshal(raw, result.TriggerConfig); err != nil {
+			return nil, fmt.Errorf("invalid trigger config: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func messageFromProto(message *botpb.IncomingMessage) *domain.IncomingMessage {
+	result := &domain.IncomingMessage{
+		ID:       message.GetId(),
+		BotID:    message.GetBotId(),
+		UserID:   message.GetUserId(),
+		Content:  message.GetContent(),
+		Channel:  domain.ChannelType(message.GetChannel()),
+		Metadata: message.GetMetadata().AsMap(),
+	}
+	if message.GetTimestamp() != nil {
+		result.Timestamp = message.GetTimestamp().AsTime()
+	}
+	return result
+}
+
+func responseToProto(response *domain.BotResponse) (*botpb.BotResponse, error) {
+	result := &botpb.BotResponse{
+		Content: response.Content,
+		Type:    string(response.Type),
+		Options: make([]*botpb.ResponseOption, 0, len(response.Options)),
+	}
+	for _, option := range response.Options {
+		result.Options = append(result.Options, &botpb.ResponseOption{Id: option.ID, Text: option.Text, Value: option.Value})
+	}
+	if response.NextStepID != nil {
+		result.NextStepId = *response.NextStepID
+	}
+	if len(response.Metadata) > 0 {
+		// El metadata puede llevar tipos que structpb no admite directamente
+		// (structs, []string); pasar por JSON los normaliza
+		raw, err := json.Marshal(response.Metadata)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode response metadata: %w", err)
+		}
+		if result.Metadata, err = rawToStruct(raw); err != nil {
+			return nil, fmt.Errorf("failed to encode response metadata: %w", err)
+		}
+	}
+	return result, nil
+}
+
+func rawToStruct(raw json.RawMessage) (*structpb.Struct, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	result := &structpb.Struct{}
+	if err := protojson.Unmarshal(raw, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func structToRaw(value *structpb.Struct) (json.RawMessage, error) {
+	if value == nil {
+		return nil, nil
+	}
+	return protojson.Marshal(value)
+}
+
+func timestampToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,40 @@
+package grpc
+
+import (
+	"github.com/company/bot-service/internal/domain"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain identifica a este servicio en el ErrorInfo de los errores gRPC
+const errorDomain = "bot-service"
+
+// grpcCodes traduce los estados HTTP del catálogo de errores a códigos gRPC
+var grpcCodes = map[int]codes.Code{
+	400: codes.InvalidArgument,
+	401: codes.Unauthenticated,
+	403: codes.PermissionDenied,
+	404: codes.NotFound,
+	409: codes.FailedPrecondition,
+	429: codes.ResourceExhausted,
+	502: codes.Unavailable,
+	503: codes.Unavailable,
+}
+
+// apiError crea un error gRPC con el código del catálogo como reason del ErrorInfo,
+// para que los clientes gRPC y REST distingan los errores igual
+func apiError(code, message string) error {
+	grpcCode := codes.Internal
+	if info, ok := domain.LookupErrorCode(code); ok {
+		if mapped, ok := grpcCodes[info.HTTPStatus]; ok {
+			grpcCode = mapped
+		}
+	}
+
+	st := status.New(grpcCode, message)
+	if detailed, err := st.WithDetails(&errdetails.ErrorInfo{Reason: code, Domain: errorDomain}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
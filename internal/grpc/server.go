@@ -0,0 +1,99 @@
+// Package grpc expone la capa de servicios por gRPC (proto/bot/v1/bot.proto)
+// junto a los handlers REST de Gin.
+package grpc
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/grpc/botpb"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// Server es el servidor gRPC del servicio
+type Server struct {
+	server *grpclib.Server
+	logger logger.Logger
+}
+
+// NewServer registra BotService y FlowService sobre los mismos servicios que usan los handlers REST
+func NewServer(botService services.BotService, flowService services.BotFlowService, logger logger.Logger) *Server {
+	s := &Server{logger: logger}
+	s.server = grpclib.NewServer(grpclib.ChainUnaryInterceptor(s.logInterceptor, s.recoverInterceptor))
+
+	botpb.RegisterBotServiceServer(s.server, &botServer{botService: botService, logger: logger})
+	botpb.RegisterFlowServiceServer(s.server, &flowServer{flowService: flowService, logger: logger})
+	reflection.Register(s.server)
+	return s
+}
+
+// Serve atiende conexiones en lis hasta que se llame a Stop
+func (s *Server) Serve(lis net.Listener) error {
+	return s.server.Serve(lis)
+}
+
+// Stop espera a que terminen las llamadas en curso; si ctx vence antes, las corta
+func (s *Server) Stop(ctx context.Context) {
+	done := make(chan struct{})
+	go func() {
+		s.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		s.server.Stop()
+	}
+}
+
+// logInterceptor propaga x-request-id/x-correlation-id como el middleware HTTP
+func (s *Server) logInterceptor(ctx context.Context, req interface{}, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	requestID := incomingMetadata(ctx, "x-request-id")
+	if requestID == "" {
+		requestID = id.New()
+	}
+	correlationID := incomingMetadata(ctx, "x-correlation-id")
+	if correlationID == "" {
+		correlationID = requestID
+	}
+	ctx = logger.ContextWithFields(ctx, logger.RequestIDKey, requestID, logger.CorrelationIDKey, correlationID)
+	_ = grpclib.SetHeader(ctx, metadata.Pairs("x-request-id", requestID, "x-correlation-id", correlationID))
+
+	resp, err := handler(ctx, req)
+	s.logger.WithContext(ctx).Info("gRPC request",
+		"method", info.FullMethod,
+		"code", status.Code(err).String(),
+		"duration", time.Since(start))
+	return resp, err
+}
+
+func (s *Server) recoverInterceptor(ctx context.Context, req interface{}, info *grpclib.UnaryServerInfo, handler grpclib.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.logger.WithContext(ctx).Error("gRPC handler panicked", "method", info.FullMethod, "panic", r)
+			err = apiError(domain.CodeInternalError, "Internal server error")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+func incomingMetadata(ctx context.Context, key string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(key); len(values) > 0 && len(values[0]) <= 128 {
+		return values[0]
+	}
+	return ""
+}
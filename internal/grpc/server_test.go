@@ -0,0 +1,83 @@
+package grpc
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/grpc/botpb"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	grpclib "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestServer_BotFlowAndMessages(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	versionRepo := repositories.NewMockFlowVersionRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	botService := services.NewBotService(botRepo, flowRepo, stepRepo, versionRepo, sessionRepo, nil,
+		services.NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, nil, nil, log)
+	flowService := services.NewBotFlowService(flowRepo, stepRepo, versionRepo, log)
+
+	lis := bufconn.Listen(1 << 20)
+	server := NewServer(botService, flowService, log)
+	go server.Serve(lis)
+	defer server.Stop(ctx)
+
+	conn, err := grpclib.DialContext(ctx, "bufnet",
+		grpclib.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpclib.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+	bots := botpb.NewBotServiceClient(conn)
+	flows := botpb.NewFlowServiceClient(conn)
+
+	config, err := structpb.NewStruct(map[string]interface{}{"summary": map[string]interface{}{"enabled": true}})
+	require.NoError(t, err)
+	bot, err := bots.CreateBot(ctx, &botpb.CreateBotRequest{Bot: &botpb.Bot{
+		Name: "Support", OwnerId: "owner-1", Channel: "web", Status: string(domain.BotStatusActive), Config: config}})
+	require.NoError(t, err)
+	assert.NotEmpty(t, bot.Id)
+	assert.NotNil(t, bot.CreatedAt)
+
+	listed, err := bots.ListBots(ctx, &botpb.ListBotsRequest{OwnerId: "owner-1"})
+	require.NoError(t, err)
+	require.Len(t, listed.Bots, 1)
+	assert.Equal(t, true, listed.Bots[0].Config.AsMap()["summary"].(map[string]interface{})["enabled"])
+
+	flow, err := flows.CreateFlow(ctx, &botpb.CreateFlowRequest{Flow: &botpb.Flow{
+		BotId: bot.Id, Name: "Welcome", EntryPoint: "hello", IsDefault: true}})
+	require.NoError(t, err)
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "hello", FlowID: flow.Id, Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Hi {{user_id}}","type":"buttons","options":[{"id":"1","text":"Help","value":"help"}]}`)}))
+
+	response, err := bots.ProcessIncomingMessage(ctx, &botpb.IncomingMessage{BotId: bot.Id, UserId: "user-1", Content: "hola"})
+	require.NoError(t, err)
+	assert.Equal(t, "Hi user-1", response.Content)
+	require.Len(t, response.Options, 1)
+	assert.Equal(t, "help", response.Options[0].Value)
+
+	_, err = bots.GetBot(ctx, &botpb.GetBotRequest{Id: "missing"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.NotFound, st.Code())
+	require.Len(t, st.Details(), 1)
+	assert.Equal(t, domain.CodeBotNotFound, st.Details()[0].(*errdetails.ErrorInfo).Reason)
+
+	_, err = flows.DeleteFlow(ctx, &botpb.DeleteFlowRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
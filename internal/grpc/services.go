@@ -0,0 +1,230 @@
+package grpc
+
+import (
+	"context"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/grpc/botpb"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// botServer implementa botpb.BotServiceServer sobre services.BotService
+type botServer struct {
+	botpb.UnimplementedBotServiceServer
+	botService services.BotService
+	logger     logger.Logger
+}
+
+func (s *botServer) GetBot(ctx context.Context, req *botpb.GetBotRequest) (*botpb.Bot, error) {
+	if req.GetId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "id is required")
+	}
+
+	bot, err := s.botService.GetBot(ctx, req.GetId())
+	if err != nil {
+		return nil, apiError(domain.CodeBotNotFound, "Bot not found")
+	}
+	return s.bot(bot)
+}
+
+func (s *botServer) ListBots(ctx context.Context, req *botpb.ListBotsRequest) (*botpb.ListBotsResponse, error) {
+	if req.GetOwnerId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "Owner ID is required")
+	}
+
+	bots, err := s.botService.GetBotsByOwner(ctx, req.GetOwnerId())
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to get bots", "owner_id", req.GetOwnerId(), "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to retrieve bots")
+	}
+
+	response := &botpb.ListBotsResponse{Bots: make([]*botpb.Bot, 0, len(bots))}
+	for _, bot := range bots {
+		converted, err := s.bot(bot)
+		if err != nil {
+			return nil, err
+		}
+		response.Bots = append(response.Bots, converted)
+	}
+	return response, nil
+}
+
+func (s *botServer) CreateBot(ctx context.Context, req *botpb.CreateBotRequest) (*botpb.Bot, error) {
+	if req.GetBot() == nil {
+		return nil, apiError(domain.CodeInvalidRequest, "bot is required")
+	}
+	bot, err := botFromProto(req.GetBot())
+	if err != nil {
+		return nil, apiError(domain.CodeInvalidRequest, "Invalid bot data: "+err.Error())
+	}
+	if bot.ID == "" {
+		bot.ID = id.New()
+	}
+
+	if err := s.botService.CreateBot(ctx, bot); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to create bot", "bot_id", bot.ID, "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to create bot")
+	}
+	return s.bot(bot)
+}
+
+func (s *botServer) UpdateBot(ctx context.Context, req *botpb.UpdateBotRequest) (*botpb.Bot, error) {
+	if req.GetBot().GetId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "bot.id is required")
+	}
+	bot, err := botFromProto(req.GetBot())
+	if err != nil {
+		return nil, apiError(domain.CodeInvalidRequest, "Invalid bot data: "+err.Error())
+	}
+
+	if err := s.botService.UpdateBot(ctx, bot); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to update bot", "bot_id", bot.ID, "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to update bot")
+	}
+	return s.bot(bot)
+}
+
+func (s *botServer) DeleteBot(ctx context.Context, req *botpb.DeleteBotRequest) (*emptypb.Empty, error) {
+	if req.GetId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "id is required")
+	}
+	if err := s.botService.DeleteBot(ctx, req.GetId()); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to delete bot", "bot_id", req.GetId(), "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to delete bot")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *botServer) ProcessIncomingMessage(ctx context.Context, req *botpb.IncomingMessage) (*botpb.BotResponse, error) {
+	if req.GetBotId() == "" || req.GetUserId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "bot_id and user_id are required")
+	}
+	message := messageFromProto(req)
+	if message.ID == "" {
+		message.ID = id.New()
+	}
+
+	response, err := s.botService.ProcessIncomingMessage(ctx, message)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to process incoming message",
+			"message_id", message.ID,
+			"bot_id", message.BotID,
+			"error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to process message")
+	}
+
+	converted, err := responseToProto(response)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to encode bot response", "message_id", message.ID, "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to encode response")
+	}
+	return converted, nil
+}
+
+func (s *botServer) bot(bot *domain.Bot) (*botpb.Bot, error) {
+	converted, err := botToProto(bot)
+	if err != nil {
+		s.logger.Error("Failed to encode bot", "bot_id", bot.ID, "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to encode bot")
+	}
+	return converted, nil
+}
+
+// flowServer implementa botpb.FlowServiceServer sobre services.BotFlowService
+type flowServer struct {
+	botpb.UnimplementedFlowServiceServer
+	flowService services.BotFlowService
+	logger      logger.Logger
+}
+
+func (s *flowServer) GetFlow(ctx context.Context, req *botpb.GetFlowRequest) (*botpb.Flow, error) {
+	if req.GetId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "id is required")
+	}
+
+	flow, err := s.flowService.GetFlow(ctx, req.GetId())
+	if err != nil {
+		return nil, apiError(domain.CodeFlowNotFound, "Flow not found")
+	}
+	return s.flow(flow)
+}
+
+func (s *flowServer) ListFlows(ctx context.Context, req *botpb.ListFlowsRequest) (*botpb.ListFlowsResponse, error) {
+	if req.GetBotId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "bot_id is required")
+	}
+
+	flows, err := s.flowService.GetFlowsByBot(ctx, req.GetBotId())
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to get flows", "bot_id", req.GetBotId(), "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to retrieve flows")
+	}
+
+	response := &botpb.ListFlowsResponse{Flows: make([]*botpb.Flow, 0, len(flows))}
+	for _, flow := range flows {
+		converted, err := s.flow(flow)
+		if err != nil {
+			return nil, err
+		}
+		response.Flows = append(response.Flows, converted)
+	}
+	return response, nil
+}
+
+func (s *flowServer) CreateFlow(ctx context.Context, req *botpb.CreateFlowRequest) (*botpb.Flow, error) {
+	if req.GetFlow().GetBotId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "flow.bot_id is required")
+	}
+	flow, err := flowFromProto(req.GetFlow())
+	if err != nil {
+		return nil, apiError(domain.CodeInvalidRequest, "Invalid flow data: "+err.Error())
+	}
+	if flow.ID == "" {
+		flow.ID = id.New()
+	}
+
+	if err := s.flowService.CreateFlow(ctx, flow); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to create flow", "bot_id", flow.BotID, "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to create flow")
+	}
+	return s.flow(flow)
+}
+
+func (s *flowServer) UpdateFlow(ctx context.Context, req *botpb.UpdateFlowRequest) (*botpb.Flow, error) {
+	if req.GetFlow().GetId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "flow.id is required")
+	}
+	flow, err := flowFromProto(req.GetFlow())
+	if err != nil {
+		return nil, apiError(domain.CodeInvalidRequest, "Invalid flow data: "+err.Error())
+	}
+
+	if err := s.flowService.UpdateFlow(ctx, flow); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to update flow", "flow_id", flow.ID, "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to update flow")
+	}
+	return s.flow(flow)
+}
+
+func (s *flowServer) DeleteFlow(ctx context.Context, req *botpb.DeleteFlowRequest) (*emptypb.Empty, error) {
+	if req.GetId() == "" {
+		return nil, apiError(domain.CodeInvalidRequest, "id is required")
+	}
+	if err := s.flowService.DeleteFlow(ctx, req.GetId()); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to delete flow", "flow_id", req.GetId(), "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to delete flow")
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *flowServer) flow(flow *domain.BotFlow) (*botpb.Flow, error) {
+	converted, err := flowToProto(flow)
+	if err != nil {
+		s.logger.Error("Failed to encode flow", "flow_id", flow.ID, "error", err)
+		return nil, apiError(domain.CodeInternalError, "Failed to encode flow")
+	}
+	return converted, nil
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAnalyticsDays es el rango del panel cuando no se indica from
+const defaultAnalyticsDays = 30
+
+// AnalyticsHandler expone el panel de analítica de los bots
+type AnalyticsHandler struct {
+	analyticsService services.AnalyticsService
+	logger           logger.Logger
+}
+
+// NewAnalyticsHandler crea un nuevo handler de analítica
+func NewAnalyticsHandler(analyticsService services.AnalyticsService, logger logger.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsService: analyticsService,
+		logger:           logger,
+	}
+}
+
+// GetBotAnalytics godoc
+// @Summary Obtener analítica de un bot
+// @Description Mensajes, usuarios únicos, sesiones, tasa de finalización y de handoff y confianza media de la IA por día (UTC), con la distribución de intents y los pasos donde se abandonan las conversaciones
+// @Tags bots
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param from query string false "Primer día (YYYY-MM-DD o RFC3339); por defecto, 30 días antes de to"
+// @Param to query string false "Último día (YYYY-MM-DD o RFC3339); por defecto, hoy"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/analytics [get]
+func (h *AnalyticsHandler) GetBotAnalytics(c *gin.Context) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, ok := parseAnalyticsDate(c, "to", value)
+		if !ok {
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, -(defaultAnalyticsDays - 1))
+	if value := c.Query("from"); value != "" {
+		parsed, ok := parseAnalyticsDate(c, "from", value)
+		if !ok {
+			return
+		}
+		from = parsed
+	}
+
+	analytics, err := h.analyticsService.GetBotAnalytics(c.Request.Context(), c.Param("id"), from, to)
+	switch {
+	case err == nil:
+		respond(c, http.StatusOK, domain.APIResponse{
+			Code:    domain.CodeSuccess,
+			Message: "Bot analytics retrieved successfully",
+			Data:    analytics,
+		})
+	case errors.Is(err, services.ErrBotNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeBotNotFound,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidAnalyticsRange):
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get bot analytics", "bot_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to get bot analytics",
+		})
+	}
+}
+
+// parseAnalyticsDate acepta un día o un instante RFC3339; si no es válido responde 400
+func parseAnalyticsDate(c *gin.Context, name, value string) (time.Time, bool) {
+	if parsed, err := time.Parse(services.AnalyticsDateLayout, value); err == nil {
+		return parsed, true
+	}
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, true
+	}
+	respond(c, http.StatusBadRequest, domain.APIResponse{
+		Code:    domain.CodeInvalidRequest,
+		Message: "Invalid " + name + ": expected YYYY-MM-DD or RFC3339",
+	})
+	return time.Time{}, false
+}
+
+// SetupAnalyticsRoutes registra las rutas de analítica de bots
+func SetupAnalyticsRoutes(router *gin.RouterGroup, handler *AnalyticsHandler) {
+	router.GET("/bots/:id/analytics", handler.GetBotAnalytics)
+}
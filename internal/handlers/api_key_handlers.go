@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler gestiona la emisión y revocación de API keys
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+	logger        logger.Logger
+}
+
+// NewAPIKeyHandler crea un nuevo handler de API keys
+func NewAPIKeyHandler(apiKeyService services.APIKeyService, logger logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Emitir API key
+// @Description Crea una API key con scopes (incoming:write, bots:read, bots:*, *) y límite por minuto. La clave en claro sólo se devuelve en esta respuesta.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param request body services.IssueAPIKeyRequest true "Datos de la clave"
+// @Success 201 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /api-keys [post]
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var request services.IssueAPIKeyRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid API key data: " + err.Error(),
+		})
+		return
+	}
+
+	// Una clave sólo puede emitir otras con scopes que ella misma tenga
+	if scopes, isKey := c.Get("api_key_scopes"); isKey {
+		granted, _ := scopes.([]string)
+		issuer := &domain.APIKey{Scopes: granted}
+		for _, scope := range request.Scopes {
+			if !services.APIKeyHasScope(issuer, scope) {
+				respond(c, http.StatusForbidden, domain.APIResponse{
+					Code:    domain.CodeInsufficientPermissions,
+					Message: "API key cannot grant scope " + scope,
+				})
+				return
+			}
+		}
+	}
+
+	key, rawKey, err := h.apiKeyService.Issue(c.Request.Context(), request, c.GetString("user_id"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidAPIKey) {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to issue API key", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to issue API key",
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "API key issued successfully; store it now, it will not be shown again",
+		Data: map[string]interface{}{
+			"api_key": key,
+			"key":     rawKey,
+		},
+	})
+}
+
+// ListAPIKeys godoc
+// @Summary Listar API keys
+// @Description Lista las API keys emitidas, incluidas las revocadas, sin el valor de la clave
+// @Tags api-keys
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /api-keys [get]
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.List(c.Request.Context())
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to list API keys", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to list API keys",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "API keys retrieved successfully",
+		Data: map[string]interface{}{
+			"api_keys": keys,
+			"count":    len(keys),
+		},
+	})
+}
+
+// RevokeAPIKey godoc
+// @Summary Revocar API key
+// @Description Revoca la clave; las peticiones que la usen pasan a responder 401
+// @Tags api-keys
+// @Produce json
+// @Param id path string true "API key ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /api-keys/{id} [delete]
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	key, err := h.apiKeyService.Revoke(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrAPIKeyNotFound) {
+			respond(c, http.StatusNotFound, domain.APIResponse{
+				Code:    domain.CodeAPIKeyNotFound,
+				Message: "API key not found",
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to revoke API key", "api_key_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to revoke API key",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "API key revoked successfully",
+		Data:    key,
+	})
+}
+
+// SetupAPIKeyRoutes registra las rutas de API keys; solo se llama si
+// API_KEYS_ENABLED está activo
+func SetupAPIKeyRoutes(router *gin.RouterGroup, handler *APIKeyHandler) {
+	router.POST("/api-keys", handler.CreateAPIKey)
+	router.GET("/api-keys", handler.ListAPIKeys)
+	router.DELETE("/api-keys/:id", handler.RevokeAPIKey)
+}
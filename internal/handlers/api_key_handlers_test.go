@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAPIKey_CannotEscalateScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+	handler := NewAPIKeyHandler(services.NewAPIKeyService(repositories.NewMockAPIKeyRepository(), 100, log), log)
+
+	router := gin.New()
+	router.POST("/api/v1/api-keys", func(c *gin.Context) {
+		// Lo que deja APIKeyAuth en el contexto
+		if scopes := c.GetHeader("X-Test-Scopes"); scopes != "" {
+			c.Set("api_key_scopes", strings.Split(scopes, ","))
+		}
+	}, handler.CreateAPIKey)
+
+	create := func(issuerScopes, body string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/api-keys", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if issuerScopes != "" {
+			req.Header.Set("X-Test-Scopes", issuerScopes)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	manager := "api_keys:manage,bots:*"
+	assert.Equal(t, http.StatusForbidden, create(manager, `{"name":"x","scopes":["*"]}`))
+	assert.Equal(t, http.StatusForbidden, create(manager, `{"name":"x","scopes":["bots:read","mcp:admin"]}`))
+	assert.Equal(t, http.StatusCreated, create(manager, `{"name":"x","scopes":["bots:read"]}`))
+	// Un usuario con JWT no está limitado por scopes propios
+	assert.Equal(t, http.StatusCreated, create("", `{"name":"x","scopes":["*"]}`))
+}
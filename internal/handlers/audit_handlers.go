@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// AuditHandler expone la consulta del registro de auditoría
+type AuditHandler struct {
+	auditService services.AuditService
+	logger       logger.Logger
+}
+
+// NewAuditHandler crea un nuevo handler de auditoría
+func NewAuditHandler(auditService services.AuditService, logger logger.Logger) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+		logger:       logger,
+	}
+}
+
+// ListAuditLogs godoc
+// @Summary Listar registros de auditoría
+// @Description Lista los cambios sobre bots, flujos, pasos, triggers y agentes MCP, del más reciente al más antiguo
+// @Tags audit
+// @Produce json
+// @Param resource query string false "Filter by resource (bot, flow, step, trigger, mcp_agent)"
+// @Param resource_id query string false "Filter by resource ID"
+// @Param user_id query string false "Filter by user ID"
+// @Param action query string false "Filter by action (create, update, delete)"
+// @Param from query string false "Created after (RFC3339)"
+// @Param to query string false "Created before (RFC3339)"
+// @Param limit query int false "Limit results"
+// @Param offset query int false "Offset results"
+// @Success 200 {object} domain.APIResponse
+// @Router /audit-logs [get]
+func (h *AuditHandler) ListAuditLogs(c *gin.Context) {
+	filters := &services.AuditFilters{}
+	if resource := c.Query("resource"); resource != "" {
+		filters.Resource = &resource
+	}
+	if resourceID := c.Query("resource_id"); resourceID != "" {
+		filters.ResourceID = &resourceID
+	}
+	if userID := c.Query("user_id"); userID != "" {
+		filters.UserID = &userID
+	}
+	if action := c.Query("action"); action != "" {
+		filters.Action = &action
+	}
+
+	if value := c.Query("from"); value != "" {
+		from, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid from: expected RFC3339",
+			})
+			return
+		}
+		filters.CreatedAt = &services.TimeRange{From: &from}
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid to: expected RFC3339",
+			})
+			return
+		}
+		if filters.CreatedAt == nil {
+			filters.CreatedAt = &services.TimeRange{}
+		}
+		filters.CreatedAt.To = &to
+	}
+
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil {
+		filters.Limit = limit
+	}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil {
+		filters.Offset = offset
+	}
+
+	logs, err := h.auditService.List(c.Request.Context(), filters)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to list audit logs", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to list audit logs",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Audit logs retrieved successfully",
+		Data: map[string]interface{}{
+			"audit_logs": logs,
+			"count":      len(logs),
+		},
+	})
+}
+
+// SetupAuditRoutes registra las rutas de auditoría
+func SetupAuditRoutes(router *gin.RouterGroup, handler *AuditHandler) {
+	router.GET("/audit-logs", handler.ListAuditLogs)
+}
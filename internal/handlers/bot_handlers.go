@@ -1,13 +1,18 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
-	"time"
+	"strconv"
+	"strings"
 
 	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/tracing"
 	"github.com/gin-gonic/gin"
 )
 
@@ -17,6 +22,8 @@ type BotHandler struct {
 	stepService        services.BotStepService
 	smartReplyService  services.SmartReplyService
 	conversationService services.ConversationService
+	faqService         services.FAQService
+	bundleService      services.BotBundleService
 	logger             logger.Logger
 }
 
@@ -26,6 +33,8 @@ func NewBotHandler(
 	stepService services.BotStepService,
 	smartReplyService services.SmartReplyService,
 	conversationService services.ConversationService,
+	faqService services.FAQService,
+	bundleService services.BotBundleService,
 	logger logger.Logger,
 ) *BotHandler {
 	return &BotHandler{
@@ -34,6 +43,8 @@ func NewBotHandler(
 		stepService:        stepService,
 		smartReplyService:  smartReplyService,
 		conversationService: conversationService,
+		faqService:         faqService,
+		bundleService:      bundleService,
 		logger:             logger,
 	}
 }
@@ -57,8 +68,8 @@ func (h *BotHandler) GetBots(c *gin.Context) {
 	}
 
 	if ownerID == "" {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Owner ID is required",
 		})
 		return
@@ -66,16 +77,16 @@ func (h *BotHandler) GetBots(c *gin.Context) {
 
 	bots, err := h.botService.GetBotsByOwner(c.Request.Context(), ownerID)
 	if err != nil {
-		h.logger.Error("Failed to get bots", "owner_id", ownerID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get bots", "owner_id", ownerID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to retrieve bots",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Bots retrieved successfully",
 		Data:    bots,
 	})
@@ -95,16 +106,16 @@ func (h *BotHandler) GetBot(c *gin.Context) {
 	
 	bot, err := h.botService.GetBot(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get bot", "bot_id", id, "error", err)
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get bot", "bot_id", id, "error", err)
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeBotNotFound,
 			Message: "Bot not found",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Bot retrieved successfully",
 		Data:    bot,
 	})
@@ -122,8 +133,8 @@ func (h *BotHandler) GetBot(c *gin.Context) {
 func (h *BotHandler) CreateBot(c *gin.Context) {
 	var bot domain.Bot
 	if err := c.ShouldBindJSON(&bot); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid bot data: " + err.Error(),
 		})
 		return
@@ -131,7 +142,7 @@ func (h *BotHandler) CreateBot(c *gin.Context) {
 
 	// Generar ID si no se proporciona
 	if bot.ID == "" {
-		bot.ID = generateUUID()
+		bot.ID = id.New()
 	}
 
 	// Establecer propietario desde el token JWT
@@ -140,21 +151,88 @@ func (h *BotHandler) CreateBot(c *gin.Context) {
 	}
 
 	if err := h.botService.CreateBot(c.Request.Context(), &bot); err != nil {
-		h.logger.Error("Failed to create bot", "bot", bot, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create bot", "bot", bot, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to create bot",
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Bot created successfully",
 		Data:    bot,
 	})
 }
 
+// ExportBot godoc
+// @Summary Exportar bot
+// @Description Exporta el bot con sus flujos, pasos, smart replies, condicionales y triggers como un bundle JSON versionado
+// @Tags bots
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Success 200 {object} domain.BotBundle
+// @Router /bots/{id}/export [get]
+func (h *BotHandler) ExportBot(c *gin.Context) {
+	id := c.Param("id")
+
+	bundle, err := h.bundleService.ExportBot(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to export bot", "bot_id", id, "error", err)
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeBotNotFound,
+			Message: "Bot not found",
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"bot-%s.json\"", id))
+	c.JSON(http.StatusOK, bundle)
+}
+
+// ImportBot godoc
+// @Summary Importar bot
+// @Description Crea un bot a partir de un bundle exportado, asignando IDs nuevos y reescribiendo las referencias internas
+// @Tags bots
+// @Accept json
+// @Produce json
+// @Param bundle body domain.BotBundle true "Bot bundle"
+// @Param owner_id query string false "Propietario del bot importado"
+// @Success 201 {object} domain.APIResponse
+// @Router /bots/import [post]
+func (h *BotHandler) ImportBot(c *gin.Context) {
+	var bundle domain.BotBundle
+	if err := c.ShouldBindJSON(&bundle); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid bundle: " + err.Error(),
+		})
+		return
+	}
+
+	ownerID := c.Query("owner_id")
+	if ownerID == "" {
+		ownerID = c.GetString("user_id")
+	}
+
+	result, err := h.bundleService.ImportBot(c.Request.Context(), &bundle, ownerID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to import bot", "source_bot_id", bundle.Bot.ID, "error", err)
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Failed to import bot: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Bot imported successfully",
+		Data:    result,
+	})
+}
+
 // UpdateBot godoc
 // @Summary Editar bot
 // @Description Actualiza un bot existente
@@ -170,8 +248,8 @@ func (h *BotHandler) UpdateBot(c *gin.Context) {
 	
 	var bot domain.Bot
 	if err := c.ShouldBindJSON(&bot); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid bot data: " + err.Error(),
 		})
 		return
@@ -179,16 +257,16 @@ func (h *BotHandler) UpdateBot(c *gin.Context) {
 
 	bot.ID = id
 	if err := h.botService.UpdateBot(c.Request.Context(), &bot); err != nil {
-		h.logger.Error("Failed to update bot", "bot_id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update bot", "bot_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to update bot",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Bot updated successfully",
 		Data:    bot,
 	})
@@ -207,20 +285,123 @@ func (h *BotHandler) DeleteBot(c *gin.Context) {
 	id := c.Param("id")
 	
 	if err := h.botService.DeleteBot(c.Request.Context(), id); err != nil {
-		h.logger.Error("Failed to delete bot", "bot_id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to delete bot", "bot_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to delete bot",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Bot deleted successfully",
 	})
 }
 
+// GetBotVariables godoc
+// @Summary Get bot variables
+// @Description Returns the bot constants available to templates and step configs as {{vars.<key>}}
+// @Tags bots
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/variables [get]
+func (h *BotHandler) GetBotVariables(c *gin.Context) {
+	variables, err := h.botService.GetBotVariables(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.botVariablesError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Bot variables retrieved successfully",
+		Data:    variables,
+	})
+}
+
+// SetBotVariables godoc
+// @Summary Set bot variables
+// @Description Creates or replaces the given bot constants; keys not in the body are kept
+// @Tags bots
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param variables body map[string]interface{} true "Variables by key"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/variables [put]
+func (h *BotHandler) SetBotVariables(c *gin.Context) {
+	var variables map[string]interface{}
+	if err := c.ShouldBindJSON(&variables); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid variables: " + err.Error(),
+		})
+		return
+	}
+
+	updated, err := h.botService.SetBotVariables(c.Request.Context(), c.Param("id"), variables)
+	if err != nil {
+		h.botVariablesError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Bot variables updated successfully",
+		Data:    updated,
+	})
+}
+
+// DeleteBotVariable godoc
+// @Summary Delete bot variable
+// @Description Removes a bot constant
+// @Tags bots
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param key path string true "Variable key"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/variables/{key} [delete]
+func (h *BotHandler) DeleteBotVariable(c *gin.Context) {
+	updated, err := h.botService.DeleteBotVariable(c.Request.Context(), c.Param("id"), c.Param("key"))
+	if err != nil {
+		h.botVariablesError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Bot variable deleted successfully",
+		Data:    updated,
+	})
+}
+
+func (h *BotHandler) botVariablesError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrBotNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeBotNotFound,
+			Message: "Bot not found",
+		})
+	case errors.Is(err, services.ErrInvalidBotVariable):
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update bot variables", "bot_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to update bot variables",
+		})
+	}
+}
+
 // Flow endpoints
 
 // GetFlows godoc
@@ -237,16 +418,16 @@ func (h *BotHandler) GetFlows(c *gin.Context) {
 	
 	flows, err := h.flowService.GetFlowsByBot(c.Request.Context(), botID)
 	if err != nil {
-		h.logger.Error("Failed to get flows", "bot_id", botID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get flows", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to retrieve flows",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Flows retrieved successfully",
 		Data:    flows,
 	})
@@ -267,8 +448,8 @@ func (h *BotHandler) CreateFlow(c *gin.Context) {
 	
 	var flow domain.BotFlow
 	if err := c.ShouldBindJSON(&flow); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid flow data: " + err.Error(),
 		})
 		return
@@ -276,20 +457,20 @@ func (h *BotHandler) CreateFlow(c *gin.Context) {
 
 	flow.BotID = botID
 	if flow.ID == "" {
-		flow.ID = generateUUID()
+		flow.ID = id.New()
 	}
 
 	if err := h.flowService.CreateFlow(c.Request.Context(), &flow); err != nil {
-		h.logger.Error("Failed to create flow", "bot_id", botID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create flow", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to create flow",
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Flow created successfully",
 		Data:    flow,
 	})
@@ -309,9 +490,9 @@ func (h *BotHandler) GetFlow(c *gin.Context) {
 	
 	flow, err := h.flowService.GetFlow(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get flow", "flow_id", id, "error", err)
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get flow", "flow_id", id, "error", err)
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFlowNotFound,
 			Message: "Flow not found",
 		})
 		return
@@ -320,7 +501,7 @@ func (h *BotHandler) GetFlow(c *gin.Context) {
 	// Obtener pasos del flujo
 	steps, err := h.stepService.GetStepsByFlow(c.Request.Context(), id)
 	if err != nil {
-		h.logger.Error("Failed to get flow steps", "flow_id", id, "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get flow steps", "flow_id", id, "error", err)
 		steps = []*domain.BotStep{} // Continuar sin pasos si hay error
 	}
 
@@ -329,8 +510,8 @@ func (h *BotHandler) GetFlow(c *gin.Context) {
 		"steps": steps,
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Flow retrieved successfully",
 		Data:    response,
 	})
@@ -351,8 +532,8 @@ func (h *BotHandler) UpdateFlow(c *gin.Context) {
 	
 	var flow domain.BotFlow
 	if err := c.ShouldBindJSON(&flow); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid flow data: " + err.Error(),
 		})
 		return
@@ -360,16 +541,16 @@ func (h *BotHandler) UpdateFlow(c *gin.Context) {
 
 	flow.ID = id
 	if err := h.flowService.UpdateFlow(c.Request.Context(), &flow); err != nil {
-		h.logger.Error("Failed to update flow", "flow_id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update flow", "flow_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to update flow",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Flow updated successfully",
 		Data:    flow,
 	})
@@ -388,20 +569,205 @@ func (h *BotHandler) DeleteFlow(c *gin.Context) {
 	id := c.Param("id")
 	
 	if err := h.flowService.DeleteFlow(c.Request.Context(), id); err != nil {
-		h.logger.Error("Failed to delete flow", "flow_id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to delete flow", "flow_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to delete flow",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Flow deleted successfully",
 	})
 }
 
+// PublishFlow godoc
+// @Summary Publicar un flujo
+// @Description Guarda el borrador actual del flujo como una nueva versión y la activa para las conversaciones nuevas
+// @Tags flows
+// @Accept json
+// @Produce json
+// @Param id path string true "Flow ID"
+// @Param request body object false "Notas de la versión"
+// @Success 201 {object} domain.APIResponse
+// @Router /flows/{id}/publish [post]
+func (h *BotHandler) PublishFlow(c *gin.Context) {
+	id := c.Param("id")
+
+	var request struct {
+		Notes string `json:"notes"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid publish request: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if _, err := h.flowService.GetFlow(c.Request.Context(), id); err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFlowNotFound,
+			Message: "Flow not found",
+		})
+		return
+	}
+
+	version, err := h.flowService.PublishFlow(c.Request.Context(), id, request.Notes)
+	if errors.Is(err, services.ErrInvalidFlow) {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeFlowInvalid,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to publish flow", "flow_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to publish flow: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Flow published successfully",
+		Data:    version,
+	})
+}
+
+// GetFlowVersions godoc
+// @Summary Listar versiones de un flujo
+// @Description Obtiene las versiones publicadas y archivadas del flujo, de la más nueva a la más antigua
+// @Tags flows
+// @Produce json
+// @Param id path string true "Flow ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /flows/{id}/versions [get]
+func (h *BotHandler) GetFlowVersions(c *gin.Context) {
+	id := c.Param("id")
+
+	flow, err := h.flowService.GetFlow(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFlowNotFound,
+			Message: "Flow not found",
+		})
+		return
+	}
+
+	versions, err := h.flowService.GetFlowVersions(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get flow versions", "flow_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to get flow versions",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Flow versions retrieved successfully",
+		Data: map[string]interface{}{
+			"published_version": flow.PublishedVersion,
+			"versions":          versions,
+			"total":             len(versions),
+		},
+	})
+}
+
+// GetFlowVersion godoc
+// @Summary Obtener una versión de un flujo
+// @Description Obtiene el flujo y sus pasos tal como quedaron en la versión indicada
+// @Tags flows
+// @Produce json
+// @Param id path string true "Flow ID"
+// @Param version path int true "Número de versión"
+// @Success 200 {object} domain.APIResponse
+// @Router /flows/{id}/versions/{version} [get]
+func (h *BotHandler) GetFlowVersion(c *gin.Context) {
+	id := c.Param("id")
+
+	number, err := strconv.Atoi(c.Param("version"))
+	if err != nil || number <= 0 {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Version must be a positive integer",
+		})
+		return
+	}
+
+	version, err := h.flowService.GetFlowVersion(c.Request.Context(), id, number)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFlowVersionNotFound,
+			Message: "Flow version not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Flow version retrieved successfully",
+		Data:    version,
+	})
+}
+
+// RollbackFlow godoc
+// @Summary Revertir un flujo a una versión anterior
+// @Description Vuelve a publicar la versión indicada y restaura el borrador a su contenido. Las conversaciones en curso mantienen su versión.
+// @Tags flows
+// @Accept json
+// @Produce json
+// @Param id path string true "Flow ID"
+// @Param request body object true "Versión destino"
+// @Success 200 {object} domain.APIResponse
+// @Router /flows/{id}/rollback [post]
+func (h *BotHandler) RollbackFlow(c *gin.Context) {
+	id := c.Param("id")
+
+	var request struct {
+		Version int `json:"version" binding:"required,min=1"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid rollback request: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := h.flowService.GetFlowVersion(c.Request.Context(), id, request.Version); err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFlowVersionNotFound,
+			Message: "Flow version not found",
+		})
+		return
+	}
+
+	version, err := h.flowService.RollbackFlow(c.Request.Context(), id, request.Version)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to roll back flow", "flow_id", id, "version", request.Version, "error", err)
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Failed to roll back flow: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Flow rolled back successfully",
+		Data:    version,
+	})
+}
+
 // Step endpoints
 
 // CreateStep godoc
@@ -419,8 +785,8 @@ func (h *BotHandler) CreateStep(c *gin.Context) {
 	
 	var step domain.BotStep
 	if err := c.ShouldBindJSON(&step); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid step data: " + err.Error(),
 		})
 		return
@@ -428,20 +794,20 @@ func (h *BotHandler) CreateStep(c *gin.Context) {
 
 	step.FlowID = flowID
 	if step.ID == "" {
-		step.ID = generateUUID()
+		step.ID = id.New()
 	}
 
 	if err := h.stepService.CreateStep(c.Request.Context(), &step); err != nil {
-		h.logger.Error("Failed to create step", "flow_id", flowID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create step", "flow_id", flowID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to create step",
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Step created successfully",
 		Data:    step,
 	})
@@ -462,8 +828,8 @@ func (h *BotHandler) UpdateStep(c *gin.Context) {
 	
 	var step domain.BotStep
 	if err := c.ShouldBindJSON(&step); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid step data: " + err.Error(),
 		})
 		return
@@ -471,16 +837,16 @@ func (h *BotHandler) UpdateStep(c *gin.Context) {
 
 	step.ID = id
 	if err := h.stepService.UpdateStep(c.Request.Context(), &step); err != nil {
-		h.logger.Error("Failed to update step", "step_id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update step", "step_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to update step",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Step updated successfully",
 		Data:    step,
 	})
@@ -499,16 +865,16 @@ func (h *BotHandler) DeleteStep(c *gin.Context) {
 	id := c.Param("id")
 	
 	if err := h.stepService.DeleteStep(c.Request.Context(), id); err != nil {
-		h.logger.Error("Failed to delete step", "step_id", id, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to delete step", "step_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to delete step",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Step deleted successfully",
 	})
 }
@@ -534,8 +900,8 @@ func (h *BotHandler) SmartReply(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid request data: " + err.Error(),
 		})
 		return
@@ -547,58 +913,198 @@ func (h *BotHandler) SmartReply(c *gin.Context) {
 
 	reply, err := h.smartReplyService.GenerateAIResponse(c.Request.Context(), botID, request.Prompt, request.Context)
 	if err != nil {
-		h.logger.Error("Failed to generate smart reply", "bot_id", botID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to generate smart reply", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to generate smart reply",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Smart reply generated successfully",
 		Data:    reply,
 	})
 }
 
+// StreamSmartReply godoc
+// @Summary Consulta a IA en streaming
+// @Description Genera una respuesta usando IA y la envía por Server-Sent Events a medida que se produce
+// @Tags smart-replies
+// @Accept json
+// @Produce text/event-stream
+// @Param id path string true "Bot ID"
+// @Param request body map[string]interface{} true "Smart reply request"
+// @Success 200 {object} domain.SmartReplyChunk
+// @Router /bots/{id}/smart-reply/stream [post]
+func (h *BotHandler) StreamSmartReply(c *gin.Context) {
+	botID := c.Param("id")
+
+	var request struct {
+		Prompt  string                 `json:"prompt" binding:"required"`
+		Context map[string]interface{} `json:"context"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	if request.Context == nil {
+		request.Context = make(map[string]interface{})
+	}
+
+	ctx := c.Request.Context()
+	chunks, err := h.smartReplyService.StreamAIResponse(ctx, botID, request.Prompt, request.Context)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to stream smart reply", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to stream smart reply",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case chunk, ok := <-chunks:
+			if !ok {
+				return false
+			}
+			switch {
+			case chunk.Error != "":
+				c.SSEvent("error", chunk)
+			case chunk.Done:
+				c.SSEvent("done", chunk)
+			default:
+				c.SSEvent("chunk", chunk)
+			}
+			return !chunk.Done
+		case <-ctx.Done():
+			return false
+		}
+	})
+}
+
 // TrainIntents godoc
 // @Summary Entrenar respuestas automáticas
-// @Description Entrena el bot con intents y respuestas predefinidas
+// @Description Crea o actualiza intents con su respuesta y les añade frases de ejemplo (utterances); las frases ya entrenadas se ignoran
 // @Tags smart-replies
 // @Accept json
 // @Produce json
 // @Param id path string true "Bot ID"
-// @Param intents body []domain.SmartReply true "Training intents"
-// @Success 200 {object} domain.APIResponse
+// @Param intents body []domain.IntentTraining true "Training intents"
+// @Success 200 {object} domain.APIResponse{data=domain.IntentTrainingResult}
 // @Router /bots/{id}/intents/train [post]
 func (h *BotHandler) TrainIntents(c *gin.Context) {
 	botID := c.Param("id")
 	
-	var intents []domain.SmartReply
+	var intents []domain.IntentTraining
 	if err := c.ShouldBindJSON(&intents); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid intents data: " + err.Error(),
 		})
 		return
 	}
+	for _, intent := range intents {
+		if strings.TrimSpace(intent.Intent) == "" {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid intents data: intent is required",
+			})
+			return
+		}
+	}
 
-	if err := h.smartReplyService.TrainIntents(c.Request.Context(), botID, intents); err != nil {
-		h.logger.Error("Failed to train intents", "bot_id", botID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+	result, err := h.smartReplyService.TrainIntents(c.Request.Context(), botID, intents)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to train intents", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to train intents",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Intents trained successfully",
-		Data: map[string]interface{}{
-			"trained_count": len(intents),
-		},
+		Data:    result,
+	})
+}
+
+// GetIntentExamples godoc
+// @Summary Listar ejemplos de entrenamiento
+// @Description Obtiene las frases de ejemplo guardadas de los intents del bot, en orden de alta
+// @Tags smart-replies
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param intent query string false "Solo los ejemplos de este intent"
+// @Success 200 {object} domain.APIResponse{data=[]domain.IntentExample}
+// @Router /bots/{id}/intents/examples [get]
+func (h *BotHandler) GetIntentExamples(c *gin.Context) {
+	botID := c.Param("id")
+
+	examples, err := h.smartReplyService.GetIntentExamples(c.Request.Context(), botID, c.Query("intent"))
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get intent examples", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to retrieve intent examples",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Intent examples retrieved successfully",
+		Data:    examples,
+	})
+}
+
+// DeleteIntentExample godoc
+// @Summary Eliminar ejemplo de entrenamiento
+// @Description Elimina una frase de ejemplo de un intent del bot
+// @Tags smart-replies
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param example_id path string true "Example ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/intents/examples/{example_id} [delete]
+func (h *BotHandler) DeleteIntentExample(c *gin.Context) {
+	botID := c.Param("id")
+
+	err := h.smartReplyService.DeleteIntentExample(c.Request.Context(), botID, c.Param("example_id"))
+	if errors.Is(err, services.ErrIntentExampleNotFound) {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeIntentExampleNotFound,
+			Message: "Intent example not found",
+		})
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to delete intent example", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to delete intent example",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Intent example deleted successfully",
 	})
 }
 
@@ -616,21 +1122,294 @@ func (h *BotHandler) GetIntents(c *gin.Context) {
 	
 	intents, err := h.smartReplyService.GetSmartRepliesByBot(c.Request.Context(), botID)
 	if err != nil {
-		h.logger.Error("Failed to get intents", "bot_id", botID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get intents", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to retrieve intents",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Intents retrieved successfully",
 		Data:    intents,
 	})
 }
 
+// GetFAQs godoc
+// @Summary Listar FAQ del bot
+// @Description Obtiene los pares pregunta/respuesta de la FAQ de un bot
+// @Tags faq
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/faqs [get]
+func (h *BotHandler) GetFAQs(c *gin.Context) {
+	botID := c.Param("id")
+
+	entries, err := h.faqService.GetEntries(c.Request.Context(), botID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get FAQ entries", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to retrieve FAQ entries",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "FAQ entries retrieved successfully",
+		Data:    entries,
+	})
+}
+
+// ImportFAQs godoc
+// @Summary Cargar FAQ
+// @Description Carga pares pregunta/respuesta en la FAQ de un bot
+// @Tags faq
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param entries body []domain.FAQEntry true "FAQ entries"
+// @Success 201 {object} domain.APIResponse
+// @Router /bots/{id}/faqs [post]
+func (h *BotHandler) ImportFAQs(c *gin.Context) {
+	botID := c.Param("id")
+
+	var entries []*domain.FAQEntry
+	if err := c.ShouldBindJSON(&entries); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid FAQ data: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.faqService.ImportEntries(c.Request.Context(), botID, entries); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to import FAQ entries", "bot_id", botID, "error", err)
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Failed to import FAQ entries: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "FAQ entries imported successfully",
+		Data:    entries,
+	})
+}
+
+// UpdateFAQ godoc
+// @Summary Actualizar entrada de FAQ
+// @Description Actualiza la pregunta, alternativas o respuesta de una entrada de FAQ
+// @Tags faq
+// @Accept json
+// @Produce json
+// @Param id path string true "FAQ ID"
+// @Param entry body domain.FAQEntry true "FAQ entry"
+// @Success 200 {object} domain.APIResponse
+// @Router /faqs/{id} [patch]
+func (h *BotHandler) UpdateFAQ(c *gin.Context) {
+	id := c.Param("id")
+
+	entry, err := h.faqService.GetEntry(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFAQNotFound,
+			Message: "FAQ entry not found",
+		})
+		return
+	}
+
+	var updates struct {
+		Question     *string  `json:"question"`
+		Alternatives []string `json:"alternatives"`
+		Answer       *string  `json:"answer"`
+		Tags         []string `json:"tags"`
+	}
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid FAQ data: " + err.Error(),
+		})
+		return
+	}
+
+	if updates.Question != nil {
+		entry.Question = *updates.Question
+	}
+	if updates.Answer != nil {
+		entry.Answer = *updates.Answer
+	}
+	if updates.Alternatives != nil {
+		entry.Alternatives = updates.Alternatives
+	}
+	if updates.Tags != nil {
+		entry.Tags = updates.Tags
+	}
+
+	if err := h.faqService.UpdateEntry(c.Request.Context(), entry); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update FAQ entry", "faq_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to update FAQ entry",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "FAQ entry updated successfully",
+		Data:    entry,
+	})
+}
+
+// DeleteFAQ godoc
+// @Summary Eliminar entrada de FAQ
+// @Tags faq
+// @Produce json
+// @Param id path string true "FAQ ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /faqs/{id} [delete]
+func (h *BotHandler) DeleteFAQ(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.faqService.DeleteEntry(c.Request.Context(), id); err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFAQNotFound,
+			Message: "FAQ entry not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "FAQ entry deleted successfully",
+	})
+}
+
+// AskFAQ godoc
+// @Summary Consultar FAQ
+// @Description Busca la respuesta de la FAQ para una pregunta sin pasar por los flujos
+// @Tags faq
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param request body map[string]string true "Question"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/faqs/ask [post]
+func (h *BotHandler) AskFAQ(c *gin.Context) {
+	botID := c.Param("id")
+
+	var request struct {
+		Question string `json:"question" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	match, err := h.faqService.Answer(c.Request.Context(), botID, request.Question)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to query FAQ", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to query FAQ",
+		})
+		return
+	}
+
+	if match == nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeFAQNotFound,
+			Message: "No FAQ entry matches the question",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "FAQ answer found",
+		Data:    match,
+	})
+}
+
+// GetUnansweredClusters godoc
+// @Summary Informe de preguntas sin respuesta
+// @Description Agrupa por similitud las preguntas que la FAQ no pudo responder
+// @Tags faq
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param threshold query number false "Similitud mínima para agrupar (0-1)"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/faqs/unanswered [get]
+func (h *BotHandler) GetUnansweredClusters(c *gin.Context) {
+	botID := c.Param("id")
+
+	threshold := 0.0
+	if raw := c.Query("threshold"); raw != "" {
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid threshold: " + err.Error(),
+			})
+			return
+		}
+		threshold = value
+	}
+
+	report, err := h.faqService.ClusterUnanswered(c.Request.Context(), botID, threshold)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to cluster unanswered questions", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to build unanswered questions report",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Unanswered questions report generated successfully",
+		Data:    report,
+	})
+}
+
+// ClearUnanswered godoc
+// @Summary Vaciar preguntas sin respuesta
+// @Tags faq
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/faqs/unanswered [delete]
+func (h *BotHandler) ClearUnanswered(c *gin.Context) {
+	botID := c.Param("id")
+
+	count, err := h.faqService.ClearUnanswered(c.Request.Context(), botID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to clear unanswered questions", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to clear unanswered questions",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Unanswered questions cleared successfully",
+		Data:    map[string]interface{}{"deleted": count},
+	})
+}
+
 // ProcessIncomingMessage godoc
 // @Summary Procesar mensaje entrante
 // @Description Recibe un mensaje entrante desde messaging-service y responde según flujo
@@ -643,8 +1422,8 @@ func (h *BotHandler) GetIntents(c *gin.Context) {
 func (h *BotHandler) ProcessIncomingMessage(c *gin.Context) {
 	var message domain.IncomingMessage
 	if err := c.ShouldBindJSON(&message); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid message data: " + err.Error(),
 		})
 		return
@@ -652,34 +1431,317 @@ func (h *BotHandler) ProcessIncomingMessage(c *gin.Context) {
 
 	// Generar ID si no se proporciona
 	if message.ID == "" {
-		message.ID = generateUUID()
+		message.ID = id.New()
 	}
 
 	response, err := h.botService.ProcessIncomingMessage(c.Request.Context(), &message)
 	if err != nil {
-		h.logger.Error("Failed to process incoming message", 
+		h.logger.WithContext(c.Request.Context()).Error("Failed to process incoming message", 
 			"message_id", message.ID,
 			"bot_id", message.BotID,
 			"error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to process message",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	attachDebugTrace(c, response)
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Message processed successfully",
 		Data:    response,
 	})
 }
 
-// Utility functions
+// attachDebugTrace añade a la metadata, bajo "debug", los IDs de la petición y
+// la cadena de componentes ejecutados, si el middleware DebugTrace la activó
+func attachDebugTrace(c *gin.Context, response *domain.BotResponse) {
+	chain := tracing.ChainFromContext(c.Request.Context())
+	if chain == nil || response == nil {
+		return
+	}
+	if response.Metadata == nil {
+		response.Metadata = make(map[string]interface{})
+	}
+	response.Metadata["debug"] = map[string]interface{}{
+		"request_id":     c.GetString(logger.RequestIDKey),
+		"correlation_id": c.GetString(logger.CorrelationIDKey),
+		"components":     chain.Components(),
+	}
+}
+
+// SummarizeConversation godoc
+// @Summary Resumir conversación
+// @Description Genera un resumen estructurado (problema, resolución, sentimiento, acciones) de la conversación. El resumen se guarda y se reutiliza mientras no haya mensajes nuevos.
+// @Tags conversations
+// @Produce json
+// @Param id path string true "Conversation (session) ID"
+// @Param refresh query bool false "Regenerar aunque exista un resumen vigente"
+// @Success 200 {object} domain.APIResponse
+// @Router /conversations/{id}/summarize [post]
+func (h *BotHandler) SummarizeConversation(c *gin.Context) {
+	id := c.Param("id")
+	refresh, _ := strconv.ParseBool(c.Query("refresh"))
+
+	summary, err := h.botService.SummarizeConversation(c.Request.Context(), id, refresh)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to summarize conversation", "session_id", id, "error", err)
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeConversationNotFound,
+			Message: "Conversation not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Conversation summarized successfully",
+		Data:    summary,
+	})
+}
+
+// GetSessionState godoc
+// @Summary Estado de una sesión
+// @Description Devuelve el flujo y paso actuales, la entrada que espera el bot, las transiciones posibles, las esperas programadas y las variables recogidas
+// @Tags conversations
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /sessions/{id}/state [get]
+func (h *BotHandler) GetSessionState(c *gin.Context) {
+	id := c.Param("id")
 
-func generateUUID() string {
-	// Implementación simple de UUID - en producción usar una librería como google/uuid
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	state, err := h.botService.GetSessionState(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeConversationNotFound,
+			Message: "Conversation not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Session state retrieved successfully",
+		Data:    state,
+	})
+}
+
+// ListMetadataNamespaces godoc
+// @Summary List reserved message metadata namespaces
+// @Description Espacios de nombres (channel, user, message) de IncomingMessage.metadata que se copian al contexto de condiciones, pasos de decisión y plantillas
+// @Tags conversations
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /metadata/namespaces [get]
+func (h *BotHandler) ListMetadataNamespaces(c *gin.Context) {
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Metadata namespaces retrieved successfully",
+		Data:    domain.MetadataNamespaces(),
+	})
+}
+
+// GetHandoffs godoc
+// @Summary Listar conversaciones derivadas
+// @Description Lista las conversaciones del bot que esperan o atiende un agente humano, por orden de llegada
+// @Tags conversations
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/handoffs [get]
+func (h *BotHandler) GetHandoffs(c *gin.Context) {
+	botID := c.Param("id")
+
+	sessions, err := h.botService.GetHandoffs(c.Request.Context(), botID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get handoffs", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to retrieve handoffs",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Handoffs retrieved successfully",
+		Data: map[string]interface{}{
+			"conversations": sessions,
+			"total":         len(sessions),
+		},
+	})
+}
+
+// EscalateConversation godoc
+// @Summary Derivar conversación a un humano
+// @Description Marca la conversación como derivada: el bot deja de responder y se emite el evento human_handoff
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation (session) ID"
+// @Param request body object true "queue y reason opcionales"
+// @Success 200 {object} domain.APIResponse
+// @Router /conversations/{id}/handoff [post]
+func (h *BotHandler) EscalateConversation(c *gin.Context) {
+	var req struct {
+		Queue  string `json:"queue"`
+		Reason string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	session, err := h.botService.EscalateConversation(c.Request.Context(), c.Param("id"), req.Queue, req.Reason)
+	if err != nil {
+		h.handoffError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Conversation escalated successfully",
+		Data:    session,
+	})
+}
+
+// AcceptHandoff godoc
+// @Summary Tomar conversación derivada
+// @Description Asigna la conversación derivada al agente de la consola
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation (session) ID"
+// @Param request body object true "agent_id"
+// @Success 200 {object} domain.APIResponse
+// @Router /conversations/{id}/handoff/accept [post]
+func (h *BotHandler) AcceptHandoff(c *gin.Context) {
+	var req struct {
+		AgentID string `json:"agent_id" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	session, err := h.botService.AcceptHandoff(c.Request.Context(), c.Param("id"), req.AgentID)
+	if err != nil {
+		h.handoffError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Handoff accepted successfully",
+		Data:    session,
+	})
+}
+
+// SendAgentMessage godoc
+// @Summary Enviar mensaje como el bot
+// @Description Envía al usuario un mensaje del agente humano asignado; se entrega mediante el evento agent_message
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation (session) ID"
+// @Param request body object true "agent_id y text"
+// @Success 201 {object} domain.APIResponse
+// @Router /conversations/{id}/handoff/messages [post]
+func (h *BotHandler) SendAgentMessage(c *gin.Context) {
+	var req struct {
+		AgentID string `json:"agent_id" binding:"required"`
+		Text    string `json:"text" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	message, err := h.botService.SendAgentMessage(c.Request.Context(), c.Param("id"), req.AgentID, req.Text)
+	if err != nil {
+		h.handoffError(c, err)
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Message sent successfully",
+		Data:    message,
+	})
+}
+
+// ReleaseHandoff godoc
+// @Summary Devolver conversación al bot
+// @Description Termina la atención humana; el flujo continúa en el paso indicado o donde quedó al derivarse
+// @Tags conversations
+// @Accept json
+// @Produce json
+// @Param id path string true "Conversation (session) ID"
+// @Param request body object false "step opcional (ID o nombre) del flujo actual"
+// @Success 200 {object} domain.APIResponse
+// @Router /conversations/{id}/handoff/release [post]
+func (h *BotHandler) ReleaseHandoff(c *gin.Context) {
+	var req struct {
+		Step string `json:"step"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	session, err := h.botService.ReleaseHandoff(c.Request.Context(), c.Param("id"), req.Step)
+	if err != nil {
+		h.handoffError(c, err)
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Conversation returned to the bot",
+		Data:    session,
+	})
+}
+
+// handoffError traduce los errores de la API de derivación a respuestas HTTP
+func (h *BotHandler) handoffError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrConversationNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeConversationNotFound,
+			Message: "Conversation not found",
+		})
+	case errors.Is(err, services.ErrInvalidHandoffState):
+		respond(c, http.StatusConflict, domain.APIResponse{
+			Code:    domain.CodeHandoffConflict,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error("Handoff operation failed", "session_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Handoff operation failed",
+		})
+	}
 }
 
 // SetupBotRoutes configura todas las rutas relacionadas with bots
@@ -688,8 +1750,13 @@ func SetupBotRoutes(router *gin.RouterGroup, handler *BotHandler) {
 	router.GET("/bots", handler.GetBots)
 	router.GET("/bots/:id", handler.GetBot)
 	router.POST("/bots", handler.CreateBot)
+	router.POST("/bots/import", handler.ImportBot)
+	router.GET("/bots/:id/export", handler.ExportBot)
 	router.PATCH("/bots/:id", handler.UpdateBot)
 	router.DELETE("/bots/:id", handler.DeleteBot)
+	router.GET("/bots/:id/variables", handler.GetBotVariables)
+	router.PUT("/bots/:id/variables", handler.SetBotVariables)
+	router.DELETE("/bots/:id/variables/:key", handler.DeleteBotVariable)
 
 	// Flow routes
 	router.GET("/bots/:id/flows", handler.GetFlows)
@@ -697,6 +1764,10 @@ func SetupBotRoutes(router *gin.RouterGroup, handler *BotHandler) {
 	router.GET("/flows/:id", handler.GetFlow)
 	router.PATCH("/flows/:id", handler.UpdateFlow)
 	router.DELETE("/flows/:id", handler.DeleteFlow)
+	router.POST("/flows/:id/publish", handler.PublishFlow)
+	router.GET("/flows/:id/versions", handler.GetFlowVersions)
+	router.GET("/flows/:id/versions/:version", handler.GetFlowVersion)
+	router.POST("/flows/:id/rollback", handler.RollbackFlow)
 
 	// Step routes
 	router.POST("/flows/:id/steps", handler.CreateStep)
@@ -705,9 +1776,31 @@ func SetupBotRoutes(router *gin.RouterGroup, handler *BotHandler) {
 
 	// Smart Reply routes
 	router.POST("/bots/:id/smart-reply", handler.SmartReply)
+	router.POST("/bots/:id/smart-reply/stream", handler.StreamSmartReply)
 	router.POST("/bots/:id/intents/train", handler.TrainIntents)
 	router.GET("/bots/:id/intents", handler.GetIntents)
+	router.GET("/bots/:id/intents/examples", handler.GetIntentExamples)
+	router.DELETE("/bots/:id/intents/examples/:example_id", handler.DeleteIntentExample)
+
+	// FAQ routes
+	router.GET("/bots/:id/faqs", handler.GetFAQs)
+	router.POST("/bots/:id/faqs", handler.ImportFAQs)
+	router.POST("/bots/:id/faqs/ask", handler.AskFAQ)
+	router.GET("/bots/:id/faqs/unanswered", handler.GetUnansweredClusters)
+	router.DELETE("/bots/:id/faqs/unanswered", handler.ClearUnanswered)
+	router.PATCH("/faqs/:id", handler.UpdateFAQ)
+	router.DELETE("/faqs/:id", handler.DeleteFAQ)
+
+	// Conversation routes
+	router.POST("/conversations/:id/summarize", handler.SummarizeConversation)
+	router.GET("/sessions/:id/state", handler.GetSessionState)
+	router.GET("/bots/:id/handoffs", handler.GetHandoffs)
+	router.POST("/conversations/:id/handoff", handler.EscalateConversation)
+	router.POST("/conversations/:id/handoff/accept", handler.AcceptHandoff)
+	router.POST("/conversations/:id/handoff/messages", handler.SendAgentMessage)
+	router.POST("/conversations/:id/handoff/release", handler.ReleaseHandoff)
 
 	// Incoming message processing
 	router.POST("/incoming", handler.ProcessIncomingMessage)
+	router.GET("/metadata/namespaces", handler.ListMetadataNamespaces)
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamSmartReply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+	ai := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{"Hello", " there"} {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", delta)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer ai.Close()
+
+	orchestrator := mcp.NewOrchestrator(mcp.NewAgentFactory(log), log)
+	_, err := orchestrator.InstantiateMCP(context.Background(), mcp.MCPConfig{Type: "ai", Name: "stream", Config: map[string]interface{}{
+		"openai_api_key": "sk-live", "base_url": ai.URL,
+	}})
+	require.NoError(t, err)
+	smartReplies := services.NewSmartReplyService(repositories.NewMockSmartReplyRepository(), repositories.NewMockIntentExampleRepository(),
+		nil, orchestrator, log)
+	handler := NewBotHandler(nil, nil, nil, smartReplies, nil, nil, nil, log)
+
+	router := gin.New()
+	router.POST("/bots/:id/smart-reply/stream", handler.StreamSmartReply)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/bots/bot-1/smart-reply/stream", "application/json", strings.NewReader(`{"prompt":"hi"}`))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var events []string
+	var chunks []domain.SmartReplyChunk
+	for _, block := range strings.Split(strings.TrimSpace(string(body)), "\n\n") {
+		var event, data string
+		for _, line := range strings.Split(block, "\n") {
+			if value, ok := strings.CutPrefix(line, "event:"); ok {
+				event = value
+			} else if value, ok := strings.CutPrefix(line, "data:"); ok {
+				data = value
+			}
+		}
+		var chunk domain.SmartReplyChunk
+		require.NoError(t, json.Unmarshal([]byte(data), &chunk), block)
+		events = append(events, event)
+		chunks = append(chunks, chunk)
+	}
+
+	assert.Equal(t, []string{"chunk", "chunk", "done"}, events)
+	assert.Equal(t, "Hello", chunks[0].Content)
+	assert.Equal(t, " there", chunks[1].Content)
+	require.NotNil(t, chunks[2].Reply)
+	assert.Equal(t, "Hello there", chunks[2].Reply.Response)
+
+	resp, err = http.Post(server.URL+"/bots/bot-1/smart-reply/stream", "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ConversationHandler expone el historial de conversaciones
+type ConversationHandler struct {
+	historyService services.ConversationHistoryService
+	logger         logger.Logger
+}
+
+// NewConversationHandler crea un nuevo handler del historial de conversaciones
+func NewConversationHandler(historyService services.ConversationHistoryService, logger logger.Logger) *ConversationHandler {
+	return &ConversationHandler{
+		historyService: historyService,
+		logger:         logger,
+	}
+}
+
+// ListConversations godoc
+// @Summary Listar conversaciones de un bot
+// @Description Conversaciones del historial, con actividad más reciente primero. Se conservan aunque la sesión haya caducado.
+// @Tags conversations
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id query string false "Filter by user ID"
+// @Param from query string false "Activity after (RFC3339)"
+// @Param to query string false "Activity before (RFC3339)"
+// @Param limit query int false "Limit results"
+// @Param offset query int false "Offset results"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/conversations [get]
+func (h *ConversationHandler) ListConversations(c *gin.Context) {
+	filter, ok := historyFilter(c)
+	if !ok {
+		return
+	}
+	filter.UserID = c.Query("user_id")
+
+	conversations, total, err := h.historyService.ListConversations(c.Request.Context(), c.Param("id"), filter)
+	if err != nil {
+		h.respondError(c, err, "Failed to list conversations")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Conversations retrieved successfully",
+		Data: gin.H{
+			"conversations": conversations,
+			"total":         total,
+		},
+	})
+}
+
+// GetConversationMessages godoc
+// @Summary Obtener mensajes de una conversación
+// @Description Todos los mensajes de la sesión (usuario, bot y agente humano) en orden cronológico
+// @Tags conversations
+// @Produce json
+// @Param id path string true "Session ID"
+// @Param from query string false "Sent after (RFC3339)"
+// @Param to query string false "Sent before (RFC3339)"
+// @Param limit query int false "Limit results"
+// @Param offset query int false "Offset results"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /conversations/{id}/messages [get]
+func (h *ConversationHandler) GetConversationMessages(c *gin.Context) {
+	filter, ok := historyFilter(c)
+	if !ok {
+		return
+	}
+
+	messages, total, err := h.historyService.GetMessages(c.Request.Context(), c.Param("id"), filter)
+	if err != nil {
+		h.respondError(c, err, "Failed to get conversation messages")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Conversation messages retrieved successfully",
+		Data: gin.H{
+			"messages": messages,
+			"total":    total,
+		},
+	})
+}
+
+// historyFilter lee from, to, limit (100 por defecto) y offset; si from o to
+// no son RFC3339 responde 400 y devuelve false
+func historyFilter(c *gin.Context) (domain.ConversationHistoryFilter, bool) {
+	filter := domain.ConversationHistoryFilter{}
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid " + bound.name + ": expected RFC3339",
+			})
+			return filter, false
+		}
+		*bound.target = &parsed
+	}
+
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+	return filter, true
+}
+
+func (h *ConversationHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrBotNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeBotNotFound,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrConversationNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeConversationNotFound,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(message, "id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: message,
+		})
+	}
+}
+
+// SetupConversationRoutes registra las rutas del historial de conversaciones
+func SetupConversationRoutes(router *gin.RouterGroup, handler *ConversationHandler) {
+	router.GET("/bots/:id/conversations", handler.ListConversations)
+	router.GET("/conversations/:id/messages", handler.GetConversationMessages)
+}
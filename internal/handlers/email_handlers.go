@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// maxInboundEmailSize limita el cuerpo de los webhooks de email (adjuntos incluidos)
+const maxInboundEmailSize = 25 << 20
+
+// EmailHandler recibe los emails entrantes de SendGrid Inbound Parse y Amazon SES
+type EmailHandler struct {
+	emailChannel services.EmailChannel
+	// inboundToken debe llegar como ?token= en la URL del webhook; vacío
+	// rechaza todas las peticiones
+	inboundToken string
+	logger       logger.Logger
+}
+
+// NewEmailHandler crea un nuevo handler del canal de email
+func NewEmailHandler(emailChannel services.EmailChannel, inboundToken string, logger logger.Logger) *EmailHandler {
+	return &EmailHandler{
+		emailChannel: emailChannel,
+		inboundToken: inboundToken,
+		logger:       logger,
+	}
+}
+
+// ReceiveSendGridEmail godoc
+// @Summary Recibir email de SendGrid
+// @Description Webhook de SendGrid Inbound Parse (multipart, con o sin el MIME completo). El email se procesa como un mensaje del bot y la respuesta se envía al remitente en el mismo hilo.
+// @Tags channels
+// @Accept mpfd
+// @Produce json
+// @Param token query string true "Token del webhook (EMAIL_INBOUND_TOKEN)"
+// @Param bot_id query string false "Bot que atiende el email; si no, se usa soporte+<bot_id>@ o el bot por defecto"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Router /channels/email/sendgrid [post]
+func (h *EmailHandler) ReceiveSendGridEmail(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxInboundEmailSize)
+	var form map[string][]string
+	if multipartForm, err := c.MultipartForm(); err == nil {
+		form = multipartForm.Value
+	} else if err := c.Request.ParseForm(); err == nil {
+		form = c.Request.PostForm
+	} else {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid inbound email form: " + err.Error(),
+		})
+		return
+	}
+
+	response, err := h.emailChannel.HandleSendGrid(c.Request.Context(), form, c.Query("bot_id"))
+	h.respondEmail(c, response, err)
+}
+
+// ReceiveSESEmail godoc
+// @Summary Recibir email de Amazon SES
+// @Description Endpoint HTTPS de la suscripción SNS de una regla de recepción de SES con acción SNS. Comprueba la firma SNS, confirma la suscripción automáticamente y procesa las notificaciones "Received".
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Param token query string true "Token del webhook (EMAIL_INBOUND_TOKEN)"
+// @Param bot_id query string false "Bot que atiende el email; si no, se usa soporte+<bot_id>@ o el bot por defecto"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 401 {object} domain.APIResponse
+// @Router /channels/email/ses [post]
+func (h *EmailHandler) ReceiveSESEmail(c *gin.Context) {
+	if !h.authorize(c) {
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxInboundEmailSize))
+	if err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Failed to read request body",
+		})
+		return
+	}
+
+	response, err := h.emailChannel.HandleSES(c.Request.Context(), body, c.Query("bot_id"))
+	h.respondEmail(c, response, err)
+}
+
+func (h *EmailHandler) authorize(c *gin.Context) bool {
+	if h.inboundToken != "" && subtle.ConstantTimeCompare([]byte(c.Query("token")), []byte(h.inboundToken)) == 1 {
+		return true
+	}
+	respond(c, http.StatusUnauthorized, domain.APIResponse{
+		Code:    domain.CodeUnauthorized,
+		Message: "Invalid inbound email token",
+	})
+	return false
+}
+
+func (h *EmailHandler) respondEmail(c *gin.Context, response *domain.BotResponse, err error) {
+	switch {
+	case err == nil:
+		respond(c, http.StatusOK, domain.APIResponse{
+			Code:    domain.CodeSuccess,
+			Message: "Email processed successfully",
+			Data:    response,
+		})
+	// Los emails automáticos se aceptan para que el proveedor no los reintente
+	case errors.Is(err, services.ErrEmailIgnored):
+		respond(c, http.StatusOK, domain.APIResponse{
+			Code:    domain.CodeSuccess,
+			Message: "Email ignored",
+		})
+	case errors.Is(err, services.ErrInvalidEmail):
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error("Failed to process inbound email", "bot_id", c.Query("bot_id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to process email",
+		})
+	}
+}
+
+// SetupEmailRoutes registra los webhooks de entrada del canal de email; son
+// públicos para el RBAC y se protegen con EMAIL_INBOUND_TOKEN, que es lo que
+// permite al llamante elegir el bot con ?bot_id=
+func SetupEmailRoutes(router *gin.RouterGroup, handler *EmailHandler) {
+	router.POST("/channels/email/sendgrid", handler.ReceiveSendGridEmail)
+	router.POST("/channels/email/ses", handler.ReceiveSESEmail)
+}
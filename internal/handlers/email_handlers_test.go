@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailHandler_RequiresInboundToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+	channel := services.NewEmailChannel(nil, services.NewLogEmailSender(log), services.EmailChannelConfig{}, log)
+
+	post := func(token, query string) int {
+		router := gin.New()
+		SetupEmailRoutes(router.Group("/api/v1"), NewEmailHandler(channel, token, log))
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/channels/email/ses"+query, strings.NewReader(`{"Type":"Notification"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// Sin token configurado no se acepta nada, ni eligiendo el bot
+	assert.Equal(t, http.StatusUnauthorized, post("", ""))
+	assert.Equal(t, http.StatusUnauthorized, post("", "?token=&bot_id=bot-1"))
+	assert.Equal(t, http.StatusUnauthorized, post("secret", "?token=wrong&bot_id=bot-1"))
+	// Con el token pasa al canal, que rechaza el mensaje sin firma SNS
+	assert.Equal(t, http.StatusBadRequest, post("secret", "?token=secret&bot_id=bot-1"))
+}
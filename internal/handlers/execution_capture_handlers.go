@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ExecutionCaptureHandler expone las ejecuciones de agentes capturadas
+type ExecutionCaptureHandler struct {
+	captureService services.ExecutionCaptureService
+	logger         logger.Logger
+}
+
+// NewExecutionCaptureHandler crea un nuevo handler de ejecuciones capturadas
+func NewExecutionCaptureHandler(captureService services.ExecutionCaptureService, logger logger.Logger) *ExecutionCaptureHandler {
+	return &ExecutionCaptureHandler{
+		captureService: captureService,
+		logger:         logger,
+	}
+}
+
+// SearchExecutions godoc
+// @Summary Buscar ejecuciones de agentes capturadas
+// @Description Ejecuciones muestreadas de los bots con execution_capture activo, las más recientes primero. La entrada y la salida están redactadas.
+// @Tags mcp
+// @Produce json
+// @Param bot_id query string false "Filter by bot ID"
+// @Param task_type query string false "Filter by task type"
+// @Param agent_type query string false "Filter by agent type"
+// @Param success query bool false "Filter by result"
+// @Param from query string false "Captured after (RFC3339)"
+// @Param to query string false "Captured before (RFC3339)"
+// @Param limit query int false "Limit results"
+// @Param offset query int false "Offset results"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /mcp/executions [get]
+func (h *ExecutionCaptureHandler) SearchExecutions(c *gin.Context) {
+	filter := domain.AgentExecutionFilter{
+		BotID:     c.Query("bot_id"),
+		TaskType:  c.Query("task_type"),
+		AgentType: c.Query("agent_type"),
+	}
+
+	for _, bound := range []struct {
+		name   string
+		target **time.Time
+	}{{"from", &filter.From}, {"to", &filter.To}} {
+		value := c.Query(bound.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid " + bound.name + ": expected RFC3339",
+			})
+			return
+		}
+		*bound.target = &parsed
+	}
+	if value := c.Query("success"); value != "" {
+		success, err := strconv.ParseBool(value)
+		if err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid success: expected true or false",
+			})
+			return
+		}
+		filter.Success = &success
+	}
+
+	filter.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
+	}
+
+	executions, total, err := h.captureService.Search(c.Request.Context(), filter)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to search agent executions", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to search agent executions",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Agent executions retrieved successfully",
+		Data: gin.H{
+			"executions": executions,
+			"total":      total,
+		},
+	})
+}
+
+// GetExecution godoc
+// @Summary Obtener ejecución de agente capturada
+// @Description Tarea y resultado redactados de una ejecución capturada
+// @Tags mcp
+// @Produce json
+// @Param id path string true "Execution ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /mcp/executions/{id} [get]
+func (h *ExecutionCaptureHandler) GetExecution(c *gin.Context) {
+	execution, err := h.captureService.GetExecution(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if errors.Is(err, services.ErrAgentExecutionNotFound) {
+			respond(c, http.StatusNotFound, domain.APIResponse{
+				Code:    domain.CodeNotFound,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get agent execution", "execution_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to get agent execution",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Agent execution retrieved successfully",
+		Data:    execution,
+	})
+}
+
+// SetupExecutionCaptureRoutes registra la búsqueda de ejecuciones capturadas
+func SetupExecutionCaptureRoutes(router *gin.RouterGroup, handler *ExecutionCaptureHandler) {
+	router.GET("/mcp/executions", handler.SearchExecutions)
+	router.GET("/mcp/executions/:id", handler.GetExecution)
+}
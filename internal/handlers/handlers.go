@@ -4,6 +4,8 @@ import (
 	"net/http"
 
 	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/i18n"
+	"github.com/company/bot-service/internal/metrics"
 	"github.com/company/bot-service/internal/middleware"
 	"github.com/company/bot-service/internal/services"
 	"github.com/company/bot-service/pkg/logger"
@@ -17,7 +19,7 @@ type Handler struct {
 	logger        logger.Logger
 }
 
-func SetupRoutes(router *gin.Engine, healthService services.HealthService, botHandler *BotHandler, mcpHandler *MCPHandler, taskHandler *TaskHandler, testHandler *TestHandlers, logger logger.Logger) {
+func SetupRoutes(router *gin.Engine, healthService services.HealthService, botHandler *BotHandler, mcpHandler *MCPHandler, taskHandler *TaskHandler, knowledgeHandler *KnowledgeHandler, testHandler *TestHandlers, logger logger.Logger) {
 	h := &Handler{
 		healthService: healthService,
 		logger:        logger,
@@ -26,12 +28,16 @@ func SetupRoutes(router *gin.Engine, healthService services.HealthService, botHa
 	// Swagger documentation (protegido en producción)
 	router.GET("/swagger/*any", middleware.SwaggerAuth(), ginSwagger.WrapHandler(swaggerFiles.Handler))
 
+	// Métricas Prometheus (monitoring/prometheus.yml)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
 	// API routes
 	api := router.Group("/api/v1")
 	{
 		// Health check
 		api.GET("/health", h.HealthCheck)
 		api.GET("/ready", h.ReadinessCheck)
+		api.GET("/errors", h.ListErrorCodes)
 		
 		// Bot routes
 		if botHandler != nil {
@@ -48,6 +54,11 @@ func SetupRoutes(router *gin.Engine, healthService services.HealthService, botHa
 			SetupTaskRoutes(api, taskHandler)
 		}
 		
+		// Knowledge base routes
+		if knowledgeHandler != nil {
+			SetupKnowledgeRoutes(api, knowledgeHandler)
+		}
+		
 		// Test routes
 		if testHandler != nil {
 			testHandler.RegisterRoutes(api)
@@ -71,7 +82,7 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	status := h.healthService.CheckHealth()
 	
 	response := domain.APIResponse{
-		Code:    "SUCCESS",
+		Code:    domain.CodeSuccess,
 		Message: "Service is healthy",
 		Data:    status,
 	}
@@ -92,19 +103,47 @@ func (h *Handler) ReadinessCheck(c *gin.Context) {
 	
 	if status["ready"].(bool) {
 		response := domain.APIResponse{
-			Code:    "SUCCESS",
+			Code:    domain.CodeSuccess,
 			Message: "Service is ready",
 			Data:    status,
 		}
 		c.JSON(http.StatusOK, response)
 	} else {
 		response := domain.APIResponse{
-			Code:    "SERVICE_UNAVAILABLE",
+			Code:    domain.CodeServiceUnavailable,
 			Message: "Service is not ready",
 			Data:    status,
 		}
-		c.JSON(http.StatusServiceUnavailable, response)
+		respond(c, http.StatusServiceUnavailable, response)
+	}
+}
+
+// ListErrorCodes godoc
+// @Summary List API error codes
+// @Description Catálogo de códigos que puede devolver APIResponse.code, con su estado HTTP, significado y mensaje en el idioma de Accept-Language
+// @Tags errors
+// @Produce json
+// @Param Accept-Language header string false "Idioma de los mensajes (en, es)"
+// @Success 200 {object} domain.APIResponse
+// @Router /errors [get]
+func (h *Handler) ListErrorCodes(c *gin.Context) {
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	type errorCode struct {
+		domain.ErrorCodeInfo
+		Message string `json:"message"`
+	}
+	catalog := make([]errorCode, 0)
+	for _, info := range domain.ErrorCatalog() {
+		message, _ := i18n.ErrorMessage(lang, info.Code)
+		catalog = append(catalog, errorCode{ErrorCodeInfo: info, Message: message})
 	}
+
+	c.Header("Content-Language", lang)
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Error codes retrieved successfully",
+		Data:    catalog,
+	})
 }
 
 // Ejemplo de handler comentado para testing
@@ -1,10 +1,12 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/services"
 	"github.com/company/bot-service/pkg/logger"
 	"github.com/gin-gonic/gin"
@@ -20,7 +22,7 @@ func TestHealthCheck(t *testing.T) {
 	logger := logger.NewLogger("debug")
 	
 	// Pass nil for botHandler since we're only testing health endpoints
-	SetupRoutes(router, healthService, nil, logger)
+	SetupRoutes(router, healthService, nil, nil, nil, nil, nil, logger)
 	
 	// Test
 	w := httptest.NewRecorder()
@@ -41,7 +43,7 @@ func TestReadinessCheck(t *testing.T) {
 	logger := logger.NewLogger("debug")
 	
 	// Pass nil for botHandler since we're only testing health endpoints
-	SetupRoutes(router, healthService, nil, logger)
+	SetupRoutes(router, healthService, nil, nil, nil, nil, nil, logger)
 	
 	// Test
 	w := httptest.NewRecorder()
@@ -51,4 +53,33 @@ func TestReadinessCheck(t *testing.T) {
 	// Assertions
 	assert.Equal(t, http.StatusOK, w.Code)
 	assert.Contains(t, w.Body.String(), "ready")
-}
\ No newline at end of file
+}
+
+func TestListErrorCodes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	SetupRoutes(router, services.NewHealthService(), nil, nil, nil, nil, nil, logger.NewLogger("error"))
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/errors", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Code string                 `json:"code"`
+		Data []domain.ErrorCodeInfo `json:"data"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, domain.CodeSuccess, response.Code)
+
+	seen := map[string]bool{}
+	for _, info := range response.Data {
+		assert.False(t, seen[info.Code], "duplicated code %s", info.Code)
+		seen[info.Code] = true
+		assert.NotZero(t, info.HTTPStatus, info.Code)
+		assert.NotEmpty(t, info.Description, info.Code)
+	}
+	for _, code := range []string{domain.CodeBotNotFound, domain.CodeFlowInvalid, domain.CodeQuotaExceeded, domain.CodeAgentUnavailable} {
+		assert.True(t, seen[code], code)
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// IncomingBatchHandler recibe los mensajes acumulados por messaging-service
+type IncomingBatchHandler struct {
+	processor *services.IncomingBatchProcessor
+	logger    logger.Logger
+}
+
+// NewIncomingBatchHandler crea un nuevo handler de batches de mensajes
+func NewIncomingBatchHandler(processor *services.IncomingBatchProcessor, logger logger.Logger) *IncomingBatchHandler {
+	return &IncomingBatchHandler{
+		processor: processor,
+		logger:    logger,
+	}
+}
+
+// ProcessIncomingBatch godoc
+// @Summary Procesar un batch de mensajes entrantes
+// @Description Procesa varios mensajes de una vez, por ejemplo para vaciar el backlog tras una caída. Las conversaciones se procesan en paralelo y los mensajes de cada una en orden; el resultado de cada mensaje va en su posición y un fallo no detiene el resto.
+// @Tags messaging
+// @Accept json
+// @Produce json
+// @Param messages body []domain.IncomingMessage true "Incoming messages"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 413 {object} domain.APIResponse
+// @Router /incoming/batch [post]
+func (h *IncomingBatchHandler) ProcessIncomingBatch(c *gin.Context) {
+	var messages []domain.IncomingMessage
+	if err := c.ShouldBindJSON(&messages); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid message batch: " + err.Error(),
+		})
+		return
+	}
+	if len(messages) == 0 {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Message batch is empty",
+		})
+		return
+	}
+
+	result, err := h.processor.Process(c.Request.Context(), messages)
+	if err != nil {
+		if errors.Is(err, services.ErrIncomingBatchTooLarge) {
+			respond(c, http.StatusRequestEntityTooLarge, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to process incoming batch", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to process message batch",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Message batch processed",
+		Data:    result,
+	})
+}
+
+// SetupIncomingBatchRoutes registra POST /incoming/batch
+func SetupIncomingBatchRoutes(router *gin.RouterGroup, handler *IncomingBatchHandler) {
+	router.POST("/incoming/batch", handler.ProcessIncomingBatch)
+}
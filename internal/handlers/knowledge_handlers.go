@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// KnowledgeHandler maneja las fuentes de la base de conocimiento y su sincronización
+type KnowledgeHandler struct {
+	knowledgeService services.KnowledgeService
+	logger           logger.Logger
+}
+
+// NewKnowledgeHandler crea un nuevo handler de base de conocimiento
+func NewKnowledgeHandler(knowledgeService services.KnowledgeService, logger logger.Logger) *KnowledgeHandler {
+	return &KnowledgeHandler{
+		knowledgeService: knowledgeService,
+		logger:           logger,
+	}
+}
+
+const redactedValue = "********"
+
+// redactSource oculta las credenciales de la configuración antes de devolver la fuente
+func redactSource(source *domain.KnowledgeSource) *domain.KnowledgeSource {
+	redacted := *source
+	redacted.Config = make(map[string]interface{}, len(source.Config))
+	for key, value := range source.Config {
+		lower := strings.ToLower(key)
+		if strings.Contains(lower, "token") || strings.Contains(lower, "password") || strings.Contains(lower, "secret") {
+			value = redactedValue
+		}
+		redacted.Config[key] = value
+	}
+	return &redacted
+}
+
+// ListSources godoc
+// @Summary Listar fuentes de conocimiento
+// @Description Obtiene las fuentes de sincronización de la base de conocimiento de un bot
+// @Tags knowledge
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/knowledge/sources [get]
+func (h *KnowledgeHandler) ListSources(c *gin.Context) {
+	botID := c.Param("id")
+
+	sources, err := h.knowledgeService.GetSourcesByBot(c.Request.Context(), botID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get knowledge sources", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to retrieve knowledge sources",
+		})
+		return
+	}
+
+	result := make([]*domain.KnowledgeSource, 0, len(sources))
+	for _, source := range sources {
+		result = append(result, redactSource(source))
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge sources retrieved successfully",
+		Data:    result,
+	})
+}
+
+// CreateSource godoc
+// @Summary Crear fuente de conocimiento
+// @Description Registra un sitemap, una base de datos de Notion o un espacio de Confluence para sincronizar
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param source body domain.KnowledgeSource true "Knowledge source"
+// @Success 201 {object} domain.APIResponse
+// @Router /bots/{id}/knowledge/sources [post]
+func (h *KnowledgeHandler) CreateSource(c *gin.Context) {
+	var source domain.KnowledgeSource
+	if err := c.ShouldBindJSON(&source); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid source data: " + err.Error(),
+		})
+		return
+	}
+	source.BotID = c.Param("id")
+
+	if err := h.knowledgeService.CreateSource(c.Request.Context(), &source); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Failed to create knowledge source: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge source created successfully",
+		Data:    redactSource(&source),
+	})
+}
+
+// GetSource godoc
+// @Summary Obtener fuente de conocimiento
+// @Tags knowledge
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /knowledge/sources/{id} [get]
+func (h *KnowledgeHandler) GetSource(c *gin.Context) {
+	source, err := h.knowledgeService.GetSource(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeKnowledgeSourceNotFound,
+			Message: "Knowledge source not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge source retrieved successfully",
+		Data:    redactSource(source),
+	})
+}
+
+// UpdateSource godoc
+// @Summary Actualizar fuente de conocimiento
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "Source ID"
+// @Param source body map[string]interface{} true "Campos a actualizar"
+// @Success 200 {object} domain.APIResponse
+// @Router /knowledge/sources/{id} [patch]
+func (h *KnowledgeHandler) UpdateSource(c *gin.Context) {
+	id := c.Param("id")
+
+	source, err := h.knowledgeService.GetSource(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeKnowledgeSourceNotFound,
+			Message: "Knowledge source not found",
+		})
+		return
+	}
+
+	var updates struct {
+		Name                *string                `json:"name"`
+		Config              map[string]interface{} `json:"config"`
+		SyncIntervalMinutes *int                   `json:"sync_interval_minutes"`
+		Enabled             *bool                  `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&updates); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid source data: " + err.Error(),
+		})
+		return
+	}
+
+	if updates.Name != nil {
+		source.Name = *updates.Name
+	}
+	if source.Config == nil {
+		source.Config = make(map[string]interface{})
+	}
+	for key, value := range updates.Config {
+		// Las credenciales ocultas que el cliente reenvía no se sobrescriben
+		if value == redactedValue {
+			continue
+		}
+		source.Config[key] = value
+	}
+	if updates.SyncIntervalMinutes != nil {
+		source.SyncIntervalMinutes = *updates.SyncIntervalMinutes
+	}
+	if updates.Enabled != nil {
+		source.Enabled = *updates.Enabled
+	}
+
+	if err := h.knowledgeService.UpdateSource(c.Request.Context(), source); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Failed to update knowledge source: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge source updated successfully",
+		Data:    redactSource(source),
+	})
+}
+
+// DeleteSource godoc
+// @Summary Eliminar fuente de conocimiento
+// @Description Elimina la fuente y todos sus documentos indexados
+// @Tags knowledge
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /knowledge/sources/{id} [delete]
+func (h *KnowledgeHandler) DeleteSource(c *gin.Context) {
+	if err := h.knowledgeService.DeleteSource(c.Request.Context(), c.Param("id")); err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeKnowledgeSourceNotFound,
+			Message: "Knowledge source not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge source deleted successfully",
+	})
+}
+
+// SyncSource godoc
+// @Summary Sincronizar fuente de conocimiento
+// @Description Sincroniza la fuente de forma incremental: sólo re-indexa los documentos modificados
+// @Tags knowledge
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /knowledge/sources/{id}/sync [post]
+func (h *KnowledgeHandler) SyncSource(c *gin.Context) {
+	id := c.Param("id")
+
+	status, err := h.knowledgeService.SyncSource(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Knowledge sync failed", "source_id", id, "error", err)
+		respond(c, http.StatusBadGateway, domain.APIResponse{
+			Code:    domain.CodeSyncFailed,
+			Message: "Knowledge source sync failed: " + err.Error(),
+			Data:    status,
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge source synced successfully",
+		Data:    status,
+	})
+}
+
+// GetSyncStatus godoc
+// @Summary Estado de sincronización
+// @Tags knowledge
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /knowledge/sources/{id}/status [get]
+func (h *KnowledgeHandler) GetSyncStatus(c *gin.Context) {
+	source, err := h.knowledgeService.GetSource(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeKnowledgeSourceNotFound,
+			Message: "Knowledge source not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Sync status retrieved successfully",
+		Data:    source.Status,
+	})
+}
+
+// ListDocuments godoc
+// @Summary Listar documentos indexados
+// @Tags knowledge
+// @Produce json
+// @Param id path string true "Source ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /knowledge/sources/{id}/documents [get]
+func (h *KnowledgeHandler) ListDocuments(c *gin.Context) {
+	id := c.Param("id")
+
+	documents, err := h.knowledgeService.GetDocuments(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get knowledge documents", "source_id", id, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to retrieve knowledge documents",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge documents retrieved successfully",
+		Data:    documents,
+	})
+}
+
+// Search godoc
+// @Summary Buscar en la base de conocimiento
+// @Tags knowledge
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param request body map[string]interface{} true "query y limit"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/knowledge/search [post]
+func (h *KnowledgeHandler) Search(c *gin.Context) {
+	botID := c.Param("id")
+
+	var request struct {
+		Query string `json:"query" binding:"required"`
+		Limit int    `json:"limit"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	results, err := h.knowledgeService.Search(c.Request.Context(), botID, request.Query, request.Limit)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Knowledge search failed", "bot_id", botID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Knowledge search failed",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Knowledge search completed",
+		Data:    results,
+	})
+}
+
+// SetupKnowledgeRoutes configura las rutas de la base de conocimiento
+func SetupKnowledgeRoutes(router *gin.RouterGroup, handler *KnowledgeHandler) {
+	router.GET("/bots/:id/knowledge/sources", handler.ListSources)
+	router.POST("/bots/:id/knowledge/sources", handler.CreateSource)
+	router.POST("/bots/:id/knowledge/search", handler.Search)
+
+	router.GET("/knowledge/sources/:id", handler.GetSource)
+	router.PATCH("/knowledge/sources/:id", handler.UpdateSource)
+	router.DELETE("/knowledge/sources/:id", handler.DeleteSource)
+	router.POST("/knowledge/sources/:id/sync", handler.SyncSource)
+	router.GET("/knowledge/sources/:id/status", handler.GetSyncStatus)
+	router.GET("/knowledge/sources/:id/documents", handler.ListDocuments)
+}
@@ -1,12 +1,15 @@
 package handlers
 
 import (
-	"fmt"
+	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 	"github.com/gin-gonic/gin"
 )
@@ -41,8 +44,8 @@ func NewMCPHandler(orchestrator interface {
 func (h *MCPHandler) CreateAgent(c *gin.Context) {
 	var config mcp.MCPConfig
 	if err := c.ShouldBindJSON(&config); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid agent configuration: " + err.Error(),
 		})
 		return
@@ -57,23 +60,33 @@ func (h *MCPHandler) CreateAgent(c *gin.Context) {
 	}
 
 	agent, err := h.orchestrator.InstantiateMCP(c.Request.Context(), config)
+	var validationErr *configschema.ValidationError
+	if errors.As(err, &validationErr) {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid agent configuration",
+			Data:    validationErr,
+		})
+		return
+	}
 	if err != nil {
-		h.logger.Error("Failed to create MCP agent", "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create MCP agent", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to create agent: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Agent created successfully",
 		Data: map[string]interface{}{
 			"agent_id":     agent.GetID(),
 			"type":         agent.GetType(),
 			"capabilities": agent.GetCapabilities(),
 			"state":        agent.GetState(),
+			"persistent":   config.Persistent,
 		},
 	})
 }
@@ -91,17 +104,19 @@ func (h *MCPHandler) ListAgents(c *gin.Context) {
 	
 	agentList := make([]map[string]interface{}, 0, len(agents))
 	for _, agent := range agents {
+		breaker, _ := h.orchestrator.GetBreakerStatus(agent.GetID())
 		agentList = append(agentList, map[string]interface{}{
-			"agent_id":     agent.GetID(),
-			"type":         agent.GetType(),
-			"capabilities": agent.GetCapabilities(),
-			"state":        agent.GetState(),
-			"healthy":      agent.IsHealthy(),
+			"agent_id":        agent.GetID(),
+			"type":            agent.GetType(),
+			"capabilities":    agent.GetCapabilities(),
+			"state":           agent.GetState(),
+			"healthy":         agent.IsHealthy(),
+			"circuit_breaker": breaker,
 		})
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Agents retrieved successfully",
 		Data: map[string]interface{}{
 			"agents": agentList,
@@ -124,24 +139,31 @@ func (h *MCPHandler) GetAgent(c *gin.Context) {
 	
 	agent, err := h.orchestrator.GetAgent(agentID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeAgentNotFound,
 			Message: "Agent not found",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	breaker, _ := h.orchestrator.GetBreakerStatus(agentID)
+	data := map[string]interface{}{
+		"agent_id":        agent.GetID(),
+		"type":            agent.GetType(),
+		"capabilities":    agent.GetCapabilities(),
+		"state":           agent.GetState(),
+		"context":         agent.GetContext(),
+		"healthy":         agent.IsHealthy(),
+		"circuit_breaker": breaker,
+	}
+	// Los agentes mcp_client muestran las herramientas del servidor con su esquema
+	if tools := mcp.AgentTools(agent); tools != nil {
+		data["tools"] = tools
+	}
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Agent retrieved successfully",
-		Data: map[string]interface{}{
-			"agent_id":     agent.GetID(),
-			"type":         agent.GetType(),
-			"capabilities": agent.GetCapabilities(),
-			"state":        agent.GetState(),
-			"context":      agent.GetContext(),
-			"healthy":      agent.IsHealthy(),
-		},
+		Data:    data,
 	})
 }
 
@@ -158,20 +180,83 @@ func (h *MCPHandler) TerminateAgent(c *gin.Context) {
 	agentID := c.Param("id")
 	
 	if err := h.orchestrator.TerminateAgent(c.Request.Context(), agentID); err != nil {
-		h.logger.Error("Failed to terminate agent", "agent_id", agentID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to terminate agent", "agent_id", agentID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to terminate agent",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Agent terminated successfully",
 	})
 }
 
+// UpdateAgentConfig godoc
+// @Summary Actualizar configuración de agente
+// @Description Cambia la configuración segura (timeouts, headers, modelo, temperatura) de un agente en ejecución sin terminarlo
+// @Tags mcp
+// @Accept json
+// @Produce json
+// @Param id path string true "Agent ID"
+// @Param config body map[string]interface{} true "Campos de configuración a cambiar"
+// @Success 200 {object} domain.APIResponse
+// @Router /mcp/agents/{id}/config [patch]
+func (h *MCPHandler) UpdateAgentConfig(c *gin.Context) {
+	agentID := c.Param("id")
+
+	var changes map[string]interface{}
+	if err := c.ShouldBindJSON(&changes); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid config data: " + err.Error(),
+		})
+		return
+	}
+
+	if _, err := h.orchestrator.GetAgent(agentID); err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeAgentNotFound,
+			Message: "Agent not found",
+		})
+		return
+	}
+
+	change, err := h.orchestrator.UpdateAgentConfig(c.Request.Context(), agentID, changes)
+	var validationErr *configschema.ValidationError
+	if errors.As(err, &validationErr) {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid agent configuration",
+			Data:    validationErr,
+		})
+		return
+	}
+	if errors.Is(err, mcp.ErrConfigUpdateNotSupported) {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update agent config", "agent_id", agentID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to update agent config: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Agent config updated successfully",
+		Data:    change,
+	})
+}
+
 // ExecuteTask godoc
 // @Summary Ejecutar tarea en agente MCP
 // @Description Ejecuta una tarea específica usando el sistema de orquestación MCP
@@ -184,8 +269,8 @@ func (h *MCPHandler) TerminateAgent(c *gin.Context) {
 func (h *MCPHandler) ExecuteTask(c *gin.Context) {
 	var task domain.MCPTask
 	if err := c.ShouldBindJSON(&task); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid task data: " + err.Error(),
 		})
 		return
@@ -211,21 +296,115 @@ func (h *MCPHandler) ExecuteTask(c *gin.Context) {
 
 	result, err := h.orchestrator.ExecuteTaskDomain(c.Request.Context(), &task)
 	if err != nil {
-		h.logger.Error("Task execution failed", "task_id", task.ID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Task execution failed", "task_id", task.ID, "error", err)
+		if errors.Is(err, mcp.ErrNoAgentAvailable) {
+			respond(c, http.StatusServiceUnavailable, domain.APIResponse{
+				Code:    domain.CodeAgentUnavailable,
+				Message: err.Error(),
+				Data:    result,
+			})
+			return
+		}
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Task execution failed: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Task executed successfully",
 		Data:    result,
 	})
 }
 
+// CoordinateTask godoc
+// @Summary Coordinar varios agentes MCP
+// @Description Ejecuta una tarea en varios agentes con la estrategia first, fan_out (combina las salidas), pipeline (la salida de cada agente es la entrada del siguiente) o vote (devuelve la respuesta en la que coincide el quórum)
+// @Tags mcp
+// @Accept json
+// @Produce json
+// @Param request body object true "agent_ids, type, input, metadata y coordination (strategy, stage_timeout_ms, min_successes, quorum, vote_field)"
+// @Success 200 {object} domain.APIResponse
+// @Router /mcp/coordinate [post]
+func (h *MCPHandler) CoordinateTask(c *gin.Context) {
+	var req struct {
+		AgentIDs     []string               `json:"agent_ids" binding:"required,min=1"`
+		Type         string                 `json:"type" binding:"required"`
+		Description  string                 `json:"description"`
+		Input        map[string]interface{} `json:"input"`
+		Metadata     map[string]interface{} `json:"metadata"`
+		Timeout      int64                  `json:"timeout"` // en milliseconds
+		Coordination map[string]interface{} `json:"coordination"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid request data: " + err.Error(),
+		})
+		return
+	}
+
+	agents := make([]mcp.Agent, 0, len(req.AgentIDs))
+	for _, agentID := range req.AgentIDs {
+		agent, err := h.orchestrator.GetAgent(agentID)
+		if err != nil {
+			respond(c, http.StatusNotFound, domain.APIResponse{
+				Code:    domain.CodeAgentNotFound,
+				Message: "Agent not found: " + agentID,
+			})
+			return
+		}
+		agents = append(agents, agent)
+	}
+
+	task := mcp.Task{
+		ID:          generateTaskID(),
+		Type:        req.Type,
+		Description: req.Description,
+		Input:       req.Input,
+		Metadata:    req.Metadata,
+	}
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]interface{})
+	}
+	if req.Coordination != nil {
+		task.Metadata["coordination"] = req.Coordination
+	}
+
+	ctx := c.Request.Context()
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	result, err := h.orchestrator.CoordinateAgents(ctx, agents, task)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("Agent coordination failed", "task_id", task.ID, "error", err)
+		status, code := http.StatusInternalServerError, domain.CodeInternalError
+		switch {
+		case errors.Is(err, mcp.ErrInvalidCoordination):
+			status, code = http.StatusBadRequest, domain.CodeInvalidRequest
+		case errors.Is(err, mcp.ErrNoAgentAvailable):
+			status, code = http.StatusServiceUnavailable, domain.CodeAgentUnavailable
+		}
+		respond(c, status, domain.APIResponse{
+			Code:    code,
+			Message: err.Error(),
+			Data:    result,
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Task coordinated successfully",
+		Data:    result,
+	})
+}
+
 // PassContext godoc
 // @Summary Pasar contexto a agente
 // @Description Pasa contexto específico a un agente MCP
@@ -241,24 +420,24 @@ func (h *MCPHandler) PassContext(c *gin.Context) {
 	
 	var context map[string]interface{}
 	if err := c.ShouldBindJSON(&context); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid context data: " + err.Error(),
 		})
 		return
 	}
 
 	if err := h.orchestrator.PassContext(c.Request.Context(), agentID, context); err != nil {
-		h.logger.Error("Failed to pass context", "agent_id", agentID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to pass context", "agent_id", agentID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to pass context: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Context passed successfully",
 	})
 }
@@ -277,20 +456,58 @@ func (h *MCPHandler) GetAgentMetrics(c *gin.Context) {
 	
 	metrics, err := h.orchestrator.GetAgentMetricsDomain(agentID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeAgentNotFound,
 			Message: "Agent not found",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Agent metrics retrieved successfully",
 		Data:    metrics,
 	})
 }
 
+// GetAgentHistory godoc
+// @Summary Obtener historial de agente
+// @Description Devuelve las transiciones de estado y los últimos errores de un agente MCP
+// @Tags mcp
+// @Accept json
+// @Produce json
+// @Param id path string true "Agent ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /mcp/agents/{id}/history [get]
+func (h *MCPHandler) GetAgentHistory(c *gin.Context) {
+	agentID := c.Param("id")
+
+	agent, err := h.orchestrator.GetAgent(agentID)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeAgentNotFound,
+			Message: "Agent not found",
+		})
+		return
+	}
+
+	history := agent.GetHistory()
+	state := agent.GetState()
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Agent history retrieved successfully",
+		Data: map[string]interface{}{
+			"agent_id":       history.AgentID,
+			"status":         state.Status,
+			"healthy":        agent.IsHealthy(),
+			"transitions":    history.Transitions,
+			"errors":         history.Errors,
+			"config_history": state.ConfigHistory,
+		},
+	})
+}
+
 // GetSystemMetrics godoc
 // @Summary Obtener métricas del sistema MCP
 // @Description Obtiene las métricas generales del sistema de orquestación MCP
@@ -302,16 +519,16 @@ func (h *MCPHandler) GetAgentMetrics(c *gin.Context) {
 func (h *MCPHandler) GetSystemMetrics(c *gin.Context) {
 	metrics, err := h.orchestrator.GetSystemMetricsDomain()
 	if err != nil {
-		h.logger.Error("Failed to get system metrics", "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get system metrics", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to get system metrics",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "System metrics retrieved successfully",
 		Data:    metrics,
 	})
@@ -319,75 +536,121 @@ func (h *MCPHandler) GetSystemMetrics(c *gin.Context) {
 
 // GetSupportedAgentTypes godoc
 // @Summary Obtener tipos de agentes soportados
-// @Description Obtiene la lista de tipos de agentes MCP soportados
+// @Description Obtiene los tipos de agentes MCP registrados, incluidos los personalizados, con sus capacidades y esquema de configuración
 // @Tags mcp
 // @Accept json
 // @Produce json
 // @Success 200 {object} domain.APIResponse
 // @Router /mcp/agent-types [get]
 func (h *MCPHandler) GetSupportedAgentTypes(c *gin.Context) {
-	types := h.orchestrator.GetSupportedAgentTypes()
-	
-	// Crear información detallada para cada tipo
-	supportedTypes := make([]map[string]interface{}, 0, len(types))
-	
-	for _, agentType := range types {
-		var description string
-		var capabilities []string
-		var configRequired map[string]string
-		
-		switch agentType {
-		case "ai":
-			description = "Agent that uses AI services for text generation and analysis"
-			capabilities = []string{"text_generation", "conversation", "analysis", "summarization"}
-			configRequired = map[string]string{
-				"openai_api_key": "OpenAI API key (optional, uses mock if not provided)",
-				"model":          "AI model to use (e.g., gpt-3.5-turbo)",
-			}
-		case "http":
-			description = "Agent that makes HTTP requests to external APIs"
-			capabilities = []string{"http_request", "api_call", "webhook", "integration"}
-			configRequired = map[string]string{
-				"base_url": "Base URL for HTTP requests",
-				"headers":  "Default headers for requests (optional)",
-			}
-		case "workflow":
-			description = "Agent that executes sequential workflow steps"
-			capabilities = []string{"workflow", "sequence", "orchestration", "automation"}
-			configRequired = map[string]string{
-				"steps": "Array of workflow steps to execute",
-			}
-		case "mock":
-			description = "Mock agent for testing and development"
-			capabilities = []string{"mock", "test", "development", "simulation"}
-			configRequired = map[string]string{}
-		default:
-			description = "Custom agent type"
-			capabilities = []string{"custom"}
-			configRequired = map[string]string{}
-		}
-		
-		supportedTypes = append(supportedTypes, map[string]interface{}{
-			"type":            agentType,
-			"description":     description,
-			"capabilities":    capabilities,
-			"config_required": configRequired,
-		})
-	}
+	types := h.orchestrator.GetAgentTypes()
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Supported agent types retrieved successfully",
 		Data: map[string]interface{}{
-			"types": supportedTypes,
-			"count": len(supportedTypes),
+			"types": types,
+			"count": len(types),
 		},
 	})
 }
 
+// GetDispatchStatus godoc
+// @Summary Estado del despacho de tareas
+// @Description Devuelve las pausas activas (global y por tipo de agente), las tareas en cola y las que esperan a que se libere un agente
+// @Tags mcp
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /mcp/dispatch [get]
+func (h *MCPHandler) GetDispatchStatus(c *gin.Context) {
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Dispatch status retrieved successfully",
+		Data:    h.orchestrator.GetDispatchStatus(),
+	})
+}
+
+// PauseDispatch godoc
+// @Summary Pausar despacho de tareas
+// @Description Pausa el despacho global o de un tipo de agente; las tareas nuevas esperan en cola hasta reanudar
+// @Tags mcp
+// @Accept json
+// @Produce json
+// @Param request body object false "agent_type opcional (vacío pausa todo) y reason"
+// @Success 200 {object} domain.APIResponse
+// @Router /mcp/dispatch/pause [post]
+func (h *MCPHandler) PauseDispatch(c *gin.Context) {
+	var req struct {
+		AgentType string `json:"agent_type"`
+		Reason    string `json:"reason"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := h.orchestrator.PauseDispatch(req.AgentType, req.Reason); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Dispatch paused successfully",
+		Data:    h.orchestrator.GetDispatchStatus(),
+	})
+}
+
+// ResumeDispatch godoc
+// @Summary Reanudar despacho de tareas
+// @Description Quita la pausa y libera las tareas en cola a drain_rate tareas por segundo (0 libera todas)
+// @Tags mcp
+// @Accept json
+// @Produce json
+// @Param request body object false "agent_type opcional y drain_rate"
+// @Success 200 {object} domain.APIResponse
+// @Router /mcp/dispatch/resume [post]
+func (h *MCPHandler) ResumeDispatch(c *gin.Context) {
+	var req struct {
+		AgentType string  `json:"agent_type"`
+		DrainRate float64 `json:"drain_rate"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid request data: " + err.Error(),
+			})
+			return
+		}
+	}
+
+	if err := h.orchestrator.ResumeDispatch(req.AgentType, req.DrainRate); err != nil {
+		respond(c, http.StatusConflict, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Dispatch resumed successfully",
+		Data:    h.orchestrator.GetDispatchStatus(),
+	})
+}
+
 // Función auxiliar para generar IDs de tarea
 func generateTaskID() string {
-	return fmt.Sprintf("task-%d", time.Now().UnixNano())
+	return "task-" + id.New()
 }
 
 // SetupMCPRoutes configura todas las rutas relacionadas con MCP
@@ -397,17 +660,25 @@ func SetupMCPRoutes(router *gin.RouterGroup, handler *MCPHandler) {
 	router.POST("/mcp/agents", handler.CreateAgent)
 	router.GET("/mcp/agents/:id", handler.GetAgent)
 	router.DELETE("/mcp/agents/:id", handler.TerminateAgent)
+	router.PATCH("/mcp/agents/:id/config", handler.UpdateAgentConfig)
 	
 	// Agent Context Management
 	router.POST("/mcp/agents/:id/context", handler.PassContext)
 	
 	// Task Execution
 	router.POST("/mcp/tasks", handler.ExecuteTask)
+	router.POST("/mcp/coordinate", handler.CoordinateTask)
 	
 	// Metrics and Monitoring
 	router.GET("/mcp/agents/:id/metrics", handler.GetAgentMetrics)
+	router.GET("/mcp/agents/:id/history", handler.GetAgentHistory)
 	router.GET("/mcp/metrics", handler.GetSystemMetrics)
 	
+	// Dispatch Control
+	router.GET("/mcp/dispatch", handler.GetDispatchStatus)
+	router.POST("/mcp/dispatch/pause", handler.PauseDispatch)
+	router.POST("/mcp/dispatch/resume", handler.ResumeDispatch)
+	
 	// Agent Types Information
 	router.GET("/mcp/agent-types", handler.GetSupportedAgentTypes)
 }
\ No newline at end of file
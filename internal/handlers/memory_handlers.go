@@ -0,0 +1,437 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// maxMemorySearchLimit acota los resultados de la búsqueda de memorias
+const maxMemorySearchLimit = 50
+
+// MemoryHandler gestiona la memoria a largo plazo de cada usuario con un bot
+type MemoryHandler struct {
+	memoryService services.MemoryService
+	logger        logger.Logger
+}
+
+// NewMemoryHandler crea un nuevo handler de memorias
+func NewMemoryHandler(memoryService services.MemoryService, logger logger.Logger) *MemoryHandler {
+	return &MemoryHandler{
+		memoryService: memoryService,
+		logger:        logger,
+	}
+}
+
+// MemoryRequest son los datos de una memoria. En las actualizaciones los
+// campos omitidos conservan su valor.
+type MemoryRequest struct {
+	Key        string                 `json:"key"`
+	Type       domain.MemoryType      `json:"type"`
+	Content    map[string]interface{} `json:"content"`
+	Tags       []string               `json:"tags"`
+	Importance int                    `json:"importance"` // 1-10; por defecto 5
+	ExpiresAt  *time.Time             `json:"expires_at"` // por defecto, la retención del servicio
+}
+
+// ContextSummaryRequest reemplaza el resumen de contexto del usuario
+type ContextSummaryRequest struct {
+	Summary   string                 `json:"summary"`
+	KeyPoints []string               `json:"key_points"`
+	Entities  map[string]interface{} `json:"entities"` // sin entities se conservan las actuales
+}
+
+var memoryTypes = map[domain.MemoryType]bool{
+	domain.MemoryTypePersonal:     true,
+	domain.MemoryTypePreference:   true,
+	domain.MemoryTypeConversation: true,
+	domain.MemoryTypeFact:         true,
+	domain.MemoryTypeGoal:         true,
+	domain.MemoryTypeHistory:      true,
+	domain.MemoryTypeCustom:       true,
+}
+
+// ListMemories godoc
+// @Summary Listar memorias de un usuario
+// @Description Memorias vigentes del usuario con el bot, las más importantes y recientes primero
+// @Tags memories
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Param type query string false "Tipo de memoria"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/memories [get]
+func (h *MemoryHandler) ListMemories(c *gin.Context) {
+	memories, err := h.memoryService.GetUserMemories(c.Request.Context(), c.Param("user_id"), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to list memories")
+		return
+	}
+
+	if memoryType := c.Query("type"); memoryType != "" {
+		filtered := memories[:0]
+		for _, memory := range memories {
+			if string(memory.Type) == memoryType {
+				filtered = append(filtered, memory)
+			}
+		}
+		memories = filtered
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Memories retrieved successfully",
+		Data: gin.H{
+			"memories": memories,
+			"count":    len(memories),
+		},
+	})
+}
+
+// CreateMemory godoc
+// @Summary Guardar memoria de un usuario
+// @Description Guarda una memoria con la clave indicada; si ya existe se reemplaza
+// @Tags memories
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Param request body MemoryRequest true "Datos de la memoria"
+// @Success 201 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/memories [post]
+func (h *MemoryHandler) CreateMemory(c *gin.Context) {
+	var request MemoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid memory data: " + err.Error(),
+		})
+		return
+	}
+	if strings.TrimSpace(request.Key) == "" {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "key is required",
+		})
+		return
+	}
+
+	memory := &domain.Memory{
+		ID:         id.New(),
+		UserID:     c.Param("user_id"),
+		BotID:      c.Param("id"),
+		Key:        request.Key,
+		Type:       domain.MemoryTypeCustom,
+		Importance: 5,
+	}
+	if !applyMemoryRequest(c, memory, request) {
+		return
+	}
+
+	if err := h.memoryService.StoreMemory(c.Request.Context(), memory); err != nil {
+		h.respondError(c, err, "Failed to store memory")
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Memory stored successfully",
+		Data:    memory,
+	})
+}
+
+// GetMemory godoc
+// @Summary Obtener memoria de un usuario
+// @Tags memories
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Param key path string true "Clave de la memoria"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/memories/{key} [get]
+func (h *MemoryHandler) GetMemory(c *gin.Context) {
+	memory, err := h.memoryService.GetMemory(c.Request.Context(), c.Param("user_id"), c.Param("id"), c.Param("key"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get memory")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Memory retrieved successfully",
+		Data:    memory,
+	})
+}
+
+// UpdateMemory godoc
+// @Summary Actualizar memoria de un usuario
+// @Description Modifica tipo, contenido, etiquetas, importancia o caducidad; los campos omitidos no cambian
+// @Tags memories
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Param key path string true "Clave de la memoria"
+// @Param request body MemoryRequest true "Campos a modificar"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/memories/{key} [put]
+func (h *MemoryHandler) UpdateMemory(c *gin.Context) {
+	var request MemoryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid memory data: " + err.Error(),
+		})
+		return
+	}
+
+	memory, err := h.memoryService.GetMemory(c.Request.Context(), c.Param("user_id"), c.Param("id"), c.Param("key"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get memory")
+		return
+	}
+	if !applyMemoryRequest(c, memory, request) {
+		return
+	}
+
+	if err := h.memoryService.UpdateMemory(c.Request.Context(), memory); err != nil {
+		h.respondError(c, err, "Failed to update memory")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Memory updated successfully",
+		Data:    memory,
+	})
+}
+
+// DeleteMemory godoc
+// @Summary Eliminar memoria de un usuario
+// @Tags memories
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Param key path string true "Clave de la memoria"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/memories/{key} [delete]
+func (h *MemoryHandler) DeleteMemory(c *gin.Context) {
+	if err := h.memoryService.DeleteMemory(c.Request.Context(), c.Param("user_id"), c.Param("id"), c.Param("key")); err != nil {
+		h.respondError(c, err, "Failed to delete memory")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Memory deleted successfully",
+	})
+}
+
+// SearchMemories godoc
+// @Summary Buscar memorias de un usuario
+// @Description Busca las memorias vigentes más parecidas a la consulta; sin embeddings compara el texto de la clave, las etiquetas y content.text
+// @Tags memories
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Param q query string true "Texto a buscar"
+// @Param limit query int false "Máximo de resultados (por defecto 10, hasta 50)"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/memories/search [get]
+func (h *MemoryHandler) SearchMemories(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "q is required",
+		})
+		return
+	}
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	limit = min(max(limit, 1), maxMemorySearchLimit)
+
+	memories, err := h.memoryService.SearchMemories(c.Request.Context(), c.Param("user_id"), c.Param("id"), query, limit)
+	if err != nil {
+		h.respondError(c, err, "Failed to search memories")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Memories retrieved successfully",
+		Data: gin.H{
+			"memories": memories,
+			"count":    len(memories),
+		},
+	})
+}
+
+// GetMemoryStats godoc
+// @Summary Estadísticas de memoria de un usuario
+// @Description Total de memorias vigentes por tipo e importancia, con la más antigua y la más reciente
+// @Tags memories
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/memories/stats [get]
+func (h *MemoryHandler) GetMemoryStats(c *gin.Context) {
+	stats, err := h.memoryService.GetMemoryStats(c.Request.Context(), c.Param("user_id"), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get memory stats")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Memory stats retrieved successfully",
+		Data:    stats,
+	})
+}
+
+// GetContextSummary godoc
+// @Summary Obtener resumen de contexto de un usuario
+// @Description Resumen, puntos clave y entidades que el bot recuerda del usuario; vacío si aún no hay
+// @Tags memories
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/context-summary [get]
+func (h *MemoryHandler) GetContextSummary(c *gin.Context) {
+	summary, err := h.memoryService.GetContextSummary(c.Request.Context(), c.Param("user_id"), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get context summary")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Context summary retrieved successfully",
+		Data:    summary,
+	})
+}
+
+// UpdateContextSummary godoc
+// @Summary Actualizar resumen de contexto de un usuario
+// @Description Reemplaza el resumen y los puntos clave; las entidades solo cambian si se envían
+// @Tags memories
+// @Accept json
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param user_id path string true "User ID"
+// @Param request body ContextSummaryRequest true "Resumen de contexto"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /bots/{id}/users/{user_id}/context-summary [put]
+func (h *MemoryHandler) UpdateContextSummary(c *gin.Context) {
+	var request ContextSummaryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid context summary: " + err.Error(),
+		})
+		return
+	}
+
+	summary, err := h.memoryService.GetContextSummary(c.Request.Context(), c.Param("user_id"), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get context summary")
+		return
+	}
+	summary.Summary = request.Summary
+	summary.KeyPoints = request.KeyPoints
+	if summary.KeyPoints == nil {
+		summary.KeyPoints = []string{}
+	}
+	if request.Entities != nil {
+		summary.Entities = request.Entities
+	}
+
+	if err := h.memoryService.UpdateContextSummary(c.Request.Context(), summary); err != nil {
+		h.respondError(c, err, "Failed to update context summary")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Context summary updated successfully",
+		Data:    summary,
+	})
+}
+
+// applyMemoryRequest copia a memory los campos enviados; si no son válidos responde 400
+func applyMemoryRequest(c *gin.Context, memory *domain.Memory, request MemoryRequest) bool {
+	if request.Type != "" {
+		if !memoryTypes[request.Type] {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "Invalid memory type: " + string(request.Type),
+			})
+			return false
+		}
+		memory.Type = request.Type
+	}
+	if request.Importance != 0 {
+		if request.Importance < 1 || request.Importance > 10 {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: "importance must be between 1 and 10",
+			})
+			return false
+		}
+		memory.Importance = request.Importance
+	}
+	if request.Content != nil {
+		memory.Content = request.Content
+	}
+	if request.Tags != nil {
+		memory.Tags = request.Tags
+	}
+	if request.ExpiresAt != nil {
+		memory.ExpiresAt = *request.ExpiresAt
+	}
+	return true
+}
+
+func (h *MemoryHandler) respondError(c *gin.Context, err error, message string) {
+	if errors.Is(err, services.ErrMemoryNotFound) {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeMemoryNotFound,
+			Message: err.Error(),
+		})
+		return
+	}
+	h.logger.WithContext(c.Request.Context()).Error(message, "bot_id", c.Param("id"), "user_id", c.Param("user_id"), "error", err)
+	respond(c, http.StatusInternalServerError, domain.APIResponse{
+		Code:    domain.CodeInternalError,
+		Message: message,
+	})
+}
+
+// SetupMemoryRoutes registra las rutas de memoria de usuarios
+func SetupMemoryRoutes(router *gin.RouterGroup, handler *MemoryHandler) {
+	router.GET("/bots/:id/users/:user_id/memories", handler.ListMemories)
+	router.POST("/bots/:id/users/:user_id/memories", handler.CreateMemory)
+	router.GET("/bots/:id/users/:user_id/memories/search", handler.SearchMemories)
+	router.GET("/bots/:id/users/:user_id/memories/stats", handler.GetMemoryStats)
+	router.GET("/bots/:id/users/:user_id/memories/:key", handler.GetMemory)
+	router.PUT("/bots/:id/users/:user_id/memories/:key", handler.UpdateMemory)
+	router.DELETE("/bots/:id/users/:user_id/memories/:key", handler.DeleteMemory)
+	router.GET("/bots/:id/users/:user_id/context-summary", handler.GetContextSummary)
+	router.PUT("/bots/:id/users/:user_id/context-summary", handler.UpdateContextSummary)
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/replication"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ReplicationHandler expone el estado de la réplica entre regiones y el failover
+type ReplicationHandler struct {
+	replicator *replication.Replicator
+	logger     logger.Logger
+}
+
+// NewReplicationHandler crea un nuevo handler de replicación
+func NewReplicationHandler(replicator *replication.Replicator, logger logger.Logger) *ReplicationHandler {
+	return &ReplicationHandler{
+		replicator: replicator,
+		logger:     logger,
+	}
+}
+
+// GetStatus godoc
+// @Summary Estado de la replicación
+// @Description Devuelve región, rol y contadores de la réplica de sesiones y memoria
+// @Tags replication
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /replication [get]
+func (h *ReplicationHandler) GetStatus(c *gin.Context) {
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Replication status retrieved successfully",
+		Data:    h.replicator.Status(),
+	})
+}
+
+// Failover godoc
+// @Summary Promover región a primaria
+// @Description Hace que esta región acepte y replique escrituras tras la caída de la primaria
+// @Tags replication
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /replication/failover [post]
+func (h *ReplicationHandler) Failover(c *gin.Context) {
+	status := h.replicator.Failover()
+	h.logger.WithContext(c.Request.Context()).Warn("Replication failover requested", "region", status.Region)
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Region promoted to primary",
+		Data:    status,
+	})
+}
+
+// SetRole godoc
+// @Summary Cambiar rol de la región
+// @Description Cambia el rol de replicación, p. ej. para devolver a secundaria una región recuperada
+// @Tags replication
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /replication/role [put]
+func (h *ReplicationHandler) SetRole(c *gin.Context) {
+	var req struct {
+		Role replication.Role `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid request: " + err.Error(),
+		})
+		return
+	}
+
+	if err := h.replicator.SetRole(req.Role); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Replication role updated",
+		Data:    h.replicator.Status(),
+	})
+}
+
+// SetupReplicationRoutes registra las rutas de replicación; solo se llama si
+// la replicación está activada
+func SetupReplicationRoutes(router *gin.RouterGroup, handler *ReplicationHandler) {
+	router.GET("/replication", handler.GetStatus)
+	router.POST("/replication/failover", handler.Failover)
+	router.PUT("/replication/role", handler.SetRole)
+}
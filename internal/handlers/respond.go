@@ -0,0 +1,18 @@
+package handlers
+
+import (
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/i18n"
+	"github.com/gin-gonic/gin"
+)
+
+// respond escribe una APIResponse. En los errores, Message sale del catálogo
+// en el idioma que pide el cliente en Accept-Language.
+func respond(c *gin.Context, status int, response domain.APIResponse) {
+	if response.Code != domain.CodeSuccess {
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		i18n.Localize(&response, lang)
+		c.Header("Content-Language", lang)
+	}
+	c.JSON(status, response)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/i18n"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// SandboxHandler expone los bots efímeros de sandbox
+type SandboxHandler struct {
+	sandboxService services.SandboxService
+	logger         logger.Logger
+}
+
+// NewSandboxHandler crea un nuevo handler de sandbox
+func NewSandboxHandler(sandboxService services.SandboxService, logger logger.Logger) *SandboxHandler {
+	return &SandboxHandler{
+		sandboxService: sandboxService,
+		logger:         logger,
+	}
+}
+
+// CreateSandboxBot godoc
+// @Summary Crear bot de sandbox
+// @Description Crea un bot completo (flujos, intents y pruebas de conversación del starter kit) que se elimina al pasar ttl_hours. Sus agentes MCP responden con datos simulados, sin llamar a servicios externos. El cuerpo es opcional.
+// @Tags sandbox
+// @Accept json
+// @Produce json
+// @Param request body services.SandboxBotRequest false "Nombre, sector, idioma y TTL"
+// @Success 201 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 429 {object} domain.APIResponse
+// @Router /sandbox/bots [post]
+func (h *SandboxHandler) CreateSandboxBot(c *gin.Context) {
+	var request services.SandboxBotRequest
+	if err := c.ShouldBindJSON(&request); err != nil && !errors.Is(err, io.EOF) {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid sandbox request: " + err.Error(),
+		})
+		return
+	}
+	if request.PrimaryLanguage == "" {
+		request.PrimaryLanguage = i18n.Negotiate(c.GetHeader("Accept-Language"))
+	}
+
+	result, err := h.sandboxService.CreateBot(c.Request.Context(), request, c.GetString("user_id"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidSandbox):
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: err.Error(),
+			})
+		case errors.Is(err, services.ErrSandboxLimit):
+			respond(c, http.StatusTooManyRequests, domain.APIResponse{
+				Code:    domain.CodeQuotaExceeded,
+				Message: err.Error(),
+			})
+		default:
+			h.logger.WithContext(c.Request.Context()).Error("Failed to create sandbox bot", "error", err)
+			respond(c, http.StatusInternalServerError, domain.APIResponse{
+				Code:    domain.CodeInternalError,
+				Message: "Failed to create sandbox bot",
+			})
+		}
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Sandbox bot created successfully",
+		Data:    result,
+	})
+}
+
+// ListSandboxBots godoc
+// @Summary Listar bots de sandbox
+// @Description Bots de sandbox vigentes creados por el usuario o API key que llama
+// @Tags sandbox
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /sandbox/bots [get]
+func (h *SandboxHandler) ListSandboxBots(c *gin.Context) {
+	bots, err := h.sandboxService.ListBots(c.Request.Context(), c.GetString("user_id"))
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to list sandbox bots", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to list sandbox bots",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Sandbox bots retrieved successfully",
+		Data: gin.H{
+			"bots":  bots,
+			"count": len(bots),
+		},
+	})
+}
+
+// DeleteSandboxBot godoc
+// @Summary Eliminar bot de sandbox
+// @Description Elimina antes de tiempo un bot de sandbox propio con sus flujos y pruebas
+// @Tags sandbox
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /sandbox/bots/{id} [delete]
+func (h *SandboxHandler) DeleteSandboxBot(c *gin.Context) {
+	if err := h.sandboxService.DeleteBot(c.Request.Context(), c.Param("id"), c.GetString("user_id")); err != nil {
+		if errors.Is(err, services.ErrSandboxBotNotFound) {
+			respond(c, http.StatusNotFound, domain.APIResponse{
+				Code:    domain.CodeBotNotFound,
+				Message: "Sandbox bot not found",
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to delete sandbox bot", "bot_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to delete sandbox bot",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Sandbox bot deleted successfully",
+	})
+}
+
+// SetupSandboxRoutes registra las rutas de sandbox; solo se llama si
+// SANDBOX_ENABLED está activo
+func SetupSandboxRoutes(router *gin.RouterGroup, handler *SandboxHandler) {
+	router.POST("/sandbox/bots", handler.CreateSandboxBot)
+	router.GET("/sandbox/bots", handler.ListSandboxBots)
+	router.DELETE("/sandbox/bots/:id", handler.DeleteSandboxBot)
+}
@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// ScriptApprovalHandler gestiona los scripts aprobados para los agentes sandbox
+type ScriptApprovalHandler struct {
+	approvalService services.ScriptApprovalService
+	logger          logger.Logger
+}
+
+// NewScriptApprovalHandler crea un nuevo handler de aprobaciones de scripts
+func NewScriptApprovalHandler(approvalService services.ScriptApprovalService, logger logger.Logger) *ScriptApprovalHandler {
+	return &ScriptApprovalHandler{
+		approvalService: approvalService,
+		logger:          logger,
+	}
+}
+
+// ApproveScript godoc
+// @Summary Aprobar script
+// @Description Aprueba un script para los agentes sandbox por su contenido o su hash SHA-256. La aprobación queda a nombre del usuario autenticado; cualquier cambio en el script necesita una aprobación nueva.
+// @Tags mcp
+// @Accept json
+// @Produce json
+// @Param request body services.ScriptApprovalRequest true "Script o hash a aprobar"
+// @Success 201 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /mcp/scripts/approvals [post]
+func (h *ScriptApprovalHandler) ApproveScript(c *gin.Context) {
+	var request services.ScriptApprovalRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid script approval: " + err.Error(),
+		})
+		return
+	}
+
+	approval, err := h.approvalService.Approve(c.Request.Context(), request, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to approve script")
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Script approved successfully",
+		Data:    approval,
+	})
+}
+
+// ListScriptApprovals godoc
+// @Summary Listar scripts aprobados
+// @Tags mcp
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /mcp/scripts/approvals [get]
+func (h *ScriptApprovalHandler) ListScriptApprovals(c *gin.Context) {
+	approvals, err := h.approvalService.List(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to list script approvals")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Script approvals retrieved successfully",
+		Data: gin.H{
+			"approvals": approvals,
+			"count":     len(approvals),
+		},
+	})
+}
+
+// RevokeScriptApproval godoc
+// @Summary Revocar aprobación de script
+// @Description Las tareas sandbox con este script fallan a partir de ahora
+// @Tags mcp
+// @Produce json
+// @Param hash path string true "SHA-256 del script"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /mcp/scripts/approvals/{hash} [delete]
+func (h *ScriptApprovalHandler) RevokeScriptApproval(c *gin.Context) {
+	if err := h.approvalService.Revoke(c.Request.Context(), c.Param("hash")); err != nil {
+		h.respondError(c, err, "Failed to revoke script approval")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Script approval revoked successfully",
+	})
+}
+
+func (h *ScriptApprovalHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrScriptApprovalNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeScriptApprovalNotFound,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidScriptApproval):
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(message, "script_sha256", c.Param("hash"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: message,
+		})
+	}
+}
+
+// SetupScriptApprovalRoutes registra las rutas de scripts aprobados
+func SetupScriptApprovalRoutes(router *gin.RouterGroup, handler *ScriptApprovalHandler) {
+	router.POST("/mcp/scripts/approvals", handler.ApproveScript)
+	router.GET("/mcp/scripts/approvals", handler.ListScriptApprovals)
+	router.DELETE("/mcp/scripts/approvals/:hash", handler.RevokeScriptApproval)
+}
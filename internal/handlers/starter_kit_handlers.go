@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/i18n"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// StarterKitHandler expone el generador de bots iniciales bilingües
+type StarterKitHandler struct {
+	starterKitService services.StarterKitService
+	logger            logger.Logger
+}
+
+// NewStarterKitHandler crea un nuevo handler del starter kit
+func NewStarterKitHandler(starterKitService services.StarterKitService, logger logger.Logger) *StarterKitHandler {
+	return &StarterKitHandler{
+		starterKitService: starterKitService,
+		logger:            logger,
+	}
+}
+
+// GenerateStarterKit godoc
+// @Summary Generar bot inicial
+// @Description Crea un bot en español e inglés para el negocio y sector dados: intents de saludo y despedida, un flujo del sector, fallback bilingüe y pruebas de conversación de ejemplo. Sin primary_language se usa el idioma de Accept-Language.
+// @Tags bots
+// @Accept json
+// @Produce json
+// @Param request body services.StarterKitRequest true "Negocio y sector"
+// @Param owner_id query string false "Propietario del bot generado"
+// @Success 201 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /bots/starter-kit [post]
+func (h *StarterKitHandler) GenerateStarterKit(c *gin.Context) {
+	var request services.StarterKitRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid starter kit request: " + err.Error(),
+		})
+		return
+	}
+	if request.PrimaryLanguage == "" {
+		request.PrimaryLanguage = i18n.Negotiate(c.GetHeader("Accept-Language"))
+	}
+
+	ownerID := c.Query("owner_id")
+	if ownerID == "" {
+		ownerID = c.GetString("user_id")
+	}
+
+	result, err := h.starterKitService.Generate(c.Request.Context(), request, ownerID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidStarterKit) {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to generate starter kit", "vertical", request.Vertical, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to generate starter kit",
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Starter bot generated successfully",
+		Data:    result,
+	})
+}
+
+// ListStarterKitVerticals godoc
+// @Summary Listar sectores del starter kit
+// @Description Sectores para los que hay flujo propio en el bot inicial
+// @Tags bots
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /bots/starter-kit/verticals [get]
+func (h *StarterKitHandler) ListStarterKitVerticals(c *gin.Context) {
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Starter kit verticals retrieved successfully",
+		Data:    h.starterKitService.Verticals(),
+	})
+}
+
+// SetupStarterKitRoutes registra las rutas del starter kit
+func SetupStarterKitRoutes(router *gin.RouterGroup, handler *StarterKitHandler) {
+	router.POST("/bots/starter-kit", handler.GenerateStarterKit)
+	router.GET("/bots/starter-kit/verticals", handler.ListStarterKitVerticals)
+}
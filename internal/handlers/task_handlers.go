@@ -1,8 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/services"
@@ -10,6 +13,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// taskEventsKeepAlive es el intervalo de los comentarios de keep-alive del
+// stream de estados
+const taskEventsKeepAlive = 15 * time.Second
+
 // TaskHandler maneja las operaciones relacionadas con tareas asíncronas
 type TaskHandler struct {
 	taskManager services.TaskManager
@@ -26,7 +33,7 @@ func NewTaskHandler(taskManager services.TaskManager, logger logger.Logger) *Tas
 
 // SubmitTask godoc
 // @Summary Enviar tarea asíncrona
-// @Description Envía una tarea para ejecución asíncrona
+// @Description Envía una tarea para ejecución asíncrona. Con callback_url se notifica el resultado con un POST firmado (HMAC-SHA256 de "<timestamp>.<body>" en X-Webhook-Signature); sin callback_secret se genera uno, que sólo se devuelve en esta respuesta.
 // @Tags tasks
 // @Accept json
 // @Produce json
@@ -36,8 +43,8 @@ func NewTaskHandler(taskManager services.TaskManager, logger logger.Logger) *Tas
 func (h *TaskHandler) SubmitTask(c *gin.Context) {
 	var task domain.AsyncTask
 	if err := c.ShouldBindJSON(&task); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Invalid task data: " + err.Error(),
 		})
 		return
@@ -52,21 +59,33 @@ func (h *TaskHandler) SubmitTask(c *gin.Context) {
 	}
 
 	if err := h.taskManager.SubmitTask(c.Request.Context(), &task); err != nil {
-		h.logger.Error("Failed to submit task", "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		if errors.Is(err, services.ErrInvalidTaskCallback) {
+			respond(c, http.StatusBadRequest, domain.APIResponse{
+				Code:    domain.CodeInvalidRequest,
+				Message: err.Error(),
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to submit task", "error", err)
+		status, code := queueErrorStatus(err)
+		respond(c, status, domain.APIResponse{
+			Code:    code,
 			Message: "Failed to submit task: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusAccepted, domain.APIResponse{
-		Code:    "SUCCESS",
+	data := map[string]interface{}{
+		"task_id": task.ID,
+		"status":  task.Status,
+	}
+	if task.CallbackURL != "" {
+		data["callback_secret"] = task.CallbackSecret
+	}
+	respond(c, http.StatusAccepted, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Task submitted successfully",
-		Data: map[string]interface{}{
-			"task_id": task.ID,
-			"status":  task.Status,
-		},
+		Data:    data,
 	})
 }
 
@@ -84,17 +103,67 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 
 	task, err := h.taskManager.GetTask(c.Request.Context(), taskID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTaskNotFound,
 			Message: "Task not found",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Task retrieved successfully",
-		Data:    task,
+		Data:    withoutCallbackSecret(task),
+	})
+}
+
+// StreamTaskEvents godoc
+// @Summary Seguir el estado de una tarea
+// @Description Envía por Server-Sent Events el estado actual de la tarea y cada cambio posterior (evento status); el último, con el resultado, es el evento done
+// @Tags tasks
+// @Produce text/event-stream
+// @Param id path string true "Task ID"
+// @Success 200 {object} domain.AsyncTask
+// @Failure 404 {object} domain.APIResponse
+// @Router /tasks/{id}/events [get]
+func (h *TaskHandler) StreamTaskEvents(c *gin.Context) {
+	ctx := c.Request.Context()
+	updates, err := h.taskManager.WatchTask(ctx, c.Param("id"))
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTaskNotFound,
+			Message: "Task not found",
+		})
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	// Comentarios periódicos para que los proxies no cierren streams inactivos
+	keepAlive := time.NewTicker(taskEventsKeepAlive)
+	defer keepAlive.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case task, ok := <-updates:
+			if !ok {
+				return false
+			}
+			if task.Status == domain.TaskStatusCompleted || task.Status == domain.TaskStatusFailed || task.Status == domain.TaskStatusCancelled {
+				c.SSEvent("done", withoutCallbackSecret(task))
+				return false
+			}
+			c.SSEvent("status", withoutCallbackSecret(task))
+			return true
+		case <-keepAlive.C:
+			_, err := io.WriteString(w, ": keep-alive\n\n")
+			return err == nil
+		case <-ctx.Done():
+			return false
+		}
 	})
 }
 
@@ -108,6 +177,8 @@ func (h *TaskHandler) GetTask(c *gin.Context) {
 // @Param type query string false "Filter by type"
 // @Param user_id query string false "Filter by user ID"
 // @Param bot_id query string false "Filter by bot ID"
+// @Param from query string false "Created after (RFC3339)"
+// @Param to query string false "Created before (RFC3339)"
 // @Param limit query int false "Limit results"
 // @Param offset query int false "Offset results"
 // @Success 200 {object} domain.APIResponse
@@ -133,6 +204,20 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 		filters.BotID = &botID
 	}
 
+	if from, err := time.Parse(time.RFC3339, c.Query("from")); err == nil {
+		if filters.CreatedAt == nil {
+			filters.CreatedAt = &services.TimeRange{}
+		}
+		filters.CreatedAt.From = &from
+	}
+
+	if to, err := time.Parse(time.RFC3339, c.Query("to")); err == nil {
+		if filters.CreatedAt == nil {
+			filters.CreatedAt = &services.TimeRange{}
+		}
+		filters.CreatedAt.To = &to
+	}
+
 	if limitStr := c.Query("limit"); limitStr != "" {
 		if limit, err := strconv.Atoi(limitStr); err == nil {
 			filters.Limit = limit
@@ -146,17 +231,20 @@ func (h *TaskHandler) ListTasks(c *gin.Context) {
 	}
 
 	tasks, err := h.taskManager.ListTasks(c.Request.Context(), filters)
+	for i, task := range tasks {
+		tasks[i] = withoutCallbackSecret(task)
+	}
 	if err != nil {
-		h.logger.Error("Failed to list tasks", "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to list tasks", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to list tasks",
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Tasks retrieved successfully",
 		Data: map[string]interface{}{
 			"tasks": tasks,
@@ -178,16 +266,16 @@ func (h *TaskHandler) CancelTask(c *gin.Context) {
 	taskID := c.Param("id")
 
 	if err := h.taskManager.CancelTask(c.Request.Context(), taskID); err != nil {
-		h.logger.Error("Failed to cancel task", "task_id", taskID, "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Failed to cancel task", "task_id", taskID, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Failed to cancel task: " + err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Task cancelled successfully",
 	})
 }
@@ -203,21 +291,207 @@ func (h *TaskHandler) CancelTask(c *gin.Context) {
 func (h *TaskHandler) GetTaskStats(c *gin.Context) {
 	stats := h.taskManager.GetStats()
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Task statistics retrieved successfully",
 		Data:    stats,
 	})
 }
 
+// ListDeadLetters godoc
+// @Summary Listar tareas en dead-letter
+// @Description Lista las tareas que fallaron definitivamente tras agotar sus reintentos
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param limit query int false "Limit results"
+// @Param offset query int false "Offset results"
+// @Success 200 {object} domain.APIResponse
+// @Router /tasks/dead-letters [get]
+func (h *TaskHandler) ListDeadLetters(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	tasks, err := h.taskManager.ListDeadLetters(c.Request.Context(), limit, offset)
+	for i, entry := range tasks {
+		tasks[i] = deadLetterWithoutSecret(entry)
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to list dead-letter tasks", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to list dead-letter tasks",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Dead-letter tasks retrieved successfully",
+		Data: map[string]interface{}{
+			"tasks": tasks,
+			"count": len(tasks),
+		},
+	})
+}
+
+// GetDeadLetter godoc
+// @Summary Obtener tarea en dead-letter
+// @Description Obtiene una tarea en dead-letter con su input original y el historial de errores
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /tasks/dead-letters/{id} [get]
+func (h *TaskHandler) GetDeadLetter(c *gin.Context) {
+	id := c.Param("id")
+
+	task, err := h.taskManager.GetDeadLetter(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeDeadLetterNotFound,
+			Message: "Dead-letter task not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Dead-letter task retrieved successfully",
+		Data:    deadLetterWithoutSecret(task),
+	})
+}
+
+// RedriveDeadLetter godoc
+// @Summary Re-ejecutar tarea en dead-letter
+// @Description Vuelve a encolar una tarea en dead-letter con sus intentos reiniciados
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 202 {object} domain.APIResponse
+// @Router /tasks/dead-letters/{id}/redrive [post]
+func (h *TaskHandler) RedriveDeadLetter(c *gin.Context) {
+	id := c.Param("id")
+
+	task, err := h.taskManager.RedriveDeadLetter(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to redrive dead-letter task", "task_id", id, "error", err)
+		status, code := queueErrorStatus(err)
+		respond(c, status, domain.APIResponse{
+			Code:    code,
+			Message: "Failed to redrive task: " + err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusAccepted, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Task redriven successfully",
+		Data: map[string]interface{}{
+			"task_id": task.ID,
+			"status":  task.Status,
+		},
+	})
+}
+
+// DeleteDeadLetter godoc
+// @Summary Descartar tarea en dead-letter
+// @Description Elimina una tarea de la cola de dead-letter
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Param id path string true "Task ID"
+// @Success 200 {object} domain.APIResponse
+// @Router /tasks/dead-letters/{id} [delete]
+func (h *TaskHandler) DeleteDeadLetter(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.taskManager.DeleteDeadLetter(c.Request.Context(), id); err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeDeadLetterNotFound,
+			Message: "Dead-letter task not found",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Dead-letter task deleted successfully",
+	})
+}
+
+// PurgeDeadLetters godoc
+// @Summary Vaciar dead-letter
+// @Description Elimina todas las tareas de la cola de dead-letter
+// @Tags tasks
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /tasks/dead-letters [delete]
+func (h *TaskHandler) PurgeDeadLetters(c *gin.Context) {
+	count, err := h.taskManager.PurgeDeadLetters(c.Request.Context())
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to purge dead-letter tasks", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to purge dead-letter tasks",
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Dead-letter queue purged successfully",
+		Data: map[string]interface{}{
+			"purged": count,
+		},
+	})
+}
+
+// withoutCallbackSecret devuelve una copia de la tarea sin el secreto de firma
+// de su callback_url
+func withoutCallbackSecret(task *domain.AsyncTask) *domain.AsyncTask {
+	if task == nil || task.CallbackSecret == "" {
+		return task
+	}
+	taskCopy := *task
+	taskCopy.CallbackSecret = ""
+	return &taskCopy
+}
+
+func deadLetterWithoutSecret(entry *domain.DeadLetterTask) *domain.DeadLetterTask {
+	entryCopy := *entry
+	entryCopy.Task = withoutCallbackSecret(entry.Task)
+	return &entryCopy
+}
+
+// queueErrorStatus distingue la cola llena, que el cliente puede reintentar,
+// de los errores internos
+func queueErrorStatus(err error) (int, string) {
+	if errors.Is(err, services.ErrTaskQueueFull) {
+		return http.StatusTooManyRequests, domain.CodeQuotaExceeded
+	}
+	return http.StatusInternalServerError, domain.CodeInternalError
+}
+
 // SetupTaskRoutes configura las rutas relacionadas con tareas asíncronas
 func SetupTaskRoutes(router *gin.RouterGroup, handler *TaskHandler) {
 	// Task Management
 	router.POST("/tasks", handler.SubmitTask)
 	router.GET("/tasks", handler.ListTasks)
 	router.GET("/tasks/:id", handler.GetTask)
+	router.GET("/tasks/:id/events", handler.StreamTaskEvents)
 	router.POST("/tasks/:id/cancel", handler.CancelTask)
 	
 	// Statistics
 	router.GET("/tasks/stats", handler.GetTaskStats)
+	
+	// Dead-letter
+	router.GET("/tasks/dead-letters", handler.ListDeadLetters)
+	router.DELETE("/tasks/dead-letters", handler.PurgeDeadLetters)
+	router.GET("/tasks/dead-letters/:id", handler.GetDeadLetter)
+	router.DELETE("/tasks/dead-letters/:id", handler.DeleteDeadLetter)
+	router.POST("/tasks/dead-letters/:id/redrive", handler.RedriveDeadLetter)
 }
\ No newline at end of file
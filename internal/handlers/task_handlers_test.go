@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeadLetterEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	log := logger.NewLogger("error")
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	tasks := repositories.NewMockTaskRepository()
+	deadLetters := repositories.NewMockDeadLetterRepository()
+	for i, id := range []string{"first", "second"} {
+		task := &domain.AsyncTask{ID: id, Type: "notify", Status: domain.TaskStatusFailed, CallbackSecret: "s3cret"}
+		require.NoError(t, tasks.Create(ctx, task))
+		require.NoError(t, deadLetters.Create(ctx, &domain.DeadLetterTask{ID: id, Task: task, Reason: "unavailable",
+			DeadLetteredAt: clk.Now().Add(time.Duration(i) * time.Minute)}))
+	}
+
+	manager := services.NewTaskManager(tasks, deadLetters, nil, nil, log, 1, 10, 0, clk)
+	require.NoError(t, manager.RegisterHandler("notify", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		return nil, nil
+	}))
+	require.NoError(t, manager.Start(ctx))
+	router := gin.New()
+	SetupTaskRoutes(router.Group("/api/v1"), NewTaskHandler(manager, log))
+
+	call := func(method, path string) (int, map[string]interface{}) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		var body struct {
+			Data map[string]interface{} `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &body)
+		return w.Code, body.Data
+	}
+	deadLettered := func() float64 {
+		code, stats := call(http.MethodGet, "/api/v1/tasks/stats")
+		require.Equal(t, http.StatusOK, code)
+		return stats["dead_lettered_tasks"].(float64)
+	}
+
+	// El listado pagina, las más recientes primero, y no expone el secreto de la callback
+	code, data := call(http.MethodGet, "/api/v1/tasks/dead-letters?limit=1")
+	require.Equal(t, http.StatusOK, code)
+	require.EqualValues(t, 1, data["count"])
+	entry := data["tasks"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "second", entry["id"])
+	assert.NotContains(t, entry["task"], "callback_secret")
+	assert.Equal(t, 2.0, deadLettered())
+
+	code, _ = call(http.MethodDelete, "/api/v1/tasks/dead-letters/missing")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = call(http.MethodDelete, "/api/v1/tasks/dead-letters/first")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, 1.0, deadLettered())
+
+	code, data = call(http.MethodPost, "/api/v1/tasks/dead-letters/second/redrive")
+	assert.Equal(t, http.StatusAccepted, code)
+	assert.Equal(t, "second", data["task_id"])
+	assert.Equal(t, string(domain.TaskStatusPending), data["status"])
+	assert.Equal(t, 0.0, deadLettered())
+
+	code, _ = call(http.MethodGet, "/api/v1/tasks/dead-letters/second")
+	assert.Equal(t, http.StatusNotFound, code)
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/services"
@@ -44,9 +46,11 @@ func (h *TestHandlers) RegisterRoutes(router *gin.RouterGroup) {
 	router.DELETE("/conditionals/:id", h.DeleteConditional)
 	router.GET("/conditionals/bot/:botId", h.GetConditionalsByBot)
 	router.POST("/conditionals/:id/evaluate", h.EvaluateConditional)
+	router.POST("/conditionals/evaluate-batch", h.EvaluateConditionalsBatch)
 
 	// Triggers
 	router.POST("/triggers", h.CreateTrigger)
+	router.GET("/triggers/actions", h.ListTriggerActions)
 	router.GET("/triggers/:id", h.GetTrigger)
 	router.PUT("/triggers/:id", h.UpdateTrigger)
 	router.DELETE("/triggers/:id", h.DeleteTrigger)
@@ -59,9 +63,18 @@ func (h *TestHandlers) RegisterRoutes(router *gin.RouterGroup) {
 	router.PUT("/test-cases/:id", h.UpdateTestCase)
 	router.DELETE("/test-cases/:id", h.DeleteTestCase)
 	router.GET("/test-cases/bot/:botId", h.GetTestCasesByBot)
+	router.GET("/test-cases/:id/runs", h.GetTestCaseRuns)
 	router.POST("/test-cases/:id/execute", h.ExecuteTestCase)
 	router.POST("/test-cases/bulk-execute", h.BulkExecuteTestCases)
 
+	// Pruebas de conversación (varios turnos)
+	router.POST("/conversation-tests", h.CreateMultiTurnTestCase)
+	router.GET("/conversation-tests/:id", h.GetMultiTurnTestCase)
+	router.PUT("/conversation-tests/:id", h.UpdateMultiTurnTestCase)
+	router.DELETE("/conversation-tests/:id", h.DeleteMultiTurnTestCase)
+	router.GET("/conversation-tests/bot/:botId", h.GetMultiTurnTestCasesByBot)
+	router.POST("/conversation-tests/:id/execute", h.ExecuteMultiTurnTestCase)
+
 	// Suites de prueba
 	router.POST("/test-suites", h.CreateTestSuite)
 	router.GET("/test-suites/:id", h.GetTestSuite)
@@ -71,14 +84,19 @@ func (h *TestHandlers) RegisterRoutes(router *gin.RouterGroup) {
 	router.POST("/test-suites/:id/execute", h.ExecuteTestSuite)
 	router.POST("/test-suites/:id/test-cases", h.AddTestCaseToSuite)
 	router.DELETE("/test-suites/:id/test-cases/:testCaseId", h.RemoveTestCaseFromSuite)
+	router.GET("/test-suites/:id/runs", h.GetTestSuiteRuns)
+
+	// Historial de ejecuciones
+	router.GET("/test-runs/:id", h.GetTestRun)
+	router.GET("/test-runs/:id/export", h.ExportTestRun)
 }
 
 // CreateConditional crea un nuevo condicional
 func (h *TestHandlers) CreateConditional(c *gin.Context) {
 	var conditional domain.Conditional
 	if err := c.ShouldBindJSON(&conditional); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -87,17 +105,17 @@ func (h *TestHandlers) CreateConditional(c *gin.Context) {
 
 	err := h.conditionalService.CreateConditional(c.Request.Context(), &conditional)
 	if err != nil {
-		h.logger.Error("Error creating conditional", "error", err)
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		h.logger.WithContext(c.Request.Context()).Error("Error creating conditional", "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al crear condicional",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Condicional creado exitosamente",
 		Data:    conditional,
 	})
@@ -109,16 +127,16 @@ func (h *TestHandlers) GetConditional(c *gin.Context) {
 	
 	conditional, err := h.conditionalService.GetConditional(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeConditionalNotFound,
 			Message: "Condicional no encontrado",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Condicional encontrado",
 		Data:    conditional,
 	})
@@ -129,8 +147,8 @@ func (h *TestHandlers) UpdateConditional(c *gin.Context) {
 	id := c.Param("id")
 	var conditional domain.Conditional
 	if err := c.ShouldBindJSON(&conditional); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -140,16 +158,16 @@ func (h *TestHandlers) UpdateConditional(c *gin.Context) {
 	conditional.ID = id
 	err := h.conditionalService.UpdateConditional(c.Request.Context(), &conditional)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al actualizar condicional",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Condicional actualizado exitosamente",
 		Data:    conditional,
 	})
@@ -161,16 +179,16 @@ func (h *TestHandlers) DeleteConditional(c *gin.Context) {
 	
 	err := h.conditionalService.DeleteConditional(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al eliminar condicional",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Condicional eliminado exitosamente",
 		Data:    nil,
 	})
@@ -182,16 +200,16 @@ func (h *TestHandlers) GetConditionalsByBot(c *gin.Context) {
 	
 	conditionals, err := h.conditionalService.GetConditionalsByBot(c.Request.Context(), botID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al obtener condicionales",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Condicionales obtenidos exitosamente",
 		Data:    conditionals,
 	})
@@ -205,8 +223,8 @@ func (h *TestHandlers) EvaluateConditional(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -215,27 +233,67 @@ func (h *TestHandlers) EvaluateConditional(c *gin.Context) {
 
 	result, err := h.conditionalService.EvaluateConditional(c.Request.Context(), id, request.Context)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al evaluar condicional",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Condicional evaluado exitosamente",
 		Data:    result,
 	})
 }
 
+// EvaluateConditionalsBatch evalúa una expresión o varios condicionales contra múltiples contextos
+func (h *TestHandlers) EvaluateConditionalsBatch(c *gin.Context) {
+	var request domain.BatchEvaluationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Datos inválidos",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	if request.Expression == "" && len(request.ConditionalIDs) == 0 {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Se requiere expression o conditional_ids",
+		})
+		return
+	}
+
+	results, err := h.conditionalService.EvaluateBatch(c.Request.Context(), &request)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Error al evaluar condicionales",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Condicionales evaluados exitosamente",
+		Data: map[string]interface{}{
+			"results": results,
+			"count":   len(results),
+		},
+	})
+}
+
 // CreateTrigger crea un nuevo trigger
 func (h *TestHandlers) CreateTrigger(c *gin.Context) {
 	var trigger domain.Trigger
 	if err := c.ShouldBindJSON(&trigger); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -244,16 +302,16 @@ func (h *TestHandlers) CreateTrigger(c *gin.Context) {
 
 	err := h.triggerService.CreateTrigger(c.Request.Context(), &trigger)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al crear trigger",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Trigger creado exitosamente",
 		Data:    trigger,
 	})
@@ -265,16 +323,16 @@ func (h *TestHandlers) GetTrigger(c *gin.Context) {
 	
 	trigger, err := h.triggerService.GetTrigger(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTriggerNotFound,
 			Message: "Trigger no encontrado",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Trigger encontrado",
 		Data:    trigger,
 	})
@@ -285,8 +343,8 @@ func (h *TestHandlers) UpdateTrigger(c *gin.Context) {
 	id := c.Param("id")
 	var trigger domain.Trigger
 	if err := c.ShouldBindJSON(&trigger); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -296,16 +354,16 @@ func (h *TestHandlers) UpdateTrigger(c *gin.Context) {
 	trigger.ID = id
 	err := h.triggerService.UpdateTrigger(c.Request.Context(), &trigger)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al actualizar trigger",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Trigger actualizado exitosamente",
 		Data:    trigger,
 	})
@@ -317,16 +375,16 @@ func (h *TestHandlers) DeleteTrigger(c *gin.Context) {
 	
 	err := h.triggerService.DeleteTrigger(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al eliminar trigger",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Trigger eliminado exitosamente",
 		Data:    nil,
 	})
@@ -338,21 +396,30 @@ func (h *TestHandlers) GetTriggersByBot(c *gin.Context) {
 	
 	triggers, err := h.triggerService.GetTriggersByBot(c.Request.Context(), botID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al obtener triggers",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Triggers obtenidos exitosamente",
 		Data:    triggers,
 	})
 }
 
+// ListTriggerActions lista los tipos de acción disponibles para triggers
+func (h *TestHandlers) ListTriggerActions(c *gin.Context) {
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Tipos de acción obtenidos exitosamente",
+		Data:    h.triggerService.ListActionTypes(),
+	})
+}
+
 // ExecuteTrigger ejecuta un trigger
 func (h *TestHandlers) ExecuteTrigger(c *gin.Context) {
 	id := c.Param("id")
@@ -361,8 +428,8 @@ func (h *TestHandlers) ExecuteTrigger(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -371,16 +438,16 @@ func (h *TestHandlers) ExecuteTrigger(c *gin.Context) {
 
 	err := h.triggerService.ExecuteTrigger(c.Request.Context(), id, request.Context)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al ejecutar trigger",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Trigger ejecutado exitosamente",
 		Data:    map[string]interface{}{"trigger_id": id, "status": "executed"},
 	})
@@ -390,8 +457,8 @@ func (h *TestHandlers) ExecuteTrigger(c *gin.Context) {
 func (h *TestHandlers) CreateTestCase(c *gin.Context) {
 	var testCase domain.TestCase
 	if err := c.ShouldBindJSON(&testCase); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -400,16 +467,16 @@ func (h *TestHandlers) CreateTestCase(c *gin.Context) {
 
 	err := h.testService.CreateTestCase(c.Request.Context(), &testCase)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al crear caso de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Caso de prueba creado exitosamente",
 		Data:    testCase,
 	})
@@ -421,16 +488,16 @@ func (h *TestHandlers) GetTestCase(c *gin.Context) {
 	
 	testCase, err := h.testService.GetTestCase(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTestCaseNotFound,
 			Message: "Caso de prueba no encontrado",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Caso de prueba encontrado",
 		Data:    testCase,
 	})
@@ -441,8 +508,8 @@ func (h *TestHandlers) UpdateTestCase(c *gin.Context) {
 	id := c.Param("id")
 	var testCase domain.TestCase
 	if err := c.ShouldBindJSON(&testCase); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -452,16 +519,16 @@ func (h *TestHandlers) UpdateTestCase(c *gin.Context) {
 	testCase.ID = id
 	err := h.testService.UpdateTestCase(c.Request.Context(), &testCase)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al actualizar caso de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Caso de prueba actualizado exitosamente",
 		Data:    testCase,
 	})
@@ -473,16 +540,16 @@ func (h *TestHandlers) DeleteTestCase(c *gin.Context) {
 	
 	err := h.testService.DeleteTestCase(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al eliminar caso de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Caso de prueba eliminado exitosamente",
 		Data:    nil,
 	})
@@ -494,16 +561,16 @@ func (h *TestHandlers) GetTestCasesByBot(c *gin.Context) {
 	
 	testCases, err := h.testService.GetTestCasesByBot(c.Request.Context(), botID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al obtener casos de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Casos de prueba obtenidos exitosamente",
 		Data:    testCases,
 	})
@@ -515,16 +582,16 @@ func (h *TestHandlers) ExecuteTestCase(c *gin.Context) {
 	
 	result, err := h.testService.ExecuteTestCase(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al ejecutar caso de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Caso de prueba ejecutado exitosamente",
 		Data:    result,
 	})
@@ -537,8 +604,8 @@ func (h *TestHandlers) BulkExecuteTestCases(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -547,27 +614,180 @@ func (h *TestHandlers) BulkExecuteTestCases(c *gin.Context) {
 
 	results, err := h.testService.BulkExecuteTestCases(c.Request.Context(), request.TestCaseIDs)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al ejecutar casos de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Casos de prueba ejecutados exitosamente",
 		Data:    results,
 	})
 }
 
+// CreateMultiTurnTestCase crea una nueva prueba de conversación
+func (h *TestHandlers) CreateMultiTurnTestCase(c *gin.Context) {
+	var testCase domain.MultiTurnTestCase
+	if err := c.ShouldBindJSON(&testCase); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Datos inválidos",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	if err := h.testService.CreateMultiTurnTestCase(c.Request.Context(), &testCase); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Error al crear prueba de conversación",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Prueba de conversación creada exitosamente",
+		Data:    testCase,
+	})
+}
+
+// GetMultiTurnTestCase obtiene una prueba de conversación por ID
+func (h *TestHandlers) GetMultiTurnTestCase(c *gin.Context) {
+	id := c.Param("id")
+
+	testCase, err := h.testService.GetMultiTurnTestCase(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTestCaseNotFound,
+			Message: "Prueba de conversación no encontrada",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Prueba de conversación encontrada",
+		Data:    testCase,
+	})
+}
+
+// UpdateMultiTurnTestCase actualiza una prueba de conversación
+func (h *TestHandlers) UpdateMultiTurnTestCase(c *gin.Context) {
+	id := c.Param("id")
+	var testCase domain.MultiTurnTestCase
+	if err := c.ShouldBindJSON(&testCase); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Datos inválidos",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	existing, err := h.testService.GetMultiTurnTestCase(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTestCaseNotFound,
+			Message: "Prueba de conversación no encontrada",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	testCase.ID = id
+	testCase.Status = existing.Status
+	testCase.Result = existing.Result
+	testCase.CreatedAt = existing.CreatedAt
+	if err := h.testService.UpdateMultiTurnTestCase(c.Request.Context(), &testCase); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Error al actualizar prueba de conversación",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Prueba de conversación actualizada exitosamente",
+		Data:    testCase,
+	})
+}
+
+// DeleteMultiTurnTestCase elimina una prueba de conversación
+func (h *TestHandlers) DeleteMultiTurnTestCase(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := h.testService.DeleteMultiTurnTestCase(c.Request.Context(), id); err != nil {
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Error al eliminar prueba de conversación",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Prueba de conversación eliminada exitosamente",
+	})
+}
+
+// GetMultiTurnTestCasesByBot obtiene las pruebas de conversación de un bot
+func (h *TestHandlers) GetMultiTurnTestCasesByBot(c *gin.Context) {
+	botID := c.Param("botId")
+
+	testCases, err := h.testService.GetMultiTurnTestCasesByBot(c.Request.Context(), botID)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Error al obtener pruebas de conversación",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Pruebas de conversación obtenidas exitosamente",
+		Data:    testCases,
+	})
+}
+
+// ExecuteMultiTurnTestCase ejecuta una prueba de conversación sobre una sesión nueva
+func (h *TestHandlers) ExecuteMultiTurnTestCase(c *gin.Context) {
+	id := c.Param("id")
+
+	result, err := h.testService.ExecuteMultiTurnTestCase(c.Request.Context(), id)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Error al ejecutar prueba de conversación",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Prueba de conversación ejecutada exitosamente",
+		Data:    result,
+	})
+}
+
 // CreateTestSuite crea un nuevo suite de prueba
 func (h *TestHandlers) CreateTestSuite(c *gin.Context) {
 	var testSuite domain.TestSuite
 	if err := c.ShouldBindJSON(&testSuite); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -576,16 +796,16 @@ func (h *TestHandlers) CreateTestSuite(c *gin.Context) {
 
 	err := h.testSuiteService.CreateTestSuite(c.Request.Context(), &testSuite)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al crear suite de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusCreated, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Suite de prueba creado exitosamente",
 		Data:    testSuite,
 	})
@@ -597,16 +817,16 @@ func (h *TestHandlers) GetTestSuite(c *gin.Context) {
 	
 	testSuite, err := h.testSuiteService.GetTestSuite(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusNotFound, domain.APIResponse{
-			Code:    "NOT_FOUND",
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTestSuiteNotFound,
 			Message: "Suite de prueba no encontrado",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Suite de prueba encontrado",
 		Data:    testSuite,
 	})
@@ -617,8 +837,8 @@ func (h *TestHandlers) UpdateTestSuite(c *gin.Context) {
 	id := c.Param("id")
 	var testSuite domain.TestSuite
 	if err := c.ShouldBindJSON(&testSuite); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -628,16 +848,16 @@ func (h *TestHandlers) UpdateTestSuite(c *gin.Context) {
 	testSuite.ID = id
 	err := h.testSuiteService.UpdateTestSuite(c.Request.Context(), &testSuite)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al actualizar suite de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Suite de prueba actualizado exitosamente",
 		Data:    testSuite,
 	})
@@ -649,16 +869,16 @@ func (h *TestHandlers) DeleteTestSuite(c *gin.Context) {
 	
 	err := h.testSuiteService.DeleteTestSuite(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al eliminar suite de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Suite de prueba eliminado exitosamente",
 		Data:    nil,
 	})
@@ -670,16 +890,16 @@ func (h *TestHandlers) GetTestSuitesByBot(c *gin.Context) {
 	
 	testSuites, err := h.testSuiteService.GetTestSuitesByBot(c.Request.Context(), botID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al obtener suites de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Suites de prueba obtenidos exitosamente",
 		Data:    testSuites,
 	})
@@ -691,16 +911,16 @@ func (h *TestHandlers) ExecuteTestSuite(c *gin.Context) {
 	
 	result, err := h.testSuiteService.ExecuteTestSuite(c.Request.Context(), id)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al ejecutar suite de prueba",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Suite de prueba ejecutado exitosamente",
 		Data:    result,
 	})
@@ -714,8 +934,8 @@ func (h *TestHandlers) AddTestCaseToSuite(c *gin.Context) {
 	}
 	
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, domain.APIResponse{
-			Code:    "INVALID_REQUEST",
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
 			Message: "Datos inválidos",
 			Data:    err.Error(),
 		})
@@ -724,16 +944,16 @@ func (h *TestHandlers) AddTestCaseToSuite(c *gin.Context) {
 
 	err := h.testSuiteService.AddTestCaseToSuite(c.Request.Context(), suiteID, request.TestCaseID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al agregar caso de prueba al suite",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Caso de prueba agregado al suite exitosamente",
 		Data:    nil,
 	})
@@ -746,17 +966,128 @@ func (h *TestHandlers) RemoveTestCaseFromSuite(c *gin.Context) {
 	
 	err := h.testSuiteService.RemoveTestCaseFromSuite(c.Request.Context(), suiteID, testCaseID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, domain.APIResponse{
-			Code:    "INTERNAL_ERROR",
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
 			Message: "Error al remover caso de prueba del suite",
 			Data:    err.Error(),
 		})
 		return
 	}
 
-	c.JSON(http.StatusOK, domain.APIResponse{
-		Code:    "SUCCESS",
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
 		Message: "Caso de prueba removido del suite exitosamente",
 		Data:    nil,
 	})
-} 
\ No newline at end of file
+}
+
+// GetTestSuiteRuns lista el historial de ejecuciones de un suite
+func (h *TestHandlers) GetTestSuiteRuns(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	runs, total, err := h.testSuiteService.GetTestSuiteRuns(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Error al obtener ejecuciones del suite",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Ejecuciones obtenidas exitosamente",
+		Data: gin.H{
+			"runs":  runs,
+			"total": total,
+		},
+	})
+}
+
+// GetTestCaseRuns lista el historial de ejecuciones de un caso de prueba
+func (h *TestHandlers) GetTestCaseRuns(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+
+	runs, total, err := h.testService.GetTestCaseRuns(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Error al obtener ejecuciones del caso de prueba",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Ejecuciones obtenidas exitosamente",
+		Data: gin.H{
+			"runs":  runs,
+			"total": total,
+		},
+	})
+}
+
+// GetTestRun obtiene una ejecución con el detalle de cada caso
+func (h *TestHandlers) GetTestRun(c *gin.Context) {
+	run, err := h.testSuiteService.GetTestRun(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTestRunNotFound,
+			Message: "Ejecución no encontrada",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Ejecución obtenida exitosamente",
+		Data:    run,
+	})
+}
+
+// ExportTestRun descarga una ejecución como JUnit XML o reporte HTML
+func (h *TestHandlers) ExportTestRun(c *gin.Context) {
+	id := c.Param("id")
+	format := services.ReportFormat(c.DefaultQuery("format", string(services.ReportFormatJUnit)))
+
+	extension := "xml"
+	switch format {
+	case services.ReportFormatJUnit:
+	case services.ReportFormatHTML:
+		extension = "html"
+	default:
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Formato de reporte no soportado",
+			Data:    "format must be junit or html",
+		})
+		return
+	}
+
+	if _, err := h.testSuiteService.GetTestRun(c.Request.Context(), id); err != nil {
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeTestRunNotFound,
+			Message: "Ejecución no encontrada",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	data, contentType, err := h.testSuiteService.ExportTestRun(c.Request.Context(), id, format)
+	if err != nil {
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Error al exportar la ejecución",
+			Data:    err.Error(),
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=\"test-run-%s.%s\"", id, extension))
+	c.Data(http.StatusOK, contentType, data)
+}
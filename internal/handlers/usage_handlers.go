@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// UsageHandler expone el consumo de IA de los bots
+type UsageHandler struct {
+	usageService services.UsageService
+	logger       logger.Logger
+}
+
+// NewUsageHandler crea un nuevo handler de consumo de IA
+func NewUsageHandler(usageService services.UsageService, logger logger.Logger) *UsageHandler {
+	return &UsageHandler{
+		usageService: usageService,
+		logger:       logger,
+	}
+}
+
+// GetBotUsage godoc
+// @Summary Obtener el consumo de IA de un bot
+// @Description Peticiones, tokens de prompt y de respuesta y coste estimado en USD por día (UTC) y por modelo, con el estado del presupuesto mensual si el bot tiene ai_budget
+// @Tags bots
+// @Produce json
+// @Param id path string true "Bot ID"
+// @Param from query string false "Primer día (YYYY-MM-DD o RFC3339); por defecto, el primer día del mes de to"
+// @Param to query string false "Último día (YYYY-MM-DD o RFC3339); por defecto, hoy"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /bots/{id}/usage [get]
+func (h *UsageHandler) GetBotUsage(c *gin.Context) {
+	to := time.Now().UTC()
+	if value := c.Query("to"); value != "" {
+		parsed, ok := parseAnalyticsDate(c, "to", value)
+		if !ok {
+			return
+		}
+		to = parsed
+	}
+	// Por defecto el mes del presupuesto
+	from := to.AddDate(0, 0, 1-to.Day())
+	if value := c.Query("from"); value != "" {
+		parsed, ok := parseAnalyticsDate(c, "from", value)
+		if !ok {
+			return
+		}
+		from = parsed
+	}
+
+	usage, err := h.usageService.GetBotUsage(c.Request.Context(), c.Param("id"), from, to)
+	switch {
+	case err == nil:
+		respond(c, http.StatusOK, domain.APIResponse{
+			Code:    domain.CodeSuccess,
+			Message: "Bot usage retrieved successfully",
+			Data:    usage,
+		})
+	case errors.Is(err, services.ErrBotNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeBotNotFound,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidUsageRange):
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get bot usage", "bot_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to get bot usage",
+		})
+	}
+}
+
+// SetupUsageRoutes registra las rutas de consumo de IA de bots
+func SetupUsageRoutes(router *gin.RouterGroup, handler *UsageHandler) {
+	router.GET("/bots/:id/usage", handler.GetBotUsage)
+}
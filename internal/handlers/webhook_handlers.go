@@ -0,0 +1,292 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler gestiona las suscripciones de webhooks y su registro de entregas
+type WebhookHandler struct {
+	webhookService services.WebhookService
+	logger         logger.Logger
+}
+
+// NewWebhookHandler crea un nuevo handler de webhooks
+func NewWebhookHandler(webhookService services.WebhookService, logger logger.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		webhookService: webhookService,
+		logger:         logger,
+	}
+}
+
+// ListWebhookEvents godoc
+// @Summary Listar eventos de webhooks
+// @Description Devuelve los eventos a los que se puede suscribir un webhook
+// @Tags webhooks
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /webhooks/events [get]
+func (h *WebhookHandler) ListWebhookEvents(c *gin.Context) {
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook events retrieved successfully",
+		Data:    services.WebhookEventTypes(),
+	})
+}
+
+// CreateWebhook godoc
+// @Summary Crear suscripción de webhook
+// @Description Suscribe una URL a eventos del servicio. Las entregas se firman con HMAC-SHA256 (X-Webhook-Signature); el secreto sólo se devuelve en esta respuesta.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param request body services.WebhookSubscriptionRequest true "Datos de la suscripción"
+// @Success 201 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /webhooks [post]
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	var request services.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid webhook data: " + err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.webhookService.Create(c.Request.Context(), request, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to create webhook")
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook created successfully; store the secret now, it will not be shown again",
+		Data:    subscription,
+	})
+}
+
+// ListWebhooks godoc
+// @Summary Listar suscripciones de webhooks
+// @Description Lista las suscripciones sin su secreto de firma
+// @Tags webhooks
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /webhooks [get]
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	subscriptions, err := h.webhookService.List(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to list webhooks")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhooks retrieved successfully",
+		Data: gin.H{
+			"webhooks": subscriptions,
+			"count":    len(subscriptions),
+		},
+	})
+}
+
+// GetWebhook godoc
+// @Summary Obtener suscripción de webhook
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /webhooks/{id} [get]
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	subscription, err := h.webhookService.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get webhook")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook retrieved successfully",
+		Data:    subscription,
+	})
+}
+
+// UpdateWebhook godoc
+// @Summary Actualizar suscripción de webhook
+// @Description Reemplaza URL, eventos y filtro de bot. Sin secret se conserva el actual.
+// @Tags webhooks
+// @Accept json
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param request body services.WebhookSubscriptionRequest true "Datos de la suscripción"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /webhooks/{id} [put]
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	var request services.WebhookSubscriptionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid webhook data: " + err.Error(),
+		})
+		return
+	}
+
+	subscription, err := h.webhookService.Update(c.Request.Context(), c.Param("id"), request)
+	if err != nil {
+		h.respondError(c, err, "Failed to update webhook")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook updated successfully",
+		Data:    subscription,
+	})
+}
+
+// DeleteWebhook godoc
+// @Summary Eliminar suscripción de webhook
+// @Description Elimina la suscripción y su registro de entregas
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /webhooks/{id} [delete]
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	if err := h.webhookService.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err, "Failed to delete webhook")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook deleted successfully",
+	})
+}
+
+// ListWebhookDeliveries godoc
+// @Summary Listar entregas de un webhook
+// @Description Registro de entregas, las más recientes primero, con estado, intentos y respuesta del receptor
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param limit query int false "Máximo de entregas"
+// @Param offset query int false "Entregas a saltar"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /webhooks/{id}/deliveries [get]
+func (h *WebhookHandler) ListWebhookDeliveries(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	if offset < 0 {
+		offset = 0
+	}
+
+	deliveries, total, err := h.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"), limit, offset)
+	if err != nil {
+		h.respondError(c, err, "Failed to list webhook deliveries")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook deliveries retrieved successfully",
+		Data: gin.H{
+			"deliveries": deliveries,
+			"total":      total,
+		},
+	})
+}
+
+// GetWebhookDelivery godoc
+// @Summary Obtener entrega de un webhook
+// @Description Incluye el payload enviado y la respuesta del último intento
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id} [get]
+func (h *WebhookHandler) GetWebhookDelivery(c *gin.Context) {
+	delivery, err := h.webhookService.GetDelivery(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get webhook delivery")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook delivery retrieved successfully",
+		Data:    delivery,
+	})
+}
+
+// RedeliverWebhook godoc
+// @Summary Reenviar entrega de un webhook
+// @Description Encola de nuevo el payload de una entrega como una entrega nueva
+// @Tags webhooks
+// @Produce json
+// @Param id path string true "Webhook ID"
+// @Param delivery_id path string true "Delivery ID"
+// @Success 202 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /webhooks/{id}/deliveries/{delivery_id}/redeliver [post]
+func (h *WebhookHandler) RedeliverWebhook(c *gin.Context) {
+	delivery, err := h.webhookService.Redeliver(c.Request.Context(), c.Param("id"), c.Param("delivery_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to redeliver webhook")
+		return
+	}
+
+	respond(c, http.StatusAccepted, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Webhook delivery queued",
+		Data:    delivery,
+	})
+}
+
+func (h *WebhookHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWebhookNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeWebhookNotFound,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidWebhook):
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(message, "webhook_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: message,
+		})
+	}
+}
+
+// SetupWebhookRoutes registra las rutas de suscripciones de webhooks
+func SetupWebhookRoutes(router *gin.RouterGroup, handler *WebhookHandler) {
+	router.GET("/webhooks/events", handler.ListWebhookEvents)
+	router.POST("/webhooks", handler.CreateWebhook)
+	router.GET("/webhooks", handler.ListWebhooks)
+	router.GET("/webhooks/:id", handler.GetWebhook)
+	router.PUT("/webhooks/:id", handler.UpdateWebhook)
+	router.DELETE("/webhooks/:id", handler.DeleteWebhook)
+	router.GET("/webhooks/:id/deliveries", handler.ListWebhookDeliveries)
+	router.GET("/webhooks/:id/deliveries/:delivery_id", handler.GetWebhookDelivery)
+	router.POST("/webhooks/:id/deliveries/:delivery_id/redeliver", handler.RedeliverWebhook)
+}
@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// WorkflowHandler gestiona los workflows guardados y sus versiones
+type WorkflowHandler struct {
+	workflowService services.WorkflowDefinitionService
+	logger          logger.Logger
+}
+
+// NewWorkflowHandler crea un nuevo handler de workflows
+func NewWorkflowHandler(workflowService services.WorkflowDefinitionService, logger logger.Logger) *WorkflowHandler {
+	return &WorkflowHandler{
+		workflowService: workflowService,
+		logger:          logger,
+	}
+}
+
+// CreateWorkflow godoc
+// @Summary Crear workflow
+// @Description Guarda un workflow reutilizable (versión 1). Los agentes workflow lo ejecutan con workflow_id en su configuración o en la tarea.
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param request body services.WorkflowDefinitionRequest true "Datos del workflow"
+// @Success 201 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Router /workflows [post]
+func (h *WorkflowHandler) CreateWorkflow(c *gin.Context) {
+	var request services.WorkflowDefinitionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid workflow data: " + err.Error(),
+		})
+		return
+	}
+
+	definition, err := h.workflowService.Create(c.Request.Context(), request, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to create workflow")
+		return
+	}
+
+	respond(c, http.StatusCreated, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflow created successfully",
+		Data:    definition,
+	})
+}
+
+// ListWorkflows godoc
+// @Summary Listar workflows
+// @Description Lista la última versión de cada workflow guardado
+// @Tags workflows
+// @Produce json
+// @Success 200 {object} domain.APIResponse
+// @Router /workflows [get]
+func (h *WorkflowHandler) ListWorkflows(c *gin.Context) {
+	definitions, err := h.workflowService.List(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to list workflows")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflows retrieved successfully",
+		Data: gin.H{
+			"workflows": definitions,
+			"count":     len(definitions),
+		},
+	})
+}
+
+// GetWorkflow godoc
+// @Summary Obtener workflow
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /workflows/{id} [get]
+func (h *WorkflowHandler) GetWorkflow(c *gin.Context) {
+	definition, err := h.workflowService.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get workflow")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflow retrieved successfully",
+		Data:    definition,
+	})
+}
+
+// UpdateWorkflow godoc
+// @Summary Actualizar workflow
+// @Description Guarda los cambios como una versión nueva; los agentes sin versión fijada ejecutan la nueva en su siguiente tarea
+// @Tags workflows
+// @Accept json
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Param request body services.WorkflowDefinitionRequest true "Datos del workflow"
+// @Success 200 {object} domain.APIResponse
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /workflows/{id} [put]
+func (h *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
+	var request services.WorkflowDefinitionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "Invalid workflow data: " + err.Error(),
+		})
+		return
+	}
+
+	definition, err := h.workflowService.Update(c.Request.Context(), c.Param("id"), request, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to update workflow")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflow updated successfully",
+		Data:    definition,
+	})
+}
+
+// DeleteWorkflow godoc
+// @Summary Eliminar workflow
+// @Description Elimina el workflow y todas sus versiones
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /workflows/{id} [delete]
+func (h *WorkflowHandler) DeleteWorkflow(c *gin.Context) {
+	if err := h.workflowService.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.respondError(c, err, "Failed to delete workflow")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflow deleted successfully",
+	})
+}
+
+// ListWorkflowVersions godoc
+// @Summary Listar versiones de un workflow
+// @Description Versiones guardadas, de la más reciente a la más antigua
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /workflows/{id}/versions [get]
+func (h *WorkflowHandler) ListWorkflowVersions(c *gin.Context) {
+	versions, err := h.workflowService.ListVersions(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to list workflow versions")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflow versions retrieved successfully",
+		Data: gin.H{
+			"versions": versions,
+			"count":    len(versions),
+		},
+	})
+}
+
+// GetWorkflowVersion godoc
+// @Summary Obtener versión de un workflow
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Param version path int true "Versión"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /workflows/{id}/versions/{version} [get]
+func (h *WorkflowHandler) GetWorkflowVersion(c *gin.Context) {
+	version, ok := h.versionParam(c)
+	if !ok {
+		return
+	}
+	definition, err := h.workflowService.GetVersion(c.Request.Context(), c.Param("id"), version)
+	if err != nil {
+		h.respondError(c, err, "Failed to get workflow version")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflow version retrieved successfully",
+		Data:    definition,
+	})
+}
+
+// RestoreWorkflowVersion godoc
+// @Summary Restaurar versión de un workflow
+// @Description Crea una versión nueva con los pasos de la versión indicada
+// @Tags workflows
+// @Produce json
+// @Param id path string true "Workflow ID"
+// @Param version path int true "Versión a restaurar"
+// @Success 200 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /workflows/{id}/versions/{version}/restore [post]
+func (h *WorkflowHandler) RestoreWorkflowVersion(c *gin.Context) {
+	version, ok := h.versionParam(c)
+	if !ok {
+		return
+	}
+	definition, err := h.workflowService.Restore(c.Request.Context(), c.Param("id"), version, c.GetString("user_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to restore workflow version")
+		return
+	}
+
+	respond(c, http.StatusOK, domain.APIResponse{
+		Code:    domain.CodeSuccess,
+		Message: "Workflow version restored successfully",
+		Data:    definition,
+	})
+}
+
+func (h *WorkflowHandler) versionParam(c *gin.Context) (int, bool) {
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "version must be a positive integer",
+		})
+		return 0, false
+	}
+	return version, true
+}
+
+func (h *WorkflowHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrWorkflowNotFound):
+		respond(c, http.StatusNotFound, domain.APIResponse{
+			Code:    domain.CodeWorkflowNotFound,
+			Message: err.Error(),
+		})
+	case errors.Is(err, services.ErrInvalidWorkflow):
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: err.Error(),
+		})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error(message, "workflow_id", c.Param("id"), "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: message,
+		})
+	}
+}
+
+// SetupWorkflowRoutes registra las rutas de workflows guardados
+func SetupWorkflowRoutes(router *gin.RouterGroup, handler *WorkflowHandler) {
+	router.POST("/workflows", handler.CreateWorkflow)
+	router.GET("/workflows", handler.ListWorkflows)
+	router.GET("/workflows/:id", handler.GetWorkflow)
+	router.PUT("/workflows/:id", handler.UpdateWorkflow)
+	router.DELETE("/workflows/:id", handler.DeleteWorkflow)
+	router.GET("/workflows/:id/versions", handler.ListWorkflowVersions)
+	router.GET("/workflows/:id/versions/:version", handler.GetWorkflowVersion)
+	router.POST("/workflows/:id/versions/:version/restore", handler.RestoreWorkflowVersion)
+}
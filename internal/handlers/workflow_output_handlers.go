@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// WorkflowOutputHandler sirve las salidas completas de los pasos de workflow
+// que se externalizaron al almacenamiento de objetos
+type WorkflowOutputHandler struct {
+	store  adapters.ObjectStoreAdapter
+	logger logger.Logger
+}
+
+// NewWorkflowOutputHandler crea un nuevo handler de salidas de workflow
+func NewWorkflowOutputHandler(store adapters.ObjectStoreAdapter, logger logger.Logger) *WorkflowOutputHandler {
+	return &WorkflowOutputHandler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// StreamStepOutput godoc
+// @Summary Descargar salida completa de un paso de workflow
+// @Description Devuelve por partes el JSON completo de un paso cuya salida superó max_step_output_bytes y se guardó con externalize_outputs
+// @Tags mcp
+// @Produce json
+// @Param task_id path string true "Task ID"
+// @Param step path int true "Step number, starting at 1"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} domain.APIResponse
+// @Failure 404 {object} domain.APIResponse
+// @Router /mcp/workflows/{task_id}/steps/{step}/output [get]
+func (h *WorkflowOutputHandler) StreamStepOutput(c *gin.Context) {
+	step, err := strconv.Atoi(c.Param("step"))
+	if err != nil || step < 1 {
+		respond(c, http.StatusBadRequest, domain.APIResponse{
+			Code:    domain.CodeInvalidRequest,
+			Message: "step must be a positive integer",
+		})
+		return
+	}
+
+	taskID := c.Param("task_id")
+	reader, size, err := h.store.OpenObject(c.Request.Context(), mcp.WorkflowStepOutputKey(taskID, step))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			respond(c, http.StatusNotFound, domain.APIResponse{
+				Code:    domain.CodeNotFound,
+				Message: "Step output not found",
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to open workflow step output", "task_id", taskID, "step", step, "error", err)
+		respond(c, http.StatusInternalServerError, domain.APIResponse{
+			Code:    domain.CodeInternalError,
+			Message: "Failed to open step output",
+		})
+		return
+	}
+	defer reader.Close()
+
+	c.DataFromReader(http.StatusOK, size, "application/json", reader, nil)
+}
+
+// SetupWorkflowOutputRoutes registra la descarga de salidas de workflow
+func SetupWorkflowOutputRoutes(router *gin.RouterGroup, handler *WorkflowOutputHandler) {
+	router.GET("/mcp/workflows/:task_id/steps/:step/output", handler.StreamStepOutput)
+}
+
+// SetupMediaRoutes sirve los objetos guardados en dir bajo /media, salvo las
+// salidas de workflow, que solo se descargan por la API autenticada
+func SetupMediaRoutes(router *gin.Engine, dir string) {
+	files := http.StripPrefix("/media", http.FileServer(gin.Dir(dir, false)))
+	serve := func(c *gin.Context) {
+		if strings.HasPrefix(strings.TrimPrefix(path.Clean(c.Param("filepath")), "/"), mcp.WorkflowOutputsPrefix) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		files.ServeHTTP(c.Writer, c.Request)
+	}
+	router.GET("/media/*filepath", serve)
+	router.HEAD("/media/*filepath", serve)
+}
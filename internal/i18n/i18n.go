@@ -0,0 +1,174 @@
+// Package i18n traduce los mensajes de error de la API según el idioma que pide
+// el cliente en Accept-Language.
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// Idiomas soportados
+const (
+	English = "en"
+	Spanish = "es"
+)
+
+// DefaultLanguage se usa si Accept-Language no pide ningún idioma soportado
+const DefaultLanguage = English
+
+var errorMessages = map[string]map[string]string{
+	English: {
+		domain.CodeInvalidRequest:          "The request is not valid",
+		domain.CodeNotFound:                "Resource not found",
+		domain.CodeInternalError:           "An internal error occurred",
+		domain.CodeServiceUnavailable:      "The service is not ready",
+		domain.CodeUnauthorized:            "Authentication is required",
+		domain.CodeInvalidToken:            "Invalid or expired token",
+		domain.CodeForbidden:               "Access denied",
+		domain.CodeInsufficientPermissions: "Insufficient permissions for this resource",
+		domain.CodeInvalidAPIKey:           "Invalid, revoked or expired API key",
+		domain.CodeRateLimited:             "Too many requests, try again later",
+		domain.CodeBotNotFound:             "Bot not found",
+		domain.CodeFlowNotFound:            "Flow not found",
+		domain.CodeFlowVersionNotFound:     "Flow version not found",
+		domain.CodeFAQNotFound:             "FAQ entry not found",
+		domain.CodeConversationNotFound:    "Conversation not found",
+		domain.CodeKnowledgeSourceNotFound: "Knowledge source not found",
+		domain.CodeAgentNotFound:           "Agent not found",
+		domain.CodeTaskNotFound:            "Task not found",
+		domain.CodeDeadLetterNotFound:      "Dead-letter task not found",
+		domain.CodeConditionalNotFound:     "Conditional not found",
+		domain.CodeTriggerNotFound:         "Trigger not found",
+		domain.CodeTestCaseNotFound:        "Test case not found",
+		domain.CodeTestSuiteNotFound:       "Test suite not found",
+		domain.CodeTestRunNotFound:         "Test execution not found",
+		domain.CodeAPIKeyNotFound:          "API key not found",
+		domain.CodeWebhookNotFound:         "Webhook not found",
+		domain.CodeWorkflowNotFound:        "Workflow not found",
+		domain.CodeScriptApprovalNotFound:  "Script approval not found",
+		domain.CodeMemoryNotFound:          "Memory not found",
+		domain.CodeIntentExampleNotFound:   "Intent example not found",
+		domain.CodeFlowInvalid:             "The flow cannot be published",
+		domain.CodeHandoffConflict:         "The conversation does not allow this handoff operation",
+		domain.CodeSyncFailed:              "Knowledge source sync failed",
+		domain.CodeQuotaExceeded:           "Capacity limit reached, try again later",
+		domain.CodeAgentUnavailable:        "No agent is available for this task",
+		domain.CodeOverloaded:              "The service is overloaded, try again later",
+	},
+	Spanish: {
+		domain.CodeInvalidRequest:          "La petición no es válida",
+		domain.CodeNotFound:                "Recurso no encontrado",
+		domain.CodeInternalError:           "Se produjo un error interno",
+		domain.CodeServiceUnavailable:      "El servicio no está listo",
+		domain.CodeUnauthorized:            "Se requiere autenticación",
+		domain.CodeInvalidToken:            "Token inválido o expirado",
+		domain.CodeForbidden:               "Acceso denegado",
+		domain.CodeInsufficientPermissions: "Permisos insuficientes para este recurso",
+		domain.CodeInvalidAPIKey:           "API key inválida, revocada o expirada",
+		domain.CodeRateLimited:             "Demasiadas peticiones, inténtalo más tarde",
+		domain.CodeBotNotFound:             "Bot no encontrado",
+		domain.CodeFlowNotFound:            "Flujo no encontrado",
+		domain.CodeFlowVersionNotFound:     "Versión del flujo no encontrada",
+		domain.CodeFAQNotFound:             "Entrada de FAQ no encontrada",
+		domain.CodeConversationNotFound:    "Conversación no encontrada",
+		domain.CodeKnowledgeSourceNotFound: "Fuente de conocimiento no encontrada",
+		domain.CodeAgentNotFound:           "Agente no encontrado",
+		domain.CodeTaskNotFound:            "Tarea no encontrada",
+		domain.CodeDeadLetterNotFound:      "Tarea en dead-letter no encontrada",
+		domain.CodeConditionalNotFound:     "Condicional no encontrado",
+		domain.CodeTriggerNotFound:         "Trigger no encontrado",
+		domain.CodeTestCaseNotFound:        "Caso de prueba no encontrado",
+		domain.CodeTestSuiteNotFound:       "Suite de prueba no encontrado",
+		domain.CodeTestRunNotFound:         "Ejecución no encontrada",
+		domain.CodeAPIKeyNotFound:          "API key no encontrada",
+		domain.CodeWebhookNotFound:         "Webhook no encontrado",
+		domain.CodeWorkflowNotFound:        "Workflow no encontrado",
+		domain.CodeScriptApprovalNotFound:  "Aprobación de script no encontrada",
+		domain.CodeMemoryNotFound:          "Memoria no encontrada",
+		domain.CodeIntentExampleNotFound:   "Ejemplo de intent no encontrado",
+		domain.CodeFlowInvalid:             "El flujo no se puede publicar",
+		domain.CodeHandoffConflict:         "La conversación no permite esta operación de derivación",
+		domain.CodeSyncFailed:              "Falló la sincronización de la fuente de conocimiento",
+		domain.CodeQuotaExceeded:           "Se alcanzó el límite de capacidad, inténtalo más tarde",
+		domain.CodeAgentUnavailable:        "No hay ningún agente disponible para esta tarea",
+		domain.CodeOverloaded:              "El servicio está sobrecargado, inténtalo más tarde",
+	},
+}
+
+// Negotiate elige el idioma soportado con mayor prioridad en una cabecera
+// Accept-Language ("es-ES,es;q=0.9,en;q=0.8")
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		lang    string
+		quality float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if q, err := strconv.ParseFloat(value, 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		base, _, _ := strings.Cut(tag, "-")
+		if _, ok := errorMessages[base]; ok && quality > 0 {
+			candidates = append(candidates, candidate{lang: base, quality: quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].quality > candidates[j].quality })
+	return candidates[0].lang
+}
+
+// ErrorMessage devuelve el mensaje del código en el idioma indicado
+func ErrorMessage(lang, code string) (string, bool) {
+	messages, ok := errorMessages[lang]
+	if !ok {
+		messages = errorMessages[DefaultLanguage]
+	}
+	message, ok := messages[code]
+	return message, ok
+}
+
+// Localize sustituye el mensaje de una respuesta de error por el del catálogo.
+// El texto original del handler, si aporta algo, pasa a Detail.
+func Localize(response *domain.APIResponse, lang string) {
+	if response.Code == domain.CodeSuccess {
+		return
+	}
+	message, ok := ErrorMessage(lang, response.Code)
+	if !ok {
+		return
+	}
+	if response.Detail == "" && !isCatalogMessage(response.Code, response.Message) {
+		response.Detail = response.Message
+	}
+	response.Message = message
+}
+
+// isCatalogMessage indica si el mensaje ya es la traducción del código en algún idioma
+func isCatalogMessage(code, message string) bool {
+	if message == "" {
+		return true
+	}
+	for _, messages := range errorMessages {
+		if messages[code] == message {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,43 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiate(t *testing.T) {
+	assert.Equal(t, Spanish, Negotiate("es-ES,es;q=0.9,en;q=0.8"))
+	assert.Equal(t, English, Negotiate("fr-FR, en;q=0.5, es;q=0.3"))
+	assert.Equal(t, Spanish, Negotiate("en;q=0.2, es-MX"))
+	assert.Equal(t, DefaultLanguage, Negotiate("de, fr;q=0.9"))
+	assert.Equal(t, DefaultLanguage, Negotiate(""))
+	assert.Equal(t, DefaultLanguage, Negotiate("es;q=0"))
+}
+
+func TestErrorMessages_CoverCatalog(t *testing.T) {
+	for lang := range errorMessages {
+		for _, info := range domain.ErrorCatalog() {
+			_, ok := ErrorMessage(lang, info.Code)
+			assert.True(t, ok, "%s has no %s message", info.Code, lang)
+		}
+	}
+}
+
+func TestLocalize(t *testing.T) {
+	response := domain.APIResponse{Code: domain.CodeInvalidRequest, Message: "Invalid bot data: missing name"}
+	Localize(&response, Spanish)
+	assert.Equal(t, "La petición no es válida", response.Message)
+	assert.Equal(t, "Invalid bot data: missing name", response.Detail)
+
+	// Un mensaje que ya es la traducción del código no se repite en detail
+	response = domain.APIResponse{Code: domain.CodeConditionalNotFound, Message: "Condicional no encontrado"}
+	Localize(&response, English)
+	assert.Equal(t, "Conditional not found", response.Message)
+	assert.Empty(t, response.Detail)
+
+	response = domain.APIResponse{Code: domain.CodeSuccess, Message: "Bot created successfully"}
+	Localize(&response, Spanish)
+	assert.Equal(t, "Bot created successfully", response.Message)
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -14,10 +16,13 @@ type adapterAgent struct {
 	*baseAgent
 	registry adapters.AdapterRegistry
 	factory  adapters.AdapterFactory
+	auth     *adapters.AuthProfiles
+	// authProfile es el perfil de las tareas HTTP que no indican el suyo
+	authProfile string
 }
 
 // NewAdapterAgent crea un nuevo agente de adaptador
-func NewAdapterAgent(config MCPConfig, registry adapters.AdapterRegistry, factory adapters.AdapterFactory, logger logger.Logger) (Agent, error) {
+func NewAdapterAgent(config MCPConfig, registry adapters.AdapterRegistry, factory adapters.AdapterFactory, auth *adapters.AuthProfiles, logger logger.Logger) (Agent, error) {
 	base := newBaseAgent(config, logger)
 	base.capabilities = []string{
 		"http_request",
@@ -26,12 +31,20 @@ func NewAdapterAgent(config MCPConfig, registry adapters.AdapterRegistry, factor
 		"integration",
 		"adapter_management",
 		"interoperability",
+		"grpc_call",
+		"graphql_query",
+		"graphql_mutation",
+		"database_query",
+		"queue_publish",
+		"queue_consume",
 	}
 	
 	return &adapterAgent{
 		baseAgent: base,
 		registry:  registry,
 		factory:   factory,
+		auth:      auth,
+		authProfile: configschema.Values(config.Config).String("auth_profile", ""),
 	}, nil
 }
 
@@ -39,16 +52,10 @@ func (a *adapterAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	start := time.Now()
 	
 	// Actualizar estado
-	a.mu.Lock()
-	a.state.Status = AgentStatusBusy
-	a.state.CurrentTask = &task
-	a.mu.Unlock()
+	a.setStatus(AgentStatusBusy, &task)
 	
 	defer func() {
-		a.mu.Lock()
-		a.state.Status = AgentStatusIdle
-		a.state.CurrentTask = nil
-		a.mu.Unlock()
+		a.setStatus(AgentStatusIdle, nil)
 	}()
 	
 	a.logger.Info("Adapter agent executing task", 
@@ -197,6 +204,7 @@ func (a *adapterAgent) executeHTTPTask(ctx context.Context, task Task) (Result,
 	}
 	
 	// Ejecutar solicitud
+	httpAdapter = a.withAuthProfile(task, request, httpAdapter)
 	response, err := httpAdapter.MakeRequest(ctx, request)
 	if err != nil {
 		return Result{
@@ -244,7 +252,7 @@ func (a *adapterAgent) executeCreateAdapterTask(ctx context.Context, task Task)
 	
 	adapterName, ok := task.Input["adapter_name"].(string)
 	if !ok {
-		adapterName = fmt.Sprintf("%s-adapter-%d", adapterType, time.Now().UnixNano())
+		adapterName = fmt.Sprintf("%s-adapter-%s", adapterType, id.New())
 	}
 	
 	config, ok := task.Input["config"].(map[string]interface{})
@@ -385,8 +393,19 @@ func (a *adapterAgent) executeAdapterHealthTask(ctx context.Context, task Task)
 }
 
 func (a *adapterAgent) executeWithAdapter(ctx context.Context, task Task) (Result, error) {
-	// Buscar adaptadores que puedan manejar la tarea
+	// Buscar adaptadores que puedan manejar la tarea; input.adapter elige uno por nombre
 	capableAdapters := a.registry.GetByCapability(task.Type)
+	if name, ok := task.Input["adapter"].(string); ok && name != "" {
+		named, err := a.registry.Get(name)
+		if err != nil || !named.CanHandle(task.Type) {
+			return Result{
+				TaskID:  task.ID,
+				Success: false,
+				Error:   fmt.Sprintf("adapter %s cannot handle task type: %s", name, task.Type),
+			}, fmt.Errorf("adapter %s cannot handle task type %s", name, task.Type)
+		}
+		capableAdapters = []adapters.Adapter{named}
+	}
 	
 	if len(capableAdapters) == 0 {
 		return Result{
@@ -413,9 +432,17 @@ func (a *adapterAgent) executeWithAdapter(ctx context.Context, task Task) (Resul
 		}, fmt.Errorf("no healthy adapter available")
 	}
 	
-	// Por ahora, solo manejar adaptadores HTTP
-	if httpAdapter, ok := selectedAdapter.(adapters.HTTPAdapter); ok {
-		return a.executeHTTPWithAdapter(ctx, task, httpAdapter)
+	switch adapter := selectedAdapter.(type) {
+	case adapters.HTTPAdapter:
+		return a.executeHTTPWithAdapter(ctx, task, adapter)
+	case adapters.GRPCAdapter:
+		return a.executeGRPCWithAdapter(ctx, task, adapter)
+	case adapters.GraphQLAdapter:
+		return a.executeGraphQLWithAdapter(ctx, task, adapter)
+	case adapters.DatabaseAdapter:
+		return a.executeDatabaseWithAdapter(ctx, task, adapter)
+	case adapters.MessageQueueAdapter:
+		return a.executeQueueWithAdapter(ctx, task, adapter)
 	}
 	
 	return Result{
@@ -448,6 +475,7 @@ func (a *adapterAgent) executeHTTPWithAdapter(ctx context.Context, task Task, ht
 	
 	// ... resto de la configuración de la solicitud
 	
+	httpAdapter = a.withAuthProfile(task, request, httpAdapter)
 	response, err := httpAdapter.MakeRequest(ctx, request)
 	if err != nil {
 		return Result{
@@ -471,12 +499,198 @@ func (a *adapterAgent) executeHTTPWithAdapter(ctx context.Context, task Task, ht
 	}, nil
 }
 
+// withAuthProfile marca la solicitud con el perfil de autenticación de la
+// tarea (o el del agente) y envuelve el adaptador para que lo aplique
+func (a *adapterAgent) withAuthProfile(task Task, request *adapters.HTTPRequest, httpAdapter adapters.HTTPAdapter) adapters.HTTPAdapter {
+	request.AuthProfile = configschema.Values(task.Input).String("auth_profile", a.authProfile)
+	if request.AuthProfile == "" {
+		return httpAdapter
+	}
+	return adapters.WithAuthProfiles(httpAdapter, a.auth)
+}
+
+// executeGRPCWithAdapter llama a input.method con input.message (JSON) y
+// input.metadata
+func (a *adapterAgent) executeGRPCWithAdapter(ctx context.Context, task Task, grpcAdapter adapters.GRPCAdapter) (Result, error) {
+	values := configschema.Values(task.Input)
+	response, err := grpcAdapter.Invoke(ctx, &adapters.GRPCRequest{
+		Method:   values.String("method", ""),
+		Message:  values.Object("message"),
+		Metadata: values.StringMap("metadata"),
+		Timeout:  values.Duration("timeout", 0),
+	})
+	if err != nil {
+		result := Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   fmt.Sprintf("gRPC call failed: %v", err),
+			Output: map[string]interface{}{
+				"adapter_name": grpcAdapter.GetName(),
+			},
+		}
+		if response != nil {
+			result.Output["code"] = response.Code
+		}
+		return result, err
+	}
+
+	return Result{
+		TaskID:  task.ID,
+		Success: response.Success,
+		Output: map[string]interface{}{
+			"code":         response.Code,
+			"message":      response.Message,
+			"duration":     response.Duration.Milliseconds(),
+			"adapter_name": grpcAdapter.GetName(),
+		},
+	}, nil
+}
+
+// executeGraphQLWithAdapter envía input.query con input.variables; los errors
+// de GraphQL dejan la tarea como fallida pero conservan data
+func (a *adapterAgent) executeGraphQLWithAdapter(ctx context.Context, task Task, graphqlAdapter adapters.GraphQLAdapter) (Result, error) {
+	values := configschema.Values(task.Input)
+	response, err := graphqlAdapter.Execute(ctx, &adapters.GraphQLRequest{
+		Query:         values.String("query", ""),
+		Variables:     values.Object("variables"),
+		OperationName: values.String("operation_name", ""),
+		Headers:       values.StringMap("headers"),
+	})
+	if err != nil {
+		return Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   fmt.Sprintf("GraphQL request failed: %v", err),
+			Output: map[string]interface{}{
+				"adapter_name": graphqlAdapter.GetName(),
+			},
+		}, err
+	}
+
+	return Result{
+		TaskID:  task.ID,
+		Success: response.Success,
+		Error:   response.Error,
+		Output: map[string]interface{}{
+			"status_code":  response.StatusCode,
+			"data":         response.Data,
+			"errors":       response.Errors,
+			"duration":     response.Duration.Milliseconds(),
+			"adapter_name": graphqlAdapter.GetName(),
+		},
+	}, nil
+}
+
+// executeDatabaseWithAdapter ejecuta input.query con input.params como
+// parámetros posicionales
+func (a *adapterAgent) executeDatabaseWithAdapter(ctx context.Context, task Task, database adapters.DatabaseAdapter) (Result, error) {
+	query, _ := task.Input["query"].(string)
+	params, _ := task.Input["params"].([]interface{})
+	response, err := database.Query(ctx, query, params...)
+	if err != nil {
+		return Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   fmt.Sprintf("database query failed: %v", err),
+			Output: map[string]interface{}{
+				"adapter_name": database.GetName(),
+			},
+		}, err
+	}
+
+	return Result{
+		TaskID:  task.ID,
+		Success: response.Success,
+		Output: map[string]interface{}{
+			"rows":         response.Rows,
+			"count":        response.Count,
+			"truncated":    response.Truncated,
+			"duration":     response.Duration.Milliseconds(),
+			"adapter_name": database.GetName(),
+		},
+	}, nil
+}
+
+// executeQueueWithAdapter publica input.payload en input.topic (queue_publish)
+// o consume hasta input.max_messages esperando input.wait (queue_consume)
+func (a *adapterAgent) executeQueueWithAdapter(ctx context.Context, task Task, queue adapters.MessageQueueAdapter) (Result, error) {
+	values := configschema.Values(task.Input)
+	topic := values.String("topic", "")
+	if task.Type == "queue_consume" {
+		messages, err := queue.Receive(ctx, topic, values.Int("max_messages", 1), values.Duration("wait", 0))
+		if err != nil {
+			return Result{
+				TaskID:  task.ID,
+				Success: false,
+				Error:   fmt.Sprintf("queue consume failed: %v", err),
+				Output: map[string]interface{}{
+					"adapter_name": queue.GetName(),
+				},
+			}, err
+		}
+
+		received := make([]map[string]interface{}, 0, len(messages))
+		for _, message := range messages {
+			received = append(received, map[string]interface{}{
+				"id":       message.ID,
+				"payload":  message.Payload,
+				"headers":  message.Headers,
+				"metadata": message.Metadata,
+			})
+		}
+		return Result{
+			TaskID:  task.ID,
+			Success: true,
+			Output: map[string]interface{}{
+				"messages":     received,
+				"count":        len(received),
+				"adapter_name": queue.GetName(),
+			},
+		}, nil
+	}
+
+	message := &adapters.Message{
+		ID:        values.String("message_id", ""),
+		Topic:     topic,
+		Payload:   task.Input["payload"],
+		Headers:   values.StringMap("headers"),
+		Timestamp: time.Now(),
+		Metadata:  map[string]interface{}{"key": values.String("key", "")},
+	}
+	if err := queue.Publish(ctx, topic, message); err != nil {
+		return Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   fmt.Sprintf("queue publish failed: %v", err),
+			Output: map[string]interface{}{
+				"adapter_name": queue.GetName(),
+			},
+		}, err
+	}
+
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output: map[string]interface{}{
+			"message_id":   message.ID,
+			"topic":        topic,
+			"adapter_name": queue.GetName(),
+		},
+	}, nil
+}
+
 func (a *adapterAgent) CanHandle(taskType string) bool {
 	supportedTypes := []string{
 		"http_request",
 		"api_call",
 		"webhook",
 		"integration",
+		"grpc_call",
+		"graphql_query",
+		"graphql_mutation",
+		"database_query",
+		"queue_publish",
+		"queue_consume",
 		"create_adapter",
 		"list_adapters",
 		"adapter_health",
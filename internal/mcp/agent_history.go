@@ -0,0 +1,92 @@
+package mcp
+
+import "time"
+
+// Límites del historial que conserva cada agente
+const (
+	maxStateTransitions = 50
+	maxAgentErrors      = 20
+)
+
+// AgentStateTransition registra un cambio de estado del agente
+type AgentStateTransition struct {
+	From   AgentStatus `json:"from"`
+	To     AgentStatus `json:"to"`
+	TaskID string      `json:"task_id,omitempty"`
+	At     time.Time   `json:"at"`
+}
+
+// AgentErrorRecord registra una tarea fallida del agente
+type AgentErrorRecord struct {
+	TaskID   string    `json:"task_id"`
+	TaskType string    `json:"task_type"`
+	Message  string    `json:"message"`
+	At       time.Time `json:"at"`
+}
+
+// AgentHistory contiene las transiciones y errores recientes de un agente,
+// del más antiguo al más reciente
+type AgentHistory struct {
+	AgentID     string                 `json:"agent_id"`
+	Transitions []AgentStateTransition `json:"transitions"`
+	Errors      []AgentErrorRecord     `json:"errors"`
+}
+
+// GetHistory devuelve una copia del historial reciente del agente
+func (a *baseAgent) GetHistory() AgentHistory {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return AgentHistory{
+		AgentID:     a.id,
+		Transitions: append([]AgentStateTransition{}, a.transitions...),
+		Errors:      append([]AgentErrorRecord{}, a.errors...),
+	}
+}
+
+// setStatus cambia el estado y la tarea actual registrando la transición
+func (a *baseAgent) setStatus(status AgentStatus, task *Task) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.setStatusLocked(status, task)
+}
+
+// setStatusLocked es setStatus para quien ya tiene tomado el lock
+func (a *baseAgent) setStatusLocked(status AgentStatus, task *Task) {
+	now := time.Now()
+	if a.state.Status != status {
+		transition := AgentStateTransition{From: a.state.Status, To: status, At: now}
+		if task != nil {
+			transition.TaskID = task.ID
+		} else if a.state.CurrentTask != nil {
+			transition.TaskID = a.state.CurrentTask.ID
+		}
+		a.transitions = append(a.transitions, transition)
+		if len(a.transitions) > maxStateTransitions {
+			a.transitions = a.transitions[len(a.transitions)-maxStateTransitions:]
+		}
+	}
+
+	a.state.Status = status
+	a.state.CurrentTask = task
+	a.state.LastActivity = now
+}
+
+// recordError guarda el error de una tarea y lo refleja en las métricas
+func (a *baseAgent) recordError(task Task, message string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	record := AgentErrorRecord{
+		TaskID:   task.ID,
+		TaskType: task.Type,
+		Message:  message,
+		At:       time.Now(),
+	}
+	a.errors = append(a.errors, record)
+	if len(a.errors) > maxAgentErrors {
+		a.errors = a.errors[len(a.errors)-maxAgentErrors:]
+	}
+	a.state.Metrics.LastError = message
+}
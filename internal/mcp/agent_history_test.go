@@ -0,0 +1,105 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentHistory_RecordsTransitionsAndErrors(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	o := NewOrchestrator(NewAgentFactory(log), log)
+	agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "http", Name: "api", Config: map[string]interface{}{"base_url": server.URL}})
+	require.NoError(t, err)
+
+	_, err = o.ExecuteTask(ctx, Task{ID: "ok", Type: "http_request", Input: map[string]interface{}{"method": "GET", "endpoint": "/ping"}})
+	require.NoError(t, err)
+	_, _ = o.ExecuteTask(ctx, Task{ID: "fails", Type: "http_request", Input: map[string]interface{}{"method": "GET", "endpoint": "/broken"}})
+
+	history, err := o.GetAgentHistory(agent.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, agent.GetID(), history.AgentID)
+
+	type step struct {
+		from, to AgentStatus
+		taskID   string
+	}
+	steps := make([]step, 0, len(history.Transitions))
+	for _, transition := range history.Transitions {
+		assert.False(t, transition.At.IsZero())
+		steps = append(steps, step{transition.From, transition.To, transition.TaskID})
+	}
+	assert.Equal(t, []step{
+		{AgentStatusIdle, AgentStatusBusy, "ok"},
+		{AgentStatusBusy, AgentStatusIdle, "ok"},
+		{AgentStatusIdle, AgentStatusBusy, "fails"},
+		{AgentStatusBusy, AgentStatusIdle, "fails"},
+	}, steps)
+
+	require.Len(t, history.Errors, 1)
+	assert.Equal(t, "fails", history.Errors[0].TaskID)
+	assert.Equal(t, "http_request", history.Errors[0].TaskType)
+	assert.Contains(t, history.Errors[0].Message, "HTTP 500")
+	assert.Equal(t, history.Errors[0].Message, agent.GetState().Metrics.LastError)
+
+	// El historial devuelto es una copia
+	history.Transitions[0].TaskID = "changed"
+	history, err = o.GetAgentHistory(agent.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, "ok", history.Transitions[0].TaskID)
+
+	require.NoError(t, agent.Stop(ctx))
+	history = agent.GetHistory()
+	last := history.Transitions[len(history.Transitions)-1]
+	assert.Equal(t, AgentStatusIdle, last.From)
+	assert.Equal(t, AgentStatusTerminated, last.To)
+
+	_, err = o.GetAgentHistory("missing")
+	assert.Error(t, err)
+}
+
+func TestAgentHistory_KeepsMostRecentEntries(t *testing.T) {
+	agent := newBaseAgent(MCPConfig{Type: "stub", Name: "capped"}, logger.NewLogger("error"))
+
+	// Repetir el mismo estado no es una transición
+	agent.setStatus(AgentStatusIdle, nil)
+	assert.Empty(t, agent.GetHistory().Transitions)
+
+	for i := 0; i < maxStateTransitions; i++ {
+		task := Task{ID: fmt.Sprintf("task-%d", i)}
+		agent.setStatus(AgentStatusBusy, &task)
+		agent.setStatus(AgentStatusIdle, nil)
+	}
+	for i := 0; i < maxAgentErrors+5; i++ {
+		agent.recordError(Task{ID: fmt.Sprintf("task-%d", i), Type: "stub"}, fmt.Sprintf("error %d", i))
+	}
+
+	history := agent.GetHistory()
+	require.Len(t, history.Transitions, maxStateTransitions)
+	first, last := history.Transitions[0], history.Transitions[maxStateTransitions-1]
+	assert.Equal(t, fmt.Sprintf("task-%d", maxStateTransitions/2), first.TaskID)
+	assert.Equal(t, AgentStatusBusy, first.To)
+	assert.Equal(t, fmt.Sprintf("task-%d", maxStateTransitions-1), last.TaskID)
+	assert.Equal(t, AgentStatusIdle, last.To)
+
+	require.Len(t, history.Errors, maxAgentErrors)
+	assert.Equal(t, "error 5", history.Errors[0].Message)
+	assert.Equal(t, fmt.Sprintf("error %d", maxAgentErrors+4), history.Errors[maxAgentErrors-1].Message)
+	assert.Equal(t, fmt.Sprintf("error %d", maxAgentErrors+4), agent.GetState().Metrics.LastError)
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"fmt"
+
+	"github.com/company/bot-service/pkg/configschema"
+)
+
+// Tipos de valor admitidos en el esquema de configuración de un agente
+const (
+	ConfigFieldString   = configschema.TypeString
+	ConfigFieldNumber   = configschema.TypeNumber
+	ConfigFieldInteger  = configschema.TypeInteger
+	ConfigFieldBoolean  = configschema.TypeBoolean
+	ConfigFieldArray    = configschema.TypeArray
+	ConfigFieldObject   = configschema.TypeObject
+	ConfigFieldDuration = configschema.TypeDuration
+)
+
+// ConfigField describe un campo de la configuración de un tipo de agente
+type ConfigField = configschema.Field
+
+// AgentTypeInfo contiene los metadatos públicos de un tipo de agente
+type AgentTypeInfo struct {
+	Type         string        `json:"type"`
+	Description  string        `json:"description"`
+	Capabilities []string      `json:"capabilities"`
+	Config       []ConfigField `json:"config"`
+	Builtin      bool          `json:"builtin"`
+}
+
+// AgentConstructor crea un agente a partir de su configuración
+type AgentConstructor func(config MCPConfig) (Agent, error)
+
+// ConfigValidator aplica validaciones que el esquema no puede expresar
+type ConfigValidator func(config MCPConfig) error
+
+// AgentTypeDefinition registra un tipo de agente en la factory. Validate es
+// opcional y se ejecuta después de validar el esquema.
+type AgentTypeDefinition struct {
+	AgentTypeInfo
+	Create   AgentConstructor
+	Validate ConfigValidator
+}
+
+// validate comprueba que la definición sea registrable
+func (d AgentTypeDefinition) validate() error {
+	if d.Type == "" {
+		return fmt.Errorf("agent type is required")
+	}
+	if d.Create == nil {
+		return fmt.Errorf("agent type %s requires a constructor", d.Type)
+	}
+	if err := configschema.CheckSchema(d.Config); err != nil {
+		return fmt.Errorf("agent type %s: %w", d.Type, err)
+	}
+	return nil
+}
+
+// copyAgentTypeInfo evita que quien consulta el registro modifique los metadatos
+func copyAgentTypeInfo(info AgentTypeInfo) AgentTypeInfo {
+	info.Capabilities = append([]string{}, info.Capabilities...)
+	fields := make([]ConfigField, len(info.Config))
+	for i, field := range info.Config {
+		field.Enum = append([]string(nil), field.Enum...)
+		fields[i] = field
+	}
+	info.Config = fields
+	return info
+}
@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func echoAgentType() AgentTypeDefinition {
+	return AgentTypeDefinition{
+		AgentTypeInfo: AgentTypeInfo{
+			Type:         "echo",
+			Description:  "Repeats its input",
+			Capabilities: []string{"echo"},
+			Config:       []ConfigField{{Name: "prefix", Type: ConfigFieldString, Required: true}},
+			Builtin:      true,
+		},
+		Create: func(config MCPConfig) (Agent, error) {
+			prefix, _ := config.Config["prefix"].(string)
+			return newStubAgent(config.Name, func(_ context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{"text": fmt.Sprintf("%s%v", prefix, input["text"])}, nil
+			}), nil
+		},
+		Validate: func(config MCPConfig) error {
+			if config.Config["prefix"] == "forbidden" {
+				return fmt.Errorf("prefix is not allowed")
+			}
+			return nil
+		},
+	}
+}
+
+func findAgentType(types []AgentTypeInfo, agentType string) (AgentTypeInfo, bool) {
+	for _, info := range types {
+		if info.Type == agentType {
+			return info, true
+		}
+	}
+	return AgentTypeInfo{}, false
+}
+
+func TestRegisterAgentType_CustomType(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	factory := NewAgentFactory(log)
+	require.NoError(t, factory.RegisterAgentType(echoAgentType()))
+
+	// El registro marca el tipo como personalizado aunque la definición diga lo contrario
+	info, ok := findAgentType(factory.GetAgentTypes(), "echo")
+	require.True(t, ok)
+	assert.False(t, info.Builtin)
+	assert.Equal(t, []string{"echo"}, info.Capabilities)
+	builtin, ok := findAgentType(factory.GetAgentTypes(), "ai")
+	require.True(t, ok)
+	assert.True(t, builtin.Builtin)
+	assert.Contains(t, factory.GetSupportedTypes(), "echo")
+
+	// Los metadatos devueltos son copias
+	info.Capabilities[0] = "changed"
+	info.Config[0].Name = "changed"
+	info, _ = findAgentType(factory.GetAgentTypes(), "echo")
+	assert.Equal(t, []string{"echo"}, info.Capabilities)
+	assert.Equal(t, "prefix", info.Config[0].Name)
+
+	// La factory crea el agente con el constructor registrado
+	agent, err := factory.CreateAgent(MCPConfig{Type: "echo", Name: "direct", Config: map[string]interface{}{"prefix": "> "}})
+	require.NoError(t, err)
+	output, err := agent.Execute(ctx, Task{ID: "direct", Input: map[string]interface{}{"text": "hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, "> hi", output.Output["text"])
+
+	// Y el orquestador lo valida e instancia como cualquier otro tipo
+	o := NewOrchestrator(factory, log)
+	agent, err = o.InstantiateMCP(ctx, MCPConfig{Type: "echo", Name: "echo", Config: map[string]interface{}{"prefix": "# "}})
+	require.NoError(t, err)
+	output, err = agent.Execute(ctx, Task{ID: "orchestrated", Input: map[string]interface{}{"text": "hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, "# hi", output.Output["text"])
+}
+
+func TestRegisterAgentType_ValidatesConfig(t *testing.T) {
+	factory := NewAgentFactory(logger.NewLogger("error"))
+	require.NoError(t, factory.RegisterAgentType(echoAgentType()))
+
+	assert.NoError(t, factory.ValidateConfig(MCPConfig{Type: "echo", Name: "echo", Config: map[string]interface{}{"prefix": "> "}}))
+
+	// El esquema registrado exige prefix
+	err := factory.ValidateConfig(MCPConfig{Type: "echo", Name: "echo", Config: map[string]interface{}{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "config.prefix")
+
+	// El validador del tipo corre después del esquema
+	err = factory.ValidateConfig(MCPConfig{Type: "echo", Name: "echo", Config: map[string]interface{}{"prefix": "forbidden"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "prefix is not allowed")
+}
+
+func TestRegisterAgentType_RejectsDuplicatesAndInvalidDefinitions(t *testing.T) {
+	factory := NewAgentFactory(logger.NewLogger("error"))
+	require.NoError(t, factory.RegisterAgentType(echoAgentType()))
+
+	err := factory.RegisterAgentType(echoAgentType())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "agent type already registered: echo")
+
+	// Un tipo incorporado no se puede reemplazar
+	builtin := echoAgentType()
+	builtin.Type = "ai"
+	err = factory.RegisterAgentType(builtin)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "agent type already registered: ai")
+	info, ok := findAgentType(factory.GetAgentTypes(), "ai")
+	require.True(t, ok)
+	assert.True(t, info.Builtin)
+
+	untyped := echoAgentType()
+	untyped.Type = ""
+	err = factory.RegisterAgentType(untyped)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "agent type is required")
+
+	noConstructor := echoAgentType()
+	noConstructor.Type = "silent"
+	noConstructor.Create = nil
+	err = factory.RegisterAgentType(noConstructor)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "agent type silent requires a constructor")
+	_, ok = findAgentType(factory.GetAgentTypes(), "silent")
+	assert.False(t, ok)
+}
+
+func TestAgentFactory_UnknownType(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	factory := NewAgentFactory(log)
+
+	_, err := factory.CreateAgent(MCPConfig{Type: "unknown", Name: "unknown"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported agent type: unknown")
+
+	err = factory.ValidateConfig(MCPConfig{Type: "unknown", Name: "unknown"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported agent type unknown")
+
+	o := NewOrchestrator(factory, log)
+	_, err = o.InstantiateMCP(ctx, MCPConfig{Type: "unknown", Name: "unknown"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid MCP config")
+	assert.Empty(t, o.ListAgents())
+}
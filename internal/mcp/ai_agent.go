@@ -4,38 +4,79 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/configschema"
 	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/sse"
+)
+
+// Proveedores de los agentes de IA, usados como etiqueta en las métricas de tokens
+const (
+	aiProviderOpenAI      = "openai"
+	aiProviderAzureOpenAI = "azure_openai"
+	aiProviderGemini      = "gemini"
 )
 
 // AIAgent implementa un agente que usa servicios de IA
 type aiAgent struct {
 	*baseAgent
 	client    *http.Client
+	provider  string
+	chatURL   string
 	apiKey    string
 	model     string
-	baseURL   string
+	temperature float64
 	useMock   bool
-	mockResponses []string
-	mockIndex     int
+	mock      *mockReplies
+}
+
+// mockReplies rota las respuestas de los agentes de IA sin credenciales
+type mockReplies struct {
+	mu        sync.Mutex
+	responses []string
+	index     int
 }
 
 // OpenAI API structures
 type openAIRequest struct {
-	Model       string    `json:"model"`
-	Messages    []message `json:"messages"`
-	Temperature float64   `json:"temperature,omitempty"`
-	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Model       string       `json:"model"`
+	Messages    []message    `json:"messages"`
+	Temperature float64      `json:"temperature,omitempty"`
+	MaxTokens   int          `json:"max_tokens,omitempty"`
+	Stream      bool         `json:"stream,omitempty"`
+	Tools       []openAITool `json:"tools,omitempty"`
+	ToolChoice  string       `json:"tool_choice,omitempty"`
 }
 
 type message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// openAITool es una herramienta ofrecida al modelo en formato de function calling
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function ToolDefinition `json:"function"`
+}
+
+// openAIToolCall es una llamada a herramienta pedida por el modelo
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
 }
 
 type openAIResponse struct {
@@ -61,67 +102,64 @@ type usage struct {
 
 // NewAIAgent crea un nuevo agente de IA
 func NewAIAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	values := configschema.Values(config.Config)
+	apiKey := values.String("openai_api_key", "")
+	model := values.String("model", "gpt-3.5-turbo")
+	baseURL := values.String("base_url", "https://api.openai.com/v1")
+	
+	return newAIAgent(config, logger, aiProviderOpenAI, baseURL+"/chat/completions", apiKey, model), nil
+}
+
+// newAIAgent crea un agente para una API de chat compatible con OpenAI
+func newAIAgent(config MCPConfig, logger logger.Logger, provider, chatURL, apiKey, model string) *aiAgent {
 	base := newBaseAgent(config, logger)
 	base.capabilities = []string{"text_generation", "conversation", "analysis", "summarization"}
 	
-	// Obtener configuración
-	apiKey, _ := config.Config["openai_api_key"].(string)
-	model, _ := config.Config["model"].(string)
-	if model == "" {
-		model = "gpt-3.5-turbo"
-	}
-	
-	baseURL := "https://api.openai.com/v1"
-	if customURL, exists := config.Config["base_url"].(string); exists {
-		baseURL = customURL
-	}
-	
 	// Determinar si usar mock
 	useMock := apiKey == "" || apiKey == "sk-test-key"
 	
-	// Respuestas mock por defecto
-	mockResponses := []string{
+	return &aiAgent{
+		baseAgent: base,
+		client: &http.Client{
+			Timeout: aiRequestTimeout(config),
+		},
+		provider:    provider,
+		chatURL:     chatURL,
+		apiKey:      apiKey,
+		model:       model,
+		temperature: configschema.Values(config.Config).Float("temperature", 0.7),
+		useMock:     useMock,
+		mock:        newMockReplies(),
+	}
+}
+
+// aiRequestTimeout es el timeout de las llamadas al proveedor, 30s por defecto
+func aiRequestTimeout(config MCPConfig) time.Duration {
+	if config.Timeout > 0 {
+		return config.Timeout
+	}
+	return 30 * time.Second
+}
+
+// newMockReplies devuelve las respuestas mock por defecto
+func newMockReplies() *mockReplies {
+	return &mockReplies{responses: []string{
 		"Hello! I'm an AI assistant ready to help you with your questions and tasks.",
 		"I understand your request. Let me provide you with a comprehensive response based on the information provided.",
 		"Thank you for your question. Here's my analysis and recommendations for your situation.",
 		"Based on the context you've provided, I can offer the following insights and suggestions.",
 		"I've processed your request and generated a response that should address your needs effectively.",
-	}
-	
-	// Configurar timeout
-	timeout := 30 * time.Second
-	if config.Timeout > 0 {
-		timeout = config.Timeout
-	}
-	
-	return &aiAgent{
-		baseAgent: base,
-		client: &http.Client{
-			Timeout: timeout,
-		},
-		apiKey:        apiKey,
-		model:         model,
-		baseURL:       baseURL,
-		useMock:       useMock,
-		mockResponses: mockResponses,
-		mockIndex:     0,
-	}, nil
+	}}
 }
 
 func (a *aiAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	start := time.Now()
 	
 	// Actualizar estado
-	a.mu.Lock()
-	a.state.Status = AgentStatusBusy
-	a.state.CurrentTask = &task
-	a.mu.Unlock()
+	a.setStatus(AgentStatusBusy, &task)
 	
 	defer func() {
-		a.mu.Lock()
-		a.state.Status = AgentStatusIdle
-		a.state.CurrentTask = nil
-		a.mu.Unlock()
+		a.setStatus(AgentStatusIdle, nil)
 	}()
 	
 	a.logger.Info("AI agent executing task", 
@@ -134,7 +172,7 @@ func (a *aiAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	var err error
 	
 	if a.useMock {
-		result, err = a.executeMockTask(task)
+		result, err = a.executeMockTask(ctx, task)
 	} else {
 		result, err = a.executeRealTask(ctx, task)
 	}
@@ -153,31 +191,22 @@ func (a *aiAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	return result, err
 }
 
-func (a *aiAgent) executeMockTask(task Task) (Result, error) {
+func (a *aiAgent) executeMockTask(ctx context.Context, task Task) (Result, error) {
 	// Simular procesamiento
-	time.Sleep(200 * time.Millisecond)
-	
-	// Obtener respuesta mock
-	response := a.mockResponses[a.mockIndex]
-	a.mockIndex = (a.mockIndex + 1) % len(a.mockResponses)
-	
-	// Personalizar respuesta basada en el input
-	if prompt, exists := task.Input["prompt"].(string); exists && prompt != "" {
-		if strings.Contains(strings.ToLower(prompt), "email") {
-			response = "Subject: Professional Response\n\nDear Customer,\n\nThank you for your inquiry. We appreciate your interest in our services and would be happy to provide you with the information you requested.\n\nBest regards,\nCustomer Service Team"
-		} else if strings.Contains(strings.ToLower(prompt), "summary") {
-			response = "Summary: Based on the provided information, the key points are: 1) Main topic identification, 2) Key insights extraction, 3) Actionable recommendations. This analysis provides a comprehensive overview of the subject matter."
-		} else if strings.Contains(strings.ToLower(prompt), "analysis") {
-			response = "Analysis Results: The data shows positive trends with several key indicators pointing toward successful outcomes. Recommendations include continued monitoring and strategic adjustments as needed."
-		}
+	select {
+	case <-time.After(200 * time.Millisecond):
+	case <-ctx.Done():
+		return Result{TaskID: task.ID, Success: false, Error: ctx.Err().Error()}, ctx.Err()
 	}
 	
+	response := a.mock.next(task)
+	
 	return Result{
 		TaskID:  task.ID,
 		Success: true,
 		Output: map[string]interface{}{
 			"text":         response,
-			"model":        a.model + "-mock",
+			"model":        a.currentModel() + "-mock",
 			"tokens_used":  len(response) / 4, // Rough token estimation
 			"finish_reason": "stop",
 		},
@@ -189,19 +218,45 @@ func (a *aiAgent) executeMockTask(task Task) (Result, error) {
 	}, nil
 }
 
-func (a *aiAgent) executeRealTask(ctx context.Context, task Task) (Result, error) {
+// next elige la respuesta mock, personalizada según el prompt
+func (m *mockReplies) next(task Task) string {
+	m.mu.Lock()
+	response := m.responses[m.index]
+	m.index = (m.index + 1) % len(m.responses)
+	m.mu.Unlock()
+	
+	// Personalizar respuesta basada en el input
+	if prompt, exists := task.Input["prompt"].(string); exists && prompt != "" {
+		if strings.Contains(strings.ToLower(prompt), "email") {
+			response = "Subject: Professional Response\n\nDear Customer,\n\nThank you for your inquiry. We appreciate your interest in our services and would be happy to provide you with the information you requested.\n\nBest regards,\nCustomer Service Team"
+		} else if strings.Contains(strings.ToLower(prompt), "summary") {
+			response = "Summary: Based on the provided information, the key points are: 1) Main topic identification, 2) Key insights extraction, 3) Actionable recommendations. This analysis provides a comprehensive overview of the subject matter."
+		} else if strings.Contains(strings.ToLower(prompt), "analysis") {
+			response = "Analysis Results: The data shows positive trends with several key indicators pointing toward successful outcomes. Recommendations include continued monitoring and strategic adjustments as needed."
+		}
+	}
+	
+	return response
+}
+
+// buildRequest construye el request de chat a partir del input de la tarea
+func (a *aiAgent) buildRequest(task Task) (openAIRequest, error) {
+	a.mu.RLock()
+	model, temperature := a.model, a.temperature
+	a.mu.RUnlock()
+	return chatRequest(task, model, temperature)
+}
+
+// chatRequest lee prompt, system, temperature y max_tokens del input de la
+// tarea; model y temperature son los del agente
+func chatRequest(task Task, model string, temperature float64) (openAIRequest, error) {
 	// Extraer prompt de la tarea
 	prompt, exists := task.Input["prompt"].(string)
 	if !exists || prompt == "" {
-		return Result{
-			TaskID:  task.ID,
-			Success: false,
-			Error:   "prompt is required for AI tasks",
-		}, fmt.Errorf("prompt is required")
+		return openAIRequest{}, fmt.Errorf("prompt is required")
 	}
 	
 	// Configurar parámetros
-	temperature := 0.7
 	if temp, exists := task.Input["temperature"].(float64); exists {
 		temperature = temp
 	}
@@ -209,6 +264,8 @@ func (a *aiAgent) executeRealTask(ctx context.Context, task Task) (Result, error
 	maxTokens := 1000
 	if tokens, exists := task.Input["max_tokens"].(float64); exists {
 		maxTokens = int(tokens)
+	} else if tokens, exists := task.Input["max_tokens"].(int); exists {
+		maxTokens = tokens
 	}
 	
 	// Preparar mensajes
@@ -227,104 +284,373 @@ func (a *aiAgent) executeRealTask(ctx context.Context, task Task) (Result, error
 		}}, messages...)
 	}
 	
-	// Crear request
-	reqBody := openAIRequest{
-		Model:       a.model,
+	return openAIRequest{
+		Model:       model,
 		Messages:    messages,
 		Temperature: temperature,
 		MaxTokens:   maxTokens,
-	}
-	
-	jsonBody, err := json.Marshal(reqBody)
+	}, nil
+}
+
+// executeRealTask llama al modelo y, si la tarea ofrece herramientas en
+// input.tools, ejecuta las que pida y le devuelve sus resultados hasta que
+// responda. Tras max_tool_iterations rondas se le exige la respuesta final.
+func (a *aiAgent) executeRealTask(ctx context.Context, task Task) (Result, error) {
+	reqBody, err := a.buildRequest(task)
 	if err != nil {
 		return Result{
 			TaskID:  task.ID,
 			Success: false,
-			Error:   fmt.Sprintf("failed to marshal request: %v", err),
+			Error:   "prompt is required for AI tasks",
 		}, err
 	}
-	
-	// Crear HTTP request
-	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/chat/completions", bytes.NewBuffer(jsonBody))
+
+	tools := a.offeredTools(ctx, task)
+	for _, tool := range tools.definitions {
+		reqBody.Tools = append(reqBody.Tools, openAITool{Type: "function", Function: tool})
+	}
+	maxIterations := configschema.Values(task.Input).Int("max_tool_iterations", DefaultMaxToolIterations)
+
+	var openAIResp openAIResponse
+	var calls []map[string]interface{}
+	promptTokens, completionTokens := 0, 0
+	for iteration := 0; ; iteration++ {
+		if len(reqBody.Tools) > 0 && iteration >= maxIterations {
+			reqBody.ToolChoice = "none"
+		}
+		openAIResp, err = a.chatCompletion(ctx, reqBody)
+		if err != nil {
+			return Result{
+				TaskID:  task.ID,
+				Success: false,
+				Error:   err.Error(),
+			}, err
+		}
+		promptTokens += openAIResp.Usage.PromptTokens
+		completionTokens += openAIResp.Usage.CompletionTokens
+		metrics.RecordAITokens(a.provider, openAIResp.Model, openAIResp.Usage.PromptTokens, openAIResp.Usage.CompletionTokens)
+
+		reply := openAIResp.Choices[0].Message
+		if len(reply.ToolCalls) == 0 || len(reqBody.Tools) == 0 {
+			break
+		}
+		if reqBody.ToolChoice == "none" {
+			err := fmt.Errorf("model kept calling tools after %d iterations", maxIterations)
+			return Result{TaskID: task.ID, Success: false, Error: err.Error()}, err
+		}
+
+		reqBody.Messages = append(reqBody.Messages, reply)
+		for _, call := range reply.ToolCalls {
+			content, record := a.runToolCall(ctx, tools, task, call)
+			calls = append(calls, record)
+			reqBody.Messages = append(reqBody.Messages, message{Role: "tool", ToolCallID: call.ID, Content: content})
+		}
+	}
+
+	// Extraer respuesta
+	choice := openAIResp.Choices[0]
+	output := map[string]interface{}{
+		"text":          choice.Message.Content,
+		"model":         openAIResp.Model,
+		"tokens_used":   promptTokens + completionTokens,
+		"finish_reason": choice.FinishReason,
+	}
+	if len(calls) > 0 {
+		output["tool_calls"] = calls
+	}
+
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output:  output,
+		Metadata: map[string]interface{}{
+			"agent_id":          a.id,
+			"agent_type":        a.agentType,
+			"mode":              "real",
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+		},
+	}, nil
+}
+
+// chatCompletion hace una llamada al endpoint de chat
+func (a *aiAgent) chatCompletion(ctx context.Context, reqBody openAIRequest) (openAIResponse, error) {
+	var openAIResp openAIResponse
+	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return Result{
-			TaskID:  task.ID,
-			Success: false,
-			Error:   fmt.Sprintf("failed to create request: %v", err),
-		}, err
+		return openAIResp, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", a.chatURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return openAIResp, fmt.Errorf("failed to create request: %w", err)
 	}
-	
-	// Agregar headers
 	req.Header.Set("Content-Type", "application/json")
+	a.authorize(req)
+
+	resp, err := a.httpClient().Do(req)
+	if err != nil {
+		return openAIResp, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return openAIResp, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return openAIResp, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		return openAIResp, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(openAIResp.Choices) == 0 {
+		return openAIResp, fmt.Errorf("no choices in API response")
+	}
+	return openAIResp, nil
+}
+
+// authorize añade la API key con la cabecera que espera el proveedor
+func (a *aiAgent) authorize(req *http.Request) {
+	if a.provider == aiProviderAzureOpenAI {
+		req.Header.Set("api-key", a.apiKey)
+		return
+	}
 	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+}
+
+// offeredToolSet son las herramientas ofrecidas al modelo en una tarea, con
+// los nombres válidos para la API asociados a su tipo de tarea
+type offeredToolSet struct {
+	runner      toolRunner
+	definitions []ToolDefinition
+	names       map[string]string
+}
+
+// offeredTools resuelve input.tools con el orquestador de la tarea. No se
+// ofrecen los tipos que atiende este agente, para que no se llame a sí mismo.
+func (a *aiAgent) offeredTools(ctx context.Context, task Task) offeredToolSet {
+	set := offeredToolSet{runner: toolRunnerFrom(ctx), names: make(map[string]string)}
+	requested := configschema.Values(task.Input).Strings("tools")
+	if set.runner == nil || len(requested) == 0 {
+		return set
+	}
+
+	allowed := make([]string, 0, len(requested))
+	for _, name := range requested {
+		if !a.CanHandle(name) {
+			allowed = append(allowed, name)
+		}
+	}
+	for _, definition := range set.runner.toolDefinitions(allowed) {
+		apiName := openAIToolName(definition.Name)
+		set.names[apiName] = definition.Name
+		definition.Name = apiName
+		set.definitions = append(set.definitions, definition)
+	}
+	return set
+}
+
+// openAIToolName adapta el nombre a los caracteres que admite la API
+func openAIToolName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	return name[:min(len(name), 64)]
+}
+
+// runToolCall ejecuta una llamada del modelo. Los errores se devuelven al
+// modelo como resultado para que pueda responder sin la herramienta.
+func (a *aiAgent) runToolCall(ctx context.Context, tools offeredToolSet, task Task, call openAIToolCall) (string, map[string]interface{}) {
+	start := time.Now()
+	name, ok := tools.names[call.Function.Name]
+	record := map[string]interface{}{"tool": call.Function.Name, "success": false}
+
+	var output map[string]interface{}
+	err := fmt.Errorf("unknown tool: %s", call.Function.Name)
+	if ok {
+		record["tool"] = name
+		arguments := map[string]interface{}{}
+		if call.Function.Arguments != "" {
+			err = json.Unmarshal([]byte(call.Function.Arguments), &arguments)
+		} else {
+			err = nil
+		}
+		if err == nil {
+			record["arguments"] = arguments
+			output, err = tools.runner.runTool(ctx, ToolCall{Name: name, Arguments: arguments, Task: task})
+		}
+	}
+	record["duration_ms"] = time.Since(start).Milliseconds()
+	// Los nombres que inventa el modelo no llegan a las etiquetas de las métricas
+	if ok {
+		metrics.RecordAIToolCall(name, err == nil)
+	} else {
+		metrics.RecordAIToolCall("unknown", false)
+	}
+
+	if err != nil {
+		a.logger.Warn("AI tool call failed", "agent_id", a.id, "task_id", task.ID, "tool", record["tool"], "error", err)
+		record["error"] = err.Error()
+		content, _ := json.Marshal(map[string]string{"error": err.Error()})
+		return string(content), record
+	}
+	record["success"] = true
+	content, marshalErr := json.Marshal(output)
+	if marshalErr != nil {
+		content, _ = json.Marshal(map[string]string{"error": marshalErr.Error()})
+	}
+	return string(content), record
+}
+
+// ExecuteStream ejecuta la tarea emitiendo la respuesta de forma incremental
+func (a *aiAgent) ExecuteStream(ctx context.Context, task Task) (<-chan StreamChunk, error) {
+	start := time.Now()
+	
+	var source <-chan StreamChunk
+	var err error
+	if a.useMock {
+		source = streamMockReply(ctx, a.mock.next(task))
+	} else {
+		source, err = a.streamRealTask(ctx, task)
+		if err != nil {
+			a.updateMetrics(false, time.Since(start))
+			return nil, err
+		}
+	}
+	
+	a.setStatus(AgentStatusBusy, &task)
+	
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		
+		success := false
+		defer func() {
+			a.setStatus(AgentStatusIdle, nil)
+			
+			a.updateMetrics(success, time.Since(start))
+		}()
+		
+		for chunk := range source {
+			if chunk.Done {
+				success = chunk.Error == ""
+				chunk.Metadata = map[string]interface{}{
+					"agent_id":   a.id,
+					"agent_type": a.agentType,
+					"model":      a.currentModel(),
+				}
+			}
+			
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	
+	return chunks, nil
+}
+
+// streamMockReply emite la respuesta mock palabra por palabra
+func streamMockReply(ctx context.Context, response string) <-chan StreamChunk {
+	chunks := make(chan StreamChunk)
+	
+	go func() {
+		defer close(chunks)
+		
+		for i, word := range strings.Fields(response) {
+			if i > 0 {
+				word = " " + word
+			}
+			
+			select {
+			case chunks <- StreamChunk{Content: word}:
+			case <-ctx.Done():
+				return
+			}
+			
+			// Simular la latencia entre tokens
+			time.Sleep(20 * time.Millisecond)
+		}
+		
+		select {
+		case chunks <- StreamChunk{Done: true, FinishReason: "stop"}:
+		case <-ctx.Done():
+		}
+	}()
 	
-	// Ejecutar request
-	resp, err := a.client.Do(req)
+	return chunks
+}
+
+// streamRealTask consume el endpoint de chat en modo stream
+func (a *aiAgent) streamRealTask(ctx context.Context, task Task) (<-chan StreamChunk, error) {
+	reqBody, err := a.buildRequest(task)
 	if err != nil {
-		return Result{
-			TaskID:  task.ID,
-			Success: false,
-			Error:   fmt.Sprintf("API request failed: %v", err),
-		}, err
+		return nil, err
 	}
-	defer resp.Body.Close()
+	reqBody.Stream = true
 	
-	// Leer respuesta
-	body, err := io.ReadAll(resp.Body)
+	jsonBody, err := json.Marshal(reqBody)
 	if err != nil {
-		return Result{
-			TaskID:  task.ID,
-			Success: false,
-			Error:   fmt.Sprintf("failed to read response: %v", err),
-		}, err
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 	
-	if resp.StatusCode != http.StatusOK {
-		return Result{
-			TaskID:  task.ID,
-			Success: false,
-			Error:   fmt.Sprintf("API error %d: %s", resp.StatusCode, string(body)),
-		}, fmt.Errorf("API error: %d", resp.StatusCode)
+	req, err := http.NewRequestWithContext(ctx, "POST", a.chatURL, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 	
-	// Parsear respuesta
-	var openAIResp openAIResponse
-	if err := json.Unmarshal(body, &openAIResp); err != nil {
-		return Result{
-			TaskID:  task.ID,
-			Success: false,
-			Error:   fmt.Sprintf("failed to parse response: %v", err),
-		}, err
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	a.authorize(req)
+	
+	// Sin timeout de cliente: el stream puede durar más que una respuesta normal
+	streamClient := &http.Client{Transport: a.httpClient().Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
 	}
 	
-	if len(openAIResp.Choices) == 0 {
-		return Result{
-			TaskID:  task.ID,
-			Success: false,
-			Error:   "no choices in API response",
-		}, fmt.Errorf("no choices in response")
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 	
-	// Extraer respuesta
-	choice := openAIResp.Choices[0]
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+		
+		finishReason, err := sse.ReadChat(resp.Body, func(content string) bool {
+			select {
+			case chunks <- StreamChunk{Content: content}:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}, nil)
+		if errors.Is(err, sse.ErrStopped) {
+			return
+		}
+		
+		final := StreamChunk{Done: true, FinishReason: finishReason}
+		if err != nil {
+			final.Error = err.Error()
+		}
+		
+		select {
+		case chunks <- final:
+		case <-ctx.Done():
+		}
+	}()
 	
-	return Result{
-		TaskID:  task.ID,
-		Success: true,
-		Output: map[string]interface{}{
-			"text":          choice.Message.Content,
-			"model":         openAIResp.Model,
-			"tokens_used":   openAIResp.Usage.TotalTokens,
-			"finish_reason": choice.FinishReason,
-		},
-		Metadata: map[string]interface{}{
-			"agent_id":         a.id,
-			"agent_type":       a.agentType,
-			"mode":             "real",
-			"prompt_tokens":    openAIResp.Usage.PromptTokens,
-			"completion_tokens": openAIResp.Usage.CompletionTokens,
-		},
-	}, nil
+	return chunks, nil
 }
 
 func (a *aiAgent) CanHandle(taskType string) bool {
@@ -346,4 +672,46 @@ func (a *aiAgent) CanHandle(taskType string) bool {
 	}
 	
 	return false
-}
\ No newline at end of file
+}
+// HotConfigFields devuelve los campos que se pueden cambiar sin reiniciar el agente
+func (a *aiAgent) HotConfigFields() []ConfigField {
+	return []ConfigField{
+		{Name: "model", Type: ConfigFieldString, Description: "AI model to use"},
+		{Name: "temperature", Type: ConfigFieldNumber, Min: configschema.Bound(0), Max: configschema.Bound(2), Description: "Default sampling temperature"},
+		{Name: "timeout", Type: ConfigFieldDuration, Min: configschema.Bound(1), Description: "Request timeout"},
+	}
+}
+
+// UpdateConfig cambia modelo, temperatura o timeout del agente en ejecución
+func (a *aiAgent) UpdateConfig(changes map[string]interface{}) (ConfigChange, error) {
+	return a.applyConfigUpdate(hotConfigUpdate{
+		fields: a.HotConfigFields(),
+		current: func() map[string]interface{} {
+			return map[string]interface{}{
+				"model":       a.model,
+				"temperature": a.temperature,
+				"timeout":     a.client.Timeout.String(),
+			}
+		},
+		apply: func(values configschema.Values) {
+			a.model = values.String("model", a.model)
+			a.temperature = values.Float("temperature", a.temperature)
+			if timeout := values.Duration("timeout", 0); timeout > 0 {
+				a.client = &http.Client{Timeout: timeout, Transport: a.client.Transport}
+			}
+		},
+	}, changes)
+}
+
+func (a *aiAgent) currentModel() string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.model
+}
+
+// httpClient devuelve el cliente vigente; UpdateConfig lo reemplaza al cambiar el timeout
+func (a *aiAgent) httpClient() *http.Client {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.client
+}
@@ -0,0 +1,92 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIProviders_ExecuteAgainstTheirAPIs(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	o := NewOrchestrator(NewAgentFactory(log), log)
+
+	azure := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/openai/deployments/chat-prod/chat/completions", r.URL.Path)
+		assert.Equal(t, defaultAzureAPIVersion, r.URL.Query().Get("api-version"))
+		assert.Equal(t, "azure-key", r.Header.Get("api-key"))
+		fmt.Fprint(w, `{"model": "gpt-4o", "choices": [{"message": {"role": "assistant", "content": "from azure"}, "finish_reason": "stop"}], "usage": {"prompt_tokens": 3, "completion_tokens": 2}}`)
+	}))
+	defer azure.Close()
+
+	var geminiRequests []geminiRequest
+	gemini := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request geminiRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		geminiRequests = append(geminiRequests, request)
+		if r.Header.Get("x-goog-api-key") != "" {
+			assert.Equal(t, "/v1beta/models/gemini-1.5-pro:generateContent", r.URL.Path)
+		} else {
+			assert.Equal(t, "/v1/projects/acme/locations/europe-west1/publishers/google/models/gemini-1.5-flash:generateContent", r.URL.Path)
+			assert.Equal(t, "Bearer vertex-token", r.Header.Get("Authorization"))
+		}
+		fmt.Fprint(w, `{"candidates": [{"content": {"role": "model", "parts": [{"text": "from "}, {"text": "gemini"}]}, "finishReason": "STOP"}], "usageMetadata": {"promptTokenCount": 4, "candidatesTokenCount": 6}}`)
+	}))
+	defer gemini.Close()
+
+	configs := []MCPConfig{
+		{Type: "azure_openai", Name: "azure", Config: map[string]interface{}{"endpoint": azure.URL + "/", "deployment": "chat-prod", "api_key": "azure-key"}},
+		{Type: "gemini", Name: "studio", Config: map[string]interface{}{"api_key": "studio-key", "model": "gemini-1.5-pro", "base_url": gemini.URL}},
+		{Type: "gemini", Name: "vertex", Config: map[string]interface{}{"vertex_project": "acme", "vertex_location": "europe-west1", "access_token": "vertex-token", "base_url": gemini.URL}},
+	}
+	expected := map[string]string{"azure": "from azure", "studio": "from gemini", "vertex": "from gemini"}
+	for _, config := range configs {
+		agent, err := o.InstantiateMCP(ctx, config)
+		require.NoError(t, err)
+
+		// Cada tarea va al agente pedido aunque los tres atiendan text_generation
+		result, err := o.ExecuteTask(ctx, Task{
+			ID:       config.Name,
+			Type:     "text_generation",
+			Input:    map[string]interface{}{"prompt": "Hi", "system": "Be brief"},
+			Metadata: map[string]interface{}{TaskAgentTypeKey: config.Type},
+		})
+		require.NoError(t, err, config.Name)
+		assert.Equal(t, expected[config.Name], result.Output["text"])
+		assert.Equal(t, agent.GetID(), result.Metadata["agent_id"])
+		require.NoError(t, o.TerminateAgent(ctx, agent.GetID()))
+	}
+
+	require.Len(t, geminiRequests, 2)
+	assert.Equal(t, "Be brief", geminiRequests[0].SystemInstruction.Parts[0].Text)
+	assert.Equal(t, []geminiContent{{Role: "user", Parts: []geminiPart{{Text: "Hi"}}}}, geminiRequests[0].Contents)
+
+	_, err := o.ExecuteTask(ctx, Task{ID: "none", Type: "text_generation", Input: map[string]interface{}{"prompt": "Hi"}, Metadata: map[string]interface{}{TaskAgentTypeKey: "gemini"}})
+	assert.ErrorIs(t, err, ErrNoAgentAvailable)
+}
+
+func TestAIProviders_ValidateConfig(t *testing.T) {
+	factory := NewAgentFactory(logger.NewLogger("error"))
+
+	invalid := map[string]MCPConfig{
+		"config.endpoint":     {Type: "azure_openai", Config: map[string]interface{}{"endpoint": "https://acme.openai.azure.com/openai/deployments/chat", "deployment": "chat"}},
+		"config.deployment":   {Type: "azure_openai", Config: map[string]interface{}{"endpoint": "https://acme.openai.azure.com"}},
+		"config.api_key":      {Type: "gemini", Config: map[string]interface{}{"model": "gemini-1.5-pro"}},
+		"config.access_token": {Type: "gemini", Config: map[string]interface{}{"vertex_project": "acme"}},
+	}
+	for field, config := range invalid {
+		config.Name = "invalid"
+		err := factory.ValidateConfig(config)
+		assert.ErrorContains(t, err, field)
+	}
+
+	assert.NoError(t, factory.ValidateConfig(MCPConfig{Name: "azure", Type: "azure_openai", Config: map[string]interface{}{"endpoint": "https://acme.openai.azure.com/", "deployment": "chat"}}))
+	assert.NoError(t, factory.ValidateConfig(MCPConfig{Name: "gemini", Type: "gemini", Config: map[string]interface{}{"api_key": ""}}))
+}
@@ -0,0 +1,101 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeChatEvents escribe eventos SSE de chat completions y los envía al cliente
+func writeChatEvents(w http.ResponseWriter, events ...string) {
+	for _, event := range events {
+		fmt.Fprintf(w, "data: %s\n\n", event)
+	}
+	w.(http.Flusher).Flush()
+}
+
+func TestExecuteTaskStream_CountsTaskWhenStreamEnds(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	finish := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-live", r.Header.Get("Authorization"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		writeChatEvents(w, `{"choices":[{"delta":{"content":"Hello"}}]}`)
+		<-finish
+		writeChatEvents(w, `{"choices":[{"delta":{"content":" world"},"finish_reason":"stop"}]}`, `[DONE]`)
+	}))
+	defer server.Close()
+
+	o := NewOrchestrator(NewAgentFactory(log), log)
+	agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "ai", Name: "stream", Config: map[string]interface{}{
+		"openai_api_key": "sk-live", "base_url": server.URL,
+	}})
+	require.NoError(t, err)
+	successes := metrics.MCPTasks.WithLabelValues("ai", metrics.StatusSuccess)
+	before := testutil.ToFloat64(successes)
+
+	chunks, err := o.ExecuteTaskStream(ctx, Task{ID: "stream-1", Type: "text_generation", Input: map[string]interface{}{"prompt": "Hi"}})
+	require.NoError(t, err)
+	assert.Equal(t, StreamChunk{Content: "Hello"}, <-chunks)
+
+	// Abrir el stream no cuenta la tarea: todavía puede fallar
+	assert.Equal(t, before, testutil.ToFloat64(successes))
+	close(finish)
+
+	var content string
+	var final StreamChunk
+	for chunk := range chunks {
+		content += chunk.Content
+		if chunk.Done {
+			final = chunk
+		}
+	}
+	assert.Equal(t, " world", content)
+	assert.Equal(t, "stop", final.FinishReason)
+	assert.Empty(t, final.Error)
+	assert.Equal(t, agent.GetID(), final.Metadata["agent_id"])
+	assert.Equal(t, before+1, testutil.ToFloat64(successes))
+	assert.Equal(t, 1, agent.GetState().Metrics.TasksCompleted)
+}
+
+func TestExecuteTaskStream_CountsBrokenStreamAsFailure(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		writeChatEvents(w, `{"choices":[{"delta":{"content":"partial"}}]}`)
+		// Cortar la conexión a mitad del stream
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	o := NewOrchestrator(NewAgentFactory(log), log)
+	_, err := o.InstantiateMCP(ctx, MCPConfig{Type: "ai", Name: "broken", Config: map[string]interface{}{
+		"openai_api_key": "sk-live", "base_url": server.URL,
+	}})
+	require.NoError(t, err)
+	successes := metrics.MCPTasks.WithLabelValues("ai", metrics.StatusSuccess)
+	failures := metrics.MCPTasks.WithLabelValues("ai", metrics.StatusFailure)
+	successesBefore, failuresBefore := testutil.ToFloat64(successes), testutil.ToFloat64(failures)
+
+	chunks, err := o.ExecuteTaskStream(ctx, Task{ID: "stream-2", Type: "text_generation", Input: map[string]interface{}{"prompt": "Hi"}})
+	require.NoError(t, err)
+	var final StreamChunk
+	for chunk := range chunks {
+		final = chunk
+	}
+	assert.True(t, final.Done)
+	assert.NotEmpty(t, final.Error)
+	assert.Equal(t, successesBefore, testutil.ToFloat64(successes))
+	assert.Equal(t, failuresBefore+1, testutil.ToFloat64(failures))
+}
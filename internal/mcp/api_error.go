@@ -0,0 +1,14 @@
+package mcp
+
+import "fmt"
+
+// APIError es una respuesta no exitosa de la API de un proveedor. Conserva el
+// código de estado para que quien reintenta lo distinga con errors.As.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.StatusCode, e.Body)
+}
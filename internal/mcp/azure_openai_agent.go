@@ -0,0 +1,43 @@
+package mcp
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// defaultAzureAPIVersion es la versión GA de la API de chat de Azure OpenAI
+const defaultAzureAPIVersion = "2024-06-01"
+
+// NewAzureOpenAIAgent crea un agente de IA sobre un deployment de Azure
+// OpenAI. La API es la de OpenAI salvo la URL, que nombra el deployment en vez
+// del modelo, y la cabecera api-key.
+func NewAzureOpenAIAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	values := configschema.Values(config.Config)
+	deployment := values.String("deployment", "")
+	chatURL := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		strings.TrimSuffix(values.String("endpoint", ""), "/"),
+		url.PathEscape(deployment),
+		url.QueryEscape(values.String("api_version", defaultAzureAPIVersion)))
+
+	// Azure ignora el modelo del body; se informa el del deployment
+	model := values.String("model", deployment)
+	return newAIAgent(config, logger, aiProviderAzureOpenAI, chatURL, values.String("api_key", ""), model), nil
+}
+
+// validateAzureOpenAIConfig exige el endpoint del recurso, no la URL completa
+// de un deployment, que es lo que suele copiarse del portal
+func validateAzureOpenAIConfig(config MCPConfig) error {
+	values := configschema.Values(config.Config)
+	result := &configschema.ValidationError{}
+	if endpoint, err := url.Parse(values.String("endpoint", "")); err == nil && strings.Trim(endpoint.Path, "/") != "" {
+		result.Add("config.endpoint", "must be the resource endpoint, e.g. https://<resource>.openai.azure.com")
+	}
+	if strings.Contains(values.String("deployment", ""), "/") {
+		result.Add("config.deployment", "must be a deployment name")
+	}
+	return result.Err()
+}
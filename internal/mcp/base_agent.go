@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -21,11 +22,16 @@ type baseAgent struct {
 	logger       logger.Logger
 	mu           sync.RWMutex
 	startTime    time.Time
+	transitions  []AgentStateTransition
+	errors       []AgentErrorRecord
 }
 
 // newBaseAgent crea una nueva instancia base de agente
 func newBaseAgent(config MCPConfig, logger logger.Logger) *baseAgent {
-	agentID := generateAgentID(config.Type, config.Name)
+	agentID := config.ID
+	if agentID == "" {
+		agentID = generateAgentID(config.Type, config.Name)
+	}
 	
 	return &baseAgent{
 		id:           agentID,
@@ -83,6 +89,7 @@ func (a *baseAgent) GetState() AgentState {
 	for k, v := range a.state.Context {
 		state.Context[k] = v
 	}
+	state.ConfigHistory = append([]ConfigChange(nil), a.state.ConfigHistory...)
 	
 	return state
 }
@@ -143,8 +150,7 @@ func (a *baseAgent) Start(ctx context.Context) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	
-	a.state.Status = AgentStatusIdle
-	a.state.LastActivity = time.Now()
+	a.setStatusLocked(AgentStatusIdle, nil)
 	a.startTime = time.Now()
 	
 	a.logger.Info("Agent started", 
@@ -160,9 +166,7 @@ func (a *baseAgent) Stop(ctx context.Context) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 	
-	a.state.Status = AgentStatusTerminated
-	a.state.LastActivity = time.Now()
-	a.state.CurrentTask = nil
+	a.setStatusLocked(AgentStatusTerminated, nil)
 	
 	a.logger.Info("Agent stopped", 
 		"agent_id", a.id,
@@ -225,8 +229,7 @@ func (a *baseAgent) updateMetrics(success bool, duration time.Duration) {
 
 // generateAgentID genera un ID único para el agente
 func generateAgentID(agentType, name string) string {
-	timestamp := time.Now().UnixNano()
-	return fmt.Sprintf("%s-%s-%d", agentType, name, timestamp)
+	return fmt.Sprintf("%s-%s-%s", agentType, name, id.New())
 }
 
 // getContextKeys obtiene las claves del contexto para logging
@@ -0,0 +1,221 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	// DefaultBreakerFailureRate es la fracción de fallos que abre el circuito
+	DefaultBreakerFailureRate = 0.5
+	// DefaultBreakerMinRequests es cuántas ejecuciones hacen falta para evaluarla
+	DefaultBreakerMinRequests = 5
+	// DefaultBreakerWindowSize es cuántas ejecuciones recientes se tienen en cuenta
+	DefaultBreakerWindowSize = 20
+	// DefaultBreakerOpenTimeout es cuánto queda abierto antes de probar de nuevo
+	DefaultBreakerOpenTimeout = 30 * time.Second
+)
+
+// BreakerState es el estado del circuito de un agente
+type BreakerState string
+
+const (
+	// BreakerClosed: el agente recibe tareas con normalidad
+	BreakerClosed BreakerState = "closed"
+	// BreakerOpen: el agente está en error y no recibe tareas
+	BreakerOpen BreakerState = "open"
+	// BreakerHalfOpen: la siguiente tarea del agente decide si se cierra o vuelve a abrirse
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// BreakerConfig ajusta los circuit breakers de los agentes; los valores a 0
+// usan los Default*
+type BreakerConfig struct {
+	// FailureRate es la fracción de fallos en la ventana que abre el circuito;
+	// un valor negativo desactiva los breakers
+	FailureRate float64
+	MinRequests int
+	WindowSize  int
+	OpenTimeout time.Duration
+}
+
+// BreakerStatus es el estado del circuito de un agente tal como se expone en la API
+type BreakerStatus struct {
+	State       BreakerState `json:"state"`
+	Requests    int          `json:"requests"`
+	FailureRate float64      `json:"failure_rate"`
+	OpenedAt    *time.Time   `json:"opened_at,omitempty"`
+	RetryAt     *time.Time   `json:"retry_at,omitempty"`
+}
+
+// circuitBreaker guarda el resultado de las últimas ejecuciones de un agente
+type circuitBreaker struct {
+	state    BreakerState
+	outcomes []bool // true si falló, de la más antigua a la más reciente
+	openedAt time.Time
+}
+
+func (b *circuitBreaker) failureRate() float64 {
+	if len(b.outcomes) == 0 {
+		return 0
+	}
+	failures := 0
+	for _, failed := range b.outcomes {
+		if failed {
+			failures++
+		}
+	}
+	return float64(failures) / float64(len(b.outcomes))
+}
+
+// breakerSet mantiene un circuito por agente. Se abre cuando la tasa de fallos
+// de las últimas ejecuciones supera FailureRate; pasado OpenTimeout queda
+// entreabierto y la siguiente ejecución lo cierra o lo vuelve a abrir.
+type breakerSet struct {
+	mu       sync.Mutex
+	config   BreakerConfig
+	breakers map[string]*circuitBreaker
+	clock    clock.Clock
+	logger   logger.Logger
+}
+
+func newBreakerSet(logger logger.Logger) *breakerSet {
+	set := &breakerSet{
+		breakers: make(map[string]*circuitBreaker),
+		clock:    clock.Real(),
+		logger:   logger,
+	}
+	set.configure(BreakerConfig{})
+	return set
+}
+
+func (s *breakerSet) configure(config BreakerConfig) {
+	if config.FailureRate == 0 {
+		config.FailureRate = DefaultBreakerFailureRate
+	}
+	if config.MinRequests <= 0 {
+		config.MinRequests = DefaultBreakerMinRequests
+	}
+	if config.WindowSize <= 0 {
+		config.WindowSize = DefaultBreakerWindowSize
+	}
+	config.WindowSize = max(config.WindowSize, config.MinRequests)
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = DefaultBreakerOpenTimeout
+	}
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+}
+
+// state devuelve el estado del circuito del agente; un circuito abierto pasa a
+// entreabierto cuando vence OpenTimeout
+func (s *breakerSet) state(agent Agent) BreakerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	breaker, ok := s.breakers[agent.GetID()]
+	if !ok {
+		return BreakerClosed
+	}
+	if breaker.state == BreakerOpen && s.clock.Since(breaker.openedAt) >= s.config.OpenTimeout {
+		s.transitionLocked(agent, breaker, BreakerHalfOpen)
+	}
+	return breaker.state
+}
+
+// record anota el resultado de una ejecución y devuelve el nuevo estado del
+// circuito y si ha cambiado
+func (s *breakerSet) record(agent Agent, failed bool) (BreakerState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.config.FailureRate < 0 {
+		return BreakerClosed, false
+	}
+	breaker, ok := s.breakers[agent.GetID()]
+	if !ok {
+		breaker = &circuitBreaker{state: BreakerClosed}
+		s.breakers[agent.GetID()] = breaker
+	}
+
+	switch breaker.state {
+	case BreakerHalfOpen:
+		if failed {
+			s.transitionLocked(agent, breaker, BreakerOpen)
+		} else {
+			s.transitionLocked(agent, breaker, BreakerClosed)
+		}
+		return breaker.state, true
+	case BreakerOpen:
+		// Ejecuciones que ya estaban en curso al abrirse el circuito
+		return breaker.state, false
+	}
+
+	breaker.outcomes = append(breaker.outcomes, failed)
+	if len(breaker.outcomes) > s.config.WindowSize {
+		breaker.outcomes = breaker.outcomes[len(breaker.outcomes)-s.config.WindowSize:]
+	}
+	if len(breaker.outcomes) >= s.config.MinRequests && breaker.failureRate() >= s.config.FailureRate {
+		s.transitionLocked(agent, breaker, BreakerOpen)
+		return breaker.state, true
+	}
+	return breaker.state, false
+}
+
+func (s *breakerSet) transitionLocked(agent Agent, breaker *circuitBreaker, state BreakerState) {
+	previous := breaker.state
+	breaker.state = state
+	switch state {
+	case BreakerOpen:
+		breaker.openedAt = s.clock.Now()
+	case BreakerClosed:
+		// Al cerrarse se empieza de cero para no reabrir por fallos antiguos
+		breaker.outcomes = nil
+	}
+
+	metrics.SetMCPCircuitState(agent.GetType(), agent.GetID(), string(state))
+	s.logger.Warn("MCP agent circuit breaker changed state",
+		"agent_id", agent.GetID(),
+		"agent_type", agent.GetType(),
+		"from", previous,
+		"to", state,
+		"failure_rate", breaker.failureRate())
+}
+
+// status devuelve el estado del circuito del agente para la API
+func (s *breakerSet) status(agent Agent) BreakerStatus {
+	state := s.state(agent)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	status := BreakerStatus{State: state}
+	breaker, ok := s.breakers[agent.GetID()]
+	if !ok {
+		return status
+	}
+	status.Requests = len(breaker.outcomes)
+	status.FailureRate = breaker.failureRate()
+	if state != BreakerClosed {
+		openedAt := breaker.openedAt
+		status.OpenedAt = &openedAt
+	}
+	if state == BreakerOpen {
+		retryAt := breaker.openedAt.Add(s.config.OpenTimeout)
+		status.RetryAt = &retryAt
+	}
+	return status
+}
+
+// forget descarta el circuito de un agente terminado
+func (s *breakerSet) forget(agent Agent) {
+	s.mu.Lock()
+	delete(s.breakers, agent.GetID())
+	s.mu.Unlock()
+	metrics.DeleteMCPCircuitState(agent.GetType(), agent.GetID())
+}
@@ -0,0 +1,70 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCircuitBreaker_EjectsFailingAgentAndProbesIt(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	o := NewOrchestrator(NewAgentFactory(log), log).(*orchestrator)
+	fake := clock.NewFake(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	o.breakers.clock = fake
+	o.ConfigureBreakers(BreakerConfig{FailureRate: 0.5, MinRequests: 3, OpenTimeout: time.Minute})
+
+	agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "mock", Name: "flaky", Config: map[string]interface{}{
+		"failure_rate":           1.0,
+		"min_processing_time_ms": 1,
+		"max_processing_time_ms": 1,
+	}})
+	require.NoError(t, err)
+	mock := unwrapAgent(agent).(*mockAgent)
+	task := Task{ID: "task", Type: "mock"}
+
+	for i := 0; i < 3; i++ {
+		_, err := o.ExecuteTask(ctx, task)
+		require.Error(t, err)
+	}
+	status, err := o.GetBreakerStatus(agent.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, BreakerOpen, status.State)
+	assert.Equal(t, AgentStatusError, agent.GetState().Status)
+	assert.False(t, agent.IsHealthy())
+
+	// Con el circuito abierto no se envían tareas al agente
+	_, err = o.ExecuteTask(ctx, task)
+	assert.ErrorIs(t, err, ErrNoAgentAvailable)
+	assert.Equal(t, 3, agent.GetState().Metrics.TasksFailed)
+
+	// Pasado el tiempo de espera una prueba fallida lo vuelve a abrir
+	fake.Advance(time.Minute)
+	status, _ = o.GetBreakerStatus(agent.GetID())
+	assert.Equal(t, BreakerHalfOpen, status.State)
+	_, err = o.ExecuteTask(ctx, task)
+	require.Error(t, err)
+	status, _ = o.GetBreakerStatus(agent.GetID())
+	assert.Equal(t, BreakerOpen, status.State)
+	assert.Equal(t, AgentStatusError, agent.GetState().Status)
+
+	// Y una prueba correcta lo cierra
+	fake.Advance(time.Minute)
+	mock.config.Config["failure_rate"] = 0.0
+	result, err := o.ExecuteTask(ctx, task)
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	status, _ = o.GetBreakerStatus(agent.GetID())
+	assert.Equal(t, BreakerClosed, status.State)
+	assert.Zero(t, status.Requests)
+	assert.True(t, agent.IsHealthy())
+
+	metrics, err := o.GetAgentMetricsDomain(agent.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, string(BreakerClosed), metrics.CircuitState)
+}
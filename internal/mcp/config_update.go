@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/company/bot-service/pkg/configschema"
+)
+
+// maxConfigHistory limita los cambios de configuración guardados en el estado
+const maxConfigHistory = 20
+
+// ErrConfigUpdateNotSupported indica que el tipo de agente no admite cambios en caliente
+var ErrConfigUpdateNotSupported = errors.New("agent does not support config updates")
+
+// ConfigChange registra una actualización de configuración aplicada en caliente
+type ConfigChange struct {
+	Fields    []string               `json:"fields"`
+	Previous  map[string]interface{} `json:"previous"`
+	Current   map[string]interface{} `json:"current"`
+	ChangedAt time.Time              `json:"changed_at"`
+}
+
+// ConfigurableAgent es implementado por los agentes que aceptan cambios de
+// configuración sin reiniciarse. HotConfigFields declara qué campos se pueden
+// modificar; el resto requiere crear un agente nuevo.
+type ConfigurableAgent interface {
+	Agent
+	HotConfigFields() []ConfigField
+	UpdateConfig(changes map[string]interface{}) (ConfigChange, error)
+}
+
+// hotConfigUpdate describe cómo leer y aplicar la configuración modificable
+// de un agente. Ambas funciones se ejecutan con el lock del agente tomado.
+type hotConfigUpdate struct {
+	fields  []ConfigField
+	current func() map[string]interface{}
+	apply   func(values configschema.Values)
+}
+
+// applyConfigUpdate valida los cambios, los aplica y los agrega al historial
+func (a *baseAgent) applyConfigUpdate(update hotConfigUpdate, changes map[string]interface{}) (ConfigChange, error) {
+	if err := validateConfigChanges(update.fields, changes); err != nil {
+		return ConfigChange{}, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.state.Status == AgentStatusTerminated {
+		return ConfigChange{}, fmt.Errorf("agent %s is terminated", a.id)
+	}
+
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	before := update.current()
+	update.apply(configschema.Values(changes))
+	after := update.current()
+
+	change := ConfigChange{
+		Fields:    fields,
+		Previous:  make(map[string]interface{}, len(fields)),
+		Current:   make(map[string]interface{}, len(fields)),
+		ChangedAt: time.Now(),
+	}
+	for _, field := range fields {
+		change.Previous[field] = before[field]
+		change.Current[field] = after[field]
+	}
+
+	a.state.ConfigHistory = append(a.state.ConfigHistory, change)
+	if len(a.state.ConfigHistory) > maxConfigHistory {
+		a.state.ConfigHistory = a.state.ConfigHistory[len(a.state.ConfigHistory)-maxConfigHistory:]
+	}
+	a.state.LastActivity = change.ChangedAt
+
+	a.logger.Info("Agent config updated",
+		"agent_id", a.id,
+		"fields", fields)
+
+	return change, nil
+}
+
+// validateConfigChanges rechaza campos no modificables en caliente y valida
+// el resto contra su esquema
+func validateConfigChanges(fields []ConfigField, changes map[string]interface{}) error {
+	result := &configschema.ValidationError{}
+	if len(changes) == 0 {
+		result.Add("config", "no changes provided")
+		return result
+	}
+
+	allowed := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		allowed[field.Name] = true
+	}
+	unknown := make([]string, 0)
+	for name := range changes {
+		if !allowed[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		result.Add(name, "cannot be updated on a running agent")
+	}
+
+	if err := configschema.Validate(fields, changes); err != nil {
+		if fieldErrs, ok := err.(*configschema.ValidationError); ok {
+			result.Errors = append(result.Errors, fieldErrs.Errors...)
+		}
+	}
+	return result.Err()
+}
@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateAgentConfig_ReachesRunningHTTPAgent(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+
+	var mu sync.Mutex
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.Header.Clone())
+		mu.Unlock()
+		fmt.Fprint(w, `{"ok": true}`)
+	}))
+	defer server.Close()
+
+	o := NewOrchestrator(NewAgentFactory(log), log)
+	agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "http", Name: "api", Config: map[string]interface{}{
+		"base_url": server.URL,
+		"headers":  map[string]interface{}{"X-Tenant": "old", "X-Debug": "1"},
+	}})
+	require.NoError(t, err)
+
+	call := func(id string) http.Header {
+		_, err := o.ExecuteTask(ctx, Task{ID: id, Type: "http_request", Input: map[string]interface{}{"method": "GET", "endpoint": "/ping"}})
+		require.NoError(t, err)
+		mu.Lock()
+		defer mu.Unlock()
+		return received[len(received)-1]
+	}
+	assert.Equal(t, "old", call("before").Get("X-Tenant"))
+
+	change, err := o.UpdateAgentConfig(ctx, agent.GetID(), map[string]interface{}{
+		"headers": map[string]interface{}{"X-Tenant": "new", "X-Debug": ""},
+		"timeout": "5s",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"headers", "timeout"}, change.Fields)
+	assert.Equal(t, "30s", change.Previous["timeout"])
+	assert.Equal(t, "5s", change.Current["timeout"])
+
+	// La petición siguiente usa los headers nuevos sin reiniciar el agente
+	headers := call("after")
+	assert.Equal(t, "new", headers.Get("X-Tenant"))
+	assert.Empty(t, headers.Get("X-Debug"))
+	assert.Equal(t, AgentStatusIdle, agent.GetState().Status)
+	assert.Equal(t, 5*time.Second, unwrapAgent(agent).(*httpAgent).client.Timeout)
+
+	history := agent.GetState().ConfigHistory
+	require.Len(t, history, 1)
+	assert.Equal(t, change.Fields, history[0].Fields)
+}
+
+func TestUpdateAgentConfig_ReachesRunningAIAgent(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+
+	var mu sync.Mutex
+	var models []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		models = append(models, req.Model)
+		mu.Unlock()
+		fmt.Fprintf(w, `{"model": %q, "choices": [{"message": {"role": "assistant", "content": "hi"}, "finish_reason": "stop"}], "usage": {"prompt_tokens": 1, "completion_tokens": 1}}`, req.Model)
+	}))
+	defer server.Close()
+
+	o := NewOrchestrator(NewAgentFactory(log), log)
+	agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "ai", Name: "assistant", Config: map[string]interface{}{
+		"openai_api_key": "key", "base_url": server.URL, "model": "gpt-old",
+	}})
+	require.NoError(t, err)
+
+	task := Task{Type: "text_generation", Input: map[string]interface{}{"prompt": "hello"}}
+	task.ID = "before"
+	_, err = o.ExecuteTask(ctx, task)
+	require.NoError(t, err)
+
+	_, err = o.UpdateAgentConfig(ctx, agent.GetID(), map[string]interface{}{"model": "gpt-new", "temperature": 0.2})
+	require.NoError(t, err)
+
+	task.ID = "after"
+	_, err = o.ExecuteTask(ctx, task)
+	require.NoError(t, err)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"gpt-old", "gpt-new"}, models)
+}
+
+func TestUpdateAgentConfig_RejectsInvalidChanges(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	o := NewOrchestrator(NewAgentFactory(log), log)
+	agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "http", Name: "api", Config: map[string]interface{}{
+		"base_url": "http://127.0.0.1:1",
+		"headers":  map[string]interface{}{"X-Tenant": "old"},
+	}})
+	require.NoError(t, err)
+
+	for name, changes := range map[string]map[string]interface{}{
+		"empty":        {},
+		"not hot":      {"base_url": "http://127.0.0.1:2"},
+		"wrong type":   {"headers": "X-Tenant"},
+		"bad duration": {"timeout": "soon"},
+		"below min":    {"timeout": "0s"},
+		"mixed":        {"headers": map[string]interface{}{"X-Tenant": "new"}, "base_url": "http://127.0.0.1:2"},
+	} {
+		_, err := o.UpdateAgentConfig(ctx, agent.GetID(), changes)
+		assert.Error(t, err, name)
+	}
+
+	// Un cambio rechazado no toca la configuración ni el historial
+	http := unwrapAgent(agent).(*httpAgent)
+	assert.Equal(t, "old", http.headers["X-Tenant"])
+	assert.Equal(t, "http://127.0.0.1:1", http.baseURL)
+	assert.Empty(t, agent.GetState().ConfigHistory)
+
+	_, err = o.UpdateAgentConfig(ctx, "missing", map[string]interface{}{"timeout": "5s"})
+	assert.Error(t, err)
+
+	mock, err := o.InstantiateMCP(ctx, MCPConfig{Type: "mock", Name: "mock"})
+	require.NoError(t, err)
+	_, err = o.UpdateAgentConfig(ctx, mock.GetID(), map[string]interface{}{"failure_rate": 0.5})
+	assert.ErrorIs(t, err, ErrConfigUpdateNotSupported)
+
+	// Un agente detenido ya no acepta cambios
+	require.NoError(t, agent.Stop(ctx))
+	_, err = o.UpdateAgentConfig(ctx, agent.GetID(), map[string]interface{}{"timeout": "5s"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is terminated")
+}
@@ -0,0 +1,285 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/configschema"
+)
+
+// CoordinationStrategy es cómo CoordinateAgents reparte la tarea entre agentes
+type CoordinationStrategy string
+
+const (
+	// CoordinationFirst ejecuta la tarea en el primer agente disponible
+	CoordinationFirst CoordinationStrategy = "first"
+	// CoordinationFanOut la ejecuta en todos a la vez y combina las salidas
+	CoordinationFanOut CoordinationStrategy = "fan_out"
+	// CoordinationPipeline la ejecuta en orden: la salida de cada agente es la
+	// entrada del siguiente
+	CoordinationPipeline CoordinationStrategy = "pipeline"
+	// CoordinationVote la ejecuta en todos y devuelve la respuesta en la que
+	// coinciden suficientes agentes
+	CoordinationVote CoordinationStrategy = "vote"
+)
+
+// coordinationKey es la clave de Task.Metadata con la CoordinationConfig
+const coordinationKey = "coordination"
+
+var (
+	// ErrInvalidCoordination indica una configuración de coordinación no válida
+	ErrInvalidCoordination = errors.New("invalid coordination config")
+	// ErrNoConsensus indica que en una votación ninguna respuesta alcanzó el quórum
+	ErrNoConsensus = errors.New("agents did not reach consensus")
+)
+
+// CoordinationConfig se lee de task.Metadata["coordination"]
+type CoordinationConfig struct {
+	Strategy CoordinationStrategy
+	// StageTimeout limita cada ejecución de un agente; 0 solo usa el contexto
+	StageTimeout time.Duration
+	// MinSuccesses es cuántos agentes deben acertar en fan_out (1 por defecto)
+	MinSuccesses int
+	// Quorum es la fracción de agentes que debe coincidir en vote; 0 exige mayoría
+	Quorum float64
+	// VoteField es la clave de la salida que se compara en vote (text por defecto)
+	VoteField string
+}
+
+// CoordinationStage es el resultado de un agente dentro de una coordinación
+type CoordinationStage struct {
+	AgentID  string                 `json:"agent_id"`
+	Success  bool                   `json:"success"`
+	Output   map[string]interface{} `json:"output,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	Duration time.Duration          `json:"duration"`
+}
+
+// ParseCoordinationConfig lee la configuración de coordinación de la tarea
+func ParseCoordinationConfig(task Task) (CoordinationConfig, error) {
+	raw, _ := task.Metadata[coordinationKey].(map[string]interface{})
+	values := configschema.Values(raw)
+	config := CoordinationConfig{
+		Strategy:     CoordinationStrategy(values.String("strategy", string(CoordinationFirst))),
+		StageTimeout: time.Duration(values.Int("stage_timeout_ms", 0)) * time.Millisecond,
+		MinSuccesses: values.Int("min_successes", 1),
+		Quorum:       values.Float("quorum", 0),
+		VoteField:    values.String("vote_field", "text"),
+	}
+	switch config.Strategy {
+	case CoordinationFirst, CoordinationFanOut, CoordinationPipeline, CoordinationVote:
+	default:
+		return config, fmt.Errorf("%w: unknown strategy %s", ErrInvalidCoordination, config.Strategy)
+	}
+	if config.Quorum < 0 || config.Quorum > 1 {
+		return config, fmt.Errorf("%w: quorum must be between 0 and 1", ErrInvalidCoordination)
+	}
+	config.MinSuccesses = max(config.MinSuccesses, 1)
+	return config, nil
+}
+
+// runStage ejecuta la tarea en un agente con el timeout de etapa
+func (o *orchestrator) runStage(ctx context.Context, agent Agent, task Task, config CoordinationConfig) CoordinationStage {
+	if config.StageTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.StageTimeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result, err := executeOnAgent(ctx, agent, task)
+	o.recordOutcome(agent, err, result.Success)
+	stage := CoordinationStage{
+		AgentID:  agent.GetID(),
+		Success:  err == nil && result.Success,
+		Output:   result.Output,
+		Duration: time.Since(start),
+	}
+	metrics.ObserveMCPTask(agent.GetType(), stage.Duration, stage.Success)
+	if !stage.Success {
+		stage.Error = result.Error
+		if err != nil {
+			stage.Error = err.Error()
+		}
+		if stage.Error == "" {
+			stage.Error = "task failed"
+		}
+	}
+	return stage
+}
+
+// runAll ejecuta la tarea en todos los agentes a la vez; los resultados
+// conservan el orden de agents y terminan con los agentes no disponibles
+func (o *orchestrator) runAll(ctx context.Context, agents []Agent, unavailable []CoordinationStage, task Task, config CoordinationConfig) []CoordinationStage {
+	stages := make([]CoordinationStage, len(agents), len(agents)+len(unavailable))
+	var wg sync.WaitGroup
+	for i, agent := range agents {
+		wg.Add(1)
+		go func(i int, agent Agent) {
+			defer wg.Done()
+			stages[i] = o.runStage(ctx, agent, task, config)
+		}(i, agent)
+	}
+	wg.Wait()
+	return append(stages, unavailable...)
+}
+
+// coordinateFanOut combina las salidas de los agentes que acertaron; si dos
+// agentes devuelven la misma clave gana el primero de la lista
+func (o *orchestrator) coordinateFanOut(ctx context.Context, agents []Agent, unavailable []CoordinationStage, task Task, config CoordinationConfig) (Result, error) {
+	stages := o.runAll(ctx, agents, unavailable, task, config)
+
+	merged := make(map[string]interface{})
+	succeeded := 0
+	var failures []string
+	for _, stage := range stages {
+		if !stage.Success {
+			failures = append(failures, stage.AgentID+": "+stage.Error)
+			continue
+		}
+		succeeded++
+		for key, value := range stage.Output {
+			if _, exists := merged[key]; !exists {
+				merged[key] = value
+			}
+		}
+	}
+	merged["agents"] = stages
+
+	result := coordinationResult(task, config, stages, merged)
+	if succeeded < config.MinSuccesses {
+		result.Error = fmt.Sprintf("%d of %d agents succeeded, %d required: %s",
+			succeeded, len(stages), config.MinSuccesses, strings.Join(failures, "; "))
+		return result, errors.New(result.Error)
+	}
+	result.Success = true
+	result.Metadata["partial"] = succeeded < len(stages)
+	return result, nil
+}
+
+// coordinatePipeline pasa la salida de cada agente, sobre la entrada original,
+// al siguiente. Se detiene en la primera etapa que falla.
+func (o *orchestrator) coordinatePipeline(ctx context.Context, agents []Agent, task Task, config CoordinationConfig) (Result, error) {
+	stages := make([]CoordinationStage, 0, len(agents))
+	input := task.Input
+	var output map[string]interface{}
+	for i, agent := range agents {
+		stageTask := task
+		stageTask.Input = input
+		stage := o.runStage(ctx, agent, stageTask, config)
+		stages = append(stages, stage)
+		if !stage.Success {
+			result := coordinationResult(task, config, stages, map[string]interface{}{"stages": stages})
+			result.Error = fmt.Sprintf("pipeline stage %d (%s) failed: %s", i+1, agent.GetID(), stage.Error)
+			return result, errors.New(result.Error)
+		}
+
+		output = stage.Output
+		next := make(map[string]interface{}, len(task.Input)+len(output))
+		for key, value := range task.Input {
+			next[key] = value
+		}
+		for key, value := range output {
+			next[key] = value
+		}
+		input = next
+	}
+
+	merged := make(map[string]interface{}, len(output)+1)
+	for key, value := range output {
+		merged[key] = value
+	}
+	merged["stages"] = stages
+	result := coordinationResult(task, config, stages, merged)
+	result.Success = true
+	return result, nil
+}
+
+// coordinateVote devuelve la salida del primer agente que dio la respuesta más
+// votada. Los agentes que fallan cuentan como abstenciones.
+func (o *orchestrator) coordinateVote(ctx context.Context, agents []Agent, unavailable []CoordinationStage, task Task, config CoordinationConfig) (Result, error) {
+	stages := o.runAll(ctx, agents, unavailable, task, config)
+
+	votes := make(map[string]int)
+	labels := make(map[string]string)
+	first := make(map[string]int)
+	for i, stage := range stages {
+		value, ok := stage.Output[config.VoteField]
+		if !stage.Success || !ok {
+			continue
+		}
+		label := fmt.Sprint(value)
+		key := strings.ToLower(strings.TrimSpace(label))
+		if _, seen := votes[key]; !seen {
+			labels[key] = label
+			first[key] = i
+		}
+		votes[key]++
+	}
+
+	// Un empate entre las más votadas impide el consenso
+	winner, best, tie := "", 0, false
+	tally := make(map[string]int, len(votes))
+	for key, count := range votes {
+		tally[labels[key]] = count
+		switch {
+		case count > best:
+			winner, best, tie = key, count, false
+		case count == best:
+			tie = true
+		}
+	}
+
+	required := len(stages)/2 + 1
+	if config.Quorum > 0 {
+		required = int(math.Ceil(config.Quorum * float64(len(stages))))
+	}
+	output := map[string]interface{}{"votes": tally, "agents": stages}
+	if tie || best == 0 || best < required {
+		err := fmt.Errorf("%w: best answer has %d of %d votes, %d required", ErrNoConsensus, best, len(stages), required)
+		result := coordinationResult(task, config, stages, output)
+		result.Error = err.Error()
+		return result, err
+	}
+
+	for key, value := range stages[first[winner]].Output {
+		output[key] = value
+	}
+	output["agreement"] = float64(best) / float64(len(stages))
+	result := coordinationResult(task, config, stages, output)
+	result.Success = true
+	return result, nil
+}
+
+// coordinationResult arma el resultado común a todas las estrategias
+func coordinationResult(task Task, config CoordinationConfig, stages []CoordinationStage, output map[string]interface{}) Result {
+	succeeded := 0
+	var duration time.Duration
+	for _, stage := range stages {
+		if stage.Success {
+			succeeded++
+		}
+		if config.Strategy == CoordinationPipeline {
+			duration += stage.Duration
+		} else {
+			duration = max(duration, stage.Duration)
+		}
+	}
+	return Result{
+		TaskID:   task.ID,
+		Output:   output,
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"strategy":  string(config.Strategy),
+			"agents":    len(stages),
+			"succeeded": succeeded,
+			"failed":    len(stages) - succeeded,
+		},
+	}
+}
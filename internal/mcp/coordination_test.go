@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAgent devuelve lo que calcule run con la entrada de la tarea
+type stubAgent struct {
+	*baseAgent
+	run func(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error)
+}
+
+func (a *stubAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	output, err := a.run(ctx, task.Input)
+	if err != nil {
+		return Result{TaskID: task.ID, Success: false, Error: err.Error()}, err
+	}
+	return Result{TaskID: task.ID, Success: true, Output: output}, nil
+}
+
+func (a *stubAgent) CanHandle(taskType string) bool { return true }
+
+func newStubAgent(name string, run func(ctx context.Context, input map[string]interface{}) (map[string]interface{}, error)) Agent {
+	return &stubAgent{baseAgent: newBaseAgent(MCPConfig{Type: "stub", Name: name}, logger.NewLogger("error")), run: run}
+}
+
+func answer(text string) Agent {
+	return newStubAgent(text, func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"text": text}, nil
+	})
+}
+
+func coordinationTask(config map[string]interface{}) Task {
+	return Task{ID: "task", Type: "analysis", Input: map[string]interface{}{"value": 1.0}, Metadata: map[string]interface{}{"coordination": config}}
+}
+
+func TestCoordinateAgents_Strategies(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	o := NewOrchestrator(NewAgentFactory(log), log).(*orchestrator)
+
+	failing := newStubAgent("failing", func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+		return nil, errors.New("provider outage")
+	})
+	slow := newStubAgent("slow", func(ctx context.Context, _ map[string]interface{}) (map[string]interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+	double := newStubAgent("double", func(_ context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+		return map[string]interface{}{"value": input["value"].(float64) * 2}, nil
+	})
+
+	t.Run("fan_out tolerates partial failures", func(t *testing.T) {
+		agents := []Agent{answer("yes"), failing, slow, newStubAgent("extra", func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"text": "ignored", "extra": true}, nil
+		})}
+		result, err := o.CoordinateAgents(ctx, agents, coordinationTask(map[string]interface{}{"strategy": "fan_out", "stage_timeout_ms": 20}))
+		require.NoError(t, err)
+		assert.True(t, result.Success)
+		assert.Equal(t, "yes", result.Output["text"], "the first agent wins on conflicting keys")
+		assert.Equal(t, true, result.Output["extra"])
+		assert.Equal(t, 2, result.Metadata["failed"])
+		assert.Equal(t, true, result.Metadata["partial"])
+
+		_, err = o.CoordinateAgents(ctx, agents, coordinationTask(map[string]interface{}{"strategy": "fan_out", "stage_timeout_ms": 20, "min_successes": 3}))
+		assert.Error(t, err)
+	})
+
+	t.Run("pipeline feeds each output to the next stage", func(t *testing.T) {
+		result, err := o.CoordinateAgents(ctx, []Agent{double, double, double}, coordinationTask(map[string]interface{}{"strategy": "pipeline"}))
+		require.NoError(t, err)
+		assert.Equal(t, 8.0, result.Output["value"])
+		assert.Len(t, result.Output["stages"], 3)
+
+		result, err = o.CoordinateAgents(ctx, []Agent{double, failing, double}, coordinationTask(map[string]interface{}{"strategy": "pipeline"}))
+		require.Error(t, err)
+		assert.False(t, result.Success)
+		assert.Contains(t, result.Error, "pipeline stage 2")
+		assert.Len(t, result.Output["stages"], 2, "later stages do not run")
+	})
+
+	t.Run("vote needs a quorum", func(t *testing.T) {
+		agents := []Agent{answer("Paris"), answer(" paris "), answer("Lyon"), failing}
+		_, err := o.CoordinateAgents(ctx, agents, coordinationTask(map[string]interface{}{"strategy": "vote"}))
+		assert.ErrorIs(t, err, ErrNoConsensus, "2 of 4 votes is not a majority")
+
+		result, err := o.CoordinateAgents(ctx, agents, coordinationTask(map[string]interface{}{"strategy": "vote", "quorum": 0.5}))
+		require.NoError(t, err)
+		assert.Equal(t, "Paris", result.Output["text"])
+		assert.Equal(t, 0.5, result.Output["agreement"])
+		assert.Equal(t, map[string]int{"Paris": 2, "Lyon": 1}, result.Output["votes"])
+	})
+
+	_, err := o.CoordinateAgents(ctx, []Agent{double}, coordinationTask(map[string]interface{}{"strategy": "round_robin"}))
+	assert.ErrorIs(t, err, ErrInvalidCoordination)
+
+	start := time.Now()
+	result, err := o.CoordinateAgents(ctx, []Agent{slow, double}, coordinationTask(map[string]interface{}{"strategy": "fan_out", "stage_timeout_ms": 20}))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, 2.0, result.Output["value"])
+}
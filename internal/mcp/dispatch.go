@@ -0,0 +1,239 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// DispatchPause describe una pausa del despacho de tareas
+type DispatchPause struct {
+	Reason   string    `json:"reason,omitempty"`
+	PausedAt time.Time `json:"paused_at"`
+}
+
+// DispatchStatus es el estado del despacho: pausas activas, tareas retenidas
+// por las pausas (Queued) y tareas esperando a que se libere un agente (Waiting)
+type DispatchStatus struct {
+	Global       *DispatchPause           `json:"global,omitempty"`
+	PausedTypes  map[string]DispatchPause `json:"paused_types"`
+	Queued       int                      `json:"queued"`
+	QueuedTypes  map[string]int           `json:"queued_types"`
+	Draining     bool                     `json:"draining"`
+	DrainRate    float64                  `json:"drain_rate,omitempty"` // tareas por segundo, 0 sin límite
+	Waiting      int                      `json:"waiting"`
+	WaitingTypes map[string]int           `json:"waiting_types"`
+}
+
+// queuedDispatch es una tarea esperando a que se reanude el despacho
+type queuedDispatch struct {
+	taskID   string
+	taskType string
+	ready    chan struct{}
+}
+
+// dispatchControl pausa el despacho global o por tipo de agente. Mientras está
+// pausado las tareas esperan en cola; al reanudar se liberan a drainRate por segundo.
+type dispatchControl struct {
+	stateMu     sync.RWMutex
+	global      *DispatchPause
+	pausedTypes map[string]DispatchPause
+
+	queueMu   sync.Mutex
+	queue     []*queuedDispatch
+	draining  bool
+	drainRate float64
+
+	// blocked indica si una tarea del tipo dado no tiene ningún agente disponible por las pausas
+	blocked func(taskType string) bool
+	logger  logger.Logger
+}
+
+func newDispatchControl(blocked func(taskType string) bool, logger logger.Logger) *dispatchControl {
+	return &dispatchControl{
+		pausedTypes: make(map[string]DispatchPause),
+		blocked:     blocked,
+		logger:      logger,
+	}
+}
+
+// pause pausa el despacho; agentType vacío pausa todos los tipos
+func (d *dispatchControl) pause(agentType, reason string) {
+	d.stateMu.Lock()
+	defer d.stateMu.Unlock()
+
+	pause := DispatchPause{Reason: reason, PausedAt: time.Now()}
+	if agentType == "" {
+		d.global = &pause
+	} else {
+		d.pausedTypes[agentType] = pause
+	}
+}
+
+// resume quita la pausa y empieza a liberar las tareas en cola; drainRate 0
+// las libera todas sin esperar
+func (d *dispatchControl) resume(agentType string, drainRate float64) error {
+	if drainRate < 0 {
+		return fmt.Errorf("drain rate must not be negative")
+	}
+
+	d.stateMu.Lock()
+	if agentType == "" {
+		if d.global == nil {
+			d.stateMu.Unlock()
+			return fmt.Errorf("dispatch is not paused")
+		}
+		d.global = nil
+	} else {
+		if _, paused := d.pausedTypes[agentType]; !paused {
+			d.stateMu.Unlock()
+			return fmt.Errorf("dispatch is not paused for agent type %s", agentType)
+		}
+		delete(d.pausedTypes, agentType)
+	}
+	d.stateMu.Unlock()
+
+	d.queueMu.Lock()
+	d.drainRate = drainRate
+	start := !d.draining && len(d.queue) > 0
+	if start {
+		d.draining = true
+	}
+	d.queueMu.Unlock()
+
+	if start {
+		go d.drain()
+	}
+	return nil
+}
+
+func (d *dispatchControl) globalPaused() bool {
+	d.stateMu.RLock()
+	defer d.stateMu.RUnlock()
+	return d.global != nil
+}
+
+func (d *dispatchControl) typePaused(agentType string) bool {
+	d.stateMu.RLock()
+	defer d.stateMu.RUnlock()
+	_, paused := d.pausedTypes[agentType]
+	return paused
+}
+
+// wait deja pasar la tarea o la encola hasta que el despacho se reanude. Las
+// tareas de un tipo que ya tiene otras en cola esperan detrás para respetar el orden.
+func (d *dispatchControl) wait(ctx context.Context, taskID, taskType string) error {
+	d.queueMu.Lock()
+	if !d.hasQueuedLocked(taskType) && !d.blocked(taskType) {
+		d.queueMu.Unlock()
+		return nil
+	}
+
+	entry := &queuedDispatch{taskID: taskID, taskType: taskType, ready: make(chan struct{})}
+	d.queue = append(d.queue, entry)
+	queued := len(d.queue)
+	d.queueMu.Unlock()
+
+	d.logger.Info("Task queued while dispatch is paused",
+		"task_id", taskID,
+		"task_type", taskType,
+		"queued", queued)
+
+	select {
+	case <-entry.ready:
+		return nil
+	case <-ctx.Done():
+		d.remove(entry)
+		return fmt.Errorf("task %s cancelled while waiting for dispatch: %w", taskID, ctx.Err())
+	}
+}
+
+// drain libera las tareas en cola que ya no están bloqueadas, respetando drainRate
+func (d *dispatchControl) drain() {
+	for {
+		d.queueMu.Lock()
+		index := -1
+		for i, entry := range d.queue {
+			if !d.blocked(entry.taskType) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			d.draining = false
+			d.queueMu.Unlock()
+			return
+		}
+
+		entry := d.queue[index]
+		d.queue = append(d.queue[:index], d.queue[index+1:]...)
+		rate := d.drainRate
+		d.queueMu.Unlock()
+
+		close(entry.ready)
+		if rate > 0 {
+			time.Sleep(time.Duration(float64(time.Second) / rate))
+		}
+	}
+}
+
+func (d *dispatchControl) remove(target *queuedDispatch) {
+	d.queueMu.Lock()
+	defer d.queueMu.Unlock()
+
+	for i, entry := range d.queue {
+		if entry == target {
+			d.queue = append(d.queue[:i], d.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+func (d *dispatchControl) hasQueuedLocked(taskType string) bool {
+	for _, entry := range d.queue {
+		if entry.taskType == taskType {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *dispatchControl) status() DispatchStatus {
+	status := DispatchStatus{
+		PausedTypes: make(map[string]DispatchPause),
+		QueuedTypes: make(map[string]int),
+	}
+
+	d.stateMu.RLock()
+	if d.global != nil {
+		global := *d.global
+		status.Global = &global
+	}
+	for agentType, pause := range d.pausedTypes {
+		status.PausedTypes[agentType] = pause
+	}
+	d.stateMu.RUnlock()
+
+	d.queueMu.Lock()
+	status.Queued = len(d.queue)
+	for _, entry := range d.queue {
+		status.QueuedTypes[entry.taskType]++
+	}
+	status.Draining = d.draining
+	status.DrainRate = d.drainRate
+	d.queueMu.Unlock()
+
+	return status
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,156 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestDispatch bloquea como el orquestador: pausa global o tipo pausado
+func newTestDispatch() *dispatchControl {
+	d := newDispatchControl(nil, logger.NewLogger("error"))
+	d.blocked = func(taskType string) bool {
+		return d.globalPaused() || d.typePaused(taskType)
+	}
+	return d
+}
+
+// waitAsync lanza wait y devuelve un canal que recibe su resultado
+func waitAsync(ctx context.Context, d *dispatchControl, taskID, taskType string) <-chan error {
+	done := make(chan error, 1)
+	go func() { done <- d.wait(ctx, taskID, taskType) }()
+	return done
+}
+
+func TestDispatchControl_GlobalPause(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDispatch()
+	require.NoError(t, d.wait(ctx, "before", "chat"))
+
+	d.pause("", "maintenance")
+	status := d.status()
+	require.NotNil(t, status.Global)
+	assert.Equal(t, "maintenance", status.Global.Reason)
+
+	chat := waitAsync(ctx, d, "chat-1", "chat")
+	image := waitAsync(ctx, d, "image-1", "image")
+	require.Eventually(t, func() bool { return d.status().Queued == 2 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, map[string]int{"chat": 1, "image": 1}, d.status().QueuedTypes)
+
+	assert.ErrorContains(t, d.resume("", -1), "must not be negative")
+	assert.True(t, d.globalPaused())
+	assert.ErrorContains(t, d.resume("chat", 0), "not paused for agent type chat")
+
+	require.NoError(t, d.resume("", 0))
+	assert.NoError(t, <-chat)
+	assert.NoError(t, <-image)
+	assert.Equal(t, 0, d.status().Queued)
+	assert.ErrorContains(t, d.resume("", 0), "dispatch is not paused")
+}
+
+func TestDispatchControl_TypePause(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDispatch()
+
+	d.pause("image", "")
+	assert.Contains(t, d.status().PausedTypes, "image")
+	require.NoError(t, d.wait(ctx, "chat-1", "chat"))
+
+	image := waitAsync(ctx, d, "image-1", "image")
+	require.Eventually(t, func() bool { return d.status().QueuedTypes["image"] == 1 }, time.Second, 5*time.Millisecond)
+
+	// Una tarea cancelada sale de la cola
+	cancelled, cancel := context.WithCancel(ctx)
+	second := waitAsync(cancelled, d, "image-2", "image")
+	require.Eventually(t, func() bool { return d.status().QueuedTypes["image"] == 2 }, time.Second, 5*time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-second, context.Canceled)
+	assert.Equal(t, 1, d.status().Queued)
+
+	require.NoError(t, d.resume("image", 0))
+	assert.NoError(t, <-image)
+	assert.Empty(t, d.status().PausedTypes)
+}
+
+func TestDispatchControl_ResumeDrainsAtRate(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDispatch()
+	d.pause("", "")
+
+	const tasks = 4
+	var mu sync.Mutex
+	var order []string
+	var released []time.Time
+	var wg sync.WaitGroup
+	for i, id := range []string{"t1", "t2", "t3", "t4"} {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			assert.NoError(t, d.wait(ctx, id, "chat"))
+			mu.Lock()
+			order = append(order, id)
+			released = append(released, time.Now())
+			mu.Unlock()
+		}(id)
+		// Encolar en orden
+		require.Eventually(t, func() bool { return d.status().Queued == i+1 }, time.Second, time.Millisecond)
+	}
+
+	const rate = 20.0
+	require.NoError(t, d.resume("", rate))
+	status := d.status()
+	assert.True(t, status.Draining)
+	assert.Equal(t, rate, status.DrainRate)
+	wg.Wait()
+
+	assert.Equal(t, []string{"t1", "t2", "t3", "t4"}, order)
+	interval := time.Duration(float64(time.Second) / rate)
+	assert.GreaterOrEqual(t, released[tasks-1].Sub(released[0]), (tasks-1)*interval-10*time.Millisecond)
+	require.Eventually(t, func() bool { return !d.status().Draining }, time.Second, 5*time.Millisecond)
+}
+
+func TestDispatchControl_ConcurrentSubmitsWhilePaused(t *testing.T) {
+	ctx := context.Background()
+	d := newTestDispatch()
+	d.pause("", "deploy")
+
+	const submits = 50
+	var wg sync.WaitGroup
+	errs := make(chan error, submits)
+	for i := 0; i < submits; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			taskType := "chat"
+			if i%2 == 0 {
+				taskType = "image"
+			}
+			errs <- d.wait(ctx, "task", taskType)
+		}(i)
+	}
+	require.Eventually(t, func() bool { return d.status().Queued == submits }, time.Second, 5*time.Millisecond)
+
+	// Reanudar mientras siguen llegando tareas y se consulta el estado
+	var readers sync.WaitGroup
+	readers.Add(1)
+	go func() {
+		defer readers.Done()
+		for i := 0; i < 100; i++ {
+			d.status()
+		}
+	}()
+	require.NoError(t, d.resume("", 0))
+	wg.Wait()
+	readers.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+	require.Eventually(t, func() bool { return !d.status().Draining }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 0, d.status().Queued)
+}
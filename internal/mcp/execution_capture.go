@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// AgentExecution describe una llamada a Execute de un agente para el registro
+// de ejecuciones
+type AgentExecution struct {
+	AgentID   string
+	AgentType string
+	Task      Task
+	Result    Result
+	Err       error
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+// ExecutionRecorder recibe cada ejecución de los agentes. RecordExecution se
+// llama en la goroutine de la tarea, así que no debe bloquear.
+type ExecutionRecorder interface {
+	RecordExecution(ctx context.Context, execution AgentExecution)
+}
+
+// CapturingFactory envuelve los agentes que crea para pasar sus ejecuciones
+// al ExecutionRecorder. El recorder se asigna después con SetRecorder porque
+// depende de repositorios que se crean más tarde que la factory.
+type CapturingFactory struct {
+	AgentFactory
+	mu       sync.RWMutex
+	recorder ExecutionRecorder
+}
+
+// WithExecutionCapture devuelve una factory cuyos agentes registran sus
+// ejecuciones en el recorder asignado con SetRecorder
+func WithExecutionCapture(factory AgentFactory) *CapturingFactory {
+	return &CapturingFactory{AgentFactory: factory}
+}
+
+// SetRecorder asigna el recorder; con nil deja de registrar ejecuciones
+func (f *CapturingFactory) SetRecorder(recorder ExecutionRecorder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.recorder = recorder
+}
+
+func (f *CapturingFactory) currentRecorder() ExecutionRecorder {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.recorder
+}
+
+func (f *CapturingFactory) CreateAgent(config MCPConfig) (Agent, error) {
+	agent, err := f.AgentFactory.CreateAgent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingAgent{Agent: agent, factory: f}, nil
+}
+
+// recordingAgent pasa al recorder la tarea y el resultado de cada Execute;
+// las ejecuciones en streaming no se registran
+type recordingAgent struct {
+	Agent
+	factory *CapturingFactory
+}
+
+func (a *recordingAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	recorder := a.factory.currentRecorder()
+	if recorder == nil {
+		return a.Agent.Execute(ctx, task)
+	}
+
+	startedAt := time.Now()
+	result, err := a.Agent.Execute(ctx, task)
+	recorder.RecordExecution(ctx, AgentExecution{
+		AgentID:   a.GetID(),
+		AgentType: a.GetType(),
+		Task:      task,
+		Result:    result,
+		Err:       err,
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+	})
+	return result, err
+}
@@ -1,157 +1,575 @@
 package mcp
 
 import (
+	"errors"
 	"fmt"
+	"sort"
+	"sync"
 
 	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/configschema"
 	"github.com/company/bot-service/pkg/logger"
 )
 
-// agentFactory implementa AgentFactory
+// agentFactory implementa AgentFactory sobre un registro de tipos de agente
 type agentFactory struct {
 	logger          logger.Logger
 	adapterRegistry adapters.AdapterRegistry
 	adapterFactory  adapters.AdapterFactory
+	mu              sync.RWMutex
+	types           map[string]AgentTypeDefinition
+	// mcpServerCommands son los comandos que pueden lanzar los agentes mcp_client
+	mcpServerCommands []string
+	// authProfiles autentican las llamadas de los agentes http y adapter
+	authProfiles *adapters.AuthProfiles
+	// workflowDefinitions son los workflows guardados que referencian los
+	// agentes workflow
+	workflowDefinitions domain.WorkflowDefinitionRepository
+	// conditions evalúa los pasos condition y branch de los agentes workflow
+	conditions ConditionEvaluator
+	// scriptApprovals son los scripts que los agentes sandbox pueden ejecutar
+	scriptApprovals domain.ScriptApprovalRepository
 }
 
-// NewAgentFactory crea una nueva factory de agentes
+// NewAgentFactory crea una nueva factory de agentes con los tipos integrados
 func NewAgentFactory(logger logger.Logger) AgentFactory {
 	// Crear registro y factory de adaptadores
 	adapterRegistry := adapters.NewAdapterRegistry(logger)
 	adapterFactory := adapters.NewAdapterFactory(logger)
-	
-	return &agentFactory{
+
+	f := &agentFactory{
 		logger:          logger,
 		adapterRegistry: adapterRegistry,
 		adapterFactory:  adapterFactory,
+		types:           make(map[string]AgentTypeDefinition),
+	}
+	for _, def := range f.builtinAgentTypes() {
+		def.Builtin = true
+		f.types[def.Type] = def
 	}
+	return f
 }
 
 // CreateAgent crea un agente basado en la configuración
 func (f *agentFactory) CreateAgent(config MCPConfig) (Agent, error) {
-	switch config.Type {
-	case "ai":
-		return NewAIAgent(config, f.logger)
-	case "http":
-		return NewHTTPAgent(config, f.logger)
-	case "workflow":
-		return NewWorkflowAgent(config, f.logger)
-	case "adapter":
-		return NewAdapterAgent(config, f.adapterRegistry, f.adapterFactory, f.logger)
-	case "mock":
-		return NewMockAgent(config, f.logger)
-	default:
+	def, ok := f.lookup(config.Type)
+	if !ok {
 		return nil, fmt.Errorf("unsupported agent type: %s", config.Type)
 	}
+	return def.Create(config)
 }
 
-// GetSupportedTypes devuelve los tipos de agentes soportados
-func (f *agentFactory) GetSupportedTypes() []string {
-	return []string{"ai", "http", "workflow", "adapter", "mock"}
-}
+// RegisterAgentType agrega un tipo de agente personalizado al registro
+func (f *agentFactory) RegisterAgentType(def AgentTypeDefinition) error {
+	if err := def.validate(); err != nil {
+		return err
+	}
 
-// ValidateConfig valida la configuración de un agente
-func (f *agentFactory) ValidateConfig(config MCPConfig) error {
-	if config.Type == "" {
-		return fmt.Errorf("agent type is required")
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, exists := f.types[def.Type]; exists {
+		return fmt.Errorf("agent type already registered: %s", def.Type)
 	}
+	def.AgentTypeInfo = copyAgentTypeInfo(def.AgentTypeInfo)
+	def.Builtin = false
+	f.types[def.Type] = def
 
-	if config.Name == "" {
-		return fmt.Errorf("agent name is required")
+	f.logger.Info("Agent type registered", "type", def.Type)
+	return nil
+}
+
+// GetAgentTypes devuelve los metadatos de los tipos registrados ordenados por tipo
+func (f *agentFactory) GetAgentTypes() []AgentTypeInfo {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	types := make([]AgentTypeInfo, 0, len(f.types))
+	for _, def := range f.types {
+		types = append(types, copyAgentTypeInfo(def.AgentTypeInfo))
 	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Type < types[j].Type })
+	return types
+}
+
+func (f *agentFactory) lookup(agentType string) (AgentTypeDefinition, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	def, ok := f.types[agentType]
+	return def, ok
+}
+
+// RegisterAdapter registra un adaptador disponible para los agentes creados
+// por la factory (por ejemplo, el almacenamiento de objetos)
+func (f *agentFactory) RegisterAdapter(name string, adapter adapters.Adapter) error {
+	return f.adapterRegistry.Register(name, adapter)
+}
 
-	// Validar que el tipo sea soportado
-	supportedTypes := f.GetSupportedTypes()
-	typeSupported := false
-	for _, supportedType := range supportedTypes {
-		if config.Type == supportedType {
-			typeSupported = true
-			break
+// objectStore busca el almacenamiento indicado en "object_store" o, si no
+// se indica, el primero registrado
+func (f *agentFactory) objectStore(config MCPConfig) (adapters.ObjectStoreAdapter, error) {
+	if name, ok := config.Config["object_store"].(string); ok && name != "" {
+		adapter, err := f.adapterRegistry.Get(name)
+		if err != nil {
+			return nil, err
 		}
+		store, ok := adapter.(adapters.ObjectStoreAdapter)
+		if !ok {
+			return nil, fmt.Errorf("adapter '%s' is not an object store", name)
+		}
+		return store, nil
 	}
 
-	if !typeSupported {
-		return fmt.Errorf("unsupported agent type: %s, supported types: %v", config.Type, supportedTypes)
+	for _, adapter := range f.adapterRegistry.GetByType("object_store") {
+		if store, ok := adapter.(adapters.ObjectStoreAdapter); ok {
+			return store, nil
+		}
 	}
+	return nil, fmt.Errorf("no object store adapter registered")
+}
 
-	// Validaciones específicas por tipo
-	switch config.Type {
-	case "ai":
-		return f.validateAIConfig(config)
-	case "http":
-		return f.validateHTTPConfig(config)
-	case "workflow":
-		return f.validateWorkflowConfig(config)
-	case "adapter":
-		return f.validateAdapterConfig(config)
-	case "mock":
-		return f.validateMockConfig(config)
-	}
+// AllowMCPServerCommands fija los comandos que los agentes mcp_client pueden
+// lanzar con el transporte stdio; sin comandos solo se admite sse
+func (f *agentFactory) AllowMCPServerCommands(commands []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mcpServerCommands = append([]string(nil), commands...)
+}
 
-	return nil
+// UseAuthProfiles fija los perfiles de autenticación que los agentes http y
+// adapter referencian con auth_profile
+func (f *agentFactory) UseAuthProfiles(profiles *adapters.AuthProfiles) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.authProfiles = profiles
 }
 
-// validateAIConfig valida configuración para agentes de IA
-func (f *agentFactory) validateAIConfig(config MCPConfig) error {
-	if config.Config == nil {
-		return fmt.Errorf("AI agent requires config")
-	}
+// UseWorkflowDefinitions fija el repositorio de los workflows guardados que
+// los agentes workflow ejecutan por workflow_id
+func (f *agentFactory) UseWorkflowDefinitions(definitions domain.WorkflowDefinitionRepository) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.workflowDefinitions = definitions
+}
 
-	// Verificar que tenga al menos un proveedor de IA configurado
-	if _, hasOpenAI := config.Config["openai_api_key"]; !hasOpenAI {
-		if _, hasVertex := config.Config["vertex_project"]; !hasVertex {
-			return fmt.Errorf("AI agent requires either openai_api_key or vertex_project")
-		}
+func (f *agentFactory) workflows() domain.WorkflowDefinitionRepository {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.workflowDefinitions
+}
+
+// UseConditionEvaluator fija el motor de expresiones de los pasos condition y
+// branch de los agentes workflow
+func (f *agentFactory) UseConditionEvaluator(evaluator ConditionEvaluator) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conditions = evaluator
+}
+
+func (f *agentFactory) conditionEvaluator() ConditionEvaluator {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.conditions
+}
+
+// UseScriptApprovals fija el repositorio de scripts aprobados por un
+// administrador que consultan los agentes sandbox
+func (f *agentFactory) UseScriptApprovals(approvals domain.ScriptApprovalRepository) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.scriptApprovals = approvals
+}
+
+func (f *agentFactory) approvals() domain.ScriptApprovalRepository {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.scriptApprovals
+}
+
+func (f *agentFactory) httpAuth() *adapters.AuthProfiles {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.authProfiles
+}
+
+// validateAuthProfile comprueba que el auth_profile de la configuración existe
+func (f *agentFactory) validateAuthProfile(config MCPConfig) error {
+	result := &configschema.ValidationError{}
+	if name := configschema.Values(config.Config).String("auth_profile", ""); name != "" && !f.httpAuth().Has(name) {
+		result.Add("config.auth_profile", "unknown auth profile %s, see HTTP_AUTH_PROFILES_FILE", name)
 	}
+	return result.Err()
+}
 
-	return nil
+// GetSupportedTypes devuelve los tipos de agentes soportados
+func (f *agentFactory) GetSupportedTypes() []string {
+	types := f.GetAgentTypes()
+	names := make([]string, len(types))
+	for i, info := range types {
+		names[i] = info.Type
+	}
+	return names
 }
 
-// validateHTTPConfig valida configuración para agentes HTTP
-func (f *agentFactory) validateHTTPConfig(config MCPConfig) error {
-	if config.Config == nil {
-		return fmt.Errorf("HTTP agent requires config")
+// ValidateConfig valida la configuración de un agente contra el esquema de
+// su tipo. Devuelve un *configschema.ValidationError con todos los campos
+// inválidos; los de la configuración llevan el prefijo "config.".
+func (f *agentFactory) ValidateConfig(config MCPConfig) error {
+	result := &configschema.ValidationError{}
+	if config.Type == "" {
+		result.Add("type", "is required")
+	}
+	if config.Name == "" {
+		result.Add("name", "is required")
+	}
+	if config.Type == "" {
+		return result.Err()
 	}
 
-	baseURL, exists := config.Config["base_url"]
-	if !exists {
-		return fmt.Errorf("HTTP agent requires base_url in config")
+	def, ok := f.lookup(config.Type)
+	if !ok {
+		result.Add("type", "unsupported agent type %s, supported types: %v", config.Type, f.GetSupportedTypes())
+		return result.Err()
 	}
 
-	if _, ok := baseURL.(string); !ok {
-		return fmt.Errorf("base_url must be a string")
+	if err := configschema.Validate(def.Config, config.Config); err != nil {
+		var schemaErr *configschema.ValidationError
+		if !errors.As(err, &schemaErr) {
+			return err
+		}
+		for _, fieldErr := range schemaErr.Errors {
+			result.Add("config."+fieldErr.Field, "%s", fieldErr.Message)
+		}
+	}
+	if len(result.Errors) > 0 {
+		return result
 	}
 
+	if def.Validate != nil {
+		if err := def.Validate(config); err != nil {
+			var fieldErr *configschema.ValidationError
+			if errors.As(err, &fieldErr) {
+				return err
+			}
+			result.Add("config", "%s", err.Error())
+			return result
+		}
+	}
 	return nil
 }
 
-// validateWorkflowConfig valida configuración para agentes de workflow
-func (f *agentFactory) validateWorkflowConfig(config MCPConfig) error {
-	if config.Config == nil {
-		return fmt.Errorf("Workflow agent requires config")
+// builtinAgentTypes define los tipos de agente incluidos en el servicio
+func (f *agentFactory) builtinAgentTypes() []AgentTypeDefinition {
+	return []AgentTypeDefinition{
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "ai",
+				Description:  "Agent that uses AI services for text generation and analysis",
+				Capabilities: []string{"text_generation", "conversation", "analysis", "summarization"},
+				Config: []ConfigField{
+					{Name: "openai_api_key", Type: ConfigFieldString, Description: "OpenAI API key, empty uses mock responses"},
+					{Name: "vertex_project", Type: ConfigFieldString, Description: "Vertex AI project, alternative to openai_api_key"},
+					{Name: "model", Type: ConfigFieldString, Description: "AI model to use", Default: "gpt-3.5-turbo"},
+					{Name: "temperature", Type: ConfigFieldNumber, Min: configschema.Bound(0), Max: configschema.Bound(2), Description: "Default sampling temperature", Default: 0.7},
+					{Name: "base_url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "OpenAI compatible API base URL", Default: "https://api.openai.com/v1"},
+				},
+			},
+			Create:   func(config MCPConfig) (Agent, error) { return NewAIAgent(config, f.logger) },
+			Validate: validateAIConfig,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "azure_openai",
+				Description:  "Agent that generates text with an Azure OpenAI deployment",
+				Capabilities: []string{"text_generation", "conversation", "analysis", "summarization"},
+				Config: []ConfigField{
+					{Name: "endpoint", Type: ConfigFieldString, Required: true, Format: configschema.FormatURL, Description: "Azure OpenAI resource endpoint"},
+					{Name: "deployment", Type: ConfigFieldString, Required: true, Description: "Model deployment name"},
+					{Name: "api_key", Type: ConfigFieldString, Description: "Azure OpenAI API key, empty uses mock responses"},
+					{Name: "api_version", Type: ConfigFieldString, Description: "Azure OpenAI API version", Default: defaultAzureAPIVersion},
+					{Name: "model", Type: ConfigFieldString, Description: "Model reported in results, defaults to the deployment name"},
+					{Name: "temperature", Type: ConfigFieldNumber, Min: configschema.Bound(0), Max: configschema.Bound(2), Description: "Default sampling temperature", Default: 0.7},
+				},
+			},
+			Create:   func(config MCPConfig) (Agent, error) { return NewAzureOpenAIAgent(config, f.logger) },
+			Validate: validateAzureOpenAIConfig,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "gemini",
+				Description:  "Agent that generates text with Google Gemini through AI Studio or Vertex AI",
+				Capabilities: []string{"text_generation", "conversation", "analysis", "summarization"},
+				Config: []ConfigField{
+					{Name: "api_key", Type: ConfigFieldString, Description: "Google AI Studio API key, empty uses mock responses"},
+					{Name: "vertex_project", Type: ConfigFieldString, Description: "Vertex AI project, alternative to api_key"},
+					{Name: "vertex_location", Type: ConfigFieldString, Description: "Vertex AI region", Default: defaultVertexLocation},
+					{Name: "access_token", Type: ConfigFieldString, Description: "OAuth access token for Vertex AI"},
+					{Name: "model", Type: ConfigFieldString, Description: "Gemini model to use", Default: defaultGeminiModel},
+					{Name: "temperature", Type: ConfigFieldNumber, Min: configschema.Bound(0), Max: configschema.Bound(2), Description: "Default sampling temperature", Default: 0.7},
+					{Name: "base_url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "API base URL, defaults to the AI Studio or regional Vertex AI host"},
+				},
+			},
+			Create:   func(config MCPConfig) (Agent, error) { return NewGeminiAgent(config, f.logger) },
+			Validate: validateGeminiConfig,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "ollama",
+				Description:  "Agent that generates text with a model served by a local Ollama server",
+				Capabilities: []string{"text_generation", "summarization"},
+				Config: []ConfigField{
+					{Name: "model", Type: ConfigFieldString, Required: true, Description: "Ollama model, e.g. llama3.1:8b"},
+					{Name: "base_url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "Ollama server URL", Default: defaultOllamaURL},
+					{Name: "temperature", Type: ConfigFieldNumber, Min: configschema.Bound(0), Max: configschema.Bound(2), Description: "Default sampling temperature", Default: 0.7},
+					{Name: "pull", Type: ConfigFieldBoolean, Description: "Pull the model on start when the server does not have it"},
+					{Name: "pull_timeout", Type: ConfigFieldDuration, Description: "Maximum time to wait for a model pull", Default: defaultOllamaPullWait.String()},
+					{Name: "keep_alive", Type: ConfigFieldString, Description: "How long the server keeps the model loaded after a request, e.g. 30m"},
+				},
+			},
+			Create: func(config MCPConfig) (Agent, error) { return NewOllamaAgent(config, f.logger) },
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "http",
+				Description:  "Agent that makes HTTP requests to external APIs",
+				Capabilities: []string{"http_request", "api_call", "webhook", "integration"},
+				Config: []ConfigField{
+					{Name: "base_url", Type: ConfigFieldString, Required: true, Format: configschema.FormatURL, Description: "Base URL for HTTP requests"},
+					{Name: "headers", Type: ConfigFieldObject, Description: "Default headers for requests"},
+					{Name: "auth_profile", Type: ConfigFieldString, Description: "Auth profile that signs the requests"},
+				},
+			},
+			Create:   func(config MCPConfig) (Agent, error) { return NewHTTPAgent(config, f.httpAuth(), f.logger) },
+			Validate: f.validateAuthProfile,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "workflow",
+				Description:  "Agent that executes sequential workflow steps",
+				Capabilities: []string{"workflow", "sequence", "orchestration", "automation"},
+				Config: []ConfigField{
+					{Name: "steps", Type: ConfigFieldArray, Description: "Workflow steps to execute in order, required without workflow_id"},
+					{Name: "workflow_id", Type: ConfigFieldString, Description: "Stored workflow to run instead of steps"},
+					{Name: "workflow_version", Type: ConfigFieldInteger, Description: "Pinned version of the stored workflow, 0 runs the latest", Min: configschema.Bound(0)},
+					{Name: "max_step_output_bytes", Type: ConfigFieldInteger, Description: "Maximum JSON size of each step output in the result", Default: defaultMaxStepOutputBytes, Min: configschema.Bound(1)},
+					{Name: "externalize_outputs", Type: ConfigFieldBoolean, Description: "Store step outputs over the limit in the object store"},
+					{Name: "object_store", Type: ConfigFieldString, Description: "Object store adapter name, defaults to the first registered"},
+				},
+			},
+			Create: func(config MCPConfig) (Agent, error) {
+				var store adapters.ObjectStoreAdapter
+				if configschema.Values(config.Config).Bool("externalize_outputs", false) {
+					var err error
+					if store, err = f.objectStore(config); err != nil {
+						return nil, err
+					}
+				}
+				return NewWorkflowAgent(config, store, f.adapterRegistry, f.workflows(), f.conditionEvaluator(), f.logger)
+			},
+			Validate: validateWorkflowConfig,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "adapter",
+				Description:  "Agent that manages adapters to talk to external systems",
+				Capabilities: []string{"http_request", "api_call", "webhook", "integration", "adapter_management", "interoperability", "grpc_call", "graphql_query", "graphql_mutation", "database_query", "queue_publish", "queue_consume"},
+				Config: []ConfigField{
+					{Name: "auth_profile", Type: ConfigFieldString, Description: "Auth profile for HTTP tasks that do not set their own"},
+				},
+			},
+			Create: func(config MCPConfig) (Agent, error) {
+				return NewAdapterAgent(config, f.adapterRegistry, f.adapterFactory, f.httpAuth(), f.logger)
+			},
+			Validate: f.validateAuthProfile,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "sandbox",
+				Description:  "Agent that runs approved scripts in isolated containers",
+				Capabilities: []string{"code_execution", "data_transform"},
+				Config: []ConfigField{
+					{Name: "runtime", Type: ConfigFieldString, Description: "Container runtime binary", Default: defaultSandboxRuntimeBin},
+					{Name: "memory_mb", Type: ConfigFieldInteger, Description: "Memory limit in MB", Default: defaultSandboxMemoryMB, Min: configschema.Bound(1), Max: configschema.Bound(maxSandboxMemoryMB)},
+					{Name: "cpus", Type: ConfigFieldNumber, Description: "CPU limit, up to 2", Default: defaultSandboxCPUs, Max: configschema.Bound(2)},
+					{Name: "pids_limit", Type: ConfigFieldInteger, Description: "Maximum number of processes", Default: defaultSandboxPidsLimit, Min: configschema.Bound(1)},
+					{Name: "max_output_bytes", Type: ConfigFieldInteger, Description: "Maximum output size in bytes", Default: defaultSandboxMaxOutput, Min: configschema.Bound(1), Max: configschema.Bound(maxSandboxMaxOutput)},
+					{Name: "max_script_bytes", Type: ConfigFieldInteger, Description: "Maximum script size in bytes", Default: defaultSandboxMaxScript, Min: configschema.Bound(1)},
+					{Name: "max_input_bytes", Type: ConfigFieldInteger, Description: "Maximum input size in bytes", Default: defaultSandboxMaxInput, Min: configschema.Bound(1)},
+					{Name: "images", Type: ConfigFieldObject, Description: "Container image overrides by language"},
+					{Name: "approved_scripts", Type: ConfigFieldArray, Description: "SHA-256 hashes of approved scripts, in addition to those approved with the admin API"},
+				},
+			},
+			Create:   func(config MCPConfig) (Agent, error) { return NewSandboxAgent(config, f.approvals(), f.logger) },
+			Validate: validateSandboxConfig,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "image",
+				Description:  "Agent that generates images and stores them in an object store",
+				Capabilities: []string{"image_generation"},
+				Config: []ConfigField{
+					{Name: "provider", Type: ConfigFieldString, Description: "Image generation provider", Default: ImageProviderOpenAI, Enum: []string{ImageProviderOpenAI, ImageProviderStability}},
+					{Name: "openai_api_key", Type: ConfigFieldString, Description: "OpenAI API key"},
+					{Name: "stability_api_key", Type: ConfigFieldString, Description: "Stability AI API key"},
+					{Name: "model", Type: ConfigFieldString, Description: "Image model, defaults depend on the provider"},
+					{Name: "base_url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "Provider API base URL"},
+					{Name: "object_store", Type: ConfigFieldString, Description: "Object store adapter name, defaults to the first registered"},
+				},
+			},
+			Create: func(config MCPConfig) (Agent, error) {
+				store, err := f.objectStore(config)
+				if err != nil {
+					return nil, err
+				}
+				return NewImageAgent(config, store, f.logger)
+			},
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "transcription",
+				Description:  "Agent that transcribes audio to text",
+				Capabilities: []string{"transcription", "speech_to_text"},
+				Config: []ConfigField{
+					{Name: "provider", Type: ConfigFieldString, Description: "Transcription provider", Default: TranscriptionProviderOpenAI, Enum: []string{TranscriptionProviderOpenAI, TranscriptionProviderLocal}},
+					{Name: "openai_api_key", Type: ConfigFieldString, Description: "OpenAI API key, empty uses mock transcriptions"},
+					{Name: "model", Type: ConfigFieldString, Description: "Transcription model", Default: "whisper-1"},
+					{Name: "base_url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "Provider API base URL, required for the local provider"},
+				},
+			},
+			Create:   func(config MCPConfig) (Agent, error) { return NewTranscriptionAgent(config, f.logger) },
+			Validate: validateTranscriptionConfig,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "translation",
+				Description:  "Agent that translates text keeping glossary terms",
+				Capabilities: []string{"translation"},
+				Config: []ConfigField{
+					{Name: "openai_api_key", Type: ConfigFieldString, Description: "OpenAI API key, empty only applies the glossary"},
+					{Name: "model", Type: ConfigFieldString, Description: "Translation model", Default: "gpt-4o-mini"},
+					{Name: "fallback_model", Type: ConfigFieldString, Description: "Model used when the primary model fails"},
+					{Name: "base_url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "OpenAI compatible API base URL", Default: "https://api.openai.com/v1"},
+					{Name: "glossary", Type: ConfigFieldArray, Description: "Terms with fixed translations per language"},
+					{Name: "language_pairs", Type: ConfigFieldArray, Description: "Allowed source and target languages"},
+				},
+			},
+			Create:   func(config MCPConfig) (Agent, error) { return NewTranslationAgent(config, f.logger) },
+			Validate: validateTranslationConfig,
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "moderation",
+				Description:  "Agent that flags and masks disallowed content",
+				Capabilities: []string{"moderation", "content_moderation"},
+				Config: []ConfigField{
+					{Name: "provider", Type: ConfigFieldString, Description: "Moderation provider, the word list is always applied", Default: ModerationProviderLocal, Enum: []string{ModerationProviderLocal, ModerationProviderOpenAI}},
+					{Name: "openai_api_key", Type: ConfigFieldString, Description: "OpenAI API key, empty only applies the word list"},
+					{Name: "model", Type: ConfigFieldString, Description: "Moderation model", Default: "omni-moderation-latest"},
+					{Name: "base_url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "OpenAI compatible API base URL", Default: "https://api.openai.com/v1"},
+					{Name: "words", Type: ConfigFieldArray, Description: "Extra disallowed words"},
+					{Name: "allowed_words", Type: ConfigFieldArray, Description: "Words removed from the list"},
+					{Name: "disable_default_words", Type: ConfigFieldBoolean, Description: "Skip the built-in word list", Default: false},
+					{Name: "categories", Type: ConfigFieldArray, Description: "Provider categories that flag content, empty means all"},
+				},
+			},
+			Create: func(config MCPConfig) (Agent, error) { return NewModerationAgent(config, f.logger) },
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "mcp_client",
+				Description:  "Agent that calls the tools of an external Model Context Protocol server",
+				Capabilities: []string{mcpToolCallTask},
+				Config: []ConfigField{
+					{Name: "transport", Type: ConfigFieldString, Description: "How to reach the server", Default: MCPTransportStdio, Enum: []string{MCPTransportStdio, MCPTransportSSE}},
+					{Name: "command", Type: ConfigFieldString, Description: "Server command for the stdio transport, must be in MCP_SERVER_COMMANDS"},
+					{Name: "args", Type: ConfigFieldArray, Description: "Server command arguments"},
+					{Name: "env", Type: ConfigFieldObject, Description: "Extra environment variables for the server process"},
+					{Name: "url", Type: ConfigFieldString, Format: configschema.FormatURL, Description: "Server SSE endpoint for the sse transport"},
+					{Name: "headers", Type: ConfigFieldObject, Description: "Headers sent to the SSE server"},
+					{Name: "tool_prefix", Type: ConfigFieldString, Description: "Prefix added to tool names to form task types"},
+					{Name: "tools", Type: ConfigFieldArray, Description: "Tools to expose, empty means all"},
+					{Name: "connect_timeout", Type: ConfigFieldDuration, Description: "Timeout to connect and list tools", Default: defaultMCPConnectTimeout.String()},
+				},
+			},
+			Create: func(config MCPConfig) (Agent, error) { return NewMCPClientAgent(config, f.logger) },
+			Validate: func(config MCPConfig) error {
+				f.mu.RLock()
+				commands := f.mcpServerCommands
+				f.mu.RUnlock()
+				return validateMCPClientConfig(config, commands)
+			},
+		},
+		{
+			AgentTypeInfo: AgentTypeInfo{
+				Type:         "mock",
+				Description:  "Mock agent for testing and development",
+				Capabilities: []string{"mock", "test", "development", "simulation"},
+				Config: []ConfigField{
+					{Name: "responses", Type: ConfigFieldArray, Description: "Responses returned at random"},
+					{Name: "min_processing_time_ms", Type: ConfigFieldInteger, Description: "Minimum simulated processing time", Default: 100, Min: configschema.Bound(0)},
+					{Name: "max_processing_time_ms", Type: ConfigFieldInteger, Description: "Maximum simulated processing time", Default: 2000, Min: configschema.Bound(0)},
+					{Name: "failure_rate", Type: ConfigFieldNumber, Description: "Probability of a simulated failure", Default: 0.05, Min: configschema.Bound(0), Max: configschema.Bound(1)},
+				},
+			},
+			Create: func(config MCPConfig) (Agent, error) { return NewMockAgent(config, f.logger) },
+		},
 	}
+}
 
-	steps, exists := config.Config["steps"]
-	if !exists {
-		return fmt.Errorf("Workflow agent requires steps in config")
+// validateAIConfig exige al menos un proveedor de IA configurado; una clave
+// de OpenAI vacía es válida y activa el modo mock
+func validateAIConfig(config MCPConfig) error {
+	_, hasOpenAI := config.Config["openai_api_key"]
+	_, hasVertex := config.Config["vertex_project"]
+	if hasOpenAI || hasVertex {
+		return nil
 	}
+	result := &configschema.ValidationError{}
+	result.Add("config.openai_api_key", "openai_api_key or vertex_project is required")
+	return result
+}
 
-	if _, ok := steps.([]interface{}); !ok {
-		return fmt.Errorf("steps must be an array")
+// validateWorkflowConfig valida que los pasos lleguen como JSON genérico o
+// que se indique un workflow guardado
+func validateWorkflowConfig(config MCPConfig) error {
+	if _, ok := config.Config["steps"].([]interface{}); ok {
+		return nil
+	}
+	if _, hasSteps := config.Config["steps"]; !hasSteps && configschema.Values(config.Config).String("workflow_id", "") != "" {
+		return nil
 	}
+	return fmt.Errorf("steps must be an array")
+}
 
-	return nil
+// validateSandboxConfig valida los límites de recursos del sandbox
+func validateSandboxConfig(config MCPConfig) error {
+	if config.Config == nil {
+		config.Config = map[string]interface{}{}
+	}
+	_, err := parseSandboxLimits(config)
+	return err
 }
 
-// validateMockConfig valida configuración para agentes mock
-func (f *agentFactory) validateMockConfig(config MCPConfig) error {
-	// Los agentes mock no requieren configuración específica
-	return nil
+// validateTranscriptionConfig exige base_url para el proveedor local
+func validateTranscriptionConfig(config MCPConfig) error {
+	values := configschema.Values(config.Config)
+	if values.String("provider", "") != TranscriptionProviderLocal || values.String("base_url", "") != "" {
+		return nil
+	}
+	result := &configschema.ValidationError{}
+	result.Add("config.base_url", "is required for the local provider")
+	return result
 }
 
-// validateAdapterConfig valida configuración para agentes de adaptador
-func (f *agentFactory) validateAdapterConfig(config MCPConfig) error {
-	// Los agentes de adaptador pueden funcionar sin configuración específica
-	// ya que pueden crear adaptadores dinámicamente
-	return nil
-}
\ No newline at end of file
+// validateTranslationConfig valida el glosario y los pares de idiomas
+func validateTranslationConfig(config MCPConfig) error {
+	if config.Config == nil {
+		return nil
+	}
+	if _, err := parseGlossary(config.Config["glossary"]); err != nil {
+		return err
+	}
+	_, err := parseLanguagePairs(config.Config["language_pairs"])
+	return err
+}
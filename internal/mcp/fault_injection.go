@@ -0,0 +1,88 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/internal/chaos"
+)
+
+// faultInjectingFactory envuelve los agentes y adaptadores que crea o registra
+// la factory para que pasen por el inyector de fallos
+type faultInjectingFactory struct {
+	AgentFactory
+	faults *chaos.Injector
+}
+
+// WithFaultInjection devuelve una factory cuyos agentes sufren los fallos de
+// faults en Execute y ExecuteStream, igual que los adaptadores registrados
+func WithFaultInjection(factory AgentFactory, faults *chaos.Injector) AgentFactory {
+	return &faultInjectingFactory{AgentFactory: factory, faults: faults}
+}
+
+func (f *faultInjectingFactory) CreateAgent(config MCPConfig) (Agent, error) {
+	agent, err := f.AgentFactory.CreateAgent(config)
+	if err != nil {
+		return nil, err
+	}
+	return &faultyAgent{Agent: agent, faults: f.faults}, nil
+}
+
+func (f *faultInjectingFactory) RegisterAdapter(name string, adapter adapters.Adapter) error {
+	return f.AgentFactory.RegisterAdapter(name, adapters.WithFaultInjection(adapter, f.faults))
+}
+
+// faultyAgent inyecta fallos antes de delegar en el agente real
+type faultyAgent struct {
+	Agent
+	faults *chaos.Injector
+}
+
+func (a *faultyAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	if err := a.faults.Inject(ctx, "agent:"+a.GetType()); err != nil {
+		return Result{TaskID: task.ID, Success: false, Error: err.Error()}, err
+	}
+	return a.Agent.Execute(ctx, task)
+}
+
+// unwrapAgent devuelve el agente real para comprobar sus interfaces opcionales
+// (ConfigurableAgent, StreamingAgent, el historial de errores de baseAgent)
+func unwrapAgent(agent Agent) Agent {
+	for {
+		switch wrapped := agent.(type) {
+		case *faultyAgent:
+			agent = wrapped.Agent
+		case *secretAgent:
+			agent = wrapped.current()
+		case *recordingAgent:
+			agent = wrapped.Agent
+		default:
+			return agent
+		}
+	}
+}
+
+// faultyStream aplica a un StreamingAgent los fallos del envoltorio, si lo hay
+type faultyStream struct {
+	StreamingAgent
+	faults *chaos.Injector
+}
+
+func (s faultyStream) ExecuteStream(ctx context.Context, task Task) (<-chan StreamChunk, error) {
+	if err := s.faults.Inject(ctx, "agent:"+s.GetType()); err != nil {
+		return nil, err
+	}
+	return s.StreamingAgent.ExecuteStream(ctx, task)
+}
+
+// streamingAgent devuelve agent como StreamingAgent si el agente real lo es
+func streamingAgent(agent Agent) (StreamingAgent, bool) {
+	streaming, ok := unwrapAgent(agent).(StreamingAgent)
+	if !ok {
+		return nil, false
+	}
+	if faulty, wrapped := agent.(*faultyAgent); wrapped {
+		return faultyStream{StreamingAgent: streaming, faults: faulty.faults}, true
+	}
+	return streaming, true
+}
@@ -0,0 +1,290 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	defaultGeminiModel    = "gemini-1.5-flash"
+	defaultGeminiBaseURL  = "https://generativelanguage.googleapis.com"
+	defaultVertexLocation = "us-central1"
+)
+
+// geminiAgent genera texto con Gemini, por Google AI Studio (api_key) o por
+// Vertex AI (vertex_project y un access_token de OAuth)
+type geminiAgent struct {
+	*baseAgent
+	client      *http.Client
+	modelsURL   string
+	apiKey      string
+	accessToken string
+	model       string
+	temperature float64
+	useMock     bool
+	mock        *mockReplies
+}
+
+// Estructuras de la API generateContent
+type geminiRequest struct {
+	Contents          []geminiContent        `json:"contents"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature     float64 `json:"temperature"`
+	MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+}
+
+type geminiResponse struct {
+	Candidates []struct {
+		Content      geminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+	UsageMetadata struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+	} `json:"usageMetadata"`
+	ModelVersion string `json:"modelVersion"`
+}
+
+// NewGeminiAgent crea un agente de Gemini. Sin credenciales responde con mocks.
+func NewGeminiAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{"text_generation", "conversation", "analysis", "summarization"}
+
+	values := configschema.Values(config.Config)
+	apiKey := values.String("api_key", "")
+	accessToken := values.String("access_token", "")
+
+	modelsURL := strings.TrimSuffix(values.String("base_url", defaultGeminiBaseURL), "/") + "/v1beta/models"
+	if project := values.String("vertex_project", ""); project != "" {
+		location := values.String("vertex_location", defaultVertexLocation)
+		baseURL := values.String("base_url", fmt.Sprintf("https://%s-aiplatform.googleapis.com", location))
+		modelsURL = fmt.Sprintf("%s/v1/projects/%s/locations/%s/publishers/google/models",
+			strings.TrimSuffix(baseURL, "/"), project, location)
+		// En Vertex la autenticación es solo por OAuth
+		apiKey = ""
+	}
+
+	return &geminiAgent{
+		baseAgent:   base,
+		client:      &http.Client{Timeout: aiRequestTimeout(config)},
+		modelsURL:   modelsURL,
+		apiKey:      apiKey,
+		accessToken: accessToken,
+		model:       values.String("model", defaultGeminiModel),
+		temperature: values.Float("temperature", 0.7),
+		useMock:     apiKey == "" && accessToken == "",
+		mock:        newMockReplies(),
+	}, nil
+}
+
+func (a *geminiAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	var result Result
+	var err error
+	if a.useMock {
+		result = a.executeMockTask(task)
+	} else {
+		result, err = a.executeRealTask(ctx, task)
+	}
+
+	result.Duration = time.Since(start)
+	a.updateMetrics(result.Success, result.Duration)
+	return result, err
+}
+
+func (a *geminiAgent) executeMockTask(task Task) Result {
+	response := a.mock.next(task)
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output: map[string]interface{}{
+			"text":          response,
+			"model":         a.model + "-mock",
+			"tokens_used":   len(response) / 4,
+			"finish_reason": "stop",
+		},
+		Metadata: map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"mode":       "mock",
+		},
+	}
+}
+
+func (a *geminiAgent) executeRealTask(ctx context.Context, task Task) (Result, error) {
+	chat, err := chatRequest(task, a.model, a.temperature)
+	if err != nil {
+		return Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   "prompt is required for AI tasks",
+		}, err
+	}
+
+	response, err := a.generateContent(ctx, toGeminiRequest(chat))
+	if err != nil {
+		return Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   err.Error(),
+		}, err
+	}
+
+	model := response.ModelVersion
+	if model == "" {
+		model = a.model
+	}
+	usage := response.UsageMetadata
+	metrics.RecordAITokens(aiProviderGemini, model, usage.PromptTokenCount, usage.CandidatesTokenCount)
+
+	candidate := response.Candidates[0]
+	texts := make([]string, 0, len(candidate.Content.Parts))
+	for _, part := range candidate.Content.Parts {
+		texts = append(texts, part.Text)
+	}
+
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output: map[string]interface{}{
+			"text":          strings.Join(texts, ""),
+			"model":         model,
+			"tokens_used":   usage.PromptTokenCount + usage.CandidatesTokenCount,
+			"finish_reason": strings.ToLower(candidate.FinishReason),
+		},
+		Metadata: map[string]interface{}{
+			"agent_id":          a.id,
+			"agent_type":        a.agentType,
+			"mode":              "real",
+			"prompt_tokens":     usage.PromptTokenCount,
+			"completion_tokens": usage.CandidatesTokenCount,
+		},
+	}, nil
+}
+
+// toGeminiRequest traduce un request de chat: los mensajes system van a
+// systemInstruction y el rol assistant es model
+func toGeminiRequest(chat openAIRequest) geminiRequest {
+	request := geminiRequest{
+		GenerationConfig: geminiGenerationConfig{Temperature: chat.Temperature, MaxOutputTokens: chat.MaxTokens},
+	}
+	for _, msg := range chat.Messages {
+		part := geminiPart{Text: msg.Content}
+		switch msg.Role {
+		case "system":
+			if request.SystemInstruction == nil {
+				request.SystemInstruction = &geminiContent{}
+			}
+			request.SystemInstruction.Parts = append(request.SystemInstruction.Parts, part)
+		case "assistant":
+			request.Contents = append(request.Contents, geminiContent{Role: "model", Parts: []geminiPart{part}})
+		default:
+			request.Contents = append(request.Contents, geminiContent{Role: "user", Parts: []geminiPart{part}})
+		}
+	}
+	return request
+}
+
+// generateContent llama a models/{model}:generateContent
+func (a *geminiAgent) generateContent(ctx context.Context, request geminiRequest) (geminiResponse, error) {
+	var response geminiResponse
+	jsonBody, err := json.Marshal(request)
+	if err != nil {
+		return response, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/%s:generateContent", a.modelsURL, a.model)
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return response, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if a.apiKey != "" {
+		req.Header.Set("x-goog-api-key", a.apiKey)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+a.accessToken)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return response, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return response, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return response, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(response.Candidates) == 0 {
+		// Gemini no devuelve candidatos cuando bloquea el prompt
+		if reason := response.PromptFeedback.BlockReason; reason != "" {
+			return response, fmt.Errorf("prompt blocked by the provider: %s", reason)
+		}
+		return response, fmt.Errorf("no candidates in API response")
+	}
+	return response, nil
+}
+
+func (a *geminiAgent) CanHandle(taskType string) bool {
+	switch taskType {
+	case "text_generation", "conversation", "analysis", "summarization", "ai", "gemini", "chat":
+		return true
+	}
+	return false
+}
+
+// validateGeminiConfig exige api_key para AI Studio o vertex_project y
+// access_token para Vertex AI
+func validateGeminiConfig(config MCPConfig) error {
+	_, hasAPIKey := config.Config["api_key"]
+	_, hasProject := config.Config["vertex_project"]
+	_, hasToken := config.Config["access_token"]
+
+	result := &configschema.ValidationError{}
+	switch {
+	case hasProject && !hasToken:
+		result.Add("config.access_token", "is required with vertex_project")
+	case !hasProject && !hasAPIKey:
+		result.Add("config.api_key", "api_key or vertex_project is required")
+	}
+	return result.Err()
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/pkg/configschema"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -19,15 +21,19 @@ type httpAgent struct {
 	baseURL string
 	headers map[string]string
 	config  MCPConfig
+	// auth firma las peticiones con el perfil authProfile, si lo hay
+	auth        *adapters.AuthProfiles
+	authProfile string
 }
 
 // NewHTTPAgent crea un nuevo agente HTTP
-func NewHTTPAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+func NewHTTPAgent(config MCPConfig, auth *adapters.AuthProfiles, logger logger.Logger) (Agent, error) {
 	base := newBaseAgent(config, logger)
 	base.capabilities = []string{"http_request", "api_call", "webhook", "integration"}
 	
 	// Obtener configuración
-	baseURL, _ := config.Config["base_url"].(string)
+	values := configschema.Values(config.Config)
+	baseURL := values.String("base_url", "")
 	
 	// Configurar headers por defecto
 	headers := map[string]string{
@@ -36,14 +42,8 @@ func NewHTTPAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
 	}
 	
 	// Agregar headers personalizados
-	if customHeaders, exists := config.Config["headers"]; exists {
-		if headerMap, ok := customHeaders.(map[string]interface{}); ok {
-			for k, v := range headerMap {
-				if strVal, ok := v.(string); ok {
-					headers[k] = strVal
-				}
-			}
-		}
+	for k, v := range values.StringMap("headers") {
+		headers[k] = v
 	}
 	
 	// Configurar timeout
@@ -60,6 +60,8 @@ func NewHTTPAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
 		baseURL: baseURL,
 		headers: headers,
 		config:  config,
+		auth:        auth,
+		authProfile: values.String("auth_profile", ""),
 	}, nil
 }
 
@@ -67,16 +69,10 @@ func (a *httpAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	start := time.Now()
 	
 	// Actualizar estado
-	a.mu.Lock()
-	a.state.Status = AgentStatusBusy
-	a.state.CurrentTask = &task
-	a.mu.Unlock()
+	a.setStatus(AgentStatusBusy, &task)
 	
 	defer func() {
-		a.mu.Lock()
-		a.state.Status = AgentStatusIdle
-		a.state.CurrentTask = nil
-		a.mu.Unlock()
+		a.setStatus(AgentStatusIdle, nil)
 	}()
 	
 	a.logger.Info("HTTP agent executing task", 
@@ -86,6 +82,9 @@ func (a *httpAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	
 	// Construir request HTTP
 	req, err := a.buildHTTPRequest(ctx, task)
+	if err == nil {
+		err = a.authenticate(ctx, req)
+	}
 	if err != nil {
 		duration := time.Since(start)
 		a.updateMetrics(false, duration)
@@ -103,7 +102,20 @@ func (a *httpAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	}
 	
 	// Ejecutar request
-	resp, err := a.client.Do(req)
+	a.mu.RLock()
+	client := a.client
+	a.mu.RUnlock()
+	resp, err := client.Do(req)
+	// Un token OAuth2 rechazado se renueva y la petición se repite una vez
+	if err == nil && resp.StatusCode == http.StatusUnauthorized && a.auth.Refreshable(a.authProfile) {
+		resp.Body.Close()
+		a.auth.Invalidate(a.authProfile)
+		if req, err = a.buildHTTPRequest(ctx, task); err == nil {
+			if err = a.authenticate(ctx, req); err == nil {
+				resp, err = client.Do(req)
+			}
+		}
+	}
 	if err != nil {
 		duration := time.Since(start)
 		a.updateMetrics(false, duration)
@@ -184,6 +196,19 @@ func (a *httpAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	return result, nil
 }
 
+// authenticate añade a la petición las credenciales del perfil del agente
+func (a *httpAgent) authenticate(ctx context.Context, req *http.Request) error {
+	if a.authProfile == "" {
+		return nil
+	}
+	credentials, err := a.auth.Credentials(ctx, a.authProfile)
+	if err != nil {
+		return err
+	}
+	credentials.Apply(req)
+	return nil
+}
+
 func (a *httpAgent) CanHandle(taskType string) bool {
 	supportedTypes := []string{
 		"http_request",
@@ -247,9 +272,11 @@ func (a *httpAgent) buildHTTPRequest(ctx context.Context, task Task) (*http.Requ
 	}
 	
 	// Agregar headers por defecto
+	a.mu.RLock()
 	for key, value := range a.headers {
 		req.Header.Set(key, value)
 	}
+	a.mu.RUnlock()
 	
 	// Agregar headers específicos de la tarea
 	if headers, exists := task.Input["headers"]; exists {
@@ -274,4 +301,45 @@ func (a *httpAgent) buildHTTPRequest(ctx context.Context, task Task) (*http.Requ
 	}
 	
 	return req, nil
-}
\ No newline at end of file
+}
+// HotConfigFields devuelve los campos que se pueden cambiar sin reiniciar el agente
+func (a *httpAgent) HotConfigFields() []ConfigField {
+	return []ConfigField{
+		{Name: "headers", Type: ConfigFieldObject, Description: "Default headers merged into the current ones, an empty value removes the header"},
+		{Name: "timeout", Type: ConfigFieldDuration, Min: configschema.Bound(1), Description: "Request timeout"},
+	}
+}
+
+// UpdateConfig cambia los headers por defecto o el timeout del agente en ejecución
+func (a *httpAgent) UpdateConfig(changes map[string]interface{}) (ConfigChange, error) {
+	return a.applyConfigUpdate(hotConfigUpdate{
+		fields: a.HotConfigFields(),
+		current: func() map[string]interface{} {
+			headers := make(map[string]string, len(a.headers))
+			for k, v := range a.headers {
+				headers[k] = v
+			}
+			return map[string]interface{}{
+				"headers": headers,
+				"timeout": a.client.Timeout.String(),
+			}
+		},
+		apply: func(values configschema.Values) {
+			headers := make(map[string]string, len(a.headers))
+			for k, v := range a.headers {
+				headers[k] = v
+			}
+			for k, v := range values.StringMap("headers") {
+				if v == "" {
+					delete(headers, k)
+					continue
+				}
+				headers[k] = v
+			}
+			a.headers = headers
+			if timeout := values.Duration("timeout", 0); timeout > 0 {
+				a.client = &http.Client{Timeout: timeout, Transport: a.client.Transport}
+			}
+		},
+	}, changes)
+}
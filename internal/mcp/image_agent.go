@@ -0,0 +1,360 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Proveedores de generación de imágenes soportados
+const (
+	ImageProviderOpenAI    = "openai"
+	ImageProviderStability = "stability"
+)
+
+const defaultImageSize = "1024x1024"
+
+// imageAgent genera imágenes con OpenAI Images o Stability y las guarda en
+// el almacenamiento de objetos, devolviendo la URL pública
+type imageAgent struct {
+	*baseAgent
+	client   *http.Client
+	provider string
+	apiKey   string
+	model    string
+	baseURL  string
+	store    adapters.ObjectStoreAdapter
+	useMock  bool
+}
+
+type openAIImageRequest struct {
+	Model          string `json:"model"`
+	Prompt         string `json:"prompt"`
+	N              int    `json:"n"`
+	Size           string `json:"size"`
+	ResponseFormat string `json:"response_format"`
+}
+
+type openAIImageResponse struct {
+	Data []struct {
+		B64JSON       string `json:"b64_json"`
+		RevisedPrompt string `json:"revised_prompt"`
+	} `json:"data"`
+}
+
+type stabilityPrompt struct {
+	Text   string  `json:"text"`
+	Weight float64 `json:"weight"`
+}
+
+type stabilityRequest struct {
+	TextPrompts []stabilityPrompt `json:"text_prompts"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Samples     int               `json:"samples"`
+}
+
+type stabilityResponse struct {
+	Artifacts []struct {
+		Base64       string `json:"base64"`
+		FinishReason string `json:"finishReason"`
+	} `json:"artifacts"`
+}
+
+// NewImageAgent crea un agente de generación de imágenes
+func NewImageAgent(config MCPConfig, store adapters.ObjectStoreAdapter, logger logger.Logger) (Agent, error) {
+	if store == nil {
+		return nil, fmt.Errorf("image agent requires an object store")
+	}
+
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{"image_generation"}
+
+	values := configschema.Values(config.Config)
+	provider := values.String("provider", ImageProviderOpenAI)
+
+	var apiKey, model, baseURL string
+	switch provider {
+	case ImageProviderOpenAI:
+		apiKey = values.String("openai_api_key", "")
+		model = "dall-e-3"
+		baseURL = "https://api.openai.com/v1"
+	case ImageProviderStability:
+		apiKey = values.String("stability_api_key", "")
+		model = "stable-diffusion-xl-1024-v1-0"
+		baseURL = "https://api.stability.ai"
+	default:
+		return nil, fmt.Errorf("unsupported image provider: %s", provider)
+	}
+	model = values.String("model", model)
+	baseURL = strings.TrimSuffix(values.String("base_url", baseURL), "/")
+
+	// La generación de imágenes es lenta; se usa un timeout mayor que en texto
+	timeout := 90 * time.Second
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+
+	return &imageAgent{
+		baseAgent: base,
+		client:    &http.Client{Timeout: timeout},
+		provider:  provider,
+		apiKey:    apiKey,
+		model:     model,
+		baseURL:   baseURL,
+		store:     store,
+		useMock:   apiKey == "" || apiKey == "sk-test-key",
+	}, nil
+}
+
+func (a *imageAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	a.logger.Info("Image agent executing task",
+		"agent_id", a.id,
+		"task_id", task.ID,
+		"provider", a.provider,
+		"use_mock", a.useMock)
+
+	fail := func(err error) (Result, error) {
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		return Result{
+			TaskID:   task.ID,
+			Success:  false,
+			Error:    err.Error(),
+			Duration: duration,
+			Metadata: map[string]interface{}{
+				"agent_id":   a.id,
+				"agent_type": a.agentType,
+			},
+		}, err
+	}
+
+	prompt, _ := task.Input["prompt"].(string)
+	if strings.TrimSpace(prompt) == "" {
+		return fail(fmt.Errorf("prompt is required"))
+	}
+	size, _ := task.Input["size"].(string)
+	if size == "" {
+		size = defaultImageSize
+	}
+	width, height, err := parseImageSize(size)
+	if err != nil {
+		return fail(err)
+	}
+
+	var imageData []byte
+	var revisedPrompt string
+	switch {
+	case a.useMock:
+		imageData, err = placeholderImage(prompt, width, height)
+	case a.provider == ImageProviderStability:
+		negative, _ := task.Input["negative_prompt"].(string)
+		imageData, err = a.generateStability(ctx, prompt, negative, width, height)
+	default:
+		imageData, revisedPrompt, err = a.generateOpenAI(ctx, prompt, size)
+	}
+	if err != nil {
+		return fail(fmt.Errorf("image generation failed: %w", err))
+	}
+
+	prefix, _ := task.Input["key_prefix"].(string)
+	if prefix == "" {
+		prefix = "images"
+	}
+	key := fmt.Sprintf("%s/%s/%s.png", strings.Trim(prefix, "/"), time.Now().Format("2006/01/02"), id.New())
+
+	stored, err := a.store.PutObject(ctx, key, imageData, "image/png")
+	if err != nil {
+		return fail(fmt.Errorf("failed to store image: %w", err))
+	}
+
+	duration := time.Since(start)
+	a.updateMetrics(true, duration)
+
+	mode := "real"
+	if a.useMock {
+		mode = "mock"
+	}
+
+	a.logger.Info("Image agent task completed",
+		"agent_id", a.id,
+		"task_id", task.ID,
+		"duration", duration,
+		"key", stored.Key)
+
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output: map[string]interface{}{
+			"image_url":      stored.URL,
+			"key":            stored.Key,
+			"content_type":   stored.ContentType,
+			"size_bytes":     stored.Size,
+			"revised_prompt": revisedPrompt,
+		},
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"provider":   a.provider,
+			"model":      a.model,
+			"mode":       mode,
+		},
+	}, nil
+}
+
+func (a *imageAgent) CanHandle(taskType string) bool {
+	switch taskType {
+	case "image_generation", "image":
+		return true
+	}
+	return false
+}
+
+// generateOpenAI usa el endpoint images/generations pidiendo la imagen en base64
+func (a *imageAgent) generateOpenAI(ctx context.Context, prompt, size string) ([]byte, string, error) {
+	body, err := a.postJSON(ctx, a.baseURL+"/images/generations", openAIImageRequest{
+		Model:          a.model,
+		Prompt:         prompt,
+		N:              1,
+		Size:           size,
+		ResponseFormat: "b64_json",
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	var resp openAIImageResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, "", fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Data) == 0 || resp.Data[0].B64JSON == "" {
+		return nil, "", fmt.Errorf("no image in API response")
+	}
+
+	data, err := base64.StdEncoding.DecodeString(resp.Data[0].B64JSON)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return data, resp.Data[0].RevisedPrompt, nil
+}
+
+// generateStability usa el endpoint text-to-image de Stability
+func (a *imageAgent) generateStability(ctx context.Context, prompt, negative string, width, height int) ([]byte, error) {
+	prompts := []stabilityPrompt{{Text: prompt, Weight: 1}}
+	if negative != "" {
+		prompts = append(prompts, stabilityPrompt{Text: negative, Weight: -1})
+	}
+
+	body, err := a.postJSON(ctx, fmt.Sprintf("%s/v1/generation/%s/text-to-image", a.baseURL, a.model), stabilityRequest{
+		TextPrompts: prompts,
+		Width:       width,
+		Height:      height,
+		Samples:     1,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var resp stabilityResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(resp.Artifacts) == 0 || resp.Artifacts[0].Base64 == "" {
+		return nil, fmt.Errorf("no image in API response")
+	}
+	if reason := resp.Artifacts[0].FinishReason; reason == "CONTENT_FILTERED" {
+		return nil, fmt.Errorf("image rejected by content filter")
+	}
+
+	return base64.StdEncoding.DecodeString(resp.Artifacts[0].Base64)
+}
+
+func (a *imageAgent) postJSON(ctx context.Context, url string, payload interface{}) ([]byte, error) {
+	jsonBody, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return body, nil
+}
+
+func parseImageSize(size string) (int, int, error) {
+	parts := strings.SplitN(strings.ToLower(size), "x", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("invalid image size: %s", size)
+	}
+	width, errW := strconv.Atoi(parts[0])
+	height, errH := strconv.Atoi(parts[1])
+	if errW != nil || errH != nil || width <= 0 || height <= 0 || width > 2048 || height > 2048 {
+		return 0, 0, fmt.Errorf("invalid image size: %s", size)
+	}
+	return width, height, nil
+}
+
+// placeholderImage genera un PNG de un color derivado del prompt, para
+// desarrollo sin API key
+func placeholderImage(prompt string, width, height int) ([]byte, error) {
+	// Se reduce el tamaño para no ocupar espacio con imágenes de prueba
+	width, height = width/8, height/8
+	sum := sha256.Sum256([]byte(prompt))
+	fill := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, fill)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,171 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestObjectStore(t *testing.T) adapters.ObjectStoreAdapter {
+	t.Helper()
+	store := adapters.NewLocalObjectStore("files", t.TempDir(), "https://files.example.com", logger.NewLogger("error"))
+	require.NoError(t, store.Start(context.Background()))
+	return store
+}
+
+func newTestImageAgent(t *testing.T, config map[string]interface{}, store adapters.ObjectStoreAdapter) Agent {
+	t.Helper()
+	agent, err := NewImageAgent(MCPConfig{Type: "image", Name: "images", Config: config}, store, logger.NewLogger("error"))
+	require.NoError(t, err)
+	return agent
+}
+
+func TestImageAgent_OpenAI(t *testing.T) {
+	ctx := context.Background()
+	image, err := placeholderImage("a red bicycle", 64, 64)
+	require.NoError(t, err)
+
+	var received openAIImageRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/images/generations", r.URL.Path)
+		assert.Equal(t, "Bearer sk-live", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		fmt.Fprintf(w, `{"data": [{"b64_json": %q, "revised_prompt": "A red bicycle on a street"}]}`, base64.StdEncoding.EncodeToString(image))
+	}))
+	defer server.Close()
+
+	store := newTestObjectStore(t)
+	agent := newTestImageAgent(t, map[string]interface{}{"openai_api_key": "sk-live", "base_url": server.URL + "/"}, store)
+
+	result, err := agent.Execute(ctx, Task{ID: "draw", Type: "image_generation", Input: map[string]interface{}{
+		"prompt": "a red bicycle", "size": "512x512", "key_prefix": "/bots/bot-1/",
+	}})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, openAIImageRequest{Model: "dall-e-3", Prompt: "a red bicycle", N: 1, Size: "512x512", ResponseFormat: "b64_json"}, received)
+
+	// La imagen del proveedor queda en el almacenamiento con su URL pública
+	key := result.Output["key"].(string)
+	assert.True(t, strings.HasPrefix(key, "bots/bot-1/"), key)
+	assert.True(t, strings.HasSuffix(key, ".png"), key)
+	assert.Equal(t, "https://files.example.com/"+key, result.Output["image_url"])
+	assert.Equal(t, "image/png", result.Output["content_type"])
+	assert.Equal(t, "A red bicycle on a street", result.Output["revised_prompt"])
+	assert.Equal(t, "real", result.Metadata["mode"])
+	stored, err := store.GetObject(ctx, key)
+	require.NoError(t, err)
+	assert.Equal(t, image, stored)
+}
+
+func TestImageAgent_Stability(t *testing.T) {
+	ctx := context.Background()
+	var received stabilityRequest
+	filtered := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/generation/sdxl-test/text-to-image", r.URL.Path)
+		assert.Equal(t, "Bearer sk-stability", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		reason := "SUCCESS"
+		if filtered {
+			reason = "CONTENT_FILTERED"
+		}
+		fmt.Fprintf(w, `{"artifacts": [{"base64": %q, "finishReason": %q}]}`, base64.StdEncoding.EncodeToString([]byte("png-bytes")), reason)
+	}))
+	defer server.Close()
+
+	store := newTestObjectStore(t)
+	agent := newTestImageAgent(t, map[string]interface{}{
+		"provider": ImageProviderStability, "stability_api_key": "sk-stability", "model": "sdxl-test", "base_url": server.URL,
+	}, store)
+
+	result, err := agent.Execute(ctx, Task{ID: "draw", Type: "image", Input: map[string]interface{}{
+		"prompt": "a lighthouse", "negative_prompt": "people", "size": "768x512",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, stabilityRequest{
+		TextPrompts: []stabilityPrompt{{Text: "a lighthouse", Weight: 1}, {Text: "people", Weight: -1}},
+		Width:       768, Height: 512, Samples: 1,
+	}, received)
+	assert.True(t, strings.HasPrefix(result.Output["key"].(string), "images/"))
+	stored, err := store.GetObject(ctx, result.Output["key"].(string))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("png-bytes"), stored)
+
+	// Una imagen filtrada no se guarda
+	filtered = true
+	result, err = agent.Execute(ctx, Task{ID: "filtered", Type: "image", Input: map[string]interface{}{"prompt": "a lighthouse"}})
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "image rejected by content filter")
+}
+
+func TestImageAgent_Failures(t *testing.T) {
+	ctx := context.Background()
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		switch calls {
+		case 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error": "rate limited"}`)
+		default:
+			fmt.Fprint(w, `{"data": []}`)
+		}
+	}))
+	defer server.Close()
+
+	agent := newTestImageAgent(t, map[string]interface{}{"openai_api_key": "sk-live", "base_url": server.URL}, newTestObjectStore(t))
+
+	result, err := agent.Execute(ctx, Task{ID: "limited", Input: map[string]interface{}{"prompt": "a cat"}})
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusTooManyRequests, apiErr.StatusCode)
+	assert.False(t, result.Success)
+
+	_, err = agent.Execute(ctx, Task{ID: "empty", Input: map[string]interface{}{"prompt": "a cat"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no image in API response")
+
+	// Las entradas inválidas fallan sin llamar al proveedor
+	_, err = agent.Execute(ctx, Task{ID: "no-prompt", Input: map[string]interface{}{"prompt": " "}})
+	assert.EqualError(t, err, "prompt is required")
+	_, err = agent.Execute(ctx, Task{ID: "too-big", Input: map[string]interface{}{"prompt": "a cat", "size": "4096x4096"}})
+	assert.EqualError(t, err, "invalid image size: 4096x4096")
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 4, agent.GetState().Metrics.TasksFailed)
+}
+
+func TestImageAgent_MockWithoutAPIKey(t *testing.T) {
+	ctx := context.Background()
+	store := newTestObjectStore(t)
+	agent := newTestImageAgent(t, map[string]interface{}{}, store)
+
+	result, err := agent.Execute(ctx, Task{ID: "draw", Input: map[string]interface{}{"prompt": "a red bicycle", "size": "256x128"}})
+	require.NoError(t, err)
+	assert.Equal(t, "mock", result.Metadata["mode"])
+
+	stored, err := store.GetObject(ctx, result.Output["key"].(string))
+	require.NoError(t, err)
+	placeholder, err := png.Decode(bytes.NewReader(stored))
+	require.NoError(t, err)
+	assert.Equal(t, 32, placeholder.Bounds().Dx())
+	assert.Equal(t, 16, placeholder.Bounds().Dy())
+
+	_, err = NewImageAgent(MCPConfig{Type: "image", Name: "images"}, nil, logger.NewLogger("error"))
+	assert.Error(t, err)
+	_, err = NewImageAgent(MCPConfig{Type: "image", Name: "images", Config: map[string]interface{}{"provider": "other"}}, store, logger.NewLogger("error"))
+	assert.EqualError(t, err, "unsupported image provider: other")
+}
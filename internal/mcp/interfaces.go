@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 	
+	"github.com/company/bot-service/internal/adapters"
 	"github.com/company/bot-service/internal/domain"
 )
 
@@ -15,6 +16,8 @@ type MCPConfig struct {
 	Config      map[string]interface{} `json:"config"`      // Configuración específica
 	Capabilities []string              `json:"capabilities"` // Capacidades del agente
 	Timeout     time.Duration          `json:"timeout"`     // Timeout para operaciones
+	Persistent  bool                   `json:"persistent"`  // Se guarda y se restaura al reiniciar el servicio
+	ID          string                 `json:"-"`           // ID fijo, solo para restaurar agentes persistidos
 }
 
 // Task representa una tarea que debe ejecutar un agente
@@ -47,6 +50,7 @@ type AgentState struct {
 	LastActivity time.Time              `json:"last_activity"`
 	Metrics      AgentMetrics           `json:"metrics"`
 	Context      map[string]interface{} `json:"context"`
+	ConfigHistory []ConfigChange        `json:"config_history,omitempty"`
 }
 
 // AgentStatus representa los posibles estados de un agente
@@ -83,6 +87,7 @@ type Agent interface {
 	// Estado del agente
 	GetState() AgentState
 	UpdateState(state AgentState) error
+	GetHistory() AgentHistory
 	
 	// Gestión del contexto
 	SetContext(ctx map[string]interface{}) error
@@ -94,6 +99,22 @@ type Agent interface {
 	IsHealthy() bool
 }
 
+// StreamChunk representa un fragmento parcial de la salida de un agente
+type StreamChunk struct {
+	Content      string                 `json:"content"`
+	Done         bool                   `json:"done"`
+	FinishReason string                 `json:"finish_reason,omitempty"`
+	Error        string                 `json:"error,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// StreamingAgent es implementado por los agentes que pueden emitir su salida
+// de forma incremental. El canal se cierra después del fragmento con Done en true.
+type StreamingAgent interface {
+	Agent
+	ExecuteStream(ctx context.Context, task Task) (<-chan StreamChunk, error)
+}
+
 // MCPOrchestrator interface define las operaciones de orquestación
 type MCPOrchestrator interface {
 	// Gestión de agentes
@@ -101,10 +122,20 @@ type MCPOrchestrator interface {
 	GetAgent(agentID string) (Agent, error)
 	ListAgents() []Agent
 	TerminateAgent(ctx context.Context, agentID string) error
+	UpdateAgentConfig(ctx context.Context, agentID string, changes map[string]interface{}) (ConfigChange, error)
+	GetAgentHistory(agentID string) (AgentHistory, error)
 	
 	// Coordinación de tareas
 	ExecuteTask(ctx context.Context, task Task) (Result, error)
+	ExecuteTaskStream(ctx context.Context, task Task) (<-chan StreamChunk, error)
 	CoordinateAgents(ctx context.Context, agents []Agent, task Task) (Result, error)
+	PauseDispatch(agentType, reason string) error
+	ResumeDispatch(agentType string, drainRate float64) error
+	GetDispatchStatus() DispatchStatus
+	ConfigureScheduler(config SchedulerConfig)
+	ConfigureBreakers(config BreakerConfig)
+	GetBreakerStatus(agentID string) (BreakerStatus, error)
+	RegisterTool(definition ToolDefinition, fn ToolFunc) error
 	
 	// Gestión de contexto
 	PassContext(ctx context.Context, agentID string, context map[string]interface{}) error
@@ -117,6 +148,8 @@ type MCPOrchestrator interface {
 	// Ciclo de vida
 	Start(ctx context.Context) error
 	Stop(ctx context.Context) error
+	UsePersistence(repo domain.MCPAgentRepository, config PersistenceConfig)
+	RestoreAgents(ctx context.Context) (int, error)
 }
 
 // SystemMetrics representa métricas del sistema de orquestación
@@ -136,7 +169,15 @@ type SystemMetrics struct {
 type AgentFactory interface {
 	CreateAgent(config MCPConfig) (Agent, error)
 	GetSupportedTypes() []string
+	GetAgentTypes() []AgentTypeInfo
+	RegisterAgentType(def AgentTypeDefinition) error
 	ValidateConfig(config MCPConfig) error
+	RegisterAdapter(name string, adapter adapters.Adapter) error
+	AllowMCPServerCommands(commands []string)
+	UseAuthProfiles(profiles *adapters.AuthProfiles)
+	UseWorkflowDefinitions(definitions domain.WorkflowDefinitionRepository)
+	UseConditionEvaluator(evaluator ConditionEvaluator)
+	UseScriptApprovals(approvals domain.ScriptApprovalRepository)
 }
 
 // MCPDomainOrchestrator interface adicional para trabajar con estructuras de dominio
@@ -147,4 +188,5 @@ type MCPDomainOrchestrator interface {
 	GetSystemMetricsDomain() (*domain.MCPSystemMetrics, error)
 	CreateAgentFromDomain(ctx context.Context, agentConfig *domain.MCPAgent) (Agent, error)
 	GetSupportedAgentTypes() []string
+	GetAgentTypes() []AgentTypeInfo
 }
\ No newline at end of file
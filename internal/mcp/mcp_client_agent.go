@@ -0,0 +1,391 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Transportes con los que el agente mcp_client se conecta a un servidor MCP
+const (
+	MCPTransportStdio = "stdio"
+	MCPTransportSSE   = "sse"
+)
+
+const (
+	// mcpToolCallTask es el tipo de tarea genérico: la herramienta va en input.tool
+	mcpToolCallTask          = "mcp_tool_call"
+	defaultMCPConnectTimeout = 10 * time.Second
+	defaultMCPCallTimeout    = 30 * time.Second
+)
+
+// MCPToolAgent es implementado por los agentes que exponen herramientas de un servidor MCP
+type MCPToolAgent interface {
+	Agent
+	Tools() []MCPTool
+}
+
+// AgentTools devuelve las herramientas MCP del agente, o nil si no es un cliente MCP
+func AgentTools(agent Agent) []MCPTool {
+	if toolAgent, ok := unwrapAgent(agent).(MCPToolAgent); ok {
+		return toolAgent.Tools()
+	}
+	return nil
+}
+
+// mcpClientAgent se conecta a un servidor MCP estándar y publica cada una de
+// sus herramientas como tipo de tarea (tool_prefix + nombre)
+type mcpClientAgent struct {
+	*baseAgent
+	transport      string
+	command        string
+	args           []string
+	env            map[string]string
+	serverURL      string
+	headers        map[string]string
+	toolPrefix     string
+	allowedTools   map[string]bool
+	connectTimeout time.Duration
+	callTimeout    time.Duration
+	httpClient     *http.Client
+
+	// connMu serializa las conexiones; client, server y tools van bajo baseAgent.mu
+	connMu sync.Mutex
+	client *mcpClient
+	server string
+	tools  map[string]MCPTool
+}
+
+// NewMCPClientAgent crea un agente cliente de MCP. La conexión se abre en Start.
+func NewMCPClientAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{mcpToolCallTask}
+
+	values := configschema.Values(config.Config)
+	transport := values.String("transport", MCPTransportStdio)
+	if transport != MCPTransportStdio && transport != MCPTransportSSE {
+		return nil, fmt.Errorf("unsupported mcp transport: %s", transport)
+	}
+
+	allowedTools := make(map[string]bool)
+	for _, tool := range values.Strings("tools") {
+		allowedTools[tool] = true
+	}
+
+	callTimeout := defaultMCPCallTimeout
+	if config.Timeout > 0 {
+		callTimeout = config.Timeout
+	}
+
+	return &mcpClientAgent{
+		baseAgent:      base,
+		transport:      transport,
+		command:        values.String("command", ""),
+		args:           values.Strings("args"),
+		env:            values.StringMap("env"),
+		serverURL:      values.String("url", ""),
+		headers:        values.StringMap("headers"),
+		toolPrefix:     values.String("tool_prefix", ""),
+		allowedTools:   allowedTools,
+		connectTimeout: values.Duration("connect_timeout", defaultMCPConnectTimeout),
+		callTimeout:    callTimeout,
+		httpClient:     &http.Client{Timeout: callTimeout},
+		tools:          make(map[string]MCPTool),
+	}, nil
+}
+
+// Start se conecta al servidor y descubre sus herramientas
+func (a *mcpClientAgent) Start(ctx context.Context) error {
+	if _, err := a.connect(ctx); err != nil {
+		return err
+	}
+	return a.baseAgent.Start(ctx)
+}
+
+// Stop cierra la conexión con el servidor
+func (a *mcpClientAgent) Stop(ctx context.Context) error {
+	a.connMu.Lock()
+	a.mu.Lock()
+	client := a.client
+	a.client = nil
+	a.mu.Unlock()
+	a.connMu.Unlock()
+
+	if client != nil {
+		_ = client.close()
+	}
+	return a.baseAgent.Stop(ctx)
+}
+
+// IsHealthy exige además que la conexión con el servidor siga abierta
+func (a *mcpClientAgent) IsHealthy() bool {
+	a.mu.RLock()
+	client := a.client
+	a.mu.RUnlock()
+	return a.baseAgent.IsHealthy() && client != nil && !client.isClosed()
+}
+
+// connect devuelve la conexión abierta o abre una nueva si el servidor la cerró
+func (a *mcpClientAgent) connect(ctx context.Context) (*mcpClient, error) {
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	a.mu.RLock()
+	current := a.client
+	a.mu.RUnlock()
+	if current != nil && !current.isClosed() {
+		return current, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, a.connectTimeout)
+	defer cancel()
+
+	var transport mcpTransport
+	var stdio *stdioTransport
+	var err error
+	switch a.transport {
+	case MCPTransportSSE:
+		transport, err = connectSSETransport(ctx, a.serverURL, a.headers, a.httpClient)
+	default:
+		stdio, err = startStdioTransport(a.command, a.args, a.env)
+		transport = stdio
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client := newMCPClient(transport, a.logger, a.refreshTools)
+	server, err := client.initialize(ctx, a.version)
+	var tools []MCPTool
+	if err == nil {
+		tools, err = client.listTools(ctx)
+	}
+	if err != nil {
+		_ = client.close()
+		if stdio != nil {
+			if reason := stdio.exitReason(); reason != "" {
+				err = fmt.Errorf("%w (server exited: %s)", err, reason)
+			}
+		}
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.client = client
+	a.server = server
+	a.mu.Unlock()
+	a.setTools(tools)
+
+	a.logger.Info("MCP server connected",
+		"agent_id", a.id,
+		"transport", a.transport,
+		"server", server,
+		"tools", len(tools))
+	return client, nil
+}
+
+// refreshTools vuelve a pedir la lista cuando el servidor avisa que cambió
+func (a *mcpClientAgent) refreshTools() {
+	a.mu.RLock()
+	client := a.client
+	a.mu.RUnlock()
+	if client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.connectTimeout)
+	defer cancel()
+	tools, err := client.listTools(ctx)
+	if err != nil {
+		a.logger.Warn("Failed to refresh MCP tools", "agent_id", a.id, "error", err)
+		return
+	}
+	a.setTools(tools)
+}
+
+// setTools publica las herramientas permitidas como tipos de tarea y capacidades
+func (a *mcpClientAgent) setTools(tools []MCPTool) {
+	byTask := make(map[string]MCPTool, len(tools))
+	capabilities := []string{mcpToolCallTask}
+	for _, tool := range tools {
+		if len(a.allowedTools) > 0 && !a.allowedTools[tool.Name] {
+			continue
+		}
+		taskType := a.toolPrefix + tool.Name
+		byTask[taskType] = tool
+		capabilities = append(capabilities, taskType)
+	}
+	sort.Strings(capabilities[1:])
+
+	a.mu.Lock()
+	a.tools = byTask
+	a.capabilities = capabilities
+	a.mu.Unlock()
+}
+
+// Tools devuelve las herramientas que el agente puede ejecutar
+func (a *mcpClientAgent) Tools() []MCPTool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	tools := make([]MCPTool, 0, len(a.tools))
+	for _, tool := range a.tools {
+		tools = append(tools, tool)
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+// toolForTask devuelve la herramienta publicada como taskType
+func (a *mcpClientAgent) toolForTask(taskType string) (MCPTool, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	tool, ok := a.tools[taskType]
+	return tool, ok
+}
+
+func (a *mcpClientAgent) CanHandle(taskType string) bool {
+	if taskType == mcpToolCallTask {
+		return true
+	}
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	_, ok := a.tools[taskType]
+	return ok
+}
+
+// resolveTool obtiene la herramienta y sus argumentos. En mcp_tool_call van en
+// input.tool e input.arguments; en el resto la entrada son los argumentos.
+func (a *mcpClientAgent) resolveTool(task Task) (string, map[string]interface{}, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if task.Type != mcpToolCallTask {
+		tool, ok := a.tools[task.Type]
+		if !ok {
+			return "", nil, fmt.Errorf("unknown mcp tool for task type %s", task.Type)
+		}
+		return tool.Name, task.Input, nil
+	}
+
+	name, _ := task.Input["tool"].(string)
+	if _, ok := a.tools[a.toolPrefix+name]; !ok || name == "" {
+		return "", nil, fmt.Errorf("unknown mcp tool: %s", name)
+	}
+	arguments, _ := task.Input["arguments"].(map[string]interface{})
+	return name, arguments, nil
+}
+
+func (a *mcpClientAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	fail := func(tool string, err error) (Result, error) {
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		return Result{
+			TaskID:   task.ID,
+			Success:  false,
+			Error:    err.Error(),
+			Duration: duration,
+			Metadata: map[string]interface{}{
+				"agent_id":   a.id,
+				"agent_type": a.agentType,
+				"tool":       tool,
+			},
+		}, err
+	}
+
+	tool, arguments, err := a.resolveTool(task)
+	if err != nil {
+		return fail(tool, err)
+	}
+	client, err := a.connect(ctx)
+	if err != nil {
+		return fail(tool, fmt.Errorf("failed to connect to mcp server: %w", err))
+	}
+
+	callCtx, cancel := context.WithTimeout(ctx, a.callTimeout)
+	defer cancel()
+	response, err := client.callTool(callCtx, tool, arguments)
+	if err != nil {
+		return fail(tool, fmt.Errorf("mcp tool %s failed: %w", tool, err))
+	}
+
+	var texts []string
+	for _, item := range response.Content {
+		if text, ok := item["text"].(string); ok && item["type"] == "text" {
+			texts = append(texts, text)
+		}
+	}
+	text := strings.Join(texts, "\n")
+
+	duration := time.Since(start)
+	a.updateMetrics(!response.IsError, duration)
+
+	a.mu.RLock()
+	server := a.server
+	a.mu.RUnlock()
+	result := Result{
+		TaskID:  task.ID,
+		Success: !response.IsError,
+		Output: map[string]interface{}{
+			"tool":    tool,
+			"text":    text,
+			"content": response.Content,
+		},
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"tool":       tool,
+			"server":     server,
+		},
+	}
+	if response.StructuredContent != nil {
+		result.Output["structured_content"] = response.StructuredContent
+	}
+	// Un error de la herramienta es una respuesta válida del servidor, como un HTTP 4xx
+	if response.IsError {
+		result.Error = text
+		if result.Error == "" {
+			result.Error = "mcp tool returned an error"
+		}
+	}
+	return result, nil
+}
+
+// validateMCPClientConfig exige el comando o la URL según el transporte. Los
+// comandos stdio deben estar en la lista de la factory: crear el agente lanza
+// un proceso en el servidor.
+func validateMCPClientConfig(config MCPConfig, allowedCommands []string) error {
+	values := configschema.Values(config.Config)
+	result := &configschema.ValidationError{}
+	switch values.String("transport", MCPTransportStdio) {
+	case MCPTransportSSE:
+		if values.String("url", "") == "" {
+			result.Add("config.url", "is required for the sse transport")
+		}
+	default:
+		command := values.String("command", "")
+		switch {
+		case command == "":
+			result.Add("config.command", "is required for the stdio transport")
+		case !containsString(allowedCommands, command):
+			result.Add("config.command", "command %s is not allowed, see MCP_SERVER_COMMANDS", command)
+		}
+	}
+	return result.Err()
+}
@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Con MCP_FAKE_SERVER el binario de test hace de servidor MCP por stdio
+func TestMain(m *testing.M) {
+	if os.Getenv("MCP_FAKE_SERVER") == "1" {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if reply := fakeMCPReply(scanner.Bytes()); reply != nil {
+				fmt.Println(string(reply))
+			}
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// fakeMCPReply responde como un servidor MCP con las herramientas echo y fail,
+// listadas en dos páginas
+func fakeMCPReply(raw []byte) []byte {
+	var msg rpcMessage
+	if err := json.Unmarshal(raw, &msg); err != nil || msg.ID == nil {
+		return nil
+	}
+	var params struct {
+		Cursor    string                 `json:"cursor"`
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	}
+	_ = json.Unmarshal(msg.Params, &params)
+
+	var result interface{}
+	switch {
+	case msg.Method == "initialize":
+		result = map[string]interface{}{"protocolVersion": mcpProtocolVersion, "serverInfo": map[string]string{"name": "fake"}}
+	case msg.Method == "tools/list" && params.Cursor == "":
+		result = map[string]interface{}{"tools": []MCPTool{{Name: "echo", Description: "Echoes the text"}}, "nextCursor": "2"}
+	case msg.Method == "tools/list":
+		result = map[string]interface{}{"tools": []MCPTool{{Name: "fail"}}}
+	case params.Name == "echo":
+		result = map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": params.Arguments["text"]}}}
+	default:
+		result = map[string]interface{}{"content": []map[string]interface{}{{"type": "text", "text": "boom"}}, "isError": true}
+	}
+	reply, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": *msg.ID, "result": result})
+	return reply
+}
+
+// newFakeSSEServer sirve el transporte HTTP+SSE con fakeMCPReply
+func newFakeSSEServer(t *testing.T) *httptest.Server {
+	replies := make(chan []byte, 16)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sse", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: endpoint\ndata: /messages?session=1\n\n")
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case reply := <-replies:
+				fmt.Fprintf(w, "event: message\ndata: %s\n\n", reply)
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+	mux.HandleFunc("/messages", func(w http.ResponseWriter, r *http.Request) {
+		var raw json.RawMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&raw))
+		if reply := fakeMCPReply(raw); reply != nil {
+			replies <- reply
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMCPClientAgent_CallsServerTools(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	factory := NewAgentFactory(log)
+	factory.AllowMCPServerCommands([]string{os.Args[0]})
+	o := NewOrchestrator(factory, log)
+
+	configs := map[string]map[string]interface{}{
+		"stdio": {"command": os.Args[0], "env": map[string]interface{}{"MCP_FAKE_SERVER": "1"}, "tool_prefix": "fake."},
+		"sse":   {"transport": "sse", "url": newFakeSSEServer(t).URL + "/sse", "tool_prefix": "fake."},
+	}
+	for transport, config := range configs {
+		t.Run(transport, func(t *testing.T) {
+			agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "mcp_client", Name: transport, Config: config})
+			require.NoError(t, err)
+			defer o.TerminateAgent(ctx, agent.GetID())
+
+			assert.Equal(t, []string{"mcp_tool_call", "fake.echo", "fake.fail"}, agent.GetCapabilities())
+			assert.Len(t, AgentTools(agent), 2)
+			assert.True(t, agent.IsHealthy())
+
+			result, err := o.ExecuteTask(ctx, Task{ID: "echo", Type: "fake.echo", Input: map[string]interface{}{"text": "hola"}})
+			require.NoError(t, err)
+			assert.True(t, result.Success)
+			assert.Equal(t, "hola", result.Output["text"])
+
+			result, err = o.ExecuteTask(ctx, Task{ID: "fail", Type: "mcp_tool_call", Input: map[string]interface{}{"tool": "fail"}})
+			require.NoError(t, err)
+			assert.False(t, result.Success)
+			assert.Equal(t, "boom", result.Error)
+		})
+	}
+
+	_, err := o.InstantiateMCP(ctx, MCPConfig{Type: "mcp_client", Name: "shell", Config: map[string]interface{}{"command": "/bin/sh"}})
+	assert.ErrorContains(t, err, "not allowed")
+}
@@ -0,0 +1,496 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// mcpProtocolVersion es la versión del Model Context Protocol que habla el cliente
+const mcpProtocolVersion = "2024-11-05"
+
+// mcpStderrLimit es cuánto se guarda de la salida de error de un servidor stdio
+const mcpStderrLimit = 4 * 1024
+
+// ErrMCPConnectionClosed indica que el servidor MCP cerró la conexión
+var ErrMCPConnectionClosed = errors.New("mcp server connection closed")
+
+// MCPTool es una herramienta publicada por un servidor MCP
+type MCPTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"inputSchema,omitempty"`
+}
+
+// mcpToolResult es la respuesta de tools/call
+type mcpToolResult struct {
+	Content           []map[string]interface{} `json:"content"`
+	StructuredContent map[string]interface{}   `json:"structuredContent,omitempty"`
+	IsError           bool                     `json:"isError"`
+}
+
+// rpcMessage cubre peticiones, respuestas y notificaciones JSON-RPC 2.0
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// mcpTransport envía y recibe mensajes JSON-RPC completos
+type mcpTransport interface {
+	send(ctx context.Context, message []byte) error
+	// messages se cierra cuando se pierde la conexión
+	messages() <-chan []byte
+	close() error
+}
+
+// mcpClient habla el Model Context Protocol sobre un transporte
+type mcpClient struct {
+	transport mcpTransport
+	logger    logger.Logger
+	// onToolsChanged se llama cuando el servidor avisa que cambió su lista de herramientas
+	onToolsChanged func()
+
+	nextID  atomic.Int64
+	mu      sync.Mutex
+	pending map[int64]chan rpcMessage
+	closed  chan struct{}
+}
+
+// newMCPClient empieza a leer los mensajes del transporte
+func newMCPClient(transport mcpTransport, logger logger.Logger, onToolsChanged func()) *mcpClient {
+	c := &mcpClient{
+		transport:      transport,
+		logger:         logger,
+		onToolsChanged: onToolsChanged,
+		pending:        make(map[int64]chan rpcMessage),
+		closed:         make(chan struct{}),
+	}
+	go c.readLoop()
+	return c
+}
+
+// readLoop entrega las respuestas a quien las espera y atiende las peticiones del servidor
+func (c *mcpClient) readLoop() {
+	defer close(c.closed)
+	for raw := range c.transport.messages() {
+		var msg rpcMessage
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			c.logger.Warn("Ignoring invalid MCP message", "error", err)
+			continue
+		}
+
+		switch {
+		case msg.Method != "" && msg.ID != nil:
+			c.answerServerRequest(msg)
+		case msg.Method != "":
+			if msg.Method == "notifications/tools/list_changed" && c.onToolsChanged != nil {
+				go c.onToolsChanged()
+			}
+		case msg.ID != nil:
+			c.mu.Lock()
+			ch, ok := c.pending[*msg.ID]
+			delete(c.pending, *msg.ID)
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		}
+	}
+}
+
+// answerServerRequest responde a ping; el cliente no ofrece otras capacidades
+func (c *mcpClient) answerServerRequest(msg rpcMessage) {
+	reply := rpcMessage{JSONRPC: "2.0", ID: msg.ID}
+	if msg.Method == "ping" {
+		reply.Result = json.RawMessage(`{}`)
+	} else {
+		reply.Error = &rpcError{Code: -32601, Message: "method not found: " + msg.Method}
+	}
+	raw, _ := json.Marshal(reply)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.transport.send(ctx, raw); err != nil {
+		c.logger.Warn("Failed to answer MCP server request", "method", msg.Method, "error", err)
+	}
+}
+
+// call envía una petición y espera su respuesta
+func (c *mcpClient) call(ctx context.Context, method string, params, result interface{}) error {
+	id := c.nextID.Add(1)
+	raw, err := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params})
+	if err != nil {
+		return err
+	}
+
+	ch := make(chan rpcMessage, 1)
+	c.mu.Lock()
+	c.pending[id] = ch
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.transport.send(ctx, raw); err != nil {
+		return fmt.Errorf("failed to send %s: %w", method, err)
+	}
+
+	select {
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("invalid %s response: %w", method, err)
+		}
+		return nil
+	case <-c.closed:
+		return ErrMCPConnectionClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// notify envía una notificación, que no tiene respuesta
+func (c *mcpClient) notify(ctx context.Context, method string) error {
+	raw, _ := json.Marshal(map[string]interface{}{"jsonrpc": "2.0", "method": method})
+	return c.transport.send(ctx, raw)
+}
+
+// initialize hace el saludo inicial y devuelve el nombre del servidor
+func (c *mcpClient) initialize(ctx context.Context, version string) (string, error) {
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ServerInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"serverInfo"`
+	}
+	params := map[string]interface{}{
+		"protocolVersion": mcpProtocolVersion,
+		"capabilities":    map[string]interface{}{},
+		"clientInfo":      map[string]interface{}{"name": "it-bot-service", "version": version},
+	}
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return "", fmt.Errorf("mcp initialize failed: %w", err)
+	}
+	if err := c.notify(ctx, "notifications/initialized"); err != nil {
+		return "", fmt.Errorf("mcp initialize failed: %w", err)
+	}
+	return result.ServerInfo.Name, nil
+}
+
+// listTools recorre todas las páginas de tools/list
+func (c *mcpClient) listTools(ctx context.Context) ([]MCPTool, error) {
+	var tools []MCPTool
+	cursor := ""
+	for {
+		params := map[string]interface{}{}
+		if cursor != "" {
+			params["cursor"] = cursor
+		}
+		var page struct {
+			Tools      []MCPTool `json:"tools"`
+			NextCursor string    `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", params, &page); err != nil {
+			return nil, fmt.Errorf("mcp tools/list failed: %w", err)
+		}
+		tools = append(tools, page.Tools...)
+		if page.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// callTool ejecuta una herramienta del servidor
+func (c *mcpClient) callTool(ctx context.Context, name string, arguments map[string]interface{}) (mcpToolResult, error) {
+	if arguments == nil {
+		arguments = map[string]interface{}{}
+	}
+	var result mcpToolResult
+	err := c.call(ctx, "tools/call", map[string]interface{}{"name": name, "arguments": arguments}, &result)
+	return result, err
+}
+
+// isClosed indica si se perdió la conexión con el servidor
+func (c *mcpClient) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *mcpClient) close() error {
+	return c.transport.close()
+}
+
+// stdioTransport lanza el servidor MCP como proceso e intercambia un mensaje
+// JSON por línea en su entrada y salida estándar
+type stdioTransport struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	incoming chan []byte
+	stderr   *cappedBuffer
+	writeMu  sync.Mutex
+	exited   chan struct{}
+}
+
+// startStdioTransport lanza el comando; el proceso vive hasta close, no
+// hasta que termina el contexto de quien crea el agente
+func startStdioTransport(command string, args []string, env map[string]string) (*stdioTransport, error) {
+	cmd := exec.Command(command, args...)
+	cmd.Env = os.Environ()
+	for key, value := range env {
+		cmd.Env = append(cmd.Env, key+"="+value)
+	}
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	t := &stdioTransport{
+		cmd:      cmd,
+		stdin:    stdin,
+		incoming: make(chan []byte, 16),
+		stderr:   &cappedBuffer{limit: mcpStderrLimit},
+		exited:   make(chan struct{}),
+	}
+	cmd.Stderr = t.stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start mcp server: %w", err)
+	}
+
+	go func() {
+		defer close(t.incoming)
+		reader := bufio.NewReaderSize(stdout, 64*1024)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				t.incoming <- line
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	go func() {
+		_ = cmd.Wait()
+		close(t.exited)
+	}()
+	return t, nil
+}
+
+func (t *stdioTransport) send(ctx context.Context, message []byte) error {
+	t.writeMu.Lock()
+	defer t.writeMu.Unlock()
+	if _, err := t.stdin.Write(append(message, '\n')); err != nil {
+		return fmt.Errorf("%w: %v", ErrMCPConnectionClosed, err)
+	}
+	return nil
+}
+
+func (t *stdioTransport) messages() <-chan []byte {
+	return t.incoming
+}
+
+// close cierra la entrada del servidor y lo mata si no termina a tiempo
+func (t *stdioTransport) close() error {
+	_ = t.stdin.Close()
+	select {
+	case <-t.exited:
+	case <-time.After(2 * time.Second):
+		_ = t.cmd.Process.Kill()
+		<-t.exited
+	}
+	return nil
+}
+
+// exitReason describe por qué terminó el servidor, con su salida de error
+func (t *stdioTransport) exitReason() string {
+	select {
+	case <-t.exited:
+	default:
+		return ""
+	}
+	reason := t.cmd.ProcessState.String()
+	if stderr := strings.TrimSpace(t.stderr.String()); stderr != "" {
+		reason += ": " + stderr
+	}
+	return reason
+}
+
+// sseTransport usa el transporte HTTP+SSE: los mensajes del servidor llegan
+// por un stream de eventos y los del cliente se envían con POST al endpoint
+// que anuncia el servidor en el evento "endpoint"
+type sseTransport struct {
+	client   *http.Client
+	headers  map[string]string
+	endpoint string
+	incoming chan []byte
+	cancel   context.CancelFunc
+}
+
+// connectSSETransport abre el stream y espera el endpoint de mensajes
+func connectSSETransport(ctx context.Context, serverURL string, headers map[string]string, client *http.Client) (*sseTransport, error) {
+	streamCtx, cancel := context.WithCancel(context.Background())
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, serverURL, nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	// El stream no usa el timeout del cliente: dura lo que dure el agente
+	streamClient := &http.Client{Transport: client.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to connect to mcp server: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("mcp server returned HTTP %d", resp.StatusCode)
+	}
+
+	t := &sseTransport{
+		client:   client,
+		headers:  headers,
+		incoming: make(chan []byte, 16),
+		cancel:   cancel,
+	}
+	endpoints := make(chan string, 1)
+	go t.readEvents(resp.Body, serverURL, endpoints)
+
+	select {
+	case endpoint, ok := <-endpoints:
+		if !ok {
+			cancel()
+			return nil, fmt.Errorf("mcp server closed the stream before sending its endpoint")
+		}
+		t.endpoint = endpoint
+		return t, nil
+	case <-ctx.Done():
+		cancel()
+		return nil, fmt.Errorf("waiting for mcp endpoint: %w", ctx.Err())
+	}
+}
+
+// readEvents interpreta el stream SSE hasta que se cierra
+func (t *sseTransport) readEvents(body io.ReadCloser, serverURL string, endpoints chan<- string) {
+	defer body.Close()
+	defer close(t.incoming)
+	defer close(endpoints)
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	event, data := "", []string{}
+	sentEndpoint := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			payload := strings.Join(data, "\n")
+			switch event {
+			case "endpoint":
+				if endpoint, err := resolveSSEEndpoint(serverURL, payload); err == nil && !sentEndpoint {
+					endpoints <- endpoint
+					sentEndpoint = true
+				}
+			case "", "message":
+				if payload != "" {
+					t.incoming <- []byte(payload)
+				}
+			}
+			event, data = "", data[:0]
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+}
+
+// resolveSSEEndpoint resuelve el endpoint, que suele ser relativo, contra la URL del stream
+func resolveSSEEndpoint(serverURL, endpoint string) (string, error) {
+	base, err := url.Parse(serverURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(strings.TrimSpace(endpoint))
+	if err != nil {
+		return "", err
+	}
+	return base.ResolveReference(ref).String(), nil
+}
+
+func (t *sseTransport) send(ctx context.Context, message []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range t.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("mcp server returned HTTP %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (t *sseTransport) messages() <-chan []byte {
+	return t.incoming
+}
+
+func (t *sseTransport) close() error {
+	t.cancel()
+	return nil
+}
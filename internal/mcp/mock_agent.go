@@ -6,6 +6,7 @@ import (
 	"math/rand"
 	"time"
 
+	"github.com/company/bot-service/pkg/configschema"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -30,15 +31,8 @@ func NewMockAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
 	}
 	
 	// Usar respuestas personalizadas si están configuradas
-	if customResponses, exists := config.Config["responses"]; exists {
-		if responseList, ok := customResponses.([]interface{}); ok {
-			responses = make([]string, 0, len(responseList))
-			for _, resp := range responseList {
-				if respStr, ok := resp.(string); ok {
-					responses = append(responses, respStr)
-				}
-			}
-		}
+	if custom := configschema.Values(config.Config).Strings("responses"); len(custom) > 0 {
+		responses = custom
 	}
 	
 	return &mockAgent{
@@ -52,16 +46,10 @@ func (a *mockAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	start := time.Now()
 	
 	// Actualizar estado
-	a.mu.Lock()
-	a.state.Status = AgentStatusBusy
-	a.state.CurrentTask = &task
-	a.mu.Unlock()
+	a.setStatus(AgentStatusBusy, &task)
 	
 	defer func() {
-		a.mu.Lock()
-		a.state.Status = AgentStatusIdle
-		a.state.CurrentTask = nil
-		a.mu.Unlock()
+		a.setStatus(AgentStatusIdle, nil)
 	}()
 	
 	a.logger.Info("Mock agent executing task", 
@@ -71,7 +59,18 @@ func (a *mockAgent) Execute(ctx context.Context, task Task) (Result, error) {
 	
 	// Simular tiempo de procesamiento
 	processingTime := a.getProcessingTime()
-	time.Sleep(processingTime)
+	select {
+	case <-time.After(processingTime):
+	case <-ctx.Done():
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		return Result{
+			TaskID:   task.ID,
+			Success:  false,
+			Error:    ctx.Err().Error(),
+			Duration: duration,
+		}, ctx.Err()
+	}
 	
 	// Simular posible fallo (5% de probabilidad)
 	if a.shouldSimulateFailure() {
@@ -130,37 +129,21 @@ func (a *mockAgent) CanHandle(taskType string) bool {
 }
 
 func (a *mockAgent) getProcessingTime() time.Duration {
-	// Simular tiempo de procesamiento variable (100ms - 2s)
-	minMs := 100
-	maxMs := 2000
-	
-	// Usar configuración personalizada si está disponible
-	if minTime, exists := a.config.Config["min_processing_time_ms"]; exists {
-		if minInt, ok := minTime.(float64); ok {
-			minMs = int(minInt)
-		}
+	// Simular tiempo de procesamiento variable (100ms - 2s por defecto)
+	values := configschema.Values(a.config.Config)
+	minMs := values.Int("min_processing_time_ms", 100)
+	maxMs := values.Int("max_processing_time_ms", 2000)
+	if maxMs <= minMs {
+		return time.Duration(minMs) * time.Millisecond
 	}
-	
-	if maxTime, exists := a.config.Config["max_processing_time_ms"]; exists {
-		if maxInt, ok := maxTime.(float64); ok {
-			maxMs = int(maxInt)
-		}
-	}
-	
+
 	randomMs := minMs + rand.Intn(maxMs-minMs)
 	return time.Duration(randomMs) * time.Millisecond
 }
 
 func (a *mockAgent) shouldSimulateFailure() bool {
 	// 5% de probabilidad de fallo por defecto
-	failureRate := 0.05
-	
-	if customRate, exists := a.config.Config["failure_rate"]; exists {
-		if rate, ok := customRate.(float64); ok {
-			failureRate = rate
-		}
-	}
-	
+	failureRate := configschema.Values(a.config.Config).Float("failure_rate", 0.05)
 	return rand.Float64() < failureRate
 }
 
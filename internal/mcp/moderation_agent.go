@@ -0,0 +1,263 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Proveedores de moderación soportados. La lista de palabras se aplica siempre;
+// "openai" añade las categorías de la API de moderación de OpenAI.
+const (
+	ModerationProviderLocal  = "local"
+	ModerationProviderOpenAI = "openai"
+)
+
+// defaultModerationWords son las palabras bloqueadas salvo disable_default_words
+var defaultModerationWords = []string{
+	"mierda", "puta", "puto", "cabron", "cabrona", "gilipollas", "joder", "cono", "pendejo", "pendeja",
+	"imbecil", "idiota", "estupido", "estupida", "malparido", "hijueputa", "carajo", "verga",
+	"fuck", "fucking", "fucker", "motherfucker", "shit", "bitch", "asshole", "bastard", "cunt", "dick",
+}
+
+// moderationFolding quita tildes para comparar palabras ("coño" = "cono")
+var moderationFolding = strings.NewReplacer(
+	"á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n",
+	"à", "a", "è", "e", "ì", "i", "ò", "o", "ù", "u",
+)
+
+// moderationAgent detecta contenido no permitido con una lista de palabras y,
+// opcionalmente, con la API de moderación de OpenAI
+type moderationAgent struct {
+	*baseAgent
+	client     *http.Client
+	provider   string
+	apiKey     string
+	model      string
+	baseURL    string
+	words      map[string]bool
+	categories map[string]bool
+}
+
+type openAIModerationResponse struct {
+	Results []struct {
+		Flagged    bool            `json:"flagged"`
+		Categories map[string]bool `json:"categories"`
+	} `json:"results"`
+}
+
+// NewModerationAgent crea un agente de moderación de contenido
+func NewModerationAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{"moderation", "content_moderation"}
+
+	values := configschema.Values(config.Config)
+	provider := values.String("provider", ModerationProviderLocal)
+	if provider != ModerationProviderLocal && provider != ModerationProviderOpenAI {
+		return nil, fmt.Errorf("unsupported moderation provider: %s", provider)
+	}
+
+	words := make(map[string]bool)
+	if !values.Bool("disable_default_words", false) {
+		for _, word := range defaultModerationWords {
+			words[word] = true
+		}
+	}
+	for _, word := range values.Strings("words") {
+		if word = foldModerationWord(word); word != "" {
+			words[word] = true
+		}
+	}
+	for _, word := range values.Strings("allowed_words") {
+		delete(words, foldModerationWord(word))
+	}
+
+	categories := make(map[string]bool)
+	for _, category := range values.Strings("categories") {
+		categories[category] = true
+	}
+
+	timeout := 10 * time.Second
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+
+	return &moderationAgent{
+		baseAgent:  base,
+		client:     &http.Client{Timeout: timeout},
+		provider:   provider,
+		apiKey:     values.String("openai_api_key", ""),
+		model:      values.String("model", "omni-moderation-latest"),
+		baseURL:    strings.TrimSuffix(values.String("base_url", "https://api.openai.com/v1"), "/"),
+		words:      words,
+		categories: categories,
+	}, nil
+}
+
+func (a *moderationAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	text, _ := task.Input["text"].(string)
+	masked, matches := a.maskWords(text)
+	categories := []string{}
+	if len(matches) > 0 {
+		categories = append(categories, "profanity")
+	}
+
+	// Sin API key el proveedor openai se queda en la lista de palabras
+	mode := "local"
+	if a.provider == ModerationProviderOpenAI && a.apiKey != "" && strings.TrimSpace(text) != "" {
+		flagged, err := a.moderateWithOpenAI(ctx, text)
+		if err != nil {
+			duration := time.Since(start)
+			a.updateMetrics(false, duration)
+			return Result{
+				TaskID:   task.ID,
+				Success:  false,
+				Error:    err.Error(),
+				Duration: duration,
+				Metadata: map[string]interface{}{
+					"agent_id":   a.id,
+					"agent_type": a.agentType,
+				},
+			}, err
+		}
+		categories = append(categories, flagged...)
+		mode = "api"
+	}
+
+	duration := time.Since(start)
+	a.updateMetrics(true, duration)
+
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output: map[string]interface{}{
+			"flagged":     len(categories) > 0,
+			"categories":  categories,
+			"matches":     matches,
+			"masked_text": masked,
+		},
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"provider":   a.provider,
+			"mode":       mode,
+		},
+	}, nil
+}
+
+func (a *moderationAgent) CanHandle(taskType string) bool {
+	switch taskType {
+	case "moderation", "content_moderation":
+		return true
+	}
+	return false
+}
+
+// maskWords sustituye por asteriscos las palabras de la lista, conservando la
+// primera letra, y devuelve las palabras encontradas sin repetir
+func (a *moderationAgent) maskWords(text string) (string, []string) {
+	var masked strings.Builder
+	matches := []string{}
+	seen := make(map[string]bool)
+
+	for len(text) > 0 {
+		end := strings.IndexFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+		if end == 0 {
+			_, size := utf8.DecodeRuneInString(text)
+			masked.WriteString(text[:size])
+			text = text[size:]
+			continue
+		}
+		if end < 0 {
+			end = len(text)
+		}
+
+		word := text[:end]
+		folded := foldModerationWord(word)
+		if a.words[folded] {
+			first, size := utf8.DecodeRuneInString(word)
+			masked.WriteRune(first)
+			masked.WriteString(strings.Repeat("*", utf8.RuneCountInString(word[size:])))
+			if !seen[folded] {
+				seen[folded] = true
+				matches = append(matches, folded)
+			}
+		} else {
+			masked.WriteString(word)
+		}
+		text = text[end:]
+	}
+	return masked.String(), matches
+}
+
+// moderateWithOpenAI devuelve las categorías marcadas por la API, filtradas
+// por las categorías configuradas si las hay
+func (a *moderationAgent) moderateWithOpenAI(ctx context.Context, text string) ([]string, error) {
+	body, err := json.Marshal(map[string]interface{}{"model": a.model, "input": text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal moderation request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("moderation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("moderation API returned status %d: %s", resp.StatusCode, string(payload))
+	}
+
+	var parsed openAIModerationResponse
+	if err := json.Unmarshal(payload, &parsed); err != nil {
+		return nil, fmt.Errorf("invalid moderation response: %w", err)
+	}
+
+	var flagged []string
+	for _, result := range parsed.Results {
+		if !result.Flagged {
+			continue
+		}
+		for category, hit := range result.Categories {
+			if hit && (len(a.categories) == 0 || a.categories[category]) {
+				flagged = append(flagged, category)
+			}
+		}
+	}
+	sort.Strings(flagged)
+	return flagged, nil
+}
+
+func foldModerationWord(word string) string {
+	return moderationFolding.Replace(strings.ToLower(strings.TrimSpace(word)))
+}
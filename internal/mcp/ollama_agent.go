@@ -0,0 +1,332 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	aiProviderOllama      = "ollama"
+	defaultOllamaURL      = "http://localhost:11434"
+	defaultOllamaPullWait = 10 * time.Minute
+	// Los modelos locales tardan más en cargar y responder que una API externa
+	defaultOllamaTimeout = 2 * time.Minute
+)
+
+// ollamaAgent genera texto con un modelo servido por Ollama en la red local,
+// para despliegues sin acceso a APIs externas. No tiene modo mock: si el
+// servidor no responde la tarea falla.
+type ollamaAgent struct {
+	*baseAgent
+	client      *http.Client
+	baseURL     string
+	model       string
+	temperature float64
+	keepAlive   string
+	pull        bool
+	pullTimeout time.Duration
+}
+
+// Estructuras de la API /api/chat de Ollama
+type ollamaChatRequest struct {
+	Model     string                 `json:"model"`
+	Messages  []message              `json:"messages"`
+	Stream    bool                   `json:"stream"`
+	Options   map[string]interface{} `json:"options,omitempty"`
+	KeepAlive string                 `json:"keep_alive,omitempty"`
+}
+
+// ollamaChatResponse es la respuesta completa o, en streaming, cada línea NDJSON
+type ollamaChatResponse struct {
+	Model           string  `json:"model"`
+	Message         message `json:"message"`
+	Done            bool    `json:"done"`
+	DoneReason      string  `json:"done_reason"`
+	PromptEvalCount int     `json:"prompt_eval_count"`
+	EvalCount       int     `json:"eval_count"`
+	Error           string  `json:"error"`
+}
+
+// NewOllamaAgent crea un agente de Ollama. El modelo se comprueba en Start.
+func NewOllamaAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{"text_generation", "summarization"}
+
+	timeout := defaultOllamaTimeout
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+
+	values := configschema.Values(config.Config)
+	return &ollamaAgent{
+		baseAgent:   base,
+		client:      &http.Client{Timeout: timeout},
+		baseURL:     strings.TrimSuffix(values.String("base_url", defaultOllamaURL), "/"),
+		model:       values.String("model", ""),
+		temperature: values.Float("temperature", 0.7),
+		keepAlive:   values.String("keep_alive", ""),
+		pull:        values.Bool("pull", false),
+		pullTimeout: values.Duration("pull_timeout", defaultOllamaPullWait),
+	}, nil
+}
+
+// Start comprueba que el servidor tiene el modelo y, con pull, lo descarga
+func (a *ollamaAgent) Start(ctx context.Context) error {
+	available, err := a.hasModel(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reach ollama server: %w", err)
+	}
+	if !available {
+		if !a.pull {
+			return fmt.Errorf("model %s is not available on the ollama server, pull it or enable pull", a.model)
+		}
+		a.logger.Info("Pulling ollama model", "agent_id", a.id, "model", a.model)
+		if err := a.pullModel(ctx); err != nil {
+			return fmt.Errorf("failed to pull model %s: %w", a.model, err)
+		}
+	}
+	return a.baseAgent.Start(ctx)
+}
+
+// hasModel busca el modelo en /api/tags; sin etiqueta Ollama usa latest
+func (a *ollamaAgent) hasModel(ctx context.Context) (bool, error) {
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := a.call(ctx, a.client, "GET", "/api/tags", nil, &tags); err != nil {
+		return false, err
+	}
+
+	wanted := a.model
+	if !strings.Contains(wanted, ":") {
+		wanted += ":latest"
+	}
+	for _, model := range tags.Models {
+		if model.Name == a.model || model.Name == wanted {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// pullModel descarga el modelo; puede tardar minutos, de ahí pull_timeout
+func (a *ollamaAgent) pullModel(ctx context.Context) error {
+	var status struct {
+		Status string `json:"status"`
+	}
+	client := &http.Client{Timeout: a.pullTimeout, Transport: a.client.Transport}
+	body := map[string]interface{}{"model": a.model, "stream": false}
+	if err := a.call(ctx, client, "POST", "/api/pull", body, &status); err != nil {
+		return err
+	}
+	if status.Status != "success" {
+		return fmt.Errorf("unexpected pull status: %s", status.Status)
+	}
+	return nil
+}
+
+// call hace una petición JSON a la API de Ollama
+func (a *ollamaAgent) call(ctx context.Context, client *http.Client, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(jsonBody)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, a.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// chatRequest traduce la tarea al formato de /api/chat
+func (a *ollamaAgent) chatRequest(task Task, stream bool) (ollamaChatRequest, error) {
+	chat, err := chatRequest(task, a.model, a.temperature)
+	if err != nil {
+		return ollamaChatRequest{}, err
+	}
+	return ollamaChatRequest{
+		Model:     a.model,
+		Messages:  chat.Messages,
+		Stream:    stream,
+		Options:   map[string]interface{}{"temperature": chat.Temperature, "num_predict": chat.MaxTokens},
+		KeepAlive: a.keepAlive,
+	}, nil
+}
+
+func (a *ollamaAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	fail := func(err error) (Result, error) {
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		return Result{TaskID: task.ID, Success: false, Error: err.Error(), Duration: duration}, err
+	}
+
+	request, err := a.chatRequest(task, false)
+	if err != nil {
+		return fail(err)
+	}
+	var response ollamaChatResponse
+	if err := a.call(ctx, a.client, "POST", "/api/chat", request, &response); err != nil {
+		return fail(err)
+	}
+	metrics.RecordAITokens(aiProviderOllama, response.Model, response.PromptEvalCount, response.EvalCount)
+
+	duration := time.Since(start)
+	a.updateMetrics(true, duration)
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output: map[string]interface{}{
+			"text":          response.Message.Content,
+			"model":         response.Model,
+			"tokens_used":   response.PromptEvalCount + response.EvalCount,
+			"finish_reason": response.DoneReason,
+		},
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"agent_id":          a.id,
+			"agent_type":        a.agentType,
+			"mode":              "local",
+			"prompt_tokens":     response.PromptEvalCount,
+			"completion_tokens": response.EvalCount,
+		},
+	}, nil
+}
+
+// ExecuteStream emite la respuesta de /api/chat a medida que el modelo la genera
+func (a *ollamaAgent) ExecuteStream(ctx context.Context, task Task) (<-chan StreamChunk, error) {
+	start := time.Now()
+
+	request, err := a.chatRequest(task, true)
+	if err != nil {
+		a.updateMetrics(false, time.Since(start))
+		return nil, err
+	}
+	jsonBody, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", a.baseURL+"/api/chat", bytes.NewReader(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	// Sin timeout de cliente: el stream dura lo que tarde el modelo
+	streamClient := &http.Client{Transport: a.client.Transport}
+	resp, err := streamClient.Do(req)
+	if err != nil {
+		a.updateMetrics(false, time.Since(start))
+		return nil, fmt.Errorf("API request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		a.updateMetrics(false, time.Since(start))
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer resp.Body.Close()
+
+		final := StreamChunk{Done: true, Error: "stream ended before the model finished"}
+		defer func() {
+			a.setStatus(AgentStatusIdle, nil)
+			a.updateMetrics(final.Error == "", time.Since(start))
+		}()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			var event ollamaChatResponse
+			if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+				continue
+			}
+			if event.Error != "" {
+				final.Error = event.Error
+				break
+			}
+			if content := event.Message.Content; content != "" {
+				select {
+				case chunks <- StreamChunk{Content: content}:
+				case <-ctx.Done():
+					return
+				}
+			}
+			if event.Done {
+				metrics.RecordAITokens(aiProviderOllama, event.Model, event.PromptEvalCount, event.EvalCount)
+				final.Error, final.FinishReason = "", event.DoneReason
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			final.Error = err.Error()
+		}
+
+		final.Metadata = map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"model":      a.model,
+		}
+		select {
+		case chunks <- final:
+		case <-ctx.Done():
+		}
+	}()
+
+	return chunks, nil
+}
+
+func (a *ollamaAgent) CanHandle(taskType string) bool {
+	switch taskType {
+	case "text_generation", "summarization", "ollama":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeOllama simula un servidor Ollama que no tiene modelos hasta el primer pull
+func newFakeOllama(t *testing.T) *httptest.Server {
+	var mu sync.Mutex
+	models := []map[string]string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/tags", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{"models": models})
+	})
+	mux.HandleFunc("/api/pull", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		models = append(models, map[string]string{"name": "llama3.1:latest"})
+		fmt.Fprint(w, `{"status": "success"}`)
+	})
+	mux.HandleFunc("/api/chat", func(w http.ResponseWriter, r *http.Request) {
+		var request ollamaChatRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, "llama3.1", request.Model)
+		assert.Equal(t, "system", request.Messages[0].Role)
+		if !request.Stream {
+			fmt.Fprint(w, `{"model": "llama3.1", "message": {"role": "assistant", "content": "Hola"}, "done": true, "done_reason": "stop", "prompt_eval_count": 7, "eval_count": 3}`)
+			return
+		}
+		for _, word := range []string{"Ho", "la"} {
+			fmt.Fprintf(w, `{"model": "llama3.1", "message": {"role": "assistant", "content": %q}, "done": false}`+"\n", word)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, `{"model": "llama3.1", "message": {"role": "assistant", "content": ""}, "done": true, "done_reason": "stop"}`+"\n")
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOllamaAgent_PullsModelAndGenerates(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	o := NewOrchestrator(NewAgentFactory(log), log)
+	server := newFakeOllama(t)
+
+	_, err := o.InstantiateMCP(ctx, MCPConfig{Type: "ollama", Name: "local", Config: map[string]interface{}{"base_url": server.URL, "model": "llama3.1"}})
+	assert.ErrorContains(t, err, "not available on the ollama server")
+
+	agent, err := o.InstantiateMCP(ctx, MCPConfig{Type: "ollama", Name: "local", Config: map[string]interface{}{"base_url": server.URL, "model": "llama3.1", "pull": true}})
+	require.NoError(t, err)
+	defer o.TerminateAgent(ctx, agent.GetID())
+
+	task := Task{ID: "local", Type: "summarization", Input: map[string]interface{}{"prompt": "Resume", "system": "Be brief"}}
+	result, err := o.ExecuteTask(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, "Hola", result.Output["text"])
+	assert.Equal(t, 10, result.Output["tokens_used"])
+
+	chunks, err := o.ExecuteTaskStream(ctx, task)
+	require.NoError(t, err)
+	text := ""
+	var last StreamChunk
+	for chunk := range chunks {
+		text += chunk.Content
+		last = chunk
+	}
+	assert.Equal(t, "Hola", text)
+	assert.True(t, last.Done)
+	assert.Empty(t, last.Error)
+	assert.Equal(t, "stop", last.FinishReason)
+}
@@ -2,14 +2,20 @@ package mcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/metrics"
 	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/tracing"
 )
 
+// ErrNoAgentAvailable indica que ningún agente sano y libre puede atender la tarea
+var ErrNoAgentAvailable = errors.New("no suitable agent available")
+
 // orchestrator implementa MCPOrchestrator
 type orchestrator struct {
 	agents        map[string]Agent
@@ -20,6 +26,11 @@ type orchestrator struct {
 	taskCounter   int64
 	metrics       SystemMetrics
 	agentMetrics  map[string]*domain.MCPAgentMetrics
+	dispatch      *dispatchControl
+	scheduler     *agentScheduler
+	breakers      *breakerSet
+	persistence   *agentPersistence
+	tools         toolRegistry
 }
 
 // NewOrchestrator crea una nueva instancia del orquestador MCP
@@ -27,18 +38,40 @@ func NewOrchestrator(factory AgentFactory, logger logger.Logger) interface {
 	MCPOrchestrator
 	MCPDomainOrchestrator
 } {
-	return &orchestrator{
+	o := &orchestrator{
 		agents:       make(map[string]Agent),
 		factory:      factory,
 		logger:       logger,
 		startTime:    time.Now(),
 		metrics:      SystemMetrics{},
 		agentMetrics: make(map[string]*domain.MCPAgentMetrics),
+		tools:        toolRegistry{tools: make(map[string]registeredTool)},
 	}
+	o.dispatch = newDispatchControl(o.dispatchBlocked, logger)
+	o.scheduler = newAgentScheduler(o.schedulable, logger)
+	o.breakers = newBreakerSet(logger)
+	return o
 }
 
-// InstantiateMCP crea e inicia un nuevo agente MCP
+// InstantiateMCP crea e inicia un nuevo agente MCP. Con config.Persistent y
+// persistencia configurada el agente se guarda para restaurarlo al reiniciar.
 func (o *orchestrator) InstantiateMCP(ctx context.Context, config MCPConfig) (Agent, error) {
+	agent, err := o.instantiate(ctx, config)
+	if err != nil || !config.Persistent || o.persistence == nil {
+		return agent, err
+	}
+	if err := o.persistNewAgent(ctx, agent, config); err != nil {
+		if stopErr := o.TerminateAgent(ctx, agent.GetID()); stopErr != nil {
+			o.logger.Error("Failed to terminate unpersisted agent", "agent_id", agent.GetID(), "error", stopErr)
+		}
+		return nil, fmt.Errorf("failed to persist agent: %w", err)
+	}
+	return agent, nil
+}
+
+func (o *orchestrator) instantiate(ctx context.Context, config MCPConfig) (Agent, error) {
+	// Se ejecuta tras liberar el lock: el nuevo agente puede atender tareas en cola
+	defer o.scheduler.dispatchQueued()
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
@@ -46,6 +79,9 @@ func (o *orchestrator) InstantiateMCP(ctx context.Context, config MCPConfig) (Ag
 	if err := o.factory.ValidateConfig(config); err != nil {
 		return nil, fmt.Errorf("invalid MCP config: %w", err)
 	}
+	if _, exists := o.agents[config.ID]; exists && config.ID != "" {
+		return nil, fmt.Errorf("agent already exists: %s", config.ID)
+	}
 
 	// Crear agente
 	agent, err := o.factory.CreateAgent(config)
@@ -62,6 +98,7 @@ func (o *orchestrator) InstantiateMCP(ctx context.Context, config MCPConfig) (Ag
 	o.agents[agent.GetID()] = agent
 	o.metrics.TotalAgents++
 	o.metrics.ActiveAgents++
+	tracing.Record(ctx, tracing.ComponentAgent, agent.GetID(), agent.GetType())
 
 	o.logger.Info("MCP agent instantiated", 
 		"agent_id", agent.GetID(),
@@ -99,6 +136,8 @@ func (o *orchestrator) ListAgents() []Agent {
 
 // TerminateAgent termina y elimina un agente
 func (o *orchestrator) TerminateAgent(ctx context.Context, agentID string) error {
+	// El planificador se actualiza tras liberar el lock, como en InstantiateMCP
+	defer o.scheduler.forget(agentID)
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
@@ -115,35 +154,233 @@ func (o *orchestrator) TerminateAgent(ctx context.Context, agentID string) error
 	// Eliminar del registro
 	delete(o.agents, agentID)
 	o.metrics.ActiveAgents--
+	o.breakers.forget(agent)
+	o.forgetPersistedAgent(ctx, agentID)
 
 	o.logger.Info("MCP agent terminated", "agent_id", agentID)
 
 	return nil
 }
 
-// ExecuteTask ejecuta una tarea en el agente más apropiado
-func (o *orchestrator) ExecuteTask(ctx context.Context, task Task) (Result, error) {
+// UpdateAgentConfig aplica cambios de configuración a un agente sin terminarlo
+func (o *orchestrator) UpdateAgentConfig(ctx context.Context, agentID string, changes map[string]interface{}) (ConfigChange, error) {
+	agent, err := o.GetAgent(agentID)
+	if err != nil {
+		return ConfigChange{}, err
+	}
+
+	configurable, ok := unwrapAgent(agent).(ConfigurableAgent)
+	if !ok {
+		return ConfigChange{}, fmt.Errorf("%w: %s", ErrConfigUpdateNotSupported, agent.GetType())
+	}
+
+	change, err := configurable.UpdateConfig(changes)
+	if err != nil {
+		return ConfigChange{}, fmt.Errorf("failed to update agent config: %w", err)
+	}
+
+	o.persistConfigChanges(ctx, agent, changes)
+
+	o.logger.Info("MCP agent config updated", "agent_id", agentID, "fields", change.Fields)
+	return change, nil
+}
+
+// PauseDispatch pausa el despacho de tareas; agentType vacío lo pausa para
+// todos los agentes. Las tareas que llegan mientras tanto quedan en cola.
+func (o *orchestrator) PauseDispatch(agentType, reason string) error {
+	if agentType != "" && !containsString(o.factory.GetSupportedTypes(), agentType) {
+		return fmt.Errorf("unsupported agent type: %s", agentType)
+	}
+
+	o.dispatch.pause(agentType, reason)
+	o.logger.Warn("MCP task dispatch paused", "agent_type", agentType, "reason", reason)
+	return nil
+}
+
+// ResumeDispatch reanuda el despacho y libera la cola a drainRate tareas por
+// segundo (0 las libera todas de inmediato)
+func (o *orchestrator) ResumeDispatch(agentType string, drainRate float64) error {
+	if err := o.dispatch.resume(agentType, drainRate); err != nil {
+		return err
+	}
+	o.scheduler.dispatchQueued()
+
+	o.logger.Info("MCP task dispatch resumed", "agent_type", agentType, "drain_rate", drainRate)
+	return nil
+}
+
+// GetDispatchStatus devuelve las pausas activas y las tareas en cola
+func (o *orchestrator) GetDispatchStatus() DispatchStatus {
+	status := o.dispatch.status()
+	status.WaitingTypes = o.scheduler.waiting()
+	for _, count := range status.WaitingTypes {
+		status.Waiting += count
+	}
+	return status
+}
+
+// ConfigureScheduler ajusta la cola de tareas que esperan un agente libre
+func (o *orchestrator) ConfigureScheduler(config SchedulerConfig) {
+	o.scheduler.configure(config)
+}
+
+// ConfigureBreakers ajusta los circuit breakers de los agentes
+func (o *orchestrator) ConfigureBreakers(config BreakerConfig) {
+	o.breakers.configure(config)
+}
+
+// GetBreakerStatus devuelve el estado del circuito de un agente
+func (o *orchestrator) GetBreakerStatus(agentID string) (BreakerStatus, error) {
+	agent, err := o.GetAgent(agentID)
+	if err != nil {
+		return BreakerStatus{}, err
+	}
+	return o.breakers.status(agent), nil
+}
+
+// schedulable devuelve los agentes sanos que pueden atender el tipo de tarea y
+// cuyo tipo no está pausado. Los de circuito abierto se saltan y los de
+// circuito entreabierto se incluyen aunque sigan en error, para probarlos.
+func (o *orchestrator) schedulable(taskType string) []Agent {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 
-	// Buscar agente apropiado
-	var selectedAgent Agent
+	agents := make([]Agent, 0, len(o.agents))
 	for _, agent := range o.agents {
-		if agent.CanHandle(task.Type) && agent.IsHealthy() {
-			state := agent.GetState()
-			if state.Status == AgentStatusIdle {
-				selectedAgent = agent
-				break
+		if !agent.CanHandle(taskType) || o.dispatch.typePaused(agent.GetType()) {
+			continue
+		}
+		switch o.breakers.state(agent) {
+		case BreakerClosed:
+			if !agent.IsHealthy() {
+				continue
 			}
+		case BreakerHalfOpen:
+			if agent.GetState().Status == AgentStatusTerminated {
+				continue
+			}
+		default:
+			continue
+		}
+		agents = append(agents, agent)
+	}
+	return agents
+}
+
+// recordOutcome anota el resultado de la tarea en el circuito del agente: al
+// abrirse el agente pasa a error y al cerrarse vuelve a idle. Las tareas
+// canceladas por quien las pidió no cuentan.
+func (o *orchestrator) recordOutcome(agent Agent, err error, success bool) {
+	if errors.Is(err, context.Canceled) {
+		return
+	}
+	state, changed := o.breakers.record(agent, err != nil || !success)
+	if !changed {
+		return
+	}
+	setter, ok := unwrapAgent(agent).(interface{ setStatus(status AgentStatus, task *Task) })
+	if !ok {
+		return
+	}
+	if state == BreakerOpen {
+		setter.setStatus(AgentStatusError, nil)
+	} else if state == BreakerClosed {
+		setter.setStatus(AgentStatusIdle, nil)
+	}
+}
+
+// dispatchBlocked indica si una tarea debe esperar: hay pausa global o todos
+// los agentes que pueden atenderla son de tipos pausados. Sin agentes capaces
+// no se bloquea, para que la tarea falle como siempre.
+func (o *orchestrator) dispatchBlocked(taskType string) bool {
+	if o.dispatch.globalPaused() {
+		return true
+	}
+
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	capable := false
+	for _, agent := range o.agents {
+		if !agent.CanHandle(taskType) {
+			continue
+		}
+		capable = true
+		if !o.dispatch.typePaused(agent.GetType()) {
+			return false
 		}
 	}
+	return capable
+}
 
-	if selectedAgent == nil {
+// GetAgentHistory devuelve las transiciones de estado y errores recientes de un agente
+func (o *orchestrator) GetAgentHistory(agentID string) (AgentHistory, error) {
+	agent, err := o.GetAgent(agentID)
+	if err != nil {
+		return AgentHistory{}, err
+	}
+	return agent.GetHistory(), nil
+}
+
+// executeOnAgent ejecuta la tarea y guarda el error en el historial del agente si falla
+func executeOnAgent(ctx context.Context, agent Agent, task Task) (Result, error) {
+	tracing.Record(ctx, tracing.ComponentAgent, agent.GetID(), agent.GetType())
+	result, err := agent.Execute(ctx, task)
+	recordTaskFailure(agent, task, result, err)
+	return result, err
+}
+
+// recordTaskFailure registra el error sólo en agentes basados en baseAgent
+func recordTaskFailure(agent Agent, task Task, result Result, err error) {
+	if err == nil && result.Success {
+		return
+	}
+	recorder, ok := unwrapAgent(agent).(interface{ recordError(task Task, message string) })
+	if !ok {
+		return
+	}
+
+	message := result.Error
+	if message == "" && err != nil {
+		message = err.Error()
+	}
+	if message == "" {
+		message = "task failed"
+	}
+	recorder.recordError(task, message)
+}
+
+// TaskAgentTypeKey en la metadata de una tarea la limita a los agentes de ese
+// tipo, por ejemplo al proveedor de IA que eligió el bot
+const TaskAgentTypeKey = "agent_type"
+
+// agentTypeFilter devuelve el filtro de TaskAgentTypeKey, o nil si la tarea no lo pide
+func agentTypeFilter(metadata map[string]interface{}) func(Agent) bool {
+	agentType, _ := metadata[TaskAgentTypeKey].(string)
+	if agentType == "" {
+		return nil
+	}
+	return func(agent Agent) bool { return agent.GetType() == agentType }
+}
+
+// ExecuteTask ejecuta una tarea en el agente más apropiado
+func (o *orchestrator) ExecuteTask(ctx context.Context, task Task) (Result, error) {
+	if err := o.dispatch.wait(ctx, task.ID, task.Type); err != nil {
 		return Result{
 			TaskID:  task.ID,
 			Success: false,
-			Error:   "no suitable agent available",
-		}, fmt.Errorf("no suitable agent found for task type: %s", task.Type)
+			Error:   err.Error(),
+		}, err
+	}
+
+	// Reservar el agente libre menos cargado, esperando en cola si están todos ocupados
+	selectedAgent, err := o.scheduler.acquire(ctx, task.ID, task.Type, agentTypeFilter(task.Metadata))
+	if err != nil {
+		return Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   err.Error(),
+		}, err
 	}
 
 	// Ejecutar tarea
@@ -152,25 +389,14 @@ func (o *orchestrator) ExecuteTask(ctx context.Context, task Task) (Result, erro
 		"task_type", task.Type,
 		"agent_id", selectedAgent.GetID())
 
+	// Los agentes de IA pueden pedir herramientas al orquestador durante la tarea
 	start := time.Now()
-	result, err := selectedAgent.Execute(ctx, task)
+	result, err := executeOnAgent(withToolRunner(ctx, o), selectedAgent, task)
 	duration := time.Since(start)
-
-	// Actualizar métricas
-	o.metrics.TotalTasks++
-	if err != nil || !result.Success {
-		o.metrics.FailedTasks++
-	} else {
-		o.metrics.CompletedTasks++
-	}
-
-	// Calcular tiempo promedio
-	if o.metrics.TotalTasks > 0 {
-		totalTime := o.metrics.AverageExecTime*time.Duration(o.metrics.TotalTasks-1) + duration
-		o.metrics.AverageExecTime = totalTime / time.Duration(o.metrics.TotalTasks)
-	} else {
-		o.metrics.AverageExecTime = duration
-	}
+	// El circuito se actualiza antes de liberar el agente para la siguiente tarea
+	o.recordOutcome(selectedAgent, err, result.Success)
+	o.scheduler.release(selectedAgent, duration)
+	metrics.ObserveMCPTask(selectedAgent.GetType(), duration, err == nil && result.Success)
 
 	if err != nil {
 		o.logger.Error("Task execution failed", 
@@ -189,7 +415,68 @@ func (o *orchestrator) ExecuteTask(ctx context.Context, task Task) (Result, erro
 	return result, nil
 }
 
-// CoordinateAgents coordina múltiples agentes para una tarea compleja
+// ExecuteTaskStream ejecuta una tarea en un agente con soporte de streaming
+func (o *orchestrator) ExecuteTaskStream(ctx context.Context, task Task) (<-chan StreamChunk, error) {
+	if err := o.dispatch.wait(ctx, task.ID, task.Type); err != nil {
+		return nil, err
+	}
+
+	ofType := agentTypeFilter(task.Metadata)
+	reserved, err := o.scheduler.acquire(ctx, task.ID, task.Type, func(agent Agent) bool {
+		_, ok := streamingAgent(agent)
+		return ok && (ofType == nil || ofType(agent))
+	})
+	if err != nil {
+		return nil, err
+	}
+	selectedAgent, _ := streamingAgent(reserved)
+
+	o.logger.Info("Executing streaming task", 
+		"task_id", task.ID,
+		"task_type", task.Type,
+		"agent_id", selectedAgent.GetID())
+
+	// En streaming solo se cuenta la tarea, al terminar de emitir: su duración
+	// depende del consumidor del canal
+	start := time.Now()
+	source, err := selectedAgent.ExecuteStream(ctx, task)
+	if err != nil {
+		recordTaskFailure(selectedAgent, task, Result{}, err)
+		o.recordOutcome(reserved, err, false)
+		o.scheduler.release(reserved, 0)
+		metrics.MCPTasks.WithLabelValues(selectedAgent.GetType(), metrics.StatusFailure).Inc()
+		return nil, err
+	}
+
+	// El agente sigue reservado hasta que termina de emitir
+	chunks := make(chan StreamChunk)
+	go func() {
+		defer close(chunks)
+		defer func() { o.scheduler.release(reserved, time.Since(start)) }()
+		failed, done := false, false
+		defer func() {
+			o.recordOutcome(reserved, ctx.Err(), !failed)
+			status := metrics.StatusSuccess
+			if failed || !done {
+				status = metrics.StatusFailure
+			}
+			metrics.MCPTasks.WithLabelValues(selectedAgent.GetType(), status).Inc()
+		}()
+		for chunk := range source {
+			failed = failed || chunk.Error != ""
+			done = done || chunk.Done
+			select {
+			case chunks <- chunk:
+			case <-ctx.Done():
+				// Sin consumidor se vacía source para que el agente termine
+			}
+		}
+	}()
+	return chunks, nil
+}
+
+// CoordinateAgents coordina múltiples agentes para una tarea compleja según la
+// estrategia de task.Metadata["coordination"] (ver CoordinationConfig)
 func (o *orchestrator) CoordinateAgents(ctx context.Context, agents []Agent, task Task) (Result, error) {
 	if len(agents) == 0 {
 		return Result{
@@ -199,29 +486,49 @@ func (o *orchestrator) CoordinateAgents(ctx context.Context, agents []Agent, tas
 		}, fmt.Errorf("no agents provided for coordination")
 	}
 
-	// Para tareas simples, usar el primer agente disponible
-	if len(agents) == 1 {
-		return agents[0].Execute(ctx, task)
+	config, err := ParseCoordinationConfig(task)
+	if err != nil {
+		return Result{TaskID: task.ID, Success: false, Error: err.Error()}, err
 	}
 
-	// Para múltiples agentes, implementar coordinación básica
-	// TODO: Implementar lógica de coordinación más sofisticada
 	o.logger.Info("Coordinating multiple agents", 
 		"task_id", task.ID,
+		"strategy", config.Strategy,
 		"agent_count", len(agents))
 
-	// Por ahora, ejecutar en el primer agente disponible
-	for _, agent := range agents {
-		if agent.IsHealthy() && agent.CanHandle(task.Type) {
-			return agent.Execute(ctx, task)
+	// Los agentes que no pueden atender la tarea cuentan como fallidos salvo en
+	// pipeline, donde cada etapa es imprescindible
+	available := make([]Agent, 0, len(agents))
+	var unavailable []CoordinationStage
+	for i, agent := range agents {
+		if agent.IsHealthy() && agent.CanHandle(task.Type) && o.breakers.state(agent) != BreakerOpen {
+			available = append(available, agent)
+			continue
 		}
+		if config.Strategy == CoordinationPipeline {
+			message := fmt.Sprintf("pipeline stage %d (%s) has no healthy agent", i+1, agent.GetID())
+			return Result{TaskID: task.ID, Success: false, Error: message}, fmt.Errorf("%w: %s", ErrNoAgentAvailable, message)
+		}
+		unavailable = append(unavailable, CoordinationStage{AgentID: agent.GetID(), Error: "agent unavailable"})
+	}
+	if len(available) == 0 {
+		return Result{
+			TaskID:  task.ID,
+			Success: false,
+			Error:   "no healthy agent available",
+		}, fmt.Errorf("%w for coordination", ErrNoAgentAvailable)
 	}
 
-	return Result{
-		TaskID:  task.ID,
-		Success: false,
-		Error:   "no healthy agent available",
-	}, fmt.Errorf("no healthy agent available for coordination")
+	switch config.Strategy {
+	case CoordinationFanOut:
+		return o.coordinateFanOut(ctx, available, unavailable, task, config)
+	case CoordinationPipeline:
+		return o.coordinatePipeline(ctx, available, task, config)
+	case CoordinationVote:
+		return o.coordinateVote(ctx, available, unavailable, task, config)
+	default:
+		return executeOnAgent(ctx, available[0], task)
+	}
 }
 
 // PassContext pasa contexto a un agente específico
@@ -294,9 +601,6 @@ func (o *orchestrator) GetSystemMetrics() (SystemMetrics, error) {
 	o.mu.RLock()
 	defer o.mu.RUnlock()
 
-	// Actualizar métricas del sistema
-	o.metrics.SystemUptime = time.Since(o.startTime)
-	
 	// Contar agentes activos
 	activeCount := 0
 	for _, agent := range o.agents {
@@ -304,9 +608,18 @@ func (o *orchestrator) GetSystemMetrics() (SystemMetrics, error) {
 			activeCount++
 		}
 	}
-	o.metrics.ActiveAgents = activeCount
 
-	return o.metrics, nil
+	// Los totales de tareas salen del registro de Prometheus, igual que /metrics
+	summary := metrics.MCPTasksSummary()
+	result := o.metrics
+	result.ActiveAgents = activeCount
+	result.SystemUptime = time.Since(o.startTime)
+	result.TotalTasks = int(summary.Total)
+	result.CompletedTasks = int(summary.Completed)
+	result.FailedTasks = int(summary.Failed)
+	result.AverageExecTime = summary.AverageDuration
+
+	return result, nil
 }
 
 // Start inicia el orquestador
@@ -316,8 +629,11 @@ func (o *orchestrator) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop detiene el orquestador y todos los agentes
+// Stop detiene el orquestador y todos los agentes; antes guarda la última
+// instantánea de los agentes persistentes
 func (o *orchestrator) Stop(ctx context.Context) error {
+	o.stopPersistence(ctx)
+
 	o.mu.Lock()
 	defer o.mu.Unlock()
 
@@ -349,31 +665,27 @@ func getMapKeys(m map[string]interface{}) []string {
 
 // ExecuteTaskDomain ejecuta una tarea usando las estructuras de dominio
 func (o *orchestrator) ExecuteTaskDomain(ctx context.Context, task *domain.MCPTask) (*domain.MCPTaskResult, error) {
-	o.mu.RLock()
-	defer o.mu.RUnlock()
+	if err := o.dispatch.wait(ctx, task.ID, task.Type); err != nil {
+		return &domain.MCPTaskResult{
+			TaskID:      task.ID,
+			Success:     false,
+			Error:       err.Error(),
+			CompletedAt: time.Now(),
+		}, err
+	}
 
 	o.logger.Info("Executing MCP domain task", "task_id", task.ID, "type", task.Type)
 
-	// Buscar agente apropiado
-	var selectedAgent Agent
-	for _, agent := range o.agents {
-		if agent.CanHandle(task.Type) && agent.IsHealthy() {
-			state := agent.GetState()
-			if state.Status == AgentStatusIdle {
-				selectedAgent = agent
-				break
-			}
-		}
-	}
-
-	if selectedAgent == nil {
+	// Reservar el agente libre menos cargado, esperando en cola si están todos ocupados
+	selectedAgent, err := o.scheduler.acquire(ctx, task.ID, task.Type, agentTypeFilter(task.Metadata))
+	if err != nil {
 		return &domain.MCPTaskResult{
 			TaskID:        task.ID,
 			Success:       false,
-			Error:         "no suitable agent available",
+			Error:         err.Error(),
 			ExecutionTime: 0,
 			CompletedAt:   time.Now(),
-		}, fmt.Errorf("no suitable agent found for task type: %s", task.Type)
+		}, err
 	}
 
 	// Pasar contexto al agente si es necesario
@@ -402,9 +714,16 @@ func (o *orchestrator) ExecuteTaskDomain(ctx context.Context, task *domain.MCPTa
 	}
 
 	start := time.Now()
-	result, err := selectedAgent.Execute(taskCtx, internalTask)
+	result, err := executeOnAgent(withToolRunner(taskCtx, o), selectedAgent, internalTask)
+	o.recordOutcome(selectedAgent, err, result.Success)
+	o.scheduler.release(selectedAgent, time.Since(start))
+	metrics.ObserveMCPTask(selectedAgent.GetType(), time.Since(start), err == nil && result.Success)
 	executionTime := time.Since(start).Milliseconds()
 
+	// Las métricas del agente las comparten las tareas concurrentes
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
 	// Inicializar métricas del agente si no existen
 	agentID := selectedAgent.GetID()
 	if _, exists := o.agentMetrics[agentID]; !exists {
@@ -436,6 +755,7 @@ func (o *orchestrator) ExecuteTaskDomain(ctx context.Context, task *domain.MCPTa
 			AgentID:       agentID,
 			Success:       false,
 			Error:         result.Error,
+			Output:        result.Output,
 			ExecutionTime: executionTime,
 			Metadata:      result.Metadata,
 			CompletedAt:   time.Now(),
@@ -477,13 +797,17 @@ func (o *orchestrator) GetAgentMetricsDomain(agentID string) (*domain.MCPAgentMe
 	defer o.mu.RUnlock()
 
 	// Verificar que el agente existe
-	if _, exists := o.agents[agentID]; !exists {
+	agent, exists := o.agents[agentID]
+	if !exists {
 		return nil, fmt.Errorf("agent not found: %s", agentID)
 	}
 
-	// Obtener métricas del agente
+	// Obtener métricas del agente; se devuelve una copia porque las tareas en
+	// curso las siguen actualizando
 	if metrics, exists := o.agentMetrics[agentID]; exists {
-		return metrics, nil
+		metricsCopy := *metrics
+		metricsCopy.CircuitState = string(o.breakers.state(agent))
+		return &metricsCopy, nil
 	}
 
 	// Si no hay métricas, crear métricas vacías
@@ -495,6 +819,7 @@ func (o *orchestrator) GetAgentMetricsDomain(agentID string) (*domain.MCPAgentMe
 		ErrorCount:          0,
 		AverageResponseTime: 0,
 		SuccessRate:         0.0,
+		CircuitState:        string(o.breakers.state(agent)),
 	}, nil
 }
 
@@ -511,31 +836,15 @@ func (o *orchestrator) GetSystemMetricsDomain() (*domain.MCPSystemMetrics, error
 		}
 	}
 
-	// Calcular métricas agregadas
-	var totalTasks, completedTasks, failedTasks int64
-	var totalResponseTime int64
-	agentCount := 0
-
-	for _, metrics := range o.agentMetrics {
-		totalTasks += metrics.TasksExecuted
-		completedTasks += metrics.TasksSuccessful
-		failedTasks += metrics.TasksFailed
-		totalResponseTime += metrics.AverageResponseTime
-		agentCount++
-	}
-
-	var averageResponseTime int64
-	if agentCount > 0 {
-		averageResponseTime = totalResponseTime / int64(agentCount)
-	}
+	summary := metrics.MCPTasksSummary()
 
 	return &domain.MCPSystemMetrics{
 		TotalAgents:         len(o.agents),
 		ActiveAgents:        activeCount,
-		TotalTasks:          totalTasks,
-		CompletedTasks:      completedTasks,
-		FailedTasks:         failedTasks,
-		AverageResponseTime: averageResponseTime,
+		TotalTasks:          summary.Total,
+		CompletedTasks:      summary.Completed,
+		FailedTasks:         summary.Failed,
+		AverageResponseTime: summary.AverageDuration.Milliseconds(),
 		SystemUptime:        int64(time.Since(o.startTime).Seconds()),
 		LastUpdated:         time.Now(),
 	}, nil
@@ -559,4 +868,9 @@ func (o *orchestrator) CreateAgentFromDomain(ctx context.Context, agentConfig *d
 // GetSupportedAgentTypes obtiene los tipos de agentes soportados
 func (o *orchestrator) GetSupportedAgentTypes() []string {
 	return o.factory.GetSupportedTypes()
+}
+
+// GetAgentTypes obtiene los metadatos y el esquema de configuración de cada tipo
+func (o *orchestrator) GetAgentTypes() []AgentTypeInfo {
+	return o.factory.GetAgentTypes()
 }
\ No newline at end of file
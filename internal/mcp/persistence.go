@@ -0,0 +1,262 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// DefaultSnapshotInterval es cada cuánto se guardan las métricas de los agentes persistentes
+const DefaultSnapshotInterval = time.Minute
+
+// PersistenceConfig ajusta qué se guarda de los agentes persistentes
+type PersistenceConfig struct {
+	// SnapshotContext guarda también el contexto del agente
+	SnapshotContext bool
+	// SnapshotInterval es cada cuánto se guardan métricas y contexto; 0 usa
+	// DefaultSnapshotInterval y un valor negativo solo los guarda al parar
+	SnapshotInterval time.Duration
+}
+
+// persistedAgent es lo que se recuerda de un agente persistente para guardarlo
+type persistedAgent struct {
+	config    MCPConfig
+	createdAt time.Time
+}
+
+// agentPersistence guarda la configuración de los agentes creados con
+// Persistent y, periódicamente, sus métricas y su contexto
+type agentPersistence struct {
+	repo   domain.MCPAgentRepository
+	config PersistenceConfig
+
+	mu       sync.Mutex
+	agents   map[string]persistedAgent
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// UsePersistence guarda los agentes persistentes en repo. Se llama al arrancar,
+// antes de RestoreAgents y de crear agentes.
+func (o *orchestrator) UsePersistence(repo domain.MCPAgentRepository, config PersistenceConfig) {
+	if config.SnapshotInterval == 0 {
+		config.SnapshotInterval = DefaultSnapshotInterval
+	}
+	p := &agentPersistence{
+		repo:   repo,
+		config: config,
+		agents: make(map[string]persistedAgent),
+		stop:   make(chan struct{}),
+	}
+	o.persistence = p
+
+	if config.SnapshotInterval > 0 {
+		go func() {
+			ticker := time.NewTicker(config.SnapshotInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					o.snapshotAgents(context.Background())
+				case <-p.stop:
+					return
+				}
+			}
+		}()
+	}
+}
+
+// RestoreAgents vuelve a crear los agentes persistidos con sus IDs, métricas y
+// contexto. Los que no se pueden crear se registran y se saltan.
+func (o *orchestrator) RestoreAgents(ctx context.Context) (int, error) {
+	p := o.persistence
+	if p == nil {
+		return 0, nil
+	}
+	records, err := p.repo.List(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list persisted agents: %w", err)
+	}
+
+	restored := 0
+	for _, record := range records {
+		config := MCPConfig{
+			ID:           record.ID,
+			Type:         record.Type,
+			Name:         record.Name,
+			Version:      record.Version,
+			Config:       record.Config,
+			Capabilities: record.Capabilities,
+			Timeout:      time.Duration(record.Timeout) * time.Millisecond,
+			Persistent:   true,
+		}
+		agent, err := o.instantiate(ctx, config)
+		if err != nil {
+			o.logger.Error("Failed to restore MCP agent", "agent_id", record.ID, "type", record.Type, "error", err)
+			continue
+		}
+		o.restoreSnapshot(agent, record)
+
+		p.mu.Lock()
+		p.agents[agent.GetID()] = persistedAgent{config: config, createdAt: record.CreatedAt}
+		p.mu.Unlock()
+		restored++
+	}
+
+	o.logger.Info("Persisted MCP agents restored", "restored", restored, "persisted", len(records))
+	return restored, nil
+}
+
+// restoreSnapshot aplica al agente recién creado las métricas y el contexto guardados
+func (o *orchestrator) restoreSnapshot(agent Agent, record *domain.MCPAgent) {
+	if len(record.AgentMetrics) > 0 {
+		var metrics AgentMetrics
+		if err := json.Unmarshal(record.AgentMetrics, &metrics); err == nil {
+			state := agent.GetState()
+			state.Metrics = metrics
+			_ = agent.UpdateState(state)
+		}
+	}
+	if record.Context != nil {
+		if err := agent.SetContext(record.Context); err != nil {
+			o.logger.Warn("Failed to restore MCP agent context", "agent_id", agent.GetID(), "error", err)
+		}
+	}
+	if record.Metrics != nil {
+		metrics := *record.Metrics
+		metrics.AgentID = agent.GetID()
+		o.mu.Lock()
+		o.agentMetrics[agent.GetID()] = &metrics
+		o.mu.Unlock()
+	}
+}
+
+// persistNewAgent guarda un agente recién creado con Persistent
+func (o *orchestrator) persistNewAgent(ctx context.Context, agent Agent, config MCPConfig) error {
+	p := o.persistence
+	entry := persistedAgent{config: config, createdAt: time.Now()}
+	if err := p.repo.Create(ctx, o.agentRecord(agent, entry)); err != nil {
+		return err
+	}
+	p.mu.Lock()
+	p.agents[agent.GetID()] = entry
+	p.mu.Unlock()
+	return nil
+}
+
+// persistConfigChanges aplica a la configuración guardada los cambios en caliente
+func (o *orchestrator) persistConfigChanges(ctx context.Context, agent Agent, changes map[string]interface{}) {
+	p := o.persistence
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	entry, ok := p.agents[agent.GetID()]
+	if ok {
+		config := make(map[string]interface{}, len(entry.config.Config)+len(changes))
+		for key, value := range entry.config.Config {
+			config[key] = value
+		}
+		for key, value := range changes {
+			config[key] = value
+		}
+		entry.config.Config = config
+		p.agents[agent.GetID()] = entry
+	}
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := p.repo.Update(ctx, o.agentRecord(agent, entry)); err != nil {
+		o.logger.Error("Failed to persist MCP agent config", "agent_id", agent.GetID(), "error", err)
+	}
+}
+
+// forgetPersistedAgent borra el agente guardado al terminarlo
+func (o *orchestrator) forgetPersistedAgent(ctx context.Context, agentID string) {
+	p := o.persistence
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	_, ok := p.agents[agentID]
+	delete(p.agents, agentID)
+	p.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	if err := p.repo.Delete(ctx, agentID); err != nil {
+		o.logger.Error("Failed to delete persisted MCP agent", "agent_id", agentID, "error", err)
+	}
+}
+
+// snapshotAgents guarda las métricas y, si se pidió, el contexto de los agentes persistentes
+func (o *orchestrator) snapshotAgents(ctx context.Context) {
+	p := o.persistence
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	entries := make(map[string]persistedAgent, len(p.agents))
+	for agentID, entry := range p.agents {
+		entries[agentID] = entry
+	}
+	p.mu.Unlock()
+
+	for agentID, entry := range entries {
+		agent, err := o.GetAgent(agentID)
+		if err != nil {
+			continue
+		}
+		if err := p.repo.Update(ctx, o.agentRecord(agent, entry)); err != nil {
+			o.logger.Warn("Failed to snapshot MCP agent", "agent_id", agentID, "error", err)
+		}
+	}
+}
+
+// stopPersistence guarda una última instantánea y para el guardado periódico
+func (o *orchestrator) stopPersistence(ctx context.Context) {
+	p := o.persistence
+	if p == nil {
+		return
+	}
+	p.stopOnce.Do(func() { close(p.stop) })
+	o.snapshotAgents(ctx)
+}
+
+// agentRecord arma el registro que se guarda de un agente persistente
+func (o *orchestrator) agentRecord(agent Agent, entry persistedAgent) *domain.MCPAgent {
+	state := agent.GetState()
+	record := &domain.MCPAgent{
+		ID:           agent.GetID(),
+		Type:         entry.config.Type,
+		Name:         entry.config.Name,
+		Version:      entry.config.Version,
+		Config:       entry.config.Config,
+		Capabilities: entry.config.Capabilities,
+		Status:       domain.MCPAgentStatus(state.Status),
+		Timeout:      entry.config.Timeout.Milliseconds(),
+		CreatedAt:    entry.createdAt,
+		UpdatedAt:    time.Now(),
+	}
+	if raw, err := json.Marshal(state.Metrics); err == nil {
+		record.AgentMetrics = raw
+	}
+	if o.persistence.config.SnapshotContext {
+		record.Context = agent.GetContext()
+	}
+
+	o.mu.RLock()
+	if metrics, ok := o.agentMetrics[agent.GetID()]; ok {
+		metricsCopy := *metrics
+		record.Metrics = &metricsCopy
+	}
+	o.mu.RUnlock()
+	return record
+}
@@ -0,0 +1,73 @@
+package mcp
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/kvstore"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openAgentStore(t *testing.T, path string) (*kvstore.Store, domain.MCPAgentRepository) {
+	t.Helper()
+	store, err := kvstore.Open(path)
+	require.NoError(t, err)
+	repo, err := repositories.NewEmbeddedMCPAgentRepository(store)
+	require.NoError(t, err)
+	return store, repo
+}
+
+func TestPersistence_RestoresAgentsAfterRestart(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	path := filepath.Join(t.TempDir(), "agents.db")
+	mockConfig := map[string]interface{}{"failure_rate": 0.0, "min_processing_time_ms": 1, "max_processing_time_ms": 1}
+
+	store, repo := openAgentStore(t, path)
+	first := NewOrchestrator(NewAgentFactory(log), log)
+	first.UsePersistence(repo, PersistenceConfig{SnapshotContext: true, SnapshotInterval: -1})
+
+	agent, err := first.InstantiateMCP(ctx, MCPConfig{Type: "mock", Name: "support", Persistent: true, Config: mockConfig})
+	require.NoError(t, err)
+	_, err = first.ExecuteTaskDomain(ctx, &domain.MCPTask{ID: "task-1", Type: "mock", Context: map[string]interface{}{"customer": "acme"}})
+	require.NoError(t, err)
+	assistant, err := first.InstantiateMCP(ctx, MCPConfig{Type: "ai", Name: "assistant", Persistent: true, Config: map[string]interface{}{"openai_api_key": "test-key"}})
+	require.NoError(t, err)
+	_, err = first.InstantiateMCP(ctx, MCPConfig{Type: "mock", Name: "ephemeral", Config: mockConfig})
+	require.NoError(t, err)
+	require.NoError(t, first.Stop(ctx))
+	require.NoError(t, store.Close())
+
+	store, repo = openAgentStore(t, path)
+	defer store.Close()
+	second := NewOrchestrator(NewAgentFactory(log), log)
+	second.UsePersistence(repo, PersistenceConfig{SnapshotInterval: -1})
+	restored, err := second.RestoreAgents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 2, restored, "only persistent agents are restored")
+	assert.Len(t, second.ListAgents(), 2)
+
+	again, err := second.GetAgent(agent.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, "acme", again.GetContext()["customer"])
+	assert.Equal(t, 1, again.GetState().Metrics.TasksCompleted)
+	metrics, err := second.GetAgentMetricsDomain(agent.GetID())
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, metrics.TasksSuccessful)
+
+	// Los cambios en caliente se guardan y terminar el agente lo borra
+	_, err = second.UpdateAgentConfig(ctx, assistant.GetID(), map[string]interface{}{"model": "gpt-4o"})
+	require.NoError(t, err)
+	record, err := repo.GetByID(ctx, assistant.GetID())
+	require.NoError(t, err)
+	assert.Equal(t, "gpt-4o", record.Config["model"])
+
+	require.NoError(t, second.TerminateAgent(ctx, assistant.GetID()))
+	_, err = repo.GetByID(ctx, assistant.GetID())
+	assert.Error(t, err)
+}
@@ -0,0 +1,471 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Límites por defecto del sandbox. Los valores de configuración nunca pueden
+// superar los máximos.
+const (
+	defaultSandboxTimeout    = 10 * time.Second
+	maxSandboxTimeout        = 60 * time.Second
+	defaultSandboxMemoryMB   = 128
+	maxSandboxMemoryMB       = 512
+	defaultSandboxCPUs       = 0.5
+	defaultSandboxPidsLimit  = 64
+	defaultSandboxMaxOutput  = 64 * 1024
+	maxSandboxMaxOutput      = 1024 * 1024
+	defaultSandboxMaxScript  = 16 * 1024
+	defaultSandboxMaxInput   = 256 * 1024
+	sandboxStderrLimit       = 4 * 1024
+	sandboxTmpfsSize         = "16m"
+	defaultSandboxRuntimeBin = "docker"
+)
+
+// ErrSandboxOutputLimit indica que el script escribió más de lo permitido
+var ErrSandboxOutputLimit = errors.New("sandbox output exceeds limit")
+
+// SandboxLimits define los recursos disponibles para una ejecución
+type SandboxLimits struct {
+	Timeout        time.Duration `json:"timeout"`
+	MemoryMB       int           `json:"memory_mb"`
+	CPUs           float64       `json:"cpus"`
+	PidsLimit      int           `json:"pids_limit"`
+	MaxOutputBytes int           `json:"max_output_bytes"`
+}
+
+// SandboxRequest representa un script listo para ejecutarse en el sandbox
+type SandboxRequest struct {
+	Name    string
+	Image   string
+	Command []string // intérprete y argumentos; el script se agrega al final
+	Script  string
+	Stdin   []byte
+	Limits  SandboxLimits
+}
+
+// SandboxOutput representa la salida de una ejecución
+type SandboxOutput struct {
+	Stdout    []byte
+	Stderr    []byte
+	ExitCode  int
+	Truncated bool
+	TimedOut  bool
+}
+
+// SandboxRuntime ejecuta scripts aislados. La implementación por defecto usa
+// contenedores sin red y con sistema de archivos de solo lectura.
+type SandboxRuntime interface {
+	Run(ctx context.Context, req SandboxRequest) (SandboxOutput, error)
+}
+
+// sandboxLanguage describe cómo ejecutar un lenguaje dentro del contenedor
+type sandboxLanguage struct {
+	Image   string
+	Command []string
+}
+
+var defaultSandboxLanguages = map[string]sandboxLanguage{
+	"python":     {Image: "python:3.12-alpine", Command: []string{"python3", "-c"}},
+	"javascript": {Image: "node:20-alpine", Command: []string{"node", "-e"}},
+}
+
+// sandboxAgent ejecuta scripts cortos de transformación de datos aprobados
+// previamente por un administrador
+type sandboxAgent struct {
+	*baseAgent
+	runtime        SandboxRuntime
+	languages      map[string]sandboxLanguage
+	limits         SandboxLimits
+	maxScriptBytes int
+	maxInputBytes  int
+	approvedHashes map[string]bool
+	// approvals son los hashes aprobados con la API de administración
+	approvals domain.ScriptApprovalRepository
+}
+
+// NewSandboxAgent crea un agente de ejecución de código sobre contenedores
+func NewSandboxAgent(config MCPConfig, approvals domain.ScriptApprovalRepository, logger logger.Logger) (Agent, error) {
+	runtimeBin := configschema.Values(config.Config).String("runtime", defaultSandboxRuntimeBin)
+	return NewSandboxAgentWithRuntime(config, newContainerRuntime(runtimeBin), approvals, logger)
+}
+
+// NewSandboxAgentWithRuntime crea el agente con un runtime específico.
+// approvals puede ser nil; entonces solo se ejecutan los scripts de
+// approved_scripts.
+func NewSandboxAgentWithRuntime(config MCPConfig, runtime SandboxRuntime, approvals domain.ScriptApprovalRepository, logger logger.Logger) (Agent, error) {
+	if runtime == nil {
+		return nil, fmt.Errorf("sandbox runtime is required")
+	}
+
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{"code_execution", "data_transform"}
+
+	limits, err := parseSandboxLimits(config)
+	if err != nil {
+		return nil, err
+	}
+	values := configschema.Values(config.Config)
+
+	languages := make(map[string]sandboxLanguage, len(defaultSandboxLanguages))
+	for name, language := range defaultSandboxLanguages {
+		languages[name] = language
+	}
+	if images := values.Object("images"); images != nil {
+		for name, image := range images {
+			language, known := languages[name]
+			imageStr, isString := image.(string)
+			if !known || !isString || imageStr == "" {
+				return nil, fmt.Errorf("invalid image override for language %q", name)
+			}
+			language.Image = imageStr
+			languages[name] = language
+		}
+	}
+
+	approved := make(map[string]bool)
+	for _, hash := range values.Strings("approved_scripts") {
+		approved[strings.ToLower(hash)] = true
+	}
+
+	return &sandboxAgent{
+		baseAgent:      base,
+		runtime:        runtime,
+		languages:      languages,
+		limits:         limits,
+		maxScriptBytes: values.Int("max_script_bytes", defaultSandboxMaxScript),
+		maxInputBytes:  values.Int("max_input_bytes", defaultSandboxMaxInput),
+		approvedHashes: approved,
+		approvals:      approvals,
+	}, nil
+}
+
+func (a *sandboxAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	fail := func(err error, output map[string]interface{}) (Result, error) {
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		a.logger.Warn("Sandbox task failed", "agent_id", a.id, "task_id", task.ID, "error", err)
+		return Result{
+			TaskID:   task.ID,
+			Success:  false,
+			Output:   output,
+			Error:    err.Error(),
+			Duration: duration,
+			Metadata: map[string]interface{}{
+				"agent_id":   a.id,
+				"agent_type": a.agentType,
+			},
+		}, err
+	}
+
+	req, hash, err := a.buildRequest(ctx, task)
+	if err != nil {
+		return fail(err, nil)
+	}
+
+	a.logger.Info("Sandbox agent executing script",
+		"agent_id", a.id,
+		"task_id", task.ID,
+		"image", req.Image,
+		"script_sha256", hash)
+
+	runCtx, cancel := context.WithTimeout(ctx, req.Limits.Timeout)
+	defer cancel()
+
+	out, err := a.runtime.Run(runCtx, req)
+	if err != nil {
+		return fail(fmt.Errorf("sandbox execution failed: %w", err), nil)
+	}
+
+	output := map[string]interface{}{
+		"exit_code":     out.ExitCode,
+		"stderr":        string(out.Stderr),
+		"script_sha256": hash,
+	}
+	switch {
+	case out.TimedOut:
+		return fail(fmt.Errorf("script exceeded timeout of %s", req.Limits.Timeout), output)
+	case out.Truncated:
+		return fail(fmt.Errorf("%w of %d bytes", ErrSandboxOutputLimit, req.Limits.MaxOutputBytes), output)
+	case out.ExitCode != 0:
+		return fail(fmt.Errorf("script exited with code %d", out.ExitCode), output)
+	}
+
+	var result interface{}
+	if err := json.Unmarshal(out.Stdout, &result); err != nil {
+		result = string(out.Stdout)
+	}
+	output["result"] = result
+
+	duration := time.Since(start)
+	a.updateMetrics(true, duration)
+
+	a.logger.Info("Sandbox task completed",
+		"agent_id", a.id,
+		"task_id", task.ID,
+		"duration", duration,
+		"output_bytes", len(out.Stdout))
+
+	return Result{
+		TaskID:   task.ID,
+		Success:  true,
+		Output:   output,
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"language":   task.Input["language"],
+		},
+	}, nil
+}
+
+func (a *sandboxAgent) CanHandle(taskType string) bool {
+	switch taskType {
+	case "code_execution", "data_transform", "script":
+		return true
+	}
+	return false
+}
+
+// buildRequest valida el script, su aprobación y los datos de entrada
+func (a *sandboxAgent) buildRequest(ctx context.Context, task Task) (SandboxRequest, string, error) {
+	script, _ := task.Input["script"].(string)
+	if strings.TrimSpace(script) == "" {
+		return SandboxRequest{}, "", fmt.Errorf("script is required")
+	}
+	if len(script) > a.maxScriptBytes {
+		return SandboxRequest{}, "", fmt.Errorf("script exceeds %d bytes", a.maxScriptBytes)
+	}
+
+	languageName, _ := task.Input["language"].(string)
+	if languageName == "" {
+		languageName = "python"
+	}
+	language, ok := a.languages[languageName]
+	if !ok {
+		return SandboxRequest{}, "", fmt.Errorf("unsupported language: %s", languageName)
+	}
+
+	sum := sha256.Sum256([]byte(script))
+	hash := hex.EncodeToString(sum[:])
+	if err := a.checkApproval(ctx, hash); err != nil {
+		return SandboxRequest{}, hash, err
+	}
+
+	var stdin []byte
+	if data, exists := task.Input["data"]; exists {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			return SandboxRequest{}, hash, fmt.Errorf("failed to encode input data: %w", err)
+		}
+		if len(encoded) > a.maxInputBytes {
+			return SandboxRequest{}, hash, fmt.Errorf("input data exceeds %d bytes", a.maxInputBytes)
+		}
+		stdin = encoded
+	}
+
+	limits := a.limits
+	if timeoutSecs, ok := toSeconds(task.Input["timeout_seconds"]); ok {
+		timeout := time.Duration(timeoutSecs * float64(time.Second))
+		if timeout > 0 && timeout < limits.Timeout {
+			limits.Timeout = timeout
+		}
+	}
+
+	return SandboxRequest{
+		Name:    containerName(task.ID),
+		Image:   language.Image,
+		Command: language.Command,
+		Script:  script,
+		Stdin:   stdin,
+		Limits:  limits,
+	}, hash, nil
+}
+
+// checkApproval exige que el hash del script esté en approved_scripts o en las
+// aprobaciones de un administrador. La tarea no puede aprobarse a sí misma y un
+// script modificado después de aprobado no se ejecuta.
+func (a *sandboxAgent) checkApproval(ctx context.Context, hash string) error {
+	if a.approvedHashes[hash] {
+		return nil
+	}
+	if a.approvals != nil {
+		if _, err := a.approvals.Get(ctx, hash); err == nil {
+			return nil
+		}
+	}
+	return fmt.Errorf("script %s is not approved", hash)
+}
+
+// parseSandboxLimits lee los límites de la configuración aplicando máximos
+func parseSandboxLimits(config MCPConfig) (SandboxLimits, error) {
+	values := configschema.Values(config.Config)
+	limits := SandboxLimits{
+		Timeout:        defaultSandboxTimeout,
+		MemoryMB:       values.Int("memory_mb", defaultSandboxMemoryMB),
+		CPUs:           values.Float("cpus", defaultSandboxCPUs),
+		PidsLimit:      values.Int("pids_limit", defaultSandboxPidsLimit),
+		MaxOutputBytes: values.Int("max_output_bytes", defaultSandboxMaxOutput),
+	}
+	if config.Timeout > 0 {
+		limits.Timeout = config.Timeout
+	}
+
+	switch {
+	case limits.Timeout > maxSandboxTimeout:
+		return limits, fmt.Errorf("sandbox timeout cannot exceed %s", maxSandboxTimeout)
+	case limits.MemoryMB <= 0 || limits.MemoryMB > maxSandboxMemoryMB:
+		return limits, fmt.Errorf("memory_mb must be between 1 and %d", maxSandboxMemoryMB)
+	case limits.CPUs <= 0 || limits.CPUs > 2:
+		return limits, fmt.Errorf("cpus must be between 0 and 2")
+	case limits.PidsLimit <= 0:
+		return limits, fmt.Errorf("pids_limit must be positive")
+	case limits.MaxOutputBytes <= 0 || limits.MaxOutputBytes > maxSandboxMaxOutput:
+		return limits, fmt.Errorf("max_output_bytes must be between 1 and %d", maxSandboxMaxOutput)
+	}
+	return limits, nil
+}
+
+func toSeconds(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// containerName agrega un ID nuevo al de la tarea: al quitar caracteres dos IDs
+// distintos pueden quedar iguales, y un reintento de la misma tarea puede
+// arrancar antes de que se borre el contenedor anterior
+func containerName(taskID string) string {
+	return fmt.Sprintf("sandbox-%s-%s", sanitizeContainerName(taskID), id.New())
+}
+
+func sanitizeContainerName(agentID string) string {
+	var b strings.Builder
+	for _, r := range agentID {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	if b.Len() == 0 {
+		return "task"
+	}
+	return b.String()
+}
+
+// containerRuntime ejecuta los scripts con el CLI de docker (o podman)
+type containerRuntime struct {
+	binary string
+}
+
+func newContainerRuntime(binary string) SandboxRuntime {
+	return &containerRuntime{binary: binary}
+}
+
+func (r *containerRuntime) Run(ctx context.Context, req SandboxRequest) (SandboxOutput, error) {
+	args := []string{
+		"run", "--rm", "-i",
+		"--name", req.Name,
+		"--network", "none",
+		"--read-only",
+		"--tmpfs", "/tmp:rw,noexec,nosuid,size=" + sandboxTmpfsSize,
+		"--memory", fmt.Sprintf("%dm", req.Limits.MemoryMB),
+		"--memory-swap", fmt.Sprintf("%dm", req.Limits.MemoryMB),
+		"--cpus", fmt.Sprintf("%.2f", req.Limits.CPUs),
+		"--pids-limit", fmt.Sprintf("%d", req.Limits.PidsLimit),
+		"--cap-drop", "ALL",
+		"--security-opt", "no-new-privileges",
+		"--user", "65534:65534",
+		req.Image,
+	}
+	args = append(args, req.Command...)
+	args = append(args, req.Script)
+
+	stdout := &cappedBuffer{limit: req.Limits.MaxOutputBytes}
+	stderr := &cappedBuffer{limit: sandboxStderrLimit}
+
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	cmd.Stdin = bytes.NewReader(req.Stdin)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err := cmd.Run()
+	out := SandboxOutput{
+		Stdout:    stdout.Bytes(),
+		Stderr:    stderr.Bytes(),
+		Truncated: stdout.truncated,
+	}
+
+	if ctx.Err() != nil {
+		// Matar el CLI no detiene el contenedor; se elimina explícitamente
+		r.remove(req.Name)
+		out.TimedOut = errors.Is(ctx.Err(), context.DeadlineExceeded)
+		out.ExitCode = -1
+		return out, nil
+	}
+
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			out.ExitCode = exitErr.ExitCode()
+			return out, nil
+		}
+		return out, err
+	}
+	return out, nil
+}
+
+func (r *containerRuntime) remove(name string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_ = exec.CommandContext(ctx, r.binary, "rm", "-f", name).Run()
+}
+
+// cappedBuffer guarda hasta limit bytes y descarta el resto sin devolver
+// error, para que el proceso no falle por escribir en una tubería cerrada
+type cappedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	remaining := b.limit - b.Len()
+	if remaining <= 0 {
+		if len(p) > 0 {
+			b.truncated = true
+		}
+		return len(p), nil
+	}
+	if len(p) > remaining {
+		b.Buffer.Write(p[:remaining])
+		b.truncated = true
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSandboxRuntime guarda las peticiones y devuelve una salida fija
+type fakeSandboxRuntime struct {
+	mu       sync.Mutex
+	requests []SandboxRequest
+	output   SandboxOutput
+}
+
+func (r *fakeSandboxRuntime) Run(ctx context.Context, req SandboxRequest) (SandboxOutput, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	return r.output, nil
+}
+
+func scriptSHA256(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestSandboxAgent_RequiresAdminApproval(t *testing.T) {
+	ctx := context.Background()
+	runtime := &fakeSandboxRuntime{output: SandboxOutput{Stdout: []byte(`{"total": 3}`)}}
+	approvals := repositories.NewMockScriptApprovalRepository()
+	agent, err := NewSandboxAgentWithRuntime(MCPConfig{Type: "sandbox", Name: "transform"}, runtime, approvals, logger.NewLogger("error"))
+	require.NoError(t, err)
+
+	script := "import json,sys; print(json.dumps({'total': 3}))"
+	hash := scriptSHA256(script)
+
+	// La aprobación que trae la propia tarea no cuenta
+	_, err = agent.Execute(ctx, Task{ID: "task-1", Type: "script", Input: map[string]interface{}{
+		"script":   script,
+		"approval": map[string]interface{}{"approved_by": "me", "script_sha256": hash},
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not approved")
+	assert.Empty(t, runtime.requests)
+
+	require.NoError(t, approvals.Create(ctx, &domain.ScriptApproval{Hash: hash, ApprovedBy: "admin", CreatedAt: time.Now()}))
+	result, err := agent.Execute(ctx, Task{ID: "task-1", Type: "script", Input: map[string]interface{}{
+		"script": script,
+		"data":   []int{1, 2},
+	}})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, map[string]interface{}{"total": 3.0}, result.Output["result"])
+	assert.Equal(t, hash, result.Output["script_sha256"])
+	require.Len(t, runtime.requests, 1)
+	assert.Equal(t, "python:3.12-alpine", runtime.requests[0].Image)
+	assert.Equal(t, []byte("[1,2]"), runtime.requests[0].Stdin)
+
+	// Un script modificado después de aprobado no se ejecuta
+	_, err = agent.Execute(ctx, Task{ID: "task-2", Type: "script", Input: map[string]interface{}{"script": script + " "}})
+	assert.Error(t, err)
+
+	// Tras revocar la aprobación tampoco
+	require.NoError(t, approvals.Delete(ctx, hash))
+	_, err = agent.Execute(ctx, Task{ID: "task-3", Type: "script", Input: map[string]interface{}{"script": script}})
+	assert.Error(t, err)
+	assert.Len(t, runtime.requests, 1)
+}
+
+func TestSandboxAgent_ConfiguredApprovalsAndFailures(t *testing.T) {
+	ctx := context.Background()
+	script := "print('hola')"
+	runtime := &fakeSandboxRuntime{output: SandboxOutput{ExitCode: 1, Stderr: []byte("boom")}}
+	agent, err := NewSandboxAgentWithRuntime(MCPConfig{
+		Type:   "sandbox",
+		Name:   "transform",
+		Config: map[string]interface{}{"approved_scripts": []interface{}{scriptSHA256(script)}},
+	}, runtime, nil, logger.NewLogger("error"))
+	require.NoError(t, err)
+
+	result, err := agent.Execute(ctx, Task{ID: "task-1", Type: "script", Input: map[string]interface{}{"script": script}})
+	require.Error(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, "boom", result.Output["stderr"])
+
+	runtime.output = SandboxOutput{Truncated: true}
+	_, err = agent.Execute(ctx, Task{ID: "task-2", Type: "script", Input: map[string]interface{}{"script": script}})
+	assert.ErrorIs(t, err, ErrSandboxOutputLimit)
+
+	_, err = agent.Execute(ctx, Task{ID: "task-3", Type: "script", Input: map[string]interface{}{"script": script, "language": "ruby"}})
+	assert.ErrorContains(t, err, "unsupported language")
+}
+
+func TestContainerName_IsUnique(t *testing.T) {
+	// "a.b" y "ab" quedan iguales al quitar caracteres, y una misma tarea puede reintentarse
+	names := map[string]bool{}
+	for _, taskID := range []string{"a.b", "ab", "ab", ""} {
+		name := containerName(taskID)
+		assert.Regexp(t, `^sandbox-[A-Za-z0-9_-]+$`, name)
+		assert.False(t, names[name], name)
+		names[name] = true
+	}
+}
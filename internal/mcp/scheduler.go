@@ -0,0 +1,275 @@
+package mcp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	// DefaultMaxQueueDepth es el máximo de tareas de un tipo esperando agente
+	DefaultMaxQueueDepth = 100
+	// DefaultQueueWaitTimeout es cuánto espera una tarea a que se libere un agente
+	DefaultQueueWaitTimeout = 30 * time.Second
+	// queueRetryInterval es cada cuánto una tarea en cola vuelve a buscar agente,
+	// por si uno se recupera o se reanuda su tipo sin liberar ninguna tarea
+	queueRetryInterval = 500 * time.Millisecond
+	// latencyWeight es el peso de la última ejecución en la latencia media del agente
+	latencyWeight = 0.3
+)
+
+// ErrQueueFull indica que la cola del tipo de tarea ya tiene MaxQueueDepth tareas
+var ErrQueueFull = errors.New("agent queue is full")
+
+// SchedulerConfig ajusta la cola de tareas que esperan a que se libere un agente
+type SchedulerConfig struct {
+	// MaxQueueDepth es el máximo de tareas en espera por tipo; 0 usa
+	// DefaultMaxQueueDepth y un valor negativo desactiva la cola
+	MaxQueueDepth int
+	// WaitTimeout es la espera máxima en la cola; 0 usa DefaultQueueWaitTimeout
+	WaitTimeout time.Duration
+}
+
+// agentLoad es lo que el planificador sabe de la carga de un agente
+type agentLoad struct {
+	busy       bool
+	dispatched int64
+	latency    time.Duration // media móvil exponencial de sus ejecuciones
+}
+
+// queuedTask es una tarea esperando agente; recibe en agent el que se le reserve
+type queuedTask struct {
+	taskType string
+	accept   func(Agent) bool
+	agent    chan Agent
+}
+
+// agentScheduler reserva agentes para las tareas: cada agente atiende una tarea
+// a la vez y, entre los libres, se elige el de menor latencia media y, a
+// igualdad, el que menos tareas ha atendido. Si todos los agentes capaces están
+// ocupados la tarea espera en la cola de su tipo, por orden de llegada.
+type agentScheduler struct {
+	mu     sync.Mutex
+	config SchedulerConfig
+	loads  map[string]*agentLoad
+	queues map[string][]*queuedTask
+
+	// candidates devuelve los agentes sanos, no pausados, que pueden atender el tipo
+	candidates func(taskType string) []Agent
+	logger     logger.Logger
+}
+
+func newAgentScheduler(candidates func(taskType string) []Agent, logger logger.Logger) *agentScheduler {
+	return &agentScheduler{
+		config:     SchedulerConfig{MaxQueueDepth: DefaultMaxQueueDepth, WaitTimeout: DefaultQueueWaitTimeout},
+		loads:      make(map[string]*agentLoad),
+		queues:     make(map[string][]*queuedTask),
+		candidates: candidates,
+		logger:     logger,
+	}
+}
+
+func (s *agentScheduler) configure(config SchedulerConfig) {
+	if config.MaxQueueDepth == 0 {
+		config.MaxQueueDepth = DefaultMaxQueueDepth
+	}
+	if config.WaitTimeout <= 0 {
+		config.WaitTimeout = DefaultQueueWaitTimeout
+	}
+	s.mu.Lock()
+	s.config = config
+	s.mu.Unlock()
+}
+
+// acquire reserva un agente para la tarea; hay que devolverlo con release. Sin
+// agentes capaces falla enseguida, como si no hubiera cola.
+func (s *agentScheduler) acquire(ctx context.Context, taskID, taskType string, accept func(Agent) bool) (Agent, error) {
+	s.mu.Lock()
+	agent, capable := s.selectLocked(taskType, accept)
+	if agent != nil && len(s.queues[taskType]) == 0 {
+		s.reserveLocked(agent)
+		s.mu.Unlock()
+		return agent, nil
+	}
+	if !capable {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("%w for task type: %s", ErrNoAgentAvailable, taskType)
+	}
+	if s.config.MaxQueueDepth < 0 || len(s.queues[taskType]) >= s.config.MaxQueueDepth {
+		s.mu.Unlock()
+		metrics.MCPQueueRejections.WithLabelValues(taskType, "full").Inc()
+		return nil, fmt.Errorf("%w for task type %s: %w", ErrNoAgentAvailable, taskType, ErrQueueFull)
+	}
+
+	queued := &queuedTask{taskType: taskType, accept: accept, agent: make(chan Agent, 1)}
+	s.queues[taskType] = append(s.queues[taskType], queued)
+	depth, timeout := len(s.queues[taskType]), s.config.WaitTimeout
+	s.mu.Unlock()
+
+	s.logger.Info("Task queued waiting for a free agent",
+		"task_id", taskID,
+		"task_type", taskType,
+		"queued", depth)
+
+	// Puede haber un agente libre reservado para tareas anteriores del tipo
+	s.dispatchQueued()
+
+	start := time.Now()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := time.NewTicker(queueRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case agent := <-queued.agent:
+			metrics.MCPQueueWait.WithLabelValues(taskType).Observe(time.Since(start).Seconds())
+			return agent, nil
+		case <-ticker.C:
+			s.dispatchQueued()
+		case <-timer.C:
+			if agent := s.abandon(queued); agent != nil {
+				return agent, nil
+			}
+			metrics.MCPQueueRejections.WithLabelValues(taskType, "timeout").Inc()
+			return nil, fmt.Errorf("%w for task type %s: timed out after %s in queue", ErrNoAgentAvailable, taskType, timeout)
+		case <-ctx.Done():
+			if agent := s.abandon(queued); agent != nil {
+				s.release(agent, 0)
+			}
+			return nil, fmt.Errorf("task %s cancelled while waiting for an agent: %w", taskID, ctx.Err())
+		}
+	}
+}
+
+// release devuelve el agente reservado y actualiza su latencia media con la
+// duración de la ejecución (0 si no llegó a ejecutar)
+func (s *agentScheduler) release(agent Agent, duration time.Duration) {
+	s.mu.Lock()
+	if load, ok := s.loads[agent.GetID()]; ok {
+		load.busy = false
+		if duration > 0 {
+			if load.latency == 0 {
+				load.latency = duration
+			} else {
+				load.latency = time.Duration(latencyWeight*float64(duration) + (1-latencyWeight)*float64(load.latency))
+			}
+		}
+	}
+	s.mu.Unlock()
+	s.dispatchQueued()
+}
+
+// forget descarta la carga de un agente terminado
+func (s *agentScheduler) forget(agentID string) {
+	s.mu.Lock()
+	delete(s.loads, agentID)
+	s.mu.Unlock()
+}
+
+// dispatchQueued reserva agentes libres para las tareas en cola, de la más
+// antigua a la más nueva de cada tipo
+func (s *agentScheduler) dispatchQueued() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for taskType, queue := range s.queues {
+		for len(queue) > 0 {
+			agent, _ := s.selectLocked(taskType, queue[0].accept)
+			if agent == nil {
+				break
+			}
+			s.reserveLocked(agent)
+			queue[0].agent <- agent
+			queue = queue[1:]
+		}
+		if len(queue) == 0 {
+			delete(s.queues, taskType)
+		} else {
+			s.queues[taskType] = queue
+		}
+	}
+}
+
+// abandon saca la tarea de la cola. Si mientras tanto ya se le había reservado
+// un agente lo devuelve para que quien abandona decida qué hacer con él.
+func (s *agentScheduler) abandon(target *queuedTask) Agent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	queue := s.queues[target.taskType]
+	for i, queued := range queue {
+		if queued == target {
+			s.queues[target.taskType] = append(queue[:i:i], queue[i+1:]...)
+			if len(s.queues[target.taskType]) == 0 {
+				delete(s.queues, target.taskType)
+			}
+			return nil
+		}
+	}
+	return <-target.agent
+}
+
+// waiting cuenta las tareas en cola por tipo
+func (s *agentScheduler) waiting() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]int, len(s.queues))
+	for taskType, queue := range s.queues {
+		counts[taskType] = len(queue)
+	}
+	return counts
+}
+
+// selectLocked elige el mejor agente libre; capable indica si hay algún agente
+// que podría atender la tarea aunque ahora esté ocupado
+func (s *agentScheduler) selectLocked(taskType string, accept func(Agent) bool) (best Agent, capable bool) {
+	var bestLoad *agentLoad
+	for _, agent := range s.candidates(taskType) {
+		if accept != nil && !accept(agent) {
+			continue
+		}
+		capable = true
+		load := s.loadLocked(agent.GetID())
+		// Un agente también puede estar ocupado con una ejecución directa; los
+		// que están en error solo llegan aquí con el circuito entreabierto
+		if load.busy || agent.GetState().Status == AgentStatusBusy {
+			continue
+		}
+		if best == nil || lessLoaded(agent, load, best, bestLoad) {
+			best, bestLoad = agent, load
+		}
+	}
+	return best, capable
+}
+
+func lessLoaded(a Agent, aLoad *agentLoad, b Agent, bLoad *agentLoad) bool {
+	if aLoad.latency != bLoad.latency {
+		return aLoad.latency < bLoad.latency
+	}
+	if aLoad.dispatched != bLoad.dispatched {
+		return aLoad.dispatched < bLoad.dispatched
+	}
+	return a.GetID() < b.GetID()
+}
+
+func (s *agentScheduler) loadLocked(agentID string) *agentLoad {
+	load, ok := s.loads[agentID]
+	if !ok {
+		load = &agentLoad{}
+		s.loads[agentID] = load
+	}
+	return load
+}
+
+func (s *agentScheduler) reserveLocked(agent Agent) {
+	load := s.loadLocked(agent.GetID())
+	load.busy = true
+	load.dispatched++
+}
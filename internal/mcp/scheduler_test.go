@@ -0,0 +1,102 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScheduler(t *testing.T, count int, config SchedulerConfig) (*agentScheduler, []Agent) {
+	t.Helper()
+	log := logger.NewLogger("error")
+	agents := make([]Agent, count)
+	for i := range agents {
+		agent, err := NewMockAgent(MCPConfig{Type: "mock", Name: fmt.Sprintf("agent-%d", i)}, log)
+		require.NoError(t, err)
+		agents[i] = agent
+	}
+	scheduler := newAgentScheduler(func(taskType string) []Agent {
+		if taskType != "mock" {
+			return nil
+		}
+		return agents
+	}, log)
+	scheduler.configure(config)
+	return scheduler, agents
+}
+
+func TestAgentScheduler_PrefersLeastLoadedAgent(t *testing.T) {
+	ctx := context.Background()
+	scheduler, agents := newTestScheduler(t, 3, SchedulerConfig{})
+
+	// Sin historial se reparten por número de tareas atendidas
+	seen := make(map[string]bool)
+	for range agents {
+		agent, err := scheduler.acquire(ctx, "task", "mock", nil)
+		require.NoError(t, err)
+		seen[agent.GetID()] = true
+	}
+	assert.Len(t, seen, 3)
+
+	scheduler.release(agents[0], 300*time.Millisecond)
+	scheduler.release(agents[1], 100*time.Millisecond)
+	scheduler.release(agents[2], 200*time.Millisecond)
+
+	agent, err := scheduler.acquire(ctx, "task", "mock", nil)
+	require.NoError(t, err)
+	assert.Equal(t, agents[1].GetID(), agent.GetID(), "the fastest idle agent is chosen")
+	next, err := scheduler.acquire(ctx, "task", "mock", nil)
+	require.NoError(t, err)
+	assert.Equal(t, agents[2].GetID(), next.GetID(), "busy agents are skipped")
+
+	// Sin agentes capaces no se encola
+	_, err = scheduler.acquire(ctx, "task", "translation", nil)
+	assert.ErrorIs(t, err, ErrNoAgentAvailable)
+	assert.NotErrorIs(t, err, ErrQueueFull)
+}
+
+func TestAgentScheduler_QueuesWhenAllAgentsAreBusy(t *testing.T) {
+	ctx := context.Background()
+	scheduler, agents := newTestScheduler(t, 1, SchedulerConfig{MaxQueueDepth: 1, WaitTimeout: time.Second})
+
+	busy, err := scheduler.acquire(ctx, "first", "mock", nil)
+	require.NoError(t, err)
+
+	acquired := make(chan Agent)
+	go func() {
+		agent, err := scheduler.acquire(ctx, "second", "mock", nil)
+		assert.NoError(t, err)
+		acquired <- agent
+	}()
+	require.Eventually(t, func() bool { return scheduler.waiting()["mock"] == 1 }, time.Second, 5*time.Millisecond)
+
+	// La cola está llena
+	_, err = scheduler.acquire(ctx, "third", "mock", nil)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.ErrorIs(t, err, ErrNoAgentAvailable)
+
+	scheduler.release(busy, 10*time.Millisecond)
+	select {
+	case agent := <-acquired:
+		assert.Equal(t, agents[0].GetID(), agent.GetID())
+	case <-time.After(time.Second):
+		t.Fatal("queued task did not get the released agent")
+	}
+	assert.Empty(t, scheduler.waiting())
+
+	// Sin liberar el agente la espera caduca
+	scheduler.configure(SchedulerConfig{WaitTimeout: 20 * time.Millisecond})
+	_, err = scheduler.acquire(ctx, "fourth", "mock", nil)
+	assert.ErrorIs(t, err, ErrNoAgentAvailable)
+	assert.Empty(t, scheduler.waiting())
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = scheduler.acquire(cancelled, "fifth", "mock", nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
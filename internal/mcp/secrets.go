@@ -0,0 +1,164 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/secrets"
+)
+
+// secretResolvingFactory sustituye las referencias ${secret:nombre} de la
+// configuración antes de crear cada agente. La configuración que guarda el
+// orquestador conserva las referencias, nunca los valores.
+type secretResolvingFactory struct {
+	AgentFactory
+	secrets *secrets.Manager
+	logger  logger.Logger
+}
+
+// WithSecrets devuelve una factory cuyos agentes leen sus credenciales del
+// gestor de secretos y se recrean con los valores nuevos cuando rotan
+func WithSecrets(factory AgentFactory, manager *secrets.Manager, logger logger.Logger) AgentFactory {
+	return &secretResolvingFactory{AgentFactory: factory, secrets: manager, logger: logger}
+}
+
+func (f *secretResolvingFactory) CreateAgent(config MCPConfig) (Agent, error) {
+	references := secrets.References(config.Config)
+	if len(references) == 0 {
+		return f.AgentFactory.CreateAgent(config)
+	}
+
+	agent, err := f.create(context.Background(), config)
+	if err != nil {
+		return nil, err
+	}
+	return &secretAgent{id: agent.GetID(), agent: agent, config: config, references: references, factory: f}, nil
+}
+
+func (f *secretResolvingFactory) create(ctx context.Context, config MCPConfig) (Agent, error) {
+	resolved, err := f.secrets.Resolve(ctx, config.Config)
+	if err != nil {
+		return nil, err
+	}
+	config.Config = resolved
+	return f.AgentFactory.CreateAgent(config)
+}
+
+// secretAgent delega en un agente creado con los secretos resueltos y lo
+// sustituye por uno nuevo, con el mismo estado y contexto, cuando rota alguno
+// de los secretos de su configuración. El ID es siempre el del primer agente,
+// que es con el que lo registra el orquestador.
+type secretAgent struct {
+	id         string
+	mu         sync.RWMutex
+	agent      Agent
+	config     MCPConfig
+	references []string
+	factory    *secretResolvingFactory
+	unwatch    func()
+}
+
+func (a *secretAgent) current() Agent {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.agent
+}
+
+func (a *secretAgent) GetID() string             { return a.id }
+func (a *secretAgent) GetType() string           { return a.current().GetType() }
+func (a *secretAgent) GetCapabilities() []string { return a.current().GetCapabilities() }
+func (a *secretAgent) CanHandle(taskType string) bool {
+	return a.current().CanHandle(taskType)
+}
+func (a *secretAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	return a.current().Execute(ctx, task)
+}
+func (a *secretAgent) GetState() AgentState               { return a.current().GetState() }
+func (a *secretAgent) UpdateState(state AgentState) error { return a.current().UpdateState(state) }
+func (a *secretAgent) GetHistory() AgentHistory           { return a.current().GetHistory() }
+func (a *secretAgent) SetContext(ctx map[string]interface{}) error {
+	return a.current().SetContext(ctx)
+}
+func (a *secretAgent) GetContext() map[string]interface{} { return a.current().GetContext() }
+func (a *secretAgent) IsHealthy() bool                    { return a.current().IsHealthy() }
+
+func (a *secretAgent) Start(ctx context.Context) error {
+	if err := a.current().Start(ctx); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	if a.unwatch == nil {
+		a.unwatch = a.factory.secrets.Watch(a.references, a.rotate)
+	}
+	a.mu.Unlock()
+	return nil
+}
+
+func (a *secretAgent) Stop(ctx context.Context) error {
+	a.mu.Lock()
+	if a.unwatch != nil {
+		a.unwatch()
+		a.unwatch = nil
+	}
+	a.mu.Unlock()
+	return a.current().Stop(ctx)
+}
+
+// rotate crea el agente con los secretos nuevos y sólo entonces retira el
+// anterior; si falla, el agente anterior sigue atendiendo
+func (a *secretAgent) rotate(changed []string) {
+	ctx := context.Background()
+	log := a.factory.logger
+	previous := a.current()
+
+	replacement, err := a.factory.create(ctx, a.config)
+	if err == nil {
+		err = replacement.Start(ctx)
+	}
+	if err != nil {
+		log.Error("Failed to recreate agent after secret rotation",
+			"agent_id", a.id,
+			"secrets", changed,
+			"error", err)
+		return
+	}
+
+	state := previous.GetState()
+	if configurable, ok := unwrapAgent(replacement).(ConfigurableAgent); ok {
+		if changes := hotChanges(state.ConfigHistory, a.config.Config); len(changes) > 0 {
+			if _, err := configurable.UpdateConfig(changes); err != nil {
+				log.Warn("Failed to reapply config changes after secret rotation", "agent_id", a.id, "error", err)
+			}
+		}
+	}
+	state.Status = replacement.GetState().Status
+	_ = replacement.UpdateState(state)
+	_ = replacement.SetContext(previous.GetContext())
+
+	a.mu.Lock()
+	a.agent = replacement
+	a.mu.Unlock()
+	if err := previous.Stop(ctx); err != nil {
+		log.Warn("Failed to stop agent replaced after secret rotation", "agent_id", a.id, "error", err)
+	}
+
+	log.Info("Agent recreated after secret rotation",
+		"agent_id", a.id,
+		"type", replacement.GetType(),
+		"secrets", changed)
+}
+
+// hotChanges acumula los cambios aplicados en caliente para repetirlos en el
+// agente recreado. Los campos con secretos salen siempre de la configuración.
+func hotChanges(history []ConfigChange, config map[string]interface{}) map[string]interface{} {
+	changes := make(map[string]interface{})
+	for _, change := range history {
+		for field, value := range change.Current {
+			if len(secrets.References(config[field])) == 0 {
+				changes[field] = value
+			}
+		}
+	}
+	return changes
+}
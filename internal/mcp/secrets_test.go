@@ -0,0 +1,72 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/secrets"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSecrets_RecreatesAgentWhenSecretRotates(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	t.Setenv("CRM_TOKEN", "token-1")
+
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	manager := secrets.NewManager(secrets.NewEnvProvider(), time.Minute, log)
+	factory := WithSecrets(NewAgentFactory(log), manager, log)
+	config := MCPConfig{
+		Type: "http",
+		Name: "crm",
+		Config: map[string]interface{}{
+			"base_url": server.URL,
+			"headers":  map[string]interface{}{"Authorization": "Bearer ${secret:crm/token}"},
+		},
+	}
+	agent, err := factory.CreateAgent(config)
+	require.NoError(t, err)
+	require.NoError(t, agent.Start(ctx))
+	id := agent.GetID()
+
+	_, err = unwrapAgent(agent).(ConfigurableAgent).UpdateConfig(map[string]interface{}{"timeout": "5s"})
+	require.NoError(t, err)
+	require.NoError(t, agent.SetContext(map[string]interface{}{"tenant": "acme"}))
+
+	_, err = agent.Execute(ctx, Task{ID: "t1", Type: "http_request", Input: map[string]interface{}{"method": "GET"}})
+	require.NoError(t, err)
+
+	t.Setenv("CRM_TOKEN", "token-2")
+	assert.Equal(t, []string{"crm/token"}, manager.Refresh(ctx))
+
+	_, err = agent.Execute(ctx, Task{ID: "t2", Type: "http_request", Input: map[string]interface{}{"method": "GET"}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-2"}, seen)
+
+	// El agente recreado conserva ID, contexto y cambios en caliente
+	assert.Equal(t, id, agent.GetID())
+	assert.Equal(t, "acme", agent.GetContext()["tenant"])
+	assert.Equal(t, 5*time.Second, unwrapAgent(agent).(*httpAgent).client.Timeout)
+	assert.Len(t, agent.GetState().ConfigHistory, 1)
+	assert.Equal(t, "Bearer ${secret:crm/token}", config.Config["headers"].(map[string]interface{})["Authorization"])
+
+	require.NoError(t, agent.Stop(ctx))
+	t.Setenv("CRM_TOKEN", "token-3")
+	manager.Refresh(ctx)
+	assert.Equal(t, AgentStatusTerminated, agent.GetState().Status)
+}
@@ -0,0 +1,182 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/company/bot-service/pkg/id"
+)
+
+// DefaultMaxToolIterations es cuántas rondas de llamadas a herramientas hace
+// el agente de IA antes de pedir la respuesta final
+const DefaultMaxToolIterations = 5
+
+// ToolDefinition describe una herramienta que el modelo puede pedir. Parameters
+// es un JSON Schema de los argumentos.
+type ToolDefinition struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// ToolCall es una llamada a herramienta pedida por el modelo durante una tarea
+type ToolCall struct {
+	Name      string
+	Arguments map[string]interface{}
+	// Task es la tarea del agente de IA que pidió la llamada
+	Task Task
+}
+
+// ToolFunc ejecuta una herramienta registrada con RegisterTool
+type ToolFunc func(ctx context.Context, call ToolCall) (map[string]interface{}, error)
+
+// toolRunner resuelve y ejecuta las herramientas que pide el modelo
+type toolRunner interface {
+	toolDefinitions(names []string) []ToolDefinition
+	runTool(ctx context.Context, call ToolCall) (map[string]interface{}, error)
+}
+
+type toolRunnerKey struct{}
+
+// withToolRunner permite a los agentes de la tarea llamar a herramientas
+func withToolRunner(ctx context.Context, runner toolRunner) context.Context {
+	return context.WithValue(ctx, toolRunnerKey{}, runner)
+}
+
+// toolRunnerFrom devuelve el toolRunner del contexto o nil
+func toolRunnerFrom(ctx context.Context) toolRunner {
+	runner, _ := ctx.Value(toolRunnerKey{}).(toolRunner)
+	return runner
+}
+
+// builtinToolSchemas son los argumentos de los tipos de tarea integrados que
+// tiene sentido ofrecer al modelo; el resto acepta cualquier objeto
+var builtinToolSchemas = map[string]ToolDefinition{
+	"http_request": {
+		Description: "Call an external HTTP API and return its status code and body",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"method":   map[string]interface{}{"type": "string", "enum": []string{"GET", "POST", "PUT", "PATCH", "DELETE"}},
+				"endpoint": map[string]interface{}{"type": "string", "description": "Path relative to the agent base URL"},
+				"params":   map[string]interface{}{"type": "object", "description": "Query parameters"},
+				"body":     map[string]interface{}{"type": "object", "description": "JSON body"},
+			},
+			"required": []string{"method", "endpoint"},
+		},
+	},
+	"workflow": {
+		Description: "Run the configured workflow with the given input",
+		Parameters:  map[string]interface{}{"type": "object", "additionalProperties": true},
+	},
+}
+
+// registeredTool es una herramienta de RegisterTool
+type registeredTool struct {
+	definition ToolDefinition
+	fn         ToolFunc
+}
+
+// toolRegistry guarda las herramientas que no son tipos de tarea de un agente
+type toolRegistry struct {
+	mu    sync.RWMutex
+	tools map[string]registeredTool
+}
+
+// RegisterTool ofrece al modelo una herramienta que no es un tipo de tarea de
+// un agente (por ejemplo, la búsqueda en la memoria del usuario)
+func (o *orchestrator) RegisterTool(definition ToolDefinition, fn ToolFunc) error {
+	if definition.Name == "" || fn == nil {
+		return fmt.Errorf("tool name and function are required")
+	}
+	if definition.Parameters == nil {
+		definition.Parameters = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+	}
+
+	o.tools.mu.Lock()
+	defer o.tools.mu.Unlock()
+	if _, exists := o.tools.tools[definition.Name]; exists {
+		return fmt.Errorf("tool already registered: %s", definition.Name)
+	}
+	o.tools.tools[definition.Name] = registeredTool{definition: definition, fn: fn}
+	return nil
+}
+
+// toolDefinitions devuelve las definiciones de las herramientas pedidas que
+// existen: las registradas y los tipos de tarea que atiende algún agente sano
+func (o *orchestrator) toolDefinitions(names []string) []ToolDefinition {
+	definitions := make([]ToolDefinition, 0, len(names))
+	for _, name := range names {
+		o.tools.mu.RLock()
+		tool, registered := o.tools.tools[name]
+		o.tools.mu.RUnlock()
+		if registered {
+			definitions = append(definitions, tool.definition)
+			continue
+		}
+
+		definition, ok := o.agentToolDefinition(name)
+		if !ok {
+			o.logger.Warn("Ignoring unknown tool", "tool", name)
+			continue
+		}
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
+
+// agentToolDefinition describe un tipo de tarea como herramienta. Las
+// herramientas de los agentes mcp_client conservan su esquema.
+func (o *orchestrator) agentToolDefinition(taskType string) (ToolDefinition, bool) {
+	for _, agent := range o.ListAgents() {
+		if !agent.IsHealthy() || !agent.CanHandle(taskType) {
+			continue
+		}
+		if toolAgent, ok := unwrapAgent(agent).(*mcpClientAgent); ok {
+			if tool, found := toolAgent.toolForTask(taskType); found && tool.InputSchema != nil {
+				return ToolDefinition{Name: taskType, Description: tool.Description, Parameters: tool.InputSchema}, true
+			}
+		}
+
+		definition, ok := builtinToolSchemas[taskType]
+		if !ok {
+			definition = ToolDefinition{
+				Description: fmt.Sprintf("Run a %s task", taskType),
+				Parameters:  map[string]interface{}{"type": "object", "additionalProperties": true},
+			}
+		}
+		definition.Name = taskType
+		return definition, true
+	}
+	return ToolDefinition{}, false
+}
+
+// runTool ejecuta la herramienta registrada o, si no lo está, una tarea del
+// tipo con los argumentos como entrada
+func (o *orchestrator) runTool(ctx context.Context, call ToolCall) (map[string]interface{}, error) {
+	o.tools.mu.RLock()
+	tool, registered := o.tools.tools[call.Name]
+	o.tools.mu.RUnlock()
+	if registered {
+		return tool.fn(ctx, call)
+	}
+
+	result, err := o.ExecuteTask(ctx, Task{
+		ID:          fmt.Sprintf("tool-%s-%s", call.Task.ID, id.New()),
+		Type:        call.Name,
+		Description: fmt.Sprintf("Tool call from task %s", call.Task.ID),
+		Input:       call.Arguments,
+		Priority:    call.Task.Priority,
+		Metadata:    map[string]interface{}{"source": "tool_call", "parent_task_id": call.Task.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("%s", result.Error)
+	}
+	return result.Output, nil
+}
@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// ordersAgent solo atiende lookup_order
+type ordersAgent struct{ *stubAgent }
+
+func (a *ordersAgent) CanHandle(taskType string) bool { return taskType == "lookup_order" }
+
+// newToolCallingServer simula la API de chat: mientras alwaysCall sea false
+// pide get_weather y lookup_order en la primera llamada y responde en la segunda
+func newToolCallingServer(t *testing.T, alwaysCall bool) (*httptest.Server, *[]openAIRequest) {
+	var mu sync.Mutex
+	requests := []openAIRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req openAIRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		reply := `{"role": "assistant", "content": "It is sunny in Lima"}`
+		if alwaysCall || len(req.Messages) == 1 {
+			reply = `{"role": "assistant", "content": "", "tool_calls": [
+				{"id": "call-1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"city\": \"Lima\"}"}},
+				{"id": "call-2", "type": "function", "function": {"name": "lookup_order", "arguments": "{\"order_id\": \"A1\"}"}}]}`
+		}
+		fmt.Fprintf(w, `{"model": "gpt-test", "choices": [{"message": %s, "finish_reason": "stop"}], "usage": {"prompt_tokens": 10, "completion_tokens": 5}}`, reply)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestAIAgent_CallsOrchestratorTools(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	factory := NewAgentFactory(log)
+	require.NoError(t, factory.RegisterAgentType(AgentTypeDefinition{
+		AgentTypeInfo: AgentTypeInfo{Type: "orders", Capabilities: []string{"lookup_order"}},
+		Create: func(config MCPConfig) (Agent, error) {
+			return &ordersAgent{newStubAgent(config.Name, func(_ context.Context, input map[string]interface{}) (map[string]interface{}, error) {
+				return map[string]interface{}{"order_id": input["order_id"], "status": "shipped"}, nil
+			}).(*stubAgent)}, nil
+		},
+	}))
+	o := NewOrchestrator(factory, log)
+	require.NoError(t, o.RegisterTool(ToolDefinition{Name: "get_weather", Description: "Weather by city"}, func(_ context.Context, call ToolCall) (map[string]interface{}, error) {
+		return map[string]interface{}{"city": call.Arguments["city"], "forecast": "sunny", "bot": call.Task.Metadata["bot_id"]}, nil
+	}))
+	_, err := o.InstantiateMCP(ctx, MCPConfig{Type: "orders", Name: "orders"})
+	require.NoError(t, err)
+
+	server, requests := newToolCallingServer(t, false)
+	assistant, err := o.InstantiateMCP(ctx, MCPConfig{Type: "ai", Name: "assistant", Config: map[string]interface{}{"openai_api_key": "key", "base_url": server.URL}})
+	require.NoError(t, err)
+
+	task := Task{ID: "ask", Type: "text_generation", Metadata: map[string]interface{}{"bot_id": "bot-1"}, Input: map[string]interface{}{
+		"prompt": "What's the weather in Lima?",
+		"tools":  []interface{}{"get_weather", "lookup_order", "text_generation", "unknown"},
+	}}
+	result, err := o.ExecuteTask(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, "It is sunny in Lima", result.Output["text"])
+	assert.Equal(t, 30, result.Output["tokens_used"])
+
+	calls := result.Output["tool_calls"].([]map[string]interface{})
+	require.Len(t, calls, 2)
+	assert.Equal(t, true, calls[0]["success"])
+	assert.Equal(t, "lookup_order", calls[1]["tool"])
+
+	require.Len(t, *requests, 2)
+	offered := []string{}
+	for _, tool := range (*requests)[0].Tools {
+		offered = append(offered, tool.Function.Name)
+	}
+	assert.Equal(t, []string{"get_weather", "lookup_order"}, offered, "the agent's own task types and unknown tools are not offered")
+	toolMessage := (*requests)[1].Messages[2]
+	assert.Equal(t, "call-1", toolMessage.ToolCallID)
+	assert.JSONEq(t, `{"city": "Lima", "forecast": "sunny", "bot": "bot-1"}`, toolMessage.Content)
+	assert.JSONEq(t, `{"order_id": "A1", "status": "shipped"}`, (*requests)[1].Messages[3].Content)
+
+	// Pasado el límite se pide la respuesta final sin herramientas
+	server, requests = newToolCallingServer(t, true)
+	require.NoError(t, o.TerminateAgent(ctx, assistant.GetID()))
+	_, err = o.InstantiateMCP(ctx, MCPConfig{Type: "ai", Name: "looping", Config: map[string]interface{}{"openai_api_key": "key", "base_url": server.URL}})
+	require.NoError(t, err)
+	task.Input["max_tool_iterations"] = 1
+	_, err = o.ExecuteTask(ctx, task)
+	assert.ErrorContains(t, err, "kept calling tools")
+	last := (*requests)[len(*requests)-1]
+	assert.Equal(t, "none", last.ToolChoice)
+}
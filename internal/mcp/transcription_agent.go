@@ -0,0 +1,319 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Proveedores de transcripción soportados. "local" apunta a un servidor
+// compatible con la API de OpenAI (por ejemplo faster-whisper) en base_url.
+const (
+	TranscriptionProviderOpenAI = "openai"
+	TranscriptionProviderLocal  = "local"
+)
+
+// maxAudioBytes coincide con el límite de archivos de la API de Whisper
+const maxAudioBytes = 25 * 1024 * 1024
+
+// transcriptionAgent transcribe audio a texto con Whisper
+type transcriptionAgent struct {
+	*baseAgent
+	client   *http.Client
+	provider string
+	apiKey   string
+	model    string
+	baseURL  string
+	useMock  bool
+}
+
+type whisperResponse struct {
+	Text     string  `json:"text"`
+	Language string  `json:"language"`
+	Duration float64 `json:"duration"`
+}
+
+// NewTranscriptionAgent crea un agente de transcripción de audio
+func NewTranscriptionAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{"transcription", "speech_to_text"}
+
+	values := configschema.Values(config.Config)
+	provider := values.String("provider", TranscriptionProviderOpenAI)
+	apiKey := values.String("openai_api_key", "")
+	model := values.String("model", "whisper-1")
+
+	baseURL := values.String("base_url", "")
+	switch provider {
+	case TranscriptionProviderOpenAI:
+		if baseURL == "" {
+			baseURL = "https://api.openai.com/v1"
+		}
+	case TranscriptionProviderLocal:
+		if baseURL == "" {
+			return nil, fmt.Errorf("local transcription provider requires base_url")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported transcription provider: %s", provider)
+	}
+
+	timeout := 60 * time.Second
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+
+	return &transcriptionAgent{
+		baseAgent: base,
+		client:    &http.Client{Timeout: timeout},
+		provider:  provider,
+		apiKey:    apiKey,
+		model:     model,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		useMock:   provider == TranscriptionProviderOpenAI && (apiKey == "" || apiKey == "sk-test-key"),
+	}, nil
+}
+
+func (a *transcriptionAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	a.logger.Info("Transcription agent executing task",
+		"agent_id", a.id,
+		"task_id", task.ID,
+		"provider", a.provider,
+		"use_mock", a.useMock)
+
+	fail := func(err error) (Result, error) {
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		return Result{
+			TaskID:   task.ID,
+			Success:  false,
+			Error:    err.Error(),
+			Duration: duration,
+			Metadata: map[string]interface{}{
+				"agent_id":   a.id,
+				"agent_type": a.agentType,
+			},
+		}, err
+	}
+
+	audioURL, _ := task.Input["audio_url"].(string)
+	encoded, _ := task.Input["audio_base64"].(string)
+	if audioURL == "" && encoded == "" {
+		return fail(fmt.Errorf("audio_url or audio_base64 is required"))
+	}
+	language, _ := task.Input["language"].(string)
+
+	var transcript whisperResponse
+	var audioSize int
+	if a.useMock {
+		// En modo mock no se descarga el audio: las URLs de medios de los
+		// canales no suelen ser accesibles desde desarrollo
+		transcript = whisperResponse{
+			Text:     "This is a simulated transcription of the voice note.",
+			Language: language,
+		}
+	} else {
+		audio, filename, err := a.loadAudio(ctx, task.Input)
+		if err != nil {
+			return fail(err)
+		}
+		audioSize = len(audio)
+
+		prompt, _ := task.Input["prompt"].(string)
+		transcript, err = a.transcribe(ctx, audio, filename, language, prompt)
+		if err != nil {
+			return fail(fmt.Errorf("transcription failed: %w", err))
+		}
+	}
+
+	duration := time.Since(start)
+	a.updateMetrics(true, duration)
+
+	mode := "real"
+	if a.useMock {
+		mode = "mock"
+	}
+
+	return Result{
+		TaskID:  task.ID,
+		Success: true,
+		Output: map[string]interface{}{
+			"text":       strings.TrimSpace(transcript.Text),
+			"language":   transcript.Language,
+			"duration":   transcript.Duration,
+			"audio_url":  audioURL,
+			"audio_size": audioSize,
+		},
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"provider":   a.provider,
+			"model":      a.model,
+			"mode":       mode,
+		},
+	}, nil
+}
+
+func (a *transcriptionAgent) CanHandle(taskType string) bool {
+	switch taskType {
+	case "transcription", "speech_to_text":
+		return true
+	}
+	return false
+}
+
+// loadAudio obtiene el audio desde audio_base64 o descargándolo de audio_url.
+// Los canales como WhatsApp exigen autenticación para descargar medios, por
+// eso se aceptan cabeceras adicionales en download_headers.
+func (a *transcriptionAgent) loadAudio(ctx context.Context, input map[string]interface{}) ([]byte, string, error) {
+	filename, _ := input["filename"].(string)
+
+	if encoded, _ := input["audio_base64"].(string); encoded != "" {
+		audio, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid audio_base64: %w", err)
+		}
+		if len(audio) > maxAudioBytes {
+			return nil, "", fmt.Errorf("audio exceeds %d bytes", maxAudioBytes)
+		}
+		return audio, audioFilename(filename, "", input), nil
+	}
+
+	audioURL, _ := input["audio_url"].(string)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, audioURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid audio_url: %w", err)
+	}
+	if headers, ok := input["download_headers"].(map[string]interface{}); ok {
+		for key, value := range headers {
+			if strValue, ok := value.(string); ok {
+				req.Header.Set(key, strValue)
+			}
+		}
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download audio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download audio: HTTP %d", resp.StatusCode)
+	}
+
+	audio, err := io.ReadAll(io.LimitReader(resp.Body, maxAudioBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read audio: %w", err)
+	}
+	if len(audio) > maxAudioBytes {
+		return nil, "", fmt.Errorf("audio exceeds %d bytes", maxAudioBytes)
+	}
+
+	return audio, audioFilename(filename, req.URL.Path, input), nil
+}
+
+// transcribe envía el audio al endpoint audio/transcriptions
+func (a *transcriptionAgent) transcribe(ctx context.Context, audio []byte, filename, language, prompt string) (whisperResponse, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return whisperResponse{}, err
+	}
+	if _, err := part.Write(audio); err != nil {
+		return whisperResponse{}, err
+	}
+
+	fields := map[string]string{
+		"model":           a.model,
+		"response_format": "verbose_json",
+		"language":        language,
+		"prompt":          prompt,
+	}
+	for key, value := range fields {
+		if value == "" {
+			continue
+		}
+		if err := writer.WriteField(key, value); err != nil {
+			return whisperResponse{}, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return whisperResponse{}, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/audio/transcriptions", &body)
+	if err != nil {
+		return whisperResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	if a.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+a.apiKey)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return whisperResponse{}, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return whisperResponse{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return whisperResponse{}, &APIError{StatusCode: resp.StatusCode, Body: string(respBody)}
+	}
+
+	var transcript whisperResponse
+	if err := json.Unmarshal(respBody, &transcript); err != nil {
+		return whisperResponse{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	return transcript, nil
+}
+
+// audioFilename elige un nombre con extensión, que Whisper usa para detectar
+// el formato. Las notas de voz de WhatsApp y Telegram son OGG/Opus.
+func audioFilename(filename, urlPath string, input map[string]interface{}) string {
+	if filename != "" {
+		return filename
+	}
+	// path.Base("") es ".", que tiene extensión "."
+	if base := path.Base(urlPath); urlPath != "" && path.Ext(base) != "" {
+		return base
+	}
+
+	mimeType, _ := input["mime_type"].(string)
+	switch {
+	case strings.Contains(mimeType, "mpeg"), strings.Contains(mimeType, "mp3"):
+		return "audio.mp3"
+	case strings.Contains(mimeType, "mp4"), strings.Contains(mimeType, "m4a"):
+		return "audio.m4a"
+	case strings.Contains(mimeType, "wav"):
+		return "audio.wav"
+	case strings.Contains(mimeType, "webm"):
+		return "audio.webm"
+	}
+	return "audio.ogg"
+}
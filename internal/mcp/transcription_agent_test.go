@@ -0,0 +1,166 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// whisperUpload es lo que recibió el servidor de transcripción
+type whisperUpload struct {
+	authorization string
+	filename      string
+	audio         string
+	fields        map[string]string
+}
+
+// newWhisperServer sirve el audio en /media/ (exige el token de descarga) y
+// transcribe en /audio/transcriptions lo que haya subido
+func newWhisperServer(t *testing.T) (*httptest.Server, func() []whisperUpload) {
+	t.Helper()
+	var mu sync.Mutex
+	var uploads []whisperUpload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/media/note.ogg":
+			if r.Header.Get("Authorization") != "Bearer media-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "ogg-audio")
+		case "/audio/transcriptions":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			file, header, err := r.FormFile("file")
+			require.NoError(t, err)
+			audio, err := io.ReadAll(file)
+			require.NoError(t, err)
+			upload := whisperUpload{authorization: r.Header.Get("Authorization"), filename: header.Filename, audio: string(audio), fields: map[string]string{}}
+			for key, values := range r.MultipartForm.Value {
+				upload.fields[key] = values[0]
+			}
+			mu.Lock()
+			uploads = append(uploads, upload)
+			mu.Unlock()
+			if string(audio) == "broken" {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, `{"error": "decoder failed"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"text": "  I want to check my order %s  ", "language": "english", "duration": 3.5}`, audio)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []whisperUpload {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]whisperUpload(nil), uploads...)
+	}
+}
+
+func newTestTranscriptionAgent(t *testing.T, config map[string]interface{}) Agent {
+	t.Helper()
+	agent, err := NewTranscriptionAgent(MCPConfig{Type: "transcription", Name: "whisper", Config: config}, logger.NewLogger("error"))
+	require.NoError(t, err)
+	return agent
+}
+
+func TestTranscriptionAgent_DownloadsAndTranscribes(t *testing.T) {
+	ctx := context.Background()
+	server, uploads := newWhisperServer(t)
+	agent := newTestTranscriptionAgent(t, map[string]interface{}{"openai_api_key": "sk-live", "base_url": server.URL + "/"})
+
+	result, err := agent.Execute(ctx, Task{ID: "voice", Type: "transcription", Input: map[string]interface{}{
+		"audio_url":        server.URL + "/media/note.ogg",
+		"download_headers": map[string]interface{}{"Authorization": "Bearer media-token"},
+		"language":         "en",
+		"prompt":           "Order numbers",
+	}})
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, "I want to check my order ogg-audio", result.Output["text"])
+	assert.Equal(t, "english", result.Output["language"])
+	assert.Equal(t, 3.5, result.Output["duration"])
+	assert.Equal(t, len("ogg-audio"), result.Output["audio_size"])
+	assert.Equal(t, "real", result.Metadata["mode"])
+
+	require.Len(t, uploads(), 1)
+	upload := uploads()[0]
+	assert.Equal(t, "Bearer sk-live", upload.authorization)
+	assert.Equal(t, "note.ogg", upload.filename)
+	assert.Equal(t, "ogg-audio", upload.audio)
+	assert.Equal(t, map[string]string{"model": "whisper-1", "response_format": "verbose_json", "language": "en", "prompt": "Order numbers"}, upload.fields)
+}
+
+func TestTranscriptionAgent_LocalProviderWithBase64Audio(t *testing.T) {
+	ctx := context.Background()
+	server, uploads := newWhisperServer(t)
+	agent := newTestTranscriptionAgent(t, map[string]interface{}{"provider": TranscriptionProviderLocal, "base_url": server.URL, "model": "large-v3"})
+
+	result, err := agent.Execute(ctx, Task{ID: "voice", Type: "speech_to_text", Input: map[string]interface{}{
+		"audio_base64": base64.StdEncoding.EncodeToString([]byte("mp3-audio")),
+		"mime_type":    "audio/mpeg",
+	}})
+	require.NoError(t, err)
+	assert.Equal(t, "I want to check my order mp3-audio", result.Output["text"])
+
+	// Sin API key no se envía Authorization y el nombre sale del tipo MIME
+	upload := uploads()[0]
+	assert.Empty(t, upload.authorization)
+	assert.Equal(t, "audio.mp3", upload.filename)
+	assert.Equal(t, "large-v3", upload.fields["model"])
+	assert.NotContains(t, upload.fields, "language")
+}
+
+func TestTranscriptionAgent_Failures(t *testing.T) {
+	ctx := context.Background()
+	server, uploads := newWhisperServer(t)
+	agent := newTestTranscriptionAgent(t, map[string]interface{}{"openai_api_key": "sk-live", "base_url": server.URL})
+
+	// Sin el token el canal rechaza la descarga
+	_, err := agent.Execute(ctx, Task{ID: "forbidden", Input: map[string]interface{}{"audio_url": server.URL + "/media/note.ogg"}})
+	assert.EqualError(t, err, "failed to download audio: HTTP 401")
+
+	result, err := agent.Execute(ctx, Task{ID: "broken", Input: map[string]interface{}{"audio_base64": base64.StdEncoding.EncodeToString([]byte("broken"))}})
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusInternalServerError, apiErr.StatusCode)
+	assert.False(t, result.Success)
+	assert.Contains(t, result.Error, "transcription failed")
+
+	_, err = agent.Execute(ctx, Task{ID: "invalid", Input: map[string]interface{}{"audio_base64": "not base64!"}})
+	assert.ErrorContains(t, err, "invalid audio_base64")
+	_, err = agent.Execute(ctx, Task{ID: "empty", Input: map[string]interface{}{}})
+	assert.EqualError(t, err, "audio_url or audio_base64 is required")
+
+	assert.Len(t, uploads(), 1)
+	assert.Equal(t, 4, agent.GetState().Metrics.TasksFailed)
+
+	_, err = NewTranscriptionAgent(MCPConfig{Type: "transcription", Name: "whisper", Config: map[string]interface{}{"provider": TranscriptionProviderLocal}}, logger.NewLogger("error"))
+	assert.EqualError(t, err, "local transcription provider requires base_url")
+	_, err = NewTranscriptionAgent(MCPConfig{Type: "transcription", Name: "whisper", Config: map[string]interface{}{"provider": "other"}}, logger.NewLogger("error"))
+	assert.EqualError(t, err, "unsupported transcription provider: other")
+}
+
+func TestTranscriptionAgent_MockWithoutAPIKey(t *testing.T) {
+	server, uploads := newWhisperServer(t)
+	agent := newTestTranscriptionAgent(t, map[string]interface{}{"base_url": server.URL})
+
+	result, err := agent.Execute(context.Background(), Task{ID: "voice", Input: map[string]interface{}{"audio_url": server.URL + "/media/note.ogg", "language": "es"}})
+	require.NoError(t, err)
+	assert.Equal(t, "mock", result.Metadata["mode"])
+	assert.Equal(t, "es", result.Output["language"])
+	assert.NotEmpty(t, result.Output["text"])
+	assert.Empty(t, uploads())
+}
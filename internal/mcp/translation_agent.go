@@ -0,0 +1,491 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// GlossaryTerm es un término que no debe traducirse libremente. Si no tiene
+// traducción para el idioma destino se conserva tal cual (nombres de marca,
+// productos); si la tiene, se usa siempre esa traducción.
+type GlossaryTerm struct {
+	Term          string            `json:"term"`
+	Translations  map[string]string `json:"translations,omitempty"`
+	CaseSensitive bool              `json:"case_sensitive,omitempty"`
+}
+
+// LanguagePair es un par origen/destino permitido; "*" admite cualquier idioma
+type LanguagePair struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+}
+
+// Motivos por los que una traducción se descarta y se devuelve el texto original
+const (
+	TranslationFallbackError       = "translation_error"
+	TranslationFallbackQuality     = "quality_check_failed"
+	TranslationFallbackUnsupported = "unsupported_language_pair"
+)
+
+// translationAgent traduce texto con un modelo de chat compatible con la API
+// de OpenAI, protegiendo los términos del glosario con marcadores
+type translationAgent struct {
+	*baseAgent
+	client        *http.Client
+	apiKey        string
+	model         string
+	fallbackModel string
+	baseURL       string
+	glossary      []GlossaryTerm
+	languagePairs []LanguagePair
+	useMock       bool
+}
+
+type translationRequest struct {
+	Model          string            `json:"model"`
+	Messages       []message         `json:"messages"`
+	Temperature    float64           `json:"temperature"`
+	ResponseFormat map[string]string `json:"response_format"`
+}
+
+type translationOutput struct {
+	DetectedLanguage string `json:"detected_language"`
+	Translation      string `json:"translation"`
+}
+
+// glossaryMarker identifica los marcadores que sustituyen a los términos protegidos
+var glossaryMarker = regexp.MustCompile(`⟦(\d+)⟧`)
+
+// NewTranslationAgent crea un agente de traducción
+func NewTranslationAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+	base := newBaseAgent(config, logger)
+	base.capabilities = []string{"translation"}
+
+	values := configschema.Values(config.Config)
+	apiKey := values.String("openai_api_key", "")
+	model := values.String("model", "gpt-4o-mini")
+	fallbackModel := values.String("fallback_model", "")
+	baseURL := strings.TrimSuffix(values.String("base_url", "https://api.openai.com/v1"), "/")
+
+	glossary, err := parseGlossary(config.Config["glossary"])
+	if err != nil {
+		return nil, err
+	}
+	pairs, err := parseLanguagePairs(config.Config["language_pairs"])
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := 30 * time.Second
+	if config.Timeout > 0 {
+		timeout = config.Timeout
+	}
+
+	return &translationAgent{
+		baseAgent:     base,
+		client:        &http.Client{Timeout: timeout},
+		apiKey:        apiKey,
+		model:         model,
+		fallbackModel: fallbackModel,
+		baseURL:       baseURL,
+		glossary:      glossary,
+		languagePairs: pairs,
+		useMock:       apiKey == "" || apiKey == "sk-test-key",
+	}, nil
+}
+
+func (a *translationAgent) Execute(ctx context.Context, task Task) (Result, error) {
+	start := time.Now()
+
+	a.setStatus(AgentStatusBusy, &task)
+
+	defer func() {
+		a.setStatus(AgentStatusIdle, nil)
+	}()
+
+	a.logger.Info("Translation agent executing task",
+		"agent_id", a.id,
+		"task_id", task.ID,
+		"use_mock", a.useMock)
+
+	text, _ := task.Input["text"].(string)
+	target, _ := task.Input["target_language"].(string)
+	source, _ := task.Input["source_language"].(string)
+	if source == "" {
+		source = "auto"
+	}
+
+	glossary, err := parseGlossary(task.Input["glossary"])
+	if err == nil && strings.TrimSpace(text) == "" {
+		err = fmt.Errorf("text is required")
+	}
+	if err == nil && target == "" {
+		err = fmt.Errorf("target_language is required")
+	}
+	if err != nil {
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		return Result{
+			TaskID:   task.ID,
+			Success:  false,
+			Error:    err.Error(),
+			Duration: duration,
+			Metadata: map[string]interface{}{
+				"agent_id":   a.id,
+				"agent_type": a.agentType,
+			},
+		}, err
+	}
+	glossary = append(glossary, a.glossary...)
+
+	// Los fallos de traducción no son errores de la tarea: se devuelve el
+	// texto original para que el mensaje llegue igualmente al usuario
+	output := map[string]interface{}{
+		"text":            text,
+		"source_text":     text,
+		"source_language": source,
+		"target_language": target,
+		"translated":      false,
+	}
+	modelUsed := ""
+
+	if source != "auto" && sameLanguage(source, target) {
+		output["detected_language"] = source
+	} else if !a.pairAllowed(source, target) {
+		output["fallback_reason"] = TranslationFallbackUnsupported
+	} else {
+		masked, replacements := protectGlossaryTerms(text, glossary, target)
+		translated, detected, model, issue := a.translateWithFallback(ctx, masked, source, target, len(replacements))
+		modelUsed = model
+		output["detected_language"] = detected
+
+		switch {
+		case issue != "":
+			output["fallback_reason"] = issue
+		case source == "auto" && detected != "" && !a.pairAllowed(detected, target):
+			output["fallback_reason"] = TranslationFallbackUnsupported
+		case source == "auto" && sameLanguage(detected, target):
+			// El texto ya estaba en el idioma destino
+		default:
+			output["text"] = restoreGlossaryTerms(translated, replacements)
+			output["translated"] = true
+		}
+	}
+
+	if reason, ok := output["fallback_reason"]; ok {
+		a.logger.Warn("Translation fell back to source text",
+			"agent_id", a.id,
+			"task_id", task.ID,
+			"reason", reason)
+	}
+
+	duration := time.Since(start)
+	a.updateMetrics(true, duration)
+
+	mode := "real"
+	if a.useMock {
+		mode = "mock"
+	}
+
+	return Result{
+		TaskID:   task.ID,
+		Success:  true,
+		Output:   output,
+		Duration: duration,
+		Metadata: map[string]interface{}{
+			"agent_id":   a.id,
+			"agent_type": a.agentType,
+			"model":      modelUsed,
+			"mode":       mode,
+		},
+	}, nil
+}
+
+func (a *translationAgent) CanHandle(taskType string) bool {
+	switch taskType {
+	case "translation", "translate":
+		return true
+	}
+	return false
+}
+
+// translateWithFallback traduce con el modelo principal y, si falla o el
+// resultado no supera el control de calidad, con el modelo de respaldo.
+// Devuelve el motivo del descarte cuando ninguno produce una traducción válida.
+func (a *translationAgent) translateWithFallback(ctx context.Context, text, source, target string, markers int) (string, string, string, string) {
+	if a.useMock {
+		// En modo mock sólo se aplican los términos del glosario
+		return text, "", "mock", ""
+	}
+
+	models := []string{a.model}
+	if a.fallbackModel != "" && a.fallbackModel != a.model {
+		models = append(models, a.fallbackModel)
+	}
+
+	issue := ""
+	for _, model := range models {
+		out, err := a.translate(ctx, model, text, source, target)
+		if err != nil {
+			a.logger.Warn("Translation request failed", "agent_id", a.id, "model", model, "error", err)
+			issue = TranslationFallbackError
+			continue
+		}
+		if problem := checkTranslationQuality(text, out.Translation, markers); problem != "" {
+			a.logger.Warn("Translation failed quality check", "agent_id", a.id, "model", model, "problem", problem)
+			issue = TranslationFallbackQuality
+			continue
+		}
+		return out.Translation, strings.ToLower(out.DetectedLanguage), model, ""
+	}
+	return "", "", "", issue
+}
+
+func (a *translationAgent) translate(ctx context.Context, model, text, source, target string) (translationOutput, error) {
+	from := "the detected source language"
+	if source != "auto" {
+		from = source
+	}
+	prompt := fmt.Sprintf("Translate the user's text from %s to %s. "+
+		"Keep every marker like ⟦0⟧ exactly as written and in a grammatically correct position. "+
+		"Preserve formatting, line breaks, URLs and emojis. "+
+		`Reply only with JSON: {"detected_language": "<ISO 639-1 code of the source text>", "translation": "<translated text>"}`,
+		from, target)
+
+	jsonBody, err := json.Marshal(translationRequest{
+		Model: model,
+		Messages: []message{
+			{Role: "system", Content: prompt},
+			{Role: "user", Content: text},
+		},
+		Temperature:    0,
+		ResponseFormat: map[string]string{"type": "json_object"},
+	})
+	if err != nil {
+		return translationOutput{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.baseURL+"/chat/completions", bytes.NewReader(jsonBody))
+	if err != nil {
+		return translationOutput{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+a.apiKey)
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return translationOutput{}, fmt.Errorf("API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return translationOutput{}, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return translationOutput{}, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var completion openAIResponse
+	if err := json.Unmarshal(body, &completion); err != nil {
+		return translationOutput{}, fmt.Errorf("failed to parse response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return translationOutput{}, fmt.Errorf("no choices in API response")
+	}
+
+	var out translationOutput
+	if err := json.Unmarshal([]byte(completion.Choices[0].Message.Content), &out); err != nil {
+		return translationOutput{}, fmt.Errorf("invalid translation payload: %w", err)
+	}
+	return out, nil
+}
+
+// pairAllowed comprueba el par de idiomas contra la configuración; sin pares
+// configurados se admite cualquiera
+func (a *translationAgent) pairAllowed(source, target string) bool {
+	if len(a.languagePairs) == 0 {
+		return true
+	}
+	for _, pair := range a.languagePairs {
+		sourceOK := pair.Source == "*" || source == "auto" || sameLanguage(pair.Source, source)
+		targetOK := pair.Target == "*" || sameLanguage(pair.Target, target)
+		if sourceOK && targetOK {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTranslationQuality descarta traducciones vacías, que pierden
+// marcadores del glosario o cuya longitud es desproporcionada
+func checkTranslationQuality(source, translation string, markers int) string {
+	if strings.TrimSpace(translation) == "" {
+		return "empty translation"
+	}
+	found := make(map[string]bool)
+	for _, match := range glossaryMarker.FindAllStringSubmatch(translation, -1) {
+		found[match[1]] = true
+	}
+	if len(found) != markers {
+		return fmt.Sprintf("expected %d glossary markers, found %d", markers, len(found))
+	}
+
+	sourceLen := utf8.RuneCountInString(source)
+	translatedLen := utf8.RuneCountInString(translation)
+	if sourceLen >= 20 && (translatedLen*3 < sourceLen || translatedLen > sourceLen*3) {
+		return "translation length out of range"
+	}
+	return ""
+}
+
+// protectGlossaryTerms sustituye cada aparición de un término por un marcador
+// y devuelve el texto que debe ocupar cada marcador tras la traducción
+func protectGlossaryTerms(text string, glossary []GlossaryTerm, target string) (string, []string) {
+	if len(glossary) == 0 {
+		return text, nil
+	}
+
+	// Los términos largos primero, para que "Acme Cloud" gane a "Acme"
+	terms := make([]GlossaryTerm, 0, len(glossary))
+	for _, term := range glossary {
+		if strings.TrimSpace(term.Term) != "" {
+			terms = append(terms, term)
+		}
+	}
+	sort.SliceStable(terms, func(i, j int) bool {
+		return utf8.RuneCountInString(terms[i].Term) > utf8.RuneCountInString(terms[j].Term)
+	})
+
+	var replacements []string
+	for _, term := range terms {
+		pattern := regexp.QuoteMeta(term.Term)
+		if !term.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re := regexp.MustCompile(pattern)
+
+		markers := glossaryMarker.FindAllStringIndex(text, -1)
+		var out strings.Builder
+		last := 0
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if !isWordBoundary(text, loc[0], loc[1]) || insideMarker(markers, loc) {
+				continue
+			}
+			out.WriteString(text[last:loc[0]])
+			out.WriteString(fmt.Sprintf("⟦%d⟧", len(replacements)))
+			replacements = append(replacements, glossaryReplacement(term, text[loc[0]:loc[1]], target))
+			last = loc[1]
+		}
+		out.WriteString(text[last:])
+		text = out.String()
+	}
+	return text, replacements
+}
+
+func restoreGlossaryTerms(text string, replacements []string) string {
+	return glossaryMarker.ReplaceAllStringFunc(text, func(marker string) string {
+		var index int
+		if _, err := fmt.Sscanf(marker, "⟦%d⟧", &index); err != nil || index >= len(replacements) {
+			return marker
+		}
+		return replacements[index]
+	})
+}
+
+func glossaryReplacement(term GlossaryTerm, matched, target string) string {
+	for language, translation := range term.Translations {
+		if sameLanguage(language, target) {
+			return translation
+		}
+	}
+	return matched
+}
+
+// isWordBoundary evita reemplazar términos dentro de otras palabras
+func isWordBoundary(text string, start, end int) bool {
+	if start > 0 {
+		r, _ := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	if end < len(text) {
+		r, _ := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
+
+func insideMarker(markers [][]int, loc []int) bool {
+	for _, marker := range markers {
+		if loc[0] < marker[1] && loc[1] > marker[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// sameLanguage compara códigos de idioma ignorando la región ("es-MX" == "es")
+func sameLanguage(a, b string) bool {
+	base := func(code string) string {
+		code = strings.ToLower(strings.TrimSpace(code))
+		if i := strings.IndexAny(code, "-_"); i > 0 {
+			code = code[:i]
+		}
+		return code
+	}
+	return a != "" && base(a) == base(b)
+}
+
+// parseGlossary acepta el glosario como lista de objetos, ya venga de JSON
+// o de estructuras del dominio
+func parseGlossary(value interface{}) ([]GlossaryTerm, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid glossary: %w", err)
+	}
+	var glossary []GlossaryTerm
+	if err := json.Unmarshal(data, &glossary); err != nil {
+		return nil, fmt.Errorf("invalid glossary: %w", err)
+	}
+	return glossary, nil
+}
+
+func parseLanguagePairs(value interface{}) ([]LanguagePair, error) {
+	if value == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid language_pairs: %w", err)
+	}
+	var pairs []LanguagePair
+	if err := json.Unmarshal(data, &pairs); err != nil {
+		return nil, fmt.Errorf("invalid language_pairs: %w", err)
+	}
+	for _, pair := range pairs {
+		if pair.Source == "" || pair.Target == "" {
+			return nil, fmt.Errorf("language pairs require source and target")
+		}
+	}
+	return pairs, nil
+}
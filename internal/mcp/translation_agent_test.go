@@ -0,0 +1,197 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTranslationServer simula la API de chat: reply decide, por modelo y
+// texto recibido, el estado HTTP y la traducción devuelta
+func newTranslationServer(t *testing.T, reply func(model, text string) (int, translationOutput)) (*httptest.Server, func() []translationRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []translationRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-live", r.Header.Get("Authorization"))
+		var req translationRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		mu.Lock()
+		requests = append(requests, req)
+		mu.Unlock()
+
+		status, out := reply(req.Model, req.Messages[1].Content)
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			fmt.Fprint(w, `{"error": "unavailable"}`)
+			return
+		}
+		content, _ := json.Marshal(out)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": string(content)}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []translationRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]translationRequest(nil), requests...)
+	}
+}
+
+func newTestTranslationAgent(t *testing.T, config map[string]interface{}) Agent {
+	t.Helper()
+	agent, err := NewTranslationAgent(MCPConfig{Type: "translation", Name: "translator", Config: config}, logger.NewLogger("error"))
+	require.NoError(t, err)
+	return agent
+}
+
+func translateTask(text, source, target string) Task {
+	return Task{ID: "translate", Type: "translation", Input: map[string]interface{}{"text": text, "source_language": source, "target_language": target}}
+}
+
+func TestTranslationAgent_TranslatesWithGlossary(t *testing.T) {
+	server, requests := newTranslationServer(t, func(model, text string) (int, translationOutput) {
+		assert.Equal(t, "Open ⟦0⟧ ⟦1⟧ now", text)
+		return http.StatusOK, translationOutput{DetectedLanguage: "EN", Translation: "Abre ⟦1⟧ de ⟦0⟧ ahora"}
+	})
+	agent := newTestTranslationAgent(t, map[string]interface{}{
+		"openai_api_key": "sk-live",
+		"base_url":       server.URL,
+		"glossary":       []interface{}{map[string]interface{}{"term": "Acme Cloud"}},
+	})
+
+	task := translateTask("Open Acme Cloud settings now", "", "es")
+	task.Input["glossary"] = []interface{}{map[string]interface{}{"term": "settings", "translations": map[string]interface{}{"es": "ajustes"}}}
+	result, err := agent.Execute(context.Background(), task)
+	require.NoError(t, err)
+
+	// Los términos del glosario vuelven a su sitio: sin traducir o con la suya
+	assert.Equal(t, "Abre ajustes de Acme Cloud ahora", result.Output["text"])
+	assert.Equal(t, "Open Acme Cloud settings now", result.Output["source_text"])
+	assert.Equal(t, "auto", result.Output["source_language"])
+	assert.Equal(t, "en", result.Output["detected_language"])
+	assert.Equal(t, true, result.Output["translated"])
+	assert.NotContains(t, result.Output, "fallback_reason")
+	assert.Equal(t, "gpt-4o-mini", result.Metadata["model"])
+	assert.Equal(t, "real", result.Metadata["mode"])
+
+	require.Len(t, requests(), 1)
+	req := requests()[0]
+	assert.Equal(t, "gpt-4o-mini", req.Model)
+	assert.Equal(t, map[string]string{"type": "json_object"}, req.ResponseFormat)
+	assert.Contains(t, req.Messages[0].Content, "from the detected source language to es")
+}
+
+func TestTranslationAgent_FallsBackToSecondModel(t *testing.T) {
+	server, requests := newTranslationServer(t, func(model, text string) (int, translationOutput) {
+		if model == "primary" {
+			return http.StatusServiceUnavailable, translationOutput{}
+		}
+		return http.StatusOK, translationOutput{DetectedLanguage: "en", Translation: "Hola"}
+	})
+	agent := newTestTranslationAgent(t, map[string]interface{}{
+		"openai_api_key": "sk-live", "base_url": server.URL, "model": "primary", "fallback_model": "secondary",
+	})
+
+	result, err := agent.Execute(context.Background(), translateTask("Hello", "en", "es"))
+	require.NoError(t, err)
+	assert.Equal(t, "Hola", result.Output["text"])
+	assert.Equal(t, "secondary", result.Metadata["model"])
+	assert.Len(t, requests(), 2)
+	assert.Contains(t, requests()[1].Messages[0].Content, "from en to es")
+}
+
+func TestTranslationAgent_ReturnsSourceTextWhenTranslationFails(t *testing.T) {
+	ctx := context.Background()
+	cases := map[string]struct {
+		reply  func(model, text string) (int, translationOutput)
+		reason string
+	}{
+		"api error": {
+			reply: func(string, string) (int, translationOutput) {
+				return http.StatusInternalServerError, translationOutput{}
+			},
+			reason: TranslationFallbackError,
+		},
+		"lost glossary marker": {
+			reply: func(string, string) (int, translationOutput) {
+				return http.StatusOK, translationOutput{DetectedLanguage: "en", Translation: "Abre los ajustes"}
+			},
+			reason: TranslationFallbackQuality,
+		},
+		"detected pair not allowed": {
+			reply: func(string, string) (int, translationOutput) {
+				return http.StatusOK, translationOutput{DetectedLanguage: "de", Translation: "Abre ⟦0⟧"}
+			},
+			reason: TranslationFallbackUnsupported,
+		},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			server, requests := newTranslationServer(t, tc.reply)
+			agent := newTestTranslationAgent(t, map[string]interface{}{
+				"openai_api_key": "sk-live", "base_url": server.URL, "fallback_model": "gpt-4o",
+				"glossary":       []interface{}{map[string]interface{}{"term": "Acme"}},
+				"language_pairs": []interface{}{map[string]interface{}{"source": "en", "target": "es"}},
+			})
+
+			// El fallo no es un error de la tarea: el usuario recibe el texto original
+			result, err := agent.Execute(ctx, translateTask("Open Acme", "auto", "es"))
+			require.NoError(t, err)
+			assert.True(t, result.Success)
+			assert.Equal(t, "Open Acme", result.Output["text"])
+			assert.Equal(t, false, result.Output["translated"])
+			assert.Equal(t, tc.reason, result.Output["fallback_reason"])
+			assert.NotEmpty(t, requests())
+		})
+	}
+}
+
+func TestTranslationAgent_SkipsTheAPI(t *testing.T) {
+	ctx := context.Background()
+	server, requests := newTranslationServer(t, func(string, string) (int, translationOutput) {
+		return http.StatusOK, translationOutput{DetectedLanguage: "es", Translation: "Hola"}
+	})
+	agent := newTestTranslationAgent(t, map[string]interface{}{
+		"openai_api_key": "sk-live", "base_url": server.URL,
+		"language_pairs": []interface{}{map[string]interface{}{"source": "*", "target": "es"}},
+	})
+
+	// Mismo idioma, con o sin región
+	result, err := agent.Execute(ctx, translateTask("Hola", "es-MX", "es"))
+	require.NoError(t, err)
+	assert.Equal(t, false, result.Output["translated"])
+	assert.Equal(t, "es-MX", result.Output["detected_language"])
+
+	result, err = agent.Execute(ctx, translateTask("Hello", "en", "fr"))
+	require.NoError(t, err)
+	assert.Equal(t, TranslationFallbackUnsupported, result.Output["fallback_reason"])
+
+	_, err = agent.Execute(ctx, translateTask(" ", "en", "es"))
+	assert.EqualError(t, err, "text is required")
+	_, err = agent.Execute(ctx, translateTask("Hello", "en", ""))
+	assert.EqualError(t, err, "target_language is required")
+	assert.Empty(t, requests())
+
+	// Con el texto ya en el idioma destino se devuelve sin cambios
+	result, err = agent.Execute(ctx, translateTask("Hola", "", "es"))
+	require.NoError(t, err)
+	assert.Equal(t, "Hola", result.Output["text"])
+	assert.Equal(t, false, result.Output["translated"])
+	assert.Len(t, requests(), 1)
+
+	_, err = NewTranslationAgent(MCPConfig{Type: "translation", Name: "translator", Config: map[string]interface{}{
+		"language_pairs": []interface{}{map[string]interface{}{"source": "en"}},
+	}}, logger.NewLogger("error"))
+	assert.EqualError(t, err, "language pairs require source and target")
+}
@@ -6,6 +6,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -13,6 +17,19 @@ import (
 type workflowAgent struct {
 	*baseAgent
 	steps []WorkflowStep
+	// maxStepOutputBytes limita el JSON de la salida de cada paso en el Result
+	maxStepOutputBytes int
+	// store guarda las salidas que superan el límite; nil las trunca sin más
+	store adapters.ObjectStoreAdapter
+	// registry da acceso a los adaptadores de los pasos database_query y
+	// queue_publish
+	registry adapters.AdapterRegistry
+	// definitions guarda los workflows referenciados por workflow_id
+	definitions     domain.WorkflowDefinitionRepository
+	workflowID      string
+	workflowVersion int
+	// conditions evalúa las expresiones de los pasos condition y branch
+	conditions ConditionEvaluator
 }
 
 // WorkflowStep representa un paso en un workflow
@@ -22,22 +39,46 @@ type WorkflowStep struct {
 	Config      map[string]interface{} `json:"config"`
 	OnError     string                 `json:"on_error,omitempty"` // "continue", "stop", "retry"
 	Timeout     time.Duration          `json:"timeout,omitempty"`
+	// Steps y Branches son los pasos hijos de loop, parallel y branch
+	Steps    []WorkflowStep   `json:"steps,omitempty"`
+	Branches []WorkflowBranch `json:"branches,omitempty"`
 }
 
-// NewWorkflowAgent crea un nuevo agente de workflow
-func NewWorkflowAgent(config MCPConfig, logger logger.Logger) (Agent, error) {
+// NewWorkflowAgent crea un nuevo agente de workflow; store es opcional y, si se
+// indica, recibe las salidas de paso que superan max_step_output_bytes. Con
+// workflow_id los pasos salen del workflow guardado en definitions.
+func NewWorkflowAgent(config MCPConfig, store adapters.ObjectStoreAdapter, registry adapters.AdapterRegistry, definitions domain.WorkflowDefinitionRepository, conditions ConditionEvaluator, logger logger.Logger) (Agent, error) {
 	base := newBaseAgent(config, logger)
 	base.capabilities = []string{"workflow", "sequence", "orchestration", "automation"}
 	
+	values := configschema.Values(config.Config)
+	workflowID := values.String("workflow_id", "")
+	
 	// Parsear pasos del workflow
-	steps, err := parseWorkflowSteps(config.Config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse workflow steps: %w", err)
+	var steps []WorkflowStep
+	if _, hasSteps := config.Config["steps"]; hasSteps || workflowID == "" {
+		var err error
+		steps, err = parseWorkflowSteps(config.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse workflow steps: %w", err)
+		}
 	}
 	
+	maxOutput := values.Int("max_step_output_bytes", defaultMaxStepOutputBytes)
+	if maxOutput <= 0 {
+		return nil, fmt.Errorf("max_step_output_bytes must be positive")
+	}
+
 	return &workflowAgent{
-		baseAgent: base,
-		steps:     steps,
+		baseAgent:          base,
+		steps:              steps,
+		maxStepOutputBytes: maxOutput,
+		store:              store,
+		registry:           registry,
+		definitions:        definitions,
+		workflowID:         workflowID,
+		workflowVersion:    values.Int("workflow_version", 0),
+		conditions:         conditions,
 	}, nil
 }
 
@@ -45,33 +86,66 @@ func (a *workflowAgent) Execute(ctx context.Context, task Task) (Result, error)
 	start := time.Now()
 	
 	// Actualizar estado
-	a.mu.Lock()
-	a.state.Status = AgentStatusBusy
-	a.state.CurrentTask = &task
-	a.mu.Unlock()
+	a.setStatus(AgentStatusBusy, &task)
 	
 	defer func() {
-		a.mu.Lock()
-		a.state.Status = AgentStatusIdle
-		a.state.CurrentTask = nil
-		a.mu.Unlock()
+		a.setStatus(AgentStatusIdle, nil)
 	}()
 	
+	steps, definition, err := a.resolveSteps(ctx, task.Input)
+	if err != nil {
+		duration := time.Since(start)
+		a.updateMetrics(false, duration)
+		return Result{
+			TaskID:   task.ID,
+			Success:  false,
+			Error:    err.Error(),
+			Duration: duration,
+		}, err
+	}
+	
 	a.logger.Info("Workflow agent executing task", 
 		"agent_id", a.id,
 		"task_id", task.ID,
-		"steps_count", len(a.steps))
+		"steps_count", len(steps))
 	
 	// Ejecutar pasos del workflow
-	results := make([]map[string]interface{}, 0, len(a.steps))
+	results := make([]map[string]interface{}, 0, len(steps))
 	workflowData := make(map[string]interface{})
+	// Salidas ya limitadas que sustituyen a step_N_result en el Result
+	limitedOutputs := make(map[string]interface{})
 	
 	// Inicializar datos del workflow con input de la tarea
 	for k, v := range task.Input {
 		workflowData[k] = v
 	}
 	
-	for i, step := range a.steps {
+	for i, step := range steps {
+		// Una tarea cancelada no sigue con los pasos restantes y devuelve lo
+		// que ya se había completado
+		if err := ctx.Err(); err != nil {
+			duration := time.Since(start)
+			a.updateMetrics(false, duration)
+			
+			return Result{
+				TaskID:  task.ID,
+				Success: false,
+				Error:   fmt.Sprintf("workflow cancelled before step %d: %v", i+1, err),
+				Output: map[string]interface{}{
+					"completed_steps":   results,
+					"cancelled_at_step": i + 1,
+					"workflow_data":     resultWorkflowData(workflowData, limitedOutputs),
+				},
+				Duration: duration,
+				Metadata: withWorkflowDefinition(map[string]interface{}{
+					"agent_id":     a.id,
+					"agent_type":   a.agentType,
+					"total_steps":  len(steps),
+					"completed":    i,
+				}, definition),
+			}, err
+		}
+		
 		stepStart := time.Now()
 		
 		a.logger.Info("Executing workflow step", 
@@ -129,23 +203,26 @@ func (a *workflowAgent) Execute(ctx context.Context, task Task) (Result, error)
 					Output: map[string]interface{}{
 						"completed_steps": results,
 						"failed_at_step":  i + 1,
-						"workflow_data":   workflowData,
+						"workflow_data":   resultWorkflowData(workflowData, limitedOutputs),
 					},
 					Duration: duration,
-					Metadata: map[string]interface{}{
+					Metadata: withWorkflowDefinition(map[string]interface{}{
 						"agent_id":     a.id,
 						"agent_type":   a.agentType,
-						"total_steps":  len(a.steps),
+						"total_steps":  len(steps),
 						"completed":    i,
-					},
+					}, definition),
 				}, err
 			}
 		}
 		
 		// Agregar resultado del paso a los datos del workflow
 		if stepResult != nil {
-			stepInfo["output"] = stepResult
-			workflowData[fmt.Sprintf("step_%d_result", i+1)] = stepResult
+			key := fmt.Sprintf("step_%d_result", i+1)
+			limited := a.limitStepOutput(ctx, task.ID, i+1, stepResult)
+			stepInfo["output"] = limited
+			workflowData[key] = stepResult
+			limitedOutputs[key] = limited
 		}
 		
 		results = append(results, stepInfo)
@@ -165,21 +242,34 @@ func (a *workflowAgent) Execute(ctx context.Context, task Task) (Result, error)
 		Success: true,
 		Output: map[string]interface{}{
 			"steps_executed": results,
-			"workflow_data":  workflowData,
+			"workflow_data":  resultWorkflowData(workflowData, limitedOutputs),
 			"summary": map[string]interface{}{
-				"total_steps":    len(a.steps),
+				"total_steps":    len(steps),
 				"completed":      len(results),
 				"total_duration": duration.Milliseconds(),
 			},
 		},
 		Duration: duration,
-		Metadata: map[string]interface{}{
+		Metadata: withWorkflowDefinition(map[string]interface{}{
 			"agent_id":   a.id,
 			"agent_type": a.agentType,
-		},
+		}, definition),
 	}, nil
 }
 
+// resultWorkflowData copia los datos del workflow con las salidas de paso limitadas;
+// los pasos siguientes siguen viendo la salida completa
+func resultWorkflowData(workflowData, limitedOutputs map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(workflowData))
+	for k, v := range workflowData {
+		data[k] = v
+	}
+	for k, v := range limitedOutputs {
+		data[k] = v
+	}
+	return data
+}
+
 func (a *workflowAgent) executeStep(ctx context.Context, step WorkflowStep, workflowData map[string]interface{}) (interface{}, error) {
 	// Aplicar timeout del paso si está configurado
 	stepCtx := ctx
@@ -202,6 +292,18 @@ func (a *workflowAgent) executeStep(ctx context.Context, step WorkflowStep, work
 		return a.executeHTTPCallStep(stepCtx, step, workflowData)
 	case "set_variable":
 		return a.executeSetVariableStep(stepCtx, step, workflowData)
+	case "translate":
+		return a.executeTranslateStep(stepCtx, step, workflowData)
+	case "database_query":
+		return a.executeDatabaseQueryStep(stepCtx, step, workflowData)
+	case "queue_publish":
+		return a.executeQueuePublishStep(stepCtx, step, workflowData)
+	case "parallel":
+		return a.executeParallelStep(stepCtx, step, workflowData)
+	case "loop":
+		return a.executeLoopStep(stepCtx, step, workflowData)
+	case "branch":
+		return a.executeBranchStep(stepCtx, step, workflowData)
 	default:
 		return nil, fmt.Errorf("unsupported step type: %s", step.Type)
 	}
@@ -284,9 +386,13 @@ func (a *workflowAgent) executeConditionStep(ctx context.Context, step WorkflowS
 		return nil, fmt.Errorf("condition step requires 'condition' config")
 	}
 	
-	// Implementación simple de evaluación de condiciones
-	// En un sistema real, esto sería más sofisticado
-	result := a.evaluateCondition(condition, workflowData)
+	result, err := a.evaluateCondition(condition, workflowData)
+	if err != nil {
+		return nil, err
+	}
+	if variable, ok := step.Config["output_variable"].(string); ok && variable != "" {
+		workflowData[variable] = result
+	}
 	
 	return map[string]interface{}{
 		"condition": condition,
@@ -329,6 +435,177 @@ func (a *workflowAgent) executeSetVariableStep(ctx context.Context, step Workflo
 	}, nil
 }
 
+// executeTranslateStep traduce "text" (admite variables) con un agente de
+// traducción configurado con el resto de la configuración del paso
+func (a *workflowAgent) executeTranslateStep(ctx context.Context, step WorkflowStep, workflowData map[string]interface{}) (interface{}, error) {
+	text, ok := step.Config["text"].(string)
+	if !ok {
+		return nil, fmt.Errorf("translate step requires 'text' config")
+	}
+	target, ok := step.Config["target_language"].(string)
+	if !ok || target == "" {
+		return nil, fmt.Errorf("translate step requires 'target_language' config")
+	}
+
+	translator, err := NewTranslationAgent(MCPConfig{
+		Type:   "translation",
+		Name:   a.name + "-translate",
+		Config: step.Config,
+	}, a.logger)
+	if err != nil {
+		return nil, err
+	}
+
+	source, _ := step.Config["source_language"].(string)
+	result, err := translator.Execute(ctx, Task{
+		ID:   fmt.Sprintf("%s-translate-%s", a.id, id.New()),
+		Type: "translation",
+		Input: map[string]interface{}{
+			"text":            a.replaceVariables(text, workflowData),
+			"source_language": a.replaceVariables(source, workflowData),
+			"target_language": a.replaceVariables(target, workflowData),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if variable, ok := step.Config["output_variable"].(string); ok && variable != "" {
+		workflowData[variable] = result.Output["text"]
+	}
+	return result.Output, nil
+}
+
+// executeDatabaseQueryStep ejecuta "query" en el adaptador database "adapter"
+// (por defecto, el primero registrado); los "params" de texto admiten
+// variables y las filas quedan en "output_variable"
+func (a *workflowAgent) executeDatabaseQueryStep(ctx context.Context, step WorkflowStep, workflowData map[string]interface{}) (interface{}, error) {
+	values := configschema.Values(step.Config)
+	query := values.String("query", "")
+	if query == "" {
+		return nil, fmt.Errorf("database_query step requires 'query' config")
+	}
+	database, err := a.database(values.String("adapter", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	params, _ := step.Config["params"].([]interface{})
+	args := make([]interface{}, len(params))
+	for i, param := range params {
+		if text, ok := param.(string); ok {
+			param = a.replaceVariables(text, workflowData)
+		}
+		args[i] = param
+	}
+	result, err := database.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	if variable := values.String("output_variable", ""); variable != "" {
+		workflowData[variable] = result.Rows
+	}
+	return map[string]interface{}{
+		"rows":      result.Rows,
+		"count":     result.Count,
+		"truncated": result.Truncated,
+	}, nil
+}
+
+// database busca el adaptador database por nombre o el primero registrado
+func (a *workflowAgent) database(name string) (adapters.DatabaseAdapter, error) {
+	if a.registry == nil {
+		return nil, fmt.Errorf("no database adapter registered")
+	}
+	if name != "" {
+		adapter, err := a.registry.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		database, ok := adapter.(adapters.DatabaseAdapter)
+		if !ok {
+			return nil, fmt.Errorf("adapter '%s' is not a database", name)
+		}
+		return database, nil
+	}
+	for _, adapter := range a.registry.GetByType("database") {
+		if database, ok := adapter.(adapters.DatabaseAdapter); ok {
+			return database, nil
+		}
+	}
+	return nil, fmt.Errorf("no database adapter registered")
+}
+
+// executeQueuePublishStep publica "payload" en "topic" con el adaptador
+// message_queue "adapter" (por defecto, el primero registrado). Los textos
+// del payload, la clave y las cabeceras admiten variables.
+func (a *workflowAgent) executeQueuePublishStep(ctx context.Context, step WorkflowStep, workflowData map[string]interface{}) (interface{}, error) {
+	values := configschema.Values(step.Config)
+	topic := a.replaceVariables(values.String("topic", ""), workflowData)
+	if topic == "" {
+		return nil, fmt.Errorf("queue_publish step requires 'topic' config")
+	}
+	queue, err := a.queue(values.String("adapter", ""))
+	if err != nil {
+		return nil, err
+	}
+
+	payload := step.Config["payload"]
+	switch typed := payload.(type) {
+	case string:
+		payload = a.replaceVariables(typed, workflowData)
+	case map[string]interface{}:
+		fields := make(map[string]interface{}, len(typed))
+		for key, value := range typed {
+			if text, ok := value.(string); ok {
+				value = a.replaceVariables(text, workflowData)
+			}
+			fields[key] = value
+		}
+		payload = fields
+	}
+	headers := values.StringMap("headers")
+	for name, value := range headers {
+		headers[name] = a.replaceVariables(value, workflowData)
+	}
+	message := &adapters.Message{
+		Topic:     topic,
+		Payload:   payload,
+		Headers:   headers,
+		Timestamp: time.Now(),
+		Metadata:  map[string]interface{}{"key": a.replaceVariables(values.String("key", ""), workflowData)},
+	}
+	if err := queue.Publish(ctx, topic, message); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"message_id": message.ID, "topic": topic}, nil
+}
+
+// queue busca el adaptador message_queue por nombre o el primero registrado
+func (a *workflowAgent) queue(name string) (adapters.MessageQueueAdapter, error) {
+	if a.registry == nil {
+		return nil, fmt.Errorf("no message queue adapter registered")
+	}
+	if name != "" {
+		adapter, err := a.registry.Get(name)
+		if err != nil {
+			return nil, err
+		}
+		queue, ok := adapter.(adapters.MessageQueueAdapter)
+		if !ok {
+			return nil, fmt.Errorf("adapter '%s' is not a message queue", name)
+		}
+		return queue, nil
+	}
+	for _, adapter := range a.registry.GetByType("message_queue") {
+		if queue, ok := adapter.(adapters.MessageQueueAdapter); ok {
+			return queue, nil
+		}
+	}
+	return nil, fmt.Errorf("no message queue adapter registered")
+}
+
 func (a *workflowAgent) replaceVariables(text string, data map[string]interface{}) string {
 	// Implementación simple de reemplazo de variables
 	// En un sistema real, esto sería más sofisticado
@@ -341,12 +618,6 @@ func (a *workflowAgent) replaceVariables(text string, data map[string]interface{
 	return result
 }
 
-func (a *workflowAgent) evaluateCondition(condition string, data map[string]interface{}) bool {
-	// Implementación muy simple de evaluación de condiciones
-	// En un sistema real, esto usaría un parser de expresiones
-	return len(data) > 0 // Condición dummy
-}
-
 func (a *workflowAgent) CanHandle(taskType string) bool {
 	supportedTypes := []string{
 		"workflow",
@@ -372,7 +643,12 @@ func parseWorkflowSteps(config map[string]interface{}) ([]WorkflowStep, error) {
 	if !exists {
 		return nil, fmt.Errorf("workflow config must contain 'steps'")
 	}
-	
+	return parseStepList(stepsInterface)
+}
+
+// parseStepList parsea una lista de pasos, incluidos los hijos de los pasos
+// loop (config.steps) y parallel (config.branches)
+func parseStepList(stepsInterface interface{}) ([]WorkflowStep, error) {
 	stepsArray, ok := stepsInterface.([]interface{})
 	if !ok {
 		return nil, fmt.Errorf("steps must be an array")
@@ -419,8 +695,54 @@ func parseWorkflowSteps(config map[string]interface{}) ([]WorkflowStep, error) {
 			return nil, fmt.Errorf("step %d must have a type", i)
 		}
 		
+		if err := parseChildSteps(&step); err != nil {
+			return nil, fmt.Errorf("step %d: %w", i, err)
+		}
+		
 		steps = append(steps, step)
 	}
 	
 	return steps, nil
-}
\ No newline at end of file
+}
+
+// parseChildSteps parsea los pasos hijos de loop y las ramas de parallel
+func parseChildSteps(step *WorkflowStep) error {
+	switch step.Type {
+	case "loop":
+		steps, err := parseStepList(step.Config["steps"])
+		if err != nil {
+			return fmt.Errorf("loop steps: %w", err)
+		}
+		step.Steps = steps
+	case "parallel":
+		branches, ok := step.Config["branches"].([]interface{})
+		if !ok || len(branches) == 0 {
+			return fmt.Errorf("parallel step requires a 'branches' array")
+		}
+		switch step.Config["join"] {
+		case nil, "", JoinWaitAll, JoinFirstSuccess:
+		default:
+			return fmt.Errorf("unsupported join strategy: %v", step.Config["join"])
+		}
+		for i, raw := range branches {
+			branchMap, ok := raw.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("branch %d must be an object", i)
+			}
+			steps, err := parseStepList(branchMap["steps"])
+			if err != nil {
+				return fmt.Errorf("branch %d steps: %w", i, err)
+			}
+			branch := WorkflowBranch{Steps: steps}
+			branch.Name, _ = branchMap["name"].(string)
+			if branch.Name == "" {
+				branch.Name = fmt.Sprintf("branch_%d", i+1)
+			}
+			branch.OnError, _ = branchMap["on_error"].(string)
+			step.Branches = append(step.Branches, branch)
+		}
+	case "branch":
+		return parseBranchCases(step)
+	}
+	return nil
+}
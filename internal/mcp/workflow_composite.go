@@ -0,0 +1,253 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Estrategias de unión del paso parallel
+const (
+	JoinWaitAll      = "wait_all"
+	JoinFirstSuccess = "first_success"
+)
+
+// defaultLoopMaxIterations limita los elementos que recorre un paso loop
+const defaultLoopMaxIterations = 1000
+
+// WorkflowBranch es una rama de un paso parallel, cuyos pasos se ejecutan en
+// orden y en paralelo con las demás ramas, o un caso de un paso branch
+type WorkflowBranch struct {
+	Name string `json:"name"`
+	// Condition es la expresión que selecciona el caso en un paso branch
+	Condition string `json:"condition,omitempty"`
+	// OnError "continue" ignora el fallo de la rama en la unión wait_all
+	OnError string         `json:"on_error,omitempty"`
+	Steps   []WorkflowStep `json:"steps"`
+}
+
+// branchResult es el resultado de una rama y los datos que ha escrito
+type branchResult struct {
+	index    int
+	outputs  []interface{}
+	data     map[string]interface{}
+	err      error
+	duration time.Duration
+}
+
+// executeParallelStep ejecuta las ramas a la vez, cada una sobre su copia de
+// los datos del workflow. Con wait_all espera a todas y falla si alguna falla
+// sin on_error "continue"; con first_success se queda con la primera que
+// termina bien y cancela el resto. Sólo se conservan las variables que
+// escriben las ramas correctas, en el orden en que están declaradas.
+func (a *workflowAgent) executeParallelStep(ctx context.Context, step WorkflowStep, workflowData map[string]interface{}) (interface{}, error) {
+	if len(step.Branches) == 0 {
+		return nil, fmt.Errorf("parallel step requires 'branches' config")
+	}
+	join, _ := step.Config["join"].(string)
+	if join == "" {
+		join = JoinWaitAll
+	}
+
+	branchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	done := make(chan branchResult, len(step.Branches))
+	for i, branch := range step.Branches {
+		data := make(map[string]interface{}, len(workflowData))
+		for k, v := range workflowData {
+			data[k] = v
+		}
+		go func(i int, branch WorkflowBranch, data map[string]interface{}) {
+			start := time.Now()
+			outputs, err := a.runSteps(branchCtx, branch.Steps, data)
+			done <- branchResult{index: i, outputs: outputs, data: data, err: err, duration: time.Since(start)}
+		}(i, branch, data)
+	}
+
+	results := make([]*branchResult, len(step.Branches))
+	winner := -1
+	for range step.Branches {
+		result := <-done
+		results[result.index] = &result
+		if join == JoinFirstSuccess && result.err == nil {
+			winner = result.index
+			cancel()
+			break
+		}
+	}
+
+	branches := make([]map[string]interface{}, len(step.Branches))
+	var failures []string
+	for i, branch := range step.Branches {
+		info := map[string]interface{}{"name": branch.Name}
+		result := results[i]
+		switch {
+		case result == nil:
+			info["status"] = "cancelled"
+		case result.err != nil:
+			info["status"] = "failed"
+			info["error"] = result.err.Error()
+			info["duration"] = result.duration.Milliseconds()
+			if branch.OnError != "continue" {
+				failures = append(failures, fmt.Sprintf("%s: %v", branch.Name, result.err))
+			}
+		default:
+			info["status"] = "succeeded"
+			info["outputs"] = result.outputs
+			info["duration"] = result.duration.Milliseconds()
+		}
+		branches[i] = info
+	}
+	output := map[string]interface{}{"join": join, "branches": branches}
+
+	if join == JoinFirstSuccess {
+		if winner < 0 {
+			return output, fmt.Errorf("all parallel branches failed: %s", strings.Join(failures, "; "))
+		}
+		mergeBranchData(workflowData, results[winner].data)
+		output["winner"] = step.Branches[winner].Name
+		return output, nil
+	}
+
+	// Se fusiona sobre una instantánea para comparar contra los datos de partida
+	snapshot := make(map[string]interface{}, len(workflowData))
+	for k, v := range workflowData {
+		snapshot[k] = v
+	}
+	for _, result := range results {
+		if result.err == nil {
+			mergeChangedData(workflowData, snapshot, result.data)
+		}
+	}
+	if len(failures) > 0 {
+		return output, fmt.Errorf("parallel branches failed: %s", strings.Join(failures, "; "))
+	}
+	return output, nil
+}
+
+// mergeBranchData copia todos los datos de la rama ganadora
+func mergeBranchData(workflowData, branchData map[string]interface{}) {
+	for k, v := range branchData {
+		workflowData[k] = v
+	}
+}
+
+// mergeChangedData copia sólo las variables que la rama creó o cambió, para
+// que una rama no pise con el valor original lo que escribió otra
+func mergeChangedData(workflowData, snapshot, branchData map[string]interface{}) {
+	for k, v := range branchData {
+		if original, exists := snapshot[k]; exists && reflect.DeepEqual(original, v) {
+			continue
+		}
+		workflowData[k] = v
+	}
+}
+
+// executeLoopStep ejecuta los pasos hijos una vez por elemento de "items", un
+// array o el nombre de una variable que lo contiene. El elemento y su índice
+// quedan en item_variable e index_variable durante la iteración, y la salida
+// del último paso de cada iteración en "results" y output_variable.
+func (a *workflowAgent) executeLoopStep(ctx context.Context, step WorkflowStep, workflowData map[string]interface{}) (interface{}, error) {
+	if len(step.Steps) == 0 {
+		return nil, fmt.Errorf("loop step requires 'steps' config")
+	}
+	items, err := loopItems(step.Config["items"], workflowData)
+	if err != nil {
+		return nil, err
+	}
+	maxIterations := defaultLoopMaxIterations
+	if limit, ok := step.Config["max_iterations"].(float64); ok && limit > 0 {
+		maxIterations = int(limit)
+	}
+	if len(items) > maxIterations {
+		return nil, fmt.Errorf("loop over %d items exceeds max_iterations %d", len(items), maxIterations)
+	}
+	itemVariable, _ := step.Config["item_variable"].(string)
+	if itemVariable == "" {
+		itemVariable = "item"
+	}
+	indexVariable, _ := step.Config["index_variable"].(string)
+	if indexVariable == "" {
+		indexVariable = "index"
+	}
+
+	// Las variables del bucle recuperan su valor anterior al terminar
+	previous := map[string]interface{}{}
+	for _, name := range []string{itemVariable, indexVariable} {
+		if value, exists := workflowData[name]; exists {
+			previous[name] = value
+		}
+	}
+	defer func() {
+		for _, name := range []string{itemVariable, indexVariable} {
+			if value, exists := previous[name]; exists {
+				workflowData[name] = value
+			} else {
+				delete(workflowData, name)
+			}
+		}
+	}()
+
+	results := make([]interface{}, 0, len(items))
+	for i, item := range items {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		workflowData[itemVariable] = item
+		workflowData[indexVariable] = i
+		outputs, err := a.runSteps(ctx, step.Steps, workflowData)
+		if err != nil {
+			return map[string]interface{}{"iterations": i, "results": results}, fmt.Errorf("loop iteration %d failed: %w", i, err)
+		}
+		var last interface{}
+		if len(outputs) > 0 {
+			last = outputs[len(outputs)-1]
+		}
+		results = append(results, last)
+	}
+
+	if variable, ok := step.Config["output_variable"].(string); ok && variable != "" {
+		workflowData[variable] = results
+	}
+	return map[string]interface{}{"iterations": len(items), "results": results}, nil
+}
+
+// loopItems resuelve "items" como array literal o nombre de variable
+func loopItems(value interface{}, workflowData map[string]interface{}) ([]interface{}, error) {
+	if name, ok := value.(string); ok {
+		name = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(name), "{{"), "}}")
+		value = workflowData[name]
+		if value == nil {
+			return nil, fmt.Errorf("loop variable %s is not set", name)
+		}
+	}
+	items, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("loop step requires 'items' to be an array")
+	}
+	return items, nil
+}
+
+// runSteps ejecuta pasos hijos en orden con la misma política on_error que
+// los pasos de primer nivel: continue sigue, retry reintenta una vez y el
+// resto detiene la secuencia
+func (a *workflowAgent) runSteps(ctx context.Context, steps []WorkflowStep, workflowData map[string]interface{}) ([]interface{}, error) {
+	outputs := make([]interface{}, 0, len(steps))
+	for i, step := range steps {
+		output, err := a.executeStep(ctx, step, workflowData)
+		if err != nil && step.OnError == "retry" {
+			output, err = a.executeStep(ctx, step, workflowData)
+		}
+		if err != nil {
+			if step.OnError == "continue" {
+				outputs = append(outputs, map[string]interface{}{"error": err.Error()})
+				continue
+			}
+			return outputs, fmt.Errorf("step %d (%s): %w", i+1, step.Type, err)
+		}
+		outputs = append(outputs, output)
+	}
+	return outputs, nil
+}
@@ -0,0 +1,89 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testStep(stepType string, config map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"type": stepType, "config": config}
+}
+
+func testBranch(name, onError string, steps ...interface{}) map[string]interface{} {
+	return map[string]interface{}{"name": name, "on_error": onError, "steps": steps}
+}
+
+func runWorkflow(t *testing.T, input map[string]interface{}, steps ...interface{}) (Result, error) {
+	agent, err := NewWorkflowAgent(MCPConfig{Type: "workflow", Name: "composite", Config: map[string]interface{}{"steps": steps}}, nil, nil, nil, nil, logger.NewLogger("error"))
+	require.NoError(t, err)
+	return agent.Execute(context.Background(), Task{ID: "task-1", Type: "workflow", Input: input})
+}
+
+func TestWorkflowAgent_ParallelBranchesJoin(t *testing.T) {
+	failing := testStep("transform", map[string]interface{}{"operation": "explode"})
+
+	result, err := runWorkflow(t, map[string]interface{}{"shared": "before"},
+		testStep("parallel", map[string]interface{}{"branches": []interface{}{
+			testBranch("stock", "", testStep("set_variable", map[string]interface{}{"name": "stock", "value": 3})),
+			testBranch("price", "", testStep("delay", map[string]interface{}{"delay_ms": float64(20)}), testStep("set_variable", map[string]interface{}{"name": "shared", "value": "after"})),
+			testBranch("optional", "continue", testStep("set_variable", map[string]interface{}{"name": "discarded", "value": true}), failing),
+		}}),
+		testStep("log", map[string]interface{}{"message": "{{stock}} {{shared}}"}),
+	)
+	require.NoError(t, err)
+	data := result.Output["workflow_data"].(map[string]interface{})
+	assert.Equal(t, 3, data["stock"])
+	assert.Equal(t, "after", data["shared"])
+	assert.NotContains(t, data, "discarded")
+	branches := data["step_1_result"].(map[string]interface{})["branches"].([]map[string]interface{})
+	assert.Equal(t, "failed", branches[2]["status"])
+	assert.Equal(t, "3 after", data["step_2_result"].(map[string]interface{})["message"])
+
+	_, err = runWorkflow(t, nil, testStep("parallel", map[string]interface{}{"branches": []interface{}{
+		testBranch("required", "", failing),
+	}}))
+	assert.ErrorContains(t, err, "required")
+
+	start := time.Now()
+	result, err = runWorkflow(t, nil, testStep("parallel", map[string]interface{}{"join": "first_success", "branches": []interface{}{
+		testBranch("slow", "", testStep("delay", map[string]interface{}{"delay_ms": float64(5000)})),
+		testBranch("broken", "", failing),
+		testBranch("fast", "", testStep("set_variable", map[string]interface{}{"name": "source", "value": "cache"})),
+	}}))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+	data = result.Output["workflow_data"].(map[string]interface{})
+	assert.Equal(t, "cache", data["source"])
+	assert.Equal(t, "fast", data["step_1_result"].(map[string]interface{})["winner"])
+
+	_, err = NewWorkflowAgent(MCPConfig{Type: "workflow", Name: "bad", Config: map[string]interface{}{"steps": []interface{}{
+		testStep("parallel", map[string]interface{}{"join": "any", "branches": []interface{}{testBranch("a", "", failing)}}),
+	}}}, nil, nil, nil, nil, logger.NewLogger("error"))
+	assert.ErrorContains(t, err, "join strategy")
+}
+
+func TestWorkflowAgent_LoopOverArray(t *testing.T) {
+	result, err := runWorkflow(t, map[string]interface{}{"orders": []interface{}{"A-1", "B-2"}, "item": "kept"},
+		testStep("loop", map[string]interface{}{"items": "{{orders}}", "output_variable": "messages", "steps": []interface{}{
+			testStep("log", map[string]interface{}{"message": "{{index}}: {{item}}"}),
+		}}),
+	)
+	require.NoError(t, err)
+	data := result.Output["workflow_data"].(map[string]interface{})
+	messages := data["messages"].([]interface{})
+	require.Len(t, messages, 2)
+	assert.Equal(t, "1: B-2", messages[1].(map[string]interface{})["message"])
+	assert.Equal(t, "kept", data["item"])
+	assert.NotContains(t, data, "index")
+
+	_, err = runWorkflow(t, map[string]interface{}{"orders": []interface{}{1, 2, 3}},
+		testStep("loop", map[string]interface{}{"items": "orders", "max_iterations": float64(2), "steps": []interface{}{testStep("log", nil)}}))
+	assert.ErrorContains(t, err, "max_iterations")
+	_, err = runWorkflow(t, nil, testStep("loop", map[string]interface{}{"items": "missing", "steps": []interface{}{testStep("log", nil)}}))
+	assert.ErrorContains(t, err, "not set")
+}
@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// ConditionEvaluator evalúa una expresión condicional sobre los datos del
+// workflow. Lo implementa el motor de expresiones de los condicionales de los
+// bots, así que admite las mismas funciones y operadores.
+type ConditionEvaluator interface {
+	Evaluate(expression string, input map[string]interface{}) (bool, error)
+}
+
+func (a *workflowAgent) evaluateCondition(condition string, data map[string]interface{}) (bool, error) {
+	if a.conditions == nil {
+		return false, fmt.Errorf("condition evaluation is not available")
+	}
+	result, err := a.conditions.Evaluate(condition, data)
+	if err != nil {
+		return false, fmt.Errorf("failed to evaluate condition %q: %w", condition, err)
+	}
+	return result, nil
+}
+
+// executeBranchStep ejecuta los pasos del primer caso cuya condición se cumple
+// o, si no se cumple ninguna, los de "default". Los pasos del caso elegido
+// trabajan sobre los datos del workflow, como los de primer nivel.
+func (a *workflowAgent) executeBranchStep(ctx context.Context, step WorkflowStep, workflowData map[string]interface{}) (interface{}, error) {
+	if len(step.Branches) == 0 {
+		return nil, fmt.Errorf("branch step requires 'cases' or 'condition' config")
+	}
+
+	selected, matched, steps := "default", false, step.Steps
+	for _, branchCase := range step.Branches {
+		result, err := a.evaluateCondition(branchCase.Condition, workflowData)
+		if err != nil {
+			return nil, fmt.Errorf("case %s: %w", branchCase.Name, err)
+		}
+		if result {
+			selected, matched, steps = branchCase.Name, true, branchCase.Steps
+			break
+		}
+	}
+
+	outputs, err := a.runSteps(ctx, steps, workflowData)
+	output := map[string]interface{}{"case": selected, "matched": matched, "outputs": outputs}
+	if err != nil {
+		return output, fmt.Errorf("branch case %s failed: %w", selected, err)
+	}
+	return output, nil
+}
+
+// parseBranchCases lee los casos de un paso branch: una lista "cases" con
+// name, condition y steps, o la forma corta condition, then y else. "default"
+// (o "else") son los pasos que se ejecutan si no se cumple ningún caso.
+func parseBranchCases(step *WorkflowStep) error {
+	cases, _ := step.Config["cases"].([]interface{})
+	if condition, ok := step.Config["condition"].(string); ok {
+		then := map[string]interface{}{"name": "then", "condition": condition, "steps": step.Config["then"]}
+		cases = append([]interface{}{then}, cases...)
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("branch step requires 'cases' or 'condition'")
+	}
+
+	for i, raw := range cases {
+		caseMap, ok := raw.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("case %d must be an object", i)
+		}
+		condition, _ := caseMap["condition"].(string)
+		if strings.TrimSpace(condition) == "" {
+			return fmt.Errorf("case %d requires a 'condition'", i)
+		}
+		steps, err := parseStepList(caseMap["steps"])
+		if err != nil {
+			return fmt.Errorf("case %d steps: %w", i, err)
+		}
+		branchCase := WorkflowBranch{Condition: condition, Steps: steps}
+		branchCase.Name, _ = caseMap["name"].(string)
+		if branchCase.Name == "" {
+			branchCase.Name = fmt.Sprintf("case_%d", i+1)
+		}
+		step.Branches = append(step.Branches, branchCase)
+	}
+
+	for _, key := range []string{"default", "else"} {
+		if raw, exists := step.Config[key]; exists {
+			steps, err := parseStepList(raw)
+			if err != nil {
+				return fmt.Errorf("branch %s steps: %w", key, err)
+			}
+			step.Steps = steps
+			break
+		}
+	}
+	return nil
+}
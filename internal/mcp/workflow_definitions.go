@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/configschema"
+)
+
+// ValidateWorkflowSteps comprueba que los pasos de un workflow guardado se
+// pueden parsear, incluidos los hijos de loop y parallel
+func ValidateWorkflowSteps(steps []map[string]interface{}) error {
+	if len(steps) == 0 {
+		return fmt.Errorf("workflow must have at least one step")
+	}
+	_, err := parseStepList(workflowStepList(steps))
+	return err
+}
+
+func workflowStepList(steps []map[string]interface{}) []interface{} {
+	list := make([]interface{}, len(steps))
+	for i, step := range steps {
+		list[i] = step
+	}
+	return list
+}
+
+// resolveSteps elige los pasos de la ejecución: los del workflow guardado que
+// indique la tarea o, si no, la configuración del agente (workflow_id y
+// workflow_version, 0 es la última), o los pasos de la configuración. El
+// workflow se lee en cada ejecución, así que sus cambios no obligan a
+// recrear el agente.
+func (a *workflowAgent) resolveSteps(ctx context.Context, input map[string]interface{}) ([]WorkflowStep, *domain.WorkflowDefinition, error) {
+	workflowID, version := a.workflowID, a.workflowVersion
+	if inputID := configschema.Values(input).String("workflow_id", ""); inputID != "" {
+		workflowID, version = inputID, configschema.Values(input).Int("workflow_version", 0)
+	}
+	if workflowID == "" {
+		return a.steps, nil, nil
+	}
+	if a.definitions == nil {
+		return nil, nil, fmt.Errorf("stored workflows are not available")
+	}
+
+	var definition *domain.WorkflowDefinition
+	var err error
+	if version > 0 {
+		definition, err = a.definitions.GetVersion(ctx, workflowID, version)
+	} else {
+		definition, err = a.definitions.GetByID(ctx, workflowID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("workflow %s not found: %w", workflowID, err)
+	}
+	steps, err := parseStepList(workflowStepList(definition.Steps))
+	if err != nil {
+		return nil, nil, fmt.Errorf("workflow %s version %d is invalid: %w", workflowID, definition.Version, err)
+	}
+	return steps, definition, nil
+}
+
+// withWorkflowDefinition añade al metadata del resultado el workflow guardado
+// y la versión que se ejecutó
+func withWorkflowDefinition(metadata map[string]interface{}, definition *domain.WorkflowDefinition) map[string]interface{} {
+	if definition != nil {
+		metadata["workflow_id"] = definition.ID
+		metadata["workflow_version"] = definition.Version
+	}
+	return metadata
+}
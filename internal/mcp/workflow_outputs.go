@@ -0,0 +1,75 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"unicode/utf8"
+)
+
+// defaultMaxStepOutputBytes es el tamaño máximo de la salida de un paso de
+// workflow dentro del Result cuando no se configura max_step_output_bytes
+const defaultMaxStepOutputBytes = 16 * 1024
+
+// WorkflowOutputsPrefix es el prefijo de las claves de las salidas de workflow
+const WorkflowOutputsPrefix = "workflows/"
+
+// WorkflowStepOutputKey es la clave del almacenamiento de objetos donde se
+// guarda la salida completa de un paso (numerado desde 1) de una tarea
+func WorkflowStepOutputKey(taskID string, step int) string {
+	return fmt.Sprintf("%s%s/step-%d.json", WorkflowOutputsPrefix, url.PathEscape(taskID), step)
+}
+
+// workflowStepOutputPath es la ruta de la API que devuelve la salida completa
+func workflowStepOutputPath(taskID string, step int) string {
+	return fmt.Sprintf("/api/v1/mcp/workflows/%s/steps/%d/output", url.PathEscape(taskID), step)
+}
+
+// limitStepOutput deja la salida tal cual si cabe en el límite; si no, la
+// sustituye por un resumen con un fragmento del JSON y, si hay almacenamiento
+// configurado, la referencia a la salida completa
+func (a *workflowAgent) limitStepOutput(ctx context.Context, taskID string, step int, output interface{}) interface{} {
+	encoded, err := json.Marshal(output)
+	if err != nil || len(encoded) <= a.maxStepOutputBytes {
+		return output
+	}
+
+	limited := map[string]interface{}{
+		"truncated":  true,
+		"size_bytes": len(encoded),
+		"preview":    truncateUTF8(encoded, a.maxStepOutputBytes),
+	}
+	if a.store == nil {
+		return limited
+	}
+
+	stored, err := a.store.PutObject(ctx, WorkflowStepOutputKey(taskID, step), encoded, "application/json")
+	if err != nil {
+		a.logger.Warn("Failed to externalize workflow step output",
+			"agent_id", a.id,
+			"task_id", taskID,
+			"step", step,
+			"error", err)
+		return limited
+	}
+	// Sin URL pública: la salida solo se descarga autenticado por stream_url
+	limited["ref"] = map[string]interface{}{
+		"key":          stored.Key,
+		"content_type": stored.ContentType,
+		"size":         stored.Size,
+	}
+	limited["stream_url"] = workflowStepOutputPath(taskID, step)
+	return limited
+}
+
+// truncateUTF8 corta data a como mucho n bytes sin partir una runa
+func truncateUTF8(data []byte, n int) string {
+	if len(data) <= n {
+		return string(data)
+	}
+	for n > 0 && !utf8.RuneStart(data[n]) {
+		n--
+	}
+	return string(data[:n])
+}
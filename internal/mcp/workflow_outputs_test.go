@@ -0,0 +1,84 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func workflowConfig(extra map[string]interface{}) MCPConfig {
+	config := map[string]interface{}{
+		"steps": []interface{}{
+			map[string]interface{}{"type": "set_variable", "config": map[string]interface{}{"name": "body", "value": strings.Repeat("á", 200)}},
+			map[string]interface{}{"type": "log", "config": map[string]interface{}{"message": "size {{body}}"}},
+		},
+		"max_step_output_bytes": float64(64),
+	}
+	for k, v := range extra {
+		config[k] = v
+	}
+	return MCPConfig{Type: "workflow", Name: "outputs", Config: config}
+}
+
+func TestWorkflowAgent_ExternalizesLargeStepOutputs(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	store := adapters.NewLocalObjectStore("objects", t.TempDir(), "http://localhost/media", log)
+	require.NoError(t, store.Start(ctx))
+
+	agent, err := NewWorkflowAgent(workflowConfig(nil), store, nil, nil, nil, log)
+	require.NoError(t, err)
+	result, err := agent.Execute(ctx, Task{ID: "task-1", Type: "workflow"})
+	require.NoError(t, err)
+
+	steps := result.Output["steps_executed"].([]map[string]interface{})
+	require.Len(t, steps, 2)
+	output := steps[0]["output"].(map[string]interface{})
+	assert.Equal(t, true, output["truncated"])
+	assert.LessOrEqual(t, len(output["preview"].(string)), 64)
+	assert.Equal(t, "/api/v1/mcp/workflows/task-1/steps/1/output", output["stream_url"])
+	assert.Equal(t, output, result.Output["workflow_data"].(map[string]interface{})["step_1_result"])
+
+	// El paso siguiente vio el valor completo, no el resumen
+	logged := steps[1]["output"].(map[string]interface{})
+	assert.Equal(t, true, logged["truncated"])
+
+	reader, size, err := store.OpenObject(ctx, WorkflowStepOutputKey("task-1", 1))
+	require.NoError(t, err)
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(data)), size)
+	var full map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &full))
+	assert.Equal(t, strings.Repeat("á", 200), full["value"])
+}
+
+func TestWorkflowAgent_TruncatesWithoutStore(t *testing.T) {
+	log := logger.NewLogger("error")
+	agent, err := NewWorkflowAgent(workflowConfig(map[string]interface{}{"max_step_output_bytes": float64(1 << 20)}), nil, nil, nil, nil, log)
+	require.NoError(t, err)
+	result, err := agent.Execute(context.Background(), Task{ID: "task-2", Type: "workflow"})
+	require.NoError(t, err)
+	steps := result.Output["steps_executed"].([]map[string]interface{})
+	assert.NotContains(t, steps[0]["output"], "truncated")
+
+	agent, err = NewWorkflowAgent(workflowConfig(nil), nil, nil, nil, nil, log)
+	require.NoError(t, err)
+	result, err = agent.Execute(context.Background(), Task{ID: "task-3", Type: "workflow"})
+	require.NoError(t, err)
+	output := result.Output["steps_executed"].([]map[string]interface{})[0]["output"].(map[string]interface{})
+	assert.Equal(t, true, output["truncated"])
+	assert.NotContains(t, output, "ref")
+	assert.NotContains(t, output, "stream_url")
+
+	_, err = NewWorkflowAgent(workflowConfig(map[string]interface{}{"max_step_output_bytes": float64(0)}), nil, nil, nil, nil, log)
+	assert.Error(t, err)
+}
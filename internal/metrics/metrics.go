@@ -0,0 +1,417 @@
+// Package metrics define las métricas Prometheus del servicio. Todas viven en
+// Registry, que es lo que se expone en /metrics.
+package metrics
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Estados de las tareas MCP en mcp_tasks_total
+const (
+	StatusSuccess = "success"
+	StatusFailure = "failure"
+)
+
+// Registry agrupa las métricas del servicio y las del runtime de Go
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Total number of HTTP requests",
+		},
+		[]string{"method", "endpoint", "status"},
+	)
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Duration of HTTP requests in seconds",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"method", "endpoint"},
+	)
+
+	MCPTasks = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcp_tasks_total",
+			Help: "MCP tasks executed, by agent type and result",
+		},
+		[]string{"agent_type", "status"},
+	)
+
+	MCPTaskDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcp_task_duration_seconds",
+			Help:    "Duration of MCP task executions in seconds",
+			Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+		},
+		[]string{"agent_type"},
+	)
+
+	MCPQueueWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "mcp_queue_wait_seconds",
+			Help:    "Time MCP tasks waited in the orchestrator queue for a free agent",
+			Buckets: []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		},
+		[]string{"task_type"},
+	)
+
+	MCPQueueRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcp_queue_rejections_total",
+			Help: "MCP tasks that failed waiting for a free agent, by task type and reason (full, timeout)",
+		},
+		[]string{"task_type", "reason"},
+	)
+
+	MCPCircuitState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "mcp_agent_circuit_state",
+			Help: "Circuit breaker state of each MCP agent (0 closed, 1 half open, 2 open)",
+		},
+		[]string{"agent_type", "agent_id"},
+	)
+
+	MCPCircuitTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "mcp_circuit_transitions_total",
+			Help: "MCP agent circuit breaker transitions, by agent type and new state",
+		},
+		[]string{"agent_type", "state"},
+	)
+
+	AITokens = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_tokens_total",
+			Help: "Tokens consumed by AI providers, by model and kind (prompt, completion)",
+		},
+		[]string{"provider", "model", "kind"},
+	)
+
+	AIToolCalls = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "ai_tool_calls_total",
+			Help: "Tool calls requested by AI agents, by tool and status (success, error)",
+		},
+		[]string{"tool", "status"},
+	)
+
+	ResponseCacheLookups = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "response_cache_lookups_total",
+			Help: "Response cache lookups of flow steps, by kind (ai, http) and result (hit, miss)",
+		},
+		[]string{"kind", "result"},
+	)
+
+	StepDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "bot_step_duration_seconds",
+			Help:    "Duration of flow step executions in seconds, by step type and result",
+			Buckets: prometheus.DefBuckets,
+		},
+		[]string{"step_type", "status"},
+	)
+
+	ChaosFaults = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chaos_faults_injected_total",
+			Help: "Faults injected by chaos mode, by target and fault (latency, error, timeout)",
+		},
+		[]string{"target", "fault"},
+	)
+
+	BackpressureRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "backpressure_rejections_total",
+			Help: "Incoming messages rejected under load, by saturated resource and level (shedding, saturated)",
+		},
+		[]string{"reason", "level"},
+	)
+
+	// TaskQueueDepth lee el tamaño de la cola en cada scrape (ver TrackTaskQueue)
+	TaskQueueDepth = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "task_queue_depth",
+			Help: "Async tasks waiting in the task manager queue",
+		},
+		func() float64 {
+			if depth, ok := taskQueueDepth.Load().(func() int); ok {
+				return float64(depth())
+			}
+			return 0
+		},
+	)
+
+	TaskWorkers = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "task_workers",
+			Help: "Workers currently in the task manager pool",
+		},
+	)
+
+	TaskWorkerScaleEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "task_worker_scale_events_total",
+			Help: "Task worker pool resizes made by the autoscaler, by direction (up, down)",
+		},
+		[]string{"direction"},
+	)
+
+	MemoryEvictions = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "memory_evictions_total",
+			Help: "Long-term memories evicted to stay under the memory limit",
+		},
+	)
+
+	MemoryExpiredCleanups = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "memory_expired_cleanups_total",
+			Help: "Expired long-term memories removed by cleanup",
+		},
+	)
+
+	MemorySearchDuration = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "memory_search_duration_seconds",
+			Help:    "Duration of long-term memory searches in seconds",
+			Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+		},
+	)
+
+	// MemoriesStored lee el número de memorias en cada scrape (ver TrackMemories)
+	MemoriesStored = prometheus.NewGaugeFunc(
+		prometheus.GaugeOpts{
+			Name: "memories_stored",
+			Help: "Long-term memories held in memory, including expired ones not yet cleaned up",
+		},
+		func() float64 {
+			if count, ok := memoriesStored.Load().(func() int); ok {
+				return float64(count())
+			}
+			return 0
+		},
+	)
+
+	sessionsActiveDesc = prometheus.NewDesc(
+		"sessions_active",
+		"Conversation sessions in the session store, by bot",
+		[]string{"bot_id"}, nil,
+	)
+
+	sessionContextSizeDesc = prometheus.NewDesc(
+		"session_context_size_bytes_avg",
+		"Average size of the session context serialized as JSON, in bytes",
+		nil, nil,
+	)
+)
+
+var (
+	taskQueueDepth atomic.Value
+	memoriesStored atomic.Value
+	sessionStore   atomic.Value
+)
+
+// SessionStoreStats es lo que TrackSessionStore lee en cada scrape
+type SessionStoreStats struct {
+	SessionsByBot      map[string]int
+	AverageContextSize float64
+}
+
+// sessionStoreCollector expone sessions_active por bot, que un GaugeFunc no
+// puede etiquetar, a partir de una sola lectura del almacén
+type sessionStoreCollector struct{}
+
+func (sessionStoreCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- sessionsActiveDesc
+	ch <- sessionContextSizeDesc
+}
+
+func (sessionStoreCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, ok := sessionStore.Load().(func() SessionStoreStats)
+	if !ok {
+		return
+	}
+	current := stats()
+	for botID, count := range current.SessionsByBot {
+		ch <- prometheus.MustNewConstMetric(sessionsActiveDesc, prometheus.GaugeValue, float64(count), botID)
+	}
+	ch <- prometheus.MustNewConstMetric(sessionContextSizeDesc, prometheus.GaugeValue, current.AverageContextSize)
+}
+
+func init() {
+	Registry.MustRegister(
+		HTTPRequests,
+		HTTPRequestDuration,
+		MCPTasks,
+		MCPTaskDuration,
+		MCPQueueWait,
+		MCPQueueRejections,
+		MCPCircuitState,
+		MCPCircuitTransitions,
+		AITokens,
+		AIToolCalls,
+		ResponseCacheLookups,
+		StepDuration,
+		ChaosFaults,
+		BackpressureRejections,
+		TaskQueueDepth,
+		TaskWorkers,
+		TaskWorkerScaleEvents,
+		MemoryEvictions,
+		MemoryExpiredCleanups,
+		MemorySearchDuration,
+		MemoriesStored,
+		sessionStoreCollector{},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// Handler sirve las métricas de Registry en formato Prometheus
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
+
+// TrackTaskQueue fija la función que da la profundidad de la cola de tareas
+func TrackTaskQueue(depth func() int) {
+	taskQueueDepth.Store(depth)
+}
+
+// Direcciones de task_worker_scale_events_total
+const (
+	ScaleUp   = "up"
+	ScaleDown = "down"
+)
+
+// SetTaskWorkers fija el tamaño del pool de workers de tareas
+func SetTaskWorkers(workers int) {
+	TaskWorkers.Set(float64(workers))
+}
+
+// RecordTaskWorkerScale cuenta un cambio de tamaño del pool de workers
+func RecordTaskWorkerScale(direction string, workers int) {
+	TaskWorkerScaleEvents.WithLabelValues(direction).Inc()
+	TaskWorkers.Set(float64(workers))
+}
+
+// TrackMemories fija la función que cuenta las memorias almacenadas
+func TrackMemories(count func() int) {
+	memoriesStored.Store(count)
+}
+
+// TrackSessionStore fija la función que resume el almacén de sesiones
+func TrackSessionStore(stats func() SessionStoreStats) {
+	sessionStore.Store(stats)
+}
+
+// ObserveMCPTask registra una ejecución de tarea MCP
+func ObserveMCPTask(agentType string, duration time.Duration, success bool) {
+	status := StatusSuccess
+	if !success {
+		status = StatusFailure
+	}
+	MCPTasks.WithLabelValues(agentType, status).Inc()
+	MCPTaskDuration.WithLabelValues(agentType).Observe(duration.Seconds())
+}
+
+// circuitStateValues traduce el estado del circuito al valor de mcp_agent_circuit_state
+var circuitStateValues = map[string]float64{"closed": 0, "half_open": 1, "open": 2}
+
+// SetMCPCircuitState registra que el circuito de un agente ha pasado a state
+func SetMCPCircuitState(agentType, agentID, state string) {
+	MCPCircuitState.WithLabelValues(agentType, agentID).Set(circuitStateValues[state])
+	MCPCircuitTransitions.WithLabelValues(agentType, state).Inc()
+}
+
+// DeleteMCPCircuitState quita el estado del circuito de un agente terminado
+func DeleteMCPCircuitState(agentType, agentID string) {
+	MCPCircuitState.DeleteLabelValues(agentType, agentID)
+}
+
+// RecordAITokens suma el consumo de tokens de una llamada a un proveedor de IA
+func RecordAITokens(provider, model string, promptTokens, completionTokens int) {
+	if promptTokens > 0 {
+		AITokens.WithLabelValues(provider, model, "prompt").Add(float64(promptTokens))
+	}
+	if completionTokens > 0 {
+		AITokens.WithLabelValues(provider, model, "completion").Add(float64(completionTokens))
+	}
+}
+
+// RecordAIToolCall cuenta una llamada a herramienta pedida por un modelo
+func RecordAIToolCall(tool string, success bool) {
+	status := "success"
+	if !success {
+		status = "error"
+	}
+	AIToolCalls.WithLabelValues(tool, status).Inc()
+}
+
+// RecordResponseCache cuenta una consulta a la caché de respuestas
+func RecordResponseCache(kind string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	ResponseCacheLookups.WithLabelValues(kind, result).Inc()
+}
+
+// MCPTaskSummary son los totales de tareas MCP de todos los tipos de agente
+type MCPTaskSummary struct {
+	Total           int64
+	Completed       int64
+	Failed          int64
+	AverageDuration time.Duration
+}
+
+// MCPTasksSummary calcula los totales a partir de mcp_tasks_total y
+// mcp_task_duration_seconds, para que la API de métricas MCP y /metrics coincidan
+func MCPTasksSummary() MCPTaskSummary {
+	var summary MCPTaskSummary
+	families, err := Registry.Gather()
+	if err != nil {
+		return summary
+	}
+
+	var durationSum float64
+	var durationCount uint64
+	for _, family := range families {
+		switch family.GetName() {
+		case "mcp_tasks_total":
+			for _, metric := range family.GetMetric() {
+				count := int64(metric.GetCounter().GetValue())
+				summary.Total += count
+				for _, label := range metric.GetLabel() {
+					if label.GetName() != "status" {
+						continue
+					}
+					if label.GetValue() == StatusSuccess {
+						summary.Completed += count
+					} else {
+						summary.Failed += count
+					}
+				}
+			}
+		case "mcp_task_duration_seconds":
+			for _, metric := range family.GetMetric() {
+				durationSum += metric.GetHistogram().GetSampleSum()
+				durationCount += metric.GetHistogram().GetSampleCount()
+			}
+		}
+	}
+	if durationCount > 0 {
+		summary.AverageDuration = time.Duration(durationSum / float64(durationCount) * float64(time.Second))
+	}
+	return summary
+}
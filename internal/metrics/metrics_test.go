@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMCPTasksSummaryAndHandler(t *testing.T) {
+	// Los colectores son globales: se comparan incrementos, no valores absolutos,
+	// para que el test se pueda repetir (go test -count=2)
+	before := MCPTasksSummary()
+	failuresBefore := testutil.ToFloat64(MCPTasks.WithLabelValues("test-agent", StatusFailure))
+	completionBefore := testutil.ToFloat64(AITokens.WithLabelValues("openai", "test-model", "completion"))
+	evictionsBefore := testutil.ToFloat64(MemoryEvictions)
+
+	ObserveMCPTask("test-agent", 200*time.Millisecond, true)
+	ObserveMCPTask("test-agent", 400*time.Millisecond, false)
+	RecordAITokens("openai", "test-model", 12, 30)
+	TrackTaskQueue(func() int { return 3 })
+	TrackMemories(func() int { return 7 })
+	TrackSessionStore(func() SessionStoreStats {
+		return SessionStoreStats{SessionsByBot: map[string]int{"bot-1": 2}, AverageContextSize: 64}
+	})
+	MemoryEvictions.Inc()
+
+	summary := MCPTasksSummary()
+	assert.Equal(t, before.Total+2, summary.Total)
+	assert.Equal(t, before.Completed+1, summary.Completed)
+	assert.Equal(t, before.Failed+1, summary.Failed)
+	assert.Greater(t, summary.AverageDuration, time.Duration(0))
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	assert.Equal(t, failuresBefore+1, testutil.ToFloat64(MCPTasks.WithLabelValues("test-agent", StatusFailure)))
+	assert.Equal(t, completionBefore+30, testutil.ToFloat64(AITokens.WithLabelValues("openai", "test-model", "completion")))
+	assert.Equal(t, 3.0, testutil.ToFloat64(TaskQueueDepth))
+	assert.Contains(t, body, `mcp_tasks_total{agent_type="test-agent",status="failure"}`)
+	assert.Contains(t, body, `ai_tokens_total{kind="completion",model="test-model",provider="openai"}`)
+	assert.Contains(t, body, "task_queue_depth 3")
+	assert.Contains(t, body, "mcp_task_duration_seconds_bucket")
+	assert.Contains(t, body, "memories_stored 7")
+	assert.Contains(t, body, `sessions_active{bot_id="bot-1"} 2`)
+	assert.Contains(t, body, "memory_evictions_total")
+
+	assert.Equal(t, 7.0, testutil.ToFloat64(MemoriesStored))
+	assert.Equal(t, evictionsBefore+1, testutil.ToFloat64(MemoryEvictions))
+	expected := `
+# HELP session_context_size_bytes_avg Average size of the session context serialized as JSON, in bytes
+# TYPE session_context_size_bytes_avg gauge
+session_context_size_bytes_avg 64
+# HELP sessions_active Conversation sessions in the session store, by bot
+# TYPE sessions_active gauge
+sessions_active{bot_id="bot-1"} 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(sessionStoreCollector{}, strings.NewReader(expected)))
+}
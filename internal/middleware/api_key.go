@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader es la cabecera con la que los servicios envían su API key
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyOptions configura APIKeyAuth
+type APIKeyOptions struct {
+	// Required son prefijos de ruta que no aceptan peticiones sin API key
+	Required []string
+	// AllowBearer deja pasar sin API key a las rutas de Required que traen un
+	// JWT; sólo tiene sentido si Authorize lo valida después
+	AllowBearer bool
+}
+
+// APIKeyAuth autentica las peticiones que traen X-API-Key: la clave debe estar
+// vigente, tener el scope de la ruta y no superar su límite por minuto. Va antes
+// de Authorize, que no pide JWT a las peticiones ya autenticadas con una clave.
+func APIKeyAuth(keys services.APIKeyService, options APIKeyOptions, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader(APIKeyHeader)
+		if rawKey == "" {
+			if requiresAPIKey(c, options) {
+				abortWithError(c, http.StatusUnauthorized, domain.CodeUnauthorized, "An API key is required for this endpoint")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		key, err := keys.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			abortWithError(c, http.StatusUnauthorized, domain.CodeInvalidAPIKey, "Invalid, revoked or expired API key")
+			return
+		}
+
+		scope := apiKeyScope(c)
+		if !services.APIKeyHasScope(key, scope) {
+			log.WithContext(c.Request.Context()).Info("API key denied",
+				"api_key_id", key.ID,
+				"method", c.Request.Method,
+				"path", c.Request.URL.Path,
+				"scope", scope)
+			abortWithError(c, http.StatusForbidden, domain.CodeInsufficientPermissions, "API key is missing scope "+scope)
+			return
+		}
+
+		if ok, retryAfter := keys.Allow(key); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			abortWithError(c, http.StatusTooManyRequests, domain.CodeRateLimited, "API key rate limit exceeded")
+			return
+		}
+
+		c.Set("api_key_id", key.ID)
+		c.Set("api_key_scopes", key.Scopes)
+		c.Set("user_id", "api_key:"+key.ID)
+		c.Next()
+	}
+}
+
+func requiresAPIKey(c *gin.Context, options APIKeyOptions) bool {
+	if options.AllowBearer && strings.HasPrefix(c.GetHeader("Authorization"), "Bearer ") {
+		return false
+	}
+	for _, prefix := range options.Required {
+		if strings.HasPrefix(c.Request.URL.Path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// apiKeyScope deriva el scope que exige la ruta: el primer segmento tras
+// /api/v1 con :read para GET y HEAD y :write para el resto
+// (POST /api/v1/incoming -> incoming:write, GET /api/v1/bots/:id -> bots:read)
+func apiKeyScope(c *gin.Context) string {
+	path := c.FullPath()
+	if path == "" {
+		path = c.Request.URL.Path
+	}
+	path = strings.TrimPrefix(path, "/api/v1")
+	resource, _, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+
+	access := "write"
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		access = "read"
+	}
+	return resource + ":" + access
+}
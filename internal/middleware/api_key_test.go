@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIKeyAuth_ScopesAndRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+	keys := services.NewAPIKeyService(repositories.NewMockAPIKeyRepository(), 100, log)
+	key, rawKey, err := keys.Issue(context.Background(), services.IssueAPIKeyRequest{
+		Name:      "messaging-service",
+		Scopes:    []string{"incoming:write"},
+		RateLimit: 2,
+	}, "admin")
+	require.NoError(t, err)
+	assert.Empty(t, key.KeyHash)
+	assert.Equal(t, rawKey[:len(key.Prefix)], key.Prefix)
+
+	router := gin.New()
+	router.Use(APIKeyAuth(keys, APIKeyOptions{Required: []string{"/api/v1/incoming"}}, log))
+	ok := func(c *gin.Context) { c.String(http.StatusOK, c.GetString("user_id")) }
+	router.POST("/api/v1/incoming", ok)
+	router.GET("/api/v1/bots/:id", ok)
+
+	request := func(method, path, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if apiKey != "" {
+			req.Header.Set(APIKeyHeader, apiKey)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request("POST", "/api/v1/incoming", "").Code)
+	assert.Equal(t, http.StatusOK, request("GET", "/api/v1/bots/b1", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, request("POST", "/api/v1/incoming", "itb_unknown").Code)
+
+	w := request("POST", "/api/v1/incoming", rawKey)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "api_key:"+key.ID, w.Body.String())
+	assert.Equal(t, http.StatusForbidden, request("GET", "/api/v1/bots/b1", rawKey).Code)
+
+	// El 403 por scope no consume el límite; la tercera llamada sí lo supera
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/incoming", rawKey).Code)
+	w = request("POST", "/api/v1/incoming", rawKey)
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	_, err = keys.Revoke(context.Background(), key.ID)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, request("POST", "/api/v1/incoming", rawKey).Code)
+}
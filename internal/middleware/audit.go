@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+type auditRoute struct {
+	resource string
+	action   string
+	// idParam es el parámetro de la ruta con el ID del recurso; en las altas
+	// el ID se toma de la respuesta
+	idParam string
+}
+
+// auditedRoutes son las operaciones que modifican bots, flujos, pasos,
+// triggers, agentes MCP, webhooks, workflows guardados y aprobaciones de
+// scripts, indexadas por método y ruta de Gin
+var auditedRoutes = map[string]auditRoute{
+	"POST /api/v1/bots":                                    {domain.AuditResourceBot, domain.AuditActionCreate, ""},
+	"POST /api/v1/bots/import":                             {domain.AuditResourceBot, domain.AuditActionCreate, ""},
+	"POST /api/v1/bots/starter-kit":                        {domain.AuditResourceBot, domain.AuditActionCreate, ""},
+	"PATCH /api/v1/bots/:id":                               {domain.AuditResourceBot, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/bots/:id":                              {domain.AuditResourceBot, domain.AuditActionDelete, "id"},
+	"PUT /api/v1/bots/:id/variables":                       {domain.AuditResourceBot, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/bots/:id/variables/:key":               {domain.AuditResourceBot, domain.AuditActionUpdate, "id"},
+	"POST /api/v1/bots/:id/flows":                          {domain.AuditResourceFlow, domain.AuditActionCreate, ""},
+	"PATCH /api/v1/flows/:id":                              {domain.AuditResourceFlow, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/flows/:id":                             {domain.AuditResourceFlow, domain.AuditActionDelete, "id"},
+	"POST /api/v1/flows/:id/steps":                         {domain.AuditResourceStep, domain.AuditActionCreate, ""},
+	"PATCH /api/v1/steps/:id":                              {domain.AuditResourceStep, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/steps/:id":                             {domain.AuditResourceStep, domain.AuditActionDelete, "id"},
+	"POST /api/v1/triggers":                                {domain.AuditResourceTrigger, domain.AuditActionCreate, ""},
+	"PUT /api/v1/triggers/:id":                             {domain.AuditResourceTrigger, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/triggers/:id":                          {domain.AuditResourceTrigger, domain.AuditActionDelete, "id"},
+	"POST /api/v1/mcp/agents":                              {domain.AuditResourceMCPAgent, domain.AuditActionCreate, ""},
+	"PATCH /api/v1/mcp/agents/:id/config":                  {domain.AuditResourceMCPAgent, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/mcp/agents/:id":                        {domain.AuditResourceMCPAgent, domain.AuditActionDelete, "id"},
+	"POST /api/v1/webhooks":                                {domain.AuditResourceWebhook, domain.AuditActionCreate, ""},
+	"PUT /api/v1/webhooks/:id":                             {domain.AuditResourceWebhook, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/webhooks/:id":                          {domain.AuditResourceWebhook, domain.AuditActionDelete, "id"},
+	"POST /api/v1/workflows":                               {domain.AuditResourceWorkflow, domain.AuditActionCreate, ""},
+	"PUT /api/v1/workflows/:id":                            {domain.AuditResourceWorkflow, domain.AuditActionUpdate, "id"},
+	"DELETE /api/v1/workflows/:id":                         {domain.AuditResourceWorkflow, domain.AuditActionDelete, "id"},
+	"POST /api/v1/workflows/:id/versions/:version/restore": {domain.AuditResourceWorkflow, domain.AuditActionUpdate, "id"},
+	"POST /api/v1/mcp/scripts/approvals":                   {domain.AuditResourceScriptApproval, domain.AuditActionCreate, ""},
+	"DELETE /api/v1/mcp/scripts/approvals/:hash":           {domain.AuditResourceScriptApproval, domain.AuditActionDelete, "hash"},
+}
+
+// auditWriter conserva una copia de la respuesta para leer el ID de los
+// recursos creados
+type auditWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *auditWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *auditWriter) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
+
+// Audit registra las altas, cambios y bajas exitosas de los recursos auditados
+// con el usuario, la IP y el diff entre el estado anterior y el posterior.
+// Va después de Authorize para conocer al usuario.
+func Audit(audits services.AuditService, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route, ok := auditedRoutes[c.Request.Method+" "+c.FullPath()]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		resourceID := ""
+		var before interface{}
+		if route.idParam != "" {
+			resourceID = c.Param(route.idParam)
+			before = audits.Snapshot(ctx, route.resource, resourceID)
+		}
+
+		writer := &auditWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if status := writer.Status(); status < http.StatusOK || status >= http.StatusMultipleChoices {
+			return
+		}
+		if resourceID == "" {
+			resourceID = createdResourceID(writer.body.Bytes())
+		}
+		var after interface{}
+		if route.action != domain.AuditActionDelete {
+			after = audits.Snapshot(ctx, route.resource, resourceID)
+		}
+
+		entry := &domain.AuditLog{
+			UserID:     c.GetString("user_id"),
+			Action:     route.action,
+			Resource:   route.resource,
+			ResourceID: resourceID,
+			Details: map[string]interface{}{
+				"method": c.Request.Method,
+				"path":   c.Request.URL.Path,
+				"status": writer.Status(),
+			},
+			IPAddress: c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+		}
+		if email := c.GetString("user_email"); email != "" {
+			entry.Details["user_email"] = email
+		}
+		if err := audits.Record(ctx, entry, before, after); err != nil {
+			log.WithContext(ctx).Error("Failed to record audit log",
+				"resource", route.resource,
+				"resource_id", resourceID,
+				"error", err)
+		}
+	}
+}
+
+// createdResourceID lee el ID del recurso de la respuesta de un alta:
+// data.id, data.agent_id (agentes MCP), data.script_sha256 (scripts aprobados)
+// o data.bot.id (importación de bots)
+func createdResourceID(body []byte) string {
+	var response struct {
+		Data struct {
+			ID      string `json:"id"`
+			AgentID string `json:"agent_id"`
+			Script  string `json:"script_sha256"`
+			Bot     struct {
+				ID string `json:"id"`
+			} `json:"bot"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return ""
+	}
+	switch {
+	case response.Data.ID != "":
+		return response.Data.ID
+	case response.Data.AgentID != "":
+		return response.Data.AgentID
+	case response.Data.Script != "":
+		return response.Data.Script
+	default:
+		return response.Data.Bot.ID
+	}
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAudit_RecordsChangesWithDiff(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	botRepo := repositories.NewMockBotRepository()
+	audits := services.NewAuditService(repositories.NewMockAuditRepository(), logger.NewLogger("error"))
+	audits.RegisterSnapshot(domain.AuditResourceBot, func(ctx context.Context, id string) (interface{}, error) {
+		return botRepo.GetByID(ctx, id)
+	})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("user_id", "user-1") })
+	router.Use(Audit(audits, logger.NewLogger("error")))
+	router.POST("/api/v1/bots", func(c *gin.Context) {
+		bot := &domain.Bot{ID: "bot-1", Name: "Soporte", Status: domain.BotStatusActive}
+		require.NoError(t, botRepo.Create(c.Request.Context(), bot))
+		c.JSON(http.StatusCreated, domain.APIResponse{Code: domain.CodeSuccess, Data: bot})
+	})
+	router.PATCH("/api/v1/bots/:id", func(c *gin.Context) {
+		bot, err := botRepo.GetByID(c.Request.Context(), c.Param("id"))
+		if err != nil {
+			c.JSON(http.StatusNotFound, domain.APIResponse{Code: domain.CodeBotNotFound})
+			return
+		}
+		bot.Name = "Ventas"
+		require.NoError(t, botRepo.Update(c.Request.Context(), bot))
+		c.JSON(http.StatusOK, domain.APIResponse{Code: domain.CodeSuccess, Data: bot})
+	})
+	router.DELETE("/api/v1/bots/:id", func(c *gin.Context) {
+		require.NoError(t, botRepo.Delete(c.Request.Context(), c.Param("id")))
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(method, path, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	require.Equal(t, http.StatusCreated, request("POST", "/api/v1/bots", `{"name":"Soporte"}`))
+	require.Equal(t, http.StatusOK, request("PATCH", "/api/v1/bots/bot-1", `{"name":"Ventas"}`))
+	require.Equal(t, http.StatusNotFound, request("PATCH", "/api/v1/bots/missing", `{}`))
+	require.Equal(t, http.StatusNoContent, request("DELETE", "/api/v1/bots/bot-1", ""))
+
+	logs, err := audits.List(context.Background(), nil)
+	require.NoError(t, err)
+	require.Len(t, logs, 3)
+
+	actions := map[string]*domain.AuditLog{}
+	for _, log := range logs {
+		assert.Equal(t, "user-1", log.UserID)
+		assert.Equal(t, domain.AuditResourceBot, log.Resource)
+		assert.Equal(t, "bot-1", log.ResourceID)
+		actions[log.Action] = log
+	}
+	changes := actions[domain.AuditActionUpdate].Details["changes"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"from": "Soporte", "to": "Ventas"}, changes["name"])
+	assert.NotContains(t, changes, "status")
+	assert.Contains(t, actions[domain.AuditActionCreate].Details["changes"], "name")
+	deleted := actions[domain.AuditActionDelete].Details["changes"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"from": "Ventas", "to": nil}, deleted["name"])
+
+	action := domain.AuditActionUpdate
+	updates, err := audits.List(context.Background(), &services.AuditFilters{Action: &action})
+	require.NoError(t, err)
+	assert.Len(t, updates, 1)
+}
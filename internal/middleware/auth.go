@@ -4,62 +4,52 @@ import (
 	"net/http"
 
 	"github.com/company/bot-service/internal/auth"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/i18n"
 	"github.com/gin-gonic/gin"
 )
 
 func JWTAuth(jwtManager *auth.JWTManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenString, err := jwtManager.ExtractTokenFromHeader(c)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    "UNAUTHORIZED",
-				"message": err.Error(),
-				"data":    nil,
-			})
-			c.Abort()
-			return
+		if authenticate(c, jwtManager) {
+			c.Next()
 		}
+	}
+}
 
-		claims, err := jwtManager.ValidateToken(tokenString)
-		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"code":    "INVALID_TOKEN",
-				"message": "Invalid or expired token",
-				"data":    nil,
-			})
-			c.Abort()
-			return
-		}
+// authenticate valida el token y guarda sus claims en el contexto; si falla
+// corta la petición y devuelve false
+func authenticate(c *gin.Context, jwtManager *auth.JWTManager) bool {
+	tokenString, err := jwtManager.ExtractTokenFromHeader(c)
+	if err != nil {
+		abortWithError(c, http.StatusUnauthorized, domain.CodeUnauthorized, err.Error())
+		return false
+	}
 
-		// Agregar claims al contexto
-		c.Set("user_id", claims.UserID)
-		c.Set("user_email", claims.Email)
-		c.Set("user_roles", claims.Roles)
-		c.Next()
+	claims, err := jwtManager.ValidateToken(tokenString)
+	if err != nil {
+		abortWithError(c, http.StatusUnauthorized, domain.CodeInvalidToken, "Invalid or expired token")
+		return false
 	}
+
+	// Agregar claims al contexto
+	c.Set("user_id", claims.UserID)
+	c.Set("user_email", claims.Email)
+	c.Set("user_roles", claims.Roles)
+	return true
 }
 
 func RequireRole(requiredRole string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		roles, exists := c.Get("user_roles")
 		if !exists {
-			c.JSON(http.StatusForbidden, gin.H{
-				"code":    "FORBIDDEN",
-				"message": "User roles not found",
-				"data":    nil,
-			})
-			c.Abort()
+			abortWithError(c, http.StatusForbidden, domain.CodeForbidden, "User roles not found")
 			return
 		}
 
 		userRoles, ok := roles.([]string)
 		if !ok {
-			c.JSON(http.StatusForbidden, gin.H{
-				"code":    "FORBIDDEN",
-				"message": "Invalid user roles format",
-				"data":    nil,
-			})
-			c.Abort()
+			abortWithError(c, http.StatusForbidden, domain.CodeForbidden, "Invalid user roles format")
 			return
 		}
 
@@ -72,12 +62,7 @@ func RequireRole(requiredRole string) gin.HandlerFunc {
 		}
 
 		if !hasRole {
-			c.JSON(http.StatusForbidden, gin.H{
-				"code":    "INSUFFICIENT_PERMISSIONS",
-				"message": "Insufficient permissions for this resource",
-				"data":    nil,
-			})
-			c.Abort()
+			abortWithError(c, http.StatusForbidden, domain.CodeInsufficientPermissions, "Insufficient permissions for this resource")
 			return
 		}
 
@@ -89,14 +74,19 @@ func SwaggerAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Solo permitir Swagger en desarrollo
 		if gin.Mode() == gin.ReleaseMode {
-			c.JSON(http.StatusNotFound, gin.H{
-				"code":    "NOT_FOUND",
-				"message": "Resource not found",
-				"data":    nil,
-			})
-			c.Abort()
+			abortWithError(c, http.StatusNotFound, domain.CodeNotFound, "Resource not found")
 			return
 		}
 		c.Next()
 	}
-}
\ No newline at end of file
+}
+
+// abortWithError corta la petición con una APIResponse de error en el idioma
+// de Accept-Language
+func abortWithError(c *gin.Context, status int, code, message string) {
+	response := domain.APIResponse{Code: code, Message: message}
+	lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+	i18n.Localize(&response, lang)
+	c.Header("Content-Language", lang)
+	c.AbortWithStatusJSON(status, response)
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/i18n"
+	"github.com/company/bot-service/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// Backpressure rechaza con 503 y Retry-After los mensajes de las rutas con esos
+// prefijos cuando la carga lo exige. El bot se lee del cuerpo (bot_id) sin
+// consumirlo, para que el handler lo vuelva a leer.
+func Backpressure(guard *services.Backpressure, prefixes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost || !hasAnyPrefix(c.Request.URL.Path, prefixes) {
+			c.Next()
+			return
+		}
+
+		rejection := guard.Admit(c.Request.Context(), peekBotID(c))
+		if rejection == nil {
+			c.Next()
+			return
+		}
+
+		response := domain.APIResponse{
+			Code:    domain.CodeOverloaded,
+			Message: "Service overloaded, retry later",
+			Data:    rejection,
+		}
+		lang := i18n.Negotiate(c.GetHeader("Accept-Language"))
+		i18n.Localize(&response, lang)
+		c.Header("Content-Language", lang)
+		c.Header("Retry-After", strconv.Itoa(rejection.RetryAfterSeconds))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, response)
+	}
+}
+
+func peekBotID(c *gin.Context) string {
+	if c.Request.Body == nil {
+		return ""
+	}
+	body, err := io.ReadAll(c.Request.Body)
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+
+	var message struct {
+		BotID string `json:"bot_id"`
+	}
+	_ = json.Unmarshal(body, &message)
+	return message.BotID
+}
+
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
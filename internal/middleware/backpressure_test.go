@@ -0,0 +1,108 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeAILoad struct {
+	inFlight    int
+	rateLimited bool
+}
+
+func (f *fakeAILoad) InFlight() int                        { return f.inFlight }
+func (f *fakeAILoad) RateLimitedWithin(time.Duration) bool { return f.rateLimited }
+
+func TestBackpressure_ShedsLowPriorityBotsFirst(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+	bus := events.NewInMemoryEventBus(log)
+	levels := make(chan string, 10)
+	require.NoError(t, bus.Subscribe(events.EventTypeBackpressure, func(ctx context.Context, event events.Event) error {
+		levels <- event.Data["level"].(string)
+		return nil
+	}))
+
+	depth := 0
+	ai := &fakeAILoad{}
+	guard := services.NewBackpressure(services.BackpressureConfig{
+		RejectThreshold:      0.9,
+		MaxAIInFlight:        10,
+		AIRateLimitCooldown:  time.Minute,
+		ShedLowPriority:      true,
+		LowPriorityThreshold: 0.5,
+		PriorityBots:         []string{"premium"},
+		RetryAfter:           3 * time.Second,
+	}, func() (int, int) { return depth, 100 }, ai, bus, log)
+
+	router := gin.New()
+	router.Use(Backpressure(guard, "/api/v1/incoming"))
+	router.POST("/api/v1/incoming", func(c *gin.Context) {
+		var message domain.IncomingMessage
+		require.NoError(t, c.ShouldBindJSON(&message))
+		c.JSON(http.StatusOK, domain.APIResponse{Code: domain.CodeSuccess, Data: message.BotID})
+	})
+	send := func(botID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/incoming", strings.NewReader(`{"bot_id":"`+botID+`","content":"hola"}`))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.Equal(t, http.StatusOK, send("basic").Code)
+
+	// Cola al 60 %: sólo se rechazan los bots de baja prioridad
+	depth = 60
+	w := send("basic")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "3", w.Header().Get("Retry-After"))
+	var response struct {
+		Code string                         `json:"code"`
+		Data services.BackpressureRejection `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, domain.CodeOverloaded, response.Code)
+	assert.Equal(t, services.BackpressureShedding, response.Data.Level)
+	assert.Equal(t, services.BackpressureReasonTaskQueue, response.Data.Reason)
+	assert.True(t, response.Data.LowPriority)
+	assert.Equal(t, 3, response.Data.RetryAfterSeconds)
+
+	premium := send("premium")
+	assert.Equal(t, http.StatusOK, premium.Code)
+	assert.Contains(t, premium.Body.String(), `"data":"premium"`)
+
+	// Proveedor de IA limitado: se rechaza todo
+	ai.rateLimited = true
+	w = send("premium")
+	require.Equal(t, http.StatusServiceUnavailable, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, services.BackpressureSaturated, response.Data.Level)
+	assert.Equal(t, services.BackpressureReasonAIRateLimited, response.Data.Reason)
+
+	depth, ai.rateLimited = 0, false
+	assert.Equal(t, http.StatusOK, send("basic").Code)
+
+	var published []string
+	for len(published) < 3 {
+		select {
+		case level := <-levels:
+			published = append(published, level)
+		case <-time.After(time.Second):
+			t.Fatalf("expected 3 backpressure events, got %v", published)
+		}
+	}
+	assert.ElementsMatch(t, []string{services.BackpressureShedding, services.BackpressureSaturated, services.BackpressureNormal}, published)
+}
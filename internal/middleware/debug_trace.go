@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"strconv"
+
+	"github.com/company/bot-service/internal/auth"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+// DebugTraceHeader pide en la respuesta la cadena de componentes que atendió
+// la petición (X-Debug-Trace: true)
+const DebugTraceHeader = "X-Debug-Trace"
+
+// DebugTrace activa el registro de la cadena de ejecución cuando el llamante lo
+// pide con DebugTraceHeader y puede verlo: un JWT con el permiso debug:trace o
+// una API key con ese scope. Con policy nil (sin autenticación) basta la
+// cabecera. Va después de APIKeyAuth y Authorize.
+func DebugTrace(policy *auth.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if requested, _ := strconv.ParseBool(c.GetHeader(DebugTraceHeader)); requested && debugTraceAllowed(c, policy) {
+			ctx, _ := tracing.WithChain(c.Request.Context())
+			c.Request = c.Request.WithContext(ctx)
+		}
+		c.Next()
+	}
+}
+
+func debugTraceAllowed(c *gin.Context, policy *auth.Policy) bool {
+	if scopes, ok := c.Get("api_key_scopes"); ok {
+		granted, _ := scopes.([]string)
+		return services.APIKeyHasScope(&domain.APIKey{Scopes: granted}, auth.PermissionDebugTrace)
+	}
+	if policy == nil {
+		return true
+	}
+	return allowed(c, policy, auth.PermissionDebugTrace)
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/internal/auth"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/tracing"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugTrace_OnlyForAuthorizedCallers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+	jwtManager := auth.NewJWTManager("secret", "bot-service")
+	policy, err := auth.LoadPolicy("")
+	require.NoError(t, err)
+	keys := services.NewAPIKeyService(repositories.NewMockAPIKeyRepository(), 100, log)
+	_, debugKey, err := keys.Issue(context.Background(), services.IssueAPIKeyRequest{
+		Name: "messaging-service", Scopes: []string{"incoming:write", "debug:trace"}}, "admin")
+	require.NoError(t, err)
+	_, plainKey, err := keys.Issue(context.Background(), services.IssueAPIKeyRequest{
+		Name: "partner", Scopes: []string{"incoming:write"}}, "admin")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(APIKeyAuth(keys, APIKeyOptions{AllowBearer: true}, log))
+	router.Use(Authorize(jwtManager, policy, log))
+	router.Use(DebugTrace(policy))
+	router.POST("/api/v1/incoming", func(c *gin.Context) {
+		tracing.Record(c.Request.Context(), tracing.ComponentStep, "welcome", "message")
+		if chain := tracing.ChainFromContext(c.Request.Context()); chain != nil {
+			c.JSON(http.StatusOK, chain.Components())
+			return
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	request := func(debug bool, apiKey string, roles ...string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/v1/incoming", nil)
+		if debug {
+			req.Header.Set(DebugTraceHeader, "true")
+		}
+		if apiKey != "" {
+			req.Header.Set(APIKeyHeader, apiKey)
+		}
+		if roles != nil {
+			token, err := jwtManager.GenerateToken("user-1", "user@example.com", roles)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := request(true, "", auth.RoleOperator)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[{"kind":"step","id":"welcome","type":"message"}]`, w.Body.String())
+	assert.Equal(t, http.StatusOK, request(true, debugKey).Code)
+
+	// Sin la cabecera, o sin permiso, la petición sigue sin traza
+	assert.Equal(t, http.StatusNoContent, request(false, "", auth.RoleOperator).Code)
+	assert.Equal(t, http.StatusNoContent, request(true, "", auth.RoleEditor).Code)
+	assert.Equal(t, http.StatusNoContent, request(true, plainKey).Code)
+
+	// Sin autenticación basta la cabecera
+	open := gin.New()
+	open.Use(DebugTrace(nil))
+	open.GET("/", func(c *gin.Context) {
+		assert.NotNil(t, tracing.ChainFromContext(c.Request.Context()))
+		c.Status(http.StatusOK)
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(DebugTraceHeader, "1")
+	open.ServeHTTP(httptest.NewRecorder(), req)
+}
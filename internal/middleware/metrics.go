@@ -4,27 +4,8 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/company/bot-service/internal/metrics"
 	"github.com/gin-gonic/gin"
-	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promauto"
-)
-
-var (
-	httpRequestsTotal = promauto.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total number of HTTP requests",
-		},
-		[]string{"method", "endpoint", "status"},
-	)
-
-	httpRequestDuration = promauto.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name: "http_request_duration_seconds",
-			Help: "Duration of HTTP requests in seconds",
-		},
-		[]string{"method", "endpoint"},
-	)
 )
 
 func Metrics() gin.HandlerFunc {
@@ -36,7 +17,13 @@ func Metrics() gin.HandlerFunc {
 		duration := time.Since(start).Seconds()
 		status := strconv.Itoa(c.Writer.Status())
 		
-		httpRequestsTotal.WithLabelValues(c.Request.Method, c.FullPath(), status).Inc()
-		httpRequestDuration.WithLabelValues(c.Request.Method, c.FullPath()).Observe(duration)
+		// Las rutas sin registrar comparten etiqueta para no disparar la cardinalidad
+		endpoint := c.FullPath()
+		if endpoint == "" {
+			endpoint = "unmatched"
+		}
+		
+		metrics.HTTPRequests.WithLabelValues(c.Request.Method, endpoint, status).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(c.Request.Method, endpoint).Observe(duration)
 	}
 }
\ No newline at end of file
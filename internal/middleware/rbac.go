@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/company/bot-service/internal/auth"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Authorize aplica la política RBAC a todas las rutas: las públicas pasan sin
+// token y el resto exige un JWT válido con un rol que conceda el permiso de la
+// ruta. Una ruta que ninguna regla cubre se deniega. Las peticiones autenticadas
+// con API key no pasan por los roles: los permisos que el scope de la ruta no
+// cubre (mcp:admin, handoff:manage, api_keys:manage...) los exige como scope.
+func Authorize(jwtManager *auth.JWTManager, policy *auth.Policy, log logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.FullPath()
+		if route == "" {
+			// Sin ruta registrada Gin responde 404
+			c.Next()
+			return
+		}
+
+		permission, ok := policy.Permission(c.Request.Method, route)
+		if ok && permission == auth.PermissionPublic {
+			c.Next()
+			return
+		}
+		// El scope de la ruta ya lo validó APIKeyAuth
+		if scopes, isKey := c.Get("api_key_scopes"); isKey {
+			if !ok {
+				log.WithContext(c.Request.Context()).Warn("No RBAC rule for route", "method", c.Request.Method, "route", route)
+				abortWithError(c, http.StatusForbidden, domain.CodeForbidden, "Access to this resource is not configured")
+				return
+			}
+			granted, _ := scopes.([]string)
+			if scope := auth.APIKeyScope(permission); scope != "" && !services.APIKeyHasScope(&domain.APIKey{Scopes: granted}, scope) {
+				log.WithContext(c.Request.Context()).Info("API key denied by RBAC",
+					"api_key_id", c.GetString("api_key_id"),
+					"method", c.Request.Method,
+					"route", route,
+					"scope", scope)
+				abortWithError(c, http.StatusForbidden, domain.CodeInsufficientPermissions, "API key is missing scope "+scope)
+				return
+			}
+			c.Next()
+			return
+		}
+		if !authenticate(c, jwtManager) {
+			return
+		}
+		if !ok {
+			log.WithContext(c.Request.Context()).Warn("No RBAC rule for route", "method", c.Request.Method, "route", route)
+			abortWithError(c, http.StatusForbidden, domain.CodeForbidden, "Access to this resource is not configured")
+			return
+		}
+		if !allowed(c, policy, permission) {
+			log.WithContext(c.Request.Context()).Info("Request denied by RBAC",
+				"user_id", c.GetString("user_id"),
+				"method", c.Request.Method,
+				"route", route,
+				"permission", permission)
+			abortWithError(c, http.StatusForbidden, domain.CodeInsufficientPermissions, "Insufficient permissions for this resource")
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequirePermission exige un permiso concreto de la política, para rutas que
+// lo necesiten además de la regla general. Va después de JWTAuth o Authorize.
+func RequirePermission(policy *auth.Policy, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allowed(c, policy, permission) {
+			abortWithError(c, http.StatusForbidden, domain.CodeInsufficientPermissions, "Insufficient permissions for this resource")
+			return
+		}
+		c.Next()
+	}
+}
+
+func allowed(c *gin.Context, policy *auth.Policy, permission string) bool {
+	roles, _ := c.Get("user_roles")
+	userRoles, _ := roles.([]string)
+	return policy.Allowed(userRoles, permission)
+}
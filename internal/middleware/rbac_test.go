@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/internal/auth"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthorize_DefaultPolicy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := auth.NewJWTManager("secret", "bot-service")
+	policy, err := auth.LoadPolicy("")
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(Authorize(jwtManager, policy, logger.NewLogger("error")))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/api/v1/health", ok)
+	router.GET("/api/v1/bots/:id", ok)
+	router.POST("/api/v1/bots", ok)
+	router.DELETE("/api/v1/bots/:id", ok)
+	router.POST("/api/v1/mcp/agents", ok)
+	router.POST("/api/v1/mcp/tasks", ok)
+	router.POST("/api/v1/conversations/:id/handoff/accept", ok)
+	router.GET("/unlisted", ok)
+
+	request := func(method, path string, roles ...string) int {
+		req := httptest.NewRequest(method, path, nil)
+		if roles != nil {
+			token, err := jwtManager.GenerateToken("user-1", "user@example.com", roles)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusOK, request("GET", "/api/v1/health"))
+	assert.Equal(t, http.StatusUnauthorized, request("GET", "/api/v1/bots/b1"))
+	assert.Equal(t, http.StatusNotFound, request("GET", "/api/v1/missing"))
+
+	assert.Equal(t, http.StatusOK, request("GET", "/api/v1/bots/b1", auth.RoleViewer))
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/bots", auth.RoleViewer))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/bots", auth.RoleEditor))
+	assert.Equal(t, http.StatusForbidden, request("DELETE", "/api/v1/bots/b1", auth.RoleEditor))
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/mcp/agents", auth.RoleEditor, auth.RoleOperator))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/mcp/tasks", auth.RoleOperator))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/conversations/c1/handoff/accept", auth.RoleOperator))
+	assert.Equal(t, http.StatusOK, request("DELETE", "/api/v1/bots/b1", auth.RoleAdmin))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/mcp/agents", auth.RoleAdmin))
+	assert.Equal(t, http.StatusForbidden, request("GET", "/api/v1/bots/b1", "guest"))
+	// Las rutas sin regla se deniegan incluso a admin
+	assert.Equal(t, http.StatusForbidden, request("GET", "/unlisted", auth.RoleAdmin))
+}
+
+func TestParsePolicy_CustomRoles(t *testing.T) {
+	policy, err := auth.ParsePolicy([]byte(`{
+		"roles": {
+			"viewer": {"permissions": ["read"]},
+			"support": {"inherits": ["viewer"], "permissions": ["handoff:manage"]}
+		},
+		"routes": [{"method": "post", "path": "/api/v1/conversations/*", "permission": "handoff:manage"}]
+	}`))
+	require.NoError(t, err)
+
+	permission, ok := policy.Permission("POST", "/api/v1/conversations/:id/handoff")
+	require.True(t, ok)
+	assert.True(t, policy.Allowed([]string{"support"}, permission))
+	assert.True(t, policy.Allowed([]string{"support"}, "read"))
+	assert.False(t, policy.Allowed([]string{"viewer"}, permission))
+
+	_, err = auth.ParsePolicy([]byte(`{"roles": {"a": {"inherits": ["b"]}, "b": {"inherits": ["a"]}}}`))
+	assert.Error(t, err)
+	_, err = auth.ParsePolicy([]byte(`{"roles": {"a": {"inherits": ["missing"]}}}`))
+	assert.Error(t, err)
+}
+
+func TestAuthorize_APIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+	policy, err := auth.LoadPolicy("")
+	require.NoError(t, err)
+	keys := services.NewAPIKeyService(repositories.NewMockAPIKeyRepository(), 100, log)
+	issue := func(scopes ...string) string {
+		_, rawKey, err := keys.Issue(context.Background(), services.IssueAPIKeyRequest{Name: "svc", Scopes: scopes}, "admin")
+		require.NoError(t, err)
+		return rawKey
+	}
+
+	router := gin.New()
+	router.Use(APIKeyAuth(keys, APIKeyOptions{}, log))
+	router.Use(Authorize(auth.NewJWTManager("secret", "bot-service"), policy, log))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/api/v1/mcp/agents", ok)
+	router.PATCH("/api/v1/mcp/agents/:id/config", ok)
+	router.POST("/api/v1/mcp/scripts/approvals", ok)
+	router.POST("/api/v1/mcp/tasks", ok)
+	router.POST("/api/v1/conversations/:id/handoff", ok)
+	router.POST("/api/v1/conversations/:id/summarize", ok)
+	router.POST("/api/v1/api-keys", ok)
+	router.GET("/api/v1/bots/:id", ok)
+	router.GET("/unlisted", ok)
+
+	request := func(method, path, apiKey string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set(APIKeyHeader, apiKey)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// El scope de la ruta no basta para los permisos elevados
+	mcpWrite := issue("mcp:write")
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/mcp/agents", mcpWrite))
+	assert.Equal(t, http.StatusForbidden, request("PATCH", "/api/v1/mcp/agents/a1/config", mcpWrite))
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/mcp/scripts/approvals", mcpWrite))
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/mcp/tasks", mcpWrite))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/mcp/tasks", issue("mcp:write", "mcp:operate")))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/mcp/agents", issue("mcp:write", "mcp:admin")))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/mcp/agents", issue("mcp:*")))
+
+	conversations := issue("conversations:write")
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/conversations/c1/summarize", conversations))
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/conversations/c1/handoff", conversations))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/conversations/c1/handoff", issue("conversations:write", "handoff:manage")))
+
+	assert.Equal(t, http.StatusForbidden, request("POST", "/api/v1/api-keys", issue("api-keys:write")))
+	assert.Equal(t, http.StatusOK, request("POST", "/api/v1/api-keys", issue("api-keys:write", "api_keys:manage")))
+
+	assert.Equal(t, http.StatusOK, request("GET", "/api/v1/bots/b1", issue("bots:read")))
+	assert.Equal(t, http.StatusForbidden, request("GET", "/unlisted", issue("*")))
+}
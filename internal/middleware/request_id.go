@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Cabeceras de correlación. X-Correlation-ID se mantiene entre servicios;
+// si no llega, vale lo mismo que el X-Request-ID de la petición.
+const (
+	RequestIDHeader     = "X-Request-ID"
+	CorrelationIDHeader = "X-Correlation-ID"
+)
+
+// maxRequestIDLength descarta IDs entrantes desmesurados
+const maxRequestIDLength = 128
+
+// RequestID genera o propaga X-Request-ID y X-Correlation-ID, los devuelve en
+// la respuesta y los guarda en el contexto de la petición para que
+// Logger.WithContext los añada a los logs
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := incomingID(c, RequestIDHeader)
+		if requestID == "" {
+			requestID = id.New()
+		}
+		correlationID := incomingID(c, CorrelationIDHeader)
+		if correlationID == "" {
+			correlationID = requestID
+		}
+
+		c.Set(logger.RequestIDKey, requestID)
+		c.Set(logger.CorrelationIDKey, correlationID)
+		c.Header(RequestIDHeader, requestID)
+		c.Header(CorrelationIDHeader, correlationID)
+		c.Request = c.Request.WithContext(logger.ContextWithFields(c.Request.Context(),
+			logger.RequestIDKey, requestID,
+			logger.CorrelationIDKey, correlationID))
+
+		c.Next()
+	}
+}
+
+func incomingID(c *gin.Context, header string) string {
+	id := c.GetHeader(header)
+	if len(id) > maxRequestIDLength {
+		return ""
+	}
+	return id
+}
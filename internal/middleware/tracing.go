@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/company/bot-service/pkg/id"
 )
 
 // Tracing middleware simplificado (sin OpenTelemetry por ahora)
@@ -28,17 +29,7 @@ func Tracing() gin.HandlerFunc {
 	}
 }
 
-// generateTraceID genera un ID de trace simple
+// generateTraceID genera un ID de trace
 func generateTraceID() string {
-	return "trace-" + time.Now().Format("20060102150405") + "-" + randomString(8)
+	return "trace-" + id.New()
 }
-
-// randomString genera una cadena aleatoria simple
-func randomString(length int) string {
-	const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
-	b := make([]byte, length)
-	for i := range b {
-		b[i] = charset[time.Now().UnixNano()%int64(len(charset))]
-	}
-	return string(b)
-}
\ No newline at end of file
@@ -0,0 +1,89 @@
+package replication
+
+import (
+	"context"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+)
+
+// sessionRepository replica las escrituras de sesiones que tienen éxito en el
+// repositorio local
+type sessionRepository struct {
+	domain.ConversationSessionRepository
+	replicator *Replicator
+}
+
+// NewSessionRepository envuelve inner para replicar Create, Update y Delete.
+// DeleteExpired no se replica: cada región expira sus propias sesiones.
+func NewSessionRepository(inner domain.ConversationSessionRepository, replicator *Replicator) domain.ConversationSessionRepository {
+	return &sessionRepository{ConversationSessionRepository: inner, replicator: replicator}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *domain.ConversationSession) error {
+	if err := r.ConversationSessionRepository.Create(ctx, session); err != nil {
+		return err
+	}
+	r.replicator.Enqueue(ctx, KindSession, OpUpsert, session.BotID, session.ID, session)
+	return nil
+}
+
+func (r *sessionRepository) Update(ctx context.Context, session *domain.ConversationSession) error {
+	if err := r.ConversationSessionRepository.Update(ctx, session); err != nil {
+		return err
+	}
+	r.replicator.Enqueue(ctx, KindSession, OpUpsert, session.BotID, session.ID, session)
+	return nil
+}
+
+func (r *sessionRepository) Delete(ctx context.Context, id string) error {
+	// El bot de la sesión decide si se replica, así que se lee antes de borrar
+	session, lookupErr := r.ConversationSessionRepository.GetByID(ctx, id)
+	if err := r.ConversationSessionRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	if lookupErr == nil {
+		r.replicator.Enqueue(ctx, KindSession, OpDelete, session.BotID, id, nil)
+	}
+	return nil
+}
+
+// memoryService replica las escrituras de memoria a largo plazo
+type memoryService struct {
+	services.MemoryService
+	replicator *Replicator
+}
+
+// NewMemoryService envuelve inner para replicar StoreMemory, UpdateMemory y DeleteMemory
+func NewMemoryService(inner services.MemoryService, replicator *Replicator) services.MemoryService {
+	return &memoryService{MemoryService: inner, replicator: replicator}
+}
+
+func (s *memoryService) StoreMemory(ctx context.Context, memory *domain.Memory) error {
+	if err := s.MemoryService.StoreMemory(ctx, memory); err != nil {
+		return err
+	}
+	s.replicator.Enqueue(ctx, KindMemory, OpUpsert, memory.BotID, memoryKey(memory.UserID, memory.BotID, memory.Key), memory)
+	return nil
+}
+
+func (s *memoryService) UpdateMemory(ctx context.Context, memory *domain.Memory) error {
+	if err := s.MemoryService.UpdateMemory(ctx, memory); err != nil {
+		return err
+	}
+	s.replicator.Enqueue(ctx, KindMemory, OpUpsert, memory.BotID, memoryKey(memory.UserID, memory.BotID, memory.Key), memory)
+	return nil
+}
+
+func (s *memoryService) DeleteMemory(ctx context.Context, userID, botID, key string) error {
+	if err := s.MemoryService.DeleteMemory(ctx, userID, botID, key); err != nil {
+		return err
+	}
+	ref := &domain.Memory{UserID: userID, BotID: botID, Key: key}
+	s.replicator.Enqueue(ctx, KindMemory, OpDelete, botID, memoryKey(userID, botID, key), ref)
+	return nil
+}
+
+func memoryKey(userID, botID, key string) string {
+	return userID + ":" + botID + ":" + key
+}
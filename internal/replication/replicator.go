@@ -0,0 +1,376 @@
+// Package replication copia de forma asíncrona las escrituras de sesiones y
+// memoria a una región secundaria a través del bus de eventos, para que una
+// caída regional no pierda las conversaciones activas.
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Role indica si la región acepta escrituras (primary) o solo aplica las que
+// le llegan de la primaria (secondary)
+type Role string
+
+const (
+	RolePrimary   Role = "primary"
+	RoleSecondary Role = "secondary"
+)
+
+// Kind es el tipo de dato replicado
+type Kind string
+
+const (
+	KindSession Kind = "session"
+	KindMemory  Kind = "memory"
+)
+
+// Op es la operación replicada
+type Op string
+
+const (
+	OpUpsert Op = "upsert"
+	OpDelete Op = "delete"
+)
+
+const (
+	defaultQueueSize = 1000
+	publishAttempts  = 3
+	retryBackoff     = 200 * time.Millisecond
+)
+
+// Record es una escritura pendiente de replicar. Payload lleva la sesión o la
+// memoria completa; en los borrados de sesión solo importa Key.
+type Record struct {
+	ID        string          `json:"id"`
+	Kind      Kind            `json:"kind"`
+	Op        Op              `json:"op"`
+	Region    string          `json:"region"`
+	Key       string          `json:"key"`
+	BotID     string          `json:"bot_id"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
+
+// Config configura el replicador. BotIDs limita la replicación a esos bots
+// (p. ej. los de clientes enterprise); vacío replica todos.
+type Config struct {
+	Region    string
+	Role      Role
+	QueueSize int
+	BotIDs    []string
+}
+
+// Status resume el estado de la replicación en esta región
+type Status struct {
+	Region        string     `json:"region"`
+	Role          Role       `json:"role"`
+	Pending       int        `json:"pending"`
+	Published     uint64     `json:"published"`
+	Applied       uint64     `json:"applied"`
+	Dropped       uint64     `json:"dropped"`
+	Failed        uint64     `json:"failed"`
+	LastAppliedAt *time.Time `json:"last_applied_at,omitempty"`
+	FailedOverAt  *time.Time `json:"failed_over_at,omitempty"`
+}
+
+// Replicator publica las escrituras de la región primaria en el bus (outbox en
+// memoria con reintentos) y, en la secundaria, las aplica sobre los
+// repositorios locales
+type Replicator struct {
+	bus    events.EventBus
+	logger logger.Logger
+	region string
+	bots   map[string]bool
+	outbox chan Record
+
+	sessions domain.ConversationSessionRepository
+	memory   services.MemoryService
+
+	mu            sync.RWMutex
+	role          Role
+	lastAppliedAt *time.Time
+	failedOverAt  *time.Time
+
+	published uint64
+	applied   uint64
+	dropped   uint64
+	failed    uint64
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewReplicator crea un replicador; sessions y memory son los almacenes locales
+// sin decorar donde se aplican los cambios que llegan de otra región
+func NewReplicator(bus events.EventBus, cfg Config, sessions domain.ConversationSessionRepository, memory services.MemoryService, logger logger.Logger) *Replicator {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultQueueSize
+	}
+	if cfg.Role != RoleSecondary {
+		cfg.Role = RolePrimary
+	}
+
+	bots := make(map[string]bool, len(cfg.BotIDs))
+	for _, id := range cfg.BotIDs {
+		bots[id] = true
+	}
+
+	return &Replicator{
+		bus:      bus,
+		logger:   logger,
+		region:   cfg.Region,
+		bots:     bots,
+		outbox:   make(chan Record, cfg.QueueSize),
+		sessions: sessions,
+		memory:   memory,
+		role:     cfg.Role,
+		stop:     make(chan struct{}),
+	}
+}
+
+// Start se suscribe a los cambios de otras regiones y arranca el worker del outbox
+func (r *Replicator) Start(ctx context.Context) error {
+	if err := r.bus.Subscribe(events.EventTypeReplication, r.apply); err != nil {
+		return fmt.Errorf("failed to subscribe to replication events: %w", err)
+	}
+
+	r.wg.Add(1)
+	go r.worker()
+
+	r.logger.Info("Replication started", "region", r.region, "role", r.Role())
+	return nil
+}
+
+// Stop vacía el outbox pendiente y detiene el worker
+func (r *Replicator) Stop() {
+	close(r.stop)
+	r.wg.Wait()
+}
+
+// Role devuelve el rol actual de la región
+func (r *Replicator) Role() Role {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.role
+}
+
+// SetRole cambia el rol de la región, p. ej. para devolver a secundaria una
+// región recuperada tras un failover
+func (r *Replicator) SetRole(role Role) error {
+	if role != RolePrimary && role != RoleSecondary {
+		return fmt.Errorf("invalid replication role: %s", role)
+	}
+
+	r.mu.Lock()
+	previous := r.role
+	r.role = role
+	r.mu.Unlock()
+
+	if previous != role {
+		r.logger.Warn("Replication role changed", "region", r.region, "from", previous, "to", role)
+	}
+	return nil
+}
+
+// Failover promueve esta región a primaria: empieza a aceptar escrituras y a
+// replicarlas, y deja de aplicar las que lleguen de la antigua primaria
+func (r *Replicator) Failover() Status {
+	r.mu.Lock()
+	if r.role != RolePrimary {
+		now := time.Now()
+		r.role = RolePrimary
+		r.failedOverAt = &now
+		r.logger.Warn("Region promoted to primary", "region", r.region)
+	}
+	r.mu.Unlock()
+
+	return r.Status()
+}
+
+// Status devuelve rol y contadores de la replicación
+func (r *Replicator) Status() Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return Status{
+		Region:        r.region,
+		Role:          r.role,
+		Pending:       len(r.outbox),
+		Published:     atomic.LoadUint64(&r.published),
+		Applied:       atomic.LoadUint64(&r.applied),
+		Dropped:       atomic.LoadUint64(&r.dropped),
+		Failed:        atomic.LoadUint64(&r.failed),
+		LastAppliedAt: r.lastAppliedAt,
+		FailedOverAt:  r.failedOverAt,
+	}
+}
+
+// Enqueue deja una escritura en el outbox sin bloquear a quien escribe. Solo la
+// región primaria replica; si el outbox está lleno el cambio se descarta.
+func (r *Replicator) Enqueue(ctx context.Context, kind Kind, op Op, botID, key string, value interface{}) {
+	if r.Role() != RolePrimary || (len(r.bots) > 0 && !r.bots[botID]) {
+		return
+	}
+
+	record := Record{
+		ID:        id.New(),
+		Kind:      kind,
+		Op:        op,
+		Region:    r.region,
+		Key:       key,
+		BotID:     botID,
+		Timestamp: time.Now(),
+	}
+	if value != nil {
+		payload, err := json.Marshal(value)
+		if err != nil {
+			r.logger.WithContext(ctx).Error("Failed to encode replication record", "kind", kind, "key", key, "error", err)
+			return
+		}
+		record.Payload = payload
+	}
+
+	select {
+	case r.outbox <- record:
+	default:
+		atomic.AddUint64(&r.dropped, 1)
+		r.logger.WithContext(ctx).Warn("Replication outbox full, dropping record", "kind", kind, "key", key)
+	}
+}
+
+func (r *Replicator) worker() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case record := <-r.outbox:
+			r.publish(record)
+		case <-r.stop:
+			for {
+				select {
+				case record := <-r.outbox:
+					r.publish(record)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (r *Replicator) publish(record Record) {
+	event := events.Event{
+		ID:        record.ID,
+		Type:      events.EventTypeReplication,
+		Source:    r.region,
+		Data:      map[string]interface{}{"record": record},
+		Timestamp: record.Timestamp,
+	}
+
+	var err error
+	for attempt := 1; attempt <= publishAttempts; attempt++ {
+		if err = r.bus.Publish(context.Background(), event); err == nil {
+			atomic.AddUint64(&r.published, 1)
+			return
+		}
+		time.Sleep(time.Duration(attempt) * retryBackoff)
+	}
+
+	atomic.AddUint64(&r.failed, 1)
+	r.logger.Error("Failed to publish replication record", "kind", record.Kind, "key", record.Key, "error", err)
+}
+
+// apply aplica en la región secundaria un cambio publicado por otra región
+func (r *Replicator) apply(ctx context.Context, event events.Event) error {
+	record, err := decodeRecord(event)
+	if err != nil {
+		return err
+	}
+	if record.Region == r.region || r.Role() != RoleSecondary {
+		return nil
+	}
+
+	switch record.Kind {
+	case KindSession:
+		err = r.applySession(ctx, record)
+	case KindMemory:
+		err = r.applyMemory(ctx, record)
+	default:
+		err = fmt.Errorf("unknown replication kind: %s", record.Kind)
+	}
+	if err != nil {
+		atomic.AddUint64(&r.failed, 1)
+		return fmt.Errorf("failed to apply replication record %s: %w", record.ID, err)
+	}
+
+	atomic.AddUint64(&r.applied, 1)
+	now := time.Now()
+	r.mu.Lock()
+	r.lastAppliedAt = &now
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Replicator) applySession(ctx context.Context, record Record) error {
+	if record.Op == OpDelete {
+		return r.sessions.Delete(ctx, record.Key)
+	}
+
+	var session domain.ConversationSession
+	if err := json.Unmarshal(record.Payload, &session); err != nil {
+		return err
+	}
+
+	// El bus no garantiza orden: gana la versión más reciente de la sesión
+	current, err := r.sessions.GetByID(ctx, session.ID)
+	if err != nil {
+		return r.sessions.Create(ctx, &session)
+	}
+	if current.UpdatedAt.After(session.UpdatedAt) {
+		return nil
+	}
+	return r.sessions.Update(ctx, &session)
+}
+
+func (r *Replicator) applyMemory(ctx context.Context, record Record) error {
+	var memory domain.Memory
+	if err := json.Unmarshal(record.Payload, &memory); err != nil {
+		return err
+	}
+	if record.Op == OpDelete {
+		if _, err := r.memory.GetMemory(ctx, memory.UserID, memory.BotID, memory.Key); err != nil {
+			return nil
+		}
+		return r.memory.DeleteMemory(ctx, memory.UserID, memory.BotID, memory.Key)
+	}
+	return r.memory.StoreMemory(ctx, &memory)
+}
+
+// decodeRecord acepta tanto el Record tal cual (bus en memoria) como su forma
+// JSON (bus externo)
+func decodeRecord(event events.Event) (Record, error) {
+	if record, ok := event.Data["record"].(Record); ok {
+		return record, nil
+	}
+
+	var record Record
+	raw, err := json.Marshal(event.Data["record"])
+	if err != nil {
+		return record, err
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return record, fmt.Errorf("invalid replication record: %w", err)
+	}
+	return record, nil
+}
@@ -0,0 +1,101 @@
+package replication
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type region struct {
+	replicator *Replicator
+	rawRepo    domain.ConversationSessionRepository
+	rawMemory  services.MemoryService
+	sessions   domain.ConversationSessionRepository
+	memory     services.MemoryService
+}
+
+func newRegion(t *testing.T, bus events.EventBus, name string, role Role, botIDs ...string) *region {
+	log := logger.NewLogger("error")
+	r := &region{
+		rawRepo:   repositories.NewMockConversationSessionRepository(),
+		rawMemory: services.NewMemoryService(log, 0, 0, nil),
+	}
+	r.replicator = NewReplicator(bus, Config{Region: name, Role: role, BotIDs: botIDs}, r.rawRepo, r.rawMemory, log)
+	require.NoError(t, r.replicator.Start(context.Background()))
+	t.Cleanup(r.replicator.Stop)
+	r.sessions = NewSessionRepository(r.rawRepo, r.replicator)
+	r.memory = NewMemoryService(r.rawMemory, r.replicator)
+	return r
+}
+
+func TestReplicator_ReplicatesAndFailsOver(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewInMemoryEventBus(logger.NewLogger("error"))
+	east := newRegion(t, bus, "us-east", RolePrimary, "bot-1")
+	west := newRegion(t, bus, "us-west", RoleSecondary, "bot-1")
+
+	session := &domain.ConversationSession{ID: "s1", UserID: "u1", BotID: "bot-1", CurrentStepID: "greet",
+		Context: map[string]interface{}{"name": "Ana"}, UpdatedAt: time.Now(), ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, east.sessions.Create(ctx, session))
+	require.NoError(t, east.memory.StoreMemory(ctx, &domain.Memory{UserID: "u1", BotID: "bot-1", Key: "plan", Content: map[string]interface{}{"tier": "gold"}}))
+	// Los bots fuera de la lista no se replican
+	require.NoError(t, east.sessions.Create(ctx, &domain.ConversationSession{ID: "s2", BotID: "bot-2"}))
+
+	require.Eventually(t, func() bool {
+		_, err := west.rawMemory.GetMemory(ctx, "u1", "bot-1", "plan")
+		replicated, serr := west.rawRepo.GetByID(ctx, "s1")
+		return err == nil && serr == nil && replicated.CurrentStepID == "greet"
+	}, time.Second, 10*time.Millisecond)
+	_, err := west.rawRepo.GetByID(ctx, "s2")
+	assert.Error(t, err)
+
+	// Escribir en la secundaria no replica hacia la primaria
+	require.NoError(t, west.sessions.Create(ctx, &domain.ConversationSession{ID: "s3", BotID: "bot-1"}))
+
+	// Caída de us-east: us-west pasa a primaria y sigue la conversación
+	status := west.replicator.Failover()
+	assert.Equal(t, RolePrimary, status.Role)
+	assert.NotNil(t, status.FailedOverAt)
+	require.NoError(t, east.replicator.SetRole(RoleSecondary))
+
+	replicated, err := west.sessions.GetByID(ctx, "s1")
+	require.NoError(t, err)
+	moved := *replicated
+	moved.CurrentStepID = "ask_email"
+	moved.UpdatedAt = time.Now()
+	require.NoError(t, west.sessions.Update(ctx, &moved))
+	require.NoError(t, west.memory.DeleteMemory(ctx, "u1", "bot-1", "plan"))
+
+	require.Eventually(t, func() bool {
+		_, err := east.rawMemory.GetMemory(ctx, "u1", "bot-1", "plan")
+		current, serr := east.rawRepo.GetByID(ctx, "s1")
+		return err != nil && serr == nil && current.CurrentStepID == "ask_email"
+	}, time.Second, 10*time.Millisecond)
+	_, err = east.rawRepo.GetByID(ctx, "s3")
+	assert.Error(t, err)
+
+	assert.Error(t, west.replicator.SetRole("leader"))
+	assert.Eventually(t, func() bool { return west.replicator.Status().Published == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestReplicator_DropsWhenOutboxFull(t *testing.T) {
+	log := logger.NewLogger("error")
+	bus := events.NewInMemoryEventBus(log)
+	replicator := NewReplicator(bus, Config{Region: "eu", QueueSize: 1}, repositories.NewMockConversationSessionRepository(), services.NewMemoryService(log, 0, 0, nil), log)
+
+	// Sin Start no hay worker que vacíe el outbox
+	replicator.Enqueue(context.Background(), KindSession, OpUpsert, "bot-1", "s1", nil)
+	replicator.Enqueue(context.Background(), KindSession, OpUpsert, "bot-1", "s2", nil)
+
+	status := replicator.Status()
+	assert.Equal(t, 1, status.Pending)
+	assert.Equal(t, uint64(1), status.Dropped)
+}
@@ -0,0 +1,1005 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/kvstore"
+)
+
+// Repositorios persistidos en el almacén embebido (STORAGE_DRIVER=embedded).
+// Cada uno envuelve la implementación en memoria: las lecturas se sirven desde
+// memoria y cada escritura se replica en el almacén, que se vuelve a cargar al
+// arrancar.
+
+// embeddedCollection guarda entidades de un tipo como JSON en un bucket
+type embeddedCollection[T any] struct {
+	store  *kvstore.Store
+	bucket string
+}
+
+// load decodifica cada entidad guardada y se la pasa a fn
+func (c embeddedCollection[T]) load(fn func(item *T) error) error {
+	return c.store.ForEach(c.bucket, func(key string, value []byte) error {
+		item := new(T)
+		if err := json.Unmarshal(value, item); err != nil {
+			return fmt.Errorf("failed to decode %s/%s: %w", c.bucket, key, err)
+		}
+		return fn(item)
+	})
+}
+
+func (c embeddedCollection[T]) put(id string, item *T) error {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s/%s: %w", c.bucket, id, err)
+	}
+	return c.store.Put(c.bucket, id, data)
+}
+
+func (c embeddedCollection[T]) delete(id string) error {
+	return c.store.Delete(c.bucket, id)
+}
+
+// prune borra del almacén las entidades para las que keep devuelve false; se
+// usa tras los borrados masivos de la implementación en memoria
+func (c embeddedCollection[T]) prune(keep func(item *T) bool) error {
+	var removed []string
+	err := c.store.ForEach(c.bucket, func(key string, value []byte) error {
+		item := new(T)
+		if err := json.Unmarshal(value, item); err != nil {
+			return fmt.Errorf("failed to decode %s/%s: %w", c.bucket, key, err)
+		}
+		if !keep(item) {
+			removed = append(removed, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range removed {
+		if err := c.store.Delete(c.bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func openCollection[T any](store *kvstore.Store, bucket string, create func(item *T) error) (embeddedCollection[T], error) {
+	collection := embeddedCollection[T]{store: store, bucket: bucket}
+	if err := collection.load(create); err != nil {
+		return collection, fmt.Errorf("failed to load %s: %w", bucket, err)
+	}
+	return collection, nil
+}
+
+// EmbeddedBotRepository
+type EmbeddedBotRepository struct {
+	domain.BotRepository
+	items embeddedCollection[domain.Bot]
+}
+
+func NewEmbeddedBotRepository(store *kvstore.Store) (domain.BotRepository, error) {
+	memory := NewMockBotRepository()
+	items, err := openCollection(store, "bots", func(bot *domain.Bot) error {
+		return memory.Create(context.Background(), bot)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedBotRepository{BotRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedBotRepository) Create(ctx context.Context, bot *domain.Bot) error {
+	if err := r.BotRepository.Create(ctx, bot); err != nil {
+		return err
+	}
+	return r.items.put(bot.ID, bot)
+}
+
+func (r *EmbeddedBotRepository) Update(ctx context.Context, bot *domain.Bot) error {
+	if err := r.BotRepository.Update(ctx, bot); err != nil {
+		return err
+	}
+	return r.items.put(bot.ID, bot)
+}
+
+func (r *EmbeddedBotRepository) Delete(ctx context.Context, id string) error {
+	if err := r.BotRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedBotFlowRepository
+type EmbeddedBotFlowRepository struct {
+	domain.BotFlowRepository
+	items embeddedCollection[domain.BotFlow]
+}
+
+func NewEmbeddedBotFlowRepository(store *kvstore.Store) (domain.BotFlowRepository, error) {
+	memory := NewMockBotFlowRepository()
+	items, err := openCollection(store, "flows", func(flow *domain.BotFlow) error {
+		return memory.Create(context.Background(), flow)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedBotFlowRepository{BotFlowRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedBotFlowRepository) Create(ctx context.Context, flow *domain.BotFlow) error {
+	if err := r.BotFlowRepository.Create(ctx, flow); err != nil {
+		return err
+	}
+	return r.items.put(flow.ID, flow)
+}
+
+func (r *EmbeddedBotFlowRepository) Update(ctx context.Context, flow *domain.BotFlow) error {
+	if err := r.BotFlowRepository.Update(ctx, flow); err != nil {
+		return err
+	}
+	return r.items.put(flow.ID, flow)
+}
+
+func (r *EmbeddedBotFlowRepository) Delete(ctx context.Context, id string) error {
+	if err := r.BotFlowRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedBotStepRepository
+type EmbeddedBotStepRepository struct {
+	domain.BotStepRepository
+	items embeddedCollection[domain.BotStep]
+}
+
+func NewEmbeddedBotStepRepository(store *kvstore.Store) (domain.BotStepRepository, error) {
+	memory := NewMockBotStepRepository()
+	items, err := openCollection(store, "steps", func(step *domain.BotStep) error {
+		return memory.Create(context.Background(), step)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedBotStepRepository{BotStepRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedBotStepRepository) Create(ctx context.Context, step *domain.BotStep) error {
+	if err := r.BotStepRepository.Create(ctx, step); err != nil {
+		return err
+	}
+	return r.items.put(step.ID, step)
+}
+
+func (r *EmbeddedBotStepRepository) Update(ctx context.Context, step *domain.BotStep) error {
+	if err := r.BotStepRepository.Update(ctx, step); err != nil {
+		return err
+	}
+	return r.items.put(step.ID, step)
+}
+
+func (r *EmbeddedBotStepRepository) Delete(ctx context.Context, id string) error {
+	if err := r.BotStepRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedFlowVersionRepository
+type EmbeddedFlowVersionRepository struct {
+	domain.FlowVersionRepository
+	items embeddedCollection[domain.FlowVersion]
+}
+
+func NewEmbeddedFlowVersionRepository(store *kvstore.Store) (domain.FlowVersionRepository, error) {
+	memory := NewMockFlowVersionRepository()
+	items, err := openCollection(store, "flow_versions", func(version *domain.FlowVersion) error {
+		return memory.Create(context.Background(), version)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedFlowVersionRepository{FlowVersionRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedFlowVersionRepository) Create(ctx context.Context, version *domain.FlowVersion) error {
+	if err := r.FlowVersionRepository.Create(ctx, version); err != nil {
+		return err
+	}
+	return r.items.put(version.ID, version)
+}
+
+func (r *EmbeddedFlowVersionRepository) Update(ctx context.Context, version *domain.FlowVersion) error {
+	if err := r.FlowVersionRepository.Update(ctx, version); err != nil {
+		return err
+	}
+	return r.items.put(version.ID, version)
+}
+
+func (r *EmbeddedFlowVersionRepository) DeleteByFlowID(ctx context.Context, flowID string) error {
+	if err := r.FlowVersionRepository.DeleteByFlowID(ctx, flowID); err != nil {
+		return err
+	}
+	return r.items.prune(func(version *domain.FlowVersion) bool {
+		return version.FlowID != flowID
+	})
+}
+
+// EmbeddedSmartReplyRepository
+type EmbeddedSmartReplyRepository struct {
+	domain.SmartReplyRepository
+	items embeddedCollection[domain.SmartReply]
+}
+
+func NewEmbeddedSmartReplyRepository(store *kvstore.Store) (domain.SmartReplyRepository, error) {
+	memory := NewMockSmartReplyRepository()
+	items, err := openCollection(store, "smart_replies", func(reply *domain.SmartReply) error {
+		return memory.Create(context.Background(), reply)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedSmartReplyRepository{SmartReplyRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedSmartReplyRepository) Create(ctx context.Context, reply *domain.SmartReply) error {
+	if err := r.SmartReplyRepository.Create(ctx, reply); err != nil {
+		return err
+	}
+	return r.items.put(reply.ID, reply)
+}
+
+func (r *EmbeddedSmartReplyRepository) Update(ctx context.Context, reply *domain.SmartReply) error {
+	if err := r.SmartReplyRepository.Update(ctx, reply); err != nil {
+		return err
+	}
+	return r.items.put(reply.ID, reply)
+}
+
+func (r *EmbeddedSmartReplyRepository) Delete(ctx context.Context, id string) error {
+	if err := r.SmartReplyRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedIntentExampleRepository
+type EmbeddedIntentExampleRepository struct {
+	domain.IntentExampleRepository
+	items embeddedCollection[domain.IntentExample]
+}
+
+func NewEmbeddedIntentExampleRepository(store *kvstore.Store) (domain.IntentExampleRepository, error) {
+	memory := NewMockIntentExampleRepository()
+	items, err := openCollection(store, "intent_examples", func(example *domain.IntentExample) error {
+		return memory.Create(context.Background(), example)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedIntentExampleRepository{IntentExampleRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedIntentExampleRepository) Create(ctx context.Context, example *domain.IntentExample) error {
+	if err := r.IntentExampleRepository.Create(ctx, example); err != nil {
+		return err
+	}
+	return r.items.put(example.ID, example)
+}
+
+func (r *EmbeddedIntentExampleRepository) Delete(ctx context.Context, id string) error {
+	if err := r.IntentExampleRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+func (r *EmbeddedIntentExampleRepository) DeleteByIntent(ctx context.Context, botID, intent string) error {
+	if err := r.IntentExampleRepository.DeleteByIntent(ctx, botID, intent); err != nil {
+		return err
+	}
+	return r.items.prune(func(example *domain.IntentExample) bool {
+		return example.BotID != botID || example.Intent != intent
+	})
+}
+
+// EmbeddedFAQRepository
+type EmbeddedFAQRepository struct {
+	domain.FAQRepository
+	items embeddedCollection[domain.FAQEntry]
+}
+
+func NewEmbeddedFAQRepository(store *kvstore.Store) (domain.FAQRepository, error) {
+	memory := NewMockFAQRepository()
+	items, err := openCollection(store, "faqs", func(entry *domain.FAQEntry) error {
+		return memory.Create(context.Background(), entry)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedFAQRepository{FAQRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedFAQRepository) Create(ctx context.Context, entry *domain.FAQEntry) error {
+	if err := r.FAQRepository.Create(ctx, entry); err != nil {
+		return err
+	}
+	return r.items.put(entry.ID, entry)
+}
+
+func (r *EmbeddedFAQRepository) Update(ctx context.Context, entry *domain.FAQEntry) error {
+	if err := r.FAQRepository.Update(ctx, entry); err != nil {
+		return err
+	}
+	return r.items.put(entry.ID, entry)
+}
+
+func (r *EmbeddedFAQRepository) Delete(ctx context.Context, id string) error {
+	if err := r.FAQRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedConversationSessionRepository
+type EmbeddedConversationSessionRepository struct {
+	domain.ConversationSessionRepository
+	items embeddedCollection[domain.ConversationSession]
+}
+
+func NewEmbeddedConversationSessionRepository(store *kvstore.Store) (domain.ConversationSessionRepository, error) {
+	memory := NewMockConversationSessionRepository()
+	items, err := openCollection(store, "sessions", func(session *domain.ConversationSession) error {
+		return memory.Create(context.Background(), session)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedConversationSessionRepository{ConversationSessionRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedConversationSessionRepository) Create(ctx context.Context, session *domain.ConversationSession) error {
+	if err := r.ConversationSessionRepository.Create(ctx, session); err != nil {
+		return err
+	}
+	return r.items.put(session.ID, session)
+}
+
+func (r *EmbeddedConversationSessionRepository) Update(ctx context.Context, session *domain.ConversationSession) error {
+	if err := r.ConversationSessionRepository.Update(ctx, session); err != nil {
+		return err
+	}
+	return r.items.put(session.ID, session)
+}
+
+func (r *EmbeddedConversationSessionRepository) Delete(ctx context.Context, id string) error {
+	if err := r.ConversationSessionRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+func (r *EmbeddedConversationSessionRepository) DeleteExpired(ctx context.Context) error {
+	if err := r.ConversationSessionRepository.DeleteExpired(ctx); err != nil {
+		return err
+	}
+	return r.items.prune(func(session *domain.ConversationSession) bool {
+		_, err := r.ConversationSessionRepository.GetByID(ctx, session.ID)
+		return err == nil
+	})
+}
+
+// EmbeddedConditionalRepository
+type EmbeddedConditionalRepository struct {
+	domain.ConditionalRepository
+	items embeddedCollection[domain.Conditional]
+}
+
+func NewEmbeddedConditionalRepository(store *kvstore.Store) (domain.ConditionalRepository, error) {
+	memory := NewMockConditionalRepository()
+	items, err := openCollection(store, "conditionals", func(conditional *domain.Conditional) error {
+		return memory.Create(context.Background(), conditional)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedConditionalRepository{ConditionalRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedConditionalRepository) Create(ctx context.Context, conditional *domain.Conditional) error {
+	if err := r.ConditionalRepository.Create(ctx, conditional); err != nil {
+		return err
+	}
+	return r.items.put(conditional.ID, conditional)
+}
+
+func (r *EmbeddedConditionalRepository) Update(ctx context.Context, conditional *domain.Conditional) error {
+	if err := r.ConditionalRepository.Update(ctx, conditional); err != nil {
+		return err
+	}
+	return r.items.put(conditional.ID, conditional)
+}
+
+func (r *EmbeddedConditionalRepository) Delete(ctx context.Context, id string) error {
+	if err := r.ConditionalRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedTriggerRepository
+type EmbeddedTriggerRepository struct {
+	domain.TriggerRepository
+	items embeddedCollection[domain.Trigger]
+}
+
+func NewEmbeddedTriggerRepository(store *kvstore.Store) (domain.TriggerRepository, error) {
+	memory := NewMockTriggerRepository()
+	items, err := openCollection(store, "triggers", func(trigger *domain.Trigger) error {
+		return memory.Create(context.Background(), trigger)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedTriggerRepository{TriggerRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedTriggerRepository) Create(ctx context.Context, trigger *domain.Trigger) error {
+	if err := r.TriggerRepository.Create(ctx, trigger); err != nil {
+		return err
+	}
+	return r.items.put(trigger.ID, trigger)
+}
+
+func (r *EmbeddedTriggerRepository) Update(ctx context.Context, trigger *domain.Trigger) error {
+	if err := r.TriggerRepository.Update(ctx, trigger); err != nil {
+		return err
+	}
+	return r.items.put(trigger.ID, trigger)
+}
+
+func (r *EmbeddedTriggerRepository) Delete(ctx context.Context, id string) error {
+	if err := r.TriggerRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+func (r *EmbeddedTriggerRepository) Execute(ctx context.Context, id string, eventData map[string]interface{}) error {
+	if err := r.TriggerRepository.Execute(ctx, id, eventData); err != nil {
+		return err
+	}
+	trigger, err := r.TriggerRepository.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	return r.items.put(trigger.ID, trigger)
+}
+
+// EmbeddedKnowledgeSourceRepository
+type EmbeddedKnowledgeSourceRepository struct {
+	domain.KnowledgeSourceRepository
+	items embeddedCollection[domain.KnowledgeSource]
+}
+
+func NewEmbeddedKnowledgeSourceRepository(store *kvstore.Store) (domain.KnowledgeSourceRepository, error) {
+	memory := NewMockKnowledgeSourceRepository()
+	items, err := openCollection(store, "knowledge_sources", func(source *domain.KnowledgeSource) error {
+		return memory.Create(context.Background(), source)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedKnowledgeSourceRepository{KnowledgeSourceRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedKnowledgeSourceRepository) Create(ctx context.Context, source *domain.KnowledgeSource) error {
+	if err := r.KnowledgeSourceRepository.Create(ctx, source); err != nil {
+		return err
+	}
+	return r.items.put(source.ID, source)
+}
+
+func (r *EmbeddedKnowledgeSourceRepository) Update(ctx context.Context, source *domain.KnowledgeSource) error {
+	if err := r.KnowledgeSourceRepository.Update(ctx, source); err != nil {
+		return err
+	}
+	return r.items.put(source.ID, source)
+}
+
+func (r *EmbeddedKnowledgeSourceRepository) Delete(ctx context.Context, id string) error {
+	if err := r.KnowledgeSourceRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedTaskRepository
+type EmbeddedTaskRepository struct {
+	domain.TaskRepository
+	items embeddedCollection[domain.AsyncTask]
+}
+
+func NewEmbeddedTaskRepository(store *kvstore.Store) (domain.TaskRepository, error) {
+	memory := NewMockTaskRepository()
+	items, err := openCollection(store, "tasks", func(task *domain.AsyncTask) error {
+		return memory.Create(context.Background(), task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedTaskRepository{TaskRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedTaskRepository) Create(ctx context.Context, task *domain.AsyncTask) error {
+	if err := r.TaskRepository.Create(ctx, task); err != nil {
+		return err
+	}
+	return r.items.put(task.ID, task)
+}
+
+func (r *EmbeddedTaskRepository) Update(ctx context.Context, task *domain.AsyncTask) error {
+	if err := r.TaskRepository.Update(ctx, task); err != nil {
+		return err
+	}
+	return r.items.put(task.ID, task)
+}
+
+func (r *EmbeddedTaskRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	deleted, err := r.TaskRepository.DeleteFinishedBefore(ctx, before)
+	if err != nil || deleted == 0 {
+		return deleted, err
+	}
+	return deleted, r.items.prune(func(task *domain.AsyncTask) bool {
+		_, err := r.TaskRepository.GetByID(ctx, task.ID)
+		return err == nil
+	})
+}
+
+// EmbeddedDeadLetterRepository
+type EmbeddedDeadLetterRepository struct {
+	domain.DeadLetterRepository
+	items embeddedCollection[domain.DeadLetterTask]
+}
+
+func NewEmbeddedDeadLetterRepository(store *kvstore.Store) (domain.DeadLetterRepository, error) {
+	memory := NewMockDeadLetterRepository()
+	items, err := openCollection(store, "dead_letters", func(task *domain.DeadLetterTask) error {
+		return memory.Create(context.Background(), task)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedDeadLetterRepository{DeadLetterRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedDeadLetterRepository) Create(ctx context.Context, task *domain.DeadLetterTask) error {
+	if err := r.DeadLetterRepository.Create(ctx, task); err != nil {
+		return err
+	}
+	return r.items.put(task.ID, task)
+}
+
+func (r *EmbeddedDeadLetterRepository) Delete(ctx context.Context, id string) error {
+	if err := r.DeadLetterRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+func (r *EmbeddedDeadLetterRepository) DeleteAll(ctx context.Context) (int, error) {
+	deleted, err := r.DeadLetterRepository.DeleteAll(ctx)
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, r.items.prune(func(*domain.DeadLetterTask) bool { return false })
+}
+
+// EmbeddedAuditRepository
+type EmbeddedAuditRepository struct {
+	domain.AuditRepository
+	items embeddedCollection[domain.AuditLog]
+}
+
+func NewEmbeddedAuditRepository(store *kvstore.Store) (domain.AuditRepository, error) {
+	memory := NewMockAuditRepository()
+	items, err := openCollection(store, "audit_logs", func(log *domain.AuditLog) error {
+		return memory.Create(context.Background(), log)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedAuditRepository{AuditRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedAuditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	if err := r.AuditRepository.Create(ctx, log); err != nil {
+		return err
+	}
+	return r.items.put(log.ID, log)
+}
+
+// EmbeddedConversationMessageRepository
+type EmbeddedConversationMessageRepository struct {
+	domain.ConversationMessageRepository
+	items embeddedCollection[domain.ConversationMessage]
+}
+
+func NewEmbeddedConversationMessageRepository(store *kvstore.Store) (domain.ConversationMessageRepository, error) {
+	memory := NewMockConversationMessageRepository()
+	items, err := openCollection(store, "conversation_messages", func(message *domain.ConversationMessage) error {
+		return memory.Create(context.Background(), message)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedConversationMessageRepository{ConversationMessageRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedConversationMessageRepository) Create(ctx context.Context, message *domain.ConversationMessage) error {
+	if err := r.ConversationMessageRepository.Create(ctx, message); err != nil {
+		return err
+	}
+	return r.items.put(message.ID, message)
+}
+
+// EmbeddedAnalyticsRepository
+type EmbeddedAnalyticsRepository struct {
+	domain.AnalyticsRepository
+	items embeddedCollection[domain.BotDailyStats]
+	// mu ordena las escrituras para que no se guarde un día con menos cuentas
+	mu sync.Mutex
+}
+
+func NewEmbeddedAnalyticsRepository(store *kvstore.Store) (domain.AnalyticsRepository, error) {
+	memory := NewMockAnalyticsRepository()
+	items, err := openCollection(store, "bot_daily_stats", func(stats *domain.BotDailyStats) error {
+		return memory.Increment(context.Background(), stats)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedAnalyticsRepository{AnalyticsRepository: memory, items: items}, nil
+}
+
+// Increment guarda el día completo tras sumar delta
+func (r *EmbeddedAnalyticsRepository) Increment(ctx context.Context, delta *domain.BotDailyStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.AnalyticsRepository.Increment(ctx, delta); err != nil {
+		return err
+	}
+	days, err := r.AnalyticsRepository.GetRange(ctx, delta.BotID, delta.Date, delta.Date)
+	if err != nil || len(days) == 0 {
+		return err
+	}
+	return r.items.put(delta.BotID+"/"+delta.Date, days[0])
+}
+
+// EmbeddedUsageRepository
+type EmbeddedUsageRepository struct {
+	domain.UsageRepository
+	items embeddedCollection[domain.BotDailyUsage]
+	mu    sync.Mutex
+}
+
+func NewEmbeddedUsageRepository(store *kvstore.Store) (domain.UsageRepository, error) {
+	memory := NewMockUsageRepository()
+	items, err := openCollection(store, "bot_daily_usage", func(usage *domain.BotDailyUsage) error {
+		return memory.Increment(context.Background(), usage)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedUsageRepository{UsageRepository: memory, items: items}, nil
+}
+
+// Increment guarda el día completo tras sumar delta, como los contadores de analítica
+func (r *EmbeddedUsageRepository) Increment(ctx context.Context, delta *domain.BotDailyUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.UsageRepository.Increment(ctx, delta); err != nil {
+		return err
+	}
+	days, err := r.UsageRepository.GetRange(ctx, delta.BotID, delta.Date, delta.Date)
+	if err != nil || len(days) == 0 {
+		return err
+	}
+	return r.items.put(delta.BotID+"/"+delta.Date, days[0])
+}
+
+// EmbeddedAPIKeyRepository
+type EmbeddedAPIKeyRepository struct {
+	domain.APIKeyRepository
+	items embeddedCollection[domain.APIKey]
+}
+
+func NewEmbeddedAPIKeyRepository(store *kvstore.Store) (domain.APIKeyRepository, error) {
+	memory := NewMockAPIKeyRepository()
+	items, err := openCollection(store, "api_keys", func(key *domain.APIKey) error {
+		return memory.Create(context.Background(), key)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedAPIKeyRepository{APIKeyRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	if err := r.APIKeyRepository.Create(ctx, key); err != nil {
+		return err
+	}
+	return r.items.put(key.ID, key)
+}
+
+func (r *EmbeddedAPIKeyRepository) Update(ctx context.Context, key *domain.APIKey) error {
+	if err := r.APIKeyRepository.Update(ctx, key); err != nil {
+		return err
+	}
+	return r.items.put(key.ID, key)
+}
+
+// EmbeddedWebhookSubscriptionRepository
+type EmbeddedWebhookSubscriptionRepository struct {
+	domain.WebhookSubscriptionRepository
+	items embeddedCollection[domain.WebhookSubscription]
+}
+
+func NewEmbeddedWebhookSubscriptionRepository(store *kvstore.Store) (domain.WebhookSubscriptionRepository, error) {
+	memory := NewMockWebhookSubscriptionRepository()
+	items, err := openCollection(store, "webhook_subscriptions", func(subscription *domain.WebhookSubscription) error {
+		return memory.Create(context.Background(), subscription)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedWebhookSubscriptionRepository{WebhookSubscriptionRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedWebhookSubscriptionRepository) Create(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := r.WebhookSubscriptionRepository.Create(ctx, subscription); err != nil {
+		return err
+	}
+	return r.items.put(subscription.ID, subscription)
+}
+
+func (r *EmbeddedWebhookSubscriptionRepository) Update(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	if err := r.WebhookSubscriptionRepository.Update(ctx, subscription); err != nil {
+		return err
+	}
+	return r.items.put(subscription.ID, subscription)
+}
+
+func (r *EmbeddedWebhookSubscriptionRepository) Delete(ctx context.Context, id string) error {
+	if err := r.WebhookSubscriptionRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedMCPAgentRepository
+type EmbeddedMCPAgentRepository struct {
+	domain.MCPAgentRepository
+	items embeddedCollection[domain.MCPAgent]
+}
+
+func NewEmbeddedMCPAgentRepository(store *kvstore.Store) (domain.MCPAgentRepository, error) {
+	memory := NewMockMCPAgentRepository()
+	items, err := openCollection(store, "mcp_agents", func(agent *domain.MCPAgent) error {
+		return memory.Create(context.Background(), agent)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedMCPAgentRepository{MCPAgentRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedMCPAgentRepository) Create(ctx context.Context, agent *domain.MCPAgent) error {
+	if err := r.MCPAgentRepository.Create(ctx, agent); err != nil {
+		return err
+	}
+	return r.items.put(agent.ID, agent)
+}
+
+func (r *EmbeddedMCPAgentRepository) Update(ctx context.Context, agent *domain.MCPAgent) error {
+	if err := r.MCPAgentRepository.Update(ctx, agent); err != nil {
+		return err
+	}
+	return r.items.put(agent.ID, agent)
+}
+
+func (r *EmbeddedMCPAgentRepository) Delete(ctx context.Context, id string) error {
+	if err := r.MCPAgentRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.delete(id)
+}
+
+// EmbeddedWorkflowDefinitionRepository guarda cada versión como una entrada
+// "<id>/<versión>"; al cargar en orden, la última de cada workflow es la actual
+type EmbeddedWorkflowDefinitionRepository struct {
+	domain.WorkflowDefinitionRepository
+	items embeddedCollection[domain.WorkflowDefinition]
+}
+
+func NewEmbeddedWorkflowDefinitionRepository(store *kvstore.Store) (domain.WorkflowDefinitionRepository, error) {
+	memory := NewMockWorkflowDefinitionRepository()
+	items, err := openCollection(store, "workflow_definitions", func(definition *domain.WorkflowDefinition) error {
+		if _, err := memory.GetByID(context.Background(), definition.ID); err != nil {
+			return memory.Create(context.Background(), definition)
+		}
+		return memory.Update(context.Background(), definition)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedWorkflowDefinitionRepository{WorkflowDefinitionRepository: memory, items: items}, nil
+}
+
+func workflowVersionKey(definition *domain.WorkflowDefinition) string {
+	return fmt.Sprintf("%s/%06d", definition.ID, definition.Version)
+}
+
+func (r *EmbeddedWorkflowDefinitionRepository) Create(ctx context.Context, definition *domain.WorkflowDefinition) error {
+	if err := r.WorkflowDefinitionRepository.Create(ctx, definition); err != nil {
+		return err
+	}
+	return r.items.put(workflowVersionKey(definition), definition)
+}
+
+func (r *EmbeddedWorkflowDefinitionRepository) Update(ctx context.Context, definition *domain.WorkflowDefinition) error {
+	if err := r.WorkflowDefinitionRepository.Update(ctx, definition); err != nil {
+		return err
+	}
+	return r.items.put(workflowVersionKey(definition), definition)
+}
+
+func (r *EmbeddedWorkflowDefinitionRepository) Delete(ctx context.Context, id string) error {
+	if err := r.WorkflowDefinitionRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	return r.items.prune(func(definition *domain.WorkflowDefinition) bool {
+		return definition.ID != id
+	})
+}
+
+// EmbeddedScriptApprovalRepository
+type EmbeddedScriptApprovalRepository struct {
+	domain.ScriptApprovalRepository
+	items embeddedCollection[domain.ScriptApproval]
+}
+
+func NewEmbeddedScriptApprovalRepository(store *kvstore.Store) (domain.ScriptApprovalRepository, error) {
+	memory := NewMockScriptApprovalRepository()
+	items, err := openCollection(store, "script_approvals", func(approval *domain.ScriptApproval) error {
+		return memory.Create(context.Background(), approval)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddedScriptApprovalRepository{ScriptApprovalRepository: memory, items: items}, nil
+}
+
+func (r *EmbeddedScriptApprovalRepository) Create(ctx context.Context, approval *domain.ScriptApproval) error {
+	if err := r.ScriptApprovalRepository.Create(ctx, approval); err != nil {
+		return err
+	}
+	return r.items.put(approval.Hash, approval)
+}
+
+func (r *EmbeddedScriptApprovalRepository) Delete(ctx context.Context, hash string) error {
+	if err := r.ScriptApprovalRepository.Delete(ctx, hash); err != nil {
+		return err
+	}
+	return r.items.delete(hash)
+}
+
+// EmbeddedSet agrupa los repositorios que persisten en el almacén embebido
+type EmbeddedSet struct {
+	Bots             domain.BotRepository
+	Flows            domain.BotFlowRepository
+	Steps            domain.BotStepRepository
+	FlowVersions     domain.FlowVersionRepository
+	SmartReplies     domain.SmartReplyRepository
+	IntentExamples   domain.IntentExampleRepository
+	FAQs             domain.FAQRepository
+	Sessions         domain.ConversationSessionRepository
+	Conditionals     domain.ConditionalRepository
+	Triggers         domain.TriggerRepository
+	KnowledgeSources domain.KnowledgeSourceRepository
+	Tasks            domain.TaskRepository
+	DeadLetters      domain.DeadLetterRepository
+	Audits           domain.AuditRepository
+	APIKeys          domain.APIKeyRepository
+	Webhooks         domain.WebhookSubscriptionRepository
+	Workflows        domain.WorkflowDefinitionRepository
+	ScriptApprovals  domain.ScriptApprovalRepository
+	MCPAgents        domain.MCPAgentRepository
+	Messages         domain.ConversationMessageRepository
+	Analytics        domain.AnalyticsRepository
+	Usage            domain.UsageRepository
+}
+
+// OpenEmbeddedSet carga todos los repositorios persistidos en el almacén
+func OpenEmbeddedSet(store *kvstore.Store) (*EmbeddedSet, error) {
+	set := &EmbeddedSet{}
+	var err error
+
+	if set.Bots, err = NewEmbeddedBotRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Flows, err = NewEmbeddedBotFlowRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Steps, err = NewEmbeddedBotStepRepository(store); err != nil {
+		return nil, err
+	}
+	if set.FlowVersions, err = NewEmbeddedFlowVersionRepository(store); err != nil {
+		return nil, err
+	}
+	if set.SmartReplies, err = NewEmbeddedSmartReplyRepository(store); err != nil {
+		return nil, err
+	}
+	if set.IntentExamples, err = NewEmbeddedIntentExampleRepository(store); err != nil {
+		return nil, err
+	}
+	if set.FAQs, err = NewEmbeddedFAQRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Sessions, err = NewEmbeddedConversationSessionRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Conditionals, err = NewEmbeddedConditionalRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Triggers, err = NewEmbeddedTriggerRepository(store); err != nil {
+		return nil, err
+	}
+	if set.KnowledgeSources, err = NewEmbeddedKnowledgeSourceRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Tasks, err = NewEmbeddedTaskRepository(store); err != nil {
+		return nil, err
+	}
+	if set.DeadLetters, err = NewEmbeddedDeadLetterRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Audits, err = NewEmbeddedAuditRepository(store); err != nil {
+		return nil, err
+	}
+	if set.APIKeys, err = NewEmbeddedAPIKeyRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Webhooks, err = NewEmbeddedWebhookSubscriptionRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Workflows, err = NewEmbeddedWorkflowDefinitionRepository(store); err != nil {
+		return nil, err
+	}
+	if set.ScriptApprovals, err = NewEmbeddedScriptApprovalRepository(store); err != nil {
+		return nil, err
+	}
+	if set.MCPAgents, err = NewEmbeddedMCPAgentRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Messages, err = NewEmbeddedConversationMessageRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Analytics, err = NewEmbeddedAnalyticsRepository(store); err != nil {
+		return nil, err
+	}
+	if set.Usage, err = NewEmbeddedUsageRepository(store); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/kvstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedSet_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "bot-service.db")
+
+	store, err := kvstore.Open(path)
+	require.NoError(t, err)
+	set, err := OpenEmbeddedSet(store)
+	require.NoError(t, err)
+
+	require.NoError(t, set.Bots.Create(ctx, &domain.Bot{ID: "bot-1", Name: "Support"}))
+	require.NoError(t, set.Bots.Create(ctx, &domain.Bot{ID: "bot-2", Name: "Sales"}))
+	require.NoError(t, set.Bots.Delete(ctx, "bot-2"))
+	session := &domain.ConversationSession{ID: "s-1", BotID: "bot-1", UserID: "user-1", Context: map[string]interface{}{"name": "Ana"}}
+	require.NoError(t, set.Sessions.Create(ctx, session))
+	session.CurrentStepID = "ask"
+	require.NoError(t, set.Sessions.Update(ctx, session))
+	require.NoError(t, set.FlowVersions.Create(ctx, &domain.FlowVersion{FlowID: "flow-1", Version: 1}))
+	require.NoError(t, set.FlowVersions.DeleteByFlowID(ctx, "flow-1"))
+	require.NoError(t, store.Close())
+
+	store, err = kvstore.Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+	set, err = OpenEmbeddedSet(store)
+	require.NoError(t, err)
+
+	bot, err := set.Bots.GetByID(ctx, "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Support", bot.Name)
+	_, err = set.Bots.GetByID(ctx, "bot-2")
+	assert.Error(t, err)
+
+	restored, err := set.Sessions.GetByUserAndBot(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ask", restored.CurrentStepID)
+	assert.Equal(t, "Ana", restored.Context["name"])
+
+	versions, err := set.FlowVersions.GetByFlowID(ctx, "flow-1")
+	require.NoError(t, err)
+	assert.Empty(t, versions)
+}
+
+func TestEmbeddedScriptApprovals_SurviveRestartInTaskStore(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "tasks.db")
+
+	store, err := kvstore.Open(path)
+	require.NoError(t, err)
+	approvals, err := NewEmbeddedScriptApprovalRepository(store)
+	require.NoError(t, err)
+	require.NoError(t, approvals.Create(ctx, &domain.ScriptApproval{Hash: "abc", ApprovedBy: "admin", Notes: "report script"}))
+	require.NoError(t, approvals.Create(ctx, &domain.ScriptApproval{Hash: "def", ApprovedBy: "admin"}))
+	require.NoError(t, approvals.Delete(ctx, "def"))
+	require.NoError(t, store.Close())
+
+	store, err = kvstore.Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+	approvals, err = NewEmbeddedScriptApprovalRepository(store)
+	require.NoError(t, err)
+
+	approval, err := approvals.Get(ctx, "abc")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", approval.ApprovedBy)
+	assert.Equal(t, "report script", approval.Notes)
+	_, err = approvals.Get(ctx, "def")
+	assert.Error(t, err)
+}
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// historySessionRepository copia al historial los mensajes nuevos de la
+// transcripción cada vez que se guarda una sesión, venga de donde venga el
+// cambio (flujo, pasos diferidos o consola de handoff)
+type historySessionRepository struct {
+	domain.ConversationSessionRepository
+	messages domain.ConversationMessageRepository
+	// mu evita que dos guardados simultáneos de una sesión copien el mismo mensaje
+	mu sync.Mutex
+}
+
+// WithMessageHistory devuelve un repositorio de sesiones que guarda en
+// messages cada mensaje que se agrega a session.Messages. La transcripción de
+// la sesión sigue limitada; el historial conserva todos los mensajes.
+func WithMessageHistory(sessions domain.ConversationSessionRepository, messages domain.ConversationMessageRepository) domain.ConversationSessionRepository {
+	return &historySessionRepository{
+		ConversationSessionRepository: sessions,
+		messages:                      messages,
+	}
+}
+
+func (r *historySessionRepository) Create(ctx context.Context, session *domain.ConversationSession) error {
+	if err := r.ConversationSessionRepository.Create(ctx, session); err != nil {
+		return err
+	}
+	return r.record(ctx, session)
+}
+
+func (r *historySessionRepository) Update(ctx context.Context, session *domain.ConversationSession) error {
+	if err := r.ConversationSessionRepository.Update(ctx, session); err != nil {
+		return err
+	}
+	return r.record(ctx, session)
+}
+
+// record guarda los mensajes de la transcripción posteriores al último que
+// ya está en el historial
+func (r *historySessionRepository) record(ctx context.Context, session *domain.ConversationSession) error {
+	if len(session.Messages) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var last time.Time
+	if thread, err := r.messages.GetConversation(ctx, session.ID); err == nil {
+		last = thread.LastMessageAt
+	}
+
+	for _, message := range session.Messages {
+		if !message.Timestamp.After(last) {
+			continue
+		}
+		stored := message
+		stored.ID = ""
+		stored.SessionID = session.ID
+		stored.BotID = session.BotID
+		stored.UserID = session.UserID
+		if err := r.messages.Create(ctx, &stored); err != nil {
+			return fmt.Errorf("failed to store conversation message: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithMessageHistory_KeepsEveryMessage(t *testing.T) {
+	ctx := context.Background()
+	messages := NewMockConversationMessageRepository()
+	sessions := WithMessageHistory(NewMockConversationSessionRepository(), messages)
+
+	start := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	session := &domain.ConversationSession{BotID: "bot-1", UserID: "user-1", ExpiresAt: at(60),
+		Messages: []domain.ConversationMessage{{Role: "user", Content: "Hola", Timestamp: at(0)}}}
+	require.NoError(t, sessions.Create(ctx, session))
+
+	// Cada guardado copia sólo los mensajes nuevos, aunque la transcripción de la sesión se recorte
+	session.Messages = append(session.Messages,
+		domain.ConversationMessage{Role: "bot", Content: "¿En qué te ayudo?", Timestamp: at(1)},
+		domain.ConversationMessage{Role: "user", Content: "Mi pedido", Timestamp: at(2)})
+	require.NoError(t, sessions.Update(ctx, session))
+	session.Messages = append(session.Messages[2:], domain.ConversationMessage{Role: "agent", Content: "Lo reviso", Timestamp: at(3)})
+	require.NoError(t, sessions.Update(ctx, session))
+
+	other := &domain.ConversationSession{BotID: "bot-1", UserID: "user-2", ExpiresAt: at(60),
+		Messages: []domain.ConversationMessage{{Role: "user", Content: "Buenas", Timestamp: at(10)}}}
+	require.NoError(t, sessions.Create(ctx, other))
+	require.NoError(t, sessions.Delete(ctx, session.ID))
+
+	history, total, err := messages.GetBySessionID(ctx, session.ID, domain.ConversationHistoryFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 4, total)
+	assert.Equal(t, []string{"Hola", "¿En qué te ayudo?", "Mi pedido", "Lo reviso"},
+		[]string{history[0].Content, history[1].Content, history[2].Content, history[3].Content})
+	assert.Equal(t, "user-1", history[3].UserID)
+	assert.NotEmpty(t, history[3].ID)
+
+	from, to := at(1), at(3)
+	history, total, err = messages.GetBySessionID(ctx, session.ID, domain.ConversationHistoryFilter{From: &from, To: &to, Limit: 1, Offset: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, history, 1)
+	assert.Equal(t, "Mi pedido", history[0].Content)
+
+	conversations, total, err := messages.ListConversations(ctx, "bot-1", domain.ConversationHistoryFilter{})
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	assert.Equal(t, other.ID, conversations[0].SessionID)
+	assert.Equal(t, 4, conversations[1].MessageCount)
+	assert.Equal(t, "Lo reviso", conversations[1].LastMessage)
+
+	conversations, total, err = messages.ListConversations(ctx, "bot-1", domain.ConversationHistoryFilter{To: &to})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, session.ID, conversations[0].SessionID)
+}
@@ -2,12 +2,14 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/company/bot-service/internal/domain"
-	"github.com/google/uuid"
+	"github.com/company/bot-service/pkg/id"
 )
 
 // Mock implementations for development and testing
@@ -53,7 +55,7 @@ func (r *MockBotRepository) Create(ctx context.Context, bot *domain.Bot) error {
 	defer r.mu.Unlock()
 	
 	if bot.ID == "" {
-		bot.ID = fmt.Sprintf("bot_%d", time.Now().UnixNano())
+		bot.ID = id.New()
 	}
 	r.bots[bot.ID] = bot
 	return nil
@@ -146,7 +148,7 @@ func (r *MockBotFlowRepository) Create(ctx context.Context, flow *domain.BotFlow
 	defer r.mu.Unlock()
 	
 	if flow.ID == "" {
-		flow.ID = fmt.Sprintf("flow_%d", time.Now().UnixNano())
+		flow.ID = id.New()
 	}
 	r.flows[flow.ID] = flow
 	return nil
@@ -212,7 +214,7 @@ func (r *MockBotStepRepository) Create(ctx context.Context, step *domain.BotStep
 	defer r.mu.Unlock()
 	
 	if step.ID == "" {
-		step.ID = fmt.Sprintf("step_%d", time.Now().UnixNano())
+		step.ID = id.New()
 	}
 	r.steps[step.ID] = step
 	return nil
@@ -237,6 +239,91 @@ func (r *MockBotStepRepository) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// MockFlowVersionRepository implementa FlowVersionRepository para testing
+type MockFlowVersionRepository struct {
+	versions map[string]*domain.FlowVersion
+	mu       sync.RWMutex
+}
+
+func NewMockFlowVersionRepository() domain.FlowVersionRepository {
+	return &MockFlowVersionRepository{
+		versions: make(map[string]*domain.FlowVersion),
+	}
+}
+
+func (r *MockFlowVersionRepository) Create(ctx context.Context, version *domain.FlowVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.versions {
+		if existing.FlowID == version.FlowID && existing.Version == version.Version {
+			return fmt.Errorf("flow version already exists")
+		}
+	}
+	if version.ID == "" {
+		version.ID = id.New()
+	}
+	r.versions[version.ID] = copyFlowVersion(version)
+	return nil
+}
+
+func (r *MockFlowVersionRepository) Update(ctx context.Context, version *domain.FlowVersion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.versions[version.ID]; !exists {
+		return fmt.Errorf("flow version not found")
+	}
+	r.versions[version.ID] = copyFlowVersion(version)
+	return nil
+}
+
+func (r *MockFlowVersionRepository) GetByVersion(ctx context.Context, flowID string, version int) (*domain.FlowVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, existing := range r.versions {
+		if existing.FlowID == flowID && existing.Version == version {
+			return copyFlowVersion(existing), nil
+		}
+	}
+	return nil, fmt.Errorf("flow version not found")
+}
+
+func (r *MockFlowVersionRepository) GetByFlowID(ctx context.Context, flowID string) ([]*domain.FlowVersion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var versions []*domain.FlowVersion
+	for _, existing := range r.versions {
+		if existing.FlowID == flowID {
+			versions = append(versions, copyFlowVersion(existing))
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].Version > versions[j].Version
+	})
+	return versions, nil
+}
+
+func (r *MockFlowVersionRepository) DeleteByFlowID(ctx context.Context, flowID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, existing := range r.versions {
+		if existing.FlowID == flowID {
+			delete(r.versions, id)
+		}
+	}
+	return nil
+}
+
+func copyFlowVersion(version *domain.FlowVersion) *domain.FlowVersion {
+	versionCopy := *version
+	versionCopy.Steps = append([]domain.BotStep(nil), version.Steps...)
+	return &versionCopy
+}
+
 // MockSmartReplyRepository
 type MockSmartReplyRepository struct {
 	replies map[string]*domain.SmartReply
@@ -290,7 +377,7 @@ func (r *MockSmartReplyRepository) Create(ctx context.Context, reply *domain.Sma
 	defer r.mu.Unlock()
 	
 	if reply.ID == "" {
-		reply.ID = fmt.Sprintf("reply_%d", time.Now().UnixNano())
+		reply.ID = id.New()
 	}
 	r.replies[reply.ID] = reply
 	return nil
@@ -315,16 +402,121 @@ func (r *MockSmartReplyRepository) Delete(ctx context.Context, id string) error
 	return nil
 }
 
+// MockIntentExampleRepository
+type MockIntentExampleRepository struct {
+	examples map[string]*domain.IntentExample
+	mu       sync.RWMutex
+}
+
+func NewMockIntentExampleRepository() domain.IntentExampleRepository {
+	return &MockIntentExampleRepository{
+		examples: make(map[string]*domain.IntentExample),
+	}
+}
+
+func (r *MockIntentExampleRepository) GetByID(ctx context.Context, id string) (*domain.IntentExample, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	example, exists := r.examples[id]
+	if !exists {
+		return nil, fmt.Errorf("intent example not found")
+	}
+	return example, nil
+}
+
+func (r *MockIntentExampleRepository) GetByBotID(ctx context.Context, botID string) ([]*domain.IntentExample, error) {
+	return r.filter(func(example *domain.IntentExample) bool { return example.BotID == botID }), nil
+}
+
+func (r *MockIntentExampleRepository) GetByIntent(ctx context.Context, botID, intent string) ([]*domain.IntentExample, error) {
+	return r.filter(func(example *domain.IntentExample) bool {
+		return example.BotID == botID && example.Intent == intent
+	}), nil
+}
+
+// filter devuelve los ejemplos que cumplen match en orden de alta
+func (r *MockIntentExampleRepository) filter(match func(example *domain.IntentExample) bool) []*domain.IntentExample {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	
+	var examples []*domain.IntentExample
+	for _, example := range r.examples {
+		if match(example) {
+			examples = append(examples, example)
+		}
+	}
+	sort.Slice(examples, func(i, j int) bool {
+		if !examples[i].CreatedAt.Equal(examples[j].CreatedAt) {
+			return examples[i].CreatedAt.Before(examples[j].CreatedAt)
+		}
+		return examples[i].ID < examples[j].ID
+	})
+	return examples
+}
+
+func (r *MockIntentExampleRepository) Create(ctx context.Context, example *domain.IntentExample) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if example.ID == "" {
+		example.ID = id.New()
+	}
+	r.examples[example.ID] = example
+	return nil
+}
+
+func (r *MockIntentExampleRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	if _, exists := r.examples[id]; !exists {
+		return fmt.Errorf("intent example not found")
+	}
+	delete(r.examples, id)
+	return nil
+}
+
+func (r *MockIntentExampleRepository) DeleteByIntent(ctx context.Context, botID, intent string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	
+	for key, example := range r.examples {
+		if example.BotID == botID && example.Intent == intent {
+			delete(r.examples, key)
+		}
+	}
+	return nil
+}
+
 // MockConversationSessionRepository
 type MockConversationSessionRepository struct {
 	sessions map[string]*domain.ConversationSession
-	mu       sync.RWMutex
+	// sizes resume cada sesión al guardarla: Stats no puede leer el contexto
+	// vivo porque quien procesa el mensaje lo modifica sin este lock
+	sizes map[string]sessionSize
+	mu    sync.RWMutex
+}
+
+type sessionSize struct {
+	botID       string
+	contextSize int
 }
 
 func NewMockConversationSessionRepository() domain.ConversationSessionRepository {
 	return &MockConversationSessionRepository{
 		sessions: make(map[string]*domain.ConversationSession),
+		sizes:    make(map[string]sessionSize),
+	}
+}
+
+// track guarda el tamaño del contexto mientras el que llama aún es su dueño
+func (r *MockConversationSessionRepository) track(session *domain.ConversationSession) {
+	size := sessionSize{botID: session.BotID}
+	if data, err := json.Marshal(session.Context); err == nil {
+		size.contextSize = len(data)
 	}
+	r.sizes[session.ID] = size
 }
 
 func (r *MockConversationSessionRepository) GetByID(ctx context.Context, id string) (*domain.ConversationSession, error) {
@@ -355,9 +547,10 @@ func (r *MockConversationSessionRepository) Create(ctx context.Context, session
 	defer r.mu.Unlock()
 	
 	if session.ID == "" {
-		session.ID = fmt.Sprintf("session_%d", time.Now().UnixNano())
+		session.ID = id.New()
 	}
 	r.sessions[session.ID] = session
+	r.track(session)
 	return nil
 }
 
@@ -369,6 +562,7 @@ func (r *MockConversationSessionRepository) Update(ctx context.Context, session
 		return fmt.Errorf("session not found")
 	}
 	r.sessions[session.ID] = session
+	r.track(session)
 	return nil
 }
 
@@ -377,6 +571,7 @@ func (r *MockConversationSessionRepository) Delete(ctx context.Context, id strin
 	defer r.mu.Unlock()
 	
 	delete(r.sessions, id)
+	delete(r.sizes, id)
 	return nil
 }
 
@@ -388,11 +583,57 @@ func (r *MockConversationSessionRepository) DeleteExpired(ctx context.Context) e
 	for id, session := range r.sessions {
 		if session.ExpiresAt.Before(now) {
 			delete(r.sessions, id)
+			delete(r.sizes, id)
 		}
 	}
 	return nil
 }
 
+func (r *MockConversationSessionRepository) GetInactiveSince(ctx context.Context, botID string, before time.Time) ([]*domain.ConversationSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*domain.ConversationSession
+	for _, session := range r.sessions {
+		if session.BotID == botID && session.EndedAt == nil && session.UpdatedAt.Before(before) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *MockConversationSessionRepository) GetHandoffs(ctx context.Context, botID string) ([]*domain.ConversationSession, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sessions []*domain.ConversationSession
+	for _, session := range r.sessions {
+		if session.BotID == botID && session.Handoff != nil {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Handoff.RequestedAt.Before(sessions[j].Handoff.RequestedAt)
+	})
+	return sessions, nil
+}
+
+func (r *MockConversationSessionRepository) Stats(ctx context.Context) (*domain.SessionStoreStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := &domain.SessionStoreStats{SessionsByBot: make(map[string]int)}
+	var contextBytes int
+	for _, size := range r.sizes {
+		stats.SessionsByBot[size.botID]++
+		contextBytes += size.contextSize
+	}
+	if len(r.sizes) > 0 {
+		stats.AverageContextSize = float64(contextBytes) / float64(len(r.sizes))
+	}
+	return stats, nil
+}
+
 // MockConditionalRepository implementa ConditionalRepository para testing
 type MockConditionalRepository struct {
 	conditionals map[string]*domain.Conditional
@@ -406,7 +647,7 @@ func NewMockConditionalRepository() domain.ConditionalRepository {
 
 func (r *MockConditionalRepository) Create(ctx context.Context, conditional *domain.Conditional) error {
 	if conditional.ID == "" {
-		conditional.ID = uuid.New().String()
+		conditional.ID = id.New()
 	}
 	r.conditionals[conditional.ID] = conditional
 	return nil
@@ -484,7 +725,7 @@ func NewMockTriggerRepository() domain.TriggerRepository {
 
 func (r *MockTriggerRepository) Create(ctx context.Context, trigger *domain.Trigger) error {
 	if trigger.ID == "" {
-		trigger.ID = uuid.New().String()
+		trigger.ID = id.New()
 	}
 	r.triggers[trigger.ID] = trigger
 	return nil
@@ -543,6 +784,16 @@ func (r *MockTriggerRepository) GetEnabledByBotID(ctx context.Context, botID str
 	return result, nil
 }
 
+func (r *MockTriggerRepository) GetEnabledByEvent(ctx context.Context, event domain.TriggerEvent) ([]*domain.Trigger, error) {
+	var result []*domain.Trigger
+	for _, trigger := range r.triggers {
+		if trigger.Enabled && trigger.Event == event {
+			result = append(result, trigger)
+		}
+	}
+	return result, nil
+}
+
 func (r *MockTriggerRepository) Execute(ctx context.Context, id string, eventData map[string]interface{}) error {
 	trigger, exists := r.triggers[id]
 	if !exists {
@@ -567,7 +818,7 @@ func NewMockTestCaseRepository() domain.TestCaseRepository {
 
 func (r *MockTestCaseRepository) Create(ctx context.Context, testCase *domain.TestCase) error {
 	if testCase.ID == "" {
-		testCase.ID = uuid.New().String()
+		testCase.ID = id.New()
 	}
 	r.testCases[testCase.ID] = testCase
 	return nil
@@ -667,7 +918,7 @@ func NewMockTestSuiteRepository() domain.TestSuiteRepository {
 
 func (r *MockTestSuiteRepository) Create(ctx context.Context, testSuite *domain.TestSuite) error {
 	if testSuite.ID == "" {
-		testSuite.ID = uuid.New().String()
+		testSuite.ID = id.New()
 	}
 	r.testSuites[testSuite.ID] = testSuite
 	return nil
@@ -778,4 +1029,1570 @@ func (r *MockTestSuiteRepository) AddTestCase(ctx context.Context, suiteID, test
 
 func (r *MockTestSuiteRepository) RemoveTestCase(ctx context.Context, suiteID, testCaseID string) error {
 	return r.RemoveTestCaseFromSuite(ctx, suiteID, testCaseID)
-}
\ No newline at end of file
+}
+
+// MockTestRunRepository implementa TestRunRepository para testing
+type MockTestRunRepository struct {
+	runs map[string]*domain.TestRun
+	mu   sync.RWMutex
+}
+
+func NewMockTestRunRepository() domain.TestRunRepository {
+	return &MockTestRunRepository{
+		runs: make(map[string]*domain.TestRun),
+	}
+}
+
+func (r *MockTestRunRepository) Create(ctx context.Context, run *domain.TestRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if run.ID == "" {
+		run.ID = id.New()
+	}
+	runCopy := *run
+	r.runs[run.ID] = &runCopy
+	return nil
+}
+
+func (r *MockTestRunRepository) GetByID(ctx context.Context, id string) (*domain.TestRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	run, exists := r.runs[id]
+	if !exists {
+		return nil, fmt.Errorf("test run not found")
+	}
+	runCopy := *run
+	return &runCopy, nil
+}
+
+func (r *MockTestRunRepository) GetBySuiteID(ctx context.Context, suiteID string, limit, offset int) ([]*domain.TestRun, int, error) {
+	return r.list(func(run *domain.TestRun) bool {
+		return run.Kind == domain.TestRunKindSuite && run.SuiteID == suiteID
+	}, limit, offset)
+}
+
+func (r *MockTestRunRepository) GetByTestCaseID(ctx context.Context, testCaseID string, limit, offset int) ([]*domain.TestRun, int, error) {
+	return r.list(func(run *domain.TestRun) bool {
+		return run.Kind == domain.TestRunKindCase && run.TestCaseID == testCaseID
+	}, limit, offset)
+}
+
+func (r *MockTestRunRepository) list(match func(*domain.TestRun) bool, limit, offset int) ([]*domain.TestRun, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var runs []*domain.TestRun
+	for _, run := range r.runs {
+		if match(run) {
+			runCopy := *run
+			runs = append(runs, &runCopy)
+		}
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].StartedAt.After(runs[j].StartedAt)
+	})
+
+	total := len(runs)
+	if offset >= total {
+		return []*domain.TestRun{}, total, nil
+	}
+	runs = runs[offset:]
+	if limit > 0 && limit < len(runs) {
+		runs = runs[:limit]
+	}
+	return runs, total, nil
+}
+
+// MockMultiTurnTestCaseRepository implementa MultiTurnTestCaseRepository para testing
+type MockMultiTurnTestCaseRepository struct {
+	testCases map[string]*domain.MultiTurnTestCase
+	mu        sync.RWMutex
+}
+
+func NewMockMultiTurnTestCaseRepository() domain.MultiTurnTestCaseRepository {
+	return &MockMultiTurnTestCaseRepository{
+		testCases: make(map[string]*domain.MultiTurnTestCase),
+	}
+}
+
+func (r *MockMultiTurnTestCaseRepository) GetByID(ctx context.Context, id string) (*domain.MultiTurnTestCase, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	testCase, exists := r.testCases[id]
+	if !exists {
+		return nil, fmt.Errorf("multi-turn test case not found")
+	}
+	testCaseCopy := *testCase
+	return &testCaseCopy, nil
+}
+
+func (r *MockMultiTurnTestCaseRepository) GetByBotID(ctx context.Context, botID string) ([]*domain.MultiTurnTestCase, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*domain.MultiTurnTestCase
+	for _, testCase := range r.testCases {
+		if testCase.BotID == botID {
+			testCaseCopy := *testCase
+			result = append(result, &testCaseCopy)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r *MockMultiTurnTestCaseRepository) Create(ctx context.Context, testCase *domain.MultiTurnTestCase) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if testCase.ID == "" {
+		testCase.ID = id.New()
+	}
+	testCaseCopy := *testCase
+	r.testCases[testCase.ID] = &testCaseCopy
+	return nil
+}
+
+func (r *MockMultiTurnTestCaseRepository) Update(ctx context.Context, testCase *domain.MultiTurnTestCase) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.testCases[testCase.ID]; !exists {
+		return fmt.Errorf("multi-turn test case not found")
+	}
+	testCaseCopy := *testCase
+	r.testCases[testCase.ID] = &testCaseCopy
+	return nil
+}
+
+func (r *MockMultiTurnTestCaseRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.testCases[id]; !exists {
+		return fmt.Errorf("multi-turn test case not found")
+	}
+	delete(r.testCases, id)
+	return nil
+}
+
+// MockTaskRepository
+type MockTaskRepository struct {
+	tasks map[string]*domain.AsyncTask
+	mu    sync.RWMutex
+}
+
+func NewMockTaskRepository() domain.TaskRepository {
+	return &MockTaskRepository{
+		tasks: make(map[string]*domain.AsyncTask),
+	}
+}
+
+func (r *MockTaskRepository) GetByID(ctx context.Context, id string) (*domain.AsyncTask, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, exists := r.tasks[id]
+	if !exists {
+		return nil, fmt.Errorf("task not found")
+	}
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (r *MockTaskRepository) GetByStatus(ctx context.Context, statuses ...domain.TaskStatus) ([]*domain.AsyncTask, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tasks []*domain.AsyncTask
+	for _, task := range r.tasks {
+		for _, status := range statuses {
+			if task.Status == status {
+				taskCopy := *task
+				tasks = append(tasks, &taskCopy)
+				break
+			}
+		}
+	}
+	sortTasksByCreation(tasks)
+	return tasks, nil
+}
+
+func (r *MockTaskRepository) Create(ctx context.Context, task *domain.AsyncTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if task.ID == "" {
+		task.ID = id.New()
+	}
+	taskCopy := *task
+	r.tasks[task.ID] = &taskCopy
+	return nil
+}
+
+func (r *MockTaskRepository) Update(ctx context.Context, task *domain.AsyncTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tasks[task.ID]; !exists {
+		return fmt.Errorf("task not found")
+	}
+	taskCopy := *task
+	r.tasks[task.ID] = &taskCopy
+	return nil
+}
+
+func (r *MockTaskRepository) List(ctx context.Context, filter domain.TaskFilter) ([]*domain.AsyncTask, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]*domain.AsyncTask, 0)
+	for _, task := range r.tasks {
+		if !matchesTask(task, filter) {
+			continue
+		}
+		taskCopy := *task
+		tasks = append(tasks, &taskCopy)
+	}
+	sortTasksByCreation(tasks)
+
+	if filter.Offset > 0 {
+		if filter.Offset >= len(tasks) {
+			return []*domain.AsyncTask{}, nil
+		}
+		tasks = tasks[filter.Offset:]
+	}
+	if filter.Limit > 0 && filter.Limit < len(tasks) {
+		tasks = tasks[:filter.Limit]
+	}
+	return tasks, nil
+}
+
+func matchesTask(task *domain.AsyncTask, filter domain.TaskFilter) bool {
+	switch {
+	case filter.Status != "" && task.Status != filter.Status,
+		filter.Type != "" && task.Type != filter.Type,
+		filter.UserID != "" && task.UserID != filter.UserID,
+		filter.BotID != "" && task.BotID != filter.BotID,
+		filter.From != nil && task.CreatedAt.Before(*filter.From),
+		filter.To != nil && task.CreatedAt.After(*filter.To):
+		return false
+	}
+	return true
+}
+
+func (r *MockTaskRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	deleted := 0
+	for id, task := range r.tasks {
+		switch task.Status {
+		case domain.TaskStatusCompleted, domain.TaskStatusFailed, domain.TaskStatusCancelled:
+			if task.CompletedAt.Before(before) {
+				delete(r.tasks, id)
+				deleted++
+			}
+		}
+	}
+	return deleted, nil
+}
+
+// MockDeadLetterRepository
+type MockDeadLetterRepository struct {
+	tasks map[string]*domain.DeadLetterTask
+	mu    sync.RWMutex
+}
+
+func NewMockDeadLetterRepository() domain.DeadLetterRepository {
+	return &MockDeadLetterRepository{
+		tasks: make(map[string]*domain.DeadLetterTask),
+	}
+}
+
+func (r *MockDeadLetterRepository) GetByID(ctx context.Context, id string) (*domain.DeadLetterTask, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	task, exists := r.tasks[id]
+	if !exists {
+		return nil, fmt.Errorf("dead letter task not found")
+	}
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+func (r *MockDeadLetterRepository) Create(ctx context.Context, task *domain.DeadLetterTask) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if task.ID == "" {
+		task.ID = id.New()
+	}
+	taskCopy := *task
+	r.tasks[task.ID] = &taskCopy
+	return nil
+}
+
+func (r *MockDeadLetterRepository) List(ctx context.Context, limit, offset int) ([]*domain.DeadLetterTask, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tasks := make([]*domain.DeadLetterTask, 0, len(r.tasks))
+	for _, task := range r.tasks {
+		taskCopy := *task
+		tasks = append(tasks, &taskCopy)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].DeadLetteredAt.After(tasks[j].DeadLetteredAt)
+	})
+
+	if offset > 0 {
+		if offset >= len(tasks) {
+			return []*domain.DeadLetterTask{}, nil
+		}
+		tasks = tasks[offset:]
+	}
+	if limit > 0 && limit < len(tasks) {
+		tasks = tasks[:limit]
+	}
+	return tasks, nil
+}
+
+func (r *MockDeadLetterRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tasks[id]; !exists {
+		return fmt.Errorf("dead letter task not found")
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+func (r *MockDeadLetterRepository) DeleteAll(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := len(r.tasks)
+	r.tasks = make(map[string]*domain.DeadLetterTask)
+	return count, nil
+}
+
+func (r *MockDeadLetterRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.tasks), nil
+}
+
+// MockFAQRepository
+type MockFAQRepository struct {
+	entries map[string]*domain.FAQEntry
+	mu      sync.RWMutex
+}
+
+func NewMockFAQRepository() domain.FAQRepository {
+	return &MockFAQRepository{
+		entries: make(map[string]*domain.FAQEntry),
+	}
+}
+
+func (r *MockFAQRepository) GetByID(ctx context.Context, id string) (*domain.FAQEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, exists := r.entries[id]
+	if !exists {
+		return nil, fmt.Errorf("faq entry not found")
+	}
+	entryCopy := *entry
+	return &entryCopy, nil
+}
+
+func (r *MockFAQRepository) GetByBotID(ctx context.Context, botID string) ([]*domain.FAQEntry, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var entries []*domain.FAQEntry
+	for _, entry := range r.entries {
+		if entry.BotID == botID {
+			entryCopy := *entry
+			entries = append(entries, &entryCopy)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].CreatedAt.Before(entries[j].CreatedAt)
+	})
+	return entries, nil
+}
+
+func (r *MockFAQRepository) Create(ctx context.Context, entry *domain.FAQEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if entry.ID == "" {
+		entry.ID = id.New()
+	}
+	entryCopy := *entry
+	r.entries[entry.ID] = &entryCopy
+	return nil
+}
+
+func (r *MockFAQRepository) Update(ctx context.Context, entry *domain.FAQEntry) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.entries[entry.ID]; !exists {
+		return fmt.Errorf("faq entry not found")
+	}
+	entryCopy := *entry
+	r.entries[entry.ID] = &entryCopy
+	return nil
+}
+
+func (r *MockFAQRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.entries[id]; !exists {
+		return fmt.Errorf("faq entry not found")
+	}
+	delete(r.entries, id)
+	return nil
+}
+
+// MockUnansweredQuestionRepository
+type MockUnansweredQuestionRepository struct {
+	questions map[string]*domain.UnansweredQuestion
+	mu        sync.RWMutex
+}
+
+func NewMockUnansweredQuestionRepository() domain.UnansweredQuestionRepository {
+	return &MockUnansweredQuestionRepository{
+		questions: make(map[string]*domain.UnansweredQuestion),
+	}
+}
+
+func (r *MockUnansweredQuestionRepository) Create(ctx context.Context, question *domain.UnansweredQuestion) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if question.ID == "" {
+		question.ID = id.New()
+	}
+	questionCopy := *question
+	r.questions[question.ID] = &questionCopy
+	return nil
+}
+
+func (r *MockUnansweredQuestionRepository) GetByBotID(ctx context.Context, botID string) ([]*domain.UnansweredQuestion, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var questions []*domain.UnansweredQuestion
+	for _, question := range r.questions {
+		if question.BotID == botID {
+			questionCopy := *question
+			questions = append(questions, &questionCopy)
+		}
+	}
+	sort.Slice(questions, func(i, j int) bool {
+		return questions[i].CreatedAt.Before(questions[j].CreatedAt)
+	})
+	return questions, nil
+}
+
+func (r *MockUnansweredQuestionRepository) DeleteByBotID(ctx context.Context, botID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for id, question := range r.questions {
+		if question.BotID == botID {
+			delete(r.questions, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// MockKnowledgeSourceRepository
+type MockKnowledgeSourceRepository struct {
+	sources map[string]*domain.KnowledgeSource
+	mu      sync.RWMutex
+}
+
+func NewMockKnowledgeSourceRepository() domain.KnowledgeSourceRepository {
+	return &MockKnowledgeSourceRepository{
+		sources: make(map[string]*domain.KnowledgeSource),
+	}
+}
+
+func (r *MockKnowledgeSourceRepository) GetByID(ctx context.Context, id string) (*domain.KnowledgeSource, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	source, exists := r.sources[id]
+	if !exists {
+		return nil, fmt.Errorf("knowledge source not found")
+	}
+	sourceCopy := *source
+	return &sourceCopy, nil
+}
+
+func (r *MockKnowledgeSourceRepository) GetByBotID(ctx context.Context, botID string) ([]*domain.KnowledgeSource, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sources []*domain.KnowledgeSource
+	for _, source := range r.sources {
+		if source.BotID == botID {
+			sourceCopy := *source
+			sources = append(sources, &sourceCopy)
+		}
+	}
+	sort.Slice(sources, func(i, j int) bool {
+		return sources[i].CreatedAt.Before(sources[j].CreatedAt)
+	})
+	return sources, nil
+}
+
+func (r *MockKnowledgeSourceRepository) GetEnabled(ctx context.Context) ([]*domain.KnowledgeSource, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var sources []*domain.KnowledgeSource
+	for _, source := range r.sources {
+		if source.Enabled {
+			sourceCopy := *source
+			sources = append(sources, &sourceCopy)
+		}
+	}
+	return sources, nil
+}
+
+func (r *MockKnowledgeSourceRepository) Create(ctx context.Context, source *domain.KnowledgeSource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if source.ID == "" {
+		source.ID = id.New()
+	}
+	sourceCopy := *source
+	r.sources[source.ID] = &sourceCopy
+	return nil
+}
+
+func (r *MockKnowledgeSourceRepository) Update(ctx context.Context, source *domain.KnowledgeSource) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sources[source.ID]; !exists {
+		return fmt.Errorf("knowledge source not found")
+	}
+	sourceCopy := *source
+	r.sources[source.ID] = &sourceCopy
+	return nil
+}
+
+func (r *MockKnowledgeSourceRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sources[id]; !exists {
+		return fmt.Errorf("knowledge source not found")
+	}
+	delete(r.sources, id)
+	return nil
+}
+
+// MockKnowledgeDocumentRepository
+type MockKnowledgeDocumentRepository struct {
+	documents map[string]*domain.KnowledgeDocument
+	mu        sync.RWMutex
+}
+
+func NewMockKnowledgeDocumentRepository() domain.KnowledgeDocumentRepository {
+	return &MockKnowledgeDocumentRepository{
+		documents: make(map[string]*domain.KnowledgeDocument),
+	}
+}
+
+func (r *MockKnowledgeDocumentRepository) GetBySourceID(ctx context.Context, sourceID string) ([]*domain.KnowledgeDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var documents []*domain.KnowledgeDocument
+	for _, document := range r.documents {
+		if document.SourceID == sourceID {
+			documentCopy := *document
+			documents = append(documents, &documentCopy)
+		}
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		return documents[i].Title < documents[j].Title
+	})
+	return documents, nil
+}
+
+func (r *MockKnowledgeDocumentRepository) GetByBotID(ctx context.Context, botID string) ([]*domain.KnowledgeDocument, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var documents []*domain.KnowledgeDocument
+	for _, document := range r.documents {
+		if document.BotID == botID {
+			documentCopy := *document
+			documents = append(documents, &documentCopy)
+		}
+	}
+	return documents, nil
+}
+
+func (r *MockKnowledgeDocumentRepository) Save(ctx context.Context, document *domain.KnowledgeDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if document.ID == "" {
+		document.ID = id.New()
+	}
+	documentCopy := *document
+	r.documents[document.ID] = &documentCopy
+	return nil
+}
+
+func (r *MockKnowledgeDocumentRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.documents[id]; !exists {
+		return fmt.Errorf("knowledge document not found")
+	}
+	delete(r.documents, id)
+	return nil
+}
+
+func (r *MockKnowledgeDocumentRepository) DeleteBySourceID(ctx context.Context, sourceID string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for id, document := range r.documents {
+		if document.SourceID == sourceID {
+			delete(r.documents, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// sortTasksByCreation ordena las tareas de la más reciente a la más antigua
+func sortTasksByCreation(tasks []*domain.AsyncTask) {
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.After(tasks[j].CreatedAt)
+	})
+}
+
+// MockAuditRepository guarda los registros de auditoría en memoria, del más
+// reciente al más antiguo
+type MockAuditRepository struct {
+	logs []*domain.AuditLog
+	mu   sync.RWMutex
+}
+
+func NewMockAuditRepository() domain.AuditRepository {
+	return &MockAuditRepository{}
+}
+
+func (r *MockAuditRepository) Create(ctx context.Context, log *domain.AuditLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if log.ID == "" {
+		log.ID = id.New()
+	}
+	if log.CreatedAt.IsZero() {
+		log.CreatedAt = time.Now()
+	}
+	logCopy := *log
+	r.logs = append(r.logs, &logCopy)
+	sort.SliceStable(r.logs, func(i, j int) bool {
+		return r.logs[i].CreatedAt.After(r.logs[j].CreatedAt)
+	})
+	return nil
+}
+
+func (r *MockAuditRepository) GetByUserID(ctx context.Context, userID string, limit, offset int) ([]*domain.AuditLog, error) {
+	return r.find(func(log *domain.AuditLog) bool { return log.UserID == userID }, limit, offset), nil
+}
+
+func (r *MockAuditRepository) GetByAction(ctx context.Context, action string, limit, offset int) ([]*domain.AuditLog, error) {
+	return r.find(func(log *domain.AuditLog) bool { return log.Action == action }, limit, offset), nil
+}
+
+func (r *MockAuditRepository) List(ctx context.Context, limit, offset int) ([]*domain.AuditLog, error) {
+	return r.find(func(*domain.AuditLog) bool { return true }, limit, offset), nil
+}
+
+func (r *MockAuditRepository) find(match func(log *domain.AuditLog) bool, limit, offset int) []*domain.AuditLog {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	logs := make([]*domain.AuditLog, 0)
+	for _, log := range r.logs {
+		if !match(log) {
+			continue
+		}
+		if offset > 0 {
+			offset--
+			continue
+		}
+		logCopy := *log
+		logs = append(logs, &logCopy)
+		if limit > 0 && len(logs) == limit {
+			break
+		}
+	}
+	return logs
+}
+
+// MockAPIKeyRepository
+type MockAPIKeyRepository struct {
+	keys map[string]*domain.APIKey
+	mu   sync.RWMutex
+}
+
+func NewMockAPIKeyRepository() domain.APIKeyRepository {
+	return &MockAPIKeyRepository{
+		keys: make(map[string]*domain.APIKey),
+	}
+}
+
+func (r *MockAPIKeyRepository) GetByID(ctx context.Context, id string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	key, exists := r.keys[id]
+	if !exists {
+		return nil, fmt.Errorf("api key not found")
+	}
+	keyCopy := *key
+	return &keyCopy, nil
+}
+
+func (r *MockAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, key := range r.keys {
+		if key.KeyHash == hash {
+			keyCopy := *key
+			return &keyCopy, nil
+		}
+	}
+	return nil, fmt.Errorf("api key not found")
+}
+
+func (r *MockAPIKeyRepository) Create(ctx context.Context, key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if key.ID == "" {
+		key.ID = id.New()
+	}
+	keyCopy := *key
+	r.keys[key.ID] = &keyCopy
+	return nil
+}
+
+func (r *MockAPIKeyRepository) Update(ctx context.Context, key *domain.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.keys[key.ID]; !exists {
+		return fmt.Errorf("api key not found")
+	}
+	keyCopy := *key
+	r.keys[key.ID] = &keyCopy
+	return nil
+}
+
+func (r *MockAPIKeyRepository) List(ctx context.Context) ([]*domain.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]*domain.APIKey, 0, len(r.keys))
+	for _, key := range r.keys {
+		keyCopy := *key
+		keys = append(keys, &keyCopy)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.After(keys[j].CreatedAt)
+	})
+	return keys, nil
+}
+
+// MockMCPAgentRepository
+type MockMCPAgentRepository struct {
+	agents map[string]*domain.MCPAgent
+	mu     sync.RWMutex
+}
+
+func NewMockMCPAgentRepository() domain.MCPAgentRepository {
+	return &MockMCPAgentRepository{
+		agents: make(map[string]*domain.MCPAgent),
+	}
+}
+
+func copyMCPAgent(agent *domain.MCPAgent) *domain.MCPAgent {
+	agentCopy := *agent
+	agentCopy.Capabilities = append([]string(nil), agent.Capabilities...)
+	if agent.Metrics != nil {
+		metrics := *agent.Metrics
+		agentCopy.Metrics = &metrics
+	}
+	return &agentCopy
+}
+
+func (r *MockMCPAgentRepository) GetByID(ctx context.Context, id string) (*domain.MCPAgent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	agent, exists := r.agents[id]
+	if !exists {
+		return nil, fmt.Errorf("mcp agent not found")
+	}
+	return copyMCPAgent(agent), nil
+}
+
+func (r *MockMCPAgentRepository) Create(ctx context.Context, agent *domain.MCPAgent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if agent.ID == "" {
+		agent.ID = id.New()
+	}
+	if _, exists := r.agents[agent.ID]; exists {
+		return fmt.Errorf("mcp agent already exists")
+	}
+	r.agents[agent.ID] = copyMCPAgent(agent)
+	return nil
+}
+
+func (r *MockMCPAgentRepository) Update(ctx context.Context, agent *domain.MCPAgent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.agents[agent.ID]; !exists {
+		return fmt.Errorf("mcp agent not found")
+	}
+	r.agents[agent.ID] = copyMCPAgent(agent)
+	return nil
+}
+
+func (r *MockMCPAgentRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.agents[id]; !exists {
+		return fmt.Errorf("mcp agent not found")
+	}
+	delete(r.agents, id)
+	return nil
+}
+
+func (r *MockMCPAgentRepository) List(ctx context.Context) ([]*domain.MCPAgent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	agents := make([]*domain.MCPAgent, 0, len(r.agents))
+	for _, agent := range r.agents {
+		agents = append(agents, copyMCPAgent(agent))
+	}
+	sort.Slice(agents, func(i, j int) bool {
+		if !agents[i].CreatedAt.Equal(agents[j].CreatedAt) {
+			return agents[i].CreatedAt.Before(agents[j].CreatedAt)
+		}
+		return agents[i].ID < agents[j].ID
+	})
+	return agents, nil
+}
+
+// MockWebhookSubscriptionRepository
+type MockWebhookSubscriptionRepository struct {
+	subscriptions map[string]*domain.WebhookSubscription
+	mu            sync.RWMutex
+}
+
+func NewMockWebhookSubscriptionRepository() domain.WebhookSubscriptionRepository {
+	return &MockWebhookSubscriptionRepository{
+		subscriptions: make(map[string]*domain.WebhookSubscription),
+	}
+}
+
+func copyWebhookSubscription(subscription *domain.WebhookSubscription) *domain.WebhookSubscription {
+	subscriptionCopy := *subscription
+	subscriptionCopy.Events = append([]string(nil), subscription.Events...)
+	return &subscriptionCopy
+}
+
+func (r *MockWebhookSubscriptionRepository) GetByID(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscription, exists := r.subscriptions[id]
+	if !exists {
+		return nil, fmt.Errorf("webhook subscription not found")
+	}
+	return copyWebhookSubscription(subscription), nil
+}
+
+func (r *MockWebhookSubscriptionRepository) Create(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if subscription.ID == "" {
+		subscription.ID = id.New()
+	}
+	r.subscriptions[subscription.ID] = copyWebhookSubscription(subscription)
+	return nil
+}
+
+func (r *MockWebhookSubscriptionRepository) Update(ctx context.Context, subscription *domain.WebhookSubscription) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.subscriptions[subscription.ID]; !exists {
+		return fmt.Errorf("webhook subscription not found")
+	}
+	r.subscriptions[subscription.ID] = copyWebhookSubscription(subscription)
+	return nil
+}
+
+func (r *MockWebhookSubscriptionRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.subscriptions[id]; !exists {
+		return fmt.Errorf("webhook subscription not found")
+	}
+	delete(r.subscriptions, id)
+	return nil
+}
+
+func (r *MockWebhookSubscriptionRepository) List(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	subscriptions := make([]*domain.WebhookSubscription, 0, len(r.subscriptions))
+	for _, subscription := range r.subscriptions {
+		subscriptions = append(subscriptions, copyWebhookSubscription(subscription))
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.After(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+// webhookDeliveriesPerSubscription limita el registro de entregas que se
+// conserva por suscripción; las más antiguas se descartan
+const webhookDeliveriesPerSubscription = 500
+
+// MockWebhookDeliveryRepository
+type MockWebhookDeliveryRepository struct {
+	deliveries map[string]*domain.WebhookDelivery
+	// bySubscription guarda los IDs en orden de creación
+	bySubscription map[string][]string
+	mu             sync.RWMutex
+}
+
+func NewMockWebhookDeliveryRepository() domain.WebhookDeliveryRepository {
+	return &MockWebhookDeliveryRepository{
+		deliveries:     make(map[string]*domain.WebhookDelivery),
+		bySubscription: make(map[string][]string),
+	}
+}
+
+func (r *MockWebhookDeliveryRepository) GetByID(ctx context.Context, id string) (*domain.WebhookDelivery, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	delivery, exists := r.deliveries[id]
+	if !exists {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	deliveryCopy := *delivery
+	return &deliveryCopy, nil
+}
+
+func (r *MockWebhookDeliveryRepository) Create(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if delivery.ID == "" {
+		delivery.ID = id.New()
+	}
+	deliveryCopy := *delivery
+	r.deliveries[delivery.ID] = &deliveryCopy
+
+	ids := append(r.bySubscription[delivery.SubscriptionID], delivery.ID)
+	if excess := len(ids) - webhookDeliveriesPerSubscription; excess > 0 {
+		for _, id := range ids[:excess] {
+			delete(r.deliveries, id)
+		}
+		ids = append([]string(nil), ids[excess:]...)
+	}
+	r.bySubscription[delivery.SubscriptionID] = ids
+	return nil
+}
+
+func (r *MockWebhookDeliveryRepository) Update(ctx context.Context, delivery *domain.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.deliveries[delivery.ID]; !exists {
+		return fmt.Errorf("webhook delivery not found")
+	}
+	deliveryCopy := *delivery
+	r.deliveries[delivery.ID] = &deliveryCopy
+	return nil
+}
+
+func (r *MockWebhookDeliveryRepository) GetBySubscriptionID(ctx context.Context, subscriptionID string, limit, offset int) ([]*domain.WebhookDelivery, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := r.bySubscription[subscriptionID]
+	total := len(ids)
+	deliveries := make([]*domain.WebhookDelivery, 0)
+	for i := total - 1 - offset; i >= 0; i-- {
+		if limit > 0 && len(deliveries) == limit {
+			break
+		}
+		deliveryCopy := *r.deliveries[ids[i]]
+		deliveries = append(deliveries, &deliveryCopy)
+	}
+	return deliveries, total, nil
+}
+
+func (r *MockWebhookDeliveryRepository) DeleteBySubscriptionID(ctx context.Context, subscriptionID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range r.bySubscription[subscriptionID] {
+		delete(r.deliveries, id)
+	}
+	delete(r.bySubscription, subscriptionID)
+	return nil
+}
+
+// MockAgentExecutionRepository guarda las ejecuciones capturadas en orden de
+// captura. Con maxRecords > 0 descarta las más antiguas al superarlo; la
+// retención por antigüedad la aplica el servicio con DeleteBefore.
+type MockAgentExecutionRepository struct {
+	executions []*domain.AgentExecution
+	maxRecords int
+	mu         sync.RWMutex
+}
+
+func NewMockAgentExecutionRepository(maxRecords int) domain.AgentExecutionRepository {
+	return &MockAgentExecutionRepository{maxRecords: maxRecords}
+}
+
+func (r *MockAgentExecutionRepository) GetByID(ctx context.Context, id string) (*domain.AgentExecution, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, execution := range r.executions {
+		if execution.ID == id {
+			executionCopy := *execution
+			return &executionCopy, nil
+		}
+	}
+	return nil, fmt.Errorf("agent execution not found")
+}
+
+func (r *MockAgentExecutionRepository) Create(ctx context.Context, execution *domain.AgentExecution) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if execution.ID == "" {
+		execution.ID = id.New()
+	}
+	executionCopy := *execution
+	r.executions = append(r.executions, &executionCopy)
+	if excess := len(r.executions) - r.maxRecords; r.maxRecords > 0 && excess > 0 {
+		r.executions = append([]*domain.AgentExecution(nil), r.executions[excess:]...)
+	}
+	return nil
+}
+
+func (r *MockAgentExecutionRepository) Search(ctx context.Context, filter domain.AgentExecutionFilter) ([]*domain.AgentExecution, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	executions := make([]*domain.AgentExecution, 0)
+	total := 0
+	for i := len(r.executions) - 1; i >= 0; i-- {
+		execution := r.executions[i]
+		if !matchesAgentExecution(execution, filter) {
+			continue
+		}
+		total++
+		if total <= filter.Offset || (filter.Limit > 0 && len(executions) == filter.Limit) {
+			continue
+		}
+		executionCopy := *execution
+		executions = append(executions, &executionCopy)
+	}
+	return executions, total, nil
+}
+
+func matchesAgentExecution(execution *domain.AgentExecution, filter domain.AgentExecutionFilter) bool {
+	switch {
+	case filter.BotID != "" && execution.BotID != filter.BotID,
+		filter.TaskType != "" && execution.TaskType != filter.TaskType,
+		filter.AgentType != "" && execution.AgentType != filter.AgentType,
+		filter.From != nil && execution.CapturedAt.Before(*filter.From),
+		filter.To != nil && !execution.CapturedAt.Before(*filter.To),
+		filter.Success != nil && execution.Success != *filter.Success:
+		return false
+	}
+	return true
+}
+
+func (r *MockAgentExecutionRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.executions[:0]
+	for _, execution := range r.executions {
+		if !execution.CapturedAt.Before(cutoff) {
+			kept = append(kept, execution)
+		}
+	}
+	deleted := len(r.executions) - len(kept)
+	clear(r.executions[len(kept):])
+	r.executions = kept
+	return deleted, nil
+}
+
+// MockConversationMessageRepository guarda los mensajes de cada sesión en
+// orden cronológico junto con el resumen de la conversación
+type MockConversationMessageRepository struct {
+	bySession map[string][]*domain.ConversationMessage
+	threads   map[string]*domain.ConversationThread
+	mu        sync.RWMutex
+}
+
+func NewMockConversationMessageRepository() domain.ConversationMessageRepository {
+	return &MockConversationMessageRepository{
+		bySession: make(map[string][]*domain.ConversationMessage),
+		threads:   make(map[string]*domain.ConversationThread),
+	}
+}
+
+func (r *MockConversationMessageRepository) Create(ctx context.Context, message *domain.ConversationMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if message.ID == "" {
+		message.ID = id.New()
+	}
+	if message.Timestamp.IsZero() {
+		message.Timestamp = time.Now()
+	}
+	messageCopy := *message
+
+	messages := r.bySession[message.SessionID]
+	position := sort.Search(len(messages), func(i int) bool { return messages[i].Timestamp.After(message.Timestamp) })
+	messages = append(messages, nil)
+	copy(messages[position+1:], messages[position:])
+	messages[position] = &messageCopy
+	r.bySession[message.SessionID] = messages
+
+	thread, exists := r.threads[message.SessionID]
+	if !exists {
+		thread = &domain.ConversationThread{SessionID: message.SessionID, BotID: message.BotID, UserID: message.UserID}
+		r.threads[message.SessionID] = thread
+	}
+	thread.MessageCount = len(messages)
+	thread.StartedAt = messages[0].Timestamp
+	thread.LastMessageAt = messages[len(messages)-1].Timestamp
+	thread.LastMessage = messages[len(messages)-1].Content
+	return nil
+}
+
+func (r *MockConversationMessageRepository) GetConversation(ctx context.Context, sessionID string) (*domain.ConversationThread, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	thread, exists := r.threads[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("conversation not found")
+	}
+	threadCopy := *thread
+	return &threadCopy, nil
+}
+
+func (r *MockConversationMessageRepository) ListConversations(ctx context.Context, botID string, filter domain.ConversationHistoryFilter) ([]*domain.ConversationThread, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := make([]*domain.ConversationThread, 0)
+	for _, thread := range r.threads {
+		switch {
+		case thread.BotID != botID,
+			filter.UserID != "" && thread.UserID != filter.UserID,
+			filter.From != nil && thread.LastMessageAt.Before(*filter.From),
+			filter.To != nil && !thread.StartedAt.Before(*filter.To):
+			continue
+		}
+		threadCopy := *thread
+		matches = append(matches, &threadCopy)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].LastMessageAt.After(matches[j].LastMessageAt) })
+	return paginate(matches, filter.Limit, filter.Offset), len(matches), nil
+}
+
+func (r *MockConversationMessageRepository) GetBySessionID(ctx context.Context, sessionID string, filter domain.ConversationHistoryFilter) ([]*domain.ConversationMessage, int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := make([]*domain.ConversationMessage, 0)
+	for _, message := range r.bySession[sessionID] {
+		if (filter.From != nil && message.Timestamp.Before(*filter.From)) || (filter.To != nil && !message.Timestamp.Before(*filter.To)) {
+			continue
+		}
+		messageCopy := *message
+		matches = append(matches, &messageCopy)
+	}
+	return paginate(matches, filter.Limit, filter.Offset), len(matches), nil
+}
+
+// paginate devuelve la página de items indicada por limit y offset; limit <= 0 no limita
+func paginate[T any](items []T, limit, offset int) []T {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(items) {
+		return items[:0]
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	return items
+}
+
+// MockAnalyticsRepository guarda los contadores diarios por bot y fecha
+type MockAnalyticsRepository struct {
+	days map[string]map[string]*domain.BotDailyStats
+	mu   sync.RWMutex
+}
+
+func NewMockAnalyticsRepository() domain.AnalyticsRepository {
+	return &MockAnalyticsRepository{days: make(map[string]map[string]*domain.BotDailyStats)}
+}
+
+func (r *MockAnalyticsRepository) Increment(ctx context.Context, delta *domain.BotDailyStats) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	days, exists := r.days[delta.BotID]
+	if !exists {
+		days = make(map[string]*domain.BotDailyStats)
+		r.days[delta.BotID] = days
+	}
+	stats, exists := days[delta.Date]
+	if !exists {
+		stats = &domain.BotDailyStats{BotID: delta.BotID, Date: delta.Date}
+		days[delta.Date] = stats
+	}
+
+	stats.MessagesReceived += delta.MessagesReceived
+	stats.MessagesSent += delta.MessagesSent
+	stats.SessionsStarted += delta.SessionsStarted
+	stats.ConversationsCompleted += delta.ConversationsCompleted
+	stats.Handoffs += delta.Handoffs
+	stats.ConfidenceSum += delta.ConfidenceSum
+	stats.ConfidenceCount += delta.ConfidenceCount
+	stats.SentimentSum += delta.SentimentSum
+	stats.SentimentCount += delta.SentimentCount
+	stats.NegativeMessages += delta.NegativeMessages
+	for userID := range delta.UserIDs {
+		if stats.UserIDs == nil {
+			stats.UserIDs = make(map[string]bool)
+		}
+		stats.UserIDs[userID] = true
+	}
+	for intent, count := range delta.Intents {
+		if stats.Intents == nil {
+			stats.Intents = make(map[string]int)
+		}
+		stats.Intents[intent] += count
+	}
+	return nil
+}
+
+func (r *MockAnalyticsRepository) GetRange(ctx context.Context, botID, from, to string) ([]*domain.BotDailyStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*domain.BotDailyStats, 0)
+	for date, stats := range r.days[botID] {
+		if date < from || date > to {
+			continue
+		}
+		statsCopy := *stats
+		statsCopy.UserIDs = make(map[string]bool, len(stats.UserIDs))
+		for userID := range stats.UserIDs {
+			statsCopy.UserIDs[userID] = true
+		}
+		statsCopy.Intents = make(map[string]int, len(stats.Intents))
+		for intent, count := range stats.Intents {
+			statsCopy.Intents[intent] = count
+		}
+		result = append(result, &statsCopy)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// MockUsageRepository guarda el consumo de IA por bot y fecha
+type MockUsageRepository struct {
+	days map[string]map[string]*domain.BotDailyUsage
+	mu   sync.RWMutex
+}
+
+func NewMockUsageRepository() domain.UsageRepository {
+	return &MockUsageRepository{days: make(map[string]map[string]*domain.BotDailyUsage)}
+}
+
+func (r *MockUsageRepository) Increment(ctx context.Context, delta *domain.BotDailyUsage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	days, exists := r.days[delta.BotID]
+	if !exists {
+		days = make(map[string]*domain.BotDailyUsage)
+		r.days[delta.BotID] = days
+	}
+	usage, exists := days[delta.Date]
+	if !exists {
+		usage = &domain.BotDailyUsage{BotID: delta.BotID, Date: delta.Date}
+		days[delta.Date] = usage
+	}
+
+	if delta.OwnerID != "" {
+		usage.OwnerID = delta.OwnerID
+	}
+	usage.Requests += delta.Requests
+	usage.PromptTokens += delta.PromptTokens
+	usage.CompletionTokens += delta.CompletionTokens
+	usage.Cost += delta.Cost
+	usage.BudgetBlocked += delta.BudgetBlocked
+	for model, tokens := range delta.Models {
+		if usage.Models == nil {
+			usage.Models = make(map[string]int)
+		}
+		usage.Models[model] += tokens
+	}
+	return nil
+}
+
+func (r *MockUsageRepository) GetRange(ctx context.Context, botID, from, to string) ([]*domain.BotDailyUsage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*domain.BotDailyUsage, 0)
+	for date, usage := range r.days[botID] {
+		if date < from || date > to {
+			continue
+		}
+		usageCopy := *usage
+		usageCopy.Models = make(map[string]int, len(usage.Models))
+		for model, tokens := range usage.Models {
+			usageCopy.Models[model] = tokens
+		}
+		result = append(result, &usageCopy)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Date < result[j].Date })
+	return result, nil
+}
+
+// MockWorkflowDefinitionRepository
+type MockWorkflowDefinitionRepository struct {
+	// versions guarda las versiones de cada workflow en orden; la última es la actual
+	versions map[string][]*domain.WorkflowDefinition
+	mu       sync.RWMutex
+}
+
+func NewMockWorkflowDefinitionRepository() domain.WorkflowDefinitionRepository {
+	return &MockWorkflowDefinitionRepository{
+		versions: make(map[string][]*domain.WorkflowDefinition),
+	}
+}
+
+func copyWorkflowDefinition(definition *domain.WorkflowDefinition) *domain.WorkflowDefinition {
+	definitionCopy := *definition
+	definitionCopy.Steps = append([]map[string]interface{}(nil), definition.Steps...)
+	return &definitionCopy
+}
+
+func (r *MockWorkflowDefinitionRepository) GetByID(ctx context.Context, id string) (*domain.WorkflowDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, exists := r.versions[id]
+	if !exists {
+		return nil, fmt.Errorf("workflow definition not found")
+	}
+	return copyWorkflowDefinition(versions[len(versions)-1]), nil
+}
+
+func (r *MockWorkflowDefinitionRepository) List(ctx context.Context) ([]*domain.WorkflowDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	definitions := make([]*domain.WorkflowDefinition, 0, len(r.versions))
+	for _, versions := range r.versions {
+		definitions = append(definitions, copyWorkflowDefinition(versions[len(versions)-1]))
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions, nil
+}
+
+func (r *MockWorkflowDefinitionRepository) Create(ctx context.Context, definition *domain.WorkflowDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if definition.ID == "" {
+		definition.ID = id.New()
+	}
+	if _, exists := r.versions[definition.ID]; exists {
+		return fmt.Errorf("workflow definition already exists")
+	}
+	r.versions[definition.ID] = []*domain.WorkflowDefinition{copyWorkflowDefinition(definition)}
+	return nil
+}
+
+func (r *MockWorkflowDefinitionRepository) Update(ctx context.Context, definition *domain.WorkflowDefinition) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, exists := r.versions[definition.ID]
+	if !exists {
+		return fmt.Errorf("workflow definition not found")
+	}
+	if definition.Version <= versions[len(versions)-1].Version {
+		return fmt.Errorf("workflow definition version %d already exists", definition.Version)
+	}
+	r.versions[definition.ID] = append(versions, copyWorkflowDefinition(definition))
+	return nil
+}
+
+func (r *MockWorkflowDefinitionRepository) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.versions[id]; !exists {
+		return fmt.Errorf("workflow definition not found")
+	}
+	delete(r.versions, id)
+	return nil
+}
+
+func (r *MockWorkflowDefinitionRepository) GetVersion(ctx context.Context, id string, version int) (*domain.WorkflowDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, existing := range r.versions[id] {
+		if existing.Version == version {
+			return copyWorkflowDefinition(existing), nil
+		}
+	}
+	return nil, fmt.Errorf("workflow definition version not found")
+}
+
+func (r *MockWorkflowDefinitionRepository) ListVersions(ctx context.Context, id string) ([]*domain.WorkflowDefinition, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions, exists := r.versions[id]
+	if !exists {
+		return nil, fmt.Errorf("workflow definition not found")
+	}
+	result := make([]*domain.WorkflowDefinition, 0, len(versions))
+	for i := len(versions) - 1; i >= 0; i-- {
+		result = append(result, copyWorkflowDefinition(versions[i]))
+	}
+	return result, nil
+}
+
+// MockScriptApprovalRepository
+type MockScriptApprovalRepository struct {
+	approvals map[string]*domain.ScriptApproval
+	mu        sync.RWMutex
+}
+
+func NewMockScriptApprovalRepository() domain.ScriptApprovalRepository {
+	return &MockScriptApprovalRepository{
+		approvals: make(map[string]*domain.ScriptApproval),
+	}
+}
+
+func (r *MockScriptApprovalRepository) Get(ctx context.Context, hash string) (*domain.ScriptApproval, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	approval, exists := r.approvals[hash]
+	if !exists {
+		return nil, fmt.Errorf("script approval not found")
+	}
+	approvalCopy := *approval
+	return &approvalCopy, nil
+}
+
+func (r *MockScriptApprovalRepository) List(ctx context.Context) ([]*domain.ScriptApproval, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	approvals := make([]*domain.ScriptApproval, 0, len(r.approvals))
+	for _, approval := range r.approvals {
+		approvalCopy := *approval
+		approvals = append(approvals, &approvalCopy)
+	}
+	sort.Slice(approvals, func(i, j int) bool {
+		return approvals[i].CreatedAt.After(approvals[j].CreatedAt)
+	})
+	return approvals, nil
+}
+
+func (r *MockScriptApprovalRepository) Create(ctx context.Context, approval *domain.ScriptApproval) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	approvalCopy := *approval
+	r.approvals[approval.Hash] = &approvalCopy
+	return nil
+}
+
+func (r *MockScriptApprovalRepository) Delete(ctx context.Context, hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.approvals[hash]; !exists {
+		return fmt.Errorf("script approval not found")
+	}
+	delete(r.approvals, hash)
+	return nil
+}
@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/id"
+)
+
+// PostgresDeadLetterRepository guarda la cola de tareas fallidas en Postgres,
+// junto a las tareas de PostgresTaskRepository, para que no se pierda al
+// reiniciar
+type PostgresDeadLetterRepository struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPostgresDeadLetterRepository crea el repositorio sobre table;
+// EnsureSchema crea la tabla
+func NewPostgresDeadLetterRepository(db *sql.DB, table string) (*PostgresDeadLetterRepository, error) {
+	if !taskTablePattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid dead letter table name: %q", table)
+	}
+	return &PostgresDeadLetterRepository{db: db, table: table}, nil
+}
+
+// EnsureSchema crea la tabla de tareas fallidas y su índice si no existen
+func (r *PostgresDeadLetterRepository) EnsureSchema(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			dead_lettered_at TIMESTAMPTZ NOT NULL,
+			data JSONB NOT NULL
+		)`, r.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_dead_lettered_at_idx ON %s (dead_lettered_at DESC)`, r.table, r.table),
+	}
+	for _, statement := range statements {
+		if _, err := r.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to prepare dead letter schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *PostgresDeadLetterRepository) GetByID(ctx context.Context, id string) (*domain.DeadLetterTask, error) {
+	query := fmt.Sprintf(`SELECT data FROM %s WHERE id = $1`, r.table)
+	var data []byte
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("dead letter task not found")
+		}
+		return nil, fmt.Errorf("failed to get dead letter task: %w", err)
+	}
+	return decodeDeadLetter(data)
+}
+
+func (r *PostgresDeadLetterRepository) Create(ctx context.Context, task *domain.DeadLetterTask) error {
+	if task.ID == "" {
+		task.ID = id.New()
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode dead letter task: %w", err)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (id, dead_lettered_at, data) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET dead_lettered_at = EXCLUDED.dead_lettered_at, data = EXCLUDED.data`, r.table)
+	if _, err := r.db.ExecContext(ctx, query, task.ID, task.DeadLetteredAt, data); err != nil {
+		return fmt.Errorf("failed to create dead letter task: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresDeadLetterRepository) List(ctx context.Context, limit, offset int) ([]*domain.DeadLetterTask, error) {
+	query, args := buildDeadLetterListQuery(r.table, limit, offset)
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dead letter tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]*domain.DeadLetterTask, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read dead letter task: %w", err)
+		}
+		task, err := decodeDeadLetter(data)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query dead letter tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+func (r *PostgresDeadLetterRepository) Delete(ctx context.Context, id string) error {
+	result, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE id = $1`, r.table), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete dead letter task: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("dead letter task not found")
+	}
+	return nil
+}
+
+func (r *PostgresDeadLetterRepository) DeleteAll(ctx context.Context) (int, error) {
+	result, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s`, r.table))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letter tasks: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead letter tasks: %w", err)
+	}
+	return int(deleted), nil
+}
+
+func (r *PostgresDeadLetterRepository) Count(ctx context.Context) (int, error) {
+	var count int
+	if err := r.db.QueryRowContext(ctx, fmt.Sprintf(`SELECT COUNT(*) FROM %s`, r.table)).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count dead letter tasks: %w", err)
+	}
+	return count, nil
+}
+
+// buildDeadLetterListQuery pagina en la base de datos, las más recientes primero
+func buildDeadLetterListQuery(table string, limit, offset int) (string, []interface{}) {
+	query := fmt.Sprintf(`SELECT data FROM %s ORDER BY dead_lettered_at DESC`, table)
+	var args []interface{}
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return query, args
+}
+
+func decodeDeadLetter(data []byte) (*domain.DeadLetterTask, error) {
+	var task domain.DeadLetterTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("failed to decode dead letter task: %w", err)
+	}
+	return &task, nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// PostgresScriptApprovalRepository guarda los scripts aprobados para los
+// agentes sandbox en Postgres, junto a las tareas, para que las aprobaciones
+// no se pierdan al reiniciar
+type PostgresScriptApprovalRepository struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPostgresScriptApprovalRepository crea el repositorio sobre table;
+// EnsureSchema crea la tabla
+func NewPostgresScriptApprovalRepository(db *sql.DB, table string) (*PostgresScriptApprovalRepository, error) {
+	if !taskTablePattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid script approval table name: %q", table)
+	}
+	return &PostgresScriptApprovalRepository{db: db, table: table}, nil
+}
+
+// EnsureSchema crea la tabla de aprobaciones si no existe
+func (r *PostgresScriptApprovalRepository) EnsureSchema(ctx context.Context) error {
+	statement := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		hash TEXT PRIMARY KEY,
+		created_at TIMESTAMPTZ NOT NULL,
+		data JSONB NOT NULL
+	)`, r.table)
+	if _, err := r.db.ExecContext(ctx, statement); err != nil {
+		return fmt.Errorf("failed to prepare script approval schema: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresScriptApprovalRepository) Get(ctx context.Context, hash string) (*domain.ScriptApproval, error) {
+	query := fmt.Sprintf(`SELECT data FROM %s WHERE hash = $1`, r.table)
+	var data []byte
+	if err := r.db.QueryRowContext(ctx, query, hash).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("script approval not found")
+		}
+		return nil, fmt.Errorf("failed to get script approval: %w", err)
+	}
+	return decodeScriptApproval(data)
+}
+
+func (r *PostgresScriptApprovalRepository) List(ctx context.Context) ([]*domain.ScriptApproval, error) {
+	rows, err := r.db.QueryContext(ctx, fmt.Sprintf(`SELECT data FROM %s ORDER BY created_at DESC`, r.table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query script approvals: %w", err)
+	}
+	defer rows.Close()
+
+	approvals := make([]*domain.ScriptApproval, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read script approval: %w", err)
+		}
+		approval, err := decodeScriptApproval(data)
+		if err != nil {
+			return nil, err
+		}
+		approvals = append(approvals, approval)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query script approvals: %w", err)
+	}
+	return approvals, nil
+}
+
+func (r *PostgresScriptApprovalRepository) Create(ctx context.Context, approval *domain.ScriptApproval) error {
+	data, err := json.Marshal(approval)
+	if err != nil {
+		return fmt.Errorf("failed to encode script approval: %w", err)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (hash, created_at, data) VALUES ($1, $2, $3)
+		ON CONFLICT (hash) DO UPDATE SET created_at = EXCLUDED.created_at, data = EXCLUDED.data`, r.table)
+	if _, err := r.db.ExecContext(ctx, query, approval.Hash, approval.CreatedAt, data); err != nil {
+		return fmt.Errorf("failed to create script approval: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresScriptApprovalRepository) Delete(ctx context.Context, hash string) error {
+	result, err := r.db.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE hash = $1`, r.table), hash)
+	if err != nil {
+		return fmt.Errorf("failed to delete script approval: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("script approval not found")
+	}
+	return nil
+}
+
+func decodeScriptApproval(data []byte) (*domain.ScriptApproval, error) {
+	var approval domain.ScriptApproval
+	if err := json.Unmarshal(data, &approval); err != nil {
+		return nil, fmt.Errorf("failed to decode script approval: %w", err)
+	}
+	return &approval, nil
+}
@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/id"
+)
+
+var taskTablePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// PostgresTaskRepository guarda las tareas asíncronas en Postgres para que
+// sobrevivan a un reinicio. La tarea completa va en data (JSONB) y las columnas
+// por las que se filtra se guardan aparte e indexadas.
+type PostgresTaskRepository struct {
+	db    *sql.DB
+	table string
+}
+
+// NewPostgresTaskRepository crea el repositorio sobre table; EnsureSchema crea
+// la tabla y los índices
+func NewPostgresTaskRepository(db *sql.DB, table string) (*PostgresTaskRepository, error) {
+	if !taskTablePattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid task table name: %q", table)
+	}
+	return &PostgresTaskRepository{db: db, table: table}, nil
+}
+
+// EnsureSchema crea la tabla de tareas y sus índices si no existen
+func (r *PostgresTaskRepository) EnsureSchema(ctx context.Context) error {
+	statements := []string{
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			id TEXT PRIMARY KEY,
+			type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			user_id TEXT NOT NULL DEFAULT '',
+			bot_id TEXT NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL,
+			completed_at TIMESTAMPTZ,
+			data JSONB NOT NULL
+		)`, r.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_status_idx ON %s (status, created_at)`, r.table, r.table),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_created_at_idx ON %s (created_at DESC)`, r.table, r.table),
+	}
+	for _, statement := range statements {
+		if _, err := r.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to prepare task schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (r *PostgresTaskRepository) GetByID(ctx context.Context, id string) (*domain.AsyncTask, error) {
+	query := fmt.Sprintf(`SELECT data FROM %s WHERE id = $1`, r.table)
+	var data []byte
+	if err := r.db.QueryRowContext(ctx, query, id).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("task not found")
+		}
+		return nil, fmt.Errorf("failed to get task: %w", err)
+	}
+	return decodeTask(data)
+}
+
+func (r *PostgresTaskRepository) GetByStatus(ctx context.Context, statuses ...domain.TaskStatus) ([]*domain.AsyncTask, error) {
+	if len(statuses) == 0 {
+		return []*domain.AsyncTask{}, nil
+	}
+	placeholders := make([]string, len(statuses))
+	args := make([]interface{}, len(statuses))
+	for i, status := range statuses {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+		args[i] = string(status)
+	}
+	query := fmt.Sprintf(`SELECT data FROM %s WHERE status IN (%s) ORDER BY created_at DESC`,
+		r.table, strings.Join(placeholders, ", "))
+	return r.query(ctx, query, args...)
+}
+
+func (r *PostgresTaskRepository) Create(ctx context.Context, task *domain.AsyncTask) error {
+	if task.ID == "" {
+		task.ID = id.New()
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (id, type, status, user_id, bot_id, created_at, completed_at, data)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (id) DO UPDATE SET type = EXCLUDED.type, status = EXCLUDED.status,
+			user_id = EXCLUDED.user_id, bot_id = EXCLUDED.bot_id, created_at = EXCLUDED.created_at,
+			completed_at = EXCLUDED.completed_at, data = EXCLUDED.data`, r.table)
+	if _, err := r.db.ExecContext(ctx, query, task.ID, task.Type, string(task.Status), task.UserID, task.BotID,
+		task.CreatedAt, nullableTime(task.CompletedAt), data); err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+	return nil
+}
+
+func (r *PostgresTaskRepository) Update(ctx context.Context, task *domain.AsyncTask) error {
+	data, err := json.Marshal(task)
+	if err != nil {
+		return fmt.Errorf("failed to encode task: %w", err)
+	}
+	query := fmt.Sprintf(`UPDATE %s SET type = $2, status = $3, user_id = $4, bot_id = $5, created_at = $6,
+		completed_at = $7, data = $8 WHERE id = $1`, r.table)
+	result, err := r.db.ExecContext(ctx, query, task.ID, task.Type, string(task.Status), task.UserID, task.BotID,
+		task.CreatedAt, nullableTime(task.CompletedAt), data)
+	if err != nil {
+		return fmt.Errorf("failed to update task: %w", err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return fmt.Errorf("task not found")
+	}
+	return nil
+}
+
+func (r *PostgresTaskRepository) List(ctx context.Context, filter domain.TaskFilter) ([]*domain.AsyncTask, error) {
+	query, args := buildTaskListQuery(r.table, filter)
+	return r.query(ctx, query, args...)
+}
+
+func (r *PostgresTaskRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int, error) {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE status IN ($1, $2, $3) AND completed_at < $4`, r.table)
+	result, err := r.db.ExecContext(ctx, query, string(domain.TaskStatusCompleted), string(domain.TaskStatusFailed),
+		string(domain.TaskStatusCancelled), before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished tasks: %w", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete finished tasks: %w", err)
+	}
+	return int(deleted), nil
+}
+
+func (r *PostgresTaskRepository) query(ctx context.Context, query string, args ...interface{}) ([]*domain.AsyncTask, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	defer rows.Close()
+
+	tasks := make([]*domain.AsyncTask, 0)
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to read task: %w", err)
+		}
+		task, err := decodeTask(data)
+		if err != nil {
+			return nil, err
+		}
+		tasks = append(tasks, task)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to query tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// buildTaskListQuery traduce el filtro a SQL: cada campo no vacío añade una
+// condición y la paginación se resuelve en la base de datos
+func buildTaskListQuery(table string, filter domain.TaskFilter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Status != "" {
+		add("status = $%d", string(filter.Status))
+	}
+	if filter.Type != "" {
+		add("type = $%d", filter.Type)
+	}
+	if filter.UserID != "" {
+		add("user_id = $%d", filter.UserID)
+	}
+	if filter.BotID != "" {
+		add("bot_id = $%d", filter.BotID)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at <= $%d", *filter.To)
+	}
+
+	query := fmt.Sprintf(`SELECT data FROM %s`, table)
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if filter.Offset > 0 {
+		args = append(args, filter.Offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+	return query, args
+}
+
+func decodeTask(data []byte) (*domain.AsyncTask, error) {
+	var task domain.AsyncTask
+	if err := json.Unmarshal(data, &task); err != nil {
+		return nil, fmt.Errorf("failed to decode task: %w", err)
+	}
+	return &task, nil
+}
+
+func nullableTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
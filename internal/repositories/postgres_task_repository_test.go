@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildTaskListQuery(t *testing.T) {
+	query, args := buildTaskListQuery("async_tasks", domain.TaskFilter{})
+	assert.Equal(t, "SELECT data FROM async_tasks ORDER BY created_at DESC", query)
+	assert.Empty(t, args)
+
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	query, args = buildTaskListQuery("async_tasks", domain.TaskFilter{
+		Status: domain.TaskStatusCompleted,
+		BotID:  "bot-1",
+		From:   &from,
+		Limit:  20,
+		Offset: 40,
+	})
+	assert.Equal(t, "SELECT data FROM async_tasks WHERE status = $1 AND bot_id = $2 AND created_at >= $3"+
+		" ORDER BY created_at DESC LIMIT $4 OFFSET $5", query)
+	assert.Equal(t, []interface{}{"completed", "bot-1", from, 20, 40}, args)
+
+	_, err := NewPostgresTaskRepository(nil, "tasks; DROP TABLE bots")
+	assert.Error(t, err)
+}
+
+func TestBuildDeadLetterListQuery(t *testing.T) {
+	query, args := buildDeadLetterListQuery("async_tasks_dead_letters", 0, 0)
+	assert.Equal(t, "SELECT data FROM async_tasks_dead_letters ORDER BY dead_lettered_at DESC", query)
+	assert.Empty(t, args)
+
+	query, args = buildDeadLetterListQuery("async_tasks_dead_letters", 20, 40)
+	assert.Equal(t, "SELECT data FROM async_tasks_dead_letters ORDER BY dead_lettered_at DESC LIMIT $1 OFFSET $2", query)
+	assert.Equal(t, []interface{}{20, 40}, args)
+
+	_, err := NewPostgresDeadLetterRepository(nil, "dead; DROP TABLE bots")
+	assert.Error(t, err)
+}
+
+func TestNewPostgresScriptApprovalRepository_ValidatesTable(t *testing.T) {
+	_, err := NewPostgresScriptApprovalRepository(nil, "async_tasks_script_approvals")
+	assert.NoError(t, err)
+
+	_, err = NewPostgresScriptApprovalRepository(nil, "approvals; DROP TABLE bots")
+	assert.Error(t, err)
+}
+
+func TestMockTaskRepository_ListFiltersAndPages(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMockTaskRepository()
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i, status := range []domain.TaskStatus{domain.TaskStatusCompleted, domain.TaskStatusPending, domain.TaskStatusCompleted, domain.TaskStatusCompleted} {
+		require.NoError(t, repo.Create(ctx, &domain.AsyncTask{
+			ID:        string(rune('a' + i)),
+			BotID:     "bot-1",
+			Status:    status,
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+		}))
+	}
+
+	tasks, err := repo.List(ctx, domain.TaskFilter{Status: domain.TaskStatusCompleted, Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, tasks, 2)
+	assert.Equal(t, "d", tasks[0].ID)
+	assert.Equal(t, "c", tasks[1].ID)
+
+	tasks, err = repo.List(ctx, domain.TaskFilter{Status: domain.TaskStatusCompleted, Offset: 2})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "a", tasks[0].ID)
+
+	to := base.Add(time.Minute)
+	tasks, err = repo.List(ctx, domain.TaskFilter{BotID: "bot-1", To: &to})
+	require.NoError(t, err)
+	assert.Len(t, tasks, 2)
+}
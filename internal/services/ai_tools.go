@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/configschema"
+)
+
+// MemorySearchTool es la herramienta con la que el modelo busca en lo que el
+// bot recuerda del usuario de la conversación
+const MemorySearchTool = "memory_search"
+
+// aiStepKey guarda en el contexto la configuración de IA del bot del paso en curso
+type aiStepKey struct{}
+
+type aiStepRequest struct {
+	tools    *domain.AIToolsConfig
+	provider string
+	userID   string
+}
+
+// withAIStep aplica el proveedor y las herramientas del bot a las respuestas
+// generadas con ctx
+func withAIStep(ctx context.Context, config domain.BotConfig, userID string) context.Context {
+	if config.AIProvider == "" && (config.AITools == nil || len(config.AITools.Tools) == 0) {
+		return ctx
+	}
+	return context.WithValue(ctx, aiStepKey{}, aiStepRequest{tools: config.AITools, provider: config.AIProvider, userID: userID})
+}
+
+// applyAIStep limita la tarea de generación al proveedor del bot y le añade
+// las herramientas y el usuario, que las herramientas leen de la metadata y
+// no de los argumentos
+func applyAIStep(ctx context.Context, task *domain.MCPTask) {
+	request, ok := ctx.Value(aiStepKey{}).(aiStepRequest)
+	if !ok {
+		return
+	}
+	if request.provider != "" {
+		task.Metadata[mcp.TaskAgentTypeKey] = request.provider
+	}
+	if request.tools == nil || len(request.tools.Tools) == 0 {
+		return
+	}
+	task.Input["tools"] = request.tools.Tools
+	if request.tools.MaxIterations > 0 {
+		task.Input["max_tool_iterations"] = request.tools.MaxIterations
+	}
+	task.Metadata["user_id"] = request.userID
+}
+
+// NewMemorySearchTool devuelve la herramienta memory_search. Solo busca en las
+// memorias del usuario y el bot de la tarea que la pide.
+func NewMemorySearchTool(memories MemoryService) (mcp.ToolDefinition, mcp.ToolFunc) {
+	definition := mcp.ToolDefinition{
+		Name:        MemorySearchTool,
+		Description: "Search what the bot remembers about the current user",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"query": map[string]interface{}{"type": "string", "description": "What to look for"},
+				"limit": map[string]interface{}{"type": "integer", "description": "Maximum number of memories, up to 10"},
+			},
+			"required": []string{"query"},
+		},
+	}
+
+	search := func(ctx context.Context, call mcp.ToolCall) (map[string]interface{}, error) {
+		metadata := configschema.Values(call.Task.Metadata)
+		userID, botID := metadata.String("user_id", ""), metadata.String("bot_id", "")
+		if userID == "" || botID == "" {
+			return nil, fmt.Errorf("memory_search needs the user and bot of the conversation")
+		}
+
+		arguments := configschema.Values(call.Arguments)
+		limit := min(max(arguments.Int("limit", maxPromptMemories), 1), 10)
+		found, err := memories.RecallMemories(ctx, userID, botID, arguments.String("query", ""), limit)
+		if err != nil {
+			return nil, err
+		}
+		results := make([]map[string]interface{}, 0, len(found))
+		for _, memory := range found {
+			results = append(results, map[string]interface{}{
+				"key":  memory.Key,
+				"text": memoryText(memory),
+				"tags": memory.Tags,
+			})
+		}
+		return map[string]interface{}{"memories": results}, nil
+	}
+	return definition, search
+}
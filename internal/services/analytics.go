@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// ErrInvalidAnalyticsRange se devuelve si el rango pedido está invertido o es demasiado largo
+var ErrInvalidAnalyticsRange = errors.New("invalid analytics range")
+
+const (
+	// AnalyticsDateLayout es el formato de las fechas de los buckets diarios (UTC)
+	AnalyticsDateLayout = "2006-01-02"
+	maxAnalyticsDays    = 366
+	// abandonedSessionIdle es la inactividad a partir de la cual una sesión
+	// sin terminar cuenta como abandonada en su paso actual
+	abandonedSessionIdle = 30 * time.Minute
+)
+
+// AnalyticsCounters son las métricas de un día o de todo el rango
+type AnalyticsCounters struct {
+	Messages               int     `json:"messages"`  // mensajes de los usuarios
+	Responses              int     `json:"responses"` // respuestas del bot
+	UniqueUsers            int     `json:"unique_users"`
+	Sessions               int     `json:"sessions"`
+	CompletedConversations int     `json:"completed_conversations"`
+	CompletionRate         float64 `json:"completion_rate"`
+	Handoffs               int     `json:"handoffs"`
+	HandoffRate            float64 `json:"handoff_rate"`
+	AverageConfidence      float64 `json:"average_confidence"` // de las respuestas de IA, 0 si no hubo
+	AverageSentiment       float64 `json:"average_sentiment"`  // de -1 a 1, 0 si el bot no puntúa sentimiento
+	NegativeMessages       int     `json:"negative_messages"`
+}
+
+// AnalyticsDay es el bucket de un día
+type AnalyticsDay struct {
+	Date string `json:"date"`
+	AnalyticsCounters
+}
+
+// IntentCount es la frecuencia de un intent en el rango
+type IntentCount struct {
+	Intent string  `json:"intent"`
+	Count  int     `json:"count"`
+	Share  float64 `json:"share"`
+}
+
+// StepDropOff cuenta las sesiones abandonadas en un paso
+type StepDropOff struct {
+	FlowID   string `json:"flow_id"`
+	StepID   string `json:"step_id"`
+	Sessions int    `json:"sessions"`
+}
+
+// BotAnalytics es el panel de un bot entre From y To (ambos incluidos)
+type BotAnalytics struct {
+	BotID    string            `json:"bot_id"`
+	From     string            `json:"from"`
+	To       string            `json:"to"`
+	Totals   AnalyticsCounters `json:"totals"`
+	Daily    []AnalyticsDay    `json:"daily"`
+	Intents  []IntentCount     `json:"intents"`
+	DropOffs []StepDropOff     `json:"drop_offs"`
+}
+
+// AnalyticsService agrega los eventos de conversación en contadores diarios
+// por bot y arma el panel de analítica
+type AnalyticsService interface {
+	// Subscribe registra el servicio en los eventos de conversación del bus
+	Subscribe(bus events.EventBus) error
+	GetBotAnalytics(ctx context.Context, botID string, from, to time.Time) (*BotAnalytics, error)
+}
+
+type analyticsService struct {
+	repo        domain.AnalyticsRepository
+	botRepo     domain.BotRepository
+	sessionRepo domain.ConversationSessionRepository
+	logger      logger.Logger
+}
+
+// NewAnalyticsService crea el servicio de analítica de bots
+func NewAnalyticsService(repo domain.AnalyticsRepository, botRepo domain.BotRepository, sessionRepo domain.ConversationSessionRepository, logger logger.Logger) AnalyticsService {
+	return &analyticsService{
+		repo:        repo,
+		botRepo:     botRepo,
+		sessionRepo: sessionRepo,
+		logger:      logger,
+	}
+}
+
+func (s *analyticsService) Subscribe(bus events.EventBus) error {
+	for _, eventType := range []string{
+		events.EventTypeMessageReceived,
+		events.EventTypeMessageProcessed,
+		events.EventTypeSessionStarted,
+		events.EventTypeConversationEnded,
+		events.EventTypeHumanHandoff,
+	} {
+		if err := bus.Subscribe(eventType, s.record); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", eventType, err)
+		}
+	}
+	return nil
+}
+
+// record convierte el evento en un incremento de los contadores de su día
+func (s *analyticsService) record(ctx context.Context, event events.Event) error {
+	botID := eventString(event.Data, "bot_id")
+	if botID == "" {
+		return nil
+	}
+	timestamp := event.Timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	delta := &domain.BotDailyStats{BotID: botID, Date: timestamp.UTC().Format(AnalyticsDateLayout)}
+
+	switch event.Type {
+	case events.EventTypeMessageReceived:
+		delta.MessagesReceived = 1
+		if event.UserID != "" {
+			delta.UserIDs = map[string]bool{event.UserID: true}
+		}
+	case events.EventTypeMessageProcessed:
+		if eventString(event.Data, "response") != "" {
+			delta.MessagesSent = 1
+		}
+		if intent := eventString(event.Data, "intent"); intent != "" {
+			delta.Intents = map[string]int{intent: 1}
+		}
+		if confidence, ok := event.Data["confidence"].(float64); ok {
+			delta.ConfidenceSum = confidence
+			delta.ConfidenceCount = 1
+		}
+		if sentiment, ok := event.Data["sentiment"].(float64); ok {
+			delta.SentimentSum = sentiment
+			delta.SentimentCount = 1
+			if eventString(event.Data, "sentiment_label") == "negative" {
+				delta.NegativeMessages = 1
+			}
+		}
+	case events.EventTypeSessionStarted:
+		delta.SessionsStarted = 1
+	case events.EventTypeConversationEnded:
+		delta.ConversationsCompleted = 1
+	case events.EventTypeHumanHandoff:
+		delta.Handoffs = 1
+	}
+
+	if err := s.repo.Increment(ctx, delta); err != nil {
+		return fmt.Errorf("failed to record analytics for bot %s: %w", botID, err)
+	}
+	return nil
+}
+
+func (s *analyticsService) GetBotAnalytics(ctx context.Context, botID string, from, to time.Time) (*BotAnalytics, error) {
+	if _, err := s.botRepo.GetByID(ctx, botID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBotNotFound, botID)
+	}
+	from, to = truncateDay(from), truncateDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to is before from", ErrInvalidAnalyticsRange)
+	}
+	if days := int(to.Sub(from)/(24*time.Hour)) + 1; days > maxAnalyticsDays {
+		return nil, fmt.Errorf("%w: at most %d days", ErrInvalidAnalyticsRange, maxAnalyticsDays)
+	}
+
+	stored, err := s.repo.GetRange(ctx, botID, from.Format(AnalyticsDateLayout), to.Format(AnalyticsDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get analytics: %w", err)
+	}
+	byDate := make(map[string]*domain.BotDailyStats, len(stored))
+	for _, stats := range stored {
+		byDate[stats.Date] = stats
+	}
+
+	analytics := &BotAnalytics{
+		BotID: botID,
+		From:  from.Format(AnalyticsDateLayout),
+		To:    to.Format(AnalyticsDateLayout),
+	}
+	total := domain.BotDailyStats{UserIDs: make(map[string]bool), Intents: make(map[string]int)}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(AnalyticsDateLayout)
+		stats, ok := byDate[date]
+		if !ok {
+			stats = &domain.BotDailyStats{Date: date}
+		}
+		analytics.Daily = append(analytics.Daily, AnalyticsDay{Date: date, AnalyticsCounters: analyticsCounters(stats)})
+
+		total.MessagesReceived += stats.MessagesReceived
+		total.MessagesSent += stats.MessagesSent
+		total.SessionsStarted += stats.SessionsStarted
+		total.ConversationsCompleted += stats.ConversationsCompleted
+		total.Handoffs += stats.Handoffs
+		total.ConfidenceSum += stats.ConfidenceSum
+		total.ConfidenceCount += stats.ConfidenceCount
+		total.SentimentSum += stats.SentimentSum
+		total.SentimentCount += stats.SentimentCount
+		total.NegativeMessages += stats.NegativeMessages
+		for userID := range stats.UserIDs {
+			total.UserIDs[userID] = true
+		}
+		for intent, count := range stats.Intents {
+			total.Intents[intent] += count
+		}
+	}
+	analytics.Totals = analyticsCounters(&total)
+	analytics.Intents = intentDistribution(total.Intents)
+
+	analytics.DropOffs, err = s.dropOffs(ctx, botID, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	return analytics, nil
+}
+
+// dropOffs agrupa por paso las sesiones abiertas cuya última actividad, entre
+// from y to, quedó hace más de abandonedSessionIdle. Las sesiones que caducan
+// se eliminan, así que sólo cuentan las de las últimas 24 horas.
+func (s *analyticsService) dropOffs(ctx context.Context, botID string, from, to time.Time) ([]StepDropOff, error) {
+	before := time.Now().Add(-abandonedSessionIdle)
+	if to.Before(before) {
+		before = to
+	}
+	sessions, err := s.sessionRepo.GetInactiveSince(ctx, botID, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get inactive sessions: %w", err)
+	}
+
+	counts := make(map[StepDropOff]int)
+	for _, session := range sessions {
+		// Sin paso actual el flujo terminó; con handoff la atiende un humano
+		if session.CurrentStepID == "" || session.Handoff != nil || session.UpdatedAt.Before(from) {
+			continue
+		}
+		counts[StepDropOff{FlowID: session.CurrentFlowID, StepID: session.CurrentStepID}]++
+	}
+
+	dropOffs := make([]StepDropOff, 0, len(counts))
+	for step, count := range counts {
+		step.Sessions = count
+		dropOffs = append(dropOffs, step)
+	}
+	sort.Slice(dropOffs, func(i, j int) bool {
+		if dropOffs[i].Sessions != dropOffs[j].Sessions {
+			return dropOffs[i].Sessions > dropOffs[j].Sessions
+		}
+		return dropOffs[i].StepID < dropOffs[j].StepID
+	})
+	return dropOffs, nil
+}
+
+func analyticsCounters(stats *domain.BotDailyStats) AnalyticsCounters {
+	counters := AnalyticsCounters{
+		Messages:               stats.MessagesReceived,
+		Responses:              stats.MessagesSent,
+		UniqueUsers:            len(stats.UserIDs),
+		Sessions:               stats.SessionsStarted,
+		CompletedConversations: stats.ConversationsCompleted,
+		Handoffs:               stats.Handoffs,
+		NegativeMessages:       stats.NegativeMessages,
+	}
+	if stats.SessionsStarted > 0 {
+		counters.CompletionRate = min(1, float64(stats.ConversationsCompleted)/float64(stats.SessionsStarted))
+		counters.HandoffRate = min(1, float64(stats.Handoffs)/float64(stats.SessionsStarted))
+	}
+	if stats.ConfidenceCount > 0 {
+		counters.AverageConfidence = stats.ConfidenceSum / float64(stats.ConfidenceCount)
+	}
+	if stats.SentimentCount > 0 {
+		counters.AverageSentiment = stats.SentimentSum / float64(stats.SentimentCount)
+	}
+	return counters
+}
+
+// intentDistribution ordena los intents de más a menos frecuente
+func intentDistribution(intents map[string]int) []IntentCount {
+	total := 0
+	for _, count := range intents {
+		total += count
+	}
+	distribution := make([]IntentCount, 0, len(intents))
+	for intent, count := range intents {
+		distribution = append(distribution, IntentCount{Intent: intent, Count: count, Share: float64(count) / float64(total)})
+	}
+	sort.Slice(distribution, func(i, j int) bool {
+		if distribution[i].Count != distribution[j].Count {
+			return distribution[i].Count > distribution[j].Count
+		}
+		return distribution[i].Intent < distribution[j].Intent
+	})
+	return distribution
+}
+
+func truncateDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,61 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/testkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyticsService_AggregatesConversationEvents(t *testing.T) {
+	ctx := context.Background()
+	server := testkit.NewServer(t, testkit.Bot("bot-1").GlobalIntent("agent", "help", "human").Flows(
+		testkit.Flow("main").Steps(
+			testkit.Message("ask", "Order number?"),
+			testkit.Input("input", "order"),
+			testkit.End("done", "Thanks"),
+		),
+		testkit.Flow("help").Steps(testkit.End("bye", "Calling an agent")),
+	))
+	analytics := services.NewAnalyticsService(repositories.NewMockAnalyticsRepository(), server.Repos.Bots, server.Repos.Sessions, logger.NewLogger("error"))
+	require.NoError(t, analytics.Subscribe(server.Events))
+
+	// user-1 completa el flujo, user-2 lo abandona en la pregunta y user-3 usa el intent global
+	server.Send("bot-1", "user-1", "hola")
+	server.Send("bot-1", "user-1", "A-1")
+	server.Send("bot-1", "user-1", "A-2")
+	server.Send("bot-1", "user-2", "hola")
+	server.Send("bot-1", "user-3", "hola")
+	server.Send("bot-1", "user-3", "human please")
+	server.Advance(45 * time.Minute)
+
+	today := time.Now().UTC()
+	var result *services.BotAnalytics
+	var err error
+	require.Eventually(t, func() bool {
+		result, err = analytics.GetBotAnalytics(ctx, "bot-1", today.AddDate(0, 0, -6), today)
+		return err == nil && result.Totals.Messages == 6 && result.Totals.CompletedConversations == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	require.Len(t, result.Daily, 7)
+	day := result.Daily[6]
+	assert.Equal(t, today.Format(services.AnalyticsDateLayout), day.Date)
+	assert.Equal(t, 3, day.UniqueUsers)
+	assert.Equal(t, 6, day.Responses)
+	assert.Equal(t, 3, day.Sessions)
+	assert.InDelta(t, 2.0/3, day.CompletionRate, 0.001)
+	assert.Zero(t, result.Daily[0].Messages)
+	assert.Equal(t, []services.IntentCount{{Intent: "agent", Count: 1, Share: 1}}, result.Intents)
+	assert.Equal(t, []services.StepDropOff{{FlowID: "main", StepID: "input", Sessions: 1}}, result.DropOffs)
+
+	_, err = analytics.GetBotAnalytics(ctx, "bot-1", today, today.AddDate(0, 0, -1))
+	assert.ErrorIs(t, err, services.ErrInvalidAnalyticsRange)
+	_, err = analytics.GetBotAnalytics(ctx, "missing", today, today)
+	assert.ErrorIs(t, err, services.ErrBotNotFound)
+}
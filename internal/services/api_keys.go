@@ -0,0 +1,209 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	// apiKeyPrefix identifica las claves del servicio en logs y escáneres de secretos
+	apiKeyPrefix = "itb_"
+	// apiKeyLastUsedInterval evita escribir el repositorio en cada petición
+	apiKeyLastUsedInterval = time.Minute
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidAPIKey  = errors.New("invalid api key")
+)
+
+// IssueAPIKeyRequest describe una API key nueva. RateLimit es el máximo de
+// peticiones por minuto; 0 usa el límite por defecto del servicio.
+type IssueAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required"`
+	Scopes    []string   `json:"scopes" binding:"required"`
+	RateLimit int        `json:"rate_limit"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyService emite, revoca y valida las API keys de los servicios que
+// llaman a la API sin usuario (messaging-service, integraciones)
+type APIKeyService interface {
+	Issue(ctx context.Context, request IssueAPIKeyRequest, createdBy string) (*domain.APIKey, string, error)
+	List(ctx context.Context) ([]*domain.APIKey, error)
+	Revoke(ctx context.Context, id string) (*domain.APIKey, error)
+	Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error)
+	// Allow consume una petición del límite por minuto de la clave; si no
+	// quedan, devuelve cuánto falta para la siguiente ventana
+	Allow(key *domain.APIKey) (bool, time.Duration)
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+type apiKeyService struct {
+	repo             domain.APIKeyRepository
+	defaultRateLimit int
+	windows          map[string]*rateWindow
+	mu               sync.Mutex
+	logger           logger.Logger
+}
+
+// NewAPIKeyService crea el servicio de API keys. defaultRateLimit se aplica a
+// las claves emitidas sin rate_limit.
+func NewAPIKeyService(repo domain.APIKeyRepository, defaultRateLimit int, logger logger.Logger) APIKeyService {
+	return &apiKeyService{
+		repo:             repo,
+		defaultRateLimit: defaultRateLimit,
+		windows:          make(map[string]*rateWindow),
+		logger:           logger,
+	}
+}
+
+func (s *apiKeyService) Issue(ctx context.Context, request IssueAPIKeyRequest, createdBy string) (*domain.APIKey, string, error) {
+	if strings.TrimSpace(request.Name) == "" || len(request.Scopes) == 0 {
+		return nil, "", fmt.Errorf("%w: name and at least one scope are required", ErrInvalidAPIKey)
+	}
+	if request.RateLimit < 0 {
+		return nil, "", fmt.Errorf("%w: rate_limit cannot be negative", ErrInvalidAPIKey)
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, "", fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPIKey)
+	}
+
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	rawKey := apiKeyPrefix + hex.EncodeToString(secret)
+
+	rateLimit := request.RateLimit
+	if rateLimit == 0 {
+		rateLimit = s.defaultRateLimit
+	}
+	key := &domain.APIKey{
+		Name:      request.Name,
+		Prefix:    rawKey[:len(apiKeyPrefix)+8],
+		KeyHash:   hashAPIKey(rawKey),
+		Scopes:    request.Scopes,
+		RateLimit: rateLimit,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+		ExpiresAt: request.ExpiresAt,
+	}
+	if err := s.repo.Create(ctx, key); err != nil {
+		return nil, "", fmt.Errorf("failed to store api key: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("API key issued", "api_key_id", key.ID, "name", key.Name, "scopes", key.Scopes)
+	return withoutHash(key), rawKey, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context) ([]*domain.APIKey, error) {
+	keys, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+	for i, key := range keys {
+		keys[i] = withoutHash(key)
+	}
+	return keys, nil
+}
+
+func (s *apiKeyService) Revoke(ctx context.Context, id string) (*domain.APIKey, error) {
+	key, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAPIKeyNotFound, id)
+	}
+	if key.RevokedAt == nil {
+		now := time.Now()
+		key.RevokedAt = &now
+		if err := s.repo.Update(ctx, key); err != nil {
+			return nil, fmt.Errorf("failed to revoke api key: %w", err)
+		}
+		s.logger.WithContext(ctx).Info("API key revoked", "api_key_id", key.ID, "name", key.Name)
+	}
+
+	s.mu.Lock()
+	delete(s.windows, key.ID)
+	s.mu.Unlock()
+	return withoutHash(key), nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*domain.APIKey, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	key, err := s.repo.GetByHash(ctx, hashAPIKey(rawKey))
+	if err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && now.After(*key.ExpiresAt)) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyLastUsedInterval {
+		key.LastUsedAt = &now
+		if err := s.repo.Update(ctx, key); err != nil {
+			s.logger.WithContext(ctx).Warn("Failed to update API key last use", "api_key_id", key.ID, "error", err)
+		}
+	}
+	return withoutHash(key), nil
+}
+
+func (s *apiKeyService) Allow(key *domain.APIKey) (bool, time.Duration) {
+	if key.RateLimit <= 0 {
+		return true, 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	window, ok := s.windows[key.ID]
+	if !ok || now.Sub(window.start) >= time.Minute {
+		window = &rateWindow{start: now}
+		s.windows[key.ID] = window
+	}
+	if window.count >= key.RateLimit {
+		return false, window.start.Add(time.Minute).Sub(now)
+	}
+	window.count++
+	return true, 0
+}
+
+// APIKeyHasScope indica si la clave concede el scope: exacto ("bots:read"),
+// todo el recurso ("bots:*") o cualquiera ("*")
+func APIKeyHasScope(key *domain.APIKey, scope string) bool {
+	resource, _, _ := strings.Cut(scope, ":")
+	for _, granted := range key.Scopes {
+		if granted == "*" || granted == scope || granted == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
+
+func withoutHash(key *domain.APIKey) *domain.APIKey {
+	keyCopy := *key
+	keyCopy.KeyHash = ""
+	return &keyCopy
+}
@@ -0,0 +1,210 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// AuditSnapshot devuelve el estado actual de un recurso auditado, o nil si no existe
+type AuditSnapshot func(ctx context.Context, id string) (interface{}, error)
+
+// AuditFilters define los filtros para consultar la auditoría
+type AuditFilters struct {
+	Resource   *string    `json:"resource,omitempty"`
+	ResourceID *string    `json:"resource_id,omitempty"`
+	UserID     *string    `json:"user_id,omitempty"`
+	Action     *string    `json:"action,omitempty"`
+	CreatedAt  *TimeRange `json:"created_at,omitempty"`
+	Limit      int        `json:"limit,omitempty"`
+	Offset     int        `json:"offset,omitempty"`
+}
+
+// AuditService registra los cambios hechos a través de la API. Los snapshots
+// por tipo de recurso permiten guardar el diff entre el estado anterior y el
+// posterior a cada operación.
+type AuditService interface {
+	RegisterSnapshot(resource string, snapshot AuditSnapshot)
+	Snapshot(ctx context.Context, resource, id string) interface{}
+	Record(ctx context.Context, entry *domain.AuditLog, before, after interface{}) error
+	List(ctx context.Context, filters *AuditFilters) ([]*domain.AuditLog, error)
+}
+
+type auditService struct {
+	repo      domain.AuditRepository
+	snapshots map[string]AuditSnapshot
+	mu        sync.RWMutex
+	logger    logger.Logger
+}
+
+// NewAuditService crea el servicio de auditoría
+func NewAuditService(repo domain.AuditRepository, logger logger.Logger) AuditService {
+	return &auditService{
+		repo:      repo,
+		snapshots: make(map[string]AuditSnapshot),
+		logger:    logger,
+	}
+}
+
+func (s *auditService) RegisterSnapshot(resource string, snapshot AuditSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[resource] = snapshot
+}
+
+// Snapshot no falla: un recurso inexistente o sin snapshot registrado da nil.
+// El estado se serializa en el momento, porque los repositorios en memoria
+// devuelven punteros que el handler puede modificar después.
+func (s *auditService) Snapshot(ctx context.Context, resource, id string) interface{} {
+	s.mu.RLock()
+	snapshot, ok := s.snapshots[resource]
+	s.mu.RUnlock()
+	if !ok || id == "" {
+		return nil
+	}
+
+	value, err := snapshot(ctx, id)
+	if err != nil {
+		return nil
+	}
+	if fields := auditFields(value); fields != nil {
+		return fields
+	}
+	return nil
+}
+
+func (s *auditService) Record(ctx context.Context, entry *domain.AuditLog, before, after interface{}) error {
+	if entry.Details == nil {
+		entry.Details = make(map[string]interface{})
+	}
+	if changes := auditDiff(before, after); len(changes) > 0 {
+		entry.Details["changes"] = changes
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+
+	if err := s.repo.Create(ctx, entry); err != nil {
+		return fmt.Errorf("failed to store audit log: %w", err)
+	}
+	return nil
+}
+
+func (s *auditService) List(ctx context.Context, filters *AuditFilters) ([]*domain.AuditLog, error) {
+	logs, err := s.repo.List(ctx, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit logs: %w", err)
+	}
+	if filters == nil {
+		return logs, nil
+	}
+
+	result := make([]*domain.AuditLog, 0, len(logs))
+	for _, log := range logs {
+		if filters.Resource != nil && log.Resource != *filters.Resource {
+			continue
+		}
+		if filters.ResourceID != nil && log.ResourceID != *filters.ResourceID {
+			continue
+		}
+		if filters.UserID != nil && log.UserID != *filters.UserID {
+			continue
+		}
+		if filters.Action != nil && log.Action != *filters.Action {
+			continue
+		}
+		if filters.CreatedAt != nil {
+			if filters.CreatedAt.From != nil && log.CreatedAt.Before(*filters.CreatedAt.From) {
+				continue
+			}
+			if filters.CreatedAt.To != nil && log.CreatedAt.After(*filters.CreatedAt.To) {
+				continue
+			}
+		}
+		result = append(result, log)
+	}
+
+	if filters.Offset > 0 {
+		if filters.Offset >= len(result) {
+			return []*domain.AuditLog{}, nil
+		}
+		result = result[filters.Offset:]
+	}
+	if filters.Limit > 0 && filters.Limit < len(result) {
+		result = result[:filters.Limit]
+	}
+	return result, nil
+}
+
+// auditIgnoredFields cambian en cada escritura y no aportan al diff
+var auditIgnoredFields = map[string]bool{"updated_at": true}
+
+// auditDiff compara los campos de primer nivel de ambos estados, tal como se
+// serializan en la API, y devuelve {"campo": {"from": ..., "to": ...}}
+func auditDiff(before, after interface{}) map[string]interface{} {
+	from := auditFields(before)
+	to := auditFields(after)
+
+	changes := make(map[string]interface{})
+	for field, value := range from {
+		if auditIgnoredFields[field] {
+			continue
+		}
+		if next, ok := to[field]; !ok || !reflect.DeepEqual(value, next) {
+			changes[field] = map[string]interface{}{"from": value, "to": to[field]}
+		}
+	}
+	for field, value := range to {
+		if _, ok := from[field]; ok || auditIgnoredFields[field] {
+			continue
+		}
+		changes[field] = map[string]interface{}{"from": nil, "to": value}
+	}
+	return changes
+}
+
+func auditFields(value interface{}) map[string]interface{} {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
+
+// AgentAuditSnapshot describe un agente MCP para la auditoría: tipo,
+// capacidades y la configuración cambiada en caliente
+func AgentAuditSnapshot(orchestrator mcp.MCPOrchestrator) AuditSnapshot {
+	return func(ctx context.Context, id string) (interface{}, error) {
+		agent, err := orchestrator.GetAgent(id)
+		if err != nil {
+			return nil, err
+		}
+
+		config := make(map[string]interface{})
+		for _, change := range agent.GetState().ConfigHistory {
+			for field, value := range change.Current {
+				config[field] = value
+			}
+		}
+		return map[string]interface{}{
+			"id":           agent.GetID(),
+			"type":         agent.GetType(),
+			"capabilities": agent.GetCapabilities(),
+			"config":       config,
+		}, nil
+	}
+}
@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Niveles de carga de Backpressure
+const (
+	BackpressureNormal    = "normal"
+	BackpressureShedding  = "shedding"  // se rechazan los bots de baja prioridad
+	BackpressureSaturated = "saturated" // se rechaza todo
+)
+
+// Recurso que determina la carga
+const (
+	BackpressureReasonTaskQueue     = "task_queue"
+	BackpressureReasonAIInFlight    = "ai_in_flight"
+	BackpressureReasonAIRateLimited = "ai_rate_limited"
+)
+
+// BackpressureConfig define cuándo se dejan de aceptar mensajes entrantes. La
+// carga es la mayor de la ocupación de la cola de tareas y de las llamadas en
+// curso al proveedor de IA, entre 0 y 1.
+type BackpressureConfig struct {
+	// RejectThreshold es la carga a partir de la que se rechazan todos los bots
+	RejectThreshold float64
+	// MaxAIInFlight es el máximo de llamadas simultáneas al proveedor; 0 no lo vigila
+	MaxAIInFlight int
+	// AIRateLimitCooldown es cuánto se considera saturado el proveedor tras un 429
+	AIRateLimitCooldown time.Duration
+	// ShedLowPriority rechaza antes los bots que no están en PriorityBots
+	ShedLowPriority      bool
+	LowPriorityThreshold float64
+	PriorityBots         []string
+	RetryAfter           time.Duration
+}
+
+// AILoad expone la carga del proveedor de IA; lo implementa ai.LoadTrackingClient
+type AILoad interface {
+	InFlight() int
+	RateLimitedWithin(window time.Duration) bool
+}
+
+// BackpressureRejection es el motivo por el que no se acepta un mensaje
+type BackpressureRejection struct {
+	Level             string        `json:"level"`
+	Reason            string        `json:"reason"`
+	Load              float64       `json:"load"`
+	LowPriority       bool          `json:"low_priority"`
+	RetryAfter        time.Duration `json:"-"`
+	RetryAfterSeconds int           `json:"retry_after_seconds"`
+}
+
+// Backpressure decide si se acepta un mensaje entrante según la carga y
+// publica un evento backpressure cada vez que cambia el nivel
+type Backpressure struct {
+	config   BackpressureConfig
+	queue    func() (depth, capacity int)
+	ai       AILoad
+	priority map[string]bool
+	eventBus events.EventBus
+	events   *events.EventFactory
+	logger   logger.Logger
+
+	mu    sync.Mutex
+	level string
+}
+
+// NewBackpressure crea el control de carga. queue y ai pueden ser nil.
+func NewBackpressure(config BackpressureConfig, queue func() (depth, capacity int), ai AILoad, eventBus events.EventBus, logger logger.Logger) *Backpressure {
+	if config.RejectThreshold <= 0 {
+		config.RejectThreshold = 0.9
+	}
+	if config.LowPriorityThreshold <= 0 || config.LowPriorityThreshold > config.RejectThreshold {
+		config.LowPriorityThreshold = config.RejectThreshold
+	}
+	if config.RetryAfter <= 0 {
+		config.RetryAfter = 5 * time.Second
+	}
+
+	priority := make(map[string]bool, len(config.PriorityBots))
+	for _, botID := range config.PriorityBots {
+		priority[botID] = true
+	}
+	return &Backpressure{
+		config:   config,
+		queue:    queue,
+		ai:       ai,
+		priority: priority,
+		eventBus: eventBus,
+		events:   events.NewEventFactory("bot-service"),
+		logger:   logger,
+		level:    BackpressureNormal,
+	}
+}
+
+// Load devuelve la carga actual y el recurso que la determina
+func (b *Backpressure) Load() (float64, string) {
+	load, reason := 0.0, BackpressureReasonTaskQueue
+	if b.queue != nil {
+		if depth, capacity := b.queue(); capacity > 0 {
+			load = float64(depth) / float64(capacity)
+		}
+	}
+	if b.ai != nil {
+		if b.config.AIRateLimitCooldown > 0 && b.ai.RateLimitedWithin(b.config.AIRateLimitCooldown) {
+			return 1, BackpressureReasonAIRateLimited
+		}
+		if b.config.MaxAIInFlight > 0 {
+			if aiLoad := float64(b.ai.InFlight()) / float64(b.config.MaxAIInFlight); aiLoad > load {
+				load, reason = aiLoad, BackpressureReasonAIInFlight
+			}
+		}
+	}
+	return load, reason
+}
+
+// Admit devuelve nil si se acepta el mensaje del bot, o el motivo del rechazo
+func (b *Backpressure) Admit(ctx context.Context, botID string) *BackpressureRejection {
+	load, reason := b.Load()
+	level := BackpressureNormal
+	switch {
+	case load >= b.config.RejectThreshold:
+		level = BackpressureSaturated
+	case b.config.ShedLowPriority && load >= b.config.LowPriorityThreshold:
+		level = BackpressureShedding
+	}
+	b.transition(ctx, level, reason, load)
+
+	lowPriority := level == BackpressureShedding
+	if level == BackpressureNormal || (lowPriority && b.priority[botID]) {
+		return nil
+	}
+
+	metrics.BackpressureRejections.WithLabelValues(reason, level).Inc()
+	return &BackpressureRejection{
+		Level:             level,
+		Reason:            reason,
+		Load:              math.Round(load*100) / 100,
+		LowPriority:       lowPriority,
+		RetryAfter:        b.config.RetryAfter,
+		RetryAfterSeconds: int(math.Ceil(b.config.RetryAfter.Seconds())),
+	}
+}
+
+// Level devuelve el último nivel de carga evaluado
+func (b *Backpressure) Level() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.level
+}
+
+func (b *Backpressure) transition(ctx context.Context, level, reason string, load float64) {
+	b.mu.Lock()
+	previous := b.level
+	b.level = level
+	b.mu.Unlock()
+	if previous == level {
+		return
+	}
+
+	b.logger.WithContext(ctx).Warn("Backpressure level changed",
+		"from", previous,
+		"to", level,
+		"reason", reason,
+		"load", load)
+	if b.eventBus == nil {
+		return
+	}
+	event := b.events.CreateSystemEvent(events.EventTypeBackpressure, map[string]interface{}{
+		"level":          level,
+		"previous_level": previous,
+		"reason":         reason,
+		"load":           load,
+		"retry_after":    int(math.Ceil(b.config.RetryAfter.Seconds())),
+	})
+	if err := b.eventBus.Publish(ctx, event); err != nil {
+		b.logger.WithContext(ctx).Error("Failed to publish backpressure event", "level", level, "error", err)
+	}
+}
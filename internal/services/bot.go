@@ -4,14 +4,26 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"math/rand"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/cache"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/tracing"
 )
 
+// maxStepHops limita los saltos encadenados (jump/switch_flow/subflow) en un mismo mensaje
+const maxStepHops = 10
+
+type stepHopsKey struct{}
+
 // BotService define las operaciones de negocio para bots
 type BotService interface {
 	GetBot(ctx context.Context, id string) (*domain.Bot, error)
@@ -20,6 +32,21 @@ type BotService interface {
 	UpdateBot(ctx context.Context, bot *domain.Bot) error
 	DeleteBot(ctx context.Context, id string) error
 	ProcessIncomingMessage(ctx context.Context, message *domain.IncomingMessage) (*domain.BotResponse, error)
+	SummarizeConversation(ctx context.Context, sessionID string, refresh bool) (*domain.ConversationSummary, error)
+	GetSessionState(ctx context.Context, sessionID string) (*domain.SessionState, error)
+	EscalateConversation(ctx context.Context, sessionID, queue, reason string) (*domain.ConversationSession, error)
+	GetHandoffs(ctx context.Context, botID string) ([]*domain.ConversationSession, error)
+	AcceptHandoff(ctx context.Context, sessionID, agentID string) (*domain.ConversationSession, error)
+	SendAgentMessage(ctx context.Context, sessionID, agentID, text string) (*domain.ConversationMessage, error)
+	ReleaseHandoff(ctx context.Context, sessionID, step string) (*domain.ConversationSession, error)
+	ResumeDelayedStep(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error)
+	GetBotVariables(ctx context.Context, botID string) (map[string]interface{}, error)
+	SetBotVariables(ctx context.Context, botID string, variables map[string]interface{}) (map[string]interface{}, error)
+	DeleteBotVariable(ctx context.Context, botID, key string) (map[string]interface{}, error)
+	UseStepMiddleware(middleware ...StepMiddleware)
+	UseClock(clk clock.Clock)
+	UseAudit(audit AuditService)
+	UseResponseCache(responseCache cache.Cache)
 }
 
 // BotFlowService define las operaciones de negocio para flujos de bot
@@ -29,6 +56,10 @@ type BotFlowService interface {
 	CreateFlow(ctx context.Context, flow *domain.BotFlow) error
 	UpdateFlow(ctx context.Context, flow *domain.BotFlow) error
 	DeleteFlow(ctx context.Context, id string) error
+	PublishFlow(ctx context.Context, id, notes string) (*domain.FlowVersion, error)
+	GetFlowVersions(ctx context.Context, id string) ([]*domain.FlowVersion, error)
+	GetFlowVersion(ctx context.Context, id string, version int) (*domain.FlowVersion, error)
+	RollbackFlow(ctx context.Context, id string, version int) (*domain.FlowVersion, error)
 }
 
 // BotStepService define las operaciones de negocio para pasos de flujo
@@ -48,7 +79,13 @@ type SmartReplyService interface {
 	UpdateSmartReply(ctx context.Context, reply *domain.SmartReply) error
 	DeleteSmartReply(ctx context.Context, id string) error
 	GenerateAIResponse(ctx context.Context, botID, prompt string, context map[string]interface{}) (*domain.SmartReply, error)
-	TrainIntents(ctx context.Context, botID string, intents []domain.SmartReply) error
+	StreamAIResponse(ctx context.Context, botID, prompt string, context map[string]interface{}) (<-chan *domain.SmartReplyChunk, error)
+	TrainIntents(ctx context.Context, botID string, intents []domain.IntentTraining) (*domain.IntentTrainingResult, error)
+	GetIntentExamples(ctx context.Context, botID, intent string) ([]*domain.IntentExample, error)
+	DeleteIntentExample(ctx context.Context, botID, id string) error
+	ClassifyIntent(ctx context.Context, botID, text string) ([]IntentScore, error)
+	UseUsage(usage UsageService)
+	UseClock(clk clock.Clock)
 }
 
 // ConversationService define las operaciones para manejo de conversaciones
@@ -65,42 +102,92 @@ type botService struct {
 	botRepo         domain.BotRepository
 	flowRepo        domain.BotFlowRepository
 	stepRepo        domain.BotStepRepository
+	flowVersionRepo domain.FlowVersionRepository
 	sessionRepo     domain.ConversationSessionRepository
 	smartReplyRepo  domain.SmartReplyRepository
 	conversationSvc ConversationService
 	smartReplySvc   SmartReplyService
+	entitySvc       EntityExtractionService
+	memorySvc       MemoryService
+	faqSvc          FAQService
 	mcpOrchestrator interface {
 		mcp.MCPOrchestrator
 		mcp.MCPDomainOrchestrator
 	}
+	conditions      *expressionEngine
+	eventBus        events.EventBus
+	scheduler       TaskScheduler
+	events          *events.EventFactory
+	translations    *translationCache
+	clock           clock.Clock
+	// random elige las variantes de los pasos random; debe admitir llamadas
+	// concurrentes (los tests usan una fuente con semilla)
+	random          func() float64
+	audit           AuditService
+	responseCache   cache.Cache
 	logger          logger.Logger
+
+	conversations  conversationLocks
+	stepMu         sync.RWMutex
+	stepMiddleware []StepMiddleware
+	stepChain      StepHandler
 }
 
 func NewBotService(
 	botRepo domain.BotRepository,
 	flowRepo domain.BotFlowRepository,
 	stepRepo domain.BotStepRepository,
+	flowVersionRepo domain.FlowVersionRepository,
 	sessionRepo domain.ConversationSessionRepository,
 	smartReplyRepo domain.SmartReplyRepository,
 	conversationSvc ConversationService,
 	smartReplySvc SmartReplyService,
+	entitySvc EntityExtractionService,
+	memorySvc MemoryService,
+	faqSvc FAQService,
 	mcpOrchestrator interface {
 		mcp.MCPOrchestrator
 		mcp.MCPDomainOrchestrator
 	},
+	eventBus events.EventBus,
+	scheduler TaskScheduler,
 	logger logger.Logger,
 ) BotService {
-	return &botService{
+	s := &botService{
 		botRepo:         botRepo,
 		flowRepo:        flowRepo,
 		stepRepo:        stepRepo,
+		flowVersionRepo: flowVersionRepo,
 		sessionRepo:     sessionRepo,
 		smartReplyRepo:  smartReplyRepo,
 		conversationSvc: conversationSvc,
 		smartReplySvc:   smartReplySvc,
+		entitySvc:       entitySvc,
+		memorySvc:       memorySvc,
+		faqSvc:          faqSvc,
 		mcpOrchestrator: mcpOrchestrator,
+		conditions:      newExpressionEngine(nil),
+		eventBus:        eventBus,
+		scheduler:       scheduler,
+		events:          events.NewEventFactory("bot-service"),
+		translations:    newTranslationCache(translationCacheSize, translationCacheTTL),
+		clock:           clock.Real(),
+		random:          rand.Float64,
 		logger:          logger,
 	}
+	s.UseStepMiddleware(stepMetricsMiddleware, s.stepLoggingMiddleware, stepTraceMiddleware, s.moderationMiddleware)
+	return s
+}
+
+// UseClock cambia el reloj con el que el bot fecha sesiones, calcula esperas y
+// caducidades y evalúa condiciones y fechas de entidades; el simulador y las
+// pruebas usan un reloj controlable
+func (s *botService) UseClock(clk clock.Clock) {
+	s.clock = clock.OrReal(clk)
+	s.conditions.now = s.clock.Now
+	if s.entitySvc != nil {
+		s.entitySvc.UseClock(s.clock)
+	}
 }
 
 func (s *botService) GetBot(ctx context.Context, id string) (*domain.Bot, error) {
@@ -112,13 +199,13 @@ func (s *botService) GetBotsByOwner(ctx context.Context, ownerID string) ([]*dom
 }
 
 func (s *botService) CreateBot(ctx context.Context, bot *domain.Bot) error {
-	bot.CreatedAt = time.Now()
-	bot.UpdatedAt = time.Now()
+	bot.CreatedAt = s.clock.Now()
+	bot.UpdatedAt = s.clock.Now()
 	return s.botRepo.Create(ctx, bot)
 }
 
 func (s *botService) UpdateBot(ctx context.Context, bot *domain.Bot) error {
-	bot.UpdatedAt = time.Now()
+	bot.UpdatedAt = s.clock.Now()
 	return s.botRepo.Update(ctx, bot)
 }
 
@@ -127,6 +214,56 @@ func (s *botService) DeleteBot(ctx context.Context, id string) error {
 }
 
 func (s *botService) ProcessIncomingMessage(ctx context.Context, message *domain.IncomingMessage) (*domain.BotResponse, error) {
+	tracing.Record(ctx, tracing.ComponentAdapter, string(message.Channel), "")
+	if s.eventBus != nil {
+		received := s.events.CreateUserEvent(events.EventTypeMessageReceived, message.UserID, map[string]interface{}{
+			"bot_id":     message.BotID,
+			"message_id": message.ID,
+			"channel":    message.Channel,
+			"content":    message.Content,
+			"timestamp":  message.Timestamp,
+		})
+		if err := s.eventBus.Publish(ctx, received); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to publish message received event", "error", err)
+		}
+	}
+
+	unlock := s.conversations.lock(message.BotID, message.UserID)
+	response, err := s.processIncomingMessage(ctx, message)
+	unlock()
+	if err != nil || s.eventBus == nil {
+		return response, err
+	}
+
+	data := map[string]interface{}{
+		"bot_id":        message.BotID,
+		"message_id":    message.ID,
+		"channel":       message.Channel,
+		"content":       message.Content,
+		"response":      response.Content,
+		"response_type": response.Type,
+	}
+	// Intent y confianza de la IA, para la analítica del bot
+	for _, key := range []string{"intent", "confidence"} {
+		if value, ok := response.Metadata[key]; ok {
+			data[key] = value
+		}
+	}
+	if len(message.Entities) > 0 {
+		data[EntitiesContextKey] = entityValues(message.Entities)
+	}
+	if message.Sentiment != nil {
+		data["sentiment"] = message.Sentiment.Score
+		data["sentiment_label"] = message.Sentiment.Label
+	}
+	event := s.events.CreateUserEvent(events.EventTypeMessageProcessed, message.UserID, data)
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish message processed event", "error", err)
+	}
+	return response, nil
+}
+
+func (s *botService) processIncomingMessage(ctx context.Context, message *domain.IncomingMessage) (*domain.BotResponse, error) {
 	// Obtener o crear sesión de conversación
 	session, err := s.conversationSvc.GetSession(ctx, message.UserID, message.BotID)
 	if err != nil {
@@ -135,12 +272,17 @@ func (s *botService) ProcessIncomingMessage(ctx context.Context, message *domain
 			BotID:     message.BotID,
 			UserID:    message.UserID,
 			Context:   make(map[string]interface{}),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-			ExpiresAt: time.Now().Add(24 * time.Hour),
+			CreatedAt: s.clock.Now(),
+			UpdatedAt: s.clock.Now(),
+			ExpiresAt: s.clock.Now().Add(24 * time.Hour),
 		}
 	}
 
+	ctx = logger.ContextWithFields(ctx, logger.BotIDKey, message.BotID)
+	if session.ID != "" {
+		ctx = logger.ContextWithFields(ctx, logger.SessionIDKey, session.ID)
+	}
+
 	// Obtener bot
 	bot, err := s.botRepo.GetByID(ctx, message.BotID)
 	if err != nil {
@@ -154,13 +296,75 @@ func (s *botService) ProcessIncomingMessage(ctx context.Context, message *domain
 		}, nil
 	}
 
+	botConfig := parseBotConfig(bot)
+	if botConfig.Sandbox != nil {
+		if s.clock.Now().After(botConfig.Sandbox.ExpiresAt) {
+			return &domain.BotResponse{
+				Content: "Bot is currently unavailable",
+				Type:    domain.ResponseTypeText,
+			}, nil
+		}
+		ctx = withSandbox(ctx)
+	}
+
+	// Las notas de voz se transcriben para que el flujo trabaje con texto
+	if audioURL, mimeType, ok := voiceNoteAudio(message); ok && (botConfig.VoiceNotes == nil || !botConfig.VoiceNotes.Disabled) {
+		transcript, err := s.transcribeVoiceNote(ctx, message, audioURL, mimeType, botConfig.VoiceNotes)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("Failed to transcribe voice note", "bot_id", bot.ID, "message_id", message.ID, "error", err)
+			return &domain.BotResponse{
+				Content:  voiceNoteFailureMessage(botConfig.VoiceNotes),
+				Type:     domain.ResponseTypeText,
+				Metadata: map[string]interface{}{"audio_url": audioURL},
+			}, nil
+		}
+		applyTranscript(message, session, transcript)
+	}
+
+	// Con traducción automática el flujo trabaja en el idioma del bot
+	translation := botConfig.Translation
+	if translation != nil && (!translation.AutoTranslate || translation.BotLanguage == "") {
+		translation = nil
+	}
+	userLanguage := ""
+	if translation != nil {
+		userLanguage = s.translateIncoming(ctx, message, session, translation)
+	}
+	if botConfig.Moderation != nil {
+		s.moderateIncoming(ctx, message, session, botConfig.Moderation)
+	}
+
+	s.appendTranscript(session, "user", message.Content)
+
+	// Con la conversación derivada a un humano el bot no ejecuta el flujo
+	if session.Handoff != nil {
+		return s.relayToHandoff(ctx, message, session), nil
+	}
+
+	// Extraer entidades del mensaje al contexto de la sesión
+	s.extractEntities(ctx, message, session, botConfig.Entities)
+	s.analyzeSentiment(ctx, message, session, botConfig.Sentiment)
+
 	// Determinar flujo a ejecutar
 	var flow *domain.BotFlow
-	if session.CurrentFlowID != "" {
-		flow, err = s.flowRepo.GetByID(ctx, session.CurrentFlowID)
+
+	// Los intents globales tienen prioridad sobre el paso actual
+	var globalIntent string
+	if intent := matchGlobalIntent(botConfig.GlobalIntents, message.Content); intent != nil {
+		flow, err = s.interruptWithIntent(ctx, intent, bot.ID, session)
 		if err != nil {
-			s.logger.Warn("Current flow not found, using default", "flow_id", session.CurrentFlowID)
+			s.logger.WithContext(ctx).Warn("Failed to run global intent", "intent", intent.Name, "error", err)
 			flow = nil
+		} else {
+			globalIntent = intent.Name
+		}
+	}
+
+	if flow == nil && session.CurrentFlowID != "" {
+		flow, err = s.flowRepo.GetByID(ctx, session.CurrentFlowID)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("Current flow not found, applying migration policy", "flow_id", session.CurrentFlowID)
+			flow, _ = s.migrateStaleSession(ctx, nil, botConfig, session)
 		}
 	}
 
@@ -171,10 +375,23 @@ func (s *botService) ProcessIncomingMessage(ctx context.Context, message *domain
 			return nil, fmt.Errorf("failed to get flows: %w", err)
 		}
 
+		// Elegir el flujo cuyo trigger coincide mejor (0 = coincidencia exacta)
+		bestDistance := -1
 		for _, f := range flows {
-			if f.Trigger != "" && f.Trigger == message.Content {
+			distance := matchFlowTrigger(f, message.Content)
+			if distance >= 0 && (bestDistance < 0 || distance < bestDistance) {
 				flow = f
-				break
+				bestDistance = distance
+			}
+		}
+
+		if flow == nil && s.faqSvc != nil {
+			// Antes del flujo por defecto, intentar responder desde la FAQ
+			if response := s.answerFromFAQ(ctx, message, session); response != nil {
+				if translation != nil {
+					s.translateResponse(ctx, bot.ID, response, userLanguage, translation)
+				}
+				return response, nil
 			}
 		}
 
@@ -187,48 +404,203 @@ func (s *botService) ProcessIncomingMessage(ctx context.Context, message *domain
 		}
 	}
 
+	// Al entrar a un flujo la sesión queda fijada a su versión publicada;
+	// las sesiones en curso siguen con la versión con la que empezaron
+	if session.CurrentFlowID != flow.ID || session.CurrentStepID == "" {
+		session.CurrentStepID = ""
+		enterFlow(session, flow)
+	}
+
 	// Ejecutar paso actual o inicial
 	var currentStep *domain.BotStep
 	if session.CurrentStepID != "" {
-		currentStep, err = s.stepRepo.GetByID(ctx, session.CurrentStepID)
+		currentStep, err = s.flowStep(ctx, flow.ID, session.FlowVersion, session.CurrentStepID)
 		if err != nil {
-			s.logger.Warn("Current step not found, using entry point", "step_id", session.CurrentStepID)
-			currentStep = nil
+			s.logger.WithContext(ctx).Warn("Current step not found, applying migration policy", "flow_id", flow.ID, "step_id", session.CurrentStepID)
+			flow, currentStep = s.migrateStaleSession(ctx, flow, botConfig, session)
 		}
 	}
 
 	if currentStep == nil {
 		// Usar punto de entrada del flujo
-		currentStep, err = s.stepRepo.GetByID(ctx, flow.EntryPoint)
+		entryPoint := s.flowEntryPoint(ctx, flow, session.FlowVersion)
+		currentStep, err = s.flowStep(ctx, flow.ID, session.FlowVersion, entryPoint)
 		if err != nil {
 			return nil, fmt.Errorf("entry point step not found: %w", err)
 		}
 	}
 
-	// Procesar paso
+	// Procesar paso (un paso switch_flow puede cambiar el flujo actual)
 	response, nextStepID, err := s.processStep(ctx, currentStep, message, session)
 	if err != nil {
 		return nil, fmt.Errorf("failed to process step: %w", err)
 	}
 
+	if globalIntent != "" {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		if _, ok := response.Metadata["intent"]; !ok {
+			response.Metadata["intent"] = globalIntent
+		}
+	}
+
 	// Actualizar sesión
-	session.CurrentFlowID = flow.ID
 	session.CurrentStepID = ""
 	if nextStepID != nil {
 		session.CurrentStepID = *nextStepID
 	}
-	session.UpdatedAt = time.Now()
+	session.UpdatedAt = s.clock.Now()
 	session.Context["last_message"] = message.Content
 	session.Context["last_response"] = response.Content
+	s.appendTranscript(session, "bot", response.Content)
+	session.Context["channel"] = string(message.Channel)
 
-	if err := s.conversationSvc.UpdateSession(ctx, session); err != nil {
-		s.logger.Error("Failed to update session", "error", err)
+	// Al terminar un sub-flujo se vuelve al flujo que lo llamó
+	if nextStepID == nil && session.EndedAt == nil && returnFromSubflow(session) {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["subflow_returned_to"] = session.CurrentFlowID
+		if session.CurrentStepID != "" {
+			nextStepID = &session.CurrentStepID
+		}
+	}
+
+	// Al terminar el flujo de un intent global, volver al punto interrumpido
+	if nextStepID == nil && session.EndedAt == nil && resumeInterruptedFlow(session) {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["resumed_flow_id"] = session.CurrentFlowID
 	}
 
+	if translation != nil {
+		s.translateResponse(ctx, bot.ID, response, userLanguage, translation)
+	}
+
+	if session.EndedAt != nil {
+		s.endConversation(ctx, session)
+		return response, nil
+	}
+
+	s.saveSession(ctx, session)
+	s.publishSentimentThreshold(ctx, message, session)
+
 	return response, nil
 }
 
+// saveSession persiste la sesión, creándola si es la primera interacción del usuario
+func (s *botService) saveSession(ctx context.Context, session *domain.ConversationSession) {
+	if session.ID == "" {
+		if err := s.conversationSvc.CreateSession(ctx, session); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to create session", "error", err)
+			return
+		}
+		s.publishSessionStarted(ctx, session)
+		return
+	}
+
+	if err := s.conversationSvc.UpdateSession(ctx, session); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to update session", "error", err)
+	}
+}
+
+// answerFromFAQ responde con la FAQ del bot; si no hay coincidencia registra la pregunta sin respuesta
+func (s *botService) answerFromFAQ(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession) *domain.BotResponse {
+	match, err := s.faqSvc.Answer(ctx, message.BotID, message.Content)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to query FAQ", "bot_id", message.BotID, "error", err)
+		return nil
+	}
+
+	if match == nil {
+		if err := s.faqSvc.RecordUnanswered(ctx, message.BotID, message.UserID, message.Content); err != nil {
+			s.logger.WithContext(ctx).Warn("Failed to record unanswered question", "bot_id", message.BotID, "error", err)
+		}
+		return nil
+	}
+
+	response := &domain.BotResponse{
+		Content: match.Entry.Answer,
+		Type:    domain.ResponseTypeText,
+		Metadata: map[string]interface{}{
+			"faq_id":    match.Entry.ID,
+			"faq_score": match.Score,
+		},
+	}
+
+	session.UpdatedAt = s.clock.Now()
+	session.Context["last_message"] = message.Content
+	session.Context["last_response"] = response.Content
+	session.Context["channel"] = string(message.Channel)
+	s.appendTranscript(session, "bot", response.Content)
+	s.saveSession(ctx, session)
+
+	return response
+}
+
+// endConversation cierra la sesión y emite el evento conversation_ended
+func (s *botService) endConversation(ctx context.Context, session *domain.ConversationSession) {
+	if session.ID != "" {
+		// Guardar el último turno antes de cerrarla para que llegue al historial
+		if err := s.conversationSvc.UpdateSession(ctx, session); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to save ended session", "session_id", session.ID, "error", err)
+		}
+		if err := s.conversationSvc.DeleteSession(ctx, session.ID); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to close session", "session_id", session.ID, "error", err)
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("Conversation ended",
+		"bot_id", session.BotID,
+		"user_id", session.UserID,
+		"flow_id", session.CurrentFlowID)
+
+	if s.eventBus == nil {
+		return
+	}
+
+	event := s.events.CreateUserEvent(events.EventTypeConversationEnded, session.UserID, map[string]interface{}{
+		"bot_id":     session.BotID,
+		"session_id": session.ID,
+		"flow_id":    session.CurrentFlowID,
+		"reason":     session.Context["end_reason"],
+		"started_at": session.CreatedAt,
+		"ended_at":   session.EndedAt,
+	})
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish conversation ended event", "error", err)
+	}
+}
+
+func (s *botService) publishSessionStarted(ctx context.Context, session *domain.ConversationSession) {
+	if s.eventBus == nil {
+		return
+	}
+
+	event := s.events.CreateUserEvent(events.EventTypeSessionStarted, session.UserID, map[string]interface{}{
+		"bot_id":     session.BotID,
+		"session_id": session.ID,
+		"flow_id":    session.CurrentFlowID,
+		"channel":    session.Context["channel"],
+		"started_at": session.CreatedAt,
+	})
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish session started event", "error", err)
+	}
+}
+
+// processStep ejecuta el paso a través de la cadena de middleware registrada
 func (s *botService) processStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	s.stepMu.RLock()
+	chain := s.stepChain
+	s.stepMu.RUnlock()
+	return chain(ctx, step, message, session)
+}
+
+// executeStep despacha el paso según su tipo; es el final de la cadena de middleware
+func (s *botService) executeStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
 	switch step.Type {
 	case domain.StepTypeMessage:
 		return s.processMessageStep(ctx, step, message, session)
@@ -240,6 +612,22 @@ func (s *botService) processStep(ctx context.Context, step *domain.BotStep, mess
 		return s.processAPICallStep(ctx, step, message, session)
 	case domain.StepTypeAI:
 		return s.processAIStep(ctx, step, message, session)
+	case domain.StepTypeRandom:
+		return s.processRandomStep(ctx, step, message, session)
+	case domain.StepTypeJump:
+		return s.processJumpStep(ctx, step, message, session)
+	case domain.StepTypeSwitch:
+		return s.processSwitchFlowStep(ctx, step, message, session)
+	case domain.StepTypeSubflow:
+		return s.processSubflowStep(ctx, step, message, session)
+	case domain.StepTypeEnd:
+		return s.processEndStep(ctx, step, message, session)
+	case domain.StepTypeHandoff:
+		return s.processHandoffStep(ctx, step, message, session)
+	case domain.StepTypeForm:
+		return s.processFormStep(ctx, step, message, session)
+	case domain.StepTypeDelay:
+		return s.processDelayStep(ctx, step, message, session)
 	default:
 		return &domain.BotResponse{
 			Content: "Unknown step type",
@@ -260,10 +648,11 @@ func (s *botService) processMessageStep(ctx context.Context, step *domain.BotSte
 		return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
 	}
 
+	data := s.templateData(ctx, message, session, optionTexts(content.Text, content.Options)...)
 	response := &domain.BotResponse{
-		Content:    content.Text,
+		Content:    renderTemplate(content.Text, data),
 		Type:       content.Type,
-		Options:    content.Options,
+		Options:    renderOptions(content.Options, data),
 		NextStepID: step.NextStepID,
 	}
 
@@ -284,9 +673,9 @@ func (s *botService) processDecisionStep(ctx context.Context, step *domain.BotSt
 		return nil, nil, fmt.Errorf("failed to parse conditions: %w", err)
 	}
 
-	// Evaluación simple de condiciones (se puede expandir)
+	input := s.buildConditionInput(ctx, message, session)
 	for _, rule := range conditions.Rules {
-		if s.evaluateCondition(rule.Condition, message.Content, session.Context) {
+		if s.evaluateCondition(rule.Condition, message.Content, input) {
 			return &domain.BotResponse{
 				Content: "Condition matched, proceeding...",
 				Type:    domain.ResponseTypeText,
@@ -301,22 +690,220 @@ func (s *botService) processDecisionStep(ctx context.Context, step *domain.BotSt
 	}, &conditions.Default, nil
 }
 
+func (s *botService) processRandomStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	// Variantes con peso para variar respuestas o repartir tráfico
+	var content struct {
+		Variants []struct {
+			ID       string                  `json:"id"`
+			Weight   float64                 `json:"weight"`
+			Text     string                  `json:"text"`
+			Type     domain.ResponseType     `json:"type"`
+			Options  []domain.ResponseOption `json:"options,omitempty"`
+			NextStep string                  `json:"next_step"`
+		} `json:"variants"`
+		// Sticky mantiene la misma variante para toda la sesión
+		Sticky bool `json:"sticky"`
+	}
+
+	if err := json.Unmarshal(step.Content, &content); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
+	}
+
+	if len(content.Variants) == 0 {
+		return nil, nil, fmt.Errorf("random step %s has no variants", step.ID)
+	}
+
+	assignmentKey := "variant_" + step.ID
+	selected := -1
+
+	if content.Sticky {
+		if assigned, ok := session.Context[assignmentKey].(string); ok {
+			for i, variant := range content.Variants {
+				if variant.ID == assigned {
+					selected = i
+					break
+				}
+			}
+		}
+	}
+
+	if selected < 0 {
+		weights := make([]float64, len(content.Variants))
+		for i, variant := range content.Variants {
+			weights[i] = variant.Weight
+		}
+		selected = pickWeighted(weights, s.random)
+	}
+
+	variant := content.Variants[selected]
+	if variant.ID == "" {
+		variant.ID = fmt.Sprintf("%d", selected)
+	}
+	session.Context[assignmentKey] = variant.ID
+
+	nextStepID := step.NextStepID
+	if variant.NextStep != "" {
+		nextStepID = &variant.NextStep
+	}
+
+	responseType := variant.Type
+	if responseType == "" {
+		responseType = domain.ResponseTypeText
+	}
+
+	data := s.templateData(ctx, message, session, optionTexts(variant.Text, variant.Options)...)
+	response := &domain.BotResponse{
+		Content:    renderTemplate(variant.Text, data),
+		Type:       responseType,
+		Options:    renderOptions(variant.Options, data),
+		NextStepID: nextStepID,
+		Metadata: map[string]interface{}{
+			"variant": variant.ID,
+		},
+	}
+
+	return response, nextStepID, nil
+}
+
+func (s *botService) processJumpStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	var content struct {
+		Step string `json:"step"` // ID o nombre del paso destino
+	}
+
+	if err := json.Unmarshal(step.Content, &content); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
+	}
+
+	target, err := s.findStepInFlow(ctx, step.FlowID, sessionFlowVersion(session, step.FlowID), content.Step)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return s.continueWithStep(ctx, target, message, session)
+}
+
+func (s *botService) processSwitchFlowStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	var content struct {
+		FlowID string `json:"flow_id"`
+		Step   string `json:"step,omitempty"` // opcional, por defecto el entry point
+	}
+
+	if err := json.Unmarshal(step.Content, &content); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
+	}
+
+	flow, err := s.flowRepo.GetByID(ctx, content.FlowID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("target flow not found: %w", err)
+	}
+
+	stepRef := content.Step
+	if stepRef == "" {
+		stepRef = s.flowEntryPoint(ctx, flow, flow.PublishedVersion)
+	}
+
+	target, err := s.findStepInFlow(ctx, flow.ID, flow.PublishedVersion, stepRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	enterFlow(session, flow)
+	return s.continueWithStep(ctx, target, message, session)
+}
+
+func (s *botService) processEndStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	var content struct {
+		Text   string `json:"text"`
+		Reason string `json:"reason"`
+	}
+
+	if len(step.Content) > 0 {
+		if err := json.Unmarshal(step.Content, &content); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
+		}
+	}
+
+	now := s.clock.Now()
+	session.EndedAt = &now
+	if content.Reason != "" {
+		session.Context["end_reason"] = content.Reason
+	}
+
+	response := &domain.BotResponse{
+		Content: renderTemplate(content.Text, s.templateData(ctx, message, session, content.Text)),
+		Type:    domain.ResponseTypeText,
+		Metadata: map[string]interface{}{
+			"conversation_ended": true,
+		},
+	}
+
+	return response, nil, nil
+}
+
+// continueWithStep ejecuta inmediatamente el paso destino de un salto
+func (s *botService) continueWithStep(ctx context.Context, target *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	hops, _ := ctx.Value(stepHopsKey{}).(int)
+	if hops >= maxStepHops {
+		return nil, nil, fmt.Errorf("too many chained jumps (max %d), possible loop at step %s", maxStepHops, target.ID)
+	}
+
+	return s.processStep(context.WithValue(ctx, stepHopsKey{}, hops+1), target, message, session)
+}
+
+// findStepInFlow busca un paso de la versión del flujo por ID o por nombre
+func (s *botService) findStepInFlow(ctx context.Context, flowID string, version int, ref string) (*domain.BotStep, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("target step is required")
+	}
+
+	steps, err := s.flowSteps(ctx, flowID, version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow steps: %w", err)
+	}
+
+	for _, candidate := range steps {
+		if candidate.ID == ref || (candidate.Name != "" && candidate.Name == ref) {
+			return candidate, nil
+		}
+	}
+
+	return nil, fmt.Errorf("step %s not found in flow %s", ref, flowID)
+}
+
 func (s *botService) processInputStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
 	// Guardar input del usuario en el contexto
 	var content struct {
 		Prompt   string `json:"prompt"`
 		Variable string `json:"variable"`
+		Entity   string `json:"entity"` // tipo de entidad esperada (opcional)
 	}
 
 	if err := json.Unmarshal(step.Content, &content); err != nil {
 		return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
 	}
 
+	var value interface{} = message.Content
+	if content.Entity != "" {
+		// Usar la entidad extraída del mensaje actual; si no hay, volver a preguntar
+		entity := findEntity(session, content.Entity)
+		if entity == nil {
+			prompt := content.Prompt
+			if prompt == "" {
+				prompt = fmt.Sprintf("Please provide a valid %s.", strings.ReplaceAll(content.Entity, "_", " "))
+			}
+			return &domain.BotResponse{
+				Content: prompt,
+				Type:    domain.ResponseTypeText,
+			}, &step.ID, nil
+		}
+		value = entity.Value
+	}
+
 	// Guardar respuesta del usuario
-	session.Context[content.Variable] = message.Content
+	session.Context[content.Variable] = value
 
 	response := &domain.BotResponse{
-		Content: fmt.Sprintf("Thank you! I've saved your response: %s", message.Content),
+		Content: fmt.Sprintf("Thank you! I've saved your response: %v", value),
 		Type:    domain.ResponseTypeText,
 	}
 
@@ -327,14 +914,44 @@ func (s *botService) processAPICallStep(ctx context.Context, step *domain.BotSte
 	// Parsear configuración del paso API
 	var content struct {
 		AgentType string                 `json:"agent_type"`
+		// TaskType es el tipo de tarea (database_query, grpc_call...); por defecto agent_type
+		TaskType  string                 `json:"task_type"`
 		Config    map[string]interface{} `json:"config"`
 		Task      map[string]interface{} `json:"task"`
+		CacheTTL  string                 `json:"cache_ttl"`
+		// ResponseMapping guarda campos de la respuesta como variables de la sesión
+		ResponseMapping map[string]responseMapping `json:"response_mapping"`
 	}
 	
 	if err := json.Unmarshal(step.Content, &content); err != nil {
 		return nil, nil, fmt.Errorf("failed to parse API step content: %w", err)
 	}
 
+	// La configuración y la tarea admiten {{vars.*}} y las variables de la sesión
+	data := s.buildConditionInput(ctx, message, session)
+	content.Config = renderConfigMap(content.Config, data)
+	content.Task = renderConfigMap(content.Task, data)
+
+	// Con cache_ttl la misma llamada se responde desde la caché sin crear el agente
+	cacheTTL := s.stepCacheTTL(ctx, step.ID, content.CacheTTL)
+	cacheKey, cacheable := "", false
+	if cacheTTL > 0 {
+		cacheKey, cacheable = apiCallCacheKey(content.AgentType, content.Config, content.Task)
+	}
+	var cachedOutput map[string]interface{}
+	if cacheable && s.cachedResponse(ctx, responseCacheHTTP, cacheKey, &cachedOutput) {
+		session.Context["api_result"] = cachedOutput
+		s.applyResponseMapping(ctx, step.ID, content.ResponseMapping, cachedOutput, session.Context)
+		return &domain.BotResponse{
+			Content: apiCallContent(cachedOutput),
+			Type:    domain.ResponseTypeText,
+			Metadata: map[string]interface{}{
+				"agent_type": content.AgentType,
+				"cached":     true,
+			},
+		}, step.NextStepID, nil
+	}
+
 	// Configurar agente MCP si es necesario
 	agentConfig := mcp.MCPConfig{
 		Type:         content.AgentType,
@@ -346,9 +963,9 @@ func (s *botService) processAPICallStep(ctx context.Context, step *domain.BotSte
 	}
 
 	// Instanciar agente MCP
-	agent, err := s.mcpOrchestrator.InstantiateMCP(ctx, agentConfig)
+	agent, err := s.instantiateAgent(ctx, agentConfig)
 	if err != nil {
-		s.logger.Error("Failed to instantiate MCP agent", "error", err)
+		s.logger.WithContext(ctx).Error("Failed to instantiate MCP agent", "error", err)
 		return &domain.BotResponse{
 			Content: "Unable to process API request at this time",
 			Type:    domain.ResponseTypeText,
@@ -365,13 +982,17 @@ func (s *botService) processAPICallStep(ctx context.Context, step *domain.BotSte
 	agentContext["bot_id"] = message.BotID
 
 	if err := s.mcpOrchestrator.PassContext(ctx, agent.GetID(), agentContext); err != nil {
-		s.logger.Error("Failed to pass context to agent", "error", err)
+		s.logger.WithContext(ctx).Error("Failed to pass context to agent", "error", err)
 	}
 
 	// Crear tarea para el agente
+	taskType := content.TaskType
+	if taskType == "" {
+		taskType = content.AgentType
+	}
 	task := mcp.Task{
-		ID:          fmt.Sprintf("task-%s-%d", step.ID, time.Now().UnixNano()),
-		Type:        content.AgentType,
+		ID:          fmt.Sprintf("task-%s-%s", step.ID, id.New()),
+		Type:        taskType,
 		Description: fmt.Sprintf("API call for step %s", step.ID),
 		Input:       content.Task,
 		Priority:    5,
@@ -381,10 +1002,14 @@ func (s *botService) processAPICallStep(ctx context.Context, step *domain.BotSte
 		},
 	}
 
-	// Ejecutar tarea
-	result, err := s.mcpOrchestrator.ExecuteTask(ctx, task)
+	// Ejecutar tarea; en sandbox la ejecuta el agente simulado recién creado
+	execute := s.mcpOrchestrator.ExecuteTask
+	if inSandbox(ctx) {
+		execute = agent.Execute
+	}
+	result, err := execute(ctx, task)
 	if err != nil {
-		s.logger.Error("MCP task execution failed", "error", err)
+		s.logger.WithContext(ctx).Error("MCP task execution failed", "error", err)
 		return &domain.BotResponse{
 			Content: "API request failed. Please try again later.",
 			Type:    domain.ResponseTypeText,
@@ -394,21 +1019,22 @@ func (s *botService) processAPICallStep(ctx context.Context, step *domain.BotSte
 	// Procesar resultado
 	var responseContent string
 	if result.Success {
-		if output, exists := result.Output["response"]; exists {
-			responseContent = fmt.Sprintf("API call successful: %v", output)
-		} else {
-			responseContent = "API call completed successfully"
-		}
+		responseContent = apiCallContent(result.Output)
 		
 		// Guardar resultado en el contexto de la sesión
 		session.Context["api_result"] = result.Output
+		if cacheable {
+			s.storeResponse(ctx, responseCacheHTTP, cacheKey, result.Output, cacheTTL)
+		}
 	} else {
 		responseContent = fmt.Sprintf("API call failed: %s", result.Error)
 	}
+	// Si la llamada falla las variables mapeadas toman su default
+	s.applyResponseMapping(ctx, step.ID, content.ResponseMapping, result.Output, session.Context)
 
 	// Terminar agente después del uso
 	if err := s.mcpOrchestrator.TerminateAgent(ctx, agent.GetID()); err != nil {
-		s.logger.Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
+		s.logger.WithContext(ctx).Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
 	}
 
 	response := &domain.BotResponse{
@@ -424,40 +1050,298 @@ func (s *botService) processAPICallStep(ctx context.Context, step *domain.BotSte
 	return response, step.NextStepID, nil
 }
 
+// apiCallContent es el texto de respuesta de una llamada API correcta
+func apiCallContent(output map[string]interface{}) string {
+	if response, exists := output["response"]; exists {
+		return fmt.Sprintf("API call successful: %v", response)
+	}
+	return "API call completed successfully"
+}
+
 func (s *botService) processAIStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
-	// Generar respuesta usando IA
-	smartReply, err := s.smartReplySvc.GenerateAIResponse(ctx, message.BotID, message.Content, session.Context)
+	var content aiStepContent
+	if len(step.Content) > 0 {
+		if err := json.Unmarshal(step.Content, &content); err == nil && content.Mode == "image" {
+			return s.processImageGenerationStep(ctx, step, content, message, session)
+		}
+	}
+
+	intents, err := s.smartReplySvc.ClassifyIntent(ctx, message.BotID, message.Content)
 	if err != nil {
-		s.logger.Error("Failed to generate AI response", "error", err)
-		return &domain.BotResponse{
-			Content: "I'm having trouble understanding. Could you please rephrase?",
-			Type:    domain.ResponseTypeText,
-		}, step.NextStepID, nil
+		s.logger.WithContext(ctx).Warn("Failed to classify intent", "bot_id", message.BotID, "error", err)
+	}
+	topConfidence := 0.0
+	if len(intents) > 0 {
+		topConfidence = intents[0].Confidence
+	}
+
+	// Por debajo del umbral el mensaje no se entiende y pasa al flujo de fallback
+	if flow := s.nluFallbackFlow(ctx, message.BotID, session, topConfidence); flow != nil {
+		return s.enterFallbackFlow(ctx, flow, message, session, map[string]interface{}{
+			"intent":            FallbackIntent,
+			"intent_confidence": topConfidence,
+		})
+	}
+
+	// El proveedor de IA y las herramientas del bot se aplican a la generación
+	if bot, err := s.botRepo.GetByID(ctx, message.BotID); err == nil {
+		ctx = withAIStep(ctx, parseBotConfig(bot), message.UserID)
+	}
+
+	// Generar respuesta usando IA, con lo que el bot recuerda del usuario
+	aiContext := s.aiStepContext(ctx, message, session)
+	cacheTTL := s.stepCacheTTL(ctx, step.ID, content.CacheTTL)
+	cacheKey, cacheable := "", false
+	if cacheTTL > 0 {
+		cacheKey, cacheable = aiCacheKey(message.BotID, message.Content, aiContext)
+	}
+	var smartReply *domain.SmartReply
+	if !cacheable || !s.cachedResponse(ctx, responseCacheAI, cacheKey, &smartReply) {
+		smartReply, err = s.smartReplySvc.GenerateAIResponse(ctx, message.BotID, message.Content, aiContext)
+		if err != nil {
+			s.logger.WithContext(ctx).Error("Failed to generate AI response", "error", err)
+			return &domain.BotResponse{
+				Content: "I'm having trouble understanding. Could you please rephrase?",
+				Type:    domain.ResponseTypeText,
+			}, step.NextStepID, nil
+		}
+		// La respuesta por presupuesto agotado no se guarda: caducaría más tarde que el presupuesto
+		if cacheable && smartReply.Intent != BudgetExceededIntent {
+			s.storeResponse(ctx, responseCacheAI, cacheKey, smartReply, cacheTTL)
+		}
+	}
+
+	// Una respuesta poco fiable no se envía: se pide aclaración o se pasa al fallback
+	if response, next, handled, err := s.lowConfidenceResponse(ctx, step, message, session, smartReply); handled {
+		return response, next, err
 	}
 
 	response := &domain.BotResponse{
 		Content: smartReply.Response,
 		Type:    domain.ResponseTypeText,
 		Metadata: map[string]interface{}{
-			"confidence": smartReply.Confidence,
-			"intent":     smartReply.Intent,
+			"confidence":        smartReply.Confidence,
+			"intent":            smartReply.Intent,
+			"intent_confidence": topConfidence,
+			"intents":           intents[:min(len(intents), 3)],
 		},
 	}
 
 	return response, step.NextStepID, nil
 }
 
-func (s *botService) evaluateCondition(condition, userInput string, context map[string]interface{}) bool {
-	// Implementación simple de evaluación de condiciones
-	// Se puede expandir para soportar expresiones más complejas
+// nluFallbackFlow devuelve el flujo de fallback del bot cuando la confianza del
+// mejor intent no llega al umbral, o nil si no aplica
+func (s *botService) nluFallbackFlow(ctx context.Context, botID string, session *domain.ConversationSession, confidence float64) *domain.BotFlow {
+	bot, err := s.botRepo.GetByID(ctx, botID)
+	if err != nil {
+		return nil
+	}
+	nlu := parseBotConfig(bot).NLU
+	if nlu == nil || nlu.FallbackFlowID == "" || session.CurrentFlowID == nlu.FallbackFlowID {
+		return nil
+	}
+	if confidence >= intentThreshold(nlu) {
+		return nil
+	}
+
+	flow, err := s.flowRepo.GetByID(ctx, nlu.FallbackFlowID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("NLU fallback flow not found", "bot_id", botID, "flow_id", nlu.FallbackFlowID, "error", err)
+		return nil
+	}
+	return flow
+}
+
+// enterFallbackFlow lleva la conversación al punto de entrada del flujo de
+// fallback y añade metadata a su respuesta
+func (s *botService) enterFallbackFlow(ctx context.Context, flow *domain.BotFlow, message *domain.IncomingMessage, session *domain.ConversationSession, metadata map[string]interface{}) (*domain.BotResponse, *string, error) {
+	target, err := s.findStepInFlow(ctx, flow.ID, flow.PublishedVersion, s.flowEntryPoint(ctx, flow, flow.PublishedVersion))
+	if err != nil {
+		return nil, nil, err
+	}
+	enterFlow(session, flow)
+	response, next, err := s.continueWithStep(ctx, target, message, session)
+	if response != nil {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		for key, value := range metadata {
+			response.Metadata[key] = value
+		}
+	}
+	return response, next, err
+}
+
+// aiStepContent es la configuración opcional de un paso de IA. Con mode
+// "image" el paso genera una imagen en lugar de texto.
+type aiStepContent struct {
+	Mode           string                 `json:"mode"`
+	Prompt         string                 `json:"prompt"`
+	NegativePrompt string                 `json:"negative_prompt"`
+	Size           string                 `json:"size"`
+	Caption        string                 `json:"caption"`
+	Variable       string                 `json:"variable"`
+	ErrorMessage   string                 `json:"error_message"`
+	Config         map[string]interface{} `json:"config"`
+	// CacheTTL reutiliza la respuesta de texto para la misma pregunta y contexto
+	CacheTTL string `json:"cache_ttl"`
+}
+
+// processImageGenerationStep genera la imagen con un agente MCP de tipo
+// image y responde con la URL pública de la imagen almacenada
+func (s *botService) processImageGenerationStep(ctx context.Context, step *domain.BotStep, content aiStepContent, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	variables := s.buildConditionInput(ctx, message, session)
+	prompt := replaceTemplateVariables(content.Prompt, variables)
+	if strings.TrimSpace(prompt) == "" {
+		return nil, nil, fmt.Errorf("image step %s requires a prompt", step.ID)
+	}
+
+	errorMessage := content.ErrorMessage
+	if errorMessage == "" {
+		errorMessage = "I couldn't generate the image right now. Please try again later."
+	}
+	failed := &domain.BotResponse{Content: errorMessage, Type: domain.ResponseTypeText}
+
+	agent, err := s.instantiateAgent(ctx, mcp.MCPConfig{
+		Type:         "image",
+		Name:         fmt.Sprintf("image-agent-%s", step.ID),
+		Version:      "1.0",
+		Config:       renderConfigMap(content.Config, variables),
+		Capabilities: []string{"image_generation"},
+	})
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to instantiate image agent", "step_id", step.ID, "error", err)
+		return failed, step.NextStepID, nil
+	}
+	defer func() {
+		if err := s.mcpOrchestrator.TerminateAgent(ctx, agent.GetID()); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
+		}
+	}()
+
+	result, err := agent.Execute(ctx, mcp.Task{
+		ID:          fmt.Sprintf("task-%s-%s", step.ID, id.New()),
+		Type:        "image_generation",
+		Description: fmt.Sprintf("Image generation for step %s", step.ID),
+		Input: map[string]interface{}{
+			"prompt":          prompt,
+			"negative_prompt": replaceTemplateVariables(content.NegativePrompt, variables),
+			"size":            content.Size,
+			"key_prefix":      fmt.Sprintf("bots/%s/images", message.BotID),
+		},
+		Priority: 5,
+		Metadata: map[string]interface{}{
+			"step_id":    step.ID,
+			"message_id": message.ID,
+		},
+	})
+	if err != nil || !result.Success {
+		s.logger.WithContext(ctx).Error("Image generation failed", "step_id", step.ID, "error", err)
+		return failed, step.NextStepID, nil
+	}
+
+	imageURL, _ := result.Output["image_url"].(string)
+	if content.Variable != "" {
+		session.Context[content.Variable] = imageURL
+	}
+
+	return &domain.BotResponse{
+		Content: imageURL,
+		Type:    domain.ResponseTypeImage,
+		Metadata: map[string]interface{}{
+			"image_url": imageURL,
+			"caption":   replaceTemplateVariables(content.Caption, variables),
+			"key":       result.Output["key"],
+			"provider":  result.Metadata["provider"],
+		},
+	}, step.NextStepID, nil
+}
+
+func (s *botService) evaluateCondition(condition, userInput string, input map[string]interface{}) bool {
 	switch condition {
 	case "contains_yes":
 		return contains(userInput, []string{"yes", "sí", "si", "ok", "okay"})
 	case "contains_no":
 		return contains(userInput, []string{"no", "nope", "not"})
-	default:
+	}
+
+	// Las condiciones que no son expresiones se comparan literalmente con el input
+	if !isExpression(condition) {
 		return userInput == condition
 	}
+
+	matched, err := s.conditions.Evaluate(condition, input)
+	if err != nil {
+		s.logger.Warn("Failed to evaluate decision condition", "condition", condition, "error", err)
+		return false
+	}
+	return matched
+}
+
+// buildConditionInput arma las variables disponibles para las condiciones de un
+// paso de decisión y las plantillas, incluidas las de metadata channel.*,
+// user.* y message.* y las constantes del bot bajo vars
+func (s *botService) buildConditionInput(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession) map[string]interface{} {
+	input := make(map[string]interface{}, len(session.Context)+len(message.Metadata)+10)
+	for key, value := range session.Context {
+		input[key] = value
+	}
+
+	input["input"] = message.Content
+	input["message"] = message.Content
+	input["channel"] = string(message.Channel)
+	input["user_id"] = message.UserID
+	input["metadata"] = message.Metadata
+	for key, value := range metadataVariables(message) {
+		input[key] = value
+	}
+	botID := session.BotID
+	if botID == "" {
+		botID = message.BotID
+	}
+	input[botVariablesKey] = s.botVariables(ctx, botID)
+
+	return input
+}
+
+func isExpression(condition string) bool {
+	return strings.ContainsAny(condition, "(){}<>=!&|")
+}
+
+// pickWeighted elige un índice con probabilidad proporcional a su peso.
+// Si ningún peso es positivo, todas las opciones son equiprobables. random
+// devuelve valores en [0, 1), como rand.Float64.
+func pickWeighted(weights []float64, random func() float64) int {
+	total := 0.0
+	for _, weight := range weights {
+		if weight > 0 {
+			total += weight
+		}
+	}
+
+	if total == 0 {
+		index := int(random() * float64(len(weights)))
+		if index >= len(weights) {
+			index = len(weights) - 1
+		}
+		return index
+	}
+
+	target := random() * total
+	last := 0
+	for i, weight := range weights {
+		if weight <= 0 {
+			continue
+		}
+		last = i
+		target -= weight
+		if target < 0 {
+			return i
+		}
+	}
+
+	return last
 }
 
 func contains(text string, keywords []string) bool {
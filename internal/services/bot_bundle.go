@@ -0,0 +1,449 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// BotBundleService exporta e importa bots completos como un documento JSON,
+// para promoverlos entre entornos (por ejemplo de staging a producción)
+type BotBundleService interface {
+	ExportBot(ctx context.Context, botID string) (*domain.BotBundle, error)
+	ImportBot(ctx context.Context, bundle *domain.BotBundle, ownerID string) (*domain.BotImportResult, error)
+	// DeleteBot elimina el bot junto con todos los recursos que exporta
+	DeleteBot(ctx context.Context, botID string) error
+}
+
+type botBundleService struct {
+	botRepo         domain.BotRepository
+	flowRepo        domain.BotFlowRepository
+	stepRepo        domain.BotStepRepository
+	smartReplyRepo  domain.SmartReplyRepository
+	conditionalRepo domain.ConditionalRepository
+	triggerRepo     domain.TriggerRepository
+	logger          logger.Logger
+}
+
+// NewBotBundleService crea el servicio de exportación/importación de bots
+func NewBotBundleService(
+	botRepo domain.BotRepository,
+	flowRepo domain.BotFlowRepository,
+	stepRepo domain.BotStepRepository,
+	smartReplyRepo domain.SmartReplyRepository,
+	conditionalRepo domain.ConditionalRepository,
+	triggerRepo domain.TriggerRepository,
+	logger logger.Logger,
+) BotBundleService {
+	return &botBundleService{
+		botRepo:         botRepo,
+		flowRepo:        flowRepo,
+		stepRepo:        stepRepo,
+		smartReplyRepo:  smartReplyRepo,
+		conditionalRepo: conditionalRepo,
+		triggerRepo:     triggerRepo,
+		logger:          logger,
+	}
+}
+
+func (s *botBundleService) ExportBot(ctx context.Context, botID string) (*domain.BotBundle, error) {
+	bot, err := s.botRepo.GetByID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("bot not found: %w", err)
+	}
+
+	bundle := &domain.BotBundle{
+		Version:      domain.BotBundleVersion,
+		ExportedAt:   time.Now(),
+		Bot:          *bot,
+		Flows:        []domain.BotFlow{},
+		Steps:        []domain.BotStep{},
+		SmartReplies: []domain.SmartReply{},
+		Conditionals: []domain.Conditional{},
+		Triggers:     []domain.Trigger{},
+	}
+
+	flows, err := s.flowRepo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flows: %w", err)
+	}
+	for _, flow := range flows {
+		bundle.Flows = append(bundle.Flows, *flow)
+
+		steps, err := s.stepRepo.GetByFlowID(ctx, flow.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get steps for flow %s: %w", flow.ID, err)
+		}
+		for _, step := range steps {
+			bundle.Steps = append(bundle.Steps, *step)
+		}
+	}
+
+	replies, err := s.smartReplyRepo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get smart replies: %w", err)
+	}
+	for _, reply := range replies {
+		bundle.SmartReplies = append(bundle.SmartReplies, *reply)
+	}
+
+	conditionals, err := s.conditionalRepo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get conditionals: %w", err)
+	}
+	for _, conditional := range conditionals {
+		bundle.Conditionals = append(bundle.Conditionals, *conditional)
+	}
+
+	triggers, err := s.triggerRepo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get triggers: %w", err)
+	}
+	for _, trigger := range triggers {
+		trigger.LastFiredAt = nil
+		bundle.Triggers = append(bundle.Triggers, *trigger)
+	}
+
+	return bundle, nil
+}
+
+// ImportBot crea el bot y sus recursos con IDs nuevos. Las referencias entre
+// recursos (entry points, next_step, flow_id de intents y saltos, condiciones
+// de triggers) se reescriben con los IDs nuevos. Si algo falla se eliminan
+// los recursos ya creados.
+func (s *botBundleService) ImportBot(ctx context.Context, bundle *domain.BotBundle, ownerID string) (*domain.BotImportResult, error) {
+	if err := validateBotBundle(bundle); err != nil {
+		return nil, err
+	}
+
+	ids := newBundleIDMap(bundle)
+	now := time.Now()
+	importer := &bundleImporter{service: s}
+
+	bot := bundle.Bot
+	bot.ID = ids[bundle.Bot.ID]
+	if ownerID != "" {
+		bot.OwnerID = ownerID
+	}
+	bot.Config = remapRawIDs(bot.Config, ids)
+	bot.CreatedAt, bot.UpdatedAt = now, now
+	if err := s.botRepo.Create(ctx, &bot); err != nil {
+		return nil, fmt.Errorf("failed to create bot: %w", err)
+	}
+	importer.botID = bot.ID
+
+	result := &domain.BotImportResult{Bot: &bot, IDMap: ids}
+
+	for _, source := range bundle.Conditionals {
+		conditional := source
+		conditional.ID = ids[source.ID]
+		conditional.BotID = bot.ID
+		conditional.Metadata = remapMapIDs(conditional.Metadata, ids)
+		conditional.CreatedAt, conditional.UpdatedAt = now, now
+		if err := s.conditionalRepo.Create(ctx, &conditional); err != nil {
+			return nil, importer.fail(ctx, fmt.Errorf("failed to create conditional %s: %w", source.ID, err))
+		}
+		importer.conditionals = append(importer.conditionals, conditional.ID)
+		result.Conditionals++
+	}
+
+	for _, source := range bundle.Flows {
+		flow := source
+		flow.ID = ids[source.ID]
+		flow.BotID = bot.ID
+		flow.EntryPoint = remapID(source.EntryPoint, ids)
+		flow.PublishedVersion = 0 // las versiones no se exportan
+		flow.CreatedAt, flow.UpdatedAt = now, now
+		if err := s.flowRepo.Create(ctx, &flow); err != nil {
+			return nil, importer.fail(ctx, fmt.Errorf("failed to create flow %s: %w", source.ID, err))
+		}
+		importer.flows = append(importer.flows, flow.ID)
+		result.Flows++
+	}
+
+	for _, source := range bundle.Steps {
+		step := source
+		step.ID = ids[source.ID]
+		step.FlowID = ids[source.FlowID]
+		if source.NextStepID != nil {
+			next := remapID(*source.NextStepID, ids)
+			step.NextStepID = &next
+		}
+		step.Content = remapRawIDs(source.Content, ids)
+		step.Conditions = remapRawIDs(source.Conditions, ids)
+		step.CreatedAt, step.UpdatedAt = now, now
+		if err := s.stepRepo.Create(ctx, &step); err != nil {
+			return nil, importer.fail(ctx, fmt.Errorf("failed to create step %s: %w", source.ID, err))
+		}
+		importer.steps = append(importer.steps, step.ID)
+		result.Steps++
+	}
+
+	for _, source := range bundle.SmartReplies {
+		reply := source
+		reply.ID = ids[source.ID]
+		reply.BotID = bot.ID
+		reply.CreatedAt, reply.UpdatedAt = now, now
+		if err := s.smartReplyRepo.Create(ctx, &reply); err != nil {
+			return nil, importer.fail(ctx, fmt.Errorf("failed to create smart reply %s: %w", source.ID, err))
+		}
+		importer.replies = append(importer.replies, reply.ID)
+		result.SmartReplies++
+	}
+
+	for _, source := range bundle.Triggers {
+		trigger := source
+		trigger.ID = ids[source.ID]
+		trigger.BotID = bot.ID
+		trigger.Condition = remapID(source.Condition, ids)
+		trigger.Action.Config = remapMapIDs(source.Action.Config, ids)
+		trigger.Metadata = remapMapIDs(source.Metadata, ids)
+		trigger.LastFiredAt = nil
+		trigger.CreatedAt, trigger.UpdatedAt = now, now
+		if err := validateTriggerSchedule(&trigger); err != nil {
+			return nil, importer.fail(ctx, fmt.Errorf("invalid trigger %s: %w", source.ID, err))
+		}
+		if err := s.triggerRepo.Create(ctx, &trigger); err != nil {
+			return nil, importer.fail(ctx, fmt.Errorf("failed to create trigger %s: %w", source.ID, err))
+		}
+		importer.triggers = append(importer.triggers, trigger.ID)
+		result.Triggers++
+	}
+
+	s.logger.WithContext(ctx).Info("Bot imported",
+		"source_bot_id", bundle.Bot.ID,
+		"bot_id", bot.ID,
+		"flows", result.Flows,
+		"steps", result.Steps)
+
+	return result, nil
+}
+
+// validateBotBundle comprueba la versión y que las referencias internas del
+// bundle sean consistentes antes de crear nada
+func validateBotBundle(bundle *domain.BotBundle) error {
+	if bundle == nil {
+		return fmt.Errorf("bundle is required")
+	}
+	if bundle.Version < 1 || bundle.Version > domain.BotBundleVersion {
+		return fmt.Errorf("unsupported bundle version %d (supported: 1-%d)", bundle.Version, domain.BotBundleVersion)
+	}
+	if bundle.Bot.ID == "" || bundle.Bot.Name == "" {
+		return fmt.Errorf("bundle bot requires id and name")
+	}
+
+	seen := map[string]bool{bundle.Bot.ID: true}
+	unique := func(kind, id string) error {
+		if id == "" {
+			return fmt.Errorf("%s without id", kind)
+		}
+		if seen[id] {
+			return fmt.Errorf("duplicated id %s", id)
+		}
+		seen[id] = true
+		return nil
+	}
+
+	flows := make(map[string]bool, len(bundle.Flows))
+	for _, flow := range bundle.Flows {
+		if err := unique("flow", flow.ID); err != nil {
+			return err
+		}
+		flows[flow.ID] = true
+	}
+
+	steps := make(map[string]bool, len(bundle.Steps))
+	for _, step := range bundle.Steps {
+		if err := unique("step", step.ID); err != nil {
+			return err
+		}
+		if !flows[step.FlowID] {
+			return fmt.Errorf("step %s references unknown flow %s", step.ID, step.FlowID)
+		}
+		steps[step.ID] = true
+	}
+
+	for _, flow := range bundle.Flows {
+		if flow.EntryPoint != "" && !steps[flow.EntryPoint] {
+			return fmt.Errorf("flow %s entry point %s is not in the bundle", flow.ID, flow.EntryPoint)
+		}
+	}
+	for _, step := range bundle.Steps {
+		if step.NextStepID != nil && *step.NextStepID != "" && !steps[*step.NextStepID] {
+			return fmt.Errorf("step %s next step %s is not in the bundle", step.ID, *step.NextStepID)
+		}
+	}
+
+	conditionals := make(map[string]bool, len(bundle.Conditionals))
+	for _, conditional := range bundle.Conditionals {
+		if err := unique("conditional", conditional.ID); err != nil {
+			return err
+		}
+		conditionals[conditional.ID] = true
+	}
+	for _, reply := range bundle.SmartReplies {
+		if err := unique("smart reply", reply.ID); err != nil {
+			return err
+		}
+	}
+	for _, trigger := range bundle.Triggers {
+		if err := unique("trigger", trigger.ID); err != nil {
+			return err
+		}
+		if trigger.Condition != "" && !conditionals[trigger.Condition] {
+			return fmt.Errorf("trigger %s references unknown conditional %s", trigger.ID, trigger.Condition)
+		}
+	}
+	return nil
+}
+
+// newBundleIDMap asigna un ID nuevo a cada recurso del bundle
+func newBundleIDMap(bundle *domain.BotBundle) map[string]string {
+	ids := map[string]string{bundle.Bot.ID: id.New()}
+	for _, flow := range bundle.Flows {
+		ids[flow.ID] = id.New()
+	}
+	for _, step := range bundle.Steps {
+		ids[step.ID] = id.New()
+	}
+	for _, reply := range bundle.SmartReplies {
+		ids[reply.ID] = id.New()
+	}
+	for _, conditional := range bundle.Conditionals {
+		ids[conditional.ID] = id.New()
+	}
+	for _, trigger := range bundle.Triggers {
+		ids[trigger.ID] = id.New()
+	}
+	return ids
+}
+
+func remapID(id string, ids map[string]string) string {
+	if mapped, ok := ids[id]; ok {
+		return mapped
+	}
+	return id
+}
+
+// remapRawIDs reescribe en un documento JSON (contenido de pasos, config del
+// bot) los valores que coinciden con un ID del bundle. Así se cubren las
+// referencias de cualquier tipo de paso sin conocer su estructura.
+func remapRawIDs(raw json.RawMessage, ids map[string]string) json.RawMessage {
+	if len(raw) == 0 {
+		return raw
+	}
+	var value interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return raw
+	}
+	remapped, err := json.Marshal(remapValueIDs(value, ids))
+	if err != nil {
+		return raw
+	}
+	return remapped
+}
+
+func remapMapIDs(values map[string]interface{}, ids map[string]string) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	remapped, _ := remapValueIDs(values, ids).(map[string]interface{})
+	return remapped
+}
+
+func remapValueIDs(value interface{}, ids map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		return remapID(v, ids)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = remapValueIDs(item, ids)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = remapValueIDs(item, ids)
+		}
+		return out
+	}
+	return value
+}
+
+// bundleImporter registra lo creado durante una importación para poder deshacerla
+func (s *botBundleService) DeleteBot(ctx context.Context, botID string) error {
+	bundle, err := s.ExportBot(ctx, botID)
+	if err != nil {
+		return err
+	}
+
+	// Se borra en el mismo orden que el rollback de una importación
+	deleter := &bundleImporter{service: s, botID: botID}
+	for _, conditional := range bundle.Conditionals {
+		deleter.conditionals = append(deleter.conditionals, conditional.ID)
+	}
+	for _, flow := range bundle.Flows {
+		deleter.flows = append(deleter.flows, flow.ID)
+	}
+	for _, step := range bundle.Steps {
+		deleter.steps = append(deleter.steps, step.ID)
+	}
+	for _, reply := range bundle.SmartReplies {
+		deleter.replies = append(deleter.replies, reply.ID)
+	}
+	for _, trigger := range bundle.Triggers {
+		deleter.triggers = append(deleter.triggers, trigger.ID)
+	}
+	return deleter.fail(ctx, nil)
+}
+
+type bundleImporter struct {
+	service      *botBundleService
+	botID        string
+	conditionals []string
+	flows        []string
+	steps        []string
+	replies      []string
+	triggers     []string
+}
+
+func (i *bundleImporter) fail(ctx context.Context, err error) error {
+	s := i.service
+	for _, id := range i.triggers {
+		if delErr := s.triggerRepo.Delete(ctx, id); delErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to roll back trigger", "trigger_id", id, "error", delErr)
+		}
+	}
+	for _, id := range i.replies {
+		if delErr := s.smartReplyRepo.Delete(ctx, id); delErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to roll back smart reply", "reply_id", id, "error", delErr)
+		}
+	}
+	for _, id := range i.steps {
+		if delErr := s.stepRepo.Delete(ctx, id); delErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to roll back step", "step_id", id, "error", delErr)
+		}
+	}
+	for _, id := range i.flows {
+		if delErr := s.flowRepo.Delete(ctx, id); delErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to roll back flow", "flow_id", id, "error", delErr)
+		}
+	}
+	for _, id := range i.conditionals {
+		if delErr := s.conditionalRepo.Delete(ctx, id); delErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to roll back conditional", "conditional_id", id, "error", delErr)
+		}
+	}
+	if i.botID != "" {
+		if delErr := s.botRepo.Delete(ctx, i.botID); delErr != nil {
+			s.logger.WithContext(ctx).Error("Failed to roll back bot", "bot_id", i.botID, "error", delErr)
+		}
+	}
+	return err
+}
@@ -0,0 +1,86 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotBundleService_ExportImportRemapsIDs(t *testing.T) {
+	ctx := context.Background()
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	conditionalRepo := repositories.NewMockConditionalRepository()
+	triggerRepo := repositories.NewMockTriggerRepository()
+	svc := NewBotBundleService(botRepo, flowRepo, stepRepo, repositories.NewMockSmartReplyRepository(), conditionalRepo, triggerRepo, logger.NewLogger("error"))
+
+	config := `{"global_intents":[{"name":"help","keywords":["help"],"flow_id":"flow-help"}]}`
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Name: "Support", OwnerID: "staging", Config: json.RawMessage(config)}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-main", BotID: "bot-1", EntryPoint: "step-1", IsDefault: true}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-help", BotID: "bot-1", EntryPoint: "step-3"}))
+	next := "step-2"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "step-1", FlowID: "flow-main", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"hi"}`), NextStepID: &next}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "step-2", FlowID: "flow-main", Type: domain.StepTypeSwitch, Content: json.RawMessage(`{"flow_id":"flow-help"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "step-3", FlowID: "flow-help", Type: domain.StepTypeEnd}))
+	require.NoError(t, conditionalRepo.Create(ctx, &domain.Conditional{ID: "cond-1", BotID: "bot-1", Expression: "true"}))
+	require.NoError(t, triggerRepo.Create(ctx, &domain.Trigger{ID: "trig-1", BotID: "bot-1", Event: domain.TriggerEventMessageReceived, Condition: "cond-1"}))
+
+	bundle, err := svc.ExportBot(ctx, "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, domain.BotBundleVersion, bundle.Version)
+	assert.Len(t, bundle.Flows, 2)
+	assert.Len(t, bundle.Steps, 3)
+
+	// El bundle debe sobrevivir a la serialización
+	data, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	var imported domain.BotBundle
+	require.NoError(t, json.Unmarshal(data, &imported))
+
+	result, err := svc.ImportBot(ctx, &imported, "production")
+	require.NoError(t, err)
+	ids := result.IDMap
+	assert.NotEqual(t, "bot-1", result.Bot.ID)
+	assert.Equal(t, "production", result.Bot.OwnerID)
+	assert.Contains(t, string(result.Bot.Config), ids["flow-help"])
+
+	flow, err := flowRepo.GetByID(ctx, ids["flow-main"])
+	require.NoError(t, err)
+	assert.Equal(t, result.Bot.ID, flow.BotID)
+	assert.Equal(t, ids["step-1"], flow.EntryPoint)
+
+	first, err := stepRepo.GetByID(ctx, ids["step-1"])
+	require.NoError(t, err)
+	require.NotNil(t, first.NextStepID)
+	assert.Equal(t, ids["step-2"], *first.NextStepID)
+
+	switchStep, err := stepRepo.GetByID(ctx, ids["step-2"])
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"flow_id":"`+ids["flow-help"]+`"}`, string(switchStep.Content))
+
+	trigger, err := triggerRepo.GetByID(ctx, ids["trig-1"])
+	require.NoError(t, err)
+	assert.Equal(t, ids["cond-1"], trigger.Condition)
+}
+
+func TestBotBundleService_ImportRejectsBrokenReferences(t *testing.T) {
+	svc := NewBotBundleService(repositories.NewMockBotRepository(), repositories.NewMockBotFlowRepository(), repositories.NewMockBotStepRepository(),
+		repositories.NewMockSmartReplyRepository(), repositories.NewMockConditionalRepository(), repositories.NewMockTriggerRepository(), logger.NewLogger("error"))
+
+	_, err := svc.ImportBot(context.Background(), &domain.BotBundle{
+		Version: domain.BotBundleVersion,
+		Bot:     domain.Bot{ID: "bot-1", Name: "Support"},
+		Flows:   []domain.BotFlow{{ID: "flow-1", EntryPoint: "missing"}},
+	}, "")
+	assert.ErrorContains(t, err, "entry point")
+
+	_, err = svc.ImportBot(context.Background(), &domain.BotBundle{Version: 99, Bot: domain.Bot{ID: "bot-1", Name: "Support"}}, "")
+	assert.ErrorContains(t, err, "unsupported bundle version")
+}
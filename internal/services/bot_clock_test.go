@@ -0,0 +1,56 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotService_UseClockDrivesTimeConditions(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	clk := clock.NewFake(time.Date(2024, 6, 12, 20, 0, 0, 0, time.UTC)) // miércoles
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, clk, log), nil, NewEntityExtractionService(nil, nil, log), nil, nil, nil, nil, nil, log)
+	bots.UseClock(clk)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "hours", IsDefault: true}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "hours", FlowID: "flow-1", Type: domain.StepTypeDecision,
+		Conditions: json.RawMessage(`{"rules":[
+			{"condition":"time_between('09:00', '18:00') && within_days({{entities.date}}, 1)","next_step":"open"}
+		],"default":"closed"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "open", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"open"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "closed", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"closed"}`)}))
+
+	step := func(userID string) string {
+		_, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: userID, Content: "can I come today?"})
+		require.NoError(t, err)
+		session, err := sessionRepo.GetByUserAndBot(ctx, userID, "bot-1")
+		require.NoError(t, err)
+		return session.CurrentStepID
+	}
+
+	// A las 20:00 del reloj falso el horario está cerrado
+	assert.Equal(t, "closed", step("user-1"))
+
+	// Al día siguiente a las 10:00 abre, y "today" es la fecha del reloj falso
+	clk.Advance(14 * time.Hour)
+	assert.Equal(t, "open", step("user-2"))
+	session, err := sessionRepo.GetByUserAndBot(ctx, "user-2", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-06-13", session.Context[EntitiesContextKey].(map[string]interface{})[domain.EntityTypeDate])
+}
@@ -9,20 +9,23 @@ import (
 )
 
 type botFlowService struct {
-	flowRepo domain.BotFlowRepository
-	stepRepo domain.BotStepRepository
-	logger   logger.Logger
+	flowRepo    domain.BotFlowRepository
+	stepRepo    domain.BotStepRepository
+	versionRepo domain.FlowVersionRepository
+	logger      logger.Logger
 }
 
 func NewBotFlowService(
 	flowRepo domain.BotFlowRepository,
 	stepRepo domain.BotStepRepository,
+	versionRepo domain.FlowVersionRepository,
 	logger logger.Logger,
 ) BotFlowService {
 	return &botFlowService{
-		flowRepo: flowRepo,
-		stepRepo: stepRepo,
-		logger:   logger,
+		flowRepo:    flowRepo,
+		stepRepo:    stepRepo,
+		versionRepo: versionRepo,
+		logger:      logger,
 	}
 }
 
@@ -41,6 +44,10 @@ func (s *botFlowService) CreateFlow(ctx context.Context, flow *domain.BotFlow) e
 }
 
 func (s *botFlowService) UpdateFlow(ctx context.Context, flow *domain.BotFlow) error {
+	// La versión publicada sólo cambia al publicar o hacer rollback
+	if existing, err := s.flowRepo.GetByID(ctx, flow.ID); err == nil {
+		flow.PublishedVersion = existing.PublishedVersion
+	}
 	flow.UpdatedAt = time.Now()
 	return s.flowRepo.Update(ctx, flow)
 }
@@ -49,14 +56,18 @@ func (s *botFlowService) DeleteFlow(ctx context.Context, id string) error {
 	// Eliminar todos los pasos del flujo primero
 	steps, err := s.stepRepo.GetByFlowID(ctx, id)
 	if err != nil {
-		s.logger.Error("Failed to get steps for flow deletion", "flow_id", id, "error", err)
+		s.logger.WithContext(ctx).Error("Failed to get steps for flow deletion", "flow_id", id, "error", err)
 	} else {
 		for _, step := range steps {
 			if err := s.stepRepo.Delete(ctx, step.ID); err != nil {
-				s.logger.Error("Failed to delete step", "step_id", step.ID, "error", err)
+				s.logger.WithContext(ctx).Error("Failed to delete step", "step_id", step.ID, "error", err)
 			}
 		}
 	}
 
+	if err := s.versionRepo.DeleteByFlowID(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to delete flow versions", "flow_id", id, "error", err)
+	}
+
 	return s.flowRepo.Delete(ctx, id)
-}
\ No newline at end of file
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// botVariablesKey es la clave bajo la que las plantillas ven las constantes del bot
+	botVariablesKey = "vars"
+	// botConfigVariablesKey es la clave de las constantes en Bot.Config
+	botConfigVariablesKey = "variables"
+)
+
+var (
+	ErrBotNotFound        = errors.New("bot not found")
+	ErrInvalidBotVariable = errors.New("invalid bot variable")
+)
+
+// Las claves no llevan puntos para que {{vars.a.b}} recorra el valor de "a"
+var botVariableKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// botVariables devuelve las constantes del bot; un bot inexistente no tiene ninguna
+func (s *botService) botVariables(ctx context.Context, botID string) map[string]interface{} {
+	if botID == "" {
+		return map[string]interface{}{}
+	}
+	bot, err := s.botRepo.GetByID(ctx, botID)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	variables := parseBotConfig(bot).Variables
+	if variables == nil {
+		return map[string]interface{}{}
+	}
+	return variables
+}
+
+func (s *botService) GetBotVariables(ctx context.Context, botID string) (map[string]interface{}, error) {
+	if _, err := s.botRepo.GetByID(ctx, botID); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBotNotFound, botID)
+	}
+	return s.botVariables(ctx, botID), nil
+}
+
+// SetBotVariables crea o reemplaza las constantes dadas; las demás se conservan
+func (s *botService) SetBotVariables(ctx context.Context, botID string, variables map[string]interface{}) (map[string]interface{}, error) {
+	for key := range variables {
+		if !botVariableKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%w: key %q must be letters, digits or underscores", ErrInvalidBotVariable, key)
+		}
+	}
+	return s.updateBotVariables(ctx, botID, func(current map[string]interface{}) {
+		for key, value := range variables {
+			current[key] = value
+		}
+	})
+}
+
+func (s *botService) DeleteBotVariable(ctx context.Context, botID, key string) (map[string]interface{}, error) {
+	return s.updateBotVariables(ctx, botID, func(current map[string]interface{}) {
+		delete(current, key)
+	})
+}
+
+// updateBotVariables reescribe "variables" en Bot.Config sin tocar el resto de
+// la configuración, incluidos los campos que BotConfig no conoce
+func (s *botService) updateBotVariables(ctx context.Context, botID string, update func(current map[string]interface{})) (map[string]interface{}, error) {
+	bot, err := s.botRepo.GetByID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBotNotFound, botID)
+	}
+
+	config := make(map[string]interface{})
+	if len(bot.Config) > 0 {
+		if err := json.Unmarshal(bot.Config, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse bot config: %w", err)
+		}
+	}
+
+	variables := make(map[string]interface{})
+	if current, ok := config[botConfigVariablesKey].(map[string]interface{}); ok {
+		for key, value := range current {
+			variables[key] = value
+		}
+	}
+	update(variables)
+	if len(variables) == 0 {
+		delete(config, botConfigVariablesKey)
+	} else {
+		config[botConfigVariablesKey] = variables
+	}
+
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode bot config: %w", err)
+	}
+	bot.Config = raw
+	bot.UpdatedAt = s.clock.Now()
+	if err := s.botRepo.Update(ctx, bot); err != nil {
+		return nil, fmt.Errorf("failed to update bot: %w", err)
+	}
+	return variables, nil
+}
+
+// renderConfig aplica las plantillas a los textos de la configuración de un
+// agente o adaptador. Un texto que es sólo un placeholder ("{{vars.skus}}")
+// toma el valor tal cual, para conservar listas y números.
+func renderConfig(value interface{}, data map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if path, ok := singlePlaceholder(v); ok {
+			if resolved := lookupVariable(path, data); resolved != nil {
+				return resolved
+			}
+		}
+		return renderTemplate(v, data)
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[key] = renderConfig(item, data)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = renderConfig(item, data)
+		}
+		return rendered
+	}
+	return value
+}
+
+func renderConfigMap(config map[string]interface{}, data map[string]interface{}) map[string]interface{} {
+	if config == nil {
+		return nil
+	}
+	return renderConfig(config, data).(map[string]interface{})
+}
+
+func singlePlaceholder(text string) (string, bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "{{") || !strings.HasSuffix(text, "}}") || strings.Count(text, "{{") != 1 {
+		return "", false
+	}
+	path := strings.TrimSpace(text[2 : len(text)-2])
+	return path, variablePathPattern.MatchString(path)
+}
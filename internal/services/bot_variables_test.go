@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBotVariables_TemplatesAndConfigs(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	bots := NewBotService(botRepo, repositories.NewMockBotFlowRepository(), repositories.NewMockBotStepRepository(),
+		repositories.NewMockFlowVersionRepository(), sessionRepo, nil, NewConversationService(sessionRepo, nil, log),
+		nil, nil, nil, nil, nil, nil, nil, log).(*botService)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{
+		ID:     "bot-1",
+		Status: domain.BotStatusActive,
+		Config: json.RawMessage(`{"global_intents":[{"name":"help","keywords":["ayuda"],"flow_id":"f1"}],"custom":true}`),
+	}))
+
+	variables, err := bots.SetBotVariables(ctx, "bot-1", map[string]interface{}{
+		"api_base_url":  "https://api.staging.example.com",
+		"support_email": "soporte@example.com",
+		"skus":          []interface{}{"A-1", "B-2"},
+	})
+	require.NoError(t, err)
+	assert.Len(t, variables, 3)
+
+	_, err = bots.SetBotVariables(ctx, "bot-1", map[string]interface{}{"api.url": "x"})
+	assert.True(t, errors.Is(err, ErrInvalidBotVariable))
+	_, err = bots.GetBotVariables(ctx, "missing")
+	assert.True(t, errors.Is(err, ErrBotNotFound))
+
+	variables, err = bots.DeleteBotVariable(ctx, "bot-1", "skus")
+	require.NoError(t, err)
+	assert.NotContains(t, variables, "skus")
+	_, err = bots.SetBotVariables(ctx, "bot-1", map[string]interface{}{"skus": []interface{}{"C-3"}})
+	require.NoError(t, err)
+
+	// El resto de la configuración del bot se conserva
+	bot, err := botRepo.GetByID(ctx, "bot-1")
+	require.NoError(t, err)
+	assert.Len(t, parseBotConfig(bot).GlobalIntents, 1)
+	assert.Contains(t, string(bot.Config), `"custom":true`)
+
+	message := &domain.IncomingMessage{BotID: "bot-1", UserID: "u-1", Content: "hola", Channel: domain.ChannelWeb}
+	session := &domain.ConversationSession{BotID: "bot-1", Context: map[string]interface{}{}}
+	data := bots.buildConditionInput(ctx, message, session)
+
+	assert.Equal(t, "Escribinos a soporte@example.com", renderTemplate("Escribinos a {{vars.support_email}}", data))
+	config := renderConfigMap(map[string]interface{}{
+		"base_url": "{{vars.api_base_url}}/v1",
+		"skus":     "{{vars.skus}}",
+		"retries":  3,
+	}, data)
+	assert.Equal(t, "https://api.staging.example.com/v1", config["base_url"])
+	assert.Equal(t, []interface{}{"C-3"}, config["skus"])
+	assert.Equal(t, 3, config["retries"])
+}
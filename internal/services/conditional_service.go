@@ -3,12 +3,13 @@ package services
 import (
 	"context"
 	"fmt"
-	"regexp"
-	"strings"
+	"sort"
+	"sync"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -21,6 +22,7 @@ type ConditionalService interface {
 	DeleteConditional(ctx context.Context, id string) error
 	EvaluateConditional(ctx context.Context, id string, input map[string]interface{}) (bool, error)
 	EvaluateExpression(ctx context.Context, expression string, input map[string]interface{}) (bool, error)
+	EvaluateBatch(ctx context.Context, request *domain.BatchEvaluationRequest) ([]domain.PayloadEvaluation, error)
 }
 
 // TriggerService define las operaciones para manejar triggers
@@ -32,12 +34,18 @@ type TriggerService interface {
 	UpdateTrigger(ctx context.Context, trigger *domain.Trigger) error
 	DeleteTrigger(ctx context.Context, id string) error
 	ExecuteTrigger(ctx context.Context, id string, eventData map[string]interface{}) error
+	FireTrigger(ctx context.Context, trigger *domain.Trigger, eventData map[string]interface{}) (bool, error)
 	ProcessEvent(ctx context.Context, botID string, event domain.TriggerEvent, eventData map[string]interface{}) error
+	RegisterAction(executor ActionExecutor) error
+	ListActionTypes() []string
+	// Subscribe dispara los triggers message_received con cada mensaje procesado
+	Subscribe(bus events.EventBus) error
 }
 
 // conditionalService implementa ConditionalService
 type conditionalService struct {
 	conditionalRepo domain.ConditionalRepository
+	engine          *expressionEngine
 	logger          logger.Logger
 }
 
@@ -45,6 +53,10 @@ type conditionalService struct {
 type triggerService struct {
 	triggerRepo domain.TriggerRepository
 	conditionalSvc ConditionalService
+	actions      map[string]ActionExecutor
+	actionsMu    sync.RWMutex
+	eventBus     events.EventBus
+	events       *events.EventFactory
 	logger       logger.Logger
 }
 
@@ -55,6 +67,7 @@ func NewConditionalService(
 ) ConditionalService {
 	return &conditionalService{
 		conditionalRepo: conditionalRepo,
+		engine:          newExpressionEngine(nil),
 		logger:          logger,
 	}
 }
@@ -63,13 +76,23 @@ func NewConditionalService(
 func NewTriggerService(
 	triggerRepo domain.TriggerRepository,
 	conditionalSvc ConditionalService,
+	actionDeps TriggerActionDeps,
 	logger logger.Logger,
 ) TriggerService {
-	return &triggerService{
+	service := &triggerService{
 		triggerRepo:     triggerRepo,
 		conditionalSvc:  conditionalSvc,
+		actions:         make(map[string]ActionExecutor),
+		eventBus:        actionDeps.EventBus,
+		events:          events.NewEventFactory("trigger-service"),
 		logger:          logger,
 	}
+
+	for _, action := range builtinActions(actionDeps) {
+		service.actions[action.Type()] = action
+	}
+
+	return service
 }
 
 // Implementación de ConditionalService
@@ -83,7 +106,7 @@ func (s *conditionalService) GetConditionalsByBot(ctx context.Context, botID str
 
 func (s *conditionalService) CreateConditional(ctx context.Context, conditional *domain.Conditional) error {
 	if conditional.ID == "" {
-		conditional.ID = uuid.New().String()
+		conditional.ID = id.New()
 	}
 	conditional.CreatedAt = time.Now()
 	conditional.UpdatedAt = time.Now()
@@ -109,71 +132,56 @@ func (s *conditionalService) EvaluateExpression(ctx context.Context, expression
 	return s.evaluateExpression(expression, input)
 }
 
-// evaluateExpression evalúa una expresión condicional
-func (s *conditionalService) evaluateExpression(expression string, input map[string]interface{}) (bool, error) {
-	// Implementación básica de evaluación de expresiones
-	// Se puede expandir para soportar expresiones más complejas
-	
-	// Reemplazar variables con valores
-	evaluatedExpr := s.replaceVariables(expression, input)
-	
-	// Evaluar expresiones simples
-	if strings.Contains(evaluatedExpr, "==") {
-		parts := strings.Split(evaluatedExpr, "==")
-		if len(parts) == 2 {
-			return strings.TrimSpace(parts[0]) == strings.TrimSpace(parts[1]), nil
-		}
+// EvaluateBatch evalúa una expresión o un conjunto de condicionales contra cada contexto, con traza
+func (s *conditionalService) EvaluateBatch(ctx context.Context, request *domain.BatchEvaluationRequest) ([]domain.PayloadEvaluation, error) {
+	var targets []domain.ExpressionEvaluation
+	if request.Expression != "" {
+		targets = append(targets, domain.ExpressionEvaluation{Expression: request.Expression})
 	}
-	
-	if strings.Contains(evaluatedExpr, "!=") {
-		parts := strings.Split(evaluatedExpr, "!=")
-		if len(parts) == 2 {
-			return strings.TrimSpace(parts[0]) != strings.TrimSpace(parts[1]), nil
+
+	for _, id := range request.ConditionalIDs {
+		conditional, err := s.conditionalRepo.GetByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("conditional %s not found: %w", id, err)
 		}
+		targets = append(targets, domain.ExpressionEvaluation{
+			ConditionalID: conditional.ID,
+			Name:          conditional.Name,
+			Expression:    conditional.Expression,
+		})
 	}
-	
-	if strings.Contains(evaluatedExpr, "contains") {
-		// Formato: "text contains keyword"
-		parts := strings.Split(evaluatedExpr, "contains")
-		if len(parts) == 2 {
-			text := strings.TrimSpace(parts[0])
-			keyword := strings.TrimSpace(parts[1])
-			return strings.Contains(strings.ToLower(text), strings.ToLower(keyword)), nil
-		}
+
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("expression or conditional_ids is required")
 	}
-	
-	if strings.Contains(evaluatedExpr, "regex") {
-		// Formato: "text regex pattern"
-		parts := strings.Split(evaluatedExpr, "regex")
-		if len(parts) == 2 {
-			text := strings.TrimSpace(parts[0])
-			pattern := strings.TrimSpace(parts[1])
-			matched, err := regexp.MatchString(pattern, text)
-			return matched, err
+
+	results := make([]domain.PayloadEvaluation, 0, len(request.Contexts))
+	for i, input := range request.Contexts {
+		payload := domain.PayloadEvaluation{
+			Index:       i,
+			Evaluations: make([]domain.ExpressionEvaluation, 0, len(targets)),
 		}
+
+		for _, target := range targets {
+			evaluation := target
+			result, trace, err := s.engine.EvaluateWithTrace(target.Expression, input)
+			evaluation.Result = result
+			evaluation.Trace = trace
+			if err != nil {
+				evaluation.Error = err.Error()
+			}
+			payload.Evaluations = append(payload.Evaluations, evaluation)
+		}
+
+		results = append(results, payload)
 	}
-	
-	// Evaluación booleana simple
-	switch strings.ToLower(evaluatedExpr) {
-	case "true", "1", "yes":
-		return true, nil
-	case "false", "0", "no":
-		return false, nil
-	default:
-		// Si no coincide con ningún patrón, devolver false
-		return false, nil
-	}
+
+	return results, nil
 }
 
-// replaceVariables reemplaza variables en la expresión con valores del input
-func (s *conditionalService) replaceVariables(expression string, input map[string]interface{}) string {
-	result := expression
-	for key, value := range input {
-		placeholder := fmt.Sprintf("{{%s}}", key)
-		replacement := fmt.Sprintf("%v", value)
-		result = strings.ReplaceAll(result, placeholder, replacement)
-	}
-	return result
+// evaluateExpression evalúa una expresión condicional usando el motor de expresiones
+func (s *conditionalService) evaluateExpression(expression string, input map[string]interface{}) (bool, error) {
+	return s.engine.Evaluate(expression, input)
 }
 
 // Implementación de TriggerService
@@ -190,8 +198,11 @@ func (s *triggerService) GetTriggersByEvent(ctx context.Context, botID string, e
 }
 
 func (s *triggerService) CreateTrigger(ctx context.Context, trigger *domain.Trigger) error {
+	if err := validateTriggerSchedule(trigger); err != nil {
+		return err
+	}
 	if trigger.ID == "" {
-		trigger.ID = uuid.New().String()
+		trigger.ID = id.New()
 	}
 	trigger.CreatedAt = time.Now()
 	trigger.UpdatedAt = time.Now()
@@ -200,6 +211,9 @@ func (s *triggerService) CreateTrigger(ctx context.Context, trigger *domain.Trig
 }
 
 func (s *triggerService) UpdateTrigger(ctx context.Context, trigger *domain.Trigger) error {
+	if err := validateTriggerSchedule(trigger); err != nil {
+		return err
+	}
 	trigger.UpdatedAt = time.Now()
 	return s.triggerRepo.Update(ctx, trigger)
 }
@@ -209,9 +223,83 @@ func (s *triggerService) DeleteTrigger(ctx context.Context, id string) error {
 }
 
 func (s *triggerService) ExecuteTrigger(ctx context.Context, id string, eventData map[string]interface{}) error {
+	trigger, err := s.triggerRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("trigger not found: %w", err)
+	}
+
+	s.actionsMu.RLock()
+	executor, exists := s.actions[trigger.Action.Type]
+	s.actionsMu.RUnlock()
+	if !exists {
+		return fmt.Errorf("unsupported action type: %s", trigger.Action.Type)
+	}
+
+	if trigger.Action.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(trigger.Action.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	if eventData == nil {
+		eventData = make(map[string]interface{})
+	}
+	config := resolveActionConfig(trigger.Action.Config, eventData)
+
+	if err := executor.Execute(ctx, trigger, config, eventData); err != nil {
+		return fmt.Errorf("action %s failed: %w", trigger.Action.Type, err)
+	}
+
+	s.logger.WithContext(ctx).Info("Trigger executed", "trigger_id", trigger.ID, "action", trigger.Action.Type)
+	s.publishFired(ctx, trigger, eventData)
+
 	return s.triggerRepo.Execute(ctx, id, eventData)
 }
 
+// publishFired publica trigger_fired tras ejecutar la acción del trigger
+func (s *triggerService) publishFired(ctx context.Context, trigger *domain.Trigger, eventData map[string]interface{}) {
+	if s.eventBus == nil {
+		return
+	}
+
+	event := s.events.CreateUserEvent(events.EventTypeTriggerFired, eventString(eventData, "user_id"), map[string]interface{}{
+		"trigger_id": trigger.ID,
+		"bot_id":     trigger.BotID,
+		"event":      trigger.Event,
+		"action":     trigger.Action.Type,
+	})
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish trigger fired event", "trigger_id", trigger.ID, "error", err)
+	}
+}
+
+func (s *triggerService) RegisterAction(executor ActionExecutor) error {
+	if executor == nil || executor.Type() == "" {
+		return fmt.Errorf("action executor must have a type")
+	}
+
+	s.actionsMu.Lock()
+	defer s.actionsMu.Unlock()
+
+	if _, exists := s.actions[executor.Type()]; exists {
+		return fmt.Errorf("action type %s already registered", executor.Type())
+	}
+	s.actions[executor.Type()] = executor
+	return nil
+}
+
+func (s *triggerService) ListActionTypes() []string {
+	s.actionsMu.RLock()
+	defer s.actionsMu.RUnlock()
+
+	types := make([]string, 0, len(s.actions))
+	for actionType := range s.actions {
+		types = append(types, actionType)
+	}
+	sort.Strings(types)
+	return types
+}
+
 func (s *triggerService) ProcessEvent(ctx context.Context, botID string, event domain.TriggerEvent, eventData map[string]interface{}) error {
 	// Obtener triggers habilitados para el evento
 	triggers, err := s.triggerRepo.GetEnabledByBotID(ctx, botID)
@@ -229,24 +317,86 @@ func (s *triggerService) ProcessEvent(ctx context.Context, botID string, event d
 	
 	// Ejecutar triggers en orden de prioridad
 	for _, trigger := range matchingTriggers {
-		// Evaluar condición si existe
-		if trigger.Condition != "" {
-			conditionMet, err := s.conditionalSvc.EvaluateConditional(ctx, trigger.Condition, eventData)
-			if err != nil {
-				s.logger.Error("Failed to evaluate trigger condition", "trigger_id", trigger.ID, "error", err)
-				continue
-			}
-			
-			if !conditionMet {
-				continue
-			}
-		}
-		
-		// Ejecutar trigger
-		if err := s.ExecuteTrigger(ctx, trigger.ID, eventData); err != nil {
-			s.logger.Error("Failed to execute trigger", "trigger_id", trigger.ID, "error", err)
+		if _, err := s.FireTrigger(ctx, trigger, eventData); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to execute trigger", "trigger_id", trigger.ID, "error", err)
 		}
 	}
 	
+	return nil
+}
+
+// Subscribe escucha message_processed en lugar de message_received para que los
+// triggers reciban, además del mensaje y la respuesta, las entidades extraídas.
+// sentiment_threshold dispara los triggers del mismo nombre.
+func (s *triggerService) Subscribe(bus events.EventBus) error {
+	if err := bus.Subscribe(events.EventTypeMessageProcessed, s.eventHandler(domain.TriggerEventMessageReceived)); err != nil {
+		return err
+	}
+	return bus.Subscribe(events.EventTypeSentimentThreshold, s.eventHandler(domain.TriggerEventSentimentThreshold))
+}
+
+// eventHandler dispara los triggers de triggerEvent con los datos del evento del bus
+func (s *triggerService) eventHandler(triggerEvent domain.TriggerEvent) events.EventHandler {
+	return func(ctx context.Context, event events.Event) error {
+		botID := eventString(event.Data, "bot_id")
+		if botID == "" {
+			return nil
+		}
+		eventData := make(map[string]interface{}, len(event.Data)+1)
+		for key, value := range event.Data {
+			eventData[key] = value
+		}
+		eventData["user_id"] = event.UserID
+		return s.ProcessEvent(ctx, botID, triggerEvent, eventData)
+	}
+}
+
+// FireTrigger evalúa la condición del trigger y, si se cumple, ejecuta su acción.
+// Devuelve false si la condición no se cumplió.
+func (s *triggerService) FireTrigger(ctx context.Context, trigger *domain.Trigger, eventData map[string]interface{}) (bool, error) {
+	if trigger.Condition != "" {
+		conditionMet, err := s.conditionalSvc.EvaluateConditional(ctx, trigger.Condition, eventData)
+		if err != nil {
+			return false, fmt.Errorf("failed to evaluate trigger condition: %w", err)
+		}
+		if !conditionMet {
+			return false, nil
+		}
+	}
+
+	if err := s.ExecuteTrigger(ctx, trigger.ID, eventData); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// validateTriggerSchedule comprueba que los triggers temporales tengan una programación válida
+func validateTriggerSchedule(trigger *domain.Trigger) error {
+	schedule := trigger.Schedule
+
+	switch trigger.Event {
+	case domain.TriggerEventSchedule:
+		if schedule == nil || (schedule.Cron == "" && schedule.At == nil) {
+			return fmt.Errorf("schedule triggers require a cron expression or an absolute time")
+		}
+	case domain.TriggerEventTimeout:
+		if schedule == nil || schedule.InactivityMinutes <= 0 {
+			return fmt.Errorf("timeout triggers require inactivity_minutes")
+		}
+	}
+
+	if schedule == nil {
+		return nil
+	}
+	if schedule.Cron != "" {
+		if _, err := parseCron(schedule.Cron); err != nil {
+			return err
+		}
+	}
+	if schedule.Timezone != "" {
+		if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
 	return nil
 } 
\ No newline at end of file
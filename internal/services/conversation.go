@@ -6,20 +6,25 @@ import (
 	"time"
 
 	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
 	"github.com/company/bot-service/pkg/logger"
 )
 
 type conversationService struct {
 	sessionRepo domain.ConversationSessionRepository
+	clock       clock.Clock
 	logger      logger.Logger
 }
 
+// NewConversationService crea el servicio de sesiones; con clk nil usa el reloj del sistema
 func NewConversationService(
 	sessionRepo domain.ConversationSessionRepository,
+	clk clock.Clock,
 	logger logger.Logger,
 ) ConversationService {
 	return &conversationService{
 		sessionRepo: sessionRepo,
+		clock:       clock.OrReal(clk),
 		logger:      logger,
 	}
 }
@@ -31,10 +36,10 @@ func (s *conversationService) GetSession(ctx context.Context, userID, botID stri
 	}
 
 	// Verificar si la sesión ha expirado
-	if session.ExpiresAt.Before(time.Now()) {
+	if session.ExpiresAt.Before(s.clock.Now()) {
 		// Eliminar sesión expirada
 		if err := s.sessionRepo.Delete(ctx, session.ID); err != nil {
-			s.logger.Error("Failed to delete expired session", "session_id", session.ID, "error", err)
+			s.logger.WithContext(ctx).Error("Failed to delete expired session", "session_id", session.ID, "error", err)
 		}
 		return nil, fmt.Errorf("session expired")
 	}
@@ -43,18 +48,18 @@ func (s *conversationService) GetSession(ctx context.Context, userID, botID stri
 }
 
 func (s *conversationService) CreateSession(ctx context.Context, session *domain.ConversationSession) error {
-	session.CreatedAt = time.Now()
-	session.UpdatedAt = time.Now()
+	session.CreatedAt = s.clock.Now()
+	session.UpdatedAt = s.clock.Now()
 	if session.ExpiresAt.IsZero() {
-		session.ExpiresAt = time.Now().Add(24 * time.Hour) // Default 24 hours
+		session.ExpiresAt = s.clock.Now().Add(24 * time.Hour) // Default 24 hours
 	}
 	return s.sessionRepo.Create(ctx, session)
 }
 
 func (s *conversationService) UpdateSession(ctx context.Context, session *domain.ConversationSession) error {
-	session.UpdatedAt = time.Now()
+	session.UpdatedAt = s.clock.Now()
 	// Extender expiración en cada actualización
-	session.ExpiresAt = time.Now().Add(24 * time.Hour)
+	session.ExpiresAt = s.clock.Now().Add(24 * time.Hour)
 	return s.sessionRepo.Update(ctx, session)
 }
 
@@ -65,10 +70,10 @@ func (s *conversationService) DeleteSession(ctx context.Context, id string) erro
 func (s *conversationService) CleanupExpiredSessions(ctx context.Context) error {
 	err := s.sessionRepo.DeleteExpired(ctx)
 	if err != nil {
-		s.logger.Error("Failed to cleanup expired sessions", "error", err)
+		s.logger.WithContext(ctx).Error("Failed to cleanup expired sessions", "error", err)
 		return err
 	}
 
-	s.logger.Info("Expired sessions cleaned up successfully")
+	s.logger.WithContext(ctx).Info("Expired sessions cleaned up successfully")
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// ConversationHistoryService consulta el historial completo de mensajes de
+// las conversaciones, que se llena desde repositories.WithMessageHistory
+type ConversationHistoryService interface {
+	ListConversations(ctx context.Context, botID string, filter domain.ConversationHistoryFilter) ([]*domain.ConversationThread, int, error)
+	GetMessages(ctx context.Context, sessionID string, filter domain.ConversationHistoryFilter) ([]*domain.ConversationMessage, int, error)
+}
+
+type conversationHistoryService struct {
+	messageRepo domain.ConversationMessageRepository
+	botRepo     domain.BotRepository
+}
+
+// NewConversationHistoryService crea el servicio de historial de conversaciones
+func NewConversationHistoryService(messageRepo domain.ConversationMessageRepository, botRepo domain.BotRepository) ConversationHistoryService {
+	return &conversationHistoryService{
+		messageRepo: messageRepo,
+		botRepo:     botRepo,
+	}
+}
+
+func (s *conversationHistoryService) ListConversations(ctx context.Context, botID string, filter domain.ConversationHistoryFilter) ([]*domain.ConversationThread, int, error) {
+	if _, err := s.botRepo.GetByID(ctx, botID); err != nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrBotNotFound, botID)
+	}
+	return s.messageRepo.ListConversations(ctx, botID, filter)
+}
+
+func (s *conversationHistoryService) GetMessages(ctx context.Context, sessionID string, filter domain.ConversationHistoryFilter) ([]*domain.ConversationMessage, int, error) {
+	if _, err := s.messageRepo.GetConversation(ctx, sessionID); err != nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrConversationNotFound, sessionID)
+	}
+	return s.messageRepo.GetBySessionID(ctx, sessionID, filter)
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// conversationLocks serializa los cambios sobre la sesión de cada conversación
+// (bot y usuario). El repositorio de sesiones devuelve la misma sesión a todos,
+// así que el pipeline de mensajes, las tareas flow_resume y las derivaciones que
+// llegan por el bus no pueden modificarla a la vez.
+type conversationLocks struct {
+	mu    sync.Mutex
+	locks map[string]*conversationLock
+}
+
+type conversationLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock bloquea la conversación y devuelve la función que la libera
+func (l *conversationLocks) lock(botID, userID string) func() {
+	key := botID + "\x00" + userID
+
+	l.mu.Lock()
+	if l.locks == nil {
+		l.locks = make(map[string]*conversationLock)
+	}
+	entry, exists := l.locks[key]
+	if !exists {
+		entry = &conversationLock{}
+		l.locks[key] = entry
+	}
+	entry.refs++
+	l.mu.Unlock()
+
+	entry.mu.Lock()
+	return func() {
+		entry.mu.Unlock()
+
+		l.mu.Lock()
+		entry.refs--
+		if entry.refs == 0 {
+			delete(l.locks, key)
+		}
+		l.mu.Unlock()
+	}
+}
+
+// lockSession bloquea la conversación de la sesión y devuelve una copia del
+// estado que dejó quien tenía el bloqueo. Los cambios se guardan con Update;
+// quien ya tenga la sesión no los ve a medias.
+func (s *botService) lockSession(ctx context.Context, sessionID string) (*domain.ConversationSession, func(), error) {
+	session, err := s.handoffSession(ctx, sessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	unlock := s.conversations.lock(session.BotID, session.UserID)
+	session, err = s.handoffSession(ctx, sessionID)
+	if err != nil {
+		unlock()
+		return nil, nil, err
+	}
+	return cloneSession(session), unlock, nil
+}
+
+// cloneSession copia la sesión con su contexto, transcripción y handoff
+func cloneSession(session *domain.ConversationSession) *domain.ConversationSession {
+	clone := *session
+	if session.Context != nil {
+		clone.Context = make(map[string]interface{}, len(session.Context))
+		for key, value := range session.Context {
+			clone.Context[key] = value
+		}
+	}
+	clone.Messages = append([]domain.ConversationMessage(nil), session.Messages...)
+	if session.Handoff != nil {
+		handoff := *session.Handoff
+		clone.Handoff = &handoff
+	}
+	return &clone
+}
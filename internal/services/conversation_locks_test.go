@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConversationLocks_SerializesSameConversation(t *testing.T) {
+	var locks conversationLocks
+	counter := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := locks.lock("bot-1", "user-1")
+			defer unlock()
+			// Sin el bloqueo otra goroutine leería el mismo valor
+			current := counter
+			runtime.Gosched()
+			counter = current + 1
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 50, counter)
+
+	// Otra conversación no espera a la que está bloqueada
+	unlock := locks.lock("bot-1", "user-1")
+	acquired := make(chan struct{})
+	go func() {
+		locks.lock("bot-1", "user-2")()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("a different conversation waited for the lock")
+	}
+	unlock()
+
+	locks.mu.Lock()
+	assert.Empty(t, locks.locks, "released locks are forgotten")
+	locks.mu.Unlock()
+}
+
+func TestConversationLocks_ConcurrentMessagesKeepTheTranscript(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "escalate", IsDefault: true}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "escalate", FlowID: "flow-1", Type: domain.StepTypeHandoff,
+		Content: json.RawMessage(`{"text":"Transferring"}`)}))
+
+	_, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: "human please"})
+	require.NoError(t, err)
+	handoffs, err := bots.GetHandoffs(ctx, "bot-1")
+	require.NoError(t, err)
+	require.Len(t, handoffs, 1)
+	sessionID := handoffs[0].ID
+	_, err = bots.AcceptHandoff(ctx, sessionID, "agent-1")
+	require.NoError(t, err)
+
+	// El usuario y el agente escriben a la vez en la misma conversación
+	const messages = 20
+	var wg sync.WaitGroup
+	for i := 0; i < messages; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			_, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: fmt.Sprintf("user %d", i)})
+			assert.NoError(t, err)
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			_, err := bots.SendAgentMessage(ctx, sessionID, "agent-1", fmt.Sprintf("agent %d", i))
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	session, err := sessionRepo.GetByID(ctx, sessionID)
+	require.NoError(t, err)
+	written := make(map[string]bool)
+	for _, message := range session.Messages {
+		written[message.Content] = true
+	}
+	for i := 0; i < messages; i++ {
+		assert.True(t, written[fmt.Sprintf("user %d", i)], "user %d lost", i)
+		assert.True(t, written[fmt.Sprintf("agent %d", i)], "agent %d lost", i)
+	}
+}
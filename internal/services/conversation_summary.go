@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/id"
+)
+
+// maxTranscriptMessages limita los mensajes que se guardan en la sesión
+const maxTranscriptMessages = 100
+
+const summarySystemPrompt = `You summarize customer conversations for the human agent who takes over.
+Reply only with JSON: {"issue": "...", "resolution": "...", "sentiment": "positive|neutral|negative", "action_items": ["..."]}.
+"resolution" is empty when the issue is still open.`
+
+// appendTranscript agrega un mensaje a la transcripción de la sesión
+func (s *botService) appendTranscript(session *domain.ConversationSession, role, content string) {
+	if strings.TrimSpace(content) == "" {
+		return
+	}
+	session.Messages = append(session.Messages, domain.ConversationMessage{
+		Role:      role,
+		Content:   content,
+		Timestamp: s.clock.Now(),
+	})
+	if len(session.Messages) > maxTranscriptMessages {
+		session.Messages = session.Messages[len(session.Messages)-maxTranscriptMessages:]
+	}
+}
+
+// SummarizeConversation devuelve el resumen de la conversación. Se reutiliza
+// el resumen guardado mientras no haya mensajes nuevos, salvo que se pida refresh.
+func (s *botService) SummarizeConversation(ctx context.Context, sessionID string, refresh bool) (*domain.ConversationSummary, error) {
+	session, unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("conversation not found: %w", err)
+	}
+	defer unlock()
+	if len(session.Messages) == 0 {
+		summary := fallbackSummary(nil)
+		summary.GeneratedAt = s.clock.Now()
+		return summary, nil
+	}
+
+	if !refresh && session.Summary != nil && session.Summary.MessageCount == len(session.Messages) {
+		return session.Summary, nil
+	}
+
+	var config *domain.SummaryConfig
+	if bot, err := s.botRepo.GetByID(ctx, session.BotID); err == nil {
+		botConfig := parseBotConfig(bot)
+		config = botConfig.Summary
+		if botConfig.Sandbox != nil {
+			ctx = withSandbox(ctx)
+		}
+	}
+
+	session.Summary = s.summarizeSession(ctx, session, config)
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to cache conversation summary", "session_id", session.ID, "error", err)
+	}
+	return session.Summary, nil
+}
+
+// summarizeSession genera el resumen con un agente MCP de IA. Si el agente
+// falla o no devuelve JSON válido se usa un resumen básico de la transcripción.
+func (s *botService) summarizeSession(ctx context.Context, session *domain.ConversationSession, config *domain.SummaryConfig) *domain.ConversationSummary {
+	summary, err := s.generateSummary(ctx, session, config)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to generate conversation summary, using fallback", "session_id", session.ID, "error", err)
+		summary = fallbackSummary(session.Messages)
+	}
+	summary.MessageCount = len(session.Messages)
+	summary.GeneratedAt = s.clock.Now()
+	return summary
+}
+
+func (s *botService) generateSummary(ctx context.Context, session *domain.ConversationSession, config *domain.SummaryConfig) (*domain.ConversationSummary, error) {
+	if s.mcpOrchestrator == nil {
+		return nil, fmt.Errorf("MCP orchestrator not configured")
+	}
+
+	agentConfig := map[string]interface{}{"openai_api_key": ""}
+	if config != nil {
+		for key, value := range config.Config {
+			agentConfig[key] = value
+		}
+	}
+
+	agent, err := s.instantiateAgent(ctx, mcp.MCPConfig{
+		Type:         "ai",
+		Name:         fmt.Sprintf("summary-agent-%s", session.ID),
+		Version:      "1.0",
+		Config:       agentConfig,
+		Capabilities: []string{"summarization"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate summary agent: %w", err)
+	}
+	defer func() {
+		if err := s.mcpOrchestrator.TerminateAgent(ctx, agent.GetID()); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
+		}
+	}()
+
+	var transcript strings.Builder
+	for _, msg := range session.Messages {
+		fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, msg.Content)
+	}
+
+	result, err := agent.Execute(ctx, mcp.Task{
+		ID:          fmt.Sprintf("summary-%s-%s", session.ID, id.New()),
+		Type:        "summarization",
+		Description: fmt.Sprintf("Summary of conversation %s", session.ID),
+		Input: map[string]interface{}{
+			"system":      summarySystemPrompt,
+			"prompt":      "Conversation:\n" + transcript.String(),
+			"temperature": 0.2,
+		},
+		Priority: 5,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("summary failed: %s", result.Error)
+	}
+
+	text, _ := result.Output["text"].(string)
+	return parseSummary(text)
+}
+
+// parseSummary extrae el JSON de la respuesta, que puede venir entre bloques de código
+func parseSummary(text string) (*domain.ConversationSummary, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("summary response is not JSON")
+	}
+
+	var summary domain.ConversationSummary
+	if err := json.Unmarshal([]byte(text[start:end+1]), &summary); err != nil {
+		return nil, fmt.Errorf("invalid summary JSON: %w", err)
+	}
+	if summary.Issue == "" {
+		return nil, fmt.Errorf("summary without issue")
+	}
+
+	switch summary.Sentiment = strings.ToLower(summary.Sentiment); summary.Sentiment {
+	case "positive", "neutral", "negative":
+	default:
+		summary.Sentiment = "neutral"
+	}
+	if summary.ActionItems == nil {
+		summary.ActionItems = []string{}
+	}
+	summary.Source = "ai"
+	return &summary, nil
+}
+
+// fallbackSummary resume con el primer mensaje del usuario como problema y la
+// última respuesta del bot como resolución
+func fallbackSummary(messages []domain.ConversationMessage) *domain.ConversationSummary {
+	summary := &domain.ConversationSummary{
+		Sentiment:   "neutral",
+		ActionItems: []string{},
+		Source:      "fallback",
+	}
+
+	negative := 0
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+		if summary.Issue == "" {
+			summary.Issue = msg.Content
+		}
+		if contains(msg.Content, []string{"angry", "terrible", "awful", "useless", "frustrated", "molesto", "pésimo", "inútil"}) {
+			negative++
+		}
+	}
+	if negative > 0 {
+		summary.Sentiment = "negative"
+	}
+
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != "user" {
+			summary.Resolution = messages[i].Content
+			break
+		}
+	}
+	return summary
+}
+
+// processHandoffStep deriva la conversación a un agente humano. El bot deja de
+// responder hasta que la consola la libere; entonces continúa en el siguiente paso.
+func (s *botService) processHandoffStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	var content struct {
+		Text   string `json:"text"`
+		Queue  string `json:"queue"`
+		Reason string `json:"reason"`
+	}
+	if len(step.Content) > 0 {
+		if err := json.Unmarshal(step.Content, &content); err != nil {
+			return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
+		}
+	}
+	if content.Text == "" {
+		content.Text = "I'm transferring you to a member of our team. They'll be with you shortly."
+	}
+
+	s.startHandoff(ctx, session, content.Queue, content.Reason, string(message.Channel))
+
+	return &domain.BotResponse{
+		Content: content.Text,
+		Type:    domain.ResponseTypeText,
+		Metadata: map[string]interface{}{
+			"handoff": true,
+			"queue":   content.Queue,
+		},
+	}, step.NextStepID, nil
+}
@@ -0,0 +1,138 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeConversation(t *testing.T) {
+	var mu sync.Mutex
+	var prompts []string
+	failing := false
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Messages []struct {
+				Role    string `json:"role"`
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		require.Len(t, req.Messages, 2)
+		assert.Equal(t, summarySystemPrompt, req.Messages[0].Content)
+
+		mu.Lock()
+		prompts = append(prompts, req.Messages[1].Content)
+		fail := failing
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		// El modelo envuelve el JSON en un bloque de código
+		content := "```json\n" + `{"issue": "Order A1 is late", "resolution": "", "sentiment": "NEGATIVE", "action_items": ["Call the carrier"]}` + "\n```"
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": content}}},
+		})
+	}))
+	defer api.Close()
+	requests := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), prompts...)
+	}
+
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	orchestrator := mcp.NewOrchestrator(mcp.NewAgentFactory(log), log)
+	bots := NewBotService(botRepo, repositories.NewMockBotFlowRepository(), repositories.NewMockBotStepRepository(),
+		repositories.NewMockFlowVersionRepository(), sessionRepo, nil, NewConversationService(sessionRepo, nil, log),
+		nil, nil, nil, nil, orchestrator, nil, nil, log)
+	now := time.Date(2024, 6, 12, 10, 0, 0, 0, time.UTC)
+	bots.UseClock(clock.NewFake(now))
+
+	config, _ := json.Marshal(domain.BotConfig{Summary: &domain.SummaryConfig{
+		Config: map[string]interface{}{"openai_api_key": "sk-live", "base_url": api.URL},
+	}})
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive, Config: config}))
+	require.NoError(t, sessionRepo.Create(ctx, &domain.ConversationSession{ID: "session-1", BotID: "bot-1", UserID: "user-1",
+		Context: map[string]interface{}{},
+		Messages: []domain.ConversationMessage{
+			{Role: "user", Content: "My order A1 is late"},
+			{Role: "bot", Content: "Let me check that for you"},
+		}}))
+
+	summary, err := bots.SummarizeConversation(ctx, "session-1", false)
+	require.NoError(t, err)
+	assert.Equal(t, &domain.ConversationSummary{
+		Issue:        "Order A1 is late",
+		Sentiment:    "negative",
+		ActionItems:  []string{"Call the carrier"},
+		MessageCount: 2,
+		Source:       "ai",
+		GeneratedAt:  now,
+	}, summary)
+	require.Len(t, requests(), 1)
+	assert.Equal(t, "Conversation:\nuser: My order A1 is late\nbot: Let me check that for you\n", requests()[0])
+	assert.Empty(t, orchestrator.ListAgents())
+
+	// El resumen queda en la sesión y se reutiliza mientras no haya mensajes nuevos
+	stored, err := sessionRepo.GetByID(ctx, "session-1")
+	require.NoError(t, err)
+	assert.Equal(t, summary, stored.Summary)
+	_, err = bots.SummarizeConversation(ctx, "session-1", false)
+	require.NoError(t, err)
+	assert.Len(t, requests(), 1)
+	_, err = bots.SummarizeConversation(ctx, "session-1", true)
+	require.NoError(t, err)
+	assert.Len(t, requests(), 2)
+
+	// Con un mensaje nuevo se regenera; si la IA falla se resume la transcripción
+	stored.Messages = append(stored.Messages, domain.ConversationMessage{Role: "user", Content: "This is terrible"})
+	require.NoError(t, sessionRepo.Update(ctx, stored))
+	mu.Lock()
+	failing = true
+	mu.Unlock()
+	summary, err = bots.SummarizeConversation(ctx, "session-1", false)
+	require.NoError(t, err)
+	assert.Len(t, requests(), 3)
+	assert.Equal(t, &domain.ConversationSummary{
+		Issue:        "My order A1 is late",
+		Resolution:   "Let me check that for you",
+		Sentiment:    "negative",
+		ActionItems:  []string{},
+		MessageCount: 3,
+		Source:       "fallback",
+		GeneratedAt:  now,
+	}, summary)
+
+	_, err = bots.SummarizeConversation(ctx, "missing", false)
+	assert.Error(t, err)
+}
+
+func TestParseSummary(t *testing.T) {
+	summary, err := parseSummary(`Here you go: {"issue": "Refund", "sentiment": "angry"}`)
+	require.NoError(t, err)
+	assert.Equal(t, "neutral", summary.Sentiment)
+	assert.Equal(t, []string{}, summary.ActionItems)
+
+	for _, text := range []string{"No JSON here", `{"issue": ""}`, `{"issue": }`} {
+		_, err := parseSummary(text)
+		assert.Error(t, err, fmt.Sprintf("%q", text))
+	}
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// TaskTypeFlowResume es el tipo de tarea que reanuda un flujo tras un paso delay
+const TaskTypeFlowResume = "flow_resume"
+
+// delayStateKey guarda en el contexto la espera pendiente de la sesión
+const delayStateKey = "delay"
+
+// TaskScheduler programa tareas diferidas; lo implementa TaskManager
+type TaskScheduler interface {
+	SubmitTask(ctx context.Context, task *domain.AsyncTask) error
+	CancelTask(ctx context.Context, taskID string) error
+}
+
+// delayContent configura un paso delay: espera duration (o hasta until) y
+// continúa en next_step, por defecto el siguiente paso
+type delayContent struct {
+	Duration string `json:"duration"` // "90s", "2h", "1d"
+	Until    string `json:"until"`    // fecha/hora absoluta
+	NextStep string `json:"next_step"`
+	Text     string `json:"text"`
+	// WaitingText responde a los mensajes del usuario durante la espera
+	WaitingText string `json:"waiting_text"`
+	// ResumeOnMessage continúa el flujo en cuanto el usuario escribe
+	ResumeOnMessage bool `json:"resume_on_message"`
+}
+
+// delayState es la espera pendiente guardada en el contexto de la sesión
+type delayState struct {
+	StepID   string
+	TaskID   string
+	ResumeAt string
+}
+
+func (s *botService) processDelayStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	var content delayContent
+	if err := json.Unmarshal(step.Content, &content); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse step content: %w", err)
+	}
+
+	nextStepID := step.NextStepID
+	if content.NextStep != "" {
+		nextStepID = &content.NextStep
+	}
+
+	// Mensaje del usuario mientras el paso ya está esperando. Una espera vencida
+	// cuya tarea no llegó a reanudar el flujo se vuelve a programar.
+	if state, ok := pendingDelay(session); ok && state.StepID == step.ID && !state.expired(s.clock.Now()) {
+		if !content.ResumeOnMessage {
+			data := s.templateData(ctx, message, session, content.WaitingText)
+			return &domain.BotResponse{
+				Content:  renderTemplate(content.WaitingText, data),
+				Type:     domain.ResponseTypeText,
+				Metadata: map[string]interface{}{"delayed": true, "resume_at": state.ResumeAt},
+			}, &step.ID, nil
+		}
+
+		delete(session.Context, delayStateKey)
+		if s.scheduler != nil {
+			if err := s.scheduler.CancelTask(ctx, state.TaskID); err != nil {
+				s.logger.WithContext(ctx).Warn("Failed to cancel delay task", "task_id", state.TaskID, "error", err)
+			}
+		}
+		return s.continueAfterDelay(ctx, nextStepID, message, session)
+	}
+
+	resumeAt, err := delayResumeAt(content, s.clock.Now())
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid delay step %s: %w", step.ID, err)
+	}
+	if !resumeAt.After(s.clock.Now()) {
+		return s.continueAfterDelay(ctx, nextStepID, message, session)
+	}
+	if s.scheduler == nil {
+		return nil, nil, fmt.Errorf("delay step %s requires a task scheduler", step.ID)
+	}
+
+	task := &domain.AsyncTask{
+		Type:        TaskTypeFlowResume,
+		Description: fmt.Sprintf("Resume flow %s after step %s", session.CurrentFlowID, step.ID),
+		UserID:      session.UserID,
+		BotID:       session.BotID,
+		Priority:    5,
+		ScheduledAt: resumeAt,
+		Input: map[string]interface{}{
+			"bot_id":  session.BotID,
+			"user_id": session.UserID,
+			"flow_id": session.CurrentFlowID,
+			"step_id": step.ID,
+		},
+	}
+	if err := s.scheduler.SubmitTask(ctx, task); err != nil {
+		return nil, nil, fmt.Errorf("failed to schedule delay: %w", err)
+	}
+
+	state := delayState{StepID: step.ID, TaskID: task.ID, ResumeAt: resumeAt.Format(time.RFC3339)}
+	session.Context[delayStateKey] = map[string]interface{}{
+		"step_id":   state.StepID,
+		"task_id":   state.TaskID,
+		"resume_at": state.ResumeAt,
+	}
+
+	data := s.templateData(ctx, message, session, content.Text)
+	response := &domain.BotResponse{
+		Content:  renderTemplate(content.Text, data),
+		Type:     domain.ResponseTypeText,
+		Metadata: map[string]interface{}{"delayed": true, "resume_at": state.ResumeAt},
+	}
+	return response, &step.ID, nil
+}
+
+// ResumeDelayedStep es el handler de las tareas flow_resume: ejecuta el paso
+// siguiente al delay y publica su respuesta como evento bot_message. Si la
+// conversación cambió de paso entretanto, la tarea no hace nada.
+func (s *botService) ResumeDelayedStep(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+	userID, _ := task.Input["user_id"].(string)
+	botID, _ := task.Input["bot_id"].(string)
+	stepID, _ := task.Input["step_id"].(string)
+
+	unlock := s.conversations.lock(botID, userID)
+	defer unlock()
+
+	session, err := s.conversationSvc.GetSession(ctx, userID, botID)
+	if err != nil {
+		return map[string]interface{}{"skipped": true, "reason": "session not found"}, nil
+	}
+	ctx = logger.ContextWithFields(ctx, logger.SessionIDKey, session.ID)
+
+	state, ok := pendingDelay(session)
+	if !ok || state.TaskID != task.ID || state.StepID != stepID || session.CurrentStepID != stepID || session.Handoff != nil {
+		return map[string]interface{}{"skipped": true, "reason": "delay is no longer pending"}, nil
+	}
+
+	step, err := s.flowStep(ctx, session.CurrentFlowID, session.FlowVersion, stepID)
+	if err != nil {
+		return nil, fmt.Errorf("delay step not found: %w", err)
+	}
+	var content delayContent
+	if err := json.Unmarshal(step.Content, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse step content: %w", err)
+	}
+	nextStepID := step.NextStepID
+	if content.NextStep != "" {
+		nextStepID = &content.NextStep
+	}
+
+	channel, _ := session.Context["channel"].(string)
+	message := &domain.IncomingMessage{
+		BotID:     botID,
+		UserID:    userID,
+		Channel:   domain.ChannelType(channel),
+		Metadata:  map[string]interface{}{"trigger": "delay", "task_id": task.ID},
+		Timestamp: s.clock.Now(),
+	}
+
+	delete(session.Context, delayStateKey)
+	response, next, err := s.continueAfterDelay(ctx, nextStepID, message, session)
+	if err != nil {
+		return nil, err
+	}
+
+	session.CurrentStepID = ""
+	if next != nil {
+		session.CurrentStepID = *next
+	}
+	session.UpdatedAt = s.clock.Now()
+	if response.Content != "" {
+		session.Context["last_response"] = response.Content
+		s.appendTranscript(session, "bot", response.Content)
+	}
+
+	s.publishBotMessage(ctx, session, channel, response)
+	if session.EndedAt != nil {
+		s.endConversation(ctx, session)
+	} else {
+		s.saveSession(ctx, session)
+	}
+
+	return map[string]interface{}{
+		"session_id": session.ID,
+		"step_id":    session.CurrentStepID,
+		"content":    response.Content,
+	}, nil
+}
+
+// continueAfterDelay ejecuta el paso que sigue al delay; sin paso siguiente el
+// flujo termina con una respuesta vacía
+func (s *botService) continueAfterDelay(ctx context.Context, nextStepID *string, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	if nextStepID == nil || *nextStepID == "" {
+		return &domain.BotResponse{Type: domain.ResponseTypeText}, nil, nil
+	}
+
+	next, err := s.flowStep(ctx, session.CurrentFlowID, session.FlowVersion, *nextStepID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("step after delay not found: %w", err)
+	}
+	session.CurrentStepID = next.ID
+	return s.processStep(ctx, next, message, session)
+}
+
+func (s *botService) publishBotMessage(ctx context.Context, session *domain.ConversationSession, channel string, response *domain.BotResponse) {
+	if s.eventBus == nil || (response.Content == "" && len(response.Options) == 0) {
+		return
+	}
+
+	event := s.events.CreateUserEvent(events.EventTypeBotMessage, session.UserID, map[string]interface{}{
+		"bot_id":     session.BotID,
+		"session_id": session.ID,
+		"channel":    channel,
+		"content":    response.Content,
+		"type":       response.Type,
+		"options":    response.Options,
+	})
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish bot message event", "session_id", session.ID, "error", err)
+	}
+}
+
+func (d delayState) expired(now time.Time) bool {
+	resumeAt, err := time.Parse(time.RFC3339, d.ResumeAt)
+	return err != nil || now.After(resumeAt.Add(time.Minute))
+}
+
+func pendingDelay(session *domain.ConversationSession) (delayState, bool) {
+	raw, ok := session.Context[delayStateKey].(map[string]interface{})
+	if !ok {
+		return delayState{}, false
+	}
+	state := delayState{}
+	state.StepID, _ = raw["step_id"].(string)
+	state.TaskID, _ = raw["task_id"].(string)
+	state.ResumeAt, _ = raw["resume_at"].(string)
+	return state, state.StepID != ""
+}
+
+// delayResumeAt calcula cuándo reanudar: until tiene prioridad sobre duration
+func delayResumeAt(content delayContent, now time.Time) (time.Time, error) {
+	if content.Until != "" {
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(content.Until), now.Location()); err == nil {
+				return t, nil
+			}
+		}
+		return time.Time{}, fmt.Errorf("invalid until %q", content.Until)
+	}
+
+	if content.Duration == "" {
+		return time.Time{}, fmt.Errorf("duration or until is required")
+	}
+	duration, err := parseDelayDuration(content.Duration)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(duration), nil
+}
+
+// parseDelayDuration acepta las duraciones de Go más días ("2d")
+func parseDelayDuration(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "d") {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(value, "d"), 64)
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(days * float64(24*time.Hour)), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,95 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingScheduler struct {
+	tasks     []*domain.AsyncTask
+	cancelled []string
+}
+
+func (r *recordingScheduler) SubmitTask(ctx context.Context, task *domain.AsyncTask) error {
+	task.ID = "task-" + task.Input["step_id"].(string)
+	r.tasks = append(r.tasks, task)
+	return nil
+}
+
+func (r *recordingScheduler) CancelTask(ctx context.Context, taskID string) error {
+	r.cancelled = append(r.cancelled, taskID)
+	return nil
+}
+
+func TestDelayStep_SchedulesResumeAndContinues(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+	scheduler := &recordingScheduler{}
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, scheduler, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "wait", IsDefault: true}))
+	reminder := "reminder"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "wait", FlowID: "flow-1", Type: domain.StepTypeDelay, NextStepID: &reminder,
+		Content: json.RawMessage(`{"duration":"1d","text":"See you tomorrow","waiting_text":"Still waiting"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "reminder", FlowID: "flow-1", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Reminder for {{user_id}}"}`)}))
+
+	send := func(text string) *domain.BotResponse {
+		response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: text})
+		require.NoError(t, err)
+		return response
+	}
+
+	start := time.Now()
+	assert.Equal(t, "See you tomorrow", send("hi").Content)
+	require.Len(t, scheduler.tasks, 1)
+	task := scheduler.tasks[0]
+	assert.Equal(t, TaskTypeFlowResume, task.Type)
+	assert.WithinDuration(t, start.Add(24*time.Hour), task.ScheduledAt, time.Minute)
+
+	// Durante la espera el flujo no avanza
+	assert.Equal(t, "Still waiting", send("hello?").Content)
+	require.Len(t, scheduler.tasks, 1)
+
+	output, err := bots.ResumeDelayedStep(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, "Reminder for user-1", output["content"])
+
+	session, err := conversations.GetSession(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.NotContains(t, session.Context, delayStateKey)
+
+	// Una tarea ya consumida no vuelve a ejecutar el paso
+	output, err = bots.ResumeDelayedStep(ctx, task)
+	require.NoError(t, err)
+	assert.Equal(t, true, output["skipped"])
+}
+
+func TestParseDelayDuration(t *testing.T) {
+	d, err := parseDelayDuration("2d")
+	require.NoError(t, err)
+	assert.Equal(t, 48*time.Hour, d)
+
+	d, err = parseDelayDuration("90m")
+	require.NoError(t, err)
+	assert.Equal(t, 90*time.Minute, d)
+
+	_, err = parseDelayDuration("-1h")
+	assert.Error(t, err)
+}
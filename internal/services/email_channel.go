@@ -0,0 +1,807 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/imap"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Errores del canal de email
+var (
+	ErrInvalidEmail = errors.New("invalid email")
+	// ErrEmailIgnored indica un email automático (auto-respuesta, rebote o
+	// propio) que no se pasa al bot para no entrar en bucles
+	ErrEmailIgnored = errors.New("automatic email ignored")
+)
+
+// maxEmailTextLength recorta el texto que llega al bot; los emails largos
+// suelen arrastrar firmas y hilos enteros
+const maxEmailTextLength = 4000
+
+const defaultEmailReplyTemplate = `{{.Content}}
+{{- if .Options}}
+
+{{range .Options}}- {{.Text}}
+{{end}}
+{{- end}}
+{{- if .Signature}}
+
+--
+{{.Signature}}
+{{- end}}
+`
+
+// InboundEmail es un email recibido y ya decodificado
+type InboundEmail struct {
+	MessageID  string
+	InReplyTo  string
+	References []string
+	From       string
+	FromName   string
+	To         []string
+	Subject    string
+	Text       string
+	Date       time.Time
+	// Automatic marca auto-respuestas y correo masivo (Auto-Submitted, Precedence)
+	Automatic bool
+}
+
+// OutgoingEmail es una respuesta del bot lista para enviar
+type OutgoingEmail struct {
+	From       string
+	To         string
+	Subject    string
+	Body       string
+	MessageID  string
+	InReplyTo  string
+	References []string
+	Date       time.Time
+}
+
+// EmailSender entrega los emails salientes
+type EmailSender interface {
+	SendEmail(ctx context.Context, email *OutgoingEmail) error
+}
+
+// EmailChannelConfig configura el canal de email
+type EmailChannelConfig struct {
+	FromAddress string
+	// FromName vacío usa el nombre del bot
+	FromName string
+	// DefaultBotID atiende los emails que no indican bot con ?bot_id ni con
+	// una dirección soporte+<bot_id>@
+	DefaultBotID string
+	// SNSTopicARN, si no está vacío, es el único topic SNS aceptado en HandleSES
+	SNSTopicARN string
+	IMAP        IMAPPollingConfig
+}
+
+// IMAPPollingConfig lee un buzón por IMAP; sin Address no se hace polling
+type IMAPPollingConfig struct {
+	Address      string
+	Username     string
+	Password     string
+	Mailbox      string
+	TLS          bool
+	PollInterval time.Duration
+	Timeout      time.Duration
+}
+
+// EmailChannel convierte emails entrantes en IncomingMessage y responde al
+// remitente en el mismo hilo. Cada hilo (Message-ID raíz) es una sesión.
+type EmailChannel interface {
+	HandleEmail(ctx context.Context, email *InboundEmail, botID string) (*domain.BotResponse, error)
+	// HandleSendGrid procesa un POST de SendGrid Inbound Parse (campos del formulario)
+	HandleSendGrid(ctx context.Context, form map[string][]string, botID string) (*domain.BotResponse, error)
+	// HandleSES procesa una notificación SNS de Amazon SES con el MIME completo
+	HandleSES(ctx context.Context, body []byte, botID string) (*domain.BotResponse, error)
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type emailChannel struct {
+	botService BotService
+	sender     EmailSender
+	config     EmailChannelConfig
+	client     *http.Client
+	logger     logger.Logger
+
+	// snsCertificate devuelve el certificado con el que SNS firma sus mensajes
+	snsCertificate func(ctx context.Context, certURL string) (*x509.Certificate, error)
+
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	snsCerts map[string]*x509.Certificate
+}
+
+// NewEmailChannel crea el canal de email
+func NewEmailChannel(botService BotService, sender EmailSender, config EmailChannelConfig, logger logger.Logger) EmailChannel {
+	if config.IMAP.Mailbox == "" {
+		config.IMAP.Mailbox = "INBOX"
+	}
+	if config.IMAP.Timeout <= 0 {
+		config.IMAP.Timeout = 30 * time.Second
+	}
+	channel := &emailChannel{
+		botService: botService,
+		sender:     sender,
+		config:     config,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		logger:     logger,
+		snsCerts:   make(map[string]*x509.Certificate),
+	}
+	channel.snsCertificate = channel.fetchSNSCertificate
+	return channel
+}
+
+func (c *emailChannel) HandleEmail(ctx context.Context, email *InboundEmail, botID string) (*domain.BotResponse, error) {
+	if email.From == "" {
+		return nil, fmt.Errorf("%w: missing sender", ErrInvalidEmail)
+	}
+	if email.Automatic || strings.EqualFold(email.From, c.config.FromAddress) {
+		return nil, ErrEmailIgnored
+	}
+
+	botID = c.resolveBot(email, botID)
+	if botID == "" {
+		return nil, fmt.Errorf("%w: no bot configured for recipient %s", ErrInvalidEmail, strings.Join(email.To, ", "))
+	}
+
+	content := stripQuotedReply(email.Text)
+	if content == "" {
+		content = strings.TrimSpace(email.Subject)
+	}
+	if content == "" {
+		return nil, fmt.Errorf("%w: empty message", ErrInvalidEmail)
+	}
+	if runes := []rune(content); len(runes) > maxEmailTextLength {
+		content = string(runes[:maxEmailTextLength])
+	}
+
+	threadID := emailThreadID(email)
+	messageID := strings.Trim(email.MessageID, "<>")
+	if messageID == "" {
+		messageID = id.New()
+	}
+	timestamp := email.Date
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+
+	message := &domain.IncomingMessage{
+		ID:        messageID,
+		BotID:     botID,
+		UserID:    emailUserID(email.From, threadID),
+		Content:   content,
+		Channel:   domain.ChannelEmail,
+		Timestamp: timestamp,
+		Metadata: map[string]interface{}{
+			"user": map[string]interface{}{
+				"email": email.From,
+				"name":  email.FromName,
+			},
+			"message": map[string]interface{}{
+				"subject":   email.Subject,
+				"thread_id": threadID,
+			},
+		},
+	}
+
+	response, err := c.botService.ProcessIncomingMessage(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+	// Sin contenido no hay respuesta (p. ej. la conversación pasó a un agente)
+	if response == nil || (response.Content == "" && len(response.Options) == 0) {
+		return response, nil
+	}
+
+	reply, err := c.composeReply(ctx, botID, email, response)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.sender.SendEmail(ctx, reply); err != nil {
+		return nil, fmt.Errorf("failed to send email reply: %w", err)
+	}
+	return response, nil
+}
+
+func (c *emailChannel) HandleSendGrid(ctx context.Context, form map[string][]string, botID string) (*domain.BotResponse, error) {
+	field := func(name string) string {
+		if values := form[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	// Con "POST the raw, full MIME message" SendGrid manda el email entero en "email"
+	if raw := field("email"); raw != "" {
+		email, err := ParseEmail([]byte(raw))
+		if err != nil {
+			return nil, err
+		}
+		return c.HandleEmail(ctx, email, botID)
+	}
+
+	header, err := parseEmailHeaderBlock(field("headers"))
+	if err != nil {
+		return nil, err
+	}
+	email := inboundFromHeader(header)
+	if email.From == "" {
+		email.From, email.FromName = parseAddress(field("from"))
+	}
+	if len(email.To) == 0 {
+		email.To = parseAddressList(field("to"))
+	}
+	if email.Subject == "" {
+		email.Subject = field("subject")
+	}
+	email.Text = field("text")
+	if email.Text == "" {
+		email.Text = htmlToText(field("html"))
+	}
+	return c.HandleEmail(ctx, email, botID)
+}
+
+// snsMessage es el sobre de SNS con el que SES entrega los emails
+type snsMessage struct {
+	Type             string `json:"Type"`
+	MessageID        string `json:"MessageId"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	Timestamp        string `json:"Timestamp"`
+	Token            string `json:"Token"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+func (c *emailChannel) HandleSES(ctx context.Context, body []byte, botID string) (*domain.BotResponse, error) {
+	var envelope snsMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("%w: invalid SNS message: %v", ErrInvalidEmail, err)
+	}
+	// Sin firma válida cualquiera podría inyectar emails o suscripciones
+	if err := c.verifySNSSignature(ctx, &envelope); err != nil {
+		return nil, err
+	}
+	if c.config.SNSTopicARN != "" && envelope.TopicArn != c.config.SNSTopicARN {
+		return nil, fmt.Errorf("%w: unexpected SNS topic %s", ErrInvalidEmail, envelope.TopicArn)
+	}
+
+	switch envelope.Type {
+	case "SubscriptionConfirmation":
+		return nil, c.confirmSNSSubscription(ctx, envelope.SubscribeURL)
+	case "Notification":
+	default:
+		return nil, ErrEmailIgnored
+	}
+
+	var notification struct {
+		NotificationType string `json:"notificationType"`
+		Content          string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(envelope.Message), &notification); err != nil {
+		return nil, fmt.Errorf("%w: invalid SES notification: %v", ErrInvalidEmail, err)
+	}
+	if notification.NotificationType != "Received" {
+		return nil, ErrEmailIgnored
+	}
+	if notification.Content == "" {
+		return nil, fmt.Errorf("%w: SES notification without content; enable the SNS action with the full message", ErrInvalidEmail)
+	}
+
+	// La acción SNS de SES codifica el mensaje en UTF-8 o en Base64
+	raw := []byte(notification.Content)
+	if decoded, err := base64.StdEncoding.DecodeString(notification.Content); err == nil {
+		raw = decoded
+	}
+	email, err := ParseEmail(raw)
+	if err != nil {
+		return nil, err
+	}
+	return c.HandleEmail(ctx, email, botID)
+}
+
+// confirmSNSSubscription visita SubscribeURL, sólo si apunta a SNS
+func (c *emailChannel) confirmSNSSubscription(ctx context.Context, subscribeURL string) error {
+	parsed, err := url.Parse(subscribeURL)
+	if err != nil || parsed.Scheme != "https" || !strings.HasSuffix(parsed.Hostname(), ".amazonaws.com") {
+		return fmt.Errorf("%w: untrusted SNS SubscribeURL", ErrInvalidEmail)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to confirm SNS subscription: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to confirm SNS subscription: status %d", resp.StatusCode)
+	}
+
+	c.logger.WithContext(ctx).Info("SNS subscription confirmed for email channel")
+	return nil
+}
+
+// resolveBot elige el bot: el indicado en la petición, el de una dirección
+// con subdirección (soporte+<bot_id>@dominio) o el bot por defecto
+func (c *emailChannel) resolveBot(email *InboundEmail, botID string) string {
+	if botID != "" {
+		return botID
+	}
+	for _, address := range email.To {
+		local, _, _ := strings.Cut(address, "@")
+		if _, tag, ok := strings.Cut(local, "+"); ok && tag != "" {
+			return tag
+		}
+	}
+	return c.config.DefaultBotID
+}
+
+// emailReplyData son los campos disponibles en la plantilla de respuesta
+type emailReplyData struct {
+	Content   string
+	Options   []domain.ResponseOption
+	BotName   string
+	Subject   string
+	Signature string
+}
+
+func (c *emailChannel) composeReply(ctx context.Context, botID string, email *InboundEmail, response *domain.BotResponse) (*OutgoingEmail, error) {
+	bot, err := c.botService.GetBot(ctx, botID)
+	if err != nil {
+		return nil, err
+	}
+	config := parseBotConfig(bot)
+	emailConfig := config.Email
+	if emailConfig == nil {
+		emailConfig = &domain.EmailConfig{}
+	}
+
+	data := emailReplyData{
+		Content:   response.Content,
+		Options:   response.Options,
+		BotName:   bot.Name,
+		Subject:   email.Subject,
+		Signature: emailConfig.Signature,
+	}
+	body, err := renderEmailReply(emailConfig.ReplyTemplate, data)
+	if err != nil {
+		c.logger.WithContext(ctx).Warn("Invalid email reply template, using default", "bot_id", botID, "error", err)
+		body, _ = renderEmailReply("", data)
+	}
+
+	fromName := c.config.FromName
+	if fromName == "" {
+		fromName = bot.Name
+	}
+	_, domainPart, _ := strings.Cut(c.config.FromAddress, "@")
+	if domainPart == "" {
+		domainPart = "bot-service.local"
+	}
+
+	// References conserva la raíz del hilo en primera posición para que la
+	// siguiente respuesta del usuario caiga en la misma sesión
+	references := append([]string(nil), email.References...)
+	if email.MessageID != "" {
+		references = append(references, email.MessageID)
+	}
+
+	return &OutgoingEmail{
+		From:       (&mail.Address{Name: fromName, Address: c.config.FromAddress}).String(),
+		To:         email.From,
+		Subject:    replySubject(email.Subject),
+		Body:       body,
+		MessageID:  fmt.Sprintf("<%s@%s>", id.New(), domainPart),
+		InReplyTo:  email.MessageID,
+		References: references,
+		Date:       time.Now(),
+	}, nil
+}
+
+func renderEmailReply(text string, data emailReplyData) (string, error) {
+	if text == "" {
+		text = defaultEmailReplyTemplate
+	}
+	tmpl, err := template.New("email_reply").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var body strings.Builder
+	if err := tmpl.Execute(&body, data); err != nil {
+		return "", err
+	}
+	return body.String(), nil
+}
+
+func replySubject(subject string) string {
+	subject = strings.TrimSpace(subject)
+	if len(subject) >= 3 && strings.EqualFold(subject[:3], "re:") {
+		return subject
+	}
+	return strings.TrimSpace("Re: " + subject)
+}
+
+// emailThreadID es el Message-ID raíz del hilo: el primero de References,
+// si no In-Reply-To y, en un email nuevo, su propio Message-ID
+func emailThreadID(email *InboundEmail) string {
+	switch {
+	case len(email.References) > 0:
+		return email.References[0]
+	case email.InReplyTo != "":
+		return email.InReplyTo
+	case email.MessageID != "":
+		return email.MessageID
+	}
+	return id.New()
+}
+
+// emailUserID identifica al remitente en un hilo, de modo que cada hilo
+// tiene su propia sesión con el bot
+func emailUserID(address, threadID string) string {
+	sum := sha256.Sum256([]byte(threadID))
+	return strings.ToLower(address) + "#" + hex.EncodeToString(sum[:6])
+}
+
+var quoteHeaderPattern = regexp.MustCompile(`(?i)^(on .+ wrote:|el .+ escribió:|-+ ?original message ?-+|-+ ?mensaje original ?-+)$`)
+
+// stripQuotedReply quita del texto la parte citada del email anterior
+func stripQuotedReply(text string) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		if quoteHeaderPattern.MatchString(trimmed) {
+			break
+		}
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// ParseEmail decodifica un email RFC 5322 y extrae su parte de texto
+func ParseEmail(raw []byte) (*InboundEmail, error) {
+	message, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+
+	email := inboundFromHeader(message.Header)
+	text, html, err := readEmailBody(message.Header.Get("Content-Type"), message.Header.Get("Content-Transfer-Encoding"), message.Body)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEmail, err)
+	}
+	email.Text = text
+	if email.Text == "" {
+		email.Text = htmlToText(html)
+	}
+	return email, nil
+}
+
+func parseEmailHeaderBlock(headers string) (mail.Header, error) {
+	if strings.TrimSpace(headers) == "" {
+		return mail.Header{}, nil
+	}
+	message, err := mail.ReadMessage(strings.NewReader(strings.TrimRight(headers, "\r\n") + "\r\n\r\n"))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid headers: %v", ErrInvalidEmail, err)
+	}
+	return message.Header, nil
+}
+
+func inboundFromHeader(header mail.Header) *InboundEmail {
+	decoder := &mime.WordDecoder{}
+	subject, err := decoder.DecodeHeader(header.Get("Subject"))
+	if err != nil {
+		subject = header.Get("Subject")
+	}
+
+	email := &InboundEmail{
+		MessageID:  strings.TrimSpace(header.Get("Message-ID")),
+		InReplyTo:  firstMessageID(header.Get("In-Reply-To")),
+		References: messageIDs(header.Get("References")),
+		To:         parseAddressList(header.Get("To")),
+		Subject:    strings.TrimSpace(subject),
+	}
+	email.From, email.FromName = parseAddress(header.Get("From"))
+	if date, err := header.Date(); err == nil {
+		email.Date = date
+	}
+
+	autoSubmitted := strings.ToLower(strings.TrimSpace(header.Get("Auto-Submitted")))
+	precedence := strings.ToLower(strings.TrimSpace(header.Get("Precedence")))
+	email.Automatic = (autoSubmitted != "" && autoSubmitted != "no") ||
+		precedence == "bulk" || precedence == "junk" || precedence == "auto_reply" ||
+		header.Get("X-Autoreply") != "" || header.Get("X-Autorespond") != ""
+	return email
+}
+
+func parseAddress(value string) (string, string) {
+	if value == "" {
+		return "", ""
+	}
+	address, err := mail.ParseAddress(value)
+	if err != nil {
+		return strings.TrimSpace(value), ""
+	}
+	return address.Address, address.Name
+}
+
+func parseAddressList(value string) []string {
+	if value == "" {
+		return nil
+	}
+	addresses, err := mail.ParseAddressList(value)
+	if err != nil {
+		return nil
+	}
+	result := make([]string, 0, len(addresses))
+	for _, address := range addresses {
+		result = append(result, address.Address)
+	}
+	return result
+}
+
+var messageIDPattern = regexp.MustCompile(`<[^<>\s]+>`)
+
+func messageIDs(value string) []string {
+	return messageIDPattern.FindAllString(value, -1)
+}
+
+func firstMessageID(value string) string {
+	if ids := messageIDs(value); len(ids) > 0 {
+		return ids[0]
+	}
+	return strings.TrimSpace(value)
+}
+
+// readEmailBody devuelve las partes text/plain y text/html, recorriendo las
+// partes multipart anidadas y saltándose los adjuntos
+func readEmailBody(contentType, transferEncoding string, body io.Reader) (string, string, error) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+		var text, html string
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return "", "", err
+			}
+			if disposition, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disposition == "attachment" {
+				continue
+			}
+			partText, partHTML, err := readEmailBody(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if err != nil {
+				return "", "", err
+			}
+			if text == "" {
+				text = partText
+			}
+			if html == "" {
+				html = partHTML
+			}
+		}
+		return text, html, nil
+	}
+
+	if mediaType != "text/plain" && mediaType != "text/html" {
+		return "", "", nil
+	}
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &base64LineReader{reader: body})
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return "", "", err
+	}
+	if mediaType == "text/html" {
+		return "", string(content), nil
+	}
+	return string(content), "", nil
+}
+
+// base64LineReader quita los saltos de línea del cuerpo en base64
+type base64LineReader struct {
+	reader io.Reader
+}
+
+func (r *base64LineReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	kept := 0
+	for _, b := range p[:n] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// FormatEmail serializa la respuesta como mensaje RFC 5322 en texto plano
+func FormatEmail(email *OutgoingEmail) []byte {
+	var buf bytes.Buffer
+	writeHeader := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+		}
+	}
+	writeHeader("From", email.From)
+	writeHeader("To", email.To)
+	writeHeader("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	writeHeader("Date", email.Date.Format(time.RFC1123Z))
+	writeHeader("Message-ID", email.MessageID)
+	writeHeader("In-Reply-To", email.InReplyTo)
+	writeHeader("References", strings.Join(email.References, " "))
+	// RFC 3834: marca la respuesta como automática para que otros bots no contesten
+	writeHeader("Auto-Submitted", "auto-replied")
+	writeHeader("MIME-Version", "1.0")
+	writeHeader("Content-Type", "text/plain; charset=utf-8")
+	writeHeader("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+
+	writer := quotedprintable.NewWriter(&buf)
+	writer.Write([]byte(strings.ReplaceAll(email.Body, "\n", "\r\n")))
+	writer.Close()
+	return buf.Bytes()
+}
+
+func (c *emailChannel) Start(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.config.IMAP.Address == "" || c.config.IMAP.PollInterval <= 0 {
+		return nil
+	}
+	if c.cancel != nil {
+		return fmt.Errorf("email polling already started")
+	}
+	ctx, c.cancel = context.WithCancel(ctx)
+	go c.run(ctx)
+
+	c.logger.Info("Email IMAP polling started", "mailbox", c.config.IMAP.Mailbox, "interval", c.config.IMAP.PollInterval)
+	return nil
+}
+
+func (c *emailChannel) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
+}
+
+func (c *emailChannel) run(ctx context.Context) {
+	ticker := time.NewTicker(c.config.IMAP.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := c.poll(ctx); err != nil {
+			c.logger.Error("Email IMAP polling failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// poll procesa los emails no leídos del buzón. Cada email se marca como
+// leído aunque el bot falle, para no reintentarlo indefinidamente.
+func (c *emailChannel) poll(ctx context.Context) error {
+	settings := c.config.IMAP
+	client, err := imap.Dial(settings.Address, settings.TLS, settings.Timeout)
+	if err != nil {
+		return err
+	}
+	defer client.Logout()
+
+	if err := client.Login(settings.Username, settings.Password); err != nil {
+		return err
+	}
+	if err := client.Select(settings.Mailbox); err != nil {
+		return err
+	}
+	uids, err := client.SearchUnseen()
+	if err != nil {
+		return err
+	}
+
+	for _, uid := range uids {
+		if ctx.Err() != nil {
+			return nil
+		}
+		raw, err := client.FetchMessage(uid)
+		if err != nil {
+			return err
+		}
+		if email, err := ParseEmail(raw); err != nil {
+			c.logger.Warn("Failed to parse email", "uid", uid, "error", err)
+		} else if _, err := c.HandleEmail(ctx, email, ""); err != nil && !errors.Is(err, ErrEmailIgnored) {
+			c.logger.Error("Failed to handle email", "uid", uid, "message_id", email.MessageID, "error", err)
+		}
+		if err := client.MarkSeen(uid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// smtpEmailSender envía por SMTP con STARTTLS cuando el servidor lo ofrece
+type smtpEmailSender struct {
+	address string
+	auth    smtp.Auth
+}
+
+// NewSMTPEmailSender crea un EmailSender SMTP; sin usuario no se autentica
+func NewSMTPEmailSender(host, port, username, password string) EmailSender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpEmailSender{address: host + ":" + port, auth: auth}
+}
+
+func (s *smtpEmailSender) SendEmail(ctx context.Context, email *OutgoingEmail) error {
+	from, _ := parseAddress(email.From)
+	return smtp.SendMail(s.address, s.auth, from, []string{email.To}, FormatEmail(email))
+}
+
+// logEmailSender registra los emails salientes; se usa si no hay SMTP configurado
+type logEmailSender struct {
+	logger logger.Logger
+}
+
+// NewLogEmailSender crea un EmailSender que sólo registra los emails
+func NewLogEmailSender(logger logger.Logger) EmailSender {
+	return &logEmailSender{logger: logger}
+}
+
+func (s *logEmailSender) SendEmail(ctx context.Context, email *OutgoingEmail) error {
+	s.logger.WithContext(ctx).Info("Outgoing email", "to", email.To, "subject", email.Subject, "message_id", email.MessageID, "body", email.Body)
+	return nil
+}
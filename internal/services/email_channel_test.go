@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingEmailSender struct {
+	sent []*OutgoingEmail
+}
+
+func (r *recordingEmailSender) SendEmail(ctx context.Context, email *OutgoingEmail) error {
+	r.sent = append(r.sent, email)
+	return nil
+}
+
+const firstEmail = "From: Ana Ruiz <ana@example.com>\r\n" +
+	"To: soporte+bot-1@acme.com\r\n" +
+	"Subject: =?utf-8?q?Pedido_atrasado?=\r\n" +
+	"Message-ID: <m1@example.com>\r\n" +
+	"Content-Type: multipart/alternative; boundary=b1\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Hola, mi pedido no lleg=C3=B3\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Hola, mi pedido no llegó</p>\r\n" +
+	"--b1--\r\n"
+
+func TestEmailChannel_ThreadsRepliesIntoSessions(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Name: "Acme", Status: domain.BotStatusActive,
+		Config: json.RawMessage(`{"email":{"signature":"Equipo Acme"}}`)}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "ask", IsDefault: true}))
+	input, done := "input", "done"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "ask", FlowID: "main", Type: domain.StepTypeMessage, NextStepID: &input,
+		Content: json.RawMessage(`{"text":"What is your order number?"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "input", FlowID: "main", Type: domain.StepTypeInput, NextStepID: &done,
+		Content: json.RawMessage(`{"variable":"order"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "done", FlowID: "main", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Order received"}`)}))
+
+	sender := &recordingEmailSender{}
+	channel := NewEmailChannel(bots, sender, EmailChannelConfig{FromAddress: "soporte@acme.com"}, log)
+
+	email, err := ParseEmail([]byte(firstEmail))
+	require.NoError(t, err)
+	assert.Equal(t, "Pedido atrasado", email.Subject)
+	assert.Equal(t, "Hola, mi pedido no llegó", strings.TrimSpace(email.Text))
+	assert.Equal(t, []string{"soporte+bot-1@acme.com"}, email.To)
+
+	response, err := channel.HandleEmail(ctx, email, "")
+	require.NoError(t, err)
+	assert.Equal(t, "What is your order number?", response.Content)
+	require.Len(t, sender.sent, 1)
+	reply := sender.sent[0]
+	assert.Equal(t, "ana@example.com", reply.To)
+	assert.Equal(t, "Re: Pedido atrasado", reply.Subject)
+	assert.Equal(t, "<m1@example.com>", reply.InReplyTo)
+	assert.Equal(t, "What is your order number?\n\n--\nEquipo Acme\n", reply.Body)
+
+	// La respuesta del usuario cita el email del bot y continúa la misma sesión
+	_, err = channel.HandleEmail(ctx, &InboundEmail{
+		MessageID:  "<m2@example.com>",
+		InReplyTo:  reply.MessageID,
+		References: append(reply.References, reply.MessageID),
+		From:       "ana@example.com",
+		To:         []string{"soporte+bot-1@acme.com"},
+		Subject:    reply.Subject,
+		Text:       "A-123\n\nOn Mon, Acme wrote:\n> What is your order number?",
+	}, "")
+	require.NoError(t, err)
+	require.Len(t, sender.sent, 2)
+	assert.Equal(t, "Re: Pedido atrasado", sender.sent[1].Subject)
+	assert.Equal(t, "<m1@example.com>", sender.sent[1].References[0])
+
+	session, err := conversations.GetSession(ctx, emailUserID("ana@example.com", "<m1@example.com>"), "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "A-123", session.Context["order"])
+
+	// Un hilo nuevo del mismo remitente abre otra sesión
+	response, err = channel.HandleEmail(ctx, &InboundEmail{MessageID: "<m3@example.com>", From: "ana@example.com", Text: "Otra consulta"}, "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "What is your order number?", response.Content)
+
+	// Las auto-respuestas no llegan al bot
+	_, err = channel.HandleEmail(ctx, &InboundEmail{From: "ana@example.com", Text: "Fuera de la oficina", Automatic: true}, "bot-1")
+	assert.ErrorIs(t, err, ErrEmailIgnored)
+	require.Len(t, sender.sent, 3)
+
+	formatted, err := mail.ReadMessage(strings.NewReader(string(FormatEmail(sender.sent[1]))))
+	require.NoError(t, err)
+	assert.Equal(t, "auto-replied", formatted.Header.Get("Auto-Submitted"))
+	assert.Equal(t, "<m1@example.com> "+reply.MessageID+" <m2@example.com>", formatted.Header.Get("References"))
+}
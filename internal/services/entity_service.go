@@ -0,0 +1,487 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/company/bot-service/internal/ai"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Claves reservadas del contexto de sesión para entidades extraídas
+const (
+	// EntitiesContextKey guarda el último valor de cada tipo de entidad: {{entities.email}}
+	EntitiesContextKey = "entities"
+	// EntityListContextKey guarda todas las entidades extraídas del último mensaje
+	EntityListContextKey = "entity_list"
+)
+
+// EntityExtractionService define las operaciones de extracción de entidades
+type EntityExtractionService interface {
+	Extract(ctx context.Context, text string, definitions []domain.EntityDefinition) ([]domain.Entity, error)
+	UseClock(clk clock.Clock)
+}
+
+type entityExtractionService struct {
+	aiClient ai.AIClient
+	logger   logger.Logger
+	now      func() time.Time
+}
+
+var (
+	emailPattern       = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	phonePattern       = regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{1,4}\)[\s.\-]?)?\d{2,4}(?:[\s.\-]?\d{2,4}){1,4}`)
+	moneyPrefixPattern = regexp.MustCompile(`(?i)(US\$|\$|€|£|USD|EUR|MXN|COP|ARS)\s?(\d{1,3}(?:[.,]\d{3})+(?:[.,]\d{1,2})?|\d+(?:[.,]\d{1,2})?)`)
+	moneySuffixPattern = regexp.MustCompile(`(?i)(\d{1,3}(?:[.,]\d{3})+(?:[.,]\d{1,2})?|\d+(?:[.,]\d{1,2})?)\s?(dólares|dolares|dollars|euros|pesos|usd|eur)\b`)
+	isoDatePattern     = regexp.MustCompile(`\b(\d{4})-(\d{2})-(\d{2})\b`)
+	slashDatePattern   = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})/(\d{2,4})\b`)
+	orderNumberPattern = regexp.MustCompile(`(?i)\b(?:order|pedido|orden)\s*(?:#|n[°º]\.?|no\.?|number|n[uú]mero)?\s*#?([A-Z0-9][A-Z0-9\-]{3,})`)
+	hashNumberPattern  = regexp.MustCompile(`#(\d{4,})\b`)
+)
+
+var currencyAliases = map[string]string{
+	"$": "USD", "us$": "USD", "usd": "USD", "dólares": "USD", "dolares": "USD", "dollars": "USD",
+	"€": "EUR", "eur": "EUR", "euros": "EUR",
+	"£":   "GBP",
+	"mxn": "MXN", "cop": "COP", "ars": "ARS", "pesos": "MXN",
+}
+
+// NewEntityExtractionService crea el servicio de extracción. aiClient es opcional y
+// sólo se usa para las entidades declaradas con tipo "ai"; clk resuelve las
+// fechas relativas (nil usa el reloj real).
+func NewEntityExtractionService(aiClient ai.AIClient, clk clock.Clock, logger logger.Logger) EntityExtractionService {
+	return &entityExtractionService{
+		aiClient: aiClient,
+		logger:   logger,
+		now:      clock.OrReal(clk).Now,
+	}
+}
+
+// UseClock cambia el reloj con el que se resuelven las fechas relativas
+func (s *entityExtractionService) UseClock(clk clock.Clock) {
+	s.now = clock.OrReal(clk).Now
+}
+
+func (s *entityExtractionService) Extract(ctx context.Context, text string, definitions []domain.EntityDefinition) ([]domain.Entity, error) {
+	var entities []domain.Entity
+
+	entities = append(entities, s.extractEmails(text)...)
+	entities = append(entities, s.extractPhones(text)...)
+	entities = append(entities, s.extractMoney(text)...)
+	entities = append(entities, s.extractDates(text)...)
+	entities = append(entities, s.extractOrderNumbers(text)...)
+
+	var aiDefinitions []domain.EntityDefinition
+	for _, definition := range definitions {
+		switch definition.Type {
+		case "enum":
+			entities = append(entities, extractEnum(text, definition)...)
+		case "pattern":
+			found, err := extractPattern(text, definition)
+			if err != nil {
+				return entities, err
+			}
+			entities = append(entities, found...)
+		case "ai":
+			aiDefinitions = append(aiDefinitions, definition)
+		}
+	}
+
+	if len(aiDefinitions) > 0 && s.aiClient != nil {
+		found, err := s.extractWithAI(ctx, text, aiDefinitions)
+		if err != nil {
+			// La extracción por IA es opcional: las reglas siguen siendo válidas
+			s.logger.WithContext(ctx).Warn("AI entity extraction failed", "error", err)
+		} else {
+			entities = append(entities, found...)
+		}
+	}
+
+	return entities, nil
+}
+
+func (s *entityExtractionService) extractEmails(text string) []domain.Entity {
+	var entities []domain.Entity
+	for _, match := range emailPattern.FindAllString(text, -1) {
+		entities = append(entities, domain.Entity{
+			Type:       domain.EntityTypeEmail,
+			Value:      strings.ToLower(match),
+			Raw:        match,
+			Confidence: 0.95,
+			Source:     "rules",
+		})
+	}
+	return entities
+}
+
+// extractPhones reconoce teléfonos con prefijo internacional o de al menos 9
+// dígitos y los normaliza a dígitos (con "+" si lo llevan)
+func (s *entityExtractionService) extractPhones(text string) []domain.Entity {
+	var entities []domain.Entity
+	for _, loc := range phonePattern.FindAllStringIndex(text, -1) {
+		match := text[loc[0]:loc[1]]
+		// Los dígitos pegados a letras o "#" son referencias ("TCK-123456789", "#48213") y
+		// las fechas ISO tienen la misma forma que un número con guiones
+		if previous, _ := utf8.DecodeLastRuneInString(text[:loc[0]]); unicode.IsLetter(previous) || strings.ContainsRune("#-_/", previous) {
+			continue
+		}
+		if isoDatePattern.MatchString(match) {
+			continue
+		}
+
+		var digits strings.Builder
+		for _, r := range match {
+			if r >= '0' && r <= '9' {
+				digits.WriteRune(r)
+			}
+		}
+		international := strings.HasPrefix(match, "+")
+		if count := digits.Len(); count > 15 || count < 7 || (!international && count < 9) {
+			continue
+		}
+
+		value := digits.String()
+		if international {
+			value = "+" + value
+		}
+		entities = append(entities, domain.Entity{
+			Type:       domain.EntityTypePhone,
+			Value:      value,
+			Raw:        match,
+			Confidence: 0.85,
+			Source:     "rules",
+		})
+	}
+	return entities
+}
+
+func (s *entityExtractionService) extractMoney(text string) []domain.Entity {
+	var entities []domain.Entity
+
+	for _, match := range moneyPrefixPattern.FindAllStringSubmatch(text, -1) {
+		if amount, ok := parseAmount(match[2]); ok {
+			entities = append(entities, moneyEntity(match[0], amount, match[1]))
+		}
+	}
+	for _, match := range moneySuffixPattern.FindAllStringSubmatch(text, -1) {
+		if amount, ok := parseAmount(match[1]); ok {
+			entities = append(entities, moneyEntity(match[0], amount, match[2]))
+		}
+	}
+
+	return entities
+}
+
+func moneyEntity(raw string, amount float64, currency string) domain.Entity {
+	code, ok := currencyAliases[strings.ToLower(currency)]
+	if !ok {
+		code = strings.ToUpper(currency)
+	}
+	return domain.Entity{
+		Type: domain.EntityTypeMoney,
+		Value: map[string]interface{}{
+			"amount":   amount,
+			"currency": code,
+		},
+		Raw:        raw,
+		Confidence: 0.9,
+		Source:     "rules",
+	}
+}
+
+// parseAmount interpreta separadores de miles y decimales en formato 1,234.56 o 1.234,56
+func parseAmount(raw string) (float64, bool) {
+	lastDot := strings.LastIndex(raw, ".")
+	lastComma := strings.LastIndex(raw, ",")
+
+	decimalSep := ""
+	if lastDot > lastComma && len(raw)-lastDot-1 <= 2 {
+		decimalSep = "."
+	} else if lastComma > lastDot && len(raw)-lastComma-1 <= 2 {
+		decimalSep = ","
+	}
+
+	var normalized strings.Builder
+	for i, r := range raw {
+		switch {
+		case r >= '0' && r <= '9':
+			normalized.WriteRune(r)
+		case decimalSep != "" && string(r) == decimalSep && (i == lastDot || i == lastComma):
+			normalized.WriteRune('.')
+		}
+	}
+
+	amount, err := strconv.ParseFloat(normalized.String(), 64)
+	return amount, err == nil
+}
+
+func (s *entityExtractionService) extractDates(text string) []domain.Entity {
+	var entities []domain.Entity
+	now := s.now()
+
+	for _, match := range isoDatePattern.FindAllString(text, -1) {
+		if date, err := time.ParseInLocation("2006-01-02", match, now.Location()); err == nil {
+			entities = append(entities, dateEntity(match, date, 0.95))
+		}
+	}
+
+	for _, match := range slashDatePattern.FindAllStringSubmatch(text, -1) {
+		day, _ := strconv.Atoi(match[1])
+		month, _ := strconv.Atoi(match[2])
+		year, _ := strconv.Atoi(match[3])
+		if year < 100 {
+			year += 2000
+		}
+		// Formato día/mes/año
+		date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, now.Location())
+		if date.Day() == day && int(date.Month()) == month {
+			entities = append(entities, dateEntity(match[0], date, 0.85))
+		}
+	}
+
+	// Fechas relativas; las frases más largas primero para no confundir "pasado mañana" con "mañana"
+	lower := normalizeText(text)
+	relative := []struct {
+		phrase string
+		days   int
+	}{
+		{"pasado manana", 2},
+		{"day after tomorrow", 2},
+		{"manana", 1},
+		{"tomorrow", 1},
+		{"hoy", 0},
+		{"today", 0},
+		{"ayer", -1},
+		{"yesterday", -1},
+	}
+	for _, candidate := range relative {
+		if phraseDistance(tokenize(candidate.phrase), tokenize(lower), 0) == 0 {
+			date := now.AddDate(0, 0, candidate.days)
+			entities = append(entities, dateEntity(candidate.phrase, date, 0.8))
+			break
+		}
+	}
+
+	return entities
+}
+
+func dateEntity(raw string, date time.Time, confidence float64) domain.Entity {
+	return domain.Entity{
+		Type:       domain.EntityTypeDate,
+		Value:      date.Format("2006-01-02"),
+		Raw:        raw,
+		Confidence: confidence,
+		Source:     "rules",
+	}
+}
+
+func (s *entityExtractionService) extractOrderNumbers(text string) []domain.Entity {
+	var entities []domain.Entity
+	seen := make(map[string]bool)
+
+	add := func(raw, value string, confidence float64) {
+		value = strings.ToUpper(value)
+		if seen[value] {
+			return
+		}
+		seen[value] = true
+		entities = append(entities, domain.Entity{
+			Type:       domain.EntityTypeOrderNumber,
+			Value:      value,
+			Raw:        raw,
+			Confidence: confidence,
+			Source:     "rules",
+		})
+	}
+
+	for _, match := range orderNumberPattern.FindAllStringSubmatch(text, -1) {
+		// Evitar capturar palabras comunes sin dígitos ("pedido nuevo")
+		if strings.ContainsAny(match[1], "0123456789") {
+			add(match[0], match[1], 0.85)
+		}
+	}
+	for _, match := range hashNumberPattern.FindAllStringSubmatch(text, -1) {
+		add(match[0], match[1], 0.7)
+	}
+
+	return entities
+}
+
+func extractEnum(text string, definition domain.EntityDefinition) []domain.Entity {
+	tokens := tokenize(normalizeText(text))
+
+	for canonical, synonyms := range definition.Values {
+		for _, candidate := range append([]string{canonical}, synonyms...) {
+			if phraseDistance(tokenize(normalizeText(candidate)), tokens, 0) == 0 {
+				return []domain.Entity{{
+					Type:       definition.Name,
+					Value:      canonical,
+					Raw:        candidate,
+					Confidence: 0.9,
+					Source:     "rules",
+				}}
+			}
+		}
+	}
+
+	return nil
+}
+
+func extractPattern(text string, definition domain.EntityDefinition) ([]domain.Entity, error) {
+	re, err := regexp.Compile(definition.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern for entity %s: %w", definition.Name, err)
+	}
+
+	var entities []domain.Entity
+	for _, match := range re.FindAllStringSubmatch(text, -1) {
+		// Si el patrón tiene un grupo, el valor es el primer grupo
+		value := match[0]
+		if len(match) > 1 && match[1] != "" {
+			value = match[1]
+		}
+		entities = append(entities, domain.Entity{
+			Type:       definition.Name,
+			Value:      value,
+			Raw:        match[0],
+			Confidence: 0.9,
+			Source:     "rules",
+		})
+	}
+	return entities, nil
+}
+
+func (s *entityExtractionService) extractWithAI(ctx context.Context, text string, definitions []domain.EntityDefinition) ([]domain.Entity, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Extract the following entities from the user message. ")
+	prompt.WriteString("Reply only with a JSON object mapping entity name to value, omitting entities that are not present.\n\nEntities:\n")
+	for _, definition := range definitions {
+		prompt.WriteString(fmt.Sprintf("- %s: %s\n", definition.Name, definition.Description))
+	}
+	prompt.WriteString("\nUser message: ")
+	prompt.WriteString(text)
+
+	response, err := s.aiClient.GenerateResponse(ctx, prompt.String(),
+		ai.WithMaxTokens(200),
+		ai.WithTemperature(0),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call AI client: %w", err)
+	}
+
+	content := strings.TrimSpace(response.Content)
+	start := strings.Index(content, "{")
+	end := strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return nil, fmt.Errorf("AI response is not a JSON object")
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal([]byte(content[start:end+1]), &values); err != nil {
+		return nil, fmt.Errorf("failed to parse AI entities: %w", err)
+	}
+
+	var entities []domain.Entity
+	for _, definition := range definitions {
+		value, ok := values[definition.Name]
+		if !ok || value == nil || value == "" {
+			continue
+		}
+		entities = append(entities, domain.Entity{
+			Type:       definition.Name,
+			Value:      value,
+			Raw:        fmt.Sprintf("%v", value),
+			Confidence: 0.7,
+			Source:     "ai",
+		})
+	}
+
+	return entities, nil
+}
+
+// extractEntities es la etapa de extracción del procesamiento de un mensaje. Las
+// entidades quedan en el contexto de la sesión (para los pasos de decisión), en
+// el resumen de contexto del usuario y en message.Entities, que viajan en el
+// evento message_processed hasta los triggers.
+func (s *botService) extractEntities(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession, definitions []domain.EntityDefinition) {
+	if s.entitySvc == nil {
+		return
+	}
+
+	entities, err := s.entitySvc.Extract(ctx, message.Content, definitions)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to extract entities", "bot_id", message.BotID, "error", err)
+	}
+	message.Entities = entities
+	storeEntities(session, entities)
+
+	if s.memorySvc == nil || len(entities) == 0 {
+		return
+	}
+	summary, err := s.memorySvc.GetContextSummary(ctx, message.UserID, message.BotID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to load context summary", "bot_id", message.BotID, "error", err)
+		return
+	}
+	// El resumen devuelto comparte el mapa con el guardado: se reemplaza, no se modifica
+	merged := make(map[string]interface{}, len(summary.Entities)+len(entities))
+	for key, value := range summary.Entities {
+		merged[key] = value
+	}
+	for key, value := range entityValues(entities) {
+		merged[key] = value
+	}
+	summary.Entities = merged
+	if err := s.memorySvc.UpdateContextSummary(ctx, summary); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to update context summary entities", "bot_id", message.BotID, "error", err)
+	}
+}
+
+// entityValues devuelve el último valor de cada tipo de entidad
+func entityValues(entities []domain.Entity) map[string]interface{} {
+	values := make(map[string]interface{}, len(entities))
+	for _, entity := range entities {
+		values[entity.Type] = entity.Value
+	}
+	return values
+}
+
+// storeEntities guarda las entidades en las claves reservadas del contexto de sesión.
+// Las entidades de mensajes anteriores se conservan salvo que se sobrescriban.
+func storeEntities(session *domain.ConversationSession, entities []domain.Entity) {
+	if session.Context == nil {
+		session.Context = make(map[string]interface{})
+	}
+
+	latest, ok := session.Context[EntitiesContextKey].(map[string]interface{})
+	if !ok {
+		latest = make(map[string]interface{})
+	}
+
+	for key, value := range entityValues(entities) {
+		latest[key] = value
+	}
+
+	session.Context[EntitiesContextKey] = latest
+	session.Context[EntityListContextKey] = entities
+}
+
+// findEntity busca una entidad del tipo dado entre las extraídas del último mensaje
+func findEntity(session *domain.ConversationSession, entityType string) *domain.Entity {
+	entities, _ := session.Context[EntityListContextKey].([]domain.Entity)
+	for i := range entities {
+		if entities[i].Type == entityType {
+			return &entities[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEntityExtraction_Rules(t *testing.T) {
+	svc := NewEntityExtractionService(nil, nil, logger.NewLogger("error")).(*entityExtractionService)
+	svc.now = func() time.Time {
+		return time.Date(2024, 6, 12, 10, 0, 0, 0, time.UTC)
+	}
+
+	definitions := []domain.EntityDefinition{
+		{Name: "plan", Type: "enum", Values: map[string][]string{"premium": {"pro", "plan completo"}}},
+		{Name: "ticket", Type: "pattern", Pattern: `TCK-(\d+)`},
+	}
+
+	entities, err := svc.Extract(context.Background(),
+		"Mi pedido #48213 de $1,250.50 no llegó mañana, escribe a Ana@Example.com, plan pro, TCK-77", definitions)
+	assert.NoError(t, err)
+
+	found := make(map[string]interface{})
+	for _, entity := range entities {
+		found[entity.Type] = entity.Value
+	}
+
+	assert.Equal(t, "ana@example.com", found[domain.EntityTypeEmail])
+	assert.Equal(t, map[string]interface{}{"amount": 1250.5, "currency": "USD"}, found[domain.EntityTypeMoney])
+	assert.Equal(t, "2024-06-13", found[domain.EntityTypeDate])
+	assert.Equal(t, "48213", found[domain.EntityTypeOrderNumber])
+	assert.Equal(t, "premium", found["plan"])
+	assert.Equal(t, "77", found["ticket"])
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := map[string]float64{
+		"1,234.56": 1234.56,
+		"1.234,56": 1234.56,
+		"1.234":    1234,
+		"99":       99,
+		"12,5":     12.5,
+	}
+
+	for raw, expected := range cases {
+		amount, ok := parseAmount(raw)
+		assert.True(t, ok, raw)
+		assert.Equal(t, expected, amount, raw)
+	}
+}
+
+func TestEntityExtraction_Phones(t *testing.T) {
+	svc := NewEntityExtractionService(nil, nil, logger.NewLogger("error"))
+
+	entities, err := svc.Extract(context.Background(),
+		"Llámame al +34 612 345 678 o al (55) 1234-5678, no al 2024-06-13 ni a la referencia TCK-123456789", nil)
+	assert.NoError(t, err)
+
+	var phones []interface{}
+	for _, entity := range entities {
+		if entity.Type == domain.EntityTypePhone {
+			phones = append(phones, entity.Value)
+		}
+	}
+	assert.Equal(t, []interface{}{"+34612345678", "5512345678"}, phones)
+}
+
+// channelAction envía por un canal los datos de cada trigger ejecutado
+type channelAction chan map[string]interface{}
+
+func (a channelAction) Type() string { return "notify" }
+
+func (a channelAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	a <- eventData
+	return nil
+}
+
+func TestExtractEntities_ReachesSessionSummaryAndTriggers(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	memory := NewMemoryService(log, 0, 0, nil)
+	bus := events.NewInMemoryEventBus(log)
+
+	triggerRepo := repositories.NewMockTriggerRepository()
+	triggers := NewTriggerService(triggerRepo, NewConditionalService(repositories.NewMockConditionalRepository(), log), TriggerActionDeps{}, log)
+	fired := make(channelAction, 1)
+	require.NoError(t, triggers.RegisterAction(fired))
+	require.NoError(t, triggers.CreateTrigger(ctx, &domain.Trigger{BotID: "bot-1", Event: domain.TriggerEventMessageReceived, Enabled: true,
+		Action: domain.TriggerAction{Type: "notify"}}))
+	require.NoError(t, triggers.Subscribe(bus))
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, NewEntityExtractionService(nil, nil, log), memory, nil, nil, bus, nil, log)
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "ask", IsDefault: true}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "ask", FlowID: "flow-1", Type: domain.StepTypeDecision,
+		Conditions: json.RawMessage(`{"rules":[{"condition":"entities.email != ''","next_step":"thanks"}],"default":"missing"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "thanks", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"Gracias"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "missing", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"¿Tu email?"}`)}))
+
+	response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1",
+		Content: "Soy ana@example.com, mi teléfono es +34 612 345 678"})
+	require.NoError(t, err)
+	assert.Equal(t, "Condition matched, proceeding...", response.Content)
+	session, err := sessionRepo.GetByUserAndBot(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "thanks", session.CurrentStepID)
+
+	summary, err := memory.GetContextSummary(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "ana@example.com", summary.Entities[domain.EntityTypeEmail])
+	assert.Equal(t, "+34612345678", summary.Entities[domain.EntityTypePhone])
+
+	select {
+	case data := <-fired:
+		assert.Equal(t, "user-1", data["user_id"])
+		assert.Equal(t, "ana@example.com", data[EntitiesContextKey].(map[string]interface{})[domain.EntityTypeEmail])
+	case <-time.After(2 * time.Second):
+		t.Fatal("message_received trigger was not fired")
+	}
+}
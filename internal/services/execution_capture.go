@@ -0,0 +1,330 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	redactedCaptureValue = "********"
+	// captureConfigTTL es lo que se reutiliza la configuración de captura de un bot
+	captureConfigTTL = 30 * time.Second
+)
+
+// ErrAgentExecutionNotFound se devuelve si la ejecución no existe o ya se eliminó por la retención
+var ErrAgentExecutionNotFound = errors.New("agent execution not found")
+
+var (
+	// Claves que se ocultan siempre, comparadas por subcadena en minúsculas
+	sensitiveCaptureKeys = []string{"password", "secret", "token", "api_key", "apikey", "authorization", "credential", "private_key", "cookie"}
+
+	captureEmailPattern  = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+	captureNumberPattern = regexp.MustCompile(`\d(?:[ -]?\d){7,}`)
+)
+
+// ExecutionCaptureConfig controla la captura de ejecuciones de agentes
+type ExecutionCaptureConfig struct {
+	// DefaultSampleRate se usa para los bots que activan la captura sin sample_rate
+	DefaultSampleRate float64
+	// Retention es la antigüedad máxima de una captura; se limpia cada CleanupInterval
+	Retention       time.Duration
+	CleanupInterval time.Duration
+	// MaxFieldLength trunca los textos de la entrada y la salida
+	MaxFieldLength int
+	QueueSize      int
+}
+
+// ExecutionCaptureService guarda muestras redactadas de las tareas de agentes
+// de los bots que lo activan en execution_capture, para depurar regresiones
+// de los modelos
+type ExecutionCaptureService interface {
+	mcp.ExecutionRecorder
+	Search(ctx context.Context, filter domain.AgentExecutionFilter) ([]*domain.AgentExecution, int, error)
+	GetExecution(ctx context.Context, id string) (*domain.AgentExecution, error)
+	// DeleteExpired elimina las capturas más antiguas que la retención y devuelve cuántas eliminó
+	DeleteExpired(ctx context.Context) (int, error)
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type cachedCaptureConfig struct {
+	config    *domain.ExecutionCaptureConfig
+	expiresAt time.Time
+}
+
+type executionCaptureService struct {
+	repo    domain.AgentExecutionRepository
+	botRepo domain.BotRepository
+	config  ExecutionCaptureConfig
+	logger  logger.Logger
+	queue   chan *domain.AgentExecution
+
+	configMu sync.Mutex
+	configs  map[string]cachedCaptureConfig
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewExecutionCaptureService crea el servicio de captura. Las ejecuciones se
+// guardan en segundo plano después de Start; si la cola está llena se descartan.
+func NewExecutionCaptureService(repo domain.AgentExecutionRepository, botRepo domain.BotRepository, config ExecutionCaptureConfig, logger logger.Logger) ExecutionCaptureService {
+	if config.QueueSize <= 0 {
+		config.QueueSize = 1000
+	}
+	return &executionCaptureService{
+		repo:    repo,
+		botRepo: botRepo,
+		config:  config,
+		logger:  logger,
+		queue:   make(chan *domain.AgentExecution, config.QueueSize),
+		configs: make(map[string]cachedCaptureConfig),
+	}
+}
+
+// RecordExecution decide si la ejecución se captura y la encola ya redactada
+func (s *executionCaptureService) RecordExecution(ctx context.Context, execution mcp.AgentExecution) {
+	botID := logger.FieldValue(ctx, logger.BotIDKey)
+	if botID == "" {
+		botID, _ = execution.Task.Metadata["bot_id"].(string)
+	}
+	if botID == "" {
+		return
+	}
+
+	config := s.captureConfig(ctx, botID)
+	if config == nil || !config.Enabled {
+		return
+	}
+	success := execution.Err == nil && execution.Result.Success
+	sampleRate := s.config.DefaultSampleRate
+	if config.SampleRate != nil {
+		sampleRate = *config.SampleRate
+	}
+	// Las ejecuciones fallidas se guardan siempre, que son las que se depuran
+	if success && rand.Float64() >= sampleRate {
+		return
+	}
+
+	errorMessage := execution.Result.Error
+	if execution.Err != nil {
+		errorMessage = execution.Err.Error()
+	}
+	redactKeys := make(map[string]bool, len(config.RedactKeys))
+	for _, key := range config.RedactKeys {
+		redactKeys[strings.ToLower(key)] = true
+	}
+	captured := &domain.AgentExecution{
+		AgentID:    execution.AgentID,
+		AgentType:  execution.AgentType,
+		TaskID:     execution.Task.ID,
+		TaskType:   execution.Task.Type,
+		BotID:      botID,
+		SessionID:  logger.FieldValue(ctx, logger.SessionIDKey),
+		Input:      s.redactMap(execution.Task.Input, redactKeys),
+		Output:     s.redactMap(execution.Result.Output, redactKeys),
+		Success:    success,
+		Error:      s.redactString(errorMessage),
+		Duration:   execution.Duration.Milliseconds(),
+		CapturedAt: execution.StartedAt,
+	}
+
+	select {
+	case s.queue <- captured:
+	default:
+		s.logger.WithContext(ctx).Warn("Execution capture queue full, dropping execution", "bot_id", botID, "task_type", captured.TaskType)
+	}
+}
+
+// captureConfig devuelve la configuración de captura del bot, reutilizada
+// durante captureConfigTTL para no leer el bot en cada tarea
+func (s *executionCaptureService) captureConfig(ctx context.Context, botID string) *domain.ExecutionCaptureConfig {
+	s.configMu.Lock()
+	cached, ok := s.configs[botID]
+	s.configMu.Unlock()
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.config
+	}
+
+	var config *domain.ExecutionCaptureConfig
+	bot, err := s.botRepo.GetByID(ctx, botID)
+	if err == nil {
+		config = parseBotConfig(bot).ExecutionCapture
+	}
+
+	s.configMu.Lock()
+	s.configs[botID] = cachedCaptureConfig{config: config, expiresAt: time.Now().Add(captureConfigTTL)}
+	s.configMu.Unlock()
+	return config
+}
+
+func (s *executionCaptureService) redactMap(values map[string]interface{}, redactKeys map[string]bool) map[string]interface{} {
+	if values == nil {
+		return nil
+	}
+	redacted := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if isSensitiveCaptureKey(key, redactKeys) {
+			redacted[key] = redactedCaptureValue
+			continue
+		}
+		redacted[key] = s.redactValue(value, redactKeys)
+	}
+	return redacted
+}
+
+func (s *executionCaptureService) redactValue(value interface{}, redactKeys map[string]bool) interface{} {
+	switch typed := value.(type) {
+	case string:
+		return s.redactString(typed)
+	case map[string]interface{}:
+		return s.redactMap(typed, redactKeys)
+	case map[string]string:
+		converted := make(map[string]interface{}, len(typed))
+		for key, item := range typed {
+			converted[key] = item
+		}
+		return s.redactMap(converted, redactKeys)
+	case []interface{}:
+		redacted := make([]interface{}, len(typed))
+		for i, item := range typed {
+			redacted[i] = s.redactValue(item, redactKeys)
+		}
+		return redacted
+	case []string:
+		redacted := make([]interface{}, len(typed))
+		for i, item := range typed {
+			redacted[i] = s.redactString(item)
+		}
+		return redacted
+	case []byte:
+		return fmt.Sprintf("<%d bytes>", len(typed))
+	default:
+		return value
+	}
+}
+
+// redactString oculta emails y números largos (teléfonos, tarjetas, documentos)
+// y trunca el texto a MaxFieldLength
+func (s *executionCaptureService) redactString(value string) string {
+	value = captureEmailPattern.ReplaceAllString(value, "[email]")
+	value = captureNumberPattern.ReplaceAllString(value, "[number]")
+	if s.config.MaxFieldLength > 0 && len(value) > s.config.MaxFieldLength {
+		cut := s.config.MaxFieldLength
+		// No cortar a mitad de un carácter UTF-8
+		for cut > 0 && value[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		value = value[:cut] + "…"
+	}
+	return value
+}
+
+func isSensitiveCaptureKey(key string, redactKeys map[string]bool) bool {
+	lower := strings.ToLower(key)
+	if redactKeys[lower] {
+		return true
+	}
+	for _, sensitive := range sensitiveCaptureKeys {
+		if strings.Contains(lower, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *executionCaptureService) Search(ctx context.Context, filter domain.AgentExecutionFilter) ([]*domain.AgentExecution, int, error) {
+	return s.repo.Search(ctx, filter)
+}
+
+func (s *executionCaptureService) GetExecution(ctx context.Context, id string) (*domain.AgentExecution, error) {
+	execution, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrAgentExecutionNotFound, id)
+	}
+	return execution, nil
+}
+
+func (s *executionCaptureService) DeleteExpired(ctx context.Context) (int, error) {
+	if s.config.Retention <= 0 {
+		return 0, nil
+	}
+	return s.repo.DeleteBefore(ctx, time.Now().Add(-s.config.Retention))
+}
+
+func (s *executionCaptureService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return fmt.Errorf("execution capture already started")
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.done = make(chan struct{})
+	go s.run(ctx, s.done)
+
+	s.logger.Info("Execution capture started", "retention", s.config.Retention)
+	return nil
+}
+
+// Stop guarda las ejecuciones que quedan en la cola y detiene la limpieza
+func (s *executionCaptureService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+		<-s.done
+		s.cancel = nil
+	}
+}
+
+func (s *executionCaptureService) run(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	var cleanup <-chan time.Time
+	if s.config.CleanupInterval > 0 {
+		ticker := time.NewTicker(s.config.CleanupInterval)
+		defer ticker.Stop()
+		cleanup = ticker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case execution := <-s.queue:
+					s.store(execution)
+				default:
+					return
+				}
+			}
+		case execution := <-s.queue:
+			s.store(execution)
+		case <-cleanup:
+			deleted, err := s.DeleteExpired(ctx)
+			if err != nil {
+				s.logger.Error("Execution capture cleanup failed", "error", err)
+			} else if deleted > 0 {
+				s.logger.Info("Expired agent executions deleted", "count", deleted)
+			}
+		}
+	}
+}
+
+func (s *executionCaptureService) store(execution *domain.AgentExecution) {
+	if err := s.repo.Create(context.Background(), execution); err != nil {
+		s.logger.Error("Failed to store agent execution", "bot_id", execution.BotID, "task_id", execution.TaskID, "error", err)
+	}
+}
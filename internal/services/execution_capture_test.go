@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecutionCapture_SamplesAndRedactsPerBot(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Config: json.RawMessage(
+		`{"execution_capture":{"enabled":true,"sample_rate":1,"redact_keys":["customer_ref"]}}`)}))
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-2", Config: json.RawMessage(
+		`{"execution_capture":{"enabled":true,"sample_rate":0}}`)}))
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-3"}))
+
+	service := NewExecutionCaptureService(repositories.NewMockAgentExecutionRepository(0), botRepo, ExecutionCaptureConfig{
+		DefaultSampleRate: 1,
+		Retention:         time.Hour,
+		MaxFieldLength:    60,
+	}, log)
+	require.NoError(t, service.Start(ctx))
+
+	factory := mcp.WithExecutionCapture(mcp.NewAgentFactory(log))
+	factory.SetRecorder(service)
+	agent, err := factory.CreateAgent(mcp.MCPConfig{Type: "mock", Name: "mock"})
+	require.NoError(t, err)
+
+	botCtx := logger.ContextWithFields(ctx, logger.BotIDKey, "bot-1", logger.SessionIDKey, "session-1")
+	_, _ = agent.Execute(botCtx, mcp.Task{ID: "t1", Type: "ai_response", Input: map[string]interface{}{
+		"prompt":       "Mi email es ana@example.com y mi tarjeta 4111 1111 1111 1111",
+		"api_key":      "sk-123",
+		"customer_ref": "C-9",
+		"headers":      map[string]interface{}{"Authorization": "Bearer abc"},
+	}})
+
+	now := time.Now()
+	// Con sample_rate 0 sólo se guardan las fallidas
+	service.RecordExecution(logger.ContextWithFields(ctx, logger.BotIDKey, "bot-2"), mcp.AgentExecution{
+		Task: mcp.Task{ID: "t2", Type: "ai_response"}, Result: mcp.Result{Success: true}, StartedAt: now})
+	service.RecordExecution(ctx, mcp.AgentExecution{
+		Task: mcp.Task{ID: "t3", Type: "translation", Metadata: map[string]interface{}{"bot_id": "bot-2"}}, Err: errors.New("timeout"), StartedAt: now})
+	// Sin execution_capture no se guarda nada
+	service.RecordExecution(logger.ContextWithFields(ctx, logger.BotIDKey, "bot-3"), mcp.AgentExecution{
+		Task: mcp.Task{ID: "t4", Type: "ai_response"}, Err: errors.New("timeout"), StartedAt: now})
+	service.Stop()
+
+	executions, total, err := service.Search(ctx, domain.AgentExecutionFilter{TaskType: "ai_response"})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	captured := executions[0]
+	assert.Equal(t, "bot-1", captured.BotID)
+	assert.Equal(t, "session-1", captured.SessionID)
+	assert.Equal(t, "mock", captured.AgentType)
+	assert.Equal(t, "Mi email es [email] y mi tarjeta [number]", captured.Input["prompt"])
+	assert.Equal(t, "********", captured.Input["api_key"])
+	assert.Equal(t, "********", captured.Input["customer_ref"])
+	assert.Equal(t, "********", captured.Input["headers"].(map[string]interface{})["Authorization"])
+
+	failed := false
+	executions, total, err = service.Search(ctx, domain.AgentExecutionFilter{BotID: "bot-2", Success: &failed})
+	require.NoError(t, err)
+	require.Equal(t, 1, total)
+	assert.Equal(t, "translation", executions[0].TaskType)
+	assert.Equal(t, "timeout", executions[0].Error)
+
+	_, total, err = service.Search(ctx, domain.AgentExecutionFilter{BotID: "bot-3"})
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	got, err := service.GetExecution(ctx, captured.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "t1", got.TaskID)
+	_, err = service.GetExecution(ctx, "missing")
+	assert.ErrorIs(t, err, ErrAgentExecutionNotFound)
+}
@@ -0,0 +1,683 @@
+package services
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/clock"
+)
+
+// ExpressionFunc es una función built-in disponible en las expresiones condicionales
+type ExpressionFunc func(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error)
+
+// expressionEngine evalúa expresiones condicionales sobre un input.
+//
+// Soporta llamadas a funciones (gt({{edad}}, 18)), comparaciones numéricas
+// ({{edad}} >= 18), combinaciones con && y ||, agrupación con paréntesis
+// ((a || b) && c) y los operadores clásicos ==, !=, contains y regex.
+type expressionEngine struct {
+	functions map[string]ExpressionFunc
+	now       func() time.Time
+}
+
+var functionCallPattern = regexp.MustCompile(`^([a-zA-Z_][a-zA-Z0-9_]*)\s*\((.*)\)$`)
+
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*[a-zA-Z0-9_.]+\s*\}\}`)
+
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"02/01/2006",
+}
+
+// newExpressionEngine crea el motor; clk fija "now" y "today" en las
+// condiciones de fecha y hora (nil usa el reloj real)
+func newExpressionEngine(clk clock.Clock) *expressionEngine {
+	e := &expressionEngine{
+		functions: make(map[string]ExpressionFunc),
+		now:       clock.OrReal(clk).Now,
+	}
+
+	// Comparaciones numéricas
+	e.functions["gt"] = numericCompare(func(a, b float64) bool { return a > b })
+	e.functions["gte"] = numericCompare(func(a, b float64) bool { return a >= b })
+	e.functions["lt"] = numericCompare(func(a, b float64) bool { return a < b })
+	e.functions["lte"] = numericCompare(func(a, b float64) bool { return a <= b })
+	e.functions["eq"] = numericCompare(func(a, b float64) bool { return a == b })
+	e.functions["between"] = fnBetween
+	e.functions["is_number"] = fnIsNumber
+
+	// Fechas
+	e.functions["date_before"] = fnDateBefore
+	e.functions["date_after"] = fnDateAfter
+	e.functions["date_between"] = fnDateBetween
+	e.functions["within_days"] = fnWithinDays
+	e.functions["time_between"] = fnTimeBetween
+	e.functions["weekday_in"] = fnWeekdayIn
+
+	// Canal y texto
+	e.functions["channel_is"] = fnChannelIs
+	e.functions["matches"] = fnMatches
+	e.functions["contains"] = fnContains
+	e.functions["equals"] = fnEquals
+	e.functions["empty"] = fnEmpty
+
+	return e
+}
+
+// NewConditionEvaluator devuelve un motor de expresiones para los pasos
+// condition y branch de los agentes workflow
+func NewConditionEvaluator() mcp.ConditionEvaluator {
+	return newExpressionEngine(nil)
+}
+
+// RegisterFunction registra una función adicional en el motor
+func (e *expressionEngine) RegisterFunction(name string, fn ExpressionFunc) {
+	e.functions[strings.ToLower(name)] = fn
+}
+
+// Evaluate evalúa la expresión contra el input
+func (e *expressionEngine) Evaluate(expression string, input map[string]interface{}) (bool, error) {
+	return e.evaluate(expression, input, nil)
+}
+
+// EvaluateWithTrace evalúa la expresión y devuelve el árbol de evaluación
+func (e *expressionEngine) EvaluateWithTrace(expression string, input map[string]interface{}) (bool, *domain.ConditionTrace, error) {
+	trace := &domain.ConditionTrace{}
+	result, err := e.evaluate(expression, input, trace)
+	return result, trace, err
+}
+
+func (e *expressionEngine) evaluate(expression string, input map[string]interface{}, trace *domain.ConditionTrace) (result bool, err error) {
+	expression = strings.TrimSpace(expression)
+	if trace != nil {
+		trace.Expression = expression
+		defer func() {
+			trace.Result = result
+			if err != nil {
+				trace.Error = err.Error()
+			}
+		}()
+	}
+
+	if expression == "" {
+		return false, nil
+	}
+	if err := checkParentheses(expression); err != nil {
+		return false, err
+	}
+
+	// Operadores lógicos: || tiene menor precedencia que &&
+	if parts := splitTopLevel(expression, "||"); len(parts) > 1 {
+		setTraceKind(trace, "or")
+		for _, part := range parts {
+			ok, err := e.evaluate(part, input, childTrace(trace))
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	if parts := splitTopLevel(expression, "&&"); len(parts) > 1 {
+		setTraceKind(trace, "and")
+		for _, part := range parts {
+			ok, err := e.evaluate(part, input, childTrace(trace))
+			if err != nil {
+				return false, err
+			}
+			if !ok {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	if strings.HasPrefix(expression, "!") && !strings.HasPrefix(expression, "!=") {
+		setTraceKind(trace, "not")
+		ok, err := e.evaluate(expression[1:], input, childTrace(trace))
+		return !ok, err
+	}
+
+	if inner, ok := groupedExpression(expression); ok {
+		setTraceKind(trace, "group")
+		return e.evaluate(inner, input, childTrace(trace))
+	}
+
+	if matches := functionCallPattern.FindStringSubmatch(expression); matches != nil {
+		if _, isFunc := e.functions[strings.ToLower(matches[1])]; isFunc {
+			return e.callFunction(matches[1], matches[2], input, trace)
+		}
+	}
+
+	return e.evaluateOperators(expression, input, trace)
+}
+
+func (e *expressionEngine) callFunction(name, rawArgs string, input map[string]interface{}, trace *domain.ConditionTrace) (bool, error) {
+	fn := e.functions[strings.ToLower(name)]
+
+	var args []interface{}
+	for _, raw := range splitTopLevel(rawArgs, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		args = append(args, e.resolveValue(raw, input))
+	}
+
+	if trace != nil {
+		trace.Kind = "function"
+		trace.Operator = strings.ToLower(name)
+		trace.Values = args
+	}
+
+	result, err := fn(e, args, input)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", name, err)
+	}
+	return result, nil
+}
+
+// evaluateOperators evalúa expresiones binarias simples
+func (e *expressionEngine) evaluateOperators(expression string, input map[string]interface{}, trace *domain.ConditionTrace) (bool, error) {
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		parts := splitTopLevel(expression, op)
+		if len(parts) != 2 {
+			continue
+		}
+		left, lok := toFloat(e.resolveValue(strings.TrimSpace(parts[0]), input))
+		right, rok := toFloat(e.resolveValue(strings.TrimSpace(parts[1]), input))
+		if !lok || !rok {
+			// No es una comparación numérica, seguir con los operadores de texto
+			break
+		}
+		if trace != nil {
+			trace.Kind = "comparison"
+			trace.Operator = op
+			trace.Values = []interface{}{left, right}
+		}
+		switch op {
+		case ">=":
+			return left >= right, nil
+		case "<=":
+			return left <= right, nil
+		case ">":
+			return left > right, nil
+		default:
+			return left < right, nil
+		}
+	}
+
+	// Los operandos se resuelven después de dividir la expresión, para que el
+	// valor de una variable no pueda aportar operadores propios
+	if trace != nil {
+		trace.Kind = "operator"
+		trace.Resolved = replaceTemplateVariables(expression, input)
+	}
+
+	for _, op := range []string{"==", "!=", "contains", "regex"} {
+		parts := splitTopLevel(expression, op)
+		if len(parts) != 2 {
+			continue
+		}
+
+		left := e.resolveOperand(parts[0], input)
+		right := e.resolveOperand(parts[1], input)
+		if trace != nil {
+			trace.Operator = op
+			trace.Values = []interface{}{left, right}
+		}
+
+		switch op {
+		case "==":
+			return left == right, nil
+		case "!=":
+			return left != right, nil
+		case "contains":
+			// Formato: "text contains keyword"
+			return strings.Contains(strings.ToLower(left), strings.ToLower(right)), nil
+		default:
+			// Formato: "text regex pattern"
+			return regexp.MatchString(right, left)
+		}
+	}
+
+	// Evaluación booleana simple
+	if trace != nil {
+		trace.Kind = "literal"
+	}
+	switch strings.ToLower(e.resolveOperand(expression, input)) {
+	case "true", "1", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func setTraceKind(trace *domain.ConditionTrace, kind string) {
+	if trace != nil {
+		trace.Kind = kind
+	}
+}
+
+// childTrace agrega un nodo hijo al trace, o devuelve nil si no se está trazando
+func childTrace(trace *domain.ConditionTrace) *domain.ConditionTrace {
+	if trace == nil {
+		return nil
+	}
+	child := &domain.ConditionTrace{}
+	trace.Children = append(trace.Children, child)
+	return child
+}
+
+// resolveValue convierte un argumento en su valor: literal entre comillas,
+// número, variable {{nombre}} o identificador presente en el input
+func (e *expressionEngine) resolveValue(raw string, input map[string]interface{}) interface{} {
+	if len(raw) >= 2 && (raw[0] == '"' || raw[0] == '\'') && raw[len(raw)-1] == raw[0] {
+		return raw[1 : len(raw)-1]
+	}
+
+	if strings.HasPrefix(raw, "{{") && strings.HasSuffix(raw, "}}") {
+		return lookupVariable(strings.TrimSpace(raw[2:len(raw)-2]), input)
+	}
+
+	if f, err := strconv.ParseFloat(raw, 64); err == nil {
+		return f
+	}
+
+	if value := lookupVariable(raw, input); value != nil {
+		return value
+	}
+
+	return raw
+}
+
+// resolveOperand resuelve un operando de texto con resolveValue; el texto
+// suelto que mezcla palabras y {{variables}} se completa con sus valores
+func (e *expressionEngine) resolveOperand(raw string, input map[string]interface{}) string {
+	raw = strings.TrimSpace(raw)
+	value := e.resolveValue(raw, input)
+	if text, ok := value.(string); ok && text == raw {
+		return replaceTemplateVariables(raw, input)
+	}
+	return toString(value)
+}
+
+// lookupVariable busca una variable en el input, soportando rutas con puntos
+func lookupVariable(path string, input map[string]interface{}) interface{} {
+	if value, exists := input[path]; exists {
+		return value
+	}
+
+	var current interface{} = input
+	for _, key := range strings.Split(path, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current, ok = m[key]
+		if !ok {
+			return nil
+		}
+	}
+	return current
+}
+
+// replaceTemplateVariables reemplaza {{variable}} con el valor del input
+func replaceTemplateVariables(expression string, input map[string]interface{}) string {
+	return templateVariablePattern.ReplaceAllStringFunc(expression, func(match string) string {
+		value := lookupVariable(strings.TrimSpace(match[2:len(match)-2]), input)
+		if value == nil {
+			return match
+		}
+		return fmt.Sprintf("%v", value)
+	})
+}
+
+// splitTopLevel divide la expresión por el separador ignorando comillas y paréntesis
+func splitTopLevel(expression, sep string) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(' || c == '{':
+			depth++
+		case c == ')' || c == '}':
+			depth--
+		case depth == 0 && strings.HasPrefix(expression[i:], sep):
+			parts = append(parts, expression[start:i])
+			i += len(sep) - 1
+			start = i + 1
+		}
+	}
+
+	return append(parts, expression[start:])
+}
+
+// checkParentheses comprueba que los paréntesis fuera de comillas estén equilibrados
+func checkParentheses(expression string) error {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth < 0 {
+				return fmt.Errorf("unbalanced parentheses in expression: %s", expression)
+			}
+		}
+	}
+	if depth != 0 {
+		return fmt.Errorf("unbalanced parentheses in expression: %s", expression)
+	}
+	return nil
+}
+
+// groupedExpression devuelve el contenido de una expresión envuelta por
+// completo en paréntesis, como (a || b); en "(a) || (b)" no lo está
+func groupedExpression(expression string) (string, bool) {
+	if len(expression) < 2 || expression[0] != '(' || expression[len(expression)-1] != ')' {
+		return "", false
+	}
+	depth := 0
+	var quote byte
+	for i := 0; i < len(expression); i++ {
+		c := expression[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+			if depth == 0 && i < len(expression)-1 {
+				return "", false
+			}
+		}
+	}
+	return expression[1 : len(expression)-1], true
+}
+
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
+func (e *expressionEngine) toTime(value interface{}) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "now":
+			return e.now(), true
+		case "today":
+			now := e.now()
+			return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()), true
+		}
+		for _, layout := range dateLayouts {
+			if t, err := time.ParseInLocation(layout, strings.TrimSpace(v), e.now().Location()); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+func toString(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return s
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func expectArgs(args []interface{}, min int) error {
+	if len(args) < min {
+		return fmt.Errorf("expected at least %d arguments, got %d", min, len(args))
+	}
+	return nil
+}
+
+func numericCompare(cmp func(a, b float64) bool) ExpressionFunc {
+	return func(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+		if err := expectArgs(args, 2); err != nil {
+			return false, err
+		}
+		a, aok := toFloat(args[0])
+		b, bok := toFloat(args[1])
+		if !aok || !bok {
+			return false, nil
+		}
+		return cmp(a, b), nil
+	}
+}
+
+func fnBetween(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 3); err != nil {
+		return false, err
+	}
+	value, ok1 := toFloat(args[0])
+	min, ok2 := toFloat(args[1])
+	max, ok3 := toFloat(args[2])
+	if !ok1 || !ok2 || !ok3 {
+		return false, nil
+	}
+	return value >= min && value <= max, nil
+}
+
+func fnIsNumber(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return false, err
+	}
+	value, ok := toFloat(args[0])
+	return ok && !math.IsNaN(value), nil
+}
+
+func fnDateBefore(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return false, err
+	}
+	date, ok1 := e.toTime(args[0])
+	ref, ok2 := e.toTime(args[1])
+	if !ok1 || !ok2 {
+		return false, nil
+	}
+	return date.Before(ref), nil
+}
+
+func fnDateAfter(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return false, err
+	}
+	date, ok1 := e.toTime(args[0])
+	ref, ok2 := e.toTime(args[1])
+	if !ok1 || !ok2 {
+		return false, nil
+	}
+	return date.After(ref), nil
+}
+
+func fnDateBetween(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 3); err != nil {
+		return false, err
+	}
+	date, ok1 := e.toTime(args[0])
+	from, ok2 := e.toTime(args[1])
+	to, ok3 := e.toTime(args[2])
+	if !ok1 || !ok2 || !ok3 {
+		return false, nil
+	}
+	return !date.Before(from) && !date.After(to), nil
+}
+
+// fnWithinDays verifica que la fecha esté a no más de N días de ahora
+func fnWithinDays(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return false, err
+	}
+	date, ok1 := e.toTime(args[0])
+	days, ok2 := toFloat(args[1])
+	if !ok1 || !ok2 {
+		return false, nil
+	}
+	diff := e.now().Sub(date)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= time.Duration(days*24)*time.Hour, nil
+}
+
+// fnTimeBetween verifica que la hora actual esté en la ventana HH:MM, soportando ventanas nocturnas
+func fnTimeBetween(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return false, err
+	}
+	from, err := time.Parse("15:04", toString(args[0]))
+	if err != nil {
+		return false, fmt.Errorf("invalid start time: %w", err)
+	}
+	to, err := time.Parse("15:04", toString(args[1]))
+	if err != nil {
+		return false, fmt.Errorf("invalid end time: %w", err)
+	}
+
+	now := e.now()
+	current := now.Hour()*60 + now.Minute()
+	start := from.Hour()*60 + from.Minute()
+	end := to.Hour()*60 + to.Minute()
+
+	if start <= end {
+		return current >= start && current <= end, nil
+	}
+	return current >= start || current <= end, nil
+}
+
+func fnWeekdayIn(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return false, err
+	}
+	today := strings.ToLower(e.now().Weekday().String())
+	for _, arg := range args {
+		day := strings.ToLower(toString(arg))
+		if day == today || (len(day) == 3 && strings.HasPrefix(today, day)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fnChannelIs compara el canal del mensaje con cualquiera de los canales indicados
+func fnChannelIs(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 1); err != nil {
+		return false, err
+	}
+	channel := strings.ToLower(toString(input["channel"]))
+	for _, arg := range args {
+		if strings.ToLower(toString(arg)) == channel {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func fnMatches(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return false, err
+	}
+	re, err := regexp.Compile(toString(args[1]))
+	if err != nil {
+		return false, fmt.Errorf("invalid pattern: %w", err)
+	}
+	return re.MatchString(toString(args[0])), nil
+}
+
+func fnContains(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return false, err
+	}
+	text := strings.ToLower(toString(args[0]))
+	for _, keyword := range args[1:] {
+		if strings.Contains(text, strings.ToLower(toString(keyword))) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func fnEquals(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if err := expectArgs(args, 2); err != nil {
+		return false, err
+	}
+	return strings.EqualFold(strings.TrimSpace(toString(args[0])), strings.TrimSpace(toString(args[1]))), nil
+}
+
+func fnEmpty(e *expressionEngine, args []interface{}, input map[string]interface{}) (bool, error) {
+	if len(args) == 0 {
+		return true, nil
+	}
+	return strings.TrimSpace(toString(args[0])) == "", nil
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressionEngine_Evaluate(t *testing.T) {
+	engine := newExpressionEngine(nil)
+	engine.now = func() time.Time {
+		return time.Date(2024, 6, 12, 10, 30, 0, 0, time.UTC) // miércoles
+	}
+
+	input := map[string]interface{}{
+		"age":     30,
+		"amount":  "150.5",
+		"channel": "whatsapp",
+		"input":   "My order is ABC-1234",
+		"date":    "2024-06-10",
+		"user":    map[string]interface{}{"tier": "gold"},
+	}
+
+	cases := []struct {
+		expression string
+		expected   bool
+	}{
+		{"gt({{age}}, 18)", true},
+		{"lte(amount, 100)", false},
+		{"between({{age}}, 18, 65)", true},
+		{"{{age}} >= 30", true},
+		{"{{amount}} < 100", false},
+		{"date_before({{date}}, 'today')", true},
+		{"date_between(date, '2024-06-01', '2024-06-30')", true},
+		{"within_days(date, 1)", false},
+		{"time_between('09:00', '18:00')", true},
+		{"time_between('22:00', '06:00')", false},
+		{"weekday_in('mon', 'wed')", true},
+		{"channel_is('web', 'whatsapp')", true},
+		{"channel_is('telegram')", false},
+		{"matches({{input}}, '[A-Z]{3}-\\d{4}')", true},
+		{"{{user.tier}} == gold", true},
+		{"channel_is('whatsapp') && gt(age, 40)", false},
+		{"channel_is('web') || gt(age, 20)", true},
+		{"!empty(input)", true},
+		{"{{channel}} != web", true},
+		{"{{input}} contains order", true},
+		{"(channel_is('web') || gt(age, 20)) && {{user.tier}} == gold", true},
+		{"(channel_is('web') || gt(age, 40)) && gt(age, 18)", false},
+		{"channel_is('web') || (gt(age, 20) && {{channel}} == whatsapp)", true},
+		{"!(channel_is('web') || lt(age, 18))", true},
+		{"((gt(age, 18)))", true},
+		{"contains({{input}}, '(') || (gt(age, 18))", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expression, func(t *testing.T) {
+			result, err := engine.Evaluate(tc.expression, input)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestExpressionEngine_InvalidArguments(t *testing.T) {
+	engine := newExpressionEngine(nil)
+
+	_, err := engine.Evaluate("between(1, 2)", nil)
+	assert.Error(t, err)
+
+	_, err = engine.Evaluate("matches('abc', '[')", nil)
+	assert.Error(t, err)
+
+	// Los paréntesis sin cerrar son un error, no un false silencioso
+	_, err = engine.Evaluate("(gt(age, 18) || lt(age, 5)", map[string]interface{}{"age": 30})
+	assert.ErrorContains(t, err, "unbalanced parentheses")
+	_, err = engine.Evaluate("gt(age, 18)) && true", map[string]interface{}{"age": 30})
+	assert.ErrorContains(t, err, "unbalanced parentheses")
+}
+
+func TestExpressionEngine_OperatorsInVariableValues(t *testing.T) {
+	engine := newExpressionEngine(nil)
+
+	// Un operador dentro del mensaje del usuario no cambia la condición
+	cases := []struct {
+		expression string
+		message    string
+		expected   bool
+	}{
+		{"{{message}} != cancel", "cancel == cancel", true},
+		{"{{message}} == cancel", "cancel == cancel", false},
+		{"{{message}} == confirmed", "x != y", false},
+		{"{{message}} contains urgent", "urgent contains nothing", true},
+		{"{{message}} regex ^hi$", "hi regex .*", false},
+		{"{{message}} == 'a && b'", "a && b", true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.expression+"/"+tc.message, func(t *testing.T) {
+			result, err := engine.Evaluate(tc.expression, map[string]interface{}{"message": tc.message})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestExpressionEngine_EvaluateWithTrace(t *testing.T) {
+	engine := newExpressionEngine(nil)
+
+	result, trace, err := engine.EvaluateWithTrace("channel_is('web') || gt({{age}}, 18)", map[string]interface{}{
+		"channel": "whatsapp",
+		"age":     21,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, result)
+	assert.Equal(t, "or", trace.Kind)
+	assert.Len(t, trace.Children, 2)
+	assert.False(t, trace.Children[0].Result)
+	assert.Equal(t, "gt", trace.Children[1].Operator)
+	assert.Equal(t, []interface{}{21, 18.0}, trace.Children[1].Values)
+}
+
+func TestExpressionEngine_TraceGroups(t *testing.T) {
+	engine := newExpressionEngine(nil)
+
+	result, trace, err := engine.EvaluateWithTrace("(channel_is('web') || gt(age, 18)) && lt(age, 65)", map[string]interface{}{
+		"channel": "whatsapp",
+		"age":     21,
+	})
+
+	assert.NoError(t, err)
+	assert.True(t, result)
+	assert.Equal(t, "and", trace.Kind)
+	assert.Len(t, trace.Children, 2)
+	group := trace.Children[0]
+	assert.Equal(t, "group", group.Kind)
+	assert.Len(t, group.Children, 1)
+	assert.Equal(t, "or", group.Children[0].Kind)
+	assert.True(t, group.Result)
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	// faqMatchThreshold es la similitud mínima para responder desde la FAQ
+	faqMatchThreshold = 0.6
+	// faqClusterThreshold es la similitud por defecto para agrupar preguntas sin respuesta
+	faqClusterThreshold = 0.5
+)
+
+// faqStopwords son palabras sin valor semántico que no participan en el embedding
+var faqStopwords = map[string]bool{
+	"el": true, "la": true, "los": true, "las": true, "un": true, "una": true, "de": true, "del": true,
+	"que": true, "y": true, "o": true, "en": true, "a": true, "al": true, "por": true, "para": true,
+	"con": true, "se": true, "me": true, "mi": true, "es": true, "lo": true, "su": true,
+	"the": true, "an": true, "of": true, "to": true, "and": true, "or": true, "in": true, "on": true,
+	"for": true, "is": true, "are": true, "my": true, "i": true, "it": true, "do": true, "does": true,
+}
+
+// FAQService define las operaciones de la FAQ de un bot
+type FAQService interface {
+	GetEntry(ctx context.Context, id string) (*domain.FAQEntry, error)
+	GetEntries(ctx context.Context, botID string) ([]*domain.FAQEntry, error)
+	ImportEntries(ctx context.Context, botID string, entries []*domain.FAQEntry) error
+	UpdateEntry(ctx context.Context, entry *domain.FAQEntry) error
+	DeleteEntry(ctx context.Context, id string) error
+
+	// Answer devuelve la entrada que responde la pregunta, o nil si ninguna supera el umbral
+	Answer(ctx context.Context, botID, question string) (*domain.FAQMatch, error)
+
+	RecordUnanswered(ctx context.Context, botID, userID, question string) error
+	ClusterUnanswered(ctx context.Context, botID string, threshold float64) (*domain.FAQClusterReport, error)
+	ClearUnanswered(ctx context.Context, botID string) (int, error)
+}
+
+type faqService struct {
+	faqRepo        domain.FAQRepository
+	unansweredRepo domain.UnansweredQuestionRepository
+	logger         logger.Logger
+}
+
+// NewFAQService crea una nueva instancia de FAQService
+func NewFAQService(faqRepo domain.FAQRepository, unansweredRepo domain.UnansweredQuestionRepository, logger logger.Logger) FAQService {
+	return &faqService{
+		faqRepo:        faqRepo,
+		unansweredRepo: unansweredRepo,
+		logger:         logger,
+	}
+}
+
+func (s *faqService) GetEntry(ctx context.Context, id string) (*domain.FAQEntry, error) {
+	return s.faqRepo.GetByID(ctx, id)
+}
+
+func (s *faqService) GetEntries(ctx context.Context, botID string) ([]*domain.FAQEntry, error) {
+	return s.faqRepo.GetByBotID(ctx, botID)
+}
+
+func (s *faqService) ImportEntries(ctx context.Context, botID string, entries []*domain.FAQEntry) error {
+	now := time.Now()
+	for _, entry := range entries {
+		if strings.TrimSpace(entry.Question) == "" || strings.TrimSpace(entry.Answer) == "" {
+			return fmt.Errorf("faq entries require question and answer")
+		}
+
+		entry.BotID = botID
+		entry.CreatedAt = now
+		entry.UpdatedAt = now
+		if err := s.faqRepo.Create(ctx, entry); err != nil {
+			return fmt.Errorf("failed to create faq entry: %w", err)
+		}
+	}
+
+	s.logger.WithContext(ctx).Info("FAQ entries imported", "bot_id", botID, "count", len(entries))
+	return nil
+}
+
+func (s *faqService) UpdateEntry(ctx context.Context, entry *domain.FAQEntry) error {
+	entry.UpdatedAt = time.Now()
+	return s.faqRepo.Update(ctx, entry)
+}
+
+func (s *faqService) DeleteEntry(ctx context.Context, id string) error {
+	return s.faqRepo.Delete(ctx, id)
+}
+
+func (s *faqService) Answer(ctx context.Context, botID, question string) (*domain.FAQMatch, error) {
+	best, err := s.bestMatch(ctx, botID, question)
+	if err != nil || best == nil || best.Score < faqMatchThreshold {
+		return nil, err
+	}
+
+	best.Entry.Hits++
+	if err := s.faqRepo.Update(ctx, best.Entry); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to update faq hits", "faq_id", best.Entry.ID, "error", err)
+	}
+
+	return best, nil
+}
+
+// bestMatch devuelve la entrada más similar a la pregunta, sin aplicar umbral
+func (s *faqService) bestMatch(ctx context.Context, botID, question string) (*domain.FAQMatch, error) {
+	entries, err := s.faqRepo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get faq entries: %w", err)
+	}
+
+	query := embedText(question)
+	if len(query) == 0 {
+		return nil, nil
+	}
+
+	var best *domain.FAQMatch
+	for _, entry := range entries {
+		for _, candidate := range append([]string{entry.Question}, entry.Alternatives...) {
+			score := cosineSimilarity(query, embedText(candidate))
+			if best == nil || score > best.Score {
+				best = &domain.FAQMatch{Entry: entry, Score: score, Matched: candidate}
+			}
+		}
+	}
+
+	return best, nil
+}
+
+func (s *faqService) RecordUnanswered(ctx context.Context, botID, userID, question string) error {
+	if len(embedText(question)) == 0 {
+		return nil
+	}
+
+	var bestScore float64
+	if best, err := s.bestMatch(ctx, botID, question); err == nil && best != nil {
+		bestScore = best.Score
+	}
+
+	return s.unansweredRepo.Create(ctx, &domain.UnansweredQuestion{
+		BotID:     botID,
+		UserID:    userID,
+		Question:  question,
+		BestScore: bestScore,
+		CreatedAt: time.Now(),
+	})
+}
+
+// ClusterUnanswered agrupa las preguntas sin respuesta por similitud, de mayor a menor frecuencia
+func (s *faqService) ClusterUnanswered(ctx context.Context, botID string, threshold float64) (*domain.FAQClusterReport, error) {
+	if threshold <= 0 || threshold > 1 {
+		threshold = faqClusterThreshold
+	}
+
+	questions, err := s.unansweredRepo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get unanswered questions: %w", err)
+	}
+
+	type cluster struct {
+		centroid map[string]float64
+		vectors  []map[string]float64
+		items    []*domain.UnansweredQuestion
+	}
+
+	var clusters []*cluster
+	for _, question := range questions {
+		vector := embedText(question.Question)
+		if len(vector) == 0 {
+			continue
+		}
+
+		var target *cluster
+		bestScore := threshold
+		for _, c := range clusters {
+			if score := cosineSimilarity(vector, c.centroid); score >= bestScore {
+				target = c
+				bestScore = score
+			}
+		}
+
+		if target == nil {
+			target = &cluster{}
+			clusters = append(clusters, target)
+		}
+		target.vectors = append(target.vectors, vector)
+		target.items = append(target.items, question)
+		target.centroid = centroid(target.vectors)
+	}
+
+	report := &domain.FAQClusterReport{
+		BotID:           botID,
+		TotalUnanswered: len(questions),
+		Threshold:       threshold,
+		Clusters:        make([]domain.FAQCluster, 0, len(clusters)),
+	}
+
+	for _, c := range clusters {
+		result := domain.FAQCluster{
+			Count:     len(c.items),
+			FirstSeen: c.items[0].CreatedAt,
+			LastSeen:  c.items[len(c.items)-1].CreatedAt,
+		}
+
+		// El representante es la pregunta más cercana al centroide
+		bestScore := -1.0
+		seen := make(map[string]bool)
+		for i, item := range c.items {
+			if score := cosineSimilarity(c.vectors[i], c.centroid); score > bestScore {
+				bestScore = score
+				result.Representative = item.Question
+			}
+			if key := normalizeText(item.Question); !seen[key] {
+				seen[key] = true
+				result.Questions = append(result.Questions, item.Question)
+			}
+		}
+
+		report.Clusters = append(report.Clusters, result)
+	}
+
+	sort.SliceStable(report.Clusters, func(i, j int) bool {
+		return report.Clusters[i].Count > report.Clusters[j].Count
+	})
+
+	return report, nil
+}
+
+func (s *faqService) ClearUnanswered(ctx context.Context, botID string) (int, error) {
+	return s.unansweredRepo.DeleteByBotID(ctx, botID)
+}
+
+// embedText genera un embedding léxico disperso: palabras relevantes más
+// trigramas de caracteres, para tolerar variaciones y errores de escritura
+func embedText(text string) map[string]float64 {
+	vector := make(map[string]float64)
+
+	for _, word := range tokenize(normalizeText(text)) {
+		if faqStopwords[word] {
+			continue
+		}
+		vector["w:"+word] += 1
+
+		padded := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			vector["t:"+string(padded[i:i+3])] += 0.25
+		}
+	}
+
+	return vector
+}
+
+func cosineSimilarity(a, b map[string]float64) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for key, value := range a {
+		normA += value * value
+		dot += value * b[key]
+	}
+	for _, value := range b {
+		normB += value * value
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+func centroid(vectors []map[string]float64) map[string]float64 {
+	result := make(map[string]float64)
+	for _, vector := range vectors {
+		for key, value := range vector {
+			result[key] += value / float64(len(vectors))
+		}
+	}
+	return result
+}
@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFAQService_AnswerAndCluster(t *testing.T) {
+	ctx := context.Background()
+	svc := NewFAQService(repositories.NewMockFAQRepository(), repositories.NewMockUnansweredQuestionRepository(), logger.NewLogger("error"))
+
+	require.NoError(t, svc.ImportEntries(ctx, "bot-1", []*domain.FAQEntry{
+		{Question: "¿Cuál es el horario de atención?", Alternatives: []string{"a qué hora abren"}, Answer: "De 9 a 18 hs."},
+		{Question: "¿Cómo cambio mi contraseña?", Answer: "Desde Configuración > Seguridad."},
+	}))
+
+	match, err := svc.Answer(ctx, "bot-1", "cual es el horario de atencion")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "De 9 a 18 hs.", match.Entry.Answer)
+
+	match, err = svc.Answer(ctx, "bot-1", "como cambio la contrasena")
+	require.NoError(t, err)
+	require.NotNil(t, match)
+	assert.Equal(t, "Desde Configuración > Seguridad.", match.Entry.Answer)
+
+	match, err = svc.Answer(ctx, "bot-1", "hacen envíos internacionales")
+	require.NoError(t, err)
+	assert.Nil(t, match)
+
+	for _, question := range []string{
+		"¿Hacen envíos internacionales?",
+		"hacen envios internacionales a chile",
+		"¿Aceptan pagos con bitcoin?",
+		"Envíos internacionales?",
+	} {
+		require.NoError(t, svc.RecordUnanswered(ctx, "bot-1", "user-1", question))
+	}
+
+	report, err := svc.ClusterUnanswered(ctx, "bot-1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 4, report.TotalUnanswered)
+	require.Len(t, report.Clusters, 2)
+	assert.Equal(t, 3, report.Clusters[0].Count)
+	assert.Equal(t, 1, report.Clusters[1].Count)
+}
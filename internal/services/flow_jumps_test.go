@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jumpFixture es un bot con el flujo main por defecto y el flujo billing
+type jumpFixture struct {
+	bots          BotService
+	conversations ConversationService
+	flows         domain.BotFlowRepository
+	steps         domain.BotStepRepository
+}
+
+func newJumpFixture(t *testing.T, bus events.EventBus) *jumpFixture {
+	t.Helper()
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, bus, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "start", IsDefault: true}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "billing", BotID: "bot-1", EntryPoint: "bill-start"}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "bill-start", FlowID: "billing", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Billing here"}`)}))
+
+	return &jumpFixture{bots: bots, conversations: conversations, flows: flowRepo, steps: stepRepo}
+}
+
+func (f *jumpFixture) step(t *testing.T, step *domain.BotStep) {
+	t.Helper()
+	require.NoError(t, f.steps.Create(context.Background(), step))
+}
+
+func (f *jumpFixture) send(text string) (*domain.BotResponse, error) {
+	return f.bots.ProcessIncomingMessage(context.Background(), &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: text})
+}
+
+func TestJumpStep_ByNameAndID(t *testing.T) {
+	f := newJumpFixture(t, nil)
+	byID := "by-id"
+	f.step(t, &domain.BotStep{ID: "start", FlowID: "main", Type: domain.StepTypeJump, Content: json.RawMessage(`{"step":"Greeting"}`)})
+	f.step(t, &domain.BotStep{ID: "greet", FlowID: "main", Name: "Greeting", Type: domain.StepTypeMessage, NextStepID: &byID,
+		Content: json.RawMessage(`{"text":"Hello"}`)})
+	f.step(t, &domain.BotStep{ID: "by-id", FlowID: "main", Type: domain.StepTypeJump, Content: json.RawMessage(`{"step":"bye"}`)})
+	f.step(t, &domain.BotStep{ID: "bye", FlowID: "main", Name: "Farewell", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"Bye"}`)})
+
+	// Por nombre
+	response, err := f.send("hi")
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", response.Content)
+
+	// Por ID
+	response, err = f.send("ok")
+	require.NoError(t, err)
+	assert.Equal(t, "Bye", response.Content)
+
+	f.step(t, &domain.BotStep{ID: "broken", FlowID: "main", Type: domain.StepTypeJump, Content: json.RawMessage(`{"step":"missing"}`)})
+	require.NoError(t, f.flows.Update(context.Background(), &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "broken", IsDefault: true}))
+	_, err = f.send("again")
+	assert.Error(t, err)
+}
+
+func TestSwitchFlowStep_GoesToEntryPoint(t *testing.T) {
+	f := newJumpFixture(t, nil)
+	f.step(t, &domain.BotStep{ID: "start", FlowID: "main", Type: domain.StepTypeSwitch, Content: json.RawMessage(`{"flow_id":"billing"}`)})
+
+	response, err := f.send("invoice")
+	require.NoError(t, err)
+	assert.Equal(t, "Billing here", response.Content)
+
+	session, err := f.conversations.GetSession(context.Background(), "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "billing", session.CurrentFlowID)
+}
+
+func TestJumpStep_HopLimit(t *testing.T) {
+	f := newJumpFixture(t, nil)
+	f.step(t, &domain.BotStep{ID: "start", FlowID: "main", Type: domain.StepTypeJump, Content: json.RawMessage(`{"step":"loop"}`)})
+	f.step(t, &domain.BotStep{ID: "loop", FlowID: "main", Type: domain.StepTypeJump, Content: json.RawMessage(`{"step":"start"}`)})
+
+	_, err := f.send("hi")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many chained jumps (max 10)")
+}
+
+func TestEndStep_PublishesEventAndDeletesSession(t *testing.T) {
+	bus := events.NewInMemoryEventBus(logger.NewLogger("error"))
+	ended := make(chan events.Event, 1)
+	require.NoError(t, bus.Subscribe(events.EventTypeConversationEnded, func(ctx context.Context, event events.Event) error {
+		ended <- event
+		return nil
+	}))
+
+	f := newJumpFixture(t, bus)
+	// El servicio de conversaciones comprueba la caducidad con la hora real
+	clk := clock.NewFake(time.Now())
+	f.bots.UseClock(clk)
+	finish := "finish"
+	f.step(t, &domain.BotStep{ID: "start", FlowID: "main", Type: domain.StepTypeMessage, NextStepID: &finish,
+		Content: json.RawMessage(`{"text":"Anything else?"}`)})
+	f.step(t, &domain.BotStep{ID: "finish", FlowID: "main", Type: domain.StepTypeEnd,
+		Content: json.RawMessage(`{"text":"Goodbye","reason":"resolved"}`)})
+
+	_, err := f.send("hi")
+	require.NoError(t, err)
+	session, err := f.conversations.GetSession(context.Background(), "user-1", "bot-1")
+	require.NoError(t, err)
+
+	clk.Advance(time.Minute)
+	response, err := f.send("no, thanks")
+	require.NoError(t, err)
+	assert.Equal(t, "Goodbye", response.Content)
+	assert.Equal(t, true, response.Metadata["conversation_ended"])
+
+	select {
+	case event := <-ended:
+		assert.Equal(t, session.ID, event.Data["session_id"])
+		assert.Equal(t, "resolved", event.Data["reason"])
+		endedAt, ok := event.Data["ended_at"].(*time.Time)
+		require.True(t, ok)
+		assert.Equal(t, clk.Now(), *endedAt)
+	case <-time.After(2 * time.Second):
+		t.Fatal("conversation_ended was not published")
+	}
+
+	_, err = f.conversations.GetSession(context.Background(), "user-1", "bot-1")
+	assert.Error(t, err)
+}
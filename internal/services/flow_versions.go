@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// ErrInvalidFlow indica que el borrador del flujo no se puede publicar
+var ErrInvalidFlow = errors.New("invalid flow")
+
+// PublishFlow toma una copia del borrador (flujo y pasos) como nueva versión
+// y la activa para las conversaciones que empiecen a partir de ahora
+func (s *botFlowService) PublishFlow(ctx context.Context, id, notes string) (*domain.FlowVersion, error) {
+	flow, err := s.flowRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("flow not found: %w", err)
+	}
+
+	steps, err := s.stepRepo.GetByFlowID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow steps: %w", err)
+	}
+	if err := validateFlowDraft(flow, steps); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.versionRepo.GetByFlowID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow versions: %w", err)
+	}
+	next := 1
+	for _, v := range versions {
+		if v.Version >= next {
+			next = v.Version + 1
+		}
+	}
+
+	now := time.Now()
+	version := &domain.FlowVersion{
+		FlowID:      id,
+		Version:     next,
+		Status:      domain.FlowVersionStatusPublished,
+		Notes:       notes,
+		Flow:        *flow,
+		Steps:       make([]domain.BotStep, 0, len(steps)),
+		CreatedAt:   now,
+		PublishedAt: now,
+	}
+	version.Flow.PublishedVersion = next
+	for _, step := range sortedSteps(steps) {
+		version.Steps = append(version.Steps, *step)
+	}
+
+	if err := s.versionRepo.Create(ctx, version); err != nil {
+		return nil, fmt.Errorf("failed to create flow version: %w", err)
+	}
+	s.archivePublished(ctx, versions)
+
+	flow.PublishedVersion = next
+	flow.UpdatedAt = now
+	if err := s.flowRepo.Update(ctx, flow); err != nil {
+		return nil, fmt.Errorf("failed to update flow: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Flow published", "flow_id", id, "version", next, "steps", len(version.Steps))
+	return version, nil
+}
+
+// GetFlowVersions devuelve las versiones del flujo, de la más nueva a la más antigua
+func (s *botFlowService) GetFlowVersions(ctx context.Context, id string) ([]*domain.FlowVersion, error) {
+	if _, err := s.flowRepo.GetByID(ctx, id); err != nil {
+		return nil, fmt.Errorf("flow not found: %w", err)
+	}
+	return s.versionRepo.GetByFlowID(ctx, id)
+}
+
+func (s *botFlowService) GetFlowVersion(ctx context.Context, id string, version int) (*domain.FlowVersion, error) {
+	return s.versionRepo.GetByVersion(ctx, id, version)
+}
+
+// RollbackFlow vuelve a publicar una versión anterior y restaura el borrador
+// a su contenido. Las sesiones en curso siguen con la versión que tenían fijada.
+func (s *botFlowService) RollbackFlow(ctx context.Context, id string, version int) (*domain.FlowVersion, error) {
+	flow, err := s.flowRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("flow not found: %w", err)
+	}
+
+	target, err := s.versionRepo.GetByVersion(ctx, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("flow version %d not found: %w", version, err)
+	}
+	if flow.PublishedVersion == version {
+		return nil, fmt.Errorf("flow version %d is already published", version)
+	}
+
+	if err := s.restoreDraft(ctx, flow, target); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.versionRepo.GetByFlowID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get flow versions: %w", err)
+	}
+	s.archivePublished(ctx, versions)
+
+	target.Status = domain.FlowVersionStatusPublished
+	target.PublishedAt = time.Now()
+	if err := s.versionRepo.Update(ctx, target); err != nil {
+		return nil, fmt.Errorf("failed to update flow version: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Flow rolled back", "flow_id", id, "version", version)
+	return target, nil
+}
+
+// restoreDraft reemplaza el flujo y los pasos del borrador por los de la versión
+func (s *botFlowService) restoreDraft(ctx context.Context, flow *domain.BotFlow, version *domain.FlowVersion) error {
+	current, err := s.stepRepo.GetByFlowID(ctx, flow.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get flow steps: %w", err)
+	}
+	for _, step := range current {
+		if err := s.stepRepo.Delete(ctx, step.ID); err != nil {
+			return fmt.Errorf("failed to delete step %s: %w", step.ID, err)
+		}
+	}
+
+	for _, source := range version.Steps {
+		step := source
+		if err := s.stepRepo.Create(ctx, &step); err != nil {
+			return fmt.Errorf("failed to restore step %s: %w", source.ID, err)
+		}
+	}
+
+	restored := version.Flow
+	restored.ID = flow.ID
+	restored.BotID = flow.BotID
+	restored.CreatedAt = flow.CreatedAt
+	restored.UpdatedAt = time.Now()
+	restored.PublishedVersion = version.Version
+	if err := s.flowRepo.Update(ctx, &restored); err != nil {
+		return fmt.Errorf("failed to update flow: %w", err)
+	}
+	return nil
+}
+
+// archivePublished marca como archivadas las versiones publicadas
+func (s *botFlowService) archivePublished(ctx context.Context, versions []*domain.FlowVersion) {
+	for _, v := range versions {
+		if v.Status != domain.FlowVersionStatusPublished {
+			continue
+		}
+		v.Status = domain.FlowVersionStatusArchived
+		if err := s.versionRepo.Update(ctx, v); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to archive flow version", "flow_id", v.FlowID, "version", v.Version, "error", err)
+		}
+	}
+}
+
+// validateFlowDraft evita publicar un flujo sin punto de entrada o con
+// referencias a pasos que no existen
+func validateFlowDraft(flow *domain.BotFlow, steps []*domain.BotStep) error {
+	ids := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		ids[step.ID] = true
+	}
+
+	if flow.EntryPoint == "" {
+		return fmt.Errorf("%w: flow %s has no entry point", ErrInvalidFlow, flow.ID)
+	}
+	if !ids[flow.EntryPoint] {
+		return fmt.Errorf("%w: entry point %s is not a step of flow %s", ErrInvalidFlow, flow.EntryPoint, flow.ID)
+	}
+	for _, step := range steps {
+		if step.NextStepID != nil && *step.NextStepID != "" && !ids[*step.NextStepID] {
+			return fmt.Errorf("%w: step %s points to missing step %s", ErrInvalidFlow, step.ID, *step.NextStepID)
+		}
+	}
+	return nil
+}
+
+func sortedSteps(steps []*domain.BotStep) []*domain.BotStep {
+	sorted := append([]*domain.BotStep(nil), steps...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+	return sorted
+}
+
+// enterFlow fija en la sesión la versión publicada del flujo al que entra
+func enterFlow(session *domain.ConversationSession, flow *domain.BotFlow) {
+	session.CurrentFlowID = flow.ID
+	session.FlowVersion = flow.PublishedVersion
+}
+
+// flowVersion devuelve la versión publicada que ejecuta la sesión; nil si el
+// flujo nunca se publicó o la versión ya no existe, en cuyo caso se usa el borrador
+func (s *botService) flowVersion(ctx context.Context, flowID string, version int) *domain.FlowVersion {
+	if version <= 0 || s.flowVersionRepo == nil {
+		return nil
+	}
+	v, err := s.flowVersionRepo.GetByVersion(ctx, flowID, version)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Pinned flow version not found, using draft", "flow_id", flowID, "version", version, "error", err)
+		return nil
+	}
+	return v
+}
+
+// flowSteps devuelve los pasos del flujo en la versión indicada
+func (s *botService) flowSteps(ctx context.Context, flowID string, version int) ([]*domain.BotStep, error) {
+	v := s.flowVersion(ctx, flowID, version)
+	if v == nil {
+		return s.stepRepo.GetByFlowID(ctx, flowID)
+	}
+	steps := make([]*domain.BotStep, len(v.Steps))
+	for i := range v.Steps {
+		steps[i] = &v.Steps[i]
+	}
+	return steps, nil
+}
+
+// flowStep busca un paso por ID en la versión indicada del flujo
+func (s *botService) flowStep(ctx context.Context, flowID string, version int, stepID string) (*domain.BotStep, error) {
+	v := s.flowVersion(ctx, flowID, version)
+	if v == nil {
+		return s.stepRepo.GetByID(ctx, stepID)
+	}
+	for i := range v.Steps {
+		if v.Steps[i].ID == stepID {
+			return &v.Steps[i], nil
+		}
+	}
+	return nil, fmt.Errorf("step %s not found in flow %s version %d", stepID, flowID, version)
+}
+
+// flowEntryPoint devuelve el punto de entrada del flujo en la versión indicada
+func (s *botService) flowEntryPoint(ctx context.Context, flow *domain.BotFlow, version int) string {
+	if v := s.flowVersion(ctx, flow.ID, version); v != nil {
+		return v.Flow.EntryPoint
+	}
+	return flow.EntryPoint
+}
+
+// sessionFlowVersion devuelve la versión fijada si el flujo es el de la sesión
+func sessionFlowVersion(session *domain.ConversationSession, flowID string) int {
+	if session.CurrentFlowID == flowID {
+		return session.FlowVersion
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlowVersions_SessionsPinPublishedVersion(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	versionRepo := repositories.NewMockFlowVersionRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+
+	flows := NewBotFlowService(flowRepo, stepRepo, versionRepo, log)
+	bots := NewBotService(botRepo, flowRepo, stepRepo, versionRepo, sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "step-1", IsDefault: true}))
+	next := "step-2"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "step-1", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"hello"}`), NextStepID: &next}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "step-2", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"bye v1"}`)}))
+
+	v1, err := flows.PublishFlow(ctx, "flow-1", "first")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v1.Version)
+
+	send := func(userID string) string {
+		response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: userID, Content: "hi"})
+		require.NoError(t, err)
+		return response.Content
+	}
+
+	assert.Equal(t, "hello", send("user-a"))
+
+	// Editar el borrador no afecta a la versión publicada
+	require.NoError(t, stepRepo.Update(ctx, &domain.BotStep{ID: "step-2", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"bye v2"}`)}))
+	assert.Equal(t, "hello", send("user-b"))
+	assert.Equal(t, "bye v1", send("user-b"))
+
+	_, err = flows.PublishFlow(ctx, "flow-1", "second")
+	require.NoError(t, err)
+
+	// La sesión en curso sigue con la versión con la que empezó
+	assert.Equal(t, "bye v1", send("user-a"))
+	assert.Equal(t, "hello", send("user-c"))
+	assert.Equal(t, "bye v2", send("user-c"))
+
+	restored, err := flows.RollbackFlow(ctx, "flow-1", 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.FlowVersionStatusPublished, restored.Status)
+
+	flow, err := flowRepo.GetByID(ctx, "flow-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, flow.PublishedVersion)
+	draft, err := stepRepo.GetByID(ctx, "step-2")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text":"bye v1"}`, string(draft.Content))
+
+	versions, err := flows.GetFlowVersions(ctx, "flow-1")
+	require.NoError(t, err)
+	require.Len(t, versions, 2)
+	assert.Equal(t, domain.FlowVersionStatusArchived, versions[0].Status)
+	assert.Equal(t, domain.FlowVersionStatusPublished, versions[1].Status)
+
+	_, err = flows.RollbackFlow(ctx, "flow-1", 1)
+	assert.Error(t, err)
+}
+
+func TestPublishFlow_RejectsMissingEntryPoint(t *testing.T) {
+	ctx := context.Background()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	flows := NewBotFlowService(flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), logger.NewLogger("error"))
+
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "missing"}))
+
+	_, err := flows.PublishFlow(ctx, "flow-1", "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// Claves de contexto para reanudar el flujo interrumpido por un intent global
+const (
+	resumeFlowKey = "resume_flow_id"
+	resumeStepKey = "resume_step_id"
+	// resumeVersionKey guarda la versión fijada del flujo interrumpido
+	resumeVersionKey = "resume_flow_version"
+)
+
+// parseBotConfig obtiene la configuración de comportamiento del bot
+func parseBotConfig(bot *domain.Bot) domain.BotConfig {
+	var config domain.BotConfig
+	if len(bot.Config) > 0 {
+		// Config puede contener otras claves; sólo interesan las conocidas
+		_ = json.Unmarshal(bot.Config, &config)
+	}
+	return config
+}
+
+// matchGlobalIntent devuelve el intent global que coincide con el mensaje, si existe
+func matchGlobalIntent(intents []domain.GlobalIntent, text string) *domain.GlobalIntent {
+	normalized := normalizeText(text)
+	if normalized == "" {
+		return nil
+	}
+
+	words := tokenize(normalized)
+
+	for i := range intents {
+		for _, keyword := range intents[i].Keywords {
+			keyword = normalizeText(keyword)
+			if keyword == "" {
+				continue
+			}
+
+			// Frases de varias palabras se buscan como subcadena; palabras sueltas como token
+			if strings.Contains(keyword, " ") {
+				if strings.Contains(normalized, keyword) {
+					return &intents[i]
+				}
+				continue
+			}
+
+			for _, word := range words {
+				if word == keyword {
+					return &intents[i]
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// interruptWithIntent cambia la sesión al flujo del intent global, guardando el punto de retorno
+func (s *botService) interruptWithIntent(ctx context.Context, intent *domain.GlobalIntent, botID string, session *domain.ConversationSession) (*domain.BotFlow, error) {
+	flow, err := s.flowRepo.GetByID(ctx, intent.FlowID)
+	if err != nil {
+		return nil, fmt.Errorf("global intent flow not found: %w", err)
+	}
+	if flow.BotID != botID {
+		return nil, fmt.Errorf("global intent flow %s does not belong to bot %s", flow.ID, botID)
+	}
+
+	// Sólo se guarda el punto de retorno si se interrumpe otro flujo
+	if intent.Resume && session.CurrentFlowID != "" && session.CurrentFlowID != flow.ID {
+		session.Context[resumeFlowKey] = session.CurrentFlowID
+		session.Context[resumeStepKey] = session.CurrentStepID
+		session.Context[resumeVersionKey] = session.FlowVersion
+	} else if !intent.Resume {
+		delete(session.Context, resumeFlowKey)
+		delete(session.Context, resumeStepKey)
+		delete(session.Context, resumeVersionKey)
+		// Sin retorno, los sub-flujos en curso quedan abandonados
+		setSubflowStack(session, nil)
+	}
+
+	s.logger.WithContext(ctx).Info("Global intent matched",
+		"bot_id", botID,
+		"intent", intent.Name,
+		"flow_id", flow.ID,
+		"interrupted_flow", session.CurrentFlowID)
+
+	enterFlow(session, flow)
+	session.CurrentStepID = ""
+
+	return flow, nil
+}
+
+// resumeInterruptedFlow restaura el flujo interrumpido cuando el flujo del intent terminó
+func resumeInterruptedFlow(session *domain.ConversationSession) bool {
+	flowID, ok := session.Context[resumeFlowKey].(string)
+	if !ok || flowID == "" {
+		return false
+	}
+
+	stepID, _ := session.Context[resumeStepKey].(string)
+	version, _ := toFloat(session.Context[resumeVersionKey])
+	session.CurrentFlowID = flowID
+	session.CurrentStepID = stepID
+	session.FlowVersion = int(version)
+
+	delete(session.Context, resumeFlowKey)
+	delete(session.Context, resumeStepKey)
+	delete(session.Context, resumeVersionKey)
+
+	return true
+}
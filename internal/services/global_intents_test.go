@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGlobalIntent_InterruptsAndResumes(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive, Config: json.RawMessage(`{
+		"global_intents": [
+			{"name": "help", "keywords": ["help"], "flow_id": "help", "resume": true},
+			{"name": "broken", "keywords": ["operator"], "flow_id": "missing", "resume": true}
+		]}`)}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "ask", IsDefault: true}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "help", BotID: "bot-1", EntryPoint: "help-text"}))
+
+	name := "name"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "ask", FlowID: "main", Type: domain.StepTypeMessage, NextStepID: &name,
+		Content: json.RawMessage(`{"text":"What is your name?"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "name", FlowID: "main", Type: domain.StepTypeInput,
+		Content: json.RawMessage(`{"variable":"name"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "help-text", FlowID: "help", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"I can help"}`)}))
+
+	send := func(userID, text string) *domain.BotResponse {
+		response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: userID, Content: text})
+		require.NoError(t, err)
+		return response
+	}
+	session := func(userID string) *domain.ConversationSession {
+		session, err := conversations.GetSession(ctx, userID, "bot-1")
+		require.NoError(t, err)
+		return session
+	}
+
+	assert.Equal(t, "What is your name?", send("user-1", "hi").Content)
+
+	// El intent interrumpe el flujo y, al terminar el suyo, vuelve al paso pendiente
+	response := send("user-1", "I need help")
+	assert.Equal(t, "I can help", response.Content)
+	assert.Equal(t, "help", response.Metadata["intent"])
+	assert.Equal(t, "main", response.Metadata["resumed_flow_id"])
+
+	current := session("user-1")
+	assert.Equal(t, "main", current.CurrentFlowID)
+	assert.Equal(t, "name", current.CurrentStepID)
+	assert.NotContains(t, current.Context, resumeFlowKey)
+	assert.NotContains(t, current.Context, resumeStepKey)
+
+	send("user-1", "Ana")
+	assert.Equal(t, "Ana", session("user-1").Context["name"])
+
+	// Si el flujo del intent no existe, el mensaje sigue en el flujo actual
+	assert.Equal(t, "What is your name?", send("user-2", "hi").Content)
+	response = send("user-2", "operator")
+	assert.NotContains(t, response.Metadata, "intent")
+	current = session("user-2")
+	assert.Equal(t, "main", current.CurrentFlowID)
+	assert.Equal(t, "operator", current.Context["name"])
+	assert.NotContains(t, current.Context, resumeFlowKey)
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+)
+
+// Errores de la API de derivación a agentes humanos
+var (
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrInvalidHandoffState  = errors.New("invalid handoff state")
+)
+
+// EscalateConversation deriva manualmente una conversación a un agente humano
+func (s *botService) EscalateConversation(ctx context.Context, sessionID, queue, reason string) (*domain.ConversationSession, error) {
+	session, unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if session.Handoff != nil {
+		return nil, fmt.Errorf("%w: conversation %s is already escalated", ErrInvalidHandoffState, sessionID)
+	}
+
+	channel, _ := session.Context["channel"].(string)
+	s.startHandoff(ctx, session, queue, reason, channel)
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to escalate conversation: %w", err)
+	}
+	return session, nil
+}
+
+// GetHandoffs devuelve las conversaciones del bot que atiende o espera un humano
+func (s *botService) GetHandoffs(ctx context.Context, botID string) ([]*domain.ConversationSession, error) {
+	sessions, err := s.sessionRepo.GetHandoffs(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get handoffs: %w", err)
+	}
+	return sessions, nil
+}
+
+// AcceptHandoff asigna la conversación al agente que la toma desde la consola
+func (s *botService) AcceptHandoff(ctx context.Context, sessionID, agentID string) (*domain.ConversationSession, error) {
+	if agentID == "" {
+		return nil, fmt.Errorf("%w: agent_id is required", ErrInvalidHandoffState)
+	}
+	session, unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if session.Handoff == nil {
+		return nil, fmt.Errorf("%w: conversation %s is not escalated", ErrInvalidHandoffState, sessionID)
+	}
+	if session.Handoff.Status == domain.HandoffStatusActive && session.Handoff.AgentID != agentID {
+		return nil, fmt.Errorf("%w: conversation %s is assigned to %s", ErrInvalidHandoffState, sessionID, session.Handoff.AgentID)
+	}
+
+	now := s.clock.Now()
+	session.Handoff.Status = domain.HandoffStatusActive
+	session.Handoff.AgentID = agentID
+	session.Handoff.AcceptedAt = &now
+	session.UpdatedAt = now
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to accept handoff: %w", err)
+	}
+
+	s.publishHandoffEvent(ctx, events.EventTypeHandoffAccepted, session, map[string]interface{}{
+		"agent_id": agentID,
+	})
+	return session, nil
+}
+
+// SendAgentMessage envía al usuario un mensaje del agente humano en nombre del
+// bot. El conector del canal lo entrega al recibir el evento agent_message.
+func (s *botService) SendAgentMessage(ctx context.Context, sessionID, agentID, text string) (*domain.ConversationMessage, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("%w: text is required", ErrInvalidHandoffState)
+	}
+	session, unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if session.Handoff == nil || session.Handoff.Status != domain.HandoffStatusActive || session.Handoff.AgentID != agentID {
+		return nil, fmt.Errorf("%w: conversation %s is not assigned to agent %s", ErrInvalidHandoffState, sessionID, agentID)
+	}
+
+	s.appendTranscript(session, "agent", text)
+	session.UpdatedAt = s.clock.Now()
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to save agent message: %w", err)
+	}
+
+	message := session.Messages[len(session.Messages)-1]
+	s.publishHandoffEvent(ctx, events.EventTypeAgentMessage, session, map[string]interface{}{
+		"agent_id": agentID,
+		"content":  message.Content,
+	})
+	return &message, nil
+}
+
+// ReleaseHandoff devuelve la conversación al bot. Con step el flujo continúa en
+// ese paso del flujo actual; si no, en el paso en que quedó al derivarse.
+func (s *botService) ReleaseHandoff(ctx context.Context, sessionID, step string) (*domain.ConversationSession, error) {
+	session, unlock, err := s.lockSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if session.Handoff == nil {
+		return nil, fmt.Errorf("%w: conversation %s is not escalated", ErrInvalidHandoffState, sessionID)
+	}
+
+	if step != "" {
+		target, err := s.findStepInFlow(ctx, session.CurrentFlowID, session.FlowVersion, step)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidHandoffState, err)
+		}
+		session.CurrentStepID = target.ID
+	}
+
+	handoff := session.Handoff
+	session.Handoff = nil
+	session.UpdatedAt = s.clock.Now()
+	if err := s.sessionRepo.Update(ctx, session); err != nil {
+		return nil, fmt.Errorf("failed to release handoff: %w", err)
+	}
+
+	s.publishHandoffEvent(ctx, events.EventTypeHandoffReleased, session, map[string]interface{}{
+		"agent_id": handoff.AgentID,
+		"step_id":  session.CurrentStepID,
+	})
+	return session, nil
+}
+
+// startHandoff marca la sesión como derivada y publica el evento human_handoff
+// con el resumen y la transcripción para quien vaya a atenderla
+func (s *botService) startHandoff(ctx context.Context, session *domain.ConversationSession, queue, reason, channel string) {
+	var config *domain.SummaryConfig
+	if bot, err := s.botRepo.GetByID(ctx, session.BotID); err == nil {
+		botConfig := parseBotConfig(bot)
+		config = botConfig.Summary
+		if botConfig.Sandbox != nil {
+			ctx = withSandbox(ctx)
+		}
+	}
+
+	summary := session.Summary
+	if summary == nil || summary.MessageCount != len(session.Messages) {
+		summary = s.summarizeSession(ctx, session, config)
+		session.Summary = summary
+	}
+
+	now := s.clock.Now()
+	session.Handoff = &domain.SessionHandoff{
+		Status:      domain.HandoffStatusPending,
+		Queue:       queue,
+		Reason:      reason,
+		RequestedAt: now,
+	}
+	if session.Context == nil {
+		session.Context = make(map[string]interface{})
+	}
+	session.Context["handoff_queue"] = queue
+	session.Context["handoff_requested_at"] = now.Format(time.RFC3339)
+
+	// Los handlers del bus son asíncronos: se publica una copia del contexto
+	snapshot := make(map[string]interface{}, len(session.Context))
+	for k, v := range session.Context {
+		snapshot[k] = v
+	}
+	s.publishHandoffEvent(ctx, events.EventTypeHumanHandoff, session, map[string]interface{}{
+		"channel":  channel,
+		"queue":    queue,
+		"reason":   reason,
+		"summary":  summary,
+		"messages": append([]domain.ConversationMessage(nil), session.Messages...),
+		"context":  snapshot,
+	})
+}
+
+// relayToHandoff guarda el mensaje del usuario para el agente humano sin
+// ejecutar el flujo; la respuesta vacía indica al canal que no conteste
+func (s *botService) relayToHandoff(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession) *domain.BotResponse {
+	session.UpdatedAt = s.clock.Now()
+	session.Context["last_message"] = message.Content
+	session.Context["channel"] = string(message.Channel)
+	s.saveSession(ctx, session)
+
+	s.publishHandoffEvent(ctx, events.EventTypeHandoffMessage, session, map[string]interface{}{
+		"agent_id":   session.Handoff.AgentID,
+		"message_id": message.ID,
+		"content":    message.Content,
+	})
+
+	return &domain.BotResponse{
+		Type: domain.ResponseTypeText,
+		Metadata: map[string]interface{}{
+			"handoff":        true,
+			"handoff_status": session.Handoff.Status,
+		},
+	}
+}
+
+func (s *botService) handoffSession(ctx context.Context, sessionID string) (*domain.ConversationSession, error) {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrConversationNotFound, sessionID)
+	}
+	return session, nil
+}
+
+func (s *botService) publishHandoffEvent(ctx context.Context, eventType string, session *domain.ConversationSession, data map[string]interface{}) {
+	if s.eventBus == nil {
+		return
+	}
+
+	data["bot_id"] = session.BotID
+	data["session_id"] = session.ID
+	event := s.events.CreateUserEvent(eventType, session.UserID, data)
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish handoff event", "event_type", eventType, "error", err)
+	}
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandoff_BotStaysSilentUntilReleased(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "escalate", IsDefault: true}))
+	next := "back"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "escalate", FlowID: "flow-1", Type: domain.StepTypeHandoff, NextStepID: &next,
+		Content: json.RawMessage(`{"text":"Transferring","queue":"billing"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "back", FlowID: "flow-1", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"bot again"}`)}))
+
+	send := func(text string) *domain.BotResponse {
+		response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: text})
+		require.NoError(t, err)
+		return response
+	}
+
+	assert.Equal(t, "Transferring", send("I need a human").Content)
+
+	response := send("hello?")
+	assert.Empty(t, response.Content)
+	assert.Equal(t, true, response.Metadata["handoff"])
+
+	handoffs, err := bots.GetHandoffs(ctx, "bot-1")
+	require.NoError(t, err)
+	require.Len(t, handoffs, 1)
+	sessionID := handoffs[0].ID
+	assert.Equal(t, "billing", handoffs[0].Handoff.Queue)
+
+	_, err = bots.SendAgentMessage(ctx, sessionID, "agent-1", "hi")
+	assert.True(t, errors.Is(err, ErrInvalidHandoffState))
+
+	_, err = bots.AcceptHandoff(ctx, sessionID, "agent-1")
+	require.NoError(t, err)
+	_, err = bots.AcceptHandoff(ctx, sessionID, "agent-2")
+	assert.True(t, errors.Is(err, ErrInvalidHandoffState))
+
+	message, err := bots.SendAgentMessage(ctx, sessionID, "agent-1", "How can I help?")
+	require.NoError(t, err)
+	assert.Equal(t, "agent", message.Role)
+
+	session, err := bots.ReleaseHandoff(ctx, sessionID, "")
+	require.NoError(t, err)
+	assert.Nil(t, session.Handoff)
+	assert.Equal(t, "back", session.CurrentStepID)
+
+	assert.Equal(t, "bot again", send("thanks").Content)
+
+	_, err = bots.ReleaseHandoff(ctx, "missing", "")
+	assert.True(t, errors.Is(err, ErrConversationNotFound))
+}
@@ -1,77 +1,156 @@
 package services
 
 import (
+	"context"
+	"sort"
+	"sync"
 	"time"
 )
 
+// Estados de un probe de salud
+const (
+	ProbeStatusUp   = "up"
+	ProbeStatusDown = "down"
+)
+
+// probeCacheTTL evita llamar a las APIs de los canales en cada /health
+const probeCacheTTL = 30 * time.Second
+
 type HealthService interface {
 	CheckHealth() map[string]interface{}
 	CheckReadiness() map[string]interface{}
 }
 
+// ProbeResult es el último resultado de un probe
+type ProbeResult struct {
+	Name      string                 `json:"name"`
+	Kind      string                 `json:"kind"`
+	Status    string                 `json:"status"`
+	Critical  bool                   `json:"critical"`
+	LatencyMs int64                  `json:"latency_ms"`
+	Error     string                 `json:"error,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
 type healthService struct {
 	startTime time.Time
+	probes    []HealthProbe
+
+	mu      sync.Mutex
+	results map[string]ProbeResult
 }
 
-func NewHealthService() HealthService {
+// NewHealthService crea el servicio de salud con los probes de conectores y
+// adaptadores que se reportan en /health y /ready
+func NewHealthService(probes ...HealthProbe) HealthService {
 	return &healthService{
 		startTime: time.Now(),
+		probes:    probes,
+		results:   make(map[string]ProbeResult),
 	}
 }
 
 func (s *healthService) CheckHealth() map[string]interface{} {
+	status := "healthy"
+	results := s.runProbes()
+	for _, result := range results {
+		if result.Status == ProbeStatusDown {
+			status = "degraded"
+			break
+		}
+	}
+
 	return map[string]interface{}{
-		"status":    "healthy",
+		"status":    status,
 		"timestamp": time.Now().UTC(),
 		"uptime":    time.Since(s.startTime).String(),
 		"service":   "it-bot-service",
 		"version":   "1.0.0",
+		"checks":    results,
 	}
 }
 
 func (s *healthService) CheckReadiness() map[string]interface{} {
-	// Aquí puedes agregar checks adicionales como:
-	// - Conexión a base de datos
-	// - Conexión a servicios externos
-	// - Estado de dependencias críticas
-	
 	ready := true
 	checks := make(map[string]bool)
-	
-	// Ejemplo de checks (comentados para testing)
-	// checks["database"] = s.checkDatabase()
-	// checks["external_api"] = s.checkExternalAPI()
-	// checks["vault"] = s.checkVault()
-	
-	// Si algún check falla, el servicio no está ready
-	for _, check := range checks {
-		if !check {
+
+	// Solo los probes críticos que fallan sacan al servicio del balanceo
+	results := s.runProbes()
+	for _, result := range results {
+		up := result.Status == ProbeStatusUp
+		checks[result.Name] = up
+		if !up && result.Critical {
 			ready = false
-			break
 		}
 	}
-	
+
 	return map[string]interface{}{
 		"ready":     ready,
 		"timestamp": time.Now().UTC(),
 		"checks":    checks,
+		"probes":    results,
 	}
 }
 
-// Ejemplos de checks comentados
-/*
-func (s *healthService) checkDatabase() bool {
-	// Implementar check de base de datos
-	return true
-}
+// runProbes ejecuta en paralelo los probes cuyo resultado ya caducó
+func (s *healthService) runProbes() []ProbeResult {
+	if len(s.probes) == 0 {
+		return []ProbeResult{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var wg sync.WaitGroup
+	fresh := make(chan ProbeResult, len(s.probes))
+	for _, probe := range s.probes {
+		if cached, ok := s.results[probe.Name()]; ok && now.Sub(cached.CheckedAt) < probeCacheTTL {
+			continue
+		}
+
+		wg.Add(1)
+		go func(probe HealthProbe) {
+			defer wg.Done()
+			fresh <- runProbe(probe)
+		}(probe)
+	}
+	wg.Wait()
+	close(fresh)
 
-func (s *healthService) checkExternalAPI() bool {
-	// Implementar check de API externa
-	return true
+	for result := range fresh {
+		s.results[result.Name] = result
+	}
+
+	results := make([]ProbeResult, 0, len(s.results))
+	for _, result := range s.results {
+		results = append(results, result)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	return results
 }
 
-func (s *healthService) checkVault() bool {
-	// Implementar check de Vault
-	return true
+func runProbe(probe HealthProbe) ProbeResult {
+	ctx, cancel := context.WithTimeout(context.Background(), probeHTTPTimeout)
+	defer cancel()
+
+	start := time.Now()
+	details, err := probe.Check(ctx)
+	result := ProbeResult{
+		Name:      probe.Name(),
+		Kind:      probe.Kind(),
+		Status:    ProbeStatusUp,
+		Critical:  probe.Critical(),
+		LatencyMs: time.Since(start).Milliseconds(),
+		Details:   details,
+		CheckedAt: time.Now(),
+	}
+	if err != nil {
+		result.Status = ProbeStatusDown
+		result.Error = err.Error()
+	}
+	return result
 }
-*/
\ No newline at end of file
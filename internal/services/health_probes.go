@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/adapters"
+)
+
+// Tipos de probe de salud
+const (
+	ProbeKindChannel = "channel"
+	ProbeKindAdapter = "adapter"
+)
+
+// Endpoints por defecto de las APIs de los canales
+const (
+	defaultWhatsAppAPIURL = "https://graph.facebook.com/v18.0"
+	defaultSlackAPIURL    = "https://slack.com/api"
+	defaultTelegramAPIURL = "https://api.telegram.org"
+)
+
+const probeHTTPTimeout = 5 * time.Second
+
+// HealthProbe comprueba una dependencia externa del servicio. Los probes
+// críticos que fallan marcan el servicio como no listo en /ready.
+type HealthProbe interface {
+	Name() string
+	Kind() string
+	Critical() bool
+	Check(ctx context.Context) (map[string]interface{}, error)
+}
+
+// channelProbe valida las credenciales de un canal con una llamada a su API
+type channelProbe struct {
+	name     string
+	critical bool
+	client   *http.Client
+	check    func(ctx context.Context, client *http.Client) (map[string]interface{}, error)
+}
+
+func (p *channelProbe) Name() string   { return p.name }
+func (p *channelProbe) Kind() string   { return ProbeKindChannel }
+func (p *channelProbe) Critical() bool { return p.critical }
+
+func (p *channelProbe) Check(ctx context.Context) (map[string]interface{}, error) {
+	return p.check(ctx, p.client)
+}
+
+// NewWhatsAppProbe comprueba que el token de WhatsApp Cloud API es válido
+// consultando el número de teléfono configurado
+func NewWhatsAppProbe(token, phoneNumberID, baseURL string, critical bool) HealthProbe {
+	baseURL = probeBaseURL(baseURL, defaultWhatsAppAPIURL)
+	return &channelProbe{
+		name:     "whatsapp",
+		critical: critical,
+		client:   &http.Client{Timeout: probeHTTPTimeout},
+		check: func(ctx context.Context, client *http.Client) (map[string]interface{}, error) {
+			url := fmt.Sprintf("%s/%s?fields=display_phone_number,verified_name", baseURL, phoneNumberID)
+			status, body, err := probeRequest(ctx, client, http.MethodGet, url, token)
+			if err != nil {
+				return nil, err
+			}
+			if status != http.StatusOK {
+				message := "unexpected status " + fmt.Sprint(status)
+				if apiErr, ok := body["error"].(map[string]interface{}); ok {
+					message = fmt.Sprintf("%v", apiErr["message"])
+				}
+				if status == http.StatusUnauthorized || status == http.StatusForbidden {
+					return nil, fmt.Errorf("invalid or expired token: %s", message)
+				}
+				return nil, fmt.Errorf("whatsapp API error: %s", message)
+			}
+			return map[string]interface{}{
+				"phone_number":  body["display_phone_number"],
+				"verified_name": body["verified_name"],
+			}, nil
+		},
+	}
+}
+
+// NewSlackProbe ejecuta auth.test con el token del bot de Slack
+func NewSlackProbe(token, baseURL string, critical bool) HealthProbe {
+	baseURL = probeBaseURL(baseURL, defaultSlackAPIURL)
+	return &channelProbe{
+		name:     "slack",
+		critical: critical,
+		client:   &http.Client{Timeout: probeHTTPTimeout},
+		check: func(ctx context.Context, client *http.Client) (map[string]interface{}, error) {
+			status, body, err := probeRequest(ctx, client, http.MethodPost, baseURL+"/auth.test", token)
+			if err != nil {
+				return nil, err
+			}
+			// Slack responde 200 también con credenciales inválidas; manda el campo ok
+			if ok, _ := body["ok"].(bool); status != http.StatusOK || !ok {
+				return nil, fmt.Errorf("slack auth.test failed: %v", body["error"])
+			}
+			return map[string]interface{}{
+				"team": body["team"],
+				"user": body["user"],
+			}, nil
+		},
+	}
+}
+
+// NewTelegramProbe ejecuta getMe con el token del bot de Telegram
+func NewTelegramProbe(token, baseURL string, critical bool) HealthProbe {
+	baseURL = probeBaseURL(baseURL, defaultTelegramAPIURL)
+	return &channelProbe{
+		name:     "telegram",
+		critical: critical,
+		client:   &http.Client{Timeout: probeHTTPTimeout},
+		check: func(ctx context.Context, client *http.Client) (map[string]interface{}, error) {
+			status, body, err := probeRequest(ctx, client, http.MethodGet, fmt.Sprintf("%s/bot%s/getMe", baseURL, token), "")
+			if err != nil {
+				// El error de red incluye la URL, que contiene el token
+				return nil, fmt.Errorf("telegram getMe request failed: %s", strings.ReplaceAll(err.Error(), token, "***"))
+			}
+			if ok, _ := body["ok"].(bool); status != http.StatusOK || !ok {
+				return nil, fmt.Errorf("telegram getMe failed: %v", body["description"])
+			}
+			details := map[string]interface{}{}
+			if result, ok := body["result"].(map[string]interface{}); ok {
+				details["username"] = result["username"]
+			}
+			return details, nil
+		},
+	}
+}
+
+// adapterProbe consulta el estado de un adaptador registrado
+type adapterProbe struct {
+	adapter  adapters.Adapter
+	critical bool
+}
+
+// NewAdapterProbe crea un probe que comprueba IsHealthy del adaptador
+func NewAdapterProbe(adapter adapters.Adapter, critical bool) HealthProbe {
+	return &adapterProbe{adapter: adapter, critical: critical}
+}
+
+func (p *adapterProbe) Name() string   { return p.adapter.GetName() }
+func (p *adapterProbe) Kind() string   { return ProbeKindAdapter }
+func (p *adapterProbe) Critical() bool { return p.critical }
+
+func (p *adapterProbe) Check(ctx context.Context) (map[string]interface{}, error) {
+	details := map[string]interface{}{
+		"type":    p.adapter.GetType(),
+		"version": p.adapter.GetVersion(),
+	}
+	if !p.adapter.IsHealthy() {
+		return details, fmt.Errorf("adapter is not healthy")
+	}
+	return details, nil
+}
+
+func probeBaseURL(baseURL, fallback string) string {
+	if baseURL == "" {
+		return fallback
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// probeRequest hace la llamada del probe y decodifica la respuesta JSON
+func probeRequest(ctx context.Context, client *http.Client, method, url, token string) (int, map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body := map[string]interface{}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil && resp.StatusCode == http.StatusOK {
+		return resp.StatusCode, nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return resp.StatusCode, body, nil
+}
@@ -1,9 +1,14 @@
 package services
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
+	"github.com/company/bot-service/internal/adapters"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestHealthService_CheckHealth(t *testing.T) {
@@ -26,4 +31,54 @@ func TestHealthService_CheckReadiness(t *testing.T) {
 	assert.Equal(t, true, result["ready"])
 	assert.NotNil(t, result["timestamp"])
 	assert.NotNil(t, result["checks"])
-}
\ No newline at end of file
+}
+
+type stubAdapter struct {
+	adapters.Adapter
+	healthy bool
+}
+
+func (a *stubAdapter) GetName() string    { return "object-store" }
+func (a *stubAdapter) GetType() string    { return "storage" }
+func (a *stubAdapter) GetVersion() string { return "1.0" }
+func (a *stubAdapter) IsHealthy() bool    { return a.healthy }
+
+func TestHealthService_ChannelAndAdapterProbes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth.test":
+			assert.Equal(t, "Bearer xoxb-bad", r.Header.Get("Authorization"))
+			w.Write([]byte(`{"ok":false,"error":"invalid_auth"}`))
+		case "/botTOKEN/getMe":
+			w.Write([]byte(`{"ok":true,"result":{"username":"support_bot"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	adapter := &stubAdapter{healthy: true}
+	service := NewHealthService(
+		NewSlackProbe("xoxb-bad", server.URL, false),
+		NewTelegramProbe("TOKEN", server.URL, false),
+		NewAdapterProbe(adapter, true),
+	)
+
+	health := service.CheckHealth()
+	assert.Equal(t, "degraded", health["status"])
+	results := health["checks"].([]ProbeResult)
+	require.Len(t, results, 3)
+	assert.Equal(t, ProbeStatusUp, results[0].Status) // object-store
+	assert.Equal(t, ProbeStatusDown, results[1].Status)
+	assert.Contains(t, results[1].Error, "invalid_auth")
+	assert.Equal(t, "support_bot", results[2].Details["username"])
+
+	// Un canal caído no crítico no saca al servicio del balanceo
+	readiness := service.CheckReadiness()
+	assert.Equal(t, true, readiness["ready"])
+	assert.Equal(t, false, readiness["checks"].(map[string]bool)["slack"])
+
+	details, err := NewAdapterProbe(&stubAdapter{}, true).Check(context.Background())
+	assert.Error(t, err)
+	assert.Equal(t, "storage", details["type"])
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// ErrIncomingBatchTooLarge indica un batch con más mensajes de los permitidos
+var ErrIncomingBatchTooLarge = errors.New("incoming batch too large")
+
+// IncomingMessageResult es el resultado de un mensaje del batch, en la
+// posición que ocupaba en la petición
+type IncomingMessageResult struct {
+	Index     int                 `json:"index"`
+	MessageID string              `json:"message_id"`
+	Success   bool                `json:"success"`
+	Response  *domain.BotResponse `json:"response,omitempty"`
+	Error     string              `json:"error,omitempty"`
+}
+
+// IncomingBatchSummary resume el procesamiento del batch
+type IncomingBatchSummary struct {
+	Total         int   `json:"total"`
+	Succeeded     int   `json:"succeeded"`
+	Failed        int   `json:"failed"`
+	Conversations int   `json:"conversations"`
+	DurationMs    int64 `json:"duration_ms"`
+}
+
+// IncomingBatchResult es la respuesta de POST /incoming/batch
+type IncomingBatchResult struct {
+	Results []IncomingMessageResult `json:"results"`
+	Summary IncomingBatchSummary    `json:"summary"`
+}
+
+// IncomingBatchProcessor procesa los mensajes acumulados por messaging-service
+// durante una caída. Las conversaciones se procesan en paralelo y los mensajes
+// de una misma conversación en el orden del batch, para que el flujo los vea
+// como si hubieran llegado uno a uno.
+type IncomingBatchProcessor struct {
+	botService  BotService
+	concurrency int
+	maxSize     int
+	clock       clock.Clock
+	logger      logger.Logger
+}
+
+// NewIncomingBatchProcessor crea el procesador; concurrency son las
+// conversaciones simultáneas y maxSize el máximo de mensajes por batch
+func NewIncomingBatchProcessor(botService BotService, concurrency, maxSize int, clk clock.Clock, logger logger.Logger) *IncomingBatchProcessor {
+	if concurrency <= 0 {
+		concurrency = 8
+	}
+	if maxSize <= 0 {
+		maxSize = 500
+	}
+	return &IncomingBatchProcessor{
+		botService:  botService,
+		concurrency: concurrency,
+		maxSize:     maxSize,
+		clock:       clock.OrReal(clk),
+		logger:      logger,
+	}
+}
+
+// Process procesa el batch. Un mensaje fallido no detiene los demás: su error
+// queda en su resultado y en el resumen.
+func (p *IncomingBatchProcessor) Process(ctx context.Context, messages []domain.IncomingMessage) (*IncomingBatchResult, error) {
+	if len(messages) > p.maxSize {
+		return nil, fmt.Errorf("%w: %d messages, at most %d allowed", ErrIncomingBatchTooLarge, len(messages), p.maxSize)
+	}
+
+	start := p.clock.Now()
+	results := make([]IncomingMessageResult, len(messages))
+
+	// Agrupar por conversación conservando el orden de llegada
+	var order []string
+	conversations := make(map[string][]int)
+	for i := range messages {
+		if messages[i].ID == "" {
+			messages[i].ID = id.New()
+		}
+		key := conversationKey(&messages[i])
+		if _, exists := conversations[key]; !exists {
+			order = append(order, key)
+		}
+		conversations[key] = append(conversations[key], i)
+	}
+
+	slots := make(chan struct{}, p.concurrency)
+	var wg sync.WaitGroup
+	for _, key := range order {
+		indexes := conversations[key]
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			for _, i := range indexes {
+				results[i] = p.processMessage(ctx, i, &messages[i])
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary := IncomingBatchSummary{
+		Total:         len(messages),
+		Conversations: len(order),
+		DurationMs:    p.clock.Since(start).Milliseconds(),
+	}
+	for _, result := range results {
+		if result.Success {
+			summary.Succeeded++
+		} else {
+			summary.Failed++
+		}
+	}
+
+	p.logger.WithContext(ctx).Info("Incoming batch processed",
+		"total", summary.Total,
+		"succeeded", summary.Succeeded,
+		"failed", summary.Failed,
+		"conversations", summary.Conversations,
+		"duration_ms", summary.DurationMs)
+	return &IncomingBatchResult{Results: results, Summary: summary}, nil
+}
+
+func (p *IncomingBatchProcessor) processMessage(ctx context.Context, index int, message *domain.IncomingMessage) IncomingMessageResult {
+	result := IncomingMessageResult{Index: index, MessageID: message.ID}
+	if err := ctx.Err(); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	response, err := p.botService.ProcessIncomingMessage(ctx, message)
+	if err != nil {
+		p.logger.WithContext(ctx).Error("Failed to process batched message",
+			"message_id", message.ID,
+			"bot_id", message.BotID,
+			"error", err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	result.Response = response
+	return result
+}
+
+// conversationKey identifica la conversación del mensaje por bot y usuario,
+// como las sesiones: los mensajes de un usuario por dos canales comparten sesión
+// y no se pueden procesar a la vez
+func conversationKey(message *domain.IncomingMessage) string {
+	return message.BotID + "\x00" + message.UserID
+}
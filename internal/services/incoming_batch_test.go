@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchBotService registra el orden en que llegan los mensajes de cada usuario
+type batchBotService struct {
+	BotService
+	mu   sync.Mutex
+	seen map[string][]string
+}
+
+func (s *batchBotService) ProcessIncomingMessage(ctx context.Context, message *domain.IncomingMessage) (*domain.BotResponse, error) {
+	s.mu.Lock()
+	s.seen[message.UserID] = append(s.seen[message.UserID], message.Content)
+	s.mu.Unlock()
+	if message.Content == "boom" {
+		return nil, errors.New("flow not found")
+	}
+	return &domain.BotResponse{Content: "echo: " + message.Content, Type: domain.ResponseTypeText}, nil
+}
+
+func TestIncomingBatchProcessor(t *testing.T) {
+	bots := &batchBotService{seen: make(map[string][]string)}
+	processor := NewIncomingBatchProcessor(bots, 2, 5, clock.NewFake(time.Now()), logger.NewLogger("error"))
+
+	// Los mensajes de un usuario por dos canales son la misma conversación
+	messages := []domain.IncomingMessage{
+		{ID: "m1", BotID: "bot-1", UserID: "ana", Channel: domain.ChannelWeb, Content: "hola"},
+		{BotID: "bot-1", UserID: "luis", Channel: domain.ChannelWeb, Content: "boom"},
+		{BotID: "bot-1", UserID: "ana", Channel: domain.ChannelWhatsApp, Content: "quiero un pedido"},
+		{BotID: "bot-1", UserID: "luis", Channel: domain.ChannelWeb, Content: "hola"},
+		{BotID: "bot-1", UserID: "ana", Channel: domain.ChannelWeb, Content: "gracias"},
+	}
+	result, err := processor.Process(context.Background(), messages)
+	require.NoError(t, err)
+
+	require.Len(t, result.Results, 5)
+	assert.Equal(t, IncomingBatchSummary{Total: 5, Succeeded: 4, Failed: 1, Conversations: 2}, result.Summary)
+	assert.Equal(t, "m1", result.Results[0].MessageID)
+	assert.Equal(t, "echo: quiero un pedido", result.Results[2].Response.Content)
+	assert.False(t, result.Results[1].Success)
+	assert.Equal(t, "flow not found", result.Results[1].Error)
+	for i, r := range result.Results {
+		assert.Equal(t, i, r.Index)
+		assert.NotEmpty(t, r.MessageID)
+	}
+
+	// Un fallo no detiene la conversación y cada una conserva su orden
+	assert.Equal(t, []string{"hola", "quiero un pedido", "gracias"}, bots.seen["ana"])
+	assert.Equal(t, []string{"boom", "hola"}, bots.seen["luis"])
+
+	_, err = processor.Process(context.Background(), append(messages, domain.IncomingMessage{Content: "extra"}))
+	assert.ErrorIs(t, err, ErrIncomingBatchTooLarge)
+}
@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+)
+
+const (
+	// DefaultIntentThreshold es la confianza mínima de un intent cuando el bot
+	// no configura nlu.threshold
+	DefaultIntentThreshold = 0.4
+	// FallbackIntent es el intent de los mensajes que no alcanzan el umbral
+	FallbackIntent = "fallback"
+	// intentModelTTL es cuánto se reutiliza el modelo de un bot antes de releer sus intents
+	intentModelTTL = 30 * time.Second
+)
+
+// IntentScore es un intent candidato con su confianza (0-1)
+type IntentScore struct {
+	Intent     string  `json:"intent"`
+	Confidence float64 `json:"confidence"`
+}
+
+// IntentClassifier clasifica un mensaje entre los intents entrenados de un bot
+type IntentClassifier interface {
+	// Classify devuelve los intents con confianza mayor que cero, de más a menos probable
+	Classify(ctx context.Context, botID, text string) ([]IntentScore, error)
+	// Invalidate descarta el modelo del bot para que el próximo Classify lo rehaga
+	Invalidate(botID string)
+}
+
+// intentModel son los embeddings de los ejemplos de cada intent de un bot
+type intentModel struct {
+	examples  map[string][]map[string]float64
+	expiresAt time.Time
+}
+
+// embeddingIntentClassifier compara el embedding del mensaje con los de los
+// ejemplos de cada intent; la confianza de un intent es la similitud con su
+// ejemplo más parecido
+type embeddingIntentClassifier struct {
+	repo     domain.SmartReplyRepository
+	examples domain.IntentExampleRepository
+	clock    clock.Clock
+	mu       sync.Mutex
+	models   map[string]*intentModel
+}
+
+// NewIntentClassifier crea un clasificador entrenado con las SmartReply de cada
+// bot y sus ejemplos guardados; examples puede ser nil
+func NewIntentClassifier(repo domain.SmartReplyRepository, examples domain.IntentExampleRepository, clk clock.Clock) IntentClassifier {
+	return &embeddingIntentClassifier{
+		repo:     repo,
+		examples: examples,
+		clock:    clock.OrReal(clk),
+		models:   make(map[string]*intentModel),
+	}
+}
+
+func (c *embeddingIntentClassifier) Classify(ctx context.Context, botID, text string) ([]IntentScore, error) {
+	model, err := c.model(ctx, botID)
+	if err != nil {
+		return nil, err
+	}
+	vector := embedText(text)
+
+	scores := make([]IntentScore, 0, len(model.examples))
+	for intent, examples := range model.examples {
+		best := 0.0
+		for _, example := range examples {
+			best = max(best, cosineSimilarity(vector, example))
+		}
+		if best > 0 {
+			scores = append(scores, IntentScore{Intent: intent, Confidence: min(1, best)})
+		}
+	}
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Confidence != scores[j].Confidence {
+			return scores[i].Confidence > scores[j].Confidence
+		}
+		return scores[i].Intent < scores[j].Intent
+	})
+	return scores, nil
+}
+
+func (c *embeddingIntentClassifier) Invalidate(botID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.models, botID)
+}
+
+// model devuelve el modelo del bot, rehecho a partir de sus SmartReply si caducó
+func (c *embeddingIntentClassifier) model(ctx context.Context, botID string) (*intentModel, error) {
+	c.mu.Lock()
+	model, ok := c.models[botID]
+	c.mu.Unlock()
+	if ok && c.clock.Now().Before(model.expiresAt) {
+		return model, nil
+	}
+
+	replies, err := c.repo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load intents for bot %s: %w", botID, err)
+	}
+	trained := make(map[string][]string)
+	if c.examples != nil {
+		stored, err := c.examples.GetByBotID(ctx, botID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load intent examples for bot %s: %w", botID, err)
+		}
+		for _, example := range stored {
+			trained[example.Intent] = append(trained[example.Intent], example.Text)
+		}
+	}
+	model = &intentModel{
+		examples:  make(map[string][]map[string]float64),
+		expiresAt: c.clock.Now().Add(intentModelTTL),
+	}
+	for _, reply := range replies {
+		if reply.Intent == "" {
+			continue
+		}
+		examples := append(append([]string(nil), reply.Examples...), trained[reply.Intent]...)
+		if len(examples) == 0 {
+			// Sin ejemplos, el propio nombre del intent ("order_status") hace de ejemplo
+			examples = []string{strings.NewReplacer("_", " ", "-", " ").Replace(reply.Intent)}
+		}
+		for _, example := range examples {
+			if vector := embedText(example); len(vector) > 0 {
+				model.examples[reply.Intent] = append(model.examples[reply.Intent], vector)
+			}
+		}
+	}
+
+	c.mu.Lock()
+	c.models[botID] = model
+	c.mu.Unlock()
+	return model, nil
+}
+
+// intentThreshold devuelve el umbral configurado o DefaultIntentThreshold
+func intentThreshold(config *domain.NLUConfig) float64 {
+	if config == nil || config.Threshold <= 0 {
+		return DefaultIntentThreshold
+	}
+	return config.Threshold
+}
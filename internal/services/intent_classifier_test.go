@@ -0,0 +1,156 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/testkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedIntents(t *testing.T, repo domain.SmartReplyRepository, botID string) {
+	t.Helper()
+	for _, reply := range []*domain.SmartReply{
+		{ID: "sr-order", BotID: botID, Intent: "order_status", Examples: []string{"dónde está mi pedido", "estado del envío"}},
+		{ID: "sr-refund", BotID: botID, Intent: "refund", Examples: []string{"quiero devolver el producto", "reembolso de mi dinero"}},
+		{ID: "sr-hours", BotID: botID, Intent: "opening_hours"},
+	} {
+		require.NoError(t, repo.Create(context.Background(), reply))
+	}
+}
+
+func TestIntentClassifier_RanksTrainedIntents(t *testing.T) {
+	repo := repositories.NewMockSmartReplyRepository()
+	seedIntents(t, repo, "bot-1")
+	classifier := services.NewIntentClassifier(repo, nil, nil)
+
+	intents, err := classifier.Classify(context.Background(), "bot-1", "¿Dónde está mi pedido?")
+	require.NoError(t, err)
+	require.NotEmpty(t, intents)
+	assert.Equal(t, "order_status", intents[0].Intent)
+	assert.Greater(t, intents[0].Confidence, services.DefaultIntentThreshold)
+
+	intents, err = classifier.Classify(context.Background(), "bot-1", "opening hours")
+	require.NoError(t, err)
+	require.NotEmpty(t, intents)
+	assert.Equal(t, "opening_hours", intents[0].Intent)
+
+	// El modelo se rehace tras Invalidate
+	require.NoError(t, repo.Create(context.Background(), &domain.SmartReply{ID: "sr-agent", BotID: "bot-1", Intent: "agent", Examples: []string{"hablar con una persona"}}))
+	classifier.Invalidate("bot-1")
+	intents, err = classifier.Classify(context.Background(), "bot-1", "quiero hablar con una persona")
+	require.NoError(t, err)
+	assert.Equal(t, "agent", intents[0].Intent)
+}
+
+func TestProcessAIStep_FallsBackBelowThreshold(t *testing.T) {
+	server := testkit.NewServer(t, testkit.Bot("shop").
+		Configure(func(config *domain.BotConfig) {
+			config.NLU = &domain.NLUConfig{Threshold: 0.5, FallbackFlowID: "not-understood"}
+		}).
+		Flows(
+			testkit.Flow("main").Default().Steps(testkit.AI("shop-ai").Next("shop-ai")),
+			testkit.Flow("not-understood").Steps(testkit.End("shop-fallback", "No te he entendido")),
+		))
+	seedIntents(t, server.Repos.SmartReplies, "shop")
+	server.AI.Reply("Tu pedido llega mañana")
+
+	response := server.Send("shop", "user-1", "¿dónde está mi pedido?")
+	assert.Equal(t, "Tu pedido llega mañana", response.Content)
+	assert.Equal(t, "order_status", response.Metadata["intent"])
+
+	response = server.Send("shop", "user-1", "me gusta el fútbol")
+	assert.Equal(t, "No te he entendido", response.Content)
+	assert.Equal(t, services.FallbackIntent, response.Metadata["intent"])
+	assert.Len(t, server.AI.Prompts(), 1)
+}
+
+func TestTrainIntents_AccumulatesExamples(t *testing.T) {
+	ctx := context.Background()
+	replies := repositories.NewMockSmartReplyRepository()
+	examples := repositories.NewMockIntentExampleRepository()
+	svc := services.NewSmartReplyService(replies, examples, nil, nil, logger.NewLogger("error"))
+
+	result, err := svc.TrainIntents(ctx, "bot-1", []domain.IntentTraining{
+		{Intent: "order_status", Response: "Tu pedido está en camino", Utterances: []string{"dónde está mi pedido", "Dónde está mi pedido?"}},
+		{Intent: "refund", Examples: []string{"quiero que me devuelvan el dinero"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &domain.IntentTrainingResult{Trained: 2, IntentsCreated: 2, ExamplesAdded: 2, DuplicatesSkipped: 1}, result)
+
+	// Un segundo entrenamiento suma ejemplos al intent existente
+	result, err = svc.TrainIntents(ctx, "bot-1", []domain.IntentTraining{
+		{Intent: "order_status", Response: "Tu pedido llega mañana", Utterances: []string{"dónde está mi pedido", "cuándo llega el paquete"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &domain.IntentTrainingResult{Trained: 1, IntentsUpdated: 1, ExamplesAdded: 1, DuplicatesSkipped: 1}, result)
+
+	intents, err := svc.GetSmartRepliesByBot(ctx, "bot-1")
+	require.NoError(t, err)
+	assert.Len(t, intents, 2)
+	reply, err := replies.GetByIntent(ctx, "bot-1", "order_status")
+	require.NoError(t, err)
+	assert.Equal(t, "Tu pedido llega mañana", reply.Response)
+
+	stored, err := svc.GetIntentExamples(ctx, "bot-1", "order_status")
+	require.NoError(t, err)
+	require.Len(t, stored, 2)
+	assert.Equal(t, "dónde está mi pedido", stored[0].Text)
+
+	// El mensaje se compara con los ejemplos, no con el nombre del intent
+	scores, err := svc.ClassifyIntent(ctx, "bot-1", "¿cuándo llega mi paquete?")
+	require.NoError(t, err)
+	require.NotEmpty(t, scores)
+	assert.Equal(t, "order_status", scores[0].Intent)
+
+	assert.ErrorIs(t, svc.DeleteIntentExample(ctx, "bot-2", stored[1].ID), services.ErrIntentExampleNotFound)
+	require.NoError(t, svc.DeleteIntentExample(ctx, "bot-1", stored[1].ID))
+	scores, err = svc.ClassifyIntent(ctx, "bot-1", "¿cuándo llega mi paquete?")
+	require.NoError(t, err)
+	for _, score := range scores {
+		assert.Less(t, score.Confidence, 0.5)
+	}
+
+	// Borrar el intent borra sus ejemplos
+	require.NoError(t, svc.DeleteSmartReply(ctx, reply.ID))
+	stored, err = svc.GetIntentExamples(ctx, "bot-1", "order_status")
+	require.NoError(t, err)
+	assert.Empty(t, stored)
+}
+
+func TestProcessAIStep_LowConfidenceResponse(t *testing.T) {
+	server := testkit.NewServer(t, testkit.Bot("shop").
+		Configure(func(config *domain.BotConfig) {
+			config.NLU = &domain.NLUConfig{
+				FallbackFlowID:       "not-understood",
+				ResponseThreshold:    0.95,
+				ClarificationMessage: "¿Puedes darme más detalles?",
+			}
+		}).
+		Flows(
+			testkit.Flow("main").Default().Steps(testkit.AI("shop-ai")),
+			testkit.Flow("not-understood").Steps(testkit.End("shop-fallback", "No te he entendido")),
+		))
+	seedIntents(t, server.Repos.SmartReplies, "shop")
+	// Las respuestas cortas del guion tienen menos confianza que las largas
+	server.AI.Reply("Quizá", "Tu pedido salió ayer del almacén y llegará mañana por la tarde", "No sé", "Tampoco")
+
+	response := server.Send("shop", "user-1", "¿dónde está mi pedido?")
+	assert.Equal(t, "¿Puedes darme más detalles?", response.Content)
+	assert.Equal(t, true, response.Metadata["clarification"])
+
+	// La aclaración vuelve al paso de IA y una respuesta fiable se envía
+	response = server.Send("shop", "user-1", "el pedido que hice el lunes, ¿dónde está?")
+	assert.Equal(t, "Tu pedido salió ayer del almacén y llegará mañana por la tarde", response.Content)
+
+	// Tras agotar las aclaraciones pasa al flujo de fallback
+	server.Send("shop", "user-2", "¿dónde está mi pedido?")
+	response = server.Send("shop", "user-2", "¿dónde está mi pedido?")
+	assert.Equal(t, "No te he entendido", response.Content)
+	assert.Equal(t, "low_confidence", response.Metadata["fallback_reason"])
+}
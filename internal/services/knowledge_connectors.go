@@ -0,0 +1,462 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// SourceDocumentRef identifica un documento remoto antes de descargar su contenido
+type SourceDocumentRef struct {
+	ExternalID string
+	Title      string
+	URL        string
+	ModifiedAt time.Time // cero si la fuente no informa la fecha de modificación
+	Content    string    // texto plano; algunos conectores lo obtienen al listar
+}
+
+// KnowledgeConnector lista y descarga documentos de una fuente externa.
+// Fetch devuelve la referencia con Title y Content en texto plano.
+type KnowledgeConnector interface {
+	Type() domain.KnowledgeSourceType
+	Validate(config map[string]interface{}) error
+	List(ctx context.Context, source *domain.KnowledgeSource) ([]SourceDocumentRef, error)
+	Fetch(ctx context.Context, source *domain.KnowledgeSource, ref SourceDocumentRef) (SourceDocumentRef, error)
+}
+
+const connectorMaxBody = 5 << 20 // 5 MB por documento
+
+var (
+	scriptStylePattern = regexp.MustCompile(`(?is)<(script|style|noscript)[^>]*>.*?</(script|style|noscript)>`)
+	blockTagPattern    = regexp.MustCompile(`(?i)</?(p|div|br|li|h[1-6]|tr|section|article)[^>]*>`)
+	htmlTagPattern     = regexp.MustCompile(`<[^>]+>`)
+	titlePattern       = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	blankLinesPattern  = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// htmlToText extrae el texto legible de un documento HTML
+func htmlToText(markup string) string {
+	text := scriptStylePattern.ReplaceAllString(markup, " ")
+	text = blockTagPattern.ReplaceAllString(text, "\n")
+	text = htmlTagPattern.ReplaceAllString(text, " ")
+	text = html.UnescapeString(text)
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLinesPattern.ReplaceAllString(text, "\n\n"))
+}
+
+func connectorRequest(ctx context.Context, client *http.Client, method, rawURL string, body interface{}, headers map[string]string) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request to %s failed: %w", rawURL, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, connectorMaxBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("request to %s returned status %d", rawURL, resp.StatusCode)
+	}
+	return data, nil
+}
+
+func sourceConfigString(config map[string]interface{}, key string) string {
+	if value, ok := config[key].(string); ok {
+		return strings.TrimSpace(value)
+	}
+	return ""
+}
+
+func sourceConfigInt(config map[string]interface{}, key string, fallback int) int {
+	if value, ok := toFloat(config[key]); ok && value > 0 {
+		return int(value)
+	}
+	return fallback
+}
+
+// websiteConnector rastrea las páginas listadas en un sitemap XML
+// config: sitemap_url, include_prefix (opcional), max_pages (opcional)
+type websiteConnector struct {
+	client *http.Client
+}
+
+type sitemapDocument struct {
+	URLs []struct {
+		Loc     string `xml:"loc"`
+		LastMod string `xml:"lastmod"`
+	} `xml:"url"`
+	Sitemaps []struct {
+		Loc string `xml:"loc"`
+	} `xml:"sitemap"`
+}
+
+func (c *websiteConnector) Type() domain.KnowledgeSourceType { return domain.KnowledgeSourceWebsite }
+
+func (c *websiteConnector) Validate(config map[string]interface{}) error {
+	raw := sourceConfigString(config, "sitemap_url")
+	if raw == "" {
+		return fmt.Errorf("website sources require sitemap_url")
+	}
+	if parsed, err := url.Parse(raw); err != nil || parsed.Host == "" {
+		return fmt.Errorf("invalid sitemap_url")
+	}
+	return nil
+}
+
+func (c *websiteConnector) List(ctx context.Context, source *domain.KnowledgeSource) ([]SourceDocumentRef, error) {
+	maxPages := sourceConfigInt(source.Config, "max_pages", 500)
+	prefix := sourceConfigString(source.Config, "include_prefix")
+
+	var refs []SourceDocumentRef
+	pending := []string{sourceConfigString(source.Config, "sitemap_url")}
+	visited := make(map[string]bool)
+
+	for len(pending) > 0 && len(refs) < maxPages {
+		sitemapURL := pending[0]
+		pending = pending[1:]
+		if visited[sitemapURL] {
+			continue
+		}
+		visited[sitemapURL] = true
+
+		data, err := connectorRequest(ctx, c.client, http.MethodGet, sitemapURL, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var sitemap sitemapDocument
+		if err := xml.Unmarshal(data, &sitemap); err != nil {
+			return nil, fmt.Errorf("invalid sitemap %s: %w", sitemapURL, err)
+		}
+
+		// Índices de sitemaps
+		for _, nested := range sitemap.Sitemaps {
+			pending = append(pending, strings.TrimSpace(nested.Loc))
+		}
+
+		for _, entry := range sitemap.URLs {
+			loc := strings.TrimSpace(entry.Loc)
+			if loc == "" || (prefix != "" && !strings.HasPrefix(loc, prefix)) {
+				continue
+			}
+			ref := SourceDocumentRef{ExternalID: loc, URL: loc, Title: loc}
+			if modified, err := parseLastMod(entry.LastMod); err == nil {
+				ref.ModifiedAt = modified
+			}
+			refs = append(refs, ref)
+			if len(refs) >= maxPages {
+				break
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+func parseLastMod(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, layout := range []string{time.RFC3339, "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid lastmod %q", value)
+}
+
+func (c *websiteConnector) Fetch(ctx context.Context, source *domain.KnowledgeSource, ref SourceDocumentRef) (SourceDocumentRef, error) {
+	data, err := connectorRequest(ctx, c.client, http.MethodGet, ref.URL, nil, nil)
+	if err != nil {
+		return ref, err
+	}
+
+	markup := string(data)
+	if match := titlePattern.FindStringSubmatch(markup); match != nil {
+		ref.Title = strings.TrimSpace(html.UnescapeString(match[1]))
+	}
+	ref.Content = htmlToText(markup)
+	return ref, nil
+}
+
+// notionConnector sincroniza las páginas de una base de datos de Notion
+// config: token, database_id
+type notionConnector struct {
+	client  *http.Client
+	baseURL string
+}
+
+func (c *notionConnector) Type() domain.KnowledgeSourceType { return domain.KnowledgeSourceNotion }
+
+func (c *notionConnector) Validate(config map[string]interface{}) error {
+	if sourceConfigString(config, "token") == "" || sourceConfigString(config, "database_id") == "" {
+		return fmt.Errorf("notion sources require token and database_id")
+	}
+	return nil
+}
+
+func (c *notionConnector) headers(source *domain.KnowledgeSource) map[string]string {
+	return map[string]string{
+		"Authorization":  "Bearer " + sourceConfigString(source.Config, "token"),
+		"Notion-Version": "2022-06-28",
+	}
+}
+
+func (c *notionConnector) List(ctx context.Context, source *domain.KnowledgeSource) ([]SourceDocumentRef, error) {
+	endpoint := fmt.Sprintf("%s/v1/databases/%s/query", c.baseURL, sourceConfigString(source.Config, "database_id"))
+
+	var refs []SourceDocumentRef
+	cursor := ""
+	for {
+		body := map[string]interface{}{"page_size": 100}
+		if cursor != "" {
+			body["start_cursor"] = cursor
+		}
+
+		data, err := connectorRequest(ctx, c.client, http.MethodPost, endpoint, body, c.headers(source))
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Results []struct {
+				ID             string                     `json:"id"`
+				URL            string                     `json:"url"`
+				LastEditedTime time.Time                  `json:"last_edited_time"`
+				Properties     map[string]json.RawMessage `json:"properties"`
+			} `json:"results"`
+			HasMore    bool   `json:"has_more"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("invalid notion response: %w", err)
+		}
+
+		for _, result := range page.Results {
+			refs = append(refs, SourceDocumentRef{
+				ExternalID: result.ID,
+				URL:        result.URL,
+				Title:      notionTitle(result.Properties),
+				ModifiedAt: result.LastEditedTime,
+			})
+		}
+
+		if !page.HasMore || page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	return refs, nil
+}
+
+// notionTitle busca la propiedad de tipo title de una página
+func notionTitle(properties map[string]json.RawMessage) string {
+	for _, raw := range properties {
+		var property struct {
+			Type  string `json:"type"`
+			Title []struct {
+				PlainText string `json:"plain_text"`
+			} `json:"title"`
+		}
+		if json.Unmarshal(raw, &property) != nil || property.Type != "title" {
+			continue
+		}
+		var parts []string
+		for _, text := range property.Title {
+			parts = append(parts, text.PlainText)
+		}
+		return strings.Join(parts, "")
+	}
+	return ""
+}
+
+func (c *notionConnector) Fetch(ctx context.Context, source *domain.KnowledgeSource, ref SourceDocumentRef) (SourceDocumentRef, error) {
+	var lines []string
+	cursor := ""
+	for {
+		endpoint := fmt.Sprintf("%s/v1/blocks/%s/children?page_size=100", c.baseURL, ref.ExternalID)
+		if cursor != "" {
+			endpoint += "&start_cursor=" + url.QueryEscape(cursor)
+		}
+
+		data, err := connectorRequest(ctx, c.client, http.MethodGet, endpoint, nil, c.headers(source))
+		if err != nil {
+			return ref, err
+		}
+
+		var page struct {
+			Results    []map[string]json.RawMessage `json:"results"`
+			HasMore    bool                         `json:"has_more"`
+			NextCursor string                       `json:"next_cursor"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return ref, fmt.Errorf("invalid notion response: %w", err)
+		}
+
+		for _, block := range page.Results {
+			if line := notionBlockText(block); line != "" {
+				lines = append(lines, line)
+			}
+		}
+
+		if !page.HasMore || page.NextCursor == "" {
+			break
+		}
+		cursor = page.NextCursor
+	}
+
+	ref.Content = strings.Join(lines, "\n\n")
+	return ref, nil
+}
+
+// notionBlockText extrae el texto plano de un bloque (párrafo, encabezado, lista, etc.)
+func notionBlockText(block map[string]json.RawMessage) string {
+	var blockType string
+	if err := json.Unmarshal(block["type"], &blockType); err != nil {
+		return ""
+	}
+
+	var content struct {
+		RichText []struct {
+			PlainText string `json:"plain_text"`
+		} `json:"rich_text"`
+	}
+	if err := json.Unmarshal(block[blockType], &content); err != nil {
+		return ""
+	}
+
+	var parts []string
+	for _, text := range content.RichText {
+		parts = append(parts, text.PlainText)
+	}
+	return strings.TrimSpace(strings.Join(parts, ""))
+}
+
+// confluenceConnector sincroniza las páginas de un espacio de Confluence
+// config: base_url, space_key, email, api_token
+type confluenceConnector struct {
+	client *http.Client
+}
+
+func (c *confluenceConnector) Type() domain.KnowledgeSourceType {
+	return domain.KnowledgeSourceConfluence
+}
+
+func (c *confluenceConnector) Validate(config map[string]interface{}) error {
+	for _, key := range []string{"base_url", "space_key", "email", "api_token"} {
+		if sourceConfigString(config, key) == "" {
+			return fmt.Errorf("confluence sources require %s", key)
+		}
+	}
+	return nil
+}
+
+func (c *confluenceConnector) List(ctx context.Context, source *domain.KnowledgeSource) ([]SourceDocumentRef, error) {
+	baseURL := strings.TrimRight(sourceConfigString(source.Config, "base_url"), "/")
+	headers := map[string]string{
+		"Authorization": "Basic " + basicAuth(sourceConfigString(source.Config, "email"), sourceConfigString(source.Config, "api_token")),
+		"Accept":        "application/json",
+	}
+
+	var refs []SourceDocumentRef
+	start := 0
+	for {
+		query := url.Values{}
+		query.Set("spaceKey", sourceConfigString(source.Config, "space_key"))
+		query.Set("type", "page")
+		query.Set("expand", "body.storage,version")
+		query.Set("limit", "50")
+		query.Set("start", fmt.Sprintf("%d", start))
+
+		data, err := connectorRequest(ctx, c.client, http.MethodGet, baseURL+"/rest/api/content?"+query.Encode(), nil, headers)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Results []struct {
+				ID      string `json:"id"`
+				Title   string `json:"title"`
+				Version struct {
+					When time.Time `json:"when"`
+				} `json:"version"`
+				Body struct {
+					Storage struct {
+						Value string `json:"value"`
+					} `json:"storage"`
+				} `json:"body"`
+				Links struct {
+					WebUI string `json:"webui"`
+				} `json:"_links"`
+			} `json:"results"`
+			Size  int `json:"size"`
+			Limit int `json:"limit"`
+		}
+		if err := json.Unmarshal(data, &page); err != nil {
+			return nil, fmt.Errorf("invalid confluence response: %w", err)
+		}
+
+		// La API devuelve el cuerpo al listar, así que no hace falta un Fetch por página
+		for _, result := range page.Results {
+			refs = append(refs, SourceDocumentRef{
+				ExternalID: result.ID,
+				Title:      result.Title,
+				URL:        baseURL + result.Links.WebUI,
+				ModifiedAt: result.Version.When,
+				Content:    htmlToText(result.Body.Storage.Value),
+			})
+		}
+
+		if page.Size < page.Limit || page.Size == 0 {
+			break
+		}
+		start += page.Size
+	}
+
+	return refs, nil
+}
+
+func (c *confluenceConnector) Fetch(ctx context.Context, source *domain.KnowledgeSource, ref SourceDocumentRef) (SourceDocumentRef, error) {
+	return ref, nil
+}
+
+func basicAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
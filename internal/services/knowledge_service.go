@@ -0,0 +1,443 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// knowledgeChunkSize es el tamaño aproximado en caracteres de cada fragmento indexado
+const knowledgeChunkSize = 800
+
+// KnowledgeService gestiona las fuentes de la base de conocimiento y su sincronización
+type KnowledgeService interface {
+	CreateSource(ctx context.Context, source *domain.KnowledgeSource) error
+	GetSource(ctx context.Context, id string) (*domain.KnowledgeSource, error)
+	GetSourcesByBot(ctx context.Context, botID string) ([]*domain.KnowledgeSource, error)
+	UpdateSource(ctx context.Context, source *domain.KnowledgeSource) error
+	DeleteSource(ctx context.Context, id string) error
+	GetDocuments(ctx context.Context, sourceID string) ([]*domain.KnowledgeDocument, error)
+
+	// SyncSource sincroniza la fuente de forma incremental y devuelve el estado resultante
+	SyncSource(ctx context.Context, id string) (*domain.SyncStatus, error)
+	Search(ctx context.Context, botID, query string, limit int) ([]domain.KnowledgeSearchResult, error)
+
+	// Start y Stop controlan la sincronización periódica de las fuentes habilitadas
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+type knowledgeService struct {
+	sourceRepo   domain.KnowledgeSourceRepository
+	documentRepo domain.KnowledgeDocumentRepository
+	connectors   map[domain.KnowledgeSourceType]KnowledgeConnector
+	logger       logger.Logger
+
+	mu      sync.Mutex
+	syncing map[string]bool
+	cancel  context.CancelFunc
+}
+
+// NewKnowledgeService crea el servicio de base de conocimiento con los conectores built-in
+func NewKnowledgeService(
+	sourceRepo domain.KnowledgeSourceRepository,
+	documentRepo domain.KnowledgeDocumentRepository,
+	logger logger.Logger,
+) KnowledgeService {
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	service := &knowledgeService{
+		sourceRepo:   sourceRepo,
+		documentRepo: documentRepo,
+		connectors:   make(map[domain.KnowledgeSourceType]KnowledgeConnector),
+		logger:       logger,
+		syncing:      make(map[string]bool),
+	}
+
+	for _, connector := range []KnowledgeConnector{
+		&websiteConnector{client: client},
+		&notionConnector{client: client, baseURL: "https://api.notion.com"},
+		&confluenceConnector{client: client},
+	} {
+		service.connectors[connector.Type()] = connector
+	}
+
+	return service
+}
+
+func (s *knowledgeService) CreateSource(ctx context.Context, source *domain.KnowledgeSource) error {
+	if err := s.validateSource(source); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	source.Status = domain.SyncStatus{State: domain.SyncStateIdle}
+	source.CreatedAt = now
+	source.UpdatedAt = now
+	return s.sourceRepo.Create(ctx, source)
+}
+
+func (s *knowledgeService) GetSource(ctx context.Context, id string) (*domain.KnowledgeSource, error) {
+	return s.sourceRepo.GetByID(ctx, id)
+}
+
+func (s *knowledgeService) GetSourcesByBot(ctx context.Context, botID string) ([]*domain.KnowledgeSource, error) {
+	return s.sourceRepo.GetByBotID(ctx, botID)
+}
+
+func (s *knowledgeService) UpdateSource(ctx context.Context, source *domain.KnowledgeSource) error {
+	if err := s.validateSource(source); err != nil {
+		return err
+	}
+
+	source.UpdatedAt = time.Now()
+	return s.sourceRepo.Update(ctx, source)
+}
+
+func (s *knowledgeService) DeleteSource(ctx context.Context, id string) error {
+	if err := s.sourceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	removed, err := s.documentRepo.DeleteBySourceID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete source documents: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Knowledge source deleted", "source_id", id, "documents_removed", removed)
+	return nil
+}
+
+func (s *knowledgeService) GetDocuments(ctx context.Context, sourceID string) ([]*domain.KnowledgeDocument, error) {
+	return s.documentRepo.GetBySourceID(ctx, sourceID)
+}
+
+func (s *knowledgeService) validateSource(source *domain.KnowledgeSource) error {
+	connector, exists := s.connectors[source.Type]
+	if !exists {
+		return fmt.Errorf("unsupported knowledge source type: %s", source.Type)
+	}
+	if source.SyncIntervalMinutes < 0 {
+		return fmt.Errorf("sync_interval_minutes must be positive")
+	}
+	return connector.Validate(source.Config)
+}
+
+func (s *knowledgeService) SyncSource(ctx context.Context, id string) (*domain.SyncStatus, error) {
+	source, err := s.sourceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	connector, exists := s.connectors[source.Type]
+	if !exists {
+		return nil, fmt.Errorf("unsupported knowledge source type: %s", source.Type)
+	}
+
+	s.mu.Lock()
+	if s.syncing[id] {
+		s.mu.Unlock()
+		return nil, fmt.Errorf("source %s is already syncing", id)
+	}
+	s.syncing[id] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.syncing, id)
+		s.mu.Unlock()
+	}()
+
+	started := time.Now()
+	previous := source.Status
+	source.Status = domain.SyncStatus{State: domain.SyncStateRunning, StartedAt: &started, LastSyncAt: previous.LastSyncAt}
+	if err := s.sourceRepo.Update(ctx, source); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to mark source as syncing", "source_id", id, "error", err)
+	}
+
+	status, syncErr := s.syncDocuments(ctx, source, connector)
+
+	finished := time.Now()
+	status.StartedAt = &started
+	status.LastSyncAt = &finished
+	status.Duration = finished.Sub(started).Milliseconds()
+	status.State = domain.SyncStateSuccess
+	if syncErr != nil {
+		status.State = domain.SyncStateFailed
+		status.LastError = syncErr.Error()
+	}
+
+	source.Status = status
+	if err := s.sourceRepo.Update(ctx, source); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to save sync status", "source_id", id, "error", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Knowledge source synced",
+		"source_id", id,
+		"state", status.State,
+		"added", status.Added,
+		"updated", status.Updated,
+		"removed", status.Removed,
+		"unchanged", status.Unchanged,
+		"failed", status.Failed)
+
+	return &status, syncErr
+}
+
+// syncDocuments aplica los cambios de la fuente: sólo descarga y re-indexa los documentos modificados
+func (s *knowledgeService) syncDocuments(ctx context.Context, source *domain.KnowledgeSource, connector KnowledgeConnector) (domain.SyncStatus, error) {
+	var status domain.SyncStatus
+
+	refs, err := connector.List(ctx, source)
+	if err != nil {
+		return status, fmt.Errorf("failed to list documents: %w", err)
+	}
+
+	existing, err := s.documentRepo.GetBySourceID(ctx, source.ID)
+	if err != nil {
+		return status, fmt.Errorf("failed to load indexed documents: %w", err)
+	}
+	indexed := make(map[string]*domain.KnowledgeDocument, len(existing))
+	for _, document := range existing {
+		indexed[document.ExternalID] = document
+	}
+
+	seen := make(map[string]bool, len(refs))
+	var lastErr error
+	for _, ref := range refs {
+		seen[ref.ExternalID] = true
+		document, known := indexed[ref.ExternalID]
+
+		// Sin cambios según la fecha de modificación remota: no hace falta descargar
+		if known && !ref.ModifiedAt.IsZero() && !ref.ModifiedAt.After(document.SourceModifiedAt) {
+			status.Unchanged++
+			continue
+		}
+
+		fetched, err := connector.Fetch(ctx, source, ref)
+		if err != nil {
+			status.Failed++
+			lastErr = err
+			s.logger.WithContext(ctx).Warn("Failed to fetch knowledge document", "source_id", source.ID, "document", ref.ExternalID, "error", err)
+			continue
+		}
+
+		hash := contentHash(fetched.Content)
+		if known && document.ContentHash == hash {
+			document.SourceModifiedAt = fetched.ModifiedAt
+			if err := s.documentRepo.Save(ctx, document); err != nil {
+				s.logger.WithContext(ctx).Warn("Failed to update knowledge document", "document_id", document.ID, "error", err)
+			}
+			status.Unchanged++
+			continue
+		}
+
+		if !known {
+			document = &domain.KnowledgeDocument{
+				BotID:      source.BotID,
+				SourceID:   source.ID,
+				ExternalID: ref.ExternalID,
+			}
+		}
+		document.Title = fetched.Title
+		document.URL = fetched.URL
+		document.Content = fetched.Content
+		document.ContentHash = hash
+		document.Chunks = chunkText(fetched.Content, knowledgeChunkSize)
+		document.SourceModifiedAt = fetched.ModifiedAt
+		document.IndexedAt = time.Now()
+
+		if err := s.documentRepo.Save(ctx, document); err != nil {
+			status.Failed++
+			lastErr = err
+			continue
+		}
+		if known {
+			status.Updated++
+		} else {
+			status.Added++
+		}
+	}
+
+	// Documentos que ya no existen en la fuente
+	for externalID, document := range indexed {
+		if seen[externalID] {
+			continue
+		}
+		if err := s.documentRepo.Delete(ctx, document.ID); err != nil {
+			status.Failed++
+			lastErr = err
+			continue
+		}
+		status.Removed++
+	}
+
+	if lastErr != nil && status.Added+status.Updated+status.Unchanged == 0 {
+		return status, lastErr
+	}
+	return status, nil
+}
+
+func (s *knowledgeService) Search(ctx context.Context, botID, query string, limit int) ([]domain.KnowledgeSearchResult, error) {
+	if limit <= 0 {
+		limit = 5
+	}
+
+	documents, err := s.documentRepo.GetByBotID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load knowledge documents: %w", err)
+	}
+
+	queryVector := embedText(query)
+	if len(queryVector) == 0 {
+		return []domain.KnowledgeSearchResult{}, nil
+	}
+
+	results := []domain.KnowledgeSearchResult{}
+	for _, document := range documents {
+		for _, chunk := range document.Chunks {
+			score := cosineSimilarity(queryVector, embedText(chunk))
+			if score <= 0 {
+				continue
+			}
+			results = append(results, domain.KnowledgeSearchResult{
+				DocumentID: document.ID,
+				SourceID:   document.SourceID,
+				Title:      document.Title,
+				URL:        document.URL,
+				Chunk:      chunk,
+				Score:      score,
+			})
+		}
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+func (s *knowledgeService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return fmt.Errorf("knowledge sync already started")
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.runScheduledSyncs(ctx)
+	return nil
+}
+
+func (s *knowledgeService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	return nil
+}
+
+func (s *knowledgeService) runScheduledSyncs(ctx context.Context) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			s.syncDueSources(ctx, now)
+		}
+	}
+}
+
+// syncDueSources sincroniza las fuentes cuyo intervalo ya transcurrió
+func (s *knowledgeService) syncDueSources(ctx context.Context, now time.Time) {
+	sources, err := s.sourceRepo.GetEnabled(ctx)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("Failed to load knowledge sources", "error", err)
+		return
+	}
+
+	for _, source := range sources {
+		if source.SyncIntervalMinutes <= 0 {
+			continue
+		}
+		interval := time.Duration(source.SyncIntervalMinutes) * time.Minute
+		if source.Status.LastSyncAt != nil && now.Sub(*source.Status.LastSyncAt) < interval {
+			continue
+		}
+
+		if _, err := s.SyncSource(ctx, source.ID); err != nil {
+			s.logger.WithContext(ctx).Warn("Scheduled knowledge sync failed", "source_id", source.ID, "error", err)
+		}
+	}
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// chunkText divide el texto en fragmentos de hasta size caracteres respetando los párrafos
+func chunkText(text string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+
+	flush := func() {
+		if chunk := strings.TrimSpace(current.String()); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		current.Reset()
+	}
+
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+
+		if current.Len() > 0 && current.Len()+len(paragraph) > size {
+			flush()
+		}
+
+		// Párrafos más largos que el fragmento se cortan por palabras
+		for len(paragraph) > size {
+			cut := strings.LastIndex(paragraph[:size], " ")
+			if cut <= 0 {
+				cut = size
+				for cut > 0 && !utf8.RuneStart(paragraph[cut]) {
+					cut--
+				}
+			}
+			current.WriteString(paragraph[:cut])
+			flush()
+			paragraph = strings.TrimSpace(paragraph[cut:])
+		}
+
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	flush()
+
+	return chunks
+}
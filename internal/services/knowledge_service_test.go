@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnowledgeService_WebsiteIncrementalSync(t *testing.T) {
+	pages := map[string]string{
+		"/envios":  "<html><title>Envíos</title><body><p>Hacemos envíos a todo el país en 48 horas.</p></body></html>",
+		"/horario": "<html><title>Horario</title><body><p>Atendemos de lunes a viernes de 9 a 18.</p></body></html>",
+	}
+	lastmod := map[string]string{"/envios": "2024-06-01", "/horario": "2024-06-01"}
+	fetches := 0
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sitemap.xml" {
+			fmt.Fprint(w, `<urlset>`)
+			for path := range pages {
+				fmt.Fprintf(w, `<url><loc>%s%s</loc><lastmod>%s</lastmod></url>`, server.URL, path, lastmod[path])
+			}
+			fmt.Fprint(w, `</urlset>`)
+			return
+		}
+		fetches++
+		fmt.Fprint(w, pages[r.URL.Path])
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	svc := NewKnowledgeService(repositories.NewMockKnowledgeSourceRepository(), repositories.NewMockKnowledgeDocumentRepository(), logger.NewLogger("error"))
+
+	source := &domain.KnowledgeSource{
+		BotID:  "bot-1",
+		Name:   "Sitio",
+		Type:   domain.KnowledgeSourceWebsite,
+		Config: map[string]interface{}{"sitemap_url": server.URL + "/sitemap.xml"},
+	}
+	require.NoError(t, svc.CreateSource(ctx, source))
+
+	status, err := svc.SyncSource(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Added)
+	assert.Equal(t, 2, fetches)
+
+	// Sin cambios en lastmod no se vuelve a descargar
+	status, err = svc.SyncSource(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, status.Unchanged)
+	assert.Equal(t, 2, fetches)
+
+	pages["/horario"] = "<html><title>Horario</title><body><p>Atendemos de lunes a sábado de 9 a 20.</p></body></html>"
+	lastmod["/horario"] = "2024-06-10"
+	delete(pages, "/envios")
+
+	status, err = svc.SyncSource(ctx, source.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.Updated)
+	assert.Equal(t, 1, status.Removed)
+	assert.Equal(t, domain.SyncStateSuccess, status.State)
+
+	results, err := svc.Search(ctx, "bot-1", "qué horario de atención tienen los sábados", 3)
+	require.NoError(t, err)
+	require.NotEmpty(t, results)
+	assert.Equal(t, "Horario", results[0].Title)
+	assert.Contains(t, results[0].Chunk, "sábado")
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+const (
+	// clarificationsKey cuenta en el contexto las aclaraciones seguidas que ha
+	// pedido el bot por respuestas poco fiables
+	clarificationsKey = "clarifications"
+	// defaultClarificationMessage se envía si el bot no configura nlu.clarification_message
+	defaultClarificationMessage = "I'm not sure I understood you. Could you rephrase it or give me more details?"
+)
+
+// lowConfidenceResponse decide qué hacer cuando la confianza de la respuesta de
+// IA no llega a nlu.response_threshold: pedir aclaración y quedarse en el paso
+// o, agotadas las aclaraciones, pasar al flujo de fallback. handled es false si
+// la respuesta se puede enviar.
+func (s *botService) lowConfidenceResponse(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession, reply *domain.SmartReply) (*domain.BotResponse, *string, bool, error) {
+	var nlu *domain.NLUConfig
+	if bot, err := s.botRepo.GetByID(ctx, message.BotID); err == nil {
+		nlu = parseBotConfig(bot).NLU
+	}
+	if nlu == nil || nlu.ResponseThreshold <= 0 || reply.Confidence >= nlu.ResponseThreshold {
+		delete(session.Context, clarificationsKey)
+		return nil, nil, false, nil
+	}
+
+	asked, _ := toFloat(session.Context[clarificationsKey])
+	maxClarifications := nlu.MaxClarifications
+	if maxClarifications <= 0 {
+		maxClarifications = 1
+	}
+	s.logger.WithContext(ctx).Info("Low-confidence AI response withheld",
+		"bot_id", message.BotID,
+		"confidence", reply.Confidence,
+		"threshold", nlu.ResponseThreshold,
+		"clarifications", int(asked))
+
+	if int(asked) >= maxClarifications && nlu.FallbackFlowID != "" && session.CurrentFlowID != nlu.FallbackFlowID {
+		flow, err := s.flowRepo.GetByID(ctx, nlu.FallbackFlowID)
+		if err == nil {
+			delete(session.Context, clarificationsKey)
+			response, next, err := s.enterFallbackFlow(ctx, flow, message, session, map[string]interface{}{
+				"intent":          FallbackIntent,
+				"confidence":      reply.Confidence,
+				"fallback_reason": "low_confidence",
+			})
+			return response, next, true, err
+		}
+		s.logger.WithContext(ctx).Warn("NLU fallback flow not found", "bot_id", message.BotID, "flow_id", nlu.FallbackFlowID, "error", err)
+	}
+
+	session.Context[clarificationsKey] = int(asked) + 1
+	text := nlu.ClarificationMessage
+	if text == "" {
+		text = defaultClarificationMessage
+	}
+	// El paso se repite para que la aclaración del usuario vuelva a la IA
+	return &domain.BotResponse{
+		Content: text,
+		Type:    domain.ResponseTypeText,
+		Metadata: map[string]interface{}{
+			"intent":        reply.Intent,
+			"confidence":    reply.Confidence,
+			"clarification": true,
+		},
+	}, &step.ID, true, nil
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+const (
+	// maxPromptMemories limita las memorias del usuario que recibe un paso de IA
+	maxPromptMemories = 5
+	// pinnedMemoryImportance es la importancia a partir de la cual una memoria
+	// se incluye aunque no tenga relación con el mensaje
+	pinnedMemoryImportance = 8
+
+	// Claves que los pasos de IA reciben en el contexto del prompt
+	UserMemoriesContextKey = "user_memories"
+	UserSummaryContextKey  = "user_summary"
+)
+
+// aiStepContext devuelve el contexto de la sesión con las memorias del usuario
+// relacionadas con el mensaje y su resumen de contexto. No modifica la sesión:
+// las memorias solo viajan en el prompt.
+func (s *botService) aiStepContext(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession) map[string]interface{} {
+	if s.memorySvc == nil {
+		return session.Context
+	}
+
+	memories, err := s.memorySvc.RecallMemories(ctx, message.UserID, message.BotID, message.Content, maxPromptMemories)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to load user memories", "bot_id", message.BotID, "error", err)
+	}
+	lines := make([]string, 0, maxPromptMemories)
+	for _, memory := range memories {
+		lines = append(lines, fmt.Sprintf("%s: %s", memory.Key, memoryText(memory)))
+	}
+
+	summary, err := s.memorySvc.GetContextSummary(ctx, message.UserID, message.BotID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to load context summary", "bot_id", message.BotID, "error", err)
+		summary = nil
+	}
+	summaryText := ""
+	if summary != nil {
+		parts := make([]string, 0, len(summary.KeyPoints)+1)
+		for _, part := range append([]string{summary.Summary}, summary.KeyPoints...) {
+			if part = strings.TrimSpace(part); part != "" {
+				parts = append(parts, part)
+			}
+		}
+		summaryText = strings.Join(parts, "; ")
+	}
+
+	if len(lines) == 0 && summaryText == "" {
+		return session.Context
+	}
+	result := make(map[string]interface{}, len(session.Context)+2)
+	for key, value := range session.Context {
+		result[key] = value
+	}
+	if len(lines) > 0 {
+		result[UserMemoriesContextKey] = strings.Join(lines, "; ")
+	}
+	if summaryText != "" {
+		result[UserSummaryContextKey] = summaryText
+	}
+	return result
+}
+
+// relevantMemories puntúa cada memoria por las palabras que comparte con el
+// mensaje (clave, etiquetas y texto) y su importancia. Las memorias sin relación
+// solo entran si son de importancia alta.
+func relevantMemories(memories []*domain.Memory, text string, limit int) []*domain.Memory {
+	words := make(map[string]bool)
+	for _, word := range strings.Fields(normalizeText(text)) {
+		if len([]rune(word)) > 2 {
+			words[word] = true
+		}
+	}
+
+	type scored struct {
+		memory *domain.Memory
+		score  int
+	}
+	var candidates []scored
+	for _, memory := range memories {
+		haystack := make(map[string]bool)
+		for _, word := range strings.Fields(normalizeText(memory.Key + " " + strings.Join(memory.Tags, " ") + " " + memoryText(memory))) {
+			haystack[word] = true
+		}
+		overlap := 0
+		for word := range words {
+			if haystack[word] {
+				overlap++
+			}
+		}
+		if overlap == 0 && memory.Importance < pinnedMemoryImportance {
+			continue
+		}
+		candidates = append(candidates, scored{memory: memory, score: overlap*10 + memory.Importance})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	result := make([]*domain.Memory, 0, min(len(candidates), limit))
+	for _, candidate := range candidates[:min(len(candidates), limit)] {
+		result = append(result, candidate.memory)
+	}
+	return result
+}
+
+// memoryText es content.text o, si no hay, el contenido serializado
+func memoryText(memory *domain.Memory) string {
+	if text, ok := memory.Content["text"].(string); ok {
+		return text
+	}
+	if len(memory.Content) == 0 {
+		return ""
+	}
+	data, err := json.Marshal(memory.Content)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/company/bot-service/internal/ai"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/vectorstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAIStepContext_AddsRelevantMemories(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	memories := NewMemoryService(log, 0, 0, nil)
+	bots := NewBotService(nil, nil, nil, nil, nil, nil, nil, nil, nil, memories, nil, nil, nil, nil, log).(*botService)
+
+	for _, memory := range []*domain.Memory{
+		{UserID: "user-1", BotID: "bot-1", Key: "direccion_envio", Type: domain.MemoryTypePersonal, Importance: 5, Content: map[string]interface{}{"text": "Calle Mayor 3, Madrid"}},
+		{UserID: "user-1", BotID: "bot-1", Key: "mascota", Type: domain.MemoryTypePersonal, Importance: 3, Content: map[string]interface{}{"text": "Tiene un gato"}},
+		{UserID: "user-1", BotID: "bot-1", Key: "idioma", Type: domain.MemoryTypePreference, Importance: 9, Content: map[string]interface{}{"text": "Prefiere que le hablen de usted"}},
+		{UserID: "user-2", BotID: "bot-1", Key: "direccion_envio", Importance: 5, Content: map[string]interface{}{"text": "Otra calle"}},
+	} {
+		require.NoError(t, memories.StoreMemory(ctx, memory))
+	}
+	require.NoError(t, memories.UpdateContextSummary(ctx, &domain.ContextSummary{UserID: "user-1", BotID: "bot-1",
+		Summary: "Cliente desde 2021", KeyPoints: []string{"Pedido #123 retrasado"}}))
+
+	session := &domain.ConversationSession{Context: map[string]interface{}{"channel": "web"}}
+	message := &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: "¿Cuál es mi dirección de envío?"}
+	result := bots.aiStepContext(ctx, message, session)
+
+	assert.Equal(t, "direccion_envio: Calle Mayor 3, Madrid; idioma: Prefiere que le hablen de usted", result[UserMemoriesContextKey])
+	assert.Equal(t, "Cliente desde 2021; Pedido #123 retrasado", result[UserSummaryContextKey])
+	assert.Equal(t, "web", result["channel"])
+	assert.NotContains(t, session.Context, UserMemoriesContextKey, "the session context is not modified")
+
+	// Sin memorias ni resumen el contexto es el de la sesión
+	other := &domain.IncomingMessage{BotID: "bot-2", UserID: "user-1", Content: "hola"}
+	assert.Equal(t, session.Context, bots.aiStepContext(ctx, other, session))
+
+	found, err := memories.SearchMemories(ctx, "user-1", "bot-1", "e", 2)
+	require.NoError(t, err)
+	require.Len(t, found, 2)
+	assert.Equal(t, "idioma", found[0].Key)
+	assert.Equal(t, "direccion_envio", found[1].Key)
+}
+
+type failingEmbedder struct{}
+
+func (failingEmbedder) Embed(ctx context.Context, texts []string, options ...ai.Option) ([][]float32, error) {
+	return nil, errors.New("embeddings unavailable")
+}
+
+func TestMemoryService_SemanticSearch(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	memories := NewMemoryService(log, 0, 0, nil)
+	memories.UseEmbeddings(MemoryEmbeddingConfig{
+		Embedder: ai.NewMockAIClient(nil, log).(ai.Embedder),
+		Store:    vectorstore.NewHNSW(vectorstore.HNSWConfig{}),
+	})
+
+	for _, memory := range []*domain.Memory{
+		{UserID: "user-1", BotID: "bot-1", Key: "mascota", Importance: 3, Content: map[string]interface{}{"text": "Tiene un gato"}},
+		{UserID: "user-1", BotID: "bot-1", Key: "idioma", Importance: 9, Content: map[string]interface{}{"text": "Prefiere que le hablen de usted"}},
+		{UserID: "user-2", BotID: "bot-1", Key: "mascota", Importance: 3, Content: map[string]interface{}{"text": "Tiene un gato"}},
+	} {
+		require.NoError(t, memories.StoreMemory(ctx, memory))
+	}
+
+	found, err := memories.SearchMemories(ctx, "user-1", "bot-1", "gato", 5)
+	require.NoError(t, err)
+	require.Len(t, found, 1, "unrelated memories are below the minimum score")
+	assert.Equal(t, "mascota", found[0].Key)
+	assert.Greater(t, found[0].Score, 0.3)
+
+	// El recuerdo añade las memorias importantes aunque no tengan relación
+	recalled, err := memories.RecallMemories(ctx, "user-1", "bot-1", "gato", 5)
+	require.NoError(t, err)
+	require.Len(t, recalled, 2)
+	assert.Equal(t, "mascota", recalled[0].Key)
+	assert.Equal(t, "idioma", recalled[1].Key)
+
+	// Al actualizar se reindexa y al borrar desaparece
+	require.NoError(t, memories.UpdateMemory(ctx, &domain.Memory{UserID: "user-1", BotID: "bot-1", Key: "mascota", Importance: 3,
+		Content: map[string]interface{}{"text": "Tiene un perro"}}))
+	found, err = memories.SearchMemories(ctx, "user-1", "bot-1", "gato", 5)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+	require.NoError(t, memories.DeleteMemory(ctx, "user-1", "bot-1", "mascota"))
+	found, err = memories.SearchMemories(ctx, "user-1", "bot-1", "perro", 5)
+	require.NoError(t, err)
+	assert.Empty(t, found)
+
+	// Si el proveedor falla se busca por texto
+	memories.UseEmbeddings(MemoryEmbeddingConfig{Embedder: failingEmbedder{}, Store: vectorstore.NewHNSW(vectorstore.HNSWConfig{})})
+	found, err = memories.SearchMemories(ctx, "user-1", "bot-1", "usted", 5)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, "idioma", found[0].Key)
+	assert.Zero(t, found[0].Score)
+}
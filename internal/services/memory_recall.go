@@ -0,0 +1,184 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/company/bot-service/internal/ai"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/vectorstore"
+)
+
+// defaultMemoryMinScore descarta coincidencias semánticas demasiado débiles
+const defaultMemoryMinScore = 0.3
+
+// MemoryEmbeddingConfig activa la búsqueda semántica de memorias: cada memoria
+// se guarda como vector en Store y las búsquedas comparan por similitud coseno
+type MemoryEmbeddingConfig struct {
+	Embedder ai.Embedder
+	Store    vectorstore.Store
+	Model    string  // vacío usa el modelo por defecto del cliente
+	MinScore float64 // similitud mínima; 0 usa defaultMemoryMinScore
+}
+
+// UseEmbeddings activa la búsqueda semántica. Solo se indexan las memorias que
+// se guarden a partir de ahora.
+func (s *memoryService) UseEmbeddings(config MemoryEmbeddingConfig) {
+	if config.Embedder == nil || config.Store == nil {
+		config = MemoryEmbeddingConfig{}
+	} else if config.MinScore <= 0 {
+		config.MinScore = defaultMemoryMinScore
+	}
+	s.mu.Lock()
+	s.embeddings = config
+	s.mu.Unlock()
+}
+
+// RecallMemories devuelve las memorias más relacionadas con text, completadas
+// con las de importancia alta aunque no tengan relación
+func (s *memoryService) RecallMemories(ctx context.Context, userID, botID, text string, limit int) ([]*domain.Memory, error) {
+	if limit <= 0 {
+		limit = maxPromptMemories
+	}
+	memories, err := s.GetUserMemories(ctx, userID, botID)
+	if err != nil {
+		return nil, err
+	}
+	if s.embeddingConfig().Embedder == nil || strings.TrimSpace(text) == "" {
+		return relevantMemories(memories, text, limit), nil
+	}
+
+	matches, err := s.semanticSearch(ctx, userID, botID, text, limit)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Semantic memory recall failed, using word overlap", "bot_id", botID, "error", err)
+		return relevantMemories(memories, text, limit), nil
+	}
+	seen := make(map[string]bool, len(matches))
+	for _, memory := range matches {
+		seen[memory.Key] = true
+	}
+	// GetUserMemories ya viene ordenado por importancia
+	for _, memory := range memories {
+		if len(matches) >= limit || memory.Importance < pinnedMemoryImportance {
+			break
+		}
+		if !seen[memory.Key] {
+			matches = append(matches, memory)
+		}
+	}
+	return matches, nil
+}
+
+func (s *memoryService) embeddingConfig() MemoryEmbeddingConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.embeddings
+}
+
+// semanticSearch busca por similitud con query las memorias vigentes del usuario
+func (s *memoryService) semanticSearch(ctx context.Context, userID, botID, query string, limit int) ([]*domain.Memory, error) {
+	config := s.embeddingConfig()
+	if config.Embedder == nil {
+		return nil, errors.New("memory embeddings are not configured")
+	}
+	if limit <= 0 {
+		limit = 10
+	}
+	vector, err := s.embed(ctx, config, query)
+	if err != nil {
+		return nil, err
+	}
+	// Se piden de más porque algunas pueden haber expirado
+	matches, err := config.Store.Search(ctx, memoryNamespace(userID, botID), vector, limit*2)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	now := s.clock.Now()
+	result := make([]*domain.Memory, 0, limit)
+	for _, match := range matches {
+		if match.Score < config.MinScore {
+			break
+		}
+		memory, ok := s.memories[memoryNamespace(userID, botID)+":"+match.ID]
+		if !ok || now.After(memory.ExpiresAt) {
+			continue
+		}
+		memoryCopy := *memory
+		memoryCopy.Score = match.Score
+		result = append(result, &memoryCopy)
+		if len(result) == limit {
+			break
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Score > result[j].Score })
+	return result, nil
+}
+
+// embedMemory calcula el vector de la memoria; nil si no hay embeddings o fallan,
+// en cuyo caso la memoria se guarda igualmente pero no aparece en búsquedas semánticas
+func (s *memoryService) embedMemory(ctx context.Context, memory *domain.Memory) []float32 {
+	config := s.embeddingConfig()
+	if config.Embedder == nil {
+		return nil
+	}
+	text := strings.ReplaceAll(memory.Key, "_", " ") + ": " + memoryText(memory)
+	if len(memory.Tags) > 0 {
+		text += " (" + strings.Join(memory.Tags, ", ") + ")"
+	}
+	vector, err := s.embed(ctx, config, text)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to embed memory", "bot_id", memory.BotID, "key", memory.Key, "error", err)
+		return nil
+	}
+	return vector
+}
+
+func (s *memoryService) embed(ctx context.Context, config MemoryEmbeddingConfig, text string) ([]float32, error) {
+	var options []ai.Option
+	if config.Model != "" {
+		options = append(options, ai.WithModel(config.Model))
+	}
+	vectors, err := config.Embedder.Embed(ctx, []string{text}, options...)
+	if err != nil {
+		return nil, err
+	}
+	if len(vectors) != 1 {
+		return nil, fmt.Errorf("expected 1 embedding, got %d", len(vectors))
+	}
+	return vectors[0], nil
+}
+
+// indexMemory guarda el vector de la memoria; con vector nil borra el anterior
+// para que no devuelva contenido desactualizado
+func (s *memoryService) indexMemory(ctx context.Context, memory *domain.Memory, vector []float32) {
+	if vector == nil {
+		s.unindexMemory(ctx, memory)
+		return
+	}
+	store := s.embeddingConfig().Store
+	if err := store.Upsert(ctx, memoryNamespace(memory.UserID, memory.BotID), memory.Key, vector); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to index memory", "bot_id", memory.BotID, "key", memory.Key, "error", err)
+	}
+}
+
+func (s *memoryService) unindexMemory(ctx context.Context, memory *domain.Memory) {
+	store := s.embeddingConfig().Store
+	if store == nil || memory == nil {
+		return
+	}
+	if err := store.Delete(ctx, memoryNamespace(memory.UserID, memory.BotID), memory.Key); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to remove memory vector", "bot_id", memory.BotID, "key", memory.Key, "error", err)
+	}
+}
+
+// memoryNamespace agrupa los vectores por usuario y bot, igual que las claves
+// del mapa de memorias
+func memoryNamespace(userID, botID string) string {
+	return userID + ":" + botID
+}
@@ -2,15 +2,22 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/clock"
 	"github.com/company/bot-service/pkg/logger"
 )
 
+// ErrMemoryNotFound indica que la memoria no existe o ya expiró
+var ErrMemoryNotFound = errors.New("memory not found")
+
 // MemoryService define las operaciones para gestión de memoria persistente
 type MemoryService interface {
 	// Memoria a largo plazo
@@ -30,6 +37,10 @@ type MemoryService interface {
 	// Limpieza
 	CleanupExpiredMemories(ctx context.Context) error
 	GetMemoryStats(ctx context.Context, userID, botID string) (*domain.MemoryStats, error)
+	
+	// Recuerdo para los pasos de IA
+	RecallMemories(ctx context.Context, userID, botID, text string, limit int) ([]*domain.Memory, error)
+	UseEmbeddings(config MemoryEmbeddingConfig)
 }
 
 // memoryService implementa MemoryService
@@ -40,10 +51,12 @@ type memoryService struct {
 	logger        logger.Logger
 	maxMemories   int
 	retentionDays int
+	clock         clock.Clock
+	embeddings    MemoryEmbeddingConfig
 }
 
-// NewMemoryService crea un nuevo servicio de memoria
-func NewMemoryService(logger logger.Logger, maxMemories, retentionDays int) MemoryService {
+// NewMemoryService crea un nuevo servicio de memoria; con clk nil usa el reloj del sistema
+func NewMemoryService(logger logger.Logger, maxMemories, retentionDays int, clk clock.Clock) MemoryService {
 	if maxMemories <= 0 {
 		maxMemories = 1000
 	}
@@ -51,17 +64,30 @@ func NewMemoryService(logger logger.Logger, maxMemories, retentionDays int) Memo
 		retentionDays = 30
 	}
 	
-	return &memoryService{
+	service := &memoryService{
 		memories:      make(map[string]*domain.Memory),
 		summaries:     make(map[string]*domain.ContextSummary),
 		logger:        logger,
 		maxMemories:   maxMemories,
 		retentionDays: retentionDays,
+		clock:         clock.OrReal(clk),
 	}
+	metrics.TrackMemories(service.count)
+	return service
 }
 
 // StoreMemory almacena una memoria a largo plazo
 func (s *memoryService) StoreMemory(ctx context.Context, memory *domain.Memory) error {
+	// El embedding se calcula fuera del lock porque puede llamar al proveedor de IA
+	vector := s.embedMemory(ctx, memory)
+	evicted := s.storeMemory(ctx, memory)
+	s.indexMemory(ctx, memory, vector)
+	s.unindexMemory(ctx, evicted)
+	return nil
+}
+
+// storeMemory guarda la memoria y devuelve la que se desalojó para hacerle sitio
+func (s *memoryService) storeMemory(ctx context.Context, memory *domain.Memory) *domain.Memory {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
@@ -69,13 +95,14 @@ func (s *memoryService) StoreMemory(ctx context.Context, memory *domain.Memory)
 	key := fmt.Sprintf("%s:%s:%s", memory.UserID, memory.BotID, memory.Key)
 	
 	// Verificar límite de memorias
-	if len(s.memories) >= s.maxMemories {
+	var evicted *domain.Memory
+	if _, exists := s.memories[key]; !exists && len(s.memories) >= s.maxMemories {
 		// Eliminar la memoria más antigua
-		s.evictOldestMemory()
+		evicted = s.evictOldestMemory()
 	}
 	
 	// Establecer timestamps
-	now := time.Now()
+	now := s.clock.Now()
 	if memory.CreatedAt.IsZero() {
 		memory.CreatedAt = now
 	}
@@ -89,14 +116,14 @@ func (s *memoryService) StoreMemory(ctx context.Context, memory *domain.Memory)
 	// Almacenar memoria
 	s.memories[key] = memory
 	
-	s.logger.Info("Memory stored", 
+	s.logger.WithContext(ctx).Info("Memory stored", 
 		"user_id", memory.UserID,
 		"bot_id", memory.BotID,
 		"key", memory.Key,
 		"type", memory.Type,
 		"importance", memory.Importance)
 	
-	return nil
+	return evicted
 }
 
 // GetMemory obtiene una memoria específica
@@ -107,12 +134,12 @@ func (s *memoryService) GetMemory(ctx context.Context, userID, botID, key string
 	memoryKey := fmt.Sprintf("%s:%s:%s", userID, botID, key)
 	memory, exists := s.memories[memoryKey]
 	if !exists {
-		return nil, fmt.Errorf("memory not found")
+		return nil, ErrMemoryNotFound
 	}
 	
 	// Verificar si ha expirado
-	if time.Now().After(memory.ExpiresAt) {
-		return nil, fmt.Errorf("memory has expired")
+	if s.clock.Now().After(memory.ExpiresAt) {
+		return nil, fmt.Errorf("memory has expired: %w", ErrMemoryNotFound)
 	}
 	
 	// Crear copia para evitar modificaciones concurrentes
@@ -131,7 +158,7 @@ func (s *memoryService) GetUserMemories(ctx context.Context, userID, botID strin
 	for key, memory := range s.memories {
 		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
 			// Verificar si ha expirado
-			if time.Now().After(memory.ExpiresAt) {
+			if s.clock.Now().After(memory.ExpiresAt) {
 				continue
 			}
 			
@@ -141,24 +168,34 @@ func (s *memoryService) GetUserMemories(ctx context.Context, userID, botID strin
 		}
 	}
 	
+	sortMemories(result)
 	return result, nil
 }
 
 // UpdateMemory actualiza una memoria existente
 func (s *memoryService) UpdateMemory(ctx context.Context, memory *domain.Memory) error {
+	vector := s.embedMemory(ctx, memory)
+	if err := s.updateMemory(ctx, memory); err != nil {
+		return err
+	}
+	s.indexMemory(ctx, memory, vector)
+	return nil
+}
+
+func (s *memoryService) updateMemory(ctx context.Context, memory *domain.Memory) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
 	key := fmt.Sprintf("%s:%s:%s", memory.UserID, memory.BotID, memory.Key)
 	
 	if _, exists := s.memories[key]; !exists {
-		return fmt.Errorf("memory not found")
+		return ErrMemoryNotFound
 	}
 	
-	memory.UpdatedAt = time.Now()
+	memory.UpdatedAt = s.clock.Now()
 	s.memories[key] = memory
 	
-	s.logger.Info("Memory updated", 
+	s.logger.WithContext(ctx).Info("Memory updated", 
 		"user_id", memory.UserID,
 		"bot_id", memory.BotID,
 		"key", memory.Key)
@@ -168,18 +205,26 @@ func (s *memoryService) UpdateMemory(ctx context.Context, memory *domain.Memory)
 
 // DeleteMemory elimina una memoria
 func (s *memoryService) DeleteMemory(ctx context.Context, userID, botID, key string) error {
+	if err := s.deleteMemory(ctx, userID, botID, key); err != nil {
+		return err
+	}
+	s.unindexMemory(ctx, &domain.Memory{UserID: userID, BotID: botID, Key: key})
+	return nil
+}
+
+func (s *memoryService) deleteMemory(ctx context.Context, userID, botID, key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
 	memoryKey := fmt.Sprintf("%s:%s:%s", userID, botID, key)
 	
 	if _, exists := s.memories[memoryKey]; !exists {
-		return fmt.Errorf("memory not found")
+		return ErrMemoryNotFound
 	}
 	
 	delete(s.memories, memoryKey)
 	
-	s.logger.Info("Memory deleted", 
+	s.logger.WithContext(ctx).Info("Memory deleted", 
 		"user_id", userID,
 		"bot_id", botID,
 		"key", key)
@@ -189,6 +234,18 @@ func (s *memoryService) DeleteMemory(ctx context.Context, userID, botID, key str
 
 // SearchMemories busca memorias por contenido (implementación simple)
 func (s *memoryService) SearchMemories(ctx context.Context, userID, botID, query string, limit int) ([]*domain.Memory, error) {
+	start := s.clock.Now()
+	defer func() { metrics.MemorySearchDuration.Observe(s.clock.Since(start).Seconds()) }()
+
+	// Con embeddings la búsqueda es semántica; si falla se busca por texto
+	if s.embeddingConfig().Embedder != nil && strings.TrimSpace(query) != "" {
+		result, err := s.semanticSearch(ctx, userID, botID, query, limit)
+		if err == nil {
+			return result, nil
+		}
+		s.logger.WithContext(ctx).Warn("Semantic memory search failed, using text search", "bot_id", botID, "error", err)
+	}
+
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	
@@ -200,13 +257,9 @@ func (s *memoryService) SearchMemories(ctx context.Context, userID, botID, query
 	prefix := fmt.Sprintf("%s:%s:", userID, botID)
 	
 	for key, memory := range s.memories {
-		if len(result) >= limit {
-			break
-		}
-		
 		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
 			// Verificar si ha expirado
-			if time.Now().After(memory.ExpiresAt) {
+			if s.clock.Now().After(memory.ExpiresAt) {
 				continue
 			}
 			
@@ -218,6 +271,12 @@ func (s *memoryService) SearchMemories(ctx context.Context, userID, botID, query
 		}
 	}
 	
+	// Ordenadas antes de cortar para que el límite no dependa del orden del mapa
+	sortMemories(result)
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	
 	return result, nil
 }
 
@@ -236,8 +295,8 @@ func (s *memoryService) GetContextSummary(ctx context.Context, userID, botID str
 			Summary:   "",
 			KeyPoints: []string{},
 			Entities:  make(map[string]interface{}),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			CreatedAt: s.clock.Now(),
+			UpdatedAt: s.clock.Now(),
 		}, nil
 	}
 	
@@ -252,15 +311,15 @@ func (s *memoryService) UpdateContextSummary(ctx context.Context, summary *domai
 	defer s.mu.Unlock()
 	
 	key := fmt.Sprintf("%s:%s", summary.UserID, summary.BotID)
-	summary.UpdatedAt = time.Now()
+	summary.UpdatedAt = s.clock.Now()
 	
 	if summary.CreatedAt.IsZero() {
-		summary.CreatedAt = time.Now()
+		summary.CreatedAt = s.clock.Now()
 	}
 	
 	s.summaries[key] = summary
 	
-	s.logger.Info("Context summary updated", 
+	s.logger.WithContext(ctx).Info("Context summary updated", 
 		"user_id", summary.UserID,
 		"bot_id", summary.BotID,
 		"key_points", len(summary.KeyPoints))
@@ -270,27 +329,32 @@ func (s *memoryService) UpdateContextSummary(ctx context.Context, summary *domai
 
 // CleanupExpiredMemories limpia memorias expiradas
 func (s *memoryService) CleanupExpiredMemories(ctx context.Context) error {
+	for _, memory := range s.cleanupExpiredMemories(ctx) {
+		s.unindexMemory(ctx, memory)
+	}
+	return nil
+}
+
+func (s *memoryService) cleanupExpiredMemories(ctx context.Context) []*domain.Memory {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	
-	now := time.Now()
-	var expiredKeys []string
+	now := s.clock.Now()
+	var expired []*domain.Memory
 	
 	for key, memory := range s.memories {
 		if now.After(memory.ExpiresAt) {
-			expiredKeys = append(expiredKeys, key)
+			expired = append(expired, memory)
+			delete(s.memories, key)
 		}
 	}
+	metrics.MemoryExpiredCleanups.Add(float64(len(expired)))
 	
-	for _, key := range expiredKeys {
-		delete(s.memories, key)
-	}
-	
-	if len(expiredKeys) > 0 {
-		s.logger.Info("Expired memories cleaned up", "count", len(expiredKeys))
+	if len(expired) > 0 {
+		s.logger.WithContext(ctx).Info("Expired memories cleaned up", "count", len(expired))
 	}
 	
-	return nil
+	return expired
 }
 
 // GetMemoryStats obtiene estadísticas de memoria para un usuario y bot
@@ -304,9 +368,9 @@ func (s *memoryService) GetMemoryStats(ctx context.Context, userID, botID string
 		TotalMemories: 0,
 		MemoriesByType: make(map[string]int),
 		MemoriesByImportance: make(map[int]int),
-		OldestMemory:  time.Now(),
+		OldestMemory:  s.clock.Now(),
 		NewestMemory:  time.Time{},
-		LastUpdated:   time.Now(),
+		LastUpdated:   s.clock.Now(),
 	}
 	
 	prefix := fmt.Sprintf("%s:%s:", userID, botID)
@@ -314,7 +378,7 @@ func (s *memoryService) GetMemoryStats(ctx context.Context, userID, botID string
 	for key, memory := range s.memories {
 		if len(key) > len(prefix) && key[:len(prefix)] == prefix {
 			// Verificar si ha expirado
-			if time.Now().After(memory.ExpiresAt) {
+			if s.clock.Now().After(memory.ExpiresAt) {
 				continue
 			}
 			
@@ -336,7 +400,7 @@ func (s *memoryService) GetMemoryStats(ctx context.Context, userID, botID string
 }
 
 // evictOldestMemory elimina la memoria más antigua para hacer espacio
-func (s *memoryService) evictOldestMemory() {
+func (s *memoryService) evictOldestMemory() *domain.Memory {
 	var oldestKey string
 	var oldestTime time.Time
 	
@@ -347,10 +411,31 @@ func (s *memoryService) evictOldestMemory() {
 		}
 	}
 	
-	if oldestKey != "" {
-		delete(s.memories, oldestKey)
-		s.logger.Info("Evicted oldest memory", "key", oldestKey)
+	if oldestKey == "" {
+		return nil
 	}
+	evicted := s.memories[oldestKey]
+	delete(s.memories, oldestKey)
+	metrics.MemoryEvictions.Inc()
+	s.logger.Info("Evicted oldest memory", "key", oldestKey)
+	return evicted
+}
+
+// sortMemories ordena por importancia y, a igualdad, de la más reciente a la más antigua
+func sortMemories(memories []*domain.Memory) {
+	sort.SliceStable(memories, func(i, j int) bool {
+		if memories[i].Importance != memories[j].Importance {
+			return memories[i].Importance > memories[j].Importance
+		}
+		return memories[i].UpdatedAt.After(memories[j].UpdatedAt)
+	})
+}
+
+// count devuelve cuántas memorias hay guardadas, expiradas o no
+func (s *memoryService) count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.memories)
 }
 
 // matchesQuery verifica si una memoria coincide con la consulta
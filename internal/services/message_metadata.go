@@ -0,0 +1,63 @@
+package services
+
+import (
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+var reservedMetadataNamespaces = map[string]bool{
+	domain.MetadataNamespaceChannel: true,
+	domain.MetadataNamespaceUser:    true,
+	domain.MetadataNamespaceMessage: true,
+}
+
+// metadataVariables aplana los espacios de nombres reservados de la metadata
+// del mensaje en claves "user.name", "channel.id"... Los canales pueden
+// enviarlos anidados ({"user": {"name": "Ana"}}) o ya con puntos
+// ({"user.name": "Ana"}); las claves built-in del catálogo las fija el motor.
+func metadataVariables(message *domain.IncomingMessage) map[string]interface{} {
+	variables := make(map[string]interface{})
+	for key, value := range message.Metadata {
+		namespace, _, _ := strings.Cut(key, ".")
+		if reservedMetadataNamespaces[namespace] {
+			flattenMetadata(key, value, variables)
+		}
+	}
+
+	variables["channel.type"] = string(message.Channel)
+	variables["user.id"] = message.UserID
+	variables["message.id"] = message.ID
+	variables["message.text"] = message.Content
+	variables["message.timestamp"] = message.Timestamp
+	return variables
+}
+
+func flattenMetadata(key string, value interface{}, variables map[string]interface{}) {
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		variables[key] = value
+		return
+	}
+	// Un namespace completo no se guarda como variable: "user" o "message" ya
+	// tienen otro significado en el contexto
+	if strings.Contains(key, ".") {
+		variables[key] = value
+	}
+	for child, childValue := range nested {
+		flattenMetadata(key+"."+child, childValue, variables)
+	}
+}
+
+// withMessageMetadata copia input y le añade las variables de metadata del
+// mensaje, para evaluar condicionales con el mismo contexto que los flujos
+func withMessageMetadata(input map[string]interface{}, message *domain.IncomingMessage) map[string]interface{} {
+	merged := make(map[string]interface{}, len(input)+8)
+	for key, value := range input {
+		merged[key] = value
+	}
+	for key, value := range metadataVariables(message) {
+		merged[key] = value
+	}
+	return merged
+}
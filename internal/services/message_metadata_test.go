@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageMetadata_Namespaces(t *testing.T) {
+	log := logger.NewLogger("error")
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	bots := NewBotService(repositories.NewMockBotRepository(), repositories.NewMockBotFlowRepository(), repositories.NewMockBotStepRepository(),
+		repositories.NewMockFlowVersionRepository(), sessionRepo, nil, NewConversationService(sessionRepo, nil, log),
+		nil, nil, nil, nil, nil, nil, nil, log).(*botService)
+
+	message := &domain.IncomingMessage{
+		ID:        "m-1",
+		UserID:    "u-1",
+		Content:   "hola",
+		Channel:   domain.ChannelWhatsApp,
+		Timestamp: time.Now(),
+		Metadata: map[string]interface{}{
+			"user":       map[string]interface{}{"name": "Ana", "locale": "es-AR", "id": "spoofed", "address": map[string]interface{}{"city": "Rosario"}},
+			"channel.id": "+5491100000000",
+			"message":    map[string]interface{}{"type": "text"},
+			"campaign":   "spring",
+		},
+	}
+	session := &domain.ConversationSession{ID: "s-1", Context: map[string]interface{}{"plan": "gold"}}
+	input := bots.buildConditionInput(context.Background(), message, session)
+
+	assert.Equal(t, "Ana", input["user.name"])
+	assert.Equal(t, "u-1", input["user.id"])
+	assert.Equal(t, "Rosario", input["user.address.city"])
+	assert.Equal(t, "+5491100000000", input["channel.id"])
+	assert.Equal(t, "whatsapp", input["channel.type"])
+	assert.Equal(t, "text", input["message.type"])
+	assert.Equal(t, "m-1", input["message.id"])
+	// Las claves previas no cambian de significado
+	assert.Equal(t, "hola", input["message"])
+	assert.Equal(t, "whatsapp", input["channel"])
+	assert.NotContains(t, input, "campaign")
+	assert.Equal(t, "spring", lookupVariable("metadata.campaign", input))
+
+	assert.True(t, bots.evaluateCondition(`{{user.locale}} == "es-AR" && {{channel.type}} == "whatsapp"`, message.Content, input))
+	assert.False(t, bots.evaluateCondition(`{{message.type}} == "audio"`, message.Content, input))
+	assert.Equal(t, "Hola Ana (gold) desde whatsapp", renderTemplate("Hola {{user.name}} ({{plan}}) desde {{channel.type}}", input))
+
+	conditionInput := withMessageMetadata(map[string]interface{}{"score": 5}, message)
+	assert.Equal(t, 5, conditionInput["score"])
+	assert.Equal(t, "es-AR", conditionInput["user.locale"])
+}
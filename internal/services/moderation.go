@@ -0,0 +1,196 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/id"
+)
+
+// ModerationFlagsContextKey cuenta en la sesión los mensajes del usuario marcados
+const ModerationFlagsContextKey = "moderation_flags"
+
+// defaultBlockedResponse sustituye a la respuesta de IA bloqueada si el bot no
+// configura blocked_response
+const defaultBlockedResponse = "Lo siento, no puedo responder a eso. ¿Puedo ayudarte con otra cosa?"
+
+// moderationResult es la salida del agente de moderación
+type moderationResult struct {
+	Flagged    bool
+	Categories []string
+	Matches    []string
+	MaskedText string
+}
+
+// UseAudit registra en la auditoría el contenido marcado o bloqueado por la moderación
+func (s *botService) UseAudit(audit AuditService) {
+	s.audit = audit
+}
+
+// moderateIncoming revisa el mensaje del usuario según la política inbound del
+// bot: lo marca en los metadatos y, en modo mask, sustituye las palabras no
+// permitidas. Si la moderación falla el mensaje sigue sin marcar.
+func (s *botService) moderateIncoming(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession, config *domain.ModerationConfig) {
+	mode := moderationMode(config.Inbound, domain.ModerationModeFlag)
+	if mode == domain.ModerationModeOff || strings.TrimSpace(message.Content) == "" {
+		return
+	}
+
+	result, err := s.moderate(ctx, message.BotID, message.Content, config)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to moderate incoming message", "bot_id", message.BotID, "message_id", message.ID, "error", err)
+		return
+	}
+	if !result.Flagged {
+		return
+	}
+
+	action := domain.AuditActionFlag
+	if mode == domain.ModerationModeMask && len(result.Matches) > 0 {
+		message.Content = result.MaskedText
+		action = domain.AuditActionMask
+	}
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]interface{})
+	}
+	message.Metadata["moderation"] = map[string]interface{}{
+		"flagged":    true,
+		"action":     action,
+		"categories": result.Categories,
+	}
+	if session.Context == nil {
+		session.Context = make(map[string]interface{})
+	}
+	flags, _ := toFloat(session.Context[ModerationFlagsContextKey])
+	session.Context[ModerationFlagsContextKey] = int(flags) + 1
+
+	// Solo se guardan las palabras encontradas, no el mensaje del usuario
+	s.recordModeration(ctx, action, message.UserID, message.ID, map[string]interface{}{
+		"bot_id":     message.BotID,
+		"session_id": session.ID,
+		"direction":  "inbound",
+		"categories": result.Categories,
+		"matches":    result.Matches,
+	})
+}
+
+// moderationMiddleware bloquea las respuestas de los pasos de IA que incumplen
+// la política outbound del bot
+func (s *botService) moderationMiddleware(next StepHandler) StepHandler {
+	return func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+		response, nextStepID, err := next(ctx, step, message, session)
+		if err != nil || step.Type != domain.StepTypeAI || response == nil || strings.TrimSpace(response.Content) == "" {
+			return response, nextStepID, err
+		}
+
+		bot, botErr := s.botRepo.GetByID(ctx, session.BotID)
+		if botErr != nil {
+			return response, nextStepID, err
+		}
+		config := parseBotConfig(bot).Moderation
+		if config == nil || moderationMode(config.Outbound, domain.ModerationModeBlock) != domain.ModerationModeBlock {
+			return response, nextStepID, err
+		}
+
+		result, modErr := s.moderate(ctx, session.BotID, response.Content, config)
+		if modErr != nil {
+			s.logger.WithContext(ctx).Warn("Failed to moderate AI response", "bot_id", session.BotID, "step_id", step.ID, "error", modErr)
+			return response, nextStepID, err
+		}
+		if !result.Flagged {
+			return response, nextStepID, err
+		}
+
+		// La respuesta bloqueada se audita completa para poder revisar el prompt
+		s.recordModeration(ctx, domain.AuditActionBlock, session.UserID, step.ID, map[string]interface{}{
+			"bot_id":     session.BotID,
+			"session_id": session.ID,
+			"direction":  "outbound",
+			"categories": result.Categories,
+			"matches":    result.Matches,
+			"content":    response.Content,
+		})
+
+		response.Content = config.BlockedResponse
+		if response.Content == "" {
+			response.Content = defaultBlockedResponse
+		}
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["moderation"] = map[string]interface{}{
+			"blocked":    true,
+			"categories": result.Categories,
+		}
+		return response, nextStepID, err
+	}
+}
+
+// moderate pasa el texto por un agente MCP de moderación
+func (s *botService) moderate(ctx context.Context, botID, text string, config *domain.ModerationConfig) (*moderationResult, error) {
+	if s.mcpOrchestrator == nil {
+		return nil, fmt.Errorf("no MCP orchestrator configured")
+	}
+
+	agent, err := s.instantiateAgent(ctx, mcp.MCPConfig{
+		Type:         "moderation",
+		Name:         fmt.Sprintf("moderation-agent-%s", botID),
+		Version:      "1.0",
+		Config:       config.Config,
+		Capabilities: []string{"moderation"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate moderation agent: %w", err)
+	}
+	defer func() {
+		if err := s.mcpOrchestrator.TerminateAgent(ctx, agent.GetID()); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
+		}
+	}()
+
+	result, err := agent.Execute(ctx, mcp.Task{
+		ID:          fmt.Sprintf("moderation-%s-%s", botID, id.New()),
+		Type:        "moderation",
+		Description: "Moderate message content",
+		Input:       map[string]interface{}{"text": text},
+		Priority:    5,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("moderation failed: %s", result.Error)
+	}
+
+	flagged, _ := result.Output["flagged"].(bool)
+	masked, _ := result.Output["masked_text"].(string)
+	categories, _ := result.Output["categories"].([]string)
+	matches, _ := result.Output["matches"].([]string)
+	return &moderationResult{Flagged: flagged, Categories: categories, Matches: matches, MaskedText: masked}, nil
+}
+
+func (s *botService) recordModeration(ctx context.Context, action, userID, resourceID string, details map[string]interface{}) {
+	if s.audit == nil {
+		return
+	}
+	entry := &domain.AuditLog{
+		UserID:     userID,
+		Action:     action,
+		Resource:   domain.AuditResourceMessage,
+		ResourceID: resourceID,
+		Details:    details,
+	}
+	if err := s.audit.Record(ctx, entry, nil, nil); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to audit moderated content", "action", action, "error", err)
+	}
+}
+
+func moderationMode(mode, def string) string {
+	if mode == "" {
+		return def
+	}
+	return mode
+}
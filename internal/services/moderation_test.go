@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newModerationBot(t *testing.T, config *domain.ModerationConfig) (*botService, domain.ConversationSessionRepository, AuditService) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	audit := NewAuditService(repositories.NewMockAuditRepository(), log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil,
+		mcp.NewOrchestrator(mcp.NewAgentFactory(log), log), nil, nil, log)
+	bots.UseAudit(audit)
+
+	raw, err := json.Marshal(domain.BotConfig{Moderation: config})
+	require.NoError(t, err)
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive, Config: raw}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "echo", IsDefault: true}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "echo", FlowID: "flow-1", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Entendido"}`)}))
+	return bots.(*botService), sessionRepo, audit
+}
+
+func TestModeration_MasksInboundAndAudits(t *testing.T) {
+	ctx := context.Background()
+	bots, sessionRepo, audit := newModerationBot(t, &domain.ModerationConfig{
+		Inbound: domain.ModerationModeMask,
+		Config:  map[string]interface{}{"words": []interface{}{"Tontería"}},
+	})
+
+	message := &domain.IncomingMessage{ID: "msg-1", BotID: "bot-1", UserID: "user-1", Content: "Qué MIERDA de servicio, menuda tontería"}
+	_, err := bots.ProcessIncomingMessage(ctx, message)
+	require.NoError(t, err)
+	assert.Equal(t, "Qué M***** de servicio, menuda t*******", message.Content)
+	assert.Equal(t, domain.AuditActionMask, message.Metadata["moderation"].(map[string]interface{})["action"])
+
+	session, err := sessionRepo.GetByUserAndBot(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, session.Context[ModerationFlagsContextKey])
+	assert.NotContains(t, session.Messages[0].Content, "MIERDA")
+
+	resource := domain.AuditResourceMessage
+	logs, err := audit.List(ctx, &AuditFilters{Resource: &resource})
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, "msg-1", logs[0].ResourceID)
+	assert.Equal(t, []string{"mierda", "tonteria"}, logs[0].Details["matches"])
+	assert.NotContains(t, logs[0].Details, "content")
+
+	// Un mensaje limpio no se marca
+	clean := &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: "Gracias"}
+	_, err = bots.ProcessIncomingMessage(ctx, clean)
+	require.NoError(t, err)
+	assert.NotContains(t, clean.Metadata, "moderation")
+}
+
+func TestModeration_BlocksAIResponses(t *testing.T) {
+	ctx := context.Background()
+	bots, _, audit := newModerationBot(t, &domain.ModerationConfig{BlockedResponse: "Respuesta no disponible"})
+
+	reply := "Eres un idiota"
+	handler := bots.moderationMiddleware(func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+		return &domain.BotResponse{Content: reply, Type: domain.ResponseTypeText}, nil, nil
+	})
+	session := &domain.ConversationSession{ID: "session-1", BotID: "bot-1", UserID: "user-1"}
+
+	response, _, err := handler(ctx, &domain.BotStep{ID: "ai", Type: domain.StepTypeAI}, &domain.IncomingMessage{}, session)
+	require.NoError(t, err)
+	assert.Equal(t, "Respuesta no disponible", response.Content)
+	assert.Equal(t, true, response.Metadata["moderation"].(map[string]interface{})["blocked"])
+
+	logs, err := audit.List(ctx, nil)
+	require.NoError(t, err)
+	require.Len(t, logs, 1)
+	assert.Equal(t, domain.AuditActionBlock, logs[0].Action)
+	assert.Equal(t, "Eres un idiota", logs[0].Details["content"])
+
+	// Solo se moderan los pasos de IA
+	response, _, err = handler(ctx, &domain.BotStep{ID: "msg", Type: domain.StepTypeMessage}, &domain.IncomingMessage{}, session)
+	require.NoError(t, err)
+	assert.Equal(t, reply, response.Content)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickWeighted_Distribution(t *testing.T) {
+	random := rand.New(rand.NewSource(42)).Float64
+	counts := make([]int, 3)
+	const picks = 20000
+	for i := 0; i < picks; i++ {
+		counts[pickWeighted([]float64{1, 3, 6}, random)]++
+	}
+
+	assert.InDelta(t, 0.1, float64(counts[0])/picks, 0.01)
+	assert.InDelta(t, 0.3, float64(counts[1])/picks, 0.01)
+	assert.InDelta(t, 0.6, float64(counts[2])/picks, 0.01)
+}
+
+func TestPickWeighted_ZeroAndNegativeWeights(t *testing.T) {
+	random := rand.New(rand.NewSource(7)).Float64
+
+	// Las variantes sin peso positivo nunca salen si alguna lo tiene
+	for i := 0; i < 1000; i++ {
+		assert.Equal(t, 2, pickWeighted([]float64{0, -5, 2}, random))
+	}
+	assert.Equal(t, 2, pickWeighted([]float64{0, -5, 2}, func() float64 { return 0.999999 }))
+
+	// Sin ningún peso positivo el reparto es uniforme
+	counts := make([]int, 3)
+	const picks = 9000
+	for i := 0; i < picks; i++ {
+		counts[pickWeighted([]float64{0, -1, 0}, random)]++
+	}
+	for _, count := range counts {
+		assert.InDelta(t, 1.0/3, float64(count)/picks, 0.02)
+	}
+	assert.Equal(t, 2, pickWeighted([]float64{0, 0, 0}, func() float64 { return 0.999999 }))
+}
+
+func TestProcessRandomStep_UsesInjectedSource(t *testing.T) {
+	s := &botService{clock: clock.Real(), random: func() float64 { return 0.5 }}
+	step := &domain.BotStep{ID: "greeting", Type: domain.StepTypeRandom, Content: json.RawMessage(`{
+		"sticky": true,
+		"variants": [
+			{"id": "a", "weight": 1, "text": "Hi"},
+			{"id": "b", "weight": 1, "text": "Hello", "next_step": "ask"},
+			{"id": "off", "weight": 0, "text": "Hey"}
+		]}`)}
+	session := &domain.ConversationSession{Context: map[string]interface{}{}}
+
+	response, next, err := s.processRandomStep(context.Background(), step, &domain.IncomingMessage{}, session)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", response.Content)
+	require.NotNil(t, next)
+	assert.Equal(t, "ask", *next)
+	assert.Equal(t, "b", session.Context["variant_greeting"])
+
+	// Con sticky la sesión conserva su variante aunque la fuente cambie
+	s.random = func() float64 { return 0 }
+	response, _, err = s.processRandomStep(context.Background(), step, &domain.IncomingMessage{}, session)
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", response.Content)
+}
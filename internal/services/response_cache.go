@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/cache"
+	"github.com/company/bot-service/pkg/configschema"
+)
+
+// Tipos de respuesta de la caché, como etiqueta de response_cache_lookups_total
+const (
+	responseCacheAI   = "ai"
+	responseCacheHTTP = "http"
+)
+
+// UseResponseCache guarda las respuestas de los pasos ai y api_call que
+// configuran cache_ttl, para no repetir la llamada ante la misma pregunta
+func (s *botService) UseResponseCache(responseCache cache.Cache) {
+	s.responseCache = responseCache
+}
+
+// stepCacheTTL interpreta el cache_ttl de un paso ("10m", "1d"); vacío,
+// inválido, sin caché configurada o en el sandbox devuelve 0 y no se cachea
+func (s *botService) stepCacheTTL(ctx context.Context, stepID, value string) time.Duration {
+	if value == "" || s.responseCache == nil || inSandbox(ctx) {
+		return 0
+	}
+	ttl, err := parseDelayDuration(value)
+	if err != nil || ttl <= 0 {
+		s.logger.WithContext(ctx).Warn("Invalid step cache_ttl, response not cached", "step_id", stepID, "cache_ttl", value)
+		return 0
+	}
+	return ttl
+}
+
+// cachedResponse decodifica en out la respuesta guardada con key. Un error de
+// la caché cuenta como fallo: el paso hace la llamada.
+func (s *botService) cachedResponse(ctx context.Context, kind, key string, out interface{}) bool {
+	data, ok, err := s.responseCache.Get(ctx, key)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to read response cache", "kind", kind, "error", err)
+	}
+	hit := ok && err == nil && json.Unmarshal(data, out) == nil
+	metrics.RecordResponseCache(kind, hit)
+	return hit
+}
+
+// storeResponse guarda la respuesta durante ttl
+func (s *botService) storeResponse(ctx context.Context, kind, key string, value interface{}, ttl time.Duration) {
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = s.responseCache.Set(ctx, key, data, ttl)
+	}
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to write response cache", "kind", kind, "error", err)
+	}
+}
+
+// normalizePrompt iguala las variantes triviales de una misma pregunta
+// (mayúsculas, espacios, signos al principio o al final)
+func normalizePrompt(prompt string) string {
+	normalized := strings.Join(strings.Fields(strings.ToLower(prompt)), " ")
+	return strings.Trim(normalized, " ¿?¡!.,;:")
+}
+
+// aiCacheKey identifica una respuesta de IA por bot, pregunta normalizada y
+// el contexto que se incluye en el prompt
+func aiCacheKey(botID, prompt string, context map[string]interface{}) (string, bool) {
+	data, err := json.Marshal(context)
+	if err != nil {
+		return "", false
+	}
+	return cache.Key(responseCacheAI, botID, normalizePrompt(prompt), string(data)), true
+}
+
+// apiCallCacheKey identifica una llamada HTTP por método, URL (base_url del
+// agente más endpoint, o url), parámetros y body. Las tareas sin URL no son
+// llamadas HTTP y no se cachean.
+func apiCallCacheKey(agentType string, config, task map[string]interface{}) (string, bool) {
+	values := configschema.Values(task)
+	target := values.String("url", "")
+	if endpoint := values.String("endpoint", ""); endpoint != "" {
+		target = configschema.Values(config).String("base_url", "") + "|" + endpoint
+	}
+	if target == "" {
+		return "", false
+	}
+
+	params, err := json.Marshal(task["params"])
+	if err != nil {
+		return "", false
+	}
+	body, err := json.Marshal(task["body"])
+	if err != nil {
+		return "", false
+	}
+	method := strings.ToUpper(values.String("method", ""))
+	return cache.Key(responseCacheHTTP, agentType, method, target, string(params), string(body)), true
+}
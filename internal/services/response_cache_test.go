@@ -0,0 +1,49 @@
+package services_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/cache"
+	"github.com/company/bot-service/pkg/testkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache_ReusesAIAndAPICallResponses(t *testing.T) {
+	server := testkit.NewServer(t,
+		testkit.Bot("faq").Flows(testkit.Flow("faq-main").Default().Steps(
+			testkit.Step("faq-ai", domain.StepTypeAI, map[string]interface{}{"cache_ttl": "1h"}),
+		)),
+		testkit.Bot("hours").Flows(testkit.Flow("hours-main").Default().Steps(
+			testkit.Step("hours-api", domain.StepTypeAPICall, map[string]interface{}{
+				"agent_type": "mock",
+				"config":     map[string]interface{}{"responses": []string{"9-18"}, "max_processing_time_ms": 0, "failure_rate": 0},
+				"task":       map[string]interface{}{"method": "GET", "url": "https://shop.example.com/hours"},
+				"cache_ttl":  "10m",
+			}),
+		)),
+	)
+	responseCache := cache.NewMemory(100, server.Clock)
+	server.Bots.UseResponseCache(responseCache)
+	server.AI.Reply("Abrimos de 9 a 18", "Abrimos de 8 a 20")
+
+	// La misma pregunta con otras mayúsculas y signos se responde desde la caché
+	assert.Equal(t, "Abrimos de 9 a 18", server.Send("faq", "user-1", "¿A qué hora abrís?").Content)
+	assert.Equal(t, "Abrimos de 9 a 18", server.Send("faq", "user-2", "a qué  hora abrís").Content)
+	assert.Len(t, server.AI.Prompts(), 1)
+
+	server.Clock.Advance(2 * time.Hour)
+	assert.Equal(t, "Abrimos de 8 a 20", server.Send("faq", "user-3", "¿A qué hora abrís?").Content)
+	assert.Len(t, server.AI.Prompts(), 2)
+
+	first := server.Send("hours", "user-1", "horario")
+	second := server.Send("hours", "user-2", "horario")
+	// El texto lleva el ID de la tarea, así que coincide sólo si no se repitió
+	assert.Contains(t, first.Content, "API call successful: 9-18")
+	assert.NotContains(t, first.Metadata, "cached")
+	assert.Equal(t, first.Content, second.Content)
+	assert.Equal(t, true, second.Metadata["cached"])
+	require.Equal(t, 2, responseCache.Len())
+}
@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Tipos a los que se convierte un campo mapeado desde la respuesta
+const (
+	mappingTypeString  = "string"
+	mappingTypeNumber  = "number"
+	mappingTypeInteger = "integer"
+	mappingTypeBoolean = "boolean"
+)
+
+// responseMapping lleva un campo de la respuesta de un api_call a una
+// variable de la sesión. En el JSON del paso admite la forma corta "$.body.id".
+type responseMapping struct {
+	// Path es una ruta sobre la salida de la llamada: "$.body.items[0].sku"
+	Path string `json:"path"`
+	// Type convierte el valor (string, number, integer, boolean); vacío lo
+	// deja tal cual y un tipo desconocido hace que se use Default
+	Type string `json:"type,omitempty"`
+	// Default se usa si la ruta no existe o el valor no se puede convertir
+	Default interface{} `json:"default,omitempty"`
+}
+
+func (m *responseMapping) UnmarshalJSON(data []byte) error {
+	var path string
+	if err := json.Unmarshal(data, &path); err == nil {
+		m.Path = path
+		return nil
+	}
+	type plain responseMapping
+	return json.Unmarshal(data, (*plain)(m))
+}
+
+// applyResponseMapping guarda en el contexto de la sesión las variables del
+// mapeo. Sin valor ni default la variable se borra, para no arrastrar el
+// resultado de una llamada anterior.
+func (s *botService) applyResponseMapping(ctx context.Context, stepID string, mapping map[string]responseMapping, output map[string]interface{}, sessionContext map[string]interface{}) {
+	if len(mapping) == 0 {
+		return
+	}
+	// La salida de los agentes lleva tipos de Go (http.Header, []string); en
+	// JSON las rutas se resuelven igual venga de la caché o del agente
+	var doc interface{}
+	if data, err := json.Marshal(output); err == nil {
+		json.Unmarshal(data, &doc)
+	}
+	for variable, field := range mapping {
+		value, found := jsonPathLookup(doc, field.Path)
+		if found && field.Type != "" {
+			converted, ok := coerceMappedValue(value, field.Type)
+			if !ok {
+				s.logger.WithContext(ctx).Warn("Cannot convert mapped response field, using default",
+					"step_id", stepID, "variable", variable, "path", field.Path, "type", field.Type)
+			}
+			value, found = converted, ok
+		}
+		if !found {
+			value = field.Default
+		}
+		if value == nil {
+			delete(sessionContext, variable)
+			continue
+		}
+		sessionContext[variable] = value
+	}
+}
+
+// coerceMappedValue convierte un valor JSON al tipo pedido
+func coerceMappedValue(value interface{}, valueType string) (interface{}, bool) {
+	switch valueType {
+	case mappingTypeString:
+		switch v := value.(type) {
+		case string:
+			return v, true
+		case map[string]interface{}, []interface{}:
+			data, err := json.Marshal(v)
+			return string(data), err == nil
+		default:
+			return fmt.Sprintf("%v", v), true
+		}
+	case mappingTypeNumber:
+		return toFloat(value)
+	case mappingTypeInteger:
+		f, ok := toFloat(value)
+		if !ok || f != math.Trunc(f) {
+			return nil, false
+		}
+		return int64(f), true
+	case mappingTypeBoolean:
+		switch v := value.(type) {
+		case bool:
+			return v, true
+		case string:
+			b, err := strconv.ParseBool(strings.TrimSpace(v))
+			return b, err == nil
+		}
+		if f, ok := toFloat(value); ok {
+			return f != 0, true
+		}
+		return nil, false
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,43 @@
+package services_test
+
+import (
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/testkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPICallStep_MapsResponseFieldsIntoSession(t *testing.T) {
+	server := testkit.NewServer(t,
+		testkit.Bot("orders").Flows(testkit.Flow("orders-main").Default().Steps(
+			testkit.Step("lookup", domain.StepTypeAPICall, map[string]interface{}{
+				"agent_type": "mock",
+				"config":     map[string]interface{}{"max_processing_time_ms": 0, "failure_rate": 0},
+				"task":       map[string]interface{}{"order_id": "A-1"},
+				"response_mapping": map[string]interface{}{
+					"agent":        "$.task_type",
+					"first_input":  map[string]interface{}{"path": "$.input_keys[0]", "type": "string"},
+					"received_at":  map[string]interface{}{"path": ".timestamp", "type": "integer"},
+					"is_agent":     map[string]interface{}{"path": "$.task_type", "type": "boolean", "default": false},
+					"order_status": map[string]interface{}{"path": "$.body.status", "default": "unknown"},
+					"tracking":     "$.body.tracking",
+				},
+			}),
+		)),
+	)
+
+	server.Send("orders", "user-1", "¿Dónde está mi pedido?")
+
+	session := server.Session("orders", "user-1")
+	require.NotNil(t, session)
+	assert.Equal(t, "mock", session.Context["agent"])
+	assert.Equal(t, "order_id", session.Context["first_input"])
+	assert.IsType(t, int64(0), session.Context["received_at"])
+	// "mock" no es un booleano: se usa el default
+	assert.Equal(t, false, session.Context["is_agent"])
+	assert.Equal(t, "unknown", session.Context["order_status"])
+	assert.NotContains(t, session.Context, "tracking")
+	assert.Contains(t, session.Context, "api_result")
+}
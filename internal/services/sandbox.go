@@ -0,0 +1,362 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+var (
+	ErrInvalidSandbox     = errors.New("invalid sandbox request")
+	ErrSandboxLimit       = errors.New("sandbox bot limit reached")
+	ErrSandboxBotNotFound = errors.New("sandbox bot not found")
+)
+
+// SandboxBotRequest describe el bot de sandbox. Todo es opcional: por defecto
+// se genera el kit inicial de retail con el TTL por defecto del servicio.
+type SandboxBotRequest struct {
+	Name            string             `json:"name"`
+	Vertical        string             `json:"vertical"`
+	Channel         domain.ChannelType `json:"channel"`
+	PrimaryLanguage string             `json:"primary_language"`
+	TTLHours        int                `json:"ttl_hours"`
+}
+
+// SandboxBotResult es el bot generado y el momento en que se eliminará
+type SandboxBotResult struct {
+	StarterKitResult
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SandboxLimits acota los bots de sandbox que se pueden crear
+type SandboxLimits struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	// MaxBots es el total de bots de sandbox vivos; MaxBotsPerCaller, por usuario o API key
+	MaxBots          int
+	MaxBotsPerCaller int
+	CleanupInterval  time.Duration
+}
+
+// SandboxService crea bots efímeros para probar la API sin tocar los bots de
+// los clientes y elimina los que caducan
+type SandboxService interface {
+	CreateBot(ctx context.Context, request SandboxBotRequest, caller string) (*SandboxBotResult, error)
+	ListBots(ctx context.Context, caller string) ([]*domain.Bot, error)
+	DeleteBot(ctx context.Context, id, caller string) error
+	// DeleteExpired elimina los bots caducados y devuelve cuántos eliminó
+	DeleteExpired(ctx context.Context) (int, error)
+	Start(ctx context.Context) error
+	Stop()
+}
+
+type sandboxService struct {
+	botRepo    domain.BotRepository
+	starterKit StarterKitService
+	bundles    BotBundleService
+	tests      TestService
+	limits     SandboxLimits
+	clock      clock.Clock
+	logger     logger.Logger
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+// NewSandboxService crea el servicio de sandbox. Los bots se generan con el
+// kit inicial, así que vienen con flujos y pruebas de conversación.
+func NewSandboxService(
+	botRepo domain.BotRepository,
+	starterKit StarterKitService,
+	bundles BotBundleService,
+	tests TestService,
+	limits SandboxLimits,
+	clk clock.Clock,
+	logger logger.Logger,
+) SandboxService {
+	if limits.DefaultTTL <= 0 {
+		limits.DefaultTTL = 24 * time.Hour
+	}
+	if limits.MaxTTL < limits.DefaultTTL {
+		limits.MaxTTL = limits.DefaultTTL
+	}
+	return &sandboxService{
+		botRepo:    botRepo,
+		starterKit: starterKit,
+		bundles:    bundles,
+		tests:      tests,
+		limits:     limits,
+		clock:      clock.OrReal(clk),
+		logger:     logger,
+	}
+}
+
+func (s *sandboxService) CreateBot(ctx context.Context, request SandboxBotRequest, caller string) (*SandboxBotResult, error) {
+	ttl := s.limits.DefaultTTL
+	if request.TTLHours < 0 {
+		return nil, fmt.Errorf("%w: ttl_hours cannot be negative", ErrInvalidSandbox)
+	}
+	if request.TTLHours > 0 {
+		ttl = time.Duration(request.TTLHours) * time.Hour
+		if ttl > s.limits.MaxTTL {
+			return nil, fmt.Errorf("%w: ttl_hours cannot exceed %d", ErrInvalidSandbox, int(s.limits.MaxTTL.Hours()))
+		}
+	}
+
+	bots, err := s.liveBots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.limits.MaxBots > 0 && len(bots) >= s.limits.MaxBots {
+		return nil, fmt.Errorf("%w: %d sandbox bots are active", ErrSandboxLimit, len(bots))
+	}
+	if s.limits.MaxBotsPerCaller > 0 && len(filterSandboxBots(bots, caller)) >= s.limits.MaxBotsPerCaller {
+		return nil, fmt.Errorf("%w: at most %d sandbox bots per caller", ErrSandboxLimit, s.limits.MaxBotsPerCaller)
+	}
+
+	name := strings.TrimSpace(request.Name)
+	if name == "" {
+		name = "Sandbox bot"
+	}
+	vertical := request.Vertical
+	if vertical == "" {
+		vertical = "retail"
+	}
+	generated, err := s.starterKit.Generate(ctx, StarterKitRequest{
+		BusinessName:    name,
+		Vertical:        vertical,
+		Channel:         request.Channel,
+		PrimaryLanguage: request.PrimaryLanguage,
+	}, domain.SandboxOwnerID)
+	if err != nil {
+		if errors.Is(err, ErrInvalidStarterKit) {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidSandbox, strings.TrimPrefix(err.Error(), ErrInvalidStarterKit.Error()+": "))
+		}
+		return nil, err
+	}
+
+	bot := generated.Bot
+	now := s.clock.Now()
+	expiresAt := now.Add(ttl)
+	if err := markSandbox(bot, &domain.SandboxConfig{ExpiresAt: expiresAt, CreatedBy: caller}, now); err != nil {
+		s.deleteBot(ctx, bot.ID)
+		return nil, err
+	}
+	if err := s.botRepo.Update(ctx, bot); err != nil {
+		s.deleteBot(ctx, bot.ID)
+		return nil, fmt.Errorf("failed to mark sandbox bot: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Sandbox bot created",
+		"bot_id", bot.ID,
+		"caller", caller,
+		"expires_at", expiresAt)
+	return &SandboxBotResult{StarterKitResult: *generated, ExpiresAt: expiresAt}, nil
+}
+
+func (s *sandboxService) ListBots(ctx context.Context, caller string) ([]*domain.Bot, error) {
+	bots, err := s.liveBots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return filterSandboxBots(bots, caller), nil
+}
+
+func (s *sandboxService) DeleteBot(ctx context.Context, id, caller string) error {
+	bot, err := s.botRepo.GetByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrSandboxBotNotFound, id)
+	}
+	sandbox := parseBotConfig(bot).Sandbox
+	if bot.OwnerID != domain.SandboxOwnerID || sandbox == nil || sandbox.CreatedBy != caller {
+		return fmt.Errorf("%w: %s", ErrSandboxBotNotFound, id)
+	}
+	return s.deleteBot(ctx, id)
+}
+
+func (s *sandboxService) DeleteExpired(ctx context.Context) (int, error) {
+	bots, err := s.botRepo.GetByOwnerID(ctx, domain.SandboxOwnerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list sandbox bots: %w", err)
+	}
+
+	now := s.clock.Now()
+	deleted := 0
+	for _, bot := range bots {
+		if sandbox := parseBotConfig(bot).Sandbox; sandbox != nil && now.Before(sandbox.ExpiresAt) {
+			continue
+		}
+		if err := s.deleteBot(ctx, bot.ID); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to delete expired sandbox bot", "bot_id", bot.ID, "error", err)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		s.logger.WithContext(ctx).Info("Expired sandbox bots deleted", "count", deleted)
+	}
+	return deleted, nil
+}
+
+// Start elimina los bots caducados cada CleanupInterval hasta que se llame a Stop
+func (s *sandboxService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.limits.CleanupInterval <= 0 {
+		return nil
+	}
+	if s.cancel != nil {
+		return fmt.Errorf("sandbox cleanup already started")
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.run(ctx)
+
+	s.logger.Info("Sandbox cleanup started", "interval", s.limits.CleanupInterval)
+	return nil
+}
+
+func (s *sandboxService) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+}
+
+func (s *sandboxService) run(ctx context.Context) {
+	ticker := s.clock.NewTicker(s.limits.CleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if _, err := s.DeleteExpired(ctx); err != nil {
+				s.logger.Error("Sandbox cleanup failed", "error", err)
+			}
+		}
+	}
+}
+
+// liveBots devuelve los bots de sandbox que todavía no han caducado
+func (s *sandboxService) liveBots(ctx context.Context) ([]*domain.Bot, error) {
+	bots, err := s.botRepo.GetByOwnerID(ctx, domain.SandboxOwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sandbox bots: %w", err)
+	}
+	now := s.clock.Now()
+	live := make([]*domain.Bot, 0, len(bots))
+	for _, bot := range bots {
+		if sandbox := parseBotConfig(bot).Sandbox; sandbox != nil && now.Before(sandbox.ExpiresAt) {
+			live = append(live, bot)
+		}
+	}
+	return live, nil
+}
+
+// deleteBot elimina el bot, sus recursos y sus pruebas de conversación
+func (s *sandboxService) deleteBot(ctx context.Context, id string) error {
+	if tests, err := s.tests.GetMultiTurnTestCasesByBot(ctx, id); err == nil {
+		for _, test := range tests {
+			if err := s.tests.DeleteMultiTurnTestCase(ctx, test.ID); err != nil {
+				s.logger.WithContext(ctx).Warn("Failed to delete sandbox test", "bot_id", id, "test_id", test.ID, "error", err)
+			}
+		}
+	}
+	return s.bundles.DeleteBot(ctx, id)
+}
+
+func filterSandboxBots(bots []*domain.Bot, caller string) []*domain.Bot {
+	filtered := make([]*domain.Bot, 0)
+	for _, bot := range bots {
+		if sandbox := parseBotConfig(bot).Sandbox; sandbox != nil && sandbox.CreatedBy == caller {
+			filtered = append(filtered, bot)
+		}
+	}
+	return filtered
+}
+
+// markSandbox añade la sección sandbox a la configuración del bot conservando el resto
+func markSandbox(bot *domain.Bot, sandbox *domain.SandboxConfig, now time.Time) error {
+	config := make(map[string]interface{})
+	if len(bot.Config) > 0 {
+		if err := json.Unmarshal(bot.Config, &config); err != nil {
+			return fmt.Errorf("failed to parse bot config: %w", err)
+		}
+	}
+	config["sandbox"] = sandbox
+	raw, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to encode bot config: %w", err)
+	}
+	bot.Config = raw
+	bot.UpdatedAt = now
+	return nil
+}
+
+// sandboxKey marca en el contexto que el mensaje es de un bot de sandbox
+type sandboxKey struct{}
+
+func withSandbox(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sandboxKey{}, true)
+}
+
+func inSandbox(ctx context.Context) bool {
+	sandboxed, _ := ctx.Value(sandboxKey{}).(bool)
+	return sandboxed
+}
+
+// sandboxMockTypes son los agentes con modo simulado propio, que se activa
+// al quitarles la API key; el resto se sustituye por el agente mock
+var sandboxMockTypes = map[string]bool{
+	"ai":            true,
+	"image":         true,
+	"moderation":    true,
+	"transcription": true,
+	"translation":   true,
+}
+
+// instantiateAgent crea el agente de un paso o servicio del bot. En sandbox
+// nunca se llama a servicios externos.
+func (s *botService) instantiateAgent(ctx context.Context, config mcp.MCPConfig) (mcp.Agent, error) {
+	if inSandbox(ctx) {
+		config = sandboxAgentConfig(config)
+	}
+	return s.mcpOrchestrator.InstantiateMCP(ctx, config)
+}
+
+func sandboxAgentConfig(config mcp.MCPConfig) mcp.MCPConfig {
+	if !sandboxMockTypes[config.Type] {
+		config.Type = "mock"
+		config.Config = map[string]interface{}{
+			"failure_rate":           0,
+			"min_processing_time_ms": 0,
+			"max_processing_time_ms": 0,
+		}
+		return config
+	}
+
+	mocked := make(map[string]interface{}, len(config.Config))
+	for key, value := range config.Config {
+		if key != "openai_api_key" && key != "base_url" {
+			mocked[key] = value
+		}
+	}
+	if config.Type == "transcription" {
+		mocked["provider"] = mcp.TranscriptionProviderOpenAI
+	}
+	config.Config = mocked
+	return config
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSandboxService_CreatesAndExpiresBots(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	conditionalRepo := repositories.NewMockConditionalRepository()
+	conditionalSvc := NewConditionalService(conditionalRepo, log)
+	triggerRepo := repositories.NewMockTriggerRepository()
+	triggerSvc := NewTriggerService(triggerRepo, conditionalSvc, TriggerActionDeps{}, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, nil, nil, log)
+	bots.UseClock(clk)
+	bundles := NewBotBundleService(botRepo, flowRepo, stepRepo, repositories.NewMockSmartReplyRepository(), conditionalRepo, triggerRepo, log)
+	tests := NewTestService(repositories.NewMockTestCaseRepository(), repositories.NewMockMultiTurnTestCaseRepository(),
+		repositories.NewMockTestRunRepository(), sessionRepo, bots, conditionalSvc, triggerSvc, log)
+	sandbox := NewSandboxService(botRepo, NewStarterKitService(bundles, tests, log), bundles, tests, SandboxLimits{
+		DefaultTTL:       time.Hour,
+		MaxTTL:           2 * time.Hour,
+		MaxBotsPerCaller: 1,
+	}, clk, log)
+
+	_, err := sandbox.CreateBot(ctx, SandboxBotRequest{TTLHours: 3}, "ci")
+	assert.ErrorIs(t, err, ErrInvalidSandbox)
+
+	result, err := sandbox.CreateBot(ctx, SandboxBotRequest{}, "ci")
+	require.NoError(t, err)
+	assert.Equal(t, domain.SandboxOwnerID, result.Bot.OwnerID)
+	assert.Equal(t, clk.Now().Add(time.Hour), result.ExpiresAt)
+
+	_, err = sandbox.CreateBot(ctx, SandboxBotRequest{}, "ci")
+	assert.ErrorIs(t, err, ErrSandboxLimit)
+	_, err = sandbox.CreateBot(ctx, SandboxBotRequest{Name: "Demo", Vertical: "restaurant", TTLHours: 2}, "prospect")
+	require.NoError(t, err)
+
+	listed, err := sandbox.ListBots(ctx, "ci")
+	require.NoError(t, err)
+	require.Len(t, listed, 1)
+	assert.Equal(t, result.Bot.ID, listed[0].ID)
+	assert.ErrorIs(t, sandbox.DeleteBot(ctx, result.Bot.ID, "prospect"), ErrSandboxBotNotFound)
+
+	// El bot funciona como cualquier otro mientras no caduca
+	for _, id := range result.ConversationTests {
+		outcome, err := tests.ExecuteMultiTurnTestCase(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, outcome.Success, "test %s failed: %+v", id, outcome.Turns)
+	}
+
+	// El servicio de sandbox y el de bots caducan el bot con el mismo reloj
+	clk.Advance(time.Hour + time.Minute)
+	bot := result.Bot
+	response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: bot.ID, UserID: "u-1", Content: "hola"})
+	require.NoError(t, err)
+	assert.Equal(t, "Bot is currently unavailable", response.Content)
+
+	deleted, err := sandbox.DeleteExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+	_, err = botRepo.GetByID(ctx, bot.ID)
+	assert.Error(t, err)
+	flows, _ := flowRepo.GetByBotID(ctx, bot.ID)
+	assert.Empty(t, flows)
+	remaining, _ := tests.GetMultiTurnTestCasesByBot(ctx, bot.ID)
+	assert.Empty(t, remaining)
+}
+
+func TestSandboxAgentConfig(t *testing.T) {
+	ai := sandboxAgentConfig(mcp.MCPConfig{Type: "ai", Config: map[string]interface{}{"openai_api_key": "sk-live", "model": "gpt-4"}})
+	assert.Equal(t, "ai", ai.Type)
+	assert.Equal(t, map[string]interface{}{"model": "gpt-4"}, ai.Config)
+
+	transcription := sandboxAgentConfig(mcp.MCPConfig{Type: "transcription", Config: map[string]interface{}{"provider": "local"}})
+	assert.Equal(t, mcp.TranscriptionProviderOpenAI, transcription.Config["provider"])
+
+	http := sandboxAgentConfig(mcp.MCPConfig{Type: "http", Config: map[string]interface{}{"base_url": "https://crm.example.com"}})
+	assert.Equal(t, "mock", http.Type)
+	assert.Equal(t, 0, http.Config["failure_rate"])
+}
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+var (
+	ErrScriptApprovalNotFound = errors.New("script approval not found")
+	ErrInvalidScriptApproval  = errors.New("invalid script approval")
+)
+
+// ScriptApprovalRequest aprueba un script por su contenido o por su hash
+// SHA-256; si vienen los dos deben coincidir
+type ScriptApprovalRequest struct {
+	Script       string `json:"script"`
+	ScriptSHA256 string `json:"script_sha256"`
+	Notes        string `json:"notes"`
+}
+
+// ScriptApprovalService gestiona los scripts que los agentes sandbox pueden
+// ejecutar. Solo los administradores aprueban; quien envía la tarea no puede.
+type ScriptApprovalService interface {
+	Approve(ctx context.Context, request ScriptApprovalRequest, approvedBy string) (*domain.ScriptApproval, error)
+	Get(ctx context.Context, hash string) (*domain.ScriptApproval, error)
+	List(ctx context.Context) ([]*domain.ScriptApproval, error)
+	Revoke(ctx context.Context, hash string) error
+}
+
+type scriptApprovalService struct {
+	repo   domain.ScriptApprovalRepository
+	clock  clock.Clock
+	logger logger.Logger
+}
+
+// NewScriptApprovalService crea el servicio de aprobación de scripts
+func NewScriptApprovalService(repo domain.ScriptApprovalRepository, clk clock.Clock, logger logger.Logger) ScriptApprovalService {
+	return &scriptApprovalService{repo: repo, clock: clock.OrReal(clk), logger: logger}
+}
+
+// scriptHash normaliza el hash de la petición o lo calcula a partir del script
+func scriptHash(request ScriptApprovalRequest) (string, error) {
+	hash := strings.ToLower(strings.TrimSpace(request.ScriptSHA256))
+	if hash != "" {
+		if decoded, err := hex.DecodeString(hash); err != nil || len(decoded) != sha256.Size {
+			return "", fmt.Errorf("%w: script_sha256 must be a hex-encoded SHA-256 hash", ErrInvalidScriptApproval)
+		}
+	}
+	if request.Script == "" {
+		if hash == "" {
+			return "", fmt.Errorf("%w: script or script_sha256 is required", ErrInvalidScriptApproval)
+		}
+		return hash, nil
+	}
+
+	sum := sha256.Sum256([]byte(request.Script))
+	computed := hex.EncodeToString(sum[:])
+	if hash != "" && hash != computed {
+		return "", fmt.Errorf("%w: script_sha256 does not match script", ErrInvalidScriptApproval)
+	}
+	return computed, nil
+}
+
+func (s *scriptApprovalService) Approve(ctx context.Context, request ScriptApprovalRequest, approvedBy string) (*domain.ScriptApproval, error) {
+	if approvedBy == "" {
+		return nil, fmt.Errorf("%w: approval requires an authenticated user", ErrInvalidScriptApproval)
+	}
+	hash, err := scriptHash(request)
+	if err != nil {
+		return nil, err
+	}
+
+	approval := &domain.ScriptApproval{
+		Hash:       hash,
+		ApprovedBy: approvedBy,
+		Notes:      request.Notes,
+		CreatedAt:  s.clock.Now(),
+	}
+	if err := s.repo.Create(ctx, approval); err != nil {
+		return nil, fmt.Errorf("failed to store script approval: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Script approved", "script_sha256", hash, "approved_by", approvedBy)
+	return approval, nil
+}
+
+func (s *scriptApprovalService) Get(ctx context.Context, hash string) (*domain.ScriptApproval, error) {
+	approval, err := s.repo.Get(ctx, strings.ToLower(hash))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrScriptApprovalNotFound, hash)
+	}
+	return approval, nil
+}
+
+func (s *scriptApprovalService) List(ctx context.Context) ([]*domain.ScriptApproval, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *scriptApprovalService) Revoke(ctx context.Context, hash string) error {
+	hash = strings.ToLower(hash)
+	if err := s.repo.Delete(ctx, hash); err != nil {
+		return fmt.Errorf("%w: %s", ErrScriptApprovalNotFound, hash)
+	}
+	s.logger.WithContext(ctx).Info("Script approval revoked", "script_sha256", hash)
+	return nil
+}
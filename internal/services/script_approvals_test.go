@@ -0,0 +1,45 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScriptApprovalService(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	service := NewScriptApprovalService(repositories.NewMockScriptApprovalRepository(), clk, logger.NewLogger("error"))
+
+	// Un hash válido que no es el de "print(1)"
+	const hash = "5a3f2dab6fac1bc4ecebc3eb8c0ffc2a1e2ed3c4b5e8f6d3a6a5e3b0f3c2a1d0"
+	_, err := service.Approve(ctx, ScriptApprovalRequest{Script: "print(1)"}, "")
+	assert.ErrorIs(t, err, ErrInvalidScriptApproval)
+	_, err = service.Approve(ctx, ScriptApprovalRequest{}, "admin")
+	assert.ErrorIs(t, err, ErrInvalidScriptApproval)
+	_, err = service.Approve(ctx, ScriptApprovalRequest{ScriptSHA256: "abc"}, "admin")
+	assert.ErrorIs(t, err, ErrInvalidScriptApproval)
+	_, err = service.Approve(ctx, ScriptApprovalRequest{Script: "print(1)", ScriptSHA256: hash}, "admin")
+	assert.ErrorIs(t, err, ErrInvalidScriptApproval)
+
+	approval, err := service.Approve(ctx, ScriptApprovalRequest{Script: "print(1)", Notes: "report"}, "admin")
+	require.NoError(t, err)
+	assert.Len(t, approval.Hash, 64)
+	assert.Equal(t, "admin", approval.ApprovedBy)
+	assert.Equal(t, clk.Now(), approval.CreatedAt)
+
+	stored, err := service.Get(ctx, approval.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, "report", stored.Notes)
+
+	require.NoError(t, service.Revoke(ctx, approval.Hash))
+	assert.ErrorIs(t, service.Revoke(ctx, approval.Hash), ErrScriptApprovalNotFound)
+	_, err = service.Get(ctx, approval.Hash)
+	assert.ErrorIs(t, err, ErrScriptApprovalNotFound)
+}
@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/id"
+)
+
+const (
+	// Claves del contexto de la sesión con el sentimiento del último mensaje
+	SentimentScoreContextKey = "sentiment_score"
+	SentimentLabelContextKey = "sentiment_label"
+	NegativeStreakContextKey = "negative_streak"
+
+	// DefaultSentimentThreshold es la puntuación a partir de la cual un mensaje
+	// cuenta para la racha negativa si el bot no configura threshold
+	DefaultSentimentThreshold = -0.3
+	// DefaultSentimentConsecutive es la racha que dispara sentiment_threshold
+	DefaultSentimentConsecutive = 2
+
+	// sentimentLabelCutoff separa neutral de positive y negative
+	sentimentLabelCutoff = 0.2
+)
+
+const sentimentSystemPrompt = `You rate the sentiment of a customer's message to a support bot.
+Reply only with JSON: {"score": <number from -1 (very negative, frustrated) to 1 (very positive)>}.`
+
+// sentimentLexicon son pesos de palabras (sin tildes) para puntuar sin IA
+var sentimentLexicon = map[string]float64{
+	"gracias": 1.5, "genial": 2, "excelente": 2.5, "perfecto": 2, "bien": 1, "bueno": 1, "encanta": 2.5, "feliz": 2, "amable": 1.5, "ayuda": 0.5,
+	"thanks": 1.5, "great": 2, "excellent": 2.5, "perfect": 2, "good": 1, "love": 2.5, "happy": 2, "awesome": 2.5, "nice": 1.5, "helpful": 1.5,
+	"mal": -1.5, "malo": -1.5, "pesimo": -3, "horrible": -3, "terrible": -3, "inutil": -2.5, "molesto": -2, "enfadado": -2.5, "harto": -2.5,
+	"cansado": -1.5, "frustrado": -2.5, "queja": -1.5, "estafa": -3, "nunca": -1, "problema": -1, "error": -1, "tarde": -0.5, "basura": -3,
+	"bad": -1.5, "awful": -3, "useless": -2.5, "angry": -2.5, "annoyed": -2, "frustrated": -2.5, "worst": -3, "hate": -3, "scam": -3,
+	"complaint": -1.5, "never": -1, "problem": -1, "broken": -2, "ridiculous": -2.5, "waste": -2, "tired": -1.5,
+}
+
+// sentimentNegations invierten el sentido de las palabras que les siguen
+var sentimentNegations = map[string]bool{"no": true, "not": true, "ni": true, "never": true}
+
+// analyzeSentiment puntúa el mensaje, guarda el resultado y la racha negativa
+// en el contexto de la sesión y lo deja en message.Sentiment
+func (s *botService) analyzeSentiment(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession, config *domain.SentimentConfig) {
+	if config == nil || strings.TrimSpace(message.Content) == "" {
+		return
+	}
+
+	sentiment, err := s.scoreWithAgent(ctx, message, config)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to score sentiment with AI, using lexicon", "bot_id", message.BotID, "message_id", message.ID, "error", err)
+	}
+	if sentiment == nil {
+		sentiment = lexiconSentiment(message.Content)
+	}
+
+	threshold := config.Threshold
+	if threshold >= 0 || threshold < -1 {
+		threshold = DefaultSentimentThreshold
+	}
+	consecutive := config.ConsecutiveMessages
+	if consecutive <= 0 {
+		consecutive = DefaultSentimentConsecutive
+	}
+
+	if session.Context == nil {
+		session.Context = make(map[string]interface{})
+	}
+	if sentiment.Score <= threshold {
+		previous, _ := toFloat(session.Context[NegativeStreakContextKey])
+		sentiment.NegativeStreak = int(previous) + 1
+		sentiment.ThresholdReached = sentiment.NegativeStreak == consecutive
+	}
+	session.Context[SentimentScoreContextKey] = sentiment.Score
+	session.Context[SentimentLabelContextKey] = sentiment.Label
+	session.Context[NegativeStreakContextKey] = sentiment.NegativeStreak
+	message.Sentiment = sentiment
+}
+
+// scoreWithAgent puntúa con un agente MCP de IA. Sin openai_api_key (o en un
+// bot de sandbox) devuelve nil para que se use el léxico.
+func (s *botService) scoreWithAgent(ctx context.Context, message *domain.IncomingMessage, config *domain.SentimentConfig) (*domain.MessageSentiment, error) {
+	if apiKey, _ := config.Config["openai_api_key"].(string); apiKey == "" || inSandbox(ctx) || s.mcpOrchestrator == nil {
+		return nil, nil
+	}
+
+	agent, err := s.instantiateAgent(ctx, mcp.MCPConfig{
+		Type:         "ai",
+		Name:         fmt.Sprintf("sentiment-agent-%s", message.BotID),
+		Version:      "1.0",
+		Config:       config.Config,
+		Capabilities: []string{"analysis"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate sentiment agent: %w", err)
+	}
+	defer func() {
+		if err := s.mcpOrchestrator.TerminateAgent(ctx, agent.GetID()); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
+		}
+	}()
+
+	result, err := agent.Execute(ctx, mcp.Task{
+		ID:          fmt.Sprintf("sentiment-%s-%s", message.BotID, id.New()),
+		Type:        string(domain.MCPTaskTypeAnalysis),
+		Description: "Sentiment of an incoming message",
+		Input: map[string]interface{}{
+			"system":      sentimentSystemPrompt,
+			"prompt":      message.Content,
+			"temperature": 0.0,
+			"max_tokens":  20,
+		},
+		Priority: 5,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("sentiment analysis failed: %s", result.Error)
+	}
+
+	text, _ := result.Output["text"].(string)
+	score, err := parseSentimentScore(text)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.MessageSentiment{Score: score, Label: sentimentLabel(score), Source: "ai"}, nil
+}
+
+// parseSentimentScore extrae la puntuación del JSON de la respuesta
+func parseSentimentScore(text string) (float64, error) {
+	start := strings.Index(text, "{")
+	end := strings.LastIndex(text, "}")
+	if start < 0 || end <= start {
+		return 0, fmt.Errorf("sentiment response is not JSON")
+	}
+	var parsed struct {
+		Score *float64 `json:"score"`
+	}
+	if err := json.Unmarshal([]byte(text[start:end+1]), &parsed); err != nil {
+		return 0, fmt.Errorf("invalid sentiment JSON: %w", err)
+	}
+	if parsed.Score == nil {
+		return 0, fmt.Errorf("sentiment response without score")
+	}
+	return math.Max(-1, math.Min(1, *parsed.Score)), nil
+}
+
+// lexiconSentiment suma los pesos de las palabras conocidas (atenuados e
+// invertidos tras una negación) y los normaliza a -1..1; las exclamaciones y las mayúsculas
+// acentúan el tono
+func lexiconSentiment(text string) *domain.MessageSentiment {
+	words := strings.Fields(normalizeText(text))
+	total := 0.0
+	for i, word := range words {
+		weight, ok := sentimentLexicon[word]
+		if !ok {
+			continue
+		}
+		// La negación alcanza hasta dos palabras antes ("no funciona bien")
+		for j := max(0, i-2); j < i; j++ {
+			if sentimentNegations[words[j]] {
+				weight = -weight / 2
+				break
+			}
+		}
+		total += weight
+	}
+
+	emphasis := 1.0 + 0.15*float64(min(strings.Count(text, "!"), 3))
+	if letters := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' {
+			return r
+		}
+		return -1
+	}, text); len(letters) >= 6 && letters == strings.ToUpper(letters) {
+		emphasis += 0.3
+	}
+	total *= emphasis
+
+	score := total / math.Sqrt(total*total+4)
+	return &domain.MessageSentiment{Score: score, Label: sentimentLabel(score), Source: "lexicon"}
+}
+
+func sentimentLabel(score float64) string {
+	switch {
+	case score >= sentimentLabelCutoff:
+		return "positive"
+	case score <= -sentimentLabelCutoff:
+		return "negative"
+	default:
+		return "neutral"
+	}
+}
+
+// publishSentimentThreshold avisa de que el usuario llegó a la racha negativa
+// configurada; los triggers sentiment_threshold lo escuchan
+func (s *botService) publishSentimentThreshold(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession) {
+	if s.eventBus == nil || message.Sentiment == nil || !message.Sentiment.ThresholdReached {
+		return
+	}
+	event := s.events.CreateUserEvent(events.EventTypeSentimentThreshold, message.UserID, map[string]interface{}{
+		"bot_id":          message.BotID,
+		"session_id":      session.ID,
+		"message_id":      message.ID,
+		"channel":         message.Channel,
+		"content":         message.Content,
+		"sentiment":       message.Sentiment.Score,
+		"sentiment_label": message.Sentiment.Label,
+		"negative_streak": message.Sentiment.NegativeStreak,
+	})
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish sentiment threshold event", "error", err)
+	}
+}
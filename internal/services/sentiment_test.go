@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLexiconSentiment(t *testing.T) {
+	tests := []struct {
+		text  string
+		label string
+	}{
+		{"¡Muchas gracias, excelente atención!", "positive"},
+		{"Quiero saber el estado de mi pedido", "neutral"},
+		{"Esto es pésimo, estoy harto", "negative"},
+		{"no funciona bien", "negative"},
+		{"This is useless, worst service ever", "negative"},
+	}
+	for _, tt := range tests {
+		sentiment := lexiconSentiment(tt.text)
+		assert.Equal(t, tt.label, sentiment.Label, tt.text)
+		assert.Equal(t, "lexicon", sentiment.Source)
+		assert.GreaterOrEqual(t, sentiment.Score, -1.0)
+		assert.LessOrEqual(t, sentiment.Score, 1.0)
+	}
+
+	assert.Less(t, lexiconSentiment("ESTO ES HORRIBLE!!!").Score, lexiconSentiment("esto es horrible").Score)
+
+	score, err := parseSentimentScore("```json\n{\"score\": -1.7}\n```")
+	require.NoError(t, err)
+	assert.Equal(t, -1.0, score)
+	_, err = parseSentimentScore("negative")
+	assert.Error(t, err)
+}
+
+func TestSentimentThreshold_EscalatesAfterConsecutiveNegativeMessages(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	bus := events.NewInMemoryEventBus(log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, bus, nil, log)
+	triggers := NewTriggerService(repositories.NewMockTriggerRepository(), NewConditionalService(repositories.NewMockConditionalRepository(), log),
+		TriggerActionDeps{Escalator: bots}, log)
+	require.NoError(t, triggers.CreateTrigger(ctx, &domain.Trigger{BotID: "bot-1", Name: "frustrated", Event: domain.TriggerEventSentimentThreshold, Enabled: true,
+		Action: domain.TriggerAction{Type: ActionEscalate, Config: map[string]interface{}{"queue": "priority"}}}))
+	require.NoError(t, triggers.Subscribe(bus))
+
+	config, err := json.Marshal(domain.BotConfig{Sentiment: &domain.SentimentConfig{ConsecutiveMessages: 2}})
+	require.NoError(t, err)
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive, Config: config}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "echo", IsDefault: true}))
+	next := "echo"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "echo", FlowID: "flow-1", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Entendido"}`), NextStepID: &next}))
+
+	send := func(text string) *domain.ConversationSession {
+		_, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: text})
+		require.NoError(t, err)
+		session, err := sessionRepo.GetByUserAndBot(ctx, "user-1", "bot-1")
+		require.NoError(t, err)
+		return session
+	}
+
+	session := send("Esto es horrible")
+	assert.Equal(t, "negative", session.Context[SentimentLabelContextKey])
+	assert.Equal(t, 1, session.Context[NegativeStreakContextKey])
+
+	// Un mensaje neutro corta la racha
+	session = send("¿Dónde está mi pedido?")
+	assert.Equal(t, 0, session.Context[NegativeStreakContextKey])
+
+	send("Sigue sin llegar, pésimo servicio")
+	session = send("Estoy harto, es inútil!!")
+	assert.Equal(t, 2, session.Context[NegativeStreakContextKey])
+
+	assert.Eventually(t, func() bool {
+		session, err := sessionRepo.GetByUserAndBot(ctx, "user-1", "bot-1")
+		return err == nil && session.Handoff != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	session, err = sessionRepo.GetByUserAndBot(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "priority", session.Handoff.Queue)
+	assert.Equal(t, "trigger:frustrated", session.Handoff.Reason)
+}
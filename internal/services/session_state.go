@@ -0,0 +1,270 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// internalContextKeys son claves que el motor de flujos guarda en el contexto
+// y que no se muestran como variables de la conversación
+var internalContextKeys = map[string]bool{
+	delayStateKey:    true,
+	subflowStackKey:  true,
+	resumeFlowKey:    true,
+	resumeStepKey:    true,
+	resumeVersionKey: true,
+}
+
+// GetSessionState describe dónde está la conversación, qué entrada espera el
+// bot y a qué pasos puede avanzar, para que soporte vea dónde está atascado un usuario
+func (s *botService) GetSessionState(ctx context.Context, sessionID string) (*domain.SessionState, error) {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("conversation not found: %w", err)
+	}
+
+	state := &domain.SessionState{
+		SessionID:     session.ID,
+		BotID:         session.BotID,
+		UserID:        session.UserID,
+		Status:        domain.SessionStatusIdle,
+		Transitions:   []domain.StateTransition{},
+		PendingTimers: []domain.PendingTimer{},
+		Variables:     sessionVariables(session),
+		Handoff:       session.Handoff,
+		UpdatedAt:     session.UpdatedAt,
+		ExpiresAt:     session.ExpiresAt,
+	}
+
+	if session.CurrentFlowID != "" {
+		state.Flow = s.sessionFlowRef(ctx, session.CurrentFlowID, session.FlowVersion)
+	}
+	for _, frame := range subflowStack(session) {
+		ref := s.sessionFlowRef(ctx, frame.FlowID, frame.FlowVersion)
+		ref.ReturnStep = frame.ReturnStep
+		state.SubflowStack = append(state.SubflowStack, *ref)
+	}
+	if flowID, ok := session.Context[resumeFlowKey].(string); ok && flowID != "" {
+		version, _ := toFloat(session.Context[resumeVersionKey])
+		state.InterruptedFlow = s.sessionFlowRef(ctx, flowID, int(version))
+		state.InterruptedFlow.ReturnStep, _ = session.Context[resumeStepKey].(string)
+	}
+	if delay, ok := pendingDelay(session); ok {
+		resumeAt, _ := time.Parse(time.RFC3339, delay.ResumeAt)
+		state.PendingTimers = append(state.PendingTimers, domain.PendingTimer{
+			Type:     string(domain.StepTypeDelay),
+			StepID:   delay.StepID,
+			TaskID:   delay.TaskID,
+			ResumeAt: resumeAt,
+			Overdue:  delay.expired(s.clock.Now()),
+		})
+	}
+
+	var step *domain.BotStep
+	if session.CurrentFlowID != "" && session.CurrentStepID != "" {
+		step, err = s.flowStep(ctx, session.CurrentFlowID, session.FlowVersion, session.CurrentStepID)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("Current step of session not found", "session_id", session.ID, "step_id", session.CurrentStepID, "error", err)
+			step = nil
+		}
+	}
+	if step != nil {
+		state.Step = &domain.SessionStepRef{ID: step.ID, Name: step.Name, Type: step.Type}
+	}
+
+	switch {
+	case session.EndedAt != nil:
+		state.Status = domain.SessionStatusEnded
+		return state, nil
+	case session.Handoff != nil:
+		state.Status = domain.SessionStatusHandoff
+		return state, nil
+	}
+
+	if step != nil {
+		state.Status = domain.SessionStatusActive
+		if step.Type == domain.StepTypeDelay && len(state.PendingTimers) > 0 {
+			state.Status = domain.SessionStatusDelayed
+		}
+		state.Expects, state.Transitions = stepTransitions(step, session)
+	} else {
+		// Sin paso pendiente el próximo mensaje empieza en el entry point
+		state.Expects = &domain.ExpectedInput{Kind: "any"}
+		if flow, err := s.flowRepo.GetByID(ctx, session.CurrentFlowID); err == nil {
+			state.Transitions = append(state.Transitions, domain.StateTransition{
+				Trigger:      "message",
+				TargetFlowID: flow.ID,
+				TargetStepID: s.flowEntryPoint(ctx, flow, session.FlowVersion),
+			})
+		}
+	}
+
+	// Los intents globales interrumpen cualquier paso
+	if bot, err := s.botRepo.GetByID(ctx, session.BotID); err == nil {
+		for _, intent := range parseBotConfig(bot).GlobalIntents {
+			state.Transitions = append(state.Transitions, domain.StateTransition{
+				Trigger:      "global_intent",
+				Condition:    strings.Join(intent.Keywords, ", "),
+				TargetFlowID: intent.FlowID,
+			})
+		}
+	}
+
+	return state, nil
+}
+
+// stepTransitions deduce del contenido del paso qué entrada necesita y sus
+// salidas posibles. Un contenido inválido deja el paso sin transiciones.
+func stepTransitions(step *domain.BotStep, session *domain.ConversationSession) (*domain.ExpectedInput, []domain.StateTransition) {
+	expects := &domain.ExpectedInput{Kind: "any"}
+	transitions := []domain.StateTransition{}
+	onMessage := func(target *string) {
+		if target != nil && *target != "" {
+			transitions = append(transitions, domain.StateTransition{Trigger: "message", TargetStepID: *target})
+		}
+	}
+
+	switch step.Type {
+	case domain.StepTypeDecision:
+		var conditions struct {
+			Rules []struct {
+				Condition string `json:"condition"`
+				NextStep  string `json:"next_step"`
+			} `json:"rules"`
+			Default string `json:"default"`
+		}
+		if json.Unmarshal(step.Conditions, &conditions) == nil {
+			for _, rule := range conditions.Rules {
+				transitions = append(transitions, domain.StateTransition{Trigger: "condition", Condition: rule.Condition, TargetStepID: rule.NextStep})
+			}
+			transitions = append(transitions, domain.StateTransition{Trigger: "default", TargetStepID: conditions.Default})
+		}
+
+	case domain.StepTypeInput:
+		var content struct {
+			Prompt   string `json:"prompt"`
+			Variable string `json:"variable"`
+			Entity   string `json:"entity"`
+		}
+		if json.Unmarshal(step.Content, &content) == nil {
+			expects = &domain.ExpectedInput{Kind: "text", Variable: content.Variable, Prompt: content.Prompt}
+			if content.Entity != "" {
+				expects.Kind = "entity"
+				expects.Entity = content.Entity
+			}
+		}
+		onMessage(step.NextStepID)
+
+	case domain.StepTypeForm:
+		var content formContent
+		if json.Unmarshal(step.Content, &content) == nil {
+			if slot := nextFormSlot(step, content.Slots, session); slot != nil {
+				expects = &domain.ExpectedInput{Kind: "slot", Slot: slot.Name, Entity: slot.Entity, Prompt: slot.Prompt, Variable: content.Variable}
+			}
+		}
+		if step.NextStepID != nil {
+			transitions = append(transitions, domain.StateTransition{Trigger: "message", Condition: "form complete", TargetStepID: *step.NextStepID})
+		}
+
+	case domain.StepTypeDelay:
+		var content delayContent
+		if json.Unmarshal(step.Content, &content) == nil {
+			next := step.NextStepID
+			if content.NextStep != "" {
+				next = &content.NextStep
+			}
+			if next != nil {
+				transitions = append(transitions, domain.StateTransition{Trigger: "timer", TargetStepID: *next})
+			}
+			if content.ResumeOnMessage {
+				onMessage(next)
+			} else {
+				onMessage(&step.ID)
+			}
+		}
+
+	case domain.StepTypeRandom:
+		var content struct {
+			Variants []struct {
+				ID       string `json:"id"`
+				NextStep string `json:"next_step"`
+			} `json:"variants"`
+		}
+		if json.Unmarshal(step.Content, &content) == nil {
+			for _, variant := range content.Variants {
+				target := variant.NextStep
+				if target == "" && step.NextStepID != nil {
+					target = *step.NextStepID
+				}
+				transitions = append(transitions, domain.StateTransition{Trigger: "message", Condition: "variant " + variant.ID, TargetStepID: target})
+			}
+		}
+
+	case domain.StepTypeJump:
+		var content struct {
+			Step string `json:"step"`
+		}
+		if json.Unmarshal(step.Content, &content) == nil {
+			onMessage(&content.Step)
+		}
+
+	case domain.StepTypeSwitch, domain.StepTypeSubflow:
+		var content struct {
+			FlowID string `json:"flow_id"`
+			Step   string `json:"step"`
+		}
+		if json.Unmarshal(step.Content, &content) == nil {
+			transitions = append(transitions, domain.StateTransition{Trigger: "message", TargetFlowID: content.FlowID, TargetStepID: content.Step})
+		}
+
+	case domain.StepTypeEnd:
+		// La conversación termina con el próximo mensaje
+
+	default:
+		onMessage(step.NextStepID)
+	}
+
+	return expects, transitions
+}
+
+// nextFormSlot devuelve el slot que el formulario está preguntando o, si aún no
+// empezó, el primer slot requerido sin valor
+func nextFormSlot(step *domain.BotStep, slots []formSlot, session *domain.ConversationSession) *formSlot {
+	if pending, ok := session.Context[formPendingKey(step.ID)].(string); ok {
+		if slot := findSlot(slots, pending); slot != nil {
+			return slot
+		}
+	}
+
+	values, _ := session.Context[formStateKey(step.ID)].(map[string]interface{})
+	for i := range slots {
+		if _, filled := values[slots[i].Name]; !filled && !slots[i].Optional {
+			return &slots[i]
+		}
+	}
+	return nil
+}
+
+func (s *botService) sessionFlowRef(ctx context.Context, flowID string, version int) *domain.SessionFlowRef {
+	ref := &domain.SessionFlowRef{ID: flowID, Version: version}
+	if flow, err := s.flowRepo.GetByID(ctx, flowID); err == nil {
+		ref.Name = flow.Name
+	}
+	return ref
+}
+
+func sessionVariables(session *domain.ConversationSession) map[string]interface{} {
+	variables := make(map[string]interface{}, len(session.Context))
+	for key, value := range session.Context {
+		if internalContextKeys[key] || strings.HasPrefix(key, formPendingKey("")) {
+			continue
+		}
+		variables[key] = value
+	}
+	return variables
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetSessionState_ShowsExpectedInputAndTransitions(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive,
+		Config: json.RawMessage(`{"global_intents":[{"name":"human","keywords":["agent"],"flow_id":"support"}]}`)}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", Name: "Orders", EntryPoint: "ask", IsDefault: true}))
+
+	form := "form"
+	thanks := "thanks"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "ask", FlowID: "main", Type: domain.StepTypeMessage, NextStepID: &form,
+		Content: json.RawMessage(`{"text":"Let's place your order"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "form", FlowID: "main", Type: domain.StepTypeForm, NextStepID: &thanks,
+		Content: json.RawMessage(`{"variable":"order","slots":[{"name":"product","prompt":"Which product?"},{"name":"quantity","prompt":"How many?"}]}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "thanks", FlowID: "main", Type: domain.StepTypeEnd,
+		Content: json.RawMessage(`{"text":"bye"}`)}))
+
+	send := func(text string) {
+		_, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: text})
+		require.NoError(t, err)
+	}
+	send("hi")
+	send("start")
+	send("pizza")
+
+	session, err := conversations.GetSession(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+
+	state, err := bots.GetSessionState(ctx, session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.SessionStatusActive, state.Status)
+	assert.Equal(t, &domain.SessionFlowRef{ID: "main", Name: "Orders"}, state.Flow)
+	assert.Equal(t, &domain.SessionStepRef{ID: "form", Type: domain.StepTypeForm}, state.Step)
+	assert.Equal(t, &domain.ExpectedInput{Kind: "slot", Slot: "quantity", Prompt: "How many?", Variable: "order"}, state.Expects)
+	assert.Equal(t, []domain.StateTransition{
+		{Trigger: "message", Condition: "form complete", TargetStepID: "thanks"},
+		{Trigger: "global_intent", Condition: "agent", TargetFlowID: "support"},
+	}, state.Transitions)
+	assert.Equal(t, map[string]interface{}{"product": "pizza"}, state.Variables["form_form"])
+	assert.NotContains(t, state.Variables, formPendingKey("form"))
+	assert.Empty(t, state.PendingTimers)
+
+	_, err = bots.GetSessionState(ctx, "missing")
+	assert.Error(t, err)
+}
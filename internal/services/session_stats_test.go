@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// El scrape de /metrics lee Stats mientras los mensajes cambian los contextos;
+// con -race este test falla si Stats vuelve a tocar los mapas vivos
+func TestSessionStats_ScrapeWhileProcessingMessages(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "ask", IsDefault: true}))
+	ask := "ask"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "ask", FlowID: "main", Type: domain.StepTypeInput, NextStepID: &ask,
+		Content: json.RawMessage(`{"variable":"answer"}`)}))
+
+	const users = 4
+	done := make(chan struct{})
+	var scrapes sync.WaitGroup
+	scrapes.Add(1)
+	go func() {
+		defer scrapes.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				_, err := sessionRepo.Stats(ctx)
+				assert.NoError(t, err)
+			}
+		}
+	}()
+
+	var senders sync.WaitGroup
+	for u := 0; u < users; u++ {
+		senders.Add(1)
+		go func(userID string) {
+			defer senders.Done()
+			for i := 0; i < 20; i++ {
+				_, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: userID, Content: fmt.Sprintf("answer %d", i)})
+				assert.NoError(t, err)
+			}
+		}(fmt.Sprintf("user-%d", u))
+	}
+	senders.Wait()
+	close(done)
+	scrapes.Wait()
+
+	stats, err := sessionRepo.Stats(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, users, stats.SessionsByBot["bot-1"])
+	assert.Greater(t, stats.AverageContextSize, float64(0))
+}
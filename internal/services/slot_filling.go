@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+)
+
+// formSlot declara un dato requerido por un paso de formulario
+type formSlot struct {
+	Name         string `json:"name"`
+	Prompt       string `json:"prompt"`
+	Entity       string `json:"entity,omitempty"`     // tipo de entidad que rellena el slot
+	MemoryKey    string `json:"memory_key,omitempty"` // memoria del usuario usada para pre-rellenar
+	Validation   string `json:"validation,omitempty"` // expresión; {{value}} es el valor candidato
+	ErrorMessage string `json:"error_message,omitempty"`
+	Optional     bool   `json:"optional,omitempty"`
+}
+
+type formContent struct {
+	Slots             []formSlot `json:"slots"`
+	Variable          string     `json:"variable"`
+	CompletionMessage string     `json:"completion_message"`
+	Remember          bool       `json:"remember"` // guardar en memoria los slots con memory_key
+}
+
+func formStateKey(stepID string) string   { return "form_" + stepID }
+func formPendingKey(stepID string) string { return "form_pending_" + stepID }
+
+// processFormStep pide sólo los slots que faltan y, al completarlos, guarda el
+// formulario en el contexto y emite el evento form_completed
+func (s *botService) processFormStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	var content formContent
+	if err := json.Unmarshal(step.Content, &content); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse form step content: %w", err)
+	}
+	if len(content.Slots) == 0 {
+		return nil, nil, fmt.Errorf("form step %s has no slots", step.ID)
+	}
+	if content.Variable == "" {
+		content.Variable = "form"
+	}
+
+	values, ok := session.Context[formStateKey(step.ID)].(map[string]interface{})
+	if !ok {
+		values = make(map[string]interface{})
+	}
+
+	// Respuesta al slot que se preguntó en el turno anterior
+	if pending, ok := session.Context[formPendingKey(step.ID)].(string); ok {
+		if slot := findSlot(content.Slots, pending); slot != nil {
+			value := interface{}(message.Content)
+			if slot.Entity != "" {
+				entity := findEntity(session, slot.Entity)
+				if entity == nil {
+					return s.askSlot(step, slot, session, true), &step.ID, nil
+				}
+				value = entity.Value
+			}
+
+			if !s.validSlotValue(ctx, slot, value, message, session) {
+				return s.askSlot(step, slot, session, true), &step.ID, nil
+			}
+			values[slot.Name] = value
+		}
+	}
+
+	// Pre-rellenar el resto con entidades y memorias del usuario
+	s.prefillSlots(ctx, content.Slots, values, message, session)
+	session.Context[formStateKey(step.ID)] = values
+
+	for i := range content.Slots {
+		slot := &content.Slots[i]
+		if _, filled := values[slot.Name]; !filled && !slot.Optional {
+			return s.askSlot(step, slot, session, false), &step.ID, nil
+		}
+	}
+
+	// Formulario completo
+	delete(session.Context, formStateKey(step.ID))
+	delete(session.Context, formPendingKey(step.ID))
+	session.Context[content.Variable] = values
+
+	if content.Remember {
+		s.rememberSlots(ctx, content.Slots, values, session)
+	}
+	s.publishFormCompleted(ctx, step, content.Variable, values, session)
+
+	text := content.CompletionMessage
+	if text == "" {
+		text = "Thank you! I have all the information I need."
+	}
+
+	return &domain.BotResponse{
+		Content: replaceTemplateVariables(text, s.buildConditionInput(ctx, message, session)),
+		Type:    domain.ResponseTypeText,
+		Metadata: map[string]interface{}{
+			"form": values,
+		},
+	}, step.NextStepID, nil
+}
+
+func findSlot(slots []formSlot, name string) *formSlot {
+	for i := range slots {
+		if slots[i].Name == name {
+			return &slots[i]
+		}
+	}
+	return nil
+}
+
+// askSlot marca el slot como pendiente y devuelve su pregunta
+func (s *botService) askSlot(step *domain.BotStep, slot *formSlot, session *domain.ConversationSession, invalid bool) *domain.BotResponse {
+	session.Context[formPendingKey(step.ID)] = slot.Name
+
+	prompt := slot.Prompt
+	if prompt == "" {
+		prompt = fmt.Sprintf("Please provide your %s.", strings.ReplaceAll(slot.Name, "_", " "))
+	}
+	if invalid {
+		errorMessage := slot.ErrorMessage
+		if errorMessage == "" {
+			errorMessage = fmt.Sprintf("That doesn't look like a valid %s.", strings.ReplaceAll(slot.Name, "_", " "))
+		}
+		prompt = errorMessage + " " + prompt
+	}
+
+	return &domain.BotResponse{
+		Content: prompt,
+		Type:    domain.ResponseTypeText,
+		Metadata: map[string]interface{}{
+			"slot": slot.Name,
+		},
+	}
+}
+
+func (s *botService) validSlotValue(ctx context.Context, slot *formSlot, value interface{}, message *domain.IncomingMessage, session *domain.ConversationSession) bool {
+	if value == nil || strings.TrimSpace(fmt.Sprintf("%v", value)) == "" {
+		return false
+	}
+	if slot.Validation == "" {
+		return true
+	}
+
+	input := s.buildConditionInput(ctx, message, session)
+	input["value"] = value
+
+	valid, err := s.conditions.Evaluate(slot.Validation, input)
+	if err != nil {
+		s.logger.Warn("Invalid slot validation", "slot", slot.Name, "error", err)
+		return false
+	}
+	return valid
+}
+
+func (s *botService) prefillSlots(ctx context.Context, slots []formSlot, values map[string]interface{}, message *domain.IncomingMessage, session *domain.ConversationSession) {
+	entities, _ := session.Context[EntitiesContextKey].(map[string]interface{})
+
+	for i := range slots {
+		slot := &slots[i]
+		if _, filled := values[slot.Name]; filled {
+			continue
+		}
+
+		if slot.Entity != "" {
+			if value, ok := entities[slot.Entity]; ok && s.validSlotValue(ctx, slot, value, message, session) {
+				values[slot.Name] = value
+				continue
+			}
+		}
+
+		if slot.MemoryKey != "" && s.memorySvc != nil {
+			memory, err := s.memorySvc.GetMemory(ctx, session.UserID, session.BotID, slot.MemoryKey)
+			if err != nil {
+				continue
+			}
+			if value, ok := memory.Content["value"]; ok && s.validSlotValue(ctx, slot, value, message, session) {
+				values[slot.Name] = value
+			}
+		}
+	}
+}
+
+func (s *botService) rememberSlots(ctx context.Context, slots []formSlot, values map[string]interface{}, session *domain.ConversationSession) {
+	if s.memorySvc == nil {
+		return
+	}
+
+	for _, slot := range slots {
+		value, ok := values[slot.Name]
+		if slot.MemoryKey == "" || !ok {
+			continue
+		}
+
+		memory := &domain.Memory{
+			UserID:     session.UserID,
+			BotID:      session.BotID,
+			Key:        slot.MemoryKey,
+			Type:       domain.MemoryTypePersonal,
+			Content:    map[string]interface{}{"value": value},
+			Importance: 5,
+		}
+		if err := s.memorySvc.StoreMemory(ctx, memory); err != nil {
+			s.logger.WithContext(ctx).Warn("Failed to remember slot", "slot", slot.Name, "error", err)
+		}
+	}
+}
+
+func (s *botService) publishFormCompleted(ctx context.Context, step *domain.BotStep, variable string, values map[string]interface{}, session *domain.ConversationSession) {
+	if s.eventBus == nil {
+		return
+	}
+
+	event := s.events.CreateUserEvent(events.EventTypeFormCompleted, session.UserID, map[string]interface{}{
+		"bot_id":     session.BotID,
+		"session_id": session.ID,
+		"flow_id":    session.CurrentFlowID,
+		"step_id":    step.ID,
+		"variable":   variable,
+		"form":       values,
+	})
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish form completed event", "error", err)
+	}
+}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormStep_FillsSlotsInTurns(t *testing.T) {
+	bus := events.NewInMemoryEventBus(logger.NewLogger("error"))
+	completed := make(chan events.Event, 1)
+	require.NoError(t, bus.Subscribe(events.EventTypeFormCompleted, func(ctx context.Context, event events.Event) error {
+		completed <- event
+		return nil
+	}))
+
+	f := newJumpFixture(t, bus)
+	f.step(t, &domain.BotStep{ID: "start", FlowID: "main", Type: domain.StepTypeForm, Content: json.RawMessage(`{
+		"variable": "signup",
+		"completion_message": "Thanks {{signup.name}}, you are registered.",
+		"slots": [
+			{"name": "name", "prompt": "What is your name?"},
+			{"name": "age", "prompt": "How old are you?", "validation": "gte({{value}}, 18)", "error_message": "You must be an adult."},
+			{"name": "favorite_color", "validation": "{{value}} != 'none'"},
+			{"name": "nickname", "prompt": "Any nickname?", "optional": true}
+		]}`)})
+
+	send := func(text string) *domain.BotResponse {
+		response, err := f.send(text)
+		require.NoError(t, err)
+		return response
+	}
+	session := func() *domain.ConversationSession {
+		session, err := f.conversations.GetSession(context.Background(), "user-1", "bot-1")
+		require.NoError(t, err)
+		return session
+	}
+
+	// Cada slot obligatorio se pide con su pregunta, en orden
+	response := send("hi")
+	assert.Equal(t, "What is your name?", response.Content)
+	assert.Equal(t, "name", response.Metadata["slot"])
+
+	response = send("Ana")
+	assert.Equal(t, "How old are you?", response.Content)
+	assert.Equal(t, "age", response.Metadata["slot"])
+	assert.Equal(t, map[string]interface{}{"name": "Ana"}, session().Context[formStateKey("start")])
+
+	// Un valor que no pasa la validación repite la pregunta con el error
+	response = send("15")
+	assert.Equal(t, "You must be an adult. How old are you?", response.Content)
+	assert.Equal(t, "age", response.Metadata["slot"])
+	assert.NotContains(t, session().Context[formStateKey("start")], "age")
+
+	// Sin pregunta ni mensaje de error se usan los textos por defecto
+	response = send("30")
+	assert.Equal(t, "Please provide your favorite color.", response.Content)
+	response = send("none")
+	assert.Equal(t, "That doesn't look like a valid favorite color. Please provide your favorite color.", response.Content)
+	response = send("   ")
+	assert.Equal(t, "favorite_color", response.Metadata["slot"])
+
+	// Con el último slot obligatorio el formulario se completa sin pedir el opcional
+	response = send("blue")
+	assert.Equal(t, "Thanks Ana, you are registered.", response.Content)
+	form := map[string]interface{}{"name": "Ana", "age": "30", "favorite_color": "blue"}
+	assert.Equal(t, form, response.Metadata["form"])
+
+	current := session()
+	assert.Equal(t, form, current.Context["signup"])
+	assert.NotContains(t, current.Context, formStateKey("start"))
+	assert.NotContains(t, current.Context, formPendingKey("start"))
+
+	select {
+	case event := <-completed:
+		assert.Equal(t, "user-1", event.UserID)
+		assert.Equal(t, "start", event.Data["step_id"])
+		assert.Equal(t, "signup", event.Data["variable"])
+		assert.Equal(t, form, event.Data["form"])
+	case <-time.After(time.Second):
+		t.Fatal("form_completed event was not published")
+	}
+}
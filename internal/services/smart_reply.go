@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,21 +10,31 @@ import (
 	"github.com/company/bot-service/internal/ai"
 	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/configschema"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
+// ErrIntentExampleNotFound indica que el ejemplo no existe o es de otro bot
+var ErrIntentExampleNotFound = errors.New("intent example not found")
+
 type smartReplyService struct {
 	smartReplyRepo  domain.SmartReplyRepository
+	exampleRepo     domain.IntentExampleRepository
 	aiClient        ai.AIClient
+	classifier      IntentClassifier
 	mcpOrchestrator interface {
 		mcp.MCPOrchestrator
 		mcp.MCPDomainOrchestrator
 	}
+	usage           UsageService
 	logger          logger.Logger
 }
 
 func NewSmartReplyService(
 	smartReplyRepo domain.SmartReplyRepository,
+	exampleRepo domain.IntentExampleRepository,
 	aiClient ai.AIClient,
 	mcpOrchestrator interface {
 		mcp.MCPOrchestrator
@@ -33,12 +44,54 @@ func NewSmartReplyService(
 ) SmartReplyService {
 	return &smartReplyService{
 		smartReplyRepo:  smartReplyRepo,
+		exampleRepo:     exampleRepo,
 		aiClient:        aiClient,
+		classifier:      NewIntentClassifier(smartReplyRepo, exampleRepo, nil),
 		mcpOrchestrator: mcpOrchestrator,
 		logger:          logger,
 	}
 }
 
+// UseClock cambia el reloj con el que caducan los modelos de intents
+func (s *smartReplyService) UseClock(clk clock.Clock) {
+	s.classifier = NewIntentClassifier(s.smartReplyRepo, s.exampleRepo, clk)
+}
+
+// UseUsage cuenta los tokens de las respuestas generadas y aplica los
+// presupuestos de IA de los bots
+func (s *smartReplyService) UseUsage(usage UsageService) {
+	s.usage = usage
+}
+
+// budgetReply devuelve la respuesta fija del bot si agotó su presupuesto de IA
+func (s *smartReplyService) budgetReply(ctx context.Context, botID string) *domain.SmartReply {
+	if s.usage == nil {
+		return nil
+	}
+	message, exceeded := s.usage.BudgetFallback(ctx, botID)
+	if !exceeded {
+		return nil
+	}
+	return &domain.SmartReply{
+		BotID:      botID,
+		Intent:     BudgetExceededIntent,
+		Response:   message,
+		Confidence: 1,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// recordUsage suma una respuesta generada al consumo del bot
+func (s *smartReplyService) recordUsage(ctx context.Context, botID string, usage TokenUsage) {
+	if s.usage == nil {
+		return
+	}
+	if err := s.usage.RecordUsage(ctx, botID, usage); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to record AI usage", "bot_id", botID, "error", err)
+	}
+}
+
 func (s *smartReplyService) GetSmartReply(ctx context.Context, id string) (*domain.SmartReply, error) {
 	return s.smartReplyRepo.GetByID(ctx, id)
 }
@@ -50,36 +103,69 @@ func (s *smartReplyService) GetSmartRepliesByBot(ctx context.Context, botID stri
 func (s *smartReplyService) CreateSmartReply(ctx context.Context, reply *domain.SmartReply) error {
 	reply.CreatedAt = time.Now()
 	reply.UpdatedAt = time.Now()
+	defer s.classifier.Invalidate(reply.BotID)
 	return s.smartReplyRepo.Create(ctx, reply)
 }
 
 func (s *smartReplyService) UpdateSmartReply(ctx context.Context, reply *domain.SmartReply) error {
 	reply.UpdatedAt = time.Now()
+	defer s.classifier.Invalidate(reply.BotID)
 	return s.smartReplyRepo.Update(ctx, reply)
 }
 
 func (s *smartReplyService) DeleteSmartReply(ctx context.Context, id string) error {
-	return s.smartReplyRepo.Delete(ctx, id)
+	reply, err := s.smartReplyRepo.GetByID(ctx, id)
+	if err != nil {
+		return s.smartReplyRepo.Delete(ctx, id)
+	}
+	defer s.classifier.Invalidate(reply.BotID)
+	if err := s.smartReplyRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	// Los ejemplos se borran con su intent para que no reaparezcan si se vuelve a crear
+	return s.exampleRepo.DeleteByIntent(ctx, reply.BotID, reply.Intent)
+}
+
+func (s *smartReplyService) ClassifyIntent(ctx context.Context, botID, text string) ([]IntentScore, error) {
+	return s.classifier.Classify(ctx, botID, text)
+}
+
+// detectIntent devuelve el intent más probable del mensaje o "general" si
+// ninguno se parece
+func (s *smartReplyService) detectIntent(ctx context.Context, botID, message string) string {
+	intents, err := s.classifier.Classify(ctx, botID, message)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to classify intent", "bot_id", botID, "error", err)
+	}
+	if len(intents) == 0 {
+		return "general"
+	}
+	return intents[0].Intent
 }
 
 func (s *smartReplyService) GenerateAIResponse(ctx context.Context, botID, prompt string, context map[string]interface{}) (*domain.SmartReply, error) {
+	if reply := s.budgetReply(ctx, botID); reply != nil {
+		return reply, nil
+	}
+
 	// Construir prompt con contexto
 	fullPrompt := s.buildPromptWithContext(prompt, context)
+	intent := s.detectIntent(ctx, botID, prompt)
 
 	// Intentar usar MCP primero, fallback a AI client si falla
-	smartReply, err := s.generateWithMCP(ctx, botID, fullPrompt, context)
+	smartReply, err := s.generateWithMCP(ctx, botID, fullPrompt, intent, context)
 	if err != nil {
-		s.logger.Warn("MCP generation failed, falling back to AI client", "error", err)
-		return s.generateWithAIClient(ctx, botID, fullPrompt, context)
+		s.logger.WithContext(ctx).Warn("MCP generation failed, falling back to AI client", "error", err)
+		return s.generateWithAIClient(ctx, botID, fullPrompt, intent)
 	}
 
 	return smartReply, nil
 }
 
-func (s *smartReplyService) generateWithMCP(ctx context.Context, botID, prompt string, context map[string]interface{}) (*domain.SmartReply, error) {
+func (s *smartReplyService) generateWithMCP(ctx context.Context, botID, prompt, intent string, context map[string]interface{}) (*domain.SmartReply, error) {
 	// Crear tarea MCP para generación de texto
 	task := &domain.MCPTask{
-		ID:          fmt.Sprintf("smart-reply-%s-%d", botID, time.Now().UnixNano()),
+		ID:          fmt.Sprintf("smart-reply-%s-%s", botID, id.New()),
 		Type:        "text_generation",
 		Description: "Generate smart reply for bot conversation",
 		Input: map[string]interface{}{
@@ -98,6 +184,7 @@ func (s *smartReplyService) generateWithMCP(ctx context.Context, botID, prompt s
 		},
 		CreatedAt: time.Now(),
 	}
+	applyAIStep(ctx, task)
 
 	// Ejecutar tarea usando MCP
 	result, err := s.mcpOrchestrator.ExecuteTaskDomain(ctx, task)
@@ -136,12 +223,21 @@ func (s *smartReplyService) generateWithMCP(ctx context.Context, botID, prompt s
 		return nil, fmt.Errorf("no text response from MCP agent")
 	}
 
-	// Determinar intent basado en el prompt original
-	intent := s.extractIntent(prompt)
-
 	// Calcular confianza basada en el resultado MCP
 	confidence := s.calculateMCPConfidence(result, finishReason, responseText)
 
+	// Los agentes informan prompt_tokens y completion_tokens; en modo mock solo el total
+	output, metadata := configschema.Values(result.Output), configschema.Values(result.Metadata)
+	usage := TokenUsage{
+		Model:            output.String("model", ""),
+		PromptTokens:     metadata.Int("prompt_tokens", 0),
+		CompletionTokens: metadata.Int("completion_tokens", 0),
+	}
+	if usage.PromptTokens+usage.CompletionTokens == 0 {
+		usage.CompletionTokens = output.Int("tokens_used", 0)
+	}
+	s.recordUsage(ctx, botID, usage)
+
 	smartReply := &domain.SmartReply{
 		BotID:      botID,
 		Intent:     intent,
@@ -151,7 +247,7 @@ func (s *smartReplyService) generateWithMCP(ctx context.Context, botID, prompt s
 		UpdatedAt:  time.Now(),
 	}
 
-	s.logger.Info("AI response generated via MCP", 
+	s.logger.WithContext(ctx).Info("AI response generated via MCP", 
 		"bot_id", botID,
 		"intent", intent,
 		"confidence", smartReply.Confidence,
@@ -162,7 +258,7 @@ func (s *smartReplyService) generateWithMCP(ctx context.Context, botID, prompt s
 	return smartReply, nil
 }
 
-func (s *smartReplyService) generateWithAIClient(ctx context.Context, botID, prompt string, context map[string]interface{}) (*domain.SmartReply, error) {
+func (s *smartReplyService) generateWithAIClient(ctx context.Context, botID, prompt, intent string) (*domain.SmartReply, error) {
 	// Generar respuesta usando el cliente AI original como fallback
 	response, err := s.aiClient.GenerateResponse(ctx, prompt, 
 		ai.WithMaxTokens(500),
@@ -172,8 +268,13 @@ func (s *smartReplyService) generateWithAIClient(ctx context.Context, botID, pro
 		return nil, fmt.Errorf("failed to generate AI response: %w", err)
 	}
 
-	// Determinar intent basado en el prompt
-	intent := s.extractIntent(prompt)
+	usage := TokenUsage{Model: response.Model}
+	metadata := configschema.Values(response.Metadata)
+	usage.PromptTokens, usage.CompletionTokens = metadata.Int("prompt_tokens", 0), metadata.Int("completion_tokens", 0)
+	if usage.PromptTokens+usage.CompletionTokens == 0 {
+		usage.CompletionTokens = response.TokensUsed
+	}
+	s.recordUsage(ctx, botID, usage)
 
 	smartReply := &domain.SmartReply{
 		BotID:      botID,
@@ -184,7 +285,7 @@ func (s *smartReplyService) generateWithAIClient(ctx context.Context, botID, pro
 		UpdatedAt:  time.Now(),
 	}
 
-	s.logger.Info("AI response generated via fallback client", 
+	s.logger.WithContext(ctx).Info("AI response generated via fallback client", 
 		"bot_id", botID,
 		"intent", intent,
 		"confidence", smartReply.Confidence,
@@ -193,26 +294,242 @@ func (s *smartReplyService) generateWithAIClient(ctx context.Context, botID, pro
 	return smartReply, nil
 }
 
-func (s *smartReplyService) TrainIntents(ctx context.Context, botID string, intents []domain.SmartReply) error {
-	// Guardar intents entrenados
-	for _, intent := range intents {
-		intent.BotID = botID
-		intent.CreatedAt = time.Now()
-		intent.UpdatedAt = time.Now()
-		
-		if err := s.smartReplyRepo.Create(ctx, &intent); err != nil {
-			s.logger.Error("Failed to save trained intent", 
+// StreamAIResponse genera una respuesta inteligente emitiendo los fragmentos a medida que llegan.
+// El último fragmento tiene Done en true e incluye la SmartReply completa.
+func (s *smartReplyService) StreamAIResponse(ctx context.Context, botID, prompt string, context map[string]interface{}) (<-chan *domain.SmartReplyChunk, error) {
+	if reply := s.budgetReply(ctx, botID); reply != nil {
+		chunks := make(chan *domain.SmartReplyChunk, 2)
+		chunks <- &domain.SmartReplyChunk{Content: reply.Response}
+		chunks <- &domain.SmartReplyChunk{Done: true, Reply: reply}
+		close(chunks)
+		return chunks, nil
+	}
+
+	fullPrompt := s.buildPromptWithContext(prompt, context)
+	intent := s.detectIntent(ctx, botID, prompt)
+
+	source, err := s.streamWithMCP(ctx, botID, fullPrompt, context)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("MCP streaming failed, falling back to AI client", "error", err)
+		source, err = s.streamWithAIClient(ctx, fullPrompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to stream AI response: %w", err)
+		}
+	}
+
+	chunks := make(chan *domain.SmartReplyChunk)
+	go func() {
+		defer close(chunks)
+
+		var content strings.Builder
+		for chunk := range source {
+			if !chunk.Done {
+				content.WriteString(chunk.Content)
+				select {
+				case chunks <- &domain.SmartReplyChunk{Content: chunk.Content}:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			final := &domain.SmartReplyChunk{Done: true, Error: chunk.Error}
+			if chunk.Error == "" {
+				// Los streams no informan tokens: cuentan como petición
+				s.recordUsage(ctx, botID, TokenUsage{})
+				final.Reply = &domain.SmartReply{
+					BotID:    botID,
+					Intent:   intent,
+					Response: content.String(),
+					Confidence: s.calculateConfidence(&ai.Response{
+						Content:      content.String(),
+						FinishReason: chunk.FinishReason,
+					}),
+					CreatedAt: time.Now(),
+					UpdatedAt: time.Now(),
+				}
+
+				s.logger.WithContext(ctx).Info("AI response streamed",
+					"bot_id", botID,
+					"intent", intent,
+					"confidence", final.Reply.Confidence)
+			}
+
+			select {
+			case chunks <- final:
+			case <-ctx.Done():
+			}
+			return
+		}
+	}()
+
+	return chunks, nil
+}
+
+func (s *smartReplyService) streamWithMCP(ctx context.Context, botID, prompt string, context map[string]interface{}) (<-chan ai.StreamChunk, error) {
+	task := mcp.Task{
+		ID:          fmt.Sprintf("smart-reply-stream-%s-%s", botID, id.New()),
+		Type:        "text_generation",
+		Description: "Stream smart reply for bot conversation",
+		Input: map[string]interface{}{
+			"prompt":      prompt,
+			"temperature": 0.7,
+			"max_tokens":  500,
+			"system":      "You are a helpful customer service assistant. Provide clear, concise, and helpful responses.",
+		},
+		Priority: 5,
+		Metadata: map[string]interface{}{
+			"bot_id":  botID,
+			"source":  "smart_reply_service",
+			"context": context,
+		},
+	}
+
+	source, err := s.mcpOrchestrator.ExecuteTaskStream(ctx, task)
+	if err != nil {
+		return nil, fmt.Errorf("MCP stream execution failed: %w", err)
+	}
+
+	// Adaptar los fragmentos MCP al formato del cliente AI
+	chunks := make(chan ai.StreamChunk)
+	go func() {
+		defer close(chunks)
+		for chunk := range source {
+			select {
+			case chunks <- ai.StreamChunk{
+				Content:      chunk.Content,
+				Done:         chunk.Done,
+				FinishReason: chunk.FinishReason,
+				Error:        chunk.Error,
+			}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return chunks, nil
+}
+
+func (s *smartReplyService) streamWithAIClient(ctx context.Context, prompt string) (<-chan ai.StreamChunk, error) {
+	return s.aiClient.StreamChatResponse(ctx, []ai.Message{
+		{Role: "user", Content: prompt},
+	},
+		ai.WithMaxTokens(500),
+		ai.WithTemperature(0.7),
+	)
+}
+
+// TrainIntents crea los intents nuevos, actualiza la respuesta de los que ya
+// existen y les añade las frases de ejemplo que aún no tenían. Se puede entrenar
+// por partes: las frases repetidas (sin contar mayúsculas, tildes ni signos) se
+// ignoran.
+func (s *smartReplyService) TrainIntents(ctx context.Context, botID string, intents []domain.IntentTraining) (*domain.IntentTrainingResult, error) {
+	result := &domain.IntentTrainingResult{}
+	defer s.classifier.Invalidate(botID)
+
+	for _, training := range intents {
+		name := strings.TrimSpace(training.Intent)
+		if name == "" {
+			return result, fmt.Errorf("intent name is required")
+		}
+		if err := s.trainIntent(ctx, botID, name, training, result); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to save trained intent", 
 				"bot_id", botID,
-				"intent", intent.Intent,
+				"intent", name,
 				"error", err)
-			return fmt.Errorf("failed to save intent %s: %w", intent.Intent, err)
+			return result, fmt.Errorf("failed to save intent %s: %w", name, err)
 		}
+		result.Trained++
 	}
 
-	s.logger.Info("Intents trained successfully", 
+	s.logger.WithContext(ctx).Info("Intents trained successfully", 
 		"bot_id", botID,
-		"count", len(intents))
+		"count", result.Trained,
+		"examples_added", result.ExamplesAdded)
+
+	return result, nil
+}
+
+func (s *smartReplyService) trainIntent(ctx context.Context, botID, name string, training domain.IntentTraining, result *domain.IntentTrainingResult) error {
+	now := time.Now()
+	reply, err := s.smartReplyRepo.GetByIntent(ctx, botID, name)
+	if err != nil {
+		reply = &domain.SmartReply{
+			BotID:     botID,
+			Intent:    name,
+			Response:  training.Response,
+			CreatedAt: now,
+			UpdatedAt: now,
+		}
+		if err := s.smartReplyRepo.Create(ctx, reply); err != nil {
+			return err
+		}
+		result.IntentsCreated++
+	} else if training.Response != "" && training.Response != reply.Response {
+		updated := *reply
+		updated.Response = training.Response
+		updated.UpdatedAt = now
+		if err := s.smartReplyRepo.Update(ctx, &updated); err != nil {
+			return err
+		}
+		result.IntentsUpdated++
+	}
+
+	stored, err := s.exampleRepo.GetByIntent(ctx, botID, name)
+	if err != nil {
+		return err
+	}
+	seen := make(map[string]bool, len(stored)+len(reply.Examples))
+	for _, example := range stored {
+		seen[normalizeText(example.Text)] = true
+	}
+	for _, text := range reply.Examples {
+		seen[normalizeText(text)] = true
+	}
 
+	for _, text := range append(append([]string(nil), training.Utterances...), training.Examples...) {
+		text = strings.TrimSpace(text)
+		key := normalizeText(text)
+		if key == "" {
+			continue
+		}
+		if seen[key] {
+			result.DuplicatesSkipped++
+			continue
+		}
+		seen[key] = true
+		if err := s.exampleRepo.Create(ctx, &domain.IntentExample{
+			BotID:     botID,
+			Intent:    name,
+			Text:      text,
+			CreatedAt: now,
+		}); err != nil {
+			return err
+		}
+		result.ExamplesAdded++
+	}
+	return nil
+}
+
+// GetIntentExamples devuelve los ejemplos guardados del bot, o solo los de
+// intent si no está vacío
+func (s *smartReplyService) GetIntentExamples(ctx context.Context, botID, intent string) ([]*domain.IntentExample, error) {
+	if intent != "" {
+		return s.exampleRepo.GetByIntent(ctx, botID, intent)
+	}
+	return s.exampleRepo.GetByBotID(ctx, botID)
+}
+
+func (s *smartReplyService) DeleteIntentExample(ctx context.Context, botID, id string) error {
+	example, err := s.exampleRepo.GetByID(ctx, id)
+	if err != nil || example.BotID != botID {
+		return ErrIntentExampleNotFound
+	}
+	if err := s.exampleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.classifier.Invalidate(botID)
 	return nil
 }
 
@@ -231,31 +548,6 @@ func (s *smartReplyService) buildPromptWithContext(prompt string, context map[st
 	return contextStr.String()
 }
 
-func (s *smartReplyService) extractIntent(prompt string) string {
-	prompt = strings.ToLower(prompt)
-	
-	// Mapeo simple de palabras clave a intents
-	intentKeywords := map[string][]string{
-		"greeting":     {"hello", "hi", "hey", "good morning", "good afternoon"},
-		"goodbye":      {"bye", "goodbye", "see you", "farewell"},
-		"help":         {"help", "assist", "support", "how to"},
-		"information":  {"what", "how", "when", "where", "why", "info"},
-		"complaint":    {"problem", "issue", "wrong", "error", "complaint"},
-		"compliment":   {"good", "great", "excellent", "amazing", "wonderful"},
-		"question":     {"?", "question", "ask"},
-	}
-
-	for intent, keywords := range intentKeywords {
-		for _, keyword := range keywords {
-			if strings.Contains(prompt, keyword) {
-				return intent
-			}
-		}
-	}
-
-	return "general"
-}
-
 func (s *smartReplyService) calculateConfidence(response *ai.Response) float64 {
 	// Cálculo simple de confianza basado en la respuesta
 	confidence := 0.7 // Base confidence
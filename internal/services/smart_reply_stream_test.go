@@ -0,0 +1,92 @@
+package services_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/testkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStreamingAIServer simula la API de chat de OpenAI respondiendo cada
+// petición con los deltas dados por SSE
+func newStreamingAIServer(t *testing.T, deltas ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range deltas {
+			fmt.Fprintf(w, "data: {\"choices\":[{\"delta\":{\"content\":%q}}]}\n\n", delta)
+			w.(http.Flusher).Flush()
+		}
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestSmartReplyService_StreamAIResponse(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	server := newStreamingAIServer(t, "Your order ", "ships ", "tomorrow.")
+
+	orchestrator := mcp.NewOrchestrator(mcp.NewAgentFactory(log), log)
+	_, err := orchestrator.InstantiateMCP(ctx, mcp.MCPConfig{Type: "ai", Name: "stream", Config: map[string]interface{}{
+		"openai_api_key": "sk-live", "base_url": server.URL,
+	}})
+	require.NoError(t, err)
+	scripted := testkit.NewScriptedAI()
+	smartReplies := services.NewSmartReplyService(repositories.NewMockSmartReplyRepository(), repositories.NewMockIntentExampleRepository(),
+		scripted, orchestrator, log)
+
+	chunks, err := smartReplies.StreamAIResponse(ctx, "bot-1", "where is my order?", nil)
+	require.NoError(t, err)
+	var parts []string
+	var final *domain.SmartReplyChunk
+	for chunk := range chunks {
+		if chunk.Done {
+			final = chunk
+			continue
+		}
+		parts = append(parts, chunk.Content)
+	}
+
+	assert.Equal(t, []string{"Your order ", "ships ", "tomorrow."}, parts)
+	require.NotNil(t, final)
+	assert.Empty(t, final.Error)
+	require.NotNil(t, final.Reply)
+	assert.Equal(t, "bot-1", final.Reply.BotID)
+	assert.Equal(t, "Your order ships tomorrow.", final.Reply.Response)
+	assert.Empty(t, scripted.Prompts(), "the AI client is only a fallback")
+}
+
+func TestSmartReplyService_StreamAIResponseFallsBackToAIClient(t *testing.T) {
+	log := logger.NewLogger("error")
+	scripted := testkit.NewScriptedAI()
+	scripted.Reply("from the client")
+	// Sin agentes de streaming se usa el cliente de IA
+	smartReplies := services.NewSmartReplyService(repositories.NewMockSmartReplyRepository(), repositories.NewMockIntentExampleRepository(),
+		scripted, mcp.NewOrchestrator(mcp.NewAgentFactory(log), log), log)
+
+	chunks, err := smartReplies.StreamAIResponse(context.Background(), "bot-1", "hi", nil)
+	require.NoError(t, err)
+	var content strings.Builder
+	var final *domain.SmartReplyChunk
+	for chunk := range chunks {
+		content.WriteString(chunk.Content)
+		final = chunk
+	}
+	assert.Equal(t, "from the client", content.String())
+	require.NotNil(t, final.Reply)
+	assert.Equal(t, "from the client", final.Reply.Response)
+	assert.Len(t, scripted.Prompts(), 1)
+}
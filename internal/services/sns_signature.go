@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// snsCertHostPattern son los hosts desde los que SNS publica sus certificados
+var snsCertHostPattern = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// verifySNSSignature comprueba la firma del mensaje con el certificado de
+// SigningCertURL, según la versión 1 (SHA1) o 2 (SHA256) de SNS
+func (c *emailChannel) verifySNSSignature(ctx context.Context, message *snsMessage) error {
+	var hash crypto.Hash
+	switch message.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported SNS signature version %q", ErrInvalidEmail, message.SignatureVersion)
+	}
+	signature, err := base64.StdEncoding.DecodeString(message.Signature)
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("%w: invalid SNS signature", ErrInvalidEmail)
+	}
+
+	cert, err := c.snsCertificate(ctx, message.SigningCertURL)
+	if err != nil {
+		return err
+	}
+	publicKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: SNS signing certificate is not RSA", ErrInvalidEmail)
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(snsStringToSign(message)))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(snsStringToSign(message)))
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(publicKey, hash, digest, signature); err != nil {
+		return fmt.Errorf("%w: SNS signature does not match", ErrInvalidEmail)
+	}
+	return nil
+}
+
+// snsStringToSign arma el texto firmado: pares nombre/valor en orden
+// alfabético, con Subject sólo si viene y los campos de suscripción en las
+// confirmaciones
+func snsStringToSign(message *snsMessage) string {
+	fields := [][2]string{{"Message", message.Message}, {"MessageId", message.MessageID}}
+	if message.Type == "Notification" {
+		if message.Subject != "" {
+			fields = append(fields, [2]string{"Subject", message.Subject})
+		}
+		fields = append(fields, [2]string{"Timestamp", message.Timestamp})
+	} else {
+		fields = append(fields,
+			[2]string{"SubscribeURL", message.SubscribeURL},
+			[2]string{"Timestamp", message.Timestamp},
+			[2]string{"Token", message.Token})
+	}
+	fields = append(fields, [2]string{"TopicArn", message.TopicArn}, [2]string{"Type", message.Type})
+
+	var text strings.Builder
+	for _, field := range fields {
+		text.WriteString(field[0] + "\n" + field[1] + "\n")
+	}
+	return text.String()
+}
+
+// fetchSNSCertificate descarga el certificado de firma, sólo desde SNS, y lo
+// guarda para los mensajes siguientes
+func (c *emailChannel) fetchSNSCertificate(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	parsed, err := url.Parse(certURL)
+	if err != nil || parsed.Scheme != "https" || !snsCertHostPattern.MatchString(parsed.Hostname()) || !strings.HasSuffix(parsed.Path, ".pem") {
+		return nil, fmt.Errorf("%w: untrusted SNS SigningCertURL", ErrInvalidEmail)
+	}
+
+	c.mu.Lock()
+	cert, ok := c.snsCerts[certURL]
+	c.mu.Unlock()
+	if ok {
+		return cert, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, parsed.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SNS signing certificate: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch SNS signing certificate: status %d", resp.StatusCode)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch SNS signing certificate: %w", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%w: invalid SNS signing certificate", ErrInvalidEmail)
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid SNS signing certificate: %v", ErrInvalidEmail, err)
+	}
+
+	c.mu.Lock()
+	c.snsCerts[certURL] = cert
+	c.mu.Unlock()
+	return cert, nil
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testTopicARN = "arn:aws:sns:eu-west-1:123456789012:inbound-email"
+
+// snsSigner firma los mensajes como SNS con un certificado propio
+type snsSigner struct {
+	key  *rsa.PrivateKey
+	cert *x509.Certificate
+}
+
+func newSNSSigner(t *testing.T) *snsSigner {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), Subject: pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore: time.Now().Add(-time.Hour), NotAfter: time.Now().Add(time.Hour)}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return &snsSigner{key: key, cert: cert}
+}
+
+func (s *snsSigner) sign(t *testing.T, message *snsMessage) []byte {
+	t.Helper()
+	message.SignatureVersion = "2"
+	message.SigningCertURL = "https://sns.eu-west-1.amazonaws.com/SimpleNotificationService-test.pem"
+	sum := sha256.Sum256([]byte(snsStringToSign(message)))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, sum[:])
+	require.NoError(t, err)
+	message.Signature = base64.StdEncoding.EncodeToString(signature)
+	body, err := json.Marshal(message)
+	require.NoError(t, err)
+	return body
+}
+
+func newSESChannel(signer *snsSigner, topicARN string) *emailChannel {
+	channel := NewEmailChannel(nil, &recordingEmailSender{}, EmailChannelConfig{SNSTopicARN: topicARN}, logger.NewLogger("error")).(*emailChannel)
+	channel.snsCertificate = func(ctx context.Context, certURL string) (*x509.Certificate, error) {
+		return signer.cert, nil
+	}
+	return channel
+}
+
+func bounceNotification() *snsMessage {
+	return &snsMessage{Type: "Notification", MessageID: "msg-1", TopicArn: testTopicARN,
+		Message: `{"notificationType":"Bounce"}`, Timestamp: "2024-05-01T10:00:00.000Z"}
+}
+
+func TestHandleSES_VerifiesSignature(t *testing.T) {
+	ctx := context.Background()
+	signer := newSNSSigner(t)
+	channel := newSESChannel(signer, testTopicARN)
+
+	// Firmada: llega al tratamiento normal (un rebote se ignora)
+	_, err := channel.HandleSES(ctx, signer.sign(t, bounceNotification()), "")
+	assert.ErrorIs(t, err, ErrEmailIgnored)
+
+	// Sin firma
+	unsigned, err := json.Marshal(bounceNotification())
+	require.NoError(t, err)
+	_, err = channel.HandleSES(ctx, unsigned, "")
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+
+	// Mensaje cambiado después de firmar
+	var tampered snsMessage
+	require.NoError(t, json.Unmarshal(signer.sign(t, bounceNotification()), &tampered))
+	tampered.Message = `{"notificationType":"Received","content":"From: x@example.com\r\n\r\nhola"}`
+	body, err := json.Marshal(&tampered)
+	require.NoError(t, err)
+	_, err = channel.HandleSES(ctx, body, "")
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+
+	// Firmado por otro certificado
+	_, err = channel.HandleSES(ctx, newSNSSigner(t).sign(t, bounceNotification()), "")
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+
+	// Bien firmado pero de otro topic
+	other := bounceNotification()
+	other.TopicArn = "arn:aws:sns:eu-west-1:999999999999:attacker"
+	_, err = channel.HandleSES(ctx, signer.sign(t, other), "")
+	assert.ErrorIs(t, err, ErrInvalidEmail)
+}
+
+func TestFetchSNSCertificate_OnlyFromSNS(t *testing.T) {
+	channel := NewEmailChannel(nil, nil, EmailChannelConfig{}, logger.NewLogger("error")).(*emailChannel)
+	for _, certURL := range []string{
+		"http://sns.eu-west-1.amazonaws.com/cert.pem",
+		"https://sns.eu-west-1.amazonaws.com.attacker.com/cert.pem",
+		"https://attacker.s3.amazonaws.com/cert.pem",
+		"https://sns.eu-west-1.amazonaws.com/cert.txt",
+	} {
+		_, err := channel.fetchSNSCertificate(context.Background(), certURL)
+		assert.ErrorIs(t, err, ErrInvalidEmail, certURL)
+	}
+}
+
+func TestSNSStringToSign(t *testing.T) {
+	notification := &snsMessage{Type: "Notification", MessageID: "id", TopicArn: "arn", Subject: "s", Message: "m", Timestamp: "ts"}
+	assert.Equal(t, "Message\nm\nMessageId\nid\nSubject\ns\nTimestamp\nts\nTopicArn\narn\nType\nNotification\n", snsStringToSign(notification))
+
+	confirmation := &snsMessage{Type: "SubscriptionConfirmation", MessageID: "id", TopicArn: "arn", Message: "m",
+		Timestamp: "ts", Token: "tok", SubscribeURL: "https://sns"}
+	assert.Equal(t, "Message\nm\nMessageId\nid\nSubscribeURL\nhttps://sns\nTimestamp\nts\nToken\ntok\nTopicArn\narn\nType\nSubscriptionConfirmation\n",
+		snsStringToSign(confirmation))
+}
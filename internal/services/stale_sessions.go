@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// migrationPolicy devuelve la política del flujo o, si no tiene, la del bot
+func migrationPolicy(flow *domain.BotFlow, config domain.BotConfig) domain.FlowMigrationPolicy {
+	if flow != nil && flow.MigrationPolicy != nil {
+		return *flow.MigrationPolicy
+	}
+	if config.FlowMigration != nil {
+		return *config.FlowMigration
+	}
+	return domain.FlowMigrationPolicy{Strategy: domain.MigrationRestart}
+}
+
+// migrateStaleSession decide dónde continúa una sesión cuyo paso actual ya no
+// existe; flow es nil si lo que desapareció es el flujo. Deja la sesión en el
+// flujo y paso devueltos: sin paso se empieza por el entry point, y sin flujo
+// se elige como en una conversación nueva.
+func (s *botService) migrateStaleSession(ctx context.Context, flow *domain.BotFlow, config domain.BotConfig, session *domain.ConversationSession) (*domain.BotFlow, *domain.BotStep) {
+	policy := migrationPolicy(flow, config)
+	staleFlowID, staleStepID := session.CurrentFlowID, session.CurrentStepID
+	session.CurrentStepID = ""
+
+	migrated := func(target *domain.BotFlow, step *domain.BotStep, strategy domain.MigrationStrategy) (*domain.BotFlow, *domain.BotStep) {
+		fields := []interface{}{"session_id", session.ID, "strategy", strategy, "stale_flow_id", staleFlowID, "stale_step_id", staleStepID}
+		if target != nil {
+			fields = append(fields, "flow_id", target.ID)
+		}
+		if step != nil {
+			session.CurrentStepID = step.ID
+			fields = append(fields, "step_id", step.ID)
+		}
+		s.logger.WithContext(ctx).Info("Migrated session with stale flow reference", fields...)
+		return target, step
+	}
+
+	if policy.Strategy == domain.MigrationMap && flow != nil {
+		if ref, ok := policy.StepMap[staleStepID]; ok {
+			step, err := s.findStepInFlow(ctx, flow.ID, session.FlowVersion, ref)
+			if err == nil {
+				return migrated(flow, step, domain.MigrationMap)
+			}
+			s.logger.WithContext(ctx).Warn("Mapped step not found", "flow_id", flow.ID, "stale_step_id", staleStepID, "step", ref, "error", err)
+		}
+	}
+
+	if policy.Strategy != domain.MigrationRestart && (policy.FallbackFlowID != "" || policy.FallbackStep != "") {
+		if target := s.fallbackFlow(ctx, flow, policy, session); target != nil {
+			if policy.FallbackStep == "" {
+				return migrated(target, nil, domain.MigrationFallback)
+			}
+			step, err := s.findStepInFlow(ctx, target.ID, session.FlowVersion, policy.FallbackStep)
+			if err == nil {
+				return migrated(target, step, domain.MigrationFallback)
+			}
+			s.logger.WithContext(ctx).Warn("Fallback step not found", "flow_id", target.ID, "step", policy.FallbackStep, "error", err)
+			return migrated(target, nil, domain.MigrationRestart)
+		}
+	}
+
+	return migrated(flow, nil, domain.MigrationRestart)
+}
+
+// fallbackFlow devuelve el flujo de fallback de la política, entrando en él si
+// no es el flujo actual
+func (s *botService) fallbackFlow(ctx context.Context, flow *domain.BotFlow, policy domain.FlowMigrationPolicy, session *domain.ConversationSession) *domain.BotFlow {
+	if policy.FallbackFlowID == "" || (flow != nil && policy.FallbackFlowID == flow.ID) {
+		return flow
+	}
+
+	target, err := s.flowRepo.GetByID(ctx, policy.FallbackFlowID)
+	if err != nil || target.BotID != session.BotID {
+		s.logger.WithContext(ctx).Warn("Fallback flow not available", "flow_id", policy.FallbackFlowID, "bot_id", session.BotID, "error", err)
+		return flow
+	}
+	enterFlow(session, target)
+	return target
+}
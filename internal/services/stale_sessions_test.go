@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStaleSessions_MigrationPolicies(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive,
+		Config: json.RawMessage(`{"flow_migration":{"strategy":"fallback","fallback_flow_id":"help","fallback_step":"sorry"}}`)}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "welcome", IsDefault: true,
+		MigrationPolicy: &domain.FlowMigrationPolicy{Strategy: domain.MigrationMap, StepMap: map[string]string{"old-ask": "Ask name"}}}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "help", BotID: "bot-1", EntryPoint: "sorry"}))
+	for _, step := range []*domain.BotStep{
+		{ID: "welcome", FlowID: "main", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"Welcome"}`)},
+		{ID: "ask", Name: "Ask name", FlowID: "main", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"What's your name?"}`)},
+		{ID: "sorry", FlowID: "help", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"Let's start over"}`)},
+	} {
+		require.NoError(t, stepRepo.Create(ctx, step))
+	}
+
+	send := func(userID string) string {
+		response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: userID, Content: "hello"})
+		require.NoError(t, err)
+		return response.Content
+	}
+	staleSession := func(id, userID, flowID, stepID string) {
+		require.NoError(t, sessionRepo.Create(ctx, &domain.ConversationSession{ID: id, BotID: "bot-1", UserID: userID,
+			CurrentFlowID: flowID, CurrentStepID: stepID, Context: map[string]interface{}{}, ExpiresAt: time.Now().Add(time.Hour)}))
+	}
+
+	// Paso renombrado: el step_map del flujo lo traduce al paso nuevo
+	staleSession("s-1", "user-1", "main", "old-ask")
+	assert.Equal(t, "What's your name?", send("user-1"))
+
+	// Paso borrado sin entrada en step_map y sin fallback: vuelve al entry point
+	staleSession("s-2", "user-2", "main", "deleted")
+	assert.Equal(t, "Welcome", send("user-2"))
+
+	// Flujo eliminado: se aplica la política del bot
+	staleSession("s-3", "user-3", "removed", "anything")
+	assert.Equal(t, "Let's start over", send("user-3"))
+	session, err := sessionRepo.GetByID(ctx, "s-3")
+	require.NoError(t, err)
+	assert.Equal(t, "help", session.CurrentFlowID)
+}
@@ -0,0 +1,361 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/i18n"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+var ErrInvalidStarterKit = errors.New("invalid starter kit request")
+
+// StarterKitRequest describe el negocio para el que se genera el bot inicial.
+// PrimaryLanguage (es o en) decide qué idioma va primero en los mensajes
+// bilingües, como el de fallback.
+type StarterKitRequest struct {
+	BusinessName    string             `json:"business_name" binding:"required"`
+	Vertical        string             `json:"vertical" binding:"required"`
+	Channel         domain.ChannelType `json:"channel"`
+	PrimaryLanguage string             `json:"primary_language"`
+}
+
+// StarterKitResult resume el bot generado y sus pruebas de conversación
+type StarterKitResult struct {
+	Bot               *domain.Bot `json:"bot"`
+	Languages         []string    `json:"languages"`
+	Flows             int         `json:"flows"`
+	Steps             int         `json:"steps"`
+	ConversationTests []string    `json:"conversation_test_ids"`
+}
+
+// StarterKitService genera en una llamada un bot bilingüe listo para probar:
+// saludo y despedida como intents globales, un flujo propio del sector,
+// fallback en ambos idiomas y pruebas de conversación de ejemplo
+type StarterKitService interface {
+	Verticals() []string
+	Generate(ctx context.Context, request StarterKitRequest, ownerID string) (*StarterKitResult, error)
+}
+
+type starterKitService struct {
+	bundles BotBundleService
+	tests   TestService
+	logger  logger.Logger
+}
+
+// NewStarterKitService crea el generador de bots iniciales. El bot se crea
+// importando un bundle, así que hereda sus validaciones y su rollback.
+func NewStarterKitService(bundles BotBundleService, tests TestService, logger logger.Logger) StarterKitService {
+	return &starterKitService{bundles: bundles, tests: tests, logger: logger}
+}
+
+// starterTopic es el flujo propio de un sector en un idioma
+type starterTopic struct {
+	Name     string
+	Keywords []string
+	Option   string
+	Text     string
+}
+
+// starterTexts son los textos del kit en un idioma; {{vars.business_name}}
+// se resuelve con las variables del bot
+type starterTexts struct {
+	GreetingName     string
+	GoodbyeName      string
+	GreetingKeywords []string
+	GoodbyeKeywords  []string
+	Greeting         string
+	GoodbyeOption    string
+	Goodbye          string
+	Fallback         string
+	VoiceNoteFailure string
+	// TestGreeting y TestGoodbye son los mensajes de las pruebas de ejemplo
+	TestGreeting string
+	TestGoodbye  string
+}
+
+var starterKitLanguages = []string{i18n.Spanish, i18n.English}
+
+var starterKitTexts = map[string]starterTexts{
+	i18n.Spanish: {
+		GreetingName:     "Saludo",
+		GoodbyeName:      "Despedida",
+		GreetingKeywords: []string{"hola", "buenas", "buenos dias", "buenas tardes", "buenas noches"},
+		GoodbyeKeywords:  []string{"adios", "chao", "hasta luego", "nos vemos"},
+		Greeting:         "¡Hola! Te damos la bienvenida a {{vars.business_name}}. ¿En qué podemos ayudarte?",
+		GoodbyeOption:    "Nada más, gracias",
+		Goodbye:          "Gracias por escribir a {{vars.business_name}}. ¡Hasta pronto!",
+		Fallback:         "Lo siento, no entendí tu mensaje. Escribe \"hola\" para ver las opciones.",
+		VoiceNoteFailure: "No pudimos escuchar tu nota de voz, ¿puedes escribir tu mensaje?",
+		TestGreeting:     "Hola",
+		TestGoodbye:      "Adiós",
+	},
+	i18n.English: {
+		GreetingName:     "Greeting",
+		GoodbyeName:      "Goodbye",
+		GreetingKeywords: []string{"hello", "hi", "hey", "good morning", "good afternoon", "good evening"},
+		GoodbyeKeywords:  []string{"bye", "goodbye", "see you"},
+		Greeting:         "Hi! Welcome to {{vars.business_name}}. How can we help you?",
+		GoodbyeOption:    "Nothing else, thanks",
+		Goodbye:          "Thanks for contacting {{vars.business_name}}. See you soon!",
+		Fallback:         "Sorry, I didn't understand your message. Type \"hello\" to see the options.",
+		VoiceNoteFailure: "We couldn't listen to your voice note, could you type your message?",
+		TestGreeting:     "Hello",
+		TestGoodbye:      "Bye",
+	},
+}
+
+// starterKitVerticals define el flujo de cada sector por idioma
+var starterKitVerticals = map[string]map[string]starterTopic{
+	"retail": {
+		i18n.Spanish: {"Pedidos", []string{"pedido", "envio", "compra"}, "Estado de mi pedido",
+			"Para consultar tu pedido en {{vars.business_name}}, envíanos el número de pedido y te diremos su estado."},
+		i18n.English: {"Orders", []string{"order", "shipping", "purchase"}, "My order status",
+			"To check your order with {{vars.business_name}}, send us your order number and we'll tell you its status."},
+	},
+	"restaurant": {
+		i18n.Spanish: {"Reservas", []string{"reserva", "reservar", "mesa"}, "Reservar una mesa",
+			"Para reservar en {{vars.business_name}}, dinos el día, la hora y cuántas personas serán."},
+		i18n.English: {"Reservations", []string{"reservation", "book", "table"}, "Book a table",
+			"To book at {{vars.business_name}}, tell us the day, time and number of guests."},
+	},
+	"healthcare": {
+		i18n.Spanish: {"Citas", []string{"cita", "turno", "consulta"}, "Pedir una cita",
+			"Para pedir una cita en {{vars.business_name}}, indícanos tu nombre y el día que prefieres."},
+		i18n.English: {"Appointments", []string{"appointment", "schedule", "doctor"}, "Book an appointment",
+			"To book an appointment at {{vars.business_name}}, tell us your name and preferred day."},
+	},
+	"services": {
+		i18n.Spanish: {"Presupuestos", []string{"presupuesto", "cotizacion", "precio"}, "Pedir un presupuesto",
+			"Para preparar tu presupuesto en {{vars.business_name}}, cuéntanos qué servicio necesitas."},
+		i18n.English: {"Quotes", []string{"quote", "pricing", "price"}, "Get a quote",
+			"To prepare your quote at {{vars.business_name}}, tell us which service you need."},
+	},
+}
+
+func (s *starterKitService) Verticals() []string {
+	verticals := make([]string, 0, len(starterKitVerticals))
+	for vertical := range starterKitVerticals {
+		verticals = append(verticals, vertical)
+	}
+	sort.Strings(verticals)
+	return verticals
+}
+
+func (s *starterKitService) Generate(ctx context.Context, request StarterKitRequest, ownerID string) (*StarterKitResult, error) {
+	request.BusinessName = strings.TrimSpace(request.BusinessName)
+	request.Vertical = strings.ToLower(strings.TrimSpace(request.Vertical))
+	if request.BusinessName == "" {
+		return nil, fmt.Errorf("%w: business_name is required", ErrInvalidStarterKit)
+	}
+	topics, ok := starterKitVerticals[request.Vertical]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown vertical %q (available: %s)", ErrInvalidStarterKit, request.Vertical, strings.Join(s.Verticals(), ", "))
+	}
+	if request.PrimaryLanguage == "" {
+		request.PrimaryLanguage = i18n.Spanish
+	}
+	if _, ok := starterKitTexts[request.PrimaryLanguage]; !ok {
+		return nil, fmt.Errorf("%w: primary_language must be es or en", ErrInvalidStarterKit)
+	}
+	if request.Channel == "" {
+		request.Channel = domain.ChannelWeb
+	}
+
+	bundle, err := starterKitBundle(request, topics)
+	if err != nil {
+		return nil, err
+	}
+	imported, err := s.bundles.ImportBot(ctx, bundle, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create starter bot: %w", err)
+	}
+
+	result := &StarterKitResult{
+		Bot:               imported.Bot,
+		Languages:         starterKitLanguages,
+		Flows:             imported.Flows,
+		Steps:             imported.Steps,
+		ConversationTests: []string{},
+	}
+	// Las pruebas son ejemplos: si no se pueden guardar el bot sigue siendo válido
+	for _, test := range starterKitTests(imported.Bot.ID, request, topics) {
+		if err := s.tests.CreateMultiTurnTestCase(ctx, test); err != nil {
+			s.logger.WithContext(ctx).Warn("Failed to create starter kit test", "bot_id", imported.Bot.ID, "test", test.Name, "error", err)
+			continue
+		}
+		result.ConversationTests = append(result.ConversationTests, test.ID)
+	}
+
+	s.logger.WithContext(ctx).Info("Starter bot generated",
+		"bot_id", imported.Bot.ID,
+		"vertical", request.Vertical,
+		"flows", result.Flows,
+		"tests", len(result.ConversationTests))
+	return result, nil
+}
+
+// starterKitBundle arma el bot como un bundle con IDs provisionales que
+// ImportBot sustituye, incluidos los flow_id de los intents globales
+func starterKitBundle(request StarterKitRequest, topics map[string]starterTopic) (*domain.BotBundle, error) {
+	now := time.Now()
+	bundle := &domain.BotBundle{
+		Version:    domain.BotBundleVersion,
+		ExportedAt: now,
+		Bot: domain.Bot{
+			ID:      "starter-bot",
+			Name:    request.BusinessName,
+			Channel: request.Channel,
+			Status:  domain.BotStatusActive,
+		},
+		Flows:        []domain.BotFlow{},
+		Steps:        []domain.BotStep{},
+		SmartReplies: []domain.SmartReply{},
+		Conditionals: []domain.Conditional{},
+		Triggers:     []domain.Trigger{},
+	}
+
+	addStep := func(id, flowID string, stepType domain.StepType, content interface{}, next string) error {
+		raw, err := json.Marshal(content)
+		if err != nil {
+			return fmt.Errorf("failed to encode starter step %s: %w", id, err)
+		}
+		step := domain.BotStep{ID: id, FlowID: flowID, Name: id, Type: stepType, Content: raw}
+		if next != "" {
+			step.NextStepID = &next
+		}
+		bundle.Steps = append(bundle.Steps, step)
+		return nil
+	}
+	addFlow := func(id, name string, keywords []string, isDefault bool) {
+		flow := domain.BotFlow{ID: id, Name: name, EntryPoint: id + "-start", IsDefault: isDefault}
+		if len(keywords) > 0 {
+			flow.TriggerConfig = &domain.FlowTrigger{Keywords: keywords}
+		}
+		bundle.Flows = append(bundle.Flows, flow)
+	}
+
+	var intents []domain.GlobalIntent
+	for _, lang := range starterKitLanguages {
+		texts := starterKitTexts[lang]
+		topic := topics[lang]
+		greetingFlow := "starter-greeting-" + lang
+		goodbyeFlow := "starter-goodbye-" + lang
+		topicFlow := "starter-topic-" + lang
+
+		addFlow(greetingFlow, starterFlowName(texts.GreetingName, lang), nil, false)
+		err := addStep(greetingFlow+"-start", greetingFlow, domain.StepTypeMessage, map[string]interface{}{
+			"text": texts.Greeting,
+			"type": domain.ResponseTypeText,
+			"options": []domain.ResponseOption{
+				{ID: "topic", Text: topic.Option, Value: topic.Keywords[0]},
+				{ID: "goodbye", Text: texts.GoodbyeOption, Value: texts.GoodbyeKeywords[0]},
+			},
+		}, "")
+		if err != nil {
+			return nil, err
+		}
+
+		addFlow(goodbyeFlow, starterFlowName(texts.GoodbyeName, lang), nil, false)
+		if err := addStep(goodbyeFlow+"-start", goodbyeFlow, domain.StepTypeEnd, map[string]interface{}{
+			"text":   texts.Goodbye,
+			"reason": "goodbye",
+		}, ""); err != nil {
+			return nil, err
+		}
+
+		addFlow(topicFlow, starterFlowName(topic.Name, lang), topic.Keywords, false)
+		if err := addStep(topicFlow+"-start", topicFlow, domain.StepTypeMessage, map[string]interface{}{
+			"text": topic.Text,
+			"type": domain.ResponseTypeText,
+		}, ""); err != nil {
+			return nil, err
+		}
+
+		// Como intents globales se reconocen aunque la sesión siga en otro flujo
+		intents = append(intents,
+			domain.GlobalIntent{Name: "greeting_" + lang, Keywords: texts.GreetingKeywords, FlowID: greetingFlow},
+			domain.GlobalIntent{Name: request.Vertical + "_" + lang, Keywords: topic.Keywords, FlowID: topicFlow},
+			domain.GlobalIntent{Name: "goodbye_" + lang, Keywords: texts.GoodbyeKeywords, FlowID: goodbyeFlow},
+		)
+	}
+
+	// El fallback no sabe en qué idioma escribió el usuario: responde en ambos
+	addFlow("starter-fallback", "Fallback", nil, true)
+	if err := addStep("starter-fallback-start", "starter-fallback", domain.StepTypeMessage, map[string]interface{}{
+		"text": bilingualText(request.PrimaryLanguage, func(texts starterTexts) string { return texts.Fallback }),
+		"type": domain.ResponseTypeText,
+	}, ""); err != nil {
+		return nil, err
+	}
+
+	config, err := json.Marshal(domain.BotConfig{
+		GlobalIntents: intents,
+		VoiceNotes: &domain.VoiceNoteConfig{
+			FailureMessage: bilingualText(request.PrimaryLanguage, func(texts starterTexts) string { return texts.VoiceNoteFailure }),
+		},
+		Variables: map[string]interface{}{
+			"business_name": request.BusinessName,
+			"vertical":      request.Vertical,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode starter bot config: %w", err)
+	}
+	bundle.Bot.Config = config
+	return bundle, nil
+}
+
+// starterKitTests genera una prueba de conversación por idioma (saludo, flujo
+// del sector y despedida) y otra para el fallback
+func starterKitTests(botID string, request StarterKitRequest, topics map[string]starterTopic) []*domain.MultiTurnTestCase {
+	tests := make([]*domain.MultiTurnTestCase, 0, len(starterKitLanguages)+1)
+	for _, lang := range starterKitLanguages {
+		texts := starterKitTexts[lang]
+		topic := topics[lang]
+		tests = append(tests, &domain.MultiTurnTestCase{
+			BotID:       botID,
+			Name:        "Starter kit (" + lang + ")",
+			Description: "Greeting, " + strings.ToLower(topic.Name) + " and goodbye generated by the starter kit",
+			Channel:     request.Channel,
+			Turns: []domain.TestTurn{
+				{Message: texts.TestGreeting, Expected: domain.TestTurnExpected{ResponseContains: request.BusinessName}},
+				{Message: topic.Keywords[0], Expected: domain.TestTurnExpected{ResponseContains: request.BusinessName}},
+				{Message: texts.TestGoodbye, Expected: domain.TestTurnExpected{ResponseContains: request.BusinessName}},
+			},
+		})
+	}
+
+	fallback := starterKitTexts[request.PrimaryLanguage].Fallback
+	tests = append(tests, &domain.MultiTurnTestCase{
+		BotID:       botID,
+		Name:        "Starter kit fallback",
+		Description: "Unrecognized messages get the bilingual fallback",
+		Channel:     request.Channel,
+		Turns: []domain.TestTurn{
+			{Message: "xyz 123", Expected: domain.TestTurnExpected{ResponseContains: fallback}},
+		},
+	})
+	return tests
+}
+
+func starterFlowName(name, lang string) string {
+	return name + " (" + lang + ")"
+}
+
+// bilingualText une el texto de ambos idiomas, primero el principal
+func bilingualText(primary string, text func(texts starterTexts) string) string {
+	parts := []string{text(starterKitTexts[primary])}
+	for _, lang := range starterKitLanguages {
+		if lang != primary {
+			parts = append(parts, text(starterKitTexts[lang]))
+		}
+	}
+	return strings.Join(parts, "\n")
+}
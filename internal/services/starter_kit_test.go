@@ -0,0 +1,52 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStarterKitService_GeneratesBilingualBotWithPassingTests(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conditionalRepo := repositories.NewMockConditionalRepository()
+	triggerRepo := repositories.NewMockTriggerRepository()
+	conditionalSvc := NewConditionalService(conditionalRepo, log)
+	triggerSvc := NewTriggerService(triggerRepo, conditionalSvc, TriggerActionDeps{}, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, nil, nil, log)
+	bundles := NewBotBundleService(botRepo, flowRepo, stepRepo, repositories.NewMockSmartReplyRepository(), conditionalRepo, triggerRepo, log)
+	tests := NewTestService(repositories.NewMockTestCaseRepository(), repositories.NewMockMultiTurnTestCaseRepository(),
+		repositories.NewMockTestRunRepository(), sessionRepo, bots, conditionalSvc, triggerSvc, log)
+	kits := NewStarterKitService(bundles, tests, log)
+
+	assert.Contains(t, kits.Verticals(), "retail")
+
+	result, err := kits.Generate(ctx, StarterKitRequest{BusinessName: "Tienda Sol", Vertical: "retail"}, "owner-1")
+	require.NoError(t, err)
+	assert.Equal(t, "owner-1", result.Bot.OwnerID)
+	assert.Equal(t, []string{"es", "en"}, result.Languages)
+	assert.NotEmpty(t, result.Flows)
+	require.Len(t, result.ConversationTests, 3)
+
+	for _, id := range result.ConversationTests {
+		outcome, err := tests.ExecuteMultiTurnTestCase(ctx, id)
+		require.NoError(t, err)
+		assert.True(t, outcome.Success, "test %s failed: %+v", id, outcome.Turns)
+	}
+
+	_, err = kits.Generate(ctx, StarterKitRequest{BusinessName: "X", Vertical: "mining"}, "owner-1")
+	assert.True(t, errors.Is(err, ErrInvalidStarterKit))
+	_, err = kits.Generate(ctx, StarterKitRequest{Vertical: "retail"}, "owner-1")
+	assert.True(t, errors.Is(err, ErrInvalidStarterKit))
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/tracing"
+)
+
+// StepHandler ejecuta un paso de flujo y devuelve la respuesta y el siguiente paso
+type StepHandler func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error)
+
+// StepMiddleware envuelve la ejecución de los pasos. Puede actuar antes de llamar
+// a next (guardrails, cuotas), después (limpieza de PII en la respuesta) o no
+// llamarlo para cortar el paso devolviendo su propia respuesta o un error.
+type StepMiddleware func(next StepHandler) StepHandler
+
+// BeforeStep crea un middleware que llama a fn antes del paso; si fn devuelve
+// error el paso no se ejecuta
+func BeforeStep(fn func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) error) StepMiddleware {
+	return func(next StepHandler) StepHandler {
+		return func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+			if err := fn(ctx, step, message, session); err != nil {
+				return nil, nil, err
+			}
+			return next(ctx, step, message, session)
+		}
+	}
+}
+
+// AfterStep crea un middleware que llama a fn con el resultado del paso. fn puede
+// modificar la respuesta; si devuelve error, ese error sustituye al resultado.
+func AfterStep(fn func(ctx context.Context, step *domain.BotStep, session *domain.ConversationSession, response *domain.BotResponse, err error) error) StepMiddleware {
+	return func(next StepHandler) StepHandler {
+		return func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+			response, nextStepID, err := next(ctx, step, message, session)
+			if hookErr := fn(ctx, step, session, response, err); hookErr != nil {
+				return nil, nil, hookErr
+			}
+			return response, nextStepID, err
+		}
+	}
+}
+
+// UseStepMiddleware añade middleware a la cadena de pasos. El primero registrado
+// es el más externo; los de fábrica (métricas, logging, traza y moderación) van antes que todos.
+func (s *botService) UseStepMiddleware(middleware ...StepMiddleware) {
+	s.stepMu.Lock()
+	defer s.stepMu.Unlock()
+
+	s.stepMiddleware = append(s.stepMiddleware, middleware...)
+	chain := StepHandler(s.executeStep)
+	for i := len(s.stepMiddleware) - 1; i >= 0; i-- {
+		chain = s.stepMiddleware[i](chain)
+	}
+	s.stepChain = chain
+}
+
+// stepMetricsMiddleware mide cada paso en bot_step_duration_seconds
+func stepMetricsMiddleware(next StepHandler) StepHandler {
+	return func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+		start := time.Now()
+		response, nextStepID, err := next(ctx, step, message, session)
+
+		status := metrics.StatusSuccess
+		if err != nil {
+			status = metrics.StatusFailure
+		}
+		metrics.StepDuration.WithLabelValues(string(step.Type), status).Observe(time.Since(start).Seconds())
+		return response, nextStepID, err
+	}
+}
+
+// stepTraceMiddleware añade el flujo y el paso a la cadena de ejecución de la
+// petición, si se pidió
+func stepTraceMiddleware(next StepHandler) StepHandler {
+	return func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+		tracing.Record(ctx, tracing.ComponentFlow, step.FlowID, "")
+		tracing.Record(ctx, tracing.ComponentStep, step.ID, string(step.Type))
+		return next(ctx, step, message, session)
+	}
+}
+
+func (s *botService) stepLoggingMiddleware(next StepHandler) StepHandler {
+	return func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+		start := time.Now()
+		response, nextStepID, err := next(ctx, step, message, session)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("Step execution failed",
+				"step_id", step.ID,
+				"step_type", step.Type,
+				"session_id", session.ID,
+				"error", err)
+			return response, nextStepID, err
+		}
+
+		s.logger.WithContext(ctx).Debug("Step executed",
+			"step_id", step.ID,
+			"step_type", step.Type,
+			"session_id", session.ID,
+			"duration", time.Since(start))
+		return response, nextStepID, err
+	}
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStepMiddleware_HooksWrapEveryStep(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "jump", IsDefault: true}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "jump", FlowID: "main", Type: domain.StepTypeJump,
+		Content: json.RawMessage(`{"step":"card"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "card", FlowID: "main", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Your card is 4111 1111 1111 1111"}`)}))
+
+	var order []string
+	errBlocked := errors.New("blocked by guardrail")
+	bots.UseStepMiddleware(
+		BeforeStep(func(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) error {
+			order = append(order, "before:"+step.ID)
+			if message.Content == "forbidden" {
+				return errBlocked
+			}
+			return nil
+		}),
+		AfterStep(func(ctx context.Context, step *domain.BotStep, session *domain.ConversationSession, response *domain.BotResponse, err error) error {
+			order = append(order, "after:"+step.ID)
+			if response != nil {
+				response.Content = strings.ReplaceAll(response.Content, "4111 1111 1111 1111", "[redacted]")
+			}
+			return err
+		}),
+	)
+
+	response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: "hi"})
+	require.NoError(t, err)
+	assert.Equal(t, "Your card is [redacted]", response.Content)
+	assert.Equal(t, []string{"before:jump", "before:card", "after:card", "after:jump"}, order)
+
+	_, err = bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-2", Content: "forbidden"})
+	assert.ErrorIs(t, err, errBlocked)
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// subflowStackKey guarda en el contexto de la sesión los sub-flujos en curso
+const subflowStackKey = "subflow_stack"
+
+// maxSubflowDepth limita los sub-flujos anidados para cortar recursiones
+const maxSubflowDepth = 10
+
+// subflowContent configura un paso subflow. Input copia claves del contexto
+// antes de entrar (clave del sub-flujo -> clave del llamador) y Output las
+// copia de vuelta al terminar (clave del llamador -> clave del sub-flujo).
+type subflowContent struct {
+	FlowID     string            `json:"flow_id"`
+	Step       string            `json:"step,omitempty"`        // opcional, por defecto el entry point
+	ReturnStep string            `json:"return_step,omitempty"` // por defecto el siguiente paso
+	Input      map[string]string `json:"input,omitempty"`
+	Output     map[string]string `json:"output,omitempty"`
+}
+
+// subflowFrame es el punto de retorno de un sub-flujo
+type subflowFrame struct {
+	FlowID      string            `json:"flow_id"`
+	FlowVersion int               `json:"flow_version"`
+	ReturnStep  string            `json:"return_step,omitempty"`
+	Output      map[string]string `json:"output,omitempty"`
+}
+
+// processSubflowStep entra al sub-flujo guardando dónde continuar cuando termine
+func (s *botService) processSubflowStep(ctx context.Context, step *domain.BotStep, message *domain.IncomingMessage, session *domain.ConversationSession) (*domain.BotResponse, *string, error) {
+	var content subflowContent
+	if err := json.Unmarshal(step.Content, &content); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse subflow step content: %w", err)
+	}
+	if content.FlowID == "" {
+		return nil, nil, fmt.Errorf("subflow step %s requires flow_id", step.ID)
+	}
+
+	stack := subflowStack(session)
+	if len(stack) >= maxSubflowDepth {
+		return nil, nil, fmt.Errorf("too many nested subflows (max %d) at step %s", maxSubflowDepth, step.ID)
+	}
+
+	flow, err := s.flowRepo.GetByID(ctx, content.FlowID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("subflow not found: %w", err)
+	}
+	if flow.BotID != session.BotID {
+		return nil, nil, fmt.Errorf("subflow %s does not belong to bot %s", flow.ID, session.BotID)
+	}
+
+	callerVersion := sessionFlowVersion(session, step.FlowID)
+	returnStep := content.ReturnStep
+	if returnStep == "" && step.NextStepID != nil {
+		returnStep = *step.NextStepID
+	}
+	if returnStep != "" {
+		// Se resuelve ahora para que un return_step por nombre sobreviva a la sesión
+		target, err := s.findStepInFlow(ctx, step.FlowID, callerVersion, returnStep)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid subflow return step: %w", err)
+		}
+		returnStep = target.ID
+	}
+
+	stepRef := content.Step
+	if stepRef == "" {
+		stepRef = s.flowEntryPoint(ctx, flow, flow.PublishedVersion)
+	}
+	target, err := s.findStepInFlow(ctx, flow.ID, flow.PublishedVersion, stepRef)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for subKey, callerKey := range content.Input {
+		if value, ok := session.Context[callerKey]; ok {
+			session.Context[subKey] = value
+		}
+	}
+
+	stack = append(stack, subflowFrame{
+		FlowID:      step.FlowID,
+		FlowVersion: callerVersion,
+		ReturnStep:  returnStep,
+		Output:      content.Output,
+	})
+	setSubflowStack(session, stack)
+
+	enterFlow(session, flow)
+	return s.continueWithStep(ctx, target, message, session)
+}
+
+// returnFromSubflow vuelve al flujo llamador cuando el sub-flujo terminó,
+// copiando sus salidas. Si el llamador no tenía paso de retorno también
+// termina y se vuelve al siguiente nivel.
+func returnFromSubflow(session *domain.ConversationSession) bool {
+	stack := subflowStack(session)
+	if len(stack) == 0 {
+		return false
+	}
+
+	for len(stack) > 0 {
+		frame := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		for callerKey, subKey := range frame.Output {
+			if value, ok := session.Context[subKey]; ok {
+				session.Context[callerKey] = value
+			}
+		}
+
+		session.CurrentFlowID = frame.FlowID
+		session.FlowVersion = frame.FlowVersion
+		session.CurrentStepID = frame.ReturnStep
+		if frame.ReturnStep != "" {
+			break
+		}
+	}
+
+	setSubflowStack(session, stack)
+	return true
+}
+
+// subflowStack lee la pila desde el contexto; al persistirse la sesión los
+// frames vuelven como mapas genéricos, por eso se decodifican vía JSON
+func subflowStack(session *domain.ConversationSession) []subflowFrame {
+	raw, ok := session.Context[subflowStackKey]
+	if !ok {
+		return nil
+	}
+	if stack, ok := raw.([]subflowFrame); ok {
+		return append([]subflowFrame(nil), stack...)
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var stack []subflowFrame
+	if err := json.Unmarshal(data, &stack); err != nil {
+		return nil
+	}
+	return stack
+}
+
+func setSubflowStack(session *domain.ConversationSession, stack []subflowFrame) {
+	if len(stack) == 0 {
+		delete(session.Context, subflowStackKey)
+		return
+	}
+	session.Context[subflowStackKey] = stack
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubflowStep_ReturnsToCallerWithOutputs(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "ask", IsDefault: true}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "address", BotID: "bot-1", EntryPoint: "addr-prompt"}))
+
+	done := "done"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "ask", FlowID: "main", Type: domain.StepTypeSubflow, NextStepID: &done,
+		Content: json.RawMessage(`{"flow_id":"address","output":{"shipping_address":"address"}}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "done", FlowID: "main", Type: domain.StepTypeMessage, Content: json.RawMessage(`{"text":"bye"}`)}))
+
+	input := "addr-input"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "addr-prompt", FlowID: "address", Type: domain.StepTypeMessage, NextStepID: &input,
+		Content: json.RawMessage(`{"text":"Where do we ship?"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "addr-input", FlowID: "address", Type: domain.StepTypeInput,
+		Content: json.RawMessage(`{"variable":"address"}`)}))
+
+	send := func(text string) *domain.BotResponse {
+		response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: "user-1", Content: text})
+		require.NoError(t, err)
+		return response
+	}
+
+	assert.Equal(t, "Where do we ship?", send("hi").Content)
+
+	response := send("Main St 1")
+	assert.Equal(t, "main", response.Metadata["subflow_returned_to"])
+
+	session, err := conversations.GetSession(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "main", session.CurrentFlowID)
+	assert.Equal(t, "done", session.CurrentStepID)
+	assert.Equal(t, "Main St 1", session.Context["shipping_address"])
+	assert.NotContains(t, session.Context, subflowStackKey)
+
+	assert.Equal(t, "bye", send("ok").Content)
+}
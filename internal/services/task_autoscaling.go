@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/bot-service/internal/metrics"
+)
+
+// WorkerScaling configura el autoescalado del pool de workers. Cada Interval
+// se calculan los workers necesarios para las tareas en ejecución más los que
+// vaciarían la cola en TargetWait, según el tiempo medio de ejecución.
+type WorkerScaling struct {
+	MinWorkers int
+	MaxWorkers int
+	Interval   time.Duration
+	TargetWait time.Duration
+}
+
+const (
+	defaultScaleInterval   = 10 * time.Second
+	defaultScaleTargetWait = 5 * time.Second
+)
+
+// EnableAutoscaling activa el autoescalado; debe llamarse antes de Start
+func (tm *taskManager) EnableAutoscaling(policy WorkerScaling) error {
+	if policy.MinWorkers <= 0 {
+		policy.MinWorkers = 1
+	}
+	if policy.MaxWorkers < policy.MinWorkers {
+		return fmt.Errorf("max workers (%d) must be at least min workers (%d)", policy.MaxWorkers, policy.MinWorkers)
+	}
+	if policy.Interval <= 0 {
+		policy.Interval = defaultScaleInterval
+	}
+	if policy.TargetWait <= 0 {
+		policy.TargetWait = defaultScaleTargetWait
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.ctx != nil {
+		return fmt.Errorf("autoscaling must be enabled before the task manager starts")
+	}
+	tm.scaling = &policy
+	return nil
+}
+
+func clampWorkers(workers int, policy *WorkerScaling) int {
+	if workers < policy.MinWorkers {
+		return policy.MinWorkers
+	}
+	if workers > policy.MaxWorkers {
+		return policy.MaxWorkers
+	}
+	return workers
+}
+
+func (tm *taskManager) runAutoscaler(ctx context.Context) {
+	ticker := tm.clock.NewTicker(tm.scaling.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			tm.autoscale()
+		}
+	}
+}
+
+// autoscale ajusta el pool a la carga actual. Se crece de golpe para absorber
+// las ráfagas y se retira un worker ocioso por ciclo para no oscilar.
+func (tm *taskManager) autoscale() {
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if tm.ctx == nil || tm.scaling == nil {
+		return
+	}
+
+	depth := tm.queue.len()
+	latency := tm.stats.AverageTime
+	if latency <= 0 {
+		latency = tm.scaling.TargetWait
+	}
+	busy := 0
+	for _, worker := range tm.workers {
+		if worker.busy() {
+			busy++
+		}
+	}
+	backlog := int((time.Duration(depth)*latency + tm.scaling.TargetWait - 1) / tm.scaling.TargetWait)
+	desired := clampWorkers(busy+backlog, tm.scaling)
+
+	current := len(tm.workers)
+	switch {
+	case desired > current:
+		for i := current; i < desired; i++ {
+			tm.addWorker()
+		}
+		metrics.RecordTaskWorkerScale(metrics.ScaleUp, len(tm.workers))
+		tm.logger.Info("Task workers scaled up", "from", current, "to", len(tm.workers), "queue_depth", depth, "average_latency", latency)
+	case desired < current:
+		if tm.retireIdleWorker() {
+			metrics.RecordTaskWorkerScale(metrics.ScaleDown, len(tm.workers))
+			tm.logger.Info("Task workers scaled down", "from", current, "to", len(tm.workers), "queue_depth", depth)
+		}
+	}
+}
+
+// addWorker crea e inicia un worker. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) addWorker() {
+	tm.nextWorkerID++
+	id := fmt.Sprintf("worker-%d", tm.nextWorkerID)
+	worker := &taskWorker{
+		id:      id,
+		manager: tm,
+		stats: &WorkerStats{
+			ID:           id,
+			Status:       "idle",
+			LastActivity: tm.clock.Now(),
+		},
+		logger: tm.logger,
+	}
+	worker.quit, worker.retire = context.WithCancel(tm.ctx)
+
+	tm.workers = append(tm.workers, worker)
+	tm.stats.WorkerStats[id] = worker.stats
+
+	go worker.run(tm.ctx)
+}
+
+// retireIdleWorker detiene el último worker ocioso. Debe llamarse con tm.mu
+// tomado.
+func (tm *taskManager) retireIdleWorker() bool {
+	for i := len(tm.workers) - 1; i >= 0; i-- {
+		worker := tm.workers[i]
+		if worker.busy() {
+			continue
+		}
+		worker.retire()
+		tm.workers = append(tm.workers[:i], tm.workers[i+1:]...)
+		delete(tm.stats.WorkerStats, worker.id)
+		return true
+	}
+	return false
+}
+
+func (w *taskWorker) busy() bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.stats.Status == "busy"
+}
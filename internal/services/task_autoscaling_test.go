@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskManager_Autoscaling(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager := NewTaskManager(repositories.NewMockTaskRepository(), repositories.NewMockDeadLetterRepository(),
+		nil, nil, logger.NewLogger("error"), 1, 20, 0, nil)
+	assert.Error(t, manager.EnableAutoscaling(WorkerScaling{MinWorkers: 3, MaxWorkers: 2}))
+	require.NoError(t, manager.EnableAutoscaling(WorkerScaling{MinWorkers: 1, MaxWorkers: 4, Interval: time.Hour}))
+
+	release := make(chan struct{})
+	started := make(chan string, 6)
+	require.NoError(t, manager.RegisterHandler("test_suite", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		started <- task.ID
+		<-release
+		return nil, nil
+	}))
+	require.NoError(t, manager.Start(ctx))
+	assert.Error(t, manager.EnableAutoscaling(WorkerScaling{MinWorkers: 1, MaxWorkers: 2}))
+
+	for i := 0; i < 6; i++ {
+		require.NoError(t, manager.SubmitTask(ctx, &domain.AsyncTask{Type: "test_suite"}))
+	}
+	waitStarted := func(count int) {
+		for i := 0; i < count; i++ {
+			select {
+			case <-started:
+			case <-time.After(2 * time.Second):
+				t.Fatalf("only %d of %d tasks started", i, count)
+			}
+		}
+	}
+	waitStarted(1)
+
+	// Con la cola llena el pool crece de golpe hasta el máximo
+	tm := manager.(*taskManager)
+	tm.autoscale()
+	assert.Len(t, manager.GetStats().WorkerStats, 4)
+	waitStarted(3)
+
+	close(release)
+	waitStarted(2)
+	require.Eventually(t, func() bool {
+		stats := manager.GetStats()
+		for _, worker := range stats.WorkerStats {
+			if worker.Status != "idle" {
+				return false
+			}
+		}
+		return stats.CompletedTasks == 6
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// Sin carga se retira un worker por ciclo hasta el mínimo
+	for _, expected := range []int{3, 2, 1, 1} {
+		tm.autoscale()
+		assert.Len(t, manager.GetStats().WorkerStats, expected)
+	}
+}
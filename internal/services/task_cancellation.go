@@ -0,0 +1,26 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// finishCancelled cierra una tarea cancelada mientras se ejecutaba. CancelTask
+// ya notificó el cambio de estado, así que sólo se guarda el resultado, con la
+// salida parcial si el agente devolvió alguna. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) finishCancelled(task *domain.AsyncTask, result *domain.MCPTaskResult, duration time.Duration) {
+	task.ExecutionTime = duration.Milliseconds()
+	task.Result = map[string]interface{}{
+		"success":   false,
+		"cancelled": true,
+		"attempts":  task.Attempts,
+	}
+	if result != nil && len(result.Output) > 0 {
+		task.Result["partial_output"] = result.Output
+	}
+
+	tm.save(context.Background(), task)
+	tm.logger.Info("Cancelled task stopped", "task_id", task.ID, "duration", duration, "partial_output", task.Result["partial_output"] != nil)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskManager_CancelStopsRunningTask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager := NewTaskManager(repositories.NewMockTaskRepository(), repositories.NewMockDeadLetterRepository(),
+		nil, nil, logger.NewLogger("error"), 1, 10, 0, nil)
+	started := make(chan string, 2)
+	stopped := make(chan struct{})
+	require.NoError(t, manager.RegisterHandler("report", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		started <- task.ID
+		<-ctx.Done()
+		close(stopped)
+		return map[string]interface{}{"rows_processed": 10}, ctx.Err()
+	}))
+	require.NoError(t, manager.Start(ctx))
+
+	running := &domain.AsyncTask{ID: "running", Type: "report"}
+	queued := &domain.AsyncTask{ID: "queued", Type: "report"}
+	require.NoError(t, manager.SubmitTask(ctx, running))
+	require.NoError(t, manager.SubmitTask(ctx, queued))
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task did not start")
+	}
+
+	// La tarea pendiente se cancela antes de llegar al worker
+	require.NoError(t, manager.CancelTask(ctx, queued.ID))
+	require.NoError(t, manager.CancelTask(ctx, running.ID))
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("running task context was not cancelled")
+	}
+
+	require.Eventually(t, func() bool {
+		task, err := manager.GetTask(ctx, running.ID)
+		return err == nil && task.Result != nil
+	}, 2*time.Second, 10*time.Millisecond)
+	task, err := manager.GetTask(ctx, running.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusCancelled, task.Status)
+	assert.Equal(t, true, task.Result["cancelled"])
+	assert.Equal(t, map[string]interface{}{"rows_processed": 10}, task.Result["partial_output"])
+
+	select {
+	case id := <-started:
+		t.Fatalf("cancelled task %s was executed", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+	stats := manager.GetStats()
+	assert.Equal(t, int64(0), stats.PendingTasks)
+	assert.Equal(t, int64(0), stats.RunningTasks)
+	assert.Equal(t, int64(2), stats.CancelledTasks)
+
+	assert.Error(t, manager.CancelTask(ctx, running.ID))
+}
@@ -2,22 +2,47 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/company/bot-service/internal/domain"
 	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
+// ErrTaskQueueFull indica que la cola de tareas no admite más trabajo
+var ErrTaskQueueFull = errors.New("task queue is full")
+
 // TaskManager define las operaciones para gestión de tareas asíncronas
 type TaskManager interface {
 	// Gestión de tareas
 	SubmitTask(ctx context.Context, task *domain.AsyncTask) error
 	GetTask(ctx context.Context, taskID string) (*domain.AsyncTask, error)
+	// WatchTask emite los cambios de estado de la tarea hasta que termina
+	WatchTask(ctx context.Context, taskID string) (<-chan *domain.AsyncTask, error)
 	ListTasks(ctx context.Context, filters *TaskFilters) ([]*domain.AsyncTask, error)
 	CancelTask(ctx context.Context, taskID string) error
+	RegisterHandler(taskType string, handler TaskHandlerFunc) error
+	// LimitConcurrency limita las tareas del tipo que se ejecutan a la vez
+	LimitConcurrency(taskType string, max int) error
+	// EnableAutoscaling ajusta los workers a la carga entre un mínimo y un máximo
+	EnableAutoscaling(policy WorkerScaling) error
+	
+	// Dead-letter
+	ListDeadLetters(ctx context.Context, limit, offset int) ([]*domain.DeadLetterTask, error)
+	GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetterTask, error)
+	RedriveDeadLetter(ctx context.Context, id string) (*domain.AsyncTask, error)
+	DeleteDeadLetter(ctx context.Context, id string) error
+	PurgeDeadLetters(ctx context.Context) (int, error)
 	
 	// Ejecución
 	Start(ctx context.Context) error
@@ -25,6 +50,7 @@ type TaskManager interface {
 	
 	// Monitoreo
 	GetStats() *TaskStats
+	QueueLoad() (depth, capacity int)
 }
 
 // TaskFilters define filtros para listar tareas
@@ -52,7 +78,12 @@ type TaskStats struct {
 	CompletedTasks int64                        `json:"completed_tasks"`
 	FailedTasks    int64                        `json:"failed_tasks"`
 	CancelledTasks int64                        `json:"cancelled_tasks"`
+	RecoveredTasks int64                        `json:"recovered_tasks"`
+	RetriedTasks   int64                        `json:"retried_tasks"`
+	DeadLettered   int64                        `json:"dead_lettered_tasks"`
 	TasksByType    map[string]int64             `json:"tasks_by_type"`
+	RunningByType  map[string]int               `json:"running_by_type"`
+	TypeLimits     map[string]int               `json:"type_concurrency_limits,omitempty"`
 	AverageTime    time.Duration                `json:"average_execution_time"`
 	WorkerStats    map[string]*WorkerStats      `json:"worker_stats"`
 	LastUpdated    time.Time                    `json:"last_updated"`
@@ -70,13 +101,18 @@ type WorkerStats struct {
 
 // taskManager implementa TaskManager
 type taskManager struct {
+	taskRepo        domain.TaskRepository
+	deadLetterRepo  domain.DeadLetterRepository
 	tasks           map[string]*domain.AsyncTask
-	taskQueue       chan *domain.AsyncTask
+	handlers        map[string]TaskHandlerFunc
+	queue           *taskQueue
 	workers         []*taskWorker
 	mcpOrchestrator interface {
 		mcp.MCPOrchestrator
 		mcp.MCPDomainOrchestrator
 	}
+	eventBus        events.EventBus
+	events          *events.EventFactory
 	logger          logger.Logger
 	mu              sync.RWMutex
 	ctx             context.Context
@@ -84,6 +120,27 @@ type taskManager struct {
 	stats           *TaskStats
 	workerCount     int
 	maxQueueSize    int
+	retention       time.Duration
+	clock           clock.Clock
+	// watchers son los streams abiertos por tarea; callbackClient entrega
+	// las notificaciones a callback_url
+	watchers        map[string]map[int]chan *domain.AsyncTask
+	nextWatcher     int
+	callbackClient  *http.Client
+	// cancels interrumpe las tareas en ejecución al cancelarlas
+	cancels         map[string]context.CancelFunc
+	scaling         *WorkerScaling
+	nextWorkerID    int
+	// random reparte los reintentos (jitter); los tests lo fijan
+	random          func() float64
+	// pending es la E/S de los cambios hechos con mu que flush hace después
+	// de soltarlo; writes la ordena por tarea y flushed avisa a Stop cuando
+	// inflight llega a cero
+	pending         []func()
+	inflight        int
+	version         uint64
+	writes          taskWrites
+	flushed         *sync.Cond
 }
 
 // taskWorker representa un worker que ejecuta tareas
@@ -93,17 +150,28 @@ type taskWorker struct {
 	stats           *WorkerStats
 	logger          logger.Logger
 	mu              sync.RWMutex
+	// quit detiene el worker al reducir el pool; la tarea en curso termina
+	quit            context.Context
+	retire          context.CancelFunc
 }
 
-// NewTaskManager crea un nuevo task manager
+// NewTaskManager crea un nuevo task manager. Las tareas se persisten en taskRepo,
+// las finalizadas se conservan durante retention y las que fallan definitivamente
+// pasan a deadLetterRepo. Cada tarea completada se publica en eventBus, si no es nil.
+// Esperas, reintentos y marcas de tiempo usan clk (el reloj del sistema si es nil).
 func NewTaskManager(
+	taskRepo domain.TaskRepository,
+	deadLetterRepo domain.DeadLetterRepository,
 	mcpOrchestrator interface {
 		mcp.MCPOrchestrator
 		mcp.MCPDomainOrchestrator
 	},
+	eventBus events.EventBus,
 	logger logger.Logger,
 	workerCount int,
 	maxQueueSize int,
+	retention time.Duration,
+	clk clock.Clock,
 ) TaskManager {
 	if workerCount <= 0 {
 		workerCount = 5
@@ -111,25 +179,46 @@ func NewTaskManager(
 	if maxQueueSize <= 0 {
 		maxQueueSize = 1000
 	}
+	if retention <= 0 {
+		retention = 7 * 24 * time.Hour
+	}
 	
-	return &taskManager{
+	tm := &taskManager{
+		taskRepo:        taskRepo,
+		deadLetterRepo:  deadLetterRepo,
 		tasks:           make(map[string]*domain.AsyncTask),
-		taskQueue:       make(chan *domain.AsyncTask, maxQueueSize),
+		handlers:        make(map[string]TaskHandlerFunc),
+		queue:           newTaskQueue(maxQueueSize),
 		workers:         make([]*taskWorker, 0, workerCount),
 		mcpOrchestrator: mcpOrchestrator,
+		eventBus:        eventBus,
+		events:          events.NewEventFactory("bot-service"),
 		logger:          logger,
 		stats: &TaskStats{
 			TasksByType: make(map[string]int64),
 			WorkerStats: make(map[string]*WorkerStats),
 		},
-		workerCount:  workerCount,
-		maxQueueSize: maxQueueSize,
+		workerCount:    workerCount,
+		maxQueueSize:   maxQueueSize,
+		retention:      retention,
+		clock:          clock.OrReal(clk),
+		watchers:       make(map[string]map[int]chan *domain.AsyncTask),
+		callbackClient: &http.Client{Timeout: taskCallbackTimeout},
+		cancels:        make(map[string]context.CancelFunc),
+		random:         rand.Float64,
 	}
+	tm.flushed = sync.NewCond(&tm.mu)
+	metrics.TrackTaskQueue(tm.queue.len)
+	return tm
 }
 
 // Start inicia el task manager
 func (tm *taskManager) Start(ctx context.Context) error {
+	// Las tareas que quedaron pendientes antes de un reinicio se leen sin el lock
+	recovered, recoverErr := tm.taskRepo.GetByStatus(ctx, domain.TaskStatusPending, domain.TaskStatusRunning)
+	
 	tm.mu.Lock()
+	defer tm.flush()
 	defer tm.mu.Unlock()
 	
 	if tm.ctx != nil {
@@ -139,26 +228,29 @@ func (tm *taskManager) Start(ctx context.Context) error {
 	tm.ctx, tm.cancel = context.WithCancel(ctx)
 	
 	// Crear y iniciar workers
-	for i := 0; i < tm.workerCount; i++ {
-		worker := &taskWorker{
-			id:      fmt.Sprintf("worker-%d", i+1),
-			manager: tm,
-			stats: &WorkerStats{
-				ID:           fmt.Sprintf("worker-%d", i+1),
-				Status:       "idle",
-				LastActivity: time.Now(),
-			},
-			logger: tm.logger,
-		}
-		
-		tm.workers = append(tm.workers, worker)
-		tm.stats.WorkerStats[worker.id] = worker.stats
-		
-		go worker.run(tm.ctx)
+	workers := tm.workerCount
+	if tm.scaling != nil {
+		workers = clampWorkers(workers, tm.scaling)
+	}
+	for i := 0; i < workers; i++ {
+		tm.addWorker()
+	}
+	metrics.SetTaskWorkers(len(tm.workers))
+	if tm.scaling != nil {
+		go tm.runAutoscaler(tm.ctx)
+	}
+	
+	// Re-encolar las tareas que quedaron pendientes antes de un reinicio
+	if recoverErr != nil {
+		tm.logger.Error("Failed to recover tasks", "error", recoverErr)
+	} else {
+		tm.recoverTasks(ctx, recovered)
 	}
 	
+	go tm.runRetention(tm.ctx)
+	
 	tm.logger.Info("Task manager started", 
-		"worker_count", tm.workerCount,
+		"worker_count", len(tm.workers),
 		"max_queue_size", tm.maxQueueSize)
 	
 	return nil
@@ -175,57 +267,93 @@ func (tm *taskManager) Stop(ctx context.Context) error {
 		tm.ctx = nil
 	}
 	
-	// Cerrar canal de tareas
-	close(tm.taskQueue)
+	// Cerrar la cola de tareas
+	tm.queue.close()
+	
+	// Esperar a que se guarde lo que los workers ya cambiaron
+	for tm.inflight > 0 {
+		tm.flushed.Wait()
+	}
 	
 	tm.logger.Info("Task manager stopped")
 	return nil
 }
 
-// SubmitTask envía una tarea para ejecución asíncrona
+// SubmitTask envía una tarea para ejecución asíncrona. La tarea se crea en el
+// repositorio antes de tomar el lock, para no bloquear al resto del manager.
 func (tm *taskManager) SubmitTask(ctx context.Context, task *domain.AsyncTask) error {
-	tm.mu.Lock()
-	defer tm.mu.Unlock()
-	
-	if tm.ctx == nil {
+	tm.mu.RLock()
+	started := tm.ctx != nil
+	tm.mu.RUnlock()
+	if !started {
 		return fmt.Errorf("task manager not started")
 	}
 	
 	// Generar ID si no se proporciona
 	if task.ID == "" {
-		task.ID = fmt.Sprintf("task-%d", time.Now().UnixNano())
+		task.ID = id.New()
+	}
+	if err := prepareCallback(task); err != nil {
+		return err
 	}
 	
 	// Establecer timestamps
-	task.CreatedAt = time.Now()
-	task.UpdatedAt = time.Now()
+	task.CreatedAt = tm.clock.Now()
+	task.UpdatedAt = tm.clock.Now()
 	task.Status = domain.TaskStatusPending
+	task.Attempts = 0
+	storeLogFields(ctx, task)
 	
 	// Guardar tarea
+	if err := tm.taskRepo.Create(ctx, task); err != nil {
+		return fmt.Errorf("failed to persist task: %w", err)
+	}
+	
+	tm.mu.Lock()
+	defer tm.flush()
+	defer tm.mu.Unlock()
+	
+	// Si el manager se detuvo mientras tanto, la tarea queda pendiente en el
+	// repositorio y se recupera al arrancar
+	if tm.ctx == nil {
+		return fmt.Errorf("task manager not started")
+	}
+	
 	tm.tasks[task.ID] = task
+	tm.statusChanged(ctx, task)
 	
 	// Actualizar estadísticas
 	tm.stats.TotalTasks++
 	tm.stats.PendingTasks++
 	tm.stats.TasksByType[task.Type]++
 	
+	// Las tareas programadas esperan en un timer hasta su hora
+	if task.ScheduledAt.After(task.CreatedAt) {
+		tm.scheduleDelayed(task)
+		tm.logger.WithContext(ctx).Info("Task scheduled",
+			"task_id", task.ID,
+			"type", task.Type,
+			"scheduled_at", task.ScheduledAt)
+		return nil
+	}
+	
 	// Enviar a la cola
-	select {
-	case tm.taskQueue <- task:
-		tm.logger.Info("Task submitted", 
+	if tm.queue.push(task) {
+		tm.logger.WithContext(ctx).Info("Task submitted", 
 			"task_id", task.ID,
 			"type", task.Type,
 			"priority", task.Priority)
 		return nil
-	default:
-		// Cola llena
-		task.Status = domain.TaskStatusFailed
-		task.Error = "task queue is full"
-		task.CompletedAt = time.Now()
-		tm.stats.PendingTasks--
-		tm.stats.FailedTasks++
-		return fmt.Errorf("task queue is full")
 	}
+	
+	// Cola llena
+	task.Status = domain.TaskStatusFailed
+	task.Error = "task queue is full"
+	task.CompletedAt = tm.clock.Now()
+	tm.stats.PendingTasks--
+	tm.stats.FailedTasks++
+	tm.persist(ctx, task)
+	return ErrTaskQueueFull
 }
 
 // GetTask obtiene una tarea por ID
@@ -235,7 +363,12 @@ func (tm *taskManager) GetTask(ctx context.Context, taskID string) (*domain.Asyn
 	
 	task, exists := tm.tasks[taskID]
 	if !exists {
-		return nil, fmt.Errorf("task not found: %s", taskID)
+		// Buscar en el historial persistido
+		stored, err := tm.taskRepo.GetByID(ctx, taskID)
+		if err != nil {
+			return nil, fmt.Errorf("task not found: %s", taskID)
+		}
+		return stored, nil
 	}
 	
 	// Crear copia para evitar modificaciones concurrentes
@@ -243,59 +376,60 @@ func (tm *taskManager) GetTask(ctx context.Context, taskID string) (*domain.Asyn
 	return &taskCopy, nil
 }
 
-// ListTasks lista tareas con filtros opcionales
+// ListTasks lista tareas con filtros opcionales. El filtrado y la paginación
+// los resuelve el repositorio, sin bloquear a los workers mientras consulta.
 func (tm *taskManager) ListTasks(ctx context.Context, filters *TaskFilters) ([]*domain.AsyncTask, error) {
+	stored, err := tm.taskRepo.List(ctx, taskFilter(filters))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks: %w", err)
+	}
+	
+	// Cada cambio de estado se persiste; la copia en memoria sólo puede ir
+	// por delante si la escritura en el repositorio falló
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
-	
-	var result []*domain.AsyncTask
-	
-	for _, task := range tm.tasks {
-		// Aplicar filtros
-		if filters != nil {
-			if filters.Status != nil && task.Status != *filters.Status {
-				continue
-			}
-			if filters.Type != nil && task.Type != *filters.Type {
-				continue
-			}
-			if filters.UserID != nil && task.UserID != *filters.UserID {
-				continue
-			}
-			if filters.BotID != nil && task.BotID != *filters.BotID {
-				continue
-			}
-			if filters.CreatedAt != nil {
-				if filters.CreatedAt.From != nil && task.CreatedAt.Before(*filters.CreatedAt.From) {
-					continue
-				}
-				if filters.CreatedAt.To != nil && task.CreatedAt.After(*filters.CreatedAt.To) {
-					continue
-				}
-			}
+	for i, task := range stored {
+		if current, exists := tm.tasks[task.ID]; exists {
+			taskCopy := *current
+			stored[i] = &taskCopy
 		}
-		
-		// Crear copia
-		taskCopy := *task
-		result = append(result, &taskCopy)
 	}
 	
-	// Aplicar paginación
-	if filters != nil {
-		if filters.Offset > 0 && filters.Offset < len(result) {
-			result = result[filters.Offset:]
-		}
-		if filters.Limit > 0 && filters.Limit < len(result) {
-			result = result[:filters.Limit]
-		}
+	return stored, nil
+}
+
+// taskFilter traduce los filtros de la API al filtro del repositorio
+func taskFilter(filters *TaskFilters) domain.TaskFilter {
+	var filter domain.TaskFilter
+	if filters == nil {
+		return filter
 	}
-	
-	return result, nil
+	if filters.Status != nil {
+		filter.Status = *filters.Status
+	}
+	if filters.Type != nil {
+		filter.Type = *filters.Type
+	}
+	if filters.UserID != nil {
+		filter.UserID = *filters.UserID
+	}
+	if filters.BotID != nil {
+		filter.BotID = *filters.BotID
+	}
+	if filters.CreatedAt != nil {
+		filter.From = filters.CreatedAt.From
+		filter.To = filters.CreatedAt.To
+	}
+	filter.Limit = filters.Limit
+	filter.Offset = filters.Offset
+	return filter
 }
 
-// CancelTask cancela una tarea
+// CancelTask cancela una tarea. Si está en ejecución se cancela su contexto,
+// lo que corta las llamadas HTTP y a la IA del agente que la ejecuta.
 func (tm *taskManager) CancelTask(ctx context.Context, taskID string) error {
 	tm.mu.Lock()
+	defer tm.flush()
 	defer tm.mu.Unlock()
 	
 	task, exists := tm.tasks[taskID]
@@ -303,35 +437,345 @@ func (tm *taskManager) CancelTask(ctx context.Context, taskID string) error {
 		return fmt.Errorf("task not found: %s", taskID)
 	}
 	
-	if task.Status == domain.TaskStatusCompleted || task.Status == domain.TaskStatusFailed {
-		return fmt.Errorf("cannot cancel completed task")
+	if isTaskFinished(task.Status) {
+		return fmt.Errorf("cannot cancel %s task", task.Status)
 	}
 	
-	task.Status = domain.TaskStatusCancelled
-	task.UpdatedAt = time.Now()
-	task.CompletedAt = time.Now()
-	task.Error = "task cancelled by user"
-	
 	// Actualizar estadísticas
 	if task.Status == domain.TaskStatusPending {
 		tm.stats.PendingTasks--
 	} else if task.Status == domain.TaskStatusRunning {
 		tm.stats.RunningTasks--
 	}
+	
+	task.Status = domain.TaskStatusCancelled
+	task.UpdatedAt = tm.clock.Now()
+	task.CompletedAt = tm.clock.Now()
+	task.Error = "task cancelled by user"
+	
+	if cancel, running := tm.cancels[taskID]; running {
+		cancel()
+	}
 	tm.stats.CancelledTasks++
+	tm.persist(ctx, task)
 	
 	tm.logger.Info("Task cancelled", "task_id", taskID)
 	return nil
 }
 
+// recoverTasks vuelve a encolar las tareas pendientes o interrumpidas leídas
+// del repositorio. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) recoverTasks(ctx context.Context, tasks []*domain.AsyncTask) {
+	// Respetar el orden original de llegada
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+	
+	for _, task := range tasks {
+		if _, exists := tm.tasks[task.ID]; exists {
+			continue
+		}
+		
+		// Las tareas en ejecución al momento de la caída se reinician desde cero
+		task.Status = domain.TaskStatusPending
+		task.StartedAt = time.Time{}
+		task.UpdatedAt = tm.clock.Now()
+		
+		tm.tasks[task.ID] = task
+		tm.stats.TotalTasks++
+		tm.stats.TasksByType[task.Type]++
+		
+		if task.ScheduledAt.After(tm.clock.Now()) {
+			tm.stats.PendingTasks++
+			tm.stats.RecoveredTasks++
+			tm.scheduleDelayed(task)
+			tm.persist(ctx, task)
+			continue
+		}
+		
+		// Un reintento pendiente conserva el backoff que le quedaba
+		if task.NextRetryAt.After(tm.clock.Now()) {
+			tm.stats.PendingTasks++
+			tm.stats.RecoveredTasks++
+			tm.enqueueAfter(task, task.NextRetryAt.Sub(tm.clock.Now()))
+			tm.persist(ctx, task)
+			continue
+		}
+		
+		if tm.queue.push(task) {
+			tm.stats.PendingTasks++
+			tm.stats.RecoveredTasks++
+		} else {
+			task.Status = domain.TaskStatusFailed
+			task.Error = "task queue is full"
+			task.CompletedAt = tm.clock.Now()
+			tm.stats.FailedTasks++
+		}
+		tm.persist(ctx, task)
+	}
+	
+	if len(tasks) > 0 {
+		tm.logger.Info("Tasks recovered", "count", tm.stats.RecoveredTasks)
+	}
+}
+
+// runRetention elimina periódicamente las tareas finalizadas que superan el período de retención
+func (tm *taskManager) runRetention(ctx context.Context) {
+	interval := tm.retention / 24
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	if interval > time.Hour {
+		interval = time.Hour
+	}
+	
+	ticker := tm.clock.NewTicker(interval)
+	defer ticker.Stop()
+	
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			tm.purgeExpiredTasks(ctx)
+		}
+	}
+}
+
+func (tm *taskManager) purgeExpiredTasks(ctx context.Context) {
+	cutoff := tm.clock.Now().Add(-tm.retention)
+	
+	deleted, err := tm.taskRepo.DeleteFinishedBefore(ctx, cutoff)
+	if err != nil {
+		tm.logger.Error("Failed to purge expired tasks", "error", err)
+		return
+	}
+	
+	tm.mu.Lock()
+	for id, task := range tm.tasks {
+		switch task.Status {
+		case domain.TaskStatusCompleted, domain.TaskStatusFailed, domain.TaskStatusCancelled:
+			if task.CompletedAt.Before(cutoff) {
+				delete(tm.tasks, id)
+				tm.writes.forget(id)
+			}
+		}
+	}
+	tm.mu.Unlock()
+	
+	if deleted > 0 {
+		tm.logger.Info("Expired tasks purged", "count", deleted, "retention", tm.retention)
+	}
+}
+
+// scheduleRetry devuelve la tarea a pendiente y la re-encola tras el backoff. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) scheduleRetry(task *domain.AsyncTask) {
+	delay := jitterBackoff(retryBackoff(task.RetryPolicy, task.Attempts), tm.random)
+	
+	task.Status = domain.TaskStatusPending
+	task.CompletedAt = time.Time{}
+	task.NextRetryAt = tm.clock.Now().Add(delay)
+	tm.stats.PendingTasks++
+	tm.stats.RetriedTasks++
+	tm.persist(context.Background(), task)
+	
+	tm.logger.Warn("Task failed, retry scheduled", 
+		"task_id", task.ID,
+		"attempt", task.Attempts,
+		"max_attempts", task.RetryPolicy.MaxAttempts,
+		"delay", delay,
+		"error", task.Error)
+	
+	tm.enqueueAfter(task, delay)
+}
+
+// enqueueAfter re-encola la tarea pendiente cuando vence su backoff. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) enqueueAfter(task *domain.AsyncTask, delay time.Duration) {
+	tm.clock.AfterFunc(delay, func() {
+		tm.mu.Lock()
+		defer tm.flush()
+		defer tm.mu.Unlock()
+		
+		// La tarea pudo cancelarse o el manager detenerse durante la espera
+		if tm.ctx == nil || task.Status != domain.TaskStatusPending {
+			return
+		}
+		
+		if !tm.queue.push(task) {
+			task.Status = domain.TaskStatusFailed
+			task.Error = "task queue is full"
+			task.CompletedAt = tm.clock.Now()
+			tm.stats.PendingTasks--
+			tm.stats.FailedTasks++
+			tm.persist(context.Background(), task)
+			tm.deadLetter(task, "retry could not be enqueued")
+		}
+	})
+}
+
+// publishCompleted publica task_completed en flush. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) publishCompleted(ctx context.Context, task *domain.AsyncTask) {
+	if tm.eventBus == nil {
+		return
+	}
+	
+	event := tm.events.CreateUserEvent(events.EventTypeTaskCompleted, task.UserID, map[string]interface{}{
+		"task_id":        task.ID,
+		"type":           task.Type,
+		"bot_id":         task.BotID,
+		"attempts":       task.Attempts,
+		"execution_time": task.ExecutionTime,
+		"result":         task.Result,
+	})
+	taskID := task.ID
+	tm.later(func() {
+		if err := tm.eventBus.Publish(ctx, event); err != nil {
+			tm.logger.WithContext(ctx).Error("Failed to publish task completed event", "task_id", taskID, "error", err)
+		}
+	})
+}
+
+// deadLetter mueve en flush una tarea fallida a la cola de dead-letter. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) deadLetter(task *domain.AsyncTask, reason string) {
+	taskCopy := *task
+	entry := &domain.DeadLetterTask{
+		ID:             task.ID,
+		Task:           &taskCopy,
+		Reason:         reason,
+		DeadLetteredAt: tm.clock.Now(),
+	}
+	if previous, ok := toFloat(task.Metadata["redrive_count"]); ok {
+		entry.RedriveCount = int(previous)
+	}
+	
+	tm.later(func() {
+		if err := tm.deadLetterRepo.Create(context.Background(), entry); err != nil {
+			tm.logger.Error("Failed to dead-letter task", "task_id", entry.ID, "error", err)
+			return
+		}
+		tm.logger.Warn("Task moved to dead-letter queue", 
+			"task_id", entry.ID,
+			"attempts", entry.Task.Attempts,
+			"reason", reason)
+	})
+}
+
+// ListDeadLetters lista las tareas en dead-letter, de la más reciente a la más antigua
+func (tm *taskManager) ListDeadLetters(ctx context.Context, limit, offset int) ([]*domain.DeadLetterTask, error) {
+	return tm.deadLetterRepo.List(ctx, limit, offset)
+}
+
+// GetDeadLetter obtiene una tarea en dead-letter por ID
+func (tm *taskManager) GetDeadLetter(ctx context.Context, id string) (*domain.DeadLetterTask, error) {
+	return tm.deadLetterRepo.GetByID(ctx, id)
+}
+
+// RedriveDeadLetter vuelve a encolar una tarea en dead-letter con sus intentos reiniciados
+func (tm *taskManager) RedriveDeadLetter(ctx context.Context, id string) (*domain.AsyncTask, error) {
+	entry, err := tm.deadLetterRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	
+	tm.mu.Lock()
+	defer tm.flush()
+	defer tm.mu.Unlock()
+	
+	if tm.ctx == nil {
+		return nil, fmt.Errorf("task manager not started")
+	}
+	
+	task := entry.Task
+	task.Status = domain.TaskStatusPending
+	task.Attempts = 0
+	task.Error = ""
+	task.Result = nil
+	task.NextRetryAt = time.Time{}
+	task.StartedAt = time.Time{}
+	task.CompletedAt = time.Time{}
+	task.UpdatedAt = tm.clock.Now()
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]interface{})
+	}
+	task.Metadata["redrive_count"] = entry.RedriveCount + 1
+	
+	if !tm.queue.push(task) {
+		return nil, ErrTaskQueueFull
+	}
+	
+	tm.tasks[task.ID] = task
+	tm.stats.PendingTasks++
+	tm.persist(ctx, task)
+	tm.later(func() {
+		if err := tm.deadLetterRepo.Delete(ctx, id); err != nil {
+			tm.logger.Error("Failed to remove redriven task from dead-letter queue", "task_id", id, "error", err)
+		}
+	})
+	
+	tm.logger.Info("Dead-lettered task redriven", "task_id", task.ID, "redrive_count", entry.RedriveCount+1)
+	
+	taskCopy := *task
+	return &taskCopy, nil
+}
+
+// DeleteDeadLetter descarta una tarea en dead-letter
+func (tm *taskManager) DeleteDeadLetter(ctx context.Context, id string) error {
+	return tm.deadLetterRepo.Delete(ctx, id)
+}
+
+// PurgeDeadLetters descarta todas las tareas en dead-letter
+func (tm *taskManager) PurgeDeadLetters(ctx context.Context) (int, error) {
+	count, err := tm.deadLetterRepo.DeleteAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge dead-letter queue: %w", err)
+	}
+	
+	tm.logger.Info("Dead-letter queue purged", "count", count)
+	return count, nil
+}
+
+// persist guarda el estado actual de la tarea y avisa del cambio; la escritura
+// y el evento se hacen en flush. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) persist(ctx context.Context, task *domain.AsyncTask) {
+	tm.save(ctx, task)
+	tm.statusChanged(ctx, task)
+}
+
+// publishStatus publica task_status_changed; cada cambio de estado pasa por
+// SubmitTask o persist, que llaman a statusChanged
+func (tm *taskManager) publishStatus(ctx context.Context, task *domain.AsyncTask) {
+	if tm.eventBus == nil {
+		return
+	}
+
+	event := tm.events.CreateUserEvent(events.EventTypeTaskStatusChanged, task.UserID, map[string]interface{}{
+		"task_id":  task.ID,
+		"type":     task.Type,
+		"bot_id":   task.BotID,
+		"status":   task.Status,
+		"attempts": task.Attempts,
+		"error":    task.Error,
+	})
+	if err := tm.eventBus.Publish(ctx, event); err != nil {
+		tm.logger.WithContext(ctx).Error("Failed to publish task status event", "task_id", task.ID, "error", err)
+	}
+}
+
 // GetStats obtiene estadísticas del task manager
 func (tm *taskManager) GetStats() *TaskStats {
+	// Las tareas en dead-letter se cuentan en su repositorio, que sobrevive a los reinicios
+	deadLettered, err := tm.deadLetterRepo.Count(context.Background())
+	if err != nil {
+		tm.logger.Error("Failed to count dead-lettered tasks", "error", err)
+	}
+	
 	tm.mu.RLock()
 	defer tm.mu.RUnlock()
 	
 	// Crear copia de las estadísticas
 	stats := *tm.stats
-	stats.LastUpdated = time.Now()
+	stats.LastUpdated = tm.clock.Now()
+	stats.DeadLettered = int64(deadLettered)
 	
 	// Copiar mapas
 	stats.TasksByType = make(map[string]int64)
@@ -339,6 +783,8 @@ func (tm *taskManager) GetStats() *TaskStats {
 		stats.TasksByType[k] = v
 	}
 	
+	stats.TypeLimits, stats.RunningByType = tm.queue.snapshot()
+	
 	stats.WorkerStats = make(map[string]*WorkerStats)
 	for k, v := range tm.stats.WorkerStats {
 		workerStats := *v
@@ -348,41 +794,75 @@ func (tm *taskManager) GetStats() *TaskStats {
 	return &stats
 }
 
+// QueueLoad devuelve las tareas en cola y la capacidad de la cola
+func (tm *taskManager) QueueLoad() (depth, capacity int) {
+	return tm.queue.len(), tm.maxQueueSize
+}
+
+// LimitConcurrency fija cuántas tareas del tipo se ejecutan a la vez; el resto
+// espera en la cola sin bloquear a los demás tipos. 0 quita el límite.
+func (tm *taskManager) LimitConcurrency(taskType string, max int) error {
+	if taskType == "" || max < 0 {
+		return fmt.Errorf("task type and a non-negative limit are required")
+	}
+	tm.queue.setLimit(taskType, max)
+	return nil
+}
+
 // run ejecuta el loop principal del worker
 func (w *taskWorker) run(ctx context.Context) {
 	w.logger.Info("Task worker started", "worker_id", w.id)
 	
 	for {
-		select {
-		case <-ctx.Done():
+		task, ok := w.manager.queue.pop(w.quit)
+		if !ok {
 			w.logger.Info("Task worker stopped", "worker_id", w.id)
 			return
-		case task, ok := <-w.manager.taskQueue:
-			if !ok {
-				w.logger.Info("Task worker stopped - queue closed", "worker_id", w.id)
-				return
-			}
-			
-			w.executeTask(ctx, task)
 		}
+		
+		w.executeTask(ctx, task)
+		w.manager.queue.done(task.Type)
 	}
 }
 
 // executeTask ejecuta una tarea
 func (w *taskWorker) executeTask(ctx context.Context, task *domain.AsyncTask) {
-	start := time.Now()
+	start := w.manager.clock.Now()
+	ctx = taskLogContext(ctx, task)
+	log := w.logger.WithContext(ctx)
+	
+	// Actualizar estado de la tarea; la que se canceló mientras esperaba en la
+	// cola ya no se ejecuta
+	w.manager.mu.Lock()
+	if task.Status != domain.TaskStatusPending {
+		w.manager.mu.Unlock()
+		log.Info("Skipping task that is no longer pending", "task_id", task.ID, "status", task.Status)
+		return
+	}
+	task.Status = domain.TaskStatusRunning
+	task.UpdatedAt = w.manager.clock.Now()
+	task.StartedAt = w.manager.clock.Now()
+	task.Attempts++
+	w.manager.stats.PendingTasks--
+	w.manager.stats.RunningTasks++
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	w.manager.cancels[task.ID] = cancel
+	w.manager.persist(ctx, task)
+	w.manager.mu.Unlock()
+	w.manager.flush()
 	
 	w.mu.Lock()
 	w.stats.Status = "busy"
 	w.stats.LastTask = &task.ID
-	w.stats.LastActivity = time.Now()
+	w.stats.LastActivity = w.manager.clock.Now()
 	w.mu.Unlock()
 	
 	defer func() {
 		w.mu.Lock()
 		w.stats.Status = "idle"
 		w.stats.TasksExecuted++
-		duration := time.Since(start)
+		duration := w.manager.clock.Since(start)
 		if w.stats.TasksExecuted == 1 {
 			w.stats.AverageTime = duration
 		} else {
@@ -391,59 +871,84 @@ func (w *taskWorker) executeTask(ctx context.Context, task *domain.AsyncTask) {
 		w.mu.Unlock()
 	}()
 	
-	w.logger.Info("Executing task", 
+	log.Info("Executing task", 
 		"worker_id", w.id,
 		"task_id", task.ID,
 		"type", task.Type)
 	
-	// Actualizar estado de la tarea
-	w.manager.mu.Lock()
-	task.Status = domain.TaskStatusRunning
-	task.UpdatedAt = time.Now()
-	task.StartedAt = time.Now()
-	w.manager.stats.PendingTasks--
-	w.manager.stats.RunningTasks++
-	w.manager.mu.Unlock()
-	
-	// Crear tarea MCP
-	mcpTask := &domain.MCPTask{
-		ID:          task.ID,
-		Type:        task.Type,
-		Description: task.Description,
-		Input:       task.Input,
-		Priority:    task.Priority,
-		Timeout:     task.Timeout,
-		Context:     task.Context,
-		Metadata:    task.Metadata,
-		CreatedAt:   task.CreatedAt,
+	var result *domain.MCPTaskResult
+	var err error
+	if handler, ok := w.manager.handler(task.Type); ok {
+		// Los tipos con handler propio se ejecutan en el servicio, sin pasar por MCP
+		result, err = runTaskHandler(ctx, w.manager.clock, handler, task)
+	} else {
+		// Crear tarea MCP
+		mcpTask := &domain.MCPTask{
+			ID:          task.ID,
+			Type:        task.Type,
+			Description: task.Description,
+			Input:       task.Input,
+			Priority:    task.Priority,
+			Timeout:     task.Timeout,
+			Context:     task.Context,
+			Metadata:    task.Metadata,
+			CreatedAt:   task.CreatedAt,
+		}
+		
+		// Ejecutar usando MCP
+		result, err = w.manager.mcpOrchestrator.ExecuteTaskDomain(ctx, mcpTask)
 	}
 	
-	// Ejecutar usando MCP
-	result, err := w.manager.mcpOrchestrator.ExecuteTaskDomain(ctx, mcpTask)
-	
-	duration := time.Since(start)
+	duration := w.manager.clock.Since(start)
 	
 	// Actualizar tarea con resultado
 	w.manager.mu.Lock()
-	task.UpdatedAt = time.Now()
-	task.CompletedAt = time.Now()
+	delete(w.manager.cancels, task.ID)
+	if task.Status == domain.TaskStatusCancelled {
+		w.manager.finishCancelled(task, result, duration)
+		w.manager.mu.Unlock()
+		w.manager.flush()
+		return
+	}
+	task.UpdatedAt = w.manager.clock.Now()
+	task.CompletedAt = w.manager.clock.Now()
 	task.ExecutionTime = duration.Milliseconds()
 	w.manager.stats.RunningTasks--
 	
 	if err != nil || !result.Success {
-		task.Status = domain.TaskStatusFailed
 		if err != nil {
 			task.Error = err.Error()
 		} else {
 			task.Error = result.Error
 		}
+		failure := err
+		if failure == nil {
+			failure = resultError(result)
+		}
+		task.ErrorHistory = append(task.ErrorHistory, domain.TaskAttemptError{
+			Attempt:    task.Attempts,
+			Error:      task.Error,
+			Class:      classifyTaskError(failure),
+			OccurredAt: w.manager.clock.Now(),
+		})
+		
+		if shouldRetry(task, failure) {
+			w.manager.scheduleRetry(task)
+			w.manager.mu.Unlock()
+			w.manager.flush()
+			return
+		}
+		
+		task.Status = domain.TaskStatusFailed
 		task.Result = map[string]interface{}{
-			"success": false,
-			"error":   task.Error,
+			"success":  false,
+			"error":    task.Error,
+			"attempts": task.Attempts,
 		}
 		w.manager.stats.FailedTasks++
+		w.manager.deadLetter(task, "execution failed")
 		
-		w.logger.Error("Task execution failed", 
+		log.Error("Task execution failed", 
 			"worker_id", w.id,
 			"task_id", task.ID,
 			"duration", duration,
@@ -455,14 +960,16 @@ func (w *taskWorker) executeTask(ctx context.Context, task *domain.AsyncTask) {
 			"output":         result.Output,
 			"agent_id":       result.AgentID,
 			"execution_time": result.ExecutionTime,
+			"attempts":       task.Attempts,
 		}
 		w.manager.stats.CompletedTasks++
 		
-		w.logger.Info("Task execution completed", 
+		log.Info("Task execution completed", 
 			"worker_id", w.id,
 			"task_id", task.ID,
 			"duration", duration,
 			"agent_id", result.AgentID)
+		w.manager.publishCompleted(ctx, task)
 	}
 	
 	// Actualizar tiempo promedio
@@ -473,5 +980,7 @@ func (w *taskWorker) executeTask(ctx context.Context, task *domain.AsyncTask) {
 		w.manager.stats.AverageTime = (w.manager.stats.AverageTime + duration) / 2
 	}
 	
+	w.manager.persist(context.Background(), task)
 	w.manager.mu.Unlock()
+	w.manager.flush()
 }
\ No newline at end of file
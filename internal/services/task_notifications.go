@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// Eventos de las notificaciones de fin de tarea, en X-Webhook-Event
+const (
+	TaskCallbackCompleted = "task.completed"
+	TaskCallbackFailed    = "task.failed"
+	TaskCallbackCancelled = "task.cancelled"
+)
+
+const (
+	taskCallbackTimeout     = 10 * time.Second
+	taskCallbackMaxAttempts = 5
+	// taskWatchBuffer son los cambios de estado que puede acumular un watcher lento
+	taskWatchBuffer = 16
+)
+
+// ErrInvalidTaskCallback indica una callback_url o callback_secret no válidos
+var ErrInvalidTaskCallback = errors.New("invalid task callback")
+
+// taskCallbackRetry espacia los reintentos de las notificaciones
+var taskCallbackRetry = domain.RetryPolicy{InitialBackoff: 1000, MaxBackoff: 60000}
+
+// taskCallbackPayload es el cuerpo que recibe la callback_url
+type taskCallbackPayload struct {
+	Event         string                 `json:"event"`
+	TaskID        string                 `json:"task_id"`
+	Type          string                 `json:"type"`
+	BotID         string                 `json:"bot_id,omitempty"`
+	UserID        string                 `json:"user_id,omitempty"`
+	Status        domain.TaskStatus      `json:"status"`
+	Attempts      int                    `json:"attempts"`
+	Result        map[string]interface{} `json:"result,omitempty"`
+	Error         string                 `json:"error,omitempty"`
+	ExecutionTime int64                  `json:"execution_time,omitempty"`
+	CompletedAt   time.Time              `json:"completed_at"`
+}
+
+// isTaskFinished indica si la tarea ya no cambiará de estado
+func isTaskFinished(status domain.TaskStatus) bool {
+	switch status {
+	case domain.TaskStatusCompleted, domain.TaskStatusFailed, domain.TaskStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// prepareCallback valida la callback_url y genera el secreto de firma si el
+// cliente no lo indica
+func prepareCallback(task *domain.AsyncTask) error {
+	if task.CallbackURL == "" {
+		task.CallbackSecret = ""
+		return nil
+	}
+	target, err := url.Parse(task.CallbackURL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: callback_url must be an absolute http(s) URL", ErrInvalidTaskCallback)
+	}
+	if task.CallbackSecret == "" {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return err
+		}
+		task.CallbackSecret = secret
+	} else if len(task.CallbackSecret) < 16 {
+		return fmt.Errorf("%w: callback_secret must be at least 16 characters", ErrInvalidTaskCallback)
+	}
+	return nil
+}
+
+// statusChanged avisa de un cambio de estado: evento en el bus (en flush),
+// watchers del stream SSE y, al terminar, la callback_url. Debe llamarse con
+// tm.mu tomado.
+func (tm *taskManager) statusChanged(ctx context.Context, task *domain.AsyncTask) {
+	if tm.eventBus != nil {
+		snapshot := *task
+		tm.ordered(task.ID, func() {
+			tm.publishStatus(ctx, &snapshot)
+		})
+	}
+	tm.notifyWatchers(task)
+	if isTaskFinished(task.Status) && task.CallbackURL != "" {
+		tm.sendCallback(task)
+	}
+}
+
+// WatchTask devuelve los estados por los que pasa la tarea, empezando por el
+// actual. El canal se cierra cuando la tarea termina o se cancela ctx.
+func (tm *taskManager) WatchTask(ctx context.Context, taskID string) (<-chan *domain.AsyncTask, error) {
+	current, err := tm.GetTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := make(chan *domain.AsyncTask, taskWatchBuffer)
+	updates <- current
+	if isTaskFinished(current.Status) {
+		close(updates)
+		return updates, nil
+	}
+
+	tm.mu.Lock()
+	// La tarea pudo terminar entre la lectura y el registro
+	if task, exists := tm.tasks[taskID]; exists && task.Status != current.Status {
+		taskCopy := *task
+		updates <- &taskCopy
+		if isTaskFinished(task.Status) {
+			tm.mu.Unlock()
+			close(updates)
+			return updates, nil
+		}
+	}
+	watcherID := tm.nextWatcher
+	tm.nextWatcher++
+	if tm.watchers[taskID] == nil {
+		tm.watchers[taskID] = make(map[int]chan *domain.AsyncTask)
+	}
+	tm.watchers[taskID][watcherID] = updates
+	tm.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		tm.mu.Lock()
+		defer tm.mu.Unlock()
+		if watcher, exists := tm.watchers[taskID][watcherID]; exists {
+			delete(tm.watchers[taskID], watcherID)
+			if len(tm.watchers[taskID]) == 0 {
+				delete(tm.watchers, taskID)
+			}
+			close(watcher)
+		}
+	}()
+	return updates, nil
+}
+
+// notifyWatchers envía una copia de la tarea a sus watchers y los cierra
+// cuando termina. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) notifyWatchers(task *domain.AsyncTask) {
+	watchers := tm.watchers[task.ID]
+	if len(watchers) == 0 {
+		return
+	}
+	finished := isTaskFinished(task.Status)
+	for _, watcher := range watchers {
+		taskCopy := *task
+		select {
+		case watcher <- &taskCopy:
+		default:
+			tm.logger.Warn("Task watcher is not keeping up, status dropped", "task_id", task.ID, "status", task.Status)
+		}
+		if finished {
+			close(watcher)
+		}
+	}
+	if finished {
+		delete(tm.watchers, task.ID)
+	}
+}
+
+// sendCallback firma el estado final de la tarea y lo entrega en segundo
+// plano. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) sendCallback(task *domain.AsyncTask) {
+	event := TaskCallbackCompleted
+	switch task.Status {
+	case domain.TaskStatusFailed:
+		event = TaskCallbackFailed
+	case domain.TaskStatusCancelled:
+		event = TaskCallbackCancelled
+	}
+	body, err := json.Marshal(taskCallbackPayload{
+		Event:         event,
+		TaskID:        task.ID,
+		Type:          task.Type,
+		BotID:         task.BotID,
+		UserID:        task.UserID,
+		Status:        task.Status,
+		Attempts:      task.Attempts,
+		Result:        task.Result,
+		Error:         task.Error,
+		ExecutionTime: task.ExecutionTime,
+		CompletedAt:   task.CompletedAt,
+	})
+	if err != nil {
+		tm.logger.Error("Failed to encode task callback", "task_id", task.ID, "error", err)
+		return
+	}
+	go tm.deliverCallback(task.ID, task.CallbackURL, task.CallbackSecret, event, body, 1)
+}
+
+// deliverCallback hace un intento de entrega. Como en los webhooks, los
+// errores de red, 408, 429 y 5xx se reintentan con backoff exponencial.
+func (tm *taskManager) deliverCallback(taskID, callbackURL, secret, event string, body []byte, attempt int) {
+	status, err := tm.postCallback(callbackURL, secret, event, body)
+	if err == nil && status >= 200 && status < 300 {
+		tm.logger.Debug("Task callback delivered", "task_id", taskID, "event", event, "attempts", attempt)
+		return
+	}
+	if err == nil {
+		err = fmt.Errorf("unexpected response status %d", status)
+	}
+	retryable := status == 0 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+	if !retryable || attempt >= taskCallbackMaxAttempts {
+		tm.logger.Warn("Task callback failed", "task_id", taskID, "event", event, "attempts", attempt, "error", err)
+		return
+	}
+	tm.clock.AfterFunc(retryBackoff(&taskCallbackRetry, attempt), func() {
+		tm.deliverCallback(taskID, callbackURL, secret, event, body, attempt+1)
+	})
+}
+
+func (tm *taskManager) postCallback(callbackURL, secret, event string, body []byte) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), taskCallbackTimeout)
+	defer cancel()
+
+	timestamp := strconv.FormatInt(tm.clock.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callbackURL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookHeaderEvent, event)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(secret, timestamp, body))
+
+	resp, err := tm.callbackClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseBodyLimit))
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskNotifications_CallbackAndWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type delivery struct {
+		header http.Header
+		body   []byte
+	}
+	deliveries := make(chan delivery, 4)
+	attempts := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		deliveries <- delivery{header: r.Header, body: body}
+	}))
+	defer receiver.Close()
+
+	manager := NewTaskManager(repositories.NewMockTaskRepository(), repositories.NewMockDeadLetterRepository(),
+		nil, nil, logger.NewLogger("error"), 1, 10, 0, nil)
+	release := make(chan struct{})
+	require.NoError(t, manager.RegisterHandler("report", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		<-release
+		if task.Input["fail"] == true {
+			return nil, errors.New("report source unavailable")
+		}
+		return map[string]interface{}{"rows": 42}, nil
+	}))
+	require.NoError(t, manager.Start(ctx))
+
+	err := manager.SubmitTask(ctx, &domain.AsyncTask{Type: "report", CallbackURL: "ftp://example.com/hook"})
+	assert.ErrorIs(t, err, ErrInvalidTaskCallback)
+
+	task := &domain.AsyncTask{Type: "report", CallbackURL: receiver.URL}
+	require.NoError(t, manager.SubmitTask(ctx, task))
+	require.NotEmpty(t, task.CallbackSecret)
+
+	updates, err := manager.WatchTask(ctx, task.ID)
+	require.NoError(t, err)
+	close(release)
+
+	var statuses []domain.TaskStatus
+	for update := range updates {
+		statuses = append(statuses, update.Status)
+	}
+	assert.Equal(t, domain.TaskStatusCompleted, statuses[len(statuses)-1])
+	assert.Contains(t, statuses, domain.TaskStatusRunning)
+
+	// El primer intento recibe un 503 y la notificación se reintenta
+	select {
+	case got := <-deliveries:
+		assert.Equal(t, TaskCallbackCompleted, got.header.Get(WebhookHeaderEvent))
+		assert.Equal(t, SignWebhookPayload(task.CallbackSecret, got.header.Get(WebhookHeaderTimestamp), got.body), got.header.Get(WebhookHeaderSignature))
+		var payload map[string]interface{}
+		require.NoError(t, json.Unmarshal(got.body, &payload))
+		assert.Equal(t, task.ID, payload["task_id"])
+		assert.Equal(t, "completed", payload["status"])
+		assert.Equal(t, float64(42), payload["result"].(map[string]interface{})["output"].(map[string]interface{})["rows"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("task callback was not delivered")
+	}
+
+	// Una tarea ya terminada se emite una vez y el canal se cierra
+	updates, err = manager.WatchTask(ctx, task.ID)
+	require.NoError(t, err)
+	final, ok := <-updates
+	require.True(t, ok)
+	assert.Equal(t, domain.TaskStatusCompleted, final.Status)
+	_, ok = <-updates
+	assert.False(t, ok)
+
+	failing := &domain.AsyncTask{Type: "report", Input: map[string]interface{}{"fail": true}, CallbackURL: receiver.URL, CallbackSecret: "my-own-signing-secret"}
+	require.NoError(t, manager.SubmitTask(ctx, failing))
+	select {
+	case got := <-deliveries:
+		assert.Equal(t, TaskCallbackFailed, got.header.Get(WebhookHeaderEvent))
+		assert.Equal(t, SignWebhookPayload("my-own-signing-secret", got.header.Get(WebhookHeaderTimestamp), got.body), got.header.Get(WebhookHeaderSignature))
+		assert.Contains(t, string(got.body), "report source unavailable")
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed task callback was not delivered")
+	}
+
+	_, err = manager.WatchTask(ctx, "missing")
+	assert.Error(t, err)
+}
@@ -0,0 +1,192 @@
+package services
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/kvstore"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openTaskStore(t *testing.T, path string) (*kvstore.Store, domain.TaskRepository) {
+	t.Helper()
+	store, err := kvstore.Open(path)
+	require.NoError(t, err)
+	repo, err := repositories.NewEmbeddedTaskRepository(store)
+	require.NoError(t, err)
+	return store, repo
+}
+
+func TestTaskManager_RecoversTasksAfterRestart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "tasks.db")
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+
+	// Estado que deja una caída: una tarea a medio ejecutar, una pendiente,
+	// una programada para más tarde y una ya terminada
+	store, repo := openTaskStore(t, path)
+	for _, task := range []*domain.AsyncTask{
+		{ID: "running", Type: "notify", Status: domain.TaskStatusRunning, CreatedAt: clk.Now().Add(-2 * time.Minute), StartedAt: clk.Now()},
+		{ID: "pending", Type: "notify", Status: domain.TaskStatusPending, CreatedAt: clk.Now().Add(-time.Minute)},
+		{ID: "scheduled", Type: "notify", Status: domain.TaskStatusPending, CreatedAt: clk.Now(), ScheduledAt: clk.Now().Add(time.Hour)},
+		{ID: "done", Type: "notify", Status: domain.TaskStatusCompleted, CreatedAt: clk.Now().Add(-time.Hour), CompletedAt: clk.Now()},
+	} {
+		require.NoError(t, repo.Create(ctx, task))
+	}
+	require.NoError(t, store.Close())
+
+	store, repo = openTaskStore(t, path)
+	var mu sync.Mutex
+	var executed []string
+	manager := NewTaskManager(repo, repositories.NewMockDeadLetterRepository(), nil, nil,
+		logger.NewLogger("error"), 1, 10, 0, clk)
+	require.NoError(t, manager.RegisterHandler("notify", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		executed = append(executed, task.ID)
+		return map[string]interface{}{"ok": true}, nil
+	}))
+	require.NoError(t, manager.Start(ctx))
+
+	completed := func(id string) func() bool {
+		return func() bool {
+			task, err := manager.GetTask(ctx, id)
+			return err == nil && task.Status == domain.TaskStatusCompleted
+		}
+	}
+	require.Eventually(t, completed("pending"), 2*time.Second, 5*time.Millisecond)
+	require.Eventually(t, completed("running"), 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, int64(3), manager.GetStats().RecoveredTasks)
+
+	// La programada espera a su hora también tras el reinicio
+	task, err := manager.GetTask(ctx, "scheduled")
+	require.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusPending, task.Status)
+	clk.Advance(time.Hour)
+	require.Eventually(t, completed("scheduled"), 2*time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []string{"running", "pending", "scheduled"}, executed)
+	mu.Unlock()
+	require.NoError(t, manager.Stop(ctx))
+	require.NoError(t, store.Close())
+
+	// Los resultados quedan en el historial persistido
+	store, repo = openTaskStore(t, path)
+	defer store.Close()
+	history, err := repo.List(ctx, domain.TaskFilter{Status: domain.TaskStatusCompleted})
+	require.NoError(t, err)
+	assert.Len(t, history, 4)
+	stored, err := repo.GetByID(ctx, "running")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ok": true}, stored.Result["output"])
+}
+
+func TestTaskManager_RetentionAndHistory(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	repo := repositories.NewMockTaskRepository()
+	manager := NewTaskManager(repo, repositories.NewMockDeadLetterRepository(), nil, nil,
+		logger.NewLogger("error"), 1, 10, 24*time.Hour, clk)
+
+	for _, task := range []*domain.AsyncTask{
+		{ID: "expired", BotID: "bot-1", Status: domain.TaskStatusCompleted, CreatedAt: clk.Now().Add(-50 * time.Hour), CompletedAt: clk.Now().Add(-48 * time.Hour)},
+		{ID: "failed-expired", BotID: "bot-1", Status: domain.TaskStatusFailed, CreatedAt: clk.Now().Add(-31 * time.Hour), CompletedAt: clk.Now().Add(-30 * time.Hour)},
+		{ID: "recent", BotID: "bot-1", Status: domain.TaskStatusCompleted, CreatedAt: clk.Now().Add(-2 * time.Hour), CompletedAt: clk.Now().Add(-time.Hour)},
+		{ID: "old-pending", BotID: "bot-2", Status: domain.TaskStatusPending, CreatedAt: clk.Now().Add(-72 * time.Hour)},
+	} {
+		require.NoError(t, repo.Create(ctx, task))
+	}
+
+	manager.(*taskManager).purgeExpiredTasks(ctx)
+
+	tasks, err := manager.ListTasks(ctx, nil)
+	require.NoError(t, err)
+	ids := make([]string, 0, len(tasks))
+	for _, task := range tasks {
+		ids = append(ids, task.ID)
+	}
+	// Las pendientes nunca caducan, por antiguas que sean
+	assert.Equal(t, []string{"recent", "old-pending"}, ids)
+
+	botID := "bot-1"
+	status := domain.TaskStatusCompleted
+	tasks, err = manager.ListTasks(ctx, &TaskFilters{BotID: &botID, Status: &status, Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, tasks, 1)
+	assert.Equal(t, "recent", tasks[0].ID)
+
+	// Un día después caduca también la reciente
+	clk.Advance(24 * time.Hour)
+	manager.(*taskManager).purgeExpiredTasks(ctx)
+	tasks, err = manager.ListTasks(ctx, &TaskFilters{BotID: &botID})
+	require.NoError(t, err)
+	assert.Empty(t, tasks)
+}
+
+// blockingTaskRepository retiene los Update hasta que se cierre release
+type blockingTaskRepository struct {
+	domain.TaskRepository
+	updating chan struct{}
+	release  chan struct{}
+}
+
+func (r *blockingTaskRepository) Update(ctx context.Context, task *domain.AsyncTask) error {
+	select {
+	case r.updating <- struct{}{}:
+	default:
+	}
+	<-r.release
+	return r.TaskRepository.Update(ctx, task)
+}
+
+func TestTaskManager_SlowRepositoryDoesNotBlockReads(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repo := &blockingTaskRepository{
+		TaskRepository: repositories.NewMockTaskRepository(),
+		updating:       make(chan struct{}, 1),
+		release:        make(chan struct{}),
+	}
+	manager := NewTaskManager(repo, repositories.NewMockDeadLetterRepository(), nil, nil,
+		logger.NewLogger("error"), 1, 10, 0, clock.Real())
+	require.NoError(t, manager.RegisterHandler("notify", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}))
+	require.NoError(t, manager.Start(ctx))
+
+	first := &domain.AsyncTask{Type: "notify"}
+	require.NoError(t, manager.SubmitTask(ctx, first))
+	<-repo.updating
+
+	// Con un Update colgado el manager sigue respondiendo y aceptando tareas
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := manager.GetTask(ctx, first.ID)
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, manager.GetStats().TotalTasks)
+		assert.NoError(t, manager.SubmitTask(ctx, &domain.AsyncTask{Type: "notify"}))
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task manager blocked behind a slow repository write")
+	}
+
+	close(repo.release)
+	require.Eventually(t, func() bool {
+		stored, err := repo.TaskRepository.GetByID(ctx, first.ID)
+		return err == nil && stored.Status == domain.TaskStatusCompleted
+	}, 2*time.Second, 5*time.Millisecond)
+	require.NoError(t, manager.Stop(ctx))
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// taskQueue es la cola de tareas pendientes del task manager. Sale primero la
+// de mayor Priority y, a igualdad, la que llegó antes. Cuando un tipo alcanza
+// su límite de concurrencia sus tareas esperan en la cola sin bloquear las de
+// otros tipos.
+type taskQueue struct {
+	mu       sync.Mutex
+	items    taskHeap
+	capacity int
+	seq      uint64
+	limits   map[string]int
+	running  map[string]int
+	// wake se cierra y se renueva con cada cambio para despertar a los workers
+	wake   chan struct{}
+	closed bool
+}
+
+type queuedTask struct {
+	task *domain.AsyncTask
+	seq  uint64
+}
+
+// taskHeap implementa heap.Interface ordenando por prioridad y llegada
+type taskHeap []queuedTask
+
+func (h taskHeap) Len() int { return len(h) }
+func (h taskHeap) Less(i, j int) bool {
+	if h[i].task.Priority != h[j].task.Priority {
+		return h[i].task.Priority > h[j].task.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+func (h taskHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *taskHeap) Push(x interface{}) { *h = append(*h, x.(queuedTask)) }
+func (h *taskHeap) Pop() interface{} {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+func newTaskQueue(capacity int) *taskQueue {
+	return &taskQueue{
+		capacity: capacity,
+		limits:   make(map[string]int),
+		running:  make(map[string]int),
+		wake:     make(chan struct{}),
+	}
+}
+
+// ParseTaskConcurrency lee las entradas tipo=máximo de TASK_TYPE_CONCURRENCY
+func ParseTaskConcurrency(entries []string) (map[string]int, error) {
+	limits := make(map[string]int, len(entries))
+	for _, entry := range entries {
+		taskType, value, ok := strings.Cut(entry, "=")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || strings.TrimSpace(taskType) == "" || err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid task concurrency %q: expected type=max with max > 0", entry)
+		}
+		limits[strings.TrimSpace(taskType)] = limit
+	}
+	return limits, nil
+}
+
+// push añade la tarea; devuelve false si la cola está llena o cerrada
+func (q *taskQueue) push(task *domain.AsyncTask) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || q.items.Len() >= q.capacity {
+		return false
+	}
+	q.seq++
+	heap.Push(&q.items, queuedTask{task: task, seq: q.seq})
+	q.broadcast()
+	return true
+}
+
+// pop espera la siguiente tarea que se puede ejecutar y la cuenta como en
+// ejecución hasta que se llama a done. Devuelve false al cerrarse la cola o
+// cancelarse ctx.
+func (q *taskQueue) pop(ctx context.Context) (*domain.AsyncTask, bool) {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return nil, false
+		}
+		if task := q.next(); task != nil {
+			q.mu.Unlock()
+			return task, true
+		}
+		wake := q.wake
+		q.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
+
+// next saca la tarea más prioritaria cuyo tipo no está en su límite. Debe
+// llamarse con q.mu tomado.
+func (q *taskQueue) next() *domain.AsyncTask {
+	var skipped []queuedTask
+	defer func() {
+		for _, item := range skipped {
+			heap.Push(&q.items, item)
+		}
+	}()
+
+	for q.items.Len() > 0 {
+		item := heap.Pop(&q.items).(queuedTask)
+		taskType := item.task.Type
+		if limit, limited := q.limits[taskType]; limited && q.running[taskType] >= limit {
+			skipped = append(skipped, item)
+			continue
+		}
+		q.running[taskType]++
+		return item.task
+	}
+	return nil
+}
+
+// done libera el hueco de concurrencia de una tarea devuelta por pop
+func (q *taskQueue) done(taskType string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.running[taskType] <= 1 {
+		delete(q.running, taskType)
+	} else {
+		q.running[taskType]--
+	}
+	q.broadcast()
+}
+
+// setLimit fija cuántas tareas del tipo se ejecutan a la vez; 0 quita el límite
+func (q *taskQueue) setLimit(taskType string, limit int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if limit <= 0 {
+		delete(q.limits, taskType)
+	} else {
+		q.limits[taskType] = limit
+	}
+	q.broadcast()
+}
+
+func (q *taskQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.broadcast()
+}
+
+func (q *taskQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+// snapshot copia los límites y las tareas en ejecución por tipo
+func (q *taskQueue) snapshot() (limits, running map[string]int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	limits = make(map[string]int, len(q.limits))
+	for taskType, limit := range q.limits {
+		limits[taskType] = limit
+	}
+	running = make(map[string]int, len(q.running))
+	for taskType, count := range q.running {
+		running[taskType] = count
+	}
+	return limits, running
+}
+
+// broadcast despierta a los workers que esperan en pop. Debe llamarse con q.mu
+// tomado.
+func (q *taskQueue) broadcast() {
+	close(q.wake)
+	q.wake = make(chan struct{})
+}
@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskQueue_PriorityAndTypeLimits(t *testing.T) {
+	ctx := context.Background()
+	queue := newTaskQueue(10)
+	queue.setLimit("text_generation", 1)
+
+	for _, task := range []*domain.AsyncTask{
+		{ID: "slow-1", Type: "text_generation", Priority: 5},
+		{ID: "slow-2", Type: "text_generation", Priority: 5},
+		{ID: "cheap-low", Type: "notify", Priority: 0},
+		{ID: "cheap-high", Type: "notify", Priority: 9},
+	} {
+		require.True(t, queue.push(task))
+	}
+
+	var order []string
+	for i := 0; i < 3; i++ {
+		task, ok := queue.pop(ctx)
+		require.True(t, ok)
+		order = append(order, task.ID)
+	}
+	// slow-2 espera a que termine slow-1 sin bloquear las tareas baratas
+	assert.Equal(t, []string{"cheap-high", "slow-1", "cheap-low"}, order)
+	assert.Equal(t, 1, queue.len())
+
+	limits, running := queue.snapshot()
+	assert.Equal(t, map[string]int{"text_generation": 1}, limits)
+	assert.Equal(t, 1, running["text_generation"])
+
+	blocked, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, ok := queue.pop(blocked)
+	assert.False(t, ok)
+
+	queue.done("text_generation")
+	task, ok := queue.pop(ctx)
+	require.True(t, ok)
+	assert.Equal(t, "slow-2", task.ID)
+
+	full := newTaskQueue(1)
+	assert.True(t, full.push(&domain.AsyncTask{ID: "a"}))
+	assert.False(t, full.push(&domain.AsyncTask{ID: "b"}))
+	full.close()
+	_, ok = full.pop(ctx)
+	assert.False(t, ok)
+}
+
+func TestTaskManager_LimitConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager := NewTaskManager(repositories.NewMockTaskRepository(), repositories.NewMockDeadLetterRepository(),
+		nil, nil, logger.NewLogger("error"), 3, 10, 0, nil)
+	require.NoError(t, manager.LimitConcurrency("text_generation", 1))
+	assert.Error(t, manager.LimitConcurrency("", 1))
+
+	release := make(chan struct{})
+	started := make(chan string, 4)
+	handler := func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		started <- task.ID
+		if task.Type == "text_generation" {
+			<-release
+		}
+		return nil, nil
+	}
+	require.NoError(t, manager.RegisterHandler("text_generation", handler))
+	require.NoError(t, manager.RegisterHandler("notify", handler))
+	require.NoError(t, manager.Start(ctx))
+
+	for _, task := range []*domain.AsyncTask{
+		{ID: "gen-1", Type: "text_generation"},
+		{ID: "gen-2", Type: "text_generation"},
+		{ID: "notify-1", Type: "notify"},
+	} {
+		require.NoError(t, manager.SubmitTask(ctx, task))
+	}
+
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case id := <-started:
+			seen[id] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("tasks did not start: %v", seen)
+		}
+	}
+	// Hay tres workers, pero solo una generación puede estar en marcha
+	assert.True(t, seen["notify-1"])
+	select {
+	case id := <-started:
+		t.Fatalf("task %s started past the concurrency limit", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	stats := manager.GetStats()
+	assert.Equal(t, 1, stats.TypeLimits["text_generation"])
+	assert.Equal(t, 1, stats.RunningByType["text_generation"])
+
+	close(release)
+	select {
+	case id := <-started:
+		seen[id] = true
+	case <-time.After(2 * time.Second):
+		t.Fatal("queued task did not start after the slot was released")
+	}
+	assert.Len(t, seen, 3)
+}
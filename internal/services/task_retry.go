@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+)
+
+// transientTaskErrors son las clases reintentables cuando la política no especifica ninguna
+var transientTaskErrors = []string{
+	domain.TaskErrorTimeout,
+	domain.TaskErrorNetwork,
+	domain.TaskErrorRateLimit,
+	domain.TaskErrorServer,
+	domain.TaskErrorAgentUnavailable,
+}
+
+// classifyTaskError asigna una clase al error de ejecución según su tipo: los
+// plazos vencidos, los errores de red, las respuestas de la API por código de
+// estado y la falta de agentes. Lo que no se reconoce es permanente.
+func classifyTaskError(err error) string {
+	var apiErr *mcp.APIError
+	var netErr net.Error
+
+	switch {
+	case err == nil:
+		return domain.TaskErrorPermanent
+	case errors.Is(err, context.DeadlineExceeded):
+		return domain.TaskErrorTimeout
+	case errors.Is(err, mcp.ErrNoAgentAvailable):
+		return domain.TaskErrorAgentUnavailable
+	case errors.As(err, &apiErr):
+		return classifyStatusCode(apiErr.StatusCode)
+	case errors.As(err, &netErr):
+		if netErr.Timeout() {
+			return domain.TaskErrorTimeout
+		}
+		return domain.TaskErrorNetwork
+	case errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, syscall.ECONNREFUSED),
+		errors.Is(err, syscall.ECONNRESET), errors.Is(err, mcp.ErrMCPConnectionClosed):
+		return domain.TaskErrorNetwork
+	default:
+		return domain.TaskErrorPermanent
+	}
+}
+
+func classifyStatusCode(statusCode int) string {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return domain.TaskErrorRateLimit
+	case statusCode == http.StatusRequestTimeout:
+		return domain.TaskErrorTimeout
+	case statusCode >= 500:
+		return domain.TaskErrorServer
+	default:
+		return domain.TaskErrorPermanent
+	}
+}
+
+// resultError reconstruye el error de un resultado fallido que no trae error
+// de Go; el agente HTTP devuelve así las respuestas que no son 2xx
+func resultError(result *domain.MCPTaskResult) error {
+	if statusCode, ok := result.Output["status_code"].(int); ok {
+		return &mcp.APIError{StatusCode: statusCode, Body: result.Error}
+	}
+	return errors.New(result.Error)
+}
+
+// shouldRetry indica si la tarea debe reintentarse tras el error dado
+func shouldRetry(task *domain.AsyncTask, err error) bool {
+	policy := task.RetryPolicy
+	if policy == nil || task.Attempts >= policy.MaxAttempts {
+		return false
+	}
+
+	retryable := policy.RetryableErrors
+	if len(retryable) == 0 {
+		retryable = transientTaskErrors
+	}
+
+	class := classifyTaskError(err)
+	for _, candidate := range retryable {
+		if candidate == "*" || candidate == class {
+			return true
+		}
+	}
+	return false
+}
+
+// retryBackoff calcula la espera antes del siguiente intento con backoff exponencial
+func retryBackoff(policy *domain.RetryPolicy, attempt int) time.Duration {
+	initial := policy.InitialBackoff
+	if initial <= 0 {
+		initial = 1000
+	}
+
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+
+	delay := float64(initial) * math.Pow(multiplier, float64(attempt-1))
+	if policy.MaxBackoff > 0 && delay > float64(policy.MaxBackoff) {
+		delay = float64(policy.MaxBackoff)
+	}
+
+	return time.Duration(delay) * time.Millisecond
+}
+
+// jitterBackoff reparte la espera entre la mitad y el total del backoff, para
+// que las tareas que fallan a la vez no se reintenten todas en el mismo instante
+func jitterBackoff(delay time.Duration, random func() float64) time.Duration {
+	half := delay / 2
+	return half + time.Duration(random()*float64(delay-half))
+}
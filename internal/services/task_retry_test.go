@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// timeoutError es un net.Error que vence por tiempo, como el de un dial lento
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyTaskError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"deadline", fmt.Errorf("calling agent: %w", context.DeadlineExceeded), domain.TaskErrorTimeout},
+		{"net timeout", &net.OpError{Op: "dial", Err: timeoutError{}}, domain.TaskErrorTimeout},
+		{"connection refused", &net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, domain.TaskErrorNetwork},
+		{"connection reset", fmt.Errorf("read: %w", syscall.ECONNRESET), domain.TaskErrorNetwork},
+		{"rate limit", fmt.Errorf("openai: %w", &mcp.APIError{StatusCode: 429}), domain.TaskErrorRateLimit},
+		{"server", &mcp.APIError{StatusCode: 503, Body: "unavailable"}, domain.TaskErrorServer},
+		{"bad request", &mcp.APIError{StatusCode: 400, Body: "timeout must be positive"}, domain.TaskErrorPermanent},
+		{"no agent", fmt.Errorf("%w for task type: ai", mcp.ErrNoAgentAvailable), domain.TaskErrorAgentUnavailable},
+		{"queue full", fmt.Errorf("%w for task type ai: %w", mcp.ErrNoAgentAvailable, mcp.ErrQueueFull), domain.TaskErrorAgentUnavailable},
+		// El texto del mensaje no cuenta: sólo el tipo del error
+		{"message only", errors.New("network timeout: 503 service unavailable"), domain.TaskErrorPermanent},
+		{"nil", nil, domain.TaskErrorPermanent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, classifyTaskError(tt.err))
+		})
+	}
+
+	// El agente HTTP devuelve las respuestas no 2xx como resultado fallido
+	failed := &domain.MCPTaskResult{Error: "HTTP 502: 502 Bad Gateway", Output: map[string]interface{}{"status_code": 502}}
+	assert.Equal(t, domain.TaskErrorServer, classifyTaskError(resultError(failed)))
+	assert.Equal(t, domain.TaskErrorPermanent, classifyTaskError(resultError(&domain.MCPTaskResult{Error: "invalid input"})))
+}
+
+func TestRetryBackoff_CapsAndJitters(t *testing.T) {
+	policy := &domain.RetryPolicy{InitialBackoff: 1000, Multiplier: 2, MaxBackoff: 5000}
+	assert.Equal(t, time.Second, retryBackoff(policy, 1))
+	assert.Equal(t, 4*time.Second, retryBackoff(policy, 3))
+	assert.Equal(t, 5*time.Second, retryBackoff(policy, 4))
+	assert.Equal(t, 5*time.Second, retryBackoff(policy, 20))
+
+	delay := retryBackoff(policy, 20)
+	assert.Equal(t, delay/2, jitterBackoff(delay, func() float64 { return 0 }))
+	assert.Equal(t, 3750*time.Millisecond, jitterBackoff(delay, func() float64 { return 0.5 }))
+	// Con jitter nunca se pasa del máximo de la política
+	assert.LessOrEqual(t, jitterBackoff(delay, func() float64 { return 0.9999 }), 5*time.Second)
+}
+
+func TestTaskManager_DeadLettersAfterMaxAttempts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	deadLetters := repositories.NewMockDeadLetterRepository()
+	manager := NewTaskManager(repositories.NewMockTaskRepository(), deadLetters, nil, nil,
+		logger.NewLogger("error"), 1, 10, 0, clk)
+	manager.(*taskManager).random = func() float64 { return 0 }
+	require.NoError(t, manager.RegisterHandler("notify", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		return nil, &mcp.APIError{StatusCode: 503, Body: "unavailable"}
+	}))
+	require.NoError(t, manager.Start(ctx))
+
+	task := &domain.AsyncTask{Type: "notify", RetryPolicy: &domain.RetryPolicy{
+		MaxAttempts: 3, InitialBackoff: 1000, Multiplier: 2, MaxBackoff: 1500,
+	}}
+	require.NoError(t, manager.SubmitTask(ctx, task))
+
+	failedAttempts := func(n int) func() bool {
+		return func() bool {
+			current, err := manager.GetTask(ctx, task.ID)
+			return err == nil && len(current.ErrorHistory) == n && current.Status != domain.TaskStatusRunning
+		}
+	}
+	// Backoffs de 1s y 2s (tope 1,5s), con el jitter en la mitad inferior
+	for attempt, wait := range []time.Duration{500 * time.Millisecond, 750 * time.Millisecond} {
+		require.Eventually(t, failedAttempts(attempt+1), 2*time.Second, 5*time.Millisecond)
+		current, err := manager.GetTask(ctx, task.ID)
+		require.NoError(t, err)
+		assert.Equal(t, domain.TaskStatusPending, current.Status)
+		assert.Equal(t, domain.TaskErrorServer, current.ErrorHistory[attempt].Class)
+		assert.Equal(t, clk.Now().Add(wait), current.NextRetryAt)
+		clk.Advance(wait)
+	}
+
+	require.Eventually(t, failedAttempts(3), 2*time.Second, 5*time.Millisecond)
+	current, err := manager.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusFailed, current.Status)
+	assert.Equal(t, 3, current.Attempts)
+
+	// La entrada en dead-letter se escribe después de soltar el lock
+	var dead []*domain.DeadLetterTask
+	require.Eventually(t, func() bool {
+		dead, err = deadLetters.List(ctx, 0, 0)
+		return err == nil && len(dead) == 1
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, task.ID, dead[0].Task.ID)
+}
+
+func TestTaskManager_RecoveryKeepsRetryBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	repo := repositories.NewMockTaskRepository()
+	require.NoError(t, repo.Create(ctx, &domain.AsyncTask{
+		ID:          "retrying",
+		Type:        "notify",
+		Status:      domain.TaskStatusPending,
+		Attempts:    1,
+		RetryPolicy: &domain.RetryPolicy{MaxAttempts: 3},
+		NextRetryAt: clk.Now().Add(10 * time.Minute),
+		CreatedAt:   clk.Now().Add(-time.Minute),
+	}))
+
+	executed := make(chan string, 1)
+	manager := NewTaskManager(repo, repositories.NewMockDeadLetterRepository(), nil, nil,
+		logger.NewLogger("error"), 1, 10, 0, clk)
+	require.NoError(t, manager.RegisterHandler("notify", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		executed <- task.ID
+		return nil, nil
+	}))
+	require.NoError(t, manager.Start(ctx))
+
+	select {
+	case id := <-executed:
+		t.Fatalf("task %s ran before its retry time", id)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clk.Advance(10 * time.Minute)
+	select {
+	case id := <-executed:
+		assert.Equal(t, "retrying", id)
+	case <-time.After(2 * time.Second):
+		t.Fatal("task was not retried after its backoff")
+	}
+}
+
+func TestTaskManager_DeadLetterQueueSurvivesRestart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	clk := clock.NewFake(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	tasks := repositories.NewMockTaskRepository()
+	deadLetters := repositories.NewMockDeadLetterRepository()
+
+	// Lo que dejó el proceso anterior: dos tareas agotadas en dead-letter
+	for _, id := range []string{"first", "second"} {
+		task := &domain.AsyncTask{ID: id, Type: "notify", Status: domain.TaskStatusFailed, Attempts: 3, Error: "unavailable"}
+		require.NoError(t, tasks.Create(ctx, task))
+		require.NoError(t, deadLetters.Create(ctx, &domain.DeadLetterTask{ID: id, Task: task, Reason: "unavailable", DeadLetteredAt: clk.Now()}))
+	}
+
+	manager := NewTaskManager(tasks, deadLetters, nil, nil, logger.NewLogger("error"), 1, 10, 0, clk)
+	require.NoError(t, manager.RegisterHandler("notify", func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error) {
+		return map[string]interface{}{"ok": true}, nil
+	}))
+	require.NoError(t, manager.Start(ctx))
+	assert.EqualValues(t, 2, manager.GetStats().DeadLettered)
+
+	listed, err := manager.ListDeadLetters(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Len(t, listed, 2)
+
+	// Borrar una entrada que no existe no descuenta nada
+	assert.Error(t, manager.DeleteDeadLetter(ctx, "missing"))
+	assert.EqualValues(t, 2, manager.GetStats().DeadLettered)
+	require.NoError(t, manager.DeleteDeadLetter(ctx, "first"))
+	assert.EqualValues(t, 1, manager.GetStats().DeadLettered)
+
+	redriven, err := manager.RedriveDeadLetter(ctx, "second")
+	require.NoError(t, err)
+	assert.Equal(t, domain.TaskStatusPending, redriven.Status)
+	assert.Equal(t, 0, redriven.Attempts)
+	assert.EqualValues(t, 1, redriven.Metadata["redrive_count"])
+	assert.EqualValues(t, 0, manager.GetStats().DeadLettered)
+	_, err = manager.RedriveDeadLetter(ctx, "second")
+	assert.Error(t, err)
+
+	require.Eventually(t, func() bool {
+		current, err := manager.GetTask(ctx, "second")
+		return err == nil && current.Status == domain.TaskStatusCompleted
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.EqualValues(t, 0, manager.GetStats().DeadLettered)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// TaskHandlerFunc ejecuta dentro del servicio las tareas de un tipo, en lugar de
+// enviarlas a un agente MCP. El mapa devuelto es la salida de la tarea.
+type TaskHandlerFunc func(ctx context.Context, task *domain.AsyncTask) (map[string]interface{}, error)
+
+// RegisterHandler asocia un handler local a un tipo de tarea
+func (tm *taskManager) RegisterHandler(taskType string, handler TaskHandlerFunc) error {
+	if taskType == "" || handler == nil {
+		return fmt.Errorf("task type and handler are required")
+	}
+
+	tm.mu.Lock()
+	defer tm.mu.Unlock()
+
+	if _, exists := tm.handlers[taskType]; exists {
+		return fmt.Errorf("handler for task type %s already registered", taskType)
+	}
+	tm.handlers[taskType] = handler
+	return nil
+}
+
+func (tm *taskManager) handler(taskType string) (TaskHandlerFunc, bool) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	handler, ok := tm.handlers[taskType]
+	return handler, ok
+}
+
+// scheduleDelayed encola la tarea cuando llega su ScheduledAt. La espera es un
+// timer, no un worker bloqueado. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) scheduleDelayed(task *domain.AsyncTask) {
+	tm.clock.AfterFunc(task.ScheduledAt.Sub(tm.clock.Now()), func() {
+		tm.mu.Lock()
+		defer tm.flush()
+		defer tm.mu.Unlock()
+
+		// La tarea pudo cancelarse o el manager detenerse durante la espera
+		if tm.ctx == nil || task.Status != domain.TaskStatusPending {
+			return
+		}
+
+		if tm.queue.push(task) {
+			tm.logger.Info("Scheduled task enqueued", "task_id", task.ID, "type", task.Type)
+		} else {
+			task.Status = domain.TaskStatusFailed
+			task.Error = "task queue is full"
+			task.CompletedAt = tm.clock.Now()
+			tm.stats.PendingTasks--
+			tm.stats.FailedTasks++
+			tm.persist(context.Background(), task)
+			tm.deadLetter(task, "scheduled task could not be enqueued")
+		}
+	})
+}
+
+// runTaskHandler adapta el resultado de un handler local al de una tarea MCP
+func runTaskHandler(ctx context.Context, clk clock.Clock, handler TaskHandlerFunc, task *domain.AsyncTask) (*domain.MCPTaskResult, error) {
+	if task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(task.Timeout)*time.Millisecond)
+		defer cancel()
+	}
+
+	start := clk.Now()
+	output, err := handler(ctx, task)
+	result := &domain.MCPTaskResult{
+		TaskID:        task.ID,
+		AgentID:       "local",
+		Success:       err == nil,
+		Output:        output,
+		ExecutionTime: clk.Since(start).Milliseconds(),
+		CompletedAt:   clk.Now(),
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	// El error se devuelve tal cual para clasificarlo al decidir el reintento
+	return result, err
+}
+
+// logFieldsMetadataKey guarda en la tarea los campos de log de la petición que
+// la creó, para que los logs del worker lleven el mismo request_id
+const logFieldsMetadataKey = "log_fields"
+
+func storeLogFields(ctx context.Context, task *domain.AsyncTask) {
+	fields := logger.FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return
+	}
+	stored := make(map[string]interface{}, len(fields)/2)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if key, ok := fields[i].(string); ok {
+			stored[key] = fields[i+1]
+		}
+	}
+	if task.Metadata == nil {
+		task.Metadata = make(map[string]interface{})
+	}
+	task.Metadata[logFieldsMetadataKey] = stored
+}
+
+// taskLogContext devuelve el contexto de ejecución de la tarea con los campos
+// de log de la petición original más task_id y bot_id
+func taskLogContext(ctx context.Context, task *domain.AsyncTask) context.Context {
+	var fields []interface{}
+	if stored, ok := task.Metadata[logFieldsMetadataKey].(map[string]interface{}); ok {
+		for key, value := range stored {
+			fields = append(fields, key, value)
+		}
+	}
+	fields = append(fields, "task_id", task.ID)
+	if task.BotID != "" {
+		fields = append(fields, logger.BotIDKey, task.BotID)
+	}
+	return logger.ContextWithFields(ctx, fields...)
+}
@@ -0,0 +1,103 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// taskWrites ordena las escrituras de cada tarea que se hacen fuera de tm.mu:
+// una copia más antigua que la última guardada se descarta. Las tareas
+// distintas se guardan en paralelo.
+type taskWrites struct {
+	mu      sync.Mutex
+	entries map[string]*taskWriteEntry
+}
+
+type taskWriteEntry struct {
+	mu      sync.Mutex
+	version uint64
+}
+
+// apply ejecuta write si version es posterior a la última aplicada en la tarea
+func (w *taskWrites) apply(taskID string, version uint64, write func()) {
+	w.mu.Lock()
+	if w.entries == nil {
+		w.entries = make(map[string]*taskWriteEntry)
+	}
+	entry, exists := w.entries[taskID]
+	if !exists {
+		entry = &taskWriteEntry{}
+		w.entries[taskID] = entry
+	}
+	w.mu.Unlock()
+
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+	if version <= entry.version {
+		return
+	}
+	write()
+	entry.version = version
+}
+
+// forget olvida las tareas que ya no se van a escribir más
+func (w *taskWrites) forget(taskID string) {
+	w.mu.Lock()
+	delete(w.entries, taskID)
+	w.mu.Unlock()
+}
+
+// later deja para flush el trabajo de E/S (repositorios, bus de eventos) de
+// un cambio hecho con tm.mu. Debe llamarse con tm.mu tomado.
+func (tm *taskManager) later(apply func()) {
+	tm.pending = append(tm.pending, apply)
+	tm.inflight++
+}
+
+// ordered deja apply para flush detrás de los cambios anteriores de la tarea;
+// si ya se aplicó uno posterior, apply se descarta. Debe llamarse con tm.mu
+// tomado.
+func (tm *taskManager) ordered(taskID string, apply func()) {
+	tm.version++
+	version := tm.version
+	tm.later(func() {
+		tm.writes.apply(taskID, version, apply)
+	})
+}
+
+// save guarda en flush una copia de la tarea tal como está ahora. Debe
+// llamarse con tm.mu tomado.
+func (tm *taskManager) save(ctx context.Context, task *domain.AsyncTask) {
+	snapshot := *task
+	tm.ordered(task.ID, func() {
+		if err := tm.taskRepo.Update(ctx, &snapshot); err != nil {
+			tm.logger.Error("Failed to persist task", "task_id", snapshot.ID, "error", err)
+		}
+	})
+}
+
+// flush hace, ya sin tm.mu, la E/S pendiente de los cambios de estado. Cada
+// método que cambia tareas lo llama al soltar el lock; si otro se adelanta,
+// aplica también los cambios de los demás en el orden en que se hicieron.
+func (tm *taskManager) flush() {
+	tm.mu.Lock()
+	pending := tm.pending
+	tm.pending = nil
+	tm.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	for _, apply := range pending {
+		apply()
+	}
+
+	tm.mu.Lock()
+	tm.inflight -= len(pending)
+	if tm.inflight == 0 {
+		tm.flushed.Broadcast()
+	}
+	tm.mu.Unlock()
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// defaultDateLayout es el formato de date cuando no se indica uno
+const defaultDateLayout = "02/01/2006"
+
+var placeholderPattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+var variablePathPattern = regexp.MustCompile(`^[a-zA-Z0-9_.]+$`)
+
+// templateHelper transforma el valor de un placeholder; arg es el argumento
+// opcional del helper, ya sin comillas, y now la hora de la conversación
+// (data["now"]), que es la que vale "now"
+type templateHelper func(value interface{}, arg string, now time.Time) interface{}
+
+var templateHelpers = map[string]templateHelper{
+	"default": func(value interface{}, arg string, _ time.Time) interface{} {
+		if toString(value) == "" {
+			return arg
+		}
+		return value
+	},
+	"upper": func(value interface{}, _ string, _ time.Time) interface{} {
+		return strings.ToUpper(toString(value))
+	},
+	"lower": func(value interface{}, _ string, _ time.Time) interface{} {
+		return strings.ToLower(toString(value))
+	},
+	"trim": func(value interface{}, _ string, _ time.Time) interface{} {
+		return strings.TrimSpace(toString(value))
+	},
+	"capitalize": func(value interface{}, _ string, _ time.Time) interface{} {
+		s := toString(value)
+		if s == "" {
+			return s
+		}
+		runes := []rune(s)
+		return strings.ToUpper(string(runes[0])) + string(runes[1:])
+	},
+	"date": func(value interface{}, arg string, now time.Time) interface{} {
+		layout := arg
+		if layout == "" {
+			layout = defaultDateLayout
+		}
+		if t, ok := parseTemplateTime(value, now); ok {
+			return t.Format(layout)
+		}
+		return value
+	},
+}
+
+// renderTemplate sustituye los placeholders {{variable | helper "arg"}} del
+// texto con los datos dados. Las variables que no existen quedan vacías salvo
+// que se use default; los placeholders mal formados se dejan tal cual.
+func renderTemplate(text string, data map[string]interface{}) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+
+	now, _ := data["now"].(time.Time)
+	return placeholderPattern.ReplaceAllStringFunc(text, func(match string) string {
+		parts := splitTopLevel(match[2:len(match)-2], "|")
+		path := strings.TrimSpace(parts[0])
+		if !variablePathPattern.MatchString(path) {
+			return match
+		}
+
+		value := lookupVariable(path, data)
+		for _, part := range parts[1:] {
+			name, arg := parseHelperCall(part)
+			helper, ok := templateHelpers[name]
+			if !ok {
+				return match
+			}
+			value = helper(value, arg, now)
+		}
+		return toString(value)
+	})
+}
+
+func parseHelperCall(part string) (string, string) {
+	part = strings.TrimSpace(part)
+	name, arg, _ := strings.Cut(part, " ")
+	return name, unquote(strings.TrimSpace(arg))
+}
+
+func parseTemplateTime(value interface{}, now time.Time) (time.Time, bool) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, true
+	case *time.Time:
+		if v != nil {
+			return *v, true
+		}
+	case string:
+		if strings.EqualFold(strings.TrimSpace(v), "now") {
+			return now, !now.IsZero()
+		}
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, strings.TrimSpace(v)); err == nil {
+				return t, true
+			}
+		}
+	}
+	return time.Time{}, false
+}
+
+// templateData reúne las variables disponibles para los mensajes del flujo: el
+// contexto de la sesión, los datos del mensaje y, bajo memory, las memorias del
+// usuario. Las memorias solo se cargan si algún texto las usa.
+func (s *botService) templateData(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession, texts ...string) map[string]interface{} {
+	data := s.buildConditionInput(ctx, message, session)
+	data["now"] = s.clock.Now()
+
+	if s.memorySvc == nil || !usesMemory(texts) {
+		return data
+	}
+
+	memories, err := s.memorySvc.GetUserMemories(ctx, session.UserID, session.BotID)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to load memories for template", "session_id", session.ID, "error", err)
+		return data
+	}
+
+	memory := make(map[string]interface{}, len(memories))
+	for _, m := range memories {
+		memory[m.Key] = m.Content
+	}
+	data["memory"] = memory
+	return data
+}
+
+func usesMemory(texts []string) bool {
+	for _, text := range texts {
+		if strings.Contains(text, "memory.") {
+			return true
+		}
+	}
+	return false
+}
+
+// renderOptions aplica renderTemplate al texto y valor de cada opción
+func renderOptions(options []domain.ResponseOption, data map[string]interface{}) []domain.ResponseOption {
+	if len(options) == 0 {
+		return options
+	}
+
+	rendered := make([]domain.ResponseOption, len(options))
+	for i, option := range options {
+		option.Text = renderTemplate(option.Text, data)
+		option.Value = renderTemplate(option.Value, data)
+		rendered[i] = option
+	}
+	return rendered
+}
+
+// optionTexts devuelve los textos de las opciones para decidir qué datos cargar
+func optionTexts(text string, options []domain.ResponseOption) []string {
+	texts := make([]string, 0, len(options)*2+1)
+	texts = append(texts, text)
+	for _, option := range options {
+		texts = append(texts, option.Text, option.Value)
+	}
+	return texts
+}
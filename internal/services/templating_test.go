@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"user_name": "ana",
+		"empty":     "",
+		"order":     map[string]interface{}{"id": 42},
+		"due":       "2024-03-05",
+		"today":     "now",
+		"now":       time.Date(2024, 7, 9, 12, 0, 0, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"plain variable", "Hi {{user_name}}", "Hi ana"},
+		{"helpers", "Hi {{ user_name | capitalize }} / {{user_name | upper}}", "Hi Ana / ANA"},
+		{"nested path", "Order #{{order.id}}", "Order #42"},
+		{"missing variable", "Hi {{nickname}}!", "Hi !"},
+		{"default", `Hi {{nickname | default "friend"}}, {{empty | default 'n/a'}}`, "Hi friend, n/a"},
+		{"date", `Due {{due | date "Jan 2, 2006"}} ({{due | date}})`, "Due Mar 5, 2024 (05/03/2024)"},
+		{"date of now uses the conversation clock", "Today is {{today | date}}", "Today is 09/07/2024"},
+		{"unknown helper left as is", "{{user_name | shout}}", "{{user_name | shout}}"},
+		{"invalid path left as is", "{{ 1 + 1 }}", "{{ 1 + 1 }}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, renderTemplate(tt.text, data))
+		})
+	}
+}
+
+func TestProcessMessageStep_RendersTextAndOptions(t *testing.T) {
+	s := &botService{clock: clock.Real()}
+	step := &domain.BotStep{ID: "greet", Type: domain.StepTypeMessage, Content: json.RawMessage(
+		`{"text":"Hi {{user_name}}","type":"buttons","options":[{"id":"1","text":"I'm {{user_name | upper}}","value":"{{user_id}}"}]}`)}
+	session := &domain.ConversationSession{Context: map[string]interface{}{"user_name": "Ana"}}
+
+	response, _, err := s.processMessageStep(context.Background(), step, &domain.IncomingMessage{UserID: "user-1"}, session)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi Ana", response.Content)
+	require.Len(t, response.Options, 1)
+	assert.Equal(t, "I'm ANA", response.Options[0].Text)
+	assert.Equal(t, "user-1", response.Options[0].Value)
+}
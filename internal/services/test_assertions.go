@@ -0,0 +1,196 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// defaultSimilarityThreshold se usa cuando una aserción de similitud no
+// define su propio umbral
+const defaultSimilarityThreshold = 0.75
+
+// matchValue compara un valor obtenido con el esperado según el modo. Devuelve
+// una descripción de la diferencia o "" si la aserción se cumple.
+func matchValue(mode domain.AssertionMode, expected, actual interface{}, found bool, threshold float64) string {
+	switch mode {
+	case domain.AssertionExists:
+		if !found {
+			return "expected value to exist"
+		}
+		return ""
+	case domain.AssertionNotExists:
+		if found {
+			return fmt.Sprintf("expected value to be unset, got %v", actual)
+		}
+		return ""
+	}
+
+	if !found {
+		return fmt.Sprintf("expected %v, got nothing", expected)
+	}
+
+	switch mode {
+	case "", domain.AssertionExact:
+		if !contextValuesEqual(expected, actual) {
+			return fmt.Sprintf("expected %v, got %v", expected, actual)
+		}
+	case domain.AssertionContains:
+		if items, ok := actual.([]interface{}); ok {
+			for _, item := range items {
+				if contextValuesEqual(expected, item) {
+					return ""
+				}
+			}
+			return fmt.Sprintf("expected list to contain %v, got %v", expected, actual)
+		}
+		if !strings.Contains(strings.ToLower(fmt.Sprint(actual)), strings.ToLower(fmt.Sprint(expected))) {
+			return fmt.Sprintf("expected %q to contain %q", fmt.Sprint(actual), fmt.Sprint(expected))
+		}
+	case domain.AssertionRegex:
+		re, err := regexp.Compile(fmt.Sprint(expected))
+		if err != nil {
+			return fmt.Sprintf("invalid regex %q: %v", expected, err)
+		}
+		if !re.MatchString(fmt.Sprint(actual)) {
+			return fmt.Sprintf("expected %q to match /%s/", fmt.Sprint(actual), expected)
+		}
+	case domain.AssertionSimilarity:
+		if threshold <= 0 {
+			threshold = defaultSimilarityThreshold
+		}
+		score := cosineSimilarity(embedText(fmt.Sprint(expected)), embedText(fmt.Sprint(actual)))
+		if score < threshold {
+			return fmt.Sprintf("similarity %.2f below threshold %.2f for %q", score, threshold, fmt.Sprint(actual))
+		}
+	default:
+		return fmt.Sprintf("unsupported assertion mode: %s", mode)
+	}
+	return ""
+}
+
+// assertionDocument arma el documento sobre el que se resuelven las rutas
+// JSON. El paso por JSON normaliza structs y tipos numéricos.
+func assertionDocument(response *domain.BotResponse, sessionContext map[string]interface{}) interface{} {
+	raw := map[string]interface{}{
+		"context": sessionContext,
+	}
+	if response != nil {
+		raw["response"] = response.Content
+		raw["type"] = response.Type
+		raw["options"] = response.Options
+		raw["metadata"] = response.Metadata
+	}
+
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return raw
+	}
+	var doc interface{}
+	if err := json.Unmarshal(encoded, &doc); err != nil {
+		return raw
+	}
+	return doc
+}
+
+// evaluateAssertions aplica las aserciones sobre el documento y devuelve las
+// que no se cumplen
+func evaluateAssertions(assertions []domain.ResponseAssertion, doc interface{}) []string {
+	var failures []string
+	for _, assertion := range assertions {
+		actual, found := jsonPathLookup(doc, assertion.Path)
+		if failure := matchValue(assertion.Mode, assertion.Value, actual, found, assertion.Threshold); failure != "" {
+			failures = append(failures, fmt.Sprintf("%s: %s", assertion.Path, failure))
+		}
+	}
+	return failures
+}
+
+// jsonPathLookup resuelve rutas del tipo "$.a.b[0].c" o "a.b.0.c"
+func jsonPathLookup(doc interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	path = strings.TrimPrefix(path, ".")
+	if path == "" {
+		return doc, doc != nil
+	}
+
+	current := doc
+	for _, segment := range splitJSONPath(path) {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, exists := node[segment]
+			if !exists {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func splitJSONPath(path string) []string {
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		for part != "" {
+			open := strings.Index(part, "[")
+			if open == -1 {
+				segments = append(segments, part)
+				break
+			}
+			if open > 0 {
+				segments = append(segments, part[:open])
+			}
+			end := strings.Index(part[open:], "]")
+			if end == -1 {
+				segments = append(segments, part[open+1:])
+				break
+			}
+			segments = append(segments, strings.Trim(part[open+1:open+end], `'"`))
+			part = part[open+end+1:]
+		}
+	}
+	return segments
+}
+
+// validateAssertions detecta modos desconocidos y expresiones regulares
+// inválidas al guardar la prueba, en lugar de al ejecutarla
+func validateAssertions(responseMode domain.AssertionMode, response string, assertions []domain.ResponseAssertion) error {
+	if err := validateAssertionMode(responseMode, response); err != nil {
+		return fmt.Errorf("response_match: %w", err)
+	}
+	for i, assertion := range assertions {
+		if strings.TrimSpace(assertion.Path) == "" {
+			return fmt.Errorf("assertion %d: path is required", i)
+		}
+		if err := validateAssertionMode(assertion.Mode, fmt.Sprint(assertion.Value)); err != nil {
+			return fmt.Errorf("assertion %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateAssertionMode(mode domain.AssertionMode, value string) error {
+	switch mode {
+	case "", domain.AssertionExact, domain.AssertionContains, domain.AssertionSimilarity,
+		domain.AssertionExists, domain.AssertionNotExists:
+		return nil
+	case domain.AssertionRegex:
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+		return nil
+	}
+	return fmt.Errorf("unsupported assertion mode: %s", mode)
+}
@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvaluateAssertions(t *testing.T) {
+	response := &domain.BotResponse{
+		Content:  "Tu pedido ABC-1234 llegará mañana",
+		Metadata: map[string]interface{}{"intent": "order_status", "score": 0.92},
+	}
+	doc := assertionDocument(response, map[string]interface{}{
+		"items": []interface{}{map[string]interface{}{"sku": "X1"}},
+	})
+
+	failures := evaluateAssertions([]domain.ResponseAssertion{
+		{Path: "$.metadata.intent", Value: "order_status"},
+		{Path: "metadata.score", Value: 0.92},
+		{Path: "context.items[0].sku", Value: "X1"},
+		{Path: "response", Mode: domain.AssertionRegex, Value: `[A-Z]{3}-\d{4}`},
+		{Path: "response", Mode: domain.AssertionContains, Value: "pedido"},
+		{Path: "response", Mode: domain.AssertionSimilarity, Value: "tu pedido llegará mañana", Threshold: 0.6},
+		{Path: "context.missing", Mode: domain.AssertionNotExists},
+	}, doc)
+	assert.Empty(t, failures)
+
+	failures = evaluateAssertions([]domain.ResponseAssertion{
+		{Path: "metadata.intent", Value: "greeting"},
+		{Path: "response", Mode: domain.AssertionSimilarity, Value: "el clima está soleado"},
+		{Path: "context.items[3]", Mode: domain.AssertionExists},
+	}, doc)
+	assert.Len(t, failures, 3)
+}
+
+func TestValidateAssertions(t *testing.T) {
+	assert.NoError(t, validateAssertions(domain.AssertionContains, "hola", nil))
+	assert.Error(t, validateAssertions(domain.AssertionRegex, "[", nil))
+	assert.Error(t, validateAssertions("", "", []domain.ResponseAssertion{{Path: "response", Mode: "fuzzy"}}))
+}
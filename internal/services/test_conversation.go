@@ -0,0 +1,240 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/id"
+)
+
+// CreateMultiTurnTestCase registra una prueba de conversación
+func (s *testService) CreateMultiTurnTestCase(ctx context.Context, testCase *domain.MultiTurnTestCase) error {
+	if err := validateMultiTurnTestCase(testCase); err != nil {
+		return err
+	}
+	if testCase.ID == "" {
+		testCase.ID = id.New()
+	}
+	testCase.Status = domain.TestStatusPending
+	testCase.Result = nil
+	testCase.CreatedAt = time.Now()
+	testCase.UpdatedAt = time.Now()
+
+	return s.multiTurnRepo.Create(ctx, testCase)
+}
+
+func (s *testService) GetMultiTurnTestCase(ctx context.Context, id string) (*domain.MultiTurnTestCase, error) {
+	return s.multiTurnRepo.GetByID(ctx, id)
+}
+
+func (s *testService) GetMultiTurnTestCasesByBot(ctx context.Context, botID string) ([]*domain.MultiTurnTestCase, error) {
+	return s.multiTurnRepo.GetByBotID(ctx, botID)
+}
+
+func (s *testService) UpdateMultiTurnTestCase(ctx context.Context, testCase *domain.MultiTurnTestCase) error {
+	if err := validateMultiTurnTestCase(testCase); err != nil {
+		return err
+	}
+	testCase.UpdatedAt = time.Now()
+	return s.multiTurnRepo.Update(ctx, testCase)
+}
+
+func (s *testService) DeleteMultiTurnTestCase(ctx context.Context, id string) error {
+	return s.multiTurnRepo.Delete(ctx, id)
+}
+
+// ExecuteMultiTurnTestCase ejecuta los turnos en orden sobre una sesión nueva
+// y se detiene en el primer turno que no cumple lo esperado
+func (s *testService) ExecuteMultiTurnTestCase(ctx context.Context, id string) (*domain.MultiTurnTestResult, error) {
+	testCase, err := s.multiTurnRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multi-turn test case: %w", err)
+	}
+
+	testCase.Status = domain.TestStatusRunning
+	testCase.UpdatedAt = time.Now()
+	if err := s.multiTurnRepo.Update(ctx, testCase); err != nil {
+		return nil, fmt.Errorf("failed to update multi-turn test case status: %w", err)
+	}
+
+	result := s.runConversation(ctx, testCase)
+
+	testCase.Result = result
+	if result.Success {
+		testCase.Status = domain.TestStatusPassed
+	} else {
+		testCase.Status = domain.TestStatusFailed
+	}
+	testCase.UpdatedAt = time.Now()
+	if err := s.multiTurnRepo.Update(ctx, testCase); err != nil {
+		return nil, fmt.Errorf("failed to update multi-turn test case result: %w", err)
+	}
+
+	return result, nil
+}
+
+// runConversation usa un usuario sintético por ejecución para no heredar
+// el estado de sesiones anteriores
+func (s *testService) runConversation(ctx context.Context, testCase *domain.MultiTurnTestCase) *domain.MultiTurnTestResult {
+	startTime := time.Now()
+	userID := "test-" + id.New()
+	channel := testCase.Channel
+	if channel == "" {
+		channel = domain.ChannelWeb
+	}
+
+	result := &domain.MultiTurnTestResult{
+		Success:    true,
+		Turns:      make([]domain.TestTurnResult, 0, len(testCase.Turns)),
+		FailedTurn: -1,
+	}
+
+	for i, turn := range testCase.Turns {
+		metadata := make(map[string]interface{}, len(testCase.Metadata)+len(turn.Metadata))
+		for key, value := range testCase.Metadata {
+			metadata[key] = value
+		}
+		for key, value := range turn.Metadata {
+			metadata[key] = value
+		}
+
+		response, err := s.botSvc.ProcessIncomingMessage(ctx, &domain.IncomingMessage{
+			ID:        id.New(),
+			BotID:     testCase.BotID,
+			UserID:    userID,
+			Content:   turn.Message,
+			Channel:   channel,
+			Metadata:  metadata,
+			Timestamp: time.Now(),
+		})
+
+		turnResult := domain.TestTurnResult{Index: i, Message: turn.Message}
+		if err != nil {
+			turnResult.Failures = []string{err.Error()}
+			result.Error = fmt.Sprintf("turn %d: %v", i, err)
+		} else {
+			turnResult.ActualResponse = response.Content
+			turnResult.ActualNextStep, turnResult.ActualContext = s.sessionState(ctx, testCase.BotID, userID)
+			turnResult.Failures = verifyTurn(turn.Expected, response, turnResult)
+		}
+		turnResult.Success = len(turnResult.Failures) == 0
+		result.Turns = append(result.Turns, turnResult)
+
+		if !turnResult.Success {
+			result.Success = false
+			result.FailedTurn = i
+			break
+		}
+	}
+
+	s.cleanupTestSession(ctx, testCase.BotID, userID)
+
+	result.ExecutionTime = time.Since(startTime).Milliseconds()
+	result.ExecutedAt = time.Now()
+	return result
+}
+
+// sessionState devuelve el paso actual y una copia del contexto de la sesión de prueba
+func (s *testService) sessionState(ctx context.Context, botID, userID string) (string, map[string]interface{}) {
+	if s.sessionRepo == nil {
+		return "", nil
+	}
+	session, err := s.sessionRepo.GetByUserAndBot(ctx, userID, botID)
+	if err != nil || session == nil {
+		return "", nil
+	}
+	contextCopy := make(map[string]interface{}, len(session.Context))
+	for key, value := range session.Context {
+		contextCopy[key] = value
+	}
+	return session.CurrentStepID, contextCopy
+}
+
+func (s *testService) cleanupTestSession(ctx context.Context, botID, userID string) {
+	if s.sessionRepo == nil {
+		return
+	}
+	session, err := s.sessionRepo.GetByUserAndBot(ctx, userID, botID)
+	if err != nil || session == nil {
+		return
+	}
+	if err := s.sessionRepo.Delete(ctx, session.ID); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to delete test session", "session_id", session.ID, "error", err)
+	}
+}
+
+// verifyTurn compara el resultado de un turno con lo esperado y devuelve
+// la lista de diferencias encontradas
+func verifyTurn(expected domain.TestTurnExpected, response *domain.BotResponse, actual domain.TestTurnResult) []string {
+	var failures []string
+
+	if expected.Response != "" {
+		if failure := matchValue(expected.ResponseMatch, expected.Response, actual.ActualResponse, true, expected.SimilarityThreshold); failure != "" {
+			failures = append(failures, "response: "+failure)
+		}
+	}
+	if expected.ResponseContains != "" && !strings.Contains(strings.ToLower(actual.ActualResponse), strings.ToLower(expected.ResponseContains)) {
+		failures = append(failures, fmt.Sprintf("expected response to contain %q, got %q", expected.ResponseContains, actual.ActualResponse))
+	}
+	if expected.NextStep != "" && actual.ActualNextStep != expected.NextStep {
+		failures = append(failures, fmt.Sprintf("expected next step %q, got %q", expected.NextStep, actual.ActualNextStep))
+	}
+
+	for key, expectedValue := range expected.Context {
+		actualValue := lookupVariable(key, actual.ActualContext)
+		if expectedValue == nil {
+			if actualValue != nil {
+				failures = append(failures, fmt.Sprintf("expected context %q to be unset, got %v", key, actualValue))
+			}
+			continue
+		}
+		if !contextValuesEqual(expectedValue, actualValue) {
+			failures = append(failures, fmt.Sprintf("expected context %q to be %v, got %v", key, expectedValue, actualValue))
+		}
+	}
+
+	if len(expected.Assertions) > 0 {
+		failures = append(failures, evaluateAssertions(expected.Assertions, assertionDocument(response, actual.ActualContext))...)
+	}
+
+	return failures
+}
+
+// contextValuesEqual compara valores tolerando diferencias de tipo numérico
+// (los valores esperados llegan como float64 desde JSON)
+func contextValuesEqual(expected, actual interface{}) bool {
+	if actual == nil {
+		return false
+	}
+	if expectedNumber, ok := toFloat(expected); ok {
+		if actualNumber, ok := toFloat(actual); ok {
+			return expectedNumber == actualNumber
+		}
+	}
+	if reflect.DeepEqual(expected, actual) {
+		return true
+	}
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+func validateMultiTurnTestCase(testCase *domain.MultiTurnTestCase) error {
+	if testCase.BotID == "" {
+		return fmt.Errorf("bot_id is required")
+	}
+	if len(testCase.Turns) == 0 {
+		return fmt.Errorf("at least one turn is required")
+	}
+	for i, turn := range testCase.Turns {
+		if strings.TrimSpace(turn.Message) == "" {
+			return fmt.Errorf("turn %d: message is required", i)
+		}
+		if err := validateAssertions(turn.Expected.ResponseMatch, turn.Expected.Response, turn.Expected.Assertions); err != nil {
+			return fmt.Errorf("turn %d: %w", i, err)
+		}
+	}
+	return nil
+}
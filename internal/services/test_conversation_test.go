@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConversationTestFixture arma un bot que pregunta el nombre y saluda con él
+func newConversationTestFixture(t *testing.T) (TestService, domain.ConversationSessionRepository) {
+	t.Helper()
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, nil, nil, nil, log)
+
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "main", BotID: "bot-1", EntryPoint: "ask", IsDefault: true}))
+	name, greet := "name", "greet"
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "ask", FlowID: "main", Type: domain.StepTypeMessage, NextStepID: &name,
+		Content: json.RawMessage(`{"text":"What is your name?"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "name", FlowID: "main", Type: domain.StepTypeInput, NextStepID: &greet,
+		Content: json.RawMessage(`{"variable":"name"}`)}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "greet", FlowID: "main", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Nice to meet you, {{name}}"}`)}))
+
+	tests := NewTestService(repositories.NewMockTestCaseRepository(), repositories.NewMockMultiTurnTestCaseRepository(),
+		nil, sessionRepo, bots, nil, nil, log)
+	return tests, sessionRepo
+}
+
+func conversationTestCase(nameTurn domain.TestTurnExpected) *domain.MultiTurnTestCase {
+	return &domain.MultiTurnTestCase{
+		BotID: "bot-1",
+		Name:  "greeting",
+		Turns: []domain.TestTurn{
+			{Message: "hi", Expected: domain.TestTurnExpected{Response: "What is your name?", NextStep: "name"}},
+			{Message: "Ana", Expected: nameTurn},
+			{Message: "thanks", Expected: domain.TestTurnExpected{Response: "Nice to meet you, Ana", Context: map[string]interface{}{"name": "Ana"}}},
+		},
+	}
+}
+
+func TestExecuteMultiTurnTestCase_Passes(t *testing.T) {
+	ctx := context.Background()
+	tests, sessionRepo := newConversationTestFixture(t)
+
+	testCase := conversationTestCase(domain.TestTurnExpected{
+		ResponseContains: "saved your response",
+		NextStep:         "greet",
+		Context:          map[string]interface{}{"name": "Ana", "missing": nil},
+		Assertions:       []domain.ResponseAssertion{{Path: "response", Mode: domain.AssertionRegex, Value: `Ana$`}},
+	})
+	require.NoError(t, tests.CreateMultiTurnTestCase(ctx, testCase))
+	assert.Equal(t, domain.TestStatusPending, testCase.Status)
+
+	result, err := tests.ExecuteMultiTurnTestCase(ctx, testCase.ID)
+	require.NoError(t, err)
+	assert.True(t, result.Success, "%+v", result.Turns)
+	assert.Equal(t, -1, result.FailedTurn)
+	require.Len(t, result.Turns, 3)
+	assert.Equal(t, "What is your name?", result.Turns[0].ActualResponse)
+	assert.Equal(t, "Ana", result.Turns[1].ActualContext["name"])
+	assert.Equal(t, "Nice to meet you, Ana", result.Turns[2].ActualResponse)
+
+	stored, err := tests.GetMultiTurnTestCase(ctx, testCase.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TestStatusPassed, stored.Status)
+	assert.Equal(t, result, stored.Result)
+
+	// La sesión sintética de la prueba se borra al terminar
+	sessions, err := sessionRepo.GetInactiveSince(ctx, "bot-1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestExecuteMultiTurnTestCase_StopsAtFailedTurn(t *testing.T) {
+	ctx := context.Background()
+	tests, sessionRepo := newConversationTestFixture(t)
+
+	// El turno del medio espera otro nombre y otro paso
+	testCase := conversationTestCase(domain.TestTurnExpected{
+		ResponseContains: "Ana",
+		NextStep:         "ask",
+		Context:          map[string]interface{}{"name": "Bob"},
+	})
+	require.NoError(t, tests.CreateMultiTurnTestCase(ctx, testCase))
+
+	result, err := tests.ExecuteMultiTurnTestCase(ctx, testCase.ID)
+	require.NoError(t, err)
+	assert.False(t, result.Success)
+	assert.Equal(t, 1, result.FailedTurn)
+	assert.Empty(t, result.Error)
+
+	// El turno siguiente no se ejecuta
+	require.Len(t, result.Turns, 2)
+	assert.True(t, result.Turns[0].Success)
+	failed := result.Turns[1]
+	assert.False(t, failed.Success)
+	assert.Equal(t, "Ana", failed.Message)
+	assert.Equal(t, "greet", failed.ActualNextStep)
+	require.Len(t, failed.Failures, 2)
+	assert.Contains(t, failed.Failures[0], `expected next step "ask"`)
+	assert.Contains(t, failed.Failures[1], `expected context "name" to be Bob, got Ana`)
+
+	stored, err := tests.GetMultiTurnTestCase(ctx, testCase.ID)
+	require.NoError(t, err)
+	assert.Equal(t, domain.TestStatusFailed, stored.Status)
+	require.NotNil(t, stored.Result)
+	assert.Equal(t, 1, stored.Result.FailedTurn)
+
+	sessions, err := sessionRepo.GetInactiveSince(ctx, "bot-1", time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
+
+func TestCreateMultiTurnTestCase_Validates(t *testing.T) {
+	ctx := context.Background()
+	tests, _ := newConversationTestFixture(t)
+
+	assert.EqualError(t, tests.CreateMultiTurnTestCase(ctx, &domain.MultiTurnTestCase{Turns: []domain.TestTurn{{Message: "hi"}}}), "bot_id is required")
+	assert.EqualError(t, tests.CreateMultiTurnTestCase(ctx, &domain.MultiTurnTestCase{BotID: "bot-1"}), "at least one turn is required")
+	assert.EqualError(t, tests.CreateMultiTurnTestCase(ctx, &domain.MultiTurnTestCase{BotID: "bot-1",
+		Turns: []domain.TestTurn{{Message: "hi"}, {Message: " "}}}), "turn 1: message is required")
+	err := tests.CreateMultiTurnTestCase(ctx, &domain.MultiTurnTestCase{BotID: "bot-1", Turns: []domain.TestTurn{
+		{Message: "hi", Expected: domain.TestTurnExpected{Response: "[", ResponseMatch: domain.AssertionRegex}},
+	}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "turn 0:")
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// ReportFormat representa los formatos de exportación de un TestRun
+type ReportFormat string
+
+const (
+	ReportFormatJUnit ReportFormat = "junit"
+	ReportFormatHTML  ReportFormat = "html"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// RenderJUnitReport genera el reporte en formato JUnit XML para pipelines de CI
+func RenderJUnitReport(run *domain.TestRun) ([]byte, error) {
+	suite := junitTestSuite{
+		Name:      run.Name,
+		Tests:     run.Total,
+		Failures:  run.Failed,
+		Errors:    run.Errored,
+		Skipped:   run.Skipped,
+		Time:      millisToSeconds(run.Duration),
+		Timestamp: run.StartedAt.UTC().Format(time.RFC3339),
+	}
+
+	for _, c := range run.Cases {
+		testCase := junitTestCase{
+			Name:      caseDisplayName(c),
+			ClassName: run.Name,
+			Time:      millisToSeconds(c.ExecutionTime),
+		}
+		switch {
+		case c.Skipped:
+			testCase.Skipped = &struct{}{}
+		case c.Error != "":
+			testCase.Error = &junitMessage{Message: c.Error, Type: "error", Body: c.Error}
+		case !c.Success:
+			details := strings.Join(c.Failures, "\n")
+			message := "assertion failed"
+			if len(c.Failures) > 0 {
+				message = c.Failures[0]
+			}
+			testCase.Failure = &junitMessage{Message: message, Type: "assertion", Body: details}
+		}
+		suite.Cases = append(suite.Cases, testCase)
+	}
+
+	report := junitTestSuites{
+		Name:     run.Name,
+		Tests:    run.Total,
+		Failures: run.Failed,
+		Errors:   run.Errored,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	data, err := xml.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to render junit report: %w", err)
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"seconds": millisToSeconds,
+	"name":    caseDisplayName,
+	"time":    func(t time.Time) string { return t.UTC().Format("2006-01-02 15:04:05 MST") },
+}).Parse(`<!DOCTYPE html>
+<html lang="es">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}} - Reporte de pruebas</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border: 1px solid #ddd; padding: 6px 10px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+.passed { color: #1a7f37; } .failed { color: #cf222e; } .skipped { color: #8a8a8a; }
+pre { margin: 0; white-space: pre-wrap; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>Estado: <strong class="{{if eq .Status "passed"}}passed{{else}}failed{{end}}">{{.Status}}</strong> ·
+Total: {{.Total}} · Pasaron: {{.Passed}} · Fallaron: {{.Failed}} · Errores: {{.Errored}} · Omitidos: {{.Skipped}} ·
+Duración: {{seconds .Duration}}s · Inicio: {{time .StartedAt}}</p>
+{{if .Regressions}}<p class="failed">Regresiones: {{range $i, $id := .Regressions}}{{if $i}}, {{end}}{{$id}}{{end}}</p>{{end}}
+{{if .Fixed}}<p class="passed">Corregidos: {{range $i, $id := .Fixed}}{{if $i}}, {{end}}{{$id}}{{end}}</p>{{end}}
+<table>
+<tr><th>Caso</th><th>Resultado</th><th>Esperado</th><th>Obtenido</th><th>Detalle</th><th>Tiempo</th></tr>
+{{range .Cases}}<tr>
+<td>{{name .}}</td>
+<td>{{if .Skipped}}<span class="skipped">omitido</span>{{else if .Success}}<span class="passed">ok</span>{{else}}<span class="failed">falló</span>{{end}}</td>
+<td><pre>{{.ExpectedResponse}}</pre></td>
+<td><pre>{{.ActualResponse}}</pre></td>
+<td><pre>{{if .Error}}{{.Error}}{{else}}{{range .Failures}}{{.}}
+{{end}}{{end}}</pre></td>
+<td>{{seconds .ExecutionTime}}s</td>
+</tr>{{end}}
+</table>
+</body>
+</html>
+`))
+
+// RenderHTMLReport genera un reporte HTML autocontenido de la ejecución
+func RenderHTMLReport(run *domain.TestRun) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := htmlReportTemplate.Execute(&buf, run); err != nil {
+		return nil, fmt.Errorf("failed to render html report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func millisToSeconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
+
+func caseDisplayName(c domain.TestRunCaseResult) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.TestCaseID
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"encoding/xml"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// reportRun cubre un caso de cada resultado, con nombres y mensajes que hay
+// que escapar en XML y HTML
+func reportRun() *domain.TestRun {
+	return &domain.TestRun{
+		ID:          "run-1",
+		Name:        `Checkout & "returns" <nightly>`,
+		Status:      domain.TestSuiteStatusFailed,
+		Total:       4,
+		Passed:      1,
+		Failed:      1,
+		Errored:     1,
+		Skipped:     1,
+		Duration:    2350,
+		Regressions: []string{"case-2"},
+		Fixed:       []string{"case-<4>"},
+		StartedAt:   time.Date(2024, 6, 12, 10, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		Cases: []domain.TestRunCaseResult{
+			{TestCaseID: "case-1", Name: "Greeting", Success: true, ExpectedResponse: "Hi!", ActualResponse: "Hi!", ExecutionTime: 120},
+			{TestCaseID: "case-2", Name: `<script>alert("x")</script> & order`, ExpectedResponse: "Total: <b>$10</b>",
+				ActualResponse: "Total: $12 & tax", Failures: []string{`expected "Total: <b>$10</b>"`, "second ]]> failure"}, ExecutionTime: 1500},
+			{TestCaseID: "case-3", Error: "bot not found: <bot-9> & 'friends'", ExecutionTime: 30},
+			{TestCaseID: "case-<4>", Name: "", Skipped: true},
+		},
+	}
+}
+
+// assertGolden compara con testdata/name; con -update reescribe el archivo
+func assertGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(path, actual, 0o644))
+	}
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(actual))
+}
+
+func TestRenderJUnitReport(t *testing.T) {
+	report, err := RenderJUnitReport(reportRun())
+	require.NoError(t, err)
+	assertGolden(t, "report.junit.xml.golden", report)
+
+	// El XML escapado vuelve a dar los nombres y mensajes originales
+	var parsed junitTestSuites
+	require.NoError(t, xml.Unmarshal(report, &parsed))
+	require.Len(t, parsed.Suites, 1)
+	suite := parsed.Suites[0]
+	assert.Equal(t, `Checkout & "returns" <nightly>`, suite.Name)
+	require.Len(t, suite.Cases, 4)
+	assert.Equal(t, `<script>alert("x")</script> & order`, suite.Cases[1].Name)
+	assert.Equal(t, `expected "Total: <b>$10</b>"`+"\nsecond ]]> failure", suite.Cases[1].Failure.Body)
+	assert.Equal(t, "bot not found: <bot-9> & 'friends'", suite.Cases[2].Error.Message)
+	assert.Equal(t, "case-<4>", suite.Cases[3].Name)
+	assert.NotNil(t, suite.Cases[3].Skipped)
+}
+
+func TestRenderHTMLReport(t *testing.T) {
+	report, err := RenderHTMLReport(reportRun())
+	require.NoError(t, err)
+	assertGolden(t, "report.html.golden", report)
+
+	assert.NotContains(t, string(report), "<script>")
+	assert.NotContains(t, string(report), "<b>")
+	assert.NotContains(t, string(report), "<nightly>")
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/id"
+)
+
+const (
+	defaultTestRunPageSize = 20
+	maxTestRunPageSize     = 100
+)
+
+// normalizePage aplica los valores por defecto y el máximo de la paginación
+func normalizePage(limit, offset int) (int, int) {
+	if limit <= 0 {
+		limit = defaultTestRunPageSize
+	}
+	if limit > maxTestRunPageSize {
+		limit = maxTestRunPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
+
+func (s *testService) GetTestCaseRuns(ctx context.Context, testCaseID string, limit, offset int) ([]*domain.TestRun, int, error) {
+	limit, offset = normalizePage(limit, offset)
+	return s.testRunRepo.GetByTestCaseID(ctx, testCaseID, limit, offset)
+}
+
+// recordCaseRun guarda la ejecución individual de un caso en el historial.
+// Un fallo al guardar no invalida la ejecución.
+func (s *testService) recordCaseRun(ctx context.Context, testCase *domain.TestCase, result *domain.TestResult, startedAt time.Time) {
+	if s.testRunRepo == nil {
+		return
+	}
+
+	caseResult := buildCaseRunResult(testCase, result)
+	run := &domain.TestRun{
+		ID:          id.New(),
+		Kind:        domain.TestRunKindCase,
+		BotID:       testCase.BotID,
+		TestCaseID:  testCase.ID,
+		Name:        testCase.Name,
+		Total:       1,
+		Duration:    caseResult.ExecutionTime,
+		Cases:       []domain.TestRunCaseResult{caseResult},
+		StartedAt:   startedAt,
+		CompletedAt: time.Now(),
+	}
+	summarizeRun(run)
+
+	previous, _, err := s.testRunRepo.GetByTestCaseID(ctx, testCase.ID, 1, 0)
+	if err == nil && len(previous) > 0 {
+		run.Regressions, run.Fixed = compareRuns(previous[0], run)
+	}
+
+	if err := s.testRunRepo.Create(ctx, run); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to record test run", "test_case_id", testCase.ID, "error", err)
+	}
+}
+
+func (s *testSuiteService) GetTestSuiteRuns(ctx context.Context, suiteID string, limit, offset int) ([]*domain.TestRun, int, error) {
+	limit, offset = normalizePage(limit, offset)
+	return s.testRunRepo.GetBySuiteID(ctx, suiteID, limit, offset)
+}
+
+func (s *testSuiteService) GetTestRun(ctx context.Context, id string) (*domain.TestRun, error) {
+	return s.testRunRepo.GetByID(ctx, id)
+}
+
+// ExportTestRun genera el reporte de una ejecución y devuelve su content type
+func (s *testSuiteService) ExportTestRun(ctx context.Context, id string, format ReportFormat) ([]byte, string, error) {
+	run, err := s.testRunRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get test run: %w", err)
+	}
+
+	switch format {
+	case ReportFormatJUnit:
+		data, err := RenderJUnitReport(run)
+		return data, "application/xml", err
+	case ReportFormatHTML:
+		data, err := RenderHTMLReport(run)
+		return data, "text/html; charset=utf-8", err
+	}
+	return nil, "", fmt.Errorf("unsupported report format: %s", format)
+}
+
+// caseRunResult arma el resultado de un caso dentro de la ejecución de una suite
+func (s *testSuiteService) caseRunResult(ctx context.Context, testCaseID string, result *domain.TestResult) domain.TestRunCaseResult {
+	testCase, err := s.testSvc.GetTestCase(ctx, testCaseID)
+	if err != nil {
+		testCase = &domain.TestCase{ID: testCaseID, Name: testCaseID}
+	}
+	return buildCaseRunResult(testCase, result)
+}
+
+// recordSuiteRun guarda la ejecución de la suite y la compara con la anterior
+func (s *testSuiteService) recordSuiteRun(ctx context.Context, testSuite *domain.TestSuite, result *domain.TestSuiteResult, cases []domain.TestRunCaseResult) {
+	if s.testRunRepo == nil {
+		return
+	}
+
+	run := &domain.TestRun{
+		ID:          id.New(),
+		Kind:        domain.TestRunKindSuite,
+		BotID:       testSuite.BotID,
+		SuiteID:     testSuite.ID,
+		Name:        testSuite.Name,
+		Total:       result.TotalTests,
+		Duration:    result.ExecutionTime,
+		Cases:       cases,
+		StartedAt:   result.StartedAt,
+		CompletedAt: result.CompletedAt,
+	}
+	summarizeRun(run)
+
+	previous, _, err := s.testRunRepo.GetBySuiteID(ctx, testSuite.ID, 1, 0)
+	if err == nil && len(previous) > 0 {
+		run.Regressions, run.Fixed = compareRuns(previous[0], run)
+	}
+
+	if err := s.testRunRepo.Create(ctx, run); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to record test run", "suite_id", testSuite.ID, "error", err)
+	}
+}
+
+func buildCaseRunResult(testCase *domain.TestCase, result *domain.TestResult) domain.TestRunCaseResult {
+	caseResult := domain.TestRunCaseResult{
+		TestCaseID:       testCase.ID,
+		Name:             testCase.Name,
+		ExpectedResponse: testCase.Expected.Response,
+	}
+	if result == nil {
+		caseResult.Skipped = true
+		return caseResult
+	}
+
+	caseResult.Success = result.Success
+	caseResult.ActualResponse = result.ActualResponse
+	caseResult.Failures = result.Failures
+	caseResult.Error = result.Error
+	caseResult.ExecutionTime = result.ExecutionTime
+
+	// Los resultados anteriores a las aserciones no traen la lista de diferencias
+	if !result.Success && result.Error == "" && len(result.Failures) == 0 && testCase.Expected.Response != result.ActualResponse {
+		caseResult.Failures = []string{fmt.Sprintf("response: expected %q, got %q", testCase.Expected.Response, result.ActualResponse)}
+	}
+	return caseResult
+}
+
+// summarizeRun calcula los contadores y el estado a partir de los casos
+func summarizeRun(run *domain.TestRun) {
+	run.Passed, run.Failed, run.Errored, run.Skipped = 0, 0, 0, 0
+	for _, c := range run.Cases {
+		switch {
+		case c.Skipped:
+			run.Skipped++
+		case c.Success:
+			run.Passed++
+		case c.Error != "":
+			run.Errored++
+		default:
+			run.Failed++
+		}
+	}
+
+	failed := run.Failed + run.Errored
+	switch {
+	case failed == 0:
+		run.Status = domain.TestSuiteStatusPassed
+	case run.Passed == 0:
+		run.Status = domain.TestSuiteStatusFailed
+	default:
+		run.Status = domain.TestSuiteStatusPartial
+	}
+}
+
+// compareRuns devuelve los casos que pasaron a fallar y los que se corrigieron
+func compareRuns(previous, current *domain.TestRun) ([]string, []string) {
+	before := make(map[string]bool, len(previous.Cases))
+	for _, c := range previous.Cases {
+		if !c.Skipped {
+			before[c.TestCaseID] = c.Success
+		}
+	}
+
+	var regressions, fixed []string
+	for _, c := range current.Cases {
+		passedBefore, existed := before[c.TestCaseID]
+		if !existed || c.Skipped {
+			continue
+		}
+		if passedBefore && !c.Success {
+			regressions = append(regressions, c.TestCaseID)
+		} else if !passedBefore && c.Success {
+			fixed = append(fixed, c.TestCaseID)
+		}
+	}
+	return regressions, fixed
+}
@@ -5,8 +5,9 @@ import (
 	"fmt"
 	"time"
 
-	"github.com/google/uuid"
 	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
@@ -20,6 +21,17 @@ type TestService interface {
 	DeleteTestCase(ctx context.Context, id string) error
 	ExecuteTestCase(ctx context.Context, id string) (*domain.TestResult, error)
 	BulkExecuteTestCases(ctx context.Context, ids []string) (map[string]*domain.TestResult, error)
+
+	// Pruebas de conversación (varios turnos)
+	CreateMultiTurnTestCase(ctx context.Context, testCase *domain.MultiTurnTestCase) error
+	GetMultiTurnTestCase(ctx context.Context, id string) (*domain.MultiTurnTestCase, error)
+	GetMultiTurnTestCasesByBot(ctx context.Context, botID string) ([]*domain.MultiTurnTestCase, error)
+	UpdateMultiTurnTestCase(ctx context.Context, testCase *domain.MultiTurnTestCase) error
+	DeleteMultiTurnTestCase(ctx context.Context, id string) error
+	ExecuteMultiTurnTestCase(ctx context.Context, id string) (*domain.MultiTurnTestResult, error)
+
+	// Historial de ejecuciones
+	GetTestCaseRuns(ctx context.Context, testCaseID string, limit, offset int) ([]*domain.TestRun, int, error)
 }
 
 // TestSuiteService define las operaciones para manejar suites de prueba
@@ -33,11 +45,19 @@ type TestSuiteService interface {
 	ExecuteTestSuite(ctx context.Context, id string) (*domain.TestSuiteResult, error)
 	AddTestCaseToSuite(ctx context.Context, suiteID, testCaseID string) error
 	RemoveTestCaseFromSuite(ctx context.Context, suiteID, testCaseID string) error
+
+	// Historial de ejecuciones y reportes
+	GetTestSuiteRuns(ctx context.Context, suiteID string, limit, offset int) ([]*domain.TestRun, int, error)
+	GetTestRun(ctx context.Context, id string) (*domain.TestRun, error)
+	ExportTestRun(ctx context.Context, id string, format ReportFormat) ([]byte, string, error)
 }
 
 // testService implementa TestService
 type testService struct {
 	testCaseRepo domain.TestCaseRepository
+	multiTurnRepo domain.MultiTurnTestCaseRepository
+	testRunRepo  domain.TestRunRepository
+	sessionRepo  domain.ConversationSessionRepository
 	botSvc       BotService
 	conditionalSvc ConditionalService
 	triggerSvc   TriggerService
@@ -47,13 +67,19 @@ type testService struct {
 // testSuiteService implementa TestSuiteService
 type testSuiteService struct {
 	testSuiteRepo domain.TestSuiteRepository
+	testRunRepo   domain.TestRunRepository
 	testSvc       TestService
+	eventBus      events.EventBus
+	events        *events.EventFactory
 	logger        logger.Logger
 }
 
 // NewTestService crea una nueva instancia de TestService
 func NewTestService(
 	testCaseRepo domain.TestCaseRepository,
+	multiTurnRepo domain.MultiTurnTestCaseRepository,
+	testRunRepo domain.TestRunRepository,
+	sessionRepo domain.ConversationSessionRepository,
 	botSvc BotService,
 	conditionalSvc ConditionalService,
 	triggerSvc TriggerService,
@@ -61,6 +87,9 @@ func NewTestService(
 ) TestService {
 	return &testService{
 		testCaseRepo:   testCaseRepo,
+		multiTurnRepo:  multiTurnRepo,
+		testRunRepo:    testRunRepo,
+		sessionRepo:    sessionRepo,
 		botSvc:         botSvc,
 		conditionalSvc: conditionalSvc,
 		triggerSvc:     triggerSvc,
@@ -68,15 +97,21 @@ func NewTestService(
 	}
 }
 
-// NewTestSuiteService crea una nueva instancia de TestSuiteService
+// NewTestSuiteService crea una nueva instancia de TestSuiteService. eventBus
+// puede ser nil; si no, recibe un evento test_suite_completed por ejecución.
 func NewTestSuiteService(
 	testSuiteRepo domain.TestSuiteRepository,
+	testRunRepo domain.TestRunRepository,
 	testSvc TestService,
+	eventBus events.EventBus,
 	logger logger.Logger,
 ) TestSuiteService {
 	return &testSuiteService{
 		testSuiteRepo: testSuiteRepo,
+		testRunRepo:   testRunRepo,
 		testSvc:       testSvc,
+		eventBus:      eventBus,
+		events:        events.NewEventFactory("bot-service"),
 		logger:        logger,
 	}
 }
@@ -96,7 +131,10 @@ func (s *testService) GetTestCasesByStatus(ctx context.Context, botID string, st
 
 func (s *testService) CreateTestCase(ctx context.Context, testCase *domain.TestCase) error {
 	if testCase.ID == "" {
-		testCase.ID = uuid.New().String()
+		testCase.ID = id.New()
+	}
+	if err := validateAssertions(testCase.Expected.ResponseMatch, testCase.Expected.Response, testCase.Expected.Assertions); err != nil {
+		return err
 	}
 	testCase.Status = domain.TestStatusPending
 	testCase.CreatedAt = time.Now()
@@ -106,6 +144,9 @@ func (s *testService) CreateTestCase(ctx context.Context, testCase *domain.TestC
 }
 
 func (s *testService) UpdateTestCase(ctx context.Context, testCase *domain.TestCase) error {
+	if err := validateAssertions(testCase.Expected.ResponseMatch, testCase.Expected.Response, testCase.Expected.Assertions); err != nil {
+		return err
+	}
 	testCase.UpdatedAt = time.Now()
 	return s.testCaseRepo.Update(ctx, testCase)
 }
@@ -129,6 +170,7 @@ func (s *testService) ExecuteTestCase(ctx context.Context, id string) (*domain.T
 	}
 	
 	// Ejecutar el caso de prueba
+	startedAt := time.Now()
 	result, err := s.executeTestCase(ctx, testCase)
 	if err != nil {
 		// Actualizar estado a failed
@@ -139,8 +181,10 @@ func (s *testService) ExecuteTestCase(ctx context.Context, id string) (*domain.T
 			ExecutedAt: time.Now(),
 		}
 		s.testCaseRepo.Update(ctx, testCase)
+		s.recordCaseRun(ctx, testCase, testCase.Result, startedAt)
 		return nil, err
 	}
+	s.recordCaseRun(ctx, testCase, result, startedAt)
 	
 	// Actualizar resultado
 	testCase.Result = result
@@ -168,7 +212,7 @@ func (s *testService) executeTestCase(ctx context.Context, testCase *domain.Test
 	
 	// Crear mensaje de entrada
 	message := &domain.IncomingMessage{
-		ID:        uuid.New().String(),
+		ID:        id.New(),
 		BotID:     testCase.BotID,
 		UserID:    testCase.Input.UserID,
 		Content:   testCase.Input.Message,
@@ -187,12 +231,13 @@ func (s *testService) executeTestCase(ctx context.Context, testCase *domain.Test
 		}, nil
 	}
 	
-	// Evaluar condiciones
+	// Evaluar condiciones con el contexto de entrada y la metadata del mensaje
+	evaluationContext := withMessageMetadata(testCase.Input.Context, message)
 	executedConditions := []string{}
 	for _, conditionID := range testCase.Conditions {
-		conditionMet, err := s.conditionalSvc.EvaluateConditional(ctx, conditionID, testCase.Input.Context)
+		conditionMet, err := s.conditionalSvc.EvaluateConditional(ctx, conditionID, evaluationContext)
 		if err != nil {
-			s.logger.Error("Failed to evaluate condition", "condition_id", conditionID, "error", err)
+			s.logger.WithContext(ctx).Error("Failed to evaluate condition", "condition_id", conditionID, "error", err)
 			continue
 		}
 		if conditionMet {
@@ -203,35 +248,53 @@ func (s *testService) executeTestCase(ctx context.Context, testCase *domain.Test
 	// Evaluar triggers
 	executedTriggers := []string{}
 	for _, triggerID := range testCase.Triggers {
-		if err := s.triggerSvc.ExecuteTrigger(ctx, triggerID, testCase.Input.Context); err != nil {
-			s.logger.Error("Failed to execute trigger", "trigger_id", triggerID, "error", err)
+		if err := s.triggerSvc.ExecuteTrigger(ctx, triggerID, evaluationContext); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to execute trigger", "trigger_id", triggerID, "error", err)
 		} else {
 			executedTriggers = append(executedTriggers, triggerID)
 		}
 	}
 	
+	// El contexto real es el de la sesión; si no existe se usa el de entrada
+	actualNextStep, actualContext := s.sessionState(ctx, testCase.BotID, testCase.Input.UserID)
+	if actualContext == nil {
+		actualContext = testCase.Input.Context
+	}
+	
 	// Verificar resultados esperados
-	success := s.verifyExpectedResults(testCase, response, executedConditions, executedTriggers)
+	failures := s.verifyExpectedResults(testCase, response, actualContext, executedConditions, executedTriggers)
 	
 	executionTime := time.Since(startTime).Milliseconds()
 	
 	return &domain.TestResult{
-		Success:            success,
+		Success:            len(failures) == 0,
 		ActualResponse:     response.Content,
-		ActualNextStep:     "",
+		ActualNextStep:     actualNextStep,
 		ExecutedConditions: executedConditions,
 		ExecutedTriggers:   executedTriggers,
-		ActualContext:      testCase.Input.Context,
+		ActualContext:      actualContext,
 		ExecutionTime:      executionTime,
+		Failures:           failures,
 		ExecutedAt:         time.Now(),
 	}, nil
 }
 
-// verifyExpectedResults verifica si los resultados coinciden con lo esperado
-func (s *testService) verifyExpectedResults(testCase *domain.TestCase, response *domain.BotResponse, executedConditions, executedTriggers []string) bool {
+// verifyExpectedResults verifica los resultados contra lo esperado y
+// devuelve la lista de diferencias
+func (s *testService) verifyExpectedResults(testCase *domain.TestCase, response *domain.BotResponse, actualContext map[string]interface{}, executedConditions, executedTriggers []string) []string {
+	var failures []string
+	expected := testCase.Expected
+	
 	// Verificar respuesta esperada
-	if testCase.Expected.Response != "" && response.Content != testCase.Expected.Response {
-		return false
+	if expected.Response != "" {
+		if failure := matchValue(expected.ResponseMatch, expected.Response, response.Content, true, expected.SimilarityThreshold); failure != "" {
+			failures = append(failures, "response: "+failure)
+		}
+	}
+	
+	// Verificar aserciones sobre metadata y contexto
+	if len(expected.Assertions) > 0 {
+		failures = append(failures, evaluateAssertions(expected.Assertions, assertionDocument(response, actualContext))...)
 	}
 	
 	// Verificar condiciones esperadas
@@ -245,7 +308,7 @@ func (s *testService) verifyExpectedResults(testCase *domain.TestCase, response
 				}
 			}
 			if !found {
-				return false
+				failures = append(failures, fmt.Sprintf("condition %s was not met", expectedCondition))
 			}
 		}
 	}
@@ -261,12 +324,12 @@ func (s *testService) verifyExpectedResults(testCase *domain.TestCase, response
 				}
 			}
 			if !found {
-				return false
+				failures = append(failures, fmt.Sprintf("trigger %s was not executed", expectedTrigger))
 			}
 		}
 	}
 	
-	return true
+	return failures
 }
 
 // Implementación de TestSuiteService
@@ -284,7 +347,7 @@ func (s *testSuiteService) GetTestSuitesByStatus(ctx context.Context, botID stri
 
 func (s *testSuiteService) CreateTestSuite(ctx context.Context, testSuite *domain.TestSuite) error {
 	if testSuite.ID == "" {
-		testSuite.ID = uuid.New().String()
+		testSuite.ID = id.New()
 	}
 	testSuite.Status = domain.TestSuiteStatusPending
 	testSuite.CreatedAt = time.Now()
@@ -325,20 +388,24 @@ func (s *testSuiteService) ExecuteTestSuite(ctx context.Context, id string) (*do
 	failedTests := 0
 	skippedTests := 0
 	
+	caseResults := make([]domain.TestRunCaseResult, 0, totalTests)
+	
 	for _, testCaseID := range testSuite.TestCases {
 		result, err := s.testSvc.ExecuteTestCase(ctx, testCaseID)
 		if err != nil {
-			s.logger.Error("Failed to execute test case", "test_case_id", testCaseID, "error", err)
+			s.logger.WithContext(ctx).Error("Failed to execute test case", "test_case_id", testCaseID, "error", err)
 			failedTests++
 			testResults[testCaseID] = &domain.TestResult{
 				Success:    false,
 				Error:      err.Error(),
 				ExecutedAt: time.Now(),
 			}
+			caseResults = append(caseResults, s.caseRunResult(ctx, testCaseID, testResults[testCaseID]))
 			continue
 		}
 		
 		testResults[testCaseID] = result
+		caseResults = append(caseResults, s.caseRunResult(ctx, testCaseID, result))
 		if result.Success {
 			passedTests++
 		} else {
@@ -383,9 +450,33 @@ func (s *testSuiteService) ExecuteTestSuite(ctx context.Context, id string) (*do
 		return nil, fmt.Errorf("failed to update test suite result: %w", err)
 	}
 	
+	s.recordSuiteRun(ctx, testSuite, result, caseResults)
+	s.publishSuiteCompleted(ctx, testSuite, result)
+	
 	return result, nil
 }
 
+func (s *testSuiteService) publishSuiteCompleted(ctx context.Context, testSuite *domain.TestSuite, result *domain.TestSuiteResult) {
+	if s.eventBus == nil {
+		return
+	}
+
+	event := s.events.CreateSystemEvent(events.EventTypeTestSuiteCompleted, map[string]interface{}{
+		"bot_id":         testSuite.BotID,
+		"suite_id":       testSuite.ID,
+		"name":           testSuite.Name,
+		"status":         testSuite.Status,
+		"total_tests":    result.TotalTests,
+		"passed_tests":   result.PassedTests,
+		"failed_tests":   result.FailedTests,
+		"success_rate":   result.SuccessRate,
+		"execution_time": result.ExecutionTime,
+	})
+	if err := s.eventBus.Publish(ctx, event); err != nil {
+		s.logger.WithContext(ctx).Error("Failed to publish test suite completed event", "suite_id", testSuite.ID, "error", err)
+	}
+}
+
 func (s *testSuiteService) AddTestCaseToSuite(ctx context.Context, suiteID, testCaseID string) error {
 	return s.testSuiteRepo.AddTestCase(ctx, suiteID, testCaseID)
 }
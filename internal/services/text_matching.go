@@ -0,0 +1,149 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// diacriticsReplacer pliega los acentos más comunes del español y portugués
+var diacriticsReplacer = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// normalizeText pasa a minúsculas, elimina acentos y signos de puntuación
+func normalizeText(text string) string {
+	text = diacriticsReplacer.Replace(strings.ToLower(text))
+	return strings.Join(tokenize(text), " ")
+}
+
+// tokenize divide el texto en palabras ignorando puntuación
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// levenshtein calcula la distancia de edición entre dos palabras
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra) == 0 {
+		return len(rb)
+	}
+	if len(rb) == 0 {
+		return len(ra)
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// phraseDistance busca la frase dentro de los tokens y devuelve la menor distancia
+// acumulada, o -1 si alguna palabra supera maxDistance
+func phraseDistance(phrase []string, tokens []string, maxDistance int) int {
+	if len(phrase) == 0 || len(phrase) > len(tokens) {
+		return -1
+	}
+
+	best := -1
+	for start := 0; start+len(phrase) <= len(tokens); start++ {
+		total := 0
+		for i, word := range phrase {
+			allowed := maxDistance
+			// Las palabras cortas no toleran errores para evitar falsos positivos
+			if len([]rune(word)) <= 3 {
+				allowed = 0
+			}
+			d := levenshtein(word, tokens[start+i])
+			if d > allowed {
+				total = -1
+				break
+			}
+			total += d
+		}
+		if total >= 0 && (best < 0 || total < best) {
+			best = total
+		}
+	}
+
+	return best
+}
+
+// matchFlowTrigger evalúa si el mensaje activa el flujo. Devuelve la distancia
+// de la mejor coincidencia (0 = exacta) o -1 si no coincide.
+func matchFlowTrigger(flow *domain.BotFlow, message string) int {
+	normalized := normalizeText(message)
+	if normalized == "" {
+		return -1
+	}
+
+	if flow.Trigger != "" && normalizeText(flow.Trigger) == normalized {
+		return 0
+	}
+
+	config := flow.TriggerConfig
+	if config == nil {
+		return -1
+	}
+
+	tokens := tokenize(normalized)
+	candidates := make([]string, 0, len(config.Keywords)+1)
+	if flow.Trigger != "" {
+		candidates = append(candidates, flow.Trigger)
+	}
+	for _, keyword := range config.Keywords {
+		candidates = append(candidates, keyword)
+		candidates = append(candidates, synonymsFor(config.Synonyms, keyword)...)
+	}
+
+	best := -1
+	for _, candidate := range candidates {
+		phrase := tokenize(normalizeText(candidate))
+		if d := phraseDistance(phrase, tokens, config.MaxDistance); d >= 0 && (best < 0 || d < best) {
+			best = d
+		}
+	}
+
+	return best
+}
+
+// synonymsFor devuelve los sinónimos configurados para una palabra clave
+func synonymsFor(synonyms map[string][]string, keyword string) []string {
+	if len(synonyms) == 0 {
+		return nil
+	}
+	if values, ok := synonyms[keyword]; ok {
+		return values
+	}
+
+	normalized := normalizeText(keyword)
+	for key, values := range synonyms {
+		if normalizeText(key) == normalized {
+			return values
+		}
+	}
+	return nil
+}
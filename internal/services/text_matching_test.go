@@ -0,0 +1,52 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeText(t *testing.T) {
+	assert.Equal(t, "hola que tal", normalizeText("  ¡Hola!  ¿Qué tal? "))
+	assert.Equal(t, "cancelacion", normalizeText("CANCELACIÓN"))
+}
+
+func TestLevenshtein(t *testing.T) {
+	assert.Equal(t, 0, levenshtein("pedido", "pedido"))
+	assert.Equal(t, 1, levenshtein("pedido", "pedid"))
+	assert.Equal(t, 2, levenshtein("factura", "fatcura"))
+	assert.Equal(t, 3, levenshtein("", "abc"))
+}
+
+func TestMatchFlowTrigger(t *testing.T) {
+	flow := &domain.BotFlow{
+		Trigger: "Soporte técnico",
+		TriggerConfig: &domain.FlowTrigger{
+			Keywords:    []string{"factura", "estado del pedido"},
+			Synonyms:    map[string][]string{"factura": {"recibo", "comprobante"}},
+			MaxDistance: 1,
+		},
+	}
+
+	assert.Equal(t, 0, matchFlowTrigger(flow, "soporte tecnico"))
+	assert.Equal(t, 0, matchFlowTrigger(flow, "necesito mi recibo"))
+	assert.Equal(t, 1, matchFlowTrigger(flow, "quiero ver el estado del pedio"))
+	assert.Equal(t, 1, matchFlowTrigger(flow, "mi fatura por favor"))
+	assert.Equal(t, -1, matchFlowTrigger(flow, "hola"))
+
+	exactOnly := &domain.BotFlow{Trigger: "menu"}
+	assert.Equal(t, 0, matchFlowTrigger(exactOnly, "Menú"))
+	assert.Equal(t, -1, matchFlowTrigger(exactOnly, "ver menu"))
+}
+
+func TestMatchGlobalIntent(t *testing.T) {
+	intents := []domain.GlobalIntent{
+		{Name: "cancel", Keywords: []string{"cancelar"}},
+		{Name: "agent", Keywords: []string{"hablar con un agente"}},
+	}
+
+	assert.Equal(t, "cancel", matchGlobalIntent(intents, "Quiero CANCELAR").Name)
+	assert.Equal(t, "agent", matchGlobalIntent(intents, "¿puedo hablar con un agente?").Name)
+	assert.Nil(t, matchGlobalIntent(intents, "cancelaron mi pedido"))
+}
@@ -0,0 +1,269 @@
+package services
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/id"
+)
+
+const (
+	// UserLanguageContextKey guarda en la sesión el idioma en que escribe el usuario
+	UserLanguageContextKey = "user_language"
+	// OriginalContentMetadataKey guarda el mensaje sin traducir
+	OriginalContentMetadataKey = "original_content"
+
+	// translationCacheSize es el máximo de traducciones guardadas entre todos los bots
+	translationCacheSize = 5000
+	// translationCacheTTL es cuánto se reutiliza una traducción
+	translationCacheTTL = 24 * time.Hour
+)
+
+// messageLanguage devuelve el idioma indicado por el canal, si lo hay
+// (Telegram envía language_code)
+func messageLanguage(message *domain.IncomingMessage) string {
+	for _, key := range []string{"language", "language_code"} {
+		if language, ok := message.Metadata[key].(string); ok && language != "" {
+			return language
+		}
+	}
+	return ""
+}
+
+// translateIncoming traduce el mensaje al idioma del bot y devuelve el idioma
+// del usuario. Si la traducción falla el mensaje sigue sin traducir.
+func (s *botService) translateIncoming(ctx context.Context, message *domain.IncomingMessage, session *domain.ConversationSession, config *domain.TranslationConfig) string {
+	source := messageLanguage(message)
+	if source == "" {
+		source, _ = session.Context[UserLanguageContextKey].(string)
+	}
+	if source == "" {
+		source = "auto"
+	}
+
+	output, err := s.translate(ctx, message.BotID, message.Content, source, config.BotLanguage, config)
+	if err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to translate incoming message", "bot_id", message.BotID, "message_id", message.ID, "error", err)
+		if source == "auto" {
+			return ""
+		}
+		return source
+	}
+
+	userLanguage := source
+	if detected, _ := output["detected_language"].(string); source == "auto" && detected != "" {
+		userLanguage = detected
+	}
+	if userLanguage == "auto" {
+		userLanguage = ""
+	}
+	if userLanguage != "" {
+		if session.Context == nil {
+			session.Context = make(map[string]interface{})
+		}
+		session.Context[UserLanguageContextKey] = userLanguage
+	}
+
+	if translated, _ := output["translated"].(bool); translated {
+		if message.Metadata == nil {
+			message.Metadata = make(map[string]interface{})
+		}
+		message.Metadata[OriginalContentMetadataKey] = message.Content
+		message.Content, _ = output["text"].(string)
+	}
+	return userLanguage
+}
+
+// translateResponse traduce la respuesta y sus opciones al idioma del usuario
+func (s *botService) translateResponse(ctx context.Context, botID string, response *domain.BotResponse, userLanguage string, config *domain.TranslationConfig) {
+	if response == nil || userLanguage == "" || strings.EqualFold(userLanguage, config.BotLanguage) {
+		return
+	}
+	// Las respuestas de imagen llevan una URL en el contenido
+	if response.Type == domain.ResponseTypeImage {
+		return
+	}
+
+	translated := false
+	if strings.TrimSpace(response.Content) != "" {
+		output, err := s.translate(ctx, botID, response.Content, config.BotLanguage, userLanguage, config)
+		if err != nil {
+			s.logger.WithContext(ctx).Warn("Failed to translate response", "bot_id", botID, "error", err)
+			return
+		}
+		if ok, _ := output["translated"].(bool); ok {
+			response.Content, _ = output["text"].(string)
+			translated = true
+		}
+	}
+
+	for i := range response.Options {
+		output, err := s.translate(ctx, botID, response.Options[i].Text, config.BotLanguage, userLanguage, config)
+		if err != nil {
+			continue
+		}
+		if ok, _ := output["translated"].(bool); ok {
+			response.Options[i].Text, _ = output["text"].(string)
+			translated = true
+		}
+	}
+
+	if translated {
+		if response.Metadata == nil {
+			response.Metadata = make(map[string]interface{})
+		}
+		response.Metadata["translated_to"] = userLanguage
+	}
+}
+
+// translate ejecuta una tarea de traducción con el glosario y los pares del bot.
+// Las frases repetidas (saludos, opciones de menú) se sirven desde la caché.
+func (s *botService) translate(ctx context.Context, botID, text, source, target string, config *domain.TranslationConfig) (map[string]interface{}, error) {
+	key := translationCacheKey(botID, text, source, target, config)
+	if output, ok := s.translations.get(key, s.clock.Now()); ok {
+		return output, nil
+	}
+
+	output, err := s.translateWithAgent(ctx, botID, text, source, target, config)
+	if err != nil {
+		return nil, err
+	}
+	// Los textos devueltos sin traducir por un fallo se reintentan la próxima vez
+	if _, failed := output["fallback_reason"]; !failed {
+		s.translations.put(key, output, s.clock.Now())
+	}
+	return output, nil
+}
+
+func (s *botService) translateWithAgent(ctx context.Context, botID, text, source, target string, config *domain.TranslationConfig) (map[string]interface{}, error) {
+	agentConfig := make(map[string]interface{}, len(config.Config)+2)
+	for key, value := range config.Config {
+		agentConfig[key] = value
+	}
+	agentConfig["glossary"] = config.Glossary
+	agentConfig["language_pairs"] = config.LanguagePairs
+
+	agent, err := s.instantiateAgent(ctx, mcp.MCPConfig{
+		Type:         "translation",
+		Name:         fmt.Sprintf("translation-agent-%s", botID),
+		Version:      "1.0",
+		Config:       agentConfig,
+		Capabilities: []string{"translation"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate translation agent: %w", err)
+	}
+	defer func() {
+		if err := s.mcpOrchestrator.TerminateAgent(ctx, agent.GetID()); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
+		}
+	}()
+
+	result, err := agent.Execute(ctx, mcp.Task{
+		ID:   fmt.Sprintf("translation-%s-%s", botID, id.New()),
+		Type: "translation",
+		Input: map[string]interface{}{
+			"text":            text,
+			"source_language": source,
+			"target_language": target,
+		},
+		Priority: 5,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("translation failed: %s", result.Error)
+	}
+	return result.Output, nil
+}
+
+// translationCacheKey identifica una traducción; incluye la configuración para
+// que un cambio de glosario o de modelo no reutilice traducciones viejas
+func translationCacheKey(botID, text, source, target string, config *domain.TranslationConfig) string {
+	configJSON, _ := json.Marshal(config)
+	hash := sha256.New()
+	for _, part := range []string{botID, source, target, string(configJSON), text} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// translationCache es una caché LRU con caducidad de resultados de traducción
+type translationCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type translationCacheEntry struct {
+	key       string
+	output    map[string]interface{}
+	expiresAt time.Time
+}
+
+func newTranslationCache(capacity int, ttl time.Duration) *translationCache {
+	return &translationCache{
+		capacity: capacity,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (c *translationCache) get(key string, now time.Time) (map[string]interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*translationCacheEntry)
+	if !now.Before(entry.expiresAt) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return copyOutput(entry.output), true
+}
+
+func (c *translationCache) put(key string, output map[string]interface{}, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry := &translationCacheEntry{key: key, output: copyOutput(output), expiresAt: now.Add(c.ttl)}
+	if element, ok := c.entries[key]; ok {
+		element.Value = entry
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*translationCacheEntry).key)
+	}
+}
+
+// copyOutput evita que quien recibe una traducción modifique la guardada
+func copyOutput(output map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(output))
+	for key, value := range output {
+		copied[key] = value
+	}
+	return copied
+}
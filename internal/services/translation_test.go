@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslation_CachesRepeatedPhrases(t *testing.T) {
+	translations := map[string]string{"hello": "hola", "Hola, ¿en qué te ayudo?": "Hi, how can I help?"}
+	var requests atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		content, _ := json.Marshal(map[string]string{"detected_language": "en", "translation": translations[body.Messages[1].Content]})
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": string(content)}}},
+		})
+	}))
+	defer api.Close()
+
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		NewConversationService(sessionRepo, nil, log), nil, nil, nil, nil,
+		mcp.NewOrchestrator(mcp.NewAgentFactory(log), log), nil, nil, log)
+
+	config, _ := json.Marshal(domain.BotConfig{Translation: &domain.TranslationConfig{
+		AutoTranslate: true,
+		BotLanguage:   "es",
+		Config:        map[string]interface{}{"openai_api_key": "sk-live", "base_url": api.URL},
+	}})
+	require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: "bot-1", Status: domain.BotStatusActive, Config: config}))
+	require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-1", BotID: "bot-1", EntryPoint: "greet", IsDefault: true}))
+	require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "greet", FlowID: "flow-1", Type: domain.StepTypeMessage,
+		Content: json.RawMessage(`{"text":"Hola, ¿en qué te ayudo?"}`)}))
+
+	for _, userID := range []string{"user-1", "user-2"} {
+		response, err := bots.ProcessIncomingMessage(ctx, &domain.IncomingMessage{BotID: "bot-1", UserID: userID, Content: "hello",
+			Metadata: map[string]interface{}{"language": "en"}})
+		require.NoError(t, err)
+		assert.Equal(t, "Hi, how can I help?", response.Content)
+	}
+	// Entrada y respuesta del primer usuario; el segundo sale entero de la caché
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestTranslationCache_EvictsAndExpires(t *testing.T) {
+	now := time.Date(2024, 6, 12, 10, 0, 0, 0, time.UTC)
+	cache := newTranslationCache(2, time.Hour)
+
+	cache.put("a", map[string]interface{}{"text": "A"}, now)
+	cache.put("b", map[string]interface{}{"text": "B"}, now)
+	_, ok := cache.get("a", now)
+	require.True(t, ok)
+	cache.put("c", map[string]interface{}{"text": "C"}, now)
+
+	_, ok = cache.get("b", now)
+	assert.False(t, ok, "least recently used entry is evicted")
+	output, ok := cache.get("a", now)
+	require.True(t, ok)
+	output["text"] = "changed"
+	output, _ = cache.get("a", now)
+	assert.Equal(t, "A", output["text"])
+
+	_, ok = cache.get("c", now.Add(time.Hour))
+	assert.False(t, ok)
+}
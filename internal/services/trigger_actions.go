@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/company/bot-service/internal/adapters"
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/id"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// Tipos de acción built-in de los triggers
+const (
+	ActionSendMessage = "send_message"
+	ActionHTTPCall    = "http_call"
+	ActionRunMCPTask  = "run_mcp_task"
+	ActionSetMemory   = "set_memory"
+	ActionEmitEvent   = "emit_event"
+	ActionEscalate    = "escalate"
+)
+
+// ActionExecutor ejecuta un tipo de acción de trigger. Las acciones
+// personalizadas se registran con TriggerService.RegisterAction.
+type ActionExecutor interface {
+	Type() string
+	Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error
+}
+
+// ChannelSender entrega un mensaje al usuario por su canal
+type ChannelSender interface {
+	Send(ctx context.Context, channel, userID, content string) error
+}
+
+// ConversationEscalator deriva una conversación a un agente humano
+type ConversationEscalator interface {
+	EscalateConversation(ctx context.Context, sessionID, queue, reason string) (*domain.ConversationSession, error)
+}
+
+// TriggerActionDeps agrupa las dependencias de las acciones built-in.
+// Las acciones cuya dependencia es nil no se registran.
+type TriggerActionDeps struct {
+	Sender       ChannelSender
+	HTTP         adapters.HTTPAdapter
+	Orchestrator mcp.MCPOrchestrator
+	Memory       MemoryService
+	EventBus     events.EventBus
+	Escalator    ConversationEscalator
+}
+
+// builtinActions construye las acciones disponibles con las dependencias dadas
+func builtinActions(deps TriggerActionDeps) []ActionExecutor {
+	var actions []ActionExecutor
+	if deps.Sender != nil {
+		actions = append(actions, &sendMessageAction{sender: deps.Sender})
+	}
+	if deps.HTTP != nil {
+		actions = append(actions, &httpCallAction{http: deps.HTTP})
+	}
+	if deps.Orchestrator != nil {
+		actions = append(actions, &runMCPTaskAction{orchestrator: deps.Orchestrator})
+	}
+	if deps.Memory != nil {
+		actions = append(actions, &setMemoryAction{memory: deps.Memory})
+	}
+	if deps.EventBus != nil {
+		actions = append(actions, &emitEventAction{bus: deps.EventBus, events: events.NewEventFactory("trigger-service")})
+	}
+	if deps.Escalator != nil {
+		actions = append(actions, &escalateAction{escalator: deps.Escalator})
+	}
+	return actions
+}
+
+// resolveActionConfig reemplaza {{variables}} de la configuración con los datos del evento
+func resolveActionConfig(config, eventData map[string]interface{}) map[string]interface{} {
+	resolved := make(map[string]interface{}, len(config))
+	for key, value := range config {
+		resolved[key] = resolveActionValue(value, eventData)
+	}
+	return resolved
+}
+
+func resolveActionValue(value interface{}, eventData map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		// Una referencia completa conserva el tipo original del dato
+		trimmed := strings.TrimSpace(v)
+		if strings.HasPrefix(trimmed, "{{") && strings.HasSuffix(trimmed, "}}") && strings.Count(trimmed, "{{") == 1 {
+			if resolved := lookupVariable(strings.TrimSpace(trimmed[2:len(trimmed)-2]), eventData); resolved != nil {
+				return resolved
+			}
+		}
+		return replaceTemplateVariables(v, eventData)
+	case map[string]interface{}:
+		return resolveActionConfig(v, eventData)
+	case []interface{}:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = resolveActionValue(item, eventData)
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+func configString(config map[string]interface{}, key, fallback string) string {
+	if value, ok := config[key]; ok && value != nil {
+		if s := fmt.Sprintf("%v", value); s != "" {
+			return s
+		}
+	}
+	return fallback
+}
+
+func eventString(eventData map[string]interface{}, key string) string {
+	if value, ok := eventData[key]; ok && value != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return ""
+}
+
+// sendMessageAction envía un mensaje al usuario: {channel, user_id, text|message}
+type sendMessageAction struct {
+	sender ChannelSender
+}
+
+func (a *sendMessageAction) Type() string { return ActionSendMessage }
+
+func (a *sendMessageAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	text := configString(config, "text", configString(config, "message", ""))
+	if text == "" {
+		return fmt.Errorf("send_message requires text")
+	}
+
+	userID := configString(config, "user_id", eventString(eventData, "user_id"))
+	if userID == "" {
+		return fmt.Errorf("send_message requires user_id")
+	}
+
+	channel := configString(config, "channel", eventString(eventData, "channel"))
+	return a.sender.Send(ctx, channel, userID, text)
+}
+
+// httpCallAction realiza una llamada HTTP: {method, url, headers, body}
+type httpCallAction struct {
+	http adapters.HTTPAdapter
+}
+
+func (a *httpCallAction) Type() string { return ActionHTTPCall }
+
+func (a *httpCallAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	url := configString(config, "url", "")
+	if url == "" {
+		return fmt.Errorf("http_call requires url")
+	}
+
+	headers := make(map[string]string)
+	if raw, ok := config["headers"].(map[string]interface{}); ok {
+		for key, value := range raw {
+			headers[key] = fmt.Sprintf("%v", value)
+		}
+	}
+
+	response, err := a.http.MakeRequest(ctx, &adapters.HTTPRequest{
+		Method:      strings.ToUpper(configString(config, "method", "POST")),
+		URL:         url,
+		Headers:     headers,
+		Body:        config["body"],
+		AuthProfile: configString(config, "auth_profile", ""),
+	})
+	if err != nil {
+		return fmt.Errorf("http_call failed: %w", err)
+	}
+	if !response.Success {
+		return fmt.Errorf("http_call returned status %d: %s", response.StatusCode, response.Error)
+	}
+	return nil
+}
+
+// runMCPTaskAction ejecuta una tarea en un agente MCP: {task_type, input, priority}
+type runMCPTaskAction struct {
+	orchestrator mcp.MCPOrchestrator
+}
+
+func (a *runMCPTaskAction) Type() string { return ActionRunMCPTask }
+
+func (a *runMCPTaskAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	taskType := configString(config, "task_type", "")
+	if taskType == "" {
+		return fmt.Errorf("run_mcp_task requires task_type")
+	}
+
+	input, _ := config["input"].(map[string]interface{})
+	if input == nil {
+		input = eventData
+	}
+
+	priority := 5
+	if value, ok := toFloat(config["priority"]); ok {
+		priority = int(value)
+	}
+
+	result, err := a.orchestrator.ExecuteTask(ctx, mcp.Task{
+		ID:          fmt.Sprintf("trigger-%s-%s", trigger.ID, id.New()),
+		Type:        taskType,
+		Description: fmt.Sprintf("Action of trigger %s", trigger.Name),
+		Input:       input,
+		Priority:    priority,
+		Metadata: map[string]interface{}{
+			"trigger_id": trigger.ID,
+			"bot_id":     trigger.BotID,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("run_mcp_task failed: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("run_mcp_task failed: %s", result.Error)
+	}
+	return nil
+}
+
+// setMemoryAction guarda una memoria del usuario: {key, value, type, importance, user_id}
+type setMemoryAction struct {
+	memory MemoryService
+}
+
+func (a *setMemoryAction) Type() string { return ActionSetMemory }
+
+func (a *setMemoryAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	key := configString(config, "key", "")
+	if key == "" {
+		return fmt.Errorf("set_memory requires key")
+	}
+
+	userID := configString(config, "user_id", eventString(eventData, "user_id"))
+	if userID == "" {
+		return fmt.Errorf("set_memory requires user_id")
+	}
+
+	importance := 5
+	if value, ok := toFloat(config["importance"]); ok {
+		importance = int(value)
+	}
+
+	return a.memory.StoreMemory(ctx, &domain.Memory{
+		UserID:     userID,
+		BotID:      trigger.BotID,
+		Key:        key,
+		Type:       domain.MemoryType(configString(config, "type", string(domain.MemoryTypeFact))),
+		Content:    map[string]interface{}{"value": config["value"]},
+		Tags:       []string{"trigger:" + trigger.ID},
+		Importance: importance,
+	})
+}
+
+// emitEventAction publica un evento en el bus: {event_type, data}
+type emitEventAction struct {
+	bus    events.EventBus
+	events *events.EventFactory
+}
+
+func (a *emitEventAction) Type() string { return ActionEmitEvent }
+
+func (a *emitEventAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	eventType := configString(config, "event_type", "")
+	if eventType == "" {
+		return fmt.Errorf("emit_event requires event_type")
+	}
+
+	data, _ := config["data"].(map[string]interface{})
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+	data["trigger_id"] = trigger.ID
+	data["bot_id"] = trigger.BotID
+
+	return a.bus.Publish(ctx, a.events.CreateUserEvent(eventType, eventString(eventData, "user_id"), data))
+}
+
+// escalateAction deriva la conversación del evento a un humano: {queue, reason, session_id}
+type escalateAction struct {
+	escalator ConversationEscalator
+}
+
+func (a *escalateAction) Type() string { return ActionEscalate }
+
+func (a *escalateAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	sessionID := configString(config, "session_id", eventString(eventData, "session_id"))
+	if sessionID == "" {
+		return fmt.Errorf("escalate requires session_id")
+	}
+
+	reason := configString(config, "reason", "trigger:"+trigger.Name)
+	_, err := a.escalator.EscalateConversation(ctx, sessionID, configString(config, "queue", ""), reason)
+	return err
+}
+
+// logChannelSender registra los mensajes salientes; se usa mientras no haya adaptadores de canal
+type logChannelSender struct {
+	logger logger.Logger
+}
+
+// NewLogChannelSender crea un ChannelSender que sólo registra los mensajes
+func NewLogChannelSender(logger logger.Logger) ChannelSender {
+	return &logChannelSender{logger: logger}
+}
+
+func (s *logChannelSender) Send(ctx context.Context, channel, userID, content string) error {
+	s.logger.WithContext(ctx).Info("Outgoing message", "channel", channel, "user_id", userID, "content", content)
+	return nil
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAction guarda la configuración resuelta y el contexto de cada ejecución
+type recordingAction struct {
+	actionType string
+	err        error
+	configs    []map[string]interface{}
+	deadlines  []bool
+}
+
+func (a *recordingAction) Type() string { return a.actionType }
+
+func (a *recordingAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	a.configs = append(a.configs, config)
+	_, hasDeadline := ctx.Deadline()
+	a.deadlines = append(a.deadlines, hasDeadline)
+	return a.err
+}
+
+type sentMessage struct {
+	channel, userID, content string
+}
+
+type recordingSender struct {
+	sent []sentMessage
+}
+
+func (s *recordingSender) Send(ctx context.Context, channel, userID, content string) error {
+	s.sent = append(s.sent, sentMessage{channel, userID, content})
+	return nil
+}
+
+func newTestTriggerService(deps TriggerActionDeps) TriggerService {
+	log := logger.NewLogger("error")
+	return NewTriggerService(repositories.NewMockTriggerRepository(), NewConditionalService(repositories.NewMockConditionalRepository(), log), deps, log)
+}
+
+func TestTriggerService_RegisterAction(t *testing.T) {
+	// Sólo se registran las acciones built-in con dependencia
+	triggers := newTestTriggerService(TriggerActionDeps{
+		Sender:   &recordingSender{},
+		EventBus: events.NewInMemoryEventBus(logger.NewLogger("error")),
+	})
+	assert.Equal(t, []string{ActionEmitEvent, ActionSendMessage}, triggers.ListActionTypes())
+
+	require.NoError(t, triggers.RegisterAction(&recordingAction{actionType: "create_ticket"}))
+	assert.Equal(t, []string{"create_ticket", ActionEmitEvent, ActionSendMessage}, triggers.ListActionTypes())
+
+	err := triggers.RegisterAction(&recordingAction{actionType: "create_ticket"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "action type create_ticket already registered")
+
+	// Una acción personalizada no reemplaza a una built-in
+	err = triggers.RegisterAction(&recordingAction{actionType: ActionSendMessage})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "action type send_message already registered")
+
+	assert.Error(t, triggers.RegisterAction(nil))
+	assert.Error(t, triggers.RegisterAction(&recordingAction{}))
+	assert.Len(t, triggers.ListActionTypes(), 3)
+}
+
+func TestTriggerService_UnknownAction(t *testing.T) {
+	ctx := context.Background()
+	triggers := newTestTriggerService(TriggerActionDeps{})
+
+	trigger := &domain.Trigger{BotID: "bot-1", Event: domain.TriggerEventMessageReceived, Enabled: true,
+		Action: domain.TriggerAction{Type: ActionSendMessage, Config: map[string]interface{}{"text": "hi"}}}
+	require.NoError(t, triggers.CreateTrigger(ctx, trigger))
+
+	// send_message no existe sin un ChannelSender
+	err := triggers.ExecuteTrigger(ctx, trigger.ID, map[string]interface{}{"user_id": "user-1"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported action type: send_message")
+
+	fired, err := triggers.FireTrigger(ctx, trigger, nil)
+	assert.Error(t, err)
+	assert.False(t, fired)
+}
+
+func TestTriggerService_DispatchesActions(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewInMemoryEventBus(logger.NewLogger("error"))
+	fired := make(chan events.Event, 2)
+	require.NoError(t, bus.Subscribe(events.EventTypeTriggerFired, func(ctx context.Context, event events.Event) error {
+		fired <- event
+		return nil
+	}))
+	sender := &recordingSender{}
+	triggers := newTestTriggerService(TriggerActionDeps{Sender: sender, EventBus: bus})
+
+	custom := &recordingAction{actionType: "create_ticket"}
+	require.NoError(t, triggers.RegisterAction(custom))
+
+	greet := &domain.Trigger{BotID: "bot-1", Name: "greet", Event: domain.TriggerEventMessageReceived, Enabled: true,
+		Action: domain.TriggerAction{Type: ActionSendMessage, Config: map[string]interface{}{"text": "Hello {{name}}"}}}
+	ticket := &domain.Trigger{BotID: "bot-1", Name: "ticket", Event: domain.TriggerEventMessageReceived, Enabled: true,
+		Action: domain.TriggerAction{Type: "create_ticket", Timeout: 1000, Config: map[string]interface{}{
+			"amount":  "{{order.amount}}",
+			"summary": "Order {{order.id}} for {{name}}",
+			"tags":    []interface{}{"{{channel}}", "support"},
+		}}}
+	for _, trigger := range []*domain.Trigger{greet, ticket} {
+		require.NoError(t, triggers.CreateTrigger(ctx, trigger))
+	}
+
+	eventData := map[string]interface{}{
+		"user_id": "user-1",
+		"channel": "web",
+		"name":    "Ana",
+		"order":   map[string]interface{}{"id": "A1", "amount": 42.5},
+	}
+	require.NoError(t, triggers.ProcessEvent(ctx, "bot-1", domain.TriggerEventMessageReceived, eventData))
+
+	// Cada trigger va a la acción de su tipo con la configuración resuelta
+	assert.Equal(t, []sentMessage{{"web", "user-1", "Hello Ana"}}, sender.sent)
+	require.Len(t, custom.configs, 1)
+	assert.Equal(t, 42.5, custom.configs[0]["amount"])
+	assert.Equal(t, "Order A1 for Ana", custom.configs[0]["summary"])
+	assert.Equal(t, []interface{}{"web", "support"}, custom.configs[0]["tags"])
+	assert.Equal(t, []bool{true}, custom.deadlines)
+
+	actions := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-fired:
+			assert.Equal(t, "user-1", event.UserID)
+			actions[event.Data["action"].(string)] = true
+		case <-time.After(time.Second):
+			t.Fatal("trigger_fired event was not published")
+		}
+	}
+	assert.Equal(t, map[string]bool{ActionSendMessage: true, "create_ticket": true}, actions)
+
+	// El error de la acción se devuelve envuelto y no publica trigger_fired
+	custom.err = errors.New("helpdesk unavailable")
+	err := triggers.ExecuteTrigger(ctx, ticket.ID, eventData)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "action create_ticket failed: helpdesk unavailable")
+	select {
+	case event := <-fired:
+		t.Fatalf("unexpected trigger_fired event: %v", event.Data)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,244 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// TriggerScheduler dispara los triggers temporales: expresiones cron, fechas
+// absolutas (evento schedule) e inactividad de la sesión (evento timeout)
+type TriggerScheduler interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+type triggerScheduler struct {
+	triggerRepo domain.TriggerRepository
+	sessionRepo domain.ConversationSessionRepository
+	triggerSvc  TriggerService
+	interval    time.Duration
+	logger      logger.Logger
+	clock       clock.Clock
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	// idleFired guarda, por trigger y sesión, el UpdatedAt de la sesión cuando se disparó,
+	// para avisar una sola vez por cada periodo de silencio
+	idleFired map[string]time.Time
+}
+
+// NewTriggerScheduler crea el planificador de triggers temporales; con clk nil
+// usa el reloj del sistema
+func NewTriggerScheduler(
+	triggerRepo domain.TriggerRepository,
+	sessionRepo domain.ConversationSessionRepository,
+	triggerSvc TriggerService,
+	interval time.Duration,
+	clk clock.Clock,
+	logger logger.Logger,
+) TriggerScheduler {
+	// Los triggers cron tienen resolución de minuto
+	if interval <= 0 || interval > time.Minute {
+		interval = 30 * time.Second
+	}
+
+	return &triggerScheduler{
+		triggerRepo: triggerRepo,
+		sessionRepo: sessionRepo,
+		triggerSvc:  triggerSvc,
+		interval:    interval,
+		logger:      logger,
+		clock:       clock.OrReal(clk),
+		idleFired:   make(map[string]time.Time),
+	}
+}
+
+func (s *triggerScheduler) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return fmt.Errorf("trigger scheduler already started")
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.run(ctx)
+
+	s.logger.Info("Trigger scheduler started", "interval", s.interval)
+	return nil
+}
+
+func (s *triggerScheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+
+	s.logger.Info("Trigger scheduler stopped")
+	return nil
+}
+
+func (s *triggerScheduler) run(ctx context.Context) {
+	ticker := s.clock.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			s.tick(ctx, s.clock.Now())
+		}
+	}
+}
+
+func (s *triggerScheduler) tick(ctx context.Context, now time.Time) {
+	scheduled, err := s.triggerRepo.GetEnabledByEvent(ctx, domain.TriggerEventSchedule)
+	if err != nil {
+		s.logger.Error("Failed to load scheduled triggers", "error", err)
+	}
+	for _, trigger := range scheduled {
+		s.checkSchedule(ctx, trigger, now)
+	}
+
+	timeouts, err := s.triggerRepo.GetEnabledByEvent(ctx, domain.TriggerEventTimeout)
+	if err != nil {
+		s.logger.Error("Failed to load timeout triggers", "error", err)
+	}
+
+	seen := make(map[string]bool)
+	for _, trigger := range timeouts {
+		s.checkInactivity(ctx, trigger, now, seen)
+	}
+
+	// Olvidar las sesiones que ya no están inactivas o desaparecieron
+	s.mu.Lock()
+	for key := range s.idleFired {
+		if !seen[key] {
+			delete(s.idleFired, key)
+		}
+	}
+	s.mu.Unlock()
+}
+
+// checkSchedule dispara el trigger si le toca por fecha absoluta o por cron
+func (s *triggerScheduler) checkSchedule(ctx context.Context, trigger *domain.Trigger, now time.Time) {
+	schedule := trigger.Schedule
+	if schedule == nil {
+		return
+	}
+
+	if schedule.Timezone != "" {
+		if location, err := time.LoadLocation(schedule.Timezone); err == nil {
+			now = now.In(location)
+		}
+	}
+
+	var due time.Time
+	switch {
+	case schedule.At != nil:
+		due = *schedule.At
+		if now.Before(due) {
+			return
+		}
+	case schedule.Cron != "":
+		cron, err := parseCron(schedule.Cron)
+		if err != nil {
+			s.logger.Warn("Invalid trigger cron expression", "trigger_id", trigger.ID, "error", err)
+			return
+		}
+		if !cron.matches(now) {
+			return
+		}
+		due = now.Truncate(time.Minute)
+	default:
+		return
+	}
+
+	// Cada vencimiento se dispara una sola vez
+	if trigger.LastFiredAt != nil && !trigger.LastFiredAt.Before(due) {
+		return
+	}
+
+	s.fire(ctx, trigger, now, map[string]interface{}{
+		"bot_id":       trigger.BotID,
+		"scheduled_at": due,
+	})
+}
+
+// checkInactivity dispara el trigger para cada sesión que lleva el tiempo configurado sin mensajes
+func (s *triggerScheduler) checkInactivity(ctx context.Context, trigger *domain.Trigger, now time.Time, seen map[string]bool) {
+	if trigger.Schedule == nil || trigger.Schedule.InactivityMinutes <= 0 {
+		return
+	}
+
+	idle := time.Duration(trigger.Schedule.InactivityMinutes) * time.Minute
+	sessions, err := s.sessionRepo.GetInactiveSince(ctx, trigger.BotID, now.Add(-idle))
+	if err != nil {
+		s.logger.Error("Failed to load inactive sessions", "bot_id", trigger.BotID, "error", err)
+		return
+	}
+
+	for _, session := range sessions {
+		// Las conversaciones derivadas a un humano no reciben mensajes del bot
+		if session.Handoff != nil {
+			continue
+		}
+
+		key := trigger.ID + ":" + session.ID
+		seen[key] = true
+
+		s.mu.Lock()
+		firedAt, fired := s.idleFired[key]
+		s.mu.Unlock()
+		if fired && firedAt.Equal(session.UpdatedAt) {
+			continue
+		}
+
+		eventData := make(map[string]interface{}, len(session.Context)+5)
+		for k, v := range session.Context {
+			eventData[k] = v
+		}
+		eventData["bot_id"] = session.BotID
+		eventData["user_id"] = session.UserID
+		eventData["session_id"] = session.ID
+		eventData["flow_id"] = session.CurrentFlowID
+		eventData["idle_minutes"] = int(now.Sub(session.UpdatedAt).Minutes())
+
+		if s.fire(ctx, trigger, now, eventData) {
+			s.mu.Lock()
+			s.idleFired[key] = session.UpdatedAt
+			s.mu.Unlock()
+		}
+	}
+}
+
+func (s *triggerScheduler) fire(ctx context.Context, trigger *domain.Trigger, now time.Time, eventData map[string]interface{}) bool {
+	executed, err := s.triggerSvc.FireTrigger(ctx, trigger, eventData)
+	if err != nil {
+		s.logger.Error("Scheduled trigger failed", "trigger_id", trigger.ID, "error", err)
+	}
+
+	// Registrar el disparo aunque la acción falle o la condición no se cumpla, para no reintentar en cada tick
+	if trigger.Event == domain.TriggerEventSchedule {
+		firedAt := now
+		trigger.LastFiredAt = &firedAt
+		if err := s.triggerRepo.Update(ctx, trigger); err != nil {
+			s.logger.Warn("Failed to record trigger firing", "trigger_id", trigger.ID, "error", err)
+		}
+	}
+
+	if executed {
+		s.logger.Info("Scheduled trigger fired", "trigger_id", trigger.ID, "event", trigger.Event)
+	}
+	return err == nil
+}
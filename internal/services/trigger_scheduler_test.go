@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingAction struct {
+	calls []map[string]interface{}
+}
+
+func (a *countingAction) Type() string { return "count" }
+
+func (a *countingAction) Execute(ctx context.Context, trigger *domain.Trigger, config, eventData map[string]interface{}) error {
+	a.calls = append(a.calls, eventData)
+	return nil
+}
+
+func TestParseCron(t *testing.T) {
+	cron, err := parseCron("*/15 9-17 * * 1-5")
+	require.NoError(t, err)
+
+	assert.True(t, cron.matches(time.Date(2024, 6, 12, 9, 45, 0, 0, time.UTC))) // miércoles
+	assert.False(t, cron.matches(time.Date(2024, 6, 12, 9, 50, 0, 0, time.UTC)))
+	assert.False(t, cron.matches(time.Date(2024, 6, 15, 10, 0, 0, 0, time.UTC))) // sábado
+	assert.Equal(t, time.Date(2024, 6, 17, 9, 0, 0, 0, time.UTC), cron.next(time.Date(2024, 6, 14, 17, 45, 0, 0, time.UTC)))
+
+	_, err = parseCron("61 * * * *")
+	assert.Error(t, err)
+}
+
+func TestTriggerScheduler_Tick(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	triggerRepo := repositories.NewMockTriggerRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+
+	action := &countingAction{}
+	triggerSvc := NewTriggerService(triggerRepo, NewConditionalService(repositories.NewMockConditionalRepository(), log), TriggerActionDeps{}, log)
+	require.NoError(t, triggerSvc.RegisterAction(action))
+
+	now := time.Date(2024, 6, 12, 10, 0, 0, 0, time.UTC)
+	require.NoError(t, triggerSvc.CreateTrigger(ctx, &domain.Trigger{
+		BotID: "bot-1", Event: domain.TriggerEventSchedule, Enabled: true,
+		Action:   domain.TriggerAction{Type: "count"},
+		Schedule: &domain.TriggerSchedule{Cron: "0 10 * * *"},
+	}))
+	require.NoError(t, triggerSvc.CreateTrigger(ctx, &domain.Trigger{
+		BotID: "bot-1", Event: domain.TriggerEventTimeout, Enabled: true,
+		Action:   domain.TriggerAction{Type: "count"},
+		Schedule: &domain.TriggerSchedule{InactivityMinutes: 5},
+	}))
+	require.NoError(t, sessionRepo.Create(ctx, &domain.ConversationSession{
+		ID: "session-1", BotID: "bot-1", UserID: "user-1",
+		Context:   map[string]interface{}{},
+		UpdatedAt: now.Add(-10 * time.Minute),
+	}))
+
+	scheduler := NewTriggerScheduler(triggerRepo, sessionRepo, triggerSvc, time.Second, nil, log).(*triggerScheduler)
+
+	scheduler.tick(ctx, now)
+	assert.Len(t, action.calls, 2)
+
+	// Mismo minuto y misma sesión inactiva: no se repite
+	scheduler.tick(ctx, now.Add(20*time.Second))
+	assert.Len(t, action.calls, 2)
+}
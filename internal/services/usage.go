@@ -0,0 +1,304 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+// ErrInvalidUsageRange se devuelve si el rango pedido está invertido o es demasiado largo
+var ErrInvalidUsageRange = errors.New("invalid usage range")
+
+const (
+	usageMonthLayout = "2006-01"
+	// defaultBudgetMessage se envía si el bot no configura ai_budget.fallback_message
+	defaultBudgetMessage = "I can't answer that right now. Please try again later or contact support."
+	// BudgetExceededIntent es el intent de las respuestas sustituidas por el presupuesto
+	BudgetExceededIntent = "budget_exceeded"
+)
+
+// ModelPrice es el precio de un modelo en USD por 1K tokens
+type ModelPrice struct {
+	Prompt     float64 `json:"prompt"`
+	Completion float64 `json:"completion"`
+}
+
+// defaultModelPrices son precios de lista de referencia. Un modelo se cobra
+// con la entrada que sea el prefijo más largo de su nombre, así que las
+// versiones con fecha (gpt-4o-2024-08-06) usan el precio de su familia.
+var defaultModelPrices = map[string]ModelPrice{
+	"gpt-3.5-turbo":    {Prompt: 0.0005, Completion: 0.0015},
+	"gpt-4":            {Prompt: 0.03, Completion: 0.06},
+	"gpt-4-turbo":      {Prompt: 0.01, Completion: 0.03},
+	"gpt-4o":           {Prompt: 0.0025, Completion: 0.01},
+	"gpt-4o-mini":      {Prompt: 0.00015, Completion: 0.0006},
+	"gemini-1.5-flash": {Prompt: 0.000075, Completion: 0.0003},
+	"gemini-1.5-pro":   {Prompt: 0.00125, Completion: 0.005},
+}
+
+// ParseModelPrices añade a los precios incluidos las entradas
+// modelo=prompt:completion de AI_MODEL_PRICES
+func ParseModelPrices(entries []string) (map[string]ModelPrice, error) {
+	prices := make(map[string]ModelPrice, len(defaultModelPrices)+len(entries))
+	for model, price := range defaultModelPrices {
+		prices[model] = price
+	}
+	for _, entry := range entries {
+		model, values, ok := strings.Cut(entry, "=")
+		promptValue, completionValue, hasBoth := strings.Cut(values, ":")
+		if !ok || !hasBoth || strings.TrimSpace(model) == "" {
+			return nil, fmt.Errorf("invalid model price %q: expected model=prompt:completion", entry)
+		}
+		prompt, err := strconv.ParseFloat(strings.TrimSpace(promptValue), 64)
+		if err != nil || prompt < 0 {
+			return nil, fmt.Errorf("invalid prompt price in %q", entry)
+		}
+		completion, err := strconv.ParseFloat(strings.TrimSpace(completionValue), 64)
+		if err != nil || completion < 0 {
+			return nil, fmt.Errorf("invalid completion price in %q", entry)
+		}
+		prices[strings.TrimSpace(model)] = ModelPrice{Prompt: prompt, Completion: completion}
+	}
+	return prices, nil
+}
+
+// TokenUsage es el consumo de una respuesta de IA
+type TokenUsage struct {
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// UsageCounters es el consumo de un día o de todo el rango
+type UsageCounters struct {
+	Requests         int     `json:"requests"`
+	PromptTokens     int     `json:"prompt_tokens"`
+	CompletionTokens int     `json:"completion_tokens"`
+	TotalTokens      int     `json:"total_tokens"`
+	Cost             float64 `json:"cost"` // USD estimados
+	BudgetBlocked    int     `json:"budget_blocked"`
+}
+
+// UsageDay es el consumo de un día, con los tokens por modelo
+type UsageDay struct {
+	Date string `json:"date"`
+	UsageCounters
+	Models map[string]int `json:"models,omitempty"`
+}
+
+// BudgetStatus es el gasto del mes en curso frente al presupuesto del bot
+type BudgetStatus struct {
+	Month     string  `json:"month"` // YYYY-MM
+	Limit     float64 `json:"limit"`
+	Spent     float64 `json:"spent"`
+	Remaining float64 `json:"remaining"`
+	Exceeded  bool    `json:"exceeded"`
+}
+
+// BotUsage es el consumo de IA de un bot entre From y To (ambos incluidos)
+type BotUsage struct {
+	BotID   string        `json:"bot_id"`
+	OwnerID string        `json:"owner_id"`
+	From    string        `json:"from"`
+	To      string        `json:"to"`
+	Totals  UsageCounters `json:"totals"`
+	Daily   []UsageDay    `json:"daily"`
+	Budget  *BudgetStatus `json:"budget,omitempty"`
+}
+
+// UsageService lleva la cuenta de los tokens de IA de cada bot, estima su
+// coste y aplica los presupuestos mensuales
+type UsageService interface {
+	// RecordUsage suma el consumo de una respuesta de IA del bot
+	RecordUsage(ctx context.Context, botID string, usage TokenUsage) error
+	// BudgetFallback devuelve la respuesta con la que sustituir la de IA si el
+	// bot agotó el presupuesto del mes
+	BudgetFallback(ctx context.Context, botID string) (string, bool)
+	GetBotUsage(ctx context.Context, botID string, from, to time.Time) (*BotUsage, error)
+}
+
+type usageService struct {
+	repo    domain.UsageRepository
+	botRepo domain.BotRepository
+	prices  map[string]ModelPrice
+	clock   clock.Clock
+	logger  logger.Logger
+}
+
+// NewUsageService crea el servicio de consumo de IA. Con prices nil usa los
+// precios incluidos.
+func NewUsageService(repo domain.UsageRepository, botRepo domain.BotRepository, prices map[string]ModelPrice, clk clock.Clock, logger logger.Logger) UsageService {
+	if prices == nil {
+		prices = defaultModelPrices
+	}
+	return &usageService{
+		repo:    repo,
+		botRepo: botRepo,
+		prices:  prices,
+		clock:   clock.OrReal(clk),
+		logger:  logger,
+	}
+}
+
+// estimateCost cobra la respuesta con el precio del modelo; los modelos sin
+// precio (locales, mocks) no cuestan nada
+func (s *usageService) estimateCost(usage TokenUsage) float64 {
+	if strings.HasSuffix(usage.Model, "-mock") {
+		return 0
+	}
+	var price ModelPrice
+	matched := ""
+	for model, candidate := range s.prices {
+		if strings.HasPrefix(usage.Model, model) && len(model) > len(matched) {
+			price, matched = candidate, model
+		}
+	}
+	return (float64(usage.PromptTokens)*price.Prompt + float64(usage.CompletionTokens)*price.Completion) / 1000
+}
+
+func (s *usageService) RecordUsage(ctx context.Context, botID string, usage TokenUsage) error {
+	delta := &domain.BotDailyUsage{
+		BotID:            botID,
+		Date:             s.clock.Now().UTC().Format(AnalyticsDateLayout),
+		Requests:         1,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             s.estimateCost(usage),
+	}
+	if usage.Model != "" {
+		delta.Models = map[string]int{usage.Model: usage.PromptTokens + usage.CompletionTokens}
+	}
+	if bot, err := s.botRepo.GetByID(ctx, botID); err == nil {
+		delta.OwnerID = bot.OwnerID
+	}
+
+	if err := s.repo.Increment(ctx, delta); err != nil {
+		return fmt.Errorf("failed to record usage for bot %s: %w", botID, err)
+	}
+	return nil
+}
+
+func (s *usageService) BudgetFallback(ctx context.Context, botID string) (string, bool) {
+	bot, err := s.botRepo.GetByID(ctx, botID)
+	if err != nil {
+		return "", false
+	}
+	config := parseBotConfig(bot).AIBudget
+	if config == nil || config.MonthlyLimit <= 0 {
+		return "", false
+	}
+
+	status, err := s.budgetStatus(ctx, botID, config)
+	if err != nil {
+		// Sin poder comprobar el gasto se responde con IA antes que dejar al bot mudo
+		s.logger.WithContext(ctx).Warn("Failed to check AI budget", "bot_id", botID, "error", err)
+		return "", false
+	}
+	if !status.Exceeded {
+		return "", false
+	}
+
+	s.logger.WithContext(ctx).Warn("AI budget exceeded, sending fallback response",
+		"bot_id", botID,
+		"limit", status.Limit,
+		"spent", status.Spent)
+	blocked := &domain.BotDailyUsage{
+		BotID:         botID,
+		OwnerID:       bot.OwnerID,
+		Date:          s.clock.Now().UTC().Format(AnalyticsDateLayout),
+		BudgetBlocked: 1,
+	}
+	if err := s.repo.Increment(ctx, blocked); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to record blocked AI response", "bot_id", botID, "error", err)
+	}
+
+	if config.FallbackMessage != "" {
+		return config.FallbackMessage, true
+	}
+	return defaultBudgetMessage, true
+}
+
+// budgetStatus suma el coste del mes natural (UTC) en curso
+func (s *usageService) budgetStatus(ctx context.Context, botID string, config *domain.AIBudgetConfig) (*BudgetStatus, error) {
+	today := truncateDay(s.clock.Now())
+	monthStart := today.AddDate(0, 0, 1-today.Day())
+	days, err := s.repo.GetRange(ctx, botID, monthStart.Format(AnalyticsDateLayout), today.Format(AnalyticsDateLayout))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &BudgetStatus{Month: today.Format(usageMonthLayout), Limit: config.MonthlyLimit}
+	for _, day := range days {
+		status.Spent += day.Cost
+	}
+	status.Remaining = max(0, status.Limit-status.Spent)
+	status.Exceeded = status.Spent >= status.Limit
+	return status, nil
+}
+
+func (s *usageService) GetBotUsage(ctx context.Context, botID string, from, to time.Time) (*BotUsage, error) {
+	bot, err := s.botRepo.GetByID(ctx, botID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBotNotFound, botID)
+	}
+	from, to = truncateDay(from), truncateDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to is before from", ErrInvalidUsageRange)
+	}
+	if days := int(to.Sub(from)/(24*time.Hour)) + 1; days > maxAnalyticsDays {
+		return nil, fmt.Errorf("%w: at most %d days", ErrInvalidUsageRange, maxAnalyticsDays)
+	}
+
+	stored, err := s.repo.GetRange(ctx, botID, from.Format(AnalyticsDateLayout), to.Format(AnalyticsDateLayout))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get usage: %w", err)
+	}
+	byDate := make(map[string]*domain.BotDailyUsage, len(stored))
+	for _, day := range stored {
+		byDate[day.Date] = day
+	}
+
+	usage := &BotUsage{
+		BotID:   botID,
+		OwnerID: bot.OwnerID,
+		From:    from.Format(AnalyticsDateLayout),
+		To:      to.Format(AnalyticsDateLayout),
+	}
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		date := day.Format(AnalyticsDateLayout)
+		daily, ok := byDate[date]
+		if !ok {
+			daily = &domain.BotDailyUsage{Date: date}
+		}
+		counters := UsageCounters{
+			Requests:         daily.Requests,
+			PromptTokens:     daily.PromptTokens,
+			CompletionTokens: daily.CompletionTokens,
+			TotalTokens:      daily.PromptTokens + daily.CompletionTokens,
+			Cost:             daily.Cost,
+			BudgetBlocked:    daily.BudgetBlocked,
+		}
+		usage.Daily = append(usage.Daily, UsageDay{Date: date, UsageCounters: counters, Models: daily.Models})
+
+		usage.Totals.Requests += counters.Requests
+		usage.Totals.PromptTokens += counters.PromptTokens
+		usage.Totals.CompletionTokens += counters.CompletionTokens
+		usage.Totals.TotalTokens += counters.TotalTokens
+		usage.Totals.Cost += counters.Cost
+		usage.Totals.BudgetBlocked += counters.BudgetBlocked
+	}
+
+	if config := parseBotConfig(bot).AIBudget; config != nil && config.MonthlyLimit > 0 {
+		if usage.Budget, err = s.budgetStatus(ctx, botID, config); err != nil {
+			return nil, fmt.Errorf("failed to get budget status: %w", err)
+		}
+	}
+	return usage, nil
+}
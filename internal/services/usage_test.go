@@ -0,0 +1,80 @@
+package services_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/testkit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsageService_TracksCostAndEnforcesBudget(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	clk := clock.NewFake(testkit.Epoch)
+	botRepo := repositories.NewMockBotRepository()
+	bot, _, _ := testkit.Bot("bot-1").Configure(func(config *domain.BotConfig) {
+		config.AIBudget = &domain.AIBudgetConfig{MonthlyLimit: 0.05, FallbackMessage: "Out of credits"}
+	}).Build()
+	require.NoError(t, botRepo.Create(ctx, bot))
+
+	prices, err := services.ParseModelPrices([]string{"llama3.1=0:0", "gpt-4o=0.005:0.015"})
+	require.NoError(t, err)
+	usage := services.NewUsageService(repositories.NewMockUsageRepository(), botRepo, prices, clk, log)
+
+	scripted := testkit.NewScriptedAI()
+	smartReplies := services.NewSmartReplyService(repositories.NewMockSmartReplyRepository(), repositories.NewMockIntentExampleRepository(),
+		scripted, mcp.NewOrchestrator(mcp.NewAgentFactory(log), log), log)
+	smartReplies.UseUsage(usage)
+
+	reply, err := smartReplies.GenerateAIResponse(ctx, "bot-1", "hola", nil)
+	require.NoError(t, err)
+	assert.Equal(t, testkit.DefaultAIReply, reply.Response)
+
+	// Las versiones con fecha se cobran con el precio de su familia
+	require.NoError(t, usage.RecordUsage(ctx, "bot-1", services.TokenUsage{Model: "gpt-4o-2024-08-06", PromptTokens: 2000, CompletionTokens: 1000}))
+	clk.Advance(24 * time.Hour)
+	require.NoError(t, usage.RecordUsage(ctx, "bot-1", services.TokenUsage{Model: "llama3.1", PromptTokens: 500, CompletionTokens: 100}))
+
+	result, err := usage.GetBotUsage(ctx, "bot-1", testkit.Epoch, clk.Now())
+	require.NoError(t, err)
+	require.Len(t, result.Daily, 2)
+	assert.Equal(t, 2, result.Daily[0].Requests)
+	assert.InDelta(t, 0.025, result.Daily[0].Cost, 1e-9)
+	assert.Equal(t, map[string]int{"gpt-4o-2024-08-06": 3000, "scripted": 0}, result.Daily[0].Models)
+	assert.Equal(t, 3, result.Totals.Requests)
+	assert.Equal(t, 3600, result.Totals.TotalTokens)
+	require.NotNil(t, result.Budget)
+	assert.False(t, result.Budget.Exceeded)
+	assert.InDelta(t, 0.025, result.Budget.Remaining, 1e-9)
+
+	// Al agotar el presupuesto el bot responde el mensaje fijo sin llamar a la IA
+	require.NoError(t, usage.RecordUsage(ctx, "bot-1", services.TokenUsage{Model: "gpt-4o", PromptTokens: 5000}))
+	reply, err = smartReplies.GenerateAIResponse(ctx, "bot-1", "hola otra vez", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Out of credits", reply.Response)
+	assert.Equal(t, services.BudgetExceededIntent, reply.Intent)
+	assert.Len(t, scripted.Prompts(), 1)
+
+	result, err = usage.GetBotUsage(ctx, "bot-1", clk.Now(), clk.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Totals.BudgetBlocked)
+	assert.True(t, result.Budget.Exceeded)
+	assert.Zero(t, result.Budget.Remaining)
+
+	_, err = usage.GetBotUsage(ctx, "bot-1", clk.Now(), testkit.Epoch)
+	assert.ErrorIs(t, err, services.ErrInvalidUsageRange)
+	_, err = usage.GetBotUsage(ctx, "missing", testkit.Epoch, testkit.Epoch)
+	assert.ErrorIs(t, err, services.ErrBotNotFound)
+
+	_, err = services.ParseModelPrices([]string{"gpt-4o=0.005"})
+	assert.Error(t, err)
+}
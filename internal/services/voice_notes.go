@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/id"
+)
+
+const (
+	// TranscriptMetadataKey guarda la transcripción en los metadatos del mensaje
+	TranscriptMetadataKey = "transcript"
+	// LastVoiceNoteContextKey guarda la última transcripción en el contexto de la sesión
+	LastVoiceNoteContextKey = "last_voice_note"
+)
+
+const defaultVoiceNoteFailureMessage = "Sorry, I couldn't understand your voice note. Could you type your message instead?"
+
+// voiceNoteTypes son los valores de tipo de mensaje que envían los canales
+// para audios (WhatsApp usa "audio"/"voice", Telegram "voice")
+var voiceNoteTypes = map[string]bool{
+	"audio":      true,
+	"voice":      true,
+	"voice_note": true,
+	"ptt":        true,
+}
+
+// voiceNoteAudio detecta si el mensaje es una nota de voz y devuelve la URL
+// del audio y su tipo MIME
+func voiceNoteAudio(message *domain.IncomingMessage) (string, string, bool) {
+	if message.Metadata == nil {
+		return "", "", false
+	}
+
+	messageType := ""
+	for _, key := range []string{"type", "message_type", "media_type"} {
+		if value, ok := message.Metadata[key].(string); ok && value != "" {
+			messageType = strings.ToLower(value)
+			break
+		}
+	}
+	if !voiceNoteTypes[messageType] {
+		return "", "", false
+	}
+
+	for _, key := range []string{"media_url", "audio_url", "url"} {
+		if audioURL, ok := message.Metadata[key].(string); ok && audioURL != "" {
+			mimeType, _ := message.Metadata["mime_type"].(string)
+			return audioURL, mimeType, true
+		}
+	}
+	return "", "", false
+}
+
+// transcribeVoiceNote transcribe el audio con un agente MCP de transcripción
+func (s *botService) transcribeVoiceNote(ctx context.Context, message *domain.IncomingMessage, audioURL, mimeType string, config *domain.VoiceNoteConfig) (*domain.Transcript, error) {
+	var agentConfig map[string]interface{}
+	language := ""
+	if config != nil {
+		agentConfig = config.Config
+		language = config.Language
+	}
+
+	agent, err := s.instantiateAgent(ctx, mcp.MCPConfig{
+		Type:         "transcription",
+		Name:         fmt.Sprintf("transcription-agent-%s", message.BotID),
+		Version:      "1.0",
+		Config:       agentConfig,
+		Capabilities: []string{"transcription"},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to instantiate transcription agent: %w", err)
+	}
+	defer func() {
+		if err := s.mcpOrchestrator.TerminateAgent(ctx, agent.GetID()); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to terminate agent", "agent_id", agent.GetID(), "error", err)
+		}
+	}()
+
+	input := map[string]interface{}{
+		"audio_url": audioURL,
+		"mime_type": mimeType,
+		"language":  language,
+	}
+	// Cabeceras para descargar medios protegidos (p. ej. token de WhatsApp Cloud API)
+	if headers, ok := message.Metadata["media_headers"].(map[string]interface{}); ok {
+		input["download_headers"] = headers
+	}
+
+	result, err := agent.Execute(ctx, mcp.Task{
+		ID:          fmt.Sprintf("transcription-%s-%s", message.ID, id.New()),
+		Type:        "transcription",
+		Description: fmt.Sprintf("Voice note transcription for message %s", message.ID),
+		Input:       input,
+		Priority:    5,
+		Metadata: map[string]interface{}{
+			"message_id": message.ID,
+			"channel":    string(message.Channel),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return nil, fmt.Errorf("transcription failed: %s", result.Error)
+	}
+
+	text, _ := result.Output["text"].(string)
+	if strings.TrimSpace(text) == "" {
+		return nil, fmt.Errorf("transcription is empty")
+	}
+
+	transcript := &domain.Transcript{
+		Text:     text,
+		AudioURL: audioURL,
+		MimeType: mimeType,
+	}
+	transcript.Language, _ = result.Output["language"].(string)
+	transcript.Duration, _ = result.Output["duration"].(float64)
+	transcript.Provider, _ = result.Metadata["provider"].(string)
+	return transcript, nil
+}
+
+// applyTranscript sustituye el contenido del mensaje por el texto transcrito
+// y deja el enlace al audio original en el mensaje y en la sesión
+func applyTranscript(message *domain.IncomingMessage, session *domain.ConversationSession, transcript *domain.Transcript) {
+	message.Content = transcript.Text
+	if message.Metadata == nil {
+		message.Metadata = make(map[string]interface{})
+	}
+	message.Metadata[TranscriptMetadataKey] = transcript
+
+	if session.Context == nil {
+		session.Context = make(map[string]interface{})
+	}
+	session.Context[LastVoiceNoteContextKey] = map[string]interface{}{
+		"text":      transcript.Text,
+		"audio_url": transcript.AudioURL,
+		"language":  transcript.Language,
+	}
+}
+
+func voiceNoteFailureMessage(config *domain.VoiceNoteConfig) string {
+	if config != nil && config.FailureMessage != "" {
+		return config.FailureMessage
+	}
+	return defaultVoiceNoteFailureMessage
+}
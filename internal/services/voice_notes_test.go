@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVoiceNoteAudio(t *testing.T) {
+	audioURL, mimeType, ok := voiceNoteAudio(&domain.IncomingMessage{Metadata: map[string]interface{}{
+		"message_type": "PTT", "audio_url": "https://media.example.com/1.ogg", "mime_type": "audio/ogg",
+	}})
+	assert.True(t, ok)
+	assert.Equal(t, "https://media.example.com/1.ogg", audioURL)
+	assert.Equal(t, "audio/ogg", mimeType)
+
+	for _, metadata := range []map[string]interface{}{
+		nil,
+		{"type": "image", "media_url": "https://media.example.com/1.png"},
+		{"type": "voice"},
+	} {
+		_, _, ok := voiceNoteAudio(&domain.IncomingMessage{Metadata: metadata})
+		assert.False(t, ok, "%v", metadata)
+	}
+}
+
+func TestProcessIncomingMessage_TranscribesVoiceNotes(t *testing.T) {
+	var transcriptions atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/media/note.ogg":
+			if r.Header.Get("Authorization") != "Bearer media-token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, "ogg-audio")
+		case "/audio/transcriptions":
+			transcriptions.Add(1)
+			assert.Equal(t, "es", r.FormValue("language"))
+			fmt.Fprint(w, `{"text": "quiero ver mi pedido", "language": "spanish", "duration": 2}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer api.Close()
+
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	botRepo := repositories.NewMockBotRepository()
+	flowRepo := repositories.NewMockBotFlowRepository()
+	stepRepo := repositories.NewMockBotStepRepository()
+	sessionRepo := repositories.NewMockConversationSessionRepository()
+	conversations := NewConversationService(sessionRepo, nil, log)
+	orchestrator := mcp.NewOrchestrator(mcp.NewAgentFactory(log), log)
+	bots := NewBotService(botRepo, flowRepo, stepRepo, repositories.NewMockFlowVersionRepository(), sessionRepo, nil,
+		conversations, nil, nil, nil, nil, orchestrator, nil, nil, log)
+
+	createBot := func(id string, voiceNotes *domain.VoiceNoteConfig) {
+		config, _ := json.Marshal(domain.BotConfig{VoiceNotes: voiceNotes})
+		require.NoError(t, botRepo.Create(ctx, &domain.Bot{ID: id, Status: domain.BotStatusActive, Config: config}))
+		require.NoError(t, flowRepo.Create(ctx, &domain.BotFlow{ID: "flow-" + id, BotID: id, EntryPoint: "request-" + id, IsDefault: true}))
+		require.NoError(t, stepRepo.Create(ctx, &domain.BotStep{ID: "request-" + id, FlowID: "flow-" + id, Type: domain.StepTypeInput,
+			Content: json.RawMessage(`{"variable":"request"}`)}))
+	}
+	createBot("bot-1", &domain.VoiceNoteConfig{
+		Language: "es",
+		Config:   map[string]interface{}{"openai_api_key": "sk-live", "base_url": api.URL},
+	})
+	createBot("bot-2", &domain.VoiceNoteConfig{
+		Language:       "es",
+		FailureMessage: "Please type your message.",
+		Config:         map[string]interface{}{"openai_api_key": "sk-live", "base_url": api.URL},
+	})
+	createBot("bot-3", &domain.VoiceNoteConfig{Disabled: true})
+
+	voiceNote := func(botID string, headers map[string]interface{}) *domain.IncomingMessage {
+		return &domain.IncomingMessage{ID: "message-1", BotID: botID, UserID: "user-1", Channel: domain.ChannelWhatsApp, Metadata: map[string]interface{}{
+			"type": "voice", "media_url": api.URL + "/media/note.ogg", "mime_type": "audio/ogg", "media_headers": headers,
+		}}
+	}
+
+	// El flujo recibe el texto transcrito y la sesión guarda el enlace al audio
+	message := voiceNote("bot-1", map[string]interface{}{"Authorization": "Bearer media-token"})
+	response, err := bots.ProcessIncomingMessage(ctx, message)
+	require.NoError(t, err)
+	assert.Contains(t, response.Content, "quiero ver mi pedido")
+	assert.Equal(t, "quiero ver mi pedido", message.Content)
+	transcript, ok := message.Metadata[TranscriptMetadataKey].(*domain.Transcript)
+	require.True(t, ok)
+	assert.Equal(t, "spanish", transcript.Language)
+	assert.Equal(t, 2.0, transcript.Duration)
+	assert.Equal(t, api.URL+"/media/note.ogg", transcript.AudioURL)
+
+	session, err := conversations.GetSession(ctx, "user-1", "bot-1")
+	require.NoError(t, err)
+	assert.Equal(t, "quiero ver mi pedido", session.Context["request"])
+	assert.Equal(t, map[string]interface{}{"text": "quiero ver mi pedido", "audio_url": api.URL + "/media/note.ogg", "language": "spanish"},
+		session.Context[LastVoiceNoteContextKey])
+
+	// Si el audio no se puede descargar, el usuario recibe el mensaje configurado
+	response, err = bots.ProcessIncomingMessage(ctx, voiceNote("bot-2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, "Please type your message.", response.Content)
+	assert.Equal(t, api.URL+"/media/note.ogg", response.Metadata["audio_url"])
+
+	// Con las notas de voz desactivadas el mensaje llega sin transcribir
+	message = voiceNote("bot-3", nil)
+	_, err = bots.ProcessIncomingMessage(ctx, message)
+	require.NoError(t, err)
+	assert.Empty(t, message.Content)
+	assert.NotContains(t, message.Metadata, TranscriptMetadataKey)
+
+	assert.Equal(t, int32(1), transcriptions.Load())
+	// Los agentes de transcripción se terminan tras cada nota
+	assert.Empty(t, orchestrator.ListAgents())
+}
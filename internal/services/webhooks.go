@@ -0,0 +1,582 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+const (
+	// webhookSecretPrefix distingue los secretos de firma de otras credenciales
+	webhookSecretPrefix = "whsec_"
+	// webhookResponseBodyLimit es lo que se guarda de la respuesta del receptor
+	webhookResponseBodyLimit = 1024
+	webhookUserAgent         = "bot-service-webhooks/1.0"
+)
+
+// Headers de cada entrega. La firma es HMAC-SHA256 de "<timestamp>.<body>".
+const (
+	WebhookHeaderSignature = "X-Webhook-Signature"
+	WebhookHeaderTimestamp = "X-Webhook-Timestamp"
+	WebhookHeaderEvent     = "X-Webhook-Event"
+	WebhookHeaderDelivery  = "X-Webhook-Delivery"
+)
+
+var (
+	ErrWebhookNotFound = errors.New("webhook subscription not found")
+	ErrInvalidWebhook  = errors.New("invalid webhook subscription")
+)
+
+// webhookEvents asocia cada evento que se puede suscribir con su tipo en el bus
+var webhookEvents = []struct {
+	name    string
+	busType string
+}{
+	{"message_processed", events.EventTypeMessageProcessed},
+	{"session_started", events.EventTypeSessionStarted},
+	{"handoff_requested", events.EventTypeHumanHandoff},
+	{"test_suite_completed", events.EventTypeTestSuiteCompleted},
+	{"task_completed", events.EventTypeTaskCompleted},
+}
+
+// WebhookEventTypes devuelve los eventos a los que se puede suscribir un webhook
+func WebhookEventTypes() []string {
+	names := make([]string, len(webhookEvents))
+	for i, event := range webhookEvents {
+		names[i] = event.name
+	}
+	return names
+}
+
+// WebhookSubscriptionRequest describe una suscripción. Sin secret se genera
+// uno; Active por defecto es true.
+type WebhookSubscriptionRequest struct {
+	Name   string   `json:"name" binding:"required"`
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+	BotID  string   `json:"bot_id"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+// WebhookDeliveryConfig controla el worker de entregas. Retry.MaxAttempts
+// incluye el primer intento.
+type WebhookDeliveryConfig struct {
+	Workers   int
+	QueueSize int
+	Timeout   time.Duration
+	Retry     domain.RetryPolicy
+}
+
+// WebhookService gestiona las suscripciones de webhooks y entrega a cada una
+// los eventos del bus a los que está suscrita
+type WebhookService interface {
+	Create(ctx context.Context, request WebhookSubscriptionRequest, createdBy string) (*domain.WebhookSubscription, error)
+	Get(ctx context.Context, id string) (*domain.WebhookSubscription, error)
+	List(ctx context.Context) ([]*domain.WebhookSubscription, error)
+	Update(ctx context.Context, id string, request WebhookSubscriptionRequest) (*domain.WebhookSubscription, error)
+	Delete(ctx context.Context, id string) error
+
+	ListDeliveries(ctx context.Context, subscriptionID string, limit, offset int) ([]*domain.WebhookDelivery, int, error)
+	GetDelivery(ctx context.Context, subscriptionID, deliveryID string) (*domain.WebhookDelivery, error)
+	// Redeliver vuelve a enviar el payload de una entrega como una entrega nueva
+	Redeliver(ctx context.Context, subscriptionID, deliveryID string) (*domain.WebhookDelivery, error)
+
+	// Subscribe registra el servicio en el bus para los eventos de webhookEvents
+	Subscribe(bus events.EventBus) error
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+type webhookService struct {
+	subscriptions domain.WebhookSubscriptionRepository
+	deliveries    domain.WebhookDeliveryRepository
+	config        WebhookDeliveryConfig
+	client        *http.Client
+	queue         chan string
+	logger        logger.Logger
+
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+// NewWebhookService crea el servicio de webhooks. Las entregas se encolan
+// desde el primer evento, pero no se envían hasta llamar a Start.
+func NewWebhookService(
+	subscriptions domain.WebhookSubscriptionRepository,
+	deliveries domain.WebhookDeliveryRepository,
+	config WebhookDeliveryConfig,
+	logger logger.Logger,
+) WebhookService {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.QueueSize <= 0 {
+		config.QueueSize = 100
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if config.Retry.MaxAttempts <= 0 {
+		config.Retry.MaxAttempts = 1
+	}
+	return &webhookService{
+		subscriptions: subscriptions,
+		deliveries:    deliveries,
+		config:        config,
+		client:        &http.Client{Timeout: config.Timeout},
+		queue:         make(chan string, config.QueueSize),
+		logger:        logger,
+	}
+}
+
+func (s *webhookService) Create(ctx context.Context, request WebhookSubscriptionRequest, createdBy string) (*domain.WebhookSubscription, error) {
+	if err := validateWebhookRequest(request); err != nil {
+		return nil, err
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		generated, err := generateWebhookSecret()
+		if err != nil {
+			return nil, err
+		}
+		secret = generated
+	}
+
+	now := time.Now()
+	subscription := &domain.WebhookSubscription{
+		Name:      request.Name,
+		URL:       request.URL,
+		Events:    request.Events,
+		BotID:     request.BotID,
+		Secret:    secret,
+		Active:    request.Active == nil || *request.Active,
+		CreatedBy: createdBy,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.subscriptions.Create(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to store webhook subscription: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Webhook subscription created",
+		"webhook_id", subscription.ID,
+		"url", subscription.URL,
+		"events", subscription.Events)
+	return subscription, nil
+}
+
+func (s *webhookService) Get(ctx context.Context, id string) (*domain.WebhookSubscription, error) {
+	subscription, err := s.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	return withoutSecret(subscription), nil
+}
+
+func (s *webhookService) List(ctx context.Context) ([]*domain.WebhookSubscription, error) {
+	subscriptions, err := s.subscriptions.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	for i, subscription := range subscriptions {
+		subscriptions[i] = withoutSecret(subscription)
+	}
+	return subscriptions, nil
+}
+
+func (s *webhookService) Update(ctx context.Context, id string, request WebhookSubscriptionRequest) (*domain.WebhookSubscription, error) {
+	subscription, err := s.subscriptions.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	if err := validateWebhookRequest(request); err != nil {
+		return nil, err
+	}
+
+	subscription.Name = request.Name
+	subscription.URL = request.URL
+	subscription.Events = request.Events
+	subscription.BotID = request.BotID
+	if request.Secret != "" {
+		subscription.Secret = request.Secret
+	}
+	if request.Active != nil {
+		subscription.Active = *request.Active
+	}
+	subscription.UpdatedAt = time.Now()
+	if err := s.subscriptions.Update(ctx, subscription); err != nil {
+		return nil, fmt.Errorf("failed to update webhook subscription: %w", err)
+	}
+	return withoutSecret(subscription), nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, id string) error {
+	if _, err := s.subscriptions.GetByID(ctx, id); err != nil {
+		return fmt.Errorf("%w: %s", ErrWebhookNotFound, id)
+	}
+	if err := s.subscriptions.Delete(ctx, id); err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+	if err := s.deliveries.DeleteBySubscriptionID(ctx, id); err != nil {
+		s.logger.WithContext(ctx).Warn("Failed to delete webhook deliveries", "webhook_id", id, "error", err)
+	}
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, subscriptionID string, limit, offset int) ([]*domain.WebhookDelivery, int, error) {
+	if _, err := s.subscriptions.GetByID(ctx, subscriptionID); err != nil {
+		return nil, 0, fmt.Errorf("%w: %s", ErrWebhookNotFound, subscriptionID)
+	}
+	return s.deliveries.GetBySubscriptionID(ctx, subscriptionID, limit, offset)
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, subscriptionID, deliveryID string) (*domain.WebhookDelivery, error) {
+	delivery, err := s.deliveries.GetByID(ctx, deliveryID)
+	if err != nil || delivery.SubscriptionID != subscriptionID {
+		return nil, fmt.Errorf("%w: delivery %s", ErrWebhookNotFound, deliveryID)
+	}
+	return delivery, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, subscriptionID, deliveryID string) (*domain.WebhookDelivery, error) {
+	original, err := s.GetDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	delivery := &domain.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		Status:         domain.WebhookDeliveryPending,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.deliveries.Create(ctx, delivery); err != nil {
+		return nil, fmt.Errorf("failed to store webhook delivery: %w", err)
+	}
+	s.enqueue(ctx, delivery)
+	return delivery, nil
+}
+
+func (s *webhookService) Subscribe(bus events.EventBus) error {
+	for _, event := range webhookEvents {
+		name := event.name
+		handler := func(ctx context.Context, event events.Event) error {
+			return s.dispatch(ctx, name, event)
+		}
+		if err := bus.Subscribe(event.busType, handler); err != nil {
+			return fmt.Errorf("failed to subscribe to %s: %w", event.busType, err)
+		}
+	}
+	return nil
+}
+
+func (s *webhookService) Start(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cancel != nil {
+		return fmt.Errorf("webhook service already started")
+	}
+	ctx, s.cancel = context.WithCancel(ctx)
+	for i := 0; i < s.config.Workers; i++ {
+		s.workers.Add(1)
+		go s.worker(ctx)
+	}
+
+	s.logger.Info("Webhook delivery workers started", "workers", s.config.Workers)
+	return nil
+}
+
+func (s *webhookService) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel == nil {
+		return nil
+	}
+	cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.workers.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		s.logger.Info("Webhook delivery workers stopped")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// webhookPayload es el cuerpo que recibe el receptor en cada entrega
+type webhookPayload struct {
+	ID        string                 `json:"id"`
+	Type      string                 `json:"type"`
+	CreatedAt time.Time              `json:"created_at"`
+	UserID    string                 `json:"user_id,omitempty"`
+	Data      map[string]interface{} `json:"data"`
+}
+
+// dispatch crea una entrega por cada suscripción activa al evento. Las
+// suscripciones con BotID sólo reciben los eventos de ese bot.
+func (s *webhookService) dispatch(ctx context.Context, name string, event events.Event) error {
+	subscriptions, err := s.subscriptions.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	var payload []byte
+	botID, _ := event.Data["bot_id"].(string)
+	for _, subscription := range subscriptions {
+		if !subscription.Active || !slices.Contains(subscription.Events, name) {
+			continue
+		}
+		if subscription.BotID != "" && subscription.BotID != botID {
+			continue
+		}
+
+		if payload == nil {
+			payload, err = json.Marshal(webhookPayload{
+				ID:        event.ID,
+				Type:      name,
+				CreatedAt: event.Timestamp,
+				UserID:    event.UserID,
+				Data:      event.Data,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to encode webhook payload: %w", err)
+			}
+		}
+
+		now := time.Now()
+		delivery := &domain.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        event.ID,
+			EventType:      name,
+			Payload:        payload,
+			Status:         domain.WebhookDeliveryPending,
+			CreatedAt:      now,
+			UpdatedAt:      now,
+		}
+		if err := s.deliveries.Create(ctx, delivery); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to store webhook delivery", "webhook_id", subscription.ID, "error", err)
+			continue
+		}
+		s.enqueue(ctx, delivery)
+	}
+	return nil
+}
+
+// enqueue pasa la entrega a los workers; con la cola llena se da por fallida
+// en lugar de bloquear al que publicó el evento
+func (s *webhookService) enqueue(ctx context.Context, delivery *domain.WebhookDelivery) {
+	select {
+	case s.queue <- delivery.ID:
+	default:
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.Error = "delivery queue is full"
+		delivery.UpdatedAt = time.Now()
+		if err := s.deliveries.Update(ctx, delivery); err != nil {
+			s.logger.WithContext(ctx).Error("Failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+		}
+		s.logger.WithContext(ctx).Warn("Webhook delivery queue is full, dropping delivery",
+			"webhook_id", delivery.SubscriptionID,
+			"delivery_id", delivery.ID)
+	}
+}
+
+func (s *webhookService) worker(ctx context.Context) {
+	defer s.workers.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case id := <-s.queue:
+			s.deliver(ctx, id)
+		}
+	}
+}
+
+// deliver hace un intento de entrega. Los errores de red, 408, 429 y 5xx se
+// reintentan con backoff exponencial hasta Retry.MaxAttempts; el resto de
+// respuestas fuera de 2xx marcan la entrega como fallida.
+func (s *webhookService) deliver(ctx context.Context, id string) {
+	delivery, err := s.deliveries.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Warn("Webhook delivery disappeared before being sent", "delivery_id", id)
+		return
+	}
+	subscription, err := s.subscriptions.GetByID(ctx, delivery.SubscriptionID)
+	if err != nil || !subscription.Active {
+		delivery.Status = domain.WebhookDeliveryFailed
+		delivery.Error = "subscription was deleted or deactivated"
+		delivery.UpdatedAt = time.Now()
+		_ = s.deliveries.Update(ctx, delivery)
+		return
+	}
+
+	retryable := true
+	start := time.Now()
+	status, body, err := s.send(ctx, subscription, delivery)
+	delivery.Attempts++
+	delivery.Duration = time.Since(start).Milliseconds()
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	delivery.NextAttemptAt = nil
+	switch {
+	case err != nil:
+		delivery.Error = err.Error()
+	case status >= 200 && status < 300:
+		delivery.Error = ""
+	default:
+		delivery.Error = fmt.Sprintf("unexpected response status %d", status)
+		retryable = status == http.StatusRequestTimeout || status == http.StatusTooManyRequests || status >= 500
+	}
+
+	switch {
+	case delivery.Error == "":
+		delivery.Status = domain.WebhookDeliverySucceeded
+	case retryable && delivery.Attempts < s.config.Retry.MaxAttempts && ctx.Err() == nil:
+		delay := retryBackoff(&s.config.Retry, delivery.Attempts)
+		next := time.Now().Add(delay)
+		delivery.Status = domain.WebhookDeliveryPending
+		delivery.NextAttemptAt = &next
+		s.scheduleRetry(ctx, delivery.ID, delay)
+	default:
+		delivery.Status = domain.WebhookDeliveryFailed
+	}
+	delivery.UpdatedAt = time.Now()
+	if err := s.deliveries.Update(ctx, delivery); err != nil {
+		s.logger.Error("Failed to update webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+
+	if delivery.Status == domain.WebhookDeliverySucceeded {
+		s.logger.Debug("Webhook delivered",
+			"webhook_id", subscription.ID,
+			"delivery_id", delivery.ID,
+			"event_type", delivery.EventType,
+			"attempts", delivery.Attempts)
+		return
+	}
+	s.logger.Warn("Webhook delivery attempt failed",
+		"webhook_id", subscription.ID,
+		"delivery_id", delivery.ID,
+		"event_type", delivery.EventType,
+		"attempts", delivery.Attempts,
+		"status", delivery.Status,
+		"error", delivery.Error)
+}
+
+func (s *webhookService) send(ctx context.Context, subscription *domain.WebhookSubscription, delivery *domain.WebhookDelivery) (int, string, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookUserAgent)
+	req.Header.Set(WebhookHeaderEvent, delivery.EventType)
+	req.Header.Set(WebhookHeaderDelivery, delivery.ID)
+	req.Header.Set(WebhookHeaderTimestamp, timestamp)
+	req.Header.Set(WebhookHeaderSignature, SignWebhookPayload(subscription.Secret, timestamp, delivery.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseBodyLimit))
+	return resp.StatusCode, string(body), nil
+}
+
+// scheduleRetry vuelve a encolar la entrega tras delay salvo que el servicio
+// se haya detenido
+func (s *webhookService) scheduleRetry(ctx context.Context, id string, delay time.Duration) {
+	time.AfterFunc(delay, func() {
+		if ctx.Err() != nil {
+			return
+		}
+		delivery, err := s.deliveries.GetByID(ctx, id)
+		if err != nil {
+			return
+		}
+		s.enqueue(ctx, delivery)
+	})
+}
+
+// SignWebhookPayload calcula el valor de X-Webhook-Signature. El receptor debe
+// repetir el cálculo con el secreto de la suscripción y el X-Webhook-Timestamp
+// recibido, y rechazar las entregas con timestamps antiguos.
+func SignWebhookPayload(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func validateWebhookRequest(request WebhookSubscriptionRequest) error {
+	if strings.TrimSpace(request.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWebhook)
+	}
+	target, err := url.Parse(request.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http(s) URL", ErrInvalidWebhook)
+	}
+	if len(request.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+	supported := WebhookEventTypes()
+	for _, event := range request.Events {
+		if !slices.Contains(supported, event) {
+			return fmt.Errorf("%w: unsupported event %q (supported: %s)", ErrInvalidWebhook, event, strings.Join(supported, ", "))
+		}
+	}
+	if request.Secret != "" && len(request.Secret) < 16 {
+		return fmt.Errorf("%w: secret must be at least 16 characters", ErrInvalidWebhook)
+	}
+	return nil
+}
+
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return webhookSecretPrefix + hex.EncodeToString(secret), nil
+}
+
+// withoutSecret oculta el secreto de firma, que sólo se devuelve al crear la suscripción
+func withoutSecret(subscription *domain.WebhookSubscription) *domain.WebhookSubscription {
+	subscription.Secret = ""
+	return subscription
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookService_SignedDeliveryWithRetry(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+
+	var mu sync.Mutex
+	var requests []*http.Request
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		requests = append(requests, r)
+		bodies = append(bodies, body)
+		if len(requests) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	svc := NewWebhookService(
+		repositories.NewMockWebhookSubscriptionRepository(),
+		repositories.NewMockWebhookDeliveryRepository(),
+		WebhookDeliveryConfig{Retry: domain.RetryPolicy{MaxAttempts: 3, InitialBackoff: 10}},
+		log,
+	)
+	bus := events.NewInMemoryEventBus(log)
+	require.NoError(t, svc.Subscribe(bus))
+	require.NoError(t, svc.Start(ctx))
+	defer svc.Stop(ctx)
+
+	subscription, err := svc.Create(ctx, WebhookSubscriptionRequest{
+		Name:   "crm",
+		URL:    server.URL,
+		Events: []string{"handoff_requested"},
+		BotID:  "bot-1",
+	}, "user-1")
+	require.NoError(t, err)
+	require.NotEmpty(t, subscription.Secret)
+
+	_, err = svc.Create(ctx, WebhookSubscriptionRequest{Name: "bad", URL: server.URL, Events: []string{"bot_created"}}, "user-1")
+	assert.ErrorIs(t, err, ErrInvalidWebhook)
+
+	stored, err := svc.Get(ctx, subscription.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Secret)
+
+	factory := events.NewEventFactory("bot-service")
+	// Otro bot y otro evento no generan entregas
+	require.NoError(t, bus.Publish(ctx, factory.CreateSystemEvent(events.EventTypeHumanHandoff, map[string]interface{}{"bot_id": "bot-2"})))
+	require.NoError(t, bus.Publish(ctx, factory.CreateSystemEvent(events.EventTypeSessionStarted, map[string]interface{}{"bot_id": "bot-1"})))
+	require.NoError(t, bus.Publish(ctx, factory.CreateSystemEvent(events.EventTypeHumanHandoff, map[string]interface{}{"bot_id": "bot-1", "session_id": "s-1"})))
+
+	var deliveries []*domain.WebhookDelivery
+	require.Eventually(t, func() bool {
+		deliveries, _, err = svc.ListDeliveries(ctx, subscription.ID, 10, 0)
+		return err == nil && len(deliveries) == 1 && deliveries[0].Status == domain.WebhookDeliverySucceeded
+	}, 2*time.Second, 10*time.Millisecond)
+
+	delivery := deliveries[0]
+	assert.Equal(t, 2, delivery.Attempts)
+	assert.Equal(t, http.StatusOK, delivery.ResponseStatus)
+	assert.Equal(t, "ok", delivery.ResponseBody)
+	assert.Equal(t, "handoff_requested", delivery.EventType)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, requests, 2)
+	last := requests[1]
+	assert.Equal(t, "handoff_requested", last.Header.Get(WebhookHeaderEvent))
+	assert.Equal(t, delivery.ID, last.Header.Get(WebhookHeaderDelivery))
+	assert.Equal(t, SignWebhookPayload(subscription.Secret, last.Header.Get(WebhookHeaderTimestamp), bodies[1]), last.Header.Get(WebhookHeaderSignature))
+
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(bodies[1], &payload))
+	assert.Equal(t, "handoff_requested", payload["type"])
+	assert.Equal(t, "s-1", payload["data"].(map[string]interface{})["session_id"])
+}
@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkflowConditions_BranchRoutesOnExpression(t *testing.T) {
+	factory := mcp.NewAgentFactory(logger.NewLogger("error"))
+	factory.UseConditionEvaluator(NewConditionEvaluator())
+
+	setTier := func(tier string) map[string]interface{} {
+		return map[string]interface{}{"type": "set_variable", "config": map[string]interface{}{"name": "tier", "value": tier}}
+	}
+	agent, err := factory.CreateAgent(mcp.MCPConfig{Type: "workflow", Name: "routing", Config: map[string]interface{}{"steps": []interface{}{
+		map[string]interface{}{"type": "condition", "config": map[string]interface{}{"condition": "{{total}} >= 100 && channel_is('web')", "output_variable": "large_web_order"}},
+		map[string]interface{}{"type": "branch", "config": map[string]interface{}{
+			"cases": []interface{}{
+				map[string]interface{}{"name": "vip", "condition": "{{customer.tier}} == gold", "steps": []interface{}{setTier("priority")}},
+				map[string]interface{}{"name": "large", "condition": "gt({{total}}, 500)", "steps": []interface{}{setTier("review")}},
+			},
+			"default": []interface{}{setTier("standard")},
+		}},
+	}}})
+	require.NoError(t, err)
+
+	run := func(input map[string]interface{}) map[string]interface{} {
+		result, err := agent.Execute(context.Background(), mcp.Task{ID: "task-1", Type: "workflow", Input: input})
+		require.NoError(t, err)
+		return result.Output["workflow_data"].(map[string]interface{})
+	}
+
+	data := run(map[string]interface{}{"total": 120, "channel": "web", "customer": map[string]interface{}{"tier": "gold"}})
+	assert.Equal(t, true, data["large_web_order"])
+	assert.Equal(t, "priority", data["tier"])
+	assert.Equal(t, "vip", data["step_2_result"].(map[string]interface{})["case"])
+
+	data = run(map[string]interface{}{"total": 900, "channel": "whatsapp", "customer": map[string]interface{}{"tier": "silver"}})
+	assert.Equal(t, false, data["large_web_order"])
+	assert.Equal(t, "review", data["tier"])
+
+	data = run(map[string]interface{}{"total": 20, "channel": "web"})
+	assert.Equal(t, "standard", data["tier"])
+	assert.Equal(t, false, data["step_2_result"].(map[string]interface{})["matched"])
+
+	_, err = factory.CreateAgent(mcp.MCPConfig{Type: "workflow", Name: "bad", Config: map[string]interface{}{"steps": []interface{}{
+		map[string]interface{}{"type": "branch", "config": map[string]interface{}{"then": []interface{}{setTier("a")}}},
+	}}})
+	assert.ErrorContains(t, err, "requires 'cases' or 'condition'")
+
+	// Forma corta: condition, then y else
+	short, err := factory.CreateAgent(mcp.MCPConfig{Type: "workflow", Name: "short", Config: map[string]interface{}{"steps": []interface{}{
+		map[string]interface{}{"type": "branch", "config": map[string]interface{}{
+			"condition": "empty(coupon)", "then": []interface{}{setTier("full_price")}, "else": []interface{}{setTier("discount")},
+		}},
+	}}})
+	require.NoError(t, err)
+	result, err := short.Execute(context.Background(), mcp.Task{ID: "task-2", Type: "workflow", Input: map[string]interface{}{"coupon": "SAVE10"}})
+	require.NoError(t, err)
+	assert.Equal(t, "discount", result.Output["workflow_data"].(map[string]interface{})["tier"])
+}
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/pkg/logger"
+)
+
+var (
+	ErrWorkflowNotFound = errors.New("workflow definition not found")
+	ErrInvalidWorkflow  = errors.New("invalid workflow definition")
+)
+
+// WorkflowDefinitionRequest son los datos de un workflow guardado; Notes
+// describe el cambio de la versión que se crea
+type WorkflowDefinitionRequest struct {
+	Name        string                   `json:"name" binding:"required"`
+	Description string                   `json:"description"`
+	Steps       []map[string]interface{} `json:"steps" binding:"required"`
+	Notes       string                   `json:"notes"`
+}
+
+// WorkflowDefinitionService gestiona los workflows guardados que los agentes
+// workflow, los pasos api_call y las tareas MCP referencian por ID
+type WorkflowDefinitionService interface {
+	Create(ctx context.Context, request WorkflowDefinitionRequest, createdBy string) (*domain.WorkflowDefinition, error)
+	Get(ctx context.Context, id string) (*domain.WorkflowDefinition, error)
+	List(ctx context.Context) ([]*domain.WorkflowDefinition, error)
+	// Update guarda los cambios como una versión nueva
+	Update(ctx context.Context, id string, request WorkflowDefinitionRequest, updatedBy string) (*domain.WorkflowDefinition, error)
+	Delete(ctx context.Context, id string) error
+
+	GetVersion(ctx context.Context, id string, version int) (*domain.WorkflowDefinition, error)
+	ListVersions(ctx context.Context, id string) ([]*domain.WorkflowDefinition, error)
+	// Restore crea una versión nueva con los pasos de una anterior
+	Restore(ctx context.Context, id string, version int, updatedBy string) (*domain.WorkflowDefinition, error)
+}
+
+type workflowDefinitionService struct {
+	repo   domain.WorkflowDefinitionRepository
+	logger logger.Logger
+}
+
+// NewWorkflowDefinitionService crea el servicio de workflows guardados
+func NewWorkflowDefinitionService(repo domain.WorkflowDefinitionRepository, logger logger.Logger) WorkflowDefinitionService {
+	return &workflowDefinitionService{repo: repo, logger: logger}
+}
+
+func validateWorkflowRequest(request WorkflowDefinitionRequest) error {
+	if strings.TrimSpace(request.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidWorkflow)
+	}
+	if err := mcp.ValidateWorkflowSteps(request.Steps); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidWorkflow, err)
+	}
+	return nil
+}
+
+func (s *workflowDefinitionService) Create(ctx context.Context, request WorkflowDefinitionRequest, createdBy string) (*domain.WorkflowDefinition, error) {
+	if err := validateWorkflowRequest(request); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	definition := &domain.WorkflowDefinition{
+		Name:        request.Name,
+		Description: request.Description,
+		Version:     1,
+		Steps:       request.Steps,
+		Notes:       request.Notes,
+		CreatedBy:   createdBy,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.repo.Create(ctx, definition); err != nil {
+		return nil, fmt.Errorf("failed to store workflow definition: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Workflow definition created",
+		"workflow_id", definition.ID,
+		"name", definition.Name,
+		"steps", len(definition.Steps))
+	return definition, nil
+}
+
+func (s *workflowDefinitionService) Get(ctx context.Context, id string) (*domain.WorkflowDefinition, error) {
+	definition, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
+	}
+	return definition, nil
+}
+
+func (s *workflowDefinitionService) List(ctx context.Context) ([]*domain.WorkflowDefinition, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *workflowDefinitionService) Update(ctx context.Context, id string, request WorkflowDefinitionRequest, updatedBy string) (*domain.WorkflowDefinition, error) {
+	if err := validateWorkflowRequest(request); err != nil {
+		return nil, err
+	}
+	current, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	definition := &domain.WorkflowDefinition{
+		ID:          current.ID,
+		Name:        request.Name,
+		Description: request.Description,
+		Version:     current.Version + 1,
+		Steps:       request.Steps,
+		Notes:       request.Notes,
+		CreatedBy:   updatedBy,
+		CreatedAt:   current.CreatedAt,
+		UpdatedAt:   time.Now(),
+	}
+	if err := s.repo.Update(ctx, definition); err != nil {
+		return nil, fmt.Errorf("failed to store workflow definition: %w", err)
+	}
+
+	s.logger.WithContext(ctx).Info("Workflow definition updated",
+		"workflow_id", definition.ID,
+		"version", definition.Version)
+	return definition, nil
+}
+
+func (s *workflowDefinitionService) Delete(ctx context.Context, id string) error {
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
+	}
+	s.logger.WithContext(ctx).Info("Workflow definition deleted", "workflow_id", id)
+	return nil
+}
+
+func (s *workflowDefinitionService) GetVersion(ctx context.Context, id string, version int) (*domain.WorkflowDefinition, error) {
+	definition, err := s.repo.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s version %d", ErrWorkflowNotFound, id, version)
+	}
+	return definition, nil
+}
+
+func (s *workflowDefinitionService) ListVersions(ctx context.Context, id string) ([]*domain.WorkflowDefinition, error) {
+	versions, err := s.repo.ListVersions(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrWorkflowNotFound, id)
+	}
+	return versions, nil
+}
+
+func (s *workflowDefinitionService) Restore(ctx context.Context, id string, version int, updatedBy string) (*domain.WorkflowDefinition, error) {
+	previous, err := s.GetVersion(ctx, id, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.Update(ctx, id, WorkflowDefinitionRequest{
+		Name:        previous.Name,
+		Description: previous.Description,
+		Steps:       previous.Steps,
+		Notes:       fmt.Sprintf("restored from version %d", version),
+	}, updatedBy)
+}
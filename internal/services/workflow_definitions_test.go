@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func greetingSteps(text string) []map[string]interface{} {
+	return []map[string]interface{}{
+		{"type": "set_variable", "config": map[string]interface{}{"name": "greeting", "value": text}},
+	}
+}
+
+func TestWorkflowDefinitions_AgentsRunLatestOrPinnedVersion(t *testing.T) {
+	ctx := context.Background()
+	log := logger.NewLogger("error")
+	repo := repositories.NewMockWorkflowDefinitionRepository()
+	workflows := NewWorkflowDefinitionService(repo, log)
+
+	_, err := workflows.Create(ctx, WorkflowDefinitionRequest{Name: "broken", Steps: []map[string]interface{}{
+		{"type": "parallel", "config": map[string]interface{}{"join": "any", "branches": []interface{}{}}},
+	}}, "user-1")
+	assert.ErrorIs(t, err, ErrInvalidWorkflow)
+
+	v1, err := workflows.Create(ctx, WorkflowDefinitionRequest{Name: "greet", Steps: greetingSteps("hello")}, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 1, v1.Version)
+
+	factory := mcp.NewAgentFactory(log)
+	factory.UseWorkflowDefinitions(repo)
+	latest, err := factory.CreateAgent(mcp.MCPConfig{Type: "workflow", Name: "latest", Config: map[string]interface{}{"workflow_id": v1.ID}})
+	require.NoError(t, err)
+	pinned, err := factory.CreateAgent(mcp.MCPConfig{Type: "workflow", Name: "pinned", Config: map[string]interface{}{"workflow_id": v1.ID, "workflow_version": 1}})
+	require.NoError(t, err)
+
+	greeting := func(agent mcp.Agent, input map[string]interface{}) interface{} {
+		result, err := agent.Execute(ctx, mcp.Task{ID: "task-1", Type: "workflow", Input: input})
+		require.NoError(t, err)
+		return result.Output["workflow_data"].(map[string]interface{})["greeting"]
+	}
+	assert.Equal(t, "hello", greeting(latest, nil))
+
+	// Editar el workflow crea la versión 2 sin recrear los agentes
+	v2, err := workflows.Update(ctx, v1.ID, WorkflowDefinitionRequest{Name: "greet", Steps: greetingSteps("hi there")}, "user-2")
+	require.NoError(t, err)
+	assert.Equal(t, 2, v2.Version)
+	assert.Equal(t, "hi there", greeting(latest, nil))
+	assert.Equal(t, "hello", greeting(pinned, nil))
+	assert.Equal(t, "hi there", greeting(pinned, map[string]interface{}{"workflow_id": v1.ID}))
+
+	restored, err := workflows.Restore(ctx, v1.ID, 1, "user-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, restored.Version)
+	assert.Equal(t, "hello", greeting(latest, nil))
+
+	versions, err := workflows.ListVersions(ctx, v1.ID)
+	require.NoError(t, err)
+	require.Len(t, versions, 3)
+	assert.Equal(t, "restored from version 1", versions[0].Notes)
+
+	require.NoError(t, workflows.Delete(ctx, v1.ID))
+	_, err = workflows.GetVersion(ctx, v1.ID, 1)
+	assert.ErrorIs(t, err, ErrWorkflowNotFound)
+	result, err := latest.Execute(ctx, mcp.Task{ID: "task-2", Type: "workflow"})
+	assert.Error(t, err)
+	assert.False(t, result.Success)
+}
@@ -2,21 +2,39 @@ package main
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/company/bot-service/internal/adapters"
 	"github.com/company/bot-service/internal/ai"
+	"github.com/company/bot-service/internal/auth"
+	"github.com/company/bot-service/internal/chaos"
 	"github.com/company/bot-service/internal/config"
+	"github.com/company/bot-service/internal/domain"
+	grpcapi "github.com/company/bot-service/internal/grpc"
 	"github.com/company/bot-service/internal/handlers"
 	"github.com/company/bot-service/internal/mcp"
 	"github.com/company/bot-service/internal/middleware"
+	"github.com/company/bot-service/internal/metrics"
+	"github.com/company/bot-service/internal/replication"
 	"github.com/company/bot-service/internal/repositories"
 	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/cache"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/kvstore"
 	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/secrets"
+	"github.com/company/bot-service/pkg/vault"
+	"github.com/company/bot-service/pkg/vectorstore"
 	"github.com/gin-gonic/gin"
+	_ "github.com/jackc/pgx/v5/stdlib"
 )
 
 // @title Microservice Template API
@@ -31,22 +49,182 @@ func main() {
 	// Inicializar logger
 	logger := logger.NewLogger(cfg.LogLevel)
 	
-	// Inicializar cliente de Vault (comentado para testing)
-	// vaultClient, err := vault.NewClient(cfg.VaultConfig)
-	// if err != nil {
-	// 	logger.Fatal("Failed to initialize Vault client", err)
-	// }
+	// Secretos: las referencias ${secret:nombre} se leen de variables de entorno o de Vault
+	var secretProvider secrets.SecretProvider
+	switch cfg.Secrets.Provider {
+	case "vault":
+		vaultClient, err := vault.NewClient(cfg.VaultConfig)
+		if err != nil {
+			logger.Fatal("Failed to initialize Vault client", err)
+		}
+		secretProvider = secrets.NewVaultProvider(vaultClient, cfg.VaultConfig.Path)
+	case "env", "":
+		secretProvider = secrets.NewEnvProvider()
+	default:
+		logger.Fatal("Failed to initialize secrets", fmt.Errorf("unsupported secrets provider: %s", cfg.Secrets.Provider))
+	}
+	secretManager := secrets.NewManager(secretProvider, time.Duration(cfg.Secrets.RefreshIntervalSeconds)*time.Second, logger)
+	
+	// Credenciales de base de datos; se vuelven a resolver cuando rotan
+	dbUser, dbPassword := cfg.Database.User, cfg.Database.Password
+	resolveDatabaseCredentials := func() error {
+		user, err := secretManager.ResolveString(context.Background(), dbUser)
+		if err != nil {
+			return err
+		}
+		password, err := secretManager.ResolveString(context.Background(), dbPassword)
+		if err != nil {
+			return err
+		}
+		cfg.Database.User, cfg.Database.Password = user, password
+		return nil
+	}
+	if err := resolveDatabaseCredentials(); err != nil {
+		logger.Fatal("Failed to resolve database credentials", err)
+	}
+	secretManager.Watch(secrets.References([]interface{}{dbUser, dbPassword}), func(changed []string) {
+		if err := resolveDatabaseCredentials(); err != nil {
+			logger.Error("Failed to resolve rotated database credentials", "error", err)
+			return
+		}
+		logger.Info("Database credentials rotated", "secrets", changed)
+	})
 	
-	// Inicializar cliente de IA
-	aiClient := ai.NewMockAIClient([]string{
+	// Inicializar cliente de IA; LoadTrackingClient mide su carga para el backpressure
+	aiClient := ai.NewLoadTrackingClient(ai.NewMockAIClient([]string{
 		"Hello! How can I help you today?",
 		"I understand your question. Let me help you with that.",
 		"Thank you for your message. Is there anything else I can assist you with?",
-	}, logger)
+	}, logger))
+	
+	// Inicializar bus de eventos
+	eventBus := events.NewInMemoryEventBus(logger)
+	
+	// Publicar los eventos de conversación en Kafka o NATS para analítica y messaging-service
+	var eventForwarder *events.Forwarder
+	if cfg.EventBroker.Driver != events.BrokerNone {
+		publisher, err := events.NewPublisher(events.BrokerConfig{
+			Driver: cfg.EventBroker.Driver,
+			URL:    cfg.EventBroker.URL,
+			Topic:  cfg.EventBroker.Topic,
+		}, logger)
+		if err != nil {
+			logger.Fatal("Failed to initialize event broker", err)
+		}
+		eventTypes := cfg.EventBroker.Events
+		if len(eventTypes) == 0 {
+			eventTypes = events.DefaultBrokerEventTypes()
+		}
+		eventForwarder = events.NewForwarder(publisher, cfg.EventBroker.QueueSize, logger)
+		if err := eventForwarder.Subscribe(eventBus, eventTypes...); err != nil {
+			logger.Fatal("Failed to subscribe event broker", err)
+		}
+		logger.Info("Event broker enabled", "driver", cfg.EventBroker.Driver, "events", eventTypes)
+	}
 	
 	// Inicializar sistema MCP
-	agentFactory := mcp.NewAgentFactory(logger)
+	// La captura de ejecuciones va por dentro para registrar la tarea que recibe el agente real
+	executionCapture := mcp.WithExecutionCapture(mcp.NewAgentFactory(logger))
+	agentFactory := mcp.WithSecrets(executionCapture, secretManager, logger)
+	agentFactory.AllowMCPServerCommands(cfg.Orchestrator.MCPServerCommands)
+	
+	// Perfiles de autenticación HTTP: las credenciales quedan fuera de los flujos
+	var authProfiles *adapters.AuthProfiles
+	if cfg.Secrets.HTTPAuthProfilesFile != "" {
+		profiles, err := adapters.LoadAuthProfiles(cfg.Secrets.HTTPAuthProfilesFile)
+		if err == nil {
+			authProfiles, err = adapters.NewAuthProfiles(profiles, secretManager)
+		}
+		if err != nil {
+			logger.Fatal("Failed to load HTTP auth profiles", err)
+		}
+		agentFactory.UseAuthProfiles(authProfiles)
+		logger.Info("HTTP auth profiles loaded", "profiles", authProfiles.Names())
+	}
+	
+	// Modo chaos: fallos aleatorios en agentes y adaptadores, nunca en producción
+	var faults *chaos.Injector
+	if cfg.Chaos.Enabled {
+		if cfg.Environment == "production" {
+			logger.Warn("Ignoring CHAOS_ENABLED in production")
+		} else {
+			faults = chaos.NewInjector(chaos.Config{
+				LatencyRate: cfg.Chaos.LatencyRate,
+				MaxLatency:  time.Duration(cfg.Chaos.MaxLatencyMs) * time.Millisecond,
+				ErrorRate:   cfg.Chaos.ErrorRate,
+				TimeoutRate: cfg.Chaos.TimeoutRate,
+				Timeout:     time.Duration(cfg.Chaos.TimeoutMs) * time.Millisecond,
+				Targets:     cfg.Chaos.Targets,
+			}, logger)
+			agentFactory = mcp.WithFaultInjection(agentFactory, faults)
+			logger.Warn("Chaos fault injection enabled",
+				"latency_rate", cfg.Chaos.LatencyRate,
+				"error_rate", cfg.Chaos.ErrorRate,
+				"timeout_rate", cfg.Chaos.TimeoutRate,
+				"targets", cfg.Chaos.Targets)
+		}
+	}
 	mcpOrchestrator := mcp.NewOrchestrator(agentFactory, logger)
+	mcpOrchestrator.ConfigureScheduler(mcp.SchedulerConfig{
+		MaxQueueDepth: cfg.Orchestrator.QueueMaxDepth,
+		WaitTimeout:   time.Duration(cfg.Orchestrator.QueueWaitTimeoutMs) * time.Millisecond,
+	})
+	mcpOrchestrator.ConfigureBreakers(mcp.BreakerConfig{
+		FailureRate: cfg.Orchestrator.BreakerFailureRate,
+		MinRequests: cfg.Orchestrator.BreakerMinRequests,
+		WindowSize:  cfg.Orchestrator.BreakerWindowSize,
+		OpenTimeout: time.Duration(cfg.Orchestrator.BreakerOpenTimeoutMs) * time.Millisecond,
+	})
+	
+	// Almacenamiento de objetos (imágenes generadas, servidas bajo /media)
+	publicBaseURL := cfg.Storage.PublicBaseURL
+	if publicBaseURL == "" {
+		publicBaseURL = "http://localhost:" + cfg.Port + "/media"
+	}
+	objectStore := adapters.NewLocalObjectStore("default-object-store", cfg.Storage.ObjectStoreDir, publicBaseURL, logger)
+	if err := objectStore.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start object store", err)
+	}
+	if err := agentFactory.RegisterAdapter(objectStore.GetName(), objectStore); err != nil {
+		logger.Fatal("Failed to register object store", err)
+	}
+	
+	// Bases de datos y colas de clientes para los pasos de integración; una
+	// caída no impide arrancar, sólo deja sin registrar ese adaptador
+	adapterFactory := adapters.NewAdapterFactory(logger)
+	for _, adaptersFile := range []struct{ adapterType, path string }{
+		{"database", cfg.Storage.DatabaseAdaptersFile},
+		{"message_queue", cfg.Storage.QueueAdaptersFile},
+	} {
+		if adaptersFile.path == "" {
+			continue
+		}
+		adapterConfigs, err := adapters.LoadAdapterConfigs(adaptersFile.path)
+		if err != nil {
+			logger.Fatal("Failed to load adapters", err)
+		}
+		for _, adapterConfig := range adapterConfigs {
+			name, _ := adapterConfig["name"].(string)
+			adapterConfig, err = secretManager.Resolve(context.Background(), adapterConfig)
+			if err != nil {
+				logger.Error("Failed to resolve adapter credentials", "type", adaptersFile.adapterType, "adapter", name, "error", err)
+				continue
+			}
+			adapter, err := adapterFactory.CreateAdapter(adaptersFile.adapterType, adapterConfig)
+			if err == nil {
+				err = adapter.Initialize(context.Background(), adapterConfig)
+			}
+			if err == nil {
+				err = adapter.Start(context.Background())
+			}
+			if err == nil {
+				err = agentFactory.RegisterAdapter(adapter.GetName(), adapter)
+			}
+			if err != nil {
+				logger.Error("Failed to start adapter", "type", adaptersFile.adapterType, "adapter", name, "error", err)
+			}
+		}
+	}
 	
 	// Iniciar orquestador MCP
 	if err := mcpOrchestrator.Start(context.Background()); err != nil {
@@ -57,7 +235,9 @@ func main() {
 	botRepo := repositories.NewMockBotRepository()
 	flowRepo := repositories.NewMockBotFlowRepository()
 	stepRepo := repositories.NewMockBotStepRepository()
+	flowVersionRepo := repositories.NewMockFlowVersionRepository()
 	smartReplyRepo := repositories.NewMockSmartReplyRepository()
+	intentExampleRepo := repositories.NewMockIntentExampleRepository()
 	sessionRepo := repositories.NewMockConversationSessionRepository()
 	
 	// Inicializar repositorios de testing
@@ -65,31 +245,452 @@ func main() {
 	triggerRepo := repositories.NewMockTriggerRepository()
 	testCaseRepo := repositories.NewMockTestCaseRepository()
 	testSuiteRepo := repositories.NewMockTestSuiteRepository()
+	multiTurnTestRepo := repositories.NewMockMultiTurnTestCaseRepository()
+	testRunRepo := repositories.NewMockTestRunRepository()
+	taskRepo := repositories.NewMockTaskRepository()
+	deadLetterRepo := repositories.NewMockDeadLetterRepository()
+	knowledgeSourceRepo := repositories.NewMockKnowledgeSourceRepository()
+	knowledgeDocumentRepo := repositories.NewMockKnowledgeDocumentRepository()
+	faqRepo := repositories.NewMockFAQRepository()
+	unansweredRepo := repositories.NewMockUnansweredQuestionRepository()
+	auditRepo := repositories.NewMockAuditRepository()
+	apiKeyRepo := repositories.NewMockAPIKeyRepository()
+	webhookRepo := repositories.NewMockWebhookSubscriptionRepository()
+	workflowRepo := repositories.NewMockWorkflowDefinitionRepository()
+	scriptApprovalRepo := repositories.NewMockScriptApprovalRepository()
+	mcpAgentRepo := repositories.NewMockMCPAgentRepository()
+	messageRepo := repositories.NewMockConversationMessageRepository()
+	analyticsRepo := repositories.NewMockAnalyticsRepository()
+	usageRepo := repositories.NewMockUsageRepository()
+	
+	// Modo embebido: persistir en un fichero local para despliegues de un solo binario
+	var store *kvstore.Store
+	if cfg.Storage.Driver == "embedded" {
+		var err error
+		store, err = kvstore.Open(cfg.Storage.EmbeddedPath)
+		if err != nil {
+			logger.Fatal("Failed to open embedded store", err)
+		}
+		embedded, err := repositories.OpenEmbeddedSet(store)
+		if err != nil {
+			logger.Fatal("Failed to load embedded repositories", err)
+		}
+		botRepo = embedded.Bots
+		flowRepo = embedded.Flows
+		stepRepo = embedded.Steps
+		flowVersionRepo = embedded.FlowVersions
+		smartReplyRepo = embedded.SmartReplies
+		intentExampleRepo = embedded.IntentExamples
+		sessionRepo = embedded.Sessions
+		conditionalRepo = embedded.Conditionals
+		triggerRepo = embedded.Triggers
+		taskRepo = embedded.Tasks
+		deadLetterRepo = embedded.DeadLetters
+		knowledgeSourceRepo = embedded.KnowledgeSources
+		faqRepo = embedded.FAQs
+		auditRepo = embedded.Audits
+		apiKeyRepo = embedded.APIKeys
+		webhookRepo = embedded.Webhooks
+		workflowRepo = embedded.Workflows
+		scriptApprovalRepo = embedded.ScriptApprovals
+		mcpAgentRepo = embedded.MCPAgents
+		messageRepo = embedded.Messages
+		analyticsRepo = embedded.Analytics
+		usageRepo = embedded.Usage
+		logger.Info("Using embedded store", "path", cfg.Storage.EmbeddedPath)
+	}
+	
+	// Las tareas asíncronas se persisten aunque el resto de datos viva en memoria,
+	// para re-encolar las pendientes tras un reinicio y conservar el historial
+	var taskStore *kvstore.Store
+	switch cfg.Tasks.Store {
+	case "embedded", "":
+		if store != nil {
+			break
+		}
+		var err error
+		taskStore, err = kvstore.Open(cfg.Tasks.StorePath)
+		if err != nil {
+			logger.Fatal("Failed to open task store", err)
+		}
+		taskRepo, err = repositories.NewEmbeddedTaskRepository(taskStore)
+		if err != nil {
+			logger.Fatal("Failed to load task store", err)
+		}
+		deadLetterRepo, err = repositories.NewEmbeddedDeadLetterRepository(taskStore)
+		if err != nil {
+			logger.Fatal("Failed to load task store", err)
+		}
+		// Los scripts aprobados del sandbox se guardan con las tareas
+		scriptApprovalRepo, err = repositories.NewEmbeddedScriptApprovalRepository(taskStore)
+		if err != nil {
+			logger.Fatal("Failed to load task store", err)
+		}
+		logger.Info("Using embedded task store", "path", cfg.Tasks.StorePath)
+	case "postgres":
+		db, err := sql.Open("pgx", fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.Name, cfg.Database.SSLMode))
+		if err != nil {
+			logger.Fatal("Failed to open task database", err)
+		}
+		postgresTasks, err := repositories.NewPostgresTaskRepository(db, cfg.Tasks.StoreTable)
+		if err != nil {
+			logger.Fatal("Failed to initialize task store", err)
+		}
+		if err := postgresTasks.EnsureSchema(context.Background()); err != nil {
+			logger.Fatal("Failed to initialize task store", err)
+		}
+		// La cola de tareas fallidas va en la misma base de datos
+		postgresDeadLetters, err := repositories.NewPostgresDeadLetterRepository(db, cfg.Tasks.StoreTable+"_dead_letters")
+		if err != nil {
+			logger.Fatal("Failed to initialize task store", err)
+		}
+		if err := postgresDeadLetters.EnsureSchema(context.Background()); err != nil {
+			logger.Fatal("Failed to initialize task store", err)
+		}
+		postgresApprovals, err := repositories.NewPostgresScriptApprovalRepository(db, cfg.Tasks.StoreTable+"_script_approvals")
+		if err != nil {
+			logger.Fatal("Failed to initialize task store", err)
+		}
+		if err := postgresApprovals.EnsureSchema(context.Background()); err != nil {
+			logger.Fatal("Failed to initialize task store", err)
+		}
+		taskRepo = postgresTasks
+		deadLetterRepo = postgresDeadLetters
+		scriptApprovalRepo = postgresApprovals
+		logger.Info("Using postgres task store", "table", cfg.Tasks.StoreTable, "dead_letter_table", cfg.Tasks.StoreTable+"_dead_letters",
+			"script_approval_table", cfg.Tasks.StoreTable+"_script_approvals")
+	case "memory":
+		logger.Warn("Tasks are kept in memory and will be lost on restart")
+		if store == nil {
+			logger.Warn("Sandbox script approvals are kept in memory and will be lost on restart")
+		}
+	default:
+		logger.Fatal("Failed to initialize task store", fmt.Errorf("unsupported task store: %s", cfg.Tasks.Store))
+	}
+	
+	// Los agentes workflow ejecutan workflows guardados por ID y evalúan
+	// condiciones con el mismo motor de expresiones que los condicionales
+	agentFactory.UseWorkflowDefinitions(workflowRepo)
+	agentFactory.UseConditionEvaluator(services.NewConditionEvaluator())
+	// Los agentes sandbox solo ejecutan scripts aprobados por un administrador
+	agentFactory.UseScriptApprovals(scriptApprovalRepo)
+	
+	// Agentes MCP persistentes: se vuelven a crear con sus IDs y métricas
+	mcpOrchestrator.UsePersistence(mcpAgentRepo, mcp.PersistenceConfig{
+		SnapshotContext:  cfg.Orchestrator.AgentSnapshotContext,
+		SnapshotInterval: time.Duration(cfg.Orchestrator.AgentSnapshotIntervalSeconds) * time.Second,
+	})
+	if _, err := mcpOrchestrator.RestoreAgents(context.Background()); err != nil {
+		logger.Error("Failed to restore MCP agents", "error", err)
+	}
+	
+	// Historial completo de mensajes: cada guardado de sesión copia los mensajes nuevos
+	sessionRepo = repositories.WithMessageHistory(sessionRepo, messageRepo)
+	
+	// Todos los servicios comparten el reloj del sistema
+	systemClock := clock.Real()
+	
+	// Réplica asíncrona a otra región: las escrituras de sesiones y memoria pasan
+	// por los decoradores y el replicador las aplica en la región secundaria
+	memoryService := services.NewMemoryService(logger, 0, 0, systemClock)
+	
+	// Embeddings de las memorias para que los pasos de IA recuerden por significado
+	var memoryVectors vectorstore.Store
+	switch cfg.Memory.VectorStore {
+	case "hnsw", "":
+		memoryVectors = vectorstore.NewHNSW(vectorstore.HNSWConfig{})
+	case "pgvector":
+		db, err := sql.Open(cfg.Memory.PGVectorDriver, fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+			cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.Name, cfg.Database.SSLMode))
+		if err != nil {
+			logger.Fatal("Failed to open pgvector database", err)
+		}
+		pgvector, err := vectorstore.NewPGVector(db, cfg.Memory.PGVectorTable, cfg.Memory.EmbeddingDimensions)
+		if err != nil {
+			logger.Fatal("Failed to initialize pgvector store", err)
+		}
+		if err := pgvector.EnsureSchema(context.Background()); err != nil {
+			logger.Fatal("Failed to initialize pgvector store", err)
+		}
+		memoryVectors = pgvector
+	case "none":
+	default:
+		logger.Fatal("Failed to initialize memory", fmt.Errorf("unsupported memory vector store: %s", cfg.Memory.VectorStore))
+	}
+	if memoryVectors != nil {
+		memoryService.UseEmbeddings(services.MemoryEmbeddingConfig{
+			Embedder: aiClient,
+			Store:    memoryVectors,
+			Model:    cfg.Memory.EmbeddingModel,
+			MinScore: cfg.Memory.MinScore,
+		})
+	}
+	var replicator *replication.Replicator
+	if cfg.Replication.Enabled {
+		if cfg.Replication.Region == "" {
+			logger.Fatal("IT_BOT_SERVICE_REPLICATION_REGION is required when replication is enabled")
+		}
+		replicator = replication.NewReplicator(eventBus, replication.Config{
+			Region:    cfg.Replication.Region,
+			Role:      replication.Role(cfg.Replication.Role),
+			QueueSize: cfg.Replication.QueueSize,
+			BotIDs:    cfg.Replication.BotIDs,
+		}, sessionRepo, memoryService, logger)
+		if err := replicator.Start(context.Background()); err != nil {
+			logger.Fatal("Failed to start replication", err)
+		}
+		sessionRepo = replication.NewSessionRepository(sessionRepo, replicator)
+		memoryService = replication.NewMemoryService(memoryService, replicator)
+	}
+	
+	// Los pasos de IA con config.ai_tools pueden consultar la memoria del usuario
+	if err := mcpOrchestrator.RegisterTool(services.NewMemorySearchTool(memoryService)); err != nil {
+		logger.Fatal("Failed to register memory search tool", err)
+	}
+	
+	// Capacidad del almacén de sesiones en /metrics; se lee en cada scrape
+	metrics.TrackSessionStore(func() metrics.SessionStoreStats {
+		stats, err := sessionRepo.Stats(context.Background())
+		if err != nil {
+			return metrics.SessionStoreStats{}
+		}
+		return metrics.SessionStoreStats{
+			SessionsByBot:      stats.SessionsByBot,
+			AverageContextSize: stats.AverageContextSize,
+		}
+	})
 	
 	// Inicializar servicios
-	healthService := services.NewHealthService()
-	conversationService := services.NewConversationService(sessionRepo, logger)
-	smartReplyService := services.NewSmartReplyService(smartReplyRepo, aiClient, mcpOrchestrator, logger)
-	botFlowService := services.NewBotFlowService(flowRepo, stepRepo, logger)
+	conversationService := services.NewConversationService(sessionRepo, systemClock, logger)
+	smartReplyService := services.NewSmartReplyService(smartReplyRepo, intentExampleRepo, aiClient, mcpOrchestrator, logger)
+	botFlowService := services.NewBotFlowService(flowRepo, stepRepo, flowVersionRepo, logger)
 	botStepService := services.NewBotStepService(stepRepo, logger)
-	taskManager := services.NewTaskManager(mcpOrchestrator, logger, 5, 1000)
+	taskManager := services.NewTaskManager(
+		taskRepo,
+		deadLetterRepo,
+		mcpOrchestrator,
+		eventBus,
+		logger,
+		cfg.Tasks.Workers,
+		cfg.Tasks.QueueSize,
+		time.Duration(cfg.Tasks.RetentionHours)*time.Hour,
+		systemClock,
+	)
+	typeConcurrency, err := services.ParseTaskConcurrency(cfg.Tasks.TypeConcurrency)
+	if err != nil {
+		logger.Fatal("Invalid TASK_TYPE_CONCURRENCY", err)
+	}
+	for taskType, limit := range typeConcurrency {
+		if err := taskManager.LimitConcurrency(taskType, limit); err != nil {
+			logger.Fatal("Failed to limit task concurrency", err)
+		}
+	}
+	if cfg.Tasks.MaxWorkers > 0 {
+		if err := taskManager.EnableAutoscaling(services.WorkerScaling{
+			MinWorkers: cfg.Tasks.MinWorkers,
+			MaxWorkers: cfg.Tasks.MaxWorkers,
+			Interval:   time.Duration(cfg.Tasks.ScaleIntervalSeconds) * time.Second,
+			TargetWait: time.Duration(cfg.Tasks.ScaleTargetWaitSeconds) * time.Second,
+		}); err != nil {
+			logger.Fatal("Invalid task worker autoscaling", err)
+		}
+	}
+	entityService := services.NewEntityExtractionService(aiClient, systemClock, logger)
+	faqService := services.NewFAQService(faqRepo, unansweredRepo, logger)
+	knowledgeService := services.NewKnowledgeService(knowledgeSourceRepo, knowledgeDocumentRepo, logger)
 	botService := services.NewBotService(
 		botRepo,
 		flowRepo,
 		stepRepo,
+		flowVersionRepo,
 		sessionRepo,
 		smartReplyRepo,
 		conversationService,
 		smartReplyService,
+		entityService,
+		memoryService,
+		faqService,
 		mcpOrchestrator,
+		eventBus,
+		taskManager,
 		logger,
 	)
+	if err := taskManager.RegisterHandler(services.TaskTypeFlowResume, botService.ResumeDelayedStep); err != nil {
+		logger.Fatal("Failed to register flow resume handler", err)
+	}
 	
 	// Inicializar servicios de testing
 	conditionalService := services.NewConditionalService(conditionalRepo, logger)
-	triggerService := services.NewTriggerService(triggerRepo, conditionalService, logger)
-	testService := services.NewTestService(testCaseRepo, botService, conditionalService, triggerService, logger)
-	testSuiteService := services.NewTestSuiteService(testSuiteRepo, testService, logger)
+	triggerHTTPAdapter := adapters.NewHTTPAdapter("trigger-actions", "1.0", logger)
+	if err := triggerHTTPAdapter.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start trigger HTTP adapter", err)
+	}
+	triggerHTTP := adapters.WithAuthProfiles(adapters.WithSecretHeaders(triggerHTTPAdapter, secretManager), authProfiles)
+	if faults != nil {
+		triggerHTTP = adapters.WithHTTPFaults(triggerHTTP, faults)
+	}
+	triggerService := services.NewTriggerService(triggerRepo, conditionalService, services.TriggerActionDeps{
+		Sender:       services.NewLogChannelSender(logger),
+		HTTP:         triggerHTTP,
+		Orchestrator: mcpOrchestrator,
+		Memory:       memoryService,
+		EventBus:     eventBus,
+		Escalator:    botService,
+	}, logger)
+	if err := triggerService.Subscribe(eventBus); err != nil {
+		logger.Fatal("Failed to subscribe triggers to the event bus", err)
+	}
+	triggerScheduler := services.NewTriggerScheduler(
+		triggerRepo,
+		sessionRepo,
+		triggerService,
+		time.Duration(cfg.Triggers.SchedulerIntervalSeconds)*time.Second,
+		systemClock,
+		logger,
+	)
+	testService := services.NewTestService(testCaseRepo, multiTurnTestRepo, testRunRepo, sessionRepo, botService, conditionalService, triggerService, logger)
+	testSuiteService := services.NewTestSuiteService(testSuiteRepo, testRunRepo, testService, eventBus, logger)
+	
+	// Auditoría de cambios: el estado de cada recurso antes y después de la operación
+	auditService := services.NewAuditService(auditRepo, logger)
+	auditService.RegisterSnapshot(domain.AuditResourceBot, func(ctx context.Context, id string) (interface{}, error) {
+		return botRepo.GetByID(ctx, id)
+	})
+	auditService.RegisterSnapshot(domain.AuditResourceFlow, func(ctx context.Context, id string) (interface{}, error) {
+		return flowRepo.GetByID(ctx, id)
+	})
+	auditService.RegisterSnapshot(domain.AuditResourceStep, func(ctx context.Context, id string) (interface{}, error) {
+		return stepRepo.GetByID(ctx, id)
+	})
+	auditService.RegisterSnapshot(domain.AuditResourceTrigger, func(ctx context.Context, id string) (interface{}, error) {
+		return triggerRepo.GetByID(ctx, id)
+	})
+	auditService.RegisterSnapshot(domain.AuditResourceMCPAgent, services.AgentAuditSnapshot(mcpOrchestrator))
+	botService.UseAudit(auditService)
+
+	// Caché de respuestas de los pasos ai y api_call con cache_ttl
+	responseCache, err := cache.New(cache.Config{
+		Driver:     cfg.Cache.Driver,
+		RedisURL:   cfg.Cache.RedisURL,
+		Prefix:     "bot-service:response:",
+		MaxEntries: cfg.Cache.MaxEntries,
+	}, systemClock)
+	if err != nil {
+		logger.Fatal("Failed to initialize response cache", err)
+	}
+	if responseCache != nil {
+		botService.UseResponseCache(responseCache)
+		logger.Info("Response cache enabled", "driver", cfg.Cache.Driver)
+	}
+	
+	// Webhooks salientes: las entregas firmadas se envían desde su propio worker
+	webhookService := services.NewWebhookService(webhookRepo, repositories.NewMockWebhookDeliveryRepository(), services.WebhookDeliveryConfig{
+		Workers:   cfg.Webhooks.Workers,
+		QueueSize: cfg.Webhooks.QueueSize,
+		Timeout:   time.Duration(cfg.Webhooks.TimeoutSeconds) * time.Second,
+		Retry: domain.RetryPolicy{
+			MaxAttempts:    cfg.Webhooks.MaxAttempts,
+			InitialBackoff: int64(cfg.Webhooks.InitialBackoffMs),
+			MaxBackoff:     int64(cfg.Webhooks.MaxBackoffMs),
+			Multiplier:     2,
+		},
+	}, logger)
+	if err := webhookService.Subscribe(eventBus); err != nil {
+		logger.Fatal("Failed to subscribe webhooks to the event bus", err)
+	}
+	// El snapshot usa el servicio para que el secreto no llegue a la auditoría
+	auditService.RegisterSnapshot(domain.AuditResourceWebhook, func(ctx context.Context, id string) (interface{}, error) {
+		return webhookService.Get(ctx, id)
+	})
+	
+	// Workflows guardados y versionados, compartidos entre bots y agentes
+	workflowService := services.NewWorkflowDefinitionService(workflowRepo, logger)
+	auditService.RegisterSnapshot(domain.AuditResourceWorkflow, func(ctx context.Context, id string) (interface{}, error) {
+		return workflowService.Get(ctx, id)
+	})
+	
+	// Scripts aprobados para los agentes sandbox (solo mcp:admin)
+	scriptApprovalService := services.NewScriptApprovalService(scriptApprovalRepo, systemClock, logger)
+	auditService.RegisterSnapshot(domain.AuditResourceScriptApproval, func(ctx context.Context, hash string) (interface{}, error) {
+		return scriptApprovalService.Get(ctx, hash)
+	})
+	
+	// Analítica de bots: contadores diarios a partir de los eventos de conversación
+	analyticsService := services.NewAnalyticsService(analyticsRepo, botRepo, sessionRepo, logger)
+	if err := analyticsService.Subscribe(eventBus); err != nil {
+		logger.Fatal("Failed to subscribe analytics to the event bus", err)
+	}
+	
+	// Consumo de IA por bot: tokens y coste estimado, con presupuestos mensuales
+	modelPrices, err := services.ParseModelPrices(cfg.Usage.ModelPrices)
+	if err != nil {
+		logger.Fatal("Invalid AI_MODEL_PRICES", err)
+	}
+	usageService := services.NewUsageService(usageRepo, botRepo, modelPrices, systemClock, logger)
+	smartReplyService.UseUsage(usageService)
+	smartReplyService.UseClock(systemClock)
+	
+	botBundleService := services.NewBotBundleService(botRepo, flowRepo, stepRepo, smartReplyRepo, conditionalRepo, triggerRepo, logger)
+	starterKitService := services.NewStarterKitService(botBundleService, testService, logger)
+	
+	// Bots de sandbox: efímeros, con agentes simulados y fuera de los bots de los clientes
+	sandboxService := services.NewSandboxService(botRepo, starterKitService, botBundleService, testService, services.SandboxLimits{
+		DefaultTTL:       time.Duration(cfg.Sandbox.DefaultTTLHours) * time.Hour,
+		MaxTTL:           time.Duration(cfg.Sandbox.MaxTTLHours) * time.Hour,
+		MaxBots:          cfg.Sandbox.MaxBots,
+		MaxBotsPerCaller: cfg.Sandbox.MaxBotsPerCaller,
+		CleanupInterval:  time.Duration(cfg.Sandbox.CleanupIntervalMinutes) * time.Minute,
+	}, systemClock, logger)
+	
+	// Captura de ejecuciones de agentes de los bots con execution_capture activo
+	var captureService services.ExecutionCaptureService
+	if cfg.Capture.Enabled {
+		captureService = services.NewExecutionCaptureService(
+			repositories.NewMockAgentExecutionRepository(cfg.Capture.MaxRecords), botRepo, services.ExecutionCaptureConfig{
+				DefaultSampleRate: cfg.Capture.DefaultSampleRate,
+				Retention:         time.Duration(cfg.Capture.RetentionHours) * time.Hour,
+				CleanupInterval:   15 * time.Minute,
+				MaxFieldLength:    cfg.Capture.MaxFieldLength,
+			}, logger)
+		executionCapture.SetRecorder(captureService)
+	}
+	
+	// Canal de email: sin SMTP las respuestas sólo se registran
+	emailSender := services.NewLogEmailSender(logger)
+	if cfg.Email.SMTPHost != "" {
+		emailSender = services.NewSMTPEmailSender(cfg.Email.SMTPHost, cfg.Email.SMTPPort, cfg.Email.SMTPUsername, cfg.Email.SMTPPassword)
+	}
+	emailChannel := services.NewEmailChannel(botService, emailSender, services.EmailChannelConfig{
+		FromAddress:  cfg.Email.FromAddress,
+		FromName:     cfg.Email.FromName,
+		DefaultBotID: cfg.Email.DefaultBotID,
+		SNSTopicARN:  cfg.Email.SESTopicARN,
+		IMAP: services.IMAPPollingConfig{
+			Address:      cfg.Email.IMAPAddress,
+			Username:     cfg.Email.IMAPUsername,
+			Password:     cfg.Email.IMAPPassword,
+			Mailbox:      cfg.Email.IMAPMailbox,
+			TLS:          cfg.Email.IMAPTLS,
+			PollInterval: time.Duration(cfg.Email.IMAPPollIntervalSeconds) * time.Second,
+		},
+	}, logger)
+	
+	// Probes de salud: adaptadores registrados y credenciales de los canales configurados
+	healthProbes := []services.HealthProbe{
+		services.NewAdapterProbe(objectStore, true),
+		services.NewAdapterProbe(triggerHTTPAdapter, false),
+	}
+	if cfg.Channels.WhatsAppToken != "" && cfg.Channels.WhatsAppPhoneNumberID != "" {
+		healthProbes = append(healthProbes, services.NewWhatsAppProbe(cfg.Channels.WhatsAppToken, cfg.Channels.WhatsAppPhoneNumberID, "", cfg.Channels.ProbesCritical))
+	}
+	if cfg.Channels.SlackBotToken != "" {
+		healthProbes = append(healthProbes, services.NewSlackProbe(cfg.Channels.SlackBotToken, "", cfg.Channels.ProbesCritical))
+	}
+	if cfg.Channels.TelegramBotToken != "" {
+		healthProbes = append(healthProbes, services.NewTelegramProbe(cfg.Channels.TelegramBotToken, "", cfg.Channels.ProbesCritical))
+	}
+	healthService := services.NewHealthService(healthProbes...)
 	
 	// Inicializar handlers
 	botHandler := handlers.NewBotHandler(
@@ -98,11 +699,14 @@ func main() {
 		botStepService,
 		smartReplyService,
 		conversationService,
+		faqService,
+		botBundleService,
 		logger,
 	)
 	
 	mcpHandler := handlers.NewMCPHandler(mcpOrchestrator, logger)
 	taskHandler := handlers.NewTaskHandler(taskManager, logger)
+	knowledgeHandler := handlers.NewKnowledgeHandler(knowledgeService, logger)
 	testHandler := handlers.NewTestHandlers(
 		conditionalService,
 		triggerService,
@@ -116,6 +720,44 @@ func main() {
 		logger.Fatal("Failed to start task manager", err)
 	}
 	
+	// Iniciar planificador de triggers temporales
+	if err := triggerScheduler.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start trigger scheduler", err)
+	}
+	
+	// Eliminar los bots de sandbox caducados, aunque el endpoint esté desactivado
+	if err := sandboxService.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start sandbox cleanup", err)
+	}
+	
+	if captureService != nil {
+		if err := captureService.Start(context.Background()); err != nil {
+			logger.Fatal("Failed to start execution capture", err)
+		}
+	}
+	
+	// Leer el buzón IMAP si está configurado
+	if cfg.Email.Enabled {
+		if err := emailChannel.Start(context.Background()); err != nil {
+			logger.Fatal("Failed to start email polling", err)
+		}
+	}
+	
+	// Iniciar entrega de webhooks
+	if err := webhookService.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start webhook delivery", err)
+	}
+	
+	// Releer los secretos periódicamente para aplicar las rotaciones
+	if err := secretManager.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start secret rotation watcher", err)
+	}
+	
+	// Iniciar sincronización periódica de la base de conocimiento
+	if err := knowledgeService.Start(context.Background()); err != nil {
+		logger.Fatal("Failed to start knowledge sync", err)
+	}
+	
 	// Configurar Gin
 	if cfg.Environment == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -123,12 +765,83 @@ func main() {
 	
 	router := gin.New()
 	router.Use(gin.Recovery())
+	router.Use(middleware.RequestID())
 	router.Use(middleware.Logger(logger))
 	router.Use(middleware.CORS())
 	router.Use(middleware.Metrics())
+	var apiKeyService services.APIKeyService
+	if cfg.APIKeys.Enabled {
+		if !cfg.Auth.Enabled {
+			logger.Warn("API key issuance endpoints are not protected without AUTH_ENABLED")
+		}
+		apiKeyService = services.NewAPIKeyService(apiKeyRepo, cfg.APIKeys.DefaultRateLimit, logger)
+		router.Use(middleware.APIKeyAuth(apiKeyService, middleware.APIKeyOptions{
+			Required:    cfg.APIKeys.RequiredRoutes,
+			AllowBearer: cfg.Auth.Enabled,
+		}, logger))
+	}
+	var policy *auth.Policy
+	if cfg.Auth.Enabled {
+		if cfg.Auth.JWTSecret == "" {
+			logger.Fatal("JWT_SECRET is required when AUTH_ENABLED is set")
+		}
+		loaded, err := auth.LoadPolicy(cfg.Auth.RBACPolicyFile)
+		if err != nil {
+			logger.Fatal("Failed to load RBAC policy", err)
+		}
+		policy = loaded
+		router.Use(middleware.Authorize(auth.NewJWTManager(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer), policy, logger))
+	}
+	router.Use(middleware.DebugTrace(policy))
+	router.Use(middleware.Audit(auditService, logger))
+	if cfg.Backpressure.Enabled {
+		// Con la cola o el proveedor de IA saturados, /incoming responde 503 con
+		// Retry-After para que messaging-service reintente más tarde
+		backpressure := services.NewBackpressure(services.BackpressureConfig{
+			RejectThreshold:      cfg.Backpressure.RejectThreshold,
+			MaxAIInFlight:        cfg.Backpressure.AIMaxInFlight,
+			AIRateLimitCooldown:  time.Duration(cfg.Backpressure.AIRateLimitCooldownSeconds) * time.Second,
+			ShedLowPriority:      cfg.Backpressure.ShedLowPriority,
+			LowPriorityThreshold: cfg.Backpressure.LowPriorityThreshold,
+			PriorityBots:         cfg.Backpressure.PriorityBots,
+			RetryAfter:           time.Duration(cfg.Backpressure.RetryAfterSeconds) * time.Second,
+		}, taskManager.QueueLoad, aiClient, eventBus, logger)
+		router.Use(middleware.Backpressure(backpressure, cfg.Backpressure.Routes...))
+	}
 	
 	// Rutas
-	handlers.SetupRoutes(router, healthService, botHandler, mcpHandler, taskHandler, testHandler, logger)
+	handlers.SetupRoutes(router, healthService, botHandler, mcpHandler, taskHandler, knowledgeHandler, testHandler, logger)
+	if replicator != nil {
+		handlers.SetupReplicationRoutes(router.Group("/api/v1"), handlers.NewReplicationHandler(replicator, logger))
+	}
+	if apiKeyService != nil {
+		handlers.SetupAPIKeyRoutes(router.Group("/api/v1"), handlers.NewAPIKeyHandler(apiKeyService, logger))
+	}
+	handlers.SetupAuditRoutes(router.Group("/api/v1"), handlers.NewAuditHandler(auditService, logger))
+	handlers.SetupWebhookRoutes(router.Group("/api/v1"), handlers.NewWebhookHandler(webhookService, logger))
+	incomingBatch := services.NewIncomingBatchProcessor(botService, cfg.Incoming.BatchConcurrency, cfg.Incoming.BatchMaxSize, systemClock, logger)
+	handlers.SetupIncomingBatchRoutes(router.Group("/api/v1"), handlers.NewIncomingBatchHandler(incomingBatch, logger))
+	handlers.SetupWorkflowRoutes(router.Group("/api/v1"), handlers.NewWorkflowHandler(workflowService, logger))
+	handlers.SetupScriptApprovalRoutes(router.Group("/api/v1"), handlers.NewScriptApprovalHandler(scriptApprovalService, logger))
+	handlers.SetupAnalyticsRoutes(router.Group("/api/v1"), handlers.NewAnalyticsHandler(analyticsService, logger))
+	handlers.SetupUsageRoutes(router.Group("/api/v1"), handlers.NewUsageHandler(usageService, logger))
+	handlers.SetupMemoryRoutes(router.Group("/api/v1"), handlers.NewMemoryHandler(memoryService, logger))
+	handlers.SetupConversationRoutes(router.Group("/api/v1"), handlers.NewConversationHandler(services.NewConversationHistoryService(messageRepo, botRepo), logger))
+	handlers.SetupStarterKitRoutes(router.Group("/api/v1"), handlers.NewStarterKitHandler(starterKitService, logger))
+	if cfg.Sandbox.Enabled {
+		handlers.SetupSandboxRoutes(router.Group("/api/v1"), handlers.NewSandboxHandler(sandboxService, logger))
+	}
+	if captureService != nil {
+		handlers.SetupExecutionCaptureRoutes(router.Group("/api/v1"), handlers.NewExecutionCaptureHandler(captureService, logger))
+	}
+	if cfg.Email.Enabled && cfg.Email.InboundToken == "" {
+		// Sin token cualquiera podría enviar emails falsos y usar el bot como relay
+		logger.Warn("Inbound email webhooks disabled: EMAIL_INBOUND_TOKEN is not set")
+	} else if cfg.Email.Enabled {
+		handlers.SetupEmailRoutes(router.Group("/api/v1"), handlers.NewEmailHandler(emailChannel, cfg.Email.InboundToken, logger))
+	}
+	handlers.SetupWorkflowOutputRoutes(router.Group("/api/v1"), handlers.NewWorkflowOutputHandler(objectStore, logger))
+	handlers.SetupMediaRoutes(router, cfg.Storage.ObjectStoreDir)
 	
 	// Servidor HTTP
 	srv := &http.Server{
@@ -144,6 +857,22 @@ func main() {
 		}
 	}()
 	
+	// Servidor gRPC (mismos servicios que la API REST)
+	var grpcServer *grpcapi.Server
+	if cfg.GRPCPort != "" {
+		lis, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+		if err != nil {
+			logger.Fatal("Failed to listen on gRPC port", err)
+		}
+		grpcServer = grpcapi.NewServer(botService, botFlowService, logger)
+		go func() {
+			logger.Info("Starting gRPC server on port " + cfg.GRPCPort)
+			if err := grpcServer.Serve(lis); err != nil {
+				logger.Fatal("Failed to start gRPC server", err)
+			}
+		}()
+	}
+	
 	// Graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -154,9 +883,54 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	
+	if grpcServer != nil {
+		grpcServer.Stop(ctx)
+	}
+	
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Fatal("Server forced to shutdown", err)
 	}
 	
+	if err := mcpOrchestrator.Stop(ctx); err != nil {
+		logger.Error("Failed to stop MCP orchestrator", "error", err)
+	}
+	secretManager.Stop()
+	sandboxService.Stop()
+	emailChannel.Stop()
+	if captureService != nil {
+		executionCapture.SetRecorder(nil)
+		captureService.Stop()
+	}
+	if err := webhookService.Stop(ctx); err != nil {
+		logger.Error("Failed to stop webhook delivery", "error", err)
+	}
+	if eventForwarder != nil {
+		if err := eventForwarder.Stop(ctx); err != nil {
+			logger.Error("Failed to flush event broker", "error", err)
+		}
+	}
+	
+	// Publicar lo que quede en el outbox antes de salir
+	if replicator != nil {
+		replicator.Stop()
+	}
+	
+	if responseCache != nil {
+		if err := responseCache.Close(); err != nil {
+			logger.Error("Failed to close response cache", "error", err)
+		}
+	}
+
+	if store != nil {
+		if err := store.Close(); err != nil {
+			logger.Error("Failed to close embedded store", "error", err)
+		}
+	}
+	if taskStore != nil {
+		if err := taskStore.Close(); err != nil {
+			logger.Error("Failed to close task store", "error", err)
+		}
+	}
+	
 	logger.Info("Server exited")
 }
\ No newline at end of file
@@ -0,0 +1,66 @@
+// Package cache guarda respuestas que se pueden reutilizar (respuestas de IA,
+// llamadas HTTP) durante un tiempo, en memoria o en Redis
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/company/bot-service/pkg/clock"
+)
+
+// Drivers soportados por New
+const (
+	DriverNone   = "none"
+	DriverMemory = "memory"
+	DriverRedis  = "redis"
+)
+
+// Cache guarda valores con caducidad. Un error de Get se trata como un fallo
+// de caché: quien la usa puede seguir sin ella.
+type Cache interface {
+	// Get devuelve el valor de la clave y si existía y no había caducado
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Close() error
+}
+
+// Config elige y configura el almacén de la caché
+type Config struct {
+	// Driver es memory (por defecto), redis o none
+	Driver string
+	// RedisURL es redis://[usuario:clave@]host:6379/db (rediss:// para TLS)
+	RedisURL string
+	// Prefix se antepone a las claves en Redis para compartir la instancia
+	Prefix string
+	// MaxEntries limita las entradas en memoria; las menos usadas salen primero
+	MaxEntries int
+	Timeout    time.Duration
+}
+
+// New crea la caché de config; con el driver none devuelve nil
+func New(config Config, clk clock.Clock) (Cache, error) {
+	switch config.Driver {
+	case "", DriverMemory:
+		return NewMemory(config.MaxEntries, clk), nil
+	case DriverRedis:
+		return NewRedis(config.RedisURL, config.Prefix, config.Timeout)
+	case DriverNone:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unsupported cache driver: %s", config.Driver)
+	}
+}
+
+// Key resume las partes en una clave de longitud fija
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,145 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemory_ExpiresAndEvictsLeastRecentlyUsed(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	memory := NewMemory(2, clk)
+
+	require.NoError(t, memory.Set(ctx, "a", []byte("1"), time.Minute))
+	require.NoError(t, memory.Set(ctx, "b", []byte("2"), time.Hour))
+	_, ok, _ := memory.Get(ctx, "a")
+	assert.True(t, ok)
+
+	// b es la menos usada y sale al entrar c
+	require.NoError(t, memory.Set(ctx, "c", []byte("3"), time.Hour))
+	_, ok, _ = memory.Get(ctx, "b")
+	assert.False(t, ok)
+
+	clk.Advance(2 * time.Minute)
+	_, ok, _ = memory.Get(ctx, "a")
+	assert.False(t, ok)
+	value, ok, err := memory.Get(ctx, "c")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "3", string(value))
+	assert.Equal(t, 1, memory.Len())
+}
+
+// fakeRedis atiende AUTH, SELECT, GET y SET ... PX sobre RESP
+func fakeRedis(t *testing.T, password string) (string, *sync.Map) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	data := &sync.Map{}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				authenticated := password == ""
+				for {
+					args, err := readCommand(reader)
+					if err != nil {
+						return
+					}
+					switch strings.ToUpper(args[0]) {
+					case "AUTH":
+						authenticated = args[len(args)-1] == password
+						if !authenticated {
+							io.WriteString(conn, "-WRONGPASS invalid password\r\n")
+							continue
+						}
+						io.WriteString(conn, "+OK\r\n")
+					case "SELECT":
+						io.WriteString(conn, "+OK\r\n")
+					case "GET":
+						if !authenticated {
+							io.WriteString(conn, "-NOAUTH Authentication required\r\n")
+						} else if value, ok := data.Load(args[1]); ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value.(string)), value)
+						} else {
+							io.WriteString(conn, "$-1\r\n")
+						}
+					case "SET":
+						data.Store(args[1], args[2])
+						io.WriteString(conn, "+OK\r\n")
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String(), data
+}
+
+func readCommand(reader *bufio.Reader) ([]string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	count, _ := strconv.Atoi(strings.TrimSpace(line[1:]))
+	args := make([]string, count)
+	for i := range args {
+		header, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, _ := strconv.Atoi(strings.TrimSpace(header[1:]))
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(reader, value); err != nil {
+			return nil, err
+		}
+		args[i] = string(value[:size])
+	}
+	return args, nil
+}
+
+func TestRedis_GetAndSetWithPrefix(t *testing.T) {
+	ctx := context.Background()
+	address, data := fakeRedis(t, "secret")
+
+	redis, err := NewRedis("redis://:secret@"+address+"/2", "bot-service:", time.Second)
+	require.NoError(t, err)
+	defer redis.Close()
+
+	_, ok, err := redis.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, redis.Set(ctx, "k", []byte("hola\r\nmundo"), time.Minute))
+	value, ok, err := redis.Get(ctx, "k")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "hola\r\nmundo", string(value))
+	_, stored := data.Load("bot-service:k")
+	assert.True(t, stored)
+
+	wrong, err := NewRedis("redis://:wrong@"+address, "", time.Second)
+	require.NoError(t, err)
+	_, _, err = wrong.Get(ctx, "k")
+	assert.ErrorContains(t, err, "WRONGPASS")
+
+	_, err = NewRedis("http://"+address, "", time.Second)
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/clock"
+)
+
+// defaultMaxEntries es el tamaño de la caché en memoria si no se indica otro
+const defaultMaxEntries = 10000
+
+// Memory es una caché LRU en memoria del proceso
+type Memory struct {
+	mu       sync.Mutex
+	capacity int
+	clock    clock.Clock
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemory crea una caché en memoria de hasta capacity entradas
+func NewMemory(capacity int, clk clock.Clock) *Memory {
+	if capacity <= 0 {
+		capacity = defaultMaxEntries
+	}
+	return &Memory{
+		capacity: capacity,
+		clock:    clock.OrReal(clk),
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+func (m *Memory) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+	entry := element.Value.(*memoryEntry)
+	if !m.clock.Now().Before(entry.expiresAt) {
+		m.order.Remove(element)
+		delete(m.entries, key)
+		return nil, false, nil
+	}
+	m.order.MoveToFront(element)
+	return append([]byte(nil), entry.value...), true, nil
+}
+
+func (m *Memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry := &memoryEntry{key: key, value: append([]byte(nil), value...), expiresAt: m.clock.Now().Add(ttl)}
+	if element, ok := m.entries[key]; ok {
+		element.Value = entry
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.entries[key] = m.order.PushFront(entry)
+	for m.order.Len() > m.capacity {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// Len devuelve el número de entradas guardadas, incluidas las caducadas que
+// aún no se han consultado
+func (m *Memory) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.order.Len()
+}
+
+func (m *Memory) Close() error {
+	return nil
+}
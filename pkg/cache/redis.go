@@ -0,0 +1,221 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRedisPort    = "6379"
+	defaultRedisTimeout = 2 * time.Second
+)
+
+// errRedisNil es la respuesta nula de Redis (la clave no existe)
+var errRedisNil = errors.New("redis: nil")
+
+// Redis es una caché en Redis compartida por las réplicas del servicio. Usa
+// una única conexión que se abre en la primera operación y se reabre si cae.
+type Redis struct {
+	address  string
+	useTLS   bool
+	username string
+	password string
+	db       int
+	prefix   string
+	timeout  time.Duration
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewRedis crea la caché para redis://[usuario:clave@]host:puerto/db
+// (rediss:// para TLS)
+func NewRedis(rawURL, prefix string, timeout time.Duration) (*Redis, error) {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "redis" && parsed.Scheme != "rediss") || parsed.Hostname() == "" {
+		return nil, fmt.Errorf("invalid redis URL %q: expected redis://host:port/db", rawURL)
+	}
+
+	r := &Redis{
+		address: parsed.Host,
+		useTLS:  parsed.Scheme == "rediss",
+		prefix:  prefix,
+		timeout: timeout,
+	}
+	if parsed.Port() == "" {
+		r.address = net.JoinHostPort(parsed.Hostname(), defaultRedisPort)
+	}
+	if r.timeout <= 0 {
+		r.timeout = defaultRedisTimeout
+	}
+	if user := parsed.User; user != nil {
+		if password, ok := user.Password(); ok {
+			r.username, r.password = user.Username(), password
+		} else {
+			r.password = user.Username()
+		}
+	}
+	if db := strings.Trim(parsed.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := r.do(ctx, "GET", r.prefix+key)
+	if errors.Is(err, errRedisNil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	_, err := r.do(ctx, "SET", r.prefix+key, string(value), "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.closeLocked()
+}
+
+// do envía un comando y lee su respuesta; si la conexión falla se cierra para
+// reabrirla en la siguiente operación
+func (r *Redis) do(ctx context.Context, args ...string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		if err := r.connect(); err != nil {
+			return nil, err
+		}
+	}
+	deadline := time.Now().Add(r.timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	r.conn.SetDeadline(deadline)
+
+	value, err := r.command(args...)
+	var redisErr redisError
+	if err != nil && !errors.Is(err, errRedisNil) && !errors.As(err, &redisErr) {
+		r.closeLocked()
+		return nil, fmt.Errorf("redis %s failed: %w", args[0], err)
+	}
+	return value, err
+}
+
+// connect abre la conexión, se autentica y elige la base de datos. Debe
+// llamarse con r.mu tomado.
+func (r *Redis) connect() error {
+	dialer := &net.Dialer{Timeout: r.timeout}
+	var conn net.Conn
+	var err error
+	if r.useTLS {
+		host, _, _ := net.SplitHostPort(r.address)
+		conn, err = tls.DialWithDialer(dialer, "tcp", r.address, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", r.address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	r.conn, r.reader = conn, bufio.NewReader(conn)
+	conn.SetDeadline(time.Now().Add(r.timeout))
+
+	var setup [][]string
+	switch {
+	case r.username != "":
+		setup = append(setup, []string{"AUTH", r.username, r.password})
+	case r.password != "":
+		setup = append(setup, []string{"AUTH", r.password})
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		if _, err := r.command(args...); err != nil {
+			r.closeLocked()
+			return fmt.Errorf("redis %s failed: %w", args[0], err)
+		}
+	}
+	return nil
+}
+
+func (r *Redis) closeLocked() error {
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn, r.reader = nil, nil
+	return err
+}
+
+// command escribe el comando en RESP y lee una respuesta simple, entera o bulk
+func (r *Redis) command(args ...string) ([]byte, error) {
+	var frame strings.Builder
+	fmt.Fprintf(&frame, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&frame, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := io.WriteString(r.conn, frame.String()); err != nil {
+		return nil, err
+	}
+
+	line, err := r.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("empty redis reply")
+	}
+	switch line[0] {
+	case '+', ':':
+		return []byte(line[1:]), nil
+	case '-':
+		return nil, redisError(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid redis bulk length %q", line)
+		}
+		if size < 0 {
+			return nil, errRedisNil
+		}
+		value := make([]byte, size+2)
+		if _, err := io.ReadFull(r.reader, value); err != nil {
+			return nil, err
+		}
+		return value[:size], nil
+	default:
+		return nil, fmt.Errorf("unexpected redis reply %q", line)
+	}
+}
+
+// redisError es un error devuelto por el servidor; la conexión sigue sirviendo
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
@@ -0,0 +1,197 @@
+// Package clock abstrae la hora del sistema para que expiraciones, timers y
+// métricas se puedan controlar en pruebas y en el simulador.
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock da la hora y crea timers y tickers
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	// AfterFunc ejecuta f cuando pasa d
+	AfterFunc(d time.Duration, f func()) Timer
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer es un timer creado con AfterFunc
+type Timer interface {
+	// Stop evita que el timer se dispare; devuelve false si ya se disparó o estaba parado
+	Stop() bool
+}
+
+// Ticker envía la hora por C cada periodo
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real devuelve el reloj del sistema
+func Real() Clock {
+	return realClock{}
+}
+
+// OrReal devuelve c o, si es nil, el reloj del sistema
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real()
+	}
+	return c
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                  { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration { return time.Since(t) }
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
+
+func (realClock) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// Fake es un reloj que sólo avanza con Advance o Set. Los timers y tickers
+// vencidos se disparan en orden al avanzar; las funciones de AfterFunc se
+// ejecutan dentro de Advance, antes de que éste devuelva.
+type Fake struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// NewFake crea un reloj parado en start
+func NewFake(start time.Time) *Fake {
+	return &Fake{now: start}
+}
+
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *Fake) Since(t time.Time) time.Duration {
+	return f.Now().Sub(t)
+}
+
+func (f *Fake) AfterFunc(d time.Duration, fn func()) Timer {
+	return f.schedule(d, 0, fn, nil)
+}
+
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	return fakeTicker{f.schedule(d, d, nil, make(chan time.Time, 1))}
+}
+
+// Advance adelanta el reloj d, disparando lo que venza por el camino, y
+// devuelve la nueva hora
+func (f *Fake) Advance(d time.Duration) time.Time {
+	return f.Set(f.Now().Add(d))
+}
+
+// Set mueve el reloj a t (nunca hacia atrás) disparando lo que venza hasta t
+func (f *Fake) Set(t time.Time) time.Time {
+	for {
+		f.mu.Lock()
+		if t.Before(f.now) {
+			t = f.now
+		}
+		waiter := f.nextDue(t)
+		if waiter == nil {
+			f.now = t
+			f.mu.Unlock()
+			return t
+		}
+		f.now = waiter.at
+		fire := waiter.fire()
+		f.mu.Unlock()
+		fire()
+	}
+}
+
+// Pending devuelve cuántos timers y tickers siguen activos
+func (f *Fake) Pending() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+func (f *Fake) schedule(d, period time.Duration, fn func(), ch chan time.Time) *fakeWaiter {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	waiter := &fakeWaiter{clock: f, at: f.now.Add(d), period: period, fn: fn, ch: ch}
+	f.waiters = append(f.waiters, waiter)
+	return waiter
+}
+
+// nextDue devuelve el primer waiter que vence hasta t. Debe llamarse con f.mu tomado.
+func (f *Fake) nextDue(t time.Time) *fakeWaiter {
+	sort.SliceStable(f.waiters, func(i, j int) bool { return f.waiters[i].at.Before(f.waiters[j].at) })
+	if len(f.waiters) == 0 || f.waiters[0].at.After(t) {
+		return nil
+	}
+	return f.waiters[0]
+}
+
+// remove quita w de los waiters activos. Debe llamarse con f.mu tomado.
+func (f *Fake) remove(w *fakeWaiter) bool {
+	for i, waiter := range f.waiters {
+		if waiter == w {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeWaiter es un timer o un ticker del reloj Fake
+type fakeWaiter struct {
+	clock  *Fake
+	at     time.Time
+	period time.Duration
+	fn     func()
+	ch     chan time.Time
+}
+
+// fire reprograma o retira el waiter y devuelve lo que hay que ejecutar
+// fuera del lock. Debe llamarse con clock.mu tomado.
+func (w *fakeWaiter) fire() func() {
+	at := w.at
+	if w.period > 0 {
+		w.at = w.at.Add(w.period)
+	} else {
+		w.clock.remove(w)
+	}
+	if w.ch != nil {
+		return func() {
+			// Como time.Ticker, si nadie leyó el tick anterior éste se pierde
+			select {
+			case w.ch <- at:
+			default:
+			}
+		}
+	}
+	return w.fn
+}
+
+func (w *fakeWaiter) Stop() bool {
+	w.clock.mu.Lock()
+	defer w.clock.mu.Unlock()
+	return w.clock.remove(w)
+}
+
+type fakeTicker struct{ waiter *fakeWaiter }
+
+func (t fakeTicker) C() <-chan time.Time { return t.waiter.ch }
+func (t fakeTicker) Stop()               { t.waiter.Stop() }
@@ -0,0 +1,74 @@
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Date(2024, 6, 12, 10, 0, 0, 0, time.UTC)
+
+func TestFake_FiresTimersInOrder(t *testing.T) {
+	fake := NewFake(start)
+	var fired []string
+	var firedAt []time.Time
+	record := func(name string) func() {
+		return func() {
+			fired = append(fired, name)
+			firedAt = append(firedAt, fake.Now())
+		}
+	}
+
+	fake.AfterFunc(2*time.Minute, record("late"))
+	fake.AfterFunc(time.Minute, record("early"))
+	stopped := fake.AfterFunc(90*time.Second, record("stopped"))
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	fake.Advance(30 * time.Second)
+	assert.Empty(t, fired)
+
+	assert.Equal(t, start.Add(5*time.Minute), fake.Advance(270*time.Second))
+	assert.Equal(t, []string{"early", "late"}, fired)
+	assert.Equal(t, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)}, firedAt)
+	assert.Equal(t, 0, fake.Pending())
+	assert.Equal(t, time.Minute, fake.Since(start.Add(4*time.Minute)))
+}
+
+func TestFake_TimerCanScheduleAnother(t *testing.T) {
+	fake := NewFake(start)
+	var fired []time.Time
+	fake.AfterFunc(time.Minute, func() {
+		fired = append(fired, fake.Now())
+		fake.AfterFunc(time.Minute, func() { fired = append(fired, fake.Now()) })
+	})
+
+	fake.Advance(3 * time.Minute)
+	assert.Equal(t, []time.Time{start.Add(time.Minute), start.Add(2 * time.Minute)}, fired)
+}
+
+func TestFake_Ticker(t *testing.T) {
+	fake := NewFake(start)
+	ticker := fake.NewTicker(time.Minute)
+
+	fake.Advance(time.Minute)
+	require.Len(t, ticker.C(), 1)
+	assert.Equal(t, start.Add(time.Minute), <-ticker.C())
+
+	// Los ticks que nadie lee se pierden, como con time.Ticker
+	fake.Advance(3 * time.Minute)
+	require.Len(t, ticker.C(), 1)
+	assert.Equal(t, start.Add(2*time.Minute), <-ticker.C())
+
+	ticker.Stop()
+	fake.Advance(time.Hour)
+	assert.Len(t, ticker.C(), 0)
+	assert.Equal(t, 0, fake.Pending())
+}
+
+func TestFake_SetNeverGoesBack(t *testing.T) {
+	fake := NewFake(start)
+	assert.Equal(t, start, fake.Set(start.Add(-time.Hour)))
+}
@@ -0,0 +1,231 @@
+// Package configschema valida configuraciones map[string]interface{} contra un
+// esquema declarativo y ofrece accesores tipados para leerlas.
+package configschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Tipos de valor admitidos en un esquema
+const (
+	TypeString   = "string"
+	TypeNumber   = "number"
+	TypeInteger  = "integer"
+	TypeBoolean  = "boolean"
+	TypeArray    = "array"
+	TypeObject   = "object"
+	TypeDuration = "duration" // texto como "30s" o número de segundos
+)
+
+// Formatos admitidos para campos de texto
+const (
+	FormatURL = "url"
+)
+
+// Field describe un campo de configuración
+type Field struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Required    bool        `json:"required"`
+	Description string      `json:"description,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+	Format      string      `json:"format,omitempty"`
+	Min         *float64    `json:"min,omitempty"`
+	Max         *float64    `json:"max,omitempty"`
+}
+
+// FieldError es el error de validación de un campo
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError agrupa todos los errores encontrados en una configuración
+type ValidationError struct {
+	Errors []FieldError `json:"errors"`
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		messages[i] = fieldErr.Field + ": " + fieldErr.Message
+	}
+	return "invalid config: " + strings.Join(messages, "; ")
+}
+
+// Add agrega un error de campo
+func (e *ValidationError) Add(field, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Err devuelve nil si no hay errores, para poder retornarlo directamente
+func (e *ValidationError) Err() error {
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Bound es un atajo para declarar Min y Max
+func Bound(value float64) *float64 {
+	return &value
+}
+
+// CheckSchema comprueba que el esquema esté bien declarado
+func CheckSchema(fields []Field) error {
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if field.Name == "" {
+			return fmt.Errorf("config field without name")
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("duplicated config field %s", field.Name)
+		}
+		seen[field.Name] = true
+		switch field.Type {
+		case TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeArray, TypeObject, TypeDuration:
+		default:
+			return fmt.Errorf("config field %s has unsupported type: %s", field.Name, field.Type)
+		}
+		if field.Format != "" && field.Format != FormatURL {
+			return fmt.Errorf("config field %s has unsupported format: %s", field.Name, field.Format)
+		}
+	}
+	return nil
+}
+
+// Validate valida la configuración y devuelve un *ValidationError con todos
+// los campos inválidos, o nil si es válida
+func Validate(fields []Field, config map[string]interface{}) error {
+	result := &ValidationError{}
+	for _, field := range fields {
+		value, exists := config[field.Name]
+		if !exists || value == nil {
+			if field.Required {
+				result.Add(field.Name, "is required")
+			}
+			continue
+		}
+		if message := field.check(value); message != "" {
+			result.Add(field.Name, "%s", message)
+		}
+	}
+	return result.Err()
+}
+
+// check devuelve la descripción del problema del valor o "" si es válido
+func (f Field) check(value interface{}) string {
+	if !matchesType(f.Type, value) {
+		return fmt.Sprintf("must be %s %s", article(f.Type), f.Type)
+	}
+
+	number, numeric := toFloat(value)
+	if str, ok := value.(string); ok {
+		if str == "" {
+			if f.Required {
+				return "is required"
+			}
+			return ""
+		}
+		if len(f.Enum) > 0 && !contains(f.Enum, str) {
+			return fmt.Sprintf("must be one of %s", strings.Join(f.Enum, ", "))
+		}
+		if f.Format == FormatURL && !isURL(str) {
+			return "must be an absolute http(s) URL"
+		}
+		if f.Type == TypeDuration {
+			duration, err := time.ParseDuration(str)
+			if err != nil {
+				return "must be a duration such as 30s"
+			}
+			// Los límites de una duración van en segundos, como su valor numérico
+			number, numeric = duration.Seconds(), true
+		}
+	}
+
+	if numeric && f.Type != TypeString {
+		if f.Min != nil && number < *f.Min {
+			return fmt.Sprintf("must be at least %v", *f.Min)
+		}
+		if f.Max != nil && number > *f.Max {
+			return fmt.Sprintf("must be at most %v", *f.Max)
+		}
+	}
+	return ""
+}
+
+// matchesType acepta también slices y structs tipados, ya que los servicios
+// pasan estructuras de dominio sin serializarlas
+func matchesType(fieldType string, value interface{}) bool {
+	if number, ok := value.(json.Number); ok {
+		switch fieldType {
+		case TypeNumber, TypeDuration:
+			return true
+		case TypeInteger:
+			_, err := number.Int64()
+			return err == nil
+		}
+		return false
+	}
+
+	kind := reflect.Indirect(reflect.ValueOf(value)).Kind()
+	switch fieldType {
+	case TypeString:
+		return kind == reflect.String
+	case TypeNumber:
+		return isNumberKind(kind)
+	case TypeInteger:
+		number, ok := toFloat(value)
+		return ok && number == float64(int64(number))
+	case TypeBoolean:
+		return kind == reflect.Bool
+	case TypeArray:
+		return kind == reflect.Slice || kind == reflect.Array
+	case TypeObject:
+		return kind == reflect.Map || kind == reflect.Struct
+	case TypeDuration:
+		return kind == reflect.String || isNumberKind(kind)
+	}
+	return false
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func isURL(value string) bool {
+	parsed, err := url.Parse(value)
+	if err != nil {
+		return false
+	}
+	return (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+func article(fieldType string) string {
+	switch fieldType {
+	case TypeArray, TypeObject, TypeInteger:
+		return "an"
+	}
+	return "a"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package configschema
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_AggregatesFieldErrors(t *testing.T) {
+	schema := []Field{
+		{Name: "base_url", Type: TypeString, Required: true, Format: FormatURL},
+		{Name: "provider", Type: TypeString, Enum: []string{"openai", "local"}},
+		{Name: "memory_mb", Type: TypeInteger, Min: Bound(1), Max: Bound(512)},
+		{Name: "timeout", Type: TypeDuration},
+		{Name: "headers", Type: TypeObject},
+	}
+
+	err := Validate(schema, map[string]interface{}{
+		"base_url":  "not a url",
+		"provider":  "other",
+		"memory_mb": 1024.0,
+		"timeout":   "soon",
+		"headers":   "x",
+	})
+
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	fields := make([]string, 0, len(validationErr.Errors))
+	for _, fieldErr := range validationErr.Errors {
+		fields = append(fields, fieldErr.Field)
+	}
+	assert.Equal(t, []string{"base_url", "provider", "memory_mb", "timeout", "headers"}, fields)
+
+	assert.NoError(t, Validate(schema, map[string]interface{}{
+		"base_url":  "https://api.example.com",
+		"provider":  "local",
+		"memory_mb": 256,
+		"timeout":   30,
+	}))
+
+	err = Validate(schema, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "base_url: is required")
+}
+
+func TestValidate_DurationBoundsInSeconds(t *testing.T) {
+	schema := []Field{{Name: "timeout", Type: TypeDuration, Min: Bound(1), Max: Bound(60)}}
+
+	assert.NoError(t, Validate(schema, map[string]interface{}{"timeout": "30s"}))
+	assert.NoError(t, Validate(schema, map[string]interface{}{"timeout": 30}))
+	for _, timeout := range []interface{}{"0s", "500ms", "2m", 0, 61} {
+		assert.Error(t, Validate(schema, map[string]interface{}{"timeout": timeout}), timeout)
+	}
+}
+
+func TestValues_TypedAccessors(t *testing.T) {
+	values := Values{
+		"model":   "",
+		"limit":   12.0,
+		"timeout": "1m",
+		"tags":    []interface{}{"a", 1, "b"},
+		"headers": map[string]interface{}{"X-Key": "v", "X-Num": 2},
+	}
+
+	assert.Equal(t, "gpt", values.String("model", "gpt"))
+	assert.Equal(t, 12, values.Int("limit", 0))
+	assert.Equal(t, time.Minute, values.Duration("timeout", 0))
+	assert.Equal(t, []string{"a", "b"}, values.Strings("tags"))
+	assert.Equal(t, map[string]string{"X-Key": "v"}, values.StringMap("headers"))
+}
@@ -0,0 +1,119 @@
+package configschema
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Values envuelve una configuración ya validada para leerla con tipos
+type Values map[string]interface{}
+
+// String devuelve el texto del campo o def si no existe o está vacío
+func (v Values) String(key, def string) string {
+	if str, ok := v[key].(string); ok && str != "" {
+		return str
+	}
+	return def
+}
+
+// Int devuelve el campo numérico como entero
+func (v Values) Int(key string, def int) int {
+	if number, ok := toFloat(v[key]); ok {
+		return int(number)
+	}
+	return def
+}
+
+// Float devuelve el campo numérico como float64
+func (v Values) Float(key string, def float64) float64 {
+	if number, ok := toFloat(v[key]); ok {
+		return number
+	}
+	return def
+}
+
+// Bool devuelve el campo booleano
+func (v Values) Bool(key string, def bool) bool {
+	if b, ok := v[key].(bool); ok {
+		return b
+	}
+	return def
+}
+
+// Duration acepta textos como "30s" o números en segundos
+func (v Values) Duration(key string, def time.Duration) time.Duration {
+	switch value := v[key].(type) {
+	case string:
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	default:
+		if seconds, ok := toFloat(value); ok {
+			return time.Duration(seconds * float64(time.Second))
+		}
+	}
+	return def
+}
+
+// Strings devuelve los elementos de texto de un campo array
+func (v Values) Strings(key string) []string {
+	switch value := v[key].(type) {
+	case []string:
+		return append([]string(nil), value...)
+	case []interface{}:
+		result := make([]string, 0, len(value))
+		for _, item := range value {
+			if str, ok := item.(string); ok {
+				result = append(result, str)
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// StringMap devuelve los valores de texto de un campo objeto
+func (v Values) StringMap(key string) map[string]string {
+	switch value := v[key].(type) {
+	case map[string]string:
+		result := make(map[string]string, len(value))
+		for k, item := range value {
+			result[k] = item
+		}
+		return result
+	case map[string]interface{}:
+		result := make(map[string]string, len(value))
+		for k, item := range value {
+			if str, ok := item.(string); ok {
+				result[k] = str
+			}
+		}
+		return result
+	}
+	return nil
+}
+
+// Object devuelve un campo objeto genérico
+func (v Values) Object(key string) map[string]interface{} {
+	object, _ := v[key].(map[string]interface{})
+	return object
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
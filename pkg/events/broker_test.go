@@ -0,0 +1,134 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type natsMessage struct {
+	subject string
+	payload []byte
+}
+
+// fakeNATSServer acepta una conexión, comprueba el CONNECT y envía los PUB recibidos a messages
+func fakeNATSServer(t *testing.T) (string, <-chan string, <-chan natsMessage) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+
+	connects := make(chan string, 1)
+	messages := make(chan natsMessage, 10)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		reader := bufio.NewReader(conn)
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch fields[0] {
+			case "CONNECT":
+				connects <- strings.TrimSpace(strings.TrimPrefix(line, "CONNECT"))
+			case "PING":
+				fmt.Fprint(conn, "PONG\r\n")
+			case "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				payload := make([]byte, size+2)
+				if _, err := io.ReadFull(reader, payload); err != nil {
+					return
+				}
+				messages <- natsMessage{subject: fields[1], payload: payload[:size]}
+			}
+		}
+	}()
+	return listener.Addr().String(), connects, messages
+}
+
+func TestForwarder_PublishesBusEventsToNATS(t *testing.T) {
+	log := logger.NewLogger("error")
+	address, connects, messages := fakeNATSServer(t)
+
+	publisher, err := NewPublisher(BrokerConfig{Driver: BrokerNATS, URL: "nats://analytics:s3cret@" + address, Topic: "bots"}, log)
+	require.NoError(t, err)
+
+	var options map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(<-connects), &options))
+	assert.Equal(t, "analytics", options["user"])
+	assert.Equal(t, "s3cret", options["pass"])
+
+	bus := NewInMemoryEventBus(log)
+	forwarder := NewForwarder(publisher, 10, log)
+	require.NoError(t, forwarder.Subscribe(bus, EventTypeMessageReceived))
+
+	factory := NewEventFactory("bot-service")
+	require.NoError(t, bus.Publish(context.Background(), factory.CreateUserEvent(EventTypeMessageReceived, "user-1", map[string]interface{}{"bot_id": "bot-1"})))
+	// Los tipos a los que no se suscribió no salen del servicio
+	require.NoError(t, bus.Publish(context.Background(), factory.CreateSystemEvent(EventTypeReplication, nil)))
+
+	select {
+	case message := <-messages:
+		assert.Equal(t, "bots.message_received", message.subject)
+		var event Event
+		require.NoError(t, json.Unmarshal(message.payload, &event))
+		assert.Equal(t, "user-1", event.UserID)
+		assert.Equal(t, "bot-1", event.Data["bot_id"])
+	case <-time.After(2 * time.Second):
+		t.Fatal("event was not published to NATS")
+	}
+
+	require.NoError(t, forwarder.Stop(context.Background()))
+	assert.ErrorIs(t, publisher.Publish(context.Background(), Event{Type: EventTypeMessageReceived}), ErrPublisherClosed)
+	assert.Empty(t, messages)
+}
+
+func TestKafkaRESTPublisher_ReportsRecordErrors(t *testing.T) {
+	var records []map[string]interface{}
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/topics/bot-events", r.URL.Path)
+		assert.Equal(t, "application/vnd.kafka.json.v2+json", r.Header.Get("Content-Type"))
+		var body struct {
+			Records []map[string]interface{} `json:"records"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		records = append(records, body.Records...)
+		if failing {
+			w.Write([]byte(`{"offsets":[{"partition":null,"offset":null,"error_code":50002,"error":"Kafka error"}]}`))
+			return
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":12}]}`))
+	}))
+	defer server.Close()
+
+	publisher, err := NewPublisher(BrokerConfig{Driver: BrokerKafka, URL: server.URL, Topic: "bot-events"}, logger.NewLogger("error"))
+	require.NoError(t, err)
+
+	event := NewEventFactory("bot-service").CreateSystemEvent(EventTypeTaskStatusChanged, map[string]interface{}{"bot_id": "bot-1", "status": "running"})
+	require.NoError(t, publisher.Publish(context.Background(), event))
+	failing = true
+	assert.ErrorContains(t, publisher.Publish(context.Background(), event), "Kafka error")
+
+	require.Len(t, records, 2)
+	assert.Equal(t, "bot-1", records[0]["key"])
+	assert.Equal(t, "task_status_changed", records[0]["value"].(map[string]interface{})["type"])
+}
@@ -2,12 +2,34 @@ package events
 
 import (
 	"context"
-	"fmt"
 	"time"
 
+	"github.com/company/bot-service/pkg/id"
 	"github.com/company/bot-service/pkg/logger"
 )
 
+// Tipos de evento emitidos por el servicio
+const (
+	EventTypeConversationEnded  = "conversation_ended"
+	EventTypeFormCompleted      = "form_completed"
+	EventTypeHumanHandoff       = "human_handoff"
+	EventTypeHandoffAccepted    = "handoff_accepted"
+	EventTypeHandoffMessage     = "handoff_message" // mensaje del usuario para el agente humano
+	EventTypeAgentMessage       = "agent_message"   // mensaje del agente humano para el usuario
+	EventTypeHandoffReleased    = "handoff_released"
+	EventTypeBotMessage         = "bot_message"  // mensaje del bot fuera de respuesta, p. ej. tras un paso delay
+	EventTypeReplication        = "replication"  // escritura de sesión o memoria a aplicar en otra región
+	EventTypeBackpressure       = "backpressure" // cambio del nivel de carga con el que /incoming rechaza mensajes
+	EventTypeMessageProcessed   = "message_processed"
+	EventTypeSessionStarted     = "session_started"
+	EventTypeTestSuiteCompleted = "test_suite_completed"
+	EventTypeTaskCompleted      = "task_completed"
+	EventTypeMessageReceived    = "message_received"
+	EventTypeTriggerFired       = "trigger_fired"
+	EventTypeTaskStatusChanged  = "task_status_changed"
+	EventTypeSentimentThreshold = "sentiment_threshold" // el usuario encadenó mensajes negativos
+)
+
 // Event representa un evento del sistema
 type Event struct {
 	ID        string                 `json:"id"`
@@ -162,5 +184,5 @@ func (f *EventFactory) CreateSystemEvent(eventType string, data map[string]inter
 }
 
 func generateEventID() string {
-	return fmt.Sprintf("evt_%d", time.Now().UnixNano())
+	return "evt_" + id.New()
 }
\ No newline at end of file
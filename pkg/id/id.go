@@ -0,0 +1,72 @@
+// Package id genera los identificadores del servicio: UUIDv7 (RFC 9562), únicos
+// entre procesos y ordenables por fecha de creación.
+package id
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxSequence es el mayor valor del contador de 12 bits dentro de un milisegundo
+const maxSequence = 0xfff
+
+var (
+	mu         sync.Mutex
+	lastMillis int64
+	sequence   uint16
+)
+
+// New devuelve un UUIDv7 nuevo en formato texto
+func New() string {
+	return NewUUID().String()
+}
+
+// NewUUID devuelve un UUIDv7 nuevo. Los 12 bits rand_a hacen de contador dentro
+// del mismo milisegundo, así que los IDs de un proceso son estrictamente crecientes
+// aunque se generen miles por milisegundo; los 62 bits finales son aleatorios.
+func NewUUID() uuid.UUID {
+	var u uuid.UUID
+	if _, err := rand.Read(u[8:]); err != nil {
+		panic(fmt.Sprintf("id: failed to read random bytes: %v", err))
+	}
+
+	mu.Lock()
+	millis := time.Now().UnixMilli()
+	if millis > lastMillis {
+		lastMillis = millis
+		sequence = 0
+	} else {
+		// Mismo milisegundo o reloj hacia atrás: se sigue contando sobre el último
+		sequence++
+		if sequence > maxSequence {
+			lastMillis++
+			sequence = 0
+		}
+	}
+	millis, seq := lastMillis, sequence
+	mu.Unlock()
+
+	var timestamp [8]byte
+	binary.BigEndian.PutUint64(timestamp[:], uint64(millis))
+	copy(u[:6], timestamp[2:])
+	u[6] = 0x70 | byte(seq>>8)
+	u[7] = byte(seq)
+	u[8] = u[8]&0x3f | 0x80
+	return u
+}
+
+// Time devuelve el instante de creación de un UUIDv7, o false si s no lo es
+func Time(s string) (time.Time, bool) {
+	u, err := uuid.Parse(s)
+	if err != nil || u.Version() != 7 {
+		return time.Time{}, false
+	}
+	var timestamp [8]byte
+	copy(timestamp[2:], u[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(timestamp[:]))), true
+}
@@ -0,0 +1,58 @@
+package id
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_IsSortableUUIDv7(t *testing.T) {
+	before := time.Now().Truncate(time.Millisecond)
+	first := New()
+	parsed, err := uuid.Parse(first)
+	require.NoError(t, err)
+	assert.Equal(t, uuid.Version(7), parsed.Version())
+	assert.Equal(t, uuid.RFC4122, parsed.Variant())
+
+	created, ok := Time(first)
+	require.True(t, ok)
+	assert.False(t, created.Before(before))
+	assert.WithinDuration(t, time.Now(), created, time.Second)
+
+	generated := make([]string, 10000)
+	for i := range generated {
+		generated[i] = New()
+	}
+	assert.True(t, sort.StringsAreSorted(generated), "ids of one process must be increasing")
+
+	_, ok = Time(uuid.New().String())
+	assert.False(t, ok)
+}
+
+func TestNew_NoCollisionsUnderLoad(t *testing.T) {
+	const workers, perWorker = 8, 5000
+	results := make(chan string, workers*perWorker)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWorker; i++ {
+				results <- New()
+			}
+		}()
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[string]bool, workers*perWorker)
+	for generated := range results {
+		require.False(t, seen[generated], "duplicate id %s", generated)
+		seen[generated] = true
+	}
+}
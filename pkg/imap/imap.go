@@ -0,0 +1,222 @@
+// Package imap es un cliente IMAP4rev1 mínimo para leer buzones por polling:
+// login, selección de carpeta, búsqueda de mensajes no leídos, descarga del
+// mensaje completo y marcado como leído. No implementa IDLE ni extensiones.
+package imap
+
+import (
+	"bufio"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoMessage indica que FETCH no devolvió el cuerpo del mensaje pedido
+var ErrNoMessage = errors.New("message not found")
+
+// Client es una conexión IMAP autenticable; no es seguro para uso concurrente
+type Client struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	tag    int
+	// timeout limita cada comando; 0 no pone límite
+	timeout time.Duration
+}
+
+// response son las líneas sin etiquetar y los literales {n} recibidos antes
+// de la respuesta etiquetada de un comando
+type response struct {
+	lines    []string
+	literals [][]byte
+}
+
+// Dial conecta con address (host:puerto). Con useTLS se usa TLS implícito
+// (puerto 993); sin él la conexión va en claro, útil sólo en desarrollo.
+// timeout se aplica a la conexión y a cada comando por separado.
+func Dial(address string, useTLS bool, timeout time.Duration) (*Client, error) {
+	dialer := &net.Dialer{Timeout: timeout}
+	var conn net.Conn
+	var err error
+	if useTLS {
+		host, _, _ := net.SplitHostPort(address)
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: host})
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to IMAP server: %w", err)
+	}
+	if timeout > 0 {
+		conn.SetDeadline(time.Now().Add(timeout))
+	}
+	client, err := NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	client.timeout = timeout
+	return client, nil
+}
+
+// NewClient usa una conexión ya abierta y lee el saludo del servidor
+func NewClient(conn net.Conn) (*Client, error) {
+	client := &Client{conn: conn, reader: bufio.NewReader(conn)}
+	greeting, err := client.readLine()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read IMAP greeting: %w", err)
+	}
+	if !strings.HasPrefix(greeting, "* OK") && !strings.HasPrefix(greeting, "* PREAUTH") {
+		return nil, fmt.Errorf("unexpected IMAP greeting: %s", greeting)
+	}
+	return client, nil
+}
+
+// Login autentica con usuario y contraseña
+func (c *Client) Login(username, password string) error {
+	_, err := c.command("LOGIN " + quote(username) + " " + quote(password))
+	return err
+}
+
+// Select abre la carpeta en modo lectura-escritura
+func (c *Client) Select(mailbox string) error {
+	_, err := c.command("SELECT " + quote(mailbox))
+	return err
+}
+
+// SearchUnseen devuelve los UID de los mensajes sin el flag \Seen
+func (c *Client) SearchUnseen() ([]uint32, error) {
+	resp, err := c.command("UID SEARCH UNSEEN")
+	if err != nil {
+		return nil, err
+	}
+
+	var uids []uint32
+	for _, line := range resp.lines {
+		rest, ok := strings.CutPrefix(line, "* SEARCH")
+		if !ok {
+			continue
+		}
+		for _, field := range strings.Fields(rest) {
+			uid, err := strconv.ParseUint(field, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UID in SEARCH response: %q", field)
+			}
+			uids = append(uids, uint32(uid))
+		}
+	}
+	return uids, nil
+}
+
+// FetchMessage descarga el mensaje completo (RFC 5322) sin marcarlo como leído
+func (c *Client) FetchMessage(uid uint32) ([]byte, error) {
+	resp, err := c.command(fmt.Sprintf("UID FETCH %d (BODY.PEEK[])", uid))
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.literals) == 0 {
+		return nil, ErrNoMessage
+	}
+	return resp.literals[0], nil
+}
+
+// MarkSeen añade el flag \Seen al mensaje
+func (c *Client) MarkSeen(uid uint32) error {
+	_, err := c.command(fmt.Sprintf("UID STORE %d +FLAGS.SILENT (\\Seen)", uid))
+	return err
+}
+
+// Logout cierra la sesión y la conexión
+func (c *Client) Logout() error {
+	_, err := c.command("LOGOUT")
+	if closeErr := c.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// command envía un comando etiquetado y lee hasta su respuesta de estado
+func (c *Client) command(cmd string) (*response, error) {
+	if c.timeout > 0 {
+		c.conn.SetDeadline(time.Now().Add(c.timeout))
+	}
+	c.tag++
+	tag := fmt.Sprintf("a%d", c.tag)
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, cmd); err != nil {
+		return nil, fmt.Errorf("failed to send IMAP command: %w", err)
+	}
+
+	resp := &response{}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read IMAP response: %w", err)
+		}
+
+		// Una línea que termina en {n} va seguida de n bytes literales y
+		// continúa después de ellos
+		for {
+			size, ok := literalSize(line)
+			if !ok {
+				break
+			}
+			literal := make([]byte, size)
+			if _, err := io.ReadFull(c.reader, literal); err != nil {
+				return nil, fmt.Errorf("failed to read IMAP literal: %w", err)
+			}
+			resp.literals = append(resp.literals, literal)
+			rest, err := c.readLine()
+			if err != nil {
+				return nil, fmt.Errorf("failed to read IMAP response: %w", err)
+			}
+			line += " " + rest
+		}
+
+		status, ok := strings.CutPrefix(line, tag+" ")
+		if !ok {
+			resp.lines = append(resp.lines, line)
+			continue
+		}
+		if !strings.HasPrefix(status, "OK") {
+			// No se repite el comando LOGIN para no dejar la contraseña en los logs
+			if strings.HasPrefix(cmd, "LOGIN ") {
+				cmd = "LOGIN"
+			}
+			return nil, fmt.Errorf("IMAP command %s failed: %s", cmd, status)
+		}
+		return resp, nil
+	}
+}
+
+func (c *Client) readLine() (string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// literalSize devuelve n si la línea termina en {n}
+func literalSize(line string) (int, bool) {
+	if !strings.HasSuffix(line, "}") {
+		return 0, false
+	}
+	open := strings.LastIndexByte(line, '{')
+	if open < 0 {
+		return 0, false
+	}
+	size, err := strconv.Atoi(line[open+1 : len(line)-1])
+	if err != nil || size < 0 {
+		return 0, false
+	}
+	return size, true
+}
+
+// quote escribe s como quoted string de IMAP
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return `"` + strings.ReplaceAll(s, `"`, `\"`) + `"`
+}
@@ -0,0 +1,62 @@
+package imap
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serve responde a cada comando con las líneas de replies; "%s" se sustituye por la etiqueta
+func serve(conn net.Conn, replies map[string]string) {
+	defer conn.Close()
+	fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		tag, command, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		reply, ok := replies[command]
+		if !ok {
+			reply = "%s BAD unknown command\r\n"
+		}
+		fmt.Fprint(conn, strings.ReplaceAll(reply, "%s", tag))
+	}
+}
+
+func TestClient_FetchesUnseenMessages(t *testing.T) {
+	message := "Subject: Hola\r\n\r\nCuerpo {3}\r\n"
+	server, conn := net.Pipe()
+	go serve(server, map[string]string{
+		`LOGIN "bot@acme.com" "s3cr\"t"`:    "%s OK LOGIN completed\r\n",
+		`SELECT "INBOX"`:                    "* 2 EXISTS\r\n%s OK [READ-WRITE] SELECT completed\r\n",
+		"UID SEARCH UNSEEN":                 "* SEARCH 7 9\r\n%s OK SEARCH completed\r\n",
+		"UID FETCH 9 (BODY.PEEK[])":         fmt.Sprintf("* 2 FETCH (UID 9 BODY[] {%d}\r\n%s)\r\n%%s OK FETCH completed\r\n", len(message), message),
+		`UID STORE 9 +FLAGS.SILENT (\Seen)`: "%s OK STORE completed\r\n",
+		"LOGOUT":                            "* BYE\r\n%s OK LOGOUT completed\r\n",
+	})
+
+	client, err := NewClient(conn)
+	require.NoError(t, err)
+	require.NoError(t, client.Login("bot@acme.com", `s3cr"t`))
+	require.NoError(t, client.Select("INBOX"))
+
+	uids, err := client.SearchUnseen()
+	require.NoError(t, err)
+	assert.Equal(t, []uint32{7, 9}, uids)
+
+	raw, err := client.FetchMessage(9)
+	require.NoError(t, err)
+	assert.Equal(t, message, string(raw))
+	require.NoError(t, client.MarkSeen(9))
+
+	_, err = client.FetchMessage(7)
+	assert.ErrorContains(t, err, "BAD unknown command")
+	require.NoError(t, client.Logout())
+}
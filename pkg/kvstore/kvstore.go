@@ -0,0 +1,311 @@
+// Package kvstore es un almacén clave-valor embebido en un único fichero, para
+// desplegar el servicio sin Postgres ni Redis. Los datos viven en memoria y cada
+// escritura se añade a un log en disco que se reproduce al abrir el almacén.
+package kvstore
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ErrNotFound indica que la clave no existe en el bucket
+var ErrNotFound = errors.New("key not found")
+
+// ErrClosed indica que el almacén ya se cerró
+var ErrClosed = errors.New("store is closed")
+
+// compactThreshold es el mínimo de registros obsoletos en el log antes de compactar
+const compactThreshold = 1000
+
+// record es una línea del log: una escritura o un borrado
+type record struct {
+	Op     string          `json:"op"` // put o delete
+	Bucket string          `json:"bucket"`
+	Key    string          `json:"key"`
+	Value  json.RawMessage `json:"value,omitempty"`
+}
+
+// Store es un almacén clave-valor persistente organizado en buckets
+type Store struct {
+	mu      sync.RWMutex
+	path    string
+	file    *os.File
+	buckets map[string]map[string][]byte
+	// stale cuenta los registros del log que ya no reflejan un valor vivo
+	stale  int
+	closed bool
+}
+
+// Open abre o crea el almacén en path y carga su contenido
+func Open(path string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	s := &Store{
+		path:    path,
+		buckets: make(map[string]map[string][]byte),
+	}
+	if err := s.replay(); err != nil {
+		return nil, err
+	}
+
+	// Se compacta al abrir para empezar con un log sin registros obsoletos
+	if err := s.rewrite(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Get devuelve el valor de la clave o ErrNotFound
+func (s *Store) Get(bucket, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	value, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Put guarda el valor, que debe ser JSON válido
+func (s *Store) Put(bucket, key string, value []byte) error {
+	if !json.Valid(value) {
+		return fmt.Errorf("value for %s/%s is not valid JSON", bucket, key)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.append(record{Op: "put", Bucket: bucket, Key: key, Value: value}); err != nil {
+		return err
+	}
+	if _, exists := s.buckets[bucket][key]; exists {
+		s.stale++
+	}
+	s.set(bucket, key, append([]byte(nil), value...))
+	return s.maybeCompact()
+}
+
+// Delete borra la clave; borrar una clave inexistente no es un error
+func (s *Store) Delete(bucket, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.buckets[bucket][key]; !exists {
+		return nil
+	}
+	if err := s.append(record{Op: "delete", Bucket: bucket, Key: key}); err != nil {
+		return err
+	}
+	delete(s.buckets[bucket], key)
+	s.stale += 2
+	return s.maybeCompact()
+}
+
+// ForEach recorre las claves del bucket en orden; si fn devuelve error se detiene
+func (s *Store) ForEach(bucket string, fn func(key string, value []byte) error) error {
+	s.mu.RLock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	values := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		values[key] = s.buckets[bucket][key]
+	}
+	s.mu.RUnlock()
+
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err := fn(key, values[key]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Compact reescribe el log con solo los valores vivos
+func (s *Store) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrClosed
+	}
+	return s.rewrite()
+}
+
+// Close sincroniza y cierra el fichero del almacén
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("failed to sync store: %w", err)
+	}
+	return s.file.Close()
+}
+
+func (s *Store) set(bucket, key string, value []byte) {
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string][]byte)
+	}
+	s.buckets[bucket][key] = value
+}
+
+// replay carga el log existente. Una última línea incompleta (escritura
+// interrumpida por una caída) se descarta.
+func (s *Store) replay() error {
+	file, err := os.Open(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read store: %w", err)
+		}
+
+		var rec record
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("corrupt store record at line %d: %w", line, err)
+		}
+		switch rec.Op {
+		case "put":
+			s.set(rec.Bucket, rec.Key, []byte(rec.Value))
+		case "delete":
+			delete(s.buckets[rec.Bucket], rec.Key)
+		default:
+			return fmt.Errorf("unknown store operation %q at line %d", rec.Op, line)
+		}
+	}
+}
+
+func (s *Store) append(rec record) error {
+	if s.closed {
+		return ErrClosed
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode store record: %w", err)
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync store: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) maybeCompact() error {
+	if s.stale < compactThreshold || s.stale < s.liveCount() {
+		return nil
+	}
+	return s.rewrite()
+}
+
+func (s *Store) liveCount() int {
+	count := 0
+	for _, bucket := range s.buckets {
+		count += len(bucket)
+	}
+	return count
+}
+
+// rewrite escribe los valores vivos en un fichero temporal y lo renombra sobre
+// el log, de modo que una caída a mitad deja intacto el log anterior
+func (s *Store) rewrite() error {
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create compacted store: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	bucketNames := make([]string, 0, len(s.buckets))
+	for name := range s.buckets {
+		bucketNames = append(bucketNames, name)
+	}
+	sort.Strings(bucketNames)
+
+	for _, bucket := range bucketNames {
+		keys := make([]string, 0, len(s.buckets[bucket]))
+		for key := range s.buckets[bucket] {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			data, err := json.Marshal(record{Op: "put", Bucket: bucket, Key: key, Value: s.buckets[bucket][key]})
+			if err == nil {
+				data = append(data, '\n')
+				_, err = writer.Write(data)
+			}
+			if err != nil {
+				tmp.Close()
+				os.Remove(tmpPath)
+				return fmt.Errorf("failed to write compacted store: %w", err)
+			}
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write compacted store: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to sync compacted store: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to close compacted store: %w", err)
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+	renameErr := os.Rename(tmpPath, s.path)
+
+	// Aunque falle el renombrado se reabre el log para seguir escribiendo en él
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if renameErr != nil {
+		os.Remove(tmpPath)
+		if err == nil {
+			s.file = file
+		}
+		return fmt.Errorf("failed to replace store: %w", renameErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	s.file = file
+	s.stale = 0
+	return nil
+}
@@ -0,0 +1,51 @@
+package kvstore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "store.db")
+
+	store, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, store.Put("bots", "b1", []byte(`{"name":"one"}`)))
+	require.NoError(t, store.Put("bots", "b2", []byte(`{"name":"two"}`)))
+	require.NoError(t, store.Put("bots", "b1", []byte(`{"name":"uno"}`)))
+	require.NoError(t, store.Delete("bots", "b2"))
+	assert.Error(t, store.Put("bots", "bad", []byte(`not json`)))
+	require.NoError(t, store.Close())
+
+	// Una escritura interrumpida deja una línea incompleta al final
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"op":"put","bucket":"bots","key":"b3"`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	store, err = Open(path)
+	require.NoError(t, err)
+	defer store.Close()
+
+	value, err := store.Get("bots", "b1")
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"uno"}`, string(value))
+
+	_, err = store.Get("bots", "b2")
+	assert.True(t, errors.Is(err, ErrNotFound))
+	_, err = store.Get("bots", "b3")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	var keys []string
+	require.NoError(t, store.ForEach("bots", func(key string, _ []byte) error {
+		keys = append(keys, key)
+		return nil
+	}))
+	assert.Equal(t, []string{"b1"}, keys)
+}
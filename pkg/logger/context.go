@@ -0,0 +1,57 @@
+package logger
+
+import "context"
+
+// Campos de correlación que propagan el middleware HTTP y las tareas
+const (
+	RequestIDKey     = "request_id"
+	CorrelationIDKey = "correlation_id"
+	BotIDKey         = "bot_id"
+	SessionIDKey     = "session_id"
+)
+
+type fieldsKey struct{}
+
+// ContextWithFields devuelve un contexto con campos clave/valor que
+// Logger.WithContext añade a cada línea. Una clave repetida reemplaza su valor.
+func ContextWithFields(ctx context.Context, fields ...interface{}) context.Context {
+	current := FieldsFromContext(ctx)
+	merged := make([]interface{}, 0, len(current)+len(fields))
+	merged = append(merged, current...)
+
+	for i := 0; i+1 < len(fields); i += 2 {
+		replaced := false
+		for j := 0; j+1 < len(merged); j += 2 {
+			if merged[j] == fields[i] {
+				merged[j+1] = fields[i+1]
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			merged = append(merged, fields[i], fields[i+1])
+		}
+	}
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FieldsFromContext devuelve los campos guardados con ContextWithFields
+func FieldsFromContext(ctx context.Context) []interface{} {
+	if ctx == nil {
+		return nil
+	}
+	fields, _ := ctx.Value(fieldsKey{}).([]interface{})
+	return fields
+}
+
+// FieldValue devuelve el valor de un campo del contexto, o "" si no está
+func FieldValue(ctx context.Context, key string) string {
+	fields := FieldsFromContext(ctx)
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == key {
+			value, _ := fields[i+1].(string)
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,32 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestWithContext_AddsContextFields(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	log := &zapLogger{logger: zap.New(core)}
+
+	ctx := ContextWithFields(context.Background(), RequestIDKey, "req-1", BotIDKey, "bot-1")
+	ctx = ContextWithFields(ctx, BotIDKey, "bot-2", SessionIDKey, "session-1")
+	assert.Equal(t, "bot-2", FieldValue(ctx, BotIDKey))
+
+	log.WithContext(ctx).Info("Step executed", "step_id", "welcome")
+	log.WithContext(context.Background()).Info("No request")
+
+	entries := logs.AllUntimed()
+	assert.Equal(t, map[string]interface{}{
+		RequestIDKey: "req-1",
+		BotIDKey:     "bot-2",
+		SessionIDKey: "session-1",
+		"step_id":    "welcome",
+	}, entries[0].ContextMap())
+	assert.Empty(t, entries[1].ContextMap())
+}
@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"context"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -11,6 +13,9 @@ type Logger interface {
 	Warn(msg string, fields ...interface{})
 	Error(msg string, fields ...interface{})
 	Fatal(msg string, fields ...interface{})
+	// WithContext devuelve un logger que añade a cada línea los campos del
+	// contexto (request_id, bot_id, session_id...)
+	WithContext(ctx context.Context) Logger
 }
 
 type zapLogger struct {
@@ -61,6 +66,14 @@ func (l *zapLogger) Fatal(msg string, fields ...interface{}) {
 	l.logger.Fatal(msg, l.convertFields(fields...)...)
 }
 
+func (l *zapLogger) WithContext(ctx context.Context) Logger {
+	fields := FieldsFromContext(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return &zapLogger{logger: l.logger.With(l.convertFields(fields...)...)}
+}
+
 func (l *zapLogger) convertFields(fields ...interface{}) []zap.Field {
 	zapFields := make([]zap.Field, 0, len(fields)/2)
 	
@@ -0,0 +1,362 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/company/bot-service/pkg/vault"
+)
+
+// ErrSecretNotFound indica que el proveedor no tiene el secreto pedido
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider lee secretos por nombre. Los nombres usan "/" para agrupar
+// (openai/api_key, database/password) y cada proveedor los traduce a su formato.
+type SecretProvider interface {
+	Name() string
+	GetSecret(ctx context.Context, name string) (string, error)
+}
+
+// envProvider lee los secretos de variables de entorno: openai/api_key → OPENAI_API_KEY
+type envProvider struct{}
+
+// NewEnvProvider crea el proveedor basado en variables de entorno
+func NewEnvProvider() SecretProvider {
+	return envProvider{}
+}
+
+func (envProvider) Name() string { return "env" }
+
+func (envProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	value, ok := os.LookupEnv(EnvName(name))
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return value, nil
+}
+
+// EnvName devuelve la variable de entorno que corresponde a un secreto
+func EnvName(name string) string {
+	return strings.ToUpper(strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(name))
+}
+
+// vaultProvider lee los secretos de Vault bajo basePath: openai/api_key es la
+// clave api_key de <basePath>/openai. Admite motores KV v1 y v2.
+type vaultProvider struct {
+	client   vault.Client
+	basePath string
+}
+
+// NewVaultProvider crea el proveedor basado en Vault
+func NewVaultProvider(client vault.Client, basePath string) SecretProvider {
+	return &vaultProvider{client: client, basePath: strings.TrimSuffix(basePath, "/")}
+}
+
+func (p *vaultProvider) Name() string { return "vault" }
+
+func (p *vaultProvider) GetSecret(ctx context.Context, name string) (string, error) {
+	path, key := p.basePath, name
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		path, key = p.basePath+"/"+name[:i], name[i+1:]
+	}
+
+	data, err := p.client.GetSecret(path)
+	if err != nil {
+		return "", err
+	}
+	// KV v2 anida los valores en "data"
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		data = nested
+	}
+	value, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrSecretNotFound, name)
+	}
+	return fmt.Sprintf("%v", value), nil
+}
+
+// referencePattern reconoce las referencias ${secret:nombre} dentro de un texto
+var referencePattern = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_./-]+)\}`)
+
+// References devuelve, ordenados y sin repetir, los secretos referenciados en
+// los textos de value (recorre mapas y listas)
+func References(value interface{}) []string {
+	seen := make(map[string]bool)
+	collectReferences(value, seen)
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func collectReferences(value interface{}, seen map[string]bool) {
+	switch v := value.(type) {
+	case string:
+		for _, match := range referencePattern.FindAllStringSubmatch(v, -1) {
+			seen[match[1]] = true
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			collectReferences(item, seen)
+		}
+	case map[string]string:
+		for _, item := range v {
+			collectReferences(item, seen)
+		}
+	case []interface{}:
+		for _, item := range v {
+			collectReferences(item, seen)
+		}
+	}
+}
+
+// Manager resuelve las referencias a secretos con un proveedor, guarda los
+// valores leídos y los vuelve a leer periódicamente para detectar rotaciones
+type Manager struct {
+	provider SecretProvider
+	interval time.Duration
+	logger   logger.Logger
+
+	mu       sync.RWMutex
+	values   map[string]string
+	watchers map[int]*watcher
+	nextID   int
+	cancel   context.CancelFunc
+}
+
+type watcher struct {
+	names    map[string]bool
+	onChange func(changed []string)
+}
+
+// NewManager crea el gestor de secretos. Con interval 0 no se detectan rotaciones.
+func NewManager(provider SecretProvider, interval time.Duration, logger logger.Logger) *Manager {
+	return &Manager{
+		provider: provider,
+		interval: interval,
+		logger:   logger,
+		values:   make(map[string]string),
+		watchers: make(map[int]*watcher),
+	}
+}
+
+// Provider devuelve el nombre del proveedor en uso
+func (m *Manager) Provider() string {
+	return m.provider.Name()
+}
+
+// Get devuelve el valor de un secreto, leyéndolo del proveedor la primera vez
+func (m *Manager) Get(ctx context.Context, name string) (string, error) {
+	m.mu.RLock()
+	value, ok := m.values[name]
+	m.mu.RUnlock()
+	if ok {
+		return value, nil
+	}
+
+	value, err := m.provider.GetSecret(ctx, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %s: %w", name, err)
+	}
+	m.mu.Lock()
+	m.values[name] = value
+	m.mu.Unlock()
+	return value, nil
+}
+
+// ResolveString sustituye las referencias ${secret:nombre} de text
+func (m *Manager) ResolveString(ctx context.Context, text string) (string, error) {
+	var resolveErr error
+	resolved := referencePattern.ReplaceAllStringFunc(text, func(reference string) string {
+		if resolveErr != nil {
+			return reference
+		}
+		value, err := m.Get(ctx, referencePattern.FindStringSubmatch(reference)[1])
+		if err != nil {
+			resolveErr = err
+			return reference
+		}
+		return value
+	})
+	if resolveErr != nil {
+		return "", resolveErr
+	}
+	return resolved, nil
+}
+
+// Resolve devuelve una copia de config con las referencias sustituidas en
+// todos los textos, incluidos los de mapas y listas anidados
+func (m *Manager) Resolve(ctx context.Context, config map[string]interface{}) (map[string]interface{}, error) {
+	resolved, err := m.resolveValue(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := resolved.(map[string]interface{})
+	return result, nil
+}
+
+func (m *Manager) resolveValue(ctx context.Context, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		return m.ResolveString(ctx, v)
+	case map[string]interface{}:
+		if v == nil {
+			return v, nil
+		}
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved, err := m.resolveValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for key, item := range v {
+			resolved, err := m.ResolveString(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, err := m.resolveValue(ctx, item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return value, nil
+	}
+}
+
+// Watch llama a onChange cuando rota alguno de los secretos indicados.
+// Devuelve la función que deja de vigilarlos.
+func (m *Manager) Watch(names []string, onChange func(changed []string)) func() {
+	w := &watcher{names: make(map[string]bool, len(names)), onChange: onChange}
+	for _, name := range names {
+		w.names[name] = true
+	}
+
+	m.mu.Lock()
+	id := m.nextID
+	m.nextID++
+	m.watchers[id] = w
+	m.mu.Unlock()
+
+	return func() {
+		m.mu.Lock()
+		delete(m.watchers, id)
+		m.mu.Unlock()
+	}
+}
+
+// Refresh vuelve a leer los secretos ya resueltos, avisa a quienes vigilan los
+// que cambiaron y los devuelve. Si un secreto no se puede leer se conserva el
+// valor anterior.
+func (m *Manager) Refresh(ctx context.Context) []string {
+	m.mu.RLock()
+	names := make([]string, 0, len(m.values))
+	for name := range m.values {
+		names = append(names, name)
+	}
+	m.mu.RUnlock()
+	sort.Strings(names)
+
+	changed := make([]string, 0)
+	for _, name := range names {
+		value, err := m.provider.GetSecret(ctx, name)
+		if err != nil {
+			m.logger.Warn("Failed to refresh secret, keeping previous value", "secret", name, "error", err)
+			continue
+		}
+		m.mu.Lock()
+		if m.values[name] != value {
+			m.values[name] = value
+			changed = append(changed, name)
+		}
+		m.mu.Unlock()
+	}
+	if len(changed) == 0 {
+		return changed
+	}
+
+	m.logger.Info("Secrets rotated", "provider", m.provider.Name(), "secrets", changed)
+	m.mu.RLock()
+	notify := make([]*watcher, 0, len(m.watchers))
+	for _, w := range m.watchers {
+		notify = append(notify, w)
+	}
+	m.mu.RUnlock()
+	for _, w := range notify {
+		affected := make([]string, 0)
+		for _, name := range changed {
+			if w.names[name] {
+				affected = append(affected, name)
+			}
+		}
+		if len(affected) > 0 {
+			w.onChange(affected)
+		}
+	}
+	return changed
+}
+
+// Start relee los secretos cada interval hasta que se llame a Stop
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.interval <= 0 {
+		return nil
+	}
+	if m.cancel != nil {
+		return fmt.Errorf("secret manager already started")
+	}
+	ctx, m.cancel = context.WithCancel(ctx)
+	go m.run(ctx)
+
+	m.logger.Info("Secret rotation watcher started", "provider", m.provider.Name(), "interval", m.interval)
+	return nil
+}
+
+// Stop detiene la relectura periódica
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+func (m *Manager) run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.Refresh(ctx)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeVault struct {
+	data map[string]map[string]interface{}
+}
+
+func (f *fakeVault) GetSecret(path string) (map[string]interface{}, error) {
+	data, ok := f.data[path]
+	if !ok {
+		return nil, errors.New("secret not found at path: " + path)
+	}
+	return data, nil
+}
+
+func (f *fakeVault) GetSecretValue(path, key string) (string, error) {
+	return "", errors.New("not used")
+}
+
+func TestManager_ResolvesReferencesAndDetectsRotation(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("OPENAI_API_KEY", "sk-one")
+	t.Setenv("CRM_API_TOKEN", "crm-token")
+
+	manager := NewManager(NewEnvProvider(), time.Minute, logger.NewLogger("error"))
+	config := map[string]interface{}{
+		"model":          "gpt-4",
+		"openai_api_key": "${secret:openai/api_key}",
+		"headers":        map[string]interface{}{"Authorization": "Bearer ${secret:crm/api-token}"},
+		"timeout":        30,
+	}
+	assert.Equal(t, []string{"crm/api-token", "openai/api_key"}, References(config))
+
+	resolved, err := manager.Resolve(ctx, config)
+	require.NoError(t, err)
+	assert.Equal(t, "sk-one", resolved["openai_api_key"])
+	assert.Equal(t, "Bearer crm-token", resolved["headers"].(map[string]interface{})["Authorization"])
+	assert.Equal(t, 30, resolved["timeout"])
+	assert.Equal(t, "${secret:openai/api_key}", config["openai_api_key"], "the original config keeps the reference")
+
+	_, err = manager.ResolveString(ctx, "${secret:missing}")
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+
+	var rotated []string
+	unwatch := manager.Watch([]string{"openai/api_key"}, func(changed []string) { rotated = append(rotated, changed...) })
+	assert.Empty(t, manager.Refresh(ctx))
+
+	t.Setenv("OPENAI_API_KEY", "sk-two")
+	assert.Equal(t, []string{"openai/api_key"}, manager.Refresh(ctx))
+	assert.Equal(t, []string{"openai/api_key"}, rotated)
+	value, err := manager.ResolveString(ctx, "${secret:openai/api_key}")
+	require.NoError(t, err)
+	assert.Equal(t, "sk-two", value)
+
+	unwatch()
+	t.Setenv("OPENAI_API_KEY", "sk-three")
+	manager.Refresh(ctx)
+	assert.Len(t, rotated, 1)
+}
+
+func TestVaultProvider_ReadsKVv1AndKVv2(t *testing.T) {
+	ctx := context.Background()
+	provider := NewVaultProvider(&fakeVault{data: map[string]map[string]interface{}{
+		"secret/bot-service":          {"jwt": "v1-value"},
+		"secret/bot-service/database": {"data": map[string]interface{}{"password": "v2-value"}},
+	}}, "secret/bot-service/")
+
+	value, err := provider.GetSecret(ctx, "jwt")
+	require.NoError(t, err)
+	assert.Equal(t, "v1-value", value)
+	value, err = provider.GetSecret(ctx, "database/password")
+	require.NoError(t, err)
+	assert.Equal(t, "v2-value", value)
+
+	_, err = provider.GetSecret(ctx, "database/user")
+	assert.True(t, errors.Is(err, ErrSecretNotFound))
+	assert.Equal(t, "DATABASE_PASSWORD", EnvName("database/password"))
+}
@@ -0,0 +1,62 @@
+// Package sse lee las respuestas en streaming (server-sent events) de las APIs
+// de chat compatibles con OpenAI.
+package sse
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrStopped indica que el consumidor cortó la lectura antes del final del stream
+var ErrStopped = errors.New("chat stream stopped by consumer")
+
+// ReadChat lee un stream de chat completions hasta "[DONE]" o el final del
+// cuerpo. Llama a delta con cada fragmento de contenido, y deja de leer con
+// ErrStopped si delta devuelve false. Los eventos que no se pueden decodificar
+// se saltan; invalid, si no es nil, recibe su error. Devuelve la finish_reason
+// del último evento que la trae.
+func ReadChat(body io.Reader, delta func(content string) bool, invalid func(err error)) (string, error) {
+	finishReason := ""
+	scanner := bufio.NewScanner(body)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var event struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+				FinishReason *string `json:"finish_reason"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			if invalid != nil {
+				invalid(err)
+			}
+			continue
+		}
+		if len(event.Choices) == 0 {
+			continue
+		}
+
+		choice := event.Choices[0]
+		if choice.FinishReason != nil {
+			finishReason = *choice.FinishReason
+		}
+		if choice.Delta.Content != "" && !delta(choice.Delta.Content) {
+			return finishReason, ErrStopped
+		}
+	}
+	return finishReason, scanner.Err()
+}
@@ -0,0 +1,79 @@
+package sse
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const chatStream = `: keep-alive
+
+data: {"choices":[{"delta":{"role":"assistant"}}]}
+
+data: {"choices":[{"delta":{"content":"Hola"}}]}
+
+data: not json
+
+data: {"choices":[]}
+
+data:{"choices":[{"delta":{"content":", ¿qué tal?"},"finish_reason":null}]}
+
+data: {"choices":[{"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+data: {"choices":[{"delta":{"content":"after done"}}]}
+`
+
+func TestReadChat(t *testing.T) {
+	var deltas []string
+	var invalid []error
+	finishReason, err := ReadChat(strings.NewReader(chatStream), func(content string) bool {
+		deltas = append(deltas, content)
+		return true
+	}, func(err error) { invalid = append(invalid, err) })
+
+	require.NoError(t, err)
+	assert.Equal(t, "stop", finishReason)
+	assert.Equal(t, []string{"Hola", ", ¿qué tal?"}, deltas)
+	assert.Len(t, invalid, 1)
+}
+
+func TestReadChat_StopsWhenConsumerStops(t *testing.T) {
+	calls := 0
+	_, err := ReadChat(strings.NewReader(chatStream), func(string) bool {
+		calls++
+		return false
+	}, nil)
+	assert.ErrorIs(t, err, ErrStopped)
+	assert.Equal(t, 1, calls)
+}
+
+func TestReadChat_ReportsReadErrors(t *testing.T) {
+	body := errorReader{data: `data: {"choices":[{"delta":{"content":"partial"}}]}` + "\n"}
+	var deltas []string
+	finishReason, err := ReadChat(&body, func(content string) bool {
+		deltas = append(deltas, content)
+		return true
+	}, nil)
+	assert.EqualError(t, err, "connection reset")
+	assert.Empty(t, finishReason)
+	assert.Equal(t, []string{"partial"}, deltas)
+}
+
+// errorReader devuelve data y después un error de conexión
+type errorReader struct {
+	data string
+	read bool
+}
+
+func (r *errorReader) Read(p []byte) (int, error) {
+	if r.read {
+		return 0, errors.New("connection reset")
+	}
+	r.read = true
+	return copy(p, r.data), nil
+}
@@ -0,0 +1,204 @@
+// Package testkit ayuda a escribir pruebas de integración contra el motor de
+// bots: builders declarativos de bots, flujos y pasos y un servidor en proceso
+// con repositorios en memoria, reloj determinista e IA guionizada.
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/company/bot-service/internal/domain"
+)
+
+// BotBuilder arma un bot activo con sus flujos
+type BotBuilder struct {
+	bot    domain.Bot
+	config domain.BotConfig
+	flows  []*FlowBuilder
+}
+
+// Bot empieza un bot activo del canal web
+func Bot(id string) *BotBuilder {
+	return &BotBuilder{bot: domain.Bot{
+		ID:      id,
+		Name:    id,
+		OwnerID: "testkit",
+		Channel: domain.ChannelWeb,
+		Status:  domain.BotStatusActive,
+	}}
+}
+
+// Named cambia el nombre del bot
+func (b *BotBuilder) Named(name string) *BotBuilder {
+	b.bot.Name = name
+	return b
+}
+
+// Disabled deja el bot deshabilitado
+func (b *BotBuilder) Disabled() *BotBuilder {
+	b.bot.Status = domain.BotStatusDisabled
+	return b
+}
+
+// Configure modifica la configuración del bot (intents globales, FAQ, traducción...)
+func (b *BotBuilder) Configure(fn func(config *domain.BotConfig)) *BotBuilder {
+	fn(&b.config)
+	return b
+}
+
+// GlobalIntent añade un intent global que salta a flowID
+func (b *BotBuilder) GlobalIntent(name, flowID string, keywords ...string) *BotBuilder {
+	b.config.GlobalIntents = append(b.config.GlobalIntents, domain.GlobalIntent{Name: name, Keywords: keywords, FlowID: flowID})
+	return b
+}
+
+// Flows añade flujos al bot; si ninguno es el flujo por defecto, lo será el primero
+func (b *BotBuilder) Flows(flows ...*FlowBuilder) *BotBuilder {
+	b.flows = append(b.flows, flows...)
+	return b
+}
+
+// Build devuelve el bot, sus flujos y los pasos de todos los flujos
+func (b *BotBuilder) Build() (*domain.Bot, []*domain.BotFlow, []*domain.BotStep) {
+	bot := b.bot
+	config, err := json.Marshal(b.config)
+	if err != nil {
+		panic(fmt.Sprintf("testkit: invalid config for bot %s: %v", bot.ID, err))
+	}
+	bot.Config = config
+
+	hasDefault := false
+	for _, flow := range b.flows {
+		hasDefault = hasDefault || flow.flow.IsDefault
+	}
+
+	var flows []*domain.BotFlow
+	var steps []*domain.BotStep
+	for i, builder := range b.flows {
+		flow, flowSteps := builder.build(bot.ID)
+		if !hasDefault && i == 0 {
+			flow.IsDefault = true
+		}
+		flows = append(flows, flow)
+		steps = append(steps, flowSteps...)
+	}
+	return &bot, flows, steps
+}
+
+// FlowBuilder arma un flujo y sus pasos
+type FlowBuilder struct {
+	flow  domain.BotFlow
+	steps []*StepBuilder
+}
+
+// Flow empieza un flujo; el primer paso añadido es su punto de entrada
+func Flow(id string) *FlowBuilder {
+	return &FlowBuilder{flow: domain.BotFlow{ID: id, Name: id}}
+}
+
+// Default marca el flujo como el flujo por defecto del bot
+func (f *FlowBuilder) Default() *FlowBuilder {
+	f.flow.IsDefault = true
+	return f
+}
+
+// Trigger hace que el flujo empiece con los mensajes que coinciden con trigger
+func (f *FlowBuilder) Trigger(trigger string) *FlowBuilder {
+	f.flow.Trigger = trigger
+	return f
+}
+
+// Steps añade pasos al flujo. Un paso sin siguiente explícito continúa con el
+// paso añadido después de él, salvo los pasos end y handoff.
+func (f *FlowBuilder) Steps(steps ...*StepBuilder) *FlowBuilder {
+	f.steps = append(f.steps, steps...)
+	return f
+}
+
+func (f *FlowBuilder) build(botID string) (*domain.BotFlow, []*domain.BotStep) {
+	flow := f.flow
+	flow.BotID = botID
+	if len(f.steps) > 0 {
+		flow.EntryPoint = f.steps[0].step.ID
+	}
+
+	steps := make([]*domain.BotStep, 0, len(f.steps))
+	for i, builder := range f.steps {
+		step := builder.step
+		step.FlowID = flow.ID
+		switch {
+		case builder.next != "":
+			next := builder.next
+			step.NextStepID = &next
+		case i+1 < len(f.steps) && step.Type != domain.StepTypeEnd && step.Type != domain.StepTypeHandoff:
+			next := f.steps[i+1].step.ID
+			step.NextStepID = &next
+		}
+		steps = append(steps, &step)
+	}
+	return &flow, steps
+}
+
+// StepBuilder arma un paso. Los IDs de los pasos deben ser únicos entre todos los bots sembrados.
+type StepBuilder struct {
+	step domain.BotStep
+	next string
+}
+
+// Step crea un paso de cualquier tipo; content se serializa a JSON
+func Step(id string, stepType domain.StepType, content interface{}) *StepBuilder {
+	raw, err := json.Marshal(content)
+	if err != nil {
+		panic(fmt.Sprintf("testkit: invalid content for step %s: %v", id, err))
+	}
+	return &StepBuilder{step: domain.BotStep{ID: id, Name: id, Type: stepType, Content: raw}}
+}
+
+// Message es un paso que responde text (admite plantillas)
+func Message(id, text string) *StepBuilder {
+	return Step(id, domain.StepTypeMessage, map[string]interface{}{"text": text})
+}
+
+// Input es un paso que guarda la respuesta del usuario en variable
+func Input(id, variable string) *StepBuilder {
+	return Step(id, domain.StepTypeInput, map[string]interface{}{"variable": variable})
+}
+
+// AI es un paso que responde con la IA del servidor
+func AI(id string) *StepBuilder {
+	return Step(id, domain.StepTypeAI, map[string]interface{}{})
+}
+
+// Handoff es un paso que deriva la conversación a un agente humano
+func Handoff(id, text string) *StepBuilder {
+	return Step(id, domain.StepTypeHandoff, map[string]interface{}{"text": text})
+}
+
+// End es un paso que responde text y termina la conversación
+func End(id, text string) *StepBuilder {
+	return Step(id, domain.StepTypeEnd, map[string]interface{}{"text": text})
+}
+
+// Decision es un paso que elige el siguiente paso con la primera regla que se
+// cumple; sin coincidencias va a defaultStep
+func Decision(id, defaultStep string, rules ...Rule) *StepBuilder {
+	builder := Step(id, domain.StepTypeDecision, map[string]interface{}{})
+	conditions, err := json.Marshal(map[string]interface{}{"rules": rules, "default": defaultStep})
+	if err != nil {
+		panic(fmt.Sprintf("testkit: invalid rules for step %s: %v", id, err))
+	}
+	builder.step.Conditions = conditions
+	return builder
+}
+
+// Rule es una regla de un paso Decision
+type Rule struct {
+	Condition string `json:"condition"`
+	NextStep  string `json:"next_step"`
+}
+
+// Next fija el paso siguiente en lugar del añadido a continuación
+func (s *StepBuilder) Next(stepID string) *StepBuilder {
+	s.next = stepID
+	return s
+}
@@ -0,0 +1,86 @@
+package testkit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/company/bot-service/internal/ai"
+)
+
+// DefaultAIReply es la respuesta de ScriptedAI cuando no quedan respuestas guionizadas
+const DefaultAIReply = "scripted reply"
+
+// ScriptedAI es un cliente de IA que devuelve, en orden, las respuestas
+// encoladas con Reply y guarda los prompts que recibe
+type ScriptedAI struct {
+	mu       sync.Mutex
+	replies  []string
+	prompts  []string
+	fallback string
+}
+
+// NewScriptedAI crea una IA guionizada que responde DefaultAIReply sin guion
+func NewScriptedAI() *ScriptedAI {
+	return &ScriptedAI{fallback: DefaultAIReply}
+}
+
+// Reply encola respuestas para las próximas llamadas
+func (a *ScriptedAI) Reply(replies ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.replies = append(a.replies, replies...)
+}
+
+// Fallback cambia la respuesta para cuando el guion se agota
+func (a *ScriptedAI) Fallback(reply string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fallback = reply
+}
+
+// Prompts devuelve los prompts recibidos hasta ahora
+func (a *ScriptedAI) Prompts() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.prompts...)
+}
+
+func (a *ScriptedAI) next(prompt string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.prompts = append(a.prompts, prompt)
+	if len(a.replies) == 0 {
+		return a.fallback
+	}
+	reply := a.replies[0]
+	a.replies = a.replies[1:]
+	return reply
+}
+
+func (a *ScriptedAI) GenerateResponse(ctx context.Context, prompt string, options ...ai.Option) (*ai.Response, error) {
+	return &ai.Response{Content: a.next(prompt), Model: "scripted", FinishReason: "stop"}, nil
+}
+
+func (a *ScriptedAI) GenerateChatResponse(ctx context.Context, messages []ai.Message, options ...ai.Option) (*ai.Response, error) {
+	prompt := ""
+	if len(messages) > 0 {
+		prompt = messages[len(messages)-1].Content
+	}
+	return a.GenerateResponse(ctx, prompt, options...)
+}
+
+func (a *ScriptedAI) StreamChatResponse(ctx context.Context, messages []ai.Message, options ...ai.Option) (<-chan ai.StreamChunk, error) {
+	response, err := a.GenerateChatResponse(ctx, messages, options...)
+	if err != nil {
+		return nil, err
+	}
+	chunks := make(chan ai.StreamChunk, 2)
+	chunks <- ai.StreamChunk{Content: response.Content}
+	chunks <- ai.StreamChunk{Done: true, FinishReason: response.FinishReason}
+	close(chunks)
+	return chunks, nil
+}
+
+func (a *ScriptedAI) Close() error {
+	return nil
+}
@@ -0,0 +1,223 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/company/bot-service/internal/handlers"
+	"github.com/company/bot-service/internal/mcp"
+	"github.com/company/bot-service/internal/repositories"
+	"github.com/company/bot-service/internal/services"
+	"github.com/company/bot-service/pkg/clock"
+	"github.com/company/bot-service/pkg/events"
+	"github.com/company/bot-service/pkg/logger"
+	"github.com/gin-gonic/gin"
+)
+
+// Epoch es la hora inicial del reloj de los servidores de prueba
+var Epoch = time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+
+// Repos son los repositorios en memoria del servidor
+type Repos struct {
+	Bots           domain.BotRepository
+	Flows          domain.BotFlowRepository
+	Steps          domain.BotStepRepository
+	FlowVersions   domain.FlowVersionRepository
+	Sessions       domain.ConversationSessionRepository
+	Messages       domain.ConversationMessageRepository
+	SmartReplies   domain.SmartReplyRepository
+	IntentExamples domain.IntentExampleRepository
+}
+
+// Server es el motor de bots en proceso detrás de la API HTTP, con
+// repositorios en memoria, reloj determinista e IA guionizada
+type Server struct {
+	*httptest.Server
+
+	Repos  Repos
+	Bots   services.BotService
+	Events events.EventBus
+	AI     *ScriptedAI
+	Clock  *clock.Fake
+
+	t        testing.TB
+	botIDs   []string
+	sequence int
+}
+
+// NewServer arranca un servidor con los bots indicados y lo cierra al terminar la prueba
+func NewServer(t testing.TB, bots ...*BotBuilder) *Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	log := logger.NewLogger("error")
+
+	messages := repositories.NewMockConversationMessageRepository()
+	repos := Repos{
+		Bots:           repositories.NewMockBotRepository(),
+		Flows:          repositories.NewMockBotFlowRepository(),
+		Steps:          repositories.NewMockBotStepRepository(),
+		FlowVersions:   repositories.NewMockFlowVersionRepository(),
+		Sessions:       repositories.WithMessageHistory(repositories.NewMockConversationSessionRepository(), messages),
+		Messages:       messages,
+		SmartReplies:   repositories.NewMockSmartReplyRepository(),
+		IntentExamples: repositories.NewMockIntentExampleRepository(),
+	}
+	smartReplyRepo := repos.SmartReplies
+	bus := events.NewInMemoryEventBus(log)
+	scripted := NewScriptedAI()
+
+	// Sin agentes registrados el orquestador falla y la IA guionizada responde
+	orchestrator := mcp.NewOrchestrator(mcp.NewAgentFactory(log), log)
+	conversations := services.NewConversationService(repos.Sessions, nil, log)
+	smartReplies := services.NewSmartReplyService(smartReplyRepo, repos.IntentExamples, scripted, orchestrator, log)
+	faqs := services.NewFAQService(repositories.NewMockFAQRepository(), repositories.NewMockUnansweredQuestionRepository(), log)
+	botService := services.NewBotService(repos.Bots, repos.Flows, repos.Steps, repos.FlowVersions, repos.Sessions, smartReplyRepo,
+		conversations, smartReplies, nil, nil, faqs, orchestrator, bus, nil, log)
+
+	botHandler := handlers.NewBotHandler(
+		botService,
+		services.NewBotFlowService(repos.Flows, repos.Steps, repos.FlowVersions, log),
+		services.NewBotStepService(repos.Steps, log),
+		smartReplies,
+		conversations,
+		faqs,
+		services.NewBotBundleService(repos.Bots, repos.Flows, repos.Steps, smartReplyRepo,
+			repositories.NewMockConditionalRepository(), repositories.NewMockTriggerRepository(), log),
+		log,
+	)
+	router := gin.New()
+	handlers.SetupRoutes(router, services.NewHealthService(), botHandler, nil, nil, nil, nil, log)
+	handlers.SetupConversationRoutes(router.Group("/api/v1"),
+		handlers.NewConversationHandler(services.NewConversationHistoryService(messages, repos.Bots), log))
+
+	server := &Server{
+		Server: httptest.NewServer(router),
+		Repos:  repos,
+		Bots:   botService,
+		Events: bus,
+		AI:     scripted,
+		Clock:  clock.NewFake(Epoch),
+		t:      t,
+	}
+	t.Cleanup(server.Close)
+	server.Seed(bots...)
+	return server
+}
+
+// Seed guarda los bots, sus flujos y sus pasos en los repositorios
+func (s *Server) Seed(bots ...*BotBuilder) {
+	s.t.Helper()
+	ctx := context.Background()
+	for _, builder := range bots {
+		bot, flows, steps := builder.Build()
+		if err := s.Repos.Bots.Create(ctx, bot); err != nil {
+			s.t.Fatalf("testkit: failed to seed bot %s: %v", bot.ID, err)
+		}
+		s.botIDs = append(s.botIDs, bot.ID)
+		for _, flow := range flows {
+			if err := s.Repos.Flows.Create(ctx, flow); err != nil {
+				s.t.Fatalf("testkit: failed to seed flow %s: %v", flow.ID, err)
+			}
+		}
+		for _, step := range steps {
+			if err := s.Repos.Steps.Create(ctx, step); err != nil {
+				s.t.Fatalf("testkit: failed to seed step %s: %v", step.ID, err)
+			}
+		}
+	}
+}
+
+// Send envía un mensaje del usuario por POST /api/v1/incoming y devuelve la
+// respuesta del bot; cualquier error hace fallar la prueba
+func (s *Server) Send(botID, userID, text string) *domain.BotResponse {
+	s.t.Helper()
+	s.sequence++
+	message := domain.IncomingMessage{
+		ID:        fmt.Sprintf("msg-%d", s.sequence),
+		BotID:     botID,
+		UserID:    userID,
+		Content:   text,
+		Channel:   domain.ChannelWeb,
+		Timestamp: s.Clock.Now(),
+	}
+
+	var response struct {
+		Code    string             `json:"code"`
+		Message string             `json:"message"`
+		Data    domain.BotResponse `json:"data"`
+	}
+	status := s.Do(http.MethodPost, "/api/v1/incoming", message, &response)
+	if status != http.StatusOK {
+		s.t.Fatalf("testkit: POST /api/v1/incoming returned %d: %s %s", status, response.Code, response.Message)
+	}
+	return &response.Data
+}
+
+// Do hace una petición a la API con body en JSON y decodifica la respuesta en
+// out (si no es nil); devuelve el código HTTP
+func (s *Server) Do(method, path string, body, out interface{}) int {
+	s.t.Helper()
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			s.t.Fatalf("testkit: failed to encode request: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, s.URL+path, &payload)
+	if err != nil {
+		s.t.Fatalf("testkit: failed to build request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		s.t.Fatalf("testkit: %s %s failed: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			s.t.Fatalf("testkit: failed to decode %s %s response: %v", method, path, err)
+		}
+	}
+	return resp.StatusCode
+}
+
+// Session devuelve la sesión activa del usuario con el bot, o nil si no hay
+func (s *Server) Session(botID, userID string) *domain.ConversationSession {
+	session, err := s.Repos.Sessions.GetByUserAndBot(context.Background(), userID, botID)
+	if err != nil {
+		return nil
+	}
+	return session
+}
+
+// Advance adelanta el reloj y envejece en d las sesiones abiertas de los bots
+// sembrados, para que el motor, que usa la hora real, vea pasar el tiempo
+// (inactividad, caducidad)
+func (s *Server) Advance(d time.Duration) {
+	s.t.Helper()
+	s.Clock.Advance(d)
+
+	ctx := context.Background()
+	for _, botID := range s.botIDs {
+		sessions, err := s.Repos.Sessions.GetInactiveSince(ctx, botID, time.Now().Add(time.Hour))
+		if err != nil {
+			s.t.Fatalf("testkit: failed to list sessions: %v", err)
+		}
+		for _, session := range sessions {
+			session.CreatedAt = session.CreatedAt.Add(-d)
+			session.UpdatedAt = session.UpdatedAt.Add(-d)
+			session.ExpiresAt = session.ExpiresAt.Add(-d)
+			if err := s.Repos.Sessions.Update(ctx, session); err != nil {
+				s.t.Fatalf("testkit: failed to age session %s: %v", session.ID, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package testkit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/company/bot-service/internal/domain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_RunsSeededFlows(t *testing.T) {
+	server := NewServer(t, Bot("support").Flows(
+		Flow("welcome").Steps(
+			Message("welcome-ask", "¿Cuál es tu pedido?"),
+			Input("welcome-order", "order"),
+			AI("welcome-ai").Next("welcome-bye"),
+			Message("welcome-skipped", "nunca"),
+			End("welcome-bye", "Pedido {{order}} registrado"),
+		),
+	))
+	server.AI.Reply("Tu pedido está en camino")
+
+	assert.Equal(t, "¿Cuál es tu pedido?", server.Send("support", "user-1", "hola").Content)
+	server.Send("support", "user-1", "A-42")
+	assert.Equal(t, "Tu pedido está en camino", server.Send("support", "user-1", "¿dónde está?").Content)
+	require.Len(t, server.AI.Prompts(), 1)
+	assert.Contains(t, server.AI.Prompts()[0], "¿dónde está?")
+
+	assert.Equal(t, "Pedido A-42 registrado", server.Send("support", "user-1", "gracias").Content)
+	assert.Nil(t, server.Session("support", "user-1"))
+	server.Send("support", "user-2", "hola")
+
+	var history struct {
+		Data struct {
+			Total int `json:"total"`
+		} `json:"data"`
+	}
+	assert.Equal(t, http.StatusOK, server.Do(http.MethodGet, "/api/v1/bots/support/conversations", nil, &history))
+	assert.Equal(t, 2, history.Data.Total)
+}
+
+func TestServer_AdvanceAgesSessions(t *testing.T) {
+	server := NewServer(t, Bot("shop").Flows(Flow("main").Steps(Message("shop-hi", "Hola"), Input("shop-name", "name"))))
+	server.Send("shop", "user-1", "hola")
+	before := server.Session("shop", "user-1").UpdatedAt
+
+	server.Advance(2 * time.Hour)
+	assert.Equal(t, Epoch.Add(2*time.Hour), server.Clock.Now())
+	assert.WithinDuration(t, before.Add(-2*time.Hour), server.Session("shop", "user-1").UpdatedAt, time.Second)
+}
+
+func TestBotBuilder_LinksSteps(t *testing.T) {
+	bot, flows, steps := Bot("b").GlobalIntent("agent", "human", "agente").Flows(
+		Flow("main").Steps(Message("m1", "a"), Handoff("m2", "b"), End("m3", "c")),
+		Flow("human").Default().Steps(Decision("h1", "h2", Rule{Condition: "yes", NextStep: "h3"}), End("h2", "x"), End("h3", "y")),
+	).Build()
+
+	assert.Contains(t, string(bot.Config), `"agent"`)
+	require.Len(t, flows, 2)
+	assert.False(t, flows[0].IsDefault)
+	assert.True(t, flows[1].IsDefault)
+	assert.Equal(t, "m1", flows[0].EntryPoint)
+	require.Len(t, steps, 6)
+	assert.Equal(t, "m2", *steps[0].NextStepID)
+	assert.Nil(t, steps[1].NextStepID)
+	assert.Equal(t, domain.StepTypeDecision, steps[3].Type)
+	assert.JSONEq(t, `{"rules":[{"condition":"yes","next_step":"h3"}],"default":"h2"}`, string(steps[3].Conditions))
+}
@@ -0,0 +1,67 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+)
+
+// Tipos de componente de la cadena de ejecución
+const (
+	ComponentAdapter = "adapter"
+	ComponentFlow    = "flow"
+	ComponentStep    = "step"
+	ComponentAgent   = "agent"
+)
+
+// Component es un elemento que intervino al atender una petición
+type Component struct {
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+	Type string `json:"type,omitempty"`
+}
+
+// Chain acumula en orden los componentes que ejecutaron una petición
+type Chain struct {
+	mu         sync.Mutex
+	components []Component
+}
+
+type chainKey struct{}
+
+// WithChain activa el registro de la cadena de ejecución en ctx
+func WithChain(ctx context.Context) (context.Context, *Chain) {
+	chain := &Chain{}
+	return context.WithValue(ctx, chainKey{}, chain), chain
+}
+
+// ChainFromContext devuelve la cadena activa en ctx, o nil si no se pidió
+func ChainFromContext(ctx context.Context) *Chain {
+	chain, _ := ctx.Value(chainKey{}).(*Chain)
+	return chain
+}
+
+// Record añade un componente a la cadena de ctx. Sin cadena no hace nada y un
+// componente ya registrado no se repite.
+func Record(ctx context.Context, kind, id, componentType string) {
+	chain := ChainFromContext(ctx)
+	if chain == nil || id == "" {
+		return
+	}
+	component := Component{Kind: kind, ID: id, Type: componentType}
+
+	chain.mu.Lock()
+	defer chain.mu.Unlock()
+	for _, existing := range chain.components {
+		if existing == component {
+			return
+		}
+	}
+	chain.components = append(chain.components, component)
+}
+
+// Components devuelve una copia de los componentes registrados
+func (c *Chain) Components() []Component {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Component(nil), c.components...)
+}
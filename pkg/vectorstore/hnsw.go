@@ -0,0 +1,325 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// HNSWConfig ajusta el índice HNSW en memoria. Los valores a cero usan los de
+// por defecto.
+type HNSWConfig struct {
+	M              int // vecinos por nodo en las capas superiores (el doble en la capa 0); 16
+	EfConstruction int // candidatos explorados al insertar; 200
+	EfSearch       int // candidatos explorados al buscar; 64
+}
+
+// HNSW es un almacén en memoria con un grafo Hierarchical Navigable Small World
+// por namespace. Los vectores se normalizan al guardarlos, así que la similitud
+// coseno es el producto escalar.
+type HNSW struct {
+	mu     sync.RWMutex
+	config HNSWConfig
+	levelM float64
+	dims   int // se fija con el primer vector
+	graphs map[string]*hnswGraph
+	rng    *rand.Rand
+}
+
+type hnswNode struct {
+	id      string
+	vector  []float32
+	friends [][]int // vecinos por capa
+	deleted bool
+}
+
+type hnswGraph struct {
+	nodes    []*hnswNode
+	ids      map[string]int // id → nodo vigente
+	entry    int
+	maxLevel int
+	deleted  int
+}
+
+// minRebuildTombstones evita reconstruir grafos pequeños en cada borrado
+const minRebuildTombstones = 32
+
+// NewHNSW crea un almacén HNSW vacío
+func NewHNSW(config HNSWConfig) *HNSW {
+	if config.M <= 1 {
+		config.M = 16
+	}
+	if config.EfConstruction <= 0 {
+		config.EfConstruction = 200
+	}
+	if config.EfSearch <= 0 {
+		config.EfSearch = 64
+	}
+	return &HNSW{
+		config: config,
+		levelM: 1 / math.Log(float64(config.M)),
+		graphs: make(map[string]*hnswGraph),
+		rng:    rand.New(rand.NewSource(1)),
+	}
+}
+
+func (h *HNSW) Upsert(ctx context.Context, namespace, id string, vector []float32) error {
+	normalized := normalize(vector)
+	if normalized == nil {
+		return errors.New("cannot index a zero vector")
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.dims == 0 {
+		h.dims = len(normalized)
+	}
+	if len(normalized) != h.dims {
+		return ErrDimensionMismatch
+	}
+
+	g, ok := h.graphs[namespace]
+	if !ok {
+		g = newHNSWGraph()
+		h.graphs[namespace] = g
+	}
+	// El nodo anterior queda como lápida: sigue sirviendo para navegar el grafo
+	if index, exists := g.ids[id]; exists {
+		g.nodes[index].deleted = true
+		g.deleted++
+	}
+	h.insert(g, id, normalized)
+	h.compact(namespace, g)
+	return nil
+}
+
+func (h *HNSW) Delete(ctx context.Context, namespace, id string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	g, ok := h.graphs[namespace]
+	if !ok {
+		return nil
+	}
+	index, exists := g.ids[id]
+	if !exists {
+		return nil
+	}
+	g.nodes[index].deleted = true
+	g.deleted++
+	delete(g.ids, id)
+	h.compact(namespace, g)
+	return nil
+}
+
+func (h *HNSW) Search(ctx context.Context, namespace string, query []float32, k int) ([]Match, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	normalized := normalize(query)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	g, ok := h.graphs[namespace]
+	if !ok || normalized == nil {
+		return nil, nil
+	}
+	if len(normalized) != h.dims {
+		return nil, ErrDimensionMismatch
+	}
+
+	entry := g.entry
+	for layer := g.maxLevel; layer > 0; layer-- {
+		entry = g.greedy(normalized, entry, layer)
+	}
+	ef := min(max(h.config.EfSearch, k)+g.deleted, len(g.nodes))
+	candidates := g.searchLayer(normalized, []int{entry}, ef, 0)
+
+	matches := make([]Match, 0, k)
+	for _, c := range candidates {
+		if node := g.nodes[c.index]; !node.deleted {
+			matches = append(matches, Match{ID: node.id, Score: c.score})
+			if len(matches) == k {
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+func newHNSWGraph() *hnswGraph {
+	return &hnswGraph{ids: make(map[string]int), entry: -1}
+}
+
+func (h *HNSW) insert(g *hnswGraph, id string, vector []float32) {
+	level := int(math.Floor(-math.Log(1-h.rng.Float64()) * h.levelM))
+	index := len(g.nodes)
+	node := &hnswNode{id: id, vector: vector, friends: make([][]int, level+1)}
+	g.nodes = append(g.nodes, node)
+	g.ids[id] = index
+
+	if g.entry < 0 {
+		g.entry = index
+		g.maxLevel = level
+		return
+	}
+
+	entry := g.entry
+	for layer := g.maxLevel; layer > level; layer-- {
+		entry = g.greedy(vector, entry, layer)
+	}
+	entries := []int{entry}
+	for layer := min(level, g.maxLevel); layer >= 0; layer-- {
+		candidates := g.searchLayer(vector, entries, h.config.EfConstruction, layer)
+		for _, c := range candidates[:min(len(candidates), h.config.M)] {
+			node.friends[layer] = append(node.friends[layer], c.index)
+			g.link(c.index, index, layer, h.maxFriends(layer))
+		}
+		entries = entries[:0]
+		for _, c := range candidates {
+			entries = append(entries, c.index)
+		}
+	}
+
+	if level > g.maxLevel {
+		g.maxLevel = level
+		g.entry = index
+	}
+}
+
+func (h *HNSW) maxFriends(layer int) int {
+	if layer == 0 {
+		return 2 * h.config.M
+	}
+	return h.config.M
+}
+
+// compact reconstruye el grafo cuando más de la mitad de sus nodos son lápidas
+func (h *HNSW) compact(namespace string, g *hnswGraph) {
+	if len(g.ids) == 0 {
+		delete(h.graphs, namespace)
+		return
+	}
+	if g.deleted < minRebuildTombstones || g.deleted*2 < len(g.nodes) {
+		return
+	}
+	rebuilt := newHNSWGraph()
+	for _, node := range g.nodes {
+		if !node.deleted {
+			h.insert(rebuilt, node.id, node.vector)
+		}
+	}
+	h.graphs[namespace] = rebuilt
+}
+
+// link añade to a los vecinos de from y, si pasan del máximo, se queda con los
+// más parecidos
+func (g *hnswGraph) link(from, to, layer, maxFriends int) {
+	node := g.nodes[from]
+	node.friends[layer] = append(node.friends[layer], to)
+	if len(node.friends[layer]) <= maxFriends {
+		return
+	}
+	friends := node.friends[layer]
+	sort.Slice(friends, func(i, j int) bool {
+		return dot(node.vector, g.nodes[friends[i]].vector) > dot(node.vector, g.nodes[friends[j]].vector)
+	})
+	node.friends[layer] = friends[:maxFriends]
+}
+
+// greedy avanza por la capa hacia el vecino más parecido hasta no mejorar
+func (g *hnswGraph) greedy(query []float32, entry, layer int) int {
+	best, bestScore := entry, dot(query, g.nodes[entry].vector)
+	for improved := true; improved; {
+		improved = false
+		for _, friend := range g.nodes[best].friends[layer] {
+			if score := dot(query, g.nodes[friend].vector); score > bestScore {
+				best, bestScore, improved = friend, score, true
+			}
+		}
+	}
+	return best
+}
+
+// searchLayer explora la capa desde entries y devuelve los ef nodos más
+// parecidos, del más al menos parecido
+func (g *hnswGraph) searchLayer(query []float32, entries []int, ef, layer int) []candidate {
+	visited := make(map[int]bool, ef*4)
+	frontier := &candidateHeap{}
+	results := &candidateHeap{worstFirst: true}
+	for _, entry := range entries {
+		if visited[entry] {
+			continue
+		}
+		visited[entry] = true
+		c := candidate{index: entry, score: dot(query, g.nodes[entry].vector)}
+		heap.Push(frontier, c)
+		heap.Push(results, c)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for frontier.Len() > 0 {
+		current := heap.Pop(frontier).(candidate)
+		if results.Len() >= ef && current.score < results.items[0].score {
+			break
+		}
+		for _, friend := range g.nodes[current.index].friends[layer] {
+			if visited[friend] {
+				continue
+			}
+			visited[friend] = true
+			score := dot(query, g.nodes[friend].vector)
+			if results.Len() < ef || score > results.items[0].score {
+				c := candidate{index: friend, score: score}
+				heap.Push(frontier, c)
+				heap.Push(results, c)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	sorted := results.items
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].score > sorted[j].score })
+	return sorted
+}
+
+type candidate struct {
+	index int
+	score float64
+}
+
+// candidateHeap tiene en la raíz el candidato más parecido, o el menos parecido
+// con worstFirst
+type candidateHeap struct {
+	items      []candidate
+	worstFirst bool
+}
+
+func (h *candidateHeap) Len() int { return len(h.items) }
+
+func (h *candidateHeap) Less(i, j int) bool {
+	if h.worstFirst {
+		return h.items[i].score < h.items[j].score
+	}
+	return h.items[i].score > h.items[j].score
+}
+
+func (h *candidateHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *candidateHeap) Push(x any) { h.items = append(h.items, x.(candidate)) }
+
+func (h *candidateHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
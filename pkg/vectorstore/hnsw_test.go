@@ -0,0 +1,104 @@
+package vectorstore
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func randomVector(rng *rand.Rand, dims int) []float32 {
+	vector := make([]float32, dims)
+	for i := range vector {
+		vector[i] = float32(rng.NormFloat64())
+	}
+	return vector
+}
+
+func TestHNSW_RecallAgainstExactSearch(t *testing.T) {
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(42))
+	store := NewHNSW(HNSWConfig{})
+
+	vectors := make(map[string][]float32)
+	for i := 0; i < 1000; i++ {
+		id := fmt.Sprintf("v%d", i)
+		vectors[id] = randomVector(rng, 32)
+		require.NoError(t, store.Upsert(ctx, "user-1", id, vectors[id]))
+	}
+	require.NoError(t, store.Upsert(ctx, "user-2", "other", vectors["v0"]))
+
+	const k = 10
+	hits := 0
+	for q := 0; q < 50; q++ {
+		query := randomVector(rng, 32)
+		normalized := normalize(query)
+		exact := make([]Match, 0, len(vectors))
+		for id, vector := range vectors {
+			exact = append(exact, Match{ID: id, Score: dot(normalized, normalize(vector))})
+		}
+		sort.Slice(exact, func(i, j int) bool { return exact[i].Score > exact[j].Score })
+
+		matches, err := store.Search(ctx, "user-1", query, k)
+		require.NoError(t, err)
+		require.Len(t, matches, k)
+		assert.True(t, sort.SliceIsSorted(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score }))
+		found := make(map[string]bool)
+		for _, match := range matches {
+			found[match.ID] = true
+		}
+		for _, match := range exact[:k] {
+			if found[match.ID] {
+				hits++
+			}
+		}
+	}
+	assert.GreaterOrEqual(t, float64(hits)/float64(50*k), 0.95)
+
+	// Los namespaces no se mezclan
+	matches, err := store.Search(ctx, "user-2", vectors["v0"], k)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "other", matches[0].ID)
+	assert.InDelta(t, 1.0, matches[0].Score, 1e-5)
+
+	_, err = store.Search(ctx, "user-1", []float32{1, 2}, k)
+	assert.ErrorIs(t, err, ErrDimensionMismatch)
+}
+
+func TestHNSW_UpsertAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewHNSW(HNSWConfig{})
+
+	require.NoError(t, store.Upsert(ctx, "ns", "a", []float32{1, 0, 0}))
+	require.NoError(t, store.Upsert(ctx, "ns", "b", []float32{0, 1, 0}))
+	require.NoError(t, store.Upsert(ctx, "ns", "a", []float32{0, 0, 1}))
+
+	matches, err := store.Search(ctx, "ns", []float32{0, 0, 1}, 5)
+	require.NoError(t, err)
+	require.Len(t, matches, 2, "the replaced vector is not returned")
+	assert.Equal(t, "a", matches[0].ID)
+
+	require.NoError(t, store.Delete(ctx, "ns", "a"))
+	require.NoError(t, store.Delete(ctx, "ns", "missing"))
+	matches, err = store.Search(ctx, "ns", []float32{0, 0, 1}, 5)
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, "b", matches[0].ID)
+
+	// Tras muchos reemplazos el grafo se compacta y sigue encontrando todo
+	rng := rand.New(rand.NewSource(7))
+	for i := 0; i < 200; i++ {
+		require.NoError(t, store.Upsert(ctx, "ns", fmt.Sprintf("k%d", i%20), randomVector(rng, 3)))
+	}
+	matches, err = store.Search(ctx, "ns", []float32{1, 1, 1}, 100)
+	require.NoError(t, err)
+	assert.Len(t, matches, 21)
+
+	assert.Error(t, store.Upsert(ctx, "ns", "zero", []float32{0, 0, 0}))
+	assert.Equal(t, "[0.5,-1,2]", vectorLiteral([]float32{0.5, -1, 2}))
+}
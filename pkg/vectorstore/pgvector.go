@@ -0,0 +1,112 @@
+package vectorstore
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var tableNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// PGVector guarda los vectores en Postgres con la extensión pgvector. Recibe
+// la conexión ya abierta; el servicio la abre con pgx/stdlib.
+type PGVector struct {
+	db         *sql.DB
+	table      string
+	dimensions int
+}
+
+// NewPGVector crea el almacén sobre table; EnsureSchema crea la tabla y el índice
+func NewPGVector(db *sql.DB, table string, dimensions int) (*PGVector, error) {
+	if !tableNamePattern.MatchString(table) {
+		return nil, fmt.Errorf("invalid pgvector table name: %q", table)
+	}
+	if dimensions <= 0 {
+		return nil, fmt.Errorf("pgvector dimensions must be positive")
+	}
+	return &PGVector{db: db, table: table, dimensions: dimensions}, nil
+}
+
+// EnsureSchema crea la extensión, la tabla y el índice HNSW de coseno si no existen
+func (s *PGVector) EnsureSchema(ctx context.Context) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS vector`,
+		fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			namespace TEXT NOT NULL,
+			id TEXT NOT NULL,
+			embedding vector(%d) NOT NULL,
+			PRIMARY KEY (namespace, id)
+		)`, s.table, s.dimensions),
+		fmt.Sprintf(`CREATE INDEX IF NOT EXISTS %s_embedding_idx ON %s USING hnsw (embedding vector_cosine_ops)`, s.table, s.table),
+	}
+	for _, statement := range statements {
+		if _, err := s.db.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to prepare pgvector schema: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *PGVector) Upsert(ctx context.Context, namespace, id string, vector []float32) error {
+	if len(vector) != s.dimensions {
+		return ErrDimensionMismatch
+	}
+	query := fmt.Sprintf(`INSERT INTO %s (namespace, id, embedding) VALUES ($1, $2, $3::vector)
+		ON CONFLICT (namespace, id) DO UPDATE SET embedding = EXCLUDED.embedding`, s.table)
+	if _, err := s.db.ExecContext(ctx, query, namespace, id, vectorLiteral(vector)); err != nil {
+		return fmt.Errorf("failed to upsert vector: %w", err)
+	}
+	return nil
+}
+
+func (s *PGVector) Delete(ctx context.Context, namespace, id string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE namespace = $1 AND id = $2`, s.table)
+	if _, err := s.db.ExecContext(ctx, query, namespace, id); err != nil {
+		return fmt.Errorf("failed to delete vector: %w", err)
+	}
+	return nil
+}
+
+func (s *PGVector) Search(ctx context.Context, namespace string, query []float32, k int) ([]Match, error) {
+	if k <= 0 {
+		return nil, nil
+	}
+	if len(query) != s.dimensions {
+		return nil, ErrDimensionMismatch
+	}
+	// <=> es la distancia coseno de pgvector: 1 - similitud
+	statement := fmt.Sprintf(`SELECT id, 1 - (embedding <=> $2::vector) AS score FROM %s
+		WHERE namespace = $1 ORDER BY embedding <=> $2::vector LIMIT $3`, s.table)
+	rows, err := s.db.QueryContext(ctx, statement, namespace, vectorLiteral(query), k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search vectors: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []Match
+	for rows.Next() {
+		var match Match
+		if err := rows.Scan(&match.ID, &match.Score); err != nil {
+			return nil, fmt.Errorf("failed to read vector match: %w", err)
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// vectorLiteral da el formato de texto de pgvector: [0.1,0.2,0.3]
+func vectorLiteral(vector []float32) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, value := range vector {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(float64(value), 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
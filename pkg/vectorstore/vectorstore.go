@@ -0,0 +1,54 @@
+// Package vectorstore guarda embeddings y busca los más parecidos a una consulta
+// por similitud coseno. Los vectores se agrupan en namespaces (p. ej. usuario y
+// bot) y las búsquedas nunca cruzan de uno a otro.
+package vectorstore
+
+import (
+	"context"
+	"errors"
+	"math"
+)
+
+// ErrDimensionMismatch indica que el vector no tiene la dimensión del almacén
+var ErrDimensionMismatch = errors.New("vector dimension mismatch")
+
+// Store es un almacén de vectores
+type Store interface {
+	// Upsert guarda o reemplaza el vector id del namespace
+	Upsert(ctx context.Context, namespace, id string, vector []float32) error
+	// Delete elimina el vector; no falla si no existe
+	Delete(ctx context.Context, namespace, id string) error
+	// Search devuelve hasta k vectores del namespace, del más al menos parecido
+	Search(ctx context.Context, namespace string, query []float32, k int) ([]Match, error)
+}
+
+// Match es un resultado de búsqueda
+type Match struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"` // similitud coseno, de -1 a 1
+}
+
+// normalize devuelve una copia de vector con norma 1, o nil si es el vector nulo
+func normalize(vector []float32) []float32 {
+	norm := 0.0
+	for _, value := range vector {
+		norm += float64(value) * float64(value)
+	}
+	if norm == 0 {
+		return nil
+	}
+	norm = math.Sqrt(norm)
+	result := make([]float32, len(vector))
+	for i, value := range vector {
+		result[i] = float32(float64(value) / norm)
+	}
+	return result
+}
+
+func dot(a, b []float32) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += float64(a[i]) * float64(b[i])
+	}
+	return sum
+}
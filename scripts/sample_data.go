@@ -31,7 +31,7 @@ func main() {
 	}, logger)
 	
 	// Initialize services
-	conversationService := services.NewConversationService(sessionRepo, logger)
+	conversationService := services.NewConversationService(sessionRepo, nil, logger)
 	smartReplyService := services.NewSmartReplyService(smartReplyRepo, aiClient, logger)
 	botFlowService := services.NewBotFlowService(flowRepo, stepRepo, logger)
 	botStepService := services.NewBotStepService(stepRepo, logger)
@@ -54,7 +54,7 @@ func (suite *E2ETestSuite) SetupSuite() {
 	logger := logger.NewLogger("debug")
 	
 	// Pass nil for botHandler since we're only testing basic endpoints
-	handlers.SetupRoutes(suite.router, healthService, nil, logger)
+	handlers.SetupRoutes(suite.router, healthService, nil, nil, nil, nil, nil, logger)
 }
 
 func (suite *E2ETestSuite) TearDownSuite() {
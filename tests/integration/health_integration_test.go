@@ -38,7 +38,7 @@ func (suite *IntegrationTestSuite) SetupSuite() {
 	logger := logger.NewLogger("debug")
 	
 	// Pass nil for botHandler since we're only testing basic endpoints
-	handlers.SetupRoutes(suite.router, healthService, nil, logger)
+	handlers.SetupRoutes(suite.router, healthService, nil, nil, nil, nil, nil, logger)
 }
 
 func (suite *IntegrationTestSuite) TearDownSuite() {